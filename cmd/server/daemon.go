@@ -0,0 +1,188 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/joshdurbin/url-shortener/internal/daemon"
+)
+
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show whether a local server is running, with its version, address and uptime",
+	Args:  cobra.NoArgs,
+	RunE:  runServerStatus,
+}
+
+var reloadCmd = &cobra.Command{
+	Use:   "reload",
+	Short: "Make a local server reload its lifecycle policies file and TLS certificate",
+	Args:  cobra.NoArgs,
+	RunE:  runServerReload,
+}
+
+var stopCmd = &cobra.Command{
+	Use:   "stop",
+	Short: "Shut a local server down gracefully and wait for it to exit",
+	Args:  cobra.NoArgs,
+	RunE:  runServerStop,
+}
+
+// daemonTarget returns the control socket and pid file flags, at least one of which is required
+func daemonTarget(cmd *cobra.Command) (string, string, error) {
+	socket, _ := cmd.Flags().GetString("control-socket")
+	pidFile, _ := cmd.Flags().GetString("pid-file")
+	if socket == "" && pidFile == "" {
+		return "", "", fmt.Errorf("give --control-socket or --pid-file")
+	}
+	return socket, pidFile, nil
+}
+
+// runningPID returns the process ID in a pid file, failing when the server is not running
+func runningPID(pidFile string) (int, error) {
+	pid, err := daemon.ReadPIDFile(pidFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, fmt.Errorf("server is not running (no pid file %s)", pidFile)
+		}
+		return 0, err
+	}
+	if !daemon.Running(pid) {
+		return 0, fmt.Errorf("server is not running (stale pid file %s names process %d)", pidFile, pid)
+	}
+	return pid, nil
+}
+
+func runServerStatus(cmd *cobra.Command, args []string) error {
+	socket, pidFile, err := daemonTarget(cmd)
+	if err != nil {
+		return err
+	}
+	if socket == "" {
+		pid, err := runningPID(pidFile)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Server is running (pid %d)\n", pid)
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	status, err := daemon.NewControlClient(socket).Status(ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Server is running (pid %d)\n", status.PID)
+	fmt.Printf("Version: %s (commit %s)\n", status.Version, status.Commit)
+	address := status.Address
+	if status.TLS {
+		address += " (HTTPS)"
+	}
+	if status.SocketActivated {
+		address += " (socket activated)"
+	}
+	fmt.Printf("Address: %s\n", address)
+	if status.AdminAddress != "" {
+		fmt.Printf("Admin address: %s\n", status.AdminAddress)
+	}
+	fmt.Printf("Started: %s (up %s)\n", status.StartedAt.Local().Format("2006-01-02 15:04:05"), status.Uptime)
+	fmt.Printf("Health: %s\n", status.Health)
+	return nil
+}
+
+func runServerReload(cmd *cobra.Command, args []string) error {
+	socket, pidFile, err := daemonTarget(cmd)
+	if err != nil {
+		return err
+	}
+	if socket == "" {
+		pid, err := runningPID(pidFile)
+		if err != nil {
+			return err
+		}
+		if err := signalProcess(pid, syscall.SIGHUP); err != nil {
+			return err
+		}
+		fmt.Printf("Sent SIGHUP to server (pid %d); see its log for the result\n", pid)
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	result, err := daemon.NewControlClient(socket).Reload(ctx)
+	if err != nil {
+		return err
+	}
+	if len(result.Reloaded) == 0 {
+		fmt.Println("Nothing to reload: the server has no policies file or TLS certificate files")
+		return nil
+	}
+	fmt.Printf("Reloaded %s\n", strings.Join(result.Reloaded, " and "))
+	return nil
+}
+
+func runServerStop(cmd *cobra.Command, args []string) error {
+	socket, pidFile, err := daemonTarget(cmd)
+	if err != nil {
+		return err
+	}
+	timeout, _ := cmd.Flags().GetDuration("timeout")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var pid int
+	if socket != "" {
+		control := daemon.NewControlClient(socket)
+		status, err := control.Status(ctx)
+		if err != nil {
+			return err
+		}
+		pid = status.PID
+		if err := control.Stop(ctx); err != nil {
+			return err
+		}
+	} else {
+		if pid, err = runningPID(pidFile); err != nil {
+			return err
+		}
+		if err := signalProcess(pid, syscall.SIGTERM); err != nil {
+			return err
+		}
+	}
+
+	if timeout == 0 {
+		fmt.Printf("Asked server (pid %d) to stop\n", pid)
+		return nil
+	}
+	deadline := time.Now().Add(timeout)
+	for daemon.Running(pid) {
+		if time.Now().After(deadline) {
+			return fmt.Errorf("server (pid %d) is still shutting down after %v", pid, timeout)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	fmt.Printf("Server (pid %d) stopped\n", pid)
+	return nil
+}
+
+// signalProcess sends sig to the process with the given ID
+func signalProcess(pid int, sig os.Signal) error {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return fmt.Errorf("failed to find server process %d: %w", pid, err)
+	}
+	if err := process.Signal(sig); err != nil {
+		return fmt.Errorf("failed to signal server process %d: %w", pid, err)
+	}
+	return nil
+}
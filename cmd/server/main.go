@@ -1,23 +1,60 @@
 package main
 
 import (
+	"bufio"
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"net"
+	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/spf13/cobra"
 
+	"github.com/joshdurbin/url-shortener/internal/abuse"
+	"github.com/joshdurbin/url-shortener/internal/analytics"
+	"github.com/joshdurbin/url-shortener/internal/archive"
+	"github.com/joshdurbin/url-shortener/internal/auth"
+	"github.com/joshdurbin/url-shortener/internal/backup"
+	"github.com/joshdurbin/url-shortener/internal/bots"
 	"github.com/joshdurbin/url-shortener/internal/cache/memory"
+	"github.com/joshdurbin/url-shortener/internal/cache/response"
+	"github.com/joshdurbin/url-shortener/internal/cdn"
 	"github.com/joshdurbin/url-shortener/internal/config"
+	"github.com/joshdurbin/url-shortener/internal/daemon"
+	"github.com/joshdurbin/url-shortener/internal/destinations"
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	"github.com/joshdurbin/url-shortener/internal/drain"
+	"github.com/joshdurbin/url-shortener/internal/events"
+	"github.com/joshdurbin/url-shortener/internal/export"
+	"github.com/joshdurbin/url-shortener/internal/failover"
+	"github.com/joshdurbin/url-shortener/internal/geoip"
+	"github.com/joshdurbin/url-shortener/internal/hosts"
+	"github.com/joshdurbin/url-shortener/internal/mailer"
+	"github.com/joshdurbin/url-shortener/internal/peers"
+	"github.com/joshdurbin/url-shortener/internal/policy"
+	"github.com/joshdurbin/url-shortener/internal/preview"
+	"github.com/joshdurbin/url-shortener/internal/privacy"
+	"github.com/joshdurbin/url-shortener/internal/reachability"
 	"github.com/joshdurbin/url-shortener/internal/repository/sqlite"
+	"github.com/joshdurbin/url-shortener/internal/retention"
 	"github.com/joshdurbin/url-shortener/internal/service"
 	"github.com/joshdurbin/url-shortener/internal/shortener"
+	"github.com/joshdurbin/url-shortener/internal/simulate"
+	"github.com/joshdurbin/url-shortener/internal/storage"
+	"github.com/joshdurbin/url-shortener/internal/tracing"
+	"github.com/joshdurbin/url-shortener/internal/transfer"
 	"github.com/joshdurbin/url-shortener/internal/transport/client"
 	httpTransport "github.com/joshdurbin/url-shortener/internal/transport/http"
+	"github.com/joshdurbin/url-shortener/internal/version"
+	"github.com/joshdurbin/url-shortener/internal/webhook"
+	apiclient "github.com/joshdurbin/url-shortener/pkg/urlshortener/client"
 )
 
 var rootCmd = &cobra.Command{
@@ -32,9 +69,45 @@ var serverCmd = &cobra.Command{
 	RunE:  runServer,
 }
 
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export all URL entries, including usage stats, from the database",
+	RunE:  runExport,
+}
+
+var importCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Import URL entries from an export file into the database",
+	RunE:  runImport,
+}
+
+var migrateCodesCmd = &cobra.Command{
+	Use:   "migrate-codes",
+	Short: "Re-encode generated short codes for a new alphabet, length or obfuscation",
+	Long: `Re-encode generated short codes for a new generator configuration.
+
+Each code generated under the current configuration (--from-*) is mapped to the
+code its counter value produces under the new one (--to-*), and the link and its
+history move to the new code. Custom codes are kept. The mapping from old to new
+codes is written to --output, and --aliases keeps each old code working as a 301
+redirect to its new short URL. Stop the server before migrating and start it
+with the new configuration afterwards.`,
+	RunE: runMigrateCodes,
+}
+
+var simulateCmd = &cobra.Command{
+	Use:   "simulate",
+	Short: "Generate synthetic traffic against a server and report latencies",
+	RunE:  runSimulate,
+}
+
 var clientCmd = &cobra.Command{
 	Use:   "client",
 	Short: "Client commands for interacting with the server",
+	// Arguments are validated by now, so failures are about the server, not usage
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		cmd.SilenceUsage = true
+	},
 }
 
 var createCmd = &cobra.Command{
@@ -44,6 +117,14 @@ var createCmd = &cobra.Command{
 	RunE:  runCreateURL,
 }
 
+var validateCmd = &cobra.Command{
+	Use:   "validate [URL]",
+	Short: "Check whether a short URL would be created, without creating it",
+	Long:  "Check a URL and link options against the server's creation rules. Every problem is listed, and the command exits non-zero if there are any.",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runValidateURL,
+}
+
 var getCmd = &cobra.Command{
 	Use:   "get [SHORT_CODE]",
 	Short: "Get information about a short URL",
@@ -53,194 +134,2388 @@ var getCmd = &cobra.Command{
 
 var deleteCmd = &cobra.Command{
 	Use:   "delete [SHORT_CODE]",
-	Short: "Delete a short URL",
-	Args:  cobra.ExactArgs(1),
+	Short: "Delete a short URL, or every short code listed in --file",
+	Args:  cobra.MaximumNArgs(1),
 	RunE:  runDeleteURL,
 }
 
+var pruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Delete links unused for a while or created before a date, after confirmation",
+	Args:  cobra.NoArgs,
+	RunE:  runPrune,
+}
+
 var listCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List all short URLs",
 	RunE:  runListURLs,
 }
 
+var campaignsCmd = &cobra.Command{
+	Use:   "campaigns",
+	Short: "List campaigns with their link counts and clicks",
+	Args:  cobra.NoArgs,
+	RunE:  runListCampaigns,
+}
+
+var statsCmd = &cobra.Command{
+	Use:   "stats [SHORT_CODE]",
+	Short: "Show link statistics, or one link's usage, last week's clicks, referrers and countries",
+	Args:  cobra.MaximumNArgs(1),
+	RunE:  runStats,
+}
+
+var timeseriesCmd = &cobra.Command{
+	Use:   "timeseries SHORT_CODE",
+	Short: "Show a link's clicks per minute, hour or day",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runTimeSeries,
+}
+
+var topCmd = &cobra.Command{
+	Use:   "top",
+	Short: "Show the most clicked or trending links over a recent window",
+	Args:  cobra.NoArgs,
+	RunE:  runTop,
+}
+
+var versionCmd = &cobra.Command{
+	Use:   "version",
+	Short: "Show client and server build information",
+	RunE:  runVersion,
+}
+
+var shellCmd = &cobra.Command{
+	Use:   "shell",
+	Short: "Manage links interactively with completion and history",
+	Args:  cobra.NoArgs,
+	RunE:  runShell,
+}
+
+var shareTokenCmd = &cobra.Command{
+	Use:   "share-token [SHORT_CODE]",
+	Short: "Issue a read-only share token for a short URL",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runShareToken,
+}
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Inspect and manage the server's link cache",
+}
+
+var cacheStatsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show the cache's size, unsynced entries and hit ratio",
+	Args:  cobra.NoArgs,
+	RunE:  runCacheStats,
+}
+
+var cacheGetCmd = &cobra.Command{
+	Use:   "get SHORT_CODE",
+	Short: "Show a short code's cache entry, with its unsynced usage",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runCacheGet,
+}
+
+var cacheFlushCmd = &cobra.Command{
+	Use:   "flush",
+	Short: "Write the cache's unsynced usage to the database now",
+	Args:  cobra.NoArgs,
+	RunE:  runCacheFlush,
+}
+
+var cacheInvalidateCmd = &cobra.Command{
+	Use:   "invalidate SHORT_CODE",
+	Short: "Drop a short code's cache entry so its next redirect reloads it from the database",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runCacheInvalidate,
+}
+
+var syncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Count buffered clicks and write cached usage to the database now",
+	Args:  cobra.NoArgs,
+	RunE:  runSync,
+}
+
+var drainCmd = &cobra.Command{
+	Use:   "drain",
+	Short: "Pause API writes and sync cached usage, e.g. before snapshotting the database",
+	Args:  cobra.NoArgs,
+	RunE:  runDrain,
+}
+
 func init() {
+	rootCmd.Version = version.String()
+	
 	// Server command flags
 	serverCmd.Flags().StringP("port", "p", "8080", "Server port")
+	serverCmd.Flags().StringSlice("listen", nil, "Also serve on these listeners: unix:PATH, fd:N (an inherited socket) or tcp:ADDR (repeatable)")
+	serverCmd.Flags().StringSlice("admin-listen", nil, "Serve the API, admin dashboard, metrics and peer invalidations only on these listeners (same forms as --listen), leaving redirects and probes on the others")
 	serverCmd.Flags().String("server-url", "http://localhost:8080", "Server URL (for client communication)")
 	serverCmd.Flags().String("db-path", "urls.db", "Database file path")
+	serverCmd.Flags().String("db-driver", "", "SQLite driver: mattn (cgo) or modernc (pure Go); defaults to mattn when built with cgo")
+	dbTuning := sqlite.DefaultTuning()
+	serverCmd.Flags().Duration("db-busy-timeout", dbTuning.BusyTimeout, "How long a database write waits for another connection's lock before failing")
+	serverCmd.Flags().Int("db-cache-size", dbTuning.CacheSizeKiB, "SQLite page cache per connection in KiB")
+	serverCmd.Flags().String("db-synchronous", dbTuning.Synchronous, "SQLite synchronous mode: OFF, NORMAL, FULL or EXTRA")
+	serverCmd.Flags().Int("db-max-open-conns", dbTuning.MaxOpenConns, "Maximum open database connections (0 = unlimited)")
+	serverCmd.Flags().Int("db-max-idle-conns", dbTuning.MaxIdleConns, "Database connections kept open while idle")
+	dbBreaker := service.DefaultBreakerConfig()
+	serverCmd.Flags().Float64("db-breaker-failure-rate", dbBreaker.FailureRate, "Share of failed database calls that opens the circuit breaker, failing calls fast instead of waiting (0 = no breaker)")
+	serverCmd.Flags().Int("db-breaker-min-calls", dbBreaker.MinCalls, "Database calls in a window before its failure rate can open the circuit breaker")
+	serverCmd.Flags().Duration("db-breaker-window", dbBreaker.Window, "How long database calls are counted before the counts start over")
+	serverCmd.Flags().Duration("db-breaker-cooldown", dbBreaker.Cooldown, "How long the circuit breaker stays open before probing the database")
+	serverCmd.Flags().Int("db-breaker-probes", dbBreaker.Probes, "Probe calls that must succeed in a row to close the circuit breaker")
 	serverCmd.Flags().Duration("sync-interval", 5*time.Second, "Cache sync interval")
+	serverCmd.Flags().Duration("sync-max-backoff", service.DefaultSyncConfig().MaxBackoff, "Longest wait between usage syncs after failures, which double the wait each time (0 = retry every interval)")
+	serverCmd.Flags().Int("sync-max-attempts", 0, "Failed usage syncs in a row before pending usage is dead-lettered (0 = retry forever)")
+	serverCmd.Flags().String("sync-dead-letter", "", "File dead-lettered usage is appended to as JSON lines (empty = log it)")
+	serverCmd.Flags().String("sync-wal", "", "File usage is kept in when the final sync at shutdown fails, replayed at the next start (empty = lose it)")
+	serverCmd.Flags().String("usage-merge", string(domain.UsageMergeDelta), "How cache syncs merge usage counts from other instances: delta or max")
+	serverCmd.Flags().Duration("cache-entry-ttl", 0, "How long a link stays cached before it is reloaded from the database (0 = forever)")
+	serverCmd.Flags().Bool("cache-refresh-on-access", true, "Extend a cached link's TTL on every redirect")
+	serverCmd.Flags().Duration("serve-stale", 0, "While the database is failing, keep redirecting from cache entries up to this long past their TTL and reject writes with 503 (0 = disabled)")
+	serverCmd.Flags().Int("cache-shards", memory.DefaultShards, "Independently locked parts of the memory cache, rounded up to a power of two; more let concurrent redirects of different links contend less")
+	serverCmd.Flags().Duration("response-cache-ttl", 0, "Cache link info, link list and storage responses this long to absorb polling (0 = disabled)")
+	serverCmd.Flags().Duration("click-dedupe-window", 0, "Count one click per visitor address and link in this window, for links without their own (0 = count every click)")
+	serverCmd.Flags().Int("click-buffer", service.DefaultClickBufferConfig().Size, "Clicks of uncapped links queued to be counted after their redirects return (0 = count during the redirect)")
+	serverCmd.Flags().Int("click-buffer-batch", service.DefaultClickBufferConfig().BatchSize, "Most buffered clicks counted at once")
+	serverCmd.Flags().String("click-buffer-overflow", service.DefaultClickBufferConfig().Overflow, "What happens to a click when the buffer is full: inline (count it during the redirect) or drop")
+	serverCmd.Flags().Bool("async-clicks", false, "Never count clicks during redirects: capped links are buffered too and may overshoot their cap, and a full buffer drops clicks (needs --click-buffer)")
+	serverCmd.Flags().Bool("event-outbox", false, "Store url.created events in the link's database transaction and publish them from an outbox, so a crash cannot lose them (subscribers may see an event twice)")
+	serverCmd.Flags().Duration("event-outbox-interval", service.DefaultOutboxConfig().Interval, "How often the event outbox is checked for events left by failures or a crash")
+	serverCmd.Flags().Int("event-outbox-batch", service.DefaultOutboxConfig().BatchSize, "Most outbox events published at once")
+	serverCmd.Flags().Duration("shutdown-timeout", 30*time.Second, "How long shutdown waits for in-flight HTTP requests")
+	serverCmd.Flags().Duration("shutdown-stage-timeout", 10*time.Second, "Timeout for each later shutdown stage: final cache sync, webhook drain, closing storage")
+	timeouts := httpTransport.DefaultTimeoutConfig()
+	serverCmd.Flags().Duration("redirect-timeout", timeouts.Redirect, "Cancel short link redirects running longer than this and answer 503 (0 = no limit)")
+	serverCmd.Flags().Duration("api-timeout", timeouts.API, "Cancel API, dashboard, probe and metrics requests running longer than this and answer 503 (0 = no limit)")
+	serverCmd.Flags().Duration("bulk-timeout", timeouts.Bulk, "Cancel link listing, export, backup, bulk delete and prune requests running longer than this and answer 503 (0 = no limit)")
+	
+	// TLS flags
+	serverCmd.Flags().String("tls-cert", "", "PEM certificate file; serves HTTPS and HTTP/2 when set with --tls-key")
+	serverCmd.Flags().String("tls-key", "", "PEM private key file for --tls-cert")
+	serverCmd.Flags().StringSlice("acme-domain", nil, "Obtain certificates for these domains from Let's Encrypt (instead of --tls-cert)")
+	serverCmd.Flags().String("acme-cache-dir", "acme-certs", "Directory where ACME certificates are cached")
+	serverCmd.Flags().String("acme-email", "", "Contact email for the ACME account")
+	serverCmd.Flags().String("http-redirect-port", "", "Also listen for plain HTTP on this port and redirect to HTTPS (use 80 for ACME HTTP-01 challenges)")
+	
+	// Redirect flags
+	serverCmd.Flags().Int("redirect-status", http.StatusFound, "Redirect status for links without their own: 301, 302, 307 or 308")
+	serverCmd.Flags().Duration("permanent-redirect-max-age", 0, "Cache-Control max-age sent with 301/308 redirects (0 = no header, clients may cache indefinitely)")
+	serverCmd.Flags().Duration("temporary-redirect-max-age", 0, "Cache-Control max-age sent with 302/307 redirects (0 = no-store, so every visit is counted)")
+	serverCmd.Flags().String("client-ip-header", "", "The one header --trusted-proxies put the visitor's address in (e.g. CF-Connecting-IP), instead of their forwarding headers")
+	serverCmd.Flags().StringSlice("trusted-proxies", nil, "Addresses or CIDRs of reverse proxies (or \"unix\" for unix socket listeners) whose Forwarded, X-Forwarded-For or X-Real-IP header gives the client address")
+	serverCmd.Flags().String("pid-file", "", "Write the server's process ID to this file while it runs")
+	serverCmd.Flags().String("control-socket", "", "Unix socket answering \"server status\", \"server reload\" and \"server stop\" (only the server's user can open it)")
+	serverCmd.Flags().String("error-pages-dir", "", "Directory of HTML templates (not_found.html, expired.html, blocked.html, landing.html, stats.html) replacing the built-in pages browsers see")
+	serverCmd.Flags().String("landing", "", "What / answers: \"page\" serves landing.html, an http(s) URL redirects there (empty = not found page)")
+	serverCmd.Flags().Bool("stats-pages", false, "Show a page with a link's destination, preview and clicks at /{code}+ instead of redirecting")
+	serverCmd.Flags().Bool("allow-crawling", false, "Let robots.txt allow search engines to follow short links (default disallows every path)")
+	serverCmd.Flags().String("favicon-file", "", "Icon served at /favicon.ico (empty answers 204 No Content)")
+	serverCmd.Flags().String("fallback-url", "", "Redirect unknown codes and links past their usage limit here instead of answering 404/410 (empty = error page)")
+	serverCmd.Flags().StringToString("fallback-campaign", nil, "Fallback URLs for the expired links of a campaign, campaign=URL (repeatable)")
+	serverCmd.Flags().String("fallback-param", httpTransport.DefaultFallbackParam, "Query parameter carrying the attempted code to the fallback URL")
 	
 	// Shortener configuration flags
 	serverCmd.Flags().Int64("shortener-counter-step", 100, "Counter step size for counter-based generator")
+	serverCmd.Flags().Bool("shortener-counter-preallocate", false, "Lease every counter range at start and the next one in the background once 80% is used, so creates never wait on the counters table (pair with a large --shortener-counter-step)")
+	serverCmd.Flags().Int("shortener-counter-shards", 1, fmt.Sprintf("Independent counters codes are spread over so concurrent creates don't wait on one another (1-%d)", shortener.MaxCounterShards))
+	serverCmd.Flags().String("shortener-shard-pick", shortener.ShardPickRoundRobin, "How a counter shard is chosen for each code: round_robin or random")
+	serverCmd.Flags().String("shortener-obfuscation", shortener.ObfuscationMultiplicative, "Counter obfuscation strategy: multiplicative, feistel, hashids, or ff1")
+	serverCmd.Flags().String("shortener-secret", "", "Key for feistel and ff1 obfuscation (required for ff1) or salt for hashids")
+	serverCmd.Flags().Uint64("shortener-multiplier", 0, "Odd multiplier for multiplicative obfuscation, e.g. 0x2545F4914F6CDD1D (0 = built-in)")
+	serverCmd.Flags().Uint64("shortener-salt", 0, "XOR salt for multiplicative obfuscation (0 = built-in)")
+	serverCmd.Flags().String("shortener-alphabet", "base62", "Characters short codes are made of: base62, base58 (no 0/O or I/l), or the characters themselves")
+	serverCmd.Flags().Int("shortener-code-length", 7, "Characters per generated short code (hashids codes may be longer)")
+	serverCmd.Flags().Bool("shortener-auto-length", false, "Start with short codes and add a character each time a length is used up, up to --shortener-code-length")
+	serverCmd.Flags().Int("shortener-min-length", shortener.DefaultMinLength, "Shortest codes issued with --shortener-auto-length")
+	serverCmd.Flags().Float64("shortener-grow-at", shortener.DefaultGrowAt, "Fraction of a length's codes used before --shortener-auto-length grows codes by one character")
+	serverCmd.Flags().StringSlice("reserved-codes", shortener.DefaultReservedCodes, "Short codes that are never issued")
+	serverCmd.Flags().String("blocked-words-file", "", "File of words (one per line) no short code may contain, added to the built-in profanity list")
+	serverCmd.Flags().StringSlice("allowed-destinations", nil, "Domains, IPs or CIDRs short URLs must point at one of, e.g. example.com,*.corp.example.com (empty = any)")
+	serverCmd.Flags().StringSlice("denied-destinations", nil, "Domains, IPs or CIDRs short URLs may not point at; private denies internal hostnames and private addresses")
+	serverCmd.Flags().Duration("destination-rules-refresh", destinations.DefaultConfig().Refresh, "How often destination rules added through the admin API on other instances are picked up (0 = only at start)")
 	
 	// Logging configuration flags
 	serverCmd.Flags().BoolP("verbose", "v", false, "Enable verbose logging (HTTP requests/responses and error details)")
+	requestLog := httpTransport.DefaultRequestLogConfig()
+	serverCmd.Flags().Int("log-sample-rate", requestLog.SampleRate, "With --verbose, log one in this many requests (5xx responses are always logged)")
+	serverCmd.Flags().Int("log-body-bytes", requestLog.MaxBodyBytes, "With --verbose, truncate logged request and error response bodies to this many bytes (0 = no bodies)")
+	serverCmd.Flags().StringSlice("log-redact-fields", requestLog.RedactFields, "With --verbose, JSON and form fields whose names contain one of these are logged as [REDACTED]")
+	
+	// Authentication flags
+	serverCmd.Flags().StringSlice("api-keys", nil, "Admin API keys granting full API access, including links owned by other keys (auth is disabled when no keys are set)")
+	serverCmd.Flags().StringSlice("user-api-keys", nil, "API keys that may update and delete only the links they created")
+	serverCmd.Flags().String("share-token-secret", "", "HMAC secret for share tokens (random per process when empty)")
+	serverCmd.Flags().Duration("share-token-max-ttl", 7*24*time.Hour, "Maximum lifetime of a share token")
+	serverCmd.Flags().String("oidc-issuer", "", "OpenID Connect issuer URL whose ID tokens are accepted (disabled when empty)")
+	serverCmd.Flags().String("oidc-client-id", "", "OIDC client ID; tokens must name it as their audience")
+	serverCmd.Flags().String("oidc-groups-claim", "groups", "ID token claim listing the user's groups")
+	serverCmd.Flags().StringSlice("oidc-admin-groups", nil, "Groups granted full API access")
+	serverCmd.Flags().StringSlice("oidc-viewer-groups", nil, "Groups granted read-only API access")
+	serverCmd.Flags().StringSlice("oidc-scopes", []string{"openid", "profile", "email"}, "Scopes the dashboard requests when signing in")
+	serverCmd.Flags().Duration("oidc-jwks-cache-ttl", time.Hour, "How long the provider's signing keys are cached")
+	
+	// Webhook flags
+	webhookDefaults := webhook.DefaultConfig()
+	serverCmd.Flags().String("webhooks-config", "", "JSON file of webhook endpoints to deliver to alongside those managed via /api/webhooks")
+	serverCmd.Flags().Duration("webhook-timeout", webhookDefaults.Timeout, "Timeout for a single webhook delivery attempt")
+	serverCmd.Flags().Int("webhook-max-attempts", webhookDefaults.MaxAttempts, "Delivery attempts per webhook event before giving up")
+	serverCmd.Flags().Float64("webhook-click-sample-rate", webhookDefaults.ClickSampleRate, "Fraction of url.clicked events delivered to webhooks (0-1)")
+	serverCmd.Flags().Float64("webhook-click-rate-limit", webhookDefaults.ClickRateLimit, "Delivered url.clicked events per second before sampling tightens automatically (0 disables)")
+	serverCmd.Flags().Duration("webhook-retention", webhookDefaults.Retention, "How long webhook delivery log entries are kept (0 = forever)")
+	
+	// Lifecycle policy flags
+	policyDefaults := policy.DefaultConfig()
+	serverCmd.Flags().String("policies-config", "", "YAML file of lifecycle policies applied alongside those managed via /api/policies")
+	serverCmd.Flags().Duration("policy-interval", policyDefaults.Interval, "How often lifecycle policies are applied (0 = only via POST /api/policies/run)")
+	serverCmd.Flags().Bool("policy-dry-run", false, "Log what scheduled policy runs would do instead of acting")
+	
+	// Failover health check flags
+	failoverDefaults := failover.DefaultConfig()
+	serverCmd.Flags().Duration("failover-interval", failoverDefaults.Interval, "How often primary destinations of links with a backup URL are health checked (0 disables)")
+	serverCmd.Flags().Duration("failover-timeout", failoverDefaults.Timeout, "Timeout for a single destination health check")
+	serverCmd.Flags().Int("failover-failure-threshold", failoverDefaults.FailureThreshold, "Consecutive failed health checks before redirects switch to the backup URL")
+	serverCmd.Flags().Int("failover-recovery-threshold", failoverDefaults.RecoveryThreshold, "Consecutive successful health checks before redirects return to the primary URL")
+	
+	// Link preview flags
+	previewDefaults := preview.DefaultConfig()
+	serverCmd.Flags().Int("preview-workers", previewDefaults.Workers, "Concurrent fetches of new links' page title, description and favicon (0 disables link previews)")
+	serverCmd.Flags().Duration("preview-timeout", previewDefaults.Timeout, "Timeout for fetching one link preview, robots.txt included")
+	serverCmd.Flags().Int64("preview-max-bytes", previewDefaults.MaxBytes, "Most of a destination page read while looking for its preview metadata")
+	
+	// Destination verification flags
+	verifyDefaults := reachability.DefaultConfig()
+	serverCmd.Flags().String("verify-destinations", string(verifyDefaults.Mode), "Check that new links' destinations answer: flag lists unreachable links, reject refuses them (empty = off)")
+	serverCmd.Flags().Duration("verify-timeout", verifyDefaults.Timeout, "Timeout for checking one destination, redirects included")
+	serverCmd.Flags().Int("verify-max-redirects", verifyDefaults.MaxRedirects, "Redirects followed before a destination counts as unreachable")
+	serverCmd.Flags().Duration("verify-interval", verifyDefaults.Interval, "How often every link's destination is checked again with --verify-destinations (0 = never)")
+	
+	// Broken link email flags
+	serverCmd.Flags().String("smtp-addr", "", "SMTP server as host:port that broken link and abuse alerts are emailed through (empty disables email)")
+	serverCmd.Flags().String("smtp-from", "", "Sender address of broken link and abuse alerts")
+	serverCmd.Flags().StringSlice("smtp-to", nil, "Recipients of broken link and abuse alerts (comma-separated)")
+	serverCmd.Flags().String("smtp-username", "", "SMTP username (empty sends without authentication)")
+	serverCmd.Flags().String("smtp-password", "", "SMTP password (default $SMTP_PASSWORD)")
+	serverCmd.Flags().String("cdn-purge-url", "", "CDN purge API endpoint changed links are purged through (empty disables purging)")
+	serverCmd.Flags().String("cdn-provider", cdn.ProviderCloudflare, "CDN purge API: cloudflare or fastly")
+	serverCmd.Flags().String("cdn-purge-token", "", "API token sent with CDN purge requests (default $CDN_PURGE_TOKEN)")
+	serverCmd.Flags().Duration("cdn-purge-timeout", 10*time.Second, "Timeout for a single CDN purge attempt")
+	serverCmd.Flags().Int("cdn-purge-attempts", cdn.DefaultConfig().MaxAttempts, "Attempts per CDN purge before giving up")
+	
+	// Custom domain flags
+	hostsDefaults := hosts.DefaultConfig()
+	serverCmd.Flags().Bool("custom-domains", hostsDefaults.Enabled, "Serve links on custom domains added through /api/admin/domains, keyed by the Host header")
+	serverCmd.Flags().Duration("custom-domains-refresh", hostsDefaults.Refresh, "How often custom domains added by other instances are picked up (0 = only at start)")
+	
+	// Backup flags
+	backupDefaults := backup.DefaultConfig()
+	serverCmd.Flags().String("backup-dir", "", "Directory database snapshots are written to (empty disables backups and /api/admin/backup)")
+	serverCmd.Flags().Duration("backup-interval", backupDefaults.Interval, "How often a database snapshot is taken (0 = only via POST /api/admin/backup)")
+	serverCmd.Flags().Int("backup-keep", backupDefaults.Keep, "Snapshots kept in the backup directory, oldest removed first (0 = keep all)")
+	
+	// Click archive flags
+	archiveDefaults := archive.DefaultConfig()
+	serverCmd.Flags().String("archive-bucket", "", "S3-compatible bucket old per-minute and per-hour clicks are moved to as gzipped CSV (empty disables archiving)")
+	serverCmd.Flags().String("archive-endpoint", "", "S3-compatible endpoint URL, e.g. https://storage.googleapis.com or http://minio:9000 (empty = AWS S3 in --archive-region)")
+	serverCmd.Flags().String("archive-region", archiveDefaults.Region, "Region archive uploads are signed for")
+	serverCmd.Flags().String("archive-prefix", archiveDefaults.Prefix, "Prepended to the key of every archive")
+	serverCmd.Flags().String("archive-access-key", "", "Access key ID for archive uploads (default $AWS_ACCESS_KEY_ID)")
+	serverCmd.Flags().String("archive-secret-key", "", "Secret access key for archive uploads (default $AWS_SECRET_ACCESS_KEY)")
+	serverCmd.Flags().Bool("archive-path-style", false, "Put the bucket in the URL path instead of the host name, as MinIO expects")
+	serverCmd.Flags().Duration("archive-after", archiveDefaults.After, "Clicks older than this are archived and deleted from the database, in whole UTC days (at least 24h)")
+	serverCmd.Flags().Duration("archive-interval", archiveDefaults.Interval, "How often old clicks are archived")
+	serverCmd.Flags().Duration("archive-timeout", archiveDefaults.Timeout, "Timeout for uploading one day of clicks")
+	
+	// Data retention flags
+	retentionDefaults := retention.DefaultConfig()
+	serverCmd.Flags().Duration("click-retention", retentionDefaults.ClickRetention, "Per-minute and per-hour clicks older than this are deleted, e.g. 8760h for a year (0 = keep them; at least 24h)")
+	serverCmd.Flags().Duration("ip-retention", retentionDefaults.AddressRetention, "Visitor addresses held in memory for click deduplication and crawler lookups are forgotten after this, e.g. 720h (0 = when they expire)")
+	serverCmd.Flags().Duration("retention-interval", retentionDefaults.Interval, "How often the click and IP retention periods are enforced")
+	
+	// IP anonymization flags
+	privacyDefaults := privacy.DefaultConfig()
+	serverCmd.Flags().String("ip-anonymization", string(privacyDefaults.Mode), "Anonymize visitor addresses before click dedupe, request logs and traces see them: truncate or hash (empty = off)")
+	serverCmd.Flags().Int("ip-anonymization-ipv4-prefix", privacyDefaults.IPv4Prefix, "Bits of IPv4 addresses kept by --ip-anonymization truncate")
+	serverCmd.Flags().Int("ip-anonymization-ipv6-prefix", privacyDefaults.IPv6Prefix, "Bits of IPv6 addresses kept by --ip-anonymization truncate")
+	serverCmd.Flags().Duration("ip-anonymization-key-rotation", privacyDefaults.KeyRotation, "How often --ip-anonymization hash replaces its random key (0 = once per start)")
+	
+	// Storage quota flags
+	storageDefaults := storage.DefaultConfig()
+	serverCmd.Flags().Int64("storage-quota-bytes", 0, "Database size quota in bytes reported by /api/admin/storage (0 = none)")
+	serverCmd.Flags().Int64("storage-quota-rows", 0, "Total row quota across all tables (0 = none)")
+	serverCmd.Flags().Float64("storage-quota-warn-ratio", storageDefaults.WarnRatio, "Fraction of a storage quota at which warnings are raised")
+	serverCmd.Flags().Duration("storage-growth-window", storageDefaults.GrowthWindow, "How far back link creation is measured to project storage growth")
+	serverCmd.Flags().Duration("storage-projection-window", storageDefaults.ProjectionWindow, "How far ahead storage growth is projected")
+	
+	// GeoIP flags for country routing rules
+	serverCmd.Flags().String("geoip-db", "", "MaxMind DB (.mmdb) or CSV of IP ranges and countries (start,end,country) used by country routing rules and click analytics")
+	serverCmd.Flags().String("geoip-country-header", "", "Trusted request header holding the visitor's country, set by a CDN or proxy (e.g. CF-IPCountry)")
+	serverCmd.Flags().String("geoip-asn-db", "", "MaxMind ASN database (.mmdb, e.g. GeoLite2-ASN) naming visitor networks for --abuse-network-clicks")
+	
+	// Bot filtering flags
+	botDefaults := bots.DefaultConfig()
+	serverCmd.Flags().String("bot-clicks", string(botDefaults.Clicks), "How redirects by bots and crawlers are counted: count, exclude or separate")
+	serverCmd.Flags().Bool("bot-verify-dns", false, "Also treat visitors whose address reverse-resolves to a crawler domain, confirmed forward, as bots")
+	serverCmd.Flags().StringSlice("bot-dns-domains", botDefaults.Domains, "Crawler domains matched by --bot-verify-dns, including subdomains")
+	serverCmd.Flags().Duration("bot-dns-timeout", botDefaults.DNSTimeout, "Timeout for the DNS lookups verifying one address")
+	serverCmd.Flags().Duration("bot-dns-ttl", botDefaults.DNSTTL, "How long an address's crawler verification is cached")
+	
+	// Click fraud detection flags
+	abuseDefaults := abuse.DefaultConfig()
+	serverCmd.Flags().Int("abuse-client-clicks", 0, "Flag a link clicked more often than this within --abuse-window from one visitor address (0 = off)")
+	serverCmd.Flags().Int("abuse-network-clicks", 0, "Flag a link clicked more often than this within --abuse-window from one network; needs --geoip-asn-db (0 = off)")
+	serverCmd.Flags().Int("abuse-bot-clicks", 0, "Flag a link clicked more often than this within --abuse-window by bots (0 = off)")
+	serverCmd.Flags().Duration("abuse-window", abuseDefaults.Window, "Window clicks are counted in for abuse detection")
+	serverCmd.Flags().String("abuse-action", string(abuseDefaults.Action), "What happens to a flagged link: flag (report only), throttle or disable")
+	serverCmd.Flags().Int("abuse-throttle-clicks", abuseDefaults.ThrottleClicks, "Redirects a throttled link serves per window; the rest get 429")
+	serverCmd.Flags().Duration("abuse-flag-duration", abuseDefaults.FlagDuration, "How long a flag, and the throttling or disabling it brings, lasts")
+	
+	// Click analytics flags
+	serverCmd.Flags().Duration("analytics-flush-interval", analytics.DefaultConfig().FlushInterval, "How often clicks are added to the referrer and UTM rollups (0 = disable click analytics)")
+	serverCmd.Flags().Duration("analytics-minute-retention", analytics.DefaultConfig().MinuteRetention, "How long clicks are kept per minute before being compacted into hourly counts (0 = keep them)")
+	
+	// Event stream flags
+	serverCmd.Flags().Int("events-max-streams", service.DefaultEventBusConfig().MaxSubscribers, "Most live /api/events streams at once (0 = disable event streaming)")
+	serverCmd.Flags().Int("events-buffer", service.DefaultEventBusConfig().Buffer, "Events held for each stream before a slow client starts missing them")
+	
+	// Event broker flags
+	serverCmd.Flags().String("events-broker", events.DefaultConfig().Broker, "External broker every event is also published to: none or nats")
+	serverCmd.Flags().String("events-nats-url", events.DefaultConfig().NATSURL, "NATS server for --events-broker nats, nats://[user:password@]host:port")
+	serverCmd.Flags().String("events-nats-subject", events.DefaultConfig().NATSSubject, "NATS subject prefix; events are published to <prefix>.<event type>")
+	serverCmd.Flags().Int("events-broker-queue", events.DefaultConfig().QueueSize, "Events buffered for the broker before new ones are dropped")
+	serverCmd.Flags().Duration("events-broker-timeout", events.DefaultConfig().Timeout, "Timeout for connecting to the broker and for each publish")
+	
+	// Event export flags
+	exportDefaults := export.DefaultConfig()
+	exportEvents := make([]string, len(exportDefaults.Events))
+	for i, eventType := range exportDefaults.Events {
+		exportEvents[i] = string(eventType)
+	}
+	serverCmd.Flags().String("events-export", exportDefaults.Sink, "Where events are exported for data pipelines, with at-least-once delivery: none, kafka or nats")
+	serverCmd.Flags().StringSlice("events-export-kafka-brokers", exportDefaults.KafkaBrokers, "Kafka bootstrap brokers for --events-export kafka, host:port")
+	serverCmd.Flags().String("events-export-kafka-topic", exportDefaults.KafkaTopic, "Kafka topic events are produced to, keyed by short code")
+	serverCmd.Flags().String("events-export-nats-url", exportDefaults.NATSURL, "NATS server for --events-export nats, nats://[user:password@]host:port")
+	serverCmd.Flags().String("events-export-nats-subject", exportDefaults.NATSSubject, "NATS subject prefix; events are exported to <prefix>.<event type>")
+	serverCmd.Flags().StringSlice("events-export-types", exportEvents, "Event types exported")
+	serverCmd.Flags().Int("events-export-batch-size", exportDefaults.BatchSize, "Events sent to the sink at once")
+	serverCmd.Flags().Duration("events-export-flush-interval", exportDefaults.FlushInterval, "How often pending events are exported when a batch is not full")
+	serverCmd.Flags().Int("events-export-max-pending", exportDefaults.MaxPending, "Events held in memory between flushes before new ones are dropped")
+	serverCmd.Flags().Duration("events-export-timeout", exportDefaults.Timeout, "Timeout for connecting to the sink and for each batch")
+	
+	// Peer cache invalidation flags
+	serverCmd.Flags().StringSlice("peers", nil, "Base URLs of the other instances sharing the database, told to reload links changed here")
+	serverCmd.Flags().String("peer-secret", "", "Secret shared by every instance to sign cache invalidations (invalidations are refused when empty)")
+	serverCmd.Flags().Duration("peer-timeout", peers.DefaultConfig().Timeout, "Timeout for each cache invalidation sent to a peer")
+	serverCmd.Flags().Int("peer-queue-size", peers.DefaultConfig().QueueSize, "Changed links waiting to be sent to peers before new ones are dropped")
+	
+	// Tracing flags
+	tracingDefaults := tracing.DefaultConfig()
+	serverCmd.Flags().String("tracing-exporter", tracingDefaults.Exporter, "Where request traces are sent: none or otlp")
+	serverCmd.Flags().String("tracing-endpoint", tracingDefaults.Endpoint, "OTLP/HTTP collector base URL; spans are posted as JSON to <endpoint>/v1/traces")
+	serverCmd.Flags().StringSlice("tracing-headers", nil, "Extra headers sent to the collector, key=value")
+	serverCmd.Flags().String("tracing-service-name", tracingDefaults.ServiceName, "service.name reported with every span")
+	serverCmd.Flags().Float64("tracing-sample-ratio", tracingDefaults.SampleRatio, "Fraction of new traces recorded (0-1); requests with a traceparent header follow its sampled flag")
+	serverCmd.Flags().Duration("tracing-timeout", tracingDefaults.Timeout, "Timeout for each export request to the collector")
+	
+	// Export/import command flags
+	exportCmd.Flags().String("db-path", "urls.db", "Database file path")
+	exportCmd.Flags().String("db-driver", "", "SQLite driver: mattn (cgo) or modernc (pure Go); defaults to mattn when built with cgo")
+	exportCmd.Flags().String("format", "json", "Export format: json or csv")
+	exportCmd.Flags().StringP("output", "o", "", "Output file (default: stdout)")
+	importCmd.Flags().String("db-path", "urls.db", "Database file path")
+	importCmd.Flags().String("db-driver", "", "SQLite driver: mattn (cgo) or modernc (pure Go); defaults to mattn when built with cgo")
+	importCmd.Flags().StringP("file", "f", "", "File to import (format inferred from extension unless --format is set)")
+	importCmd.Flags().String("format", "", "Import format: json or csv")
+	importCmd.Flags().String("on-conflict", string(domain.ConflictFail), "Strategy for existing short codes: skip, overwrite, or fail")
+	importCmd.MarkFlagRequired("file")
+	for _, cmd := range []*cobra.Command{statusCmd, reloadCmd, stopCmd} {
+		cmd.Flags().String("control-socket", "", "Control socket of the server (its --control-socket)")
+		cmd.Flags().String("pid-file", "", "Pid file of the server (its --pid-file), used when there is no control socket")
+	}
+	stopCmd.Flags().Duration("timeout", time.Minute, "How long to wait for the server to exit (0 = do not wait)")
+	
+	// Migrate codes command flags
+	migrateCodesCmd.Flags().String("db-path", "urls.db", "Database file path")
+	migrateCodesCmd.Flags().String("db-driver", "", "SQLite driver: mattn (cgo) or modernc (pure Go); defaults to mattn when built with cgo")
+	for _, side := range []string{"from", "to"} {
+		migrateCodesCmd.Flags().String(side+"-obfuscation", shortener.ObfuscationMultiplicative, "Counter obfuscation strategy "+side+" which codes are migrated")
+		migrateCodesCmd.Flags().String(side+"-secret", "", "Obfuscation secret "+side+" which codes are migrated")
+		migrateCodesCmd.Flags().Uint64(side+"-multiplier", 0, "Multiplicative obfuscation multiplier "+side+" which codes are migrated (0 = built-in)")
+		migrateCodesCmd.Flags().Uint64(side+"-salt", 0, "Multiplicative obfuscation salt "+side+" which codes are migrated (0 = built-in)")
+		migrateCodesCmd.Flags().String(side+"-alphabet", "base62", "Code alphabet "+side+" which codes are migrated")
+		migrateCodesCmd.Flags().Int(side+"-code-length", 7, "Code length "+side+" which codes are migrated")
+	}
+	migrateCodesCmd.Flags().StringSlice("reserved-codes", shortener.DefaultReservedCodes, "Short codes that are never issued; codes that would become one are kept")
+	migrateCodesCmd.Flags().String("blocked-words-file", "", "File of words no short code may contain; codes that would contain one are kept")
+	migrateCodesCmd.Flags().StringP("output", "o", "", "Mapping file from old to new codes (default: stdout)")
+	migrateCodesCmd.Flags().String("format", "", "Mapping format: json or csv (default: inferred from --output)")
+	migrateCodesCmd.Flags().Bool("dry-run", false, "Write the mapping without changing the database")
+	migrateCodesCmd.Flags().Bool("aliases", false, "Keep each old code as a 301 redirect to its new short URL")
+	migrateCodesCmd.Flags().String("server-url", "http://localhost:8080", "Server URL new short URLs are built from for --aliases; links on a custom domain use https://<domain>")
+	serverCmd.AddCommand(exportCmd, importCmd, migrateCodesCmd, statusCmd, reloadCmd, stopCmd)
+	
+	// Simulate command flags
+	simulateDefaults := simulate.DefaultConfig()
+	simulateCmd.Flags().StringP("server-url", "u", simulateDefaults.TargetURL, "Target server URL")
+	simulateCmd.Flags().Int("rps", simulateDefaults.RPS, "Target requests per second")
+	simulateCmd.Flags().Duration("duration", simulateDefaults.Duration, "How long to generate traffic")
+	simulateCmd.Flags().Int("concurrency", simulateDefaults.Concurrency, "Number of concurrent workers")
+	simulateCmd.Flags().Int("seed-urls", simulateDefaults.SeedURLs, "Number of links to create before generating traffic")
+	simulateCmd.Flags().Float64("create-ratio", simulateDefaults.CreateRatio, "Fraction of requests that create links")
+	simulateCmd.Flags().Float64("delete-ratio", simulateDefaults.DeleteRatio, "Fraction of requests that delete links")
 	
 	// Client command flags
 	clientCmd.PersistentFlags().StringP("server-url", "u", "http://localhost:8080", "Server URL")
+	clientCmd.PersistentFlags().String("api-key", os.Getenv("URL_SHORTENER_API_KEY"), "API key or share token (default $URL_SHORTENER_API_KEY)")
+	clientCmd.PersistentFlags().StringP("output", "o", "table", "Output format: table, json or csv")
+	clientCmd.PersistentFlags().String("config", client.DefaultConfigPath(), "Client config file with named profiles of server URL, API key and output format")
+	clientCmd.PersistentFlags().String("profile", os.Getenv("URL_SHORTENER_PROFILE"), "Profile from the client config file (default $URL_SHORTENER_PROFILE, then the file's default_profile, then \"default\")")
+	clientCmd.PersistentFlags().Int("retries", apiclient.DefaultRetries, "Retry GET and DELETE requests this many times on connection errors, 429 and 502-504 (0 = no retries)")
+	clientCmd.PersistentFlags().Duration("request-timeout", apiclient.DefaultTimeout, "Give up on a request attempt after this long (0 = no limit)")
+	clientCmd.PersistentFlags().String("proxy", "", "Send requests through this HTTP(S) proxy URL (default $HTTPS_PROXY, $HTTP_PROXY and $NO_PROXY)")
+	clientCmd.PersistentFlags().String("ca-cert", "", "Trust the server certificate signed by the CA in this PEM file instead of the system roots")
+	clientCmd.PersistentFlags().String("client-cert", "", "Present the client certificate in this PEM file for mutual TLS (requires --client-key)")
+	clientCmd.PersistentFlags().String("client-key", "", "PEM key of the --client-cert certificate")
+	// create and validate take the same link options
+	for _, cmd := range []*cobra.Command{createCmd, validateCmd} {
+		cmd.Flags().Int("max-uses", 0, "Deactivate the link after this many redirects (0 = unlimited)")
+		cmd.Flags().StringSlice("tag", nil, "Tag the link (repeatable), e.g. for lifecycle policies")
+		cmd.Flags().Int("redirect-status", 0, "Redirect with 301, 302, 307 or 308 (0 = server default)")
+		cmd.Flags().String("backup-url", "", "Redirect here instead while health checks find the destination broken")
+		cmd.Flags().StringToString("param", nil, "Add a query parameter to the destination on every redirect, e.g. --param utm_source=newsletter (repeatable)")
+		cmd.Flags().Bool("forward-query", false, "Pass the short link's query parameters on to the destination")
+		cmd.Flags().String("campaign", "", "Group the link under a campaign, e.g. spring-sale")
+		cmd.Flags().Int("dedupe-seconds", 0, "Count one click per visitor in this many seconds (0 = server default, -1 = every click)")
+		cmd.Flags().String("domain", "", "Serve the link only on this custom domain, e.g. go.example.com")
+		cmd.Flags().Bool("reuse", false, "Return an existing uncapped short URL for the same destination instead of creating one")
+		cmd.Flags().Bool("burn-after-reading", false, "Deactivate the link after its first redirect, which no one else can follow")
+	}
+	listCmd.Flags().String("campaign", "", "Only list the links of this campaign")
+	listCmd.Flags().String("owner", "", "Only list the links created by this owner (\"me\" for links created with --api-key)")
+	listCmd.MarkFlagsMutuallyExclusive("campaign", "owner")
+	deleteCmd.Flags().String("file", "", "Delete the short codes listed in this file, one per line (- for stdin)")
+	pruneCmd.Flags().String("unused-for", "", "Only links not redirected for this long, e.g. 90d (never-used links count from creation)")
+	pruneCmd.Flags().String("created-before", "", "Only links created before this date (2006-01-02), RFC 3339 time, or age such as 180d")
+	pruneCmd.Flags().Bool("dry-run", false, "Only list the matching links")
+	pruneCmd.Flags().BoolP("yes", "y", false, "Delete without asking for confirmation")
+	statsCmd.Flags().Int("limit", 0, "Rows per report section (0 = server default of 10)")
+	statsCmd.Flags().Bool("referrers", false, "Show only the link's clicks by referrer and UTM parameters")
+	statsCmd.Flags().Bool("countries", false, "Show only the link's clicks by visitor country and region")
+	timeseriesCmd.Flags().String("interval", "", "Bucket width: minute, hour or day (default: hour)")
+	timeseriesCmd.Flags().String("from", "", "Start as an RFC 3339 time or an age such as 12h or 7d (default: depends on --interval)")
+	timeseriesCmd.Flags().String("to", "", "End as an RFC 3339 time or an age such as 1h (default: now)")
+	topCmd.Flags().String("window", "", "How far back to count clicks, e.g. 1h or 7d (default: server default of 24h)")
+	topCmd.Flags().Int("limit", 0, "Number of links (0 = server default of 10)")
+	topCmd.Flags().Bool("trending", false, "Order by the gain in clicks over the window before instead of by clicks")
+	shareTokenCmd.Flags().Duration("ttl", 0, "Token lifetime (default: server default of 24h)")
+	drainCmd.Flags().Bool("end", false, "End the drain, accepting writes again")
+	shellCmd.Flags().String("history-file", defaultHistoryFile(), "File the shell history is kept in (empty = no history)")
 	
 	// Add subcommands
-	clientCmd.AddCommand(createCmd, getCmd, deleteCmd, listCmd)
-	rootCmd.AddCommand(serverCmd, clientCmd)
+	cacheCmd.AddCommand(cacheStatsCmd, cacheGetCmd, cacheFlushCmd, cacheInvalidateCmd)
+	clientCmd.AddCommand(createCmd, validateCmd, getCmd, deleteCmd, pruneCmd, listCmd, campaignsCmd, statsCmd, timeseriesCmd, topCmd, shareTokenCmd, cacheCmd, syncCmd, drainCmd, versionCmd, shellCmd)
+	rootCmd.AddCommand(serverCmd, clientCmd, simulateCmd)
 }
 
 func runServer(cmd *cobra.Command, args []string) error {
 	// Get configuration from CLI flags
 	port, _ := cmd.Flags().GetString("port")
+	listen, _ := cmd.Flags().GetStringSlice("listen")
+	adminListen, _ := cmd.Flags().GetStringSlice("admin-listen")
 	serverURL, _ := cmd.Flags().GetString("server-url")
 	dbPath, _ := cmd.Flags().GetString("db-path")
+	dbDriver, _ := cmd.Flags().GetString("db-driver")
 	syncInterval, _ := cmd.Flags().GetDuration("sync-interval")
+	var syncConfig service.SyncConfig
+	syncConfig.MaxBackoff, _ = cmd.Flags().GetDuration("sync-max-backoff")
+	syncConfig.MaxAttempts, _ = cmd.Flags().GetInt("sync-max-attempts")
+	syncConfig.DeadLetterPath, _ = cmd.Flags().GetString("sync-dead-letter")
+	syncConfig.WALPath, _ = cmd.Flags().GetString("sync-wal")
+	usageMerge, _ := cmd.Flags().GetString("usage-merge")
+	cacheEntryTTL, _ := cmd.Flags().GetDuration("cache-entry-ttl")
+	cacheRefreshOnAccess, _ := cmd.Flags().GetBool("cache-refresh-on-access")
+	serveStale, _ := cmd.Flags().GetDuration("serve-stale")
+	cacheShards, _ := cmd.Flags().GetInt("cache-shards")
+	responseCacheTTL, _ := cmd.Flags().GetDuration("response-cache-ttl")
+	clickDedupeWindow, _ := cmd.Flags().GetDuration("click-dedupe-window")
+	clickConfig := service.DefaultClickBufferConfig()
+	clickConfig.Size, _ = cmd.Flags().GetInt("click-buffer")
+	clickConfig.BatchSize, _ = cmd.Flags().GetInt("click-buffer-batch")
+	clickConfig.Overflow, _ = cmd.Flags().GetString("click-buffer-overflow")
+	clickConfig.Async, _ = cmd.Flags().GetBool("async-clicks")
+	outboxConfig := service.DefaultOutboxConfig()
+	outboxConfig.Enabled, _ = cmd.Flags().GetBool("event-outbox")
+	outboxConfig.Interval, _ = cmd.Flags().GetDuration("event-outbox-interval")
+	outboxConfig.BatchSize, _ = cmd.Flags().GetInt("event-outbox-batch")
+	shutdownTimeout, _ := cmd.Flags().GetDuration("shutdown-timeout")
+	shutdownStageTimeout, _ := cmd.Flags().GetDuration("shutdown-stage-timeout")
+	timeouts := httpTransport.TimeoutConfig{}
+	timeouts.Redirect, _ = cmd.Flags().GetDuration("redirect-timeout")
+	timeouts.API, _ = cmd.Flags().GetDuration("api-timeout")
+	timeouts.Bulk, _ = cmd.Flags().GetDuration("bulk-timeout")
+	
+	// Get TLS configuration
+	tlsConfig := httpTransport.TLSConfig{}
+	tlsConfig.CertFile, _ = cmd.Flags().GetString("tls-cert")
+	tlsConfig.KeyFile, _ = cmd.Flags().GetString("tls-key")
+	tlsConfig.ACMEDomains, _ = cmd.Flags().GetStringSlice("acme-domain")
+	tlsConfig.ACMECacheDir, _ = cmd.Flags().GetString("acme-cache-dir")
+	tlsConfig.ACMEEmail, _ = cmd.Flags().GetString("acme-email")
+	tlsConfig.RedirectPort, _ = cmd.Flags().GetString("http-redirect-port")
+	
+	// Get redirect configuration
+	redirectConfig := httpTransport.DefaultRedirectConfig()
+	redirectConfig.Status, _ = cmd.Flags().GetInt("redirect-status")
+	redirectConfig.PermanentMaxAge, _ = cmd.Flags().GetDuration("permanent-redirect-max-age")
+	redirectConfig.TemporaryMaxAge, _ = cmd.Flags().GetDuration("temporary-redirect-max-age")
+	redirectConfig.Landing, _ = cmd.Flags().GetString("landing")
+	redirectConfig.StatsPages, _ = cmd.Flags().GetBool("stats-pages")
+	redirectConfig.AllowCrawling, _ = cmd.Flags().GetBool("allow-crawling")
+	redirectConfig.FaviconFile, _ = cmd.Flags().GetString("favicon-file")
+	redirectConfig.Fallback, _ = cmd.Flags().GetString("fallback-url")
+	redirectConfig.CampaignFallbacks, _ = cmd.Flags().GetStringToString("fallback-campaign")
+	redirectConfig.FallbackParam, _ = cmd.Flags().GetString("fallback-param")
+	proxyConfig := httpTransport.ProxyConfig{}
+	proxyConfig.TrustedProxies, _ = cmd.Flags().GetStringSlice("trusted-proxies")
+	proxyConfig.ClientIPHeader, _ = cmd.Flags().GetString("client-ip-header")
+	errorPagesDir, _ := cmd.Flags().GetString("error-pages-dir")
+	pidFile, _ := cmd.Flags().GetString("pid-file")
+	controlSocket, _ := cmd.Flags().GetString("control-socket")
 	
 	// Get shortener configuration
 	shortenerCounterStep, _ := cmd.Flags().GetInt64("shortener-counter-step")
+	shortenerCounterPreallocate, _ := cmd.Flags().GetBool("shortener-counter-preallocate")
+	shortenerCounterShards, _ := cmd.Flags().GetInt("shortener-counter-shards")
+	shortenerShardPick, _ := cmd.Flags().GetString("shortener-shard-pick")
+	shortenerObfuscation, _ := cmd.Flags().GetString("shortener-obfuscation")
+	shortenerSecret, _ := cmd.Flags().GetString("shortener-secret")
+	shortenerMultiplier, _ := cmd.Flags().GetUint64("shortener-multiplier")
+	shortenerSalt, _ := cmd.Flags().GetUint64("shortener-salt")
+	shortenerAlphabet, _ := cmd.Flags().GetString("shortener-alphabet")
+	shortenerCodeLength, _ := cmd.Flags().GetInt("shortener-code-length")
+	shortenerAutoLength, _ := cmd.Flags().GetBool("shortener-auto-length")
+	shortenerMinLength, _ := cmd.Flags().GetInt("shortener-min-length")
+	shortenerGrowAt, _ := cmd.Flags().GetFloat64("shortener-grow-at")
+	reservedCodes, _ := cmd.Flags().GetStringSlice("reserved-codes")
+	blockedWordsFile, _ := cmd.Flags().GetString("blocked-words-file")
+	blockedWords := shortener.DefaultBlockedWords
+	if blockedWordsFile != "" {
+		extraWords, err := shortener.LoadBlockedWords(blockedWordsFile)
+		if err != nil {
+			return err
+		}
+		blockedWords = append(append([]string{}, blockedWords...), extraWords...)
+	}
 	
 	// Get logging configuration
 	verbose, _ := cmd.Flags().GetBool("verbose")
+	requestLog := httpTransport.DefaultRequestLogConfig()
+	requestLog.SampleRate, _ = cmd.Flags().GetInt("log-sample-rate")
+	requestLog.MaxBodyBytes, _ = cmd.Flags().GetInt("log-body-bytes")
+	requestLog.RedactFields, _ = cmd.Flags().GetStringSlice("log-redact-fields")
+	
+	// Get authentication configuration
+	apiKeys, _ := cmd.Flags().GetStringSlice("api-keys")
+	userAPIKeys, _ := cmd.Flags().GetStringSlice("user-api-keys")
+	shareTokenSecret, _ := cmd.Flags().GetString("share-token-secret")
+	shareTokenMaxTTL, _ := cmd.Flags().GetDuration("share-token-max-ttl")
+	var oidcConfig auth.OIDCConfig
+	oidcConfig.Issuer, _ = cmd.Flags().GetString("oidc-issuer")
+	oidcConfig.ClientID, _ = cmd.Flags().GetString("oidc-client-id")
+	oidcConfig.GroupsClaim, _ = cmd.Flags().GetString("oidc-groups-claim")
+	oidcConfig.AdminGroups, _ = cmd.Flags().GetStringSlice("oidc-admin-groups")
+	oidcConfig.ViewerGroups, _ = cmd.Flags().GetStringSlice("oidc-viewer-groups")
+	oidcConfig.Scopes, _ = cmd.Flags().GetStringSlice("oidc-scopes")
+	oidcConfig.JWKSCacheTTL, _ = cmd.Flags().GetDuration("oidc-jwks-cache-ttl")
+	
+	// Get webhook configuration
+	webhookConfig := webhook.DefaultConfig()
+	webhookConfig.EndpointsFile, _ = cmd.Flags().GetString("webhooks-config")
+	webhookConfig.Timeout, _ = cmd.Flags().GetDuration("webhook-timeout")
+	webhookConfig.MaxAttempts, _ = cmd.Flags().GetInt("webhook-max-attempts")
+	webhookConfig.ClickSampleRate, _ = cmd.Flags().GetFloat64("webhook-click-sample-rate")
+	webhookConfig.ClickRateLimit, _ = cmd.Flags().GetFloat64("webhook-click-rate-limit")
+	webhookConfig.Retention, _ = cmd.Flags().GetDuration("webhook-retention")
+	
+	// Get lifecycle policy configuration
+	policyConfig := policy.DefaultConfig()
+	policyConfig.File, _ = cmd.Flags().GetString("policies-config")
+	policyConfig.Interval, _ = cmd.Flags().GetDuration("policy-interval")
+	policyConfig.DryRun, _ = cmd.Flags().GetBool("policy-dry-run")
+	
+	// Get failover health check configuration
+	failoverConfig := failover.DefaultConfig()
+	failoverConfig.Interval, _ = cmd.Flags().GetDuration("failover-interval")
+	failoverConfig.Timeout, _ = cmd.Flags().GetDuration("failover-timeout")
+	failoverConfig.FailureThreshold, _ = cmd.Flags().GetInt("failover-failure-threshold")
+	failoverConfig.RecoveryThreshold, _ = cmd.Flags().GetInt("failover-recovery-threshold")
+	
+	// Get link preview configuration
+	previewConfig := preview.DefaultConfig()
+	previewConfig.Workers, _ = cmd.Flags().GetInt("preview-workers")
+	previewConfig.Timeout, _ = cmd.Flags().GetDuration("preview-timeout")
+	previewConfig.MaxBytes, _ = cmd.Flags().GetInt64("preview-max-bytes")
+	
+	// Get destination verification configuration
+	verifyConfig := reachability.DefaultConfig()
+	verifyMode, _ := cmd.Flags().GetString("verify-destinations")
+	verifyConfig.Mode = reachability.Mode(verifyMode)
+	verifyConfig.Timeout, _ = cmd.Flags().GetDuration("verify-timeout")
+	verifyConfig.MaxRedirects, _ = cmd.Flags().GetInt("verify-max-redirects")
+	verifyConfig.Interval, _ = cmd.Flags().GetDuration("verify-interval")
+	
+	// Get broken link email configuration
+	mailConfig := mailer.DefaultConfig()
+	mailConfig.Addr, _ = cmd.Flags().GetString("smtp-addr")
+	mailConfig.From, _ = cmd.Flags().GetString("smtp-from")
+	mailConfig.To, _ = cmd.Flags().GetStringSlice("smtp-to")
+	mailConfig.Username, _ = cmd.Flags().GetString("smtp-username")
+	mailConfig.Password, _ = cmd.Flags().GetString("smtp-password")
+	if mailConfig.Password == "" {
+		mailConfig.Password = os.Getenv("SMTP_PASSWORD")
+	}
+	
+	// Get CDN purge configuration
+	cdnConfig := cdn.DefaultConfig()
+	cdnConfig.PurgeURL, _ = cmd.Flags().GetString("cdn-purge-url")
+	cdnConfig.Provider, _ = cmd.Flags().GetString("cdn-provider")
+	cdnConfig.Token, _ = cmd.Flags().GetString("cdn-purge-token")
+	if cdnConfig.Token == "" {
+		cdnConfig.Token = os.Getenv("CDN_PURGE_TOKEN")
+	}
+	cdnConfig.Timeout, _ = cmd.Flags().GetDuration("cdn-purge-timeout")
+	cdnConfig.MaxAttempts, _ = cmd.Flags().GetInt("cdn-purge-attempts")
+	
+	// Get custom domain configuration
+	hostsConfig := hosts.DefaultConfig()
+	hostsConfig.Enabled, _ = cmd.Flags().GetBool("custom-domains")
+	hostsConfig.Refresh, _ = cmd.Flags().GetDuration("custom-domains-refresh")
+	
+	// Get storage quota configuration
+	storageConfig := storage.DefaultConfig()
+	storageConfig.MaxBytes, _ = cmd.Flags().GetInt64("storage-quota-bytes")
+	storageConfig.MaxRows, _ = cmd.Flags().GetInt64("storage-quota-rows")
+	storageConfig.WarnRatio, _ = cmd.Flags().GetFloat64("storage-quota-warn-ratio")
+	storageConfig.GrowthWindow, _ = cmd.Flags().GetDuration("storage-growth-window")
+	storageConfig.ProjectionWindow, _ = cmd.Flags().GetDuration("storage-projection-window")
+	
+	// Get database tuning
+	dbTuning := sqlite.DefaultTuning()
+	dbTuning.BusyTimeout, _ = cmd.Flags().GetDuration("db-busy-timeout")
+	dbTuning.CacheSizeKiB, _ = cmd.Flags().GetInt("db-cache-size")
+	dbTuning.Synchronous, _ = cmd.Flags().GetString("db-synchronous")
+	dbTuning.MaxOpenConns, _ = cmd.Flags().GetInt("db-max-open-conns")
+	dbTuning.MaxIdleConns, _ = cmd.Flags().GetInt("db-max-idle-conns")
+	dbBreaker := service.DefaultBreakerConfig()
+	dbBreaker.FailureRate, _ = cmd.Flags().GetFloat64("db-breaker-failure-rate")
+	dbBreaker.MinCalls, _ = cmd.Flags().GetInt("db-breaker-min-calls")
+	dbBreaker.Window, _ = cmd.Flags().GetDuration("db-breaker-window")
+	dbBreaker.Cooldown, _ = cmd.Flags().GetDuration("db-breaker-cooldown")
+	dbBreaker.Probes, _ = cmd.Flags().GetInt("db-breaker-probes")
+
+	// Get backup configuration
+	backupConfig := backup.DefaultConfig()
+	backupConfig.Dir, _ = cmd.Flags().GetString("backup-dir")
+	backupConfig.Interval, _ = cmd.Flags().GetDuration("backup-interval")
+	backupConfig.Keep, _ = cmd.Flags().GetInt("backup-keep")
+	
+	// Get click archive configuration
+	archiveConfig := archive.DefaultConfig()
+	archiveConfig.Bucket, _ = cmd.Flags().GetString("archive-bucket")
+	archiveConfig.Endpoint, _ = cmd.Flags().GetString("archive-endpoint")
+	archiveConfig.Region, _ = cmd.Flags().GetString("archive-region")
+	archiveConfig.Prefix, _ = cmd.Flags().GetString("archive-prefix")
+	archiveConfig.AccessKeyID, _ = cmd.Flags().GetString("archive-access-key")
+	if archiveConfig.AccessKeyID == "" {
+		archiveConfig.AccessKeyID = os.Getenv("AWS_ACCESS_KEY_ID")
+	}
+	archiveConfig.SecretAccessKey, _ = cmd.Flags().GetString("archive-secret-key")
+	if archiveConfig.SecretAccessKey == "" {
+		archiveConfig.SecretAccessKey = os.Getenv("AWS_SECRET_ACCESS_KEY")
+	}
+	archiveConfig.PathStyle, _ = cmd.Flags().GetBool("archive-path-style")
+	archiveConfig.After, _ = cmd.Flags().GetDuration("archive-after")
+	archiveConfig.Interval, _ = cmd.Flags().GetDuration("archive-interval")
+	archiveConfig.Timeout, _ = cmd.Flags().GetDuration("archive-timeout")
+	
+	// Get data retention configuration
+	retentionConfig := retention.DefaultConfig()
+	retentionConfig.ClickRetention, _ = cmd.Flags().GetDuration("click-retention")
+	retentionConfig.AddressRetention, _ = cmd.Flags().GetDuration("ip-retention")
+	retentionConfig.Interval, _ = cmd.Flags().GetDuration("retention-interval")
+	
+	// Get IP anonymization configuration
+	privacyConfig := privacy.DefaultConfig()
+	privacyMode, _ := cmd.Flags().GetString("ip-anonymization")
+	privacyConfig.Mode = privacy.Mode(privacyMode)
+	privacyConfig.IPv4Prefix, _ = cmd.Flags().GetInt("ip-anonymization-ipv4-prefix")
+	privacyConfig.IPv6Prefix, _ = cmd.Flags().GetInt("ip-anonymization-ipv6-prefix")
+	privacyConfig.KeyRotation, _ = cmd.Flags().GetDuration("ip-anonymization-key-rotation")
+	
+	// Get destination allow and deny lists
+	destConfig := destinations.DefaultConfig()
+	destConfig.Allow, _ = cmd.Flags().GetStringSlice("allowed-destinations")
+	destConfig.Deny, _ = cmd.Flags().GetStringSlice("denied-destinations")
+	destConfig.Refresh, _ = cmd.Flags().GetDuration("destination-rules-refresh")
+	
+	// Get GeoIP configuration
+	geoConfig := geoip.DefaultConfig()
+	geoConfig.Database, _ = cmd.Flags().GetString("geoip-db")
+	geoConfig.CountryHeader, _ = cmd.Flags().GetString("geoip-country-header")
+	geoConfig.ASNDatabase, _ = cmd.Flags().GetString("geoip-asn-db")
+	
+	// Get bot filtering configuration
+	botConfig := bots.DefaultConfig()
+	botClicks, _ := cmd.Flags().GetString("bot-clicks")
+	botConfig.Clicks = domain.BotClickMode(botClicks)
+	botConfig.VerifyDNS, _ = cmd.Flags().GetBool("bot-verify-dns")
+	botConfig.Domains, _ = cmd.Flags().GetStringSlice("bot-dns-domains")
+	botConfig.DNSTimeout, _ = cmd.Flags().GetDuration("bot-dns-timeout")
+	botConfig.DNSTTL, _ = cmd.Flags().GetDuration("bot-dns-ttl")
+	
+	// Get click fraud detection configuration
+	abuseConfig := abuse.DefaultConfig()
+	abuseConfig.MaxClientClicks, _ = cmd.Flags().GetInt("abuse-client-clicks")
+	abuseConfig.MaxNetworkClicks, _ = cmd.Flags().GetInt("abuse-network-clicks")
+	abuseConfig.MaxBotClicks, _ = cmd.Flags().GetInt("abuse-bot-clicks")
+	abuseConfig.Window, _ = cmd.Flags().GetDuration("abuse-window")
+	abuseAction, _ := cmd.Flags().GetString("abuse-action")
+	abuseConfig.Action = domain.AbuseAction(abuseAction)
+	abuseConfig.ThrottleClicks, _ = cmd.Flags().GetInt("abuse-throttle-clicks")
+	abuseConfig.FlagDuration, _ = cmd.Flags().GetDuration("abuse-flag-duration")
+	
+	// Get click analytics configuration
+	analyticsConfig := analytics.DefaultConfig()
+	analyticsConfig.FlushInterval, _ = cmd.Flags().GetDuration("analytics-flush-interval")
+	analyticsConfig.MinuteRetention, _ = cmd.Flags().GetDuration("analytics-minute-retention")
+	
+	// Get event stream configuration
+	eventsConfig := service.DefaultEventBusConfig()
+	eventsConfig.MaxSubscribers, _ = cmd.Flags().GetInt("events-max-streams")
+	eventsConfig.Buffer, _ = cmd.Flags().GetInt("events-buffer")
+	
+	// Get event broker configuration
+	brokerConfig := events.DefaultConfig()
+	brokerConfig.Broker, _ = cmd.Flags().GetString("events-broker")
+	brokerConfig.NATSURL, _ = cmd.Flags().GetString("events-nats-url")
+	brokerConfig.NATSSubject, _ = cmd.Flags().GetString("events-nats-subject")
+	brokerConfig.QueueSize, _ = cmd.Flags().GetInt("events-broker-queue")
+	brokerConfig.Timeout, _ = cmd.Flags().GetDuration("events-broker-timeout")
+	
+	// Get event export configuration
+	exportConfig := export.DefaultConfig()
+	exportConfig.Sink, _ = cmd.Flags().GetString("events-export")
+	exportConfig.KafkaBrokers, _ = cmd.Flags().GetStringSlice("events-export-kafka-brokers")
+	exportConfig.KafkaTopic, _ = cmd.Flags().GetString("events-export-kafka-topic")
+	exportConfig.NATSURL, _ = cmd.Flags().GetString("events-export-nats-url")
+	exportConfig.NATSSubject, _ = cmd.Flags().GetString("events-export-nats-subject")
+	exportTypes, _ := cmd.Flags().GetStringSlice("events-export-types")
+	exportConfig.Events = make([]domain.EventType, len(exportTypes))
+	for i, eventType := range exportTypes {
+		exportConfig.Events[i] = domain.EventType(eventType)
+	}
+	exportConfig.BatchSize, _ = cmd.Flags().GetInt("events-export-batch-size")
+	exportConfig.FlushInterval, _ = cmd.Flags().GetDuration("events-export-flush-interval")
+	exportConfig.MaxPending, _ = cmd.Flags().GetInt("events-export-max-pending")
+	exportConfig.Timeout, _ = cmd.Flags().GetDuration("events-export-timeout")
+	
+	// Get peer configuration
+	peersConfig := peers.DefaultConfig()
+	peersConfig.Peers, _ = cmd.Flags().GetStringSlice("peers")
+	peersConfig.Secret, _ = cmd.Flags().GetString("peer-secret")
+	peersConfig.Timeout, _ = cmd.Flags().GetDuration("peer-timeout")
+	peersConfig.QueueSize, _ = cmd.Flags().GetInt("peer-queue-size")
+	
+	// Get tracing configuration
+	tracingConfig := tracing.DefaultConfig()
+	tracingConfig.Exporter, _ = cmd.Flags().GetString("tracing-exporter")
+	tracingConfig.Endpoint, _ = cmd.Flags().GetString("tracing-endpoint")
+	tracingConfig.Headers, _ = cmd.Flags().GetStringSlice("tracing-headers")
+	tracingConfig.ServiceName, _ = cmd.Flags().GetString("tracing-service-name")
+	tracingConfig.SampleRatio, _ = cmd.Flags().GetFloat64("tracing-sample-ratio")
+	tracingConfig.Timeout, _ = cmd.Flags().GetDuration("tracing-timeout")
 	
 	shortenerConfig := shortener.Config{
-		CounterStep: shortenerCounterStep,
+		CounterStep:   shortenerCounterStep,
+		Preallocate:   shortenerCounterPreallocate,
+		CounterShards: shortenerCounterShards,
+		ShardPick:     shortenerShardPick,
+		Obfuscation:   shortenerObfuscation,
+		Secret:        shortenerSecret,
+		Multiplier:    shortenerMultiplier,
+		Salt:          shortenerSalt,
+		Alphabet:      shortenerAlphabet,
+		Length:        shortenerCodeLength,
+		AutoLength:    shortenerAutoLength,
+		MinLength:     shortenerMinLength,
+		GrowAt:        shortenerGrowAt,
+		ReservedCodes: reservedCodes,
+		BlockedWords:  blockedWords,
 	}
 	
 	// Create configuration
-	cfg, err := config.New(port, serverURL, dbPath, syncInterval, verbose, shortenerConfig)
+	cfg, err := config.New(port, serverURL, dbPath, syncInterval, verbose, shortenerConfig,
+		config.WithAuth(auth.Config{
+			APIKeys:          apiKeys,
+			UserAPIKeys:      userAPIKeys,
+			ShareTokenSecret: shareTokenSecret,
+			ShareTokenMaxTTL: shareTokenMaxTTL,
+			OIDC:             oidcConfig,
+		}),
+		config.WithDatabaseDriver(dbDriver),
+		config.WithDatabaseTuning(dbTuning),
+		config.WithCircuitBreaker(dbBreaker),
+		config.WithUsageMerge(domain.UsageMergeStrategy(usageMerge)),
+		config.WithCacheEntryTTL(cacheEntryTTL, cacheRefreshOnAccess),
+		config.WithServeStale(serveStale),
+		config.WithSyncRetry(syncConfig),
+		config.WithCacheShards(cacheShards),
+		config.WithResponseCacheTTL(responseCacheTTL),
+		config.WithClickDedupeWindow(clickDedupeWindow),
+		config.WithClickBuffer(clickConfig),
+		config.WithEventOutbox(outboxConfig),
+		config.WithShutdownTimeouts(shutdownTimeout, shutdownStageTimeout),
+		config.WithTimeouts(timeouts),
+		config.WithTLS(tlsConfig),
+		config.WithRedirects(redirectConfig),
+		config.WithProxies(proxyConfig),
+		config.WithRequestLog(requestLog),
+		config.WithErrorPagesDir(errorPagesDir),
+		config.WithDaemon(pidFile, controlSocket),
+		config.WithListen(listen, adminListen),
+		config.WithWebhooks(webhookConfig),
+		config.WithPolicies(policyConfig),
+		config.WithFailover(failoverConfig),
+		config.WithPreviews(previewConfig),
+		config.WithStorage(storageConfig),
+		config.WithBackup(backupConfig),
+		config.WithArchive(archiveConfig),
+		config.WithRetention(retentionConfig),
+		config.WithPrivacy(privacyConfig),
+		config.WithDestinations(destConfig),
+		config.WithVerify(verifyConfig),
+		config.WithMail(mailConfig),
+		config.WithCDN(cdnConfig),
+		config.WithHosts(hostsConfig),
+		config.WithGeoIP(geoConfig),
+		config.WithBots(botConfig),
+		config.WithAbuse(abuseConfig),
+		config.WithAnalytics(analyticsConfig),
+		config.WithEvents(eventsConfig),
+		config.WithBroker(brokerConfig),
+		config.WithExport(exportConfig),
+		config.WithTracing(tracingConfig),
+		config.WithPeers(peersConfig))
 	if err != nil {
 		return fmt.Errorf("failed to create configuration: %w", err)
 	}
 
-	log.Printf("Starting URL shortener server with config: port=%s", cfg.Server.Port)
+	log.Printf("Starting URL shortener server %s with config: port=%s", version.String(), cfg.Server.Port)
+	startedAt := time.Now()
+
+	// Record the process ID for service managers and "server stop"; removed
+	// once shutdown has finished
+	if cfg.Server.PIDFile != "" {
+		if err := daemon.WritePIDFile(cfg.Server.PIDFile); err != nil {
+			return err
+		}
+		defer daemon.RemovePIDFile(cfg.Server.PIDFile)
+	}
+
 
+	// Background work (cache sync, webhook delivery) lives until shutdown
+	runCtx, stopBackground := context.WithCancel(context.Background())
+	defer stopBackground()
+
+	// Each component registers a shutdown stage once it has started. Stages
+	// run in reverse, so the HTTP server drains first and the database closes
+	// last, and an early return below still stops whatever was started.
+	stageTimeout := cfg.Server.ShutdownStageTimeout
+	var coordinator shutdownCoordinator
+	defer func() {
+		if err := coordinator.shutdown(); err != nil {
+			log.Printf("Shutdown completed with errors: %v", err)
+		}
+	}()
 
 	// Initialize database
-	repo, err := sqlite.New(cfg.Database.Path)
+	repo, err := sqlite.New(cfg.Database.Path,
+		sqlite.WithDriver(cfg.Database.Driver),
+		sqlite.WithTuning(cfg.Database.Tuning))
 	if err != nil {
 		return fmt.Errorf("failed to initialize database: %w", err)
 	}
-	defer func() {
-		if err := repo.Close(); err != nil {
-			log.Printf("Error closing repository: %v", err)
+	log.Printf("Using the %s SQLite driver: busy_timeout=%v synchronous=%s max_open_conns=%d",
+		cfg.Database.Driver, cfg.Database.Tuning.BusyTimeout, cfg.Database.Tuning.Synchronous, cfg.Database.Tuning.MaxOpenConns)
+	coordinator.add("closing database", stageTimeout, func(ctx context.Context) error {
+		return repo.Close()
+	})
+
+	// Start exporting traces; flushed after everything else that can record
+	// spans has stopped
+	tracer, err := tracing.New(cfg.Tracing, version.Version)
+	if err != nil {
+		return fmt.Errorf("failed to create tracer: %w", err)
+	}
+	if tracer != nil {
+		if err := tracer.Start(runCtx); err != nil {
+			return fmt.Errorf("failed to start tracer: %w", err)
 		}
-	}()
+		coordinator.add("flushing traces", stageTimeout, func(ctx context.Context) error {
+			return tracer.Close()
+		})
+		log.Printf("Exporting traces to %s (sample ratio %v)", cfg.Tracing.Endpoint, cfg.Tracing.SampleRatio)
+	}
 
 	// Initialize shortener generator
 	generator, err := shortener.NewGenerator(cfg.Shortener, repo.GetQueries())
 	if err != nil {
 		return fmt.Errorf("failed to create shortener generator: %w", err)
 	}
-	log.Printf("Using %s shortener generator", generator.Type())
+	coordinator.add("releasing counter leases", stageTimeout, func(ctx context.Context) error {
+		return generator.Close()
+	})
+	log.Printf("Using %s shortener generator with %s obfuscation and %d counter shard(s)", generator.Type(), cfg.Shortener.Obfuscation, cfg.Shortener.CounterShards)
+	if cfg.Shortener.Preallocate {
+		if err := shortener.Warm(runCtx, generator); err != nil {
+			return err
+		}
+		log.Printf("Preallocated counter ranges of %d values, refilled in the background once %g is used", cfg.Shortener.CounterStep, shortener.RefillAt)
+	}
+	if cfg.Shortener.AutoLength {
+		log.Printf("Short codes grow from %d to %d characters after %g of each length is used", cfg.Shortener.MinLength, cfg.Shortener.Length, cfg.Shortener.GrowAt)
+	}
 
-	// Initialize cache and service
-	memoryCache := memory.New()
-	urlShortener := service.NewURLShortener(repo, memoryCache, generator)
-	log.Printf("Using in-memory cache")
+	// Initialize webhooks; stored endpoints are loaded when the dispatcher starts
+	var staticEndpoints []*domain.WebhookEndpoint
+	if cfg.Webhooks.EndpointsFile != "" {
+		staticEndpoints, err = webhook.LoadEndpoints(cfg.Webhooks.EndpointsFile)
+		if err != nil {
+			return fmt.Errorf("failed to load webhook endpoints: %w", err)
+		}
+	}
+	dispatcher := webhook.New(cfg.Webhooks, repo, staticEndpoints)
 
-	defer func() {
-		if err := urlShortener.Close(); err != nil {
-			log.Printf("Error closing shortener: %v", err)
+	// Every event the service publishes goes through the bus: webhooks, link
+	// previews, click analytics and live streams subscribe, and a broker can
+	// carry the events out of the process
+	broker, err := events.NewBroker(cfg.Broker)
+	if err != nil {
+		return fmt.Errorf("failed to create event broker: %w", err)
+	}
+	bus := events.New(cfg.Broker, broker)
+	bus.Subscribe(dispatcher)
+
+	// Link previews are fetched for each new link, after it is created
+	var previews *preview.Fetcher
+	if cfg.Previews.Workers > 0 {
+		previews = preview.New(cfg.Previews, repo)
+		bus.Subscribe(previews, domain.EventURLCreated)
+	}
+
+	// Destinations are checked before links are created, or afterwards when
+	// they are only flagged
+	verifier := reachability.New(cfg.Verify, repo, repo, bus)
+	if verifier != nil {
+		bus.Subscribe(verifier, domain.EventURLCreated)
+	}
+
+	// Links that break or are flagged for click fraud are emailed to the
+	// configured recipients
+	alerts := mailer.New(cfg.Mail)
+	if alerts != nil {
+		bus.Subscribe(alerts, domain.EventURLBroken, domain.EventURLFlagged)
+	}
+
+	// Redirects are screened for click fraud; flags are published to the bus
+	abuseDetector := abuse.New(cfg.Abuse, bus)
+	if abuseDetector != nil {
+		log.Printf("Abuse detection enabled (window %v, action %s for %v)", cfg.Abuse.Window, cfg.Abuse.Action, cfg.Abuse.FlagDuration)
+		if cfg.Abuse.MaxNetworkClicks > 0 && cfg.GeoIP.ASNDatabase == "" {
+			log.Printf("Warning: --abuse-network-clicks needs --geoip-asn-db; network spikes are not detected")
 		}
-	}()
+	}
+
+	// Counted clicks are rolled up by referrer and UTM parameters
+	recorder := analytics.New(cfg.Analytics, repo)
+	if recorder != nil {
+		bus.Subscribe(recorder, domain.EventURLClicked)
+	}
+
+	// Live event streams follow every event
+	streams := service.NewEventBus(cfg.Events)
+	if streams != nil {
+		bus.Subscribe(streams)
+	}
+
+	// Created and clicked events can be exported to Kafka or NATS for data pipelines
+	exportSink, err := export.NewSink(cfg.Export)
+	if err != nil {
+		return fmt.Errorf("failed to create event export sink: %w", err)
+	}
+	var exporter *export.Exporter
+	if exportSink != nil {
+		exporter = export.New(cfg.Export, repo, exportSink)
+		bus.Subscribe(exporter, cfg.Export.Events...)
+	}
+
+	// Initialize cache and service
+	memoryCache := memory.New(memory.WithEntryTTL(cfg.Cache.EntryTTL, cfg.Cache.RefreshOnAccess), memory.WithStaleTTL(cfg.Cache.ServeStale), memory.WithShards(cfg.Cache.Shards))
+	responses := response.New(cfg.Cache.ResponseTTL)
+	collisions := &service.CollisionStats{}
+	clickBuffer := service.NewClickBuffer(cfg.Clicks)
+	outbox := service.NewEventOutbox(cfg.Outbox, repo)
+	breaker := service.NewCircuitBreaker(cfg.Database.Breaker)
+	// Background jobs that write on their own are paused while the server is
+	// drained; those created after the service join below
+	drainWriters := &drain.Writers{}
+	drainWriters.Add(recorder, exporter, dispatcher, previews, verifier)
+	broadcaster := peers.New(cfg.Peers)
+	destFilter, err := destinations.New(cfg.Domains, repo)
+	if err != nil {
+		return fmt.Errorf("failed to create destination rules: %w", err)
+	}
+	registry := hosts.New(cfg.Hosts, repo, cfg.Server.ServerURL)
+	purger := cdn.New(cfg.CDN, cfg.Server.ServerURL, func() []string {
+		if registry == nil {
+			return nil
+		}
+		var custom []string
+		for _, d := range registry.Domains() {
+			custom = append(custom, d.Host)
+		}
+		return custom
+	})
+	urlShortener := service.NewURLShortener(repo, memoryCache, generator,
+		service.WithResponseCache(responses),
+		service.WithCollisionStats(collisions),
+		service.WithClickBuffer(clickBuffer),
+		service.WithEventOutbox(outbox),
+		service.WithDrainWriters(drainWriters),
+		service.WithCircuitBreaker(breaker),
+		service.WithServeStale(cfg.Cache.ServeStale > 0),
+		service.WithSyncRetry(cfg.Cache.Sync),
+		service.WithPeers(broadcaster),
+		service.WithCDN(purger),
+		service.WithNotifier(bus),
+		service.WithUsageMerge(cfg.Cache.UsageMerge),
+		service.WithClickDedupe(cfg.Cache.ClickDedupeWindow),
+		service.WithBotClicks(cfg.Bots.Clicks),
+		service.WithBlacklist(cfg.Shortener.Blacklist()),
+		service.WithDestinations(destFilter),
+		service.WithVerifier(verifier),
+		service.WithAbuseGuard(abuseDetector),
+		service.WithDomains(registry),
+		service.WithAliases(repo))
+	if cfg.Cache.EntryTTL > 0 {
+		log.Printf("Using in-memory cache (entry TTL %v, refresh on access: %v)", cfg.Cache.EntryTTL, cfg.Cache.RefreshOnAccess)
+	} else {
+		log.Printf("Using in-memory cache")
+	}
+	if len(cfg.Domains.Allow) > 0 || len(cfg.Domains.Deny) > 0 {
+		log.Printf("Restricting destinations (allowed: %v, denied: %v)", cfg.Domains.Allow, cfg.Domains.Deny)
+	}
+	if outbox != nil {
+		log.Printf("Publishing url.created events from the event outbox (checked every %v)", cfg.Outbox.Interval)
+	}
+	if cfg.Cache.ServeStale > 0 {
+		log.Printf("Serving cached links up to %v past their TTL while the database is failing", cfg.Cache.ServeStale)
+	}
+	if clickBuffer != nil && cfg.Clicks.Async {
+		log.Printf("Counting clicks asynchronously, buffering up to %d and dropping the rest", cfg.Clicks.Size)
+	} else if clickBuffer != nil {
+		log.Printf("Buffering up to %d clicks of uncapped links (overflow: %s)", cfg.Clicks.Size, cfg.Clicks.Overflow)
+	}
+	if cfg.Cache.ResponseTTL > 0 {
+		log.Printf("Caching link info, link list and storage responses for %v", cfg.Cache.ResponseTTL)
+	}
+	if cfg.Cache.ClickDedupeWindow > 0 {
+		log.Printf("Counting one click per visitor and link every %v", cfg.Cache.ClickDedupeWindow)
+	}
 
 	// Initialize cache with existing data
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := context.WithTimeout(runCtx, 30*time.Second)
 	defer cancel()
 
 	if err := urlShortener.InitializeCache(ctx); err != nil {
 		return fmt.Errorf("failed to initialize cache: %w", err)
 	}
 
-	// Start cache synchronization
-	if err := urlShortener.StartCacheSync(ctx, cfg.Cache.SyncInterval); err != nil {
-		return fmt.Errorf("failed to start cache sync: %w", err)
+	// Load destination rules added through the admin API and keep them in
+	// sync with other instances
+	if err := destFilter.Start(ctx); err != nil {
+		return fmt.Errorf("failed to load destination rules: %w", err)
 	}
-	defer func() {
-		if err := urlShortener.StopCacheSync(); err != nil {
-			log.Printf("Error stopping cache sync: %v", err)
+	coordinator.add("stopping destination rules", stageTimeout, func(ctx context.Context) error {
+		return destFilter.Close()
+	})
+
+	// Load the custom domains links are served on and keep them in sync with
+	// other instances
+	if err := registry.Start(ctx); err != nil {
+		return fmt.Errorf("failed to load custom domains: %w", err)
+	}
+	coordinator.add("stopping custom domains", stageTimeout, func(ctx context.Context) error {
+		return registry.Close()
+	})
+	if registry != nil {
+		log.Printf("Serving links on %d custom domains (refreshed every %v)", len(registry.Domains()), cfg.Hosts.Refresh)
+	}
+
+	// Start publishing to the event broker; closed once every other component
+	// has stopped publishing
+	if err := bus.Start(ctx); err != nil {
+		return fmt.Errorf("failed to start event bus: %w", err)
+	}
+	coordinator.add("closing event broker", stageTimeout, func(ctx context.Context) error {
+		return bus.Close()
+	})
+	if bus.Brokered() {
+		log.Printf("Publishing events to %s (%s, subject prefix %s)", cfg.Broker.Broker, cfg.Broker.NATSURL, cfg.Broker.NATSSubject)
+	}
+
+	// Start exporting events; on shutdown the buffered events are written to
+	// the outbox and sent once more, and anything unsent is exported on the
+	// next start
+	if exporter != nil {
+		if err := exporter.Start(ctx); err != nil {
+			return fmt.Errorf("failed to start event exporter: %w", err)
 		}
-	}()
+		coordinator.add("exporting events", stageTimeout, func(ctx context.Context) error {
+			return exporter.Close()
+		})
+		log.Printf("Exporting %v events to %s in batches of %d", cfg.Export.Events, cfg.Export.Sink, cfg.Export.BatchSize)
+	}
 
+	// Start telling peers about changed links; the queued invalidations are
+	// sent once lifecycle policies and failover checks stop changing links
+	if err := broadcaster.Start(ctx); err != nil {
+		return fmt.Errorf("failed to start peer invalidations: %w", err)
+	}
+	coordinator.add("sending peer invalidations", stageTimeout, func(ctx context.Context) error {
+		return broadcaster.Close()
+	})
+	if broadcaster != nil {
+		log.Printf("Sending cache invalidations to %d peers", len(cfg.Peers.Peers))
+	}
+	if cfg.Peers.Secret != "" {
+		log.Printf("Accepting signed cache invalidations at %s", peers.Path)
+	}
 
-	// Create and start HTTP server
-	server := httpTransport.NewServer(urlShortener, cfg.Server.Port, cfg.Server.ServerURL, cfg.Logging.Verbose)
+	// Start CDN purging; like peer invalidations, the queued purges are sent
+	// once nothing is left to change links
+	if purger != nil {
+		if err := purger.Start(); err != nil {
+			return fmt.Errorf("failed to start CDN purger: %w", err)
+		}
+		coordinator.add("stopping CDN purger", stageTimeout, func(ctx context.Context) error {
+			return purger.Close()
+		})
+		log.Printf("Purging changed links from the CDN via %s (%s API, %d attempts)", cfg.CDN.PurgeURL, cfg.CDN.Provider, cfg.CDN.MaxAttempts)
+	}
 
-	// Set up graceful shutdown
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	// Start webhook delivery; stopped after the final cache sync, before the database closes
+	if err := dispatcher.Start(ctx); err != nil {
+		return fmt.Errorf("failed to start webhook dispatcher: %w", err)
+	}
+	coordinator.add("stopping webhook delivery", stageTimeout, func(ctx context.Context) error {
+		return dispatcher.Close()
+	})
+	log.Printf("Webhooks enabled (%d stored endpoints, %d from config file)", len(dispatcher.ListEndpoints()), len(staticEndpoints))
 
-	// Start server in a goroutine
-	errChan := make(chan error, 1)
-	go func() {
-		errChan <- server.Start()
-	}()
+	// Start link preview fetching; stopped before the database closes
+	if previews != nil {
+		if err := previews.Start(ctx); err != nil {
+			return fmt.Errorf("failed to start preview fetcher: %w", err)
+		}
+		coordinator.add("stopping preview fetches", stageTimeout, func(ctx context.Context) error {
+			return previews.Close()
+		})
+		log.Printf("Link previews enabled (%d workers, timeout %v)", cfg.Previews.Workers, cfg.Previews.Timeout)
+	}
 
-	// Wait for shutdown signal or server error
-	select {
-	case err := <-errChan:
-		if err != nil {
-			return fmt.Errorf("server error: %w", err)
+	// Start broken link emails; stopped after destination verification, which
+	// publishes the alerts
+	if alerts != nil {
+		if err := alerts.Start(); err != nil {
+			return fmt.Errorf("failed to start broken link emails: %w", err)
 		}
-	case sig := <-sigChan:
-		log.Printf("Received signal %v, shutting down gracefully...", sig)
-		
-		// Create shutdown context with timeout
-		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 30*time.Second)
-		defer shutdownCancel()
-		
-		// Shutdown server
-		if err := server.Shutdown(shutdownCtx); err != nil {
-			log.Printf("Error during server shutdown: %v", err)
+		coordinator.add("stopping broken link emails", stageTimeout, func(ctx context.Context) error {
+			return alerts.Close()
+		})
+		log.Printf("Emailing broken link alerts to %s via %s", strings.Join(cfg.Mail.To, ", "), cfg.Mail.Addr)
+		if !cfg.Verify.Enabled() {
+			log.Printf("Warning: broken link alerts need --verify-destinations to find broken links")
 		}
 	}
 
-	log.Println("Server stopped")
-	return nil
-}
+	// Start destination verification; stopped before the database closes
+	if verifier != nil {
+		if err := verifier.Start(ctx); err != nil {
+			return fmt.Errorf("failed to start destination verification: %w", err)
+		}
+		coordinator.add("stopping destination verification", stageTimeout, func(ctx context.Context) error {
+			return verifier.Close()
+		})
+		log.Printf("Verifying link destinations (%s, timeout %v, re-checked every %v)", cfg.Verify.Mode, cfg.Verify.Timeout, cfg.Verify.Interval)
+	}
 
-func runCreateURL(cmd *cobra.Command, args []string) error {
-	serverURL, _ := cmd.Flags().GetString("server-url")
-	c := client.NewClient(serverURL)
-	commands := client.NewCommands(c)
-	
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-	
-	return commands.Create(ctx, args[0])
-}
+	// Start cache synchronization; stopping it runs a final sync of pending usage
+	if err := urlShortener.StartCacheSync(runCtx, cfg.Cache.SyncInterval); err != nil {
+		return fmt.Errorf("failed to start cache sync: %w", err)
+	}
+	coordinator.add("final cache sync", stageTimeout, func(ctx context.Context) error {
+		return urlShortener.StopCacheSync()
+	})
 
-func runGetURL(cmd *cobra.Command, args []string) error {
-	serverURL, _ := cmd.Flags().GetString("server-url")
-	c := client.NewClient(serverURL)
-	commands := client.NewCommands(c)
-	
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-	
-	return commands.Get(ctx, args[0])
-}
+	// Start click analytics; stopping them flushes the clicks recorded since the last flush
+	if recorder != nil {
+		if err := recorder.Start(ctx); err != nil {
+			return fmt.Errorf("failed to start click analytics: %w", err)
+		}
+		coordinator.add("flushing click analytics", stageTimeout, func(ctx context.Context) error {
+			return recorder.Close()
+		})
+		log.Printf("Click analytics enabled (flushed every %v)", cfg.Analytics.FlushInterval)
+	}
 
-func runDeleteURL(cmd *cobra.Command, args []string) error {
-	serverURL, _ := cmd.Flags().GetString("server-url")
-	c := client.NewClient(serverURL)
-	commands := client.NewCommands(c)
-	
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-	
-	return commands.Delete(ctx, args[0])
-}
+	// Start lifecycle policies; stopped before the final cache sync so no
+	// scheduled run deletes links after usage has been persisted
+	var staticPolicies []*domain.LifecyclePolicy
+	if cfg.Policies.File != "" {
+		staticPolicies, err = policy.LoadPolicies(cfg.Policies.File)
+		if err != nil {
+			return fmt.Errorf("failed to load lifecycle policies: %w", err)
+		}
+	}
+	policies := policy.New(cfg.Policies, repo, urlShortener, staticPolicies)
+	drainWriters.Add(policies)
+	if err := policies.Start(ctx); err != nil {
+		return fmt.Errorf("failed to start lifecycle policies: %w", err)
+	}
+	coordinator.add("stopping lifecycle policies", stageTimeout, func(ctx context.Context) error {
+		return policies.Close()
+	})
+	if cfg.Policies.Interval > 0 {
+		log.Printf("Lifecycle policies enabled (%d policies, every %v, dry run: %t)", len(policies.Policies()), cfg.Policies.Interval, cfg.Policies.DryRun)
+	}
 
-func runListURLs(cmd *cobra.Command, args []string) error {
-	serverURL, _ := cmd.Flags().GetString("server-url")
-	c := client.NewClient(serverURL)
-	commands := client.NewCommands(c)
-	
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-	
-	return commands.List(ctx)
-}
+	// Start destination health checks for links with a backup URL
+	failoverMonitor := failover.New(cfg.Failover, urlShortener)
+	if err := failoverMonitor.Start(ctx); err != nil {
+		return fmt.Errorf("failed to start failover monitor: %w", err)
+	}
+	coordinator.add("stopping failover monitor", stageTimeout, func(ctx context.Context) error {
+		return failoverMonitor.Close()
+	})
+	if cfg.Failover.Interval > 0 {
+		log.Printf("Failover health checks enabled (every %v, fail after %d, recover after %d)", cfg.Failover.Interval, cfg.Failover.FailureThreshold, cfg.Failover.RecoveryThreshold)
+	}
 
-func main() {
-	if err := rootCmd.Execute(); err != nil {
-		log.Fatal(err)
+
+	// Start database backups
+	var backups *backup.Manager
+	if cfg.Backup.Dir != "" {
+		backups = backup.New(cfg.Backup, repo)
+		if err := backups.Start(ctx); err != nil {
+			return fmt.Errorf("failed to start backups: %w", err)
+		}
+		coordinator.add("stopping backups", stageTimeout, func(ctx context.Context) error {
+			return backups.Close()
+		})
+		if cfg.Backup.Interval > 0 {
+			log.Printf("Database backups enabled (to %s every %v, keeping %d)", cfg.Backup.Dir, cfg.Backup.Interval, cfg.Backup.Keep)
+		} else {
+			log.Printf("Database backups enabled on demand (to %s, keeping %d)", cfg.Backup.Dir, cfg.Backup.Keep)
+		}
+	}
+
+	// Start archiving old clicks
+	if cfg.Archive.Enabled() {
+		uploader, err := archive.NewS3(cfg.Archive)
+		if err != nil {
+			return fmt.Errorf("failed to initialize click archive: %w", err)
+		}
+		archiver := archive.New(cfg.Archive, repo, uploader)
+		drainWriters.Add(archiver)
+		if err := archiver.Start(ctx); err != nil {
+			return fmt.Errorf("failed to start click archive: %w", err)
+		}
+		coordinator.add("stopping click archive", stageTimeout, func(ctx context.Context) error {
+			return archiver.Close()
+		})
+		log.Printf("Archiving clicks older than %v to bucket %s every %v", cfg.Archive.After, cfg.Archive.Bucket, cfg.Archive.Interval)
+	}
+
+	// Initialize authentication
+	authenticator, err := auth.New(cfg.Auth)
+	if err != nil {
+		return fmt.Errorf("failed to initialize authentication: %w", err)
+	}
+	if len(cfg.Auth.APIKeys) > 0 || len(cfg.Auth.UserAPIKeys) > 0 {
+		log.Printf("API key authentication enabled (%d admin keys, %d user keys)", len(cfg.Auth.APIKeys), len(cfg.Auth.UserAPIKeys))
+	} else {
+		log.Printf("API key authentication disabled")
+	}
+	if cfg.Auth.OIDC.Enabled() {
+		log.Printf("OIDC authentication enabled (issuer %s)", cfg.Auth.OIDC.Issuer)
+	}
+	if cfg.Auth.ShareTokenSecret == "" {
+		log.Printf("No share token secret configured; share tokens will not survive a restart")
+	}
+
+	// Load the GeoIP database for country routing rules and click analytics
+	locator, err := geoip.New(cfg.GeoIP)
+	if err != nil {
+		return fmt.Errorf("failed to initialize GeoIP: %w", err)
+	}
+	if loaded := locator.Database(); loaded != "" {
+		log.Printf("Loaded %s from %s", loaded, cfg.GeoIP.Database)
+	}
+	if loaded := locator.Networks(); loaded != "" {
+		log.Printf("Loaded %s from %s", loaded, cfg.GeoIP.ASNDatabase)
+	}
+	if cfg.GeoIP.CountryHeader != "" {
+		log.Printf("Trusting visitor country from the %s header", cfg.GeoIP.CountryHeader)
+	}
+
+	// Recognize crawlers by reverse DNS as well as by User-Agent
+	botDetector := bots.New(cfg.Bots)
+	if cfg.Bots.Clicks != domain.BotClicksCount {
+		log.Printf("Bot clicks: %s", cfg.Bots.Clicks)
+	}
+	if cfg.Bots.VerifyDNS {
+		log.Printf("Verifying crawlers by reverse DNS (%d domains, timeout %v, cached %v)", len(cfg.Bots.Domains), cfg.Bots.DNSTimeout, cfg.Bots.DNSTTL)
+	}
+
+	// Enforce data retention; visitor addresses can be purged on request even without it
+	retentionEnforcer := retention.New(cfg.Retention, repo, urlShortener, botDetector)
+	drainWriters.Add(retentionEnforcer)
+	if err := retentionEnforcer.Start(ctx); err != nil {
+		return fmt.Errorf("failed to start data retention: %w", err)
+	}
+	coordinator.add("stopping data retention", stageTimeout, func(ctx context.Context) error {
+		return retentionEnforcer.Close()
+	})
+	if cfg.Retention.Enabled() {
+		log.Printf("Data retention enabled (clicks %v, IP addresses %v, every %v)", cfg.Retention.ClickRetention, cfg.Retention.AddressRetention, cfg.Retention.Interval)
+	}
+
+	// Anonymize visitor addresses before they are remembered or logged
+	anonymizer := privacy.New(cfg.Privacy)
+	switch cfg.Privacy.Mode {
+	case privacy.ModeTruncate:
+		log.Printf("Anonymizing visitor addresses to /%d (IPv4) and /%d (IPv6) networks", cfg.Privacy.IPv4Prefix, cfg.Privacy.IPv6Prefix)
+	case privacy.ModeHash:
+		log.Printf("Anonymizing visitor addresses by keyed hash (key rotated every %v)", cfg.Privacy.KeyRotation)
+	}
+
+	// Describe this build and configuration for /api/version
+	versionInfo := version.Info()
+	versionInfo.Storage = "sqlite"
+	versionInfo.Cache = "memory"
+	versionInfo.Generator = generator.Type()
+	versionInfo.Features = []string{"max_uses", "share_tokens", "webhooks", "tags", "lifecycle_policies", "redirect_status", "failover", "templates", "query_params", "routing", "usage_merge_" + string(cfg.Cache.UsageMerge)}
+	if len(cfg.Auth.APIKeys) > 0 || len(cfg.Auth.UserAPIKeys) > 0 {
+		versionInfo.Features = append(versionInfo.Features, "api_key_auth")
+	}
+	if len(cfg.Auth.UserAPIKeys) > 0 {
+		versionInfo.Features = append(versionInfo.Features, "link_ownership")
+	}
+	if cfg.Auth.OIDC.Enabled() {
+		versionInfo.Features = append(versionInfo.Features, "oidc")
+	}
+	if cfg.Server.TLS.Enabled() {
+		versionInfo.Features = append(versionInfo.Features, "tls")
+	}
+	if cfg.Previews.Workers > 0 {
+		versionInfo.Features = append(versionInfo.Features, "link_previews")
+	}
+	if cfg.Verify.Enabled() {
+		versionInfo.Features = append(versionInfo.Features, "destination_verification")
+	}
+	if cfg.Mail.Enabled() {
+		versionInfo.Features = append(versionInfo.Features, "broken_link_emails")
+	}
+	if cfg.Abuse.Enabled() {
+		versionInfo.Features = append(versionInfo.Features, "abuse_detection")
+	}
+	if cfg.Hosts.Enabled {
+		versionInfo.Features = append(versionInfo.Features, "custom_domains")
+	}
+	if cfg.GeoIP.Enabled() {
+		versionInfo.Features = append(versionInfo.Features, "geoip")
+	}
+	if cfg.Analytics.FlushInterval > 0 {
+		versionInfo.Features = append(versionInfo.Features, "click_analytics")
+	}
+	if cfg.Events.MaxSubscribers > 0 {
+		versionInfo.Features = append(versionInfo.Features, "event_stream")
+	}
+	if bus.Brokered() {
+		versionInfo.Features = append(versionInfo.Features, "event_broker_"+cfg.Broker.Broker)
+	}
+	if exporter != nil {
+		versionInfo.Features = append(versionInfo.Features, "event_export_"+cfg.Export.Sink)
+	}
+	if clickBuffer != nil {
+		versionInfo.Features = append(versionInfo.Features, "click_buffer")
+	}
+	if broadcaster != nil || cfg.Peers.Secret != "" {
+		versionInfo.Features = append(versionInfo.Features, "peer_invalidation")
+	}
+	if tracer != nil {
+		versionInfo.Features = append(versionInfo.Features, "tracing_"+cfg.Tracing.Exporter)
+	}
+
+	// Report storage usage, surfacing quota warnings at startup
+	storageReporter := storage.New(cfg.Storage, repo)
+	if report, err := storageReporter.Report(ctx); err != nil {
+		log.Printf("Warning: failed to measure storage: %v", err)
+	} else {
+		for _, warning := range report.Warnings {
+			log.Printf("Storage warning: %s", warning)
+		}
+	}
+
+	errorPages, err := httpTransport.LoadErrorPages(cfg.Server.ErrorPagesDir)
+	if err != nil {
+		return fmt.Errorf("failed to load error pages: %w", err)
+	}
+	if cfg.Server.ErrorPagesDir != "" {
+		log.Printf("Error pages loaded from %s", cfg.Server.ErrorPagesDir)
+	}
+
+	// Serve on the sockets systemd passed with socket activation in place of
+	// the port, plus every --listen address
+	listeners, err := daemon.Listeners()
+	if err != nil {
+		return err
+	}
+	socketActivated := len(listeners) > 0
+	for _, listener := range listeners {
+		log.Printf("Using socket-activated listener %s", listener.Addr())
+	}
+	if !socketActivated {
+		listener, err := net.Listen("tcp", ":"+cfg.Server.Port)
+		if err != nil {
+			return fmt.Errorf("failed to listen on port %s: %w", cfg.Server.Port, err)
+		}
+		listeners = append(listeners, listener)
+	}
+	var adminListeners []net.Listener
+	closeListeners := func() {
+		for _, opened := range append(listeners, adminListeners...) {
+			opened.Close()
+		}
+	}
+	for _, address := range cfg.Server.Listen {
+		listener, err := daemon.OpenListener(address)
+		if err != nil {
+			closeListeners()
+			return err
+		}
+		listeners = append(listeners, listener)
+	}
+	for _, address := range cfg.Server.AdminListen {
+		listener, err := daemon.OpenListener(address)
+		if err != nil {
+			closeListeners()
+			return err
+		}
+		adminListeners = append(adminListeners, listener)
+	}
+
+	// Create and start HTTP server
+	server := httpTransport.NewServer(urlShortener, cfg.Server.Port, cfg.Server.ServerURL, cfg.Logging.Verbose,
+		httpTransport.WithAuthenticator(authenticator),
+		httpTransport.WithWebhooks(dispatcher),
+		httpTransport.WithPolicies(policies),
+		httpTransport.WithPreviews(previews),
+		httpTransport.WithStorage(storageReporter),
+		httpTransport.WithBackups(backups),
+		httpTransport.WithRetention(retentionEnforcer),
+		httpTransport.WithPrivacy(anonymizer),
+		httpTransport.WithDestinations(destFilter),
+		httpTransport.WithVerifier(verifier),
+		httpTransport.WithAbuseDetector(abuseDetector),
+		httpTransport.WithHosts(registry),
+		httpTransport.WithResponseCache(responses),
+		httpTransport.WithCollisionStats(collisions),
+		httpTransport.WithCounters(shortener.Counters(generator)),
+		httpTransport.WithClickBuffer(clickBuffer),
+		httpTransport.WithCircuitBreaker(breaker),
+		httpTransport.WithPeers(cfg.Peers.Secret, broadcaster),
+		httpTransport.WithVersion(versionInfo),
+		httpTransport.WithTLS(cfg.Server.TLS),
+		httpTransport.WithListeners(listeners...),
+		httpTransport.WithAdminListeners(adminListeners...),
+		httpTransport.WithRedirects(cfg.Server.Redirects),
+		httpTransport.WithProxies(cfg.Server.Proxies),
+		httpTransport.WithRequestLog(cfg.Logging.Requests),
+		httpTransport.WithTimeouts(cfg.Server.Timeouts),
+		httpTransport.WithErrorPages(errorPages),
+		httpTransport.WithGeoIP(locator),
+		httpTransport.WithBotDetector(botDetector),
+		httpTransport.WithAnalytics(recorder),
+		httpTransport.WithEventBus(streams),
+		httpTransport.WithEventMetrics(bus),
+		httpTransport.WithExporter(exporter),
+		httpTransport.WithTracer(tracer))
+
+	// Reload the lifecycle policies file and TLS certificate files on SIGHUP
+	// or "server reload", keeping the old ones when a file is invalid
+	reload := func(ctx context.Context) (daemon.ReloadResult, error) {
+		daemon.Notify(daemon.NotifyReloading)
+		defer daemon.Notify(daemon.NotifyReady)
+
+		result := daemon.ReloadResult{Reloaded: []string{}}
+		var errs []error
+		if cfg.Policies.File != "" {
+			loaded, err := policy.LoadPolicies(cfg.Policies.File)
+			if err == nil {
+				err = policies.ReplaceFilePolicies(loaded)
+			}
+			if err != nil {
+				errs = append(errs, fmt.Errorf("failed to reload lifecycle policies: %w", err))
+			} else {
+				result.Reloaded = append(result.Reloaded, "lifecycle policies")
+			}
+		}
+		if cfg.Server.TLS.CertFile != "" {
+			if err := server.ReloadCertificate(); err != nil {
+				errs = append(errs, err)
+			} else {
+				result.Reloaded = append(result.Reloaded, "TLS certificate")
+			}
+		}
+		log.Printf("Reloaded: %v", result.Reloaded)
+		return result, errors.Join(errs...)
+	}
+
+	// Answer "server status", "server reload" and "server stop"; the socket
+	// closes after the HTTP drain, so status works while requests finish
+	stopRequested := make(chan struct{}, 1)
+	if cfg.Server.ControlSocket != "" {
+		control, err := daemon.Listen(cfg.Server.ControlSocket, daemon.Controls{
+			Status: func(ctx context.Context) (daemon.Status, error) {
+				return daemon.Status{
+					PID:             os.Getpid(),
+					Version:         version.Version,
+					Commit:          version.Commit,
+					Address:         server.Address(),
+					AdminAddress:    server.AdminAddress(),
+					TLS:             cfg.Server.TLS.Enabled(),
+					SocketActivated: socketActivated,
+					StartedAt:       startedAt,
+					Uptime:          time.Since(startedAt).Round(time.Second).String(),
+					Health:          string(urlShortener.CheckHealth(ctx).Status),
+				}, nil
+			},
+			Reload: reload,
+			Stop: func() {
+				select {
+				case stopRequested <- struct{}{}:
+				default:
+				}
+			},
+		})
+		if err != nil {
+			return err
+		}
+		coordinator.add("closing control socket", stageTimeout, func(ctx context.Context) error {
+			return control.Close()
+		})
+		log.Printf("Control socket listening on %s", cfg.Server.ControlSocket)
+	}
+
+	// Set up graceful shutdown
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	hupChan := make(chan os.Signal, 1)
+	signal.Notify(hupChan, syscall.SIGHUP)
+
+	// Start server in a goroutine
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- server.Start()
+	}()
+	coordinator.add("draining HTTP requests", cfg.Server.DrainTimeout, server.Shutdown)
+	if err := daemon.Notify(daemon.NotifyReady); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+
+	// Wait for shutdown signal, stop request or server error, reloading on SIGHUP
+wait:
+	for {
+		select {
+		case err := <-errChan:
+			if err != nil {
+				return fmt.Errorf("server error: %w", err)
+			}
+			break wait
+		case <-hupChan:
+			log.Printf("Received SIGHUP, reloading...")
+			if _, err := reload(runCtx); err != nil {
+				log.Printf("Reload failed: %v", err)
+			}
+		case <-stopRequested:
+			log.Printf("Stop requested on the control socket, shutting down gracefully...")
+			break wait
+		case sig := <-sigChan:
+			log.Printf("Received signal %v, shutting down gracefully...", sig)
+			break wait
+		}
+	}
+	daemon.Notify(daemon.NotifyStopping)
+
+	if err := coordinator.shutdown(); err != nil {
+		log.Printf("Shutdown completed with errors: %v", err)
+	}
+	log.Println("Server stopped")
+	return nil
+}
+
+func runCreateURL(cmd *cobra.Command, args []string) error {
+	commands, err := newCommands(cmd)
+	if err != nil {
+		return err
+	}
+	
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	
+	return commands.Create(ctx, args[0], createOptionsFromFlags(cmd))
+}
+
+func runValidateURL(cmd *cobra.Command, args []string) error {
+	commands, err := newCommands(cmd)
+	if err != nil {
+		return err
+	}
+	
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	
+	return commands.Validate(ctx, args[0], createOptionsFromFlags(cmd))
+}
+
+// createOptionsFromFlags reads the link options shared by create and validate
+func createOptionsFromFlags(cmd *cobra.Command) domain.CreateOptions {
+	maxUses, _ := cmd.Flags().GetInt("max-uses")
+	tags, _ := cmd.Flags().GetStringSlice("tag")
+	redirectStatus, _ := cmd.Flags().GetInt("redirect-status")
+	reuse, _ := cmd.Flags().GetBool("reuse")
+	backupURL, _ := cmd.Flags().GetString("backup-url")
+	queryParams, _ := cmd.Flags().GetStringToString("param")
+	forwardQuery, _ := cmd.Flags().GetBool("forward-query")
+	campaign, _ := cmd.Flags().GetString("campaign")
+	dedupeSeconds, _ := cmd.Flags().GetInt("dedupe-seconds")
+	customDomain, _ := cmd.Flags().GetString("domain")
+	burn, _ := cmd.Flags().GetBool("burn-after-reading")
+	return domain.CreateOptions{
+		MaxUses:          maxUses,
+		Tags:             tags,
+		RedirectStatus:   redirectStatus,
+		ReuseExisting:    reuse,
+		BackupURL:        backupURL,
+		QueryParams:      queryParams,
+		ForwardQuery:     forwardQuery,
+		Campaign:         campaign,
+		DedupeSeconds:    dedupeSeconds,
+		Domain:           customDomain,
+		BurnAfterReading: burn,
+	}
+}
+
+func runGetURL(cmd *cobra.Command, args []string) error {
+	commands, err := newCommands(cmd)
+	if err != nil {
+		return err
+	}
+	
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	
+	return commands.Get(ctx, args[0])
+}
+
+func runDeleteURL(cmd *cobra.Command, args []string) error {
+	commands, err := newCommands(cmd)
+	if err != nil {
+		return err
+	}
+	
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	
+	file, _ := cmd.Flags().GetString("file")
+	if (file == "") == (len(args) == 0) {
+		return fmt.Errorf("give either a short code or --file")
+	}
+	if file == "" {
+		return commands.Delete(ctx, args[0])
+	}
+
+	input := os.Stdin
+	if file != "-" {
+		if input, err = os.Open(file); err != nil {
+			return fmt.Errorf("failed to open short code file: %w", err)
+		}
+		defer input.Close()
+	}
+	shortCodes, err := client.ReadShortCodes(input)
+	if err != nil {
+		return err
+	}
+	if len(shortCodes) == 0 {
+		return fmt.Errorf("no short codes in %s", file)
+	}
+	return commands.DeleteMany(ctx, shortCodes)
+}
+
+func runPrune(cmd *cobra.Command, args []string) error {
+	var prune domain.PruneRequest
+	if value, _ := cmd.Flags().GetString("unused-for"); value != "" {
+		age, err := domain.ParseAge(value)
+		if err != nil {
+			return fmt.Errorf("invalid --unused-for: %w", err)
+		}
+		prune.UnusedFor = age
+	}
+	if value, _ := cmd.Flags().GetString("created-before"); value != "" {
+		createdBefore, err := time.ParseInLocation(time.DateOnly, value, time.Local)
+		if err != nil {
+			if createdBefore, err = parseTimeOrAge(value); err != nil {
+				return fmt.Errorf("invalid --created-before: %w", err)
+			}
+		}
+		prune.CreatedBefore = createdBefore
+	}
+	if prune.Empty() {
+		return fmt.Errorf("give --unused-for, --created-before or both")
+	}
+	prune.DryRun, _ = cmd.Flags().GetBool("dry-run")
+
+	commands, err := newCommands(cmd)
+	if err != nil {
+		return err
+	}
+	
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+	
+	var confirm func(matches int) bool
+	if yes, _ := cmd.Flags().GetBool("yes"); !yes {
+		confirm = func(matches int) bool {
+			fmt.Fprintf(os.Stderr, "Delete these %d links? [y/N] ", matches)
+			answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+			answer = strings.ToLower(strings.TrimSpace(answer))
+			return answer == "y" || answer == "yes"
+		}
+	}
+	return commands.Prune(ctx, prune, confirm)
+}
+
+func runListURLs(cmd *cobra.Command, args []string) error {
+	campaign, _ := cmd.Flags().GetString("campaign")
+	owner, _ := cmd.Flags().GetString("owner")
+	commands, err := newCommands(cmd)
+	if err != nil {
+		return err
+	}
+	
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	
+	if campaign != "" {
+		return commands.ListCampaign(ctx, campaign)
+	}
+	if owner != "" {
+		return commands.ListOwner(ctx, owner)
+	}
+	return commands.List(ctx)
+}
+
+func runListCampaigns(cmd *cobra.Command, args []string) error {
+	commands, err := newCommands(cmd)
+	if err != nil {
+		return err
+	}
+	
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	
+	return commands.Campaigns(ctx)
+}
+
+func runStats(cmd *cobra.Command, args []string) error {
+	commands, err := newCommands(cmd)
+	if err != nil {
+		return err
+	}
+	
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	
+	if len(args) == 0 {
+		return commands.Stats(ctx)
+	}
+	limit, _ := cmd.Flags().GetInt("limit")
+	if countries, _ := cmd.Flags().GetBool("countries"); countries {
+		return commands.Countries(ctx, args[0], limit)
+	}
+	if referrers, _ := cmd.Flags().GetBool("referrers"); referrers {
+		return commands.Referrers(ctx, args[0], limit)
+	}
+	return commands.CodeStats(ctx, args[0], limit)
+}
+
+func runTimeSeries(cmd *cobra.Command, args []string) error {
+	interval, _ := cmd.Flags().GetString("interval")
+	if interval != "" && domain.TimeSeriesInterval(interval).Duration() == 0 {
+		return fmt.Errorf("invalid --interval %q: must be minute, hour or day", interval)
+	}
+	var from, to time.Time
+	for name, t := range map[string]*time.Time{"from": &from, "to": &to} {
+		value, _ := cmd.Flags().GetString(name)
+		if value == "" {
+			continue
+		}
+		parsed, err := parseTimeOrAge(value)
+		if err != nil {
+			return fmt.Errorf("invalid --%s: %w", name, err)
+		}
+		*t = parsed
+	}
+
+	commands, err := newCommands(cmd)
+	if err != nil {
+		return err
+	}
+	
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	
+	return commands.TimeSeries(ctx, args[0], domain.TimeSeriesInterval(interval), from, to)
+}
+
+// parseTimeOrAge parses an RFC 3339 time, or an age such as "7d" meaning that
+// long ago
+func parseTimeOrAge(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	age, err := domain.ParseAge(value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q is neither an RFC 3339 time nor an age such as 7d", value)
+	}
+	return time.Now().Add(-time.Duration(age)), nil
+}
+
+func runTop(cmd *cobra.Command, args []string) error {
+	var window time.Duration
+	if value, _ := cmd.Flags().GetString("window"); value != "" {
+		age, err := domain.ParseAge(value)
+		if err != nil {
+			return fmt.Errorf("invalid --window: %w", err)
+		}
+		window = time.Duration(age)
+	}
+	limit, _ := cmd.Flags().GetInt("limit")
+	trending, _ := cmd.Flags().GetBool("trending")
+
+	commands, err := newCommands(cmd)
+	if err != nil {
+		return err
+	}
+	
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	
+	return commands.Top(ctx, window, limit, trending)
+}
+
+func runVersion(cmd *cobra.Command, args []string) error {
+	commands, err := newCommands(cmd)
+	if err != nil {
+		return err
+	}
+	
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	
+	return commands.Version(ctx)
+}
+
+func runShareToken(cmd *cobra.Command, args []string) error {
+	ttl, _ := cmd.Flags().GetDuration("ttl")
+	commands, err := newCommands(cmd)
+	if err != nil {
+		return err
+	}
+	
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	
+	return commands.ShareToken(ctx, args[0], ttl)
+}
+
+func runCacheStats(cmd *cobra.Command, args []string) error {
+	commands, err := newCommands(cmd)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	return commands.CacheStats(ctx)
+}
+
+func runCacheGet(cmd *cobra.Command, args []string) error {
+	commands, err := newCommands(cmd)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	return commands.CacheEntry(ctx, args[0])
+}
+
+func runCacheFlush(cmd *cobra.Command, args []string) error {
+	commands, err := newCommands(cmd)
+	if err != nil {
+		return err
+	}
+
+	// A flush writes every unsynced entry, which takes longer than a lookup
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	return commands.FlushCache(ctx)
+}
+
+func runCacheInvalidate(cmd *cobra.Command, args []string) error {
+	commands, err := newCommands(cmd)
+	if err != nil {
+		return err
+	}
+
+	// An entry with unsynced usage is flushed before it is dropped
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	return commands.InvalidateCache(ctx, args[0])
+}
+
+func runSync(cmd *cobra.Command, args []string) error {
+	commands, err := newCommands(cmd)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	return commands.SyncNow(ctx)
+}
+
+func runDrain(cmd *cobra.Command, args []string) error {
+	end, _ := cmd.Flags().GetBool("end")
+	commands, err := newCommands(cmd)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if end {
+		return commands.EndDrain(ctx)
+	}
+	return commands.Drain(ctx)
+}
+
+func runShell(cmd *cobra.Command, args []string) error {
+	historyFile, _ := cmd.Flags().GetString("history-file")
+	commands, err := newCommands(cmd)
+	if err != nil {
+		return err
+	}
+	shell := client.NewShell(commands, historyFile)
+	return shell.Run(cmd.Context(), os.Stdin, os.Stdout)
+}
+
+// defaultHistoryFile returns the shell history path in the home directory
+func defaultHistoryFile() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".url_shortener_history")
+}
+
+// clientProfile returns the server URL, API key, output format and
+// connection settings to use:
+// flags given on the command line win, then $URL_SHORTENER_API_KEY for the
+// key, then the --profile from the client config file, then flag defaults
+func clientProfile(cmd *cobra.Command) (client.Profile, error) {
+	configPath, _ := cmd.Flags().GetString("config")
+	file, err := client.LoadConfigFile(configPath)
+	if err != nil {
+		return client.Profile{}, err
+	}
+	name, _ := cmd.Flags().GetString("profile")
+	profile, err := file.Profile(name)
+	if err != nil {
+		return client.Profile{}, err
+	}
+
+	if cmd.Flags().Changed("server-url") || profile.ServerURL == "" {
+		profile.ServerURL, _ = cmd.Flags().GetString("server-url")
+	}
+	// The API key flag defaults to the environment variable, which beats the file
+	if apiKey, _ := cmd.Flags().GetString("api-key"); apiKey != "" || profile.APIKey == "" {
+		profile.APIKey = apiKey
+	}
+	if cmd.Flags().Changed("output") || profile.Output == "" {
+		profile.Output, _ = cmd.Flags().GetString("output")
+	}
+	for flag, value := range map[string]*string{
+		"proxy":       &profile.Proxy,
+		"ca-cert":     &profile.CACert,
+		"client-cert": &profile.ClientCert,
+		"client-key":  &profile.ClientKey,
+	} {
+		if cmd.Flags().Changed(flag) {
+			*value, _ = cmd.Flags().GetString(flag)
+		}
+	}
+	return profile, nil
+}
+
+// newCommands creates client commands for the selected profile, printing in
+// its output format
+func newCommands(cmd *cobra.Command) (*client.Commands, error) {
+	profile, err := clientProfile(cmd)
+	if err != nil {
+		return nil, err
+	}
+	format, err := client.ParseOutputFormat(profile.Output)
+	if err != nil {
+		return nil, err
+	}
+	opts, err := profile.ClientOptions()
+	if err != nil {
+		return nil, err
+	}
+	retries, _ := cmd.Flags().GetInt("retries")
+	timeout, _ := cmd.Flags().GetDuration("request-timeout")
+	apiClient := apiclient.NewClient(profile.ServerURL, append(opts, apiclient.WithRetries(retries), apiclient.WithTimeout(timeout))...)
+	return client.NewCommands(apiClient, client.WithOutputFormat(format)), nil
+}
+
+// openDatabase opens the database at dbPath with the --db-driver driver
+func openDatabase(cmd *cobra.Command, dbPath string) (*sqlite.Repository, error) {
+	driverName, _ := cmd.Flags().GetString("db-driver")
+	driver, err := sqlite.ParseDriver(driverName)
+	if err != nil {
+		return nil, err
+	}
+	repo, err := sqlite.New(dbPath, sqlite.WithDriver(driver))
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize database: %w", err)
+	}
+	return repo, nil
+}
+
+func runExport(cmd *cobra.Command, args []string) error {
+	dbPath, _ := cmd.Flags().GetString("db-path")
+	formatName, _ := cmd.Flags().GetString("format")
+	output, _ := cmd.Flags().GetString("output")
+
+	format, err := transfer.ParseFormat(formatName)
+	if err != nil {
+		return err
+	}
+
+	repo, err := openDatabase(cmd, dbPath)
+	if err != nil {
+		return err
+	}
+	defer repo.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	entries, err := repo.GetAllURLs(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := os.Stdout
+	if output != "" {
+		file, err := os.Create(output)
+		if err != nil {
+			return fmt.Errorf("failed to create output file: %w", err)
+		}
+		defer file.Close()
+		w = file
+	}
+
+	if err := transfer.Encode(w, entries, format); err != nil {
+		return err
+	}
+
+	if output != "" {
+		log.Printf("Exported %d URLs to %s", len(entries), output)
+	}
+	return nil
+}
+
+func runImport(cmd *cobra.Command, args []string) error {
+	dbPath, _ := cmd.Flags().GetString("db-path")
+	path, _ := cmd.Flags().GetString("file")
+	formatName, _ := cmd.Flags().GetString("format")
+	onConflict, _ := cmd.Flags().GetString("on-conflict")
+
+	format := transfer.FormatFromPath(path)
+	if formatName != "" {
+		parsed, err := transfer.ParseFormat(formatName)
+		if err != nil {
+			return err
+		}
+		format = parsed
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open import file: %w", err)
+	}
+	defer file.Close()
+
+	entries, err := transfer.Decode(file, format)
+	if err != nil {
+		return err
+	}
+
+	repo, err := openDatabase(cmd, dbPath)
+	if err != nil {
+		return err
+	}
+	defer repo.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	result, err := repo.ImportURLs(ctx, entries, domain.ConflictStrategy(onConflict))
+	if err != nil {
+		return fmt.Errorf("import failed, no changes were made: %w", err)
+	}
+
+	log.Printf("Imported %d URLs (%d overwritten, %d skipped)", result.Imported, result.Overwritten, result.Skipped)
+	return nil
+}
+
+// migrationConfig reads the --from-* or --to-* generator flags of migrate-codes
+func migrationConfig(cmd *cobra.Command, side string) shortener.Config {
+	config := shortener.DefaultConfig()
+	config.Obfuscation, _ = cmd.Flags().GetString(side + "-obfuscation")
+	config.Secret, _ = cmd.Flags().GetString(side + "-secret")
+	config.Multiplier, _ = cmd.Flags().GetUint64(side + "-multiplier")
+	config.Salt, _ = cmd.Flags().GetUint64(side + "-salt")
+	config.Alphabet, _ = cmd.Flags().GetString(side + "-alphabet")
+	config.Length, _ = cmd.Flags().GetInt(side + "-code-length")
+	return config
+}
+
+func runMigrateCodes(cmd *cobra.Command, args []string) error {
+	dbPath, _ := cmd.Flags().GetString("db-path")
+	output, _ := cmd.Flags().GetString("output")
+	formatName, _ := cmd.Flags().GetString("format")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	withAliases, _ := cmd.Flags().GetBool("aliases")
+	serverURL, _ := cmd.Flags().GetString("server-url")
+	reservedCodes, _ := cmd.Flags().GetStringSlice("reserved-codes")
+	blockedWordsFile, _ := cmd.Flags().GetString("blocked-words-file")
+
+	format := transfer.FormatFromPath(output)
+	if formatName != "" {
+		parsed, err := transfer.ParseFormat(formatName)
+		if err != nil {
+			return err
+		}
+		format = parsed
+	}
+
+	from, to := migrationConfig(cmd, "from"), migrationConfig(cmd, "to")
+	to.ReservedCodes = reservedCodes
+	if blockedWordsFile != "" {
+		extraWords, err := shortener.LoadBlockedWords(blockedWordsFile)
+		if err != nil {
+			return err
+		}
+		to.BlockedWords = append(append([]string{}, to.BlockedWords...), extraWords...)
+	}
+
+	repo, err := openDatabase(cmd, dbPath)
+	if err != nil {
+		return err
+	}
+	defer repo.Close()
+
+	recoder, err := shortener.NewRecoder(from, to, shortener.NewCounterCache(repo.GetQueries(), 1))
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+	defer cancel()
+
+	entries, err := repo.GetAllURLs(ctx)
+	if err != nil {
+		return err
+	}
+	codes := make([]string, len(entries))
+	for i, entry := range entries {
+		codes[i] = entry.ShortCode
+	}
+	recoding, err := recoder.Recode(ctx, codes)
+	if err != nil {
+		return fmt.Errorf("migration failed, no changes were made: %w", err)
+	}
+
+	// Aliases keep their codes, so no link may move onto one
+	existing, err := repo.ListAllAliases(ctx)
+	if err != nil {
+		return err
+	}
+	aliased := make(map[string]bool, len(existing))
+	for _, alias := range existing {
+		aliased[alias.Alias] = true
+	}
+	changes := make([]domain.CodeChange, len(recoding.Changes))
+	for i, change := range recoding.Changes {
+		if aliased[change.New] {
+			return fmt.Errorf("migration failed, no changes were made: new code %s for %s is an alias", change.New, change.Old)
+		}
+		changes[i] = domain.CodeChange{OldCode: change.Old, NewCode: change.New}
+	}
+
+	// An old code becomes an alias unless another link moves onto it
+	var aliases []*domain.URLEntry
+	if withAliases {
+		byCode := make(map[string]*domain.URLEntry, len(entries))
+		for _, entry := range entries {
+			byCode[entry.ShortCode] = entry
+		}
+		taken := make(map[string]bool, len(changes))
+		for _, change := range changes {
+			taken[change.NewCode] = true
+		}
+		now := time.Now().UTC()
+		for _, change := range changes {
+			if taken[change.OldCode] {
+				continue
+			}
+			entry := byCode[change.OldCode]
+			base := strings.TrimSuffix(serverURL, "/")
+			if entry.Domain != "" {
+				base = "https://" + entry.Domain
+			}
+			aliases = append(aliases, &domain.URLEntry{
+				ShortCode:      change.OldCode,
+				OriginalURL:    base + "/" + change.NewCode,
+				CreatedAt:      now,
+				RedirectStatus: http.StatusMovedPermanently,
+				Owner:          entry.Owner,
+				Domain:         entry.Domain,
+			})
+		}
+	}
+
+	w := os.Stdout
+	if output != "" {
+		file, err := os.Create(output)
+		if err != nil {
+			return fmt.Errorf("failed to create output file: %w", err)
+		}
+		defer file.Close()
+		w = file
+	}
+	if err := transfer.EncodeCodeChanges(w, changes, format); err != nil {
+		return err
+	}
+
+	if dryRun {
+		log.Printf("Dry run: %d codes would move (%d aliases), %d custom and %d blocked codes kept",
+			len(changes), len(aliases), len(recoding.Unmatched), len(recoding.Blocked))
+		return nil
+	}
+	if err := repo.MigrateShortCodes(ctx, changes, aliases); err != nil {
+		return fmt.Errorf("migration failed, no changes were made: %w", err)
+	}
+
+	log.Printf("Moved %d codes (%d aliases), kept %d custom and %d blocked codes",
+		len(changes), len(aliases), len(recoding.Unmatched), len(recoding.Blocked))
+	return nil
+}
+
+func runSimulate(cmd *cobra.Command, args []string) error {
+	serverURL, _ := cmd.Flags().GetString("server-url")
+	rps, _ := cmd.Flags().GetInt("rps")
+	duration, _ := cmd.Flags().GetDuration("duration")
+	concurrency, _ := cmd.Flags().GetInt("concurrency")
+	seedURLs, _ := cmd.Flags().GetInt("seed-urls")
+	createRatio, _ := cmd.Flags().GetFloat64("create-ratio")
+	deleteRatio, _ := cmd.Flags().GetFloat64("delete-ratio")
+
+	sim, err := simulate.New(simulate.Config{
+		TargetURL:   serverURL,
+		RPS:         rps,
+		Duration:    duration,
+		Concurrency: concurrency,
+		SeedURLs:    seedURLs,
+		CreateRatio: createRatio,
+		DeleteRatio: deleteRatio,
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Simulating %d req/s against %s for %s", rps, serverURL, duration)
+
+	// Stop early on interrupt but still print the report
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	report, err := sim.Run(ctx)
+	if err != nil {
+		return err
+	}
+
+	report.Print(os.Stdout)
+
+	if errors := report.TotalErrors(); errors > 0 {
+		return fmt.Errorf("simulation recorded %d failed or invalid responses", errors)
+	}
+	return nil
+}
+
+func main() {
+	if err := rootCmd.Execute(); err != nil {
+		// Cobra has already printed the error
+		if hint := client.Suggestion(err); hint != "" {
+			fmt.Fprintf(os.Stderr, "Hint: %s\n", hint)
+		}
+		os.Exit(1)
 	}
 }
\ No newline at end of file
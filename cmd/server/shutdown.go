@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// shutdownStage is one step of an orderly shutdown
+type shutdownStage struct {
+	name    string
+	timeout time.Duration
+	run     func(ctx context.Context) error
+}
+
+// shutdownCoordinator stops server components in dependency order. Stages are
+// registered as components start and run in reverse, so anything still in
+// use by a later component (like the database) is closed last.
+type shutdownCoordinator struct {
+	stages []shutdownStage
+}
+
+// add registers a stage. A zero timeout lets the stage run to completion.
+func (c *shutdownCoordinator) add(name string, timeout time.Duration, run func(ctx context.Context) error) {
+	c.stages = append(c.stages, shutdownStage{name: name, timeout: timeout, run: run})
+}
+
+// shutdown runs every registered stage, newest first, and returns their
+// combined errors. A failed or timed-out stage is logged and the remaining
+// stages still run, so one stuck component cannot keep the database open.
+func (c *shutdownCoordinator) shutdown() error {
+	if len(c.stages) == 0 {
+		return nil
+	}
+
+	start := time.Now()
+	var errs []error
+	for i := len(c.stages) - 1; i >= 0; i-- {
+		if err := c.runStage(c.stages[i]); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	c.stages = nil
+	log.Printf("Shutdown finished in %v", time.Since(start).Round(time.Millisecond))
+	return errors.Join(errs...)
+}
+
+// runStage runs a single stage, abandoning it once its timeout expires
+func (c *shutdownCoordinator) runStage(stage shutdownStage) error {
+	ctx := context.Background()
+	if stage.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, stage.timeout)
+		defer cancel()
+	}
+
+	log.Printf("Shutdown: %s...", stage.name)
+	start := time.Now()
+
+	// Run in a goroutine so a stage that ignores ctx cannot stall shutdown
+	done := make(chan error, 1)
+	go func() {
+		done <- stage.run(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			log.Printf("Shutdown: %s failed after %v: %v", stage.name, time.Since(start).Round(time.Millisecond), err)
+			return fmt.Errorf("%s: %w", stage.name, err)
+		}
+		log.Printf("Shutdown: %s done in %v", stage.name, time.Since(start).Round(time.Millisecond))
+		return nil
+	case <-ctx.Done():
+		log.Printf("Shutdown: %s timed out after %v", stage.name, stage.timeout)
+		return fmt.Errorf("%s: %w", stage.name, ctx.Err())
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShutdownCoordinator_RunsStagesInReverse(t *testing.T) {
+	var order []string
+	var coordinator shutdownCoordinator
+	for _, name := range []string{"database", "cache sync", "http"} {
+		coordinator.add(name, time.Second, func(ctx context.Context) error {
+			order = append(order, name)
+			return nil
+		})
+	}
+
+	assert.NoError(t, coordinator.shutdown())
+	assert.Equal(t, []string{"http", "cache sync", "database"}, order)
+
+	// Stages only run once
+	assert.NoError(t, coordinator.shutdown())
+	assert.Len(t, order, 3)
+}
+
+func TestShutdownCoordinator_ContinuesAfterFailures(t *testing.T) {
+	stageErr := errors.New("flush failed")
+	databaseClosed := false
+
+	var coordinator shutdownCoordinator
+	coordinator.add("database", time.Second, func(ctx context.Context) error {
+		databaseClosed = true
+		return nil
+	})
+	coordinator.add("failing", time.Second, func(ctx context.Context) error {
+		return stageErr
+	})
+	coordinator.add("stuck", 10*time.Millisecond, func(ctx context.Context) error {
+		// Ignores ctx, like a component without cancellation support
+		time.Sleep(time.Second)
+		return nil
+	})
+
+	start := time.Now()
+	err := coordinator.shutdown()
+
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+	assert.True(t, databaseClosed)
+	assert.ErrorIs(t, err, stageErr)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Contains(t, err.Error(), "stuck")
+}
+
+func TestShutdownCoordinator_ZeroTimeoutWaits(t *testing.T) {
+	var coordinator shutdownCoordinator
+	coordinator.add("slow", 0, func(ctx context.Context) error {
+		_, hasDeadline := ctx.Deadline()
+		assert.False(t, hasDeadline)
+		time.Sleep(20 * time.Millisecond)
+		return nil
+	})
+
+	assert.NoError(t, coordinator.shutdown())
+}
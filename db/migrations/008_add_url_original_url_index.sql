@@ -0,0 +1 @@
+CREATE INDEX IF NOT EXISTS idx_urls_original_url ON urls(original_url);
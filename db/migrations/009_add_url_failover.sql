@@ -0,0 +1,4 @@
+ALTER TABLE urls ADD COLUMN backup_url TEXT NOT NULL DEFAULT '';
+ALTER TABLE urls ADD COLUMN failover_active BOOLEAN NOT NULL DEFAULT 0;
+ALTER TABLE urls ADD COLUMN failover_reason TEXT NOT NULL DEFAULT '';
+ALTER TABLE urls ADD COLUMN failover_changed_at DATETIME;
@@ -0,0 +1,2 @@
+ALTER TABLE urls ADD COLUMN query_params TEXT NOT NULL DEFAULT '';
+ALTER TABLE urls ADD COLUMN forward_query BOOLEAN NOT NULL DEFAULT 0;
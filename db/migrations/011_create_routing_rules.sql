@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS routing_rules (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    short_code TEXT NOT NULL REFERENCES urls(short_code) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    countries TEXT NOT NULL DEFAULT '',
+    devices TEXT NOT NULL DEFAULT '',
+    languages TEXT NOT NULL DEFAULT '',
+    destination TEXT NOT NULL,
+    created_at DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_routing_rules_short_code ON routing_rules(short_code, position);
@@ -0,0 +1,3 @@
+ALTER TABLE urls ADD COLUMN campaign TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_urls_campaign ON urls(campaign);
@@ -0,0 +1,3 @@
+ALTER TABLE urls ADD COLUMN updated_at DATETIME;
+
+UPDATE urls SET updated_at = created_at;
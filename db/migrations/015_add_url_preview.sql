@@ -0,0 +1,5 @@
+ALTER TABLE urls ADD COLUMN preview_title TEXT NOT NULL DEFAULT '';
+ALTER TABLE urls ADD COLUMN preview_description TEXT NOT NULL DEFAULT '';
+ALTER TABLE urls ADD COLUMN preview_favicon_url TEXT NOT NULL DEFAULT '';
+ALTER TABLE urls ADD COLUMN preview_error TEXT NOT NULL DEFAULT '';
+ALTER TABLE urls ADD COLUMN preview_fetched_at DATETIME;
@@ -0,0 +1 @@
+ALTER TABLE urls ADD COLUMN dedupe_seconds INTEGER NOT NULL DEFAULT 0;
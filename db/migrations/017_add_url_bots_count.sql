@@ -0,0 +1 @@
+ALTER TABLE urls ADD COLUMN bots_count INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS country_rollups (
+    short_code TEXT NOT NULL REFERENCES urls(short_code) ON DELETE CASCADE,
+    country TEXT NOT NULL,
+    region TEXT NOT NULL DEFAULT '',
+    clicks INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (short_code, country, region)
+);
+
+CREATE INDEX IF NOT EXISTS idx_country_rollups_country ON country_rollups(country);
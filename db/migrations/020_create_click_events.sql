@@ -0,0 +1,10 @@
+-- Clicks per link and minute; minute is the minute's start in Unix seconds
+CREATE TABLE IF NOT EXISTS click_events (
+    short_code TEXT NOT NULL REFERENCES urls(short_code) ON DELETE CASCADE,
+    minute INTEGER NOT NULL,
+    clicks INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (short_code, minute)
+);
+
+-- Covers the top links query, which scans a window of minutes across all links
+CREATE INDEX IF NOT EXISTS idx_click_events_minute ON click_events(minute, short_code, clicks);
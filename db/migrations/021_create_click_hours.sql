@@ -0,0 +1,10 @@
+-- Clicks per link and hour, compacted from click_events once minutes age out;
+-- hour is the hour's start in Unix seconds
+CREATE TABLE IF NOT EXISTS click_hours (
+    short_code TEXT NOT NULL REFERENCES urls(short_code) ON DELETE CASCADE,
+    hour INTEGER NOT NULL,
+    clicks INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (short_code, hour)
+);
+
+CREATE INDEX IF NOT EXISTS idx_click_hours_hour ON click_hours(hour, short_code, clicks);
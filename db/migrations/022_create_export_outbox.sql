@@ -0,0 +1,7 @@
+-- Events waiting to be exported to Kafka or NATS, oldest first; rows are
+-- deleted once the broker has acknowledged them
+CREATE TABLE IF NOT EXISTS export_outbox (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    event TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);
@@ -0,0 +1,7 @@
+-- Events written in the same transaction as the change they describe, waiting
+-- to be published to the event bus; rows are deleted once published
+CREATE TABLE IF NOT EXISTS event_outbox (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    event TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);
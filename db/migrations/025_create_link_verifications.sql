@@ -0,0 +1,11 @@
+-- The latest destination reachability check of each link
+CREATE TABLE IF NOT EXISTS link_verifications (
+    short_code TEXT PRIMARY KEY REFERENCES urls(short_code) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    reachable BOOLEAN NOT NULL,
+    status_code INTEGER NOT NULL DEFAULT 0,
+    error TEXT NOT NULL DEFAULT '',
+    checked_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_link_verifications_reachable ON link_verifications(reachable);
@@ -0,0 +1,15 @@
+-- Custom hostnames short links are served on, with their redirect default
+-- and the branding of the pages visitors see there
+CREATE TABLE IF NOT EXISTS domains (
+    host TEXT PRIMARY KEY,
+    redirect_status INTEGER NOT NULL DEFAULT 0,
+    brand_name TEXT NOT NULL DEFAULT '',
+    brand_logo_url TEXT NOT NULL DEFAULT '',
+    brand_color TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL
+);
+
+-- The custom domain a link is served on; '' is the server's own host
+ALTER TABLE urls ADD COLUMN domain TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_urls_domain ON urls(domain);
@@ -0,0 +1,9 @@
+-- Extra short codes resolving to a link. Redirects through an alias count
+-- toward the link's usage and analytics.
+CREATE TABLE IF NOT EXISTS aliases (
+    alias TEXT PRIMARY KEY,
+    short_code TEXT NOT NULL REFERENCES urls(short_code) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_aliases_short_code ON aliases(short_code);
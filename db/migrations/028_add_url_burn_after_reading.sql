@@ -0,0 +1,2 @@
+-- Links that deactivate after their first redirect; they are created with max_uses 1
+ALTER TABLE urls ADD COLUMN burn_after_reading BOOLEAN NOT NULL DEFAULT 0;
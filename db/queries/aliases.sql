@@ -0,0 +1,28 @@
+-- name: CreateAlias :exec
+INSERT INTO aliases (alias, short_code, created_at)
+VALUES (?, ?, ?);
+
+-- name: GetAlias :one
+SELECT * FROM aliases
+WHERE alias = ?;
+
+-- name: ListAliases :many
+SELECT * FROM aliases
+WHERE short_code = ?
+ORDER BY created_at, alias;
+
+-- name: ListAllAliases :many
+SELECT * FROM aliases
+ORDER BY short_code, created_at, alias;
+
+-- name: DeleteAlias :execrows
+DELETE FROM aliases
+WHERE short_code = ? AND alias = ?;
+
+-- name: DeleteLinkAliases :exec
+DELETE FROM aliases
+WHERE short_code = ?;
+
+-- name: AliasExists :one
+SELECT COUNT(*) FROM aliases
+WHERE alias = ?;
@@ -0,0 +1,149 @@
+-- Rollups only count clicks on links that still exist, so a flush racing a deletion skips them.
+-- name: AddReferrerClicks :exec
+INSERT INTO referrer_rollups (short_code, referrer, clicks)
+SELECT sqlc.arg(short_code), sqlc.arg(referrer), sqlc.arg(clicks)
+WHERE EXISTS (SELECT 1 FROM urls WHERE urls.short_code = sqlc.arg(short_code))
+ON CONFLICT (short_code, referrer) DO UPDATE SET clicks = referrer_rollups.clicks + excluded.clicks;
+
+-- name: AddUTMClicks :exec
+INSERT INTO utm_rollups (short_code, utm_source, utm_medium, utm_campaign, clicks)
+SELECT sqlc.arg(short_code), sqlc.arg(utm_source), sqlc.arg(utm_medium), sqlc.arg(utm_campaign), sqlc.arg(clicks)
+WHERE EXISTS (SELECT 1 FROM urls WHERE urls.short_code = sqlc.arg(short_code))
+ON CONFLICT (short_code, utm_source, utm_medium, utm_campaign) DO UPDATE SET clicks = utm_rollups.clicks + excluded.clicks;
+
+-- name: AddCountryClicks :exec
+INSERT INTO country_rollups (short_code, country, region, clicks)
+SELECT sqlc.arg(short_code), sqlc.arg(country), sqlc.arg(region), sqlc.arg(clicks)
+WHERE EXISTS (SELECT 1 FROM urls WHERE urls.short_code = sqlc.arg(short_code))
+ON CONFLICT (short_code, country, region) DO UPDATE SET clicks = country_rollups.clicks + excluded.clicks;
+
+-- name: AddMinuteClicks :exec
+INSERT INTO click_events (short_code, minute, clicks)
+SELECT sqlc.arg(short_code), sqlc.arg(minute), sqlc.arg(clicks)
+WHERE EXISTS (SELECT 1 FROM urls WHERE urls.short_code = sqlc.arg(short_code))
+ON CONFLICT (short_code, minute) DO UPDATE SET clicks = click_events.clicks + excluded.clicks;
+
+-- name: ListReferrerClicks :many
+SELECT referrer, clicks FROM referrer_rollups
+WHERE short_code = ?
+ORDER BY clicks DESC, referrer
+LIMIT ?;
+
+-- name: ListUTMClicks :many
+SELECT utm_source, utm_medium, utm_campaign, clicks FROM utm_rollups
+WHERE short_code = ?
+ORDER BY clicks DESC, utm_source, utm_medium, utm_campaign
+LIMIT ?;
+
+-- name: ListTopReferrers :many
+SELECT referrer, CAST(SUM(clicks) AS INTEGER) AS clicks, COUNT(*) AS links FROM referrer_rollups
+GROUP BY referrer
+ORDER BY clicks DESC, referrer
+LIMIT ?;
+
+-- name: ListCountryClicks :many
+SELECT country, region, clicks FROM country_rollups
+WHERE short_code = ?
+ORDER BY clicks DESC, country, region
+LIMIT ?;
+
+-- name: ListTopCountries :many
+SELECT country, CAST(SUM(clicks) AS INTEGER) AS clicks, COUNT(DISTINCT short_code) AS links FROM country_rollups
+GROUP BY country
+ORDER BY clicks DESC, country
+LIMIT ?;
+
+-- Counts each link's clicks since since and in the window before it, from previous_since;
+-- trending orders by the gain between the two. Compacted hours count from their start.
+-- name: ListTopLinks :many
+SELECT e.short_code, u.original_url,
+    CAST(SUM(CASE WHEN e.at >= sqlc.arg(since) THEN e.clicks ELSE 0 END) AS INTEGER) AS clicks,
+    CAST(SUM(CASE WHEN e.at < sqlc.arg(since) THEN e.clicks ELSE 0 END) AS INTEGER) AS previous_clicks
+FROM (
+    SELECT short_code, minute AS at, clicks FROM click_events WHERE minute >= sqlc.arg(previous_since)
+    UNION ALL
+    SELECT short_code, hour AS at, clicks FROM click_hours WHERE hour >= sqlc.arg(previous_since)
+) e
+JOIN urls u ON u.short_code = e.short_code
+GROUP BY e.short_code
+HAVING clicks > 0
+ORDER BY CASE WHEN sqlc.arg(trending) THEN clicks - previous_clicks ELSE clicks END DESC, clicks DESC, e.short_code
+LIMIT sqlc.arg(limit);
+
+-- Sums a link's clicks into buckets of bucket_seconds from from_time until to_time, from
+-- minutes and compacted hours alike; buckets without clicks are left out.
+-- name: ListClickBuckets :many
+SELECT CAST(e.at - e.at % sqlc.arg(bucket_seconds) AS INTEGER) AS bucket,
+    CAST(SUM(e.clicks) AS INTEGER) AS clicks
+FROM (
+    SELECT minute AS at, clicks FROM click_events
+    WHERE short_code = sqlc.arg(short_code) AND minute >= sqlc.arg(from_time) AND minute < sqlc.arg(to_time)
+    UNION ALL
+    SELECT hour AS at, clicks FROM click_hours
+    WHERE short_code = sqlc.arg(short_code) AND hour >= sqlc.arg(from_time) AND hour < sqlc.arg(to_time)
+) e
+GROUP BY bucket
+ORDER BY bucket;
+
+-- Compaction moves minutes before an hour boundary into click_hours in one transaction.
+-- name: AddHourClicksFromEvents :exec
+INSERT INTO click_hours (short_code, hour, clicks)
+SELECT short_code, minute - minute % 3600, SUM(clicks)
+FROM click_events
+WHERE minute < sqlc.arg(before)
+GROUP BY short_code, minute - minute % 3600
+ON CONFLICT (short_code, hour) DO UPDATE SET clicks = click_hours.clicks + excluded.clicks;
+
+-- name: DeleteClickEventsBefore :execrows
+DELETE FROM click_events
+WHERE minute < ?;
+
+-- name: DeleteReferrerRollups :exec
+DELETE FROM referrer_rollups
+WHERE short_code = ?;
+
+-- name: DeleteUTMRollups :exec
+DELETE FROM utm_rollups
+WHERE short_code = ?;
+
+-- name: DeleteCountryRollups :exec
+DELETE FROM country_rollups
+WHERE short_code = ?;
+
+-- name: DeleteClickEvents :exec
+DELETE FROM click_events
+WHERE short_code = ?;
+
+-- name: DeleteClickHours :exec
+DELETE FROM click_hours
+WHERE short_code = ?;
+
+-- Archiving moves the clicks of whole UTC days out of both tables; it starts
+-- from the oldest minute or hour before a cutoff.
+-- name: OldestClickBefore :one
+SELECT CAST(e.at AS INTEGER) AS at FROM (
+    SELECT MIN(minute) AS at FROM click_events WHERE minute < sqlc.arg(before)
+    UNION ALL
+    SELECT MIN(hour) AS at FROM click_hours WHERE hour < sqlc.arg(before)
+) e
+WHERE e.at IS NOT NULL
+ORDER BY e.at
+LIMIT 1;
+
+-- name: ListClicksBetween :many
+SELECT e.short_code, CAST(e.at AS INTEGER) AS at, CAST(e.bucket_seconds AS INTEGER) AS bucket_seconds, e.clicks FROM (
+    SELECT short_code, minute AS at, 60 AS bucket_seconds, clicks FROM click_events
+    WHERE minute >= sqlc.arg(from_time) AND minute < sqlc.arg(to_time)
+    UNION ALL
+    SELECT short_code, hour AS at, 3600 AS bucket_seconds, clicks FROM click_hours
+    WHERE hour >= sqlc.arg(from_time) AND hour < sqlc.arg(to_time)
+) e
+ORDER BY e.at, e.short_code, e.bucket_seconds;
+
+-- name: DeleteClickEventsBetween :execrows
+DELETE FROM click_events
+WHERE minute >= sqlc.arg(from_time) AND minute < sqlc.arg(to_time);
+
+-- name: DeleteClickHoursBetween :execrows
+DELETE FROM click_hours
+WHERE hour >= sqlc.arg(from_time) AND hour < sqlc.arg(to_time);
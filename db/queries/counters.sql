@@ -6,7 +6,7 @@ INSERT OR REPLACE INTO counters (key, value, updated_at) VALUES (?, ?, CURRENT_T
 
 -- name: IncrementCounter :one
 INSERT INTO counters (key, value, updated_at) VALUES (?, ?, CURRENT_TIMESTAMP)
-ON CONFLICT(key) DO UPDATE SET 
-    value = counters.value + ?,
+ON CONFLICT(key) DO UPDATE SET
+    value = counters.value + excluded.value,
     updated_at = CURRENT_TIMESTAMP
 RETURNING value;
\ No newline at end of file
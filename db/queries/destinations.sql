@@ -0,0 +1,11 @@
+-- name: CreateDestinationRule :exec
+INSERT INTO destination_rules (list, pattern, created_at)
+VALUES (?, ?, ?);
+
+-- name: ListDestinationRules :many
+SELECT * FROM destination_rules
+ORDER BY list, created_at, pattern;
+
+-- name: DeleteDestinationRule :execrows
+DELETE FROM destination_rules
+WHERE list = ? AND pattern = ?;
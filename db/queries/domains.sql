@@ -0,0 +1,20 @@
+-- name: CreateDomain :exec
+INSERT INTO domains (host, redirect_status, brand_name, brand_logo_url, brand_color, created_at)
+VALUES (?, ?, ?, ?, ?, ?);
+
+-- name: UpdateDomain :execrows
+UPDATE domains
+SET redirect_status = ?, brand_name = ?, brand_logo_url = ?, brand_color = ?
+WHERE host = ?;
+
+-- name: ListDomains :many
+SELECT * FROM domains
+ORDER BY host;
+
+-- name: DeleteDomain :execrows
+DELETE FROM domains
+WHERE host = ?;
+
+-- name: CountURLsByDomain :one
+SELECT COUNT(*) FROM urls
+WHERE domain = ?;
@@ -0,0 +1,15 @@
+-- name: AddExportEvent :exec
+INSERT INTO export_outbox (event, created_at)
+VALUES (?, ?);
+
+-- name: ListExportEvents :many
+SELECT * FROM export_outbox
+ORDER BY id
+LIMIT ?;
+
+-- name: DeleteExportEventsThrough :execrows
+DELETE FROM export_outbox
+WHERE id <= ?;
+
+-- name: CountExportEvents :one
+SELECT COUNT(*) FROM export_outbox;
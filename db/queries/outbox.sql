@@ -0,0 +1,12 @@
+-- name: AddOutboxEvent :exec
+INSERT INTO event_outbox (event, created_at)
+VALUES (?, ?);
+
+-- name: ListOutboxEvents :many
+SELECT * FROM event_outbox
+ORDER BY id
+LIMIT ?;
+
+-- name: DeleteOutboxEventsThrough :execrows
+DELETE FROM event_outbox
+WHERE id <= ?;
@@ -0,0 +1,21 @@
+-- name: CreateLifecyclePolicy :one
+INSERT INTO lifecycle_policies (name, tag, older_than, unused_for, action, created_at)
+VALUES (?, ?, ?, ?, ?, ?)
+RETURNING *;
+
+-- name: ListLifecyclePolicies :many
+SELECT * FROM lifecycle_policies
+ORDER BY id;
+
+-- name: DeleteLifecyclePolicy :execrows
+DELETE FROM lifecycle_policies
+WHERE id = ?;
+
+-- name: CreatePolicyAction :exec
+INSERT INTO policy_actions (policy_name, action, short_code, original_url, snapshot, error, created_at)
+VALUES (?, ?, ?, ?, ?, ?, ?);
+
+-- name: ListPolicyActions :many
+SELECT * FROM policy_actions
+ORDER BY id DESC
+LIMIT ?;
@@ -0,0 +1,16 @@
+-- name: CreateRoutingRule :exec
+INSERT INTO routing_rules (short_code, position, countries, devices, languages, destination, created_at)
+VALUES (?, ?, ?, ?, ?, ?, ?);
+
+-- name: ListRoutingRules :many
+SELECT * FROM routing_rules
+WHERE short_code = ?
+ORDER BY position;
+
+-- name: ListAllRoutingRules :many
+SELECT * FROM routing_rules
+ORDER BY short_code, position;
+
+-- name: DeleteRoutingRules :exec
+DELETE FROM routing_rules
+WHERE short_code = ?;
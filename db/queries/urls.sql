@@ -1,20 +1,73 @@
 -- name: CreateURL :one
-INSERT INTO urls (short_code, original_url, created_at, usage_count)
-VALUES (?, ?, ?, 0)
+INSERT INTO urls (short_code, original_url, created_at, usage_count, max_uses, tags, redirect_status, backup_url, query_params, forward_query, campaign, owner, updated_at, dedupe_seconds, domain, burn_after_reading)
+VALUES (?, ?, ?, 0, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 RETURNING *;
 
 -- name: GetURL :one
 SELECT * FROM urls
 WHERE short_code = ?;
 
+-- name: GetReusableURLByOriginalURL :one
+-- The caller's oldest uncapped link to a destination on the server's own host; capped links can expire, so they are never
+-- reused, and links on a custom domain would not redirect on the host the caller expects.
+SELECT * FROM urls
+WHERE original_url = ? AND owner = ? AND max_uses IS NULL AND domain = ''
+ORDER BY created_at, id
+LIMIT 1;
+
 -- name: GetAllURLs :many
 SELECT * FROM urls
 ORDER BY created_at DESC;
 
--- name: UpdateUsage :exec
-UPDATE urls 
-SET usage_count = ?, last_used_at = ?
-WHERE short_code = ?;
+-- name: GetURLsByCampaign :many
+SELECT * FROM urls
+WHERE campaign = ?
+ORDER BY created_at DESC;
+
+-- name: GetURLsByOwner :many
+SELECT * FROM urls
+WHERE owner = ?
+ORDER BY created_at DESC;
+
+-- name: UpdateUsage :one
+-- Max-count wins: a stale writer can never move the count or timestamp backwards.
+UPDATE urls
+SET usage_count = MAX(COALESCE(usage_count, 0), sqlc.arg(usage_count)),
+    last_used_at = MAX(COALESCE(last_used_at, sqlc.arg(last_used_at)), sqlc.arg(last_used_at))
+WHERE short_code = sqlc.arg(short_code)
+RETURNING usage_count;
+
+-- name: AddUsage :one
+-- Delta merge: concurrent writers each add the redirects they counted since their last sync.
+UPDATE urls
+SET usage_count = COALESCE(usage_count, 0) + sqlc.arg(delta),
+    last_used_at = MAX(COALESCE(last_used_at, sqlc.arg(last_used_at)), sqlc.arg(last_used_at))
+WHERE short_code = sqlc.arg(short_code)
+RETURNING usage_count;
+
+-- name: BurnURL :execrows
+-- Claims the single redirect of a burn-after-reading link; once used, no row matches.
+UPDATE urls
+SET usage_count = 1, last_used_at = ?
+WHERE short_code = ? AND burn_after_reading = 1 AND COALESCE(usage_count, 0) = 0;
+
+-- name: UpdateURL :one
+UPDATE urls
+SET original_url = ?, max_uses = ?, tags = ?, redirect_status = ?, backup_url = ?, query_params = ?, forward_query = ?, campaign = ?, updated_at = ?, dedupe_seconds = ?
+WHERE short_code = ?
+RETURNING *;
+
+-- name: SetURLFailover :one
+UPDATE urls
+SET failover_active = ?, failover_reason = ?, failover_changed_at = ?
+WHERE short_code = ?
+RETURNING *;
+
+-- name: SetURLPreview :one
+UPDATE urls
+SET preview_title = ?, preview_description = ?, preview_favicon_url = ?, preview_error = ?, preview_fetched_at = ?
+WHERE short_code = ?
+RETURNING *;
 
 -- name: DeleteURL :exec
 DELETE FROM urls 
@@ -22,4 +75,20 @@ WHERE short_code = ?;
 
 -- name: URLExists :one
 SELECT COUNT(*) FROM urls
-WHERE short_code = ?;
\ No newline at end of file
+WHERE short_code = ?;
+
+-- name: CountURLsCreatedSince :one
+SELECT COUNT(*) FROM urls
+WHERE created_at >= ?;
+
+-- name: SumUsage :one
+SELECT CAST(COALESCE(SUM(usage_count), 0) AS INTEGER) AS total_clicks FROM urls;
+
+-- name: ImportURL :exec
+INSERT INTO urls (short_code, original_url, created_at, last_used_at, usage_count, max_uses, tags, redirect_status, backup_url, query_params, forward_query, campaign, owner, updated_at, dedupe_seconds, bots_count, domain, burn_after_reading)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);
+
+-- name: OverwriteURL :exec
+UPDATE urls
+SET original_url = ?, created_at = ?, last_used_at = ?, usage_count = ?, max_uses = ?, tags = ?, redirect_status = ?, backup_url = ?, query_params = ?, forward_query = ?, campaign = ?, owner = ?, updated_at = ?, dedupe_seconds = ?, bots_count = ?, domain = ?, burn_after_reading = ?
+WHERE short_code = ?;
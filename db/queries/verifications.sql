@@ -0,0 +1,28 @@
+-- name: SetLinkVerification :exec
+-- A check racing the link's deletion stores nothing.
+INSERT INTO link_verifications (short_code, url, reachable, status_code, error, checked_at)
+SELECT sqlc.arg(short_code), sqlc.arg(url), sqlc.arg(reachable), sqlc.arg(status_code), sqlc.arg(error), sqlc.arg(checked_at)
+WHERE EXISTS (SELECT 1 FROM urls WHERE urls.short_code = sqlc.arg(short_code))
+ON CONFLICT (short_code) DO UPDATE SET
+    url = excluded.url,
+    reachable = excluded.reachable,
+    status_code = excluded.status_code,
+    error = excluded.error,
+    checked_at = excluded.checked_at;
+
+-- name: GetLinkVerification :one
+SELECT * FROM link_verifications
+WHERE short_code = ?;
+
+-- name: ListLinkVerifications :many
+SELECT * FROM link_verifications
+ORDER BY checked_at DESC, short_code;
+
+-- name: ListUnreachableLinkVerifications :many
+SELECT * FROM link_verifications
+WHERE reachable = 0
+ORDER BY checked_at DESC, short_code;
+
+-- name: DeleteLinkVerification :exec
+DELETE FROM link_verifications
+WHERE short_code = ?;
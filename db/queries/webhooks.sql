@@ -0,0 +1,39 @@
+-- name: CreateWebhookEndpoint :one
+INSERT INTO webhook_endpoints (url, secret, events, created_at)
+VALUES (?, ?, ?, ?)
+RETURNING *;
+
+-- name: GetWebhookEndpoint :one
+SELECT * FROM webhook_endpoints
+WHERE id = ?;
+
+-- name: ListWebhookEndpoints :many
+SELECT * FROM webhook_endpoints
+ORDER BY id;
+
+-- name: DeleteWebhookEndpoint :execrows
+DELETE FROM webhook_endpoints
+WHERE id = ?;
+
+-- name: CreateWebhookDelivery :exec
+INSERT INTO webhook_deliveries (endpoint_id, endpoint_url, event_id, event_type, attempt, status_code, error, success, duration_ms, created_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?);
+
+-- name: ListWebhookDeliveries :many
+SELECT * FROM webhook_deliveries
+ORDER BY id DESC
+LIMIT ?;
+
+-- name: ListWebhookDeliveriesByEndpoint :many
+SELECT * FROM webhook_deliveries
+WHERE endpoint_id = ?
+ORDER BY id DESC
+LIMIT ?;
+
+-- name: DeleteWebhookDeliveriesByEndpoint :exec
+DELETE FROM webhook_deliveries
+WHERE endpoint_id = ?;
+
+-- name: DeleteWebhookDeliveriesBefore :execrows
+DELETE FROM webhook_deliveries
+WHERE created_at < ?;
@@ -22,8 +22,8 @@ func (q *Queries) GetCounter(ctx context.Context, key string) (int64, error) {
 
 const incrementCounter = `-- name: IncrementCounter :one
 INSERT INTO counters (key, value, updated_at) VALUES (?, ?, CURRENT_TIMESTAMP)
-ON CONFLICT(key) DO UPDATE SET 
-    value = counters.value + ?,
+ON CONFLICT(key) DO UPDATE SET
+    value = counters.value + excluded.value,
     updated_at = CURRENT_TIMESTAMP
 RETURNING value
 `
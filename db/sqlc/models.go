@@ -9,17 +9,167 @@ import (
 	"time"
 )
 
+type Alias struct {
+	Alias     string    `json:"alias"`
+	ShortCode string    `json:"short_code"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type ClickEvent struct {
+	ShortCode string `json:"short_code"`
+	Minute    int64  `json:"minute"`
+	Clicks    int64  `json:"clicks"`
+}
+
+type ClickHour struct {
+	ShortCode string `json:"short_code"`
+	Hour      int64  `json:"hour"`
+	Clicks    int64  `json:"clicks"`
+}
+
 type Counter struct {
 	Key       string    `json:"key"`
 	Value     int64     `json:"value"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
 
+type CountryRollup struct {
+	ShortCode string `json:"short_code"`
+	Country   string `json:"country"`
+	Region    string `json:"region"`
+	Clicks    int64  `json:"clicks"`
+}
+
+type DestinationRule struct {
+	List      string    `json:"list"`
+	Pattern   string    `json:"pattern"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Domain struct {
+	Host           string    `json:"host"`
+	RedirectStatus int64     `json:"redirect_status"`
+	BrandName      string    `json:"brand_name"`
+	BrandLogoUrl   string    `json:"brand_logo_url"`
+	BrandColor     string    `json:"brand_color"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+type EventOutbox struct {
+	ID        int64     `json:"id"`
+	Event     string    `json:"event"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type ExportOutbox struct {
+	ID        int64     `json:"id"`
+	Event     string    `json:"event"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type LifecyclePolicy struct {
+	ID        int64     `json:"id"`
+	Name      string    `json:"name"`
+	Tag       string    `json:"tag"`
+	OlderThan int64     `json:"older_than"`
+	UnusedFor int64     `json:"unused_for"`
+	Action    string    `json:"action"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type LinkVerification struct {
+	ShortCode  string    `json:"short_code"`
+	Url        string    `json:"url"`
+	Reachable  bool      `json:"reachable"`
+	StatusCode int64     `json:"status_code"`
+	Error      string    `json:"error"`
+	CheckedAt  time.Time `json:"checked_at"`
+}
+
+type PolicyAction struct {
+	ID          int64          `json:"id"`
+	PolicyName  string         `json:"policy_name"`
+	Action      string         `json:"action"`
+	ShortCode   string         `json:"short_code"`
+	OriginalUrl string         `json:"original_url"`
+	Snapshot    sql.NullString `json:"snapshot"`
+	Error       sql.NullString `json:"error"`
+	CreatedAt   time.Time      `json:"created_at"`
+}
+
+type ReferrerRollup struct {
+	ShortCode string `json:"short_code"`
+	Referrer  string `json:"referrer"`
+	Clicks    int64  `json:"clicks"`
+}
+
+type RoutingRule struct {
+	ID          int64     `json:"id"`
+	ShortCode   string    `json:"short_code"`
+	Position    int64     `json:"position"`
+	Countries   string    `json:"countries"`
+	Devices     string    `json:"devices"`
+	Languages   string    `json:"languages"`
+	Destination string    `json:"destination"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
 type Url struct {
-	ID          int64         `json:"id"`
-	ShortCode   string        `json:"short_code"`
-	OriginalUrl string        `json:"original_url"`
-	CreatedAt   time.Time     `json:"created_at"`
-	LastUsedAt  sql.NullTime  `json:"last_used_at"`
-	UsageCount  sql.NullInt64 `json:"usage_count"`
+	ID                 int64         `json:"id"`
+	ShortCode          string        `json:"short_code"`
+	OriginalUrl        string        `json:"original_url"`
+	CreatedAt          time.Time     `json:"created_at"`
+	LastUsedAt         sql.NullTime  `json:"last_used_at"`
+	UsageCount         sql.NullInt64 `json:"usage_count"`
+	MaxUses            sql.NullInt64 `json:"max_uses"`
+	Tags               string        `json:"tags"`
+	RedirectStatus     int64         `json:"redirect_status"`
+	BackupUrl          string        `json:"backup_url"`
+	FailoverActive     bool          `json:"failover_active"`
+	FailoverReason     string        `json:"failover_reason"`
+	FailoverChangedAt  sql.NullTime  `json:"failover_changed_at"`
+	QueryParams        string        `json:"query_params"`
+	ForwardQuery       bool          `json:"forward_query"`
+	Campaign           string        `json:"campaign"`
+	Owner              string        `json:"owner"`
+	UpdatedAt          sql.NullTime  `json:"updated_at"`
+	PreviewTitle       string        `json:"preview_title"`
+	PreviewDescription string        `json:"preview_description"`
+	PreviewFaviconUrl  string        `json:"preview_favicon_url"`
+	PreviewError       string        `json:"preview_error"`
+	PreviewFetchedAt   sql.NullTime  `json:"preview_fetched_at"`
+	DedupeSeconds      int64         `json:"dedupe_seconds"`
+	BotsCount          int64         `json:"bots_count"`
+	Domain             string        `json:"domain"`
+	BurnAfterReading   bool          `json:"burn_after_reading"`
+}
+
+type UtmRollup struct {
+	ShortCode   string `json:"short_code"`
+	UtmSource   string `json:"utm_source"`
+	UtmMedium   string `json:"utm_medium"`
+	UtmCampaign string `json:"utm_campaign"`
+	Clicks      int64  `json:"clicks"`
+}
+
+type WebhookDelivery struct {
+	ID          int64          `json:"id"`
+	EndpointID  sql.NullInt64  `json:"endpoint_id"`
+	EndpointUrl string         `json:"endpoint_url"`
+	EventID     string         `json:"event_id"`
+	EventType   string         `json:"event_type"`
+	Attempt     int64          `json:"attempt"`
+	StatusCode  sql.NullInt64  `json:"status_code"`
+	Error       sql.NullString `json:"error"`
+	Success     bool           `json:"success"`
+	DurationMs  int64          `json:"duration_ms"`
+	CreatedAt   time.Time      `json:"created_at"`
+}
+
+type WebhookEndpoint struct {
+	ID        int64     `json:"id"`
+	Url       string    `json:"url"`
+	Secret    string    `json:"secret"`
+	Events    string    `json:"events"`
+	CreatedAt time.Time `json:"created_at"`
 }
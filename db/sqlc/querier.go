@@ -6,18 +6,111 @@ package sqlc
 
 import (
 	"context"
+	"database/sql"
+	"time"
 )
 
 type Querier interface {
+	AddCountryClicks(ctx context.Context, arg AddCountryClicksParams) error
+	AddExportEvent(ctx context.Context, arg AddExportEventParams) error
+	// Compaction moves minutes before an hour boundary into click_hours in one transaction.
+	AddHourClicksFromEvents(ctx context.Context, before int64) error
+	AddMinuteClicks(ctx context.Context, arg AddMinuteClicksParams) error
+	AddOutboxEvent(ctx context.Context, arg AddOutboxEventParams) error
+	// Rollups only count clicks on links that still exist, so a flush racing a deletion skips them.
+	AddReferrerClicks(ctx context.Context, arg AddReferrerClicksParams) error
+	AddUTMClicks(ctx context.Context, arg AddUTMClicksParams) error
+	// Delta merge: concurrent writers each add the redirects they counted since their last sync.
+	AddUsage(ctx context.Context, arg AddUsageParams) (sql.NullInt64, error)
+	AliasExists(ctx context.Context, alias string) (int64, error)
+	// Claims the single redirect of a burn-after-reading link; once used, no row matches.
+	BurnURL(ctx context.Context, arg BurnURLParams) (int64, error)
+	CountExportEvents(ctx context.Context) (int64, error)
+	CountURLsByDomain(ctx context.Context, domain string) (int64, error)
+	CountURLsCreatedSince(ctx context.Context, createdAt time.Time) (int64, error)
+	CreateAlias(ctx context.Context, arg CreateAliasParams) error
+	CreateDomain(ctx context.Context, arg CreateDomainParams) error
+	CreateDestinationRule(ctx context.Context, arg CreateDestinationRuleParams) error
+	CreateLifecyclePolicy(ctx context.Context, arg CreateLifecyclePolicyParams) (LifecyclePolicy, error)
+	CreatePolicyAction(ctx context.Context, arg CreatePolicyActionParams) error
+	CreateRoutingRule(ctx context.Context, arg CreateRoutingRuleParams) error
 	CreateURL(ctx context.Context, arg CreateURLParams) (Url, error)
+	CreateWebhookDelivery(ctx context.Context, arg CreateWebhookDeliveryParams) error
+	CreateWebhookEndpoint(ctx context.Context, arg CreateWebhookEndpointParams) (WebhookEndpoint, error)
+	DeleteAlias(ctx context.Context, arg DeleteAliasParams) (int64, error)
+	DeleteClickEvents(ctx context.Context, shortCode string) error
+	DeleteClickEventsBefore(ctx context.Context, minute int64) (int64, error)
+	DeleteClickEventsBetween(ctx context.Context, arg DeleteClickEventsBetweenParams) (int64, error)
+	DeleteClickHours(ctx context.Context, shortCode string) error
+	DeleteClickHoursBetween(ctx context.Context, arg DeleteClickHoursBetweenParams) (int64, error)
+	DeleteCountryRollups(ctx context.Context, shortCode string) error
+	DeleteDestinationRule(ctx context.Context, arg DeleteDestinationRuleParams) (int64, error)
+	DeleteDomain(ctx context.Context, host string) (int64, error)
+	DeleteExportEventsThrough(ctx context.Context, id int64) (int64, error)
+	DeleteLifecyclePolicy(ctx context.Context, id int64) (int64, error)
+	DeleteLinkAliases(ctx context.Context, shortCode string) error
+	DeleteLinkVerification(ctx context.Context, shortCode string) error
+	DeleteOutboxEventsThrough(ctx context.Context, id int64) (int64, error)
+	DeleteReferrerRollups(ctx context.Context, shortCode string) error
+	DeleteRoutingRules(ctx context.Context, shortCode string) error
+	DeleteUTMRollups(ctx context.Context, shortCode string) error
 	DeleteURL(ctx context.Context, shortCode string) error
+	DeleteWebhookDeliveriesBefore(ctx context.Context, createdAt time.Time) (int64, error)
+	DeleteWebhookDeliveriesByEndpoint(ctx context.Context, endpointID sql.NullInt64) error
+	DeleteWebhookEndpoint(ctx context.Context, id int64) (int64, error)
+	GetAlias(ctx context.Context, alias string) (Alias, error)
 	GetAllURLs(ctx context.Context) ([]Url, error)
 	GetCounter(ctx context.Context, key string) (int64, error)
+	GetLinkVerification(ctx context.Context, shortCode string) (LinkVerification, error)
+	// The oldest uncapped link to a destination; capped links can expire, so they are never reused.
+	GetReusableURLByOriginalURL(ctx context.Context, arg GetReusableURLByOriginalURLParams) (Url, error)
 	GetURL(ctx context.Context, shortCode string) (Url, error)
+	GetURLsByCampaign(ctx context.Context, campaign string) ([]Url, error)
+	GetURLsByOwner(ctx context.Context, owner string) ([]Url, error)
+	GetWebhookEndpoint(ctx context.Context, id int64) (WebhookEndpoint, error)
+	ImportURL(ctx context.Context, arg ImportURLParams) error
 	IncrementCounter(ctx context.Context, arg IncrementCounterParams) (int64, error)
+	ListAliases(ctx context.Context, shortCode string) ([]Alias, error)
+	ListAllAliases(ctx context.Context) ([]Alias, error)
+	ListAllRoutingRules(ctx context.Context) ([]RoutingRule, error)
+	// Sums a link's clicks into buckets of bucket_seconds from from_time until to_time, from
+	// minutes and compacted hours alike; buckets without clicks are left out.
+	ListClickBuckets(ctx context.Context, arg ListClickBucketsParams) ([]ListClickBucketsRow, error)
+	ListClicksBetween(ctx context.Context, arg ListClicksBetweenParams) ([]ListClicksBetweenRow, error)
+	ListCountryClicks(ctx context.Context, arg ListCountryClicksParams) ([]ListCountryClicksRow, error)
+	ListDestinationRules(ctx context.Context) ([]DestinationRule, error)
+	ListDomains(ctx context.Context) ([]Domain, error)
+	ListExportEvents(ctx context.Context, limit int64) ([]ExportOutbox, error)
+	ListLifecyclePolicies(ctx context.Context) ([]LifecyclePolicy, error)
+	ListLinkVerifications(ctx context.Context) ([]LinkVerification, error)
+	ListOutboxEvents(ctx context.Context, limit int64) ([]EventOutbox, error)
+	ListPolicyActions(ctx context.Context, limit int64) ([]PolicyAction, error)
+	ListReferrerClicks(ctx context.Context, arg ListReferrerClicksParams) ([]ListReferrerClicksRow, error)
+	ListRoutingRules(ctx context.Context, shortCode string) ([]RoutingRule, error)
+	ListTopCountries(ctx context.Context, limit int64) ([]ListTopCountriesRow, error)
+	// Counts each link's clicks since since and in the window before it, from previous_since;
+	// trending orders by the gain between the two. Compacted hours count from their start.
+	ListTopLinks(ctx context.Context, arg ListTopLinksParams) ([]ListTopLinksRow, error)
+	ListTopReferrers(ctx context.Context, limit int64) ([]ListTopReferrersRow, error)
+	ListUTMClicks(ctx context.Context, arg ListUTMClicksParams) ([]ListUTMClicksRow, error)
+	ListUnreachableLinkVerifications(ctx context.Context) ([]LinkVerification, error)
+	ListWebhookDeliveries(ctx context.Context, limit int64) ([]WebhookDelivery, error)
+	ListWebhookDeliveriesByEndpoint(ctx context.Context, arg ListWebhookDeliveriesByEndpointParams) ([]WebhookDelivery, error)
+	ListWebhookEndpoints(ctx context.Context) ([]WebhookEndpoint, error)
+	// Archiving moves the clicks of whole UTC days out of both tables; it starts
+	// from the oldest minute or hour before a cutoff.
+	OldestClickBefore(ctx context.Context, before int64) (int64, error)
+	OverwriteURL(ctx context.Context, arg OverwriteURLParams) error
 	SetCounter(ctx context.Context, arg SetCounterParams) error
+	// A check racing the link's deletion stores nothing.
+	SetLinkVerification(ctx context.Context, arg SetLinkVerificationParams) error
+	SetURLFailover(ctx context.Context, arg SetURLFailoverParams) (Url, error)
+	SumUsage(ctx context.Context) (int64, error)
 	URLExists(ctx context.Context, shortCode string) (int64, error)
-	UpdateUsage(ctx context.Context, arg UpdateUsageParams) error
+	UpdateDomain(ctx context.Context, arg UpdateDomainParams) (int64, error)
+	UpdateURL(ctx context.Context, arg UpdateURLParams) (Url, error)
+	// Max-count wins: a stale writer can never move the count or timestamp backwards.
+	UpdateUsage(ctx context.Context, arg UpdateUsageParams) (sql.NullInt64, error)
 }
 
 var _ Querier = (*Queries)(nil)
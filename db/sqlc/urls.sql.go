@@ -11,20 +11,102 @@ import (
 	"time"
 )
 
+const addUsage = `-- name: AddUsage :one
+UPDATE urls
+SET usage_count = COALESCE(usage_count, 0) + ?1,
+    last_used_at = MAX(COALESCE(last_used_at, ?2), ?2)
+WHERE short_code = ?3
+RETURNING usage_count
+`
+
+type AddUsageParams struct {
+	Delta      int64        `json:"delta"`
+	LastUsedAt sql.NullTime `json:"last_used_at"`
+	ShortCode  string       `json:"short_code"`
+}
+
+// Delta merge: concurrent writers each add the redirects they counted since their last sync.
+func (q *Queries) AddUsage(ctx context.Context, arg AddUsageParams) (sql.NullInt64, error) {
+	row := q.db.QueryRowContext(ctx, addUsage, arg.Delta, arg.LastUsedAt, arg.ShortCode)
+	var usage_count sql.NullInt64
+	err := row.Scan(&usage_count)
+	return usage_count, err
+}
+
+const burnURL = `-- name: BurnURL :execrows
+UPDATE urls
+SET usage_count = 1, last_used_at = ?
+WHERE short_code = ? AND burn_after_reading = 1 AND COALESCE(usage_count, 0) = 0
+`
+
+type BurnURLParams struct {
+	LastUsedAt sql.NullTime `json:"last_used_at"`
+	ShortCode  string       `json:"short_code"`
+}
+
+// Claims the single redirect of a burn-after-reading link; once used, no row matches.
+func (q *Queries) BurnURL(ctx context.Context, arg BurnURLParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, burnURL, arg.LastUsedAt, arg.ShortCode)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const countURLsCreatedSince = `-- name: CountURLsCreatedSince :one
+SELECT COUNT(*) FROM urls
+WHERE created_at >= ?
+`
+
+func (q *Queries) CountURLsCreatedSince(ctx context.Context, createdAt time.Time) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countURLsCreatedSince, createdAt)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createURL = `-- name: CreateURL :one
-INSERT INTO urls (short_code, original_url, created_at, usage_count)
-VALUES (?, ?, ?, 0)
-RETURNING id, short_code, original_url, created_at, last_used_at, usage_count
+INSERT INTO urls (short_code, original_url, created_at, usage_count, max_uses, tags, redirect_status, backup_url, query_params, forward_query, campaign, owner, updated_at, dedupe_seconds, domain, burn_after_reading)
+VALUES (?, ?, ?, 0, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING id, short_code, original_url, created_at, last_used_at, usage_count, max_uses, tags, redirect_status, backup_url, failover_active, failover_reason, failover_changed_at, query_params, forward_query, campaign, owner, updated_at, preview_title, preview_description, preview_favicon_url, preview_error, preview_fetched_at, dedupe_seconds, bots_count, domain, burn_after_reading
 `
 
 type CreateURLParams struct {
-	ShortCode   string    `json:"short_code"`
-	OriginalUrl string    `json:"original_url"`
-	CreatedAt   time.Time `json:"created_at"`
+	ShortCode        string        `json:"short_code"`
+	OriginalUrl      string        `json:"original_url"`
+	CreatedAt        time.Time     `json:"created_at"`
+	MaxUses          sql.NullInt64 `json:"max_uses"`
+	Tags             string        `json:"tags"`
+	RedirectStatus   int64         `json:"redirect_status"`
+	BackupUrl        string        `json:"backup_url"`
+	QueryParams      string        `json:"query_params"`
+	ForwardQuery     bool          `json:"forward_query"`
+	Campaign         string        `json:"campaign"`
+	Owner            string        `json:"owner"`
+	UpdatedAt        sql.NullTime  `json:"updated_at"`
+	DedupeSeconds    int64         `json:"dedupe_seconds"`
+	Domain           string        `json:"domain"`
+	BurnAfterReading bool          `json:"burn_after_reading"`
 }
 
 func (q *Queries) CreateURL(ctx context.Context, arg CreateURLParams) (Url, error) {
-	row := q.db.QueryRowContext(ctx, createURL, arg.ShortCode, arg.OriginalUrl, arg.CreatedAt)
+	row := q.db.QueryRowContext(ctx, createURL,
+		arg.ShortCode,
+		arg.OriginalUrl,
+		arg.CreatedAt,
+		arg.MaxUses,
+		arg.Tags,
+		arg.RedirectStatus,
+		arg.BackupUrl,
+		arg.QueryParams,
+		arg.ForwardQuery,
+		arg.Campaign,
+		arg.Owner,
+		arg.UpdatedAt,
+		arg.DedupeSeconds,
+		arg.Domain,
+		arg.BurnAfterReading,
+	)
 	var i Url
 	err := row.Scan(
 		&i.ID,
@@ -33,6 +115,27 @@ func (q *Queries) CreateURL(ctx context.Context, arg CreateURLParams) (Url, erro
 		&i.CreatedAt,
 		&i.LastUsedAt,
 		&i.UsageCount,
+		&i.MaxUses,
+		&i.Tags,
+		&i.RedirectStatus,
+		&i.BackupUrl,
+		&i.FailoverActive,
+		&i.FailoverReason,
+		&i.FailoverChangedAt,
+		&i.QueryParams,
+		&i.ForwardQuery,
+		&i.Campaign,
+		&i.Owner,
+		&i.UpdatedAt,
+		&i.PreviewTitle,
+		&i.PreviewDescription,
+		&i.PreviewFaviconUrl,
+		&i.PreviewError,
+		&i.PreviewFetchedAt,
+		&i.DedupeSeconds,
+		&i.BotsCount,
+		&i.Domain,
+		&i.BurnAfterReading,
 	)
 	return i, err
 }
@@ -48,7 +151,7 @@ func (q *Queries) DeleteURL(ctx context.Context, shortCode string) error {
 }
 
 const getAllURLs = `-- name: GetAllURLs :many
-SELECT id, short_code, original_url, created_at, last_used_at, usage_count FROM urls
+SELECT id, short_code, original_url, created_at, last_used_at, usage_count, max_uses, tags, redirect_status, backup_url, failover_active, failover_reason, failover_changed_at, query_params, forward_query, campaign, owner, updated_at, preview_title, preview_description, preview_favicon_url, preview_error, preview_fetched_at, dedupe_seconds, bots_count, domain, burn_after_reading FROM urls
 ORDER BY created_at DESC
 `
 
@@ -68,6 +171,27 @@ func (q *Queries) GetAllURLs(ctx context.Context) ([]Url, error) {
 			&i.CreatedAt,
 			&i.LastUsedAt,
 			&i.UsageCount,
+			&i.MaxUses,
+			&i.Tags,
+			&i.RedirectStatus,
+			&i.BackupUrl,
+			&i.FailoverActive,
+			&i.FailoverReason,
+			&i.FailoverChangedAt,
+			&i.QueryParams,
+			&i.ForwardQuery,
+			&i.Campaign,
+			&i.Owner,
+			&i.UpdatedAt,
+			&i.PreviewTitle,
+			&i.PreviewDescription,
+			&i.PreviewFaviconUrl,
+			&i.PreviewError,
+			&i.PreviewFetchedAt,
+			&i.DedupeSeconds,
+			&i.BotsCount,
+			&i.Domain,
+			&i.BurnAfterReading,
 		); err != nil {
 			return nil, err
 		}
@@ -82,8 +206,57 @@ func (q *Queries) GetAllURLs(ctx context.Context) ([]Url, error) {
 	return items, nil
 }
 
+const getReusableURLByOriginalURL = `-- name: GetReusableURLByOriginalURL :one
+SELECT id, short_code, original_url, created_at, last_used_at, usage_count, max_uses, tags, redirect_status, backup_url, failover_active, failover_reason, failover_changed_at, query_params, forward_query, campaign, owner, updated_at, preview_title, preview_description, preview_favicon_url, preview_error, preview_fetched_at, dedupe_seconds, bots_count, domain, burn_after_reading FROM urls
+WHERE original_url = ? AND owner = ? AND max_uses IS NULL AND domain = ''
+ORDER BY created_at, id
+LIMIT 1
+`
+
+type GetReusableURLByOriginalURLParams struct {
+	OriginalUrl string `json:"original_url"`
+	Owner       string `json:"owner"`
+}
+
+// The caller's oldest uncapped link to a destination on the server's own host; capped links can expire, so they are never
+// reused, and links on a custom domain would not redirect on the host the caller expects.
+func (q *Queries) GetReusableURLByOriginalURL(ctx context.Context, arg GetReusableURLByOriginalURLParams) (Url, error) {
+	row := q.db.QueryRowContext(ctx, getReusableURLByOriginalURL, arg.OriginalUrl, arg.Owner)
+	var i Url
+	err := row.Scan(
+		&i.ID,
+		&i.ShortCode,
+		&i.OriginalUrl,
+		&i.CreatedAt,
+		&i.LastUsedAt,
+		&i.UsageCount,
+		&i.MaxUses,
+		&i.Tags,
+		&i.RedirectStatus,
+		&i.BackupUrl,
+		&i.FailoverActive,
+		&i.FailoverReason,
+		&i.FailoverChangedAt,
+		&i.QueryParams,
+		&i.ForwardQuery,
+		&i.Campaign,
+		&i.Owner,
+		&i.UpdatedAt,
+		&i.PreviewTitle,
+		&i.PreviewDescription,
+		&i.PreviewFaviconUrl,
+		&i.PreviewError,
+		&i.PreviewFetchedAt,
+		&i.DedupeSeconds,
+		&i.BotsCount,
+		&i.Domain,
+		&i.BurnAfterReading,
+	)
+	return i, err
+}
+
 const getURL = `-- name: GetURL :one
-SELECT id, short_code, original_url, created_at, last_used_at, usage_count FROM urls
+SELECT id, short_code, original_url, created_at, last_used_at, usage_count, max_uses, tags, redirect_status, backup_url, failover_active, failover_reason, failover_changed_at, query_params, forward_query, campaign, owner, updated_at, preview_title, preview_description, preview_favicon_url, preview_error, preview_fetched_at, dedupe_seconds, bots_count, domain, burn_after_reading FROM urls
 WHERE short_code = ?
 `
 
@@ -97,10 +270,369 @@ func (q *Queries) GetURL(ctx context.Context, shortCode string) (Url, error) {
 		&i.CreatedAt,
 		&i.LastUsedAt,
 		&i.UsageCount,
+		&i.MaxUses,
+		&i.Tags,
+		&i.RedirectStatus,
+		&i.BackupUrl,
+		&i.FailoverActive,
+		&i.FailoverReason,
+		&i.FailoverChangedAt,
+		&i.QueryParams,
+		&i.ForwardQuery,
+		&i.Campaign,
+		&i.Owner,
+		&i.UpdatedAt,
+		&i.PreviewTitle,
+		&i.PreviewDescription,
+		&i.PreviewFaviconUrl,
+		&i.PreviewError,
+		&i.PreviewFetchedAt,
+		&i.DedupeSeconds,
+		&i.BotsCount,
+		&i.Domain,
+		&i.BurnAfterReading,
+	)
+	return i, err
+}
+
+const getURLsByCampaign = `-- name: GetURLsByCampaign :many
+SELECT id, short_code, original_url, created_at, last_used_at, usage_count, max_uses, tags, redirect_status, backup_url, failover_active, failover_reason, failover_changed_at, query_params, forward_query, campaign, owner, updated_at, preview_title, preview_description, preview_favicon_url, preview_error, preview_fetched_at, dedupe_seconds, bots_count, domain, burn_after_reading FROM urls
+WHERE campaign = ?
+ORDER BY created_at DESC
+`
+
+func (q *Queries) GetURLsByCampaign(ctx context.Context, campaign string) ([]Url, error) {
+	rows, err := q.db.QueryContext(ctx, getURLsByCampaign, campaign)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Url{}
+	for rows.Next() {
+		var i Url
+		if err := rows.Scan(
+			&i.ID,
+			&i.ShortCode,
+			&i.OriginalUrl,
+			&i.CreatedAt,
+			&i.LastUsedAt,
+			&i.UsageCount,
+			&i.MaxUses,
+			&i.Tags,
+			&i.RedirectStatus,
+			&i.BackupUrl,
+			&i.FailoverActive,
+			&i.FailoverReason,
+			&i.FailoverChangedAt,
+			&i.QueryParams,
+			&i.ForwardQuery,
+			&i.Campaign,
+			&i.Owner,
+			&i.UpdatedAt,
+			&i.PreviewTitle,
+			&i.PreviewDescription,
+			&i.PreviewFaviconUrl,
+			&i.PreviewError,
+			&i.PreviewFetchedAt,
+			&i.DedupeSeconds,
+			&i.BotsCount,
+			&i.Domain,
+			&i.BurnAfterReading,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getURLsByOwner = `-- name: GetURLsByOwner :many
+SELECT id, short_code, original_url, created_at, last_used_at, usage_count, max_uses, tags, redirect_status, backup_url, failover_active, failover_reason, failover_changed_at, query_params, forward_query, campaign, owner, updated_at, preview_title, preview_description, preview_favicon_url, preview_error, preview_fetched_at, dedupe_seconds, bots_count, domain, burn_after_reading FROM urls
+WHERE owner = ?
+ORDER BY created_at DESC
+`
+
+func (q *Queries) GetURLsByOwner(ctx context.Context, owner string) ([]Url, error) {
+	rows, err := q.db.QueryContext(ctx, getURLsByOwner, owner)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Url{}
+	for rows.Next() {
+		var i Url
+		if err := rows.Scan(
+			&i.ID,
+			&i.ShortCode,
+			&i.OriginalUrl,
+			&i.CreatedAt,
+			&i.LastUsedAt,
+			&i.UsageCount,
+			&i.MaxUses,
+			&i.Tags,
+			&i.RedirectStatus,
+			&i.BackupUrl,
+			&i.FailoverActive,
+			&i.FailoverReason,
+			&i.FailoverChangedAt,
+			&i.QueryParams,
+			&i.ForwardQuery,
+			&i.Campaign,
+			&i.Owner,
+			&i.UpdatedAt,
+			&i.PreviewTitle,
+			&i.PreviewDescription,
+			&i.PreviewFaviconUrl,
+			&i.PreviewError,
+			&i.PreviewFetchedAt,
+			&i.DedupeSeconds,
+			&i.BotsCount,
+			&i.Domain,
+			&i.BurnAfterReading,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const importURL = `-- name: ImportURL :exec
+INSERT INTO urls (short_code, original_url, created_at, last_used_at, usage_count, max_uses, tags, redirect_status, backup_url, query_params, forward_query, campaign, owner, updated_at, dedupe_seconds, bots_count, domain, burn_after_reading)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+`
+
+type ImportURLParams struct {
+	ShortCode        string        `json:"short_code"`
+	OriginalUrl      string        `json:"original_url"`
+	CreatedAt        time.Time     `json:"created_at"`
+	LastUsedAt       sql.NullTime  `json:"last_used_at"`
+	UsageCount       sql.NullInt64 `json:"usage_count"`
+	MaxUses          sql.NullInt64 `json:"max_uses"`
+	Tags             string        `json:"tags"`
+	RedirectStatus   int64         `json:"redirect_status"`
+	BackupUrl        string        `json:"backup_url"`
+	QueryParams      string        `json:"query_params"`
+	ForwardQuery     bool          `json:"forward_query"`
+	Campaign         string        `json:"campaign"`
+	Owner            string        `json:"owner"`
+	UpdatedAt        sql.NullTime  `json:"updated_at"`
+	DedupeSeconds    int64         `json:"dedupe_seconds"`
+	BotsCount        int64         `json:"bots_count"`
+	Domain           string        `json:"domain"`
+	BurnAfterReading bool          `json:"burn_after_reading"`
+}
+
+func (q *Queries) ImportURL(ctx context.Context, arg ImportURLParams) error {
+	_, err := q.db.ExecContext(ctx, importURL,
+		arg.ShortCode,
+		arg.OriginalUrl,
+		arg.CreatedAt,
+		arg.LastUsedAt,
+		arg.UsageCount,
+		arg.MaxUses,
+		arg.Tags,
+		arg.RedirectStatus,
+		arg.BackupUrl,
+		arg.QueryParams,
+		arg.ForwardQuery,
+		arg.Campaign,
+		arg.Owner,
+		arg.UpdatedAt,
+		arg.DedupeSeconds,
+		arg.BotsCount,
+		arg.Domain,
+		arg.BurnAfterReading,
+	)
+	return err
+}
+
+const overwriteURL = `-- name: OverwriteURL :exec
+UPDATE urls
+SET original_url = ?, created_at = ?, last_used_at = ?, usage_count = ?, max_uses = ?, tags = ?, redirect_status = ?, backup_url = ?, query_params = ?, forward_query = ?, campaign = ?, owner = ?, updated_at = ?, dedupe_seconds = ?, bots_count = ?, domain = ?, burn_after_reading = ?
+WHERE short_code = ?
+`
+
+type OverwriteURLParams struct {
+	OriginalUrl      string        `json:"original_url"`
+	CreatedAt        time.Time     `json:"created_at"`
+	LastUsedAt       sql.NullTime  `json:"last_used_at"`
+	UsageCount       sql.NullInt64 `json:"usage_count"`
+	MaxUses          sql.NullInt64 `json:"max_uses"`
+	Tags             string        `json:"tags"`
+	RedirectStatus   int64         `json:"redirect_status"`
+	BackupUrl        string        `json:"backup_url"`
+	QueryParams      string        `json:"query_params"`
+	ForwardQuery     bool          `json:"forward_query"`
+	Campaign         string        `json:"campaign"`
+	Owner            string        `json:"owner"`
+	UpdatedAt        sql.NullTime  `json:"updated_at"`
+	DedupeSeconds    int64         `json:"dedupe_seconds"`
+	BotsCount        int64         `json:"bots_count"`
+	Domain           string        `json:"domain"`
+	BurnAfterReading bool          `json:"burn_after_reading"`
+	ShortCode        string        `json:"short_code"`
+}
+
+func (q *Queries) OverwriteURL(ctx context.Context, arg OverwriteURLParams) error {
+	_, err := q.db.ExecContext(ctx, overwriteURL,
+		arg.OriginalUrl,
+		arg.CreatedAt,
+		arg.LastUsedAt,
+		arg.UsageCount,
+		arg.MaxUses,
+		arg.Tags,
+		arg.RedirectStatus,
+		arg.BackupUrl,
+		arg.QueryParams,
+		arg.ForwardQuery,
+		arg.Campaign,
+		arg.Owner,
+		arg.UpdatedAt,
+		arg.DedupeSeconds,
+		arg.BotsCount,
+		arg.Domain,
+		arg.BurnAfterReading,
+		arg.ShortCode,
+	)
+	return err
+}
+
+const setURLFailover = `-- name: SetURLFailover :one
+UPDATE urls
+SET failover_active = ?, failover_reason = ?, failover_changed_at = ?
+WHERE short_code = ?
+RETURNING id, short_code, original_url, created_at, last_used_at, usage_count, max_uses, tags, redirect_status, backup_url, failover_active, failover_reason, failover_changed_at, query_params, forward_query, campaign, owner, updated_at, preview_title, preview_description, preview_favicon_url, preview_error, preview_fetched_at, dedupe_seconds, bots_count, domain, burn_after_reading
+`
+
+type SetURLFailoverParams struct {
+	FailoverActive    bool         `json:"failover_active"`
+	FailoverReason    string       `json:"failover_reason"`
+	FailoverChangedAt sql.NullTime `json:"failover_changed_at"`
+	ShortCode         string       `json:"short_code"`
+}
+
+func (q *Queries) SetURLFailover(ctx context.Context, arg SetURLFailoverParams) (Url, error) {
+	row := q.db.QueryRowContext(ctx, setURLFailover,
+		arg.FailoverActive,
+		arg.FailoverReason,
+		arg.FailoverChangedAt,
+		arg.ShortCode,
+	)
+	var i Url
+	err := row.Scan(
+		&i.ID,
+		&i.ShortCode,
+		&i.OriginalUrl,
+		&i.CreatedAt,
+		&i.LastUsedAt,
+		&i.UsageCount,
+		&i.MaxUses,
+		&i.Tags,
+		&i.RedirectStatus,
+		&i.BackupUrl,
+		&i.FailoverActive,
+		&i.FailoverReason,
+		&i.FailoverChangedAt,
+		&i.QueryParams,
+		&i.ForwardQuery,
+		&i.Campaign,
+		&i.Owner,
+		&i.UpdatedAt,
+		&i.PreviewTitle,
+		&i.PreviewDescription,
+		&i.PreviewFaviconUrl,
+		&i.PreviewError,
+		&i.PreviewFetchedAt,
+		&i.DedupeSeconds,
+		&i.BotsCount,
+		&i.Domain,
+		&i.BurnAfterReading,
+	)
+	return i, err
+}
+
+const setURLPreview = `-- name: SetURLPreview :one
+UPDATE urls
+SET preview_title = ?, preview_description = ?, preview_favicon_url = ?, preview_error = ?, preview_fetched_at = ?
+WHERE short_code = ?
+RETURNING id, short_code, original_url, created_at, last_used_at, usage_count, max_uses, tags, redirect_status, backup_url, failover_active, failover_reason, failover_changed_at, query_params, forward_query, campaign, owner, updated_at, preview_title, preview_description, preview_favicon_url, preview_error, preview_fetched_at, dedupe_seconds, bots_count, domain, burn_after_reading
+`
+
+type SetURLPreviewParams struct {
+	PreviewTitle       string       `json:"preview_title"`
+	PreviewDescription string       `json:"preview_description"`
+	PreviewFaviconUrl  string       `json:"preview_favicon_url"`
+	PreviewError       string       `json:"preview_error"`
+	PreviewFetchedAt   sql.NullTime `json:"preview_fetched_at"`
+	ShortCode          string       `json:"short_code"`
+}
+
+func (q *Queries) SetURLPreview(ctx context.Context, arg SetURLPreviewParams) (Url, error) {
+	row := q.db.QueryRowContext(ctx, setURLPreview,
+		arg.PreviewTitle,
+		arg.PreviewDescription,
+		arg.PreviewFaviconUrl,
+		arg.PreviewError,
+		arg.PreviewFetchedAt,
+		arg.ShortCode,
+	)
+	var i Url
+	err := row.Scan(
+		&i.ID,
+		&i.ShortCode,
+		&i.OriginalUrl,
+		&i.CreatedAt,
+		&i.LastUsedAt,
+		&i.UsageCount,
+		&i.MaxUses,
+		&i.Tags,
+		&i.RedirectStatus,
+		&i.BackupUrl,
+		&i.FailoverActive,
+		&i.FailoverReason,
+		&i.FailoverChangedAt,
+		&i.QueryParams,
+		&i.ForwardQuery,
+		&i.Campaign,
+		&i.Owner,
+		&i.UpdatedAt,
+		&i.PreviewTitle,
+		&i.PreviewDescription,
+		&i.PreviewFaviconUrl,
+		&i.PreviewError,
+		&i.PreviewFetchedAt,
+		&i.DedupeSeconds,
+		&i.BotsCount,
+		&i.Domain,
+		&i.BurnAfterReading,
 	)
 	return i, err
 }
 
+const sumUsage = `-- name: SumUsage :one
+SELECT CAST(COALESCE(SUM(usage_count), 0) AS INTEGER) AS total_clicks FROM urls
+`
+
+func (q *Queries) SumUsage(ctx context.Context) (int64, error) {
+	row := q.db.QueryRowContext(ctx, sumUsage)
+	var total_clicks int64
+	err := row.Scan(&total_clicks)
+	return total_clicks, err
+}
+
 const uRLExists = `-- name: URLExists :one
 SELECT COUNT(*) FROM urls
 WHERE short_code = ?
@@ -113,10 +645,80 @@ func (q *Queries) URLExists(ctx context.Context, shortCode string) (int64, error
 	return count, err
 }
 
-const updateUsage = `-- name: UpdateUsage :exec
-UPDATE urls 
-SET usage_count = ?, last_used_at = ?
+const updateURL = `-- name: UpdateURL :one
+UPDATE urls
+SET original_url = ?, max_uses = ?, tags = ?, redirect_status = ?, backup_url = ?, query_params = ?, forward_query = ?, campaign = ?, updated_at = ?, dedupe_seconds = ?
 WHERE short_code = ?
+RETURNING id, short_code, original_url, created_at, last_used_at, usage_count, max_uses, tags, redirect_status, backup_url, failover_active, failover_reason, failover_changed_at, query_params, forward_query, campaign, owner, updated_at, preview_title, preview_description, preview_favicon_url, preview_error, preview_fetched_at, dedupe_seconds, bots_count, domain, burn_after_reading
+`
+
+type UpdateURLParams struct {
+	OriginalUrl    string        `json:"original_url"`
+	MaxUses        sql.NullInt64 `json:"max_uses"`
+	Tags           string        `json:"tags"`
+	RedirectStatus int64         `json:"redirect_status"`
+	BackupUrl      string        `json:"backup_url"`
+	QueryParams    string        `json:"query_params"`
+	ForwardQuery   bool          `json:"forward_query"`
+	Campaign       string        `json:"campaign"`
+	UpdatedAt      sql.NullTime  `json:"updated_at"`
+	DedupeSeconds  int64         `json:"dedupe_seconds"`
+	ShortCode      string        `json:"short_code"`
+}
+
+func (q *Queries) UpdateURL(ctx context.Context, arg UpdateURLParams) (Url, error) {
+	row := q.db.QueryRowContext(ctx, updateURL,
+		arg.OriginalUrl,
+		arg.MaxUses,
+		arg.Tags,
+		arg.RedirectStatus,
+		arg.BackupUrl,
+		arg.QueryParams,
+		arg.ForwardQuery,
+		arg.Campaign,
+		arg.UpdatedAt,
+		arg.DedupeSeconds,
+		arg.ShortCode,
+	)
+	var i Url
+	err := row.Scan(
+		&i.ID,
+		&i.ShortCode,
+		&i.OriginalUrl,
+		&i.CreatedAt,
+		&i.LastUsedAt,
+		&i.UsageCount,
+		&i.MaxUses,
+		&i.Tags,
+		&i.RedirectStatus,
+		&i.BackupUrl,
+		&i.FailoverActive,
+		&i.FailoverReason,
+		&i.FailoverChangedAt,
+		&i.QueryParams,
+		&i.ForwardQuery,
+		&i.Campaign,
+		&i.Owner,
+		&i.UpdatedAt,
+		&i.PreviewTitle,
+		&i.PreviewDescription,
+		&i.PreviewFaviconUrl,
+		&i.PreviewError,
+		&i.PreviewFetchedAt,
+		&i.DedupeSeconds,
+		&i.BotsCount,
+		&i.Domain,
+		&i.BurnAfterReading,
+	)
+	return i, err
+}
+
+const updateUsage = `-- name: UpdateUsage :one
+UPDATE urls
+SET usage_count = MAX(COALESCE(usage_count, 0), ?1),
+    last_used_at = MAX(COALESCE(last_used_at, ?2), ?2)
+WHERE short_code = ?3
+RETURNING usage_count
 `
 
 type UpdateUsageParams struct {
@@ -125,7 +727,10 @@ type UpdateUsageParams struct {
 	ShortCode  string        `json:"short_code"`
 }
 
-func (q *Queries) UpdateUsage(ctx context.Context, arg UpdateUsageParams) error {
-	_, err := q.db.ExecContext(ctx, updateUsage, arg.UsageCount, arg.LastUsedAt, arg.ShortCode)
-	return err
+// Max-count wins: a stale writer can never move the count or timestamp backwards.
+func (q *Queries) UpdateUsage(ctx context.Context, arg UpdateUsageParams) (sql.NullInt64, error) {
+	row := q.db.QueryRowContext(ctx, updateUsage, arg.UsageCount, arg.LastUsedAt, arg.ShortCode)
+	var usage_count sql.NullInt64
+	err := row.Scan(&usage_count)
+	return usage_count, err
 }
@@ -0,0 +1,57 @@
+package abuse
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+)
+
+// Config holds click fraud detection configuration
+type Config struct {
+	Window           time.Duration      // Clicks are counted per link in fixed windows of this length
+	MaxClientClicks  int                // Flag a link clicked more often than this in a window from one visitor address; 0 = off
+	MaxNetworkClicks int                // Flag a link clicked more often than this in a window from one network; 0 = off
+	MaxBotClicks     int                // Flag a link clicked more often than this in a window by bots; 0 = off
+	Action           domain.AbuseAction // What happens to a flagged link's redirects
+	ThrottleClicks   int                // Redirects a throttled link serves per window
+	FlagDuration     time.Duration      // How long a flag, and its action, lasts
+}
+
+// DefaultConfig returns the default configuration, which flags nothing
+func DefaultConfig() Config {
+	return Config{
+		Window:         time.Minute,
+		Action:         domain.AbuseActionFlag,
+		ThrottleClicks: 10,
+		FlagDuration:   time.Hour,
+	}
+}
+
+// Enabled reports whether any heuristic is on
+func (c Config) Enabled() bool {
+	return c.MaxClientClicks > 0 || c.MaxNetworkClicks > 0 || c.MaxBotClicks > 0
+}
+
+// Validate checks that the configuration values are usable
+func (c Config) Validate() error {
+	if c.MaxClientClicks < 0 || c.MaxNetworkClicks < 0 || c.MaxBotClicks < 0 {
+		return fmt.Errorf("click thresholds cannot be negative")
+	}
+	if !c.Enabled() {
+		return nil
+	}
+	if c.Window <= 0 {
+		return fmt.Errorf("window must be positive, got: %v", c.Window)
+	}
+	if !domain.ValidAbuseAction(c.Action) {
+		return fmt.Errorf("action must be flag, throttle or disable, got: %q", c.Action)
+	}
+	if c.Action == domain.AbuseActionThrottle && c.ThrottleClicks < 1 {
+		return fmt.Errorf("throttled clicks must be at least 1, got: %d", c.ThrottleClicks)
+	}
+	if c.FlagDuration < c.Window {
+		return fmt.Errorf("flag duration must be at least the window of %v, got: %v", c.Window, c.FlagDuration)
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package abuse
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+)
+
+func TestConfig_Validate(t *testing.T) {
+	assert.NoError(t, DefaultConfig().Validate())
+	assert.False(t, DefaultConfig().Enabled(), "nothing is flagged by default")
+	assert.NoError(t, Config{Window: -time.Second}.Validate(), "settings are unused while off")
+
+	testCases := []struct {
+		name   string
+		modify func(*Config)
+	}{
+		{"negative threshold", func(c *Config) { c.MaxBotClicks = -1 }},
+		{"zero window", func(c *Config) { c.Window = 0 }},
+		{"unknown action", func(c *Config) { c.Action = "block" }},
+		{"no throttled clicks", func(c *Config) { c.Action = domain.AbuseActionThrottle; c.ThrottleClicks = 0 }},
+		{"flag shorter than window", func(c *Config) { c.FlagDuration = time.Second }},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			config := DefaultConfig()
+			config.MaxClientClicks = 100
+			tc.modify(&config)
+			assert.Error(t, config.Validate())
+		})
+	}
+}
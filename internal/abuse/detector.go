@@ -0,0 +1,249 @@
+package abuse
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	"github.com/joshdurbin/url-shortener/internal/service"
+	"github.com/joshdurbin/url-shortener/internal/shardkey"
+)
+
+// maxTrackedSources bounds the click counters held per window; clicks that
+// would add another counter are not counted until the next window, so a flood
+// of distinct visitors cannot exhaust memory. Each shard holds its share.
+const maxTrackedSources = 100_000
+
+// shardCount is how many independently locked parts the detector's state is
+// split into by short code; a power of two
+const shardCount = 64
+
+// sourceKey identifies the clicks of one source on one link: a visitor
+// address, a network or neither (bots)
+type sourceKey struct {
+	shortCode string
+	reason    domain.AbuseReason
+	client    string
+	network   uint32
+}
+
+// shard is one lock and the state of the links whose short codes hash to it.
+// Each shard runs its own windows.
+type shard struct {
+	mu          sync.Mutex
+	windowStart time.Time
+	clicks      map[sourceKey]int
+	served      map[string]int // Redirects of throttled links in the current window
+	flags       map[string]domain.AbuseFlag
+}
+
+// Detector flags links whose redirects look like click fraud: too many clicks
+// within a window from one visitor address, from one network or from bots.
+// Clicks are counted in memory in fixed windows, so each instance judges the
+// traffic it serves. The state is sharded by short code, so redirects of
+// different links rarely wait on each other. A flagged link is reported with a url.flagged event and,
+// depending on the action, throttled or disabled until its flag expires. A
+// nil Detector flags nothing.
+type Detector struct {
+	config   Config
+	notifier service.Notifier // Receives url.flagged events; may be nil
+	now      func() time.Time
+
+	shards  [shardCount]shard
+	dropped atomic.Int64
+}
+
+// New creates a detector publishing url.flagged events to notifier, or
+// returns nil when every heuristic is off
+func New(config Config, notifier service.Notifier) *Detector {
+	if !config.Enabled() {
+		return nil
+	}
+	d := &Detector{
+		config:   config,
+		notifier: notifier,
+		now:      time.Now,
+	}
+	for i := range d.shards {
+		d.shards[i].clicks = make(map[sourceKey]int)
+		d.shards[i].served = make(map[string]int)
+		d.shards[i].flags = make(map[string]domain.AbuseFlag)
+	}
+	return d
+}
+
+// shardFor returns the shard holding a short code's state
+func (d *Detector) shardFor(shortCode string) *shard {
+	return &d.shards[shardkey.Hash(shortCode)&(shardCount-1)]
+}
+
+// Screen counts a redirect of shortCode and returns an error wrapping
+// domain.ErrLinkSuspended when the link's flag does not let it redirect.
+// Peeks are screened but never counted.
+func (d *Detector) Screen(shortCode string, req domain.RedirectRequest) error {
+	if d == nil {
+		return nil
+	}
+
+	now := d.now()
+	s := d.shardFor(shortCode)
+	s.mu.Lock()
+	if now.Sub(s.windowStart) >= d.config.Window {
+		s.windowStart = now
+		clear(s.clicks)
+		clear(s.served)
+	}
+
+	flag, flagged := s.flags[shortCode]
+	if flagged && !now.Before(flag.Until) {
+		delete(s.flags, shortCode)
+		flagged = false
+	}
+	var raised *domain.AbuseFlag
+	if !flagged && !req.Peek {
+		if raised = d.count(s, shortCode, req, now); raised != nil {
+			flag, flagged = *raised, true
+			s.flags[shortCode] = flag
+		}
+	}
+
+	var err error
+	if flagged {
+		switch flag.Action {
+		case domain.AbuseActionDisable:
+			err = fmt.Errorf("short code %s is disabled until %s: %w", shortCode, flag.Until.Format(time.RFC3339), domain.ErrLinkSuspended)
+		case domain.AbuseActionThrottle:
+			if !req.Peek {
+				s.served[shortCode]++
+			}
+			if s.served[shortCode] > d.config.ThrottleClicks {
+				err = fmt.Errorf("short code %s is throttled until %s: %w", shortCode, flag.Until.Format(time.RFC3339), domain.ErrLinkSuspended)
+			}
+		}
+	}
+	s.mu.Unlock()
+
+	if raised != nil {
+		d.report(*raised)
+	}
+	return err
+}
+
+// count adds a click to the counters of its sources in the link's shard and
+// returns the flag it raises, if any. The caller holds the shard's lock.
+func (d *Detector) count(s *shard, shortCode string, req domain.RedirectRequest, now time.Time) *domain.AbuseFlag {
+	checks := [...]struct {
+		key    sourceKey
+		limit  int
+		counts bool
+	}{
+		{sourceKey{shortCode: shortCode, reason: domain.AbuseClientSpike, client: req.ClientIP}, d.config.MaxClientClicks, req.ClientIP != ""},
+		{sourceKey{shortCode: shortCode, reason: domain.AbuseNetworkSpike, network: req.ASN}, d.config.MaxNetworkClicks, req.ASN != 0},
+		{sourceKey{shortCode: shortCode, reason: domain.AbuseBotTraffic}, d.config.MaxBotClicks, req.Device == domain.DeviceBot},
+	}
+	for _, check := range checks {
+		if check.limit <= 0 || !check.counts {
+			continue
+		}
+		clicks, ok := s.clicks[check.key]
+		if !ok && len(s.clicks) >= maxTrackedSources/shardCount {
+			d.dropped.Add(1)
+			continue
+		}
+		clicks++
+		s.clicks[check.key] = clicks
+		if clicks > check.limit {
+			source := check.key.client
+			if check.key.network != 0 {
+				source = fmt.Sprintf("AS%d", check.key.network)
+			}
+			return &domain.AbuseFlag{
+				ShortCode: shortCode,
+				Reason:    check.key.reason,
+				Source:    source,
+				Clicks:    clicks,
+				Action:    d.config.Action,
+				FlaggedAt: now,
+				Until:     now.Add(d.config.FlagDuration),
+			}
+		}
+	}
+	return nil
+}
+
+// report logs a new flag and publishes it as a url.flagged event
+func (d *Detector) report(flag domain.AbuseFlag) {
+	reason := fmt.Sprintf("%s: %d clicks within %v", flag.Reason, flag.Clicks, d.config.Window)
+	if flag.Source != "" {
+		reason = fmt.Sprintf("%s: %d clicks from %s within %v", flag.Reason, flag.Clicks, flag.Source, d.config.Window)
+	}
+	log.Printf("Abuse: flagged %s (%s); action %s until %s", flag.ShortCode, reason, flag.Action, flag.Until.Format(time.RFC3339))
+	if d.notifier != nil {
+		d.notifier.Notify(domain.NewEvent(domain.EventURLFlagged, domain.EventData{
+			ShortCode: flag.ShortCode,
+			Reason:    reason,
+		}))
+	}
+}
+
+// Flags returns the links flagged now, most recently flagged first
+func (d *Detector) Flags() []domain.AbuseFlag {
+	if d == nil {
+		return nil
+	}
+
+	now := d.now()
+	flags := []domain.AbuseFlag{}
+	for i := range d.shards {
+		s := &d.shards[i]
+		s.mu.Lock()
+		for shortCode, flag := range s.flags {
+			if !now.Before(flag.Until) {
+				delete(s.flags, shortCode)
+				continue
+			}
+			flags = append(flags, flag)
+		}
+		s.mu.Unlock()
+	}
+	sort.Slice(flags, func(i, j int) bool {
+		if !flags[i].FlaggedAt.Equal(flags[j].FlaggedAt) {
+			return flags[i].FlaggedAt.After(flags[j].FlaggedAt)
+		}
+		return flags[i].ShortCode < flags[j].ShortCode
+	})
+	return flags
+}
+
+// Clear removes a link's flag, lifting its throttling or disabling. Its clicks
+// in the current window still count toward the next flag.
+func (d *Detector) Clear(shortCode string) error {
+	if d == nil {
+		return domain.ErrAbuseFlagNotFound
+	}
+
+	s := d.shardFor(shortCode)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	flag, ok := s.flags[shortCode]
+	if !ok || !d.now().Before(flag.Until) {
+		delete(s.flags, shortCode)
+		return domain.ErrAbuseFlagNotFound
+	}
+	delete(s.flags, shortCode)
+	delete(s.served, shortCode)
+	return nil
+}
+
+// Dropped returns how many clicks went uncounted because the counters were full
+func (d *Detector) Dropped() int64 {
+	if d == nil {
+		return 0
+	}
+	return d.dropped.Load()
+}
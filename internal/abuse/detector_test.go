@@ -0,0 +1,245 @@
+package abuse
+
+import (
+	"math"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+)
+
+// recordingNotifier collects the events it is notified of
+type recordingNotifier struct {
+	events []domain.Event
+}
+
+func (r *recordingNotifier) Notify(event domain.Event) {
+	r.events = append(r.events, event)
+}
+
+// newTestDetector returns a detector whose clock is advanced by the returned
+// function
+func newTestDetector(config Config, notifier *recordingNotifier) (*Detector, func(time.Duration)) {
+	d := New(config, notifier)
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	d.now = func() time.Time { return now }
+	return d, func(by time.Duration) { now = now.Add(by) }
+}
+
+func TestNew_Disabled(t *testing.T) {
+	var d *Detector
+	assert.Nil(t, New(DefaultConfig(), nil))
+	assert.NoError(t, d.Screen("abc123", domain.RedirectRequest{ClientIP: "203.0.113.7"}))
+	assert.Empty(t, d.Flags())
+	assert.ErrorIs(t, d.Clear("abc123"), domain.ErrAbuseFlagNotFound)
+	assert.Zero(t, d.Dropped())
+}
+
+func TestDetector_ClientSpike(t *testing.T) {
+	config := DefaultConfig()
+	config.MaxClientClicks = 3
+	notifier := &recordingNotifier{}
+	d, advance := newTestDetector(config, notifier)
+
+	visitor := domain.RedirectRequest{ClientIP: "203.0.113.7"}
+	for i := 0; i < 3; i++ {
+		require.NoError(t, d.Screen("abc123", visitor))
+	}
+	// Other visitors and links are counted apart
+	require.NoError(t, d.Screen("abc123", domain.RedirectRequest{ClientIP: "198.51.100.1"}))
+	require.NoError(t, d.Screen("def456", visitor))
+	// Peeks are never counted
+	require.NoError(t, d.Screen("abc123", domain.RedirectRequest{ClientIP: "203.0.113.7", Peek: true}))
+	assert.Empty(t, d.Flags())
+
+	// The flag only reports the link, which keeps redirecting
+	require.NoError(t, d.Screen("abc123", visitor))
+	flags := d.Flags()
+	require.Len(t, flags, 1)
+	assert.Equal(t, domain.AbuseClientSpike, flags[0].Reason)
+	assert.Equal(t, "203.0.113.7", flags[0].Source)
+	assert.Equal(t, 4, flags[0].Clicks)
+	assert.Equal(t, domain.AbuseActionFlag, flags[0].Action)
+	require.NoError(t, d.Screen("abc123", visitor))
+
+	require.Len(t, notifier.events, 1, "a link is reported once per flag")
+	assert.Equal(t, domain.EventURLFlagged, notifier.events[0].Type)
+	assert.Equal(t, "abc123", notifier.events[0].Data.ShortCode)
+	assert.Equal(t, "client_spike: 4 clicks from 203.0.113.7 within 1m0s", notifier.events[0].Data.Reason)
+
+	// Clicks are counted afresh each window
+	advance(config.Window)
+	require.NoError(t, d.Screen("def456", visitor))
+	assert.Len(t, d.Flags(), 1)
+
+	// The flag expires
+	advance(config.FlagDuration)
+	assert.Empty(t, d.Flags())
+}
+
+func TestDetector_NetworkAndBots(t *testing.T) {
+	config := DefaultConfig()
+	config.MaxNetworkClicks = 2
+	config.MaxBotClicks = 2
+	d, advance := newTestDetector(config, &recordingNotifier{})
+
+	// Clicks without a known network are not counted against one
+	for i := 0; i < 3; i++ {
+		require.NoError(t, d.Screen("abc123", domain.RedirectRequest{ClientIP: "203.0.113.7"}))
+	}
+	for i := 0; i < 3; i++ {
+		require.NoError(t, d.Screen("abc123", domain.RedirectRequest{ASN: 64496}))
+	}
+	advance(time.Second)
+	for i := 0; i < 3; i++ {
+		require.NoError(t, d.Screen("def456", domain.RedirectRequest{Device: domain.DeviceBot}))
+	}
+
+	flags := d.Flags()
+	require.Len(t, flags, 2)
+	assert.Equal(t, "def456", flags[0].ShortCode, "most recently flagged first")
+	assert.Equal(t, domain.AbuseBotTraffic, flags[0].Reason)
+	assert.Empty(t, flags[0].Source)
+	assert.Equal(t, domain.AbuseNetworkSpike, flags[1].Reason)
+	assert.Equal(t, "AS64496", flags[1].Source)
+}
+
+func TestDetector_Disable(t *testing.T) {
+	config := DefaultConfig()
+	config.MaxClientClicks = 1
+	config.Action = domain.AbuseActionDisable
+	d, advance := newTestDetector(config, &recordingNotifier{})
+
+	visitor := domain.RedirectRequest{ClientIP: "203.0.113.7"}
+	require.NoError(t, d.Screen("abc123", visitor))
+	assert.ErrorIs(t, d.Screen("abc123", visitor), domain.ErrLinkSuspended)
+	assert.ErrorIs(t, d.Screen("abc123", domain.RedirectRequest{ClientIP: "198.51.100.1"}), domain.ErrLinkSuspended,
+		"a disabled link redirects no one")
+
+	// Clearing the flag lifts it
+	require.NoError(t, d.Clear("abc123"))
+	assert.ErrorIs(t, d.Clear("abc123"), domain.ErrAbuseFlagNotFound)
+	advance(config.Window)
+	assert.NoError(t, d.Screen("abc123", visitor))
+}
+
+func TestDetector_Throttle(t *testing.T) {
+	config := DefaultConfig()
+	config.MaxClientClicks = 1
+	config.Action = domain.AbuseActionThrottle
+	config.ThrottleClicks = 2
+	d, advance := newTestDetector(config, &recordingNotifier{})
+
+	visitor := domain.RedirectRequest{ClientIP: "203.0.113.7"}
+	require.NoError(t, d.Screen("abc123", visitor))
+	// The redirect that raised the flag is the first throttled one
+	require.NoError(t, d.Screen("abc123", visitor))
+	require.NoError(t, d.Screen("abc123", visitor))
+	assert.ErrorIs(t, d.Screen("abc123", visitor), domain.ErrLinkSuspended)
+
+	// Each window serves the throttled number again
+	advance(config.Window)
+	require.NoError(t, d.Screen("abc123", visitor))
+	require.NoError(t, d.Screen("abc123", visitor))
+	assert.ErrorIs(t, d.Screen("abc123", visitor), domain.ErrLinkSuspended)
+
+	// Until the flag expires
+	advance(config.FlagDuration)
+	assert.NoError(t, d.Screen("abc123", visitor))
+}
+
+func TestDetector_Dropped(t *testing.T) {
+	config := DefaultConfig()
+	config.MaxClientClicks = 10
+	d, _ := newTestDetector(config, &recordingNotifier{})
+
+	// The first click starts the window the full counters belong to
+	require.NoError(t, d.Screen("abc123", domain.RedirectRequest{ClientIP: "198.51.100.1"}))
+	s := d.shardFor("abc123")
+	for i := 0; i < maxTrackedSources/shardCount; i++ {
+		s.clicks[sourceKey{shortCode: "abc123", reason: domain.AbuseClientSpike, client: strconv.Itoa(i)}] = 1
+	}
+	require.NoError(t, d.Screen("abc123", domain.RedirectRequest{ClientIP: "203.0.113.7"}))
+	assert.Equal(t, int64(1), d.Dropped())
+}
+
+func TestDetector_ShardsByShortCode(t *testing.T) {
+	config := DefaultConfig()
+	config.MaxClientClicks = 1
+	d, _ := newTestDetector(config, &recordingNotifier{})
+
+	// Links in different shards are counted and flagged apart
+	codes := []string{"abc123", "def456", "ghi789"}
+	for _, code := range codes {
+		visitor := domain.RedirectRequest{ClientIP: "203.0.113.7"}
+		require.NoError(t, d.Screen(code, visitor))
+		require.NoError(t, d.Screen(code, visitor))
+	}
+	assert.NotSame(t, d.shardFor("abc123"), d.shardFor("def456"))
+	assert.Len(t, d.Flags(), len(codes))
+
+	require.NoError(t, d.Clear("def456"))
+	flags := d.Flags()
+	require.Len(t, flags, 2)
+	for _, flag := range flags {
+		assert.NotEqual(t, "def456", flag.ShortCode)
+	}
+}
+
+// BenchmarkDetector_Screen measures screening redirects of many links from
+// many visitors across all cores; it adds to the service's
+// BenchmarkURLShortener_GetOriginalURL when click fraud detection is on
+func BenchmarkDetector_Screen(b *testing.B) {
+	config := DefaultConfig()
+	config.MaxClientClicks = math.MaxInt
+	config.MaxNetworkClicks = math.MaxInt
+	d := New(config, nil)
+
+	shortCodes := make([]string, 10000)
+	clients := make([]string, 256)
+	for i := range shortCodes {
+		shortCodes[i] = "code" + strconv.Itoa(i)
+	}
+	for i := range clients {
+		clients[i] = "203.0.113." + strconv.Itoa(i)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			req := domain.RedirectRequest{ClientIP: clients[i%len(clients)], ASN: uint32(64496 + i%16)}
+			if err := d.Screen(shortCodes[i%len(shortCodes)], req); err != nil {
+				b.Fatal(err)
+			}
+			i++
+		}
+	})
+	b.ReportMetric(float64(b.N)/b.Elapsed().Seconds(), "redirects/s")
+}
+
+// BenchmarkDetector_Screen_HotLink measures every core screening redirects
+// of the same link, which all take one shard's lock
+func BenchmarkDetector_Screen_HotLink(b *testing.B) {
+	config := DefaultConfig()
+	config.MaxClientClicks = math.MaxInt
+	config.MaxNetworkClicks = math.MaxInt
+	d := New(config, nil)
+	req := domain.RedirectRequest{ClientIP: "203.0.113.7", ASN: 64496}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if err := d.Screen("abc123", req); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.ReportMetric(float64(b.N)/b.Elapsed().Seconds(), "redirects/s")
+}
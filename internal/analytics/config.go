@@ -0,0 +1,62 @@
+package analytics
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+)
+
+// Report limits
+const (
+	DefaultReportLimit = 10  // Rows per report section when no limit is requested
+	MaxReportLimit     = 100 // Most rows a report section may return
+)
+
+// Top links windows
+const (
+	DefaultTopWindow = 24 * time.Hour      // Window of the top links report when none is requested
+	MinTopWindow     = time.Minute         // Clicks are counted per minute
+	MaxTopWindow     = 30 * 24 * time.Hour // Longest window of the top links report
+)
+
+// MaxTimeSeriesBuckets is the most buckets a time series may return, a day of minutes
+const MaxTimeSeriesBuckets = 1440
+
+// DefaultTimeSeriesSpan returns how far back a time series reaches when no
+// start is requested: an hour of minutes, a day of hours or 30 days
+func DefaultTimeSeriesSpan(interval domain.TimeSeriesInterval) time.Duration {
+	switch interval {
+	case domain.IntervalMinute:
+		return time.Hour
+	case domain.IntervalDay:
+		return 30 * 24 * time.Hour
+	default:
+		return 24 * time.Hour
+	}
+}
+
+// Config holds click analytics configuration
+type Config struct {
+	FlushInterval   time.Duration // How often recorded clicks are added to the rollups; 0 disables analytics
+	MinuteRetention time.Duration // How long clicks are kept per minute before being compacted into hours; 0 keeps them
+}
+
+// DefaultConfig returns the default configuration
+func DefaultConfig() Config {
+	return Config{
+		FlushInterval:   10 * time.Second,
+		MinuteRetention: 7 * 24 * time.Hour,
+	}
+}
+
+// Validate checks that the configuration values are usable
+func (c Config) Validate() error {
+	if c.FlushInterval < 0 {
+		return fmt.Errorf("flush interval cannot be negative, got: %v", c.FlushInterval)
+	}
+	if c.MinuteRetention < 0 || (c.MinuteRetention > 0 && c.MinuteRetention < time.Hour) {
+		return fmt.Errorf("minute retention must be 0 or at least 1h, got: %v", c.MinuteRetention)
+	}
+	return nil
+}
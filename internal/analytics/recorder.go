@@ -0,0 +1,463 @@
+package analytics
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	"github.com/joshdurbin/url-shortener/internal/drain"
+	"github.com/joshdurbin/url-shortener/internal/repository"
+)
+
+// Pending rollup limits
+const (
+	// maxPendingRows bounds the distinct rollup rows held between flushes;
+	// clicks that would add another row are dropped until the next flush
+	maxPendingRows = 50_000
+	// closeTimeout bounds the final flush when the recorder is closed
+	closeTimeout = 30 * time.Second
+	// compactInterval is how often minutes past the retention are compacted
+	compactInterval = time.Hour
+)
+
+// referrerKey identifies a referrer rollup row
+type referrerKey struct {
+	shortCode string
+	referrer  string
+}
+
+// utmKey identifies a UTM rollup row
+type utmKey struct {
+	shortCode string
+	utm       domain.UTMParams
+}
+
+// countryKey identifies a country rollup row
+type countryKey struct {
+	shortCode string
+	country   string
+	region    string
+}
+
+// minuteKey identifies a per-minute rollup row
+type minuteKey struct {
+	shortCode string
+	minute    int64 // Unix seconds
+}
+
+// pendingClicks are the clicks recorded since the last flush
+type pendingClicks struct {
+	referrers map[referrerKey]int
+	utm       map[utmKey]int
+	countries map[countryKey]int
+	minutes   map[minuteKey]int
+}
+
+// newPendingClicks returns an empty set of pending clicks
+func newPendingClicks() pendingClicks {
+	return pendingClicks{
+		referrers: make(map[referrerKey]int),
+		utm:       make(map[utmKey]int),
+		countries: make(map[countryKey]int),
+		minutes:   make(map[minuteKey]int),
+	}
+}
+
+// rows returns the number of rollup rows the clicks add to
+func (p pendingClicks) rows() int {
+	return len(p.referrers) + len(p.utm) + len(p.countries) + len(p.minutes)
+}
+
+// add counts clicks into p from other
+func (p pendingClicks) add(other pendingClicks) {
+	for key, clicks := range other.referrers {
+		p.referrers[key] += clicks
+	}
+	for key, clicks := range other.utm {
+		p.utm[key] += clicks
+	}
+	for key, clicks := range other.countries {
+		p.countries[key] += clicks
+	}
+	for key, clicks := range other.minutes {
+		p.minutes[key] += clicks
+	}
+}
+
+// rollups converts the clicks to rollup rows
+func (p pendingClicks) rollups() domain.ClickRollups {
+	rollups := domain.ClickRollups{
+		Referrers: make([]domain.ReferrerClicks, 0, len(p.referrers)),
+		UTM:       make([]domain.UTMClicks, 0, len(p.utm)),
+		Countries: make([]domain.CountryClicks, 0, len(p.countries)),
+		Minutes:   make([]domain.MinuteClicks, 0, len(p.minutes)),
+	}
+	for key, clicks := range p.referrers {
+		rollups.Referrers = append(rollups.Referrers, domain.ReferrerClicks{ShortCode: key.shortCode, Referrer: key.referrer, Clicks: clicks})
+	}
+	for key, clicks := range p.utm {
+		rollups.UTM = append(rollups.UTM, domain.UTMClicks{ShortCode: key.shortCode, UTMParams: key.utm, Clicks: clicks})
+	}
+	for key, clicks := range p.countries {
+		rollups.Countries = append(rollups.Countries, domain.CountryClicks{ShortCode: key.shortCode, Country: key.country, Region: key.region, Clicks: clicks})
+	}
+	for key, clicks := range p.minutes {
+		rollups.Minutes = append(rollups.Minutes, domain.MinuteClicks{ShortCode: key.shortCode, Minute: time.Unix(key.minute, 0).UTC(), Clicks: clicks})
+	}
+	return rollups
+}
+
+// Recorder rolls counted clicks up by referring domain, UTM parameters,
+// visitor country and minute. It takes the clicks from url.clicked events on
+// the event bus.
+// Clicks are counted in memory and added to the stored rollups every flush
+// interval, so a redirect never waits on the database. A nil Recorder
+// records nothing.
+type Recorder struct {
+	config Config
+	store  repository.AnalyticsRepository
+
+	mu      sync.Mutex
+	pending pendingClicks
+	dropped atomic.Int64
+
+	flushMu   sync.Mutex // Serializes flushes so a failed one can put its clicks back
+	gate      drain.Gate // Holds flushes and compaction while the server is drained
+	compacted time.Time  // When the flush loop last compacted minutes
+	started   bool
+	closed    bool
+	stopChan  chan struct{}
+	wg        sync.WaitGroup
+}
+
+// New creates a recorder, or returns nil when analytics are disabled
+func New(config Config, store repository.AnalyticsRepository) *Recorder {
+	if config.FlushInterval <= 0 {
+		return nil
+	}
+	return &Recorder{
+		config:   config,
+		store:    store,
+		pending:  newPendingClicks(),
+		stopChan: make(chan struct{}),
+	}
+}
+
+// RecordClick counts a click until the next flush
+func (r *Recorder) RecordClick(click domain.Click) {
+	if r == nil {
+		return
+	}
+
+	at := click.At
+	if at.IsZero() {
+		at = time.Now()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	mk := minuteKey{shortCode: click.ShortCode, minute: at.Truncate(time.Minute).Unix()}
+	rk := referrerKey{shortCode: click.ShortCode, referrer: click.Referrer}
+	uk := utmKey{shortCode: click.ShortCode, utm: click.UTM}
+	ck := countryKey{shortCode: click.ShortCode, country: click.Country, region: click.Region}
+	rows := r.pending.rows()
+	if _, ok := r.pending.referrers[rk]; !ok {
+		rows++
+	}
+	if _, ok := r.pending.utm[uk]; !ok && !click.UTM.Empty() {
+		rows++
+	}
+	if _, ok := r.pending.countries[ck]; !ok && click.Country != "" {
+		rows++
+	}
+	if _, ok := r.pending.minutes[mk]; !ok {
+		rows++
+	}
+	if rows > maxPendingRows {
+		r.dropped.Add(1)
+		return
+	}
+
+	r.pending.referrers[rk]++
+	if !click.UTM.Empty() {
+		r.pending.utm[uk]++
+	}
+	if click.Country != "" {
+		r.pending.countries[ck]++
+	}
+	r.pending.minutes[mk]++
+}
+
+// Notify records the click behind a url.clicked event, so the recorder can
+// subscribe to the event bus
+func (r *Recorder) Notify(event domain.Event) {
+	if event.Type == domain.EventURLClicked && event.Click != nil {
+		r.RecordClick(*event.Click)
+	}
+}
+
+// Dropped returns the clicks dropped because too many rollup rows were pending
+func (r *Recorder) Dropped() int64 {
+	if r == nil {
+		return 0
+	}
+	return r.dropped.Load()
+}
+
+// Start starts flushing recorded clicks every flush interval
+func (r *Recorder) Start(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.started {
+		return fmt.Errorf("analytics recorder already started")
+	}
+	r.started = true
+
+	r.wg.Add(1)
+	go r.flushLoop()
+	return nil
+}
+
+// Close stops the flush loop and flushes the clicks recorded since the last flush
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return nil
+	}
+	r.closed = true
+	close(r.stopChan)
+	r.mu.Unlock()
+
+	r.wg.Wait()
+
+	// The final flush is written even during a drain, so no clicks are lost
+	ctx, cancel := context.WithTimeout(context.Background(), closeTimeout)
+	defer cancel()
+	return r.flush(ctx)
+}
+
+// Pause holds flushes and compaction until Resume, then flushes the clicks
+// recorded so far, so the stored rollups stay put during a drain. Clicks are
+// still counted in memory meanwhile; pausing again flushes them.
+func (r *Recorder) Pause(ctx context.Context) error {
+	if r == nil {
+		return nil
+	}
+	if err := r.gate.Pause(ctx); err != nil {
+		return err
+	}
+	return r.flush(ctx)
+}
+
+// Resume lets flushes and compaction write again
+func (r *Recorder) Resume() {
+	if r == nil {
+		return
+	}
+	r.gate.Resume()
+}
+
+// flushLoop flushes recorded clicks until the recorder is closed
+func (r *Recorder) flushLoop() {
+	defer r.wg.Done()
+
+	ticker := time.NewTicker(r.config.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := r.Flush(context.Background()); err != nil {
+				log.Printf("Failed to flush click analytics: %v", err)
+			}
+			if time.Since(r.compacted) >= compactInterval {
+				r.compacted = time.Now()
+				if _, err := r.Compact(context.Background()); err != nil {
+					log.Printf("Failed to compact click analytics: %v", err)
+				}
+			}
+		case <-r.stopChan:
+			return
+		}
+	}
+}
+
+// Flush adds the clicks recorded since the last flush to the stored rollups.
+// When the store fails, or the recorder is paused, the clicks are kept for
+// the next flush.
+func (r *Recorder) Flush(ctx context.Context) error {
+	if r == nil || !r.gate.TryEnter() {
+		return nil
+	}
+	defer r.gate.Leave()
+	return r.flush(ctx)
+}
+
+// flush adds the pending clicks to the stored rollups, paused or not
+func (r *Recorder) flush(ctx context.Context) error {
+	r.flushMu.Lock()
+	defer r.flushMu.Unlock()
+
+	r.mu.Lock()
+	pending := r.pending
+	r.pending = newPendingClicks()
+	r.mu.Unlock()
+
+	if pending.rows() == 0 {
+		return nil
+	}
+
+	if err := r.store.AddClickRollups(ctx, pending.rollups()); err != nil {
+		// Put the clicks back for the next flush
+		r.mu.Lock()
+		r.pending.add(pending)
+		r.mu.Unlock()
+		return fmt.Errorf("failed to flush %d click rollups: %w", pending.rows(), err)
+	}
+	return nil
+}
+
+// Compact sums the per-minute clicks older than the minute retention into
+// hourly rows, so time series and top links queries over old clicks stay
+// fast. It returns how many minutes were compacted; none while the recorder
+// is paused.
+func (r *Recorder) Compact(ctx context.Context) (int64, error) {
+	if r == nil || r.config.MinuteRetention <= 0 || !r.gate.TryEnter() {
+		return 0, nil
+	}
+	defer r.gate.Leave()
+	before := time.Now().UTC().Add(-r.config.MinuteRetention).Truncate(time.Hour)
+	compacted, err := r.store.CompactClickEvents(ctx, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to compact clicks before %s: %w", before.Format(time.RFC3339), err)
+	}
+	return compacted, nil
+}
+
+// ReferrerReport returns a link's clicks by referring domain and by UTM
+// parameters, up to limit rows each. Pending clicks are flushed first, so the
+// report is current.
+func (r *Recorder) ReferrerReport(ctx context.Context, shortCode string, limit int) (*domain.ReferrerReport, error) {
+	if err := r.Flush(ctx); err != nil {
+		return nil, err
+	}
+
+	referrers, err := r.store.ListReferrerClicks(ctx, shortCode, limit)
+	if err != nil {
+		return nil, err
+	}
+	utm, err := r.store.ListUTMClicks(ctx, shortCode, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.ReferrerReport{ShortCode: shortCode, Referrers: referrers, UTM: utm}, nil
+}
+
+// TopReferrers returns the referring domains with the most clicks across all
+// links, up to limit. Pending clicks are flushed first.
+func (r *Recorder) TopReferrers(ctx context.Context, limit int) (*domain.TopReferrersResponse, error) {
+	if err := r.Flush(ctx); err != nil {
+		return nil, err
+	}
+
+	referrers, err := r.store.ListTopReferrers(ctx, limit)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.TopReferrersResponse{Referrers: referrers}, nil
+}
+
+// CountryReport returns a link's clicks by visitor country and region, up to
+// limit rows. Pending clicks are flushed first.
+func (r *Recorder) CountryReport(ctx context.Context, shortCode string, limit int) (*domain.CountryReport, error) {
+	if err := r.Flush(ctx); err != nil {
+		return nil, err
+	}
+
+	countries, err := r.store.ListCountryClicks(ctx, shortCode, limit)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.CountryReport{ShortCode: shortCode, Countries: countries}, nil
+}
+
+// TopCountries returns the countries with the most clicks across all links,
+// up to limit. Pending clicks are flushed first.
+func (r *Recorder) TopCountries(ctx context.Context, limit int) (*domain.TopCountriesResponse, error) {
+	if err := r.Flush(ctx); err != nil {
+		return nil, err
+	}
+
+	countries, err := r.store.ListTopCountries(ctx, limit)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.TopCountriesResponse{Countries: countries}, nil
+}
+
+// TopLinks returns the links with the most clicks over the last window, or
+// with the largest gain over the window before it when sorting by trending,
+// up to limit. Windows start on a minute boundary. Pending clicks are flushed
+// first.
+func (r *Recorder) TopLinks(ctx context.Context, window time.Duration, sort domain.TopLinksSort, limit int) (*domain.TopLinksResponse, error) {
+	if err := r.Flush(ctx); err != nil {
+		return nil, err
+	}
+
+	since := time.Now().UTC().Add(-window).Truncate(time.Minute)
+	links, err := r.store.ListTopLinks(ctx, since, since.Add(-window), sort, limit)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.TopLinksResponse{Window: domain.Age(window), Since: since, Sort: sort, Links: links}, nil
+}
+
+// TimeSeries returns a link's clicks per interval from from until to, both
+// widened to whole buckets, including buckets without clicks. Buckets older
+// than the minute retention hold whole hours, so minute buckets there show
+// each hour's clicks in its first minute. Pending clicks are flushed first.
+func (r *Recorder) TimeSeries(ctx context.Context, shortCode string, interval domain.TimeSeriesInterval, from, to time.Time) (*domain.TimeSeries, error) {
+	if err := r.Flush(ctx); err != nil {
+		return nil, err
+	}
+
+	size := interval.Duration()
+	from = from.UTC().Truncate(size)
+	if aligned := to.UTC().Truncate(size); aligned.Before(to) {
+		to = aligned.Add(size)
+	} else {
+		to = aligned
+	}
+
+	clicks, err := r.store.ListClickBuckets(ctx, shortCode, from, to, size)
+	if err != nil {
+		return nil, err
+	}
+
+	series := &domain.TimeSeries{
+		ShortCode: shortCode,
+		Interval:  interval,
+		From:      from,
+		To:        to,
+		Buckets:   make([]domain.TimeSeriesBucket, 0, to.Sub(from)/size),
+	}
+	next := 0
+	for start := from; start.Before(to); start = start.Add(size) {
+		bucket := domain.TimeSeriesBucket{Start: start}
+		if next < len(clicks) && clicks[next].Start.Equal(start) {
+			bucket.Clicks = clicks[next].Clicks
+			next++
+		}
+		series.Total += bucket.Clicks
+		series.Buckets = append(series.Buckets, bucket)
+	}
+	return series, nil
+}
@@ -0,0 +1,269 @@
+package analytics
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	"github.com/joshdurbin/url-shortener/internal/repository/mocks"
+)
+
+func TestNew_Disabled(t *testing.T) {
+	recorder := New(Config{}, new(mocks.AnalyticsRepository))
+	assert.Nil(t, recorder)
+
+	// A nil recorder records nothing
+	recorder.RecordClick(domain.Click{ShortCode: "abc123", Referrer: domain.DirectReferrer})
+	assert.NoError(t, recorder.Flush(context.Background()))
+	assert.Zero(t, recorder.Dropped())
+}
+
+func TestRecorder_Flush(t *testing.T) {
+	store := new(mocks.AnalyticsRepository)
+	recorder := New(DefaultConfig(), store)
+	ctx := context.Background()
+
+	newsletter := domain.UTMParams{Source: "newsletter"}
+	recorder.RecordClick(domain.Click{ShortCode: "abc123", Referrer: "twitter.com", UTM: newsletter, Country: "US", Region: "CA"})
+	recorder.RecordClick(domain.Click{ShortCode: "abc123", Referrer: "twitter.com", Country: "US", Region: "CA"})
+	recorder.RecordClick(domain.Click{ShortCode: "def456", Referrer: domain.DirectReferrer})
+
+	var rollups domain.ClickRollups
+	store.On("AddClickRollups", ctx, mock.Anything).Run(func(args mock.Arguments) {
+		rollups = args.Get(1).(domain.ClickRollups)
+	}).Return(nil).Once()
+
+	require.NoError(t, recorder.Flush(ctx))
+	assert.ElementsMatch(t, []domain.ReferrerClicks{
+		{ShortCode: "abc123", Referrer: "twitter.com", Clicks: 2},
+		{ShortCode: "def456", Referrer: domain.DirectReferrer, Clicks: 1},
+	}, rollups.Referrers)
+	assert.Equal(t, []domain.UTMClicks{{ShortCode: "abc123", UTMParams: newsletter, Clicks: 1}}, rollups.UTM)
+	assert.Equal(t, []domain.CountryClicks{{ShortCode: "abc123", Country: "US", Region: "CA", Clicks: 2}}, rollups.Countries,
+		"clicks from unknown countries are not rolled up")
+	require.Len(t, rollups.Minutes, 2, "clicks without a time count in the current minute")
+	for _, minute := range rollups.Minutes {
+		assert.WithinDuration(t, time.Now(), minute.Minute, 2*time.Minute)
+		assert.Zero(t, minute.Minute.Second())
+	}
+
+	// Nothing is pending after a flush
+	require.NoError(t, recorder.Flush(ctx))
+	store.AssertExpectations(t)
+}
+
+func TestRecorder_Notify(t *testing.T) {
+	store := new(mocks.AnalyticsRepository)
+	recorder := New(DefaultConfig(), store)
+	ctx := context.Background()
+	at := time.Date(2024, 5, 1, 12, 30, 15, 0, time.UTC)
+
+	clicked := domain.NewEvent(domain.EventURLClicked, domain.EventData{ShortCode: "abc123"})
+	clicked.Click = &domain.Click{ShortCode: "abc123", Referrer: "twitter.com", At: at}
+	recorder.Notify(clicked)
+	recorder.Notify(domain.NewEvent(domain.EventURLClicked, domain.EventData{ShortCode: "abc123"})) // No click to record
+	recorder.Notify(domain.NewEvent(domain.EventURLCreated, domain.EventData{ShortCode: "abc123"}))
+
+	store.On("AddClickRollups", ctx, domain.ClickRollups{
+		Referrers: []domain.ReferrerClicks{{ShortCode: "abc123", Referrer: "twitter.com", Clicks: 1}},
+		UTM:       []domain.UTMClicks{},
+		Countries: []domain.CountryClicks{},
+		Minutes:   []domain.MinuteClicks{{ShortCode: "abc123", Minute: at.Truncate(time.Minute), Clicks: 1}},
+	}).Return(nil).Once()
+	require.NoError(t, recorder.Flush(ctx))
+	store.AssertExpectations(t)
+}
+
+func TestRecorder_FlushFailureKeepsClicks(t *testing.T) {
+	store := new(mocks.AnalyticsRepository)
+	recorder := New(DefaultConfig(), store)
+	ctx := context.Background()
+	at := time.Date(2024, 5, 1, 12, 30, 15, 0, time.UTC)
+
+	recorder.RecordClick(domain.Click{ShortCode: "abc123", Referrer: "twitter.com", At: at})
+	store.On("AddClickRollups", ctx, mock.Anything).Return(errors.New("database is locked")).Once()
+	assert.Error(t, recorder.Flush(ctx))
+
+	recorder.RecordClick(domain.Click{ShortCode: "abc123", Referrer: "twitter.com", At: at.Add(30 * time.Second)})
+	store.On("AddClickRollups", ctx, domain.ClickRollups{
+		Referrers: []domain.ReferrerClicks{{ShortCode: "abc123", Referrer: "twitter.com", Clicks: 2}},
+		UTM:       []domain.UTMClicks{},
+		Countries: []domain.CountryClicks{},
+		Minutes:   []domain.MinuteClicks{{ShortCode: "abc123", Minute: at.Truncate(time.Minute), Clicks: 2}},
+	}).Return(nil).Once()
+	require.NoError(t, recorder.Flush(ctx))
+	store.AssertExpectations(t)
+}
+
+func TestRecorder_ReferrerReport(t *testing.T) {
+	store := new(mocks.AnalyticsRepository)
+	recorder := New(DefaultConfig(), store)
+	ctx := context.Background()
+
+	recorder.RecordClick(domain.Click{ShortCode: "abc123", Referrer: "twitter.com"})
+	store.On("AddClickRollups", ctx, mock.Anything).Return(nil).Once()
+	store.On("ListReferrerClicks", ctx, "abc123", 5).Return([]domain.ReferrerClicks{{Referrer: "twitter.com", Clicks: 1}}, nil)
+	store.On("ListUTMClicks", ctx, "abc123", 5).Return([]domain.UTMClicks{}, nil)
+
+	report, err := recorder.ReferrerReport(ctx, "abc123", 5)
+	require.NoError(t, err)
+	assert.Equal(t, "abc123", report.ShortCode)
+	assert.Equal(t, []domain.ReferrerClicks{{Referrer: "twitter.com", Clicks: 1}}, report.Referrers)
+	assert.Empty(t, report.UTM)
+	store.AssertExpectations(t)
+}
+
+func TestRecorder_CountryReport(t *testing.T) {
+	store := new(mocks.AnalyticsRepository)
+	recorder := New(DefaultConfig(), store)
+	ctx := context.Background()
+
+	store.On("ListCountryClicks", ctx, "abc123", 5).Return([]domain.CountryClicks{{Country: "US", Region: "CA", Clicks: 2}}, nil)
+	store.On("ListTopCountries", ctx, 5).Return([]domain.CountryClicks{{Country: "US", Clicks: 2, Links: 1}}, nil)
+
+	report, err := recorder.CountryReport(ctx, "abc123", 5)
+	require.NoError(t, err)
+	assert.Equal(t, &domain.CountryReport{ShortCode: "abc123", Countries: []domain.CountryClicks{{Country: "US", Region: "CA", Clicks: 2}}}, report)
+
+	top, err := recorder.TopCountries(ctx, 5)
+	require.NoError(t, err)
+	assert.Equal(t, []domain.CountryClicks{{Country: "US", Clicks: 2, Links: 1}}, top.Countries)
+	store.AssertExpectations(t)
+}
+
+func TestRecorder_TopLinks(t *testing.T) {
+	store := new(mocks.AnalyticsRepository)
+	recorder := New(DefaultConfig(), store)
+	ctx := context.Background()
+
+	var since, previousSince time.Time
+	links := []domain.TopLink{{ShortCode: "abc123", Clicks: 3, PreviousClicks: 1}}
+	store.On("ListTopLinks", ctx, mock.Anything, mock.Anything, domain.TopLinksByTrending, 5).Run(func(args mock.Arguments) {
+		since, previousSince = args.Get(1).(time.Time), args.Get(2).(time.Time)
+	}).Return(links, nil)
+
+	top, err := recorder.TopLinks(ctx, time.Hour, domain.TopLinksByTrending, 5)
+	require.NoError(t, err)
+	assert.Equal(t, links, top.Links)
+	assert.Equal(t, domain.Age(time.Hour), top.Window)
+	assert.Equal(t, domain.TopLinksByTrending, top.Sort)
+	assert.Equal(t, since, top.Since)
+	assert.WithinDuration(t, time.Now().Add(-time.Hour), since, time.Minute)
+	assert.Zero(t, since.Second(), "windows start on a minute")
+	assert.Equal(t, time.Hour, since.Sub(previousSince))
+	store.AssertExpectations(t)
+}
+
+func TestRecorder_TimeSeries(t *testing.T) {
+	store := new(mocks.AnalyticsRepository)
+	recorder := New(DefaultConfig(), store)
+	ctx := context.Background()
+
+	day := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	store.On("ListClickBuckets", ctx, "abc123", day, day.Add(4*time.Hour), time.Hour).Return([]domain.TimeSeriesBucket{
+		{Start: day.Add(time.Hour), Clicks: 2},
+		{Start: day.Add(3 * time.Hour), Clicks: 5},
+	}, nil)
+
+	// Both ends widen to whole hours
+	series, err := recorder.TimeSeries(ctx, "abc123", domain.IntervalHour, day.Add(30*time.Minute), day.Add(3*time.Hour+time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, &domain.TimeSeries{
+		ShortCode: "abc123",
+		Interval:  domain.IntervalHour,
+		From:      day,
+		To:        day.Add(4 * time.Hour),
+		Total:     7,
+		Buckets: []domain.TimeSeriesBucket{
+			{Start: day},
+			{Start: day.Add(time.Hour), Clicks: 2},
+			{Start: day.Add(2 * time.Hour)},
+			{Start: day.Add(3 * time.Hour), Clicks: 5},
+		},
+	}, series)
+	store.AssertExpectations(t)
+}
+
+func TestRecorder_Compact(t *testing.T) {
+	store := new(mocks.AnalyticsRepository)
+	ctx := context.Background()
+
+	var before time.Time
+	store.On("CompactClickEvents", ctx, mock.Anything).Run(func(args mock.Arguments) {
+		before = args.Get(1).(time.Time)
+	}).Return(int64(12), nil).Once()
+
+	recorder := New(Config{FlushInterval: time.Second, MinuteRetention: 48 * time.Hour}, store)
+	compacted, err := recorder.Compact(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(12), compacted)
+	assert.WithinDuration(t, time.Now().Add(-48*time.Hour), before, time.Hour)
+	assert.Equal(t, before, before.Truncate(time.Hour), "compaction stops on an hour")
+
+	// Without a retention minutes are kept
+	recorder = New(Config{FlushInterval: time.Second}, store)
+	compacted, err = recorder.Compact(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, compacted)
+	store.AssertExpectations(t)
+}
+
+func TestRecorder_CloseFlushes(t *testing.T) {
+	store := new(mocks.AnalyticsRepository)
+	recorder := New(Config{FlushInterval: time.Hour}, store)
+	require.NoError(t, recorder.Start(context.Background()))
+	assert.Error(t, recorder.Start(context.Background()))
+
+	recorder.RecordClick(domain.Click{ShortCode: "abc123", Referrer: "twitter.com"})
+	store.On("AddClickRollups", mock.Anything, mock.Anything).Return(nil).Once()
+
+	require.NoError(t, recorder.Close())
+	require.NoError(t, recorder.Close())
+	store.AssertExpectations(t)
+}
+
+func TestRecorder_PauseHoldsWrites(t *testing.T) {
+	store := new(mocks.AnalyticsRepository)
+	recorder := New(Config{FlushInterval: time.Hour, MinuteRetention: 48 * time.Hour}, store)
+	ctx := context.Background()
+
+	// Pausing flushes what was recorded before
+	recorder.RecordClick(domain.Click{ShortCode: "abc123", Referrer: "twitter.com"})
+	store.On("AddClickRollups", ctx, mock.Anything).Return(nil).Once()
+	require.NoError(t, recorder.Pause(ctx))
+	store.AssertExpectations(t)
+
+	// Paused, clicks are counted but neither flushed nor compacted
+	recorder.RecordClick(domain.Click{ShortCode: "abc123", Referrer: "twitter.com"})
+	require.NoError(t, recorder.Flush(ctx))
+	compacted, err := recorder.Compact(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, compacted)
+	store.AssertNotCalled(t, "CompactClickEvents", mock.Anything, mock.Anything)
+	store.AssertNumberOfCalls(t, "AddClickRollups", 1)
+
+	// Resumed, the held clicks are flushed
+	recorder.Resume()
+	store.On("AddClickRollups", ctx, mock.Anything).Return(nil).Once()
+	require.NoError(t, recorder.Flush(ctx))
+	store.AssertExpectations(t)
+
+	var nilRecorder *Recorder
+	assert.NoError(t, nilRecorder.Pause(ctx))
+	nilRecorder.Resume()
+}
+
+func TestConfig_Validate(t *testing.T) {
+	assert.NoError(t, DefaultConfig().Validate())
+	assert.NoError(t, Config{}.Validate(), "zero disables analytics")
+	assert.Error(t, Config{FlushInterval: -time.Second}.Validate())
+	assert.NoError(t, Config{FlushInterval: time.Second, MinuteRetention: time.Hour}.Validate())
+	assert.Error(t, Config{FlushInterval: time.Second, MinuteRetention: time.Minute}.Validate())
+	assert.Error(t, Config{FlushInterval: time.Second, MinuteRetention: -time.Hour}.Validate())
+}
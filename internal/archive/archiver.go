@@ -0,0 +1,242 @@
+package archive
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	"github.com/joshdurbin/url-shortener/internal/drain"
+	"github.com/joshdurbin/url-shortener/internal/repository"
+)
+
+// day is the span of clicks in one archive
+const day = 24 * time.Hour
+
+// Archiver keeps the database small by moving per-minute and per-hour clicks
+// older than After to gzipped CSV files in a bucket, one UTC day at a time.
+// A day's clicks are deleted only after its file is uploaded, so a failure
+// in between uploads the day again on the next run: consumers should expect
+// a day to have more than one file and add them up.
+type Archiver struct {
+	config   Config
+	store    repository.ArchiveRepository
+	uploader Uploader
+	now      func() time.Time
+
+	mutex    sync.Mutex
+	started  bool
+	closed   bool
+	stopChan chan struct{}
+	wg       sync.WaitGroup
+
+	archiveMutex sync.Mutex // Serializes runs
+	gate         drain.Gate // Holds runs, between days, while the server is drained
+	archived     atomic.Int64
+	failures     atomic.Int64
+}
+
+// New creates a click archiver
+func New(config Config, store repository.ArchiveRepository, uploader Uploader) *Archiver {
+	return &Archiver{
+		config:   config,
+		store:    store,
+		uploader: uploader,
+		now:      time.Now,
+		stopChan: make(chan struct{}),
+	}
+}
+
+// Archived returns how many stored minutes and hours have been archived
+func (a *Archiver) Archived() int64 {
+	return a.archived.Load()
+}
+
+// Failures returns how many archive runs failed
+func (a *Archiver) Failures() int64 {
+	return a.failures.Load()
+}
+
+// Start archives old clicks now and then every interval
+func (a *Archiver) Start(ctx context.Context) error {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if a.started {
+		return fmt.Errorf("click archiver already started")
+	}
+	a.started = true
+
+	a.wg.Add(1)
+	go a.scheduleLoop()
+	return nil
+}
+
+// Close stops the scheduler, interrupting a run in progress; the day being
+// archived is archived again by the next run
+func (a *Archiver) Close() error {
+	a.mutex.Lock()
+	if a.closed {
+		a.mutex.Unlock()
+		return nil
+	}
+	a.closed = true
+	close(a.stopChan)
+	a.mutex.Unlock()
+
+	a.wg.Wait()
+	return nil
+}
+
+// Pause stops archiving after the day in progress and holds runs until
+// Resume, so the stored clicks stay put during a drain
+func (a *Archiver) Pause(ctx context.Context) error {
+	if a == nil {
+		return nil
+	}
+	return a.gate.Pause(ctx)
+}
+
+// Resume lets runs archive again
+func (a *Archiver) Resume() {
+	if a == nil {
+		return
+	}
+	a.gate.Resume()
+}
+
+// scheduleLoop archives every interval until the archiver is closed
+func (a *Archiver) scheduleLoop() {
+	defer a.wg.Done()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-a.stopChan
+		cancel()
+	}()
+
+	ticker := time.NewTicker(a.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := a.Archive(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("Error archiving clicks: %v", err)
+		}
+		select {
+		case <-a.stopChan:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Archive uploads and deletes the clicks of every UTC day that ended more
+// than After ago, oldest first, and returns how many stored minutes and
+// hours were archived. A paused archiver stops before the next day.
+func (a *Archiver) Archive(ctx context.Context) (int64, error) {
+	a.archiveMutex.Lock()
+	defer a.archiveMutex.Unlock()
+
+	runAt := a.now().UTC()
+	cutoff := runAt.Add(-a.config.After).Truncate(day)
+	var total int64
+	for {
+		if err := ctx.Err(); err != nil {
+			return total, err
+		}
+		if !a.gate.TryEnter() {
+			return total, nil
+		}
+		archived, ok, err := a.archiveOldest(ctx, cutoff, runAt)
+		a.gate.Leave()
+		total += archived
+		if err != nil {
+			a.failures.Add(1)
+			return total, err
+		}
+		if !ok {
+			return total, nil
+		}
+		if archived == 0 {
+			// The day's clicks went away meanwhile; stop rather than spin
+			return total, nil
+		}
+	}
+}
+
+// archiveOldest archives the oldest day with clicks before cutoff, reporting
+// false when there is none
+func (a *Archiver) archiveOldest(ctx context.Context, cutoff, runAt time.Time) (int64, bool, error) {
+	oldest, ok, err := a.store.OldestClickBefore(ctx, cutoff)
+	if err != nil || !ok {
+		return 0, false, err
+	}
+	archived, err := a.archiveDay(ctx, oldest.Truncate(day), runAt)
+	return archived, true, err
+}
+
+// archiveDay uploads the clicks of the day starting at start, then deletes them
+func (a *Archiver) archiveDay(ctx context.Context, start, runAt time.Time) (int64, error) {
+	end := start.Add(day)
+	clicks, err := a.store.ListClicksBetween(ctx, start, end)
+	if err != nil {
+		return 0, err
+	}
+	body, err := encodeClicks(clicks)
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode clicks of %s: %w", start.Format(time.DateOnly), err)
+	}
+
+	key := objectKey(a.config.Prefix, start, runAt)
+	uploadCtx, cancel := context.WithTimeout(ctx, a.config.Timeout)
+	err = a.uploader.Put(uploadCtx, key, body, "application/gzip")
+	cancel()
+	if err != nil {
+		return 0, err
+	}
+
+	deleted, err := a.store.DeleteClicksBetween(ctx, start, end)
+	if err != nil {
+		return 0, fmt.Errorf("archived %s but failed to delete its clicks: %w", key, err)
+	}
+	a.archived.Add(deleted)
+	log.Printf("Archived %d click rows of %s to %s (%d bytes)", deleted, start.Format(time.DateOnly), key, len(body))
+	return deleted, nil
+}
+
+// objectKey names the archive of a day's clicks: partitioned by date, and
+// unique per run so a day archived twice keeps both files
+func objectKey(prefix string, start, runAt time.Time) string {
+	return prefix + "dt=" + start.Format(time.DateOnly) + "/clicks-" + runAt.Format("20060102T150405Z") + ".csv.gz"
+}
+
+// encodeClicks writes clicks as gzipped CSV with a header row
+func encodeClicks(clicks []domain.ArchivedClicks) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	w := csv.NewWriter(zw)
+	if err := w.Write([]string{"short_code", "start", "interval", "clicks"}); err != nil {
+		return nil, err
+	}
+	for _, c := range clicks {
+		if err := w.Write([]string{c.ShortCode, c.Start.UTC().Format(time.RFC3339), string(c.Interval), strconv.Itoa(c.Clicks)}); err != nil {
+			return nil, err
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,148 @@
+package archive
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	"github.com/joshdurbin/url-shortener/internal/repository/mocks"
+)
+
+// recordingUploader keeps uploaded objects, failing while err is set
+type recordingUploader struct {
+	mu      sync.Mutex
+	err     error
+	objects map[string][]byte
+}
+
+func (u *recordingUploader) Put(ctx context.Context, key string, body []byte, contentType string) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.err != nil {
+		return u.err
+	}
+	if u.objects == nil {
+		u.objects = make(map[string][]byte)
+	}
+	u.objects[key] = body
+	return nil
+}
+
+func gunzip(t *testing.T, data []byte) string {
+	t.Helper()
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	require.NoError(t, err)
+	out, err := io.ReadAll(zr)
+	require.NoError(t, err)
+	return string(out)
+}
+
+func newTestArchiver(store *mocks.ArchiveRepository, uploader Uploader) *Archiver {
+	config := DefaultConfig()
+	config.Bucket = "click-archive"
+	config.After = 30 * 24 * time.Hour
+	archiver := New(config, store, uploader)
+	archiver.now = func() time.Time { return time.Date(2026, 3, 1, 12, 30, 0, 0, time.UTC) }
+	return archiver
+}
+
+func TestArchiver_Archive(t *testing.T) {
+	ctx := context.Background()
+	store := &mocks.ArchiveRepository{}
+	uploader := &recordingUploader{}
+	archiver := newTestArchiver(store, uploader)
+
+	// Days that ended more than 30 days before the run are archived, oldest first
+	cutoff := time.Date(2026, 1, 30, 0, 0, 0, 0, time.UTC)
+	first := time.Date(2026, 1, 10, 0, 0, 0, 0, time.UTC)
+	second := time.Date(2026, 1, 12, 0, 0, 0, 0, time.UTC)
+	store.On("OldestClickBefore", ctx, cutoff).Return(first.Add(3*time.Hour), true, nil).Once()
+	store.On("ListClicksBetween", ctx, first, first.Add(day)).Return([]domain.ArchivedClicks{
+		{ShortCode: "abc123", Start: first.Add(3 * time.Hour), Interval: domain.IntervalHour, Clicks: 12},
+		{ShortCode: "xyz789", Start: first.Add(3*time.Hour + 5*time.Minute), Interval: domain.IntervalMinute, Clicks: 2},
+	}, nil)
+	store.On("DeleteClicksBetween", ctx, first, first.Add(day)).Return(int64(2), nil)
+	store.On("OldestClickBefore", ctx, cutoff).Return(second, true, nil).Once()
+	store.On("ListClicksBetween", ctx, second, second.Add(day)).Return([]domain.ArchivedClicks{
+		{ShortCode: "abc123", Start: second, Interval: domain.IntervalHour, Clicks: 1},
+	}, nil)
+	store.On("DeleteClicksBetween", ctx, second, second.Add(day)).Return(int64(1), nil)
+	store.On("OldestClickBefore", ctx, cutoff).Return(time.Time{}, false, nil).Once()
+
+	archived, err := archiver.Archive(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), archived)
+	assert.Equal(t, int64(3), archiver.Archived())
+	store.AssertExpectations(t)
+
+	require.Len(t, uploader.objects, 2)
+	assert.Equal(t, "short_code,start,interval,clicks\n"+
+		"abc123,2026-01-10T03:00:00Z,hour,12\n"+
+		"xyz789,2026-01-10T03:05:00Z,minute,2\n",
+		gunzip(t, uploader.objects["clicks/dt=2026-01-10/clicks-20260301T123000Z.csv.gz"]))
+	assert.Contains(t, uploader.objects, "clicks/dt=2026-01-12/clicks-20260301T123000Z.csv.gz")
+}
+
+func TestArchiver_UploadFailure(t *testing.T) {
+	ctx := context.Background()
+	store := &mocks.ArchiveRepository{}
+	uploader := &recordingUploader{err: errors.New("503 Service Unavailable")}
+	archiver := newTestArchiver(store, uploader)
+
+	// A day that fails to upload keeps its clicks
+	day := time.Date(2026, 1, 10, 0, 0, 0, 0, time.UTC)
+	store.On("OldestClickBefore", ctx, mock.Anything).Return(day, true, nil)
+	store.On("ListClicksBetween", ctx, day, day.Add(24*time.Hour)).Return([]domain.ArchivedClicks{}, nil)
+
+	_, err := archiver.Archive(ctx)
+	assert.ErrorContains(t, err, "503 Service Unavailable")
+	assert.Equal(t, int64(1), archiver.Failures())
+	store.AssertNotCalled(t, "DeleteClicksBetween", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestArchiver_PauseHoldsRuns(t *testing.T) {
+	ctx := context.Background()
+	store := &mocks.ArchiveRepository{}
+	archiver := newTestArchiver(store, &recordingUploader{})
+
+	// Paused, a run reads and deletes nothing
+	require.NoError(t, archiver.Pause(ctx))
+	archived, err := archiver.Archive(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, archived)
+	store.AssertNotCalled(t, "OldestClickBefore", mock.Anything, mock.Anything)
+
+	archiver.Resume()
+	store.On("OldestClickBefore", ctx, mock.Anything).Return(time.Time{}, false, nil).Once()
+	_, err = archiver.Archive(ctx)
+	require.NoError(t, err)
+	store.AssertExpectations(t)
+}
+
+func TestArchiver_StartClose(t *testing.T) {
+	store := &mocks.ArchiveRepository{}
+	archiver := newTestArchiver(store, &recordingUploader{})
+	ran := make(chan struct{})
+	store.On("OldestClickBefore", mock.Anything, mock.Anything).Run(func(mock.Arguments) { close(ran) }).Return(time.Time{}, false, nil).Once()
+
+	// The first run starts right away
+	require.NoError(t, archiver.Start(context.Background()))
+	assert.Error(t, archiver.Start(context.Background()))
+	select {
+	case <-ran:
+	case <-time.After(5 * time.Second):
+		t.Fatal("archiver did not run on start")
+	}
+	require.NoError(t, archiver.Close())
+	require.NoError(t, archiver.Close())
+}
@@ -0,0 +1,70 @@
+package archive
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Config holds click archive configuration
+type Config struct {
+	Bucket          string        // Bucket archives are uploaded to; empty disables archiving
+	Endpoint        string        // S3-compatible endpoint URL; empty uses AWS S3 in Region
+	Region          string        // Region requests are signed for
+	Prefix          string        // Prepended to the key of every archive
+	AccessKeyID     string        // Access key ID (or GCS HMAC key) requests are signed with
+	SecretAccessKey string        // Secret of the access key
+	PathStyle       bool          // Put the bucket in the URL path instead of the host name, as MinIO expects
+	After           time.Duration // Clicks older than this are archived, in whole UTC days
+	Interval        time.Duration // How often old clicks are archived
+	Timeout         time.Duration // Timeout for each upload
+}
+
+// DefaultConfig returns the default configuration
+func DefaultConfig() Config {
+	return Config{
+		Region:   "us-east-1",
+		Prefix:   "clicks/",
+		After:    90 * 24 * time.Hour,
+		Interval: 24 * time.Hour,
+		Timeout:  5 * time.Minute,
+	}
+}
+
+// Enabled reports whether clicks are archived
+func (c Config) Enabled() bool {
+	return c.Bucket != ""
+}
+
+// Validate checks that the configuration values are usable
+func (c Config) Validate() error {
+	if !c.Enabled() {
+		return nil
+	}
+	if len(c.Bucket) < 3 || len(c.Bucket) > 63 || strings.ContainsAny(c.Bucket, "/ ") {
+		return fmt.Errorf("bucket must be 3-63 characters without slashes or spaces, got: %q", c.Bucket)
+	}
+	if c.Endpoint != "" {
+		endpoint, err := url.Parse(c.Endpoint)
+		if err != nil || (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "" {
+			return fmt.Errorf("endpoint must be an http or https URL, got: %q", c.Endpoint)
+		}
+	}
+	if c.Region == "" {
+		return fmt.Errorf("region is required")
+	}
+	if c.AccessKeyID == "" || c.SecretAccessKey == "" {
+		return fmt.Errorf("an access key ID and secret access key are required")
+	}
+	if c.After < 24*time.Hour {
+		return fmt.Errorf("clicks must be at least 24h old to be archived, got: %v", c.After)
+	}
+	if c.Interval <= 0 {
+		return fmt.Errorf("interval must be positive, got: %v", c.Interval)
+	}
+	if c.Timeout <= 0 {
+		return fmt.Errorf("timeout must be positive, got: %v", c.Timeout)
+	}
+	return nil
+}
@@ -0,0 +1,291 @@
+package auth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Errors returned when authenticating a credential
+var (
+	ErrMissingCredentials = errors.New("missing credentials")
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	ErrTokenExpired       = errors.New("token expired")
+)
+
+// PrincipalKind identifies how a request was authenticated
+type PrincipalKind string
+
+// PrincipalKind constants
+const (
+	KindAnonymous  PrincipalKind = "anonymous"   // Authentication is disabled
+	KindAPIKey     PrincipalKind = "api_key"     // API access; Role says whether it is limited to owned links
+	KindShareToken PrincipalKind = "share_token" // Read-only access to one link
+	KindOIDC       PrincipalKind = "oidc"        // Identity provider user; access depends on Role
+)
+
+// ScopeRead is the only scope share tokens currently grant
+const ScopeRead = "read"
+
+// Principal describes the caller of an authenticated request
+type Principal struct {
+	Kind      PrincipalKind
+	Role      Role      // Access level of an API key or identity provider user
+	Subject   string    // Email or subject of an identity provider user
+	KeyID     string    // Fingerprint of an API key, safe to store and log
+	ShortCode string    // Link a share token is scoped to
+	ExpiresAt time.Time // Expiry of a share token or ID token
+}
+
+// Owner identifies the principal as the owner of the links it creates: the
+// API key's fingerprint or the identity provider subject. It is empty for
+// anonymous callers and share tokens.
+func (p *Principal) Owner() string {
+	switch p.Kind {
+	case KindAPIKey:
+		return "key:" + p.KeyID
+	case KindOIDC:
+		return p.Subject
+	}
+	return ""
+}
+
+// ManagesAll reports whether the principal may update or delete any link:
+// admins, and anonymous callers when auth is disabled
+func (p *Principal) ManagesAll() bool {
+	return p.Kind == KindAnonymous || p.Role == RoleAdmin
+}
+
+// CanManage reports whether the principal may update or delete a link with
+// the given owner. Users manage only the links they own.
+func (p *Principal) CanManage(owner string) bool {
+	if p.ManagesAll() {
+		return true
+	}
+	return p.Role == RoleUser && owner != "" && owner == p.Owner()
+}
+
+// KeyID returns the fingerprint identifying an API key: the first 12 hex
+// digits of its SHA-256 hash
+func KeyID(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:6])
+}
+
+// ShareClaims is the signed payload of a share token
+type ShareClaims struct {
+	ShortCode string `json:"code"`
+	Scope     string `json:"scope"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// Config holds authentication configuration
+type Config struct {
+	APIKeys          []string      // Admin keys granting full API access; auth is disabled when empty (with no user keys)
+	UserAPIKeys      []string      // Keys that may update and delete only the links they created
+	ShareTokenSecret string        // HMAC secret for share tokens; random per process when empty
+	ShareTokenMaxTTL time.Duration // Upper bound on share token lifetime
+	OIDC             OIDCConfig    // External identity provider; disabled when the issuer is empty
+}
+
+// DefaultShareTokenTTL is used when a share token is issued without a TTL
+const DefaultShareTokenTTL = 24 * time.Hour
+
+// Authenticator validates API keys and issues/validates share tokens
+type Authenticator struct {
+	apiKeys []apiKey
+	secret  []byte
+	maxTTL  time.Duration
+	oidc    *oidcVerifier
+	now     func() time.Time
+}
+
+// New creates a new authenticator
+func New(config Config) (*Authenticator, error) {
+	secret := []byte(config.ShareTokenSecret)
+	if len(secret) == 0 {
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, fmt.Errorf("failed to generate share token secret: %w", err)
+		}
+	}
+
+	maxTTL := config.ShareTokenMaxTTL
+	if maxTTL <= 0 {
+		maxTTL = 7 * 24 * time.Hour
+	}
+
+	a := &Authenticator{
+		secret: secret,
+		maxTTL: maxTTL,
+		now:    time.Now,
+	}
+	if config.OIDC.Enabled() {
+		if err := config.OIDC.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid OIDC configuration: %w", err)
+		}
+		a.oidc = newOIDCVerifier(config.OIDC)
+	}
+	a.addAPIKeys(config.APIKeys, RoleAdmin)
+	a.addAPIKeys(config.UserAPIKeys, RoleUser)
+
+	return a, nil
+}
+
+// apiKey is a configured API key and the role it grants
+type apiKey struct {
+	key  []byte
+	role Role
+}
+
+// addAPIKeys registers keys granting role, skipping blank entries
+func (a *Authenticator) addAPIKeys(keys []string, role Role) {
+	for _, key := range keys {
+		if key = strings.TrimSpace(key); key != "" {
+			a.apiKeys = append(a.apiKeys, apiKey{key: []byte(key), role: role})
+		}
+	}
+}
+
+// Enabled reports whether authentication is enforced, by API keys or an identity provider
+func (a *Authenticator) Enabled() bool {
+	return len(a.apiKeys) > 0 || a.oidc != nil
+}
+
+// OIDC returns the identity provider configuration with defaults applied,
+// and whether identity provider tokens are accepted
+func (a *Authenticator) OIDC() (OIDCConfig, bool) {
+	if a.oidc == nil {
+		return OIDCConfig{}, false
+	}
+	return a.oidc.config, true
+}
+
+// ProviderMetadata returns the identity provider's discovery document, used
+// by the dashboard to start a sign-in
+func (a *Authenticator) ProviderMetadata(ctx context.Context) (*ProviderMetadata, error) {
+	if a.oidc == nil {
+		return nil, fmt.Errorf("OIDC is not configured")
+	}
+	return a.oidc.providerMetadata(ctx)
+}
+
+// Authenticate resolves a bearer credential to a principal. The credential
+// may be an API key, a share token, or an identity provider ID token.
+func (a *Authenticator) Authenticate(ctx context.Context, credential string) (*Principal, error) {
+	if credential == "" {
+		if !a.Enabled() {
+			return &Principal{Kind: KindAnonymous}, nil
+		}
+		return nil, ErrMissingCredentials
+	}
+
+	for _, key := range a.apiKeys {
+		if subtle.ConstantTimeCompare(key.key, []byte(credential)) == 1 {
+			return &Principal{Kind: KindAPIKey, Role: key.role, KeyID: KeyID(credential)}, nil
+		}
+	}
+
+	if a.oidc != nil && looksLikeJWT(credential) {
+		return a.oidc.verify(ctx, credential)
+	}
+
+	claims, err := a.ValidateShareToken(credential)
+	if err != nil {
+		if !a.Enabled() && errors.Is(err, ErrInvalidCredentials) {
+			// Unknown credentials are ignored when auth is disabled
+			return &Principal{Kind: KindAnonymous}, nil
+		}
+		return nil, err
+	}
+
+	return &Principal{
+		Kind:      KindShareToken,
+		ShortCode: claims.ShortCode,
+		ExpiresAt: time.Unix(claims.ExpiresAt, 0),
+	}, nil
+}
+
+// IssueShareToken creates a read-only token for one short code. A zero TTL
+// uses DefaultShareTokenTTL; TTLs above the configured maximum are rejected.
+func (a *Authenticator) IssueShareToken(shortCode string, ttl time.Duration) (string, time.Time, error) {
+	if shortCode == "" {
+		return "", time.Time{}, fmt.Errorf("short code cannot be empty")
+	}
+	if ttl == 0 {
+		ttl = DefaultShareTokenTTL
+	}
+	if ttl < 0 || ttl > a.maxTTL {
+		return "", time.Time{}, fmt.Errorf("ttl must be between 0 and %v", a.maxTTL)
+	}
+
+	expiresAt := a.now().Add(ttl).Truncate(time.Second)
+	payload, err := json.Marshal(ShareClaims{
+		ShortCode: shortCode,
+		Scope:     ScopeRead,
+		ExpiresAt: expiresAt.Unix(),
+	})
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to encode share token: %w", err)
+	}
+
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + a.sign(encoded), expiresAt, nil
+}
+
+// ValidateShareToken verifies a share token's signature and expiry
+func (a *Authenticator) ValidateShareToken(token string) (*ShareClaims, error) {
+	encoded, signature, found := strings.Cut(token, ".")
+	if !found {
+		return nil, ErrInvalidCredentials
+	}
+
+	if !hmac.Equal([]byte(signature), []byte(a.sign(encoded))) {
+		return nil, ErrInvalidCredentials
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidCredentials
+	}
+
+	var claims ShareClaims
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.ShortCode == "" || claims.Scope != ScopeRead {
+		return nil, ErrInvalidCredentials
+	}
+
+	if !a.now().Before(time.Unix(claims.ExpiresAt, 0)) {
+		return nil, ErrTokenExpired
+	}
+
+	return &claims, nil
+}
+
+// sign returns the base64url HMAC-SHA256 signature of a token payload
+func (a *Authenticator) sign(encoded string) string {
+	mac := hmac.New(sha256.New, a.secret)
+	mac.Write([]byte(encoded))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+type principalKey struct{}
+
+// WithPrincipal returns a context carrying the authenticated principal
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFromContext returns the authenticated principal, if any
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(*Principal)
+	return principal, ok
+}
@@ -0,0 +1,146 @@
+package auth
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthenticator_Disabled(t *testing.T) {
+	a, err := New(Config{})
+	require.NoError(t, err)
+	assert.False(t, a.Enabled())
+
+	principal, err := a.Authenticate(context.Background(), "")
+	require.NoError(t, err)
+	assert.Equal(t, KindAnonymous, principal.Kind)
+
+	// Unknown credentials are ignored rather than rejected
+	principal, err = a.Authenticate(context.Background(), "whatever")
+	require.NoError(t, err)
+	assert.Equal(t, KindAnonymous, principal.Kind)
+}
+
+func TestAuthenticator_APIKeys(t *testing.T) {
+	a, err := New(Config{APIKeys: []string{"key-one", " key-two ", ""}})
+	require.NoError(t, err)
+	assert.True(t, a.Enabled())
+
+	for _, key := range []string{"key-one", "key-two"} {
+		principal, err := a.Authenticate(context.Background(), key)
+		require.NoError(t, err)
+		assert.Equal(t, KindAPIKey, principal.Kind)
+	}
+
+	_, err = a.Authenticate(context.Background(), "")
+	assert.ErrorIs(t, err, ErrMissingCredentials)
+
+	_, err = a.Authenticate(context.Background(), "wrong")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+}
+
+func TestAuthenticator_UserAPIKeys(t *testing.T) {
+	a, err := New(Config{APIKeys: []string{"admin-key"}, UserAPIKeys: []string{"alice-key", "bob-key"}})
+	require.NoError(t, err)
+
+	admin, err := a.Authenticate(context.Background(), "admin-key")
+	require.NoError(t, err)
+	assert.Equal(t, RoleAdmin, admin.Role)
+
+	alice, err := a.Authenticate(context.Background(), "alice-key")
+	require.NoError(t, err)
+	assert.Equal(t, RoleUser, alice.Role)
+	assert.Equal(t, "key:"+KeyID("alice-key"), alice.Owner())
+	assert.Len(t, alice.KeyID, 12)
+
+	bob, err := a.Authenticate(context.Background(), "bob-key")
+	require.NoError(t, err)
+	assert.NotEqual(t, alice.Owner(), bob.Owner())
+
+	assert.True(t, alice.CanManage(alice.Owner()))
+	assert.False(t, alice.CanManage(bob.Owner()))
+	assert.False(t, alice.CanManage(""), "links without an owner are managed by admins only")
+	assert.True(t, admin.CanManage(bob.Owner()))
+	assert.True(t, admin.CanManage(""))
+	assert.True(t, (&Principal{Kind: KindAnonymous}).CanManage("key:abc"))
+	assert.False(t, (&Principal{Kind: KindOIDC, Role: RoleViewer, Subject: "v@example.com"}).CanManage("v@example.com"))
+
+	// User keys alone enable authentication
+	users, err := New(Config{UserAPIKeys: []string{"alice-key"}})
+	require.NoError(t, err)
+	assert.True(t, users.Enabled())
+}
+
+func TestAuthenticator_ShareTokens(t *testing.T) {
+	a, err := New(Config{APIKeys: []string{"admin"}, ShareTokenSecret: "secret", ShareTokenMaxTTL: time.Hour})
+	require.NoError(t, err)
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	a.now = func() time.Time { return now }
+
+	token, expiresAt, err := a.IssueShareToken("abc123", 30*time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, now.Add(30*time.Minute), expiresAt)
+
+	t.Run("valid token authenticates", func(t *testing.T) {
+		principal, err := a.Authenticate(context.Background(), token)
+		require.NoError(t, err)
+		assert.Equal(t, KindShareToken, principal.Kind)
+		assert.Equal(t, "abc123", principal.ShortCode)
+		assert.True(t, expiresAt.Equal(principal.ExpiresAt))
+	})
+
+	t.Run("tampered token is rejected", func(t *testing.T) {
+		payload, signature, _ := strings.Cut(token, ".")
+		forged, _, err := a.IssueShareToken("other", time.Minute)
+		require.NoError(t, err)
+		forgedPayload, _, _ := strings.Cut(forged, ".")
+
+		_, err = a.Authenticate(context.Background(), forgedPayload+"."+signature)
+		assert.ErrorIs(t, err, ErrInvalidCredentials)
+
+		_, err = a.Authenticate(context.Background(), payload+".bad")
+		assert.ErrorIs(t, err, ErrInvalidCredentials)
+	})
+
+	t.Run("token from another secret is rejected", func(t *testing.T) {
+		other, err := New(Config{APIKeys: []string{"admin"}, ShareTokenSecret: "different"})
+		require.NoError(t, err)
+
+		_, err = other.Authenticate(context.Background(), token)
+		assert.ErrorIs(t, err, ErrInvalidCredentials)
+	})
+
+	t.Run("expired token is rejected", func(t *testing.T) {
+		a.now = func() time.Time { return now.Add(time.Hour) }
+		defer func() { a.now = func() time.Time { return now } }()
+
+		_, err := a.Authenticate(context.Background(), token)
+		assert.ErrorIs(t, err, ErrTokenExpired)
+	})
+
+	t.Run("ttl bounds", func(t *testing.T) {
+		_, _, err := a.IssueShareToken("abc123", 2*time.Hour)
+		assert.Error(t, err)
+
+		_, _, err = a.IssueShareToken("abc123", -time.Minute)
+		assert.Error(t, err)
+
+		_, _, err = a.IssueShareToken("", time.Minute)
+		assert.Error(t, err)
+	})
+}
+
+func TestPrincipalContext(t *testing.T) {
+	_, ok := PrincipalFromContext(context.Background())
+	assert.False(t, ok)
+
+	ctx := WithPrincipal(context.Background(), &Principal{Kind: KindAPIKey})
+	principal, ok := PrincipalFromContext(ctx)
+	require.True(t, ok)
+	assert.Equal(t, KindAPIKey, principal.Kind)
+}
@@ -0,0 +1,467 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	_ "crypto/sha256" // Registers SHA-256 for RS256/ES256
+	_ "crypto/sha512" // Registers SHA-384/512 for RS384/RS512/ES384/ES512
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNoRole is returned for a valid identity provider token whose groups map to no role
+var ErrNoRole = errors.New("identity has no role")
+
+// Role is the access level granted to an API key or identity provider user
+type Role string
+
+// Role constants
+const (
+	RoleAdmin  Role = "admin"  // Full API access, including links owned by others
+	RoleUser   Role = "user"   // Creates links and updates or deletes only the ones it owns
+	RoleViewer Role = "viewer" // Read-only API access
+)
+
+// OIDCConfig configures ID tokens from an external OpenID Connect provider as
+// API credentials, with the user's groups mapped to roles
+type OIDCConfig struct {
+	Issuer       string        // Provider issuer URL; OIDC is disabled when empty
+	ClientID     string        // Required token audience, also used by the dashboard to sign in
+	GroupsClaim  string        // Claim listing the user's groups (default "groups")
+	AdminGroups  []string      // Groups granted RoleAdmin
+	ViewerGroups []string      // Groups granted RoleViewer
+	Scopes       []string      // Scopes the dashboard requests (default openid, profile, email)
+	JWKSCacheTTL time.Duration // How long signing keys are cached (default 1h)
+}
+
+// Enabled reports whether identity provider tokens are accepted
+func (c OIDCConfig) Enabled() bool {
+	return c.Issuer != ""
+}
+
+// Validate checks that an enabled configuration can verify tokens and assign roles
+func (c OIDCConfig) Validate() error {
+	if !c.Enabled() {
+		return nil
+	}
+
+	issuer, err := url.Parse(c.Issuer)
+	if err != nil || issuer.Host == "" {
+		return fmt.Errorf("OIDC issuer must be an absolute URL, got: %q", c.Issuer)
+	}
+	// Signing keys are fetched from the issuer, so plain HTTP is only safe locally
+	if issuer.Scheme != "https" && !(issuer.Scheme == "http" && isLoopback(issuer.Hostname())) {
+		return fmt.Errorf("OIDC issuer must use https, got: %q", c.Issuer)
+	}
+	if c.ClientID == "" {
+		return fmt.Errorf("OIDC client ID cannot be empty")
+	}
+	if len(c.AdminGroups) == 0 && len(c.ViewerGroups) == 0 {
+		return fmt.Errorf("OIDC requires at least one admin or viewer group")
+	}
+	if c.JWKSCacheTTL < 0 {
+		return fmt.Errorf("OIDC JWKS cache TTL cannot be negative, got: %v", c.JWKSCacheTTL)
+	}
+	return nil
+}
+
+// isLoopback reports whether host names the local machine
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// ProviderMetadata is the subset of the provider's discovery document the server uses
+type ProviderMetadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+const (
+	// clockLeeway tolerates clock skew between the provider and this server
+	clockLeeway = time.Minute
+	// minKeyRefresh limits JWKS refetches triggered by unknown key IDs
+	minKeyRefresh = time.Minute
+)
+
+// oidcVerifier validates provider-signed ID tokens. Discovery metadata is
+// fetched once; signing keys are cached for JWKSCacheTTL and refetched early
+// when a token names an unknown key, so provider key rotation is picked up.
+type oidcVerifier struct {
+	config OIDCConfig
+	client *http.Client
+	now    func() time.Time
+
+	mutex     sync.Mutex
+	metadata  *ProviderMetadata
+	keys      map[string]crypto.PublicKey
+	fetchedAt time.Time
+}
+
+// newOIDCVerifier creates a verifier; nothing is fetched until the first token arrives
+func newOIDCVerifier(config OIDCConfig) *oidcVerifier {
+	if config.GroupsClaim == "" {
+		config.GroupsClaim = "groups"
+	}
+	if len(config.Scopes) == 0 {
+		config.Scopes = []string{"openid", "profile", "email"}
+	}
+	if config.JWKSCacheTTL == 0 {
+		config.JWKSCacheTTL = time.Hour
+	}
+	return &oidcVerifier{
+		config: config,
+		client: &http.Client{Timeout: 10 * time.Second},
+		now:    time.Now,
+	}
+}
+
+// providerMetadata returns the provider's discovery document, fetching it on first use
+func (v *oidcVerifier) providerMetadata(ctx context.Context) (*ProviderMetadata, error) {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	return v.metadataLocked(ctx)
+}
+
+func (v *oidcVerifier) metadataLocked(ctx context.Context) (*ProviderMetadata, error) {
+	if v.metadata != nil {
+		return v.metadata, nil
+	}
+
+	var metadata ProviderMetadata
+	discoveryURL := strings.TrimSuffix(v.config.Issuer, "/") + "/.well-known/openid-configuration"
+	if err := v.getJSON(ctx, discoveryURL, &metadata); err != nil {
+		return nil, fmt.Errorf("failed to fetch OIDC discovery document: %w", err)
+	}
+	if metadata.Issuer != v.config.Issuer {
+		return nil, fmt.Errorf("OIDC discovery document is for issuer %q, expected %q", metadata.Issuer, v.config.Issuer)
+	}
+	if metadata.JWKSURI == "" {
+		return nil, fmt.Errorf("OIDC discovery document has no jwks_uri")
+	}
+
+	v.metadata = &metadata
+	return v.metadata, nil
+}
+
+// key returns the signing key with the given ID
+func (v *oidcVerifier) key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+
+	age := v.now().Sub(v.fetchedAt)
+	key, found := v.keys[kid]
+	if v.keys != nil && age < v.config.JWKSCacheTTL && (found || age < minKeyRefresh) {
+		if !found {
+			return nil, ErrInvalidCredentials
+		}
+		return key, nil
+	}
+
+	if err := v.refreshKeysLocked(ctx); err != nil {
+		if found {
+			// Keep verifying with the cached key while the provider is unreachable
+			return key, nil
+		}
+		return nil, err
+	}
+
+	if key, found = v.keys[kid]; !found {
+		return nil, ErrInvalidCredentials
+	}
+	return key, nil
+}
+
+// refreshKeysLocked refetches the provider's signing keys
+func (v *oidcVerifier) refreshKeysLocked(ctx context.Context) error {
+	metadata, err := v.metadataLocked(ctx)
+	if err != nil {
+		return err
+	}
+
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := v.getJSON(ctx, metadata.JWKSURI, &set); err != nil {
+		return fmt.Errorf("failed to fetch OIDC signing keys: %w", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			// One unsupported key must not block the others
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+
+	v.keys = keys
+	v.fetchedAt = v.now()
+	return nil
+}
+
+// getJSON fetches a JSON document from the provider
+func (v *oidcVerifier) getJSON(ctx context.Context, target string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned status %d", target, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// idTokenClaims are the registered claims checked on every token
+type idTokenClaims struct {
+	Issuer    string   `json:"iss"`
+	Subject   string   `json:"sub"`
+	Audience  audience `json:"aud"`
+	ExpiresAt int64    `json:"exp"`
+	NotBefore int64    `json:"nbf"`
+	Email     string   `json:"email"`
+}
+
+// audience accepts the aud claim as either a string or a list of strings
+type audience []string
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = audience{single}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*a = list
+	return nil
+}
+
+// verify checks a token's signature, issuer, audience and lifetime, and maps
+// its groups to a role
+func (v *oidcVerifier) verify(ctx context.Context, token string) (*Principal, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrInvalidCredentials
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, ErrInvalidCredentials
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrInvalidCredentials
+	}
+
+	key, err := v.key(ctx, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifySignature(header.Alg, key, parts[0]+"."+parts[1], signature); err != nil {
+		return nil, ErrInvalidCredentials
+	}
+
+	var claims idTokenClaims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, ErrInvalidCredentials
+	}
+	now := v.now()
+	switch {
+	case claims.Issuer != v.config.Issuer:
+		return nil, ErrInvalidCredentials
+	case !slices.Contains(claims.Audience, v.config.ClientID):
+		return nil, ErrInvalidCredentials
+	case claims.ExpiresAt == 0 || now.After(time.Unix(claims.ExpiresAt, 0).Add(clockLeeway)):
+		return nil, ErrTokenExpired
+	case claims.NotBefore != 0 && now.Add(clockLeeway).Before(time.Unix(claims.NotBefore, 0)):
+		return nil, ErrInvalidCredentials
+	}
+
+	var raw map[string]json.RawMessage
+	if err := decodeSegment(parts[1], &raw); err != nil {
+		return nil, ErrInvalidCredentials
+	}
+	role := v.role(groupsFromClaim(raw[v.config.GroupsClaim]))
+	if role == "" {
+		return nil, fmt.Errorf("subject %q: %w", claims.Subject, ErrNoRole)
+	}
+
+	subject := claims.Email
+	if subject == "" {
+		subject = claims.Subject
+	}
+	return &Principal{
+		Kind:      KindOIDC,
+		Role:      role,
+		Subject:   subject,
+		ExpiresAt: time.Unix(claims.ExpiresAt, 0),
+	}, nil
+}
+
+// role returns the most privileged role any of the groups maps to
+func (v *oidcVerifier) role(groups []string) Role {
+	for _, group := range groups {
+		if slices.Contains(v.config.AdminGroups, group) {
+			return RoleAdmin
+		}
+	}
+	for _, group := range groups {
+		if slices.Contains(v.config.ViewerGroups, group) {
+			return RoleViewer
+		}
+	}
+	return ""
+}
+
+// groupsFromClaim accepts the groups claim as a list or a single string
+func groupsFromClaim(raw json.RawMessage) []string {
+	var groups []string
+	if err := json.Unmarshal(raw, &groups); err == nil {
+		return groups
+	}
+	var group string
+	if err := json.Unmarshal(raw, &group); err == nil && group != "" {
+		return []string{group}
+	}
+	return nil
+}
+
+// decodeSegment decodes a base64url JSON segment of a token
+func decodeSegment(segment string, out any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
+
+// looksLikeJWT reports whether a credential has the three segments of a JWT,
+// as opposed to the two of a share token
+func looksLikeJWT(credential string) bool {
+	return strings.Count(credential, ".") == 2
+}
+
+// signatureHashes maps supported JWS algorithms to their digest
+var signatureHashes = map[string]crypto.Hash{
+	"RS256": crypto.SHA256, "RS384": crypto.SHA384, "RS512": crypto.SHA512,
+	"ES256": crypto.SHA256, "ES384": crypto.SHA384, "ES512": crypto.SHA512,
+}
+
+// verifySignature checks an RSA PKCS#1 v1.5 or ECDSA signature over signed
+func verifySignature(alg string, key crypto.PublicKey, signed string, signature []byte) error {
+	hash, ok := signatureHashes[alg]
+	if !ok {
+		return fmt.Errorf("unsupported signing algorithm %q", alg)
+	}
+	h := hash.New()
+	h.Write([]byte(signed))
+	digest := h.Sum(nil)
+
+	switch key := key.(type) {
+	case *rsa.PublicKey:
+		if !strings.HasPrefix(alg, "RS") {
+			return fmt.Errorf("algorithm %q does not match RSA key", alg)
+		}
+		return rsa.VerifyPKCS1v15(key, hash, digest, signature)
+	case *ecdsa.PublicKey:
+		size := (key.Curve.Params().BitSize + 7) / 8
+		if !strings.HasPrefix(alg, "ES") || len(signature) != 2*size {
+			return fmt.Errorf("algorithm %q does not match EC key", alg)
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(key, digest, r, s) {
+			return fmt.Errorf("invalid signature")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported key type %T", key)
+	}
+}
+
+// jsonWebKey is a public signing key from the provider's JWKS
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// publicKey converts an RSA or EC JWK to a Go public key
+func (k jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, err
+		}
+		exponent := new(big.Int).SetBytes(e)
+		if !exponent.IsInt64() || exponent.Int64() > 1<<31-1 {
+			return nil, fmt.Errorf("RSA exponent too large")
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}, nil
+	case "EC":
+		curves := map[string]elliptic.Curve{"P-256": elliptic.P256(), "P-384": elliptic.P384(), "P-521": elliptic.P521()}
+		curve, ok := curves[k.Crv]
+		if !ok {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		key := &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !curve.IsOnCurve(key.X, key.Y) {
+			return nil, fmt.Errorf("EC key is not on curve %s", k.Crv)
+		}
+		return key, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
@@ -0,0 +1,223 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testProvider is an identity provider serving discovery and JWKS documents
+type testProvider struct {
+	server      *httptest.Server
+	key         *rsa.PrivateKey
+	kid         string
+	jwksFetches atomic.Int32
+}
+
+func newTestProvider(t *testing.T) *testProvider {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	p := &testProvider{key: key, kid: "key-1"}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(ProviderMetadata{
+			Issuer:                p.server.URL,
+			AuthorizationEndpoint: p.server.URL + "/authorize",
+			TokenEndpoint:         p.server.URL + "/token",
+			JWKSURI:               p.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		p.jwksFetches.Add(1)
+		json.NewEncoder(w).Encode(map[string]any{"keys": []jsonWebKey{{
+			Kty: "RSA",
+			Kid: p.kid,
+			Use: "sig",
+			N:   base64.RawURLEncoding.EncodeToString(p.key.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(p.key.E)).Bytes()),
+		}}})
+	})
+	p.server = httptest.NewServer(mux)
+	t.Cleanup(p.server.Close)
+	return p
+}
+
+// sign creates an RS256 token over claims with the provider's current key
+func (p *testProvider) sign(t *testing.T, kid string, claims map[string]any) string {
+	t.Helper()
+
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "kid": kid, "typ": "JWT"})
+	require.NoError(t, err)
+	payload, err := json.Marshal(claims)
+	require.NoError(t, err)
+
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signed))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, p.key, crypto.SHA256, digest[:])
+	require.NoError(t, err)
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func (p *testProvider) config() OIDCConfig {
+	return OIDCConfig{
+		Issuer:       p.server.URL,
+		ClientID:     "dashboard",
+		AdminGroups:  []string{"shortener-admins"},
+		ViewerGroups: []string{"staff"},
+	}
+}
+
+func TestOIDCConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  OIDCConfig
+		wantErr string
+	}{
+		{name: "disabled", config: OIDCConfig{}},
+		{name: "valid", config: OIDCConfig{Issuer: "https://idp.example.com", ClientID: "dashboard", AdminGroups: []string{"admins"}}},
+		{name: "loopback http", config: OIDCConfig{Issuer: "http://127.0.0.1:5556", ClientID: "dashboard", ViewerGroups: []string{"staff"}}},
+		{name: "plain http", config: OIDCConfig{Issuer: "http://idp.example.com", ClientID: "dashboard", AdminGroups: []string{"admins"}}, wantErr: "must use https"},
+		{name: "relative issuer", config: OIDCConfig{Issuer: "idp", ClientID: "dashboard", AdminGroups: []string{"admins"}}, wantErr: "absolute URL"},
+		{name: "missing client ID", config: OIDCConfig{Issuer: "https://idp.example.com", AdminGroups: []string{"admins"}}, wantErr: "client ID"},
+		{name: "no groups", config: OIDCConfig{Issuer: "https://idp.example.com", ClientID: "dashboard"}, wantErr: "at least one admin or viewer group"},
+		{name: "negative cache TTL", config: OIDCConfig{Issuer: "https://idp.example.com", ClientID: "dashboard", AdminGroups: []string{"admins"}, JWKSCacheTTL: -time.Second}, wantErr: "cannot be negative"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestAuthenticator_OIDC(t *testing.T) {
+	provider := newTestProvider(t)
+	a, err := New(Config{OIDC: provider.config()})
+	require.NoError(t, err)
+	assert.True(t, a.Enabled())
+
+	now := time.Now()
+	claims := func(overrides map[string]any) map[string]any {
+		c := map[string]any{
+			"iss":    provider.server.URL,
+			"sub":    "user-1",
+			"aud":    "dashboard",
+			"exp":    now.Add(time.Hour).Unix(),
+			"email":  "ada@example.com",
+			"groups": []string{"staff"},
+		}
+		for k, v := range overrides {
+			c[k] = v
+		}
+		return c
+	}
+
+	tests := []struct {
+		name         string
+		token        string
+		expectedRole Role
+		expectedErr  error
+	}{
+		{name: "viewer", token: provider.sign(t, provider.kid, claims(nil)), expectedRole: RoleViewer},
+		{name: "admin wins over viewer", token: provider.sign(t, provider.kid, claims(map[string]any{"groups": []string{"staff", "shortener-admins"}})), expectedRole: RoleAdmin},
+		{name: "audience list", token: provider.sign(t, provider.kid, claims(map[string]any{"aud": []string{"other", "dashboard"}})), expectedRole: RoleViewer},
+		{name: "single group string", token: provider.sign(t, provider.kid, claims(map[string]any{"groups": "shortener-admins"})), expectedRole: RoleAdmin},
+		{name: "expired", token: provider.sign(t, provider.kid, claims(map[string]any{"exp": now.Add(-time.Hour).Unix()})), expectedErr: ErrTokenExpired},
+		{name: "not yet valid", token: provider.sign(t, provider.kid, claims(map[string]any{"nbf": now.Add(time.Hour).Unix()})), expectedErr: ErrInvalidCredentials},
+		{name: "wrong audience", token: provider.sign(t, provider.kid, claims(map[string]any{"aud": "other"})), expectedErr: ErrInvalidCredentials},
+		{name: "wrong issuer", token: provider.sign(t, provider.kid, claims(map[string]any{"iss": "https://evil.example.com"})), expectedErr: ErrInvalidCredentials},
+		{name: "no matching group", token: provider.sign(t, provider.kid, claims(map[string]any{"groups": []string{"contractors"}})), expectedErr: ErrNoRole},
+		{name: "unknown key", token: provider.sign(t, "key-2", claims(nil)), expectedErr: ErrInvalidCredentials},
+		{name: "tampered signature", token: provider.sign(t, provider.kid, claims(nil)) + "A", expectedErr: ErrInvalidCredentials},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			principal, err := a.Authenticate(context.Background(), tt.token)
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, KindOIDC, principal.Kind)
+			assert.Equal(t, tt.expectedRole, principal.Role)
+			assert.Equal(t, "ada@example.com", principal.Subject)
+		})
+	}
+
+	t.Run("share tokens still work", func(t *testing.T) {
+		token, _, err := a.IssueShareToken("abc123", time.Hour)
+		require.NoError(t, err)
+
+		principal, err := a.Authenticate(context.Background(), token)
+		require.NoError(t, err)
+		assert.Equal(t, KindShareToken, principal.Kind)
+	})
+
+	t.Run("missing credentials", func(t *testing.T) {
+		_, err := a.Authenticate(context.Background(), "")
+		assert.ErrorIs(t, err, ErrMissingCredentials)
+	})
+}
+
+func TestAuthenticator_OIDCKeyCaching(t *testing.T) {
+	provider := newTestProvider(t)
+	config := provider.config()
+	config.JWKSCacheTTL = time.Hour
+	a, err := New(Config{OIDC: config})
+	require.NoError(t, err)
+
+	now := time.Now()
+	a.oidc.now = func() time.Time { return now }
+	claims := map[string]any{"iss": provider.server.URL, "aud": "dashboard", "exp": now.Add(2 * time.Hour).Unix(), "groups": []string{"staff"}}
+
+	authenticate := func(kid string) error {
+		_, err := a.Authenticate(context.Background(), provider.sign(t, kid, claims))
+		return err
+	}
+
+	require.NoError(t, authenticate(provider.kid))
+	require.NoError(t, authenticate(provider.kid))
+	assert.Equal(t, int32(1), provider.jwksFetches.Load(), "keys are cached")
+
+	// Unknown key IDs only trigger a refetch once per minute
+	assert.ErrorIs(t, authenticate("rotated"), ErrInvalidCredentials)
+	assert.Equal(t, int32(1), provider.jwksFetches.Load())
+
+	// After rotation the new key is picked up without waiting for the TTL
+	now = now.Add(2 * time.Minute)
+	provider.kid = "rotated"
+	require.NoError(t, authenticate("rotated"))
+	assert.Equal(t, int32(2), provider.jwksFetches.Load())
+
+	// Keys are refetched once the TTL expires
+	now = now.Add(time.Hour)
+	require.NoError(t, authenticate("rotated"))
+	assert.Equal(t, int32(3), provider.jwksFetches.Load())
+
+	metadata, err := a.ProviderMetadata(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, provider.server.URL+"/token", metadata.TokenEndpoint)
+}
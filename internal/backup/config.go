@@ -0,0 +1,32 @@
+package backup
+
+import (
+	"fmt"
+	"time"
+)
+
+// Config holds database backup configuration
+type Config struct {
+	Dir      string        // Directory snapshots are written to; empty disables backups
+	Interval time.Duration // How often a snapshot is taken; 0 takes them only on demand
+	Keep     int           // Snapshots kept after each backup, oldest removed first; 0 keeps all
+}
+
+// DefaultConfig returns the default configuration
+func DefaultConfig() Config {
+	return Config{
+		Interval: 24 * time.Hour,
+		Keep:     7,
+	}
+}
+
+// Validate checks that the configuration values are usable
+func (c Config) Validate() error {
+	if c.Interval < 0 {
+		return fmt.Errorf("interval cannot be negative, got: %v", c.Interval)
+	}
+	if c.Keep < 0 {
+		return fmt.Errorf("keep cannot be negative, got: %d", c.Keep)
+	}
+	return nil
+}
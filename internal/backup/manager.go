@@ -0,0 +1,206 @@
+package backup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	"github.com/joshdurbin/url-shortener/internal/repository"
+)
+
+// Snapshot file names are the UTC time they were taken, so they sort by age
+const (
+	snapshotPrefix     = "urls-"
+	snapshotSuffix     = ".db"
+	snapshotTimeFormat = "20060102T150405.000Z"
+	// partialSuffix marks a snapshot still being written; it is renamed once complete
+	partialSuffix = ".partial"
+)
+
+// Manager takes online snapshots of the database into a directory, on an
+// interval and on demand, and removes the oldest beyond Keep. Snapshots are
+// written under a temporary name and renamed when complete, so a listed
+// snapshot is always a usable database.
+type Manager struct {
+	config Config
+	repo   repository.BackupRepository
+	now    func() time.Time
+
+	mutex    sync.Mutex
+	started  bool
+	closed   bool
+	stopChan chan struct{}
+	wg       sync.WaitGroup
+
+	backupMutex sync.Mutex // Serializes snapshots and rotation
+}
+
+// New creates a backup manager
+func New(config Config, repo repository.BackupRepository) *Manager {
+	return &Manager{
+		config:   config,
+		repo:     repo,
+		now:      time.Now,
+		stopChan: make(chan struct{}),
+	}
+}
+
+// Start starts the backup scheduler
+func (m *Manager) Start(ctx context.Context) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.started {
+		return fmt.Errorf("backup manager already started")
+	}
+	m.started = true
+
+	if m.config.Interval > 0 {
+		m.wg.Add(1)
+		go m.scheduleLoop()
+	}
+
+	return nil
+}
+
+// Close stops the scheduler, waiting for a backup in progress to finish
+func (m *Manager) Close() error {
+	m.mutex.Lock()
+	if m.closed {
+		m.mutex.Unlock()
+		return nil
+	}
+	m.closed = true
+	close(m.stopChan)
+	m.mutex.Unlock()
+
+	m.wg.Wait()
+	return nil
+}
+
+// Backup takes a snapshot now and rotates old snapshots out
+func (m *Manager) Backup(ctx context.Context) (*domain.Snapshot, error) {
+	m.backupMutex.Lock()
+	defer m.backupMutex.Unlock()
+
+	if err := os.MkdirAll(m.config.Dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create backup directory: %w", err)
+	}
+
+	// Truncated to the precision of the file name, so listings report the same time
+	createdAt := m.now().UTC().Truncate(time.Millisecond)
+	name := snapshotPrefix + createdAt.Format(snapshotTimeFormat) + snapshotSuffix
+	path := filepath.Join(m.config.Dir, name)
+	partial := path + partialSuffix
+
+	// Left behind if the process died mid-backup
+	os.Remove(partial)
+	if err := m.repo.Backup(ctx, partial); err != nil {
+		os.Remove(partial)
+		return nil, err
+	}
+	if err := os.Rename(partial, path); err != nil {
+		os.Remove(partial)
+		return nil, fmt.Errorf("failed to finish snapshot %s: %w", name, err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat snapshot %s: %w", name, err)
+	}
+	log.Printf("Backed up database to %s (%d bytes)", path, info.Size())
+
+	if err := m.rotate(); err != nil {
+		log.Printf("Error rotating backups: %v", err)
+	}
+
+	return &domain.Snapshot{Name: name, Bytes: info.Size(), CreatedAt: createdAt}, nil
+}
+
+// Snapshots lists the snapshots in the backup directory, newest first
+func (m *Manager) Snapshots() ([]domain.Snapshot, error) {
+	entries, err := os.ReadDir(m.config.Dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return []domain.Snapshot{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list backup directory: %w", err)
+	}
+
+	snapshots := make([]domain.Snapshot, 0, len(entries))
+	for _, entry := range entries {
+		createdAt, ok := parseSnapshotName(entry.Name())
+		if !ok || !entry.Type().IsRegular() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			// Removed since the directory was read
+			continue
+		}
+		snapshots = append(snapshots, domain.Snapshot{Name: entry.Name(), Bytes: info.Size(), CreatedAt: createdAt})
+	}
+
+	sort.Slice(snapshots, func(i, j int) bool { return snapshots[i].CreatedAt.After(snapshots[j].CreatedAt) })
+	return snapshots, nil
+}
+
+// rotate removes the oldest snapshots beyond Keep
+func (m *Manager) rotate() error {
+	if m.config.Keep == 0 {
+		return nil
+	}
+	snapshots, err := m.Snapshots()
+	if err != nil {
+		return err
+	}
+	for _, snapshot := range snapshots[min(m.config.Keep, len(snapshots)):] {
+		if err := os.Remove(filepath.Join(m.config.Dir, snapshot.Name)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to remove snapshot %s: %w", snapshot.Name, err)
+		}
+		log.Printf("Removed old backup %s", snapshot.Name)
+	}
+	return nil
+}
+
+// parseSnapshotName returns the time a snapshot was taken from its file name
+func parseSnapshotName(name string) (time.Time, bool) {
+	if !strings.HasPrefix(name, snapshotPrefix) || !strings.HasSuffix(name, snapshotSuffix) {
+		return time.Time{}, false
+	}
+	stamp := strings.TrimSuffix(strings.TrimPrefix(name, snapshotPrefix), snapshotSuffix)
+	createdAt, err := time.Parse(snapshotTimeFormat, stamp)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return createdAt, true
+}
+
+// scheduleLoop takes a snapshot every interval until the manager is closed
+func (m *Manager) scheduleLoop() {
+	defer m.wg.Done()
+
+	ticker := time.NewTicker(m.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.stopChan:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), m.config.Interval)
+			if _, err := m.Backup(ctx); err != nil {
+				log.Printf("Error backing up database: %v", err)
+			}
+			cancel()
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package backup
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	"github.com/joshdurbin/url-shortener/internal/repository/mocks"
+)
+
+// newTestManager returns a manager whose repository writes a small file for
+// each backup and whose clock advances a minute per snapshot
+func newTestManager(t *testing.T, keep int) (*Manager, *mocks.BackupRepository) {
+	repo := &mocks.BackupRepository{}
+	repo.On("Backup", mock.Anything, mock.AnythingOfType("string")).Run(func(args mock.Arguments) {
+		path := args.String(1)
+		assert.True(t, filepath.Ext(path) == partialSuffix, "snapshots are written under a temporary name")
+		require.NoError(t, os.WriteFile(path, []byte("SQLite format 3"), 0o600))
+	}).Return(nil)
+
+	manager := New(Config{Dir: filepath.Join(t.TempDir(), "backups"), Keep: keep}, repo)
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	manager.now = func() time.Time {
+		now = now.Add(time.Minute)
+		return now
+	}
+	return manager, repo
+}
+
+func TestManager_Backup(t *testing.T) {
+	manager, _ := newTestManager(t, 0)
+	ctx := context.Background()
+
+	snapshot, err := manager.Backup(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "urls-20260301T120100.000Z.db", snapshot.Name)
+	assert.Equal(t, int64(15), snapshot.Bytes)
+	assert.Equal(t, time.Date(2026, 3, 1, 12, 1, 0, 0, time.UTC), snapshot.CreatedAt)
+
+	_, err = os.Stat(filepath.Join(manager.config.Dir, snapshot.Name+partialSuffix))
+	assert.True(t, os.IsNotExist(err), "the temporary file is renamed")
+
+	snapshots, err := manager.Snapshots()
+	require.NoError(t, err)
+	assert.Equal(t, []string{snapshot.Name}, names(snapshots))
+}
+
+func TestManager_Rotation(t *testing.T) {
+	manager, _ := newTestManager(t, 2)
+	ctx := context.Background()
+
+	for i := 0; i < 4; i++ {
+		_, err := manager.Backup(ctx)
+		require.NoError(t, err)
+	}
+
+	// Files that are not snapshots are left alone
+	other := filepath.Join(manager.config.Dir, "notes.txt")
+	require.NoError(t, os.WriteFile(other, []byte("keep me"), 0o600))
+
+	snapshots, err := manager.Snapshots()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"urls-20260301T120400.000Z.db", "urls-20260301T120300.000Z.db"}, names(snapshots))
+	assert.FileExists(t, other)
+}
+
+func TestManager_BackupError(t *testing.T) {
+	repo := &mocks.BackupRepository{}
+	repo.On("Backup", mock.Anything, mock.AnythingOfType("string")).Run(func(args mock.Arguments) {
+		require.NoError(t, os.WriteFile(args.String(1), []byte("half"), 0o600))
+	}).Return(errors.New("disk I/O error"))
+	manager := New(Config{Dir: t.TempDir(), Keep: 3}, repo)
+
+	_, err := manager.Backup(context.Background())
+	assert.ErrorContains(t, err, "disk I/O error")
+
+	entries, err := os.ReadDir(manager.config.Dir)
+	require.NoError(t, err)
+	assert.Empty(t, entries, "a failed snapshot leaves nothing behind")
+}
+
+func TestManager_SnapshotsWithoutDirectory(t *testing.T) {
+	manager := New(Config{Dir: filepath.Join(t.TempDir(), "missing")}, &mocks.BackupRepository{})
+	snapshots, err := manager.Snapshots()
+	require.NoError(t, err)
+	assert.Empty(t, snapshots)
+}
+
+func TestManager_Schedule(t *testing.T) {
+	manager, repo := newTestManager(t, 0)
+	manager.config.Interval = 10 * time.Millisecond
+	require.NoError(t, manager.Start(context.Background()))
+	assert.Error(t, manager.Start(context.Background()))
+
+	assert.Eventually(t, func() bool {
+		snapshots, err := manager.Snapshots()
+		return err == nil && len(snapshots) >= 2
+	}, time.Second, 5*time.Millisecond)
+
+	require.NoError(t, manager.Close())
+	require.NoError(t, manager.Close())
+	repo.AssertCalled(t, "Backup", mock.Anything, mock.Anything)
+}
+
+func TestConfig_Validate(t *testing.T) {
+	assert.NoError(t, DefaultConfig().Validate())
+	assert.ErrorContains(t, Config{Interval: -time.Second}.Validate(), "interval cannot be negative")
+	assert.ErrorContains(t, Config{Keep: -1}.Validate(), "keep cannot be negative")
+}
+
+func names(snapshots []domain.Snapshot) []string {
+	result := make([]string, len(snapshots))
+	for i, snapshot := range snapshots {
+		result[i] = snapshot.Name
+	}
+	return result
+}
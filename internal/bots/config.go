@@ -0,0 +1,71 @@
+package bots
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+)
+
+// DefaultCrawlerDomains are the reverse DNS domains of well-known search
+// engine crawlers
+var DefaultCrawlerDomains = []string{
+	"googlebot.com",
+	"google.com",
+	"search.msn.com",
+	"crawl.yahoo.net",
+	"yandex.com",
+	"yandex.net",
+	"yandex.ru",
+	"baidu.com",
+	"baidu.jp",
+	"applebot.apple.com",
+	"crawl.amazonbot.amazon",
+	"petalsearch.com",
+}
+
+// Config holds bot detection configuration
+type Config struct {
+	Clicks     domain.BotClickMode // How redirects from bots are counted
+	VerifyDNS  bool                // Also treat visitors whose address reverse-resolves to a crawler domain as bots
+	Domains    []string            // Crawler domains for VerifyDNS; a host matches a domain or any subdomain
+	DNSTimeout time.Duration       // Timeout for the reverse and forward lookups of one address
+	DNSTTL     time.Duration       // How long an address's lookup result is cached
+}
+
+// DefaultConfig returns the default configuration, which counts bots like
+// any visitor and detects them by User-Agent only
+func DefaultConfig() Config {
+	return Config{
+		Clicks:     domain.BotClicksCount,
+		Domains:    DefaultCrawlerDomains,
+		DNSTimeout: 500 * time.Millisecond,
+		DNSTTL:     time.Hour,
+	}
+}
+
+// Validate checks that the configuration values are usable
+func (c Config) Validate() error {
+	if !domain.ValidBotClickMode(c.Clicks) {
+		return fmt.Errorf("bot clicks must be count, exclude or separate, got: %q", c.Clicks)
+	}
+	if !c.VerifyDNS {
+		return nil
+	}
+	if len(c.Domains) == 0 {
+		return fmt.Errorf("reverse DNS verification needs at least one crawler domain")
+	}
+	for _, d := range c.Domains {
+		if d == "" || strings.ContainsAny(d, " /:") {
+			return fmt.Errorf("crawler domain must be a domain name, got: %q", d)
+		}
+	}
+	if c.DNSTimeout <= 0 {
+		return fmt.Errorf("DNS timeout must be positive, got: %v", c.DNSTimeout)
+	}
+	if c.DNSTTL < 0 {
+		return fmt.Errorf("DNS cache TTL cannot be negative, got: %v", c.DNSTTL)
+	}
+	return nil
+}
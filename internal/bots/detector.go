@@ -0,0 +1,189 @@
+package bots
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxCachedAddrs bounds the lookup cache; when it is full, expired results
+// are dropped, and if none have expired the cache starts over
+const maxCachedAddrs = 10_000
+
+// Resolver is the subset of net.Resolver the detector needs
+type Resolver interface {
+	LookupAddr(ctx context.Context, addr string) ([]string, error)
+	LookupHost(ctx context.Context, host string) ([]string, error)
+}
+
+// Detector recognizes crawlers whose User-Agent looks like a browser by their
+// address: it reverse-resolves it, checks the host name against the crawler
+// domains and resolves that name forward again, so a spoofed PTR record cannot
+// make a visitor look like a crawler. Results are cached per address. A nil
+// Detector recognizes nothing.
+type Detector struct {
+	resolver Resolver
+	domains  []string
+	timeout  time.Duration
+	ttl      time.Duration
+
+	mu      sync.Mutex
+	results map[string]lookupResult
+}
+
+// lookupResult is the cached verdict for one address
+type lookupResult struct {
+	crawler bool
+	expires time.Time
+}
+
+// New creates a detector from the configuration, or returns nil when reverse
+// DNS verification is disabled
+func New(config Config) *Detector {
+	if !config.VerifyDNS {
+		return nil
+	}
+	return NewWithResolver(config, net.DefaultResolver)
+}
+
+// NewWithResolver creates a detector that looks addresses up with resolver
+func NewWithResolver(config Config, resolver Resolver) *Detector {
+	domains := make([]string, len(config.Domains))
+	for i, d := range config.Domains {
+		domains[i] = strings.ToLower(strings.TrimSuffix(d, "."))
+	}
+	return &Detector{
+		resolver: resolver,
+		domains:  domains,
+		timeout:  config.DNSTimeout,
+		ttl:      config.DNSTTL,
+		results:  make(map[string]lookupResult),
+	}
+}
+
+// Crawler reports whether addr belongs to a crawler. Failed lookups count as
+// not a crawler and are cached like any other result.
+func (d *Detector) Crawler(ctx context.Context, addr string) bool {
+	if d == nil || addr == "" {
+		return false
+	}
+
+	now := time.Now()
+	d.mu.Lock()
+	result, ok := d.results[addr]
+	d.mu.Unlock()
+	if ok && now.Before(result.expires) {
+		return result.crawler
+	}
+
+	crawler := d.lookup(ctx, addr)
+	d.store(addr, lookupResult{crawler: crawler, expires: now.Add(d.ttl)}, now)
+	return crawler
+}
+
+// lookup resolves addr to host names and checks each crawler name resolves back
+func (d *Detector) lookup(ctx context.Context, addr string) bool {
+	ctx, cancel := context.WithTimeout(ctx, d.timeout)
+	defer cancel()
+
+	hosts, err := d.resolver.LookupAddr(ctx, addr)
+	if err != nil {
+		return false
+	}
+	for _, host := range hosts {
+		host = strings.ToLower(strings.TrimSuffix(host, "."))
+		if !d.crawlerHost(host) {
+			continue
+		}
+		addrs, err := d.resolver.LookupHost(ctx, host)
+		if err != nil {
+			continue
+		}
+		for _, forward := range addrs {
+			if sameAddr(forward, addr) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// sameAddr reports whether two address strings are the same address, however
+// each is written
+func sameAddr(a, b string) bool {
+	x, errX := netip.ParseAddr(a)
+	y, errY := netip.ParseAddr(b)
+	if errX != nil || errY != nil {
+		return a == b
+	}
+	return x.Unmap() == y.Unmap()
+}
+
+// crawlerHost reports whether host is a crawler domain or one of its subdomains
+func (d *Detector) crawlerHost(host string) bool {
+	for _, domain := range d.domains {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// store caches a result, making room when the cache is full
+func (d *Detector) store(addr string, result lookupResult, now time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if len(d.results) >= maxCachedAddrs {
+		for cached, r := range d.results {
+			if !now.Before(r.expires) {
+				delete(d.results, cached)
+			}
+		}
+		if len(d.results) >= maxCachedAddrs {
+			d.results = make(map[string]lookupResult)
+		}
+	}
+	d.results[addr] = result
+}
+
+// ForgetAddress drops the cached result for addr, however it is written, and
+// returns how many were dropped
+func (d *Detector) ForgetAddress(addr string) int {
+	if d == nil {
+		return 0
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	forgotten := 0
+	for cached := range d.results {
+		if sameAddr(cached, addr) {
+			delete(d.results, cached)
+			forgotten++
+		}
+	}
+	return forgotten
+}
+
+// ForgetAddressesBefore drops the results looked up before cutoff and returns
+// how many were dropped
+func (d *Detector) ForgetAddressesBefore(cutoff time.Time) int {
+	if d == nil {
+		return 0
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	forgotten := 0
+	for cached, r := range d.results {
+		if r.expires.Add(-d.ttl).Before(cutoff) {
+			delete(d.results, cached)
+			forgotten++
+		}
+	}
+	return forgotten
+}
@@ -0,0 +1,142 @@
+package bots
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+)
+
+// fakeResolver answers lookups from fixed records and counts reverse lookups
+type fakeResolver struct {
+	ptr     map[string][]string
+	hosts   map[string][]string
+	lookups int
+}
+
+func (r *fakeResolver) LookupAddr(ctx context.Context, addr string) ([]string, error) {
+	r.lookups++
+	if names, ok := r.ptr[addr]; ok {
+		return names, nil
+	}
+	return nil, errors.New("no such host")
+}
+
+func (r *fakeResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	if addrs, ok := r.hosts[host]; ok {
+		return addrs, nil
+	}
+	return nil, errors.New("no such host")
+}
+
+func TestDetector_Crawler(t *testing.T) {
+	resolver := &fakeResolver{
+		ptr: map[string][]string{
+			"66.249.66.1":  {"crawl-66-249-66-1.googlebot.com."},
+			"203.0.113.7":  {"crawl-203-0-113-7.googlebot.com."}, // Spoofed PTR record
+			"198.51.100.1": {"host.example.net."},
+			"2001:db8::1":  {"crawler.search.msn.com."},
+		},
+		hosts: map[string][]string{
+			"crawl-66-249-66-1.googlebot.com": {"66.249.66.1"},
+			"crawl-203-0-113-7.googlebot.com": {"66.249.66.2"},
+			"host.example.net":                {"198.51.100.1"},
+			"crawler.search.msn.com":          {"2001:0db8:0000:0000:0000:0000:0000:0001"},
+		},
+	}
+	config := DefaultConfig()
+	config.VerifyDNS = true
+	detector := NewWithResolver(config, resolver)
+	ctx := context.Background()
+
+	assert.True(t, detector.Crawler(ctx, "66.249.66.1"))
+	assert.True(t, detector.Crawler(ctx, "2001:db8::1"), "forward addresses match however they are written")
+	assert.False(t, detector.Crawler(ctx, "203.0.113.7"), "the PTR record must resolve back to the address")
+	assert.False(t, detector.Crawler(ctx, "198.51.100.1"), "hosts outside the crawler domains are not crawlers")
+	assert.False(t, detector.Crawler(ctx, "192.0.2.1"), "failed lookups are not crawlers")
+	assert.False(t, detector.Crawler(ctx, ""))
+
+	// Results are cached
+	lookups := resolver.lookups
+	assert.True(t, detector.Crawler(ctx, "66.249.66.1"))
+	assert.False(t, detector.Crawler(ctx, "192.0.2.1"))
+	assert.Equal(t, lookups, resolver.lookups)
+}
+
+func TestDetector_Expiry(t *testing.T) {
+	resolver := &fakeResolver{}
+	config := DefaultConfig()
+	config.VerifyDNS = true
+	config.DNSTTL = 0
+	detector := NewWithResolver(config, resolver)
+
+	detector.Crawler(context.Background(), "192.0.2.1")
+	detector.Crawler(context.Background(), "192.0.2.1")
+	assert.Equal(t, 2, resolver.lookups, "expired results are looked up again")
+}
+
+func TestDetector_Forget(t *testing.T) {
+	resolver := &fakeResolver{}
+	config := DefaultConfig()
+	config.VerifyDNS = true
+	detector := NewWithResolver(config, resolver)
+	ctx := context.Background()
+
+	detector.Crawler(ctx, "2001:db8::1")
+	detector.Crawler(ctx, "192.0.2.1")
+	assert.Equal(t, 1, detector.ForgetAddress("2001:0db8::0001"), "addresses match however they are written")
+	assert.Zero(t, detector.ForgetAddress("2001:db8::1"))
+	detector.Crawler(ctx, "2001:db8::1")
+	assert.Equal(t, 3, resolver.lookups, "a forgotten address is looked up again")
+
+	assert.Zero(t, detector.ForgetAddressesBefore(time.Now().Add(-time.Minute)))
+	assert.Equal(t, 2, detector.ForgetAddressesBefore(time.Now().Add(time.Second)))
+
+	var none *Detector
+	assert.Zero(t, none.ForgetAddress("192.0.2.1"))
+	assert.Zero(t, none.ForgetAddressesBefore(time.Now()))
+}
+
+func TestNew(t *testing.T) {
+	assert.Nil(t, New(DefaultConfig()), "no detector without reverse DNS verification")
+
+	var detector *Detector
+	assert.False(t, detector.Crawler(context.Background(), "66.249.66.1"), "a nil detector recognizes nothing")
+
+	config := DefaultConfig()
+	config.VerifyDNS = true
+	require.NotNil(t, New(config))
+}
+
+func TestConfig_Validate(t *testing.T) {
+	assert.NoError(t, DefaultConfig().Validate())
+
+	testCases := []struct {
+		name   string
+		modify func(*Config)
+	}{
+		{"unknown clicks mode", func(c *Config) { c.Clicks = "ignore" }},
+		{"no domains", func(c *Config) { c.VerifyDNS = true; c.Domains = nil }},
+		{"invalid domain", func(c *Config) { c.VerifyDNS = true; c.Domains = []string{"https://googlebot.com"} }},
+		{"zero timeout", func(c *Config) { c.VerifyDNS = true; c.DNSTimeout = 0 }},
+		{"negative TTL", func(c *Config) { c.VerifyDNS = true; c.DNSTTL = -time.Second }},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			config := DefaultConfig()
+			tc.modify(&config)
+			assert.Error(t, config.Validate())
+		})
+	}
+
+	for _, mode := range []domain.BotClickMode{domain.BotClicksCount, domain.BotClicksExclude, domain.BotClicksSeparate} {
+		config := DefaultConfig()
+		config.Clicks = mode
+		assert.NoError(t, config.Validate())
+	}
+}
@@ -2,6 +2,7 @@ package cache
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"github.com/joshdurbin/url-shortener/internal/domain"
@@ -15,11 +16,29 @@ type Cache interface {
 	// Set stores a cache entry
 	Set(ctx context.Context, shortCode string, entry *domain.CacheEntry) error
 	
+	// UpdateLink changes an entry's destination and settings (usage cap, redirect status, backup URL, query parameters, click dedupe window), keeping its usage counters
+	UpdateLink(ctx context.Context, shortCode, originalURL string, opts domain.CreateOptions) error
+	
+	// SetFailover switches an entry's redirects to its backup URL (active) or back to its original URL
+	SetFailover(ctx context.Context, shortCode string, active bool) error
+	
+	// SetRoutes replaces an entry's routing rules, keeping its usage counters
+	SetRoutes(ctx context.Context, shortCode string, routes []domain.RoutingRule) error
+	
 	// Delete removes a cache entry
 	Delete(ctx context.Context, shortCode string) error
 	
-	// IncrementUsage increments the usage count for a short code
-	IncrementUsage(ctx context.Context, shortCode string) error
+	// IncrementUsage increments the usage count for a short code and returns the
+	// new count, or domain.ErrUsageLimitReached if the entry's MaxUses has been reached
+	IncrementUsage(ctx context.Context, shortCode string) (int, error)
+	
+	// AddUsage adds n redirects that were already served, such as buffered
+	// clicks, to a short code's usage without checking its cap, and returns
+	// the new count (0 for unknown codes)
+	AddUsage(ctx context.Context, shortCode string, n int) (int, error)
+	
+	// IncrementBots counts a bot redirect for a short code, separately from its usage
+	IncrementBots(ctx context.Context, shortCode string) error
 	
 	// GetDirtyEntries returns all cache entries that need to be synced to the database
 	GetDirtyEntries(ctx context.Context) (map[string]*domain.CacheEntry, error)
@@ -34,12 +53,75 @@ type Cache interface {
 	Close() error
 }
 
+// SyncFunc persists a snapshot of dirty entries and returns the count stored
+// for each short code after merging with other writers. The cache rebases its
+// entries on those counts, keeping redirects that arrived during the sync.
+// Short codes missing from the result are treated as synced as-is.
+type SyncFunc func(dirtyEntries map[string]*domain.CacheEntry) (map[string]int, error)
+
+// ErrSyncDeferred is returned by a SyncFunc that skipped a sync, such as
+// while backing off after failures; entries stay dirty without an error
+// being logged
+var ErrSyncDeferred = errors.New("sync deferred")
+
+// HealthChecker is implemented by cache backends that depend on an external
+// service. A failed check only degrades readiness: the service treats cache
+// errors as non-fatal and falls back to the repository.
+type HealthChecker interface {
+	// Ping verifies the cache backend is reachable
+	Ping(ctx context.Context) error
+}
+
+// Viewer is implemented by caches that can hand out an entry without copying
+// it. Redirects use it instead of Get, which copies every entry it returns.
+type Viewer interface {
+	// View returns a short code's settings and current usage count. The entry
+	// is shared with the cache and must not be modified; its counter fields
+	// are not set.
+	View(ctx context.Context, shortCode string) (*domain.CacheEntry, int, bool)
+}
+
+// StaleViewer is implemented by caches that keep expired entries for a while.
+// Redirects fall back to it when the repository fails.
+type StaleViewer interface {
+	// ViewStale is Viewer.View including expired entries that have not been
+	// evicted yet
+	ViewStale(ctx context.Context, shortCode string) (*domain.CacheEntry, int, bool)
+}
+
+// ErrEntryDirty is returned when an entry with unsynced usage would be evicted
+var ErrEntryDirty = domain.Conflict(errors.New("cache entry has unsynced usage"))
+
+// ErrSyncNotRunning is returned by Flush when background sync has not started
+var ErrSyncNotRunning = errors.New("background sync is not running")
+
+// Inspector is implemented by caches that let operators look inside and
+// manage them
+type Inspector interface {
+	// Stats counts the entries, those with unsynced usage and the lookups
+	// served since the start
+	Stats(ctx context.Context) domain.CacheStats
+	
+	// Inspect returns a copy of a short code's entry, including an expired
+	// one, without counting as a lookup
+	Inspect(ctx context.Context, shortCode string) (*domain.CacheEntry, bool)
+	
+	// Flush runs a sync with the background sync's SyncFunc now and returns
+	// how many dirty entries it wrote, or the SyncFunc's error
+	Flush(ctx context.Context) (int, error)
+	
+	// Evict removes a short code's entry so it is reloaded on its next use,
+	// returning false when it is not cached and ErrEntryDirty while it has
+	// unsynced usage
+	Evict(ctx context.Context, shortCode string) (bool, error)
+}
+
 // SyncableCache extends Cache with sync capabilities
 type SyncableCache interface {
 	Cache
 	
 	// StartBackgroundSync starts background synchronization with the given interval
-	StartBackgroundSync(ctx context.Context, interval time.Duration, syncFunc func(map[string]*domain.CacheEntry) error) error
+	StartBackgroundSync(ctx context.Context, interval time.Duration, syncFunc SyncFunc) error
 	
 	// StopBackgroundSync stops background synchronization
 	StopBackgroundSync() error
@@ -2,83 +2,323 @@ package memory
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/joshdurbin/url-shortener/internal/cache"
 	"github.com/joshdurbin/url-shortener/internal/domain"
+	"github.com/joshdurbin/url-shortener/internal/shardkey"
+	"github.com/joshdurbin/url-shortener/internal/tracing"
 )
 
-// Cache implements cache.SyncableCache using in-memory storage
+// Cache implements cache.SyncableCache using in-memory storage. Entries are
+// spread over shards with their own locks (WithShards), and redirects count usage with
+// atomics under a shard's read lock, so concurrent redirects of different
+// links, or of one popular link, do not wait for each other.
 type Cache struct {
-	data     map[string]*domain.CacheEntry
-	mutex    sync.RWMutex
-	stopChan chan struct{}
-	running  bool
+	shards    []shard
+	shardMask uint32     // len(shards)-1; the count is a power of two
+	mutex     sync.Mutex // Guards the background sync state
+	stopChan  chan struct{}
+	syncDone  chan struct{}
+	running   bool
+	syncFunc  cache.SyncFunc // The running background sync's, for Flush
+	syncMu    sync.Mutex     // Serializes syncs, so a flush and a background sync never write the same usage twice
+	lastSync  atomic.Int64   // Unix nanoseconds of the last successful sync; 0 before the first
+
+	hits   atomic.Uint64 // Get and View lookups served from the cache
+	misses atomic.Uint64
+
+	entryTTL        time.Duration // 0 keeps entries until deleted
+	refreshOnAccess bool          // Redirects push an entry's expiry back by entryTTL
+	staleTTL        time.Duration // How long expired entries stay for ViewStale
+	now             func() time.Time
+}
+
+// Option configures a Cache
+type Option func(*Cache)
+
+// WithEntryTTL expires entries ttl after they are stored, so rarely used links
+// do not stay in memory forever; an expired entry is a miss and the service
+// reloads it from the repository. With refreshOnAccess, every redirect extends
+// the entry's lifetime. Entries with unsynced usage never expire before their
+// next sync. A ttl of 0 disables expiry.
+func WithEntryTTL(ttl time.Duration, refreshOnAccess bool) Option {
+	return func(c *Cache) {
+		c.entryTTL = ttl
+		c.refreshOnAccess = refreshOnAccess
+	}
+}
+
+// WithStaleTTL keeps expired entries for another ttl before they are
+// evicted. They are still misses for Get and View, but ViewStale returns
+// them, so redirects can fall back to them while the database is failing.
+func WithStaleTTL(ttl time.Duration) Option {
+	return func(c *Cache) {
+		c.staleTTL = ttl
+	}
+}
+
+// WithShards splits the cache into n independently locked maps, rounded up
+// to a power of two. More shards let more redirects of different links
+// proceed without waiting for each other; 1 puts every link behind one lock.
+func WithShards(n int) Option {
+	return func(c *Cache) {
+		shards := 1
+		for shards < n {
+			shards <<= 1
+		}
+		c.shards = make([]shard, shards)
+	}
 }
 
 // New creates a new in-memory cache
-func New() *Cache {
-	return &Cache{
-		data:     make(map[string]*domain.CacheEntry),
+func New(opts ...Option) *Cache {
+	c := &Cache{
+		shards:   make([]shard, DefaultShards),
 		stopChan: make(chan struct{}),
+		now:      time.Now,
 	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	c.shardMask = uint32(len(c.shards) - 1)
+	for i := range c.shards {
+		c.shards[i].data = make(map[string]*entry)
+	}
+	return c
+}
+
+// shardIndex returns the index of the shard holding a short code
+func (c *Cache) shardIndex(shortCode string) uint32 {
+	return shardkey.Hash(shortCode) & c.shardMask
+}
+
+// shardFor returns the shard holding a short code
+func (c *Cache) shardFor(shortCode string) *shard {
+	return &c.shards[c.shardIndex(shortCode)]
+}
+
+// lookup returns the entry of a short code, or nil when it is not cached
+func (c *Cache) lookup(shortCode string) *entry {
+	s := c.shardFor(shortCode)
+	s.mu.RLock()
+	e := s.data[shortCode]
+	s.mu.RUnlock()
+	return e
+}
+
+// expiry returns when an entry stored now expires, or the zero time without a TTL
+func (c *Cache) expiry() time.Time {
+	if c.entryTTL <= 0 {
+		return time.Time{}
+	}
+	return c.now().Add(c.entryTTL)
+}
+
+// expired reports whether an entry has outlived its TTL. Dirty entries are
+// kept so their pending usage reaches the database.
+func (c *Cache) expired(e *entry) bool {
+	expires := e.expires.Load()
+	return expires != 0 && !e.dirty.Load() && c.now().UnixNano() >= expires
+}
+
+// evictable reports whether an expired entry has also outlived its stale TTL
+func (c *Cache) evictable(e *entry) bool {
+	expires := e.expires.Load()
+	return expires != 0 && !e.dirty.Load() && c.now().UnixNano() >= expires+int64(c.staleTTL)
 }
 
 // Get retrieves a cache entry by short code
 func (c *Cache) Get(ctx context.Context, shortCode string) (*domain.CacheEntry, bool) {
-	c.mutex.RLock()
-	defer c.mutex.RUnlock()
-	
-	entry, exists := c.data[shortCode]
-	if !exists {
+	_, span := tracing.Start(ctx, "cache.Get", tracing.String("url.short_code", shortCode))
+	defer span.End()
+
+	e := c.lookup(shortCode)
+	if e == nil || c.expired(e) {
+		c.misses.Add(1)
+		span.SetAttributes(tracing.Bool("cache.hit", false))
 		return nil, false
 	}
+	c.hits.Add(1)
+	span.SetAttributes(tracing.Bool("cache.hit", true))
 	
 	// Return a copy to prevent external modification
-	return &domain.CacheEntry{
-		OriginalURL: entry.OriginalURL,
-		UsageCount:  entry.UsageCount,
-		LastUsedAt:  entry.LastUsedAt,
-		Dirty:       entry.Dirty,
-	}, true
+	return e.snapshot(), true
+}
+
+// View returns a short code's settings and usage count without copying the
+// entry. The returned entry is shared and must not be modified; its counter
+// fields are not set.
+func (c *Cache) View(ctx context.Context, shortCode string) (*domain.CacheEntry, int, bool) {
+	_, span := tracing.Start(ctx, "cache.View", tracing.String("url.short_code", shortCode))
+	defer span.End()
+
+	e := c.lookup(shortCode)
+	if e == nil || c.expired(e) {
+		c.misses.Add(1)
+		span.SetAttributes(tracing.Bool("cache.hit", false))
+		return nil, 0, false
+	}
+	c.hits.Add(1)
+	span.SetAttributes(tracing.Bool("cache.hit", true))
+	
+	return e.link.Load(), int(e.usage.Load()), true
+}
+
+// ViewStale is View including entries that have expired but not yet been
+// evicted
+func (c *Cache) ViewStale(ctx context.Context, shortCode string) (*domain.CacheEntry, int, bool) {
+	_, span := tracing.Start(ctx, "cache.ViewStale", tracing.String("url.short_code", shortCode))
+	defer span.End()
+
+	e := c.lookup(shortCode)
+	if e == nil {
+		span.SetAttributes(tracing.Bool("cache.hit", false))
+		return nil, 0, false
+	}
+	span.SetAttributes(tracing.Bool("cache.hit", true))
+	
+	return e.link.Load(), int(e.usage.Load()), true
 }
 
 // Set stores a cache entry
 func (c *Cache) Set(ctx context.Context, shortCode string, entry *domain.CacheEntry) error {
-	c.mutex.Lock()
-	defer c.mutex.Unlock()
-	
+	_, span := tracing.Start(ctx, "cache.Set", tracing.String("url.short_code", shortCode))
+	defer span.End()
+
 	// Store a copy to prevent external modification
-	c.data[shortCode] = &domain.CacheEntry{
-		OriginalURL: entry.OriginalURL,
-		UsageCount:  entry.UsageCount,
-		LastUsedAt:  entry.LastUsedAt,
-		Dirty:       entry.Dirty,
-	}
+	stored := newEntry(entry, c.expiry())
+	
+	s := c.shardFor(shortCode)
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	
+	s.data[shortCode] = stored
+	return nil
+}
+
+// updateLink changes the settings of a cached short code under its shard's
+// lock; counters are kept, so redirects counted concurrently are not lost.
+// Unknown codes are ignored.
+func (c *Cache) updateLink(shortCode string, change func(link *domain.CacheEntry)) {
+	s := c.shardFor(shortCode)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	if e, exists := s.data[shortCode]; exists {
+		e.update(change)
+	}
+}
+
+// UpdateLink changes an entry's destination, usage cap, redirect status, backup URL,
+// query parameters, click dedupe window and campaign, keeping its counters. Unknown codes are ignored.
+func (c *Cache) UpdateLink(ctx context.Context, shortCode, originalURL string, opts domain.CreateOptions) error {
+	c.updateLink(shortCode, func(link *domain.CacheEntry) {
+		link.OriginalURL = originalURL
+		link.MaxUses = opts.MaxUses
+		link.RedirectStatus = opts.RedirectStatus
+		link.BackupURL = opts.BackupURL
+		link.QueryParams = opts.QueryParams
+		link.ForwardQuery = opts.ForwardQuery
+		link.DedupeSeconds = opts.DedupeSeconds
+		link.Campaign = opts.Campaign
+	})
+	return nil
+}
+
+// SetFailover switches an entry's redirects to or from its backup URL. Unknown codes are ignored.
+func (c *Cache) SetFailover(ctx context.Context, shortCode string, active bool) error {
+	c.updateLink(shortCode, func(link *domain.CacheEntry) {
+		link.FailoverActive = active
+	})
+	return nil
+}
+
+// SetRoutes replaces an entry's routing rules. Unknown codes are ignored.
+func (c *Cache) SetRoutes(ctx context.Context, shortCode string, routes []domain.RoutingRule) error {
+	c.updateLink(shortCode, func(link *domain.CacheEntry) {
+		link.Routes = routes
+	})
 	return nil
 }
 
 // Delete removes a cache entry
 func (c *Cache) Delete(ctx context.Context, shortCode string) error {
-	c.mutex.Lock()
-	defer c.mutex.Unlock()
+	_, span := tracing.Start(ctx, "cache.Delete", tracing.String("url.short_code", shortCode))
+	defer span.End()
+
+	s := c.shardFor(shortCode)
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	
-	delete(c.data, shortCode)
+	delete(s.data, shortCode)
 	return nil
 }
 
-// IncrementUsage increments the usage count for a short code
-func (c *Cache) IncrementUsage(ctx context.Context, shortCode string) error {
-	c.mutex.Lock()
-	defer c.mutex.Unlock()
+// IncrementUsage increments the usage count for a short code. The usage cap
+// is checked and the count raised in one compare-and-swap, so concurrent
+// redirects never exceed it and exactly one caller sees the count reach the
+// cap. Returns 0 for unknown codes.
+func (c *Cache) IncrementUsage(ctx context.Context, shortCode string) (int, error) {
+	_, span := tracing.Start(ctx, "cache.IncrementUsage", tracing.String("url.short_code", shortCode))
+	defer span.End()
+
+	e := c.lookup(shortCode)
+	if e == nil {
+		return 0, nil
+	}
+	maxUses := int64(e.link.Load().MaxUses)
+	count := e.usage.Load()
+	for {
+		if maxUses > 0 && count >= maxUses {
+			return int(count), domain.ErrUsageLimitReached
+		}
+		if e.usage.CompareAndSwap(count, count+1) {
+			break
+		}
+		count = e.usage.Load()
+	}
 	
-	if entry, exists := c.data[shortCode]; exists {
-		entry.UsageCount++
-		entry.LastUsedAt = time.Now()
-		entry.Dirty = true
+	c.touch(e)
+	return int(count + 1), nil
+}
+
+// AddUsage adds n redirects that were already served, such as buffered
+// clicks, to a short code's usage. The cap is not checked, since the
+// redirects happened. Returns the new count, or 0 for unknown codes.
+func (c *Cache) AddUsage(ctx context.Context, shortCode string, n int) (int, error) {
+	e := c.lookup(shortCode)
+	if e == nil {
+		return 0, nil
+	}
+	count := e.usage.Add(int64(n))
+	c.touch(e)
+	return int(count), nil
+}
+
+// touch records a use of an entry: its last use, pending usage and, with
+// refreshOnAccess, a later expiry. Uses of an entry past its expiry, served
+// stale, do not refresh it.
+func (c *Cache) touch(e *entry) {
+	now := c.now()
+	e.lastUsed.Store(now.UnixNano())
+	e.dirty.Store(true)
+	if c.refreshOnAccess && c.entryTTL > 0 && now.UnixNano() < e.expires.Load() {
+		e.expires.Store(now.Add(c.entryTTL).UnixNano())
+	}
+}
+
+// IncrementBots counts a bot redirect for a short code. Bots do not use up
+// the usage cap or count as the link's last use. Unknown codes are ignored.
+func (c *Cache) IncrementBots(ctx context.Context, shortCode string) error {
+	if e := c.lookup(shortCode); e != nil {
+		e.bots.Add(1)
+		e.dirty.Store(true)
 	}
 	
 	return nil
@@ -86,20 +326,17 @@ func (c *Cache) IncrementUsage(ctx context.Context, shortCode string) error {
 
 // GetDirtyEntries returns all cache entries that need to be synced to the database
 func (c *Cache) GetDirtyEntries(ctx context.Context) (map[string]*domain.CacheEntry, error) {
-	c.mutex.RLock()
-	defer c.mutex.RUnlock()
-	
 	dirty := make(map[string]*domain.CacheEntry)
-	for shortCode, entry := range c.data {
-		if entry.Dirty {
-			// Return a copy
-			dirty[shortCode] = &domain.CacheEntry{
-				OriginalURL: entry.OriginalURL,
-				UsageCount:  entry.UsageCount,
-				LastUsedAt:  entry.LastUsedAt,
-				Dirty:       entry.Dirty,
+	for i := range c.shards {
+		s := &c.shards[i]
+		s.mu.RLock()
+		for shortCode, e := range s.data {
+			if e.dirty.Load() {
+				// Return a copy
+				dirty[shortCode] = e.snapshot()
 			}
 		}
+		s.mu.RUnlock()
 	}
 	
 	return dirty, nil
@@ -107,81 +344,89 @@ func (c *Cache) GetDirtyEntries(ctx context.Context) (map[string]*domain.CacheEn
 
 // MarkClean marks a cache entry as clean (synced to database)
 func (c *Cache) MarkClean(ctx context.Context, shortCode string) error {
-	c.mutex.Lock()
-	defer c.mutex.Unlock()
-	
-	if entry, exists := c.data[shortCode]; exists {
-		entry.Dirty = false
+	if e := c.lookup(shortCode); e != nil {
+		e.dirty.Store(false)
+		e.synced.Store(e.usage.Load())
+		e.syncedBots.Store(e.bots.Load())
 	}
 	
 	return nil
 }
 
-// LoadData loads data into the cache from a map
+// LoadData loads data into the cache from a map, replacing what it held.
+// Each shard is replaced under its own lock.
 func (c *Cache) LoadData(ctx context.Context, data map[string]*domain.CacheEntry) error {
-	c.mutex.Lock()
-	defer c.mutex.Unlock()
-	
-	// Clear existing data and load new data
-	c.data = make(map[string]*domain.CacheEntry)
-	for shortCode, entry := range data {
+	loaded := make([]map[string]*entry, len(c.shards))
+	for i := range loaded {
+		loaded[i] = make(map[string]*entry)
+	}
+	expires := c.expiry()
+	for shortCode, source := range data {
 		// Store a copy
-		c.data[shortCode] = &domain.CacheEntry{
-			OriginalURL: entry.OriginalURL,
-			UsageCount:  entry.UsageCount,
-			LastUsedAt:  entry.LastUsedAt,
-			Dirty:       entry.Dirty,
-		}
+		loaded[c.shardIndex(shortCode)][shortCode] = newEntry(source, expires)
+	}
+	
+	for i := range c.shards {
+		s := &c.shards[i]
+		s.mu.Lock()
+		s.data = loaded[i]
+		s.mu.Unlock()
 	}
 	
 	return nil
 }
 
 // StartBackgroundSync starts background synchronization with the given interval
-func (c *Cache) StartBackgroundSync(ctx context.Context, interval time.Duration, syncFunc func(map[string]*domain.CacheEntry) error) error {
+func (c *Cache) StartBackgroundSync(ctx context.Context, interval time.Duration, syncFunc cache.SyncFunc) error {
 	c.mutex.Lock()
 	if c.running {
 		c.mutex.Unlock()
 		return nil // Already running
 	}
 	c.running = true
+	c.syncFunc = syncFunc
+	c.syncDone = make(chan struct{})
+	stopChan, done := c.stopChan, c.syncDone
 	c.mutex.Unlock()
 
-	go c.backgroundSync(ctx, interval, syncFunc)
+	go c.backgroundSync(ctx, interval, syncFunc, stopChan, done)
 	return nil
 }
 
-// StopBackgroundSync stops background synchronization
+// StopBackgroundSync stops background synchronization and waits for the
+// final sync to finish, so redirects counted before shutdown are persisted
 func (c *Cache) StopBackgroundSync() error {
 	c.mutex.Lock()
-	defer c.mutex.Unlock()
-	
 	if !c.running {
+		c.mutex.Unlock()
 		return nil
 	}
 	
 	c.running = false
 	close(c.stopChan)
+	done := c.syncDone
 	
 	// Create new channel for potential restart
 	c.stopChan = make(chan struct{})
+	c.mutex.Unlock()
+	
+	// The final sync takes the lock, so wait outside it
+	<-done
 	return nil
 }
 
-// backgroundSync runs the background synchronization loop
-func (c *Cache) backgroundSync(ctx context.Context, interval time.Duration, syncFunc func(map[string]*domain.CacheEntry) error) {
+// backgroundSync runs the background synchronization loop until stopChan is
+// closed or ctx is done, closing done on exit
+func (c *Cache) backgroundSync(ctx context.Context, interval time.Duration, syncFunc cache.SyncFunc, stopChan, done chan struct{}) {
+	defer close(done)
 	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
-	// Get a copy of stopChan to avoid race condition
-	c.mutex.RLock()
-	stopChan := c.stopChan
-	c.mutex.RUnlock()
-
 	for {
 		select {
 		case <-ticker.C:
 			c.syncToDatabase(ctx, syncFunc)
+			c.evictExpired()
 		case <-stopChan:
 			// Final sync before stopping
 			c.syncToDatabase(ctx, syncFunc)
@@ -192,29 +437,144 @@ func (c *Cache) backgroundSync(ctx context.Context, interval time.Duration, sync
 	}
 }
 
-// syncToDatabase syncs dirty entries to the database
-func (c *Cache) syncToDatabase(ctx context.Context, syncFunc func(map[string]*domain.CacheEntry) error) {
+// syncToDatabase syncs dirty entries to the database, logging failures
+func (c *Cache) syncToDatabase(ctx context.Context, syncFunc cache.SyncFunc) {
+	_, err := c.sync(ctx, syncFunc)
+	if err != nil && !errors.Is(err, cache.ErrSyncDeferred) {
+		log.Printf("Error syncing cache entries to database: %v", err)
+	}
+}
+
+// sync writes the dirty entries with syncFunc and returns how many there were
+func (c *Cache) sync(ctx context.Context, syncFunc cache.SyncFunc) (int, error) {
+	c.syncMu.Lock()
+	defer c.syncMu.Unlock()
+
 	dirtyEntries, err := c.GetDirtyEntries(ctx)
 	if err != nil {
-		log.Printf("Error getting dirty entries: %v", err)
-		return
+		return 0, fmt.Errorf("failed to get dirty entries: %w", err)
 	}
 	
 	if len(dirtyEntries) == 0 {
-		return
+		c.lastSync.Store(c.now().UnixNano())
+		return 0, nil
 	}
 	
-	if err := syncFunc(dirtyEntries); err != nil {
-		log.Printf("Error syncing cache entries to database: %v", err)
+	counts, err := syncFunc(dirtyEntries)
+	if err != nil {
+		return 0, err
+	}
+	
+	for shortCode, synced := range dirtyEntries {
+		e := c.lookup(shortCode)
+		if e == nil {
+			continue
+		}
+		// Rebasing adds the difference, so redirects counted while the sync
+		// was in flight stay pending
+		syncedCount := int64(synced.UsageCount)
+		if stored, ok := counts[shortCode]; ok {
+			e.usage.Add(int64(stored) - syncedCount)
+			syncedCount = int64(stored)
+		}
+		e.synced.Store(syncedCount)
+		// Bot counts are always added, so the snapshot is what was stored
+		e.syncedBots.Store(int64(synced.BotsCount))
+		e.clean()
+	}
+	c.lastSync.Store(c.now().UnixNano())
+	return len(dirtyEntries), nil
+}
+
+// evictExpired removes expired entries past their stale TTL, which are only
+// ever clean
+func (c *Cache) evictExpired() {
+	if c.entryTTL <= 0 {
 		return
 	}
 	
-	// Mark entries as clean
-	for shortCode := range dirtyEntries {
-		if err := c.MarkClean(ctx, shortCode); err != nil {
-			log.Printf("Error marking entry %s as clean: %v", shortCode, err)
+	for i := range c.shards {
+		s := &c.shards[i]
+		s.mu.Lock()
+		for shortCode, e := range s.data {
+			if c.evictable(e) {
+				delete(s.data, shortCode)
+			}
 		}
+		s.mu.Unlock()
+	}
+}
+
+// Stats counts the entries, those with unsynced usage and the lookups served
+// since the start
+func (c *Cache) Stats(ctx context.Context) domain.CacheStats {
+	var stats domain.CacheStats
+	for i := range c.shards {
+		s := &c.shards[i]
+		s.mu.RLock()
+		for _, e := range s.data {
+			stats.Entries++
+			if c.expired(e) {
+				stats.Expired++
+			}
+			if e.dirty.Load() {
+				stats.Dirty++
+				stats.PendingUsage += int(e.usage.Load() - e.synced.Load())
+			}
+		}
+		s.mu.RUnlock()
+	}
+	
+	stats.Hits, stats.Misses = c.hits.Load(), c.misses.Load()
+	if lookups := stats.Hits + stats.Misses; lookups > 0 {
+		stats.HitRatio = float64(stats.Hits) / float64(lookups)
+	}
+	if lastSync := c.lastSync.Load(); lastSync != 0 {
+		at := time.Unix(0, lastSync).UTC()
+		stats.LastSyncAt = &at
+	}
+	return stats
+}
+
+// Inspect returns a copy of a short code's entry, including an expired one,
+// without counting as a lookup
+func (c *Cache) Inspect(ctx context.Context, shortCode string) (*domain.CacheEntry, bool) {
+	e := c.lookup(shortCode)
+	if e == nil {
+		return nil, false
+	}
+	return e.snapshot(), true
+}
+
+// Flush syncs the dirty entries now with the background sync's SyncFunc,
+// waiting for a sync already in progress, and returns how many it wrote
+func (c *Cache) Flush(ctx context.Context) (int, error) {
+	c.mutex.Lock()
+	syncFunc, running := c.syncFunc, c.running
+	c.mutex.Unlock()
+	if !running {
+		return 0, cache.ErrSyncNotRunning
+	}
+	
+	return c.sync(ctx, syncFunc)
+}
+
+// Evict removes a short code's entry if its usage is synced. Like expired
+// entries, dirty ones are kept so their pending usage reaches the database.
+func (c *Cache) Evict(ctx context.Context, shortCode string) (bool, error) {
+	s := c.shardFor(shortCode)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	e, exists := s.data[shortCode]
+	if !exists {
+		return false, nil
+	}
+	if e.dirty.Load() {
+		return false, fmt.Errorf("short code %s: %w", shortCode, cache.ErrEntryDirty)
 	}
+	delete(s.data, shortCode)
+	return true, nil
 }
 
 // Close closes the cache (stops background sync)
@@ -224,4 +584,6 @@ func (c *Cache) Close() error {
 
 // Ensure Cache implements the interfaces
 var _ cache.Cache = (*Cache)(nil)
-var _ cache.SyncableCache = (*Cache)(nil)
\ No newline at end of file
+var _ cache.SyncableCache = (*Cache)(nil)
+var _ cache.Viewer = (*Cache)(nil)
+var _ cache.Inspector = (*Cache)(nil)
\ No newline at end of file
@@ -2,19 +2,23 @@ package memory
 
 import (
 	"context"
+	"fmt"
+	"net/http"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
+	"github.com/joshdurbin/url-shortener/internal/cache"
 	"github.com/joshdurbin/url-shortener/internal/domain"
 )
 
 func TestCache_New(t *testing.T) {
 	cache := New()
 	assert.NotNil(t, cache)
-	assert.NotNil(t, cache.data)
+	assert.Zero(t, entryCount(cache))
 	assert.NotNil(t, cache.stopChan)
 	assert.False(t, cache.running)
 }
@@ -102,8 +106,9 @@ func TestCache_IncrementUsage(t *testing.T) {
 	assert.NoError(t, err)
 
 	// Increment usage
-	err = cache.IncrementUsage(ctx, "test123")
+	count, err := cache.IncrementUsage(ctx, "test123")
 	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
 
 	// Verify changes
 	retrieved, exists := cache.Get(ctx, "test123")
@@ -113,8 +118,128 @@ func TestCache_IncrementUsage(t *testing.T) {
 	assert.True(t, retrieved.Dirty)
 
 	// Increment usage on non-existent entry (should not error)
-	err = cache.IncrementUsage(ctx, "nonexistent")
+	count, err = cache.IncrementUsage(ctx, "nonexistent")
 	assert.NoError(t, err)
+	assert.Equal(t, 0, count)
+}
+
+func TestCache_AddUsage(t *testing.T) {
+	cache := New()
+	ctx := context.Background()
+
+	err := cache.Set(ctx, "test123", &domain.CacheEntry{
+		OriginalURL: "https://example.com",
+		UsageCount:  3,
+		SyncedCount: 3,
+	})
+	assert.NoError(t, err)
+
+	count, err := cache.AddUsage(ctx, "test123", 4)
+	assert.NoError(t, err)
+	assert.Equal(t, 7, count)
+
+	retrieved, exists := cache.Get(ctx, "test123")
+	assert.True(t, exists)
+	assert.Equal(t, 7, retrieved.UsageCount)
+	assert.Equal(t, 4, retrieved.PendingUsage())
+	assert.True(t, retrieved.Dirty)
+	assert.False(t, retrieved.LastUsedAt.IsZero())
+
+	// Unknown codes add nothing
+	count, err = cache.AddUsage(ctx, "nonexistent", 2)
+	assert.NoError(t, err)
+	assert.Zero(t, count)
+}
+
+func TestCache_IncrementUsage_MaxUses(t *testing.T) {
+	cache := New()
+	ctx := context.Background()
+
+	err := cache.Set(ctx, "test123", &domain.CacheEntry{
+		OriginalURL: "https://example.com",
+		UsageCount:  0,
+		MaxUses:     5,
+	})
+	assert.NoError(t, err)
+
+	// Concurrent redirects must never exceed the cap
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	allowed, rejected, reachedCap := 0, 0, 0
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			count, err := cache.IncrementUsage(ctx, "test123")
+			mu.Lock()
+			defer mu.Unlock()
+			if err == nil {
+				allowed++
+				if count == 5 {
+					reachedCap++
+				}
+			} else {
+				assert.ErrorIs(t, err, domain.ErrUsageLimitReached)
+				rejected++
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 5, allowed)
+	assert.Equal(t, 45, rejected)
+	assert.Equal(t, 1, reachedCap)
+
+	entry, exists := cache.Get(ctx, "test123")
+	assert.True(t, exists)
+	assert.Equal(t, 5, entry.UsageCount)
+	assert.Equal(t, 5, entry.MaxUses)
+}
+
+func TestCache_IncrementBots(t *testing.T) {
+	c := New()
+	ctx := context.Background()
+
+	err := c.LoadData(ctx, map[string]*domain.CacheEntry{
+		"test123": {OriginalURL: "https://example.com", UsageCount: 1, MaxUses: 1, SyncedCount: 1, BotsCount: 4, SyncedBots: 4},
+	})
+	assert.NoError(t, err)
+
+	// Bots are counted on used up links and do not touch usage
+	assert.NoError(t, c.IncrementBots(ctx, "test123"))
+	entry, exists := c.Get(ctx, "test123")
+	assert.True(t, exists)
+	assert.Equal(t, 5, entry.BotsCount)
+	assert.Equal(t, 1, entry.PendingBots())
+	assert.Equal(t, 0, entry.PendingUsage())
+	assert.True(t, entry.LastUsedAt.IsZero())
+	assert.True(t, entry.Dirty)
+
+	c.syncToDatabase(ctx, func(entries map[string]*domain.CacheEntry) (map[string]int, error) {
+		assert.Equal(t, 1, entries["test123"].PendingBots())
+		return nil, nil
+	})
+	entry, _ = c.Get(ctx, "test123")
+	assert.Equal(t, 0, entry.PendingBots())
+	assert.False(t, entry.Dirty)
+
+	assert.NoError(t, c.IncrementBots(ctx, "nonexistent"))
+}
+
+func TestCache_SyncDeferred(t *testing.T) {
+	ctx := context.Background()
+	c := New()
+	require.NoError(t, c.Set(ctx, "abc123", &domain.CacheEntry{OriginalURL: "https://example.com"}))
+	_, err := c.IncrementUsage(ctx, "abc123")
+	require.NoError(t, err)
+
+	// A skipped sync leaves the usage pending for the next one
+	c.syncToDatabase(ctx, func(map[string]*domain.CacheEntry) (map[string]int, error) {
+		return nil, cache.ErrSyncDeferred
+	})
+	entry, _ := c.Get(ctx, "abc123")
+	assert.True(t, entry.Dirty)
+	assert.Equal(t, 1, entry.PendingUsage())
 }
 
 func TestCache_GetDirtyEntries(t *testing.T) {
@@ -183,6 +308,96 @@ func TestCache_MarkClean(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestCache_UpdateLink(t *testing.T) {
+	cache := New()
+	ctx := context.Background()
+
+	err := cache.Set(ctx, "test123", &domain.CacheEntry{
+		OriginalURL: "https://example.com",
+		UsageCount:  2,
+		MaxUses:     2,
+		Dirty:       true,
+	})
+	assert.NoError(t, err)
+
+	// Raising the cap reopens an exhausted link without losing pending usage
+	err = cache.UpdateLink(ctx, "test123", "https://example.com/new", domain.CreateOptions{MaxUses: 5, RedirectStatus: http.StatusMovedPermanently, BackupURL: "https://backup.example.com"})
+	assert.NoError(t, err)
+
+	entry, exists := cache.Get(ctx, "test123")
+	assert.True(t, exists)
+	assert.Equal(t, "https://example.com/new", entry.OriginalURL)
+	assert.Equal(t, 5, entry.MaxUses)
+	assert.Equal(t, http.StatusMovedPermanently, entry.RedirectStatus)
+	assert.Equal(t, "https://backup.example.com", entry.BackupURL)
+	assert.Equal(t, 2, entry.UsageCount)
+	assert.True(t, entry.Dirty)
+
+	count, err := cache.IncrementUsage(ctx, "test123")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, count)
+
+	// Unknown codes are ignored
+	err = cache.UpdateLink(ctx, "nonexistent", "https://example.com", domain.CreateOptions{})
+	assert.NoError(t, err)
+	_, exists = cache.Get(ctx, "nonexistent")
+	assert.False(t, exists)
+}
+
+func TestCache_SetFailover(t *testing.T) {
+	cache := New()
+	ctx := context.Background()
+
+	err := cache.Set(ctx, "test123", &domain.CacheEntry{
+		OriginalURL: "https://example.com",
+		BackupURL:   "https://backup.example.com",
+		UsageCount:  2,
+	})
+	assert.NoError(t, err)
+
+	entry, _ := cache.Get(ctx, "test123")
+	assert.Equal(t, "https://example.com", entry.Destination())
+
+	assert.NoError(t, cache.SetFailover(ctx, "test123", true))
+	entry, _ = cache.Get(ctx, "test123")
+	assert.True(t, entry.FailoverActive)
+	assert.Equal(t, "https://backup.example.com", entry.Destination())
+	assert.Equal(t, 2, entry.UsageCount)
+
+	assert.NoError(t, cache.SetFailover(ctx, "test123", false))
+	entry, _ = cache.Get(ctx, "test123")
+	assert.Equal(t, "https://example.com", entry.Destination())
+
+	// Unknown codes are ignored
+	assert.NoError(t, cache.SetFailover(ctx, "nonexistent", true))
+	_, exists := cache.Get(ctx, "nonexistent")
+	assert.False(t, exists)
+}
+
+func TestCache_SetRoutes(t *testing.T) {
+	cache := New()
+	ctx := context.Background()
+
+	err := cache.Set(ctx, "test123", &domain.CacheEntry{OriginalURL: "https://example.com", UsageCount: 2})
+	assert.NoError(t, err)
+
+	routes := []domain.RoutingRule{{Countries: []string{"DE"}, Destination: "https://example.de"}}
+	assert.NoError(t, cache.SetRoutes(ctx, "test123", routes))
+	entry, _ := cache.Get(ctx, "test123")
+	assert.Equal(t, routes, entry.Routes)
+	assert.Equal(t, "https://example.de", entry.Route(domain.RedirectRequest{Country: "DE"}))
+	assert.Equal(t, 2, entry.UsageCount)
+
+	assert.NoError(t, cache.SetRoutes(ctx, "test123", nil))
+	entry, _ = cache.Get(ctx, "test123")
+	assert.Equal(t, "https://example.com", entry.Route(domain.RedirectRequest{Country: "DE"}))
+
+	// Unknown codes are ignored
+	assert.NoError(t, cache.SetRoutes(ctx, "nonexistent", routes))
+	_, exists := cache.Get(ctx, "nonexistent")
+	assert.False(t, exists)
+}
+
 func TestCache_LoadData(t *testing.T) {
 	cache := New()
 	ctx := context.Background()
@@ -242,7 +457,7 @@ func TestCache_BackgroundSync(t *testing.T) {
 	var syncedEntries map[string]*domain.CacheEntry
 	var mu sync.Mutex
 
-	syncFunc := func(entries map[string]*domain.CacheEntry) error {
+	syncFunc := func(entries map[string]*domain.CacheEntry) (map[string]int, error) {
 		mu.Lock()
 		defer mu.Unlock()
 		syncCallCount++
@@ -250,7 +465,7 @@ func TestCache_BackgroundSync(t *testing.T) {
 		for k, v := range entries {
 			syncedEntries[k] = v
 		}
-		return nil
+		return nil, nil
 	}
 
 	// Add dirty entry
@@ -286,12 +501,43 @@ func TestCache_BackgroundSync(t *testing.T) {
 	assert.Len(t, dirty, 0)
 }
 
+func TestCache_StopBackgroundSync_WaitsForFinalSync(t *testing.T) {
+	cache := New()
+	ctx := context.Background()
+
+	var synced int
+	syncFunc := func(entries map[string]*domain.CacheEntry) (map[string]int, error) {
+		// Slow enough that a non-blocking stop would return first
+		time.Sleep(50 * time.Millisecond)
+		synced = entries["test123"].UsageCount
+		return nil, nil
+	}
+
+	err := cache.Set(ctx, "test123", &domain.CacheEntry{OriginalURL: "https://example.com"})
+	assert.NoError(t, err)
+
+	// The interval is long enough that only the final sync runs
+	err = cache.StartBackgroundSync(ctx, time.Hour, syncFunc)
+	assert.NoError(t, err)
+
+	_, err = cache.IncrementUsage(ctx, "test123")
+	assert.NoError(t, err)
+
+	err = cache.StopBackgroundSync()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, synced)
+
+	dirty, err := cache.GetDirtyEntries(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, dirty, 0)
+}
+
 func TestCache_BackgroundSync_StartWhenAlreadyRunning(t *testing.T) {
 	cache := New()
 	ctx := context.Background()
 	
-	syncFunc := func(entries map[string]*domain.CacheEntry) error {
-		return nil
+	syncFunc := func(entries map[string]*domain.CacheEntry) (map[string]int, error) {
+		return nil, nil
 	}
 
 	// Start first sync
@@ -319,8 +565,8 @@ func TestCache_Close(t *testing.T) {
 	cache := New()
 	ctx := context.Background()
 	
-	syncFunc := func(entries map[string]*domain.CacheEntry) error {
-		return nil
+	syncFunc := func(entries map[string]*domain.CacheEntry) (map[string]int, error) {
+		return nil, nil
 	}
 
 	// Start background sync
@@ -370,7 +616,7 @@ func TestCache_ConcurrentAccess(t *testing.T) {
 				assert.NotNil(t, retrieved)
 				
 				// Increment
-				err = cache.IncrementUsage(ctx, shortCode)
+				_, err = cache.IncrementUsage(ctx, shortCode)
 				assert.NoError(t, err)
 				
 				// Delete occasionally
@@ -405,9 +651,9 @@ func TestCache_ContextCancellation(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	
 	syncCallCount := 0
-	syncFunc := func(entries map[string]*domain.CacheEntry) error {
+	syncFunc := func(entries map[string]*domain.CacheEntry) (map[string]int, error) {
 		syncCallCount++
-		return nil
+		return nil, nil
 	}
 
 	// Add dirty entry
@@ -439,4 +685,463 @@ func TestCache_ContextCancellation(t *testing.T) {
 	
 	// Some syncs should have happened before cancellation
 	assert.GreaterOrEqual(t, syncCallCount, 0)
-}
\ No newline at end of file
+}
+func TestCache_SyncRebasesOnMergedCounts(t *testing.T) {
+	tests := []struct {
+		name          string
+		stored        map[string]int
+		expectedCount int
+		expectedDirty bool
+	}{
+		{"merged with other writers", map[string]int{"test123": 10}, 11, true},
+		{"no merge result", nil, 5, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := New()
+			ctx := context.Background()
+
+			err := c.LoadData(ctx, map[string]*domain.CacheEntry{
+				"test123": {OriginalURL: "https://example.com", UsageCount: 2, SyncedCount: 2},
+			})
+			assert.NoError(t, err)
+			_, err = c.IncrementUsage(ctx, "test123")
+			assert.NoError(t, err)
+			_, err = c.IncrementUsage(ctx, "test123")
+			assert.NoError(t, err)
+
+			c.syncToDatabase(ctx, func(entries map[string]*domain.CacheEntry) (map[string]int, error) {
+				assert.Equal(t, 2, entries["test123"].PendingUsage())
+				// A redirect arrives while the sync is in flight
+				_, err := c.IncrementUsage(ctx, "test123")
+				assert.NoError(t, err)
+				return tt.stored, nil
+			})
+
+			entry, exists := c.Get(ctx, "test123")
+			if !assert.True(t, exists) {
+				return
+			}
+			assert.Equal(t, tt.expectedCount, entry.UsageCount)
+			assert.Equal(t, 1, entry.PendingUsage())
+			assert.Equal(t, tt.expectedDirty, entry.Dirty)
+		})
+	}
+}
+
+func TestCache_EntryTTL(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name            string
+		refreshOnAccess bool
+		redirect        bool // Redirect once after 40 minutes
+		wantCached      bool // Still cached after 70 minutes
+	}{
+		{name: "expires after the TTL", wantCached: false},
+		{name: "redirect without refresh keeps the original expiry", redirect: true, wantCached: false},
+		{name: "redirect with refresh extends the expiry", refreshOnAccess: true, redirect: true, wantCached: true},
+		{name: "no redirect with refresh still expires", refreshOnAccess: true, wantCached: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := New(WithEntryTTL(time.Hour, tt.refreshOnAccess))
+			clock := now
+			cache.now = func() time.Time { return clock }
+
+			require.NoError(t, cache.Set(ctx, "abc123", &domain.CacheEntry{OriginalURL: "https://example.com"}))
+			entry, exists := cache.Get(ctx, "abc123")
+			require.True(t, exists)
+			assert.Equal(t, now.Add(time.Hour), entry.ExpiresAt)
+
+			if tt.redirect {
+				clock = now.Add(40 * time.Minute)
+				_, err := cache.IncrementUsage(ctx, "abc123")
+				require.NoError(t, err)
+				// Pending usage is synced before the entry may expire
+				require.NoError(t, cache.MarkClean(ctx, "abc123"))
+			}
+
+			clock = now.Add(70 * time.Minute)
+			_, exists = cache.Get(ctx, "abc123")
+			assert.Equal(t, tt.wantCached, exists)
+		})
+	}
+}
+
+func TestCache_EntryTTL_KeepsDirtyEntries(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	cache := New(WithEntryTTL(time.Minute, false))
+	clock := now
+	cache.now = func() time.Time { return clock }
+
+	require.NoError(t, cache.Set(ctx, "abc123", &domain.CacheEntry{OriginalURL: "https://example.com"}))
+	_, err := cache.IncrementUsage(ctx, "abc123")
+	require.NoError(t, err)
+
+	// Unsynced usage keeps the entry past its TTL
+	clock = now.Add(time.Hour)
+	cache.evictExpired()
+	entry, exists := cache.Get(ctx, "abc123")
+	require.True(t, exists)
+	assert.Equal(t, 1, entry.UsageCount)
+
+	// Once synced it expires and is swept
+	require.NoError(t, cache.MarkClean(ctx, "abc123"))
+	_, exists = cache.Get(ctx, "abc123")
+	assert.False(t, exists)
+	cache.evictExpired()
+	assert.Zero(t, entryCount(cache))
+}
+
+func TestCache_NoEntryTTL(t *testing.T) {
+	ctx := context.Background()
+	cache := New()
+
+	require.NoError(t, cache.LoadData(ctx, map[string]*domain.CacheEntry{"abc123": {OriginalURL: "https://example.com"}}))
+	entry, exists := cache.Get(ctx, "abc123")
+	require.True(t, exists)
+	assert.True(t, entry.ExpiresAt.IsZero())
+}
+
+// entryCount returns how many entries the cache holds, expired or not
+func entryCount(c *Cache) int {
+	count := 0
+	for i := range c.shards {
+		count += len(c.shards[i].data)
+	}
+	return count
+}
+
+func TestCache_View(t *testing.T) {
+	ctx := context.Background()
+	cache := New()
+
+	_, _, exists := cache.View(ctx, "abc123")
+	assert.False(t, exists)
+
+	require.NoError(t, cache.Set(ctx, "abc123", &domain.CacheEntry{OriginalURL: "https://example.com", MaxUses: 10, UsageCount: 3}))
+	_, err := cache.IncrementUsage(ctx, "abc123")
+	require.NoError(t, err)
+
+	link, usageCount, exists := cache.View(ctx, "abc123")
+	require.True(t, exists)
+	assert.Equal(t, "https://example.com", link.OriginalURL)
+	assert.Equal(t, 10, link.MaxUses)
+	assert.Equal(t, 4, usageCount)
+	assert.Zero(t, link.UsageCount, "counters are not set on the shared entry")
+
+	// Updates replace the settings rather than changing an entry already handed out
+	require.NoError(t, cache.UpdateLink(ctx, "abc123", "https://example.org", domain.CreateOptions{MaxUses: 20}))
+	assert.Equal(t, "https://example.com", link.OriginalURL)
+	updated, usageCount, _ := cache.View(ctx, "abc123")
+	assert.Equal(t, "https://example.org", updated.OriginalURL)
+	assert.Equal(t, 20, updated.MaxUses)
+	assert.Equal(t, 4, usageCount)
+}
+
+func TestCache_View_Expired(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	cache := New(WithEntryTTL(time.Hour, false))
+	clock := now
+	cache.now = func() time.Time { return clock }
+
+	require.NoError(t, cache.Set(ctx, "abc123", &domain.CacheEntry{OriginalURL: "https://example.com"}))
+	clock = now.Add(2 * time.Hour)
+	_, _, exists := cache.View(ctx, "abc123")
+	assert.False(t, exists)
+}
+
+func TestCache_ViewStale(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	cache := New(WithEntryTTL(time.Hour, true), WithStaleTTL(time.Hour))
+	clock := now
+	cache.now = func() time.Time { return clock }
+
+	_, _, exists := cache.ViewStale(ctx, "abc123")
+	assert.False(t, exists)
+
+	require.NoError(t, cache.Set(ctx, "abc123", &domain.CacheEntry{OriginalURL: "https://example.com"}))
+
+	// Expired entries are misses, but stay for ViewStale until the stale TTL passes
+	clock = now.Add(90 * time.Minute)
+	cache.evictExpired()
+	_, _, exists = cache.View(ctx, "abc123")
+	assert.False(t, exists)
+	link, usageCount, exists := cache.ViewStale(ctx, "abc123")
+	require.True(t, exists)
+	assert.Equal(t, "https://example.com", link.OriginalURL)
+	assert.Zero(t, usageCount)
+
+	// A stale redirect does not refresh the entry, which expires once synced
+	_, err := cache.IncrementUsage(ctx, "abc123")
+	require.NoError(t, err)
+	require.NoError(t, cache.MarkClean(ctx, "abc123"))
+	_, _, exists = cache.View(ctx, "abc123")
+	assert.False(t, exists)
+
+	clock = now.Add(2 * time.Hour)
+	cache.evictExpired()
+	_, _, exists = cache.ViewStale(ctx, "abc123")
+	assert.False(t, exists)
+	assert.Zero(t, entryCount(cache))
+}
+
+func TestCache_SyncDuringRedirects(t *testing.T) {
+	ctx := context.Background()
+	cache := New()
+	require.NoError(t, cache.Set(ctx, "abc123", &domain.CacheEntry{OriginalURL: "https://example.com"}))
+
+	// Every redirect reaches exactly one sync, however they interleave
+	var mu sync.Mutex
+	synced := 0
+	syncFunc := func(dirty map[string]*domain.CacheEntry) (map[string]int, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, entry := range dirty {
+			synced += entry.PendingUsage()
+		}
+		return nil, nil
+	}
+
+	const redirects = 2000
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < redirects/4; j++ {
+				_, err := cache.IncrementUsage(ctx, "abc123")
+				assert.NoError(t, err)
+			}
+		}()
+	}
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	for running := true; running; {
+		select {
+		case <-done:
+			running = false
+		default:
+			cache.syncToDatabase(ctx, syncFunc)
+		}
+	}
+	cache.syncToDatabase(ctx, syncFunc)
+
+	assert.Equal(t, redirects, synced)
+	entry, _ := cache.Get(ctx, "abc123")
+	assert.Equal(t, redirects, entry.UsageCount)
+	assert.False(t, entry.Dirty)
+}
+
+// BenchmarkCache_View measures cache hits across all cores
+func BenchmarkCache_View(b *testing.B) {
+	ctx := context.Background()
+	cache := New()
+	shortCodes := make([]string, 10000)
+	for i := range shortCodes {
+		shortCodes[i] = fmt.Sprintf("code%05d", i)
+		require.NoError(b, cache.Set(ctx, shortCodes[i], &domain.CacheEntry{OriginalURL: "https://example.com"}))
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			if _, _, exists := cache.View(ctx, shortCodes[i%len(shortCodes)]); !exists {
+				b.Fatal("cache miss")
+			}
+			i++
+		}
+	})
+}
+
+// BenchmarkCache_IncrementUsage measures counting redirects of one link
+// across all cores, the most contended case
+func BenchmarkCache_IncrementUsage(b *testing.B) {
+	ctx := context.Background()
+	cache := New()
+	require.NoError(b, cache.Set(ctx, "abc123", &domain.CacheEntry{OriginalURL: "https://example.com"}))
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if _, err := cache.IncrementUsage(ctx, "abc123"); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func TestCache_WithShards(t *testing.T) {
+	ctx := context.Background()
+	for shards, expected := range map[int]int{0: 1, 1: 1, 3: 4, 64: 64, 100: 128} {
+		cache := New(WithShards(shards))
+		assert.Len(t, cache.shards, expected, "WithShards(%d)", shards)
+
+		// Every entry is found in its shard, and loads spread over them
+		entries := make(map[string]*domain.CacheEntry)
+		for i := 0; i < 100; i++ {
+			entries[fmt.Sprintf("code%d", i)] = &domain.CacheEntry{OriginalURL: "https://example.com"}
+		}
+		require.NoError(t, cache.LoadData(ctx, entries))
+		assert.Equal(t, 100, entryCount(cache))
+		for shortCode := range entries {
+			_, _, exists := cache.View(ctx, shortCode)
+			assert.True(t, exists, shortCode)
+		}
+	}
+}
+
+// BenchmarkCache_Shards compares one lock for every link, as the cache had
+// before it was sharded, with the default shards, under redirects of many
+// links mixed with one write in 16. Run with -cpu 1,4,8 to see contention.
+func BenchmarkCache_Shards(b *testing.B) {
+	for _, shards := range []int{1, DefaultShards} {
+		b.Run(fmt.Sprintf("shards=%d", shards), func(b *testing.B) {
+			ctx := context.Background()
+			cache := New(WithShards(shards))
+			shortCodes := make([]string, 10000)
+			for i := range shortCodes {
+				shortCodes[i] = fmt.Sprintf("code%05d", i)
+				require.NoError(b, cache.Set(ctx, shortCodes[i], &domain.CacheEntry{OriginalURL: "https://example.com"}))
+			}
+			replacement := &domain.CacheEntry{OriginalURL: "https://example.org"}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				i := 0
+				for pb.Next() {
+					shortCode := shortCodes[i%len(shortCodes)]
+					if i%16 == 0 {
+						if err := cache.Set(ctx, shortCode, replacement); err != nil {
+							b.Fatal(err)
+						}
+					} else {
+						cache.View(ctx, shortCode)
+						if _, err := cache.IncrementUsage(ctx, shortCode); err != nil {
+							b.Fatal(err)
+						}
+					}
+					i++
+				}
+			})
+		})
+	}
+}
+
+func TestCache_Stats(t *testing.T) {
+	ctx := context.Background()
+	c := New(WithEntryTTL(time.Minute, false), WithStaleTTL(time.Hour))
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	c.now = func() time.Time { return now }
+	require.NoError(t, c.Set(ctx, "abc123", &domain.CacheEntry{OriginalURL: "https://example.com/a"}))
+	require.NoError(t, c.Set(ctx, "def456", &domain.CacheEntry{OriginalURL: "https://example.com/b"}))
+	for i := 0; i < 3; i++ {
+		_, err := c.IncrementUsage(ctx, "abc123")
+		require.NoError(t, err)
+	}
+
+	_, hit := c.Get(ctx, "abc123")
+	require.True(t, hit)
+	_, _, hit = c.View(ctx, "nope")
+	require.False(t, hit)
+	// Inspecting never counts as a lookup
+	entry, exists := c.Inspect(ctx, "abc123")
+	require.True(t, exists)
+	assert.Equal(t, 3, entry.PendingUsage())
+
+	stats := c.Stats(ctx)
+	assert.Equal(t, 2, stats.Entries)
+	assert.Equal(t, 1, stats.Dirty)
+	assert.Equal(t, 3, stats.PendingUsage)
+	assert.Equal(t, uint64(1), stats.Hits)
+	assert.Equal(t, uint64(1), stats.Misses)
+	assert.Equal(t, 0.5, stats.HitRatio)
+	assert.Nil(t, stats.LastSyncAt)
+
+	// Expired entries are still inspected and counted until evicted
+	now = now.Add(2 * time.Minute)
+	_, hit = c.Get(ctx, "def456")
+	assert.False(t, hit)
+	_, exists = c.Inspect(ctx, "def456")
+	assert.True(t, exists)
+	stats = c.Stats(ctx)
+	assert.Equal(t, 2, stats.Entries)
+	assert.Equal(t, 1, stats.Expired)
+}
+
+func TestCache_Flush(t *testing.T) {
+	ctx := context.Background()
+	c := New()
+	require.NoError(t, c.Set(ctx, "abc123", &domain.CacheEntry{OriginalURL: "https://example.com"}))
+	_, err := c.IncrementUsage(ctx, "abc123")
+	require.NoError(t, err)
+
+	_, err = c.Flush(ctx)
+	assert.ErrorIs(t, err, cache.ErrSyncNotRunning)
+
+	var synced []map[string]*domain.CacheEntry
+	var mu sync.Mutex
+	require.NoError(t, c.StartBackgroundSync(ctx, time.Hour, func(entries map[string]*domain.CacheEntry) (map[string]int, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		synced = append(synced, entries)
+		return nil, nil
+	}))
+	defer c.StopBackgroundSync()
+
+	count, err := c.Flush(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+	mu.Lock()
+	require.Len(t, synced, 1)
+	assert.Equal(t, 1, synced[0]["abc123"].PendingUsage())
+	mu.Unlock()
+
+	stats := c.Stats(ctx)
+	assert.Zero(t, stats.Dirty)
+	require.NotNil(t, stats.LastSyncAt)
+
+	// Nothing is left to write
+	count, err = c.Flush(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, count)
+}
+
+func TestCache_Evict(t *testing.T) {
+	ctx := context.Background()
+	c := New()
+	require.NoError(t, c.Set(ctx, "abc123", &domain.CacheEntry{OriginalURL: "https://example.com"}))
+	_, err := c.IncrementUsage(ctx, "abc123")
+	require.NoError(t, err)
+
+	// Unsynced usage is never dropped
+	evicted, err := c.Evict(ctx, "abc123")
+	assert.ErrorIs(t, err, cache.ErrEntryDirty)
+	assert.ErrorIs(t, err, domain.ErrConflict)
+	assert.False(t, evicted)
+
+	require.NoError(t, c.MarkClean(ctx, "abc123"))
+	evicted, err = c.Evict(ctx, "abc123")
+	require.NoError(t, err)
+	assert.True(t, evicted)
+	_, exists := c.Inspect(ctx, "abc123")
+	assert.False(t, exists)
+
+	evicted, err = c.Evict(ctx, "abc123")
+	require.NoError(t, err)
+	assert.False(t, evicted)
+}
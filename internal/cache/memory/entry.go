@@ -0,0 +1,108 @@
+package memory
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+)
+
+// DefaultShards is how many independently locked maps the cache is split
+// into unless WithShards says otherwise
+const DefaultShards = 64
+
+// shard is one lock and the entries whose short codes hash to it
+type shard struct {
+	mu   sync.RWMutex
+	data map[string]*entry
+}
+
+// entry is a cached link. Its settings are an immutable snapshot that is
+// replaced as a whole when they change, so redirects read them without
+// copying, and its counters are atomics, so redirects only take their
+// shard's read lock to find the entry.
+type entry struct {
+	link       atomic.Pointer[domain.CacheEntry] // Settings only; never modified once stored
+	usage      atomic.Int64
+	synced     atomic.Int64 // Usage as of the last load or sync
+	bots       atomic.Int64
+	syncedBots atomic.Int64 // Bot redirects as of the last load or sync
+	lastUsed   atomic.Int64 // Unix nanoseconds; 0 when never used
+	expires    atomic.Int64 // Unix nanoseconds; 0 when the entry never expires
+	dirty      atomic.Bool
+}
+
+// newEntry creates an entry holding a copy of source that expires at expires
+func newEntry(source *domain.CacheEntry, expires time.Time) *entry {
+	e := &entry{}
+	e.link.Store(&domain.CacheEntry{
+		OriginalURL:      source.OriginalURL,
+		MaxUses:          source.MaxUses,
+		BurnAfterReading: source.BurnAfterReading,
+		RedirectStatus:   source.RedirectStatus,
+		BackupURL:        source.BackupURL,
+		FailoverActive:   source.FailoverActive,
+		QueryParams:      source.QueryParams,
+		ForwardQuery:     source.ForwardQuery,
+		DedupeSeconds:    source.DedupeSeconds,
+		Campaign:         source.Campaign,
+		Domain:           source.Domain,
+		Routes:           source.Routes,
+	})
+	e.usage.Store(int64(source.UsageCount))
+	e.synced.Store(int64(source.SyncedCount))
+	e.bots.Store(int64(source.BotsCount))
+	e.syncedBots.Store(int64(source.SyncedBots))
+	e.lastUsed.Store(unixNano(source.LastUsedAt))
+	e.expires.Store(unixNano(expires))
+	e.dirty.Store(source.Dirty)
+	return e
+}
+
+// snapshot returns a copy of the entry's settings and current counters
+func (e *entry) snapshot() *domain.CacheEntry {
+	snapshot := *e.link.Load()
+	snapshot.UsageCount = int(e.usage.Load())
+	snapshot.SyncedCount = int(e.synced.Load())
+	snapshot.BotsCount = int(e.bots.Load())
+	snapshot.SyncedBots = int(e.syncedBots.Load())
+	snapshot.LastUsedAt = fromUnixNano(e.lastUsed.Load())
+	snapshot.ExpiresAt = fromUnixNano(e.expires.Load())
+	snapshot.Dirty = e.dirty.Load()
+	return &snapshot
+}
+
+// update replaces the entry's settings with a changed copy. Callers hold the
+// shard's write lock, so concurrent updates are not lost.
+func (e *entry) update(change func(link *domain.CacheEntry)) {
+	link := *e.link.Load()
+	change(&link)
+	e.link.Store(&link)
+}
+
+// clean clears the dirty flag unless counts are still pending. The flag is
+// cleared before the counts are compared, so a redirect counted concurrently
+// always leaves it set.
+func (e *entry) clean() {
+	e.dirty.Store(false)
+	if e.usage.Load() != e.synced.Load() || e.bots.Load() != e.syncedBots.Load() {
+		e.dirty.Store(true)
+	}
+}
+
+// unixNano converts t for storing in an atomic, with 0 for the zero time
+func unixNano(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano()
+}
+
+// fromUnixNano converts a time stored by unixNano back, in UTC
+func fromUnixNano(nanos int64) time.Time {
+	if nanos == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, nanos).UTC()
+}
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/mock"
+	"github.com/joshdurbin/url-shortener/internal/cache"
 	"github.com/joshdurbin/url-shortener/internal/domain"
 )
 
@@ -28,6 +29,24 @@ func (m *Cache) Set(ctx context.Context, shortCode string, entry *domain.CacheEn
 	return args.Error(0)
 }
 
+// UpdateLink changes an entry's destination and settings, keeping its usage counters
+func (m *Cache) UpdateLink(ctx context.Context, shortCode, originalURL string, opts domain.CreateOptions) error {
+	args := m.Called(ctx, shortCode, originalURL, opts)
+	return args.Error(0)
+}
+
+// SetFailover switches an entry's redirects to or from its backup URL
+func (m *Cache) SetFailover(ctx context.Context, shortCode string, active bool) error {
+	args := m.Called(ctx, shortCode, active)
+	return args.Error(0)
+}
+
+// SetRoutes replaces an entry's routing rules
+func (m *Cache) SetRoutes(ctx context.Context, shortCode string, routes []domain.RoutingRule) error {
+	args := m.Called(ctx, shortCode, routes)
+	return args.Error(0)
+}
+
 // Delete removes a cache entry
 func (m *Cache) Delete(ctx context.Context, shortCode string) error {
 	args := m.Called(ctx, shortCode)
@@ -35,7 +54,19 @@ func (m *Cache) Delete(ctx context.Context, shortCode string) error {
 }
 
 // IncrementUsage increments the usage count for a short code
-func (m *Cache) IncrementUsage(ctx context.Context, shortCode string) error {
+func (m *Cache) IncrementUsage(ctx context.Context, shortCode string) (int, error) {
+	args := m.Called(ctx, shortCode)
+	return args.Int(0), args.Error(1)
+}
+
+// AddUsage adds already served redirects to the usage count for a short code
+func (m *Cache) AddUsage(ctx context.Context, shortCode string, n int) (int, error) {
+	args := m.Called(ctx, shortCode, n)
+	return args.Int(0), args.Error(1)
+}
+
+// IncrementBots increments the bots count for a short code
+func (m *Cache) IncrementBots(ctx context.Context, shortCode string) error {
 	args := m.Called(ctx, shortCode)
 	return args.Error(0)
 }
@@ -73,7 +104,7 @@ type SyncableCache struct {
 }
 
 // StartBackgroundSync starts background synchronization with the given interval
-func (m *SyncableCache) StartBackgroundSync(ctx context.Context, interval time.Duration, syncFunc func(map[string]*domain.CacheEntry) error) error {
+func (m *SyncableCache) StartBackgroundSync(ctx context.Context, interval time.Duration, syncFunc cache.SyncFunc) error {
 	args := m.Called(ctx, interval, syncFunc)
 	return args.Error(0)
 }
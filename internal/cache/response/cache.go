@@ -0,0 +1,134 @@
+package response
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultMaxEntries bounds how many responses are kept at once
+const DefaultMaxEntries = 10000
+
+// Cache keeps assembled API responses (link info, link lists, storage
+// reports) for a short TTL, so frequent polling, e.g. by the admin dashboard,
+// does not reach the repository on every request. Writers invalidate the keys
+// they change; anything else is at most one TTL stale. Values are shared, so
+// callers must not modify what they store or get.
+type Cache struct {
+	ttl        time.Duration
+	maxEntries int
+	mutex      sync.Mutex
+	entries    map[string]item
+	hits       atomic.Uint64
+	misses     atomic.Uint64
+	now        func() time.Time
+}
+
+// item is a cached value and when it expires
+type item struct {
+	value     any
+	expiresAt time.Time
+}
+
+// Stats reports the cache's effectiveness
+type Stats struct {
+	Hits    uint64
+	Misses  uint64
+	Entries int
+}
+
+// HitRate returns the fraction of lookups served from the cache
+func (s Stats) HitRate() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Hits+s.Misses)
+}
+
+// New creates a response cache; a ttl of 0 or less disables it and returns nil.
+// A nil *Cache is valid and never hits.
+func New(ttl time.Duration) *Cache {
+	if ttl <= 0 {
+		return nil
+	}
+	return &Cache{
+		ttl:        ttl,
+		maxEntries: DefaultMaxEntries,
+		entries:    make(map[string]item),
+		now:        time.Now,
+	}
+}
+
+// Get returns the value stored under key if it has not expired
+func (c *Cache) Get(key string) (any, bool) {
+	if c == nil {
+		return nil, false
+	}
+
+	c.mutex.Lock()
+	cached, ok := c.entries[key]
+	if ok && !c.now().Before(cached.expiresAt) {
+		delete(c.entries, key)
+		ok = false
+	}
+	c.mutex.Unlock()
+
+	if !ok {
+		c.misses.Add(1)
+		return nil, false
+	}
+	c.hits.Add(1)
+	return cached.value, true
+}
+
+// Set stores value under key for the cache's TTL. When the cache is full,
+// expired entries are dropped first and the value is not stored if that frees
+// no room.
+func (c *Cache) Set(key string, value any) {
+	if c == nil {
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	now := c.now()
+	if _, exists := c.entries[key]; !exists && len(c.entries) >= c.maxEntries {
+		for k, cached := range c.entries {
+			if !now.Before(cached.expiresAt) {
+				delete(c.entries, k)
+			}
+		}
+		if len(c.entries) >= c.maxEntries {
+			return
+		}
+	}
+	c.entries[key] = item{value: value, expiresAt: now.Add(c.ttl)}
+}
+
+// Invalidate removes the given keys
+func (c *Cache) Invalidate(keys ...string) {
+	if c == nil {
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for _, key := range keys {
+		delete(c.entries, key)
+	}
+}
+
+// Stats returns the hit and miss counts and the number of stored entries
+func (c *Cache) Stats() Stats {
+	if c == nil {
+		return Stats{}
+	}
+
+	c.mutex.Lock()
+	entries := len(c.entries)
+	c.mutex.Unlock()
+
+	return Stats{Hits: c.hits.Load(), Misses: c.misses.Load(), Entries: entries}
+}
@@ -0,0 +1,89 @@
+package response
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestCache(ttl time.Duration) (*Cache, *time.Time) {
+	cache := New(ttl)
+	clock := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	cache.now = func() time.Time { return clock }
+	return cache, &clock
+}
+
+func TestCache_GetSet(t *testing.T) {
+	cache, clock := newTestCache(2 * time.Second)
+
+	_, ok := cache.Get("urls")
+	assert.False(t, ok)
+
+	cache.Set("urls", "list")
+	value, ok := cache.Get("urls")
+	require.True(t, ok)
+	assert.Equal(t, "list", value)
+
+	*clock = clock.Add(2 * time.Second)
+	_, ok = cache.Get("urls")
+	assert.False(t, ok, "entries expire after the TTL")
+
+	stats := cache.Stats()
+	assert.Equal(t, uint64(1), stats.Hits)
+	assert.Equal(t, uint64(2), stats.Misses)
+	assert.Equal(t, 0, stats.Entries, "expired entries are dropped on lookup")
+	assert.InDelta(t, 1.0/3, stats.HitRate(), 0.0001)
+}
+
+func TestCache_Invalidate(t *testing.T) {
+	cache, _ := newTestCache(time.Minute)
+	cache.Set("urls", "list")
+	cache.Set("url:abc123", "info")
+	cache.Set("url:def456", "info")
+
+	cache.Invalidate("urls", "url:abc123", "url:missing")
+
+	_, ok := cache.Get("urls")
+	assert.False(t, ok)
+	_, ok = cache.Get("url:abc123")
+	assert.False(t, ok)
+	_, ok = cache.Get("url:def456")
+	assert.True(t, ok)
+}
+
+func TestCache_MaxEntries(t *testing.T) {
+	cache, clock := newTestCache(time.Minute)
+	cache.maxEntries = 3
+	for i := 0; i < 3; i++ {
+		cache.Set(fmt.Sprintf("url:%d", i), i)
+	}
+
+	cache.Set("url:full", "dropped")
+	_, ok := cache.Get("url:full")
+	assert.False(t, ok, "a full cache stores nothing new")
+
+	cache.Set("url:0", "replaced")
+	value, _ := cache.Get("url:0")
+	assert.Equal(t, "replaced", value, "existing keys can still be refreshed")
+
+	*clock = clock.Add(time.Minute)
+	cache.Set("url:new", "stored")
+	_, ok = cache.Get("url:new")
+	assert.True(t, ok, "expired entries make room")
+	assert.Equal(t, 1, cache.Stats().Entries)
+}
+
+func TestCache_Disabled(t *testing.T) {
+	cache := New(0)
+	assert.Nil(t, cache)
+
+	cache.Set("urls", "list")
+	_, ok := cache.Get("urls")
+	assert.False(t, ok)
+	cache.Invalidate("urls")
+	assert.Equal(t, Stats{}, cache.Stats())
+	assert.Zero(t, cache.Stats().HitRate())
+}
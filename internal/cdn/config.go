@@ -0,0 +1,63 @@
+package cdn
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// Config holds CDN purge configuration
+type Config struct {
+	PurgeURL       string        // Endpoint purge requests are sent to; empty purges nothing
+	Provider       string        // Purge API: ProviderCloudflare or ProviderFastly
+	Token          string        // Sent with each purge request as the provider expects
+	Timeout        time.Duration // Timeout for a single purge attempt
+	MaxAttempts    int           // Attempts per batch before giving up
+	InitialBackoff time.Duration // Wait before the first retry; doubles per attempt
+	MaxBackoff     time.Duration // Upper bound on the wait between retries
+	QueueSize      int           // Links waiting to be purged before more are dropped
+}
+
+// DefaultConfig returns the default configuration, which purges nothing
+func DefaultConfig() Config {
+	return Config{
+		Provider:       ProviderCloudflare,
+		Timeout:        10 * time.Second,
+		MaxAttempts:    3,
+		InitialBackoff: time.Second,
+		MaxBackoff:     30 * time.Second,
+		QueueSize:      1000,
+	}
+}
+
+// Enabled reports whether changed links are purged
+func (c Config) Enabled() bool {
+	return c.PurgeURL != ""
+}
+
+// Validate checks that the configuration values are usable
+func (c Config) Validate() error {
+	if !c.Enabled() {
+		return nil
+	}
+	u, err := url.Parse(c.PurgeURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("purge URL must be an http or https URL, got: %q", c.PurgeURL)
+	}
+	if c.Provider != ProviderCloudflare && c.Provider != ProviderFastly {
+		return fmt.Errorf("provider must be %s or %s, got: %q", ProviderCloudflare, ProviderFastly, c.Provider)
+	}
+	if c.Timeout <= 0 {
+		return fmt.Errorf("timeout must be positive, got: %v", c.Timeout)
+	}
+	if c.MaxAttempts < 1 {
+		return fmt.Errorf("max attempts must be at least 1, got: %d", c.MaxAttempts)
+	}
+	if c.InitialBackoff < 0 || c.MaxBackoff < c.InitialBackoff {
+		return fmt.Errorf("backoff must satisfy 0 <= initial (%v) <= max (%v)", c.InitialBackoff, c.MaxBackoff)
+	}
+	if c.QueueSize < 1 {
+		return fmt.Errorf("queue size must be at least 1, got: %d", c.QueueSize)
+	}
+	return nil
+}
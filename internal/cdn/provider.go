@@ -0,0 +1,129 @@
+package cdn
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/joshdurbin/url-shortener/internal/version"
+)
+
+// CDN purge APIs the purger can call
+const (
+	ProviderCloudflare = "cloudflare" // One POST of {"files": [...]} per batch, bearer token
+	ProviderFastly     = "fastly"     // One POST to <purge URL>/<host>/<path> per URL, Fastly-Key token
+)
+
+// Provider purges URLs through one CDN's purge API. Purge is called by a
+// single worker with at most maxBatch URLs; an error wrapping a StatusError
+// is retried only for 429 and 5xx statuses, any other error always is.
+type Provider interface {
+	Purge(ctx context.Context, urls []string) error
+}
+
+// StatusError is a purge API's answer other than 2xx
+type StatusError struct {
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("purge endpoint returned status %d", e.StatusCode)
+}
+
+// retryable reports whether a failed purge may succeed when retried
+func retryable(err error) bool {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode == http.StatusTooManyRequests || statusErr.StatusCode >= 500
+	}
+	return true
+}
+
+// newProvider returns the provider named by the configuration
+func newProvider(config Config, client *http.Client) Provider {
+	if config.Provider == ProviderFastly {
+		return &fastly{config: config, client: client}
+	}
+	return &cloudflare{config: config, client: client}
+}
+
+// cloudflare purges through the Cloudflare purge-by-URL API, or an adapter
+// accepting its request shape
+type cloudflare struct {
+	config Config
+	client *http.Client
+}
+
+// purgeRequest is the body of a Cloudflare purge request
+type purgeRequest struct {
+	Files []string `json:"files"`
+}
+
+func (c *cloudflare) Purge(ctx context.Context, urls []string) error {
+	body, err := json.Marshal(purgeRequest{Files: urls})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.config.PurgeURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.config.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.config.Token)
+	}
+	return send(c.client, req)
+}
+
+// fastly purges through the Fastly single-URL purge API, which takes the
+// cached URL without its scheme in the path
+type fastly struct {
+	config Config
+	client *http.Client
+}
+
+func (f *fastly) Purge(ctx context.Context, urls []string) error {
+	var errs []error
+	for _, link := range urls {
+		u, err := url.Parse(link)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		target := strings.TrimSuffix(f.config.PurgeURL, "/") + "/" + u.Host + u.EscapedPath()
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, nil)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if f.config.Token != "" {
+			req.Header.Set("Fastly-Key", f.config.Token)
+		}
+		if err := send(f.client, req); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", link, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// send makes one purge request and checks its status
+func send(client *http.Client, req *http.Request) error {
+	req.Header.Set("User-Agent", version.UserAgent())
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return &StatusError{StatusCode: resp.StatusCode}
+	}
+	return nil
+}
@@ -0,0 +1,210 @@
+package cdn
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxBatch is the most URLs sent in one purge request, the limit of the
+// Cloudflare purge API
+const maxBatch = 30
+
+// Purger asks a CDN to drop the redirects it cached for changed links, so
+// edits, deletions and failovers take effect before the cached copies
+// expire. Links are queued by Invalidate and purged in batches by one
+// worker, so a slow CDN API never holds up the service; failed batches are
+// retried with exponential backoff.
+type Purger struct {
+	config    Config
+	provider  Provider
+	serverURL string          // Base URL of the server's own host
+	hosts     func() []string // Custom domains links may also be served on
+
+	mutex    sync.RWMutex
+	started  bool
+	closed   bool
+	queue    chan string
+	stopChan chan struct{}
+	wg       sync.WaitGroup
+}
+
+// New creates a purger for a server whose own base URL is serverURL, or
+// returns nil when purging is off; Start, Close and Invalidate of a nil
+// purger do nothing. hosts, which may be nil, lists the custom domains a
+// link is also purged on.
+func New(config Config, serverURL string, hosts func() []string) *Purger {
+	if !config.Enabled() {
+		return nil
+	}
+
+	return &Purger{
+		config:    config,
+		provider:  newProvider(config, &http.Client{Timeout: config.Timeout}),
+		serverURL: strings.TrimSuffix(serverURL, "/"),
+		hosts:     hosts,
+		queue:     make(chan string, config.QueueSize),
+		stopChan:  make(chan struct{}),
+	}
+}
+
+// Start starts the worker sending purge requests
+func (p *Purger) Start() error {
+	if p == nil {
+		return nil
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.started {
+		return fmt.Errorf("CDN purger already started")
+	}
+	p.started = true
+
+	p.wg.Add(1)
+	go p.worker()
+	return nil
+}
+
+// Close stops the worker once the links already queued are purged
+func (p *Purger) Close() error {
+	if p == nil {
+		return nil
+	}
+
+	p.mutex.Lock()
+	if p.closed {
+		p.mutex.Unlock()
+		return nil
+	}
+	p.closed = true
+	close(p.stopChan)
+	p.mutex.Unlock()
+
+	p.wg.Wait()
+	return nil
+}
+
+// Invalidate queues a link to be purged without blocking; links are dropped
+// when the queue is full
+func (p *Purger) Invalidate(shortCode string) {
+	if p == nil {
+		return
+	}
+
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	if !p.started || p.closed {
+		return
+	}
+
+	select {
+	case p.queue <- shortCode:
+	default:
+		log.Printf("CDN purge queue full, dropping the purge of %s", shortCode)
+	}
+}
+
+// worker purges queued links until the purger is closed, then purges what is
+// left in the queue
+func (p *Purger) worker() {
+	defer p.wg.Done()
+
+	for {
+		select {
+		case <-p.stopChan:
+			for p.purgeQueued("") {
+			}
+			return
+		case shortCode := <-p.queue:
+			p.purgeQueued(shortCode)
+		}
+	}
+}
+
+// purgeQueued purges shortCode, if set, together with the links queued
+// behind it, and reports whether anything was purged
+func (p *Purger) purgeQueued(shortCode string) bool {
+	var urls []string
+	if shortCode != "" {
+		urls = p.urls(shortCode)
+	}
+drain:
+	for len(urls) < maxBatch {
+		select {
+		case next := <-p.queue:
+			urls = append(urls, p.urls(next)...)
+		default:
+			break drain
+		}
+	}
+	if len(urls) == 0 {
+		return false
+	}
+
+	for start := 0; start < len(urls); start += maxBatch {
+		batch := urls[start:min(start+maxBatch, len(urls))]
+		p.purge(batch)
+	}
+	return true
+}
+
+// urls returns the public URLs of a link: on the server's own host and on
+// every custom domain
+func (p *Purger) urls(shortCode string) []string {
+	path := "/" + url.PathEscape(shortCode)
+	urls := []string{p.serverURL + path}
+	if p.hosts != nil {
+		scheme := "https"
+		if u, err := url.Parse(p.serverURL); err == nil && u.Scheme != "" {
+			scheme = u.Scheme
+		}
+		for _, host := range p.hosts() {
+			urls = append(urls, scheme+"://"+host+path)
+		}
+	}
+	return urls
+}
+
+// purge sends one batch to the provider, retrying failures until they
+// succeed, cannot succeed, run out of attempts or the purger is closed
+func (p *Purger) purge(urls []string) {
+	for attempt := 1; ; attempt++ {
+		ctx, cancel := context.WithTimeout(context.Background(), p.config.Timeout)
+		err := p.provider.Purge(ctx, urls)
+		cancel()
+		if err == nil {
+			return
+		}
+		if !retryable(err) || attempt == p.config.MaxAttempts {
+			log.Printf("Error purging %d URLs from the CDN after %d attempts: %v", len(urls), attempt, err)
+			return
+		}
+
+		select {
+		case <-p.stopChan:
+			log.Printf("Error purging %d URLs from the CDN, not retried while closing: %v", len(urls), err)
+			return
+		case <-time.After(p.backoff(attempt)):
+		}
+	}
+}
+
+// backoff returns the wait before the retry that follows the given attempt
+func (p *Purger) backoff(attempt int) time.Duration {
+	wait := p.config.InitialBackoff
+	for i := 1; i < attempt && wait < p.config.MaxBackoff; i++ {
+		wait *= 2
+	}
+	if wait > p.config.MaxBackoff {
+		wait = p.config.MaxBackoff
+	}
+	return wait
+}
@@ -0,0 +1,244 @@
+package cdn
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// purgeCall is one request received by the test purge endpoint
+type purgeCall struct {
+	auth  string
+	files []string
+}
+
+func purgeServer(t *testing.T, status int) (*httptest.Server, chan purgeCall) {
+	t.Helper()
+	calls := make(chan purgeCall, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		var body purgeRequest
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		calls <- purgeCall{auth: r.Header.Get("Authorization"), files: body.Files}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+	return server, calls
+}
+
+func TestConfig_Validate(t *testing.T) {
+	assert.NoError(t, DefaultConfig().Validate())
+	assert.False(t, DefaultConfig().Enabled())
+
+	config := DefaultConfig()
+	config.PurgeURL = "https://api.cdn.example/purge"
+	assert.NoError(t, config.Validate())
+
+	config.PurgeURL = "api.cdn.example/purge"
+	assert.ErrorContains(t, config.Validate(), "http or https URL")
+
+	config.PurgeURL = "https://api.cdn.example/purge"
+	config.Timeout = 0
+	assert.ErrorContains(t, config.Validate(), "timeout")
+
+	config.Timeout = time.Second
+	config.QueueSize = 0
+	assert.ErrorContains(t, config.Validate(), "queue size")
+
+	config = DefaultConfig()
+	config.PurgeURL = "https://api.fastly.com/purge"
+	config.Provider = ProviderFastly
+	assert.NoError(t, config.Validate())
+	config.Provider = "akamai"
+	assert.ErrorContains(t, config.Validate(), "provider must be")
+
+	config.Provider = ProviderCloudflare
+	config.MaxAttempts = 0
+	assert.ErrorContains(t, config.Validate(), "max attempts")
+
+	config.MaxAttempts = 1
+	config.MaxBackoff = time.Millisecond
+	assert.ErrorContains(t, config.Validate(), "backoff")
+}
+
+func TestNew_Off(t *testing.T) {
+	p := New(DefaultConfig(), "http://localhost:8080", nil)
+	assert.Nil(t, p)
+
+	assert.NoError(t, p.Start())
+	p.Invalidate("abc123")
+	assert.NoError(t, p.Close())
+}
+
+func TestPurger_Invalidate(t *testing.T) {
+	server, calls := purgeServer(t, http.StatusOK)
+	config := DefaultConfig()
+	config.PurgeURL = server.URL
+	config.Token = "cdn-token"
+
+	p := New(config, "https://sho.rt/", func() []string { return []string{"go.example.com"} })
+	require.NotNil(t, p)
+	require.NoError(t, p.Start())
+	defer p.Close()
+
+	p.Invalidate("abc123")
+	select {
+	case call := <-calls:
+		assert.Equal(t, "Bearer cdn-token", call.auth)
+		assert.Equal(t, []string{"https://sho.rt/abc123", "https://go.example.com/abc123"}, call.files)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the link was not purged")
+	}
+}
+
+func TestPurger_Batches(t *testing.T) {
+	server, calls := purgeServer(t, http.StatusOK)
+	config := DefaultConfig()
+	config.PurgeURL = server.URL
+
+	p := New(config, "http://localhost:8080", nil)
+	// Queued before the worker starts, so they are purged together
+	p.started = true
+	for _, code := range []string{"a", "b", "c"} {
+		p.Invalidate(code)
+	}
+	p.started = false
+	require.NoError(t, p.Start())
+
+	select {
+	case call := <-calls:
+		assert.Empty(t, call.auth)
+		assert.Equal(t, []string{"http://localhost:8080/a", "http://localhost:8080/b", "http://localhost:8080/c"}, call.files)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the links were not purged")
+	}
+	require.NoError(t, p.Close())
+
+	// Links invalidated after closing are not purged
+	p.Invalidate("d")
+	assert.Empty(t, calls)
+}
+
+func TestPurger_CloseDrainsQueue(t *testing.T) {
+	server, calls := purgeServer(t, http.StatusOK)
+	config := DefaultConfig()
+	config.PurgeURL = server.URL
+
+	p := New(config, "http://localhost:8080", nil)
+	p.started = true
+	for i := 0; i < maxBatch+5; i++ {
+		p.Invalidate("code")
+	}
+	// The worker sees the stop signal with the queue full
+	close(p.stopChan)
+	p.closed = true
+	p.wg.Add(1)
+	p.worker()
+
+	require.Len(t, calls, 2)
+	assert.Len(t, (<-calls).files, maxBatch)
+	assert.Len(t, (<-calls).files, 5)
+}
+
+func TestPurger_Retries(t *testing.T) {
+	statuses := make(chan int, 3)
+	statuses <- http.StatusServiceUnavailable
+	statuses <- http.StatusTooManyRequests
+	statuses <- http.StatusOK
+	attempts := make(chan struct{}, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts <- struct{}{}
+		select {
+		case status := <-statuses:
+			w.WriteHeader(status)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	config := DefaultConfig()
+	config.PurgeURL = server.URL
+	config.InitialBackoff = time.Millisecond
+	config.MaxBackoff = 2 * time.Millisecond
+	p := New(config, "http://localhost:8080", nil)
+
+	// Retried until it succeeds
+	p.purge([]string{"http://localhost:8080/abc123"})
+	assert.Len(t, attempts, 3)
+
+	// Client errors are not retried
+	<-attempts
+	<-attempts
+	<-attempts
+	p.purge([]string{"http://localhost:8080/abc123"})
+	assert.Len(t, attempts, 1)
+}
+
+func TestPurger_GivesUp(t *testing.T) {
+	server, calls := purgeServer(t, http.StatusBadGateway)
+	config := DefaultConfig()
+	config.PurgeURL = server.URL
+	config.InitialBackoff = time.Millisecond
+	config.MaxBackoff = time.Millisecond
+
+	p := New(config, "http://localhost:8080", nil)
+	p.purge([]string{"http://localhost:8080/abc123"})
+	assert.Len(t, calls, config.MaxAttempts)
+}
+
+func TestPurger_Backoff(t *testing.T) {
+	config := DefaultConfig()
+	config.PurgeURL = "https://api.cdn.example/purge"
+	p := New(config, "http://localhost:8080", nil)
+
+	assert.Equal(t, time.Second, p.backoff(1))
+	assert.Equal(t, 2*time.Second, p.backoff(2))
+	assert.Equal(t, 16*time.Second, p.backoff(5))
+	assert.Equal(t, 30*time.Second, p.backoff(10))
+}
+
+func TestFastly_Purge(t *testing.T) {
+	var paths, keys []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		paths = append(paths, r.URL.EscapedPath())
+		keys = append(keys, r.Header.Get("Fastly-Key"))
+		if strings.HasSuffix(r.URL.Path, "/fail") {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	config := DefaultConfig()
+	config.PurgeURL = server.URL + "/purge/"
+	config.Provider = ProviderFastly
+	config.Token = "fastly-key"
+	p := New(config, "https://sho.rt", nil)
+
+	err := p.provider.Purge(context.Background(), []string{"https://sho.rt/abc123", "https://go.example.com/a%20b", "https://sho.rt/fail"})
+	assert.Equal(t, []string{"/purge/sho.rt/abc123", "/purge/go.example.com/a%20b", "/purge/sho.rt/fail"}, paths)
+	assert.Equal(t, []string{"fastly-key", "fastly-key", "fastly-key"}, keys)
+
+	// One failed URL fails the batch so it is retried
+	var statusErr *StatusError
+	require.ErrorAs(t, err, &statusErr)
+	assert.Equal(t, http.StatusInternalServerError, statusErr.StatusCode)
+	assert.True(t, retryable(err))
+}
+
+func TestRetryable(t *testing.T) {
+	assert.True(t, retryable(errors.New("connection refused")))
+	assert.True(t, retryable(&StatusError{StatusCode: http.StatusBadGateway}))
+	assert.True(t, retryable(&StatusError{StatusCode: http.StatusTooManyRequests}))
+	assert.False(t, retryable(&StatusError{StatusCode: http.StatusForbidden}))
+}
@@ -4,7 +4,36 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/joshdurbin/url-shortener/internal/abuse"
+	"github.com/joshdurbin/url-shortener/internal/analytics"
+	"github.com/joshdurbin/url-shortener/internal/archive"
+	"github.com/joshdurbin/url-shortener/internal/auth"
+	"github.com/joshdurbin/url-shortener/internal/backup"
+	"github.com/joshdurbin/url-shortener/internal/bots"
+	"github.com/joshdurbin/url-shortener/internal/cache/memory"
+	"github.com/joshdurbin/url-shortener/internal/cdn"
+	"github.com/joshdurbin/url-shortener/internal/daemon"
+	"github.com/joshdurbin/url-shortener/internal/destinations"
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	"github.com/joshdurbin/url-shortener/internal/events"
+	"github.com/joshdurbin/url-shortener/internal/export"
+	"github.com/joshdurbin/url-shortener/internal/failover"
+	"github.com/joshdurbin/url-shortener/internal/geoip"
+	"github.com/joshdurbin/url-shortener/internal/hosts"
+	"github.com/joshdurbin/url-shortener/internal/mailer"
+	"github.com/joshdurbin/url-shortener/internal/peers"
+	"github.com/joshdurbin/url-shortener/internal/policy"
+	"github.com/joshdurbin/url-shortener/internal/preview"
+	"github.com/joshdurbin/url-shortener/internal/privacy"
+	"github.com/joshdurbin/url-shortener/internal/reachability"
+	"github.com/joshdurbin/url-shortener/internal/repository/sqlite"
+	"github.com/joshdurbin/url-shortener/internal/retention"
+	"github.com/joshdurbin/url-shortener/internal/service"
 	"github.com/joshdurbin/url-shortener/internal/shortener"
+	"github.com/joshdurbin/url-shortener/internal/storage"
+	"github.com/joshdurbin/url-shortener/internal/tracing"
+	httpTransport "github.com/joshdurbin/url-shortener/internal/transport/http"
+	"github.com/joshdurbin/url-shortener/internal/webhook"
 )
 
 // Config holds the application configuration
@@ -14,47 +43,496 @@ type Config struct {
 	Cache     CacheConfig
 	Logging   LoggingConfig
 	Shortener shortener.Config
+	Auth      auth.Config
+	Webhooks  webhook.Config
+	Policies  policy.Config
+	Failover  failover.Config
+	Previews  preview.Config
+	Storage   storage.Config
+	Backup    backup.Config
+	Archive   archive.Config
+	Retention retention.Config
+	Privacy   privacy.Config
+	Domains   destinations.Config
+	Verify    reachability.Config
+	Mail      mailer.Config
+	Hosts     hosts.Config
+	CDN       cdn.Config
+	GeoIP     geoip.Config
+	Bots      bots.Config
+	Abuse     abuse.Config
+	Analytics analytics.Config
+	Events    service.EventBusConfig
+	Clicks    service.ClickBufferConfig
+	Outbox    service.OutboxConfig
+	Broker    events.Config
+	Export    export.Config
+	Tracing   tracing.Config
+	Peers     peers.Config
 }
 
 // ServerConfig holds server-related configuration
 type ServerConfig struct {
 	Port      string
 	ServerURL string
+	// DrainTimeout bounds how long shutdown waits for in-flight HTTP requests (0 = no limit)
+	DrainTimeout time.Duration
+	// ShutdownStageTimeout bounds each shutdown stage after the HTTP drain (0 = no limit)
+	ShutdownStageTimeout time.Duration
+	// TLS enables HTTPS when a certificate source is configured
+	TLS httpTransport.TLSConfig
+	// Redirects sets the default redirect status and permanent redirect caching
+	Redirects httpTransport.RedirectConfig
+	// Proxies lists the reverse proxies trusted to report the client address
+	Proxies httpTransport.ProxyConfig
+	// Timeouts bounds how long redirects, API and bulk requests may run
+	Timeouts httpTransport.TimeoutConfig
+	// ErrorPagesDir holds HTML templates replacing the built-in error pages ("" = built-in only)
+	ErrorPagesDir string
+	// PIDFile records the server's process ID while it runs ("" = none)
+	PIDFile string
+	// ControlSocket is the unix socket answering server status, reload and stop ("" = none)
+	ControlSocket string
+	// Listen adds listeners to the port: unix:PATH, fd:N or tcp:ADDR
+	Listen []string
+	// AdminListen serves the API, dashboard and metrics only on these listeners (none = on every listener)
+	AdminListen []string
 }
 
 // DatabaseConfig holds database-related configuration
 type DatabaseConfig struct {
 	Path string
+	// Driver is the SQLite driver: mattn (cgo) or modernc (pure Go)
+	Driver sqlite.Driver
+	// Tuning holds the connection pragmas and pool limits
+	Tuning sqlite.Tuning
+	// Breaker fails database calls fast while too many of them fail
+	Breaker service.BreakerConfig
 }
 
 // CacheConfig holds cache-related configuration
 type CacheConfig struct {
 	SyncInterval time.Duration
+	UsageMerge   domain.UsageMergeStrategy
+	// EntryTTL expires cache entries this long after they are loaded (0 = never)
+	EntryTTL time.Duration
+	// RefreshOnAccess extends an entry's TTL on every redirect
+	RefreshOnAccess bool
+	// Sync sets the retries, dead-letter log and WAL of failed usage syncs
+	Sync service.SyncConfig
+	// ServeStale keeps expired entries this long to serve redirects while the
+	// database is failing, and rejects failing writes with 503 (0 = disabled)
+	ServeStale time.Duration
+	// Shards splits the memory cache into this many locked maps, rounded up to a power of two
+	// (0 = memory.DefaultShards)
+	Shards int
+	// ResponseTTL caches link info, link list and storage responses this long (0 = disabled)
+	ResponseTTL time.Duration
+	// ClickDedupeWindow counts one click per client and link per window, for
+	// links without their own window (0 = count every click)
+	ClickDedupeWindow time.Duration
 }
 
+// maxCacheShards bounds the memory cache shards; past a few per core more
+// only cost memory
+const maxCacheShards = 4096
 
 // LoggingConfig holds logging-related configuration
 type LoggingConfig struct {
 	Verbose bool
+	// Requests sets the sampling, body limit and redaction of verbose request logs
+	Requests httpTransport.RequestLogConfig
+}
+
+// Option configures optional settings before validation
+type Option func(*Config)
+
+// WithAuth sets the authentication configuration
+func WithAuth(authConfig auth.Config) Option {
+	return func(c *Config) {
+		c.Auth = authConfig
+	}
+}
+
+// WithUsageMerge sets how cache syncs resolve usage counts written by other instances
+func WithUsageMerge(strategy domain.UsageMergeStrategy) Option {
+	return func(c *Config) {
+		c.Cache.UsageMerge = strategy
+	}
+}
+
+// WithDatabaseDriver selects the SQLite driver by name; empty keeps the default
+func WithDatabaseDriver(name string) Option {
+	return func(c *Config) {
+		if name != "" {
+			c.Database.Driver = sqlite.Driver(name)
+		}
+	}
+}
+
+// WithDatabaseTuning sets the SQLite pragmas and connection pool limits
+func WithDatabaseTuning(tuning sqlite.Tuning) Option {
+	return func(c *Config) {
+		c.Database.Tuning = tuning
+	}
+}
+
+// WithCacheEntryTTL sets how long cache entries live and whether redirects extend them
+func WithCacheEntryTTL(ttl time.Duration, refreshOnAccess bool) Option {
+	return func(c *Config) {
+		c.Cache.EntryTTL = ttl
+		c.Cache.RefreshOnAccess = refreshOnAccess
+	}
+}
+
+// WithSyncRetry sets how failed usage syncs are retried and where usage that
+// cannot be synced is kept
+func WithSyncRetry(syncConfig service.SyncConfig) Option {
+	return func(c *Config) {
+		c.Cache.Sync = syncConfig
+	}
+}
+
+// WithServeStale sets how long expired cache entries may serve redirects
+// while the database is failing
+func WithServeStale(ttl time.Duration) Option {
+	return func(c *Config) {
+		c.Cache.ServeStale = ttl
+	}
+}
+
+// WithCacheShards sets how many independently locked maps the memory cache is split into
+func WithCacheShards(shards int) Option {
+	return func(c *Config) {
+		c.Cache.Shards = shards
+	}
+}
+
+// WithResponseCacheTTL sets how long assembled API responses are cached
+func WithResponseCacheTTL(ttl time.Duration) Option {
+	return func(c *Config) {
+		c.Cache.ResponseTTL = ttl
+	}
+}
+
+// WithClickDedupeWindow sets the default window repeated clicks are counted once in
+func WithClickDedupeWindow(window time.Duration) Option {
+	return func(c *Config) {
+		c.Cache.ClickDedupeWindow = window
+	}
+}
+
+// WithShutdownTimeouts sets the HTTP drain timeout and the timeout for each
+// later shutdown stage (final cache sync, webhook drain, closing storage)
+func WithShutdownTimeouts(drain, stage time.Duration) Option {
+	return func(c *Config) {
+		c.Server.DrainTimeout = drain
+		c.Server.ShutdownStageTimeout = stage
+	}
+}
+
+// WithTLS sets the HTTPS configuration
+func WithTLS(tlsConfig httpTransport.TLSConfig) Option {
+	return func(c *Config) {
+		c.Server.TLS = tlsConfig
+	}
+}
+
+// WithRedirects sets the default redirect status and permanent redirect caching
+func WithRedirects(redirectConfig httpTransport.RedirectConfig) Option {
+	return func(c *Config) {
+		c.Server.Redirects = redirectConfig
+	}
+}
+
+// WithErrorPagesDir sets the directory of error page templates overriding the built-in ones
+func WithErrorPagesDir(dir string) Option {
+	return func(c *Config) {
+		c.Server.ErrorPagesDir = dir
+	}
+}
+
+// WithDaemon sets the pid file and control socket used to manage the server as a service
+func WithDaemon(pidFile, controlSocket string) Option {
+	return func(c *Config) {
+		c.Server.PIDFile = pidFile
+		c.Server.ControlSocket = controlSocket
+	}
+}
+
+// WithRequestLog sets the sampling, body limit and redaction of verbose request logs
+func WithRequestLog(logConfig httpTransport.RequestLogConfig) Option {
+	return func(c *Config) {
+		c.Logging.Requests = logConfig
+	}
+}
+
+// WithTimeouts sets the request deadline of each route class
+func WithTimeouts(timeouts httpTransport.TimeoutConfig) Option {
+	return func(c *Config) {
+		c.Server.Timeouts = timeouts
+	}
+}
+
+// WithProxies sets the reverse proxies trusted to report the client address
+func WithProxies(proxyConfig httpTransport.ProxyConfig) Option {
+	return func(c *Config) {
+		c.Server.Proxies = proxyConfig
+	}
+}
+
+// WithListen adds listeners (unix:PATH, fd:N or tcp:ADDR) to the server port,
+// and moves the management routes to separate admin listeners when given
+func WithListen(addresses, adminAddresses []string) Option {
+	return func(c *Config) {
+		c.Server.Listen = addresses
+		c.Server.AdminListen = adminAddresses
+	}
+}
+
+// WithWebhooks sets the webhook delivery configuration
+func WithWebhooks(webhookConfig webhook.Config) Option {
+	return func(c *Config) {
+		c.Webhooks = webhookConfig
+	}
+}
+
+// WithPolicies sets the lifecycle policy configuration
+func WithPolicies(policyConfig policy.Config) Option {
+	return func(c *Config) {
+		c.Policies = policyConfig
+	}
+}
+
+// WithFailover sets the destination health check configuration
+func WithFailover(failoverConfig failover.Config) Option {
+	return func(c *Config) {
+		c.Failover = failoverConfig
+	}
+}
+
+// WithPreviews sets the link preview fetching configuration
+func WithPreviews(previewConfig preview.Config) Option {
+	return func(c *Config) {
+		c.Previews = previewConfig
+	}
+}
+
+// WithStorage sets the storage reporting and quota configuration
+func WithStorage(storageConfig storage.Config) Option {
+	return func(c *Config) {
+		c.Storage = storageConfig
+	}
+}
+
+// WithBackup sets the database backup directory, schedule and rotation
+func WithBackup(backupConfig backup.Config) Option {
+	return func(c *Config) {
+		c.Backup = backupConfig
+	}
+}
+
+// WithArchive sets where and when old clicks are archived
+func WithArchive(archiveConfig archive.Config) Option {
+	return func(c *Config) {
+		c.Archive = archiveConfig
+	}
+}
+
+// WithRetention sets how long clicks and visitor addresses are kept
+func WithRetention(retentionConfig retention.Config) Option {
+	return func(c *Config) {
+		c.Retention = retentionConfig
+	}
+}
+
+// WithPrivacy sets how visitor addresses are anonymized
+func WithPrivacy(privacyConfig privacy.Config) Option {
+	return func(c *Config) {
+		c.Privacy = privacyConfig
+	}
+}
+
+// WithDestinations sets the domains short URLs may and may not point at
+func WithDestinations(destConfig destinations.Config) Option {
+	return func(c *Config) {
+		c.Domains = destConfig
+	}
+}
+
+// WithVerify sets whether and how link destinations are checked for reachability
+func WithVerify(verifyConfig reachability.Config) Option {
+	return func(c *Config) {
+		c.Verify = verifyConfig
+	}
+}
+
+// WithMail sets the SMTP server and recipients of broken link and abuse emails
+func WithMail(mailConfig mailer.Config) Option {
+	return func(c *Config) {
+		c.Mail = mailConfig
+	}
+}
+
+// WithHosts sets whether links can be served on custom domains
+func WithHosts(hostsConfig hosts.Config) Option {
+	return func(c *Config) {
+		c.Hosts = hostsConfig
+	}
+}
+
+// WithCDN sets the endpoint changed links are purged from a CDN through
+func WithCDN(cdnConfig cdn.Config) Option {
+	return func(c *Config) {
+		c.CDN = cdnConfig
+	}
+}
+
+// WithGeoIP sets the visitor country lookup used by routing rules
+func WithGeoIP(geoConfig geoip.Config) Option {
+	return func(c *Config) {
+		c.GeoIP = geoConfig
+	}
+}
+
+// WithBots sets how bot redirects are counted and how crawlers are detected
+func WithBots(botConfig bots.Config) Option {
+	return func(c *Config) {
+		c.Bots = botConfig
+	}
+}
+
+// WithAbuse sets which click patterns flag links for click fraud and what
+// happens to flagged links
+func WithAbuse(abuseConfig abuse.Config) Option {
+	return func(c *Config) {
+		c.Abuse = abuseConfig
+	}
+}
+
+// WithAnalytics sets how often clicks are added to the analytics rollups
+func WithAnalytics(analyticsConfig analytics.Config) Option {
+	return func(c *Config) {
+		c.Analytics = analyticsConfig
+	}
+}
+
+// WithEvents sets how many live event streams are allowed and how many events
+// each may fall behind
+func WithEvents(eventsConfig service.EventBusConfig) Option {
+	return func(c *Config) {
+		c.Events = eventsConfig
+	}
+}
+
+// WithBroker sets the external broker events are published to
+func WithBroker(brokerConfig events.Config) Option {
+	return func(c *Config) {
+		c.Broker = brokerConfig
+	}
+}
+
+// WithCircuitBreaker sets when database calls fail fast instead of waiting on a failing database
+func WithCircuitBreaker(breakerConfig service.BreakerConfig) Option {
+	return func(c *Config) {
+		c.Database.Breaker = breakerConfig
+	}
+}
+
+// WithClickBuffer sets how clicks are buffered between redirects and the cache
+func WithClickBuffer(clickConfig service.ClickBufferConfig) Option {
+	return func(c *Config) {
+		c.Clicks = clickConfig
+	}
+}
+
+// WithEventOutbox sets whether created events are stored with their links
+// and published from the event outbox
+func WithEventOutbox(outboxConfig service.OutboxConfig) Option {
+	return func(c *Config) {
+		c.Outbox = outboxConfig
+	}
+}
+
+// WithExport sets where created and clicked events are exported for data pipelines
+func WithExport(exportConfig export.Config) Option {
+	return func(c *Config) {
+		c.Export = exportConfig
+	}
+}
+
+// WithTracing sets where request traces are exported
+func WithTracing(tracingConfig tracing.Config) Option {
+	return func(c *Config) {
+		c.Tracing = tracingConfig
+	}
+}
+
+// WithPeers sets the instances sharing the database that are told about changed links
+func WithPeers(peersConfig peers.Config) Option {
+	return func(c *Config) {
+		c.Peers = peersConfig
+	}
 }
 
 // New creates a new config with the given parameters
-func New(port, serverURL, dbPath string, syncInterval time.Duration, verbose bool, shortenerConfig shortener.Config) (*Config, error) {
+func New(port, serverURL, dbPath string, syncInterval time.Duration, verbose bool, shortenerConfig shortener.Config, opts ...Option) (*Config, error) {
 	cfg := &Config{
 		Server: ServerConfig{
-			Port:      port,
-			ServerURL: serverURL,
+			Port:                 port,
+			ServerURL:            serverURL,
+			DrainTimeout:         30 * time.Second,
+			ShutdownStageTimeout: 10 * time.Second,
+			Redirects:            httpTransport.DefaultRedirectConfig(),
+			Timeouts:             httpTransport.DefaultTimeoutConfig(),
 		},
 		Database: DatabaseConfig{
-			Path: dbPath,
+			Path:    dbPath,
+			Driver:  sqlite.DefaultDriver(),
+			Tuning:  sqlite.DefaultTuning(),
+			Breaker: service.DefaultBreakerConfig(),
 		},
 		Cache: CacheConfig{
-			SyncInterval: syncInterval,
+			SyncInterval:    syncInterval,
+			UsageMerge:      domain.UsageMergeDelta,
+			RefreshOnAccess: true,
+			Sync:            service.DefaultSyncConfig(),
+			Shards:          memory.DefaultShards,
 		},
 		Logging: LoggingConfig{
-			Verbose: verbose,
+			Verbose:  verbose,
+			Requests: httpTransport.DefaultRequestLogConfig(),
 		},
 		Shortener: shortenerConfig,
+		Webhooks:  webhook.DefaultConfig(),
+		Policies:  policy.DefaultConfig(),
+		Failover:  failover.DefaultConfig(),
+		Previews:  preview.DefaultConfig(),
+		Storage:   storage.DefaultConfig(),
+		Backup:    backup.DefaultConfig(),
+		Archive:   archive.DefaultConfig(),
+		Retention: retention.DefaultConfig(),
+		Privacy:   privacy.DefaultConfig(),
+		Domains:   destinations.DefaultConfig(),
+		Verify:    reachability.DefaultConfig(),
+		Mail:      mailer.DefaultConfig(),
+		Hosts:     hosts.DefaultConfig(),
+		CDN:       cdn.DefaultConfig(),
+		GeoIP:     geoip.DefaultConfig(),
+		Bots:      bots.DefaultConfig(),
+		Abuse:     abuse.DefaultConfig(),
+		Analytics: analytics.DefaultConfig(),
+		Events:    service.DefaultEventBusConfig(),
+		Clicks:    service.DefaultClickBufferConfig(),
+		Outbox:    service.DefaultOutboxConfig(),
+		Broker:    events.DefaultConfig(),
+		Export:    export.DefaultConfig(),
+		Tracing:   tracing.DefaultConfig(),
+		Peers:     peers.DefaultConfig(),
+	}
+
+	for _, opt := range opts {
+		opt(cfg)
 	}
 
 	// Validate configuration
@@ -75,13 +553,195 @@ func (c *Config) validate() error {
 		return fmt.Errorf("server URL cannot be empty")
 	}
 
+	if c.Server.DrainTimeout < 0 || c.Server.ShutdownStageTimeout < 0 {
+		return fmt.Errorf("shutdown timeouts cannot be negative, got drain %v and stage %v", c.Server.DrainTimeout, c.Server.ShutdownStageTimeout)
+	}
+
+	if err := c.Server.TLS.Validate(); err != nil {
+		return fmt.Errorf("invalid TLS configuration: %w", err)
+	}
+
+	if err := c.Server.Redirects.Validate(); err != nil {
+		return fmt.Errorf("invalid redirect configuration: %w", err)
+	}
+
+	if err := c.Logging.Requests.Validate(); err != nil {
+		return fmt.Errorf("invalid request log configuration: %w", err)
+	}
+
+	if err := c.Server.Timeouts.Validate(); err != nil {
+		return fmt.Errorf("invalid timeout configuration: %w", err)
+	}
+
+	if err := c.Server.Proxies.Validate(); err != nil {
+		return fmt.Errorf("invalid proxy configuration: %w", err)
+	}
+
+	for _, address := range append(c.Server.Listen, c.Server.AdminListen...) {
+		if err := daemon.ValidateListenAddress(address); err != nil {
+			return err
+		}
+	}
+
 	if c.Database.Path == "" {
 		return fmt.Errorf("database path cannot be empty")
 	}
 
+	if _, err := sqlite.ParseDriver(string(c.Database.Driver)); err != nil {
+		return err
+	}
+
+	if err := c.Database.Tuning.Validate(); err != nil {
+		return fmt.Errorf("invalid database tuning: %w", err)
+	}
+
 	if c.Cache.SyncInterval <= 0 {
 		return fmt.Errorf("cache sync interval must be positive, got: %v", c.Cache.SyncInterval)
 	}
 
+	switch c.Cache.UsageMerge {
+	case domain.UsageMergeMax, domain.UsageMergeDelta:
+	default:
+		return fmt.Errorf("unknown usage merge strategy: %q", c.Cache.UsageMerge)
+	}
+
+	if c.Cache.EntryTTL < 0 {
+		return fmt.Errorf("cache entry TTL cannot be negative, got: %v", c.Cache.EntryTTL)
+	}
+
+	if err := c.Cache.Sync.Validate(); err != nil {
+		return fmt.Errorf("invalid usage sync configuration: %w", err)
+	}
+
+	if c.Cache.ServeStale < 0 {
+		return fmt.Errorf("serve stale duration cannot be negative, got: %v", c.Cache.ServeStale)
+	}
+
+	if c.Cache.Shards == 0 {
+		c.Cache.Shards = memory.DefaultShards
+	}
+	if c.Cache.Shards < 1 || c.Cache.Shards > maxCacheShards {
+		return fmt.Errorf("cache shards must be between 1 and %d, got: %d", maxCacheShards, c.Cache.Shards)
+	}
+
+	if c.Cache.ResponseTTL < 0 {
+		return fmt.Errorf("response cache TTL cannot be negative, got: %v", c.Cache.ResponseTTL)
+	}
+
+	if c.Cache.ClickDedupeWindow < 0 {
+		return fmt.Errorf("click dedupe window cannot be negative, got: %v", c.Cache.ClickDedupeWindow)
+	}
+
+	if err := c.Shortener.Validate(); err != nil {
+		return fmt.Errorf("invalid shortener configuration: %w", err)
+	}
+
+	if c.Auth.ShareTokenMaxTTL < 0 {
+		return fmt.Errorf("share token max TTL cannot be negative, got: %v", c.Auth.ShareTokenMaxTTL)
+	}
+
+	if err := c.Auth.OIDC.Validate(); err != nil {
+		return fmt.Errorf("invalid OIDC configuration: %w", err)
+	}
+
+	if err := c.Webhooks.Validate(); err != nil {
+		return fmt.Errorf("invalid webhook configuration: %w", err)
+	}
+
+	if err := c.Policies.Validate(); err != nil {
+		return fmt.Errorf("invalid lifecycle policy configuration: %w", err)
+	}
+
+	if err := c.Failover.Validate(); err != nil {
+		return fmt.Errorf("invalid failover configuration: %w", err)
+	}
+
+	if err := c.Previews.Validate(); err != nil {
+		return fmt.Errorf("invalid link preview configuration: %w", err)
+	}
+
+	if err := c.Storage.Validate(); err != nil {
+		return fmt.Errorf("invalid storage configuration: %w", err)
+	}
+
+	if err := c.Backup.Validate(); err != nil {
+		return fmt.Errorf("invalid backup configuration: %w", err)
+	}
+	if err := c.Archive.Validate(); err != nil {
+		return fmt.Errorf("invalid click archive configuration: %w", err)
+	}
+	if err := c.Retention.Validate(); err != nil {
+		return fmt.Errorf("invalid data retention configuration: %w", err)
+	}
+	if c.Archive.Enabled() && c.Retention.ClickRetention > 0 && c.Retention.ClickRetention <= c.Archive.After {
+		// Clicks would be deleted before they are archived
+		return fmt.Errorf("invalid data retention configuration: click retention %v must exceed the archive age %v", c.Retention.ClickRetention, c.Archive.After)
+	}
+	if err := c.Privacy.Validate(); err != nil {
+		return fmt.Errorf("invalid IP anonymization configuration: %w", err)
+	}
+	if err := c.Domains.Validate(); err != nil {
+		return fmt.Errorf("invalid destination rules configuration: %w", err)
+	}
+	if err := c.Verify.Validate(); err != nil {
+		return fmt.Errorf("invalid destination verification configuration: %w", err)
+	}
+	if err := c.Mail.Validate(); err != nil {
+		return fmt.Errorf("invalid broken link email configuration: %w", err)
+	}
+	if err := c.Hosts.Validate(); err != nil {
+		return fmt.Errorf("invalid custom domain configuration: %w", err)
+	}
+	if err := c.CDN.Validate(); err != nil {
+		return fmt.Errorf("invalid CDN purge configuration: %w", err)
+	}
+
+	if err := c.GeoIP.Validate(); err != nil {
+		return fmt.Errorf("invalid GeoIP configuration: %w", err)
+	}
+
+	if err := c.Bots.Validate(); err != nil {
+		return fmt.Errorf("invalid bot configuration: %w", err)
+	}
+
+	if err := c.Abuse.Validate(); err != nil {
+		return fmt.Errorf("invalid abuse detection configuration: %w", err)
+	}
+
+	if err := c.Analytics.Validate(); err != nil {
+		return fmt.Errorf("invalid analytics configuration: %w", err)
+	}
+
+	if err := c.Events.Validate(); err != nil {
+		return fmt.Errorf("invalid event stream configuration: %w", err)
+	}
+
+	if err := c.Database.Breaker.Validate(); err != nil {
+		return fmt.Errorf("invalid circuit breaker configuration: %w", err)
+	}
+
+	if err := c.Clicks.Validate(); err != nil {
+		return fmt.Errorf("invalid click buffer configuration: %w", err)
+	}
+	if err := c.Outbox.Validate(); err != nil {
+		return fmt.Errorf("invalid event outbox configuration: %w", err)
+	}
+
+	if err := c.Broker.Validate(); err != nil {
+		return fmt.Errorf("invalid event broker configuration: %w", err)
+	}
+
+	if err := c.Export.Validate(); err != nil {
+		return fmt.Errorf("invalid event export configuration: %w", err)
+	}
+
+	if err := c.Tracing.Validate(); err != nil {
+		return fmt.Errorf("invalid tracing configuration: %w", err)
+	}
+	
+	if err := c.Peers.Validate(); err != nil {
+		return fmt.Errorf("invalid peer configuration: %w", err)
+	}
+
 	return nil
 }
\ No newline at end of file
@@ -0,0 +1,202 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"time"
+)
+
+// Status describes a running server, as returned by the control socket
+type Status struct {
+	PID             int       `json:"pid"`
+	Version         string    `json:"version"`
+	Commit          string    `json:"commit"`
+	Address         string    `json:"address"`                 // Where the server accepts requests
+	AdminAddress    string    `json:"admin_address,omitempty"` // Where the API is served when it is separate
+	TLS             bool      `json:"tls"`
+	SocketActivated bool      `json:"socket_activated,omitempty"`
+	StartedAt       time.Time `json:"started_at"`
+	Uptime          string    `json:"uptime"`
+	Health          string    `json:"health"` // The readiness check's overall status
+}
+
+// ReloadResult lists what a reload read again
+type ReloadResult struct {
+	Reloaded []string `json:"reloaded"`
+}
+
+// Controls are what the control socket's requests do
+type Controls struct {
+	Status func(ctx context.Context) (Status, error)
+	Reload func(ctx context.Context) (ReloadResult, error)
+	Stop   func() // Starts a graceful shutdown without waiting for it
+}
+
+// ControlServer answers status, reload and stop requests on a unix socket
+// that only the user running the server can open:
+//
+//	GET  /status  the server's Status
+//	POST /reload  reload configuration files, as SIGHUP does
+//	POST /stop    shut down gracefully, as SIGTERM does
+type ControlServer struct {
+	path   string
+	server *http.Server
+}
+
+// Listen creates the control socket at path, replacing a stale socket left
+// by a server that did not shut down cleanly
+func Listen(path string, controls Controls) (*ControlServer, error) {
+	listener, err := listenUnix(path)
+	if err != nil {
+		return nil, fmt.Errorf("control socket: %w", err)
+	}
+	if err := os.Chmod(path, 0o600); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to restrict control socket: %w", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/status", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			writeControlError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+		status, err := controls.Status(r.Context())
+		if err != nil {
+			writeControlError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		writeControlJSON(w, http.StatusOK, status)
+	})
+	mux.HandleFunc("/reload", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			writeControlError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+		result, err := controls.Reload(r.Context())
+		if err != nil {
+			writeControlError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		writeControlJSON(w, http.StatusOK, result)
+	})
+	mux.HandleFunc("/stop", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			writeControlError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+		controls.Stop()
+	})
+
+	c := &ControlServer{
+		path:   path,
+		server: &http.Server{Handler: mux, ReadTimeout: 10 * time.Second},
+	}
+	go func() {
+		if err := c.server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			log.Printf("Control socket error: %v", err)
+		}
+	}()
+	return c, nil
+}
+
+// Close stops answering requests and removes the socket. It is nil-safe.
+func (c *ControlServer) Close() error {
+	if c == nil {
+		return nil
+	}
+	err := c.server.Close()
+	os.Remove(c.path)
+	return err
+}
+
+// writeControlJSON writes v as a JSON response
+func writeControlJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeControlError writes {"error": message}
+func writeControlError(w http.ResponseWriter, status int, message string) {
+	writeControlJSON(w, status, map[string]string{"error": message})
+}
+
+// ControlClient sends requests to a server's control socket
+type ControlClient struct {
+	http *http.Client
+}
+
+// NewControlClient creates a client for the control socket at path
+func NewControlClient(path string) *ControlClient {
+	return &ControlClient{http: &http.Client{
+		Timeout: 30 * time.Second,
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var dialer net.Dialer
+				return dialer.DialContext(ctx, "unix", path)
+			},
+		},
+	}}
+}
+
+// Status returns the server's status
+func (c *ControlClient) Status(ctx context.Context) (*Status, error) {
+	var status Status
+	if err := c.do(ctx, http.MethodGet, "/status", http.StatusOK, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// Reload asks the server to reload its configuration files
+func (c *ControlClient) Reload(ctx context.Context) (*ReloadResult, error) {
+	var result ReloadResult
+	if err := c.do(ctx, http.MethodPost, "/reload", http.StatusOK, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Stop asks the server to shut down gracefully and returns without waiting for it
+func (c *ControlClient) Stop(ctx context.Context) error {
+	return c.do(ctx, http.MethodPost, "/stop", http.StatusAccepted, nil)
+}
+
+// do sends a request and decodes a response with the expected status into result
+func (c *ControlClient) do(ctx context.Context, method, path string, expected int, result any) error {
+	req, err := http.NewRequestWithContext(ctx, method, "http://control"+path, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach the server's control socket: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != expected {
+		var body struct {
+			Error string `json:"error"`
+		}
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+		if json.Unmarshal(data, &body) == nil && body.Error != "" {
+			return fmt.Errorf("server error (status %d): %s", resp.StatusCode, body.Error)
+		}
+		return fmt.Errorf("server error (status %d)", resp.StatusCode)
+	}
+	if result == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
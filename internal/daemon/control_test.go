@@ -0,0 +1,77 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestControl(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "control.sock")
+	started := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	stopped := make(chan struct{}, 1)
+	reloadErr := error(nil)
+
+	server, err := Listen(path, Controls{
+		Status: func(ctx context.Context) (Status, error) {
+			return Status{PID: 42, Version: "v1.2.0", Address: ":8080", StartedAt: started, Health: "ok"}, nil
+		},
+		Reload: func(ctx context.Context) (ReloadResult, error) {
+			return ReloadResult{Reloaded: []string{"TLS certificate"}}, reloadErr
+		},
+		Stop: func() { stopped <- struct{}{} },
+	})
+	require.NoError(t, err)
+	defer server.Close()
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	_, err = Listen(path, Controls{})
+	assert.ErrorContains(t, err, "control socket: unix socket "+path+" is in use by another server")
+
+	ctx := context.Background()
+	client := NewControlClient(path)
+
+	status, err := client.Status(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 42, status.PID)
+	assert.Equal(t, started, status.StartedAt)
+
+	result, err := client.Reload(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"TLS certificate"}, result.Reloaded)
+
+	reloadErr = errors.New("failed to load TLS certificate: bad key")
+	_, err = client.Reload(ctx)
+	assert.EqualError(t, err, "server error (status 500): failed to load TLS certificate: bad key")
+
+	require.NoError(t, client.Stop(ctx))
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("stop was not requested")
+	}
+
+	require.NoError(t, server.Close())
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+	_, err = client.Status(ctx)
+	assert.ErrorContains(t, err, "failed to reach the server's control socket")
+}
+
+func TestControl_ReplacesStaleSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "control.sock")
+	require.NoError(t, os.WriteFile(path, nil, 0o600))
+
+	server, err := Listen(path, Controls{})
+	require.NoError(t, err)
+	assert.NoError(t, server.Close())
+}
@@ -0,0 +1,84 @@
+package daemon
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ValidateListenAddress checks an address for OpenListener without opening it
+func ValidateListenAddress(address string) error {
+	_, _, err := parseListenAddress(address)
+	return err
+}
+
+// OpenListener opens the listener an address names:
+//
+//	unix:PATH  a unix socket at PATH, replacing a stale one
+//	fd:N       a listening socket inherited as file descriptor N
+//	tcp:ADDR   a TCP address such as 127.0.0.1:8081
+func OpenListener(address string) (net.Listener, error) {
+	kind, value, err := parseListenAddress(address)
+	if err != nil {
+		return nil, err
+	}
+	switch kind {
+	case "unix":
+		return listenUnix(value)
+	case "fd":
+		fd, _ := strconv.Atoi(value)
+		file := os.NewFile(uintptr(fd), "fd:"+value)
+		defer file.Close()
+		listener, err := net.FileListener(file)
+		if err != nil {
+			return nil, fmt.Errorf("file descriptor %d is not a listening socket: %w", fd, err)
+		}
+		return listener, nil
+	default:
+		listener, err := net.Listen("tcp", value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to listen on %s: %w", value, err)
+		}
+		return listener, nil
+	}
+}
+
+// parseListenAddress splits an address into its kind and value
+func parseListenAddress(address string) (string, string, error) {
+	kind, value, ok := strings.Cut(address, ":")
+	if !ok || value == "" {
+		return "", "", fmt.Errorf("invalid listen address %q: want unix:PATH, fd:N or tcp:ADDR", address)
+	}
+	switch kind {
+	case "unix", "tcp":
+	case "fd":
+		if fd, err := strconv.Atoi(value); err != nil || fd < 0 {
+			return "", "", fmt.Errorf("invalid listen address %q: %q is not a file descriptor", address, value)
+		}
+	default:
+		return "", "", fmt.Errorf("invalid listen address %q: want unix:PATH, fd:N or tcp:ADDR", address)
+	}
+	return kind, value, nil
+}
+
+// listenUnix creates a unix socket at path, replacing a stale socket left by
+// a server that did not shut down cleanly. The socket is removed when the
+// listener closes.
+func listenUnix(path string) (net.Listener, error) {
+	if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+		conn.Close()
+		return nil, fmt.Errorf("unix socket %s is in use by another server", path)
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to remove stale unix socket: %w", err)
+	}
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create unix socket: %w", err)
+	}
+	return listener, nil
+}
@@ -0,0 +1,42 @@
+package daemon
+
+import (
+	"net"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenListener(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "shortener.sock")
+	unix, err := OpenListener("unix:" + path)
+	require.NoError(t, err)
+	defer unix.Close()
+	assert.Equal(t, path, unix.Addr().String())
+
+	_, err = OpenListener("unix:" + path)
+	assert.ErrorContains(t, err, "in use by another server")
+
+	tcp, err := OpenListener("tcp:127.0.0.1:0")
+	require.NoError(t, err)
+	defer tcp.Close()
+
+	file, err := tcp.(*net.TCPListener).File()
+	require.NoError(t, err)
+	inherited, err := OpenListener("fd:" + strconv.Itoa(int(file.Fd())))
+	require.NoError(t, err)
+	defer inherited.Close()
+	assert.Equal(t, tcp.Addr().String(), inherited.Addr().String())
+}
+
+func TestValidateListenAddress(t *testing.T) {
+	for _, address := range []string{"unix:/run/shortener.sock", "fd:3", "tcp::8081", "tcp:127.0.0.1:8081"} {
+		assert.NoError(t, ValidateListenAddress(address), address)
+	}
+	for _, address := range []string{"", "127.0.0.1:8081", "unix:", "fd:-1", "fd:x", "udp:127.0.0.1:53"} {
+		assert.ErrorContains(t, ValidateListenAddress(address), "invalid listen address", address)
+	}
+}
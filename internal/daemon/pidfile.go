@@ -0,0 +1,62 @@
+// Package daemon lets the server run as a managed service: a pid file, a
+// local control socket answering status, reload and stop requests, systemd
+// socket activation and readiness notifications.
+package daemon
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// WritePIDFile records the current process ID at path. It fails when the
+// file names another process that is still running; a stale file left by a
+// crashed server is replaced.
+func WritePIDFile(path string) error {
+	if pid, err := ReadPIDFile(path); err == nil && pid != os.Getpid() && Running(pid) {
+		return fmt.Errorf("pid file %s belongs to running process %d", path, pid)
+	}
+	if err := os.WriteFile(path, []byte(strconv.Itoa(os.Getpid())+"\n"), 0o644); err != nil {
+		return fmt.Errorf("failed to write pid file: %w", err)
+	}
+	return nil
+}
+
+// RemovePIDFile removes the pid file at path if it still names the current process
+func RemovePIDFile(path string) error {
+	pid, err := ReadPIDFile(path)
+	if errors.Is(err, fs.ErrNotExist) || (err == nil && pid != os.Getpid()) {
+		return nil
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to remove pid file: %w", err)
+	}
+	return nil
+}
+
+// ReadPIDFile returns the process ID recorded at path
+func ReadPIDFile(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil || pid <= 0 {
+		return 0, fmt.Errorf("pid file %s does not hold a process ID", path)
+	}
+	return pid, nil
+}
+
+// Running reports whether a process with the given ID exists
+func Running(pid int) bool {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	err = process.Signal(syscall.Signal(0))
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
@@ -0,0 +1,56 @@
+package daemon
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPIDFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "server.pid")
+
+	require.NoError(t, WritePIDFile(path))
+	pid, err := ReadPIDFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.Getpid(), pid)
+	assert.True(t, Running(pid))
+
+	require.NoError(t, RemovePIDFile(path))
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+	assert.NoError(t, RemovePIDFile(path))
+}
+
+func TestPIDFile_Stale(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "server.pid")
+	// Process IDs this large are never handed out
+	require.NoError(t, os.WriteFile(path, []byte("999999999\n"), 0o644))
+
+	require.NoError(t, WritePIDFile(path))
+	pid, err := ReadPIDFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.Getpid(), pid)
+}
+
+func TestPIDFile_OtherProcessRunning(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "server.pid")
+	require.NoError(t, os.WriteFile(path, []byte(strconv.Itoa(os.Getppid())), 0o644))
+
+	assert.ErrorContains(t, WritePIDFile(path), "belongs to running process")
+	// Another process's pid file is left alone
+	require.NoError(t, RemovePIDFile(path))
+	_, err := os.Stat(path)
+	assert.NoError(t, err)
+}
+
+func TestReadPIDFile_Invalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "server.pid")
+	require.NoError(t, os.WriteFile(path, []byte("not a pid"), 0o644))
+
+	_, err := ReadPIDFile(path)
+	assert.ErrorContains(t, err, "does not hold a process ID")
+}
@@ -0,0 +1,80 @@
+package daemon
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+)
+
+// listenFDsStart is the first file descriptor systemd passes to an activated service
+const listenFDsStart = 3
+
+// Listeners returns the sockets systemd passed to the process with socket
+// activation, or none when it was started any other way. The activation
+// environment is cleared so child processes do not inherit it.
+func Listeners() ([]net.Listener, error) {
+	defer os.Unsetenv("LISTEN_PID")
+	defer os.Unsetenv("LISTEN_FDS")
+	defer os.Unsetenv("LISTEN_FDNAMES")
+	return listeners(os.Getenv("LISTEN_PID"), os.Getenv("LISTEN_FDS"), listenFDsStart)
+}
+
+// listeners wraps the count file descriptors from start, if pid is this process
+func listeners(pid, count string, start int) ([]net.Listener, error) {
+	if pid == "" || count == "" {
+		return nil, nil
+	}
+	if parsed, err := strconv.Atoi(pid); err != nil || parsed != os.Getpid() {
+		return nil, nil
+	}
+	n, err := strconv.Atoi(count)
+	if err != nil || n < 0 {
+		return nil, fmt.Errorf("invalid LISTEN_FDS %q", count)
+	}
+
+	// FileListener duplicates each descriptor, so the originals are closed
+	listeners := make([]net.Listener, 0, n)
+	for fd := start; fd < start+n; fd++ {
+		file := os.NewFile(uintptr(fd), "LISTEN_FD_"+strconv.Itoa(fd))
+		listener, err := net.FileListener(file)
+		file.Close()
+		if err != nil {
+			for _, opened := range listeners {
+				opened.Close()
+			}
+			return nil, fmt.Errorf("socket activation file descriptor %d is not a listening socket: %w", fd, err)
+		}
+		listeners = append(listeners, listener)
+	}
+	return listeners, nil
+}
+
+// Notification states sent to systemd with Notify
+const (
+	NotifyReady     = "READY=1"
+	NotifyReloading = "RELOADING=1"
+	NotifyStopping  = "STOPPING=1"
+)
+
+// Notify sends a state to systemd for services with Type=notify. It does
+// nothing when the service manager did not ask for notifications.
+func Notify(state string) error {
+	socket := os.Getenv("NOTIFY_SOCKET")
+	if socket == "" {
+		return nil
+	}
+	if socket[0] == '@' {
+		socket = "\x00" + socket[1:] // Abstract socket namespace
+	}
+
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		return fmt.Errorf("failed to reach systemd notify socket: %w", err)
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte(state)); err != nil {
+		return fmt.Errorf("failed to notify systemd: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package daemon
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListeners(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	file, err := listener.(*net.TCPListener).File()
+	require.NoError(t, err)
+	defer file.Close()
+
+	pid := strconv.Itoa(os.Getpid())
+	activated, err := listeners(pid, "1", int(file.Fd()))
+	require.NoError(t, err)
+	require.Len(t, activated, 1)
+	defer activated[0].Close()
+	assert.Equal(t, listener.Addr().String(), activated[0].Addr().String())
+
+	// Meant for another process, or not socket activated at all
+	for _, env := range [][2]string{{"1", "1"}, {"", ""}} {
+		activated, err := listeners(env[0], env[1], int(file.Fd()))
+		require.NoError(t, err)
+		assert.Empty(t, activated)
+	}
+
+	_, err = listeners(pid, "many", int(file.Fd()))
+	assert.ErrorContains(t, err, "invalid LISTEN_FDS")
+}
+
+func TestNotify(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notify.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	require.NoError(t, err)
+	defer conn.Close()
+
+	t.Setenv("NOTIFY_SOCKET", path)
+	require.NoError(t, Notify(NotifyReady))
+
+	buf := make([]byte, 64)
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	n, err := conn.Read(buf)
+	require.NoError(t, err)
+	assert.Equal(t, NotifyReady, string(buf[:n]))
+
+	t.Setenv("NOTIFY_SOCKET", "")
+	assert.NoError(t, Notify(NotifyReady))
+}
@@ -0,0 +1,39 @@
+package destinations
+
+import (
+	"fmt"
+	"time"
+)
+
+// Config holds destination allow and deny list configuration
+type Config struct {
+	Allow   []string      // Patterns destinations must match one of, when any are set
+	Deny    []string      // Patterns destinations must not match
+	Refresh time.Duration // How often rules other instances added through the API are picked up; 0 only at start
+}
+
+// DefaultConfig returns the default configuration, which allows every
+// destination until rules are added
+func DefaultConfig() Config {
+	return Config{
+		Refresh: time.Minute,
+	}
+}
+
+// Validate checks that the configuration values are usable
+func (c Config) Validate() error {
+	for _, pattern := range c.Allow {
+		if _, _, err := parsePattern(pattern); err != nil {
+			return fmt.Errorf("invalid allowed destination: %w", err)
+		}
+	}
+	for _, pattern := range c.Deny {
+		if _, _, err := parsePattern(pattern); err != nil {
+			return fmt.Errorf("invalid denied destination: %w", err)
+		}
+	}
+	if c.Refresh < 0 {
+		return fmt.Errorf("refresh interval cannot be negative, got: %v", c.Refresh)
+	}
+	return nil
+}
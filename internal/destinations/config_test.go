@@ -0,0 +1,47 @@
+package destinations
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfig_Validate(t *testing.T) {
+	assert.NoError(t, DefaultConfig().Validate())
+	assert.NoError(t, Config{Allow: []string{"example.com", "*.corp.example"}, Deny: []string{"private", "10.0.0.0/8", "::1"}}.Validate())
+
+	assert.ErrorContains(t, Config{Allow: []string{"https://example.com"}}.Validate(), "invalid allowed destination")
+	assert.ErrorContains(t, Config{Deny: []string{"10.0.0.0/33"}}.Validate(), "invalid denied destination")
+	assert.ErrorContains(t, Config{Refresh: -time.Second}.Validate(), "cannot be negative")
+}
+
+func TestParsePattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    string
+		wantErr bool
+	}{
+		{pattern: "Example.COM.", want: "example.com"},
+		{pattern: "*.example.com", want: "*.example.com"},
+		{pattern: " private ", want: "private"},
+		{pattern: "10.1.2.3/8", want: "10.0.0.0/8"},
+		{pattern: "::ffff:192.0.2.1", want: "192.0.2.1"},
+		{pattern: "[2001:db8::1]", want: "2001:db8::1"},
+		{pattern: "", wantErr: true},
+		{pattern: "example.*", wantErr: true},
+		{pattern: "exa mple.com", wantErr: true},
+		{pattern: "example.com/path", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			got, _, err := parsePattern(tt.pattern)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
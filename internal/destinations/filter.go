@@ -0,0 +1,278 @@
+package destinations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/netip"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	"github.com/joshdurbin/url-shortener/internal/repository"
+)
+
+// refreshTimeout bounds reloading the stored rules
+const refreshTimeout = 30 * time.Second
+
+// rule is a destination rule with its matcher
+type rule struct {
+	domain.DestinationRule
+	match matcher
+}
+
+// Filter decides which destinations links may point to. A destination is
+// rejected when its host matches a deny rule, or when allow rules exist and
+// it matches none of them. Rules come from the server configuration and from
+// the API; the API's are stored, so every instance sharing the database
+// applies them after its next refresh. Host names are matched as written and
+// never resolved, so a public name pointing at an internal address passes.
+// A nil Filter allows every destination.
+type Filter struct {
+	config Config
+	store  repository.DestinationRepository
+
+	mutex    sync.RWMutex
+	static   []rule // Rules from the configuration
+	stored   []rule // Cached copy of the stored rules
+	started  bool
+	closed   bool
+	stopChan chan struct{}
+	wg       sync.WaitGroup
+}
+
+// New creates a destination filter from the configured rules
+func New(config Config, store repository.DestinationRepository) (*Filter, error) {
+	f := &Filter{
+		config:   config,
+		store:    store,
+		stopChan: make(chan struct{}),
+	}
+	for _, list := range []struct {
+		name     domain.DestinationList
+		patterns []string
+	}{{domain.DestinationAllow, config.Allow}, {domain.DestinationDeny, config.Deny}} {
+		for _, pattern := range list.patterns {
+			r, err := newRule(list.name, pattern)
+			if err != nil {
+				return nil, err
+			}
+			r.Static = true
+			f.static = append(f.static, r)
+		}
+	}
+	return f, nil
+}
+
+// newRule parses a pattern into a rule of list
+func newRule(list domain.DestinationList, pattern string) (rule, error) {
+	normalized, match, err := parsePattern(pattern)
+	if err != nil {
+		return rule{}, err
+	}
+	return rule{DestinationRule: domain.DestinationRule{List: list, Pattern: normalized}, match: match}, nil
+}
+
+// Start loads the stored rules and keeps them fresh every refresh interval
+func (f *Filter) Start(ctx context.Context) error {
+	if err := f.reload(ctx); err != nil {
+		return err
+	}
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if f.started {
+		return fmt.Errorf("destination filter already started")
+	}
+	f.started = true
+
+	if f.config.Refresh > 0 {
+		f.wg.Add(1)
+		go f.refreshLoop()
+	}
+	return nil
+}
+
+// Close stops refreshing the stored rules
+func (f *Filter) Close() error {
+	f.mutex.Lock()
+	if f.closed {
+		f.mutex.Unlock()
+		return nil
+	}
+	f.closed = true
+	close(f.stopChan)
+	f.mutex.Unlock()
+
+	f.wg.Wait()
+	return nil
+}
+
+// refreshLoop reloads the stored rules every refresh interval until closed
+func (f *Filter) refreshLoop() {
+	defer f.wg.Done()
+
+	ticker := time.NewTicker(f.config.Refresh)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-f.stopChan:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), refreshTimeout)
+			if err := f.reload(ctx); err != nil {
+				log.Printf("Error refreshing destination rules: %v", err)
+			}
+			cancel()
+		}
+	}
+}
+
+// reload replaces the cached copy of the stored rules. Stored patterns that
+// no longer parse are skipped rather than blocking the rest.
+func (f *Filter) reload(ctx context.Context) error {
+	stored, err := f.store.ListDestinationRules(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load destination rules: %w", err)
+	}
+	rules := make([]rule, 0, len(stored))
+	for _, s := range stored {
+		r, err := newRule(s.List, s.Pattern)
+		if err != nil {
+			log.Printf("Skipping destination rule %s %q: %v", s.List, s.Pattern, err)
+			continue
+		}
+		r.CreatedAt = s.CreatedAt
+		rules = append(rules, r)
+	}
+
+	f.mutex.Lock()
+	f.stored = rules
+	f.mutex.Unlock()
+	return nil
+}
+
+// Check returns an error when rawURL's host may not be a destination.
+// Template URLs are checked by their host, which placeholders cannot change.
+func (f *Filter) Check(rawURL string) error {
+	if f == nil {
+		return nil
+	}
+	host, err := destinationHost(rawURL)
+	if err != nil {
+		return err
+	}
+	addr, addrErr := netip.ParseAddr(host)
+	isAddr := addrErr == nil
+	addr = addr.Unmap()
+
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+
+	allowRules, allowed := 0, false
+	for _, rules := range [][]rule{f.static, f.stored} {
+		for _, r := range rules {
+			if !r.match(host, addr, isAddr) {
+				if r.List == domain.DestinationAllow {
+					allowRules++
+				}
+				continue
+			}
+			if r.List == domain.DestinationDeny {
+				return fmt.Errorf("destination host %q is denied by %q", host, r.Pattern)
+			}
+			allowRules++
+			allowed = true
+		}
+	}
+	if allowRules > 0 && !allowed {
+		return fmt.Errorf("destination host %q is not on the allow list", host)
+	}
+	return nil
+}
+
+// destinationHost returns the lower-cased host of rawURL without a port,
+// brackets or trailing dot
+func destinationHost(rawURL string) (string, error) {
+	if domain.IsTemplate(rawURL) {
+		tmpl, err := domain.ParseTemplate(rawURL)
+		if err != nil {
+			return "", fmt.Errorf("invalid URL template: %w", err)
+		}
+		rawURL = tmpl.Sample()
+	}
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid URL: %w", err)
+	}
+	return strings.TrimSuffix(strings.ToLower(parsed.Hostname()), "."), nil
+}
+
+// Rules returns the configured rules followed by the stored ones
+func (f *Filter) Rules() []domain.DestinationRule {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+
+	rules := make([]domain.DestinationRule, 0, len(f.static)+len(f.stored))
+	for _, r := range f.static {
+		rules = append(rules, r.DestinationRule)
+	}
+	for _, r := range f.stored {
+		rules = append(rules, r.DestinationRule)
+	}
+	return rules
+}
+
+// Add stores a rule and applies it at once; other instances apply it after
+// their next refresh
+func (f *Filter) Add(ctx context.Context, list domain.DestinationList, pattern string) (*domain.DestinationRule, error) {
+	if !domain.ValidDestinationList(list) {
+		return nil, domain.Invalid("list", fmt.Errorf("list must be allow or deny, got: %q", list))
+	}
+	r, err := newRule(list, pattern)
+	if err != nil {
+		return nil, domain.Invalid("pattern", err)
+	}
+	if f.configured(r.List, r.Pattern) {
+		return nil, domain.ErrDestinationRuleExists
+	}
+
+	now := time.Now().UTC()
+	r.CreatedAt = &now
+	if err := f.store.CreateDestinationRule(ctx, r.DestinationRule); err != nil {
+		return nil, err
+	}
+	if err := f.reload(ctx); err != nil {
+		return nil, err
+	}
+	return &r.DestinationRule, nil
+}
+
+// Remove deletes a stored rule; rules from the configuration cannot be removed
+func (f *Filter) Remove(ctx context.Context, list domain.DestinationList, pattern string) error {
+	normalized, _, err := parsePattern(pattern)
+	if err != nil {
+		return domain.Invalid("pattern", err)
+	}
+	if f.configured(list, normalized) {
+		return domain.Conflict(errors.New("destination rule is part of the server configuration"))
+	}
+	if err := f.store.DeleteDestinationRule(ctx, list, normalized); err != nil {
+		return err
+	}
+	return f.reload(ctx)
+}
+
+// configured reports whether the configuration has pattern on list
+func (f *Filter) configured(list domain.DestinationList, pattern string) bool {
+	for _, r := range f.static {
+		if r.List == list && r.Pattern == pattern {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,143 @@
+package destinations
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	"github.com/joshdurbin/url-shortener/internal/repository/mocks"
+)
+
+func newTestFilter(t *testing.T, config Config, stored []domain.DestinationRule) (*Filter, *mocks.DestinationRepository) {
+	t.Helper()
+	store := &mocks.DestinationRepository{}
+	store.On("ListDestinationRules", mock.Anything).Return(stored, nil).Once()
+	filter, err := New(config, store)
+	require.NoError(t, err)
+	require.NoError(t, filter.Start(context.Background()))
+	t.Cleanup(func() { filter.Close() })
+	return filter, store
+}
+
+func TestFilter_CheckDeny(t *testing.T) {
+	filter, _ := newTestFilter(t, Config{Deny: []string{"private", "evil.example", "198.51.100.0/24"}}, nil)
+
+	denied := []string{
+		"http://localhost:8080/admin",
+		"http://127.0.0.1/",
+		"http://10.1.2.3/",
+		"http://[::1]:9000/",
+		"http://[fd00::1]/",
+		"http://169.254.169.254/latest/meta-data/",
+		"http://0.0.0.0/",
+		"http://intranet/",
+		"http://db.internal/",
+		"http://printer.local/",
+		"http://2130706433/",
+		"http://0x7f.1/",
+		"https://evil.example/",
+		"https://WWW.Evil.Example./",
+		"https://198.51.100.7/",
+		"https://evil.example/{path}",
+	}
+	for _, rawURL := range denied {
+		assert.Error(t, filter.Check(rawURL), rawURL)
+	}
+
+	allowed := []string{
+		"https://example.com/",
+		"https://notevil.example/",
+		"http://8.8.8.8/",
+		"http://[2001:4860:4860::8888]/",
+		"https://198.51.101.1/",
+	}
+	for _, rawURL := range allowed {
+		assert.NoError(t, filter.Check(rawURL), rawURL)
+	}
+	assert.ErrorContains(t, filter.Check("http://10.0.0.1/"), `denied by "private"`)
+}
+
+func TestFilter_CheckAllow(t *testing.T) {
+	filter, _ := newTestFilter(t, Config{Allow: []string{"example.com", "*.corp.example"}, Deny: []string{"legacy.example.com"}}, nil)
+
+	assert.NoError(t, filter.Check("https://example.com/"))
+	assert.NoError(t, filter.Check("https://docs.example.com/"))
+	assert.NoError(t, filter.Check("https://wiki.corp.example/"))
+	assert.ErrorContains(t, filter.Check("https://corp.example/"), "not on the allow list", "*. matches only subdomains")
+	assert.ErrorContains(t, filter.Check("https://other.org/"), "not on the allow list")
+	assert.ErrorContains(t, filter.Check("https://legacy.example.com/"), "denied", "deny rules win over allow rules")
+}
+
+func TestFilter_Nil(t *testing.T) {
+	var filter *Filter
+	assert.NoError(t, filter.Check("http://127.0.0.1/"))
+
+	empty, _ := newTestFilter(t, DefaultConfig(), nil)
+	assert.NoError(t, empty.Check("http://127.0.0.1/"), "no rules allow everything")
+}
+
+func TestFilter_Manage(t *testing.T) {
+	ctx := context.Background()
+	added := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	filter, store := newTestFilter(t, Config{Deny: []string{"private"}}, []domain.DestinationRule{
+		{List: domain.DestinationDeny, Pattern: "evil.example", CreatedAt: &added},
+		{List: domain.DestinationDeny, Pattern: "not a pattern"},
+	})
+
+	// Stored rules apply alongside the configured ones; broken ones are skipped
+	assert.Error(t, filter.Check("https://evil.example/"))
+	assert.Equal(t, []domain.DestinationRule{
+		{List: domain.DestinationDeny, Pattern: "private", Static: true},
+		{List: domain.DestinationDeny, Pattern: "evil.example", CreatedAt: &added},
+	}, filter.Rules())
+
+	// Added rules are normalized, stored and applied at once
+	store.On("CreateDestinationRule", ctx, mock.MatchedBy(func(rule domain.DestinationRule) bool {
+		return rule.List == domain.DestinationAllow && rule.Pattern == "example.com" && rule.CreatedAt != nil
+	})).Return(nil).Once()
+	store.On("ListDestinationRules", ctx).Return([]domain.DestinationRule{
+		{List: domain.DestinationDeny, Pattern: "evil.example", CreatedAt: &added},
+		{List: domain.DestinationAllow, Pattern: "example.com", CreatedAt: &added},
+	}, nil).Once()
+	rule, err := filter.Add(ctx, domain.DestinationAllow, "Example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "example.com", rule.Pattern)
+	assert.ErrorContains(t, filter.Check("https://other.org/"), "not on the allow list")
+
+	_, err = filter.Add(ctx, "maybe", "example.com")
+	assert.ErrorIs(t, err, domain.ErrValidation)
+	_, err = filter.Add(ctx, domain.DestinationDeny, "https://example.com")
+	assert.ErrorIs(t, err, domain.ErrValidation)
+	_, err = filter.Add(ctx, domain.DestinationDeny, "PRIVATE")
+	assert.ErrorIs(t, err, domain.ErrDestinationRuleExists, "configured rules are not stored again")
+
+	// Configured rules cannot be removed; stored ones can
+	assert.ErrorIs(t, filter.Remove(ctx, domain.DestinationDeny, "private"), domain.ErrConflict)
+	store.On("DeleteDestinationRule", ctx, domain.DestinationAllow, "example.com").Return(nil).Once()
+	store.On("ListDestinationRules", ctx).Return([]domain.DestinationRule{
+		{List: domain.DestinationDeny, Pattern: "evil.example", CreatedAt: &added},
+	}, nil).Once()
+	require.NoError(t, filter.Remove(ctx, domain.DestinationAllow, "example.com."))
+	assert.NoError(t, filter.Check("https://other.org/"))
+
+	store.On("DeleteDestinationRule", ctx, domain.DestinationAllow, "other.org").Return(domain.ErrDestinationRuleNotFound).Once()
+	assert.ErrorIs(t, filter.Remove(ctx, domain.DestinationAllow, "other.org"), domain.ErrNotFound)
+	store.AssertExpectations(t)
+}
+
+func TestFilter_StartFails(t *testing.T) {
+	store := &mocks.DestinationRepository{}
+	store.On("ListDestinationRules", mock.Anything).Return(nil, domain.Storage(errors.New("database is locked")))
+	filter, err := New(DefaultConfig(), store)
+	require.NoError(t, err)
+	assert.ErrorIs(t, filter.Start(context.Background()), domain.ErrStorage)
+
+	_, err = New(Config{Deny: []string{"10.0.0.0/99"}}, store)
+	assert.Error(t, err)
+}
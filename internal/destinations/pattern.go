@@ -0,0 +1,87 @@
+package destinations
+
+import (
+	"fmt"
+	"net/netip"
+	"regexp"
+	"strings"
+)
+
+// Private is the pattern matching loopback, private, link-local and
+// unspecified addresses and host names that only resolve internally
+const Private = "private"
+
+// internalSuffixes are the domains of hosts that only resolve internally
+var internalSuffixes = []string{".localhost", ".local", ".internal", ".lan", ".home.arpa"}
+
+// domainPattern is the allowed form of a domain name pattern
+var domainPattern = regexp.MustCompile(`^(\*\.)?[a-z0-9]([a-z0-9-]*[a-z0-9])?(\.[a-z0-9]([a-z0-9-]*[a-z0-9])?)*$`)
+
+// numericLabel is a label some resolvers read as part of an IPv4 address,
+// such as the "2130706433" of http://2130706433/ or the "0x7f" of 0x7f.1
+var numericLabel = regexp.MustCompile(`^(0x[0-9a-f]*|[0-9]+)$`)
+
+// matcher reports whether a destination host, and its address when the host
+// is an IP literal, matches a pattern
+type matcher func(host string, addr netip.Addr, isAddr bool) bool
+
+// parsePattern normalizes a pattern and returns its matcher
+func parsePattern(pattern string) (string, matcher, error) {
+	normalized := strings.TrimSuffix(strings.ToLower(strings.TrimSpace(pattern)), ".")
+	if normalized == Private {
+		return normalized, privateHost, nil
+	}
+	if strings.Contains(normalized, "/") {
+		prefix, err := netip.ParsePrefix(normalized)
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid CIDR range %q: %w", pattern, err)
+		}
+		prefix = prefix.Masked()
+		return prefix.String(), func(host string, addr netip.Addr, isAddr bool) bool {
+			return isAddr && prefix.Contains(addr)
+		}, nil
+	}
+	if ip, err := netip.ParseAddr(strings.Trim(normalized, "[]")); err == nil {
+		ip = ip.Unmap()
+		return ip.String(), func(host string, addr netip.Addr, isAddr bool) bool {
+			return isAddr && addr == ip
+		}, nil
+	}
+	if !domainPattern.MatchString(normalized) || len(normalized) > 253 {
+		return "", nil, fmt.Errorf("pattern must be a domain, *.domain, IP address, CIDR range or %q, got: %q", Private, pattern)
+	}
+	if suffix, ok := strings.CutPrefix(normalized, "*"); ok {
+		return normalized, func(host string, addr netip.Addr, isAddr bool) bool {
+			return strings.HasSuffix(host, suffix)
+		}, nil
+	}
+	return normalized, func(host string, addr netip.Addr, isAddr bool) bool {
+		return host == normalized || strings.HasSuffix(host, "."+normalized)
+	}, nil
+}
+
+// privateHost matches addresses that are not on the public internet and host
+// names that resolve only internally: localhost, single-label names,
+// internal-use domains and numbers that resolve as IPv4 addresses
+func privateHost(host string, addr netip.Addr, isAddr bool) bool {
+	if isAddr {
+		return addr.IsLoopback() || addr.IsPrivate() || addr.IsLinkLocalUnicast() ||
+			addr.IsLinkLocalMulticast() || addr.IsUnspecified() || addr.IsInterfaceLocalMulticast()
+	}
+	if host == "localhost" || !strings.Contains(host, ".") {
+		return true
+	}
+	for _, suffix := range internalSuffixes {
+		if strings.HasSuffix(host, suffix) {
+			return true
+		}
+	}
+	numeric := true
+	for _, label := range strings.Split(host, ".") {
+		if !numericLabel.MatchString(label) {
+			numeric = false
+			break
+		}
+	}
+	return numeric
+}
@@ -0,0 +1,44 @@
+package domain
+
+import "time"
+
+// AbuseReason names the heuristic that flagged a link
+type AbuseReason string
+
+// AbuseReason constants
+const (
+	AbuseClientSpike  AbuseReason = "client_spike"  // Too many clicks from one visitor address
+	AbuseNetworkSpike AbuseReason = "network_spike" // Too many clicks from one network (autonomous system)
+	AbuseBotTraffic   AbuseReason = "bot_traffic"   // Too many clicks from bots and crawlers
+)
+
+// AbuseAction determines what happens to a flagged link's redirects
+type AbuseAction string
+
+// AbuseAction constants
+const (
+	AbuseActionFlag     AbuseAction = "flag"     // Report the link; it keeps redirecting
+	AbuseActionThrottle AbuseAction = "throttle" // Limit the link's redirects per window
+	AbuseActionDisable  AbuseAction = "disable"  // Stop the link redirecting
+)
+
+// ValidAbuseAction reports whether action is one of the AbuseAction constants
+func ValidAbuseAction(action AbuseAction) bool {
+	switch action {
+	case AbuseActionFlag, AbuseActionThrottle, AbuseActionDisable:
+		return true
+	}
+	return false
+}
+
+// AbuseFlag marks a link whose clicks look like click fraud. The flag, and
+// any throttling or disabling it brought, ends at Until.
+type AbuseFlag struct {
+	ShortCode string      `json:"short_code"`
+	Reason    AbuseReason `json:"reason"`
+	Source    string      `json:"source,omitempty"` // Visitor address or network ("AS15169") the clicks came from
+	Clicks    int         `json:"clicks"`           // Clicks in the window that raised the flag
+	Action    AbuseAction `json:"action"`
+	FlaggedAt time.Time   `json:"flagged_at"`
+	Until     time.Time   `json:"until"`
+}
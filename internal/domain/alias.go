@@ -0,0 +1,42 @@
+package domain
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// MaxAliasLength is the longest alias a link may have
+const MaxAliasLength = 64
+
+// aliasPattern is the allowed form of an alias: letters, digits, '-' and '_',
+// so it needs no escaping in a URL path
+var aliasPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// ValidateAlias checks that alias can be used as a short code
+func ValidateAlias(alias string) error {
+	if alias == "" {
+		return Invalid("alias", fmt.Errorf("alias cannot be empty"))
+	}
+	if len(alias) > MaxAliasLength {
+		return Invalid("alias", fmt.Errorf("alias must be at most %d characters, got %d", MaxAliasLength, len(alias)))
+	}
+	if !aliasPattern.MatchString(alias) {
+		return Invalid("alias", fmt.Errorf("alias %q may only contain letters, digits, '-' and '_'", alias))
+	}
+	return nil
+}
+
+// Alias is an extra short code for a link, such as a vanity code next to the
+// generated one. It redirects to the link's destination with the link's
+// settings, and its redirects count toward the link's usage and analytics.
+type Alias struct {
+	Alias     string    `json:"alias"`
+	ShortCode string    `json:"short_code"` // The link the alias resolves to
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// AddAliasRequest represents a request to add an alias to a link
+type AddAliasRequest struct {
+	Alias string `json:"alias"`
+}
@@ -0,0 +1,220 @@
+package domain
+
+import (
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// DirectReferrer is the referrer of clicks that did not come from a web page:
+// no Referer header, or one that is not an http(s) URL
+const DirectReferrer = "(direct)"
+
+// Analytics value limits; longer values are truncated before they are counted
+const (
+	maxReferrerLength = 253 // Longest DNS name
+	maxUTMLength      = 100
+)
+
+// Click is a counted redirect as recorded in the analytics rollups
+type Click struct {
+	ShortCode string
+	Referrer  string // Referring domain, or DirectReferrer
+	UTM       UTMParams
+	Country   string // Visitor's country code; empty when unknown
+	Region    string // Visitor's subdivision code within Country; empty when unknown
+	At        time.Time
+}
+
+// ClickRollups are click counts to add to the stored rollups
+type ClickRollups struct {
+	Referrers []ReferrerClicks
+	UTM       []UTMClicks
+	Countries []CountryClicks
+	Minutes   []MinuteClicks
+}
+
+// MinuteClicks is the number of clicks on a link within one minute
+type MinuteClicks struct {
+	ShortCode string
+	Minute    time.Time // Start of the minute
+	Clicks    int
+}
+
+// ArchivedClicks is a stored click count moved out of the database by the
+// click archive: a link's clicks within one minute, or within one hour once
+// minutes are compacted
+type ArchivedClicks struct {
+	ShortCode string
+	Start     time.Time          // Start of the minute or hour
+	Interval  TimeSeriesInterval // IntervalMinute or IntervalHour
+	Clicks    int
+}
+
+// TopLinksSort orders the top links report
+type TopLinksSort string
+
+// Top links orders
+const (
+	TopLinksByClicks   TopLinksSort = "clicks"   // Most clicks in the window first
+	TopLinksByTrending TopLinksSort = "trending" // Largest gain over the window before first
+)
+
+// TopLink is a link's clicks in a top links window and in the window of the
+// same length before it
+type TopLink struct {
+	ShortCode      string `json:"short_code"`
+	OriginalURL    string `json:"original_url"`
+	Clicks         int    `json:"clicks"`
+	PreviousClicks int    `json:"previous_clicks"`
+}
+
+// TopLinksResponse is the links with the most clicks, or the largest gain in
+// clicks, since Since
+type TopLinksResponse struct {
+	Window Age          `json:"window"`
+	Since  time.Time    `json:"since"`
+	Sort   TopLinksSort `json:"sort"`
+	Links  []TopLink    `json:"links"`
+}
+
+// TimeSeriesInterval is the width of the buckets of a link's click time series
+type TimeSeriesInterval string
+
+// Time series intervals
+const (
+	IntervalMinute TimeSeriesInterval = "minute"
+	IntervalHour   TimeSeriesInterval = "hour"
+	IntervalDay    TimeSeriesInterval = "day" // UTC days
+)
+
+// Duration returns the width of the interval's buckets, or 0 for an unknown interval
+func (i TimeSeriesInterval) Duration() time.Duration {
+	switch i {
+	case IntervalMinute:
+		return time.Minute
+	case IntervalHour:
+		return time.Hour
+	case IntervalDay:
+		return 24 * time.Hour
+	}
+	return 0
+}
+
+// TimeSeriesBucket is a link's clicks from Start until the next bucket
+type TimeSeriesBucket struct {
+	Start  time.Time `json:"start"`
+	Clicks int       `json:"clicks"`
+}
+
+// TimeSeries is a link's clicks per bucket from From until To, including
+// buckets without clicks
+type TimeSeries struct {
+	ShortCode string             `json:"short_code"`
+	Interval  TimeSeriesInterval `json:"interval"`
+	From      time.Time          `json:"from"`
+	To        time.Time          `json:"to"`
+	Total     int                `json:"total"`
+	Buckets   []TimeSeriesBucket `json:"buckets"`
+}
+
+// UTMParams are the campaign parameters of a visit's incoming URL
+type UTMParams struct {
+	Source   string `json:"utm_source"`
+	Medium   string `json:"utm_medium"`
+	Campaign string `json:"utm_campaign"`
+}
+
+// Empty reports whether no UTM parameter was set
+func (p UTMParams) Empty() bool {
+	return p.Source == "" && p.Medium == "" && p.Campaign == ""
+}
+
+// ReferrerClicks is the number of clicks from one referring domain. Links is
+// the number of links it sent clicks to, in reports across links.
+type ReferrerClicks struct {
+	ShortCode string `json:"short_code,omitempty"`
+	Referrer  string `json:"referrer"`
+	Clicks    int    `json:"clicks"`
+	Links     int    `json:"links,omitempty"`
+}
+
+// UTMClicks is the number of clicks with one combination of UTM parameters
+type UTMClicks struct {
+	ShortCode string `json:"short_code,omitempty"`
+	UTMParams
+	Clicks int `json:"clicks"`
+}
+
+// CountryClicks is the number of clicks from one country, or from one region
+// of it in a link's report. Links is the number of links its visitors clicked,
+// in reports across links.
+type CountryClicks struct {
+	ShortCode string `json:"short_code,omitempty"`
+	Country   string `json:"country"`
+	Region    string `json:"region,omitempty"`
+	Clicks    int    `json:"clicks"`
+	Links     int    `json:"links,omitempty"`
+}
+
+// ReferrerReport is a link's clicks grouped by referring domain and by UTM
+// parameters, most clicks first
+type ReferrerReport struct {
+	ShortCode string           `json:"short_code"`
+	Referrers []ReferrerClicks `json:"referrers"`
+	UTM       []UTMClicks      `json:"utm"`
+}
+
+// TopReferrersResponse is the referring domains with the most clicks across
+// all links
+type TopReferrersResponse struct {
+	Referrers []ReferrerClicks `json:"referrers"`
+}
+
+// CountryReport is a link's clicks grouped by the visitor's country and region,
+// most clicks first. Clicks from unknown countries are not included.
+type CountryReport struct {
+	ShortCode string          `json:"short_code"`
+	Countries []CountryClicks `json:"countries"`
+}
+
+// TopCountriesResponse is the countries with the most clicks across all links
+type TopCountriesResponse struct {
+	Countries []CountryClicks `json:"countries"`
+}
+
+// ReferrerDomain returns the host of a Referer header value, lowercased and
+// without a leading "www.", or DirectReferrer when there is none
+func ReferrerDomain(referer string) string {
+	u, err := url.Parse(strings.TrimSpace(referer))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return DirectReferrer
+	}
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	host = strings.TrimPrefix(host, "www.")
+	if host == "" {
+		return DirectReferrer
+	}
+	return truncate(host, maxReferrerLength)
+}
+
+// UTMFromQuery returns the UTM parameters of a redirect's query
+func UTMFromQuery(query url.Values) UTMParams {
+	return UTMParams{
+		Source:   truncate(strings.TrimSpace(query.Get("utm_source")), maxUTMLength),
+		Medium:   truncate(strings.TrimSpace(query.Get("utm_medium")), maxUTMLength),
+		Campaign: truncate(strings.TrimSpace(query.Get("utm_campaign")), maxUTMLength),
+	}
+}
+
+// truncate shortens s to at most n bytes without splitting a UTF-8 character
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
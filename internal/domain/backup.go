@@ -0,0 +1,16 @@
+package domain
+
+import "time"
+
+// Snapshot is a database backup file in the backup directory
+type Snapshot struct {
+	Name      string    `json:"name"`
+	Bytes     int64     `json:"bytes"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// BackupResponse is returned by POST /api/admin/backup
+type BackupResponse struct {
+	Snapshot  Snapshot   `json:"snapshot"`
+	Snapshots []Snapshot `json:"snapshots"` // Every snapshot kept after rotation, newest first
+}
@@ -0,0 +1,20 @@
+package domain
+
+// BotClickMode determines how redirects from crawlers and other bots are counted
+type BotClickMode string
+
+// BotClickMode constants
+const (
+	BotClicksCount    BotClickMode = "count"    // Count bots like any visitor
+	BotClicksExclude  BotClickMode = "exclude"  // Redirect bots without counting them
+	BotClicksSeparate BotClickMode = "separate" // Count bots in BotsCount instead of UsageCount
+)
+
+// ValidBotClickMode reports whether mode is one of the BotClickMode constants
+func ValidBotClickMode(mode BotClickMode) bool {
+	switch mode {
+	case BotClicksCount, BotClicksExclude, BotClicksSeparate:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,23 @@
+package domain
+
+import (
+	"regexp"
+	"time"
+)
+
+// campaignPattern is the allowed form of a campaign name: lowercase letters,
+// digits, '-', '_' and '.'
+var campaignPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_.-]{0,63}$`)
+
+// ValidCampaign reports whether name is a well-formed, lowercase campaign name
+func ValidCampaign(name string) bool {
+	return campaignPattern.MatchString(name)
+}
+
+// Campaign summarizes the links grouped under one campaign name
+type Campaign struct {
+	Name        string     `json:"name"`
+	Links       int        `json:"links"`
+	TotalClicks int        `json:"total_clicks"`
+	LastUsedAt  *time.Time `json:"last_used_at,omitempty"` // Most recent redirect of any of its links
+}
@@ -0,0 +1,47 @@
+package domain
+
+import (
+	"regexp"
+	"time"
+)
+
+// hostPattern is the allowed form of a custom domain: a lowercase DNS name
+// with at least two labels and no port
+var hostPattern = regexp.MustCompile(`^([a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?\.)+[a-z]([a-z0-9-]{0,61}[a-z0-9])?$`)
+
+// colorPattern is the allowed form of a brand color: #rgb or #rrggbb
+var colorPattern = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// ValidHost reports whether host is a well-formed, lowercase host name
+func ValidHost(host string) bool {
+	return len(host) <= 253 && hostPattern.MatchString(host)
+}
+
+// ValidColor reports whether color is a #rgb or #rrggbb hex color
+func ValidColor(color string) bool {
+	return colorPattern.MatchString(color)
+}
+
+// CustomDomain is a hostname short links are served on besides the server's
+// own. Each domain has its own links: a link created for a domain redirects
+// only when requested on that host.
+type CustomDomain struct {
+	Host           string    `json:"host"`
+	RedirectStatus int       `json:"redirect_status,omitempty"` // Status of the domain's links that set none; 0 uses the server default
+	Branding       Branding  `json:"branding"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// Branding customizes the pages visitors see on a custom domain
+type Branding struct {
+	Name    string `json:"name,omitempty"`     // Shown instead of the server's name
+	LogoURL string `json:"logo_url,omitempty"` // http(s) image shown above the message
+	Color   string `json:"color,omitempty"`    // Accent color, #rgb or #rrggbb
+}
+
+// UpdateDomainRequest represents a partial update of a custom domain; omitted
+// fields are unchanged
+type UpdateDomainRequest struct {
+	RedirectStatus *int      `json:"redirect_status,omitempty"` // 0 reverts to the server default
+	Branding       *Branding `json:"branding,omitempty"`        // Replaces the branding as a whole
+}
@@ -0,0 +1,27 @@
+package domain
+
+import "time"
+
+// DestinationList is the list a destination rule belongs to
+type DestinationList string
+
+const (
+	DestinationAllow DestinationList = "allow" // When any allow rule exists, destinations must match one
+	DestinationDeny  DestinationList = "deny"  // Destinations matching a deny rule are rejected
+)
+
+// ValidDestinationList reports whether list is allow or deny
+func ValidDestinationList(list DestinationList) bool {
+	return list == DestinationAllow || list == DestinationDeny
+}
+
+// DestinationRule is a pattern of destination hosts that are allowed or
+// denied: a domain with its subdomains ("example.com"), only the subdomains
+// ("*.example.com"), an IP address or CIDR range, or "private" for
+// loopback, private and link-local addresses and internal host names
+type DestinationRule struct {
+	List      DestinationList `json:"list"`
+	Pattern   string          `json:"pattern"`
+	Static    bool            `json:"static,omitempty"`     // From the server configuration, so it cannot be removed through the API
+	CreatedAt *time.Time      `json:"created_at,omitempty"` // When it was added through the API
+}
@@ -0,0 +1,147 @@
+package domain
+
+import "errors"
+
+// Error categories. Errors that API callers should see wrap one of these, so
+// the HTTP layer picks the status with errors.Is rather than by message.
+// ErrStorage marks a failure of the database itself, which callers must not
+// mistake for a missing record; it and anything uncategorized are internal
+// errors. ErrUnavailable marks work refused to protect a failing dependency,
+// which callers may retry later.
+var (
+	ErrNotFound    = errors.New("not found")
+	ErrConflict    = errors.New("conflict")
+	ErrValidation  = errors.New("validation failed")
+	ErrStorage     = errors.New("storage failure")
+	ErrUnavailable = errors.New("temporarily unavailable")
+)
+
+// ErrUsageLimitReached is returned when a link has been redirected max_uses times
+var ErrUsageLimitReached = errors.New("usage limit reached")
+
+// ErrLinkBlocked is returned when a short code is reserved or contains a
+// blocked word, so it no longer redirects even if it was issued before
+var ErrLinkBlocked = errors.New("link is blocked")
+
+// ErrLinkSuspended is returned when abuse detection throttles or disables a
+// flagged link
+var ErrLinkSuspended = errors.New("link is suspended")
+
+// ErrURLNotFound is returned when no short URL matches a lookup
+var ErrURLNotFound = NotFound(errors.New("short URL not found"))
+
+// ErrShortCodeTaken is returned when a short code is already in use
+var ErrShortCodeTaken = Conflict(errors.New("short code already exists"))
+
+// ErrNotCached is returned when a short code has no entry in the cache
+var ErrNotCached = NotFound(errors.New("short code is not cached"))
+
+// ErrDraining is returned by writes while the server is drained
+var ErrDraining = Unavailable(errors.New("server is draining, writes are paused"))
+
+// ErrAliasNotFound is returned when no alias matches a lookup
+var ErrAliasNotFound = NotFound(errors.New("alias not found"))
+
+// ErrWebhookNotFound is returned when a webhook endpoint ID does not exist
+var ErrWebhookNotFound = NotFound(errors.New("webhook endpoint not found"))
+
+// ErrPolicyNotFound is returned when a lifecycle policy ID does not exist
+var ErrPolicyNotFound = NotFound(errors.New("lifecycle policy not found"))
+
+// ErrVerificationNotFound is returned when a link's destination has not been checked
+var ErrVerificationNotFound = NotFound(errors.New("link verification not found"))
+
+// ErrDestinationRuleNotFound is returned when a destination rule does not exist
+var ErrDestinationRuleNotFound = NotFound(errors.New("destination rule not found"))
+
+// ErrDestinationRuleExists is returned when a destination rule is added twice
+var ErrDestinationRuleExists = Conflict(errors.New("destination rule already exists"))
+
+// ErrAbuseFlagNotFound is returned when a link is not flagged for abuse
+var ErrAbuseFlagNotFound = NotFound(errors.New("abuse flag not found"))
+
+// ErrDomainNotFound is returned when a custom domain does not exist
+var ErrDomainNotFound = NotFound(errors.New("custom domain not found"))
+
+// ErrDomainExists is returned when a custom domain is added twice
+var ErrDomainExists = Conflict(errors.New("custom domain already exists"))
+
+// ErrDomainInUse is returned when a custom domain that still has links is removed
+var ErrDomainInUse = Conflict(errors.New("custom domain still has links"))
+
+// categoryError places err in a category while keeping its message
+type categoryError struct {
+	err      error
+	category error
+}
+
+func (e *categoryError) Error() string {
+	return e.err.Error()
+}
+
+func (e *categoryError) Unwrap() []error {
+	return []error{e.err, e.category}
+}
+
+// NotFound marks err as ErrNotFound
+func NotFound(err error) error {
+	return &categoryError{err: err, category: ErrNotFound}
+}
+
+// Conflict marks err as ErrConflict
+func Conflict(err error) error {
+	return &categoryError{err: err, category: ErrConflict}
+}
+
+// Storage marks err as ErrStorage
+func Storage(err error) error {
+	return &categoryError{err: err, category: ErrStorage}
+}
+
+// Unavailable marks err as ErrUnavailable
+func Unavailable(err error) error {
+	return &categoryError{err: err, category: ErrUnavailable}
+}
+
+// ValidationError is a rejected request value. Field is its JSON name, or
+// empty when the problem is not about a single field.
+type ValidationError struct {
+	Field string
+	Err   error
+}
+
+func (e *ValidationError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ValidationError) Unwrap() []error {
+	return []error{e.Err, ErrValidation}
+}
+
+// Invalid marks err as a ValidationError of field
+func Invalid(field string, err error) error {
+	return &ValidationError{Field: field, Err: err}
+}
+
+// Codes of ErrorResponse. They are part of the API, so existing ones must not change.
+const (
+	ErrorCodeInvalidRequest   = "invalid_request"
+	ErrorCodeValidation       = "validation_failed"
+	ErrorCodeUnauthorized     = "unauthorized"
+	ErrorCodeForbidden        = "forbidden"
+	ErrorCodeNotFound         = "not_found"
+	ErrorCodeMethodNotAllowed = "method_not_allowed"
+	ErrorCodeConflict         = "conflict"
+	ErrorCodeUsageLimit       = "usage_limit_reached"
+	ErrorCodeRateLimited      = "rate_limited"
+	ErrorCodeInternal         = "internal_error"
+	ErrorCodeNotImplemented   = "not_implemented"
+	ErrorCodeUnavailable      = "unavailable"
+)
+
+// ErrorResponse is the body of every API error
+type ErrorResponse struct {
+	Code    string         `json:"code"`
+	Message string         `json:"message"`
+	Details map[string]any `json:"details,omitempty"`
+}
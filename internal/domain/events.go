@@ -0,0 +1,80 @@
+package domain
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+)
+
+// EventType identifies a link lifecycle event
+type EventType string
+
+// EventType constants
+const (
+	EventURLCreated   EventType = "url.created"   // A short URL was created
+	EventURLDeleted   EventType = "url.deleted"   // A short URL was deleted
+	EventURLExpired   EventType = "url.expired"   // A capped short URL used its last redirect
+	EventURLClicked   EventType = "url.clicked"   // A short URL was redirected (sampled)
+	EventURLFailover  EventType = "url.failover"  // A short URL's primary destination failed health checks; redirects use its backup
+	EventURLRecovered EventType = "url.recovered" // A short URL's redirects returned to its primary destination
+	EventURLBroken    EventType = "url.broken"    // A short URL's destination stopped answering destination checks
+	EventURLFlagged   EventType = "url.flagged"   // A short URL's clicks looked like click fraud
+)
+
+// EventTypes lists every event type in the order they are documented
+var EventTypes = []EventType{EventURLCreated, EventURLDeleted, EventURLExpired, EventURLClicked, EventURLFailover, EventURLRecovered, EventURLBroken, EventURLFlagged}
+
+// Valid reports whether t is a known event type
+func (t EventType) Valid() bool {
+	for _, known := range EventTypes {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
+
+// Event is a link lifecycle event
+type Event struct {
+	ID        string    `json:"id"`
+	Type      EventType `json:"type"`
+	CreatedAt time.Time `json:"created_at"`
+	Data      EventData `json:"data"`
+	// SampleRate is the fraction of url.clicked events delivered when this
+	// one was sampled; divide by it to estimate the total number of clicks
+	SampleRate float64 `json:"sample_rate,omitempty"`
+	// Click is the counted click behind a url.clicked event, with its
+	// referrer, UTM parameters and location. It stays in the process: webhooks
+	// and brokers never see it.
+	Click *Click `json:"-"`
+}
+
+// EventData describes the link an event refers to
+type EventData struct {
+	ShortCode   string `json:"short_code"`
+	OriginalURL string `json:"original_url,omitempty"`
+	Campaign    string `json:"campaign,omitempty"`
+	UsageCount  int    `json:"usage_count"`
+	MaxUses     int    `json:"max_uses,omitempty"`
+	BackupURL   string `json:"backup_url,omitempty"`
+	Reason      string `json:"reason,omitempty"` // Why a failover, broken or flagged event happened
+}
+
+// NewEvent creates an event with a random ID and the current time
+func NewEvent(eventType EventType, data EventData) Event {
+	id := make([]byte, 16)
+	rand.Read(id)
+
+	return Event{
+		ID:        "evt_" + hex.EncodeToString(id),
+		Type:      eventType,
+		CreatedAt: time.Now().UTC(),
+		Data:      data,
+	}
+}
+
+// ExportRecord is an event waiting in the export outbox or the event outbox
+type ExportRecord struct {
+	ID    int64 // Outbox position; records are sent in ID order
+	Event Event
+}
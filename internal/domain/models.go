@@ -1,36 +1,357 @@
 package domain
 
 import (
+	"net/http"
+	"regexp"
 	"time"
 )
 
 // URLEntry represents a shortened URL with its metadata
 type URLEntry struct {
-	ID          int        `json:"id"`
-	ShortCode   string     `json:"short_code"`
-	OriginalURL string     `json:"original_url"`
-	CreatedAt   time.Time  `json:"created_at"`
-	LastUsedAt  *time.Time `json:"last_used_at,omitempty"`
-	UsageCount  int        `json:"usage_count"`
+	ID                int               `json:"id"`
+	ShortCode         string            `json:"short_code"`
+	OriginalURL       string            `json:"original_url"`
+	CreatedAt         time.Time         `json:"created_at"`
+	LastUsedAt        *time.Time        `json:"last_used_at,omitempty"`
+	UpdatedAt         *time.Time        `json:"updated_at,omitempty"` // When the destination or settings last changed
+	UsageCount        int               `json:"usage_count"`
+	BotsCount         int               `json:"bots_count,omitempty"`         // Redirects from bots, counted here instead of UsageCount when bot clicks are separate
+	MaxUses           int               `json:"max_uses,omitempty"`           // 0 means unlimited
+	BurnAfterReading  bool              `json:"burn_after_reading,omitempty"` // Deactivated by its first redirect; MaxUses is 1
+	Tags              []string          `json:"tags,omitempty"`
+	RedirectStatus    int               `json:"redirect_status,omitempty"`     // 0 means the server default
+	BackupURL         string            `json:"backup_url,omitempty"`          // Served while the primary destination is unhealthy
+	FailoverActive    bool              `json:"failover_active,omitempty"`     // Set by the destination health checker while redirects use BackupURL
+	FailoverReason    string            `json:"failover_reason,omitempty"`     // Why failover last changed state
+	FailoverChangedAt *time.Time        `json:"failover_changed_at,omitempty"` // When failover last changed state
+	TemplateParams    []TemplateParam   `json:"template_params,omitempty"`     // Placeholders of a template link, filled from the redirect's query
+	QueryParams       map[string]string `json:"query_params,omitempty"`        // Added to the destination's query on redirect, e.g. UTM parameters
+	ForwardQuery      bool              `json:"forward_query,omitempty"`       // Pass the short link's incoming query parameters on to the destination
+	Campaign          string            `json:"campaign,omitempty"`            // Name of the campaign the link belongs to
+	Owner             string            `json:"owner,omitempty"`               // API key fingerprint or user that created the link
+	Domain            string            `json:"domain,omitempty"`              // Custom domain the link is served on; empty for the server's own host
+	DedupeSeconds     int               `json:"dedupe_seconds,omitempty"`      // Click dedupe window; 0 means the server default, -1 counts every click
+	Preview           *LinkPreview      `json:"preview,omitempty"`             // Title, description and favicon of the destination page
+	Verification      *LinkVerification `json:"verification,omitempty"`        // Latest failed destination check, in broken link listings
+}
+
+// MaxListLimit is the most links one page of GET /api/urls may hold
+const MaxListLimit = 1000
+
+// HasTag reports whether the entry is labeled with tag
+func (e *URLEntry) HasTag(tag string) bool {
+	for _, t := range e.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// LastActivity returns when the link was last redirected, or when it was
+// created if it has never been used
+func (e *URLEntry) LastActivity() time.Time {
+	if e.LastUsedAt != nil && !e.LastUsedAt.IsZero() {
+		return *e.LastUsedAt
+	}
+	return e.CreatedAt
+}
+
+// LastModified returns the latest time anything shown for the entry changed:
+// its creation, last update, last use, last failover switch or preview fetch
+func (e *URLEntry) LastModified() time.Time {
+	modified := e.CreatedAt
+	for _, t := range []*time.Time{e.UpdatedAt, e.LastUsedAt, e.FailoverChangedAt} {
+		if t != nil && t.After(modified) {
+			modified = *t
+		}
+	}
+	if e.Preview != nil && e.Preview.FetchedAt.After(modified) {
+		modified = e.Preview.FetchedAt
+	}
+	return modified
+}
+
+// MaxTags is the most tags a single link may carry
+const MaxTags = 10
+
+// Limits on the query parameters a link appends to its destination
+const (
+	MaxQueryParams      = 20
+	MaxQueryParamLength = 256 // Applies to names and values
+)
+
+// tagPattern is the allowed form of a tag: lowercase letters, digits, '-' and '_'
+var tagPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,31}$`)
+
+// ValidTag reports whether tag is a well-formed, lowercase tag
+func ValidTag(tag string) bool {
+	return tagPattern.MatchString(tag)
+}
+
+// ValidRedirectStatus reports whether status is an HTTP redirect status a link
+// may use: 301 or 308 (permanent) or 302 or 307 (temporary)
+func ValidRedirectStatus(status int) bool {
+	switch status {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		return true
+	}
+	return false
+}
+
+// PermanentRedirect reports whether status tells clients they may cache the redirect
+func PermanentRedirect(status int) bool {
+	return status == http.StatusMovedPermanently || status == http.StatusPermanentRedirect
 }
 
 // CacheEntry represents an entry in the cache
 type CacheEntry struct {
-	OriginalURL string    `json:"original_url"`
-	UsageCount  int       `json:"usage_count"`
-	LastUsedAt  time.Time `json:"last_used_at"`
-	Dirty       bool      `json:"dirty"` // Indicates if the entry needs to be synced to DB
+	OriginalURL      string            `json:"original_url"`
+	UsageCount       int               `json:"usage_count"`
+	MaxUses          int               `json:"max_uses,omitempty"`           // 0 means unlimited
+	BurnAfterReading bool              `json:"burn_after_reading,omitempty"` // The first redirect is claimed in the database
+	RedirectStatus   int               `json:"redirect_status,omitempty"`    // 0 means the server default
+	BackupURL        string            `json:"backup_url,omitempty"`
+	FailoverActive   bool              `json:"failover_active,omitempty"` // Redirect to BackupURL instead of OriginalURL
+	QueryParams      map[string]string `json:"query_params,omitempty"`
+	ForwardQuery     bool              `json:"forward_query,omitempty"`
+	DedupeSeconds    int               `json:"dedupe_seconds,omitempty"` // 0 means the server default, -1 counts every click
+	Campaign         string            `json:"campaign,omitempty"`
+	Domain           string            `json:"domain,omitempty"` // Custom domain the link is served on; empty for the server's own host
+	Routes           []RoutingRule     `json:"routes,omitempty"` // Evaluated in order; the first match replaces the destination
+	LastUsedAt       time.Time         `json:"last_used_at"`
+	Dirty            bool              `json:"dirty"`                 // Indicates if the entry needs to be synced to DB
+	SyncedCount      int               `json:"synced_count"`          // Usage count as of the last load from or sync to the DB
+	BotsCount        int               `json:"bots_count,omitempty"`  // Redirects from bots counted separately
+	SyncedBots       int               `json:"synced_bots,omitempty"` // Bots count as of the last load from or sync to the DB
+	ExpiresAt        time.Time         `json:"expires_at,omitempty"`  // When the cache drops the entry; zero means never
+}
+
+// Destination returns the URL redirects currently go to: the backup while
+// failover is active, otherwise the original URL
+func (e *CacheEntry) Destination() string {
+	if e.FailoverActive && e.BackupURL != "" {
+		return e.BackupURL
+	}
+	return e.OriginalURL
+}
+
+// PendingUsage returns the redirects counted since the entry was last synced
+func (e *CacheEntry) PendingUsage() int {
+	return e.UsageCount - e.SyncedCount
+}
+
+// UsedUp reports whether the entry has reached its usage cap
+func (e *CacheEntry) UsedUp() bool {
+	return e.MaxUses > 0 && e.UsageCount >= e.MaxUses
+}
+
+// PendingBots returns the bot redirects counted since the entry was last synced
+func (e *CacheEntry) PendingBots() int {
+	return e.BotsCount - e.SyncedBots
+}
+
+// CacheStats describes the link cache's contents and how well it serves lookups
+type CacheStats struct {
+	Entries      int        `json:"entries"`
+	Expired      int        `json:"expired"`       // Entries past their TTL, kept only to serve stale redirects
+	Dirty        int        `json:"dirty"`         // Entries with usage not yet synced to the DB
+	PendingUsage int        `json:"pending_usage"` // Redirects counted but not yet synced
+	Hits         uint64     `json:"hits"`
+	Misses       uint64     `json:"misses"`
+	HitRatio     float64    `json:"hit_ratio"` // Fraction of lookups since the start served from the cache
+	LastSyncAt   *time.Time `json:"last_sync_at,omitempty"`
+}
+
+// CacheFlushResponse is the response of a forced cache sync
+type CacheFlushResponse struct {
+	Synced int `json:"synced"` // Entries whose usage was written
+}
+
+// SyncSummary is the response of a forced usage sync or a drain
+type SyncSummary struct {
+	Synced     int   `json:"synced"`      // Entries whose usage was written
+	DurationMs int64 `json:"duration_ms"` // Including the wait for buffered clicks
+	Draining   bool  `json:"draining"`    // Writes stay paused until the drain is ended
+}
+
+// UsageMergeStrategy determines how a usage sync resolves counts written by other writers
+type UsageMergeStrategy string
+
+// UsageMergeStrategy constants
+const (
+	UsageMergeMax   UsageMergeStrategy = "max"   // Keep the higher of the stored and synced counts
+	UsageMergeDelta UsageMergeStrategy = "delta" // Add the redirects counted since the last sync
+)
+
+// UsageUpdate is a single entry of a usage sync batch
+type UsageUpdate struct {
+	ShortCode  string
+	UsageCount int // Absolute count seen by the writer, used by UsageMergeMax
+	Delta      int // Redirects since the writer's last sync, used by UsageMergeDelta
+	BotsDelta  int // Bot redirects since the writer's last sync; always added
+	LastUsedAt time.Time
+}
+
+// CreateOptions holds the optional settings of a short URL
+type CreateOptions struct {
+	MaxUses          int               // Deactivate the link after this many redirects; 0 means unlimited
+	BurnAfterReading bool              // Deactivate the link atomically with its first redirect; implies MaxUses 1
+	Tags             []string          // Labels used to group links, e.g. by lifecycle policies
+	RedirectStatus   int               // 301, 302, 307 or 308; 0 uses the server default
+	ReuseExisting    bool              // Return an existing uncapped link to the same URL instead of creating one
+	BackupURL        string            // Destination used while the original URL fails health checks
+	QueryParams      map[string]string // Added to the destination's query on every redirect
+	ForwardQuery     bool              // Pass incoming query parameters on to the destination
+	Campaign         string            // Groups the link with others for aggregate reporting
+	Owner            string            // Credential creating the link; set by the server, never by the request
+	Domain           string            // Custom domain the link is served on; empty for the server's own host
+	DedupeSeconds    int               // Count one click per client per this many seconds; 0 uses the server default, -1 counts every click
 }
 
 // CreateURLRequest represents the request to create a short URL
 type CreateURLRequest struct {
-	URL string `json:"url"`
+	URL              string            `json:"url"`
+	MaxUses          int               `json:"max_uses,omitempty"`
+	BurnAfterReading bool              `json:"burn_after_reading,omitempty"`
+	Tags             []string          `json:"tags,omitempty"`
+	RedirectStatus   int               `json:"redirect_status,omitempty"`
+	ReuseExisting    bool              `json:"reuse_existing,omitempty"`
+	BackupURL        string            `json:"backup_url,omitempty"`
+	QueryParams      map[string]string `json:"query_params,omitempty"`
+	ForwardQuery     bool              `json:"forward_query,omitempty"`
+	Campaign         string            `json:"campaign,omitempty"`
+	DedupeSeconds    int               `json:"dedupe_seconds,omitempty"`
+	Domain           string            `json:"domain,omitempty"`
+}
+
+// UpdateURLRequest represents a partial update of a short URL; omitted fields are unchanged
+type UpdateURLRequest struct {
+	OriginalURL    *string            `json:"original_url,omitempty"`
+	MaxUses        *int               `json:"max_uses,omitempty"`        // 0 removes the cap
+	Tags           *[]string          `json:"tags,omitempty"`            // An empty list removes all tags
+	RedirectStatus *int               `json:"redirect_status,omitempty"` // 0 reverts to the server default
+	BackupURL      *string            `json:"backup_url,omitempty"`      // An empty string removes the backup
+	QueryParams    *map[string]string `json:"query_params,omitempty"`    // An empty object removes all parameters
+	ForwardQuery   *bool              `json:"forward_query,omitempty"`
+	Campaign       *string            `json:"campaign,omitempty"`       // An empty string removes the link from its campaign
+	DedupeSeconds  *int               `json:"dedupe_seconds,omitempty"` // 0 reverts to the server default
+}
+
+// ValidationProblem is one reason a create request would be rejected
+type ValidationProblem struct {
+	Field   string `json:"field"` // Request field at fault, e.g. "url" or "backup_url"
+	Message string `json:"message"`
+}
+
+// ValidateURLResponse is returned by POST /api/urls/validate
+type ValidateURLResponse struct {
+	Valid    bool                `json:"valid"`
+	Problems []ValidationProblem `json:"problems"`
 }
 
 // CreateURLResponse represents the response when creating a short URL
 type CreateURLResponse struct {
-	ShortCode   string    `json:"short_code"`
-	ShortURL    string    `json:"short_url"`
-	OriginalURL string    `json:"original_url"`
-	CreatedAt   time.Time `json:"created_at"`
+	ShortCode        string            `json:"short_code"`
+	ShortURL         string            `json:"short_url"`
+	OriginalURL      string            `json:"original_url"`
+	CreatedAt        time.Time         `json:"created_at"`
+	MaxUses          int               `json:"max_uses,omitempty"`
+	BurnAfterReading bool              `json:"burn_after_reading,omitempty"`
+	Tags             []string          `json:"tags,omitempty"`
+	RedirectStatus   int               `json:"redirect_status,omitempty"`
+	BackupURL        string            `json:"backup_url,omitempty"`
+	TemplateParams   []TemplateParam   `json:"template_params,omitempty"`
+	QueryParams      map[string]string `json:"query_params,omitempty"`
+	ForwardQuery     bool              `json:"forward_query,omitempty"`
+	Campaign         string            `json:"campaign,omitempty"`
+	DedupeSeconds    int               `json:"dedupe_seconds,omitempty"`
+	Domain           string            `json:"domain,omitempty"`
+}
+
+// ShareTokenRequest represents the request to issue a share token
+type ShareTokenRequest struct {
+	TTL string `json:"ttl,omitempty"` // Go duration, e.g. "24h"
+}
+
+// ShareTokenResponse represents an issued read-only share token for one link
+type ShareTokenResponse struct {
+	Token     string    `json:"token"`
+	ShortCode string    `json:"short_code"`
+	ExpiresAt time.Time `json:"expires_at"`
+	InfoURL   string    `json:"info_url"`
+}
+
+// ConflictStrategy determines how an import handles short codes that already exist
+type ConflictStrategy string
+
+// ConflictStrategy constants
+const (
+	ConflictSkip      ConflictStrategy = "skip"      // Keep the existing entry
+	ConflictOverwrite ConflictStrategy = "overwrite" // Replace the existing entry
+	ConflictFail      ConflictStrategy = "fail"      // Abort the whole import
+)
+
+// ImportResult summarizes the outcome of an import
+type ImportResult struct {
+	Imported    int `json:"imported"`
+	Overwritten int `json:"overwritten"`
+	Skipped     int `json:"skipped"`
+}
+
+// CodeChange is a link's short code before and after re-encoding it for a new
+// generator configuration
+type CodeChange struct {
+	OldCode string `json:"old_code"`
+	NewCode string `json:"new_code"`
+}
+
+// HealthStatus summarizes whether the server or one of its dependencies can serve traffic
+type HealthStatus string
+
+const (
+	// HealthOK means every dependency is reachable
+	HealthOK HealthStatus = "ok"
+	// HealthDegraded means requests are still served, with reduced capability
+	HealthDegraded HealthStatus = "degraded"
+	// HealthUnavailable means requests cannot be served
+	HealthUnavailable HealthStatus = "unavailable"
+)
+
+// ComponentHealth reports the state of a single dependency
+type ComponentHealth struct {
+	Status HealthStatus `json:"status"`
+	Error  string       `json:"error,omitempty"`
+}
+
+// HealthResponse is the readiness report returned by /readyz
+type HealthResponse struct {
+	Status     HealthStatus               `json:"status"`
+	Components map[string]ComponentHealth `json:"components"`
+}
+
+// VersionResponse describes the running build and how the server is configured
+type VersionResponse struct {
+	Version   string   `json:"version"`
+	Commit    string   `json:"commit"`
+	BuildDate string   `json:"build_date"`
+	GoVersion string   `json:"go_version"`
+	Storage   string   `json:"storage,omitempty"`
+	Cache     string   `json:"cache,omitempty"`
+	Generator string   `json:"generator,omitempty"`
+	Features  []string `json:"features,omitempty"`
+}
+
+// DashboardConfig tells the admin dashboard how users sign in
+type DashboardConfig struct {
+	OIDC *DashboardOIDC `json:"oidc,omitempty"` // Set when identity provider sign-in is enabled
+}
+
+// DashboardOIDC is the public part of the identity provider configuration the
+// dashboard needs for an authorization code flow with PKCE
+type DashboardOIDC struct {
+	ClientID              string   `json:"client_id"`
+	AuthorizationEndpoint string   `json:"authorization_endpoint"`
+	TokenEndpoint         string   `json:"token_endpoint"`
+	Scopes                []string `json:"scopes"`
 }
\ No newline at end of file
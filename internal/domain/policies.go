@@ -0,0 +1,111 @@
+package domain
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PolicyAction is what a lifecycle policy does to the links it matches
+type PolicyAction string
+
+// PolicyAction constants
+const (
+	PolicyActionDelete  PolicyAction = "delete"  // Remove the link
+	PolicyActionArchive PolicyAction = "archive" // Remove the link, keeping a snapshot in the audit log
+)
+
+// Valid reports whether a is a known policy action
+func (a PolicyAction) Valid() bool {
+	return a == PolicyActionDelete || a == PolicyActionArchive
+}
+
+// Age is a duration written either as a Go duration ("36h") or a whole
+// number of days ("30d")
+type Age time.Duration
+
+// ParseAge parses an age such as "30d" or "12h30m"
+func ParseAge(s string) (Age, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid age %q", s)
+		}
+		return Age(time.Duration(n) * 24 * time.Hour), nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid age %q", s)
+	}
+	return Age(d), nil
+}
+
+// String formats whole days as "30d" and anything else as a Go duration
+func (a Age) String() string {
+	d := time.Duration(a)
+	if d > 0 && d%(24*time.Hour) == 0 {
+		return strconv.FormatInt(int64(d/(24*time.Hour)), 10) + "d"
+	}
+	return d.String()
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (a Age) MarshalText() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (a *Age) UnmarshalText(text []byte) error {
+	parsed, err := ParseAge(string(text))
+	if err != nil {
+		return err
+	}
+	*a = parsed
+	return nil
+}
+
+// LifecyclePolicy is a rule applied to every link that matches all of its
+// conditions, e.g. "links tagged temp older than 30d are deleted"
+type LifecyclePolicy struct {
+	ID        int64        `json:"id,omitempty" yaml:"-"` // 0 for policies from the config file
+	Name      string       `json:"name" yaml:"name"`
+	Tag       string       `json:"tag,omitempty" yaml:"tag"`               // Only links carrying this tag
+	OlderThan Age          `json:"older_than,omitempty" yaml:"older_than"` // Only links created at least this long ago
+	UnusedFor Age          `json:"unused_for,omitempty" yaml:"unused_for"` // Only links not redirected for this long
+	Action    PolicyAction `json:"action" yaml:"action"`
+	CreatedAt time.Time    `json:"created_at,omitzero" yaml:"-"`
+}
+
+// Matches reports whether the policy applies to entry at the given time
+func (p *LifecyclePolicy) Matches(entry *URLEntry, now time.Time) bool {
+	if p.Tag != "" && !entry.HasTag(p.Tag) {
+		return false
+	}
+	if p.OlderThan > 0 && now.Sub(entry.CreatedAt) < time.Duration(p.OlderThan) {
+		return false
+	}
+	if p.UnusedFor > 0 && now.Sub(entry.LastActivity()) < time.Duration(p.UnusedFor) {
+		return false
+	}
+	return true
+}
+
+// PolicyMatch is a link a policy would act on, as shown by a dry run
+type PolicyMatch struct {
+	Policy string       `json:"policy"`
+	Action PolicyAction `json:"action"`
+	Link   *URLEntry    `json:"link"`
+}
+
+// PolicyAuditEntry records an action a policy took on a link
+type PolicyAuditEntry struct {
+	ID          int64        `json:"id,omitempty"` // Assigned when the entry is stored
+	Policy      string       `json:"policy"`
+	Action      PolicyAction `json:"action"`
+	ShortCode   string       `json:"short_code"`
+	OriginalURL string       `json:"original_url"`
+	Snapshot    *URLEntry    `json:"snapshot,omitempty"` // The link as it was before being archived
+	Error       string       `json:"error,omitempty"`
+	CreatedAt   time.Time    `json:"created_at"`
+}
@@ -0,0 +1,13 @@
+package domain
+
+import "time"
+
+// LinkPreview is human-readable context about a link's destination page,
+// fetched in the background after the link is created
+type LinkPreview struct {
+	Title       string    `json:"title,omitempty"`
+	Description string    `json:"description,omitempty"`
+	FaviconURL  string    `json:"favicon_url,omitempty"`
+	Error       string    `json:"error,omitempty"` // Why the last fetch failed, e.g. disallowed by robots.txt
+	FetchedAt   time.Time `json:"fetched_at"`
+}
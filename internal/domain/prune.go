@@ -0,0 +1,50 @@
+package domain
+
+import "time"
+
+// MaxBulkDelete is the most short codes one bulk delete request may name
+const MaxBulkDelete = 1000
+
+// BulkDeleteRequest is the body of POST /api/urls/delete
+type BulkDeleteRequest struct {
+	ShortCodes []string `json:"short_codes"`
+}
+
+// BulkDeleteResponse reports what happened to each short code of a bulk delete
+type BulkDeleteResponse struct {
+	Deleted   []string `json:"deleted"`
+	NotFound  []string `json:"not_found,omitempty"`
+	Forbidden []string `json:"forbidden,omitempty"` // Owned by another credential
+	Failed    []string `json:"failed,omitempty"`
+}
+
+// PruneRequest is the body of POST /api/urls/prune. It selects the links
+// matching every condition set; at least one is required.
+type PruneRequest struct {
+	UnusedFor     Age       `json:"unused_for,omitempty"`    // Only links not redirected for this long
+	CreatedBefore time.Time `json:"created_before,omitzero"` // Only links created before this time
+	DryRun        bool      `json:"dry_run,omitempty"`       // List the matching links without deleting them
+}
+
+// Empty reports whether the request sets no conditions, which would match every link
+func (p *PruneRequest) Empty() bool {
+	return p.UnusedFor == 0 && p.CreatedBefore.IsZero()
+}
+
+// Matches reports whether entry meets the request's conditions at the given time
+func (p *PruneRequest) Matches(entry *URLEntry, now time.Time) bool {
+	if !p.CreatedBefore.IsZero() && !entry.CreatedAt.Before(p.CreatedBefore) {
+		return false
+	}
+	if p.UnusedFor > 0 && now.Sub(entry.LastActivity()) < time.Duration(p.UnusedFor) {
+		return false
+	}
+	return true
+}
+
+// PruneResponse lists the links a prune matched and what happened to them
+type PruneResponse struct {
+	Links   []*URLEntry        `json:"links"`
+	DryRun  bool               `json:"dry_run,omitempty"`
+	Results BulkDeleteResponse `json:"results"` // Empty for a dry run
+}
@@ -0,0 +1,7 @@
+package domain
+
+// DataPurgeResponse is returned by DELETE /api/admin/data
+type DataPurgeResponse struct {
+	IP             string `json:"ip"`
+	EntriesRemoved int    `json:"entries_removed"` // In-memory entries that held the address
+}
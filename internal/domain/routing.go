@@ -0,0 +1,203 @@
+package domain
+
+import (
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DeviceType is the kind of device a visitor uses, derived from its User-Agent
+type DeviceType string
+
+// DeviceType constants
+const (
+	DeviceDesktop DeviceType = "desktop"
+	DeviceMobile  DeviceType = "mobile"
+	DeviceTablet  DeviceType = "tablet"
+	DeviceBot     DeviceType = "bot"
+)
+
+// ValidDeviceType reports whether device is one of the DeviceType constants
+func ValidDeviceType(device DeviceType) bool {
+	switch device {
+	case DeviceDesktop, DeviceMobile, DeviceTablet, DeviceBot:
+		return true
+	}
+	return false
+}
+
+// User-Agent substrings that identify each device type, checked in order
+var (
+	botAgents    = []string{"bot", "crawl", "spider", "slurp", "archiver", "facebookexternalhit", "preview", "embedly", "whatsapp", "mediapartners-google", "google-inspectiontool", "feedfetcher", "headlesschrome", "lighthouse", "pingdom", "uptime", "scrapy", "python-requests", "curl/", "wget/"}
+	tabletAgents = []string{"ipad", "tablet", "kindle", "silk", "playbook"}
+	mobileAgents = []string{"mobi", "iphone", "ipod", "android", "windows phone", "blackberry", "opera mini"}
+)
+
+// ParseDeviceType classifies a User-Agent. Bots are recognized first, then
+// tablets (including Android devices that do not claim to be mobile), then
+// phones; anything else, including an empty User-Agent, is a desktop.
+func ParseDeviceType(userAgent string) DeviceType {
+	agent := strings.ToLower(userAgent)
+	switch {
+	case containsAny(agent, botAgents):
+		return DeviceBot
+	case containsAny(agent, tabletAgents),
+		strings.Contains(agent, "android") && !strings.Contains(agent, "mobile"):
+		return DeviceTablet
+	case containsAny(agent, mobileAgents):
+		return DeviceMobile
+	}
+	return DeviceDesktop
+}
+
+// containsAny reports whether s contains any of the substrings
+func containsAny(s string, substrings []string) bool {
+	for _, substring := range substrings {
+		if strings.Contains(s, substring) {
+			return true
+		}
+	}
+	return false
+}
+
+// PreferredLanguage returns the lowercased language tag an Accept-Language
+// header ranks highest, e.g. "en-us", or "" when it names none
+func PreferredLanguage(acceptLanguage string) string {
+	type ranked struct {
+		tag     string
+		quality float64
+	}
+
+	var languages []ranked
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || tag == "*" {
+			continue
+		}
+		quality := 1.0
+		if value, found := strings.CutPrefix(strings.TrimSpace(params), "q="); found {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			quality = parsed
+		}
+		if quality > 0 {
+			languages = append(languages, ranked{tag, quality})
+		}
+	}
+	if len(languages) == 0 {
+		return ""
+	}
+
+	// Stable, so equally ranked languages keep the order the client sent
+	sort.SliceStable(languages, func(i, j int) bool { return languages[i].quality > languages[j].quality })
+	return languages[0].tag
+}
+
+// RedirectRequest describes a redirect: the short URL's query parameters and
+// what is known about the visitor
+type RedirectRequest struct {
+	Query    url.Values
+	Country  string     // ISO 3166-1 alpha-2 code, uppercase; empty when unknown
+	Region   string     // ISO 3166-2 subdivision code within Country, for the country analytics; empty when unknown
+	Device   DeviceType // From the User-Agent
+	Language string     // Most preferred Accept-Language tag, lowercase; empty when unknown
+	ClientIP string     // Visitor's address, used to count repeated clicks once; empty when unknown
+	ASN      uint32     // Autonomous system number of the visitor's network; 0 when unknown
+	Referrer string     // Referer header, for the referrer analytics; empty when not sent
+	Domain   string     // Custom domain the request arrived on; empty for the server's own host
+	Peek     bool       // Resolve without counting a use, as for HEAD requests
+}
+
+// MaxRoutingRules is the most routing rules a single link may have
+const MaxRoutingRules = 20
+
+// countryPattern is the form of an ISO 3166-1 alpha-2 country code
+var countryPattern = regexp.MustCompile(`^[A-Z]{2}$`)
+
+// ValidCountry reports whether country is an uppercase two-letter country code
+func ValidCountry(country string) bool {
+	return countryPattern.MatchString(country)
+}
+
+// languagePattern is the form of a lowercase language tag such as "en" or "pt-br"
+var languagePattern = regexp.MustCompile(`^[a-z]{2,3}(-[a-z0-9]{2,8})*$`)
+
+// ValidLanguage reports whether language is a lowercase language tag
+func ValidLanguage(language string) bool {
+	return languagePattern.MatchString(language)
+}
+
+// RoutingRule sends visitors matching all of its conditions to another
+// destination. An empty condition matches any visitor.
+type RoutingRule struct {
+	Countries   []string     `json:"countries,omitempty"` // Country codes, e.g. "DE"
+	Devices     []DeviceType `json:"devices,omitempty"`
+	Languages   []string     `json:"languages,omitempty"` // Language tags; "en" also matches "en-us"
+	Destination string       `json:"destination"`
+}
+
+// Matches reports whether a redirect satisfies every condition of the rule
+func (r *RoutingRule) Matches(req RedirectRequest) bool {
+	if len(r.Countries) > 0 && !contains(r.Countries, req.Country) {
+		return false
+	}
+	if len(r.Devices) > 0 && !contains(r.Devices, req.Device) {
+		return false
+	}
+	if len(r.Languages) > 0 && !matchesLanguage(r.Languages, req.Language) {
+		return false
+	}
+	return true
+}
+
+// contains reports whether values includes value
+func contains[T comparable](values []T, value T) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesLanguage reports whether language equals one of the tags or is a
+// regional variant of one, e.g. "en-us" for "en"
+func matchesLanguage(tags []string, language string) bool {
+	if language == "" {
+		return false
+	}
+	for _, tag := range tags {
+		if language == tag || strings.HasPrefix(language, tag+"-") {
+			return true
+		}
+	}
+	return false
+}
+
+// SelectRoute returns the destination of the first rule the redirect matches
+func SelectRoute(rules []RoutingRule, req RedirectRequest) (string, bool) {
+	for i := range rules {
+		if rules[i].Matches(req) {
+			return rules[i].Destination, true
+		}
+	}
+	return "", false
+}
+
+// Route returns the URL a redirect goes to: the backup while failover is
+// active, otherwise the first matching routing rule's destination, falling
+// back to the original URL
+func (e *CacheEntry) Route(req RedirectRequest) string {
+	if e.FailoverActive && e.BackupURL != "" {
+		return e.BackupURL
+	}
+	if destination, ok := SelectRoute(e.Routes, req); ok {
+		return destination
+	}
+	return e.OriginalURL
+}
@@ -0,0 +1,43 @@
+package domain
+
+import "time"
+
+// StorageStats is a point-in-time measurement of the database
+type StorageStats struct {
+	DatabaseBytes int64            // Size of the database files, including the write-ahead log
+	TableRows     map[string]int64 // Row count per table
+	TotalClicks   int64            // Sum of usage counts across all links
+	LinksCreated  int64            // Links created since the requested time
+}
+
+// StorageReport describes database usage, projected growth and quota headroom
+type StorageReport struct {
+	DatabaseBytes int64            `json:"database_bytes"`
+	TableRows     map[string]int64 `json:"table_rows"`
+	TotalRows     int64            `json:"total_rows"`
+	TotalClicks   int64            `json:"total_clicks"`
+	Growth        StorageGrowth    `json:"growth"`
+	Quota         StorageQuota     `json:"quota"`
+	Warnings      []string         `json:"warnings"`
+	GeneratedAt   time.Time        `json:"generated_at"`
+}
+
+// StorageGrowth projects database growth from the recent link creation rate
+type StorageGrowth struct {
+	WindowDays     int     `json:"window_days"`     // Days of link creation the rate is measured over
+	LinksPerDay    float64 `json:"links_per_day"`   // Links created per day over the window
+	BytesPerDay    int64   `json:"bytes_per_day"`   // Estimated database growth per day
+	RowsPerDay     int64   `json:"rows_per_day"`    // Estimated row growth per day across all tables
+	ProjectionDays int     `json:"projection_days"` // How far ahead the projection looks
+	ProjectedBytes int64   `json:"projected_bytes"` // Estimated database size after ProjectionDays
+	ProjectedRows  int64   `json:"projected_rows"`  // Estimated total rows after ProjectionDays
+}
+
+// StorageQuota reports usage against the configured quotas; zero limits are unset
+type StorageQuota struct {
+	MaxBytes      int64   `json:"max_bytes,omitempty"`
+	MaxRows       int64   `json:"max_rows,omitempty"`
+	BytesUsed     float64 `json:"bytes_used,omitempty"`      // Fraction of MaxBytes in use
+	RowsUsed      float64 `json:"rows_used,omitempty"`       // Fraction of MaxRows in use
+	DaysUntilFull *int    `json:"days_until_full,omitempty"` // Days until the first quota is reached at the current growth rate
+}
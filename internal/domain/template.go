@@ -0,0 +1,179 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// ErrInvalidTemplateParams is returned when a template link's query parameters
+// do not satisfy its placeholders
+var ErrInvalidTemplateParams = Invalid("", errors.New("invalid template parameters"))
+
+// MaxTemplateParamLength is the longest value a template parameter may take
+const MaxTemplateParamLength = 256
+
+// templateParamPattern is the allowed form of a placeholder name
+var templateParamPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]{0,31}$`)
+
+// TemplateParam is a named placeholder in a template link's destination
+type TemplateParam struct {
+	Name     string `json:"name"`
+	Default  string `json:"default,omitempty"`
+	Required bool   `json:"required"`
+}
+
+// LinkTemplate is a destination with named placeholders, e.g.
+// "https://site/item/{id}?page={page=1}". A placeholder without a default is
+// required. Values are escaped for the part of the URL they land in.
+type LinkTemplate struct {
+	Params []TemplateParam
+	parts  []templatePart
+}
+
+// templatePart is a literal run of the destination or a placeholder
+type templatePart struct {
+	literal string
+	param   int  // Index into Params when literal is empty
+	query   bool // The placeholder is in the query string
+}
+
+// IsTemplate reports whether a destination contains placeholders
+func IsTemplate(rawURL string) bool {
+	return strings.Contains(rawURL, "{")
+}
+
+// ParseTemplate parses a destination with placeholders. Placeholders may only
+// appear in the path, query or fragment, and a name used twice must have the
+// same default each time.
+func ParseTemplate(rawURL string) (*LinkTemplate, error) {
+	tmpl := &LinkTemplate{}
+	index := make(map[string]int)
+	inQuery := false
+	rest := rawURL
+
+	for rest != "" {
+		open := strings.IndexByte(rest, '{')
+		literal := rest
+		if open >= 0 {
+			literal = rest[:open]
+		}
+		if strings.Contains(literal, "}") {
+			return nil, fmt.Errorf("unmatched '}' in template")
+		}
+		if open < 0 {
+			tmpl.parts = append(tmpl.parts, templatePart{literal: literal})
+			break
+		}
+		if literal != "" {
+			tmpl.parts = append(tmpl.parts, templatePart{literal: literal})
+			inQuery = inQuery || strings.ContainsAny(literal, "?#")
+		}
+
+		end := strings.IndexByte(rest[open:], '}')
+		if end < 0 {
+			return nil, fmt.Errorf("unterminated placeholder in template")
+		}
+		name, def, hasDefault := strings.Cut(rest[open+1:open+end], "=")
+		if !templateParamPattern.MatchString(name) {
+			return nil, fmt.Errorf("invalid placeholder name %q: use up to 32 letters, digits or '_'", name)
+		}
+		if strings.ContainsAny(def, "{") {
+			return nil, fmt.Errorf("invalid default for placeholder %q", name)
+		}
+		if !afterHost(rawURL[:len(rawURL)-len(rest)+open]) {
+			return nil, fmt.Errorf("placeholder %q must be in the path, query or fragment", name)
+		}
+
+		param := TemplateParam{Name: name, Default: def, Required: !hasDefault}
+		i, seen := index[name]
+		if !seen {
+			i = len(tmpl.Params)
+			index[name] = i
+			tmpl.Params = append(tmpl.Params, param)
+		} else if tmpl.Params[i] != param {
+			return nil, fmt.Errorf("placeholder %q is declared with different defaults", name)
+		}
+		tmpl.parts = append(tmpl.parts, templatePart{param: i, query: inQuery})
+		rest = rest[open+end+1:]
+	}
+
+	if len(tmpl.Params) == 0 {
+		return nil, fmt.Errorf("template has no placeholders")
+	}
+	return tmpl, nil
+}
+
+// afterHost reports whether prefix, the destination up to a placeholder,
+// already includes the scheme and host
+func afterHost(prefix string) bool {
+	_, afterScheme, ok := strings.Cut(prefix, "://")
+	return ok && strings.ContainsAny(afterScheme, "/?#")
+}
+
+// Sample fills every placeholder with its default, or "x" when it has none,
+// giving a concrete URL that can be validated
+func (t *LinkTemplate) Sample() string {
+	values := make([]string, len(t.Params))
+	for i, param := range t.Params {
+		values[i] = param.Default
+		if param.Required {
+			values[i] = "x"
+		}
+	}
+	return t.render(values)
+}
+
+// Expand fills the placeholders from query parameters. Every required
+// parameter must be given, each parameter at most once, and parameters the
+// template does not declare are rejected.
+func (t *LinkTemplate) Expand(query url.Values) (string, error) {
+	declared := make(map[string]bool, len(t.Params))
+	for _, param := range t.Params {
+		declared[param.Name] = true
+	}
+	for name := range query {
+		if !declared[name] {
+			return "", fmt.Errorf("%w: unknown parameter %q", ErrInvalidTemplateParams, name)
+		}
+	}
+
+	values := make([]string, len(t.Params))
+	for i, param := range t.Params {
+		given, ok := query[param.Name]
+		switch {
+		case !ok && param.Required:
+			return "", fmt.Errorf("%w: missing parameter %q", ErrInvalidTemplateParams, param.Name)
+		case !ok:
+			values[i] = param.Default
+			continue
+		case len(given) > 1:
+			return "", fmt.Errorf("%w: parameter %q given more than once", ErrInvalidTemplateParams, param.Name)
+		case given[0] == "":
+			return "", fmt.Errorf("%w: parameter %q is empty", ErrInvalidTemplateParams, param.Name)
+		case len(given[0]) > MaxTemplateParamLength:
+			return "", fmt.Errorf("%w: parameter %q is longer than %d characters", ErrInvalidTemplateParams, param.Name, MaxTemplateParamLength)
+		}
+		values[i] = given[0]
+	}
+
+	return t.render(values), nil
+}
+
+// render joins the literal parts with escaped placeholder values
+func (t *LinkTemplate) render(values []string) string {
+	var b strings.Builder
+	for _, part := range t.parts {
+		switch {
+		case part.literal != "":
+			b.WriteString(part.literal)
+		case part.query:
+			b.WriteString(url.QueryEscape(values[part.param]))
+		default:
+			b.WriteString(url.PathEscape(values[part.param]))
+		}
+	}
+	return b.String()
+}
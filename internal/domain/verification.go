@@ -0,0 +1,15 @@
+package domain
+
+import "time"
+
+// LinkVerification is the result of the latest check that a link's
+// destination answers: a HEAD (or GET) request following a limited number of
+// redirects, none of which may lead to a private address
+type LinkVerification struct {
+	ShortCode  string    `json:"short_code"`
+	URL        string    `json:"url"` // The destination checked; templates are checked with sample values
+	Reachable  bool      `json:"reachable"`
+	StatusCode int       `json:"status_code,omitempty"` // Status of the last response, when one arrived
+	Error      string    `json:"error,omitempty"`       // Why the destination is unreachable
+	CheckedAt  time.Time `json:"checked_at"`
+}
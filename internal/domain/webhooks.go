@@ -0,0 +1,49 @@
+package domain
+
+import (
+	"time"
+)
+
+// WebhookEndpoint is a URL that receives signed event deliveries
+type WebhookEndpoint struct {
+	ID        int64       `json:"id"`
+	URL       string      `json:"url"`
+	Secret    string      `json:"secret,omitempty"` // Only returned when the endpoint is created
+	Events    []EventType `json:"events"`           // Empty subscribes to every event
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+// Subscribes reports whether the endpoint wants events of type t
+func (e *WebhookEndpoint) Subscribes(t EventType) bool {
+	if len(e.Events) == 0 {
+		return true
+	}
+	for _, subscribed := range e.Events {
+		if subscribed == t {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDelivery records one attempt to deliver an event to an endpoint
+type WebhookDelivery struct {
+	ID          int64     `json:"id"`
+	EndpointID  int64     `json:"endpoint_id,omitempty"` // 0 for endpoints from the config file
+	EndpointURL string    `json:"endpoint_url"`
+	EventID     string    `json:"event_id"`
+	EventType   EventType `json:"event_type"`
+	Attempt     int       `json:"attempt"`
+	StatusCode  int       `json:"status_code,omitempty"`
+	Error       string    `json:"error,omitempty"`
+	Success     bool      `json:"success"`
+	DurationMs  int64     `json:"duration_ms"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// CreateWebhookRequest represents the request to register a webhook endpoint
+type CreateWebhookRequest struct {
+	URL    string      `json:"url"`
+	Secret string      `json:"secret,omitempty"` // Generated when empty
+	Events []EventType `json:"events,omitempty"`
+}
@@ -0,0 +1,111 @@
+// Package drain lets the background jobs that write to the repository on
+// their own be paused while the server is drained for a snapshot.
+package drain
+
+import (
+	"context"
+	"sync"
+)
+
+// Writer is a background job that writes to the repository on its own.
+// Pause writes what the job holds in memory, then stops its writes and waits
+// for those in progress; Resume lets it write again.
+type Writer interface {
+	Pause(ctx context.Context) error
+	Resume()
+}
+
+// Gate holds a job's writes while it is paused. Each write runs between
+// Enter, or TryEnter, and Leave. The zero Gate is open.
+type Gate struct {
+	mu      sync.Mutex
+	resumed chan struct{} // Closed by Resume; nil while the gate is open
+	active  int           // Writes between Enter and Leave
+	idle    chan struct{} // Closed when the last write leaves a paused gate
+}
+
+// Enter starts a write, waiting while the gate is paused. It returns ctx's
+// error if ctx is done first.
+func (g *Gate) Enter(ctx context.Context) error {
+	for {
+		g.mu.Lock()
+		resumed := g.resumed
+		if resumed == nil {
+			g.active++
+			g.mu.Unlock()
+			return nil
+		}
+		g.mu.Unlock()
+
+		select {
+		case <-resumed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// TryEnter starts a write unless the gate is paused, for jobs that skip a
+// run rather than wait
+func (g *Gate) TryEnter() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.resumed != nil {
+		return false
+	}
+	g.active++
+	return true
+}
+
+// Leave ends a write started by Enter or TryEnter
+func (g *Gate) Leave() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.active--
+	if g.active == 0 && g.idle != nil {
+		close(g.idle)
+		g.idle = nil
+	}
+}
+
+// Paused reports whether the gate is paused
+func (g *Gate) Paused() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.resumed != nil
+}
+
+// Pause stops new writes and waits for the writes in progress to leave. It
+// returns ctx's error if ctx is done first; the gate stays paused either way.
+func (g *Gate) Pause(ctx context.Context) error {
+	g.mu.Lock()
+	if g.resumed == nil {
+		g.resumed = make(chan struct{})
+	}
+	if g.active == 0 {
+		g.mu.Unlock()
+		return nil
+	}
+	if g.idle == nil {
+		g.idle = make(chan struct{})
+	}
+	idle := g.idle
+	g.mu.Unlock()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Resume lets writes start again
+func (g *Gate) Resume() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.resumed != nil {
+		close(g.resumed)
+		g.resumed = nil
+	}
+}
@@ -0,0 +1,82 @@
+package drain
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGate_ZeroValueIsOpen(t *testing.T) {
+	var gate Gate
+	assert.False(t, gate.Paused())
+	require.True(t, gate.TryEnter())
+	gate.Leave()
+	require.NoError(t, gate.Enter(context.Background()))
+	gate.Leave()
+}
+
+func TestGate_PauseWaitsForWritesInProgress(t *testing.T) {
+	var gate Gate
+	require.True(t, gate.TryEnter())
+
+	paused := make(chan error, 1)
+	go func() { paused <- gate.Pause(context.Background()) }()
+
+	select {
+	case <-paused:
+		t.Fatal("Pause returned while a write was in progress")
+	case <-time.After(20 * time.Millisecond):
+	}
+	assert.False(t, gate.TryEnter(), "new writes are held while pausing")
+
+	gate.Leave()
+	require.NoError(t, <-paused)
+	assert.True(t, gate.Paused())
+}
+
+func TestGate_PauseGivesUpWithContext(t *testing.T) {
+	var gate Gate
+	require.True(t, gate.TryEnter())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, gate.Pause(ctx), context.DeadlineExceeded)
+	assert.True(t, gate.Paused(), "the gate stays paused")
+	gate.Leave()
+}
+
+func TestGate_EnterWaitsForResume(t *testing.T) {
+	var gate Gate
+	require.NoError(t, gate.Pause(context.Background()))
+	assert.False(t, gate.TryEnter())
+
+	entered := make(chan error, 1)
+	go func() { entered <- gate.Enter(context.Background()) }()
+
+	select {
+	case <-entered:
+		t.Fatal("Enter returned while the gate was paused")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	gate.Resume()
+	require.NoError(t, <-entered)
+	gate.Leave()
+	assert.False(t, gate.Paused())
+}
+
+func TestGate_EnterGivesUpWithContext(t *testing.T) {
+	var gate Gate
+	require.NoError(t, gate.Pause(context.Background()))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, gate.Enter(ctx), context.Canceled)
+
+	// The canceled Enter left nothing behind for the next pause to wait on
+	gate.Resume()
+	require.NoError(t, gate.Pause(context.Background()))
+}
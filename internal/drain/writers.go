@@ -0,0 +1,49 @@
+package drain
+
+import (
+	"context"
+	"sync"
+)
+
+// Writers pauses and resumes a set of writers together, so the writers
+// created after the service can still join its drain. The zero Writers is
+// empty.
+type Writers struct {
+	mu      sync.Mutex
+	writers []Writer
+}
+
+// Add adds writers to the set, normally at startup before any drain. Nil
+// writers are skipped; a typed nil, such as a disabled component's nil
+// pointer, is kept and must be nil-safe.
+func (w *Writers) Add(writers ...Writer) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, writer := range writers {
+		if writer != nil {
+			w.writers = append(w.writers, writer)
+		}
+	}
+}
+
+// Pause pauses every writer in the order added, stopping at the first that
+// fails. Resume resumes them all either way.
+func (w *Writers) Pause(ctx context.Context) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, writer := range w.writers {
+		if err := writer.Pause(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Resume resumes every writer
+func (w *Writers) Resume() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, writer := range w.writers {
+		writer.Resume()
+	}
+}
@@ -0,0 +1,56 @@
+package drain
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeWriter counts its pauses and resumes, failing to pause while err is set
+type fakeWriter struct {
+	err     error
+	paused  int
+	resumed int
+}
+
+func (w *fakeWriter) Pause(ctx context.Context) error {
+	if w.err != nil {
+		return w.err
+	}
+	w.paused++
+	return nil
+}
+
+func (w *fakeWriter) Resume() {
+	w.resumed++
+}
+
+func TestWriters_PauseAndResume(t *testing.T) {
+	first, second := &fakeWriter{}, &fakeWriter{}
+	var writers Writers
+	writers.Add(first, nil)
+	writers.Add(second)
+
+	assert.NoError(t, writers.Pause(context.Background()))
+	writers.Resume()
+	assert.Equal(t, 1, first.paused)
+	assert.Equal(t, 1, second.paused)
+	assert.Equal(t, 1, first.resumed)
+	assert.Equal(t, 1, second.resumed)
+}
+
+func TestWriters_PauseStopsAtAFailure(t *testing.T) {
+	failing, next := &fakeWriter{err: errors.New("database is locked")}, &fakeWriter{}
+	var writers Writers
+	writers.Add(failing, next)
+
+	assert.ErrorContains(t, writers.Pause(context.Background()), "database is locked")
+	assert.Zero(t, next.paused)
+
+	// Every writer is resumed, paused or not
+	writers.Resume()
+	assert.Equal(t, 1, failing.resumed)
+	assert.Equal(t, 1, next.resumed)
+}
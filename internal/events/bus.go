@@ -0,0 +1,233 @@
+// Package events is the in-process publish/subscribe bus for link lifecycle
+// and click events. The service publishes every event to the bus; webhooks,
+// link previews, click analytics, live event streams and metrics subscribe to
+// it, and a Broker can carry the events to other systems as well.
+package events
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+)
+
+// Subscriber receives events from the bus. Notify must not block.
+type Subscriber interface {
+	Notify(event domain.Event)
+}
+
+// Broker publishes events to an external message broker. Publish may block
+// until the broker accepts the event; the bus calls it from its own goroutine.
+type Broker interface {
+	Publish(ctx context.Context, event domain.Event) error
+	Close() error
+}
+
+// NewBroker creates the broker named in config, or returns nil when events
+// stay in the process
+func NewBroker(config Config) (Broker, error) {
+	switch config.Broker {
+	case "", BrokerNone:
+		return nil, nil
+	case BrokerNATS:
+		return NewNATSBroker(config.NATSURL, config.NATSSubject, config.Timeout)
+	default:
+		return nil, fmt.Errorf("unknown event broker %q", config.Broker)
+	}
+}
+
+// subscription is a subscriber and the event types it wants
+type subscription struct {
+	subscriber Subscriber
+	types      map[domain.EventType]bool // nil wants every type
+}
+
+// wants reports whether the subscriber takes events of the given type
+func (s subscription) wants(eventType domain.EventType) bool {
+	return s.types == nil || s.types[eventType]
+}
+
+// Bus fans published events out to its subscribers, in the order they
+// subscribed, and queues them for the broker if there is one. Publishing never
+// blocks: events are dropped when the broker queue is full.
+type Bus struct {
+	config Config
+	broker Broker
+
+	mu          sync.RWMutex
+	subscribers []subscription
+	started     bool
+	closed      bool
+	queue       chan domain.Event
+	stopChan    chan struct{}
+	wg          sync.WaitGroup
+
+	published     map[domain.EventType]*atomic.Int64 // Fixed at creation, so read without the lock
+	brokerDropped atomic.Int64
+	brokerFailed  atomic.Int64
+	failing       atomic.Bool // Whether the last broker publish failed
+}
+
+// New creates a bus. broker may be nil to keep events in the process.
+func New(config Config, broker Broker) *Bus {
+	b := &Bus{
+		config:    config,
+		broker:    broker,
+		stopChan:  make(chan struct{}),
+		published: make(map[domain.EventType]*atomic.Int64, len(domain.EventTypes)),
+	}
+	if broker != nil {
+		b.queue = make(chan domain.Event, config.QueueSize)
+	}
+	for _, eventType := range domain.EventTypes {
+		b.published[eventType] = &atomic.Int64{}
+	}
+	return b
+}
+
+// Subscribe passes events of the given types, or of every type when none are
+// given, to subscriber
+func (b *Bus) Subscribe(subscriber Subscriber, types ...domain.EventType) {
+	sub := subscription{subscriber: subscriber}
+	if len(types) > 0 {
+		sub.types = make(map[domain.EventType]bool, len(types))
+		for _, eventType := range types {
+			sub.types[eventType] = true
+		}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subscribers = append(b.subscribers, sub)
+}
+
+// Notify publishes event to the subscribers that want it and queues it for
+// the broker. It makes the bus the service's Notifier.
+func (b *Bus) Notify(event domain.Event) {
+	if counter, ok := b.published[event.Type]; ok {
+		counter.Add(1)
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for _, sub := range b.subscribers {
+		if sub.wants(event.Type) {
+			sub.subscriber.Notify(event)
+		}
+	}
+
+	if b.queue == nil || b.closed {
+		return
+	}
+	select {
+	case b.queue <- event:
+	default:
+		b.brokerDropped.Add(1)
+	}
+}
+
+// Start starts publishing queued events to the broker
+func (b *Bus) Start(ctx context.Context) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.started {
+		return fmt.Errorf("event bus already started")
+	}
+	b.started = true
+
+	if b.broker != nil {
+		b.wg.Add(1)
+		go b.forward()
+	}
+	return nil
+}
+
+// Close stops queueing events for the broker, publishes the ones already
+// queued and closes the broker. Subscribers keep receiving events.
+func (b *Bus) Close() error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return nil
+	}
+	b.closed = true
+	close(b.stopChan)
+	b.mu.Unlock()
+
+	b.wg.Wait()
+
+	if b.broker == nil {
+		return nil
+	}
+	if err := b.broker.Close(); err != nil {
+		return fmt.Errorf("failed to close event broker: %w", err)
+	}
+	return nil
+}
+
+// forward publishes queued events to the broker until the bus is closed
+func (b *Bus) forward() {
+	defer b.wg.Done()
+
+	for {
+		select {
+		case event := <-b.queue:
+			b.publish(event)
+		case <-b.stopChan:
+			for {
+				select {
+				case event := <-b.queue:
+					b.publish(event)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// publish sends one event to the broker, counting failures
+func (b *Bus) publish(event domain.Event) {
+	ctx, cancel := context.WithTimeout(context.Background(), b.config.Timeout)
+	defer cancel()
+
+	// Only changes are logged, so a broker that is down does not flood the log
+	if err := b.broker.Publish(ctx, event); err != nil {
+		b.brokerFailed.Add(1)
+		if !b.failing.Swap(true) {
+			log.Printf("Failed to publish event %s to the broker, dropping events until it recovers: %v", event.ID, err)
+		}
+		return
+	}
+	if b.failing.Swap(false) {
+		log.Printf("Publishing events to the broker again")
+	}
+}
+
+// Published returns the number of events of the given type published
+func (b *Bus) Published(eventType domain.EventType) int64 {
+	if counter, ok := b.published[eventType]; ok {
+		return counter.Load()
+	}
+	return 0
+}
+
+// Brokered reports whether events are also published to a broker
+func (b *Bus) Brokered() bool {
+	return b.broker != nil
+}
+
+// BrokerDropped returns the events dropped because the broker queue was full
+func (b *Bus) BrokerDropped() int64 {
+	return b.brokerDropped.Load()
+}
+
+// BrokerFailed returns the events the broker failed to publish
+func (b *Bus) BrokerFailed() int64 {
+	return b.brokerFailed.Load()
+}
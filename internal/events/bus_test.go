@@ -0,0 +1,193 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+)
+
+// recordingSubscriber keeps the types of the events it receives
+type recordingSubscriber struct {
+	events []domain.EventType
+}
+
+func (s *recordingSubscriber) Notify(event domain.Event) {
+	s.events = append(s.events, event.Type)
+}
+
+// fakeBroker keeps published events and can be made to fail or wait
+type fakeBroker struct {
+	mu     sync.Mutex
+	events []string
+	err    error
+	block  chan struct{}
+	closed bool
+}
+
+func (b *fakeBroker) Publish(ctx context.Context, event domain.Event) error {
+	if b.block != nil {
+		<-b.block
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.err != nil {
+		return b.err
+	}
+	b.events = append(b.events, event.ID)
+	return nil
+}
+
+func (b *fakeBroker) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	return nil
+}
+
+func (b *fakeBroker) published() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]string(nil), b.events...)
+}
+
+func TestBus_Subscribers(t *testing.T) {
+	bus := New(DefaultConfig(), nil)
+	all, created := &recordingSubscriber{}, &recordingSubscriber{}
+	bus.Subscribe(all)
+	bus.Subscribe(created, domain.EventURLCreated)
+
+	bus.Notify(domain.NewEvent(domain.EventURLCreated, domain.EventData{ShortCode: "abc123"}))
+	bus.Notify(domain.NewEvent(domain.EventURLClicked, domain.EventData{ShortCode: "abc123"}))
+	bus.Notify(domain.NewEvent(domain.EventURLClicked, domain.EventData{ShortCode: "abc123"}))
+
+	assert.Equal(t, []domain.EventType{domain.EventURLCreated, domain.EventURLClicked, domain.EventURLClicked}, all.events)
+	assert.Equal(t, []domain.EventType{domain.EventURLCreated}, created.events)
+	assert.Equal(t, int64(1), bus.Published(domain.EventURLCreated))
+	assert.Equal(t, int64(2), bus.Published(domain.EventURLClicked))
+	assert.Zero(t, bus.Published("url.renamed"))
+
+	// Without a broker there is nothing to start or close
+	assert.False(t, bus.Brokered())
+	require.NoError(t, bus.Start(context.Background()))
+	require.NoError(t, bus.Close())
+}
+
+func TestBus_Broker(t *testing.T) {
+	broker := &fakeBroker{}
+	bus := New(DefaultConfig(), broker)
+	assert.True(t, bus.Brokered())
+	require.NoError(t, bus.Start(context.Background()))
+	assert.Error(t, bus.Start(context.Background()))
+
+	first := domain.NewEvent(domain.EventURLCreated, domain.EventData{ShortCode: "abc123"})
+	second := domain.NewEvent(domain.EventURLDeleted, domain.EventData{ShortCode: "abc123"})
+	bus.Notify(first)
+	bus.Notify(second)
+
+	// Close publishes what is queued, then closes the broker
+	require.NoError(t, bus.Close())
+	require.NoError(t, bus.Close())
+	assert.Equal(t, []string{first.ID, second.ID}, broker.published())
+	assert.True(t, broker.closed)
+
+	// Subscribers still receive events after the broker is closed
+	subscriber := &recordingSubscriber{}
+	bus.Subscribe(subscriber)
+	bus.Notify(domain.NewEvent(domain.EventURLCreated, domain.EventData{}))
+	assert.Len(t, subscriber.events, 1)
+	assert.Len(t, broker.published(), 2)
+}
+
+func TestBus_BrokerBackpressure(t *testing.T) {
+	broker := &fakeBroker{block: make(chan struct{})}
+	config := DefaultConfig()
+	config.QueueSize = 1
+	bus := New(config, broker)
+	require.NoError(t, bus.Start(context.Background()))
+
+	// The first event is taken by the blocked broker, the second fills the
+	// queue and the rest are dropped without waiting
+	bus.Notify(domain.NewEvent(domain.EventURLClicked, domain.EventData{}))
+	require.Eventually(t, func() bool { return len(bus.queue) == 0 }, time.Second, time.Millisecond)
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 3; i++ {
+			bus.Notify(domain.NewEvent(domain.EventURLClicked, domain.EventData{}))
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("publishing blocked on the broker")
+	}
+	assert.Equal(t, int64(2), bus.BrokerDropped())
+
+	close(broker.block)
+	require.NoError(t, bus.Close())
+	assert.Len(t, broker.published(), 2)
+}
+
+func TestBus_BrokerFailures(t *testing.T) {
+	broker := &fakeBroker{err: errors.New("connection refused")}
+	bus := New(DefaultConfig(), broker)
+	require.NoError(t, bus.Start(context.Background()))
+
+	bus.Notify(domain.NewEvent(domain.EventURLCreated, domain.EventData{}))
+	bus.Notify(domain.NewEvent(domain.EventURLCreated, domain.EventData{}))
+	require.NoError(t, bus.Close())
+	assert.Equal(t, int64(2), bus.BrokerFailed())
+}
+
+func TestNewBroker(t *testing.T) {
+	broker, err := NewBroker(DefaultConfig())
+	require.NoError(t, err)
+	assert.Nil(t, broker)
+
+	config := DefaultConfig()
+	config.Broker = BrokerNATS
+	broker, err = NewBroker(config)
+	require.NoError(t, err)
+	assert.IsType(t, &NATSBroker{}, broker)
+
+	config.Broker = "kafka"
+	_, err = NewBroker(config)
+	assert.Error(t, err)
+}
+
+func TestConfig_Validate(t *testing.T) {
+	assert.NoError(t, DefaultConfig().Validate())
+	assert.NoError(t, Config{}.Validate(), "no broker needs no settings")
+
+	nats := DefaultConfig()
+	nats.Broker = BrokerNATS
+	assert.NoError(t, nats.Validate())
+
+	tests := []struct {
+		name   string
+		modify func(*Config)
+	}{
+		{"unknown broker", func(c *Config) { c.Broker = "kafka" }},
+		{"http url", func(c *Config) { c.NATSURL = "http://127.0.0.1:4222" }},
+		{"url without host", func(c *Config) { c.NATSURL = "nats://" }},
+		{"empty subject", func(c *Config) { c.NATSSubject = "" }},
+		{"wildcard subject", func(c *Config) { c.NATSSubject = "links.*" }},
+		{"empty subject token", func(c *Config) { c.NATSSubject = "links..events" }},
+		{"zero queue", func(c *Config) { c.QueueSize = 0 }},
+		{"zero timeout", func(c *Config) { c.Timeout = 0 }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := nats
+			tt.modify(&config)
+			assert.Error(t, config.Validate())
+		})
+	}
+}
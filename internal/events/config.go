@@ -0,0 +1,84 @@
+package events
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Broker names
+const (
+	BrokerNone = "none" // Events stay in the process
+	BrokerNATS = "nats" // Events are also published to a NATS server
+)
+
+// Config holds event bus configuration
+type Config struct {
+	Broker      string        // External broker events are also published to: none or nats
+	NATSURL     string        // NATS server, nats://[user:password@]host:port
+	NATSSubject string        // Subject prefix; each event is published to <prefix>.<event type>
+	QueueSize   int           // Events buffered for the broker before new ones are dropped
+	Timeout     time.Duration // Timeout for connecting to the broker and for each publish
+}
+
+// DefaultConfig returns the default configuration
+func DefaultConfig() Config {
+	return Config{
+		Broker:      BrokerNone,
+		NATSURL:     "nats://127.0.0.1:4222",
+		NATSSubject: "url_shortener",
+		QueueSize:   1000,
+		Timeout:     5 * time.Second,
+	}
+}
+
+// Validate checks that the configuration values are usable
+func (c Config) Validate() error {
+	switch c.Broker {
+	case "", BrokerNone:
+		return nil
+	case BrokerNATS:
+		if _, err := parseNATSURL(c.NATSURL); err != nil {
+			return err
+		}
+		if err := validateSubject(c.NATSSubject); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("broker must be %s or %s, got: %q", BrokerNone, BrokerNATS, c.Broker)
+	}
+	if c.QueueSize < 1 {
+		return fmt.Errorf("broker queue size must be at least 1, got: %d", c.QueueSize)
+	}
+	if c.Timeout <= 0 {
+		return fmt.Errorf("broker timeout must be positive, got: %v", c.Timeout)
+	}
+	return nil
+}
+
+// parseNATSURL checks a nats:// server URL
+func parseNATSURL(raw string) (*url.URL, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid NATS URL: %w", err)
+	}
+	if u.Scheme != "nats" || u.Host == "" {
+		return nil, fmt.Errorf("NATS URL must be nats://host:port, got: %q", raw)
+	}
+	return u, nil
+}
+
+// validateSubject checks a NATS subject prefix: dot-separated tokens without
+// whitespace or wildcards
+func validateSubject(subject string) error {
+	if subject == "" {
+		return fmt.Errorf("NATS subject cannot be empty")
+	}
+	for _, token := range strings.Split(subject, ".") {
+		if token == "" || strings.ContainsAny(token, " \t\r\n*>") {
+			return fmt.Errorf("NATS subject must be dot-separated tokens without spaces or wildcards, got: %q", subject)
+		}
+	}
+	return nil
+}
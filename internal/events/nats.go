@@ -0,0 +1,321 @@
+package events
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	"github.com/joshdurbin/url-shortener/internal/version"
+)
+
+// NATSConn is a publish-only connection to a NATS server using the core text
+// protocol. It connects on the first publish and again after a failure; TLS is
+// not supported.
+type NATSConn struct {
+	address  string
+	user     string
+	password string
+	timeout  time.Duration
+
+	mu     sync.Mutex
+	conn   net.Conn
+	writer *bufio.Writer
+	pongs  chan struct{} // Answers to Flush's PINGs
+	closed bool
+}
+
+// natsInfo is the part of the server's INFO message the connection reads
+type natsInfo struct {
+	TLSRequired bool `json:"tls_required"`
+}
+
+// natsConnect is the CONNECT message sent after INFO
+type natsConnect struct {
+	Verbose  bool   `json:"verbose"`
+	Pedantic bool   `json:"pedantic"`
+	Name     string `json:"name"`
+	Lang     string `json:"lang"`
+	Version  string `json:"version"`
+	User     string `json:"user,omitempty"`
+	Password string `json:"pass,omitempty"`
+	Token    string `json:"auth_token,omitempty"`
+}
+
+// NewNATSConn creates a connection to a nats://[user:password@]host:port
+// server. A user without a password is sent as a token.
+func NewNATSConn(serverURL string, timeout time.Duration) (*NATSConn, error) {
+	u, err := parseNATSURL(serverURL)
+	if err != nil {
+		return nil, err
+	}
+
+	n := &NATSConn{address: u.Host, timeout: timeout}
+	if u.Port() == "" {
+		n.address = net.JoinHostPort(u.Hostname(), "4222")
+	}
+	if u.User != nil {
+		n.user = u.User.Username()
+		n.password, _ = u.User.Password()
+	}
+	return n, nil
+}
+
+// Publish writes a message to subject, connecting first if needed. The
+// server has not necessarily received it; Flush waits until it has.
+func (n *NATSConn) Publish(ctx context.Context, subject string, payload []byte) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if err := n.ensureConnected(ctx); err != nil {
+		return err
+	}
+
+	n.conn.SetWriteDeadline(n.deadline(ctx))
+	fmt.Fprintf(n.writer, "PUB %s %d\r\n", subject, len(payload))
+	n.writer.Write(payload)
+	n.writer.WriteString("\r\n")
+	if err := n.writer.Flush(); err != nil {
+		n.disconnect()
+		return fmt.Errorf("failed to publish to NATS at %s: %w", n.address, err)
+	}
+	return nil
+}
+
+// Flush waits until the server has processed every message published so far,
+// by sending a PING and waiting for its PONG
+func (n *NATSConn) Flush(ctx context.Context) error {
+	n.mu.Lock()
+	if err := n.ensureConnected(ctx); err != nil {
+		n.mu.Unlock()
+		return err
+	}
+	conn, pongs := n.conn, n.pongs
+	conn.SetWriteDeadline(n.deadline(ctx))
+	n.writer.WriteString("PING\r\n")
+	if err := n.writer.Flush(); err != nil {
+		n.disconnect()
+		n.mu.Unlock()
+		return fmt.Errorf("failed to flush NATS at %s: %w", n.address, err)
+	}
+	n.mu.Unlock()
+
+	timer := time.NewTimer(time.Until(n.deadline(ctx)))
+	defer timer.Stop()
+	select {
+	case _, ok := <-pongs:
+		if !ok {
+			return fmt.Errorf("NATS connection to %s closed before the flush completed", n.address)
+		}
+		return nil
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+
+	// Without an answer the connection's state is unknown, so start over
+	n.mu.Lock()
+	if n.conn == conn {
+		n.disconnect()
+	}
+	n.mu.Unlock()
+	return fmt.Errorf("timed out flushing NATS at %s", n.address)
+}
+
+// Close closes the connection to the server
+func (n *NATSConn) Close() error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.closed = true
+	n.disconnect()
+	return nil
+}
+
+// ensureConnected connects unless already connected. The caller holds n.mu.
+func (n *NATSConn) ensureConnected(ctx context.Context) error {
+	if n.closed {
+		return fmt.Errorf("NATS connection closed")
+	}
+	if n.conn != nil {
+		return nil
+	}
+	return n.connect(ctx)
+}
+
+// connect dials the server and completes the handshake: INFO from the server,
+// then CONNECT and a PING whose PONG confirms the server accepted it. The
+// caller holds n.mu.
+func (n *NATSConn) connect(ctx context.Context) error {
+	dialer := net.Dialer{Timeout: n.timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", n.address)
+	if err != nil {
+		return fmt.Errorf("failed to connect to NATS at %s: %w", n.address, err)
+	}
+	conn.SetDeadline(n.deadline(ctx))
+	reader := bufio.NewReader(conn)
+
+	fail := func(err error) error {
+		conn.Close()
+		return fmt.Errorf("failed to connect to NATS at %s: %w", n.address, err)
+	}
+
+	line, err := readNATSLine(reader)
+	if err != nil {
+		return fail(err)
+	}
+	infoJSON, ok := strings.CutPrefix(line, "INFO ")
+	if !ok {
+		return fail(fmt.Errorf("expected INFO, got %q", line))
+	}
+	var info natsInfo
+	if err := json.Unmarshal([]byte(infoJSON), &info); err != nil {
+		return fail(fmt.Errorf("invalid INFO: %w", err))
+	}
+	if info.TLSRequired {
+		return fail(fmt.Errorf("the server requires TLS, which is not supported"))
+	}
+
+	connect := natsConnect{Name: "url-shortener", Lang: "go", Version: version.Version}
+	if n.password != "" {
+		connect.User, connect.Password = n.user, n.password
+	} else {
+		connect.Token = n.user
+	}
+	connectJSON, _ := json.Marshal(connect)
+	if _, err := fmt.Fprintf(conn, "CONNECT %s\r\nPING\r\n", connectJSON); err != nil {
+		return fail(err)
+	}
+
+	for {
+		line, err := readNATSLine(reader)
+		if err != nil {
+			return fail(err)
+		}
+		if line == "PONG" {
+			break
+		}
+		if strings.HasPrefix(line, "-ERR") {
+			return fail(fmt.Errorf("server refused the connection: %s", line))
+		}
+		// Anything else, such as +OK or a repeated INFO, is skipped
+	}
+
+	conn.SetDeadline(time.Time{})
+	n.conn = conn
+	n.writer = bufio.NewWriter(conn)
+	n.pongs = make(chan struct{}, 16)
+	go n.read(conn, reader, n.pongs)
+	return nil
+}
+
+// read answers the server's PINGs, which keep the connection open, passes
+// on its PONGs and logs its errors until the connection closes
+func (n *NATSConn) read(conn net.Conn, reader *bufio.Reader, pongs chan struct{}) {
+	defer close(pongs)
+	for {
+		line, err := readNATSLine(reader)
+		if err != nil {
+			n.mu.Lock()
+			if n.conn == conn {
+				n.disconnect()
+			}
+			n.mu.Unlock()
+			return
+		}
+
+		switch {
+		case line == "PING":
+			n.mu.Lock()
+			if n.conn == conn {
+				conn.SetWriteDeadline(time.Now().Add(n.timeout))
+				n.writer.WriteString("PONG\r\n")
+				n.writer.Flush()
+			}
+			n.mu.Unlock()
+		case line == "PONG":
+			select {
+			case pongs <- struct{}{}:
+			default:
+			}
+		case strings.HasPrefix(line, "-ERR"):
+			log.Printf("NATS server at %s reported an error: %s", n.address, line)
+		}
+	}
+}
+
+// disconnect closes the connection, so the next publish reconnects. The
+// caller holds n.mu.
+func (n *NATSConn) disconnect() {
+	if n.conn != nil {
+		n.conn.Close()
+		n.conn, n.writer, n.pongs = nil, nil, nil
+	}
+}
+
+// deadline returns ctx's deadline, or the connection timeout from now
+func (n *NATSConn) deadline(ctx context.Context) time.Time {
+	if deadline, ok := ctx.Deadline(); ok {
+		return deadline
+	}
+	return time.Now().Add(n.timeout)
+}
+
+// readNATSLine reads one protocol line without its CRLF
+func readNATSLine(reader *bufio.Reader) (string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// NATSBroker publishes each event as a JSON message on <subject>.<event type>,
+// for example url_shortener.url.clicked
+type NATSBroker struct {
+	conn    *NATSConn
+	subject string
+}
+
+// NewNATSBroker creates a broker for a nats://[user:password@]host:port server
+func NewNATSBroker(serverURL, subject string, timeout time.Duration) (*NATSBroker, error) {
+	conn, err := NewNATSConn(serverURL, timeout)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateSubject(subject); err != nil {
+		return nil, err
+	}
+	return &NATSBroker{conn: conn, subject: subject}, nil
+}
+
+// Publish sends event to the server
+func (b *NATSBroker) Publish(ctx context.Context, event domain.Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event %s: %w", event.ID, err)
+	}
+	return b.conn.Publish(ctx, b.subject+"."+string(event.Type), payload)
+}
+
+// Close closes the connection to the server
+func (b *NATSBroker) Close() error {
+	return b.conn.Close()
+}
+
+// ValidateNATSSubject checks a NATS subject: dot-separated tokens without
+// whitespace or wildcards
+func ValidateNATSSubject(subject string) error {
+	return validateSubject(subject)
+}
+
+// ValidateNATSURL checks a nats://[user:password@]host:port server URL
+func ValidateNATSURL(serverURL string) error {
+	_, err := parseNATSURL(serverURL)
+	return err
+}
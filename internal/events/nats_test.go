@@ -0,0 +1,218 @@
+package events
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+)
+
+// natsMessage is a message received by fakeNATS
+type natsMessage struct {
+	subject string
+	payload []byte
+}
+
+// fakeNATS is a NATS server that accepts connections, answers PINGs and
+// collects published messages
+type fakeNATS struct {
+	listener net.Listener
+	info     string
+	connects chan string
+	messages chan natsMessage
+}
+
+func newFakeNATS(t *testing.T, info string) *fakeNATS {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	server := &fakeNATS{
+		listener: listener,
+		info:     info,
+		connects: make(chan string, 10),
+		messages: make(chan natsMessage, 10),
+	}
+	go server.serve()
+	return server
+}
+
+func (s *fakeNATS) url() string {
+	return "nats://" + s.listener.Addr().String()
+}
+
+func (s *fakeNATS) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *fakeNATS) handle(conn net.Conn) {
+	defer conn.Close()
+	fmt.Fprintf(conn, "INFO %s\r\n", s.info)
+
+	reader := bufio.NewReader(conn)
+	for {
+		line, err := readNATSLine(reader)
+		if err != nil {
+			return
+		}
+		switch {
+		case strings.HasPrefix(line, "CONNECT "):
+			s.connects <- strings.TrimPrefix(line, "CONNECT ")
+		case line == "PING":
+			fmt.Fprint(conn, "PONG\r\n")
+		case strings.HasPrefix(line, "PUB "):
+			fields := strings.Fields(line)
+			size, _ := strconv.Atoi(fields[len(fields)-1])
+			payload := make([]byte, size+2)
+			if _, err := io.ReadFull(reader, payload); err != nil {
+				return
+			}
+			s.messages <- natsMessage{subject: fields[1], payload: payload[:size]}
+		}
+	}
+}
+
+func TestNATSBroker_Publish(t *testing.T) {
+	server := newFakeNATS(t, `{"server_id":"test","max_payload":1048576}`)
+	u := strings.Replace(server.url(), "nats://", "nats://links:s3cret@", 1)
+
+	broker, err := NewNATSBroker(u, "url_shortener", time.Second)
+	require.NoError(t, err)
+	defer broker.Close()
+
+	event := domain.NewEvent(domain.EventURLClicked, domain.EventData{ShortCode: "abc123", Campaign: "spring"})
+	event.Click = &domain.Click{ShortCode: "abc123", Referrer: "twitter.com"}
+	require.NoError(t, broker.Publish(context.Background(), event))
+
+	var connect natsConnect
+	require.NoError(t, json.Unmarshal([]byte(<-server.connects), &connect))
+	assert.Equal(t, "links", connect.User)
+	assert.Equal(t, "s3cret", connect.Password)
+	assert.Equal(t, "url-shortener", connect.Name)
+
+	message := <-server.messages
+	assert.Equal(t, "url_shortener.url.clicked", message.subject)
+	var published domain.Event
+	require.NoError(t, json.Unmarshal(message.payload, &published))
+	assert.Equal(t, event.ID, published.ID)
+	assert.Equal(t, "spring", published.Data.Campaign)
+	assert.NotContains(t, string(message.payload), "twitter.com", "clicks stay in the process")
+
+	// The connection is reused
+	require.NoError(t, broker.Publish(context.Background(), domain.NewEvent(domain.EventURLDeleted, domain.EventData{ShortCode: "abc123"})))
+	assert.Equal(t, "url_shortener.url.deleted", (<-server.messages).subject)
+	assert.Empty(t, server.connects)
+
+	require.NoError(t, broker.Close())
+	assert.Error(t, broker.Publish(context.Background(), event))
+}
+
+func TestNATSConn_Flush(t *testing.T) {
+	server := newFakeNATS(t, `{}`)
+	conn, err := NewNATSConn(server.url(), time.Second)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	// Flush connects if needed and returns once the server answered
+	require.NoError(t, conn.Flush(context.Background()))
+	require.NoError(t, conn.Publish(context.Background(), "links.export", []byte(`{"id":"evt_1"}`)))
+	require.NoError(t, conn.Flush(context.Background()))
+	message := <-server.messages
+	assert.Equal(t, "links.export", message.subject)
+	assert.Equal(t, `{"id":"evt_1"}`, string(message.payload))
+	assert.Len(t, server.connects, 1)
+
+	// A server that never answers times the flush out and drops the connection
+	silent, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer silent.Close()
+	go func() {
+		c, err := silent.Accept()
+		if err != nil {
+			return
+		}
+		defer c.Close()
+		fmt.Fprint(c, "INFO {}\r\n")
+		reader := bufio.NewReader(c)
+		readNATSLine(reader) // CONNECT
+		readNATSLine(reader) // PING
+		fmt.Fprint(c, "PONG\r\n")
+		io.Copy(io.Discard, reader)
+	}()
+	conn, err = NewNATSConn("nats://"+silent.Addr().String(), 100*time.Millisecond)
+	require.NoError(t, err)
+	defer conn.Close()
+	assert.ErrorContains(t, conn.Flush(context.Background()), "timed out")
+	assert.Nil(t, conn.conn)
+}
+
+func TestNATSBroker_Reconnects(t *testing.T) {
+	server := newFakeNATS(t, `{}`)
+	broker, err := NewNATSBroker(server.url()+"?ignored=1", "links", time.Second)
+	require.NoError(t, err)
+	defer broker.Close()
+
+	require.NoError(t, broker.Publish(context.Background(), domain.NewEvent(domain.EventURLCreated, domain.EventData{})))
+	<-server.messages
+	<-server.connects
+
+	// Drop the connection from the broker's side; the next publish reconnects
+	broker.conn.mu.Lock()
+	broker.conn.disconnect()
+	broker.conn.mu.Unlock()
+	require.NoError(t, broker.Publish(context.Background(), domain.NewEvent(domain.EventURLCreated, domain.EventData{})))
+	<-server.messages
+	<-server.connects
+}
+
+func TestNATSBroker_ConnectErrors(t *testing.T) {
+	t.Run("tls required", func(t *testing.T) {
+		server := newFakeNATS(t, `{"tls_required":true}`)
+		broker, err := NewNATSBroker(server.url(), "links", time.Second)
+		require.NoError(t, err)
+		err = broker.Publish(context.Background(), domain.NewEvent(domain.EventURLCreated, domain.EventData{}))
+		assert.ErrorContains(t, err, "requires TLS")
+	})
+
+	t.Run("unreachable", func(t *testing.T) {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		address := listener.Addr().String()
+		listener.Close()
+
+		broker, err := NewNATSBroker("nats://"+address, "links", time.Second)
+		require.NoError(t, err)
+		err = broker.Publish(context.Background(), domain.NewEvent(domain.EventURLCreated, domain.EventData{}))
+		assert.ErrorContains(t, err, "failed to connect to NATS")
+	})
+
+	t.Run("invalid settings", func(t *testing.T) {
+		_, err := NewNATSBroker("tcp://127.0.0.1:4222", "links", time.Second)
+		assert.Error(t, err)
+		_, err = NewNATSBroker("nats://127.0.0.1:4222", "links.>", time.Second)
+		assert.Error(t, err)
+	})
+
+	t.Run("default port", func(t *testing.T) {
+		broker, err := NewNATSBroker("nats://nats.internal", "links", time.Second)
+		require.NoError(t, err)
+		assert.Equal(t, "nats.internal:4222", broker.conn.address)
+	})
+}
@@ -0,0 +1,115 @@
+package export
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	"github.com/joshdurbin/url-shortener/internal/events"
+)
+
+// Sink names
+const (
+	SinkNone  = "none"  // Events are not exported
+	SinkKafka = "kafka" // Events are produced to a Kafka topic
+	SinkNATS  = "nats"  // Events are published to a NATS subject
+)
+
+// maxTopicLength is the longest topic name Kafka accepts
+const maxTopicLength = 249
+
+// Config holds event export configuration
+type Config struct {
+	Sink          string             // Where events are exported: none, kafka or nats
+	KafkaBrokers  []string           // Bootstrap brokers, host:port
+	KafkaTopic    string             // Topic events are produced to, keyed by short code
+	NATSURL       string             // NATS server, nats://[user:password@]host:port
+	NATSSubject   string             // Subject prefix; each event is published to <prefix>.<event type>
+	Events        []domain.EventType // Event types exported
+	BatchSize     int                // Events sent to the sink at once
+	FlushInterval time.Duration      // How often pending events are sent when a batch is not full
+	MaxPending    int                // Events held in memory between flushes before new ones are dropped
+	Timeout       time.Duration      // Timeout for connecting to the sink and for each batch
+}
+
+// DefaultConfig returns the default configuration
+func DefaultConfig() Config {
+	return Config{
+		Sink:          SinkNone,
+		KafkaBrokers:  []string{"127.0.0.1:9092"},
+		KafkaTopic:    "url-shortener-events",
+		NATSURL:       "nats://127.0.0.1:4222",
+		NATSSubject:   "url_shortener.export",
+		Events:        []domain.EventType{domain.EventURLCreated, domain.EventURLClicked},
+		BatchSize:     500,
+		FlushInterval: time.Second,
+		MaxPending:    10000,
+		Timeout:       10 * time.Second,
+	}
+}
+
+// Validate checks that the configuration values are usable
+func (c Config) Validate() error {
+	switch c.Sink {
+	case "", SinkNone:
+		return nil
+	case SinkKafka:
+		if len(c.KafkaBrokers) == 0 {
+			return fmt.Errorf("at least one Kafka broker is required")
+		}
+		for _, broker := range c.KafkaBrokers {
+			if _, _, err := net.SplitHostPort(broker); err != nil {
+				return fmt.Errorf("Kafka broker must be host:port, got: %q", broker)
+			}
+		}
+		if err := validateTopic(c.KafkaTopic); err != nil {
+			return err
+		}
+	case SinkNATS:
+		if err := events.ValidateNATSURL(c.NATSURL); err != nil {
+			return err
+		}
+		if err := events.ValidateNATSSubject(c.NATSSubject); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("export sink must be %s, %s or %s, got: %q", SinkNone, SinkKafka, SinkNATS, c.Sink)
+	}
+	if len(c.Events) == 0 {
+		return fmt.Errorf("at least one event type must be exported")
+	}
+	for _, eventType := range c.Events {
+		if !eventType.Valid() {
+			return fmt.Errorf("unknown event type %q", eventType)
+		}
+	}
+	if c.BatchSize < 1 {
+		return fmt.Errorf("export batch size must be at least 1, got: %d", c.BatchSize)
+	}
+	if c.FlushInterval <= 0 {
+		return fmt.Errorf("export flush interval must be positive, got: %v", c.FlushInterval)
+	}
+	if c.MaxPending < c.BatchSize {
+		return fmt.Errorf("export max pending (%d) must be at least the batch size (%d)", c.MaxPending, c.BatchSize)
+	}
+	if c.Timeout <= 0 {
+		return fmt.Errorf("export timeout must be positive, got: %v", c.Timeout)
+	}
+	return nil
+}
+
+// validateTopic checks a Kafka topic name: up to 249 ASCII letters, digits,
+// dots, underscores and hyphens
+func validateTopic(topic string) error {
+	if topic == "" || topic == "." || topic == ".." || len(topic) > maxTopicLength {
+		return fmt.Errorf("invalid Kafka topic %q", topic)
+	}
+	if strings.IndexFunc(topic, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '.' || r == '_' || r == '-')
+	}) >= 0 {
+		return fmt.Errorf("Kafka topic may only contain letters, digits, '.', '_' and '-', got: %q", topic)
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package export
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+)
+
+func TestConfig_Validate(t *testing.T) {
+	assert.NoError(t, DefaultConfig().Validate())
+	assert.NoError(t, Config{}.Validate(), "no sink needs no settings")
+
+	kafka := DefaultConfig()
+	kafka.Sink = SinkKafka
+	assert.NoError(t, kafka.Validate())
+	nats := DefaultConfig()
+	nats.Sink = SinkNATS
+	assert.NoError(t, nats.Validate())
+
+	tests := []struct {
+		name   string
+		base   Config
+		modify func(*Config)
+	}{
+		{"unknown sink", kafka, func(c *Config) { c.Sink = "pulsar" }},
+		{"no brokers", kafka, func(c *Config) { c.KafkaBrokers = nil }},
+		{"broker without port", kafka, func(c *Config) { c.KafkaBrokers = []string{"kafka.internal"} }},
+		{"empty topic", kafka, func(c *Config) { c.KafkaTopic = "" }},
+		{"topic with spaces", kafka, func(c *Config) { c.KafkaTopic = "link events" }},
+		{"http url", nats, func(c *Config) { c.NATSURL = "http://127.0.0.1:4222" }},
+		{"wildcard subject", nats, func(c *Config) { c.NATSSubject = "links.>" }},
+		{"no events", kafka, func(c *Config) { c.Events = nil }},
+		{"unknown event", kafka, func(c *Config) { c.Events = []domain.EventType{"url.renamed"} }},
+		{"zero batch", kafka, func(c *Config) { c.BatchSize = 0 }},
+		{"zero interval", kafka, func(c *Config) { c.FlushInterval = 0 }},
+		{"pending below batch", kafka, func(c *Config) { c.MaxPending = c.BatchSize - 1 }},
+		{"zero timeout", kafka, func(c *Config) { c.Timeout = 0 }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := tt.base
+			tt.modify(&config)
+			assert.Error(t, config.Validate())
+		})
+	}
+}
+
+func TestNewSink(t *testing.T) {
+	sink, err := NewSink(DefaultConfig())
+	require.NoError(t, err)
+	assert.Nil(t, sink)
+
+	config := DefaultConfig()
+	config.Sink = SinkKafka
+	sink, err = NewSink(config)
+	require.NoError(t, err)
+	assert.IsType(t, &kafkaSink{}, sink)
+
+	config.Sink = SinkNATS
+	sink, err = NewSink(config)
+	require.NoError(t, err)
+	assert.IsType(t, &natsSink{}, sink)
+
+	config.Sink = "pulsar"
+	_, err = NewSink(config)
+	assert.Error(t, err)
+}
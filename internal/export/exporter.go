@@ -0,0 +1,310 @@
+// Package export publishes selected events to Kafka or NATS for downstream
+// data pipelines. Events are written to an outbox table before they are sent
+// and removed only once the sink has acknowledged them, so delivery is at
+// least once: a crash or an unreachable sink delays events rather than losing
+// them, and consumers should skip duplicates by event ID.
+package export
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	"github.com/joshdurbin/url-shortener/internal/drain"
+	"github.com/joshdurbin/url-shortener/internal/repository"
+)
+
+// maxBackoff caps the wait between attempts while the sink is failing
+const maxBackoff = time.Minute
+
+// Sink delivers batches of events to a message broker. Send returns only once
+// the broker has acknowledged every event in the batch.
+type Sink interface {
+	Send(ctx context.Context, events []domain.Event) error
+	Close() error
+}
+
+// NewSink creates the sink named in config, or returns nil when events are
+// not exported
+func NewSink(config Config) (Sink, error) {
+	switch config.Sink {
+	case "", SinkNone:
+		return nil, nil
+	case SinkKafka:
+		return newKafkaSink(config.KafkaBrokers, config.KafkaTopic, config.Timeout), nil
+	case SinkNATS:
+		return newNATSSink(config.NATSURL, config.NATSSubject, config.Timeout)
+	default:
+		return nil, fmt.Errorf("unknown export sink %q", config.Sink)
+	}
+}
+
+// Exporter subscribes to the event bus and exports the configured event
+// types. Notify only buffers events in memory; a background loop moves them
+// to the outbox and sends the outbox to the sink in batches, oldest first,
+// backing off while the sink fails.
+type Exporter struct {
+	config Config
+	store  repository.ExportRepository
+	sink   Sink
+	types  map[domain.EventType]bool
+
+	mu       sync.Mutex
+	pending  []domain.Event
+	started  bool
+	closed   bool
+	wake     chan struct{}
+	stopChan chan struct{}
+	wg       sync.WaitGroup
+	gate     drain.Gate // Holds the loop's flushes while the server is drained
+
+	exported atomic.Int64
+	dropped  atomic.Int64
+	failures atomic.Int64
+	backlog  atomic.Int64 // Events in the outbox
+	failing  bool         // Whether the last send failed; only used by the loop
+}
+
+// New creates an exporter that sends events to sink
+func New(config Config, store repository.ExportRepository, sink Sink) *Exporter {
+	types := make(map[domain.EventType]bool, len(config.Events))
+	for _, eventType := range config.Events {
+		types[eventType] = true
+	}
+	return &Exporter{
+		config:   config,
+		store:    store,
+		sink:     sink,
+		types:    types,
+		wake:     make(chan struct{}, 1),
+		stopChan: make(chan struct{}),
+	}
+}
+
+// Notify buffers an exported event without blocking. Events are dropped when
+// the buffer is full, which only happens if the outbox cannot be written.
+func (e *Exporter) Notify(event domain.Event) {
+	if !e.types[event.Type] {
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.closed {
+		return
+	}
+	if len(e.pending) >= e.config.MaxPending {
+		e.dropped.Add(1)
+		return
+	}
+	e.pending = append(e.pending, event)
+	if len(e.pending) >= e.config.BatchSize {
+		select {
+		case e.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Start counts the events left in the outbox by the last run and starts the
+// export loop, which sends them first
+func (e *Exporter) Start(ctx context.Context) error {
+	backlog, err := e.store.CountExportEvents(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to count export outbox: %w", err)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.started {
+		return fmt.Errorf("exporter already started")
+	}
+	e.started = true
+	e.backlog.Store(backlog)
+
+	e.wg.Add(1)
+	go e.run()
+	return nil
+}
+
+// Close stops the export loop, stores the buffered events in the outbox and
+// makes a last attempt to send it. Events the sink does not acknowledge stay
+// in the outbox for the next start.
+func (e *Exporter) Close() error {
+	e.mu.Lock()
+	if e.closed {
+		e.mu.Unlock()
+		return nil
+	}
+	e.closed = true
+	close(e.stopChan)
+	e.mu.Unlock()
+
+	e.wg.Wait()
+
+	ctx, cancel := context.WithTimeout(context.Background(), e.config.Timeout)
+	defer cancel()
+
+	storeErr := e.storePending(ctx)
+	if err := e.sendOutbox(ctx); err != nil {
+		log.Printf("Exporter stopped with %d events in the outbox: %v", e.backlog.Load(), err)
+	}
+	if err := e.sink.Close(); err != nil {
+		return fmt.Errorf("failed to close export sink: %w", err)
+	}
+	return storeErr
+}
+
+// Pause holds the export loop until Resume, then stores the buffered events
+// in the outbox, so the outbox stays put during a drain. Events are buffered
+// in memory meanwhile; pausing again stores them.
+func (e *Exporter) Pause(ctx context.Context) error {
+	if e == nil {
+		return nil
+	}
+	if err := e.gate.Pause(ctx); err != nil {
+		return err
+	}
+	if err := e.storePending(ctx); err != nil {
+		return fmt.Errorf("failed to store events in the export outbox: %w", err)
+	}
+	return nil
+}
+
+// Resume lets the export loop store and send events again
+func (e *Exporter) Resume() {
+	if e == nil {
+		return
+	}
+	e.gate.Resume()
+}
+
+// run flushes the exporter every interval, or sooner when a batch fills,
+// until it is closed
+func (e *Exporter) run() {
+	defer e.wg.Done()
+
+	ticker := time.NewTicker(e.config.FlushInterval)
+	defer ticker.Stop()
+
+	var backoff time.Duration
+	var retryAt time.Time
+	for {
+		select {
+		case <-e.stopChan:
+			return
+		case <-ticker.C:
+		case <-e.wake:
+		}
+		if !e.gate.TryEnter() {
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), e.config.Timeout)
+		if err := e.storePending(ctx); err != nil {
+			log.Printf("Failed to store events in the export outbox: %v", err)
+		}
+		if time.Now().Before(retryAt) {
+			cancel()
+			e.gate.Leave()
+			continue
+		}
+		err := e.sendOutbox(ctx)
+		cancel()
+		e.gate.Leave()
+
+		// Only changes are logged, so a sink that is down does not flood the log
+		if err != nil {
+			e.failures.Add(1)
+			backoff = min(max(2*backoff, e.config.FlushInterval), maxBackoff)
+			retryAt = time.Now().Add(backoff)
+			if !e.failing {
+				log.Printf("Failed to export events, retrying with backoff: %v", err)
+			}
+			e.failing = true
+			continue
+		}
+		if e.failing {
+			log.Printf("Exporting events again")
+		}
+		backoff, retryAt, e.failing = 0, time.Time{}, false
+	}
+}
+
+// storePending moves the buffered events to the outbox. On failure they are
+// kept for the next attempt.
+func (e *Exporter) storePending(ctx context.Context) error {
+	e.mu.Lock()
+	pending := e.pending
+	e.pending = nil
+	e.mu.Unlock()
+
+	if len(pending) == 0 {
+		return nil
+	}
+	if err := e.store.AddExportEvents(ctx, pending); err != nil {
+		e.mu.Lock()
+		e.pending = append(pending, e.pending...)
+		e.mu.Unlock()
+		return err
+	}
+	e.backlog.Add(int64(len(pending)))
+	return nil
+}
+
+// sendOutbox sends the outbox to the sink a batch at a time, removing each
+// batch once it is acknowledged, until the outbox is empty
+func (e *Exporter) sendOutbox(ctx context.Context) error {
+	for {
+		records, err := e.store.ListExportEvents(ctx, e.config.BatchSize)
+		if err != nil {
+			return err
+		}
+		if len(records) == 0 {
+			return nil
+		}
+
+		batch := make([]domain.Event, len(records))
+		for i, record := range records {
+			batch[i] = record.Event
+		}
+		if err := e.sink.Send(ctx, batch); err != nil {
+			return err
+		}
+		if err := e.store.DeleteExportEvents(ctx, records[len(records)-1].ID); err != nil {
+			return err
+		}
+		e.exported.Add(int64(len(records)))
+		e.backlog.Add(-int64(len(records)))
+
+		if len(records) < e.config.BatchSize {
+			return nil
+		}
+	}
+}
+
+// Exported returns the number of events the sink acknowledged
+func (e *Exporter) Exported() int64 {
+	return e.exported.Load()
+}
+
+// Dropped returns the number of events dropped because the buffer was full
+func (e *Exporter) Dropped() int64 {
+	return e.dropped.Load()
+}
+
+// Failures returns the number of failed attempts to send the outbox
+func (e *Exporter) Failures() int64 {
+	return e.failures.Load()
+}
+
+// Backlog returns the number of events in the outbox waiting to be sent
+func (e *Exporter) Backlog() int64 {
+	return e.backlog.Load()
+}
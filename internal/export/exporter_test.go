@@ -0,0 +1,215 @@
+package export
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	"github.com/joshdurbin/url-shortener/internal/repository/mocks"
+)
+
+// fakeSink keeps the batches it is sent and can be made to fail
+type fakeSink struct {
+	mu      sync.Mutex
+	batches [][]domain.Event
+	err     error
+	sent    chan struct{}
+	closed  bool
+}
+
+func newFakeSink() *fakeSink {
+	return &fakeSink{sent: make(chan struct{}, 10)}
+}
+
+func (s *fakeSink) Send(ctx context.Context, batch []domain.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defer func() { s.sent <- struct{}{} }()
+	if s.err != nil {
+		return s.err
+	}
+	s.batches = append(s.batches, batch)
+	return nil
+}
+
+func (s *fakeSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	return nil
+}
+
+// testConfig returns a configuration that only flushes when a batch fills or
+// the exporter closes
+func testConfig() Config {
+	config := DefaultConfig()
+	config.Sink = SinkKafka
+	config.BatchSize = 2
+	config.MaxPending = 3
+	config.FlushInterval = time.Hour
+	return config
+}
+
+// outbox turns events into the records the store returns for them
+func outbox(firstID int64, events ...domain.Event) []domain.ExportRecord {
+	records := make([]domain.ExportRecord, len(events))
+	for i, event := range events {
+		records[i] = domain.ExportRecord{ID: firstID + int64(i), Event: event}
+	}
+	return records
+}
+
+func TestExporter_ExportsOnClose(t *testing.T) {
+	store := &mocks.ExportRepository{}
+	sink := newFakeSink()
+	exporter := New(testConfig(), store, sink)
+
+	created := domain.NewEvent(domain.EventURLCreated, domain.EventData{ShortCode: "abc123"})
+	deleted := domain.NewEvent(domain.EventURLDeleted, domain.EventData{ShortCode: "abc123"})
+
+	store.On("CountExportEvents", mock.Anything).Return(int64(0), nil)
+	store.On("AddExportEvents", mock.Anything, []domain.Event{created}).Return(nil)
+	store.On("ListExportEvents", mock.Anything, 2).Return(outbox(7, created), nil).Once()
+	store.On("DeleteExportEvents", mock.Anything, int64(7)).Return(nil)
+
+	require.NoError(t, exporter.Start(context.Background()))
+	assert.Error(t, exporter.Start(context.Background()))
+
+	// Only the configured types are exported
+	exporter.Notify(created)
+	exporter.Notify(deleted)
+
+	require.NoError(t, exporter.Close())
+	require.NoError(t, exporter.Close())
+	assert.Equal(t, [][]domain.Event{{created}}, sink.batches)
+	assert.True(t, sink.closed)
+	assert.Equal(t, int64(1), exporter.Exported())
+	assert.Zero(t, exporter.Backlog())
+	store.AssertExpectations(t)
+
+	// Nothing is buffered once closed
+	exporter.Notify(created)
+	assert.Empty(t, exporter.pending)
+}
+
+func TestExporter_SendsFullBatches(t *testing.T) {
+	store := &mocks.ExportRepository{}
+	sink := newFakeSink()
+	exporter := New(testConfig(), store, sink)
+
+	first := domain.NewEvent(domain.EventURLClicked, domain.EventData{ShortCode: "abc123"})
+	second := domain.NewEvent(domain.EventURLClicked, domain.EventData{ShortCode: "abc123"})
+	third := domain.NewEvent(domain.EventURLCreated, domain.EventData{ShortCode: "xyz789"})
+
+	// One event is left over from the last run and goes out first
+	store.On("CountExportEvents", mock.Anything).Return(int64(1), nil)
+	store.On("AddExportEvents", mock.Anything, []domain.Event{first, second}).Return(nil)
+	store.On("ListExportEvents", mock.Anything, 2).Return(outbox(1, third, first), nil).Once()
+	store.On("ListExportEvents", mock.Anything, 2).Return(outbox(3, second), nil).Once()
+	store.On("ListExportEvents", mock.Anything, 2).Return(nil, nil)
+	store.On("DeleteExportEvents", mock.Anything, mock.AnythingOfType("int64")).Return(nil)
+
+	require.NoError(t, exporter.Start(context.Background()))
+	assert.Equal(t, int64(1), exporter.Backlog())
+
+	// A full batch is sent without waiting for the interval
+	exporter.Notify(first)
+	exporter.Notify(second)
+	for i := 0; i < 2; i++ {
+		select {
+		case <-sink.sent:
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for the batch")
+		}
+	}
+
+	require.NoError(t, exporter.Close())
+	assert.Equal(t, [][]domain.Event{{third, first}, {second}}, sink.batches)
+	assert.Equal(t, int64(3), exporter.Exported())
+	assert.Zero(t, exporter.Backlog())
+	store.AssertCalled(t, "DeleteExportEvents", mock.Anything, int64(2))
+	store.AssertCalled(t, "DeleteExportEvents", mock.Anything, int64(3))
+}
+
+func TestExporter_PauseHoldsTheLoop(t *testing.T) {
+	store := &mocks.ExportRepository{}
+	sink := newFakeSink()
+	exporter := New(testConfig(), store, sink)
+
+	created := domain.NewEvent(domain.EventURLCreated, domain.EventData{ShortCode: "abc123"})
+	first := domain.NewEvent(domain.EventURLClicked, domain.EventData{ShortCode: "abc123"})
+	second := domain.NewEvent(domain.EventURLClicked, domain.EventData{ShortCode: "abc123"})
+
+	store.On("CountExportEvents", mock.Anything).Return(int64(0), nil)
+	require.NoError(t, exporter.Start(context.Background()))
+
+	// Pausing stores the buffered events without sending them
+	exporter.Notify(created)
+	store.On("AddExportEvents", mock.Anything, []domain.Event{created}).Return(nil).Once()
+	require.NoError(t, exporter.Pause(context.Background()))
+	assert.Equal(t, int64(1), exporter.Backlog())
+
+	// Paused, a full batch wakes the loop but nothing is stored or sent
+	exporter.Notify(first)
+	exporter.Notify(second)
+	time.Sleep(50 * time.Millisecond)
+	store.AssertNumberOfCalls(t, "AddExportEvents", 1)
+	store.AssertNotCalled(t, "ListExportEvents", mock.Anything, mock.Anything)
+
+	exporter.Resume()
+	store.On("AddExportEvents", mock.Anything, []domain.Event{first, second}).Return(nil).Once()
+	store.On("ListExportEvents", mock.Anything, 2).Return(outbox(1, created, first), nil).Once()
+	store.On("ListExportEvents", mock.Anything, 2).Return(outbox(3, second), nil).Once()
+	store.On("DeleteExportEvents", mock.Anything, mock.AnythingOfType("int64")).Return(nil)
+	require.NoError(t, exporter.Close())
+	assert.Equal(t, [][]domain.Event{{created, first}, {second}}, sink.batches)
+	store.AssertExpectations(t)
+}
+
+func TestExporter_SinkFailures(t *testing.T) {
+	store := &mocks.ExportRepository{}
+	sink := newFakeSink()
+	sink.err = errors.New("connection refused")
+	config := testConfig()
+	config.FlushInterval = time.Millisecond
+	exporter := New(config, store, sink)
+
+	event := domain.NewEvent(domain.EventURLCreated, domain.EventData{ShortCode: "abc123"})
+	store.On("CountExportEvents", mock.Anything).Return(int64(0), nil)
+	store.On("AddExportEvents", mock.Anything, []domain.Event{event}).Return(nil)
+	store.On("ListExportEvents", mock.Anything, 2).Return(outbox(1, event), nil)
+
+	require.NoError(t, exporter.Start(context.Background()))
+	exporter.Notify(event)
+	require.Eventually(t, func() bool { return exporter.Failures() >= 2 }, 5*time.Second, time.Millisecond)
+
+	// Unacknowledged events stay in the outbox
+	require.NoError(t, exporter.Close())
+	store.AssertNotCalled(t, "DeleteExportEvents", mock.Anything, mock.Anything)
+	assert.Equal(t, int64(1), exporter.Backlog())
+	assert.Zero(t, exporter.Exported())
+}
+
+func TestExporter_StoreFailures(t *testing.T) {
+	store := &mocks.ExportRepository{}
+	exporter := New(testConfig(), store, newFakeSink())
+
+	// Events that cannot be stored stay buffered, and new ones are dropped
+	// once the buffer is full
+	store.On("AddExportEvents", mock.Anything, mock.Anything).Return(errors.New("disk full"))
+	store.On("ListExportEvents", mock.Anything, 2).Return(nil, nil)
+	for i := 0; i < 4; i++ {
+		exporter.Notify(domain.NewEvent(domain.EventURLClicked, domain.EventData{ShortCode: "abc123"}))
+	}
+	assert.Equal(t, int64(1), exporter.Dropped())
+
+	assert.ErrorContains(t, exporter.Close(), "disk full")
+	assert.Len(t, exporter.pending, 3)
+}
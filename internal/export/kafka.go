@@ -0,0 +1,564 @@
+package export
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+)
+
+// Kafka API keys and the versions the sink speaks. These versions predate the
+// flexible encoding, so every request uses the same plain header.
+const (
+	kafkaProduceKey      int16 = 0
+	kafkaProduceVersion  int16 = 3
+	kafkaMetadataKey     int16 = 3
+	kafkaMetadataVersion int16 = 4
+)
+
+// kafkaClientID identifies the sink to the brokers
+const kafkaClientID = "url-shortener"
+
+// kafkaMaxResponse bounds the size of a response the sink will read
+const kafkaMaxResponse = 64 << 20
+
+// kafkaErrors names the error codes a producer commonly sees
+var kafkaErrors = map[int16]string{
+	1:  "OFFSET_OUT_OF_RANGE",
+	2:  "CORRUPT_MESSAGE",
+	3:  "UNKNOWN_TOPIC_OR_PARTITION",
+	5:  "LEADER_NOT_AVAILABLE",
+	6:  "NOT_LEADER_OR_FOLLOWER",
+	7:  "REQUEST_TIMED_OUT",
+	10: "MESSAGE_TOO_LARGE",
+	17: "INVALID_TOPIC_EXCEPTION",
+	19: "NOT_ENOUGH_REPLICAS",
+	20: "NOT_ENOUGH_REPLICAS_AFTER_APPEND",
+	29: "TOPIC_AUTHORIZATION_FAILED",
+	87: "INVALID_RECORD",
+}
+
+// kafkaError describes a Kafka error code
+func kafkaError(code int16) error {
+	if name, ok := kafkaErrors[code]; ok {
+		return fmt.Errorf("kafka error %d (%s)", code, name)
+	}
+	return fmt.Errorf("kafka error %d", code)
+}
+
+// castagnoli is the CRC table record batches are checksummed with
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// kafkaSink produces events to a Kafka topic over the binary protocol. Each
+// event is a JSON record keyed by its short code, so a link's events land in
+// one partition in order, with the event type in a header. Produce requests
+// wait for every in-sync replica (acks=all). TLS and SASL are not supported.
+type kafkaSink struct {
+	brokers []string
+	topic   string
+	timeout time.Duration
+
+	mu          sync.Mutex
+	conns       map[string]*kafkaConn // Open connections by broker address
+	leaders     []string              // Leader address of each partition; nil until metadata is loaded
+	correlation int32
+}
+
+// kafkaConn is a connection to one broker
+type kafkaConn struct {
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+// newKafkaSink creates a sink that bootstraps from the given brokers
+func newKafkaSink(brokers []string, topic string, timeout time.Duration) *kafkaSink {
+	return &kafkaSink{
+		brokers: brokers,
+		topic:   topic,
+		timeout: timeout,
+		conns:   make(map[string]*kafkaConn),
+	}
+}
+
+// Send produces events to the topic, grouped into one request per partition
+// leader. Any failure forgets the partition leaders so the next attempt looks
+// them up again.
+func (s *kafkaSink) Send(ctx context.Context, batch []domain.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.send(ctx, batch); err != nil {
+		s.leaders = nil
+		return err
+	}
+	return nil
+}
+
+// send does the work of Send. The caller holds s.mu.
+func (s *kafkaSink) send(ctx context.Context, batch []domain.Event) error {
+	if s.leaders == nil {
+		if err := s.loadMetadata(ctx); err != nil {
+			return err
+		}
+	}
+
+	// Partition the records, then group the partitions by leader
+	partitions := make(map[int32][]kafkaRecord)
+	for _, event := range batch {
+		value, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("failed to encode event %s: %w", event.ID, err)
+		}
+		key := event.Data.ShortCode
+		if key == "" {
+			key = event.ID
+		}
+		partition := int32(murmur2([]byte(key))&0x7fffffff) % int32(len(s.leaders))
+		partitions[partition] = append(partitions[partition], kafkaRecord{
+			key:       []byte(key),
+			value:     value,
+			eventType: string(event.Type),
+			timestamp: event.CreatedAt,
+		})
+	}
+
+	byLeader := make(map[string]map[int32][]kafkaRecord)
+	for partition, records := range partitions {
+		leader := s.leaders[partition]
+		if byLeader[leader] == nil {
+			byLeader[leader] = make(map[int32][]kafkaRecord)
+		}
+		byLeader[leader][partition] = records
+	}
+
+	for leader, partitions := range byLeader {
+		if err := s.produce(ctx, leader, partitions); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadMetadata looks up the leader of every partition of the topic, asking
+// each bootstrap broker in turn. The caller holds s.mu.
+func (s *kafkaSink) loadMetadata(ctx context.Context) error {
+	var errs []error
+	for _, broker := range s.brokers {
+		leaders, err := s.metadata(ctx, broker)
+		if err == nil {
+			s.leaders = leaders
+			return nil
+		}
+		errs = append(errs, err)
+	}
+	return fmt.Errorf("failed to load Kafka metadata for topic %s: %w", s.topic, errors.Join(errs...))
+}
+
+// metadata asks one broker for the topic's partition leaders
+func (s *kafkaSink) metadata(ctx context.Context, broker string) ([]string, error) {
+	var req kafkaEncoder
+	req.int32(1) // One topic
+	req.string(s.topic)
+	req.bool(false) // Never create the topic
+
+	resp, err := s.roundTrip(ctx, broker, kafkaMetadataKey, kafkaMetadataVersion, req.buf)
+	if err != nil {
+		return nil, err
+	}
+
+	resp.int32() // Throttle time
+	addresses := make(map[int32]string)
+	for i := resp.arrayLen(); i > 0 && resp.err == nil; i-- {
+		node := resp.int32()
+		host := resp.string()
+		port := resp.int32()
+		resp.nullableString() // Rack
+		addresses[node] = net.JoinHostPort(host, strconv.Itoa(int(port)))
+	}
+	resp.nullableString() // Cluster ID
+	resp.int32()          // Controller ID
+
+	var leaders []string
+	for i := resp.arrayLen(); i > 0 && resp.err == nil; i-- {
+		code := resp.int16()
+		name := resp.string()
+		resp.bool() // Internal
+		partitions := make(map[int32]int32)
+		for j := resp.arrayLen(); j > 0 && resp.err == nil; j-- {
+			resp.int16() // Partition error; a missing leader is caught below
+			partition := resp.int32()
+			partitions[partition] = resp.int32()
+			resp.int32Array() // Replicas
+			resp.int32Array() // In-sync replicas
+		}
+		if resp.err != nil {
+			break
+		}
+		if name != s.topic {
+			continue
+		}
+		if code != 0 {
+			return nil, fmt.Errorf("topic %s: %w", s.topic, kafkaError(code))
+		}
+
+		leaders = make([]string, len(partitions))
+		for partition, leader := range partitions {
+			address, ok := addresses[leader]
+			if partition < 0 || int(partition) >= len(leaders) || !ok {
+				return nil, fmt.Errorf("topic %s partition %d has no available leader", s.topic, partition)
+			}
+			leaders[partition] = address
+		}
+	}
+	if resp.err != nil {
+		return nil, fmt.Errorf("invalid metadata response from %s: %w", broker, resp.err)
+	}
+	if len(leaders) == 0 {
+		return nil, fmt.Errorf("topic %s not found on %s", s.topic, broker)
+	}
+	return leaders, nil
+}
+
+// produce sends one record batch per partition to their leader and checks
+// that every partition accepted its batch
+func (s *kafkaSink) produce(ctx context.Context, leader string, partitions map[int32][]kafkaRecord) error {
+	var req kafkaEncoder
+	req.nullableString(nil) // Not transactional
+	req.int16(-1)           // acks=all
+	req.int32(int32(s.timeout / time.Millisecond))
+	req.int32(1) // One topic
+	req.string(s.topic)
+	req.int32(int32(len(partitions)))
+	for partition, records := range partitions {
+		req.int32(partition)
+		req.bytes(encodeRecordBatch(records))
+	}
+
+	resp, err := s.roundTrip(ctx, leader, kafkaProduceKey, kafkaProduceVersion, req.buf)
+	if err != nil {
+		return err
+	}
+
+	acknowledged := 0
+	for i := resp.arrayLen(); i > 0 && resp.err == nil; i-- {
+		resp.string() // Topic
+		for j := resp.arrayLen(); j > 0 && resp.err == nil; j-- {
+			partition := resp.int32()
+			code := resp.int16()
+			resp.int64() // Base offset
+			resp.int64() // Log append time
+			if resp.err != nil {
+				break
+			}
+			if code != 0 {
+				return fmt.Errorf("failed to produce to %s partition %d: %w", s.topic, partition, kafkaError(code))
+			}
+			acknowledged++
+		}
+	}
+	if resp.err != nil {
+		return fmt.Errorf("invalid produce response from %s: %w", leader, resp.err)
+	}
+	if acknowledged != len(partitions) {
+		return fmt.Errorf("%s acknowledged %d of %d partitions", leader, acknowledged, len(partitions))
+	}
+	return nil
+}
+
+// roundTrip sends a request to broker and reads its response, reconnecting
+// if needed. A failed connection is closed so the next request redials.
+func (s *kafkaSink) roundTrip(ctx context.Context, broker string, key, version int16, body []byte) (*kafkaDecoder, error) {
+	conn, err := s.connect(ctx, broker)
+	if err != nil {
+		return nil, err
+	}
+
+	s.correlation++
+	correlation := s.correlation
+
+	var req kafkaEncoder
+	req.int32(0) // Size, filled in below
+	req.int16(key)
+	req.int16(version)
+	req.int32(correlation)
+	req.string(kafkaClientID)
+	req.buf = append(req.buf, body...)
+	binary.BigEndian.PutUint32(req.buf, uint32(len(req.buf)-4))
+
+	fail := func(err error) (*kafkaDecoder, error) {
+		conn.conn.Close()
+		delete(s.conns, broker)
+		return nil, fmt.Errorf("kafka request to %s failed: %w", broker, err)
+	}
+
+	conn.conn.SetDeadline(s.deadline(ctx))
+	if _, err := conn.conn.Write(req.buf); err != nil {
+		return fail(err)
+	}
+
+	var header [8]byte
+	if _, err := io.ReadFull(conn.reader, header[:]); err != nil {
+		return fail(err)
+	}
+	size := binary.BigEndian.Uint32(header[:4])
+	if size < 4 || size > kafkaMaxResponse {
+		return fail(fmt.Errorf("invalid response size %d", size))
+	}
+	if got := int32(binary.BigEndian.Uint32(header[4:])); got != correlation {
+		return fail(fmt.Errorf("response for request %d, expected %d", got, correlation))
+	}
+	payload := make([]byte, size-4)
+	if _, err := io.ReadFull(conn.reader, payload); err != nil {
+		return fail(err)
+	}
+	return &kafkaDecoder{buf: payload}, nil
+}
+
+// connect returns the open connection to broker, dialing it if needed
+func (s *kafkaSink) connect(ctx context.Context, broker string) (*kafkaConn, error) {
+	if conn, ok := s.conns[broker]; ok {
+		return conn, nil
+	}
+	dialer := net.Dialer{Timeout: s.timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", broker)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to Kafka at %s: %w", broker, err)
+	}
+	kc := &kafkaConn{conn: conn, reader: bufio.NewReader(conn)}
+	s.conns[broker] = kc
+	return kc, nil
+}
+
+// deadline returns ctx's deadline, or the sink timeout from now
+func (s *kafkaSink) deadline(ctx context.Context) time.Time {
+	if deadline, ok := ctx.Deadline(); ok {
+		return deadline
+	}
+	return time.Now().Add(s.timeout)
+}
+
+// Close closes the connections to the brokers
+func (s *kafkaSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for broker, conn := range s.conns {
+		conn.conn.Close()
+		delete(s.conns, broker)
+	}
+	s.leaders = nil
+	return nil
+}
+
+// kafkaRecord is one event to produce
+type kafkaRecord struct {
+	key       []byte
+	value     []byte
+	eventType string
+	timestamp time.Time
+}
+
+// encodeRecordBatch encodes records as an uncompressed, non-transactional
+// record batch (magic 2)
+func encodeRecordBatch(records []kafkaRecord) []byte {
+	base := records[0].timestamp.UnixMilli()
+	maxTimestamp := base
+	for _, record := range records {
+		maxTimestamp = max(maxTimestamp, record.timestamp.UnixMilli())
+	}
+
+	// The CRC covers everything from the attributes on
+	var body kafkaEncoder
+	body.int16(0) // Attributes: no compression, create time
+	body.int32(int32(len(records) - 1))
+	body.int64(base)
+	body.int64(maxTimestamp)
+	body.int64(-1) // Producer ID
+	body.int16(-1) // Producer epoch
+	body.int32(-1) // Base sequence
+	body.int32(int32(len(records)))
+	for i, record := range records {
+		var r kafkaEncoder
+		r.int8(0) // Attributes
+		r.varint(record.timestamp.UnixMilli() - base)
+		r.varint(int64(i))
+		r.varint(int64(len(record.key)))
+		r.buf = append(r.buf, record.key...)
+		r.varint(int64(len(record.value)))
+		r.buf = append(r.buf, record.value...)
+		r.varint(1) // One header
+		r.varint(int64(len("event_type")))
+		r.buf = append(r.buf, "event_type"...)
+		r.varint(int64(len(record.eventType)))
+		r.buf = append(r.buf, record.eventType...)
+
+		body.varint(int64(len(r.buf)))
+		body.buf = append(body.buf, r.buf...)
+	}
+
+	var batch kafkaEncoder
+	batch.int64(0)                                // Base offset, assigned by the broker
+	batch.int32(int32(4 + 1 + 4 + len(body.buf))) // Length after this field
+	batch.int32(-1)                               // Partition leader epoch
+	batch.int8(2)                                 // Magic
+	batch.int32(int32(crc32.Checksum(body.buf, castagnoli)))
+	batch.buf = append(batch.buf, body.buf...)
+	return batch.buf
+}
+
+// murmur2 is the hash Kafka's Java client partitions keys with, so records
+// for a key land in the partition other producers would choose
+func murmur2(data []byte) uint32 {
+	const (
+		seed = 0x9747b28c
+		m    = 0x5bd1e995
+		r    = 24
+	)
+
+	length := len(data)
+	h := uint32(seed) ^ uint32(length)
+	for i := 0; i+4 <= length; i += 4 {
+		k := binary.LittleEndian.Uint32(data[i:])
+		k *= m
+		k ^= k >> r
+		k *= m
+		h *= m
+		h ^= k
+	}
+
+	tail := data[length&^3:]
+	switch len(tail) {
+	case 3:
+		h ^= uint32(tail[2]) << 16
+		fallthrough
+	case 2:
+		h ^= uint32(tail[1]) << 8
+		fallthrough
+	case 1:
+		h ^= uint32(tail[0])
+		h *= m
+	}
+
+	h ^= h >> 13
+	h *= m
+	h ^= h >> 15
+	return h
+}
+
+// kafkaEncoder appends Kafka protocol primitives to a buffer
+type kafkaEncoder struct {
+	buf []byte
+}
+
+func (e *kafkaEncoder) int8(v int8)    { e.buf = append(e.buf, byte(v)) }
+func (e *kafkaEncoder) int16(v int16)  { e.buf = binary.BigEndian.AppendUint16(e.buf, uint16(v)) }
+func (e *kafkaEncoder) int32(v int32)  { e.buf = binary.BigEndian.AppendUint32(e.buf, uint32(v)) }
+func (e *kafkaEncoder) int64(v int64)  { e.buf = binary.BigEndian.AppendUint64(e.buf, uint64(v)) }
+func (e *kafkaEncoder) varint(v int64) { e.buf = binary.AppendVarint(e.buf, v) }
+
+func (e *kafkaEncoder) bool(v bool) {
+	if v {
+		e.int8(1)
+	} else {
+		e.int8(0)
+	}
+}
+
+func (e *kafkaEncoder) string(v string) {
+	e.int16(int16(len(v)))
+	e.buf = append(e.buf, v...)
+}
+
+func (e *kafkaEncoder) nullableString(v *string) {
+	if v == nil {
+		e.int16(-1)
+		return
+	}
+	e.string(*v)
+}
+
+func (e *kafkaEncoder) bytes(v []byte) {
+	e.int32(int32(len(v)))
+	e.buf = append(e.buf, v...)
+}
+
+// kafkaDecoder reads Kafka protocol primitives from a response. The first
+// short read sets err and every later read returns zero values.
+type kafkaDecoder struct {
+	buf []byte
+	err error
+}
+
+// take returns the next n bytes, or nil once the buffer is exhausted
+func (d *kafkaDecoder) take(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+	if n < 0 || n > len(d.buf) {
+		d.err = io.ErrUnexpectedEOF
+		return nil
+	}
+	v := d.buf[:n]
+	d.buf = d.buf[n:]
+	return v
+}
+
+func (d *kafkaDecoder) bool() bool {
+	b := d.take(1)
+	return b != nil && b[0] != 0
+}
+
+func (d *kafkaDecoder) int16() int16 {
+	if b := d.take(2); b != nil {
+		return int16(binary.BigEndian.Uint16(b))
+	}
+	return 0
+}
+
+func (d *kafkaDecoder) int32() int32 {
+	if b := d.take(4); b != nil {
+		return int32(binary.BigEndian.Uint32(b))
+	}
+	return 0
+}
+
+func (d *kafkaDecoder) int64() int64 {
+	if b := d.take(8); b != nil {
+		return int64(binary.BigEndian.Uint64(b))
+	}
+	return 0
+}
+
+func (d *kafkaDecoder) string() string {
+	return string(d.take(int(d.int16())))
+}
+
+func (d *kafkaDecoder) nullableString() *string {
+	n := d.int16()
+	if n < 0 {
+		return nil
+	}
+	v := string(d.take(int(n)))
+	return &v
+}
+
+// arrayLen reads an array length, treating a null array as empty
+func (d *kafkaDecoder) arrayLen() int {
+	return max(int(d.int32()), 0)
+}
+
+func (d *kafkaDecoder) int32Array() {
+	for i := d.arrayLen(); i > 0 && d.err == nil; i-- {
+		d.int32()
+	}
+}
@@ -0,0 +1,312 @@
+package export
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"hash/crc32"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+)
+
+// producedRecord is a record received by fakeKafka
+type producedRecord struct {
+	partition int32
+	key       string
+	value     []byte
+	headers   map[string]string
+}
+
+// fakeKafka is a single Kafka broker that leads every partition of one topic.
+// It answers Metadata and Produce requests, checking each record batch's CRC.
+type fakeKafka struct {
+	t          *testing.T
+	listener   net.Listener
+	topic      string
+	partitions int32
+
+	mu          sync.Mutex
+	records     []producedRecord
+	metadata    int     // Metadata requests answered
+	produceErrs []int16 // Error codes for the next produce responses, in order
+}
+
+func newFakeKafka(t *testing.T, topic string, partitions int32) *fakeKafka {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	server := &fakeKafka{t: t, listener: listener, topic: topic, partitions: partitions}
+	go server.serve()
+	return server
+}
+
+func (s *fakeKafka) address() string {
+	return s.listener.Addr().String()
+}
+
+func (s *fakeKafka) produced() []producedRecord {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]producedRecord(nil), s.records...)
+}
+
+func (s *fakeKafka) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *fakeKafka) handle(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	for {
+		var size [4]byte
+		if _, err := io.ReadFull(reader, size[:]); err != nil {
+			return
+		}
+		payload := make([]byte, binary.BigEndian.Uint32(size[:]))
+		if _, err := io.ReadFull(reader, payload); err != nil {
+			return
+		}
+
+		req := &kafkaDecoder{buf: payload}
+		key, version, correlation := req.int16(), req.int16(), req.int32()
+		assert.Equal(s.t, kafkaClientID, req.string())
+
+		var resp kafkaEncoder
+		resp.int32(0)
+		resp.int32(correlation)
+		switch key {
+		case kafkaMetadataKey:
+			assert.Equal(s.t, kafkaMetadataVersion, version)
+			s.writeMetadata(&resp)
+		case kafkaProduceKey:
+			assert.Equal(s.t, kafkaProduceVersion, version)
+			s.writeProduce(req, &resp)
+		default:
+			s.t.Errorf("unexpected API key %d", key)
+			return
+		}
+		binary.BigEndian.PutUint32(resp.buf, uint32(len(resp.buf)-4))
+		if _, err := conn.Write(resp.buf); err != nil {
+			return
+		}
+	}
+}
+
+func (s *fakeKafka) writeMetadata(resp *kafkaEncoder) {
+	s.mu.Lock()
+	s.metadata++
+	s.mu.Unlock()
+
+	host, port, _ := net.SplitHostPort(s.address())
+	portNumber, _ := strconv.Atoi(port)
+
+	resp.int32(0) // Throttle time
+	resp.int32(1) // One broker
+	resp.int32(1)
+	resp.string(host)
+	resp.int32(int32(portNumber))
+	resp.nullableString(nil)
+	resp.nullableString(nil) // Cluster ID
+	resp.int32(1)            // Controller ID
+	resp.int32(2)            // An unrelated topic, then the requested one
+	resp.int16(0)
+	resp.string("other")
+	resp.bool(false)
+	resp.int32(0)
+	resp.int16(0)
+	resp.string(s.topic)
+	resp.bool(false)
+	resp.int32(s.partitions)
+	for partition := int32(0); partition < s.partitions; partition++ {
+		resp.int16(0)
+		resp.int32(partition)
+		resp.int32(1) // Leader
+		resp.int32(1)
+		resp.int32(1)
+		resp.int32(1)
+		resp.int32(1)
+	}
+}
+
+func (s *fakeKafka) writeProduce(req *kafkaDecoder, resp *kafkaEncoder) {
+	assert.Nil(s.t, req.nullableString())
+	assert.Equal(s.t, int16(-1), req.int16(), "acks=all")
+	req.int32() // Timeout
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var code int16
+	if len(s.produceErrs) > 0 {
+		code, s.produceErrs = s.produceErrs[0], s.produceErrs[1:]
+	}
+
+	resp.int32(1)
+	for topics := req.arrayLen(); topics > 0; topics-- {
+		topic := req.string()
+		assert.Equal(s.t, s.topic, topic)
+		resp.string(topic)
+		partitions := req.arrayLen()
+		resp.int32(int32(partitions))
+		for ; partitions > 0; partitions-- {
+			partition := req.int32()
+			batch := req.take(int(req.int32()))
+			require.NoError(s.t, req.err)
+			if code == 0 {
+				s.records = append(s.records, decodeRecordBatch(s.t, partition, batch)...)
+			}
+			resp.int32(partition)
+			resp.int16(code)
+			resp.int64(0)
+			resp.int64(-1)
+		}
+	}
+	resp.int32(0) // Throttle time
+}
+
+// decodeRecordBatch decodes a magic 2 record batch, checking its length and CRC
+func decodeRecordBatch(t *testing.T, partition int32, batch []byte) []producedRecord {
+	d := &kafkaDecoder{buf: batch}
+	assert.Zero(t, d.int64(), "base offset")
+	assert.Equal(t, len(batch)-12, int(d.int32()), "batch length")
+	d.int32() // Partition leader epoch
+	assert.Equal(t, int8(2), int8(d.take(1)[0]), "magic")
+	crc := uint32(d.int32())
+	assert.Equal(t, crc32.Checksum(d.buf, crc32.MakeTable(crc32.Castagnoli)), crc, "crc")
+
+	d.int16() // Attributes
+	d.int32() // Last offset delta
+	d.int64() // Base timestamp
+	d.int64() // Max timestamp
+	assert.Equal(t, int64(-1), d.int64(), "producer id")
+	d.int16()
+	d.int32()
+
+	varint := func() int64 {
+		v, n := binary.Varint(d.buf)
+		require.Positive(t, n)
+		d.buf = d.buf[n:]
+		return v
+	}
+
+	var records []producedRecord
+	for count := d.int32(); count > 0; count-- {
+		varint()  // Length
+		d.take(1) // Attributes
+		varint()  // Timestamp delta
+		varint()  // Offset delta
+		record := producedRecord{partition: partition, headers: make(map[string]string)}
+		record.key = string(d.take(int(varint())))
+		record.value = d.take(int(varint()))
+		for headers := varint(); headers > 0; headers-- {
+			name := string(d.take(int(varint())))
+			record.headers[name] = string(d.take(int(varint())))
+		}
+		records = append(records, record)
+	}
+	require.NoError(t, d.err)
+	assert.Empty(t, d.buf)
+	return records
+}
+
+func TestKafkaSink_Send(t *testing.T) {
+	server := newFakeKafka(t, "links", 4)
+	sink := newKafkaSink([]string{server.address()}, "links", time.Second)
+	defer sink.Close()
+
+	batch := []domain.Event{
+		domain.NewEvent(domain.EventURLCreated, domain.EventData{ShortCode: "abc123", Campaign: "spring"}),
+		domain.NewEvent(domain.EventURLClicked, domain.EventData{ShortCode: "abc123"}),
+		domain.NewEvent(domain.EventURLClicked, domain.EventData{ShortCode: "xyz789"}),
+		domain.NewEvent(domain.EventURLClicked, domain.EventData{ShortCode: "foobar"}),
+	}
+	require.NoError(t, sink.Send(context.Background(), batch))
+
+	records := server.produced()
+	require.Len(t, records, len(batch))
+	byID := make(map[string]producedRecord)
+	for _, record := range records {
+		var event domain.Event
+		require.NoError(t, json.Unmarshal(record.value, &event))
+		byID[event.ID] = record
+	}
+	for _, event := range batch {
+		record, ok := byID[event.ID]
+		require.True(t, ok, event.ID)
+		assert.Equal(t, event.Data.ShortCode, record.key)
+		assert.Equal(t, string(event.Type), record.headers["event_type"])
+		assert.Equal(t, int32(murmur2([]byte(record.key))&0x7fffffff)%4, record.partition)
+	}
+	assert.Equal(t, byID[batch[0].ID].partition, byID[batch[1].ID].partition, "a link's events share a partition")
+
+	// Metadata is cached between batches
+	require.NoError(t, sink.Send(context.Background(), batch[:1]))
+	assert.Equal(t, 1, server.metadata)
+}
+
+func TestKafkaSink_Errors(t *testing.T) {
+	server := newFakeKafka(t, "links", 1)
+	sink := newKafkaSink([]string{server.address()}, "links", time.Second)
+	defer sink.Close()
+
+	// A rejected batch fails and the next attempt reloads the metadata
+	server.produceErrs = []int16{6}
+	batch := []domain.Event{domain.NewEvent(domain.EventURLCreated, domain.EventData{ShortCode: "abc123"})}
+	assert.ErrorContains(t, sink.Send(context.Background(), batch), "NOT_LEADER_OR_FOLLOWER")
+	require.NoError(t, sink.Send(context.Background(), batch))
+	assert.Equal(t, 2, server.metadata)
+	assert.Len(t, server.produced(), 1)
+
+	t.Run("unknown topic", func(t *testing.T) {
+		sink := newKafkaSink([]string{server.address()}, "missing", time.Second)
+		defer sink.Close()
+		assert.ErrorContains(t, sink.Send(context.Background(), batch), "topic missing not found")
+	})
+
+	t.Run("unreachable", func(t *testing.T) {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		address := listener.Addr().String()
+		listener.Close()
+
+		// The second bootstrap broker is tried after the first fails
+		sink := newKafkaSink([]string{address, server.address()}, "links", time.Second)
+		defer sink.Close()
+		require.NoError(t, sink.Send(context.Background(), batch))
+
+		sink = newKafkaSink([]string{address}, "links", time.Second)
+		defer sink.Close()
+		assert.ErrorContains(t, sink.Send(context.Background(), batch), "failed to connect to Kafka")
+	})
+}
+
+func TestMurmur2(t *testing.T) {
+	// Values from the Java client, which partitions keys with this hash
+	tests := map[string]int32{
+		"21":                         -973932308,
+		"foobar":                     -790332482,
+		"a-little-bit-long-string":   -985981536,
+		"a-little-bit-longer-string": -1486304829,
+		"abc":                        479470107,
+	}
+	for key, expected := range tests {
+		assert.Equal(t, expected, int32(murmur2([]byte(key))), key)
+	}
+}
@@ -0,0 +1,50 @@
+package export
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	"github.com/joshdurbin/url-shortener/internal/events"
+)
+
+// natsSink publishes each event as a JSON message on <subject>.<event type>
+// and flushes the connection after each batch, so a batch is acknowledged
+// once the server has processed it
+type natsSink struct {
+	conn    *events.NATSConn
+	subject string
+}
+
+// newNATSSink creates a sink for a nats://[user:password@]host:port server
+func newNATSSink(serverURL, subject string, timeout time.Duration) (*natsSink, error) {
+	conn, err := events.NewNATSConn(serverURL, timeout)
+	if err != nil {
+		return nil, err
+	}
+	if err := events.ValidateNATSSubject(subject); err != nil {
+		return nil, err
+	}
+	return &natsSink{conn: conn, subject: subject}, nil
+}
+
+// Send publishes events and waits for the server to process them
+func (s *natsSink) Send(ctx context.Context, batch []domain.Event) error {
+	for _, event := range batch {
+		payload, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("failed to encode event %s: %w", event.ID, err)
+		}
+		if err := s.conn.Publish(ctx, s.subject+"."+string(event.Type), payload); err != nil {
+			return err
+		}
+	}
+	return s.conn.Flush(ctx)
+}
+
+// Close closes the connection to the server
+func (s *natsSink) Close() error {
+	return s.conn.Close()
+}
@@ -0,0 +1,80 @@
+package export
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+)
+
+// serveNATS runs a NATS server that answers PINGs and sends the subjects of
+// published messages to the returned channel
+func serveNATS(t *testing.T) (string, <-chan string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	subjects := make(chan string, 10)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				fmt.Fprint(conn, "INFO {}\r\n")
+				reader := bufio.NewReader(conn)
+				for {
+					line, err := reader.ReadString('\n')
+					if err != nil {
+						return
+					}
+					fields := strings.Fields(line)
+					switch {
+					case len(fields) == 0:
+					case fields[0] == "PING":
+						fmt.Fprint(conn, "PONG\r\n")
+					case fields[0] == "PUB":
+						size, _ := strconv.Atoi(fields[len(fields)-1])
+						if _, err := io.CopyN(io.Discard, reader, int64(size+2)); err != nil {
+							return
+						}
+						subjects <- fields[1]
+					}
+				}
+			}()
+		}
+	}()
+	return "nats://" + listener.Addr().String(), subjects
+}
+
+func TestNATSSink_Send(t *testing.T) {
+	serverURL, subjects := serveNATS(t)
+	sink, err := newNATSSink(serverURL, "links.export", time.Second)
+	require.NoError(t, err)
+	defer sink.Close()
+
+	require.NoError(t, sink.Send(context.Background(), []domain.Event{
+		domain.NewEvent(domain.EventURLCreated, domain.EventData{ShortCode: "abc123"}),
+		domain.NewEvent(domain.EventURLClicked, domain.EventData{ShortCode: "abc123"}),
+	}))
+
+	// Send returns after the server answered the flush, so both have arrived
+	require.Len(t, subjects, 2)
+	assert.Equal(t, "links.export.url.created", <-subjects)
+	assert.Equal(t, "links.export.url.clicked", <-subjects)
+
+	require.NoError(t, sink.Close())
+	assert.Error(t, sink.Send(context.Background(), []domain.Event{domain.NewEvent(domain.EventURLCreated, domain.EventData{})}))
+}
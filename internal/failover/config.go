@@ -0,0 +1,41 @@
+package failover
+
+import (
+	"fmt"
+	"time"
+)
+
+// Config holds destination health check configuration
+type Config struct {
+	Interval          time.Duration // How often primary destinations with a backup are probed; 0 disables health checks
+	Timeout           time.Duration // Timeout for a single probe
+	FailureThreshold  int           // Consecutive failed probes before redirects move to the backup
+	RecoveryThreshold int           // Consecutive successful probes before redirects return to the primary
+}
+
+// DefaultConfig returns the default configuration
+func DefaultConfig() Config {
+	return Config{
+		Interval:          30 * time.Second,
+		Timeout:           5 * time.Second,
+		FailureThreshold:  3,
+		RecoveryThreshold: 2,
+	}
+}
+
+// Validate checks that the configuration values are usable
+func (c Config) Validate() error {
+	if c.Interval < 0 {
+		return fmt.Errorf("interval cannot be negative, got: %v", c.Interval)
+	}
+	if c.Interval == 0 {
+		return nil
+	}
+	if c.Timeout <= 0 {
+		return fmt.Errorf("timeout must be positive, got: %v", c.Timeout)
+	}
+	if c.FailureThreshold < 1 || c.RecoveryThreshold < 1 {
+		return fmt.Errorf("failure and recovery thresholds must be at least 1, got: %d and %d", c.FailureThreshold, c.RecoveryThreshold)
+	}
+	return nil
+}
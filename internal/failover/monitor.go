@@ -0,0 +1,244 @@
+package failover
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	"github.com/joshdurbin/url-shortener/internal/service"
+	"github.com/joshdurbin/url-shortener/internal/version"
+)
+
+// maxConcurrentProbes bounds how many destinations are probed at once
+const maxConcurrentProbes = 8
+
+// Monitor health checks the primary destination of every link that has a
+// backup URL. After FailureThreshold consecutive failed probes it switches the
+// link's redirects to the backup; after RecoveryThreshold consecutive
+// successful probes it switches them back. Streaks live in memory, so a
+// restart only delays the next transition; the failover state itself is stored
+// with the link.
+type Monitor struct {
+	config Config
+	links  service.URLShortener
+	client *http.Client
+
+	mutex    sync.Mutex
+	streaks  map[string]*streak // Consecutive probe results per short code
+	started  bool
+	closed   bool
+	stopChan chan struct{}
+	wg       sync.WaitGroup
+
+	checkMutex sync.Mutex // Serializes checks so streaks are counted once per round
+}
+
+// streak counts consecutive probe results for one link
+type streak struct {
+	failures  int
+	successes int
+}
+
+// New creates a destination health monitor
+func New(config Config, links service.URLShortener) *Monitor {
+	return &Monitor{
+		config: config,
+		links:  links,
+		client: &http.Client{
+			Timeout: config.Timeout,
+			// A redirecting destination is answering, so redirects count as healthy
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		streaks:  make(map[string]*streak),
+		stopChan: make(chan struct{}),
+	}
+}
+
+// Start starts the health check scheduler
+func (m *Monitor) Start(ctx context.Context) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.started {
+		return fmt.Errorf("failover monitor already started")
+	}
+	m.started = true
+
+	if m.config.Interval > 0 {
+		m.wg.Add(1)
+		go m.scheduleLoop()
+	}
+
+	return nil
+}
+
+// Close stops the scheduler, waiting for a check in progress to finish
+func (m *Monitor) Close() error {
+	m.mutex.Lock()
+	if m.closed {
+		m.mutex.Unlock()
+		return nil
+	}
+	m.closed = true
+	close(m.stopChan)
+	m.mutex.Unlock()
+
+	m.wg.Wait()
+	return nil
+}
+
+// Check probes every primary destination with a backup once and applies any
+// resulting failover transitions
+func (m *Monitor) Check(ctx context.Context) error {
+	m.checkMutex.Lock()
+	defer m.checkMutex.Unlock()
+
+	entries, err := m.links.GetAllURLs(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list links: %w", err)
+	}
+
+	monitored := make([]*domain.URLEntry, 0)
+	for _, entry := range entries {
+		if entry.BackupURL != "" {
+			monitored = append(monitored, entry)
+		}
+	}
+
+	results := make([]error, len(monitored))
+	semaphore := make(chan struct{}, maxConcurrentProbes)
+	var wg sync.WaitGroup
+	for i, entry := range monitored {
+		wg.Add(1)
+		semaphore <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+			results[i] = m.probe(ctx, probeTarget(entry.OriginalURL))
+		}()
+	}
+	wg.Wait()
+
+	m.mutex.Lock()
+	seen := make(map[string]*streak, len(monitored))
+	for _, entry := range monitored {
+		s := m.streaks[entry.ShortCode]
+		if s == nil {
+			s = &streak{}
+		}
+		seen[entry.ShortCode] = s
+	}
+	// Forget links that were deleted or lost their backup
+	m.streaks = seen
+	m.mutex.Unlock()
+
+	for i, entry := range monitored {
+		m.record(ctx, entry, seen[entry.ShortCode], results[i])
+	}
+	return nil
+}
+
+// record updates a link's streak with one probe result and switches its
+// redirects once a threshold is reached
+func (m *Monitor) record(ctx context.Context, entry *domain.URLEntry, s *streak, probeErr error) {
+	if probeErr != nil {
+		s.failures++
+		s.successes = 0
+		if entry.FailoverActive || s.failures < m.config.FailureThreshold {
+			return
+		}
+
+		reason := fmt.Sprintf("%d consecutive failed health checks: %v", s.failures, probeErr)
+		if _, err := m.links.SetFailover(ctx, entry.ShortCode, true, reason); err != nil {
+			log.Printf("Error failing over %s to its backup: %v", entry.ShortCode, err)
+			return
+		}
+		log.Printf("Failover: %s primary %s is unhealthy (%s); redirecting to backup %s", entry.ShortCode, entry.OriginalURL, reason, entry.BackupURL)
+		return
+	}
+
+	s.successes++
+	s.failures = 0
+	if !entry.FailoverActive || s.successes < m.config.RecoveryThreshold {
+		return
+	}
+
+	reason := fmt.Sprintf("%d consecutive successful health checks", s.successes)
+	if _, err := m.links.SetFailover(ctx, entry.ShortCode, false, reason); err != nil {
+		log.Printf("Error restoring %s to its primary: %v", entry.ShortCode, err)
+		return
+	}
+	log.Printf("Failover: %s primary %s recovered (%s); redirecting to primary again", entry.ShortCode, entry.OriginalURL, reason)
+}
+
+// probe reports whether a destination answers without an error status. Some
+// probeTarget returns the URL checked for a primary destination; a template
+// link is checked with its sample URL (defaults, or "x" for required values)
+func probeTarget(destination string) string {
+	if !domain.IsTemplate(destination) {
+		return destination
+	}
+	tmpl, err := domain.ParseTemplate(destination)
+	if err != nil {
+		return destination
+	}
+	return tmpl.Sample()
+}
+
+// servers reject HEAD, so a 405 or 501 is retried with GET.
+func (m *Monitor) probe(ctx context.Context, target string) error {
+	status, err := m.request(ctx, http.MethodHead, target)
+	if err == nil && (status == http.StatusMethodNotAllowed || status == http.StatusNotImplemented) {
+		status, err = m.request(ctx, http.MethodGet, target)
+	}
+	if err != nil {
+		return err
+	}
+	if status >= http.StatusBadRequest {
+		return fmt.Errorf("status %d", status)
+	}
+	return nil
+}
+
+// request sends one probe and returns the response status
+func (m *Monitor) request(ctx context.Context, method, target string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, method, target, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("User-Agent", version.UserAgent())
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+// scheduleLoop checks destinations every interval until the monitor is closed
+func (m *Monitor) scheduleLoop() {
+	defer m.wg.Done()
+
+	ticker := time.NewTicker(m.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.stopChan:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), m.config.Interval)
+			if err := m.Check(ctx); err != nil {
+				log.Printf("Error checking failover destinations: %v", err)
+			}
+			cancel()
+		}
+	}
+}
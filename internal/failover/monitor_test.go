@@ -0,0 +1,168 @@
+package failover
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	"github.com/joshdurbin/url-shortener/internal/service/mocks"
+)
+
+// newTestMonitor returns a monitor without a scheduler that fails over after
+// two failed checks and recovers after two successful ones
+func newTestMonitor(links *mocks.URLShortener) *Monitor {
+	return New(Config{Timeout: time.Second, FailureThreshold: 2, RecoveryThreshold: 2}, links)
+}
+
+func TestMonitor_FailoverAndRecovery(t *testing.T) {
+	var healthy atomic.Bool
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer primary.Close()
+
+	ctx := context.Background()
+	link := &domain.URLEntry{ShortCode: "abc123", OriginalURL: primary.URL, BackupURL: "https://backup.example.com"}
+	links := &mocks.URLShortener{}
+	links.On("GetAllURLs", ctx).Return([]*domain.URLEntry{
+		link,
+		{ShortCode: "nobkup", OriginalURL: "http://127.0.0.1:1/unreachable"},
+	}, nil)
+	links.On("SetFailover", ctx, "abc123", true, mock.MatchedBy(func(reason string) bool {
+		return strings.HasPrefix(reason, "2 consecutive failed health checks") && strings.Contains(reason, "status 503")
+	})).Return(link, nil).Once()
+	links.On("SetFailover", ctx, "abc123", false, "2 consecutive successful health checks").Return(link, nil).Once()
+
+	monitor := newTestMonitor(links)
+
+	// One failure is below the threshold
+	require.NoError(t, monitor.Check(ctx))
+	links.AssertNotCalled(t, "SetFailover", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+
+	require.NoError(t, monitor.Check(ctx))
+	links.AssertNumberOfCalls(t, "SetFailover", 1)
+
+	// Failed over links are not failed over again while the primary stays down
+	link.FailoverActive = true
+	require.NoError(t, monitor.Check(ctx))
+	links.AssertNumberOfCalls(t, "SetFailover", 1)
+
+	healthy.Store(true)
+	require.NoError(t, monitor.Check(ctx))
+	links.AssertNumberOfCalls(t, "SetFailover", 1)
+
+	require.NoError(t, monitor.Check(ctx))
+	links.AssertNumberOfCalls(t, "SetFailover", 2)
+	links.AssertExpectations(t)
+}
+
+func TestMonitor_FailureStreakResetsOnSuccess(t *testing.T) {
+	var requests atomic.Int32
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Fail, succeed, fail: never two failures in a row
+		if requests.Add(1)%2 == 0 {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer primary.Close()
+
+	ctx := context.Background()
+	links := &mocks.URLShortener{}
+	links.On("GetAllURLs", ctx).Return([]*domain.URLEntry{
+		{ShortCode: "abc123", OriginalURL: primary.URL, BackupURL: "https://backup.example.com"},
+	}, nil)
+
+	monitor := newTestMonitor(links)
+	for i := 0; i < 3; i++ {
+		require.NoError(t, monitor.Check(ctx))
+	}
+	links.AssertNotCalled(t, "SetFailover", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestMonitor_Probe(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/no-head":
+			if r.Method == http.MethodHead {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		case "/moved":
+			http.Redirect(w, r, "/missing", http.StatusFound)
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			assert.NotEmpty(t, r.Header.Get("User-Agent"))
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer server.Close()
+
+	monitor := newTestMonitor(&mocks.URLShortener{})
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		path    string
+		wantErr bool
+	}{
+		{name: "healthy", path: "/"},
+		{name: "HEAD not allowed falls back to GET", path: "/no-head"},
+		{name: "redirects are not followed", path: "/moved"},
+		{name: "client error is unhealthy", path: "/missing", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := monitor.probe(ctx, server.URL+tt.path)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestMonitor_CheckListError(t *testing.T) {
+	ctx := context.Background()
+	links := &mocks.URLShortener{}
+	links.On("GetAllURLs", ctx).Return(nil, errors.New("database is locked"))
+
+	err := newTestMonitor(links).Check(ctx)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to list links")
+}
+
+func TestMonitor_StartClose(t *testing.T) {
+	monitor := New(Config{Interval: time.Hour, Timeout: time.Second, FailureThreshold: 1, RecoveryThreshold: 1}, &mocks.URLShortener{})
+	require.NoError(t, monitor.Start(context.Background()))
+	assert.Error(t, monitor.Start(context.Background()))
+	assert.NoError(t, monitor.Close())
+	assert.NoError(t, monitor.Close())
+}
+
+func TestConfig_Validate(t *testing.T) {
+	assert.NoError(t, DefaultConfig().Validate())
+	assert.NoError(t, Config{}.Validate(), "a zero interval disables health checks")
+	assert.Error(t, Config{Interval: -time.Minute}.Validate())
+	assert.Error(t, Config{Interval: time.Minute, FailureThreshold: 1, RecoveryThreshold: 1}.Validate())
+	assert.Error(t, Config{Interval: time.Minute, Timeout: time.Second, FailureThreshold: 0, RecoveryThreshold: 1}.Validate())
+}
@@ -0,0 +1,31 @@
+package geoip
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Config holds visitor country lookup configuration
+type Config struct {
+	Database      string // Optional MaxMind DB or CSV of IP ranges and their countries
+	ASNDatabase   string // Optional MaxMind ASN database (e.g. GeoLite2-ASN) naming each visitor's network
+	CountryHeader string // Optional request header holding the country, set by a trusted CDN or proxy (e.g. CF-IPCountry)
+}
+
+// DefaultConfig returns the default configuration, which looks up nothing
+func DefaultConfig() Config {
+	return Config{}
+}
+
+// Validate checks that the configuration values are usable
+func (c Config) Validate() error {
+	if strings.ContainsAny(c.CountryHeader, " :") {
+		return fmt.Errorf("country header must be a header name, got: %q", c.CountryHeader)
+	}
+	return nil
+}
+
+// Enabled reports whether countries or networks can be looked up at all
+func (c Config) Enabled() bool {
+	return c.Database != "" || c.CountryHeader != "" || c.ASNDatabase != ""
+}
@@ -0,0 +1,273 @@
+package geoip
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"net/netip"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+)
+
+// Locator resolves a visitor's country, first from the configured header and
+// then from the GeoIP database: a CSV of IP ranges or a MaxMind DB, which can
+// also resolve the visitor's region. An ASN database adds the visitor's
+// network. A nil Locator resolves nothing.
+type Locator struct {
+	header string
+	ranges []ipRange // Sorted by start
+	mmdb   *mmdbReader
+	asns   *mmdbReader
+}
+
+// Location is where a visitor is, as far as it is known
+type Location struct {
+	Country string // ISO 3166-1 alpha-2 country code, or ""
+	Region  string // ISO 3166-2 subdivision code within the country (e.g. "CA"), or ""
+	ASN     uint32 // Autonomous system number of the visitor's network, or 0
+}
+
+// ipRange is an inclusive range of addresses in one country
+type ipRange struct {
+	start, end netip.Addr
+	country    string
+}
+
+// New builds a Locator from the configuration, loading its database. It
+// returns nil when neither a database nor a header is configured. A database
+// file that does not exist is logged and skipped, so lookups fail open; one
+// that exists but cannot be read is an error.
+func New(config Config) (*Locator, error) {
+	if !config.Enabled() {
+		return nil, nil
+	}
+
+	locator := &Locator{header: config.CountryHeader}
+	if config.ASNDatabase != "" {
+		buf, err := os.ReadFile(config.ASNDatabase)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			log.Printf("ASN database %s not found; visitor networks are unknown until it is added and the server restarted", config.ASNDatabase)
+		case err != nil:
+			return nil, fmt.Errorf("failed to open ASN database: %w", err)
+		default:
+			if locator.asns, err = newMMDBReader(buf); err != nil {
+				return nil, fmt.Errorf("failed to load ASN database %s: %w", config.ASNDatabase, err)
+			}
+		}
+	}
+	if config.Database == "" {
+		return locator, nil
+	}
+
+	buf, err := os.ReadFile(config.Database)
+	if errors.Is(err, fs.ErrNotExist) {
+		log.Printf("GeoIP database %s not found; visitor locations are unknown until it is added and the server restarted", config.Database)
+		return locator, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open GeoIP database: %w", err)
+	}
+
+	if isMMDB(buf) {
+		if locator.mmdb, err = newMMDBReader(buf); err != nil {
+			return nil, fmt.Errorf("failed to load MaxMind database %s: %w", config.Database, err)
+		}
+		return locator, nil
+	}
+	if locator.ranges, err = parseRanges(bytes.NewReader(buf)); err != nil {
+		return nil, fmt.Errorf("failed to load GeoIP database %s: %w", config.Database, err)
+	}
+	return locator, nil
+}
+
+// parseRanges reads CSV rows of start,end,country where addresses are written
+// either as IPs (as in DB-IP's lite files) or as decimal numbers (as in
+// IP2Location's LITE files). Extra columns are ignored, as is a header row.
+// Ranges with a placeholder country such as "-" are skipped.
+func parseRanges(r io.Reader) ([]ipRange, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+
+	var ranges []ipRange
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(record) < 3 {
+			return nil, fmt.Errorf("line %d: want start,end,country, got %d columns", line, len(record))
+		}
+
+		start, err := parseAddr(record[0])
+		if err != nil {
+			if line == 1 {
+				continue
+			}
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		end, err := parseAddr(record[1])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if start.Is4() != end.Is4() || end.Less(start) {
+			return nil, fmt.Errorf("line %d: invalid range %s-%s", line, start, end)
+		}
+
+		country := strings.ToUpper(strings.TrimSpace(record[2]))
+		if !domain.ValidCountry(country) || country == "ZZ" {
+			continue
+		}
+		ranges = append(ranges, ipRange{start: start, end: end, country: country})
+	}
+
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i].start.Less(ranges[j].start) })
+	return ranges, nil
+}
+
+// parseAddr reads an IP address or its decimal number, unmapping IPv4-mapped
+// IPv6 addresses so they sort and match as IPv4
+func parseAddr(s string) (netip.Addr, error) {
+	s = strings.TrimSpace(s)
+	if addr, err := netip.ParseAddr(s); err == nil {
+		return addr.Unmap(), nil
+	}
+
+	n, ok := new(big.Int).SetString(s, 10)
+	if !ok || n.Sign() < 0 || n.BitLen() > 128 {
+		return netip.Addr{}, fmt.Errorf("invalid address %q", s)
+	}
+	if n.BitLen() <= 32 {
+		var b [4]byte
+		return netip.AddrFrom4([4]byte(n.FillBytes(b[:]))), nil
+	}
+	var b [16]byte
+	return netip.AddrFrom16([16]byte(n.FillBytes(b[:]))).Unmap(), nil
+}
+
+// Country returns the request's country code, or "" when it is unknown. The
+// header is trusted as is, so only configure one a proxy in front always sets.
+func (l *Locator) Country(r *http.Request) string {
+	return l.Locate(r).Country
+}
+
+// Locate returns the request's country and, when a MaxMind database knows it
+// and the header does not name another country, its region
+func (l *Locator) Locate(r *http.Request) Location {
+	if l == nil {
+		return Location{}
+	}
+
+	var loc Location
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	if addr, err := netip.ParseAddr(host); err == nil {
+		loc = l.LookupLocation(addr)
+	}
+
+	if l.header != "" {
+		if country := strings.ToUpper(strings.TrimSpace(r.Header.Get(l.header))); domain.ValidCountry(country) && country != loc.Country {
+			return Location{Country: country, ASN: loc.ASN}
+		}
+	}
+	return loc
+}
+
+// Lookup returns the country of the network containing addr, or ""
+func (l *Locator) Lookup(addr netip.Addr) string {
+	return l.LookupLocation(addr).Country
+}
+
+// LookupLocation returns the country, region and ASN of the network
+// containing addr. A database that cannot be read for addr resolves nothing.
+func (l *Locator) LookupLocation(addr netip.Addr) Location {
+	if l == nil {
+		return Location{}
+	}
+	addr = addr.Unmap()
+
+	loc := l.lookupCountry(addr)
+	if l.asns != nil {
+		asn, err := l.asns.asn(addr)
+		if err != nil {
+			log.Printf("Failed to look up %s in the ASN database: %v", addr, err)
+		}
+		loc.ASN = asn
+	}
+	return loc
+}
+
+// lookupCountry returns the country and region of the network containing addr
+func (l *Locator) lookupCountry(addr netip.Addr) Location {
+	if l.mmdb != nil {
+		loc, err := l.mmdb.location(addr)
+		if err != nil {
+			log.Printf("Failed to look up %s in the MaxMind database: %v", addr, err)
+			return Location{}
+		}
+		if !domain.ValidCountry(loc.Country) {
+			return Location{}
+		}
+		return loc
+	}
+
+	// The last range starting at or before addr is the only one that can contain it
+	i := sort.Search(len(l.ranges), func(i int) bool { return addr.Less(l.ranges[i].start) }) - 1
+	if i >= 0 && addr.Compare(l.ranges[i].end) <= 0 {
+		return Location{Country: l.ranges[i].country}
+	}
+	return Location{}
+}
+
+// Regions reports whether the database resolves regions as well as countries
+func (l *Locator) Regions() bool {
+	return l != nil && l.mmdb != nil
+}
+
+// Database describes the loaded database for logging, or returns "" when
+// none was loaded
+func (l *Locator) Database() string {
+	switch {
+	case l == nil:
+		return ""
+	case l.mmdb != nil:
+		return fmt.Sprintf("%s MaxMind database (%d nodes)", l.mmdb.databaseType, l.mmdb.nodeCount)
+	case l.ranges != nil:
+		return fmt.Sprintf("%d GeoIP ranges", len(l.ranges))
+	default:
+		return ""
+	}
+}
+
+// Networks describes the loaded ASN database for logging, or returns "" when
+// none was loaded
+func (l *Locator) Networks() string {
+	if l == nil || l.asns == nil {
+		return ""
+	}
+	return fmt.Sprintf("%s MaxMind database (%d nodes)", l.asns.databaseType, l.asns.nodeCount)
+}
+
+// Ranges returns the number of IP ranges loaded
+func (l *Locator) Ranges() int {
+	if l == nil {
+		return 0
+	}
+	return len(l.ranges)
+}
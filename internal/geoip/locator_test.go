@@ -0,0 +1,126 @@
+package geoip
+
+import (
+	"net/http/httptest"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testDatabase = `start_ip,end_ip,country
+1.0.0.0,1.0.0.255,AU
+"8.8.8.0","8.8.8.255","US"
+2001:db8::,2001:db8::ffff,DE
+10.0.0.0,10.255.255.255,-
+16843008,16843263,CN,China
+`
+
+func TestParseRanges(t *testing.T) {
+	ranges, err := parseRanges(strings.NewReader(testDatabase))
+	require.NoError(t, err)
+
+	locator := &Locator{ranges: ranges}
+	assert.Equal(t, 4, locator.Ranges(), "the placeholder country is skipped")
+
+	tests := []struct {
+		addr string
+		want string
+	}{
+		{"1.0.0.0", "AU"},
+		{"1.0.0.255", "AU"},
+		{"1.0.1.0", ""},
+		{"1.1.1.1", "CN"},
+		{"8.8.8.8", "US"},
+		{"::ffff:8.8.8.8", "US"},
+		{"2001:db8::1", "DE"},
+		{"2001:db8::1:0", ""},
+		{"10.1.2.3", ""},
+		{"0.0.0.1", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.addr, func(t *testing.T) {
+			assert.Equal(t, tt.want, locator.Lookup(netip.MustParseAddr(tt.addr)))
+		})
+	}
+}
+
+func TestParseRanges_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		csv  string
+	}{
+		{name: "too few columns", csv: "1.0.0.0,1.0.0.255\n"},
+		{name: "invalid address", csv: "1.0.0.0,1.0.0.255,AU\nnope,1.0.1.255,AU\n"},
+		{name: "reversed range", csv: "1.0.0.255,1.0.0.0,AU\n"},
+		{name: "mixed families", csv: "1.0.0.0,2001:db8::,AU\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseRanges(strings.NewReader(tt.csv))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestLocator_Country(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "countries.csv")
+	require.NoError(t, os.WriteFile(path, []byte(testDatabase), 0o600))
+
+	locator, err := New(Config{Database: path, CountryHeader: "CF-IPCountry"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		header     string
+		want       string
+	}{
+		{name: "database", remoteAddr: "8.8.8.8:1234", want: "US"},
+		{name: "ipv6 database", remoteAddr: "[2001:db8::1]:1234", want: "DE"},
+		{name: "header wins", remoteAddr: "8.8.8.8:1234", header: "gb", want: "GB"},
+		{name: "invalid header falls back", remoteAddr: "8.8.8.8:1234", header: "Great Britain", want: "US"},
+		{name: "unknown address", remoteAddr: "192.0.2.1:1234", want: ""},
+		{name: "unparseable address", remoteAddr: "pipe", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/abc", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.header != "" {
+				req.Header.Set("CF-IPCountry", tt.header)
+			}
+			assert.Equal(t, tt.want, locator.Country(req))
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	locator, err := New(DefaultConfig())
+	require.NoError(t, err)
+	assert.Nil(t, locator)
+
+	req := httptest.NewRequest("GET", "/abc", nil)
+	assert.Empty(t, locator.Country(req), "a nil locator resolves nothing")
+
+	// A missing database fails open, while an unreadable one is an error
+	locator, err = New(Config{Database: filepath.Join(t.TempDir(), "missing.csv")})
+	require.NoError(t, err)
+	assert.Empty(t, locator.Lookup(netip.MustParseAddr("8.8.8.8")))
+	assert.Empty(t, locator.Database())
+
+	path := filepath.Join(t.TempDir(), "broken.csv")
+	require.NoError(t, os.WriteFile(path, []byte("1.0.0.0,1.0.0.255\n"), 0o600))
+	_, err = New(Config{Database: path})
+	assert.Error(t, err)
+}
+
+func TestConfig_Validate(t *testing.T) {
+	assert.NoError(t, DefaultConfig().Validate())
+	assert.NoError(t, Config{CountryHeader: "CF-IPCountry"}.Validate())
+	assert.Error(t, Config{CountryHeader: "CF-IPCountry: US"}.Validate())
+}
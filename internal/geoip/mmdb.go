@@ -0,0 +1,382 @@
+package geoip
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"net/netip"
+	"strings"
+)
+
+// MaxMind DB format constants
+const (
+	// mmdbDataSeparator is the block of zeros between the search tree and the data section
+	mmdbDataSeparator = 16
+	// mmdbMaxDepth bounds nested maps, arrays and pointers, so a corrupt
+	// database cannot recurse without end
+	mmdbMaxDepth = 32
+)
+
+// mmdbMetadataMarker starts the metadata map at the end of a MaxMind DB file
+var mmdbMetadataMarker = []byte("\xab\xcd\xefMaxMind.com")
+
+// mmdbReader looks up addresses in a MaxMind DB (.mmdb) file held in memory,
+// such as GeoLite2-Country, GeoLite2-City or the DB-IP lite databases
+type mmdbReader struct {
+	tree         []byte
+	data         []byte
+	nodeCount    uint
+	recordSize   uint
+	ipVersion    uint
+	ipv4Start    uint // Node IPv4 lookups start at in an IPv6 tree
+	databaseType string
+}
+
+// isMMDB reports whether buf is a MaxMind DB file
+func isMMDB(buf []byte) bool {
+	return bytes.Contains(buf, mmdbMetadataMarker)
+}
+
+// newMMDBReader parses a MaxMind DB file's metadata and splits it into its
+// search tree and data section
+func newMMDBReader(buf []byte) (*mmdbReader, error) {
+	at := bytes.LastIndex(buf, mmdbMetadataMarker)
+	if at < 0 {
+		return nil, errors.New("no MaxMind DB metadata found")
+	}
+	metaSection := buf[at+len(mmdbMetadataMarker):]
+	value, _, err := (&mmdbDecoder{data: metaSection}).decode(0, 0)
+	if err != nil {
+		return nil, fmt.Errorf("invalid metadata: %w", err)
+	}
+	meta, ok := value.(map[string]any)
+	if !ok {
+		return nil, errors.New("invalid metadata: not a map")
+	}
+
+	r := &mmdbReader{
+		nodeCount:  uint(metaUint(meta, "node_count")),
+		recordSize: uint(metaUint(meta, "record_size")),
+		ipVersion:  uint(metaUint(meta, "ip_version")),
+	}
+	r.databaseType, _ = meta["database_type"].(string)
+	if major := metaUint(meta, "binary_format_major_version"); major != 2 {
+		return nil, fmt.Errorf("unsupported binary format version %d", major)
+	}
+	if r.recordSize != 24 && r.recordSize != 28 && r.recordSize != 32 {
+		return nil, fmt.Errorf("unsupported record size %d", r.recordSize)
+	}
+	if r.ipVersion != 4 && r.ipVersion != 6 {
+		return nil, fmt.Errorf("unsupported IP version %d", r.ipVersion)
+	}
+
+	treeSize := r.nodeCount * r.recordSize / 4
+	if treeSize+mmdbDataSeparator > uint(at) {
+		return nil, fmt.Errorf("search tree of %d nodes exceeds the file", r.nodeCount)
+	}
+	r.tree = buf[:treeSize]
+	r.data = buf[treeSize+mmdbDataSeparator : at]
+
+	// IPv4 addresses live under ::/96 of an IPv6 tree
+	if r.ipVersion == 6 {
+		for i := 0; i < 96 && r.ipv4Start < r.nodeCount; i++ {
+			r.ipv4Start = r.record(r.ipv4Start, 0)
+		}
+	}
+	return r, nil
+}
+
+// metaUint returns an unsigned metadata value, or 0 when it is missing
+func metaUint(meta map[string]any, key string) uint64 {
+	value, _ := meta[key].(uint64)
+	return value
+}
+
+// record returns the left (bit 0) or right (bit 1) record of a node
+func (r *mmdbReader) record(node uint, bit uint) uint {
+	switch r.recordSize {
+	case 24:
+		b := r.tree[node*6+bit*3:]
+		return uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2])
+	case 28:
+		b := r.tree[node*7:]
+		if bit == 0 {
+			return uint(b[3]&0xf0)<<20 | uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2])
+		}
+		return uint(b[3]&0x0f)<<24 | uint(b[4])<<16 | uint(b[5])<<8 | uint(b[6])
+	default:
+		return uint(binary.BigEndian.Uint32(r.tree[node*8+bit*4:]))
+	}
+}
+
+// lookup returns the data record of the network containing addr, or nil when
+// the database has none
+func (r *mmdbReader) lookup(addr netip.Addr) (map[string]any, error) {
+	addr = addr.Unmap()
+	if addr.Is6() && r.ipVersion == 4 {
+		return nil, nil
+	}
+
+	node, bits := uint(0), addr.AsSlice()
+	if addr.Is4() && r.ipVersion == 6 {
+		node = r.ipv4Start
+	}
+	for i := 0; i < len(bits)*8 && node < r.nodeCount; i++ {
+		node = r.record(node, uint(bits[i/8]>>(7-i%8)&1))
+	}
+	if node <= r.nodeCount {
+		return nil, nil // No network contains addr
+	}
+
+	offset := node - r.nodeCount - mmdbDataSeparator
+	if offset >= uint(len(r.data)) {
+		return nil, fmt.Errorf("data offset %d exceeds the data section", offset)
+	}
+	value, _, err := (&mmdbDecoder{data: r.data}).decode(offset, 0)
+	if err != nil {
+		return nil, err
+	}
+	record, _ := value.(map[string]any)
+	return record, nil
+}
+
+// location reads a visitor's country and region from a data record: the
+// country's ISO code (the registered country when the address has none, as
+// for anonymous proxies) and the ISO code of the first subdivision
+func (r *mmdbReader) location(addr netip.Addr) (Location, error) {
+	record, err := r.lookup(addr)
+	if err != nil || record == nil {
+		return Location{}, err
+	}
+
+	var loc Location
+	for _, key := range []string{"country", "registered_country"} {
+		if code := isoCode(record[key]); code != "" {
+			loc.Country = code
+			break
+		}
+	}
+	if subdivisions, ok := record["subdivisions"].([]any); ok && len(subdivisions) > 0 {
+		loc.Region = isoCode(subdivisions[0])
+	}
+	return loc, nil
+}
+
+// asn reads the autonomous system number of the network containing addr
+// from an ASN database's record, or returns 0 when it has none
+func (r *mmdbReader) asn(addr netip.Addr) (uint32, error) {
+	record, err := r.lookup(addr)
+	if err != nil || record == nil {
+		return 0, err
+	}
+	number, _ := record["autonomous_system_number"].(uint64)
+	return uint32(number), nil
+}
+
+// isoCode returns the iso_code of a country or subdivision map, uppercased
+func isoCode(value any) string {
+	entry, _ := value.(map[string]any)
+	code, _ := entry["iso_code"].(string)
+	return strings.ToUpper(code)
+}
+
+// mmdbDecoder decodes values from a MaxMind DB data section. Pointers are
+// offsets from the start of data.
+type mmdbDecoder struct {
+	data []byte
+}
+
+// MaxMind DB data types
+const (
+	mmdbExtended = iota
+	mmdbPointer
+	mmdbString
+	mmdbDouble
+	mmdbBytes
+	mmdbUint16
+	mmdbUint32
+	mmdbMap
+	mmdbInt32
+	mmdbUint64
+	mmdbUint128
+	mmdbArray
+	mmdbContainer
+	mmdbEndMarker
+	mmdbBool
+	mmdbFloat
+)
+
+// decode reads the value at offset, returning it and the offset after it.
+// Integers decode as uint64 (int64 for int32), uint128 as its 16 bytes.
+func (d *mmdbDecoder) decode(offset uint, depth int) (any, uint, error) {
+	if depth > mmdbMaxDepth {
+		return nil, 0, errors.New("data nested too deeply")
+	}
+	ctrl, err := d.byteAt(offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	offset++
+
+	kind := uint(ctrl >> 5)
+	if kind == mmdbPointer {
+		pointer, next, err := d.pointer(ctrl, offset)
+		if err != nil {
+			return nil, 0, err
+		}
+		value, _, err := d.decode(pointer, depth+1)
+		return value, next, err
+	}
+	if kind == mmdbExtended {
+		extended, err := d.byteAt(offset)
+		if err != nil {
+			return nil, 0, err
+		}
+		kind = 7 + uint(extended)
+		offset++
+	}
+
+	size, offset, err := d.size(ctrl, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	switch kind {
+	case mmdbMap:
+		m := make(map[string]any, min(size, 64))
+		for i := uint(0); i < size; i++ {
+			key, next, err := d.decode(offset, depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+			name, ok := key.(string)
+			if !ok {
+				return nil, 0, errors.New("map key is not a string")
+			}
+			if m[name], offset, err = d.decode(next, depth+1); err != nil {
+				return nil, 0, err
+			}
+		}
+		return m, offset, nil
+	case mmdbArray:
+		a := make([]any, 0, min(size, 64))
+		for i := uint(0); i < size; i++ {
+			value, next, err := d.decode(offset, depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+			a = append(a, value)
+			offset = next
+		}
+		return a, offset, nil
+	case mmdbBool:
+		return size != 0, offset, nil
+	case mmdbEndMarker, mmdbContainer:
+		return nil, offset, nil
+	}
+
+	raw, err := d.bytesAt(offset, size)
+	if err != nil {
+		return nil, 0, err
+	}
+	offset += size
+
+	switch kind {
+	case mmdbString:
+		return string(raw), offset, nil
+	case mmdbBytes, mmdbUint128:
+		return bytes.Clone(raw), offset, nil
+	case mmdbDouble:
+		if size != 8 {
+			return nil, 0, fmt.Errorf("invalid double size %d", size)
+		}
+		return math.Float64frombits(binary.BigEndian.Uint64(raw)), offset, nil
+	case mmdbFloat:
+		if size != 4 {
+			return nil, 0, fmt.Errorf("invalid float size %d", size)
+		}
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(raw))), offset, nil
+	case mmdbUint16, mmdbUint32, mmdbUint64, mmdbInt32:
+		if size > 8 {
+			return nil, 0, fmt.Errorf("invalid integer size %d", size)
+		}
+		var n uint64
+		for _, b := range raw {
+			n = n<<8 | uint64(b)
+		}
+		if kind == mmdbInt32 {
+			return int64(int32(uint32(n))), offset, nil
+		}
+		return n, offset, nil
+	}
+	return nil, 0, fmt.Errorf("unknown data type %d", kind)
+}
+
+// pointer reads a pointer's target offset and the offset after the pointer
+func (d *mmdbDecoder) pointer(ctrl byte, offset uint) (uint, uint, error) {
+	n := uint(ctrl>>3)&0x3 + 1
+	raw, err := d.bytesAt(offset, n)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var pointer uint
+	if n < 4 {
+		pointer = uint(ctrl & 0x7)
+	}
+	for _, b := range raw {
+		pointer = pointer<<8 | uint(b)
+	}
+	switch n {
+	case 2:
+		pointer += 2048
+	case 3:
+		pointer += 526336
+	}
+	return pointer, offset + n, nil
+}
+
+// size reads a value's size from its control byte and the bytes following it
+func (d *mmdbDecoder) size(ctrl byte, offset uint) (uint, uint, error) {
+	size := uint(ctrl & 0x1f)
+	if size < 29 {
+		return size, offset, nil
+	}
+
+	n := size - 28
+	raw, err := d.bytesAt(offset, n)
+	if err != nil {
+		return 0, 0, err
+	}
+	var extra uint
+	for _, b := range raw {
+		extra = extra<<8 | uint(b)
+	}
+	switch n {
+	case 1:
+		size = 29 + extra
+	case 2:
+		size = 285 + extra
+	default:
+		size = 65821 + extra
+	}
+	return size, offset + n, nil
+}
+
+// byteAt returns the byte at offset
+func (d *mmdbDecoder) byteAt(offset uint) (byte, error) {
+	if offset >= uint(len(d.data)) {
+		return 0, fmt.Errorf("offset %d exceeds the data section", offset)
+	}
+	return d.data[offset], nil
+}
+
+// bytesAt returns n bytes starting at offset
+func (d *mmdbDecoder) bytesAt(offset, n uint) ([]byte, error) {
+	if offset > uint(len(d.data)) || n > uint(len(d.data))-offset {
+		return nil, fmt.Errorf("value at offset %d exceeds the data section", offset)
+	}
+	return d.data[offset : offset+n], nil
+}
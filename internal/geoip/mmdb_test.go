@@ -0,0 +1,281 @@
+package geoip
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"net/http/httptest"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mmdbWriter builds a small MaxMind DB for tests. Values are pre-encoded with
+// the enc* helpers below.
+type mmdbWriter struct {
+	ipVersion  int
+	recordSize int
+	nodes      [][2]mmdbRecord
+	data       bytes.Buffer
+}
+
+// mmdbRecord is a search tree record: another node, data or nothing
+type mmdbRecord struct {
+	node    int // Index of the next node, when > 0
+	data    int // Offset of the data, when hasData
+	hasData bool
+}
+
+func newMMDBWriter(ipVersion, recordSize int) *mmdbWriter {
+	return &mmdbWriter{ipVersion: ipVersion, recordSize: recordSize, nodes: make([][2]mmdbRecord, 1)}
+}
+
+// addData appends an encoded value to the data section, returning its offset
+func (w *mmdbWriter) addData(value []byte) int {
+	offset := w.data.Len()
+	w.data.Write(value)
+	return offset
+}
+
+// insert points the network at the data at offset. IPv4 networks in an IPv6
+// tree go under ::/96.
+func (w *mmdbWriter) insert(network string, offset int) {
+	prefix := netip.MustParsePrefix(network)
+	bits, length := prefix.Addr().AsSlice(), prefix.Bits()
+	if prefix.Addr().Is4() && w.ipVersion == 6 {
+		bits, length = append(make([]byte, 12), bits...), length+96
+	}
+
+	node := 0
+	for i := 0; i < length; i++ {
+		bit := bits[i/8] >> (7 - i%8) & 1
+		if i == length-1 {
+			w.nodes[node][bit] = mmdbRecord{data: offset, hasData: true}
+			return
+		}
+		if w.nodes[node][bit].node == 0 {
+			w.nodes = append(w.nodes, [2]mmdbRecord{})
+			w.nodes[node][bit].node = len(w.nodes) - 1
+		}
+		node = w.nodes[node][bit].node
+	}
+}
+
+// bytes serializes the database
+func (w *mmdbWriter) bytes() []byte {
+	var buf bytes.Buffer
+	count := len(w.nodes)
+	value := func(r mmdbRecord) uint32 {
+		switch {
+		case r.hasData:
+			return uint32(count + mmdbDataSeparator + r.data)
+		case r.node > 0:
+			return uint32(r.node)
+		default:
+			return uint32(count)
+		}
+	}
+	for _, node := range w.nodes {
+		left, right := value(node[0]), value(node[1])
+		switch w.recordSize {
+		case 24:
+			buf.Write([]byte{byte(left >> 16), byte(left >> 8), byte(left), byte(right >> 16), byte(right >> 8), byte(right)})
+		case 28:
+			buf.Write([]byte{byte(left >> 16), byte(left >> 8), byte(left), byte(left>>20&0xf0 | right>>24&0x0f), byte(right >> 16), byte(right >> 8), byte(right)})
+		default:
+			buf.Write(binary.BigEndian.AppendUint32(binary.BigEndian.AppendUint32(nil, left), right))
+		}
+	}
+	buf.Write(make([]byte, mmdbDataSeparator))
+	buf.Write(w.data.Bytes())
+	buf.Write(mmdbMetadataMarker)
+	buf.Write(encMap(
+		"binary_format_major_version", encUint(mmdbUint16, 2),
+		"binary_format_minor_version", encUint(mmdbUint16, 0),
+		"database_type", encString("Test-City"),
+		"ip_version", encUint(mmdbUint16, uint64(w.ipVersion)),
+		"languages", encArray(encString("en")),
+		"node_count", encUint(mmdbUint32, uint64(count)),
+		"record_size", encUint(mmdbUint16, uint64(w.recordSize)),
+		"build_epoch", encUint(mmdbUint64, 1700000000),
+	))
+	return buf.Bytes()
+}
+
+// encControl encodes a control byte for a type and a size under 29
+func encControl(kind, size int) []byte {
+	if size >= 29 {
+		panic(fmt.Sprintf("test values must be shorter than 29, got %d", size))
+	}
+	if kind > 7 {
+		return []byte{byte(size), byte(kind - 7)}
+	}
+	return []byte{byte(kind<<5 | size)}
+}
+
+func encString(s string) []byte {
+	return append(encControl(mmdbString, len(s)), s...)
+}
+
+func encUint(kind int, v uint64) []byte {
+	var raw []byte
+	for ; v > 0; v >>= 8 {
+		raw = append([]byte{byte(v)}, raw...)
+	}
+	return append(encControl(kind, len(raw)), raw...)
+}
+
+func encMap(pairs ...any) []byte {
+	out := encControl(mmdbMap, len(pairs)/2)
+	for i := 0; i < len(pairs); i += 2 {
+		out = append(out, encString(pairs[i].(string))...)
+		out = append(out, pairs[i+1].([]byte)...)
+	}
+	return out
+}
+
+func encArray(values ...[]byte) []byte {
+	out := encControl(mmdbArray, len(values))
+	for _, value := range values {
+		out = append(out, value...)
+	}
+	return out
+}
+
+// encPointer encodes a pointer to a data offset below 2048
+func encPointer(offset int) []byte {
+	return []byte{byte(mmdbPointer<<5 | offset>>8), byte(offset)}
+}
+
+// testMMDB builds a database with a City-style record using a pointer for
+// its country, a record with only a registered country, and an IPv6 record
+func testMMDB(ipVersion, recordSize int) []byte {
+	w := newMMDBWriter(ipVersion, recordSize)
+	us := w.addData(encMap("iso_code", encString("US"), "geoname_id", encUint(mmdbUint32, 6252001)))
+	w.insert("8.8.8.0/24", w.addData(encMap(
+		"country", encPointer(us),
+		"subdivisions", encArray(encMap("iso_code", encString("CA"))),
+		"location", encMap("accuracy_radius", encUint(mmdbUint16, 1000)),
+	)))
+	w.insert("1.0.0.0/24", w.addData(encMap("registered_country", encMap("iso_code", encString("AU")))))
+	w.insert("10.0.0.0/8", w.addData(encMap("city", encMap("names", encMap("en", encString("Nowhere"))))))
+	if ipVersion == 6 {
+		w.insert("2001:db8::/32", w.addData(encMap("country", encMap("iso_code", encString("de")))))
+	}
+	return w.bytes()
+}
+
+func TestMMDBReader(t *testing.T) {
+	for _, ipVersion := range []int{4, 6} {
+		for _, recordSize := range []int{24, 28, 32} {
+			t.Run(fmt.Sprintf("ipv%d/%d-bit", ipVersion, recordSize), func(t *testing.T) {
+				reader, err := newMMDBReader(testMMDB(ipVersion, recordSize))
+				require.NoError(t, err)
+				assert.Equal(t, "Test-City", reader.databaseType)
+
+				tests := []struct {
+					addr string
+					want Location
+				}{
+					{"8.8.8.8", Location{Country: "US", Region: "CA"}},
+					{"::ffff:8.8.8.8", Location{Country: "US", Region: "CA"}},
+					{"1.0.0.1", Location{Country: "AU"}},
+					{"10.1.2.3", Location{}},
+					{"9.9.9.9", Location{}},
+				}
+				if ipVersion == 6 {
+					tests = append(tests, struct {
+						addr string
+						want Location
+					}{"2001:db8::1", Location{Country: "DE"}})
+				}
+				for _, tt := range tests {
+					loc, err := reader.location(netip.MustParseAddr(tt.addr))
+					require.NoError(t, err, tt.addr)
+					assert.Equal(t, tt.want, loc, tt.addr)
+				}
+
+				loc, err := reader.location(netip.MustParseAddr("2001:db9::1"))
+				require.NoError(t, err)
+				assert.Equal(t, Location{}, loc)
+			})
+		}
+	}
+}
+
+func TestMMDBReader_Invalid(t *testing.T) {
+	valid := testMMDB(6, 24)
+
+	_, err := newMMDBReader([]byte("start,end,country\n"))
+	assert.Error(t, err, "no metadata")
+
+	truncated := append([]byte{}, valid[:20]...)
+	truncated = append(truncated, valid[bytes.LastIndex(valid, mmdbMetadataMarker):]...)
+	_, err = newMMDBReader(truncated)
+	assert.Error(t, err, "tree exceeds the file")
+
+	// A record pointing past the data section is an error, not a panic
+	reader, err := newMMDBReader(valid)
+	require.NoError(t, err)
+	reader.data = reader.data[:4]
+	_, err = reader.location(netip.MustParseAddr("8.8.8.8"))
+	assert.Error(t, err)
+}
+
+func TestLocator_MaxMindDatabase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "city.mmdb")
+	require.NoError(t, os.WriteFile(path, testMMDB(6, 28), 0o600))
+
+	locator, err := New(Config{Database: path, CountryHeader: "CF-IPCountry"})
+	require.NoError(t, err)
+	assert.True(t, locator.Regions())
+	assert.Contains(t, locator.Database(), "Test-City MaxMind database")
+
+	tests := []struct {
+		name   string
+		header string
+		want   Location
+	}{
+		{name: "database", want: Location{Country: "US", Region: "CA"}},
+		{name: "header agrees", header: "us", want: Location{Country: "US", Region: "CA"}},
+		{name: "header wins without the database's region", header: "GB", want: Location{Country: "GB"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/abc", nil)
+			req.RemoteAddr = "8.8.8.8:1234"
+			if tt.header != "" {
+				req.Header.Set("CF-IPCountry", tt.header)
+			}
+			assert.Equal(t, tt.want, locator.Locate(req))
+			assert.Equal(t, tt.want.Country, locator.Country(req))
+		})
+	}
+}
+
+func TestLocator_ASNDatabase(t *testing.T) {
+	w := newMMDBWriter(6, 24)
+	w.insert("8.8.8.0/24", w.addData(encMap("autonomous_system_number", encUint(mmdbUint32, 15169))))
+	path := filepath.Join(t.TempDir(), "asn.mmdb")
+	require.NoError(t, os.WriteFile(path, w.bytes(), 0o600))
+
+	locator, err := New(Config{ASNDatabase: path, CountryHeader: "CF-IPCountry"})
+	require.NoError(t, err)
+	assert.Contains(t, locator.Networks(), "MaxMind database")
+	assert.False(t, locator.Regions())
+
+	req := httptest.NewRequest("GET", "/abc", nil)
+	req.RemoteAddr = "8.8.8.8:1234"
+	req.Header.Set("CF-IPCountry", "US")
+	assert.Equal(t, Location{Country: "US", ASN: 15169}, locator.Locate(req))
+	assert.Equal(t, Location{}, locator.LookupLocation(netip.MustParseAddr("9.9.9.9")))
+
+	// A missing ASN database leaves networks unknown
+	locator, err = New(Config{ASNDatabase: filepath.Join(t.TempDir(), "missing.mmdb")})
+	require.NoError(t, err)
+	assert.Empty(t, locator.Networks())
+}
@@ -0,0 +1,28 @@
+package hosts
+
+import (
+	"fmt"
+	"time"
+)
+
+// Config holds custom domain configuration
+type Config struct {
+	Enabled bool          // Serve links on the custom domains added through the API
+	Refresh time.Duration // How often domains other instances added through the API are picked up; 0 only at start
+}
+
+// DefaultConfig returns the default configuration, which serves links only
+// on the server's own host
+func DefaultConfig() Config {
+	return Config{
+		Refresh: time.Minute,
+	}
+}
+
+// Validate checks that the configuration values are usable
+func (c Config) Validate() error {
+	if c.Refresh < 0 {
+		return fmt.Errorf("refresh interval cannot be negative, got: %v", c.Refresh)
+	}
+	return nil
+}
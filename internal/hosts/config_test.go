@@ -0,0 +1,15 @@
+package hosts
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfig_Validate(t *testing.T) {
+	assert.NoError(t, DefaultConfig().Validate())
+	assert.False(t, DefaultConfig().Enabled)
+	assert.NoError(t, Config{Enabled: true}.Validate())
+	assert.ErrorContains(t, Config{Enabled: true, Refresh: -time.Second}.Validate(), "cannot be negative")
+}
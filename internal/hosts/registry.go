@@ -0,0 +1,255 @@
+package hosts
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	"github.com/joshdurbin/url-shortener/internal/repository"
+)
+
+// refreshTimeout bounds reloading the stored domains
+const refreshTimeout = 30 * time.Second
+
+// maxBrandName is the longest brand name shown on a domain's pages
+const maxBrandName = 64
+
+// Registry holds the custom domains links are served on. Domains are added
+// through the API and stored, so every instance sharing the database serves
+// them after its next refresh; redirects look them up in memory. A nil
+// Registry has no domains.
+type Registry struct {
+	config  Config
+	store   repository.DomainRepository
+	primary string // The server's own host, which is never a custom domain
+	now     func() time.Time
+
+	mutex    sync.RWMutex
+	domains  map[string]domain.CustomDomain // Cached copy of the stored domains by host
+	started  bool
+	closed   bool
+	stopChan chan struct{}
+	wg       sync.WaitGroup
+}
+
+// New creates a registry for a server whose own base URL is serverURL, or
+// returns nil when custom domains are off
+func New(config Config, store repository.DomainRepository, serverURL string) *Registry {
+	if !config.Enabled {
+		return nil
+	}
+
+	r := &Registry{
+		config:   config,
+		store:    store,
+		now:      time.Now,
+		domains:  make(map[string]domain.CustomDomain),
+		stopChan: make(chan struct{}),
+	}
+	if u, err := url.Parse(serverURL); err == nil {
+		r.primary = Normalize(u.Host)
+	}
+	return r
+}
+
+// Normalize returns host lowercased, without a port or trailing dot, as
+// domains are stored and looked up
+func Normalize(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(host)), ".")
+}
+
+// Start loads the stored domains and keeps them fresh every refresh interval
+func (r *Registry) Start(ctx context.Context) error {
+	if r == nil {
+		return nil
+	}
+	if err := r.reload(ctx); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.started {
+		return fmt.Errorf("domain registry already started")
+	}
+	r.started = true
+
+	if r.config.Refresh > 0 {
+		r.wg.Add(1)
+		go r.refreshLoop()
+	}
+	return nil
+}
+
+// Close stops refreshing the stored domains
+func (r *Registry) Close() error {
+	if r == nil {
+		return nil
+	}
+
+	r.mutex.Lock()
+	if r.closed {
+		r.mutex.Unlock()
+		return nil
+	}
+	r.closed = true
+	close(r.stopChan)
+	r.mutex.Unlock()
+
+	r.wg.Wait()
+	return nil
+}
+
+// refreshLoop reloads the stored domains every refresh interval until closed
+func (r *Registry) refreshLoop() {
+	defer r.wg.Done()
+
+	ticker := time.NewTicker(r.config.Refresh)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.stopChan:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), refreshTimeout)
+			if err := r.reload(ctx); err != nil {
+				log.Printf("Error refreshing custom domains: %v", err)
+			}
+			cancel()
+		}
+	}
+}
+
+// reload replaces the cached copy of the stored domains
+func (r *Registry) reload(ctx context.Context) error {
+	stored, err := r.store.ListDomains(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load custom domains: %w", err)
+	}
+	domains := make(map[string]domain.CustomDomain, len(stored))
+	for _, d := range stored {
+		domains[d.Host] = d
+	}
+
+	r.mutex.Lock()
+	r.domains = domains
+	r.mutex.Unlock()
+	return nil
+}
+
+// Lookup returns the custom domain for a request's Host header, which may
+// carry a port
+func (r *Registry) Lookup(host string) (domain.CustomDomain, bool) {
+	if r == nil {
+		return domain.CustomDomain{}, false
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	d, ok := r.domains[Normalize(host)]
+	return d, ok
+}
+
+// Domains returns every custom domain ordered by host
+func (r *Registry) Domains() []domain.CustomDomain {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	domains := make([]domain.CustomDomain, 0, len(r.domains))
+	for _, d := range r.domains {
+		domains = append(domains, d)
+	}
+	sort.Slice(domains, func(i, j int) bool { return domains[i].Host < domains[j].Host })
+	return domains
+}
+
+// Add stores a new custom domain
+func (r *Registry) Add(ctx context.Context, d domain.CustomDomain) (*domain.CustomDomain, error) {
+	d.Host = Normalize(d.Host)
+	if err := r.validate(d); err != nil {
+		return nil, err
+	}
+	d.CreatedAt = r.now().UTC()
+
+	if err := r.store.CreateDomain(ctx, d); err != nil {
+		return nil, err
+	}
+	if err := r.reload(ctx); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// Update changes a custom domain's redirect status and branding
+func (r *Registry) Update(ctx context.Context, host string, req domain.UpdateDomainRequest) (*domain.CustomDomain, error) {
+	d, ok := r.Lookup(host)
+	if !ok {
+		// It may have been added by another instance since the last refresh
+		if err := r.reload(ctx); err != nil {
+			return nil, err
+		}
+		if d, ok = r.Lookup(host); !ok {
+			return nil, domain.ErrDomainNotFound
+		}
+	}
+
+	if req.RedirectStatus != nil {
+		d.RedirectStatus = *req.RedirectStatus
+	}
+	if req.Branding != nil {
+		d.Branding = *req.Branding
+	}
+	if err := r.validate(d); err != nil {
+		return nil, err
+	}
+
+	if err := r.store.UpdateDomain(ctx, d); err != nil {
+		return nil, err
+	}
+	if err := r.reload(ctx); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// Remove deletes a custom domain that no longer has links
+func (r *Registry) Remove(ctx context.Context, host string) error {
+	if err := r.store.DeleteDomain(ctx, Normalize(host)); err != nil {
+		return err
+	}
+	return r.reload(ctx)
+}
+
+// validate checks a domain's host, redirect status and branding
+func (r *Registry) validate(d domain.CustomDomain) error {
+	switch {
+	case !domain.ValidHost(d.Host):
+		return domain.Invalid("host", fmt.Errorf("host must be a domain name such as go.example.com, got: %q", d.Host))
+	case d.Host == r.primary:
+		return domain.Invalid("host", fmt.Errorf("%s is the server's own host", d.Host))
+	case d.RedirectStatus != 0 && !domain.ValidRedirectStatus(d.RedirectStatus):
+		return domain.Invalid("redirect_status", fmt.Errorf("redirect status must be 301, 302, 307 or 308, got: %d", d.RedirectStatus))
+	case len(d.Branding.Name) > maxBrandName:
+		return domain.Invalid("branding", fmt.Errorf("brand name cannot be longer than %d characters", maxBrandName))
+	case d.Branding.Color != "" && !domain.ValidColor(d.Branding.Color):
+		return domain.Invalid("branding", fmt.Errorf("brand color must be #rgb or #rrggbb, got: %q", d.Branding.Color))
+	}
+	if d.Branding.LogoURL != "" {
+		u, err := url.Parse(d.Branding.LogoURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return domain.Invalid("branding", fmt.Errorf("brand logo must be an http or https URL, got: %q", d.Branding.LogoURL))
+		}
+	}
+	return nil
+}
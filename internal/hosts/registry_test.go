@@ -0,0 +1,128 @@
+package hosts
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	"github.com/joshdurbin/url-shortener/internal/repository/mocks"
+)
+
+var testCreatedAt = time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func newTestRegistry(t *testing.T, stored []domain.CustomDomain) (*Registry, *mocks.DomainRepository) {
+	t.Helper()
+	store := &mocks.DomainRepository{}
+	store.On("ListDomains", mock.Anything).Return(stored, nil).Once()
+	registry := New(Config{Enabled: true}, store, "https://sho.rt:8443")
+	require.NotNil(t, registry)
+	registry.now = func() time.Time { return testCreatedAt }
+	require.NoError(t, registry.Start(context.Background()))
+	t.Cleanup(func() { registry.Close() })
+	return registry, store
+}
+
+func TestNew_Off(t *testing.T) {
+	registry := New(DefaultConfig(), nil, "http://localhost:8080")
+	assert.Nil(t, registry)
+
+	// A nil registry has no domains
+	assert.NoError(t, registry.Start(context.Background()))
+	_, ok := registry.Lookup("go.example.com")
+	assert.False(t, ok)
+	assert.NoError(t, registry.Close())
+}
+
+func TestNormalize(t *testing.T) {
+	assert.Equal(t, "go.example.com", Normalize("Go.Example.COM"))
+	assert.Equal(t, "go.example.com", Normalize("go.example.com:8080"))
+	assert.Equal(t, "go.example.com", Normalize("go.example.com."))
+	assert.Equal(t, "::1", Normalize("[::1]:8080"))
+}
+
+func TestRegistry_Lookup(t *testing.T) {
+	registry, _ := newTestRegistry(t, []domain.CustomDomain{
+		{Host: "go.example.com", RedirectStatus: 301},
+		{Host: "a.example.org"},
+	})
+
+	d, ok := registry.Lookup("GO.example.com:443")
+	require.True(t, ok)
+	assert.Equal(t, 301, d.RedirectStatus)
+	_, ok = registry.Lookup("sho.rt")
+	assert.False(t, ok)
+
+	domains := registry.Domains()
+	require.Len(t, domains, 2)
+	assert.Equal(t, "a.example.org", domains[0].Host)
+}
+
+func TestRegistry_Add(t *testing.T) {
+	registry, store := newTestRegistry(t, nil)
+	ctx := context.Background()
+
+	added := domain.CustomDomain{
+		Host:      "go.example.com",
+		Branding:  domain.Branding{Name: "Example", Color: "#336699", LogoURL: "https://example.com/logo.png"},
+		CreatedAt: testCreatedAt,
+	}
+	store.On("CreateDomain", ctx, added).Return(nil).Once()
+	store.On("ListDomains", ctx).Return([]domain.CustomDomain{added}, nil).Once()
+
+	d, err := registry.Add(ctx, domain.CustomDomain{Host: "Go.Example.com.", Branding: added.Branding})
+	require.NoError(t, err)
+	assert.Equal(t, added, *d)
+	_, ok := registry.Lookup("go.example.com")
+	assert.True(t, ok)
+
+	invalid := []domain.CustomDomain{
+		{Host: "localhost"},
+		{Host: "go.example.com/path"},
+		{Host: "sho.rt"},
+		{Host: "go.example.com", RedirectStatus: 200},
+		{Host: "go.example.com", Branding: domain.Branding{Color: "red; background: url(x)"}},
+		{Host: "go.example.com", Branding: domain.Branding{LogoURL: "javascript:alert(1)"}},
+		{Host: "go.example.com", Branding: domain.Branding{Name: string(make([]byte, 65))}},
+	}
+	for _, d := range invalid {
+		_, err := registry.Add(ctx, d)
+		assert.ErrorIs(t, err, domain.ErrValidation, d.Host)
+	}
+	store.AssertExpectations(t)
+}
+
+func TestRegistry_UpdateAndRemove(t *testing.T) {
+	stored := domain.CustomDomain{Host: "go.example.com", Branding: domain.Branding{Name: "Example"}, CreatedAt: testCreatedAt}
+	registry, store := newTestRegistry(t, []domain.CustomDomain{stored})
+	ctx := context.Background()
+
+	updated := stored
+	updated.RedirectStatus = 308
+	store.On("UpdateDomain", ctx, updated).Return(nil).Once()
+	store.On("ListDomains", ctx).Return([]domain.CustomDomain{updated}, nil).Once()
+
+	status := 308
+	d, err := registry.Update(ctx, "go.example.com", domain.UpdateDomainRequest{RedirectStatus: &status})
+	require.NoError(t, err)
+	assert.Equal(t, updated, *d)
+
+	// Unknown domains are looked for in the database before giving up
+	store.On("ListDomains", ctx).Return([]domain.CustomDomain{updated}, nil).Once()
+	_, err = registry.Update(ctx, "nope.example.com", domain.UpdateDomainRequest{})
+	assert.ErrorIs(t, err, domain.ErrDomainNotFound)
+
+	store.On("DeleteDomain", ctx, "go.example.com").Return(domain.ErrDomainInUse).Once()
+	assert.ErrorIs(t, registry.Remove(ctx, "GO.example.com"), domain.ErrConflict)
+
+	store.On("DeleteDomain", ctx, "go.example.com").Return(nil).Once()
+	store.On("ListDomains", ctx).Return([]domain.CustomDomain{}, nil).Once()
+	require.NoError(t, registry.Remove(ctx, "go.example.com"))
+	_, ok := registry.Lookup("go.example.com")
+	assert.False(t, ok)
+	store.AssertExpectations(t)
+}
@@ -0,0 +1,51 @@
+package mailer
+
+import (
+	"fmt"
+	"net"
+)
+
+// Config holds broken link email configuration
+type Config struct {
+	Addr      string   // SMTP server as host:port; empty sends no email
+	From      string   // Sender address
+	To        []string // Recipient addresses
+	Username  string   // SMTP username; empty sends without authentication
+	Password  string   // SMTP password
+	QueueSize int      // Emails waiting to be sent before more are dropped
+}
+
+// DefaultConfig returns the default configuration, which sends no email
+func DefaultConfig() Config {
+	return Config{
+		QueueSize: 100,
+	}
+}
+
+// Enabled reports whether email is sent
+func (c Config) Enabled() bool {
+	return c.Addr != ""
+}
+
+// Validate checks that the configuration values are usable
+func (c Config) Validate() error {
+	if !c.Enabled() {
+		return nil
+	}
+	if _, _, err := net.SplitHostPort(c.Addr); err != nil {
+		return fmt.Errorf("SMTP address must be host:port, got: %q", c.Addr)
+	}
+	if c.From == "" {
+		return fmt.Errorf("sender address is required")
+	}
+	if len(c.To) == 0 {
+		return fmt.Errorf("at least one recipient is required")
+	}
+	if c.Password != "" && c.Username == "" {
+		return fmt.Errorf("SMTP password requires a username")
+	}
+	if c.QueueSize < 1 {
+		return fmt.Errorf("queue size must be at least 1, got: %d", c.QueueSize)
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+package mailer
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"net"
+	"net/smtp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+)
+
+// sendFunc sends one message; smtp.SendMail outside tests
+type sendFunc func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+
+// Mailer emails the configured recipients when a link breaks or is flagged
+// for click fraud. Events are
+// queued by Notify and sent one at a time, so a slow SMTP server never holds
+// up the event bus.
+type Mailer struct {
+	config Config
+	auth   smtp.Auth
+	send   sendFunc
+	now    func() time.Time
+
+	mutex    sync.RWMutex
+	started  bool
+	closed   bool
+	queue    chan domain.Event
+	stopChan chan struct{}
+	wg       sync.WaitGroup
+}
+
+// New creates a mailer, or returns nil when email is off; Start, Close and
+// Notify of a nil mailer do nothing
+func New(config Config) *Mailer {
+	if !config.Enabled() {
+		return nil
+	}
+
+	m := &Mailer{
+		config:   config,
+		send:     smtp.SendMail,
+		now:      time.Now,
+		queue:    make(chan domain.Event, config.QueueSize),
+		stopChan: make(chan struct{}),
+	}
+	if config.Username != "" {
+		host, _, _ := net.SplitHostPort(config.Addr)
+		m.auth = smtp.PlainAuth("", config.Username, config.Password, host)
+	}
+	return m
+}
+
+// Start starts the worker sending queued emails
+func (m *Mailer) Start() error {
+	if m == nil {
+		return nil
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.started {
+		return fmt.Errorf("mailer already started")
+	}
+	m.started = true
+
+	m.wg.Add(1)
+	go m.worker()
+	return nil
+}
+
+// Close stops the worker once the email being sent, if any, is sent; queued
+// emails are dropped
+func (m *Mailer) Close() error {
+	if m == nil {
+		return nil
+	}
+
+	m.mutex.Lock()
+	if m.closed {
+		m.mutex.Unlock()
+		return nil
+	}
+	m.closed = true
+	close(m.stopChan)
+	m.mutex.Unlock()
+
+	m.wg.Wait()
+	return nil
+}
+
+// Notify queues an email for each url.broken and url.flagged event without
+// blocking; events are dropped when the queue is full
+func (m *Mailer) Notify(event domain.Event) {
+	if m == nil || (event.Type != domain.EventURLBroken && event.Type != domain.EventURLFlagged) {
+		return
+	}
+
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	if !m.started || m.closed {
+		return
+	}
+
+	select {
+	case m.queue <- event:
+	default:
+		log.Printf("Email queue full, dropping the %s alert for %s", event.Type, event.Data.ShortCode)
+	}
+}
+
+// worker sends queued emails until the mailer is closed
+func (m *Mailer) worker() {
+	defer m.wg.Done()
+
+	for {
+		select {
+		case <-m.stopChan:
+			return
+		case event := <-m.queue:
+			if err := m.send(m.config.Addr, m.auth, m.config.From, m.config.To, m.message(event)); err != nil {
+				log.Printf("Error emailing the %s alert for %s: %v", event.Type, event.Data.ShortCode, err)
+			}
+		}
+	}
+}
+
+// message formats the plain text email for a broken or flagged link
+func (m *Mailer) message(event domain.Event) []byte {
+	data := event.Data
+
+	var b bytes.Buffer
+	header := func(name, value string) {
+		// Values come from links, so line breaks must not start new headers
+		value = strings.NewReplacer("\r", " ", "\n", " ").Replace(value)
+		fmt.Fprintf(&b, "%s: %s\r\n", name, value)
+	}
+	header("From", m.config.From)
+	header("To", strings.Join(m.config.To, ", "))
+	subject := "Broken link: "
+	if event.Type == domain.EventURLFlagged {
+		subject = "Suspicious clicks: "
+	}
+	header("Subject", subject+data.ShortCode)
+	header("Date", m.now().Format(time.RFC1123Z))
+	header("MIME-Version", "1.0")
+	header("Content-Type", "text/plain; charset=utf-8")
+	b.WriteString("\r\n")
+
+	if event.Type == domain.EventURLFlagged {
+		fmt.Fprintf(&b, "Short link %s was flagged for clicks that look like click fraud.\r\n\r\n", data.ShortCode)
+		fmt.Fprintf(&b, "Reason: %s\r\n", data.Reason)
+		fmt.Fprintf(&b, "Flagged: %s\r\n", event.CreatedAt.UTC().Format(time.RFC3339))
+		return b.Bytes()
+	}
+	fmt.Fprintf(&b, "The destination of short link %s stopped answering.\r\n\r\n", data.ShortCode)
+	fmt.Fprintf(&b, "Destination: %s\r\n", data.OriginalURL)
+	fmt.Fprintf(&b, "Reason: %s\r\n", data.Reason)
+	if data.Campaign != "" {
+		fmt.Fprintf(&b, "Campaign: %s\r\n", data.Campaign)
+	}
+	if data.BackupURL != "" {
+		fmt.Fprintf(&b, "Backup URL: %s\r\n", data.BackupURL)
+	}
+	fmt.Fprintf(&b, "Clicks: %d\r\n", data.UsageCount)
+	fmt.Fprintf(&b, "Checked: %s\r\n", event.CreatedAt.UTC().Format(time.RFC3339))
+	return b.Bytes()
+}
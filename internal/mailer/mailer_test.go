@@ -0,0 +1,147 @@
+package mailer
+
+import (
+	"net/smtp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+)
+
+// sentMessage is one message handed to the send function
+type sentMessage struct {
+	addr string
+	auth smtp.Auth
+	from string
+	to   []string
+	msg  string
+}
+
+func testConfig() Config {
+	config := DefaultConfig()
+	config.Addr = "smtp.example.com:587"
+	config.From = "alerts@example.com"
+	config.To = []string{"ops@example.com", "web@example.com"}
+	return config
+}
+
+func TestConfig_Validate(t *testing.T) {
+	assert.NoError(t, DefaultConfig().Validate())
+	assert.False(t, DefaultConfig().Enabled())
+	assert.NoError(t, testConfig().Validate())
+
+	config := testConfig()
+	config.Addr = "smtp.example.com"
+	assert.ErrorContains(t, config.Validate(), "host:port")
+
+	config = testConfig()
+	config.From = ""
+	assert.ErrorContains(t, config.Validate(), "sender address is required")
+
+	config = testConfig()
+	config.To = nil
+	assert.ErrorContains(t, config.Validate(), "at least one recipient")
+
+	config = testConfig()
+	config.Password = "secret"
+	assert.ErrorContains(t, config.Validate(), "requires a username")
+
+	config = testConfig()
+	config.QueueSize = 0
+	assert.ErrorContains(t, config.Validate(), "queue size")
+}
+
+func TestNew_Off(t *testing.T) {
+	m := New(DefaultConfig())
+	assert.Nil(t, m)
+
+	assert.NoError(t, m.Start())
+	m.Notify(domain.Event{Type: domain.EventURLBroken})
+	assert.NoError(t, m.Close())
+}
+
+func TestMailer_Notify(t *testing.T) {
+	config := testConfig()
+	config.Username = "alerts"
+	config.Password = "secret"
+	m := New(config)
+	require.NotNil(t, m)
+	assert.NotNil(t, m.auth)
+	m.now = func() time.Time { return time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC) }
+
+	sent := make(chan sentMessage, 2)
+	m.send = func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		sent <- sentMessage{addr: addr, auth: a, from: from, to: to, msg: string(msg)}
+		return nil
+	}
+	require.NoError(t, m.Start())
+	defer m.Close()
+
+	m.Notify(domain.Event{Type: domain.EventURLCreated, Data: domain.EventData{ShortCode: "new"}})
+	m.Notify(domain.Event{
+		Type:      domain.EventURLBroken,
+		CreatedAt: time.Date(2026, 3, 1, 11, 59, 0, 0, time.UTC),
+		Data: domain.EventData{
+			ShortCode:   "abc123",
+			OriginalURL: "https://gone.example.com/page",
+			Campaign:    "spring",
+			UsageCount:  42,
+			Reason:      "status 404",
+		},
+	})
+
+	select {
+	case message := <-sent:
+		assert.Equal(t, "smtp.example.com:587", message.addr)
+		assert.Equal(t, m.auth, message.auth)
+		assert.Equal(t, "alerts@example.com", message.from)
+		assert.Equal(t, config.To, message.to)
+		assert.Contains(t, message.msg, "To: ops@example.com, web@example.com\r\n")
+		assert.Contains(t, message.msg, "Subject: Broken link: abc123\r\n")
+		assert.Contains(t, message.msg, "Date: Sun, 01 Mar 2026 12:00:00 +0000\r\n")
+		assert.Contains(t, message.msg, "Destination: https://gone.example.com/page\r\n")
+		assert.Contains(t, message.msg, "Reason: status 404\r\n")
+		assert.Contains(t, message.msg, "Campaign: spring\r\n")
+		assert.Contains(t, message.msg, "Clicks: 42\r\n")
+		assert.Contains(t, message.msg, "Checked: 2026-03-01T11:59:00Z\r\n")
+		assert.NotContains(t, message.msg, "Backup URL")
+	case <-time.After(5 * time.Second):
+		t.Fatal("the broken link alert was not sent")
+	}
+
+	// Only url.broken and url.flagged events are emailed
+	select {
+	case message := <-sent:
+		t.Fatalf("unexpected email: %s", message.msg)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestMailer_FlaggedMessage(t *testing.T) {
+	m := New(testConfig())
+	msg := string(m.message(domain.Event{
+		Type:      domain.EventURLFlagged,
+		CreatedAt: time.Date(2026, 3, 1, 11, 59, 0, 0, time.UTC),
+		Data:      domain.EventData{ShortCode: "abc123", Reason: "client_spike: 101 clicks from 203.0.113.7 within 1m0s"},
+	}))
+	assert.Contains(t, msg, "Subject: Suspicious clicks: abc123\r\n")
+	assert.Contains(t, msg, "Reason: client_spike: 101 clicks from 203.0.113.7 within 1m0s\r\n")
+	assert.Contains(t, msg, "Flagged: 2026-03-01T11:59:00Z\r\n")
+	assert.NotContains(t, msg, "Destination:")
+}
+
+func TestMailer_HeaderInjection(t *testing.T) {
+	config := testConfig()
+	m := New(config)
+	msg := string(m.message(domain.Event{
+		Type: domain.EventURLBroken,
+		Data: domain.EventData{ShortCode: "abc\r\nBcc: someone@example.com"},
+	}))
+	headers, _, _ := strings.Cut(msg, "\r\n\r\n")
+	assert.Contains(t, headers, "Subject: Broken link: abc  Bcc: someone@example.com")
+	assert.NotContains(t, headers, "\r\nBcc:")
+}
@@ -0,0 +1,233 @@
+// Package peers keeps the memory caches of instances sharing one database
+// consistent. An instance that changes or deletes a link tells its peers,
+// which reload the link's cached settings from the database.
+package peers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Path is where instances receive invalidations
+const Path = "/internal/cache/invalidate"
+
+// maxBatch bounds how many short codes one invalidation carries
+const maxBatch = 100
+
+// Invalidation is the body of a request to Path
+type Invalidation struct {
+	ShortCodes []string `json:"short_codes"`
+}
+
+// Broadcaster sends the short codes of changed links to every peer. Changes
+// are queued without blocking the caller and sent in batches by a single
+// goroutine; a peer that misses one keeps serving the old settings until its
+// entry is reloaded or expires. A nil Broadcaster sends nothing.
+type Broadcaster struct {
+	config Config
+	client *http.Client
+
+	mu       sync.Mutex
+	started  bool
+	closed   bool
+	queue    chan string
+	stopChan chan struct{}
+	done     chan struct{}
+
+	sent    atomic.Uint64
+	failed  atomic.Uint64
+	dropped atomic.Uint64
+	failing atomic.Bool // Whether the last request to a peer failed
+}
+
+// New creates a broadcaster, or returns nil when there are no peers
+func New(config Config) *Broadcaster {
+	if len(config.Peers) == 0 {
+		return nil
+	}
+	return &Broadcaster{
+		config:   config,
+		client:   &http.Client{Timeout: config.Timeout},
+		queue:    make(chan string, config.QueueSize),
+		stopChan: make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// Invalidate queues a changed link's short code for the peers, dropping it
+// when the queue is full
+func (b *Broadcaster) Invalidate(shortCode string) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.started || b.closed {
+		return
+	}
+	select {
+	case b.queue <- shortCode:
+	default:
+		b.dropped.Add(1)
+	}
+}
+
+// Start starts sending queued short codes
+func (b *Broadcaster) Start(ctx context.Context) error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.started {
+		return fmt.Errorf("peer broadcaster already started")
+	}
+	b.started = true
+	go b.run()
+	return nil
+}
+
+// Close stops queueing short codes and sends the ones already queued
+func (b *Broadcaster) Close() error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	if !b.started || b.closed {
+		b.mu.Unlock()
+		return nil
+	}
+	b.closed = true
+	close(b.stopChan)
+	b.mu.Unlock()
+
+	<-b.done
+	return nil
+}
+
+// run sends whatever is queued, up to a batch, each time a short code
+// arrives, until the broadcaster is closed; then it sends what is left
+func (b *Broadcaster) run() {
+	defer close(b.done)
+
+	for {
+		select {
+		case shortCode := <-b.queue:
+			b.broadcast(b.batch(shortCode))
+		case <-b.stopChan:
+			for {
+				select {
+				case shortCode := <-b.queue:
+					b.broadcast(b.batch(shortCode))
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// batch collects first and the distinct short codes queued behind it
+func (b *Broadcaster) batch(first string) []string {
+	seen := map[string]bool{first: true}
+	shortCodes := []string{first}
+	for len(shortCodes) < maxBatch {
+		select {
+		case shortCode := <-b.queue:
+			if !seen[shortCode] {
+				seen[shortCode] = true
+				shortCodes = append(shortCodes, shortCode)
+			}
+		default:
+			return shortCodes
+		}
+	}
+	return shortCodes
+}
+
+// broadcast sends one invalidation to every peer, counting failures
+func (b *Broadcaster) broadcast(shortCodes []string) {
+	body, err := json.Marshal(Invalidation{ShortCodes: shortCodes})
+	if err != nil {
+		log.Printf("Failed to encode cache invalidation: %v", err)
+		return
+	}
+
+	for _, peer := range b.config.Peers {
+		// Only changes are logged, so a peer that is down does not flood the log
+		if err := b.send(peer, body); err != nil {
+			b.failed.Add(1)
+			if !b.failing.Swap(true) {
+				log.Printf("Failed to send cache invalidation to %s: %v", peer, err)
+			}
+			continue
+		}
+		b.sent.Add(1)
+		if b.failing.Swap(false) {
+			log.Printf("Cache invalidations are reaching peers again")
+		}
+	}
+}
+
+// send makes a single signed request to a peer
+func (b *Broadcaster) send(peer string, body []byte) error {
+	timestamp := time.Now().Unix()
+
+	req, err := http.NewRequest(http.MethodPost, strings.TrimSuffix(peer, "/")+Path, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "url-shortener-peers")
+	req.Header.Set(HeaderTimestamp, fmt.Sprintf("%d", timestamp))
+	req.Header.Set(HeaderSignature, Sign(b.config.Secret, timestamp, body))
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// Drain a bounded amount so the connection can be reused
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("peer returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Sent returns how many invalidations peers accepted
+func (b *Broadcaster) Sent() uint64 {
+	if b == nil {
+		return 0
+	}
+	return b.sent.Load()
+}
+
+// Failed returns how many invalidations did not reach a peer
+func (b *Broadcaster) Failed() uint64 {
+	if b == nil {
+		return 0
+	}
+	return b.failed.Load()
+}
+
+// Dropped returns how many changed short codes were lost because the queue was full
+func (b *Broadcaster) Dropped() uint64 {
+	if b == nil {
+		return 0
+	}
+	return b.dropped.Load()
+}
@@ -0,0 +1,171 @@
+package peers
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// peer records the invalidations it receives
+type peer struct {
+	mu       sync.Mutex
+	received [][]string
+	status   int
+}
+
+func (p *peer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	if r.URL.Path != Path || !Verify("secret", r.Header.Get(HeaderTimestamp), r.Header.Get(HeaderSignature), body, time.Now()) {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	var invalidation Invalidation
+	if err := json.Unmarshal(body, &invalidation); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.received = append(p.received, invalidation.ShortCodes)
+	if p.status != 0 {
+		w.WriteHeader(p.status)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (p *peer) shortCodes() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var shortCodes []string
+	for _, batch := range p.received {
+		shortCodes = append(shortCodes, batch...)
+	}
+	return shortCodes
+}
+
+func testConfig(peers ...string) Config {
+	config := DefaultConfig()
+	config.Peers = peers
+	config.Secret = "secret"
+	return config
+}
+
+func TestConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr string
+	}{
+		{name: "default", config: DefaultConfig()},
+		{name: "receive only", config: Config{Secret: "secret"}},
+		{name: "peers", config: testConfig("http://10.0.0.2:8080", "https://b.internal")},
+		{name: "missing secret", config: Config{Peers: []string{"http://10.0.0.2:8080"}}, wantErr: "shared secret"},
+		{name: "relative peer", config: testConfig("10.0.0.2:8080"), wantErr: "http or https URL"},
+		{name: "zero timeout", config: Config{Peers: []string{"http://a"}, Secret: "secret", QueueSize: 1}, wantErr: "timeout must be positive"},
+		{name: "zero queue", config: Config{Peers: []string{"http://a"}, Secret: "secret", Timeout: time.Second}, wantErr: "queue size must be at least 1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestVerify(t *testing.T) {
+	body := []byte(`{"short_codes":["abc123"]}`)
+	now := time.Now()
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	signature := Sign("secret", now.Unix(), body)
+
+	assert.True(t, Verify("secret", timestamp, signature, body, now))
+	assert.False(t, Verify("other", timestamp, signature, body, now), "wrong secret")
+	assert.False(t, Verify("secret", timestamp, signature, []byte(`{"short_codes":["xyz789"]}`), now), "changed body")
+	assert.False(t, Verify("secret", timestamp, signature, body, now.Add(MaxSkew+time.Minute)), "replayed")
+	assert.False(t, Verify("secret", "yesterday", signature, body, now), "malformed timestamp")
+}
+
+func TestNew_NoPeers(t *testing.T) {
+	broadcaster := New(Config{Secret: "secret"})
+	assert.Nil(t, broadcaster)
+
+	// A nil broadcaster sends nothing
+	require.NoError(t, broadcaster.Start(context.Background()))
+	broadcaster.Invalidate("abc123")
+	require.NoError(t, broadcaster.Close())
+	assert.Zero(t, broadcaster.Sent())
+}
+
+func TestBroadcaster_SendsToEveryPeer(t *testing.T) {
+	first, second := &peer{}, &peer{}
+	firstServer := httptest.NewServer(first)
+	defer firstServer.Close()
+	secondServer := httptest.NewServer(second)
+	defer secondServer.Close()
+
+	broadcaster := New(testConfig(firstServer.URL, secondServer.URL+"/"))
+	broadcaster.Invalidate("ignored") // Not started yet
+	require.NoError(t, broadcaster.Start(context.Background()))
+	broadcaster.Invalidate("abc123")
+	broadcaster.Invalidate("xyz789")
+	require.NoError(t, broadcaster.Close())
+
+	for _, p := range []*peer{first, second} {
+		assert.ElementsMatch(t, []string{"abc123", "xyz789"}, p.shortCodes())
+	}
+	assert.Equal(t, broadcaster.Sent(), uint64(len(first.received)+len(second.received)))
+	assert.Zero(t, broadcaster.Failed())
+
+	// Closed broadcasters queue nothing
+	broadcaster.Invalidate("late01")
+	assert.NotContains(t, first.shortCodes(), "late01")
+}
+
+func TestBroadcaster_CountsFailures(t *testing.T) {
+	failing := &peer{status: http.StatusInternalServerError}
+	server := httptest.NewServer(failing)
+	defer server.Close()
+
+	broadcaster := New(testConfig(server.URL))
+	require.NoError(t, broadcaster.Start(context.Background()))
+	broadcaster.Invalidate("abc123")
+	require.NoError(t, broadcaster.Close())
+
+	assert.Equal(t, uint64(1), broadcaster.Failed())
+	assert.Zero(t, broadcaster.Sent())
+}
+
+func TestBroadcaster_Batch(t *testing.T) {
+	broadcaster := New(testConfig("http://peer"))
+	for _, shortCode := range []string{"xyz789", "abc123", "xyz789"} {
+		broadcaster.queue <- shortCode
+	}
+	assert.Equal(t, []string{"abc123", "xyz789"}, broadcaster.batch("abc123"))
+}
+
+func TestBroadcaster_DropsWhenFull(t *testing.T) {
+	config := testConfig("http://peer")
+	config.QueueSize = 1
+	broadcaster := New(config)
+	broadcaster.started = true // Queue without sending
+
+	broadcaster.Invalidate("abc123")
+	broadcaster.Invalidate("xyz789")
+	assert.Equal(t, uint64(1), broadcaster.Dropped())
+}
@@ -0,0 +1,46 @@
+package peers
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// Config holds cache invalidation configuration for instances sharing a database
+type Config struct {
+	Peers     []string      // Base URLs of the other instances; none disables broadcasting
+	Secret    string        // Shared by every instance to sign and verify invalidations; empty refuses them
+	Timeout   time.Duration // Timeout for each request to a peer
+	QueueSize int           // Changed short codes waiting to be broadcast before new ones are dropped
+}
+
+// DefaultConfig returns the default configuration
+func DefaultConfig() Config {
+	return Config{
+		Timeout:   5 * time.Second,
+		QueueSize: 1000,
+	}
+}
+
+// Validate checks that the configuration values are usable
+func (c Config) Validate() error {
+	if len(c.Peers) == 0 {
+		return nil
+	}
+	if c.Secret == "" {
+		return fmt.Errorf("peers need a shared secret")
+	}
+	for _, peer := range c.Peers {
+		u, err := url.Parse(peer)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("peer must be an http or https URL, got: %q", peer)
+		}
+	}
+	if c.Timeout <= 0 {
+		return fmt.Errorf("peer timeout must be positive, got: %v", c.Timeout)
+	}
+	if c.QueueSize < 1 {
+		return fmt.Errorf("peer queue size must be at least 1, got: %d", c.QueueSize)
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package peers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"time"
+)
+
+// Headers set on every invalidation
+const (
+	HeaderTimestamp = "X-Peer-Timestamp" // Unix seconds the request was signed at
+	HeaderSignature = "X-Peer-Signature" // sha256=<hex HMAC of "timestamp.body">
+)
+
+// MaxSkew is how far a request's timestamp may be from the receiver's clock
+// before it is rejected as a replay
+const MaxSkew = 5 * time.Minute
+
+// signaturePrefix names the HMAC algorithm in the signature header
+const signaturePrefix = "sha256="
+
+// Sign computes the signature header value for an invalidation
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks the timestamp and signature header values of an
+// invalidation received at now, comparing the signature in constant time
+func Verify(secret, timestamp, signature string, body []byte, now time.Time) bool {
+	signedAt, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false
+	}
+	if skew := now.Sub(time.Unix(signedAt, 0)); skew > MaxSkew || skew < -MaxSkew {
+		return false
+	}
+	return hmac.Equal([]byte(signature), []byte(Sign(secret, signedAt, body)))
+}
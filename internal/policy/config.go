@@ -0,0 +1,73 @@
+package policy
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+)
+
+// Config holds lifecycle policy scheduling configuration
+type Config struct {
+	File     string        // Optional YAML file of policies applied alongside stored ones
+	Interval time.Duration // How often policies are evaluated; 0 disables scheduled runs
+	DryRun   bool          // Log what scheduled runs would do instead of acting
+}
+
+// DefaultConfig returns the default configuration
+func DefaultConfig() Config {
+	return Config{
+		Interval: time.Hour,
+	}
+}
+
+// Validate checks that the configuration values are usable
+func (c Config) Validate() error {
+	if c.Interval < 0 {
+		return fmt.Errorf("interval cannot be negative, got: %v", c.Interval)
+	}
+	return nil
+}
+
+// policiesFile is the layout of the policies config file
+type policiesFile struct {
+	Policies []domain.LifecyclePolicy `yaml:"policies"`
+}
+
+// LoadPolicies reads policies from a YAML file of the form
+//
+//	policies:
+//	  - name: expire-temp
+//	    tag: temp
+//	    older_than: 30d
+//	    action: delete
+func LoadPolicies(path string) ([]*domain.LifecyclePolicy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policies file: %w", err)
+	}
+
+	var file policiesFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse policies file: %w", err)
+	}
+
+	policies := make([]*domain.LifecyclePolicy, 0, len(file.Policies))
+	names := make(map[string]bool, len(file.Policies))
+	for i := range file.Policies {
+		policy := file.Policies[i]
+		if err := validatePolicy(&policy); err != nil {
+			return nil, fmt.Errorf("policy %d: %w", i+1, err)
+		}
+		if names[policy.Name] {
+			return nil, fmt.Errorf("policy %d: duplicate name %q", i+1, policy.Name)
+		}
+		names[policy.Name] = true
+		policies = append(policies, &policy)
+	}
+
+	return policies, nil
+}
@@ -0,0 +1,346 @@
+package policy
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	"github.com/joshdurbin/url-shortener/internal/drain"
+	"github.com/joshdurbin/url-shortener/internal/repository"
+	"github.com/joshdurbin/url-shortener/internal/service"
+)
+
+// runTimeout bounds a single scheduled evaluation
+const runTimeout = 5 * time.Minute
+
+// Engine applies lifecycle policies to links. Policies come from a config
+// file and from the API; each run lists every link, finds the first policy
+// that matches it, and applies that policy's action, recording each action
+// in the audit log. A dry run reports the same matches without acting.
+type Engine struct {
+	config Config
+	store  repository.PolicyRepository
+	links  service.URLShortener
+
+	mutex    sync.RWMutex
+	static   []*domain.LifecyclePolicy // Policies from the config file
+	stored   []*domain.LifecyclePolicy // Cached copy of the stored policies
+	started  bool
+	closed   bool
+	stopChan chan struct{}
+	wg       sync.WaitGroup
+
+	runMutex sync.Mutex       // Serializes runs so a link is never acted on twice
+	gate     drain.Gate       // Holds scheduled runs while the server is drained
+	now      func() time.Time // Clock for evaluating policy ages
+}
+
+// New creates a policy engine. Static policies, typically from LoadPolicies,
+// are evaluated before the policies managed through the API.
+func New(config Config, store repository.PolicyRepository, links service.URLShortener, static []*domain.LifecyclePolicy) *Engine {
+	return &Engine{
+		config:   config,
+		store:    store,
+		links:    links,
+		static:   static,
+		stopChan: make(chan struct{}),
+		now:      time.Now,
+	}
+}
+
+// Start loads the stored policies and starts the scheduler
+func (e *Engine) Start(ctx context.Context) error {
+	policies, err := e.store.ListLifecyclePolicies(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load lifecycle policies: %w", err)
+	}
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if e.started {
+		return fmt.Errorf("policy engine already started")
+	}
+	e.started = true
+	e.stored = policies
+
+	if e.config.Interval > 0 {
+		e.wg.Add(1)
+		go e.scheduleLoop()
+	}
+
+	return nil
+}
+
+// Close stops the scheduler, waiting for a run in progress to finish
+func (e *Engine) Close() error {
+	e.mutex.Lock()
+	if e.closed {
+		e.mutex.Unlock()
+		return nil
+	}
+	e.closed = true
+	close(e.stopChan)
+	e.mutex.Unlock()
+
+	e.wg.Wait()
+	return nil
+}
+
+// Policies returns the file policies followed by the stored policies, in evaluation order
+func (e *Engine) Policies() []*domain.LifecyclePolicy {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+
+	policies := make([]*domain.LifecyclePolicy, 0, len(e.static)+len(e.stored))
+	for _, list := range [][]*domain.LifecyclePolicy{e.static, e.stored} {
+		for _, policy := range list {
+			copied := *policy
+			policies = append(policies, &copied)
+		}
+	}
+	return policies
+}
+
+// CreatePolicy validates and stores a new policy. Names must be unique
+// across file and stored policies since the audit log refers to them by name.
+func (e *Engine) CreatePolicy(ctx context.Context, policy domain.LifecyclePolicy) (*domain.LifecyclePolicy, error) {
+	if err := validatePolicy(&policy); err != nil {
+		return nil, domain.Invalid("", err)
+	}
+	for _, existing := range e.Policies() {
+		if existing.Name == policy.Name {
+			return nil, domain.Conflict(fmt.Errorf("a policy named %q already exists", policy.Name))
+		}
+	}
+
+	policy.ID = 0
+	policy.CreatedAt = e.now()
+	created, err := e.store.CreateLifecyclePolicy(ctx, &policy)
+	if err != nil {
+		return nil, err
+	}
+
+	e.mutex.Lock()
+	e.stored = append(e.stored, created)
+	e.mutex.Unlock()
+
+	copied := *created
+	return &copied, nil
+}
+
+// ReplaceFilePolicies swaps in policies reloaded from the config file. Runs
+// already under way finish with the old ones. Names must stay unique across
+// file and stored policies.
+func (e *Engine) ReplaceFilePolicies(policies []*domain.LifecyclePolicy) error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	for _, policy := range policies {
+		for _, stored := range e.stored {
+			if stored.Name == policy.Name {
+				return domain.Conflict(fmt.Errorf("file policy %q has the name of a stored policy", policy.Name))
+			}
+		}
+	}
+	e.static = policies
+	return nil
+}
+
+// DeletePolicy removes a stored policy; file policies cannot be deleted
+func (e *Engine) DeletePolicy(ctx context.Context, id int64) error {
+	if err := e.store.DeleteLifecyclePolicy(ctx, id); err != nil {
+		return err
+	}
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	remaining := make([]*domain.LifecyclePolicy, 0, len(e.stored))
+	for _, policy := range e.stored {
+		if policy.ID != id {
+			remaining = append(remaining, policy)
+		}
+	}
+	e.stored = remaining
+	return nil
+}
+
+// Preview returns the links the policies would act on now, without acting
+func (e *Engine) Preview(ctx context.Context) ([]*domain.PolicyMatch, error) {
+	return e.evaluate(ctx)
+}
+
+// Run applies every policy now and returns the actions taken. A failed action
+// is recorded with its error and does not stop the run.
+func (e *Engine) Run(ctx context.Context) ([]*domain.PolicyAuditEntry, error) {
+	e.runMutex.Lock()
+	defer e.runMutex.Unlock()
+
+	matches, err := e.evaluate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	actions := make([]*domain.PolicyAuditEntry, 0, len(matches))
+	for _, match := range matches {
+		actions = append(actions, e.apply(ctx, match))
+	}
+	return actions, nil
+}
+
+// Actions returns the most recent entries of the audit log
+func (e *Engine) Actions(ctx context.Context, limit int) ([]*domain.PolicyAuditEntry, error) {
+	return e.store.ListPolicyActions(ctx, limit)
+}
+
+// evaluate matches every link against the policies; the first matching
+// policy wins, so a link is acted on at most once per run
+func (e *Engine) evaluate(ctx context.Context) ([]*domain.PolicyMatch, error) {
+	policies := e.Policies()
+	if len(policies) == 0 {
+		return []*domain.PolicyMatch{}, nil
+	}
+
+	entries, err := e.links.GetAllURLs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list links: %w", err)
+	}
+
+	now := e.now()
+	matches := []*domain.PolicyMatch{}
+	for _, entry := range entries {
+		for _, policy := range policies {
+			if policy.Matches(entry, now) {
+				matches = append(matches, &domain.PolicyMatch{Policy: policy.Name, Action: policy.Action, Link: entry})
+				break
+			}
+		}
+	}
+	return matches, nil
+}
+
+// apply performs one policy action and records it in the audit log
+func (e *Engine) apply(ctx context.Context, match *domain.PolicyMatch) *domain.PolicyAuditEntry {
+	entry := &domain.PolicyAuditEntry{
+		Policy:      match.Policy,
+		Action:      match.Action,
+		ShortCode:   match.Link.ShortCode,
+		OriginalURL: match.Link.OriginalURL,
+		CreatedAt:   e.now(),
+	}
+	if match.Action == domain.PolicyActionArchive {
+		entry.Snapshot = match.Link
+	}
+
+	// Both actions remove the link; archive differs only in the snapshot it keeps
+	if err := e.links.DeleteShortURL(ctx, match.Link.ShortCode); err != nil {
+		entry.Error = err.Error()
+	}
+
+	if err := e.store.RecordPolicyAction(ctx, entry); err != nil {
+		log.Printf("Error recording policy %s action on %s: %v", entry.Policy, entry.ShortCode, err)
+	}
+	return entry
+}
+
+// Pause waits for a scheduled run in progress and skips the next ones until
+// Resume, so no links are removed or actions recorded during a drain. Dry
+// runs still log their matches.
+func (e *Engine) Pause(ctx context.Context) error {
+	if e == nil {
+		return nil
+	}
+	return e.gate.Pause(ctx)
+}
+
+// Resume lets scheduled runs apply the policies again
+func (e *Engine) Resume() {
+	if e == nil {
+		return
+	}
+	e.gate.Resume()
+}
+
+// scheduleLoop evaluates the policies every interval until the engine is closed
+func (e *Engine) scheduleLoop() {
+	defer e.wg.Done()
+
+	ticker := time.NewTicker(e.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-e.stopChan:
+			return
+		case <-ticker.C:
+			e.scheduledRun()
+		}
+	}
+}
+
+// scheduledRun applies the policies, or only logs the matches in dry-run mode
+func (e *Engine) scheduledRun() {
+	ctx, cancel := context.WithTimeout(context.Background(), runTimeout)
+	defer cancel()
+
+	if e.config.DryRun {
+		matches, err := e.Preview(ctx)
+		if err != nil {
+			log.Printf("Error evaluating lifecycle policies: %v", err)
+			return
+		}
+		for _, match := range matches {
+			log.Printf("Policy %s would %s %s (dry run)", match.Policy, match.Action, match.Link.ShortCode)
+		}
+		return
+	}
+
+	if !e.gate.TryEnter() {
+		return
+	}
+	defer e.gate.Leave()
+	actions, err := e.Run(ctx)
+	if err != nil {
+		log.Printf("Error applying lifecycle policies: %v", err)
+		return
+	}
+	failed := 0
+	for _, action := range actions {
+		if action.Error != "" {
+			failed++
+		}
+	}
+	if len(actions) > 0 {
+		log.Printf("Lifecycle policies acted on %d links (%d failed)", len(actions), failed)
+	}
+}
+
+// validatePolicy checks a policy's name, action, and conditions, lowercasing its tag
+func validatePolicy(policy *domain.LifecyclePolicy) error {
+	policy.Name = strings.TrimSpace(policy.Name)
+	if policy.Name == "" {
+		return fmt.Errorf("policy name is required")
+	}
+	if !policy.Action.Valid() {
+		return fmt.Errorf("unknown policy action %q: use %q or %q", policy.Action, domain.PolicyActionDelete, domain.PolicyActionArchive)
+	}
+	if policy.Tag != "" {
+		policy.Tag = strings.ToLower(policy.Tag)
+		if !domain.ValidTag(policy.Tag) {
+			return fmt.Errorf("invalid tag %q", policy.Tag)
+		}
+	}
+	if policy.OlderThan < 0 || policy.UnusedFor < 0 {
+		return fmt.Errorf("policy ages cannot be negative")
+	}
+	// A policy without an age would act on every matching link at once
+	if policy.OlderThan == 0 && policy.UnusedFor == 0 {
+		return fmt.Errorf("policy needs older_than or unused_for")
+	}
+	return nil
+}
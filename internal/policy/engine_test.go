@@ -0,0 +1,248 @@
+package policy
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	repoMocks "github.com/joshdurbin/url-shortener/internal/repository/mocks"
+	"github.com/joshdurbin/url-shortener/internal/service/mocks"
+)
+
+const day = 24 * time.Hour
+
+// testLinks returns links relative to now: a fresh temp link, an old temp
+// link, and an old untagged link last used long ago
+func testLinks(now time.Time) []*domain.URLEntry {
+	lastUsed := now.Add(-200 * day)
+	return []*domain.URLEntry{
+		{ShortCode: "fresh1", OriginalURL: "https://example.com/fresh", CreatedAt: now.Add(-day), Tags: []string{"temp"}},
+		{ShortCode: "oldtmp", OriginalURL: "https://example.com/old", CreatedAt: now.Add(-40 * day), Tags: []string{"temp"}},
+		{ShortCode: "stale1", OriginalURL: "https://example.com/stale", CreatedAt: now.Add(-300 * day), LastUsedAt: &lastUsed},
+	}
+}
+
+// testPolicies returns a delete and an archive policy
+func testPolicies() []*domain.LifecyclePolicy {
+	return []*domain.LifecyclePolicy{
+		{Name: "expire-temp", Tag: "temp", OlderThan: domain.Age(30 * day), Action: domain.PolicyActionDelete},
+		{Name: "archive-unused", UnusedFor: domain.Age(180 * day), Action: domain.PolicyActionArchive},
+	}
+}
+
+// newTestEngine returns a started engine with a fixed clock and no scheduler
+func newTestEngine(t *testing.T, store *repoMocks.PolicyRepository, links *mocks.URLShortener, now time.Time) *Engine {
+	t.Helper()
+
+	store.On("ListLifecyclePolicies", mock.Anything).Return([]*domain.LifecyclePolicy{}, nil)
+	engine := New(Config{}, store, links, testPolicies())
+	engine.now = func() time.Time { return now }
+	require.NoError(t, engine.Start(context.Background()))
+	t.Cleanup(func() { engine.Close() })
+	return engine
+}
+
+func TestEngine_Preview(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	store := &repoMocks.PolicyRepository{}
+	links := &mocks.URLShortener{}
+	links.On("GetAllURLs", ctx).Return(testLinks(now), nil)
+
+	engine := newTestEngine(t, store, links, now)
+
+	matches, err := engine.Preview(ctx)
+	require.NoError(t, err)
+	require.Len(t, matches, 2)
+	assert.Equal(t, "expire-temp", matches[0].Policy)
+	assert.Equal(t, "oldtmp", matches[0].Link.ShortCode)
+	assert.Equal(t, "archive-unused", matches[1].Policy)
+	assert.Equal(t, "stale1", matches[1].Link.ShortCode)
+
+	// A dry run never acts or writes to the audit log
+	links.AssertNotCalled(t, "DeleteShortURL", mock.Anything, mock.Anything)
+	store.AssertNotCalled(t, "RecordPolicyAction", mock.Anything, mock.Anything)
+}
+
+func TestEngine_Run(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	store := &repoMocks.PolicyRepository{}
+	links := &mocks.URLShortener{}
+	links.On("GetAllURLs", ctx).Return(testLinks(now), nil)
+	links.On("DeleteShortURL", ctx, "oldtmp").Return(nil)
+	links.On("DeleteShortURL", ctx, "stale1").Return(errors.New("database is locked"))
+
+	var recorded []*domain.PolicyAuditEntry
+	store.On("RecordPolicyAction", ctx, mock.AnythingOfType("*domain.PolicyAuditEntry")).
+		Run(func(args mock.Arguments) {
+			recorded = append(recorded, args.Get(1).(*domain.PolicyAuditEntry))
+		}).
+		Return(nil)
+
+	engine := newTestEngine(t, store, links, now)
+
+	actions, err := engine.Run(ctx)
+	require.NoError(t, err)
+	require.Len(t, actions, 2)
+	assert.Equal(t, actions, recorded)
+
+	assert.Equal(t, domain.PolicyActionDelete, actions[0].Action)
+	assert.Equal(t, "oldtmp", actions[0].ShortCode)
+	assert.Nil(t, actions[0].Snapshot)
+	assert.Empty(t, actions[0].Error)
+
+	// Archived links keep a snapshot, and failures are recorded rather than fatal
+	assert.Equal(t, domain.PolicyActionArchive, actions[1].Action)
+	require.NotNil(t, actions[1].Snapshot)
+	assert.Equal(t, "https://example.com/stale", actions[1].Snapshot.OriginalURL)
+	assert.Equal(t, "database is locked", actions[1].Error)
+
+	links.AssertExpectations(t)
+}
+
+func TestEngine_PauseSkipsScheduledRuns(t *testing.T) {
+	now := time.Now()
+	store := &repoMocks.PolicyRepository{}
+	links := &mocks.URLShortener{}
+	engine := newTestEngine(t, store, links, now)
+
+	require.NoError(t, engine.Pause(context.Background()))
+	engine.scheduledRun()
+	links.AssertNotCalled(t, "GetAllURLs", mock.Anything)
+	store.AssertNotCalled(t, "RecordPolicyAction", mock.Anything, mock.Anything)
+
+	// Resumed, the next run applies the policies
+	engine.Resume()
+	links.On("GetAllURLs", mock.Anything).Return([]*domain.URLEntry{}, nil).Once()
+	engine.scheduledRun()
+	links.AssertExpectations(t)
+}
+
+func TestEngine_CreatePolicy(t *testing.T) {
+	ctx := context.Background()
+	store := &repoMocks.PolicyRepository{}
+	store.On("CreateLifecyclePolicy", ctx, mock.MatchedBy(func(policy *domain.LifecyclePolicy) bool {
+		return policy.Name == "old-promos" && policy.Tag == "promo" && !policy.CreatedAt.IsZero()
+	})).Return(&domain.LifecyclePolicy{ID: 1, Name: "old-promos", Tag: "promo", OlderThan: domain.Age(90 * day), Action: domain.PolicyActionDelete}, nil)
+	store.On("DeleteLifecyclePolicy", ctx, int64(1)).Return(nil)
+	store.On("DeleteLifecyclePolicy", ctx, int64(2)).Return(domain.ErrPolicyNotFound)
+
+	engine := newTestEngine(t, store, &mocks.URLShortener{}, time.Now())
+
+	created, err := engine.CreatePolicy(ctx, domain.LifecyclePolicy{Name: "old-promos", Tag: "Promo", OlderThan: domain.Age(90 * day), Action: domain.PolicyActionDelete})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), created.ID)
+	assert.Len(t, engine.Policies(), 3)
+
+	_, err = engine.CreatePolicy(ctx, domain.LifecyclePolicy{Name: "expire-temp", OlderThan: domain.Age(day), Action: domain.PolicyActionDelete})
+	assert.ErrorContains(t, err, "already exists")
+
+	require.NoError(t, engine.DeletePolicy(ctx, 1))
+	assert.Len(t, engine.Policies(), 2)
+	assert.ErrorIs(t, engine.DeletePolicy(ctx, 2), domain.ErrPolicyNotFound)
+
+	store.AssertExpectations(t)
+}
+
+func TestEngine_ReplaceFilePolicies(t *testing.T) {
+	ctx := context.Background()
+	store := &repoMocks.PolicyRepository{}
+	store.On("CreateLifecyclePolicy", ctx, mock.Anything).Return(&domain.LifecyclePolicy{ID: 1, Name: "old-promos", Tag: "promo", Action: domain.PolicyActionDelete}, nil)
+
+	engine := newTestEngine(t, store, &mocks.URLShortener{}, time.Now())
+	_, err := engine.CreatePolicy(ctx, domain.LifecyclePolicy{Name: "old-promos", Tag: "promo", OlderThan: domain.Age(90 * day), Action: domain.PolicyActionDelete})
+	require.NoError(t, err)
+
+	reloaded := []*domain.LifecyclePolicy{{Name: "expire-drafts", Tag: "draft", Action: domain.PolicyActionDelete}}
+	require.NoError(t, engine.ReplaceFilePolicies(reloaded))
+	policies := engine.Policies()
+	require.Len(t, policies, 2)
+	assert.Equal(t, "expire-drafts", policies[0].Name)
+	assert.Equal(t, "old-promos", policies[1].Name)
+
+	err = engine.ReplaceFilePolicies([]*domain.LifecyclePolicy{{Name: "old-promos", Action: domain.PolicyActionDelete}})
+	assert.ErrorIs(t, err, domain.ErrConflict)
+	assert.Equal(t, "expire-drafts", engine.Policies()[0].Name)
+}
+
+func TestValidatePolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  domain.LifecyclePolicy
+		wantErr string
+	}{
+		{name: "valid", policy: domain.LifecyclePolicy{Name: "p", OlderThan: domain.Age(day), Action: domain.PolicyActionDelete}},
+		{name: "missing name", policy: domain.LifecyclePolicy{Name: " ", OlderThan: domain.Age(day), Action: domain.PolicyActionDelete}, wantErr: "name is required"},
+		{name: "unknown action", policy: domain.LifecyclePolicy{Name: "p", OlderThan: domain.Age(day), Action: "expire"}, wantErr: "unknown policy action"},
+		{name: "invalid tag", policy: domain.LifecyclePolicy{Name: "p", Tag: "a b", OlderThan: domain.Age(day), Action: domain.PolicyActionDelete}, wantErr: "invalid tag"},
+		{name: "no age", policy: domain.LifecyclePolicy{Name: "p", Tag: "temp", Action: domain.PolicyActionDelete}, wantErr: "older_than or unused_for"},
+		{name: "negative age", policy: domain.LifecyclePolicy{Name: "p", UnusedFor: -1, Action: domain.PolicyActionArchive}, wantErr: "negative"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validatePolicy(&tt.policy)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestLoadPolicies(t *testing.T) {
+	write := func(t *testing.T, content string) string {
+		path := filepath.Join(t.TempDir(), "policies.yaml")
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		return path
+	}
+
+	t.Run("valid", func(t *testing.T) {
+		policies, err := LoadPolicies(write(t, `
+policies:
+  - name: expire-temp
+    tag: temp
+    older_than: 30d
+    action: delete
+  - name: archive-unused
+    unused_for: 4320h
+    action: archive
+`))
+		require.NoError(t, err)
+		require.Len(t, policies, 2)
+		assert.Equal(t, domain.Age(30*day), policies[0].OlderThan)
+		assert.Equal(t, domain.Age(180*day), policies[1].UnusedFor)
+		assert.Equal(t, "180d", policies[1].UnusedFor.String())
+	})
+
+	t.Run("invalid age", func(t *testing.T) {
+		_, err := LoadPolicies(write(t, "policies:\n  - name: p\n    older_than: soon\n    action: delete\n"))
+		assert.ErrorContains(t, err, "invalid age")
+	})
+
+	t.Run("duplicate name", func(t *testing.T) {
+		_, err := LoadPolicies(write(t, "policies:\n  - {name: p, older_than: 1d, action: delete}\n  - {name: p, older_than: 2d, action: delete}\n"))
+		assert.ErrorContains(t, err, "duplicate name")
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		_, err := LoadPolicies(filepath.Join(t.TempDir(), "missing.yaml"))
+		assert.Error(t, err)
+	})
+}
+
+func TestConfig_Validate(t *testing.T) {
+	assert.NoError(t, DefaultConfig().Validate())
+	assert.NoError(t, Config{}.Validate(), "a zero interval disables scheduled runs")
+	assert.Error(t, Config{Interval: -time.Hour}.Validate())
+}
@@ -0,0 +1,49 @@
+package preview
+
+import (
+	"fmt"
+	"time"
+)
+
+// Config holds link preview fetching configuration
+type Config struct {
+	Workers   int           // Concurrent page fetches; 0 disables previews
+	QueueSize int           // New links waiting for a fetch before more are dropped
+	Timeout   time.Duration // Timeout for fetching one preview, robots.txt included
+	MaxBytes  int64         // Most of a page read while looking for its metadata
+	RobotsTTL time.Duration // How long a site's robots.txt is cached
+}
+
+// DefaultConfig returns the default configuration
+func DefaultConfig() Config {
+	return Config{
+		Workers:   2,
+		QueueSize: 1000,
+		Timeout:   10 * time.Second,
+		MaxBytes:  512 << 10,
+		RobotsTTL: time.Hour,
+	}
+}
+
+// Validate checks that the configuration values are usable
+func (c Config) Validate() error {
+	if c.Workers < 0 {
+		return fmt.Errorf("workers cannot be negative, got: %d", c.Workers)
+	}
+	if c.Workers == 0 {
+		return nil
+	}
+	if c.QueueSize < 1 {
+		return fmt.Errorf("queue size must be at least 1, got: %d", c.QueueSize)
+	}
+	if c.Timeout <= 0 {
+		return fmt.Errorf("timeout must be positive, got: %v", c.Timeout)
+	}
+	if c.MaxBytes < 1 {
+		return fmt.Errorf("max bytes must be at least 1, got: %d", c.MaxBytes)
+	}
+	if c.RobotsTTL < 0 {
+		return fmt.Errorf("robots.txt cache TTL cannot be negative, got: %v", c.RobotsTTL)
+	}
+	return nil
+}
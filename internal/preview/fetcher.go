@@ -0,0 +1,293 @@
+package preview
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/html/charset"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	"github.com/joshdurbin/url-shortener/internal/drain"
+	"github.com/joshdurbin/url-shortener/internal/publicnet"
+	"github.com/joshdurbin/url-shortener/internal/repository"
+	"github.com/joshdurbin/url-shortener/internal/version"
+)
+
+// maxRedirects is how many redirects a page fetch follows
+const maxRedirects = 10
+
+// job is a link waiting for its preview
+type job struct {
+	shortCode   string
+	destination string
+}
+
+// Fetcher fills in link previews: the title, description and favicon of each
+// new link's destination page. New links are queued without blocking the
+// caller and fetched by a pool of workers; Refresh fetches one link again on
+// demand. Pages (and every redirect on the way) that the site's robots.txt
+// disallows for us are not fetched, and the preview records why. Loopback,
+// private, link-local, carrier-grade NAT, NAT64 and other non-public
+// addresses are never connected to.
+type Fetcher struct {
+	config Config
+	store  repository.URLRepository
+	client *http.Client
+	robots *robotsCache
+	allow  func(netip.Addr) bool // Whether a fetch may connect to an address
+	now    func() time.Time
+	gate   drain.Gate // Holds storing previews while the server is drained
+
+	mutex    sync.RWMutex
+	started  bool
+	closed   bool
+	queue    chan job
+	stopChan chan struct{}
+	cancel   context.CancelFunc // Aborts fetches in progress on Close
+	wg       sync.WaitGroup
+}
+
+// New creates a preview fetcher storing previews in store
+func New(config Config, store repository.URLRepository) *Fetcher {
+	f := &Fetcher{
+		config:   config,
+		store:    store,
+		allow:    publicnet.Public,
+		now:      time.Now,
+		queue:    make(chan job, config.QueueSize),
+		stopChan: make(chan struct{}),
+	}
+	// Checked for every connection, including redirects and robots.txt
+	dialer := publicnet.Dialer(config.Timeout, func(addr netip.Addr) bool { return f.allow(addr) })
+	// No proxy, so the address checked is the one connected to
+	transport := &http.Transport{
+		DialContext:         dialer.DialContext,
+		TLSHandshakeTimeout: config.Timeout,
+	}
+	f.robots = newRobotsCache(&http.Client{Timeout: config.Timeout, Transport: transport}, config.RobotsTTL)
+	f.client = &http.Client{
+		Timeout:   config.Timeout,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxRedirects {
+				return fmt.Errorf("stopped after %d redirects", maxRedirects)
+			}
+			return f.robots.check(req.Context(), req.URL)
+		},
+	}
+	return f
+}
+
+// Start starts the fetch workers
+func (f *Fetcher) Start(ctx context.Context) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.started {
+		return fmt.Errorf("preview fetcher already started")
+	}
+	f.started = true
+
+	runCtx, cancel := context.WithCancel(context.Background())
+	f.cancel = cancel
+	for i := 0; i < f.config.Workers; i++ {
+		f.wg.Add(1)
+		go f.worker(runCtx)
+	}
+	return nil
+}
+
+// Close stops the workers, abandoning fetches in progress. Links still queued
+// keep no preview until they are refreshed.
+func (f *Fetcher) Close() error {
+	f.mutex.Lock()
+	if f.closed {
+		f.mutex.Unlock()
+		return nil
+	}
+	f.closed = true
+	close(f.stopChan)
+	if f.cancel != nil {
+		f.cancel()
+	}
+	f.mutex.Unlock()
+
+	f.wg.Wait()
+
+	if pending := len(f.queue); pending > 0 {
+		log.Printf("Preview fetcher stopped with %d links waiting for a preview", pending)
+	}
+	return nil
+}
+
+// Pause waits for previews being stored and holds the next ones until
+// Resume, so links keep their previews during a drain. Workers still fetch
+// meanwhile, each waiting with its preview.
+func (f *Fetcher) Pause(ctx context.Context) error {
+	if f == nil {
+		return nil
+	}
+	return f.gate.Pause(ctx)
+}
+
+// Resume lets the workers store previews again
+func (f *Fetcher) Resume() {
+	if f == nil {
+		return
+	}
+	f.gate.Resume()
+}
+
+// Notify queues a preview fetch for each url.created event without blocking;
+// links are skipped when the queue is full
+func (f *Fetcher) Notify(event domain.Event) {
+	if event.Type != domain.EventURLCreated {
+		return
+	}
+
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+
+	if !f.started || f.closed {
+		return
+	}
+
+	select {
+	case f.queue <- job{shortCode: event.Data.ShortCode, destination: event.Data.OriginalURL}:
+	default:
+		log.Printf("Preview queue full, skipping the preview of %s", event.Data.ShortCode)
+	}
+}
+
+// Refresh fetches a link's preview again and stores it. A page that could not
+// be fetched is not an error; the stored preview says what went wrong. A
+// paused fetcher returns domain.ErrDraining.
+func (f *Fetcher) Refresh(ctx context.Context, shortCode string) (*domain.LinkPreview, error) {
+	if !f.gate.TryEnter() {
+		return nil, domain.ErrDraining
+	}
+	defer f.gate.Leave()
+
+	entry, err := f.store.GetURL(ctx, shortCode)
+	if err != nil {
+		return nil, err
+	}
+
+	preview := f.Fetch(ctx, entry.OriginalURL)
+	updated, err := f.store.SetURLPreview(ctx, shortCode, preview)
+	if err != nil {
+		return nil, err
+	}
+	return updated.Preview, nil
+}
+
+// Fetch reads the preview of a destination. Template destinations are
+// fetched with their sample values filled in.
+func (f *Fetcher) Fetch(ctx context.Context, destination string) domain.LinkPreview {
+	ctx, cancel := context.WithTimeout(ctx, f.config.Timeout)
+	defer cancel()
+
+	preview := domain.LinkPreview{FetchedAt: f.now().UTC()}
+	p, err := f.fetch(ctx, fetchTarget(destination))
+	if err != nil {
+		preview.Error = err.Error()
+		return preview
+	}
+	preview.Title = p.title
+	preview.Description = p.description
+	preview.FaviconURL = p.favicon
+	return preview
+}
+
+// fetch downloads target and reads its metadata. Pages that are not HTML,
+// such as PDFs and images, only get the site's default favicon.
+func (f *Fetcher) fetch(ctx context.Context, target string) (page, error) {
+	u, err := url.Parse(target)
+	if err != nil {
+		return page{}, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return page{}, fmt.Errorf("unsupported scheme %q", u.Scheme)
+	}
+	if err := f.robots.check(ctx, u); err != nil {
+		return page{}, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return page{}, err
+	}
+	req.Header.Set("User-Agent", version.UserAgent())
+	req.Header.Set("Accept", "text/html,application/xhtml+xml;q=0.9,*/*;q=0.1")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		// Report a redirect robots.txt disallows without the url.Error wrapping
+		if errors.Is(err, errDisallowed) {
+			return page{}, errDisallowed
+		}
+		return page{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return page{}, fmt.Errorf("status %d", resp.StatusCode)
+	}
+
+	// The page's own URL, after redirects, resolves relative links
+	base := resp.Request.URL
+	contentType := resp.Header.Get("Content-Type")
+	if mediaType, _, _ := mime.ParseMediaType(contentType); mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return parsePage(strings.NewReader(""), base), nil
+	}
+
+	body, err := charset.NewReader(io.LimitReader(resp.Body, f.config.MaxBytes), contentType)
+	if err != nil {
+		return page{}, fmt.Errorf("unsupported charset: %w", err)
+	}
+	return parsePage(body, base), nil
+}
+
+// worker fetches queued previews until the fetcher is closed
+func (f *Fetcher) worker(ctx context.Context) {
+	defer f.wg.Done()
+
+	for {
+		select {
+		case <-f.stopChan:
+			return
+		case j := <-f.queue:
+			preview := f.Fetch(ctx, j.destination)
+			if ctx.Err() != nil || f.gate.Enter(ctx) != nil {
+				return
+			}
+			if _, err := f.store.SetURLPreview(ctx, j.shortCode, preview); err != nil && !errors.Is(err, domain.ErrURLNotFound) {
+				log.Printf("Error storing the preview of %s: %v", j.shortCode, err)
+			}
+			f.gate.Leave()
+		}
+	}
+}
+
+// fetchTarget returns the URL fetched for a destination; a template link is
+// fetched with its sample URL (defaults, or "x" for required values)
+func fetchTarget(destination string) string {
+	if !domain.IsTemplate(destination) {
+		return destination
+	}
+	tmpl, err := domain.ParseTemplate(destination)
+	if err != nil {
+		return destination
+	}
+	return tmpl.Sample()
+}
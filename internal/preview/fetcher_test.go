@@ -0,0 +1,316 @@
+package preview
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	"github.com/joshdurbin/url-shortener/internal/repository/mocks"
+)
+
+func TestParsePage(t *testing.T) {
+	base, err := url.Parse("https://example.com/articles/one")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		html     string
+		expected page
+	}{
+		{
+			name: "title, description and icon",
+			html: `<!DOCTYPE html><html><head>
+				<title>  Example &amp;
+				Domain </title>
+				<meta name="description" content="For use in examples">
+				<link rel="shortcut icon" href="/static/icon.png">
+				</head><body><title>Not this one</title></body></html>`,
+			expected: page{title: "Example & Domain", description: "For use in examples", favicon: "https://example.com/static/icon.png"},
+		},
+		{
+			name: "Open Graph fallbacks",
+			html: `<head><meta property="og:title" content="OG title">
+				<meta property="og:description" content="OG description">
+				<link rel="apple-touch-icon" href="touch.png"></head>`,
+			expected: page{title: "OG title", description: "OG description", favicon: "https://example.com/articles/touch.png"},
+		},
+		{
+			name:     "base href and default favicon",
+			html:     `<head><base href="https://cdn.example.com/site/"><title>Based</title></head>`,
+			expected: page{title: "Based", favicon: "https://cdn.example.com/favicon.ico"},
+		},
+		{
+			name:     "data URI icons are skipped",
+			html:     `<head><link rel="icon" href="data:image/png;base64,AAAA"></head>`,
+			expected: page{favicon: "https://example.com/favicon.ico"},
+		},
+		{
+			name:     "long titles are cut",
+			html:     `<title>` + strings.Repeat("a", maxTitleLength+10) + `</title>`,
+			expected: page{title: strings.Repeat("a", maxTitleLength-1) + "…", favicon: "https://example.com/favicon.ico"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, parsePage(strings.NewReader(tt.html), base))
+		})
+	}
+}
+
+// newTestSite serves a robots.txt that disallows /private and a few pages
+func newTestSite(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/robots.txt", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("User-agent: *\nDisallow: /private\n"))
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(`<html><head><title>Home</title><meta name="description" content="The home page"><link rel="icon" href="/icon.svg"></head></html>`))
+	})
+	mux.HandleFunc("/latin1", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=iso-8859-1")
+		w.Write([]byte("<title>Caf\xe9</title>"))
+	})
+	mux.HandleFunc("/report.pdf", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Write([]byte("%PDF-1.4"))
+	})
+	mux.HandleFunc("/private", func(w http.ResponseWriter, r *http.Request) {
+		t.Error("fetched a page disallowed by robots.txt")
+	})
+	mux.HandleFunc("/moved", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/private", http.StatusFound)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// newTestFetcher returns a fetcher that may connect to the loopback test
+// servers
+func newTestFetcher(store *mocks.URLRepository) *Fetcher {
+	fetcher := New(DefaultConfig(), store)
+	fetcher.allow = func(addr netip.Addr) bool { return addr.IsLoopback() }
+	return fetcher
+}
+
+func TestFetcher_Fetch(t *testing.T) {
+	site := newTestSite(t)
+	fetcher := newTestFetcher(&mocks.URLRepository{})
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	fetcher.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	tests := []struct {
+		name        string
+		destination string
+		expected    domain.LinkPreview
+	}{
+		{
+			name:        "HTML page",
+			destination: site.URL + "/",
+			expected:    domain.LinkPreview{Title: "Home", Description: "The home page", FaviconURL: site.URL + "/icon.svg", FetchedAt: now},
+		},
+		{
+			name:        "declared charset",
+			destination: site.URL + "/latin1",
+			expected:    domain.LinkPreview{Title: "Café", FaviconURL: site.URL + "/favicon.ico", FetchedAt: now},
+		},
+		{
+			name:        "not HTML",
+			destination: site.URL + "/report.pdf",
+			expected:    domain.LinkPreview{FaviconURL: site.URL + "/favicon.ico", FetchedAt: now},
+		},
+		{
+			name:        "template sample",
+			destination: site.URL + "/{path=}",
+			expected:    domain.LinkPreview{Title: "Home", Description: "The home page", FaviconURL: site.URL + "/icon.svg", FetchedAt: now},
+		},
+		{
+			name:        "disallowed by robots.txt",
+			destination: site.URL + "/private",
+			expected:    domain.LinkPreview{Error: "disallowed by robots.txt", FetchedAt: now},
+		},
+		{
+			name:        "redirect disallowed by robots.txt",
+			destination: site.URL + "/moved",
+			expected:    domain.LinkPreview{Error: "disallowed by robots.txt", FetchedAt: now},
+		},
+		{
+			name:        "error status",
+			destination: site.URL + "/missing",
+			expected:    domain.LinkPreview{Error: "status 404", FetchedAt: now},
+		},
+		{
+			name:        "unsupported scheme",
+			destination: "ftp://example.com/file",
+			expected:    domain.LinkPreview{Error: `unsupported scheme "ftp"`, FetchedAt: now},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, fetcher.Fetch(ctx, tt.destination))
+		})
+	}
+}
+
+func TestFetcher_RefusesPrivateAddresses(t *testing.T) {
+	var requests atomic.Int32
+	internal := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Write([]byte("<title>Internal</title>"))
+	}))
+	t.Cleanup(internal.Close)
+
+	fetcher := New(DefaultConfig(), &mocks.URLRepository{})
+	preview := fetcher.Fetch(context.Background(), internal.URL+"/")
+	assert.Empty(t, preview.Title)
+	assert.Contains(t, preview.Error, "private address 127.0.0.1")
+	assert.Zero(t, requests.Load(), "neither robots.txt nor the page may be requested")
+
+	// A page on an allowed address cannot redirect to a refused one
+	listener, err := net.Listen("tcp", "127.0.0.2:0")
+	if err != nil {
+		t.Skipf("127.0.0.2 is not available: %v", err)
+	}
+	public := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			http.NotFound(w, r)
+			return
+		}
+		http.Redirect(w, r, internal.URL+"/", http.StatusFound)
+	}))
+	public.Listener.Close()
+	public.Listener = listener
+	public.Start()
+	t.Cleanup(public.Close)
+
+	fetcher.allow = func(addr netip.Addr) bool { return addr == netip.MustParseAddr("127.0.0.2") }
+	preview = fetcher.Fetch(context.Background(), public.URL+"/")
+	assert.Empty(t, preview.Title)
+	assert.NotEmpty(t, preview.Error)
+	assert.Zero(t, requests.Load(), "the redirect target may not be requested")
+}
+
+func TestFetcher_Notify(t *testing.T) {
+	site := newTestSite(t)
+	store := &mocks.URLRepository{}
+	fetcher := newTestFetcher(store)
+
+	stored := make(chan domain.LinkPreview, 1)
+	store.On("SetURLPreview", mock.Anything, "abc123", mock.AnythingOfType("domain.LinkPreview")).
+		Run(func(args mock.Arguments) { stored <- args.Get(2).(domain.LinkPreview) }).
+		Return(&domain.URLEntry{ShortCode: "abc123"}, nil).Once()
+
+	// Events before Start are dropped, like the webhook dispatcher's
+	fetcher.Notify(domain.NewEvent(domain.EventURLCreated, domain.EventData{ShortCode: "early", OriginalURL: site.URL + "/"}))
+
+	require.NoError(t, fetcher.Start(context.Background()))
+	fetcher.Notify(domain.NewEvent(domain.EventURLDeleted, domain.EventData{ShortCode: "abc123"}))
+	fetcher.Notify(domain.NewEvent(domain.EventURLCreated, domain.EventData{ShortCode: "abc123", OriginalURL: site.URL + "/"}))
+
+	select {
+	case preview := <-stored:
+		assert.Equal(t, "Home", preview.Title)
+	case <-time.After(5 * time.Second):
+		t.Fatal("preview was not stored")
+	}
+
+	require.NoError(t, fetcher.Close())
+	require.NoError(t, fetcher.Close())
+	store.AssertExpectations(t)
+}
+
+func TestFetcher_PauseHoldsPreviews(t *testing.T) {
+	site := newTestSite(t)
+	store := &mocks.URLRepository{}
+	fetcher := newTestFetcher(store)
+
+	stored := make(chan domain.LinkPreview, 1)
+	store.On("SetURLPreview", mock.Anything, "abc123", mock.AnythingOfType("domain.LinkPreview")).
+		Run(func(args mock.Arguments) { stored <- args.Get(2).(domain.LinkPreview) }).
+		Return(&domain.URLEntry{ShortCode: "abc123"}, nil).Once()
+
+	require.NoError(t, fetcher.Pause(context.Background()))
+	require.NoError(t, fetcher.Start(context.Background()))
+	fetcher.Notify(domain.NewEvent(domain.EventURLCreated, domain.EventData{ShortCode: "abc123", OriginalURL: site.URL + "/"}))
+
+	// Paused, the preview is fetched but not stored, and refreshes are refused
+	select {
+	case <-stored:
+		t.Fatal("preview was stored while paused")
+	case <-time.After(100 * time.Millisecond):
+	}
+	_, err := fetcher.Refresh(context.Background(), "abc123")
+	assert.ErrorIs(t, err, domain.ErrDraining)
+
+	fetcher.Resume()
+	select {
+	case preview := <-stored:
+		assert.Equal(t, "Home", preview.Title)
+	case <-time.After(5 * time.Second):
+		t.Fatal("preview was not stored after resuming")
+	}
+	require.NoError(t, fetcher.Close())
+	store.AssertExpectations(t)
+}
+
+func TestFetcher_Refresh(t *testing.T) {
+	site := newTestSite(t)
+	store := &mocks.URLRepository{}
+	fetcher := newTestFetcher(store)
+	ctx := context.Background()
+
+	store.On("GetURL", ctx, "abc123").Return(&domain.URLEntry{ShortCode: "abc123", OriginalURL: site.URL + "/"}, nil)
+	store.On("SetURLPreview", ctx, "abc123", mock.MatchedBy(func(p domain.LinkPreview) bool { return p.Title == "Home" })).
+		Return(&domain.URLEntry{ShortCode: "abc123", Preview: &domain.LinkPreview{Title: "Home"}}, nil)
+	preview, err := fetcher.Refresh(ctx, "abc123")
+	require.NoError(t, err)
+	assert.Equal(t, "Home", preview.Title)
+
+	store.On("GetURL", ctx, "missing").Return(nil, domain.ErrURLNotFound)
+	_, err = fetcher.Refresh(ctx, "missing")
+	assert.ErrorIs(t, err, domain.ErrURLNotFound)
+
+	store.AssertExpectations(t)
+}
+
+func TestConfig_Validate(t *testing.T) {
+	require.NoError(t, DefaultConfig().Validate())
+	require.NoError(t, Config{Workers: 0}.Validate(), "disabled previews need no other settings")
+
+	tests := []struct {
+		name   string
+		modify func(*Config)
+		errMsg string
+	}{
+		{"negative workers", func(c *Config) { c.Workers = -1 }, "workers cannot be negative"},
+		{"no queue", func(c *Config) { c.QueueSize = 0 }, "queue size must be at least 1"},
+		{"no timeout", func(c *Config) { c.Timeout = 0 }, "timeout must be positive"},
+		{"no body", func(c *Config) { c.MaxBytes = 0 }, "max bytes must be at least 1"},
+		{"negative robots TTL", func(c *Config) { c.RobotsTTL = -time.Second }, "cannot be negative"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultConfig()
+			tt.modify(&config)
+			assert.ErrorContains(t, config.Validate(), tt.errMsg)
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package preview
+
+import (
+	"io"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Longest title and description stored, in characters; pages sometimes put
+// whole articles in their meta tags
+const (
+	maxTitleLength       = 300
+	maxDescriptionLength = 1000
+)
+
+// page is the metadata read from the head of an HTML page
+type page struct {
+	title       string
+	description string
+	favicon     string
+}
+
+// parsePage reads the title, description and favicon from an HTML page's
+// head, resolving the favicon against base (or the page's <base href>).
+// Open Graph and Twitter tags stand in for a missing title or description,
+// and /favicon.ico for a missing icon link.
+func parsePage(r io.Reader, base *url.URL) page {
+	var (
+		p                                page
+		ogTitle, ogDescription, iconHref string
+		touchIcon                        string
+		baseSet                          bool
+	)
+
+	tokenizer := html.NewTokenizer(r)
+	for done := false; !done; {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			done = true
+		case html.EndTagToken:
+			if name, _ := tokenizer.TagName(); string(name) == "head" {
+				done = true
+			}
+		case html.StartTagToken, html.SelfClosingTagToken:
+			token := tokenizer.Token()
+			switch token.Data {
+			case "body":
+				done = true
+			case "title":
+				if p.title == "" && tokenizer.Next() == html.TextToken {
+					p.title = string(tokenizer.Text())
+				}
+			case "base":
+				if href := attr(token, "href"); href != "" && !baseSet {
+					if resolved, err := base.Parse(href); err == nil {
+						base = resolved
+					}
+					baseSet = true
+				}
+			case "meta":
+				key := attr(token, "name")
+				if key == "" {
+					key = attr(token, "property") // Open Graph uses property
+				}
+				content := attr(token, "content")
+				switch strings.ToLower(key) {
+				case "description":
+					p.description = content
+				case "og:title", "twitter:title":
+					if ogTitle == "" {
+						ogTitle = content
+					}
+				case "og:description", "twitter:description":
+					if ogDescription == "" {
+						ogDescription = content
+					}
+				}
+			case "link":
+				href := attr(token, "href")
+				for _, rel := range strings.Fields(strings.ToLower(attr(token, "rel"))) {
+					switch {
+					case rel == "icon" && iconHref == "":
+						iconHref = href
+					case rel == "apple-touch-icon" && touchIcon == "":
+						touchIcon = href
+					}
+				}
+			}
+		}
+	}
+
+	p.title = clean(p.title, ogTitle, maxTitleLength)
+	p.description = clean(p.description, ogDescription, maxDescriptionLength)
+	for _, href := range []string{iconHref, touchIcon, "/favicon.ico"} {
+		if href == "" {
+			continue
+		}
+		if icon, err := base.Parse(href); err == nil && (icon.Scheme == "http" || icon.Scheme == "https") {
+			p.favicon = icon.String()
+			break
+		}
+	}
+	return p
+}
+
+// attr returns the value of a token's attribute, or "" without it
+func attr(token html.Token, name string) string {
+	for _, a := range token.Attr {
+		if a.Key == name {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// clean collapses whitespace in value, or in fallback when value is blank,
+// and cuts the result to at most max characters
+func clean(value, fallback string, max int) string {
+	value = strings.Join(strings.Fields(value), " ")
+	if value == "" {
+		value = strings.Join(strings.Fields(fallback), " ")
+	}
+	if runes := []rune(value); len(runes) > max {
+		value = strings.TrimSpace(string(runes[:max-1])) + "…"
+	}
+	return value
+}
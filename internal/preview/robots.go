@@ -0,0 +1,234 @@
+package preview
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/joshdurbin/url-shortener/internal/publicnet"
+	"github.com/joshdurbin/url-shortener/internal/version"
+)
+
+// errDisallowed is the preview error for pages robots.txt keeps us out of
+var errDisallowed = errors.New("disallowed by robots.txt")
+
+// unreachableRobotsTTL bounds how long a robots.txt that could not be fetched
+// keeps a site disallowed, so a brief outage does not hide previews for long
+const unreachableRobotsTTL = time.Minute
+
+// maxRobotsBytes is the most of a robots.txt that is parsed, as RFC 9309
+// allows crawlers to limit it to 500 KiB
+const maxRobotsBytes = 500 << 10
+
+// robotsRule is one Allow or Disallow line
+type robotsRule struct {
+	allow   bool
+	length  int // Length of the pattern; the longest matching rule wins
+	pattern *regexp.Regexp
+}
+
+// robotsRules are the rules of the groups that apply to us. A nil value
+// allows everything.
+type robotsRules []robotsRule
+
+// allowed reports whether target (an escaped path with its query) may be
+// fetched: the longest matching rule decides, Allow winning ties
+func (rules robotsRules) allowed(target string) bool {
+	if target == "/robots.txt" {
+		return true
+	}
+	best := -1
+	allow := true
+	for _, rule := range rules {
+		if !rule.pattern.MatchString(target) {
+			continue
+		}
+		if rule.length > best || (rule.length == best && rule.allow) {
+			best = rule.length
+			allow = rule.allow
+		}
+	}
+	return allow
+}
+
+// parseRobots reads the rules of the groups for agent from a robots.txt,
+// falling back to the "*" groups when none names it
+func parseRobots(r io.Reader, agent string) robotsRules {
+	var (
+		named, wildcard robotsRules
+		matchesAgent    bool
+		matchesAny      bool
+		inAgents        bool // Reading the user-agent lines that start a group
+	)
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			if !inAgents {
+				matchesAgent, matchesAny = false, false
+				inAgents = true
+			}
+			switch strings.ToLower(value) {
+			case agent:
+				matchesAgent = true
+			case "*":
+				matchesAny = true
+			}
+		case "allow", "disallow":
+			inAgents = false
+			if value == "" {
+				continue // An empty Disallow allows everything
+			}
+			rule := robotsRule{allow: key == "allow", length: len(value), pattern: robotsPattern(value)}
+			if matchesAgent {
+				named = append(named, rule)
+			}
+			if matchesAny {
+				wildcard = append(wildcard, rule)
+			}
+		default:
+			// Other lines, such as Sitemap and Crawl-delay, do not end a group's user-agent list
+		}
+	}
+
+	if named != nil {
+		return named
+	}
+	return wildcard
+}
+
+// robotsPattern compiles a path pattern, where * matches any run of
+// characters and a trailing $ anchors the end
+func robotsPattern(value string) *regexp.Regexp {
+	anchored := strings.HasSuffix(value, "$")
+	value = strings.TrimSuffix(value, "$")
+
+	parts := strings.Split(value, "*")
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+	expr := "^" + strings.Join(parts, ".*")
+	if anchored {
+		expr += "$"
+	}
+	return regexp.MustCompile(expr)
+}
+
+// robotsEntry is one site's cached robots.txt
+type robotsEntry struct {
+	rules   robotsRules
+	expires time.Time
+}
+
+// robotsCache fetches and caches robots.txt per site
+type robotsCache struct {
+	client *http.Client
+	agent  string // Our product token, matched against User-agent lines
+	ttl    time.Duration
+	now    func() time.Time
+
+	mutex sync.Mutex
+	sites map[string]*robotsEntry
+}
+
+func newRobotsCache(client *http.Client, ttl time.Duration) *robotsCache {
+	agent, _, _ := strings.Cut(version.UserAgent(), "/")
+	return &robotsCache{
+		client: client,
+		agent:  strings.ToLower(agent),
+		ttl:    ttl,
+		now:    time.Now,
+		sites:  make(map[string]*robotsEntry),
+	}
+}
+
+// check returns errDisallowed when robots.txt keeps us away from target
+func (c *robotsCache) check(ctx context.Context, target *url.URL) error {
+	rules, err := c.rules(ctx, target)
+	if err != nil {
+		return err
+	}
+	path := target.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if target.RawQuery != "" {
+		path += "?" + target.RawQuery
+	}
+	if !rules.allowed(path) {
+		return errDisallowed
+	}
+	return nil
+}
+
+// rules returns target's site rules from the cache, fetching them when missing
+// or expired. A site on an address the client refuses to connect to is an
+// error rather than an unreachable robots.txt, so the caller reports why.
+func (c *robotsCache) rules(ctx context.Context, target *url.URL) (robotsRules, error) {
+	site := target.Scheme + "://" + target.Host
+
+	c.mutex.Lock()
+	entry, ok := c.sites[site]
+	c.mutex.Unlock()
+	if ok && c.now().Before(entry.expires) {
+		return entry.rules, nil
+	}
+
+	rules, err := c.fetch(ctx, site)
+	var private *publicnet.AddressError
+	if errors.As(err, &private) {
+		return nil, private
+	}
+	ttl := c.ttl
+	if err != nil {
+		// An unreachable robots.txt disallows the whole site (RFC 9309)
+		rules = robotsRules{{allow: false, length: 1, pattern: robotsPattern("/")}}
+		ttl = min(ttl, unreachableRobotsTTL)
+	}
+
+	c.mutex.Lock()
+	c.sites[site] = &robotsEntry{rules: rules, expires: c.now().Add(ttl)}
+	c.mutex.Unlock()
+	return rules, nil
+}
+
+// fetch downloads and parses a site's robots.txt. A missing file (any 4xx)
+// allows everything; server errors and network failures are errors.
+func (c *robotsCache) fetch(ctx context.Context, site string) (robotsRules, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, site+"/robots.txt", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", version.UserAgent())
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= http.StatusInternalServerError:
+		return nil, fmt.Errorf("robots.txt status %d", resp.StatusCode)
+	case resp.StatusCode >= http.StatusBadRequest:
+		return nil, nil
+	}
+	return parseRobots(io.LimitReader(resp.Body, maxRobotsBytes), c.agent), nil
+}
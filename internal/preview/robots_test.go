@@ -0,0 +1,113 @@
+package preview
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRobots(t *testing.T) {
+	robots := `
+# Everyone else stays out of /private
+User-agent: *
+Disallow: /private
+Allow: /private/public
+
+User-agent: OtherBot
+Disallow: /
+
+user-agent: url-shortener
+user-agent: SomeBot
+disallow: /drafts   # comment
+Disallow: /*.pdf$
+Allow: /drafts/shared
+Sitemap: https://example.com/sitemap.xml
+Disallow: /tmp*/cache
+`
+	rules := parseRobots(strings.NewReader(robots), "url-shortener")
+
+	tests := []struct {
+		path    string
+		allowed bool
+	}{
+		{"/", true},
+		{"/private", true}, // Our own group replaces the * group
+		{"/drafts", false},
+		{"/drafts/2024", false},
+		{"/drafts/shared/doc", true},
+		{"/report.pdf", false},
+		{"/report.pdf?download=1", true},
+		{"/tmp-1/cache/x", false},
+		{"/robots.txt", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			assert.Equal(t, tt.allowed, rules.allowed(tt.path))
+		})
+	}
+
+	t.Run("wildcard group", func(t *testing.T) {
+		rules := parseRobots(strings.NewReader(robots), "anotherbot")
+		assert.False(t, rules.allowed("/private/x"))
+		assert.True(t, rules.allowed("/private/public/x"))
+		assert.True(t, rules.allowed("/drafts"))
+	})
+
+	t.Run("allow wins ties", func(t *testing.T) {
+		rules := parseRobots(strings.NewReader("User-agent: *\nDisallow: /page\nAllow: /page\n"), "url-shortener")
+		assert.True(t, rules.allowed("/page"))
+	})
+
+	t.Run("empty disallow allows everything", func(t *testing.T) {
+		rules := parseRobots(strings.NewReader("User-agent: *\nDisallow:\n"), "url-shortener")
+		assert.True(t, rules.allowed("/anything"))
+	})
+}
+
+func TestRobotsCache(t *testing.T) {
+	var status atomic.Int32
+	status.Store(http.StatusOK)
+	var fetches atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		w.WriteHeader(int(status.Load()))
+		w.Write([]byte("User-agent: *\nDisallow: /private\n"))
+	}))
+	defer server.Close()
+
+	now := time.Now()
+	cache := newRobotsCache(server.Client(), time.Hour)
+	cache.now = func() time.Time { return now }
+	ctx := context.Background()
+	target := func(path string) *url.URL {
+		u, err := url.Parse(server.URL + path)
+		require.NoError(t, err)
+		return u
+	}
+
+	assert.NoError(t, cache.check(ctx, target("/public")))
+	assert.ErrorIs(t, cache.check(ctx, target("/private?x=1")), errDisallowed)
+	assert.Equal(t, int32(1), fetches.Load(), "robots.txt is fetched once per site")
+
+	// A missing robots.txt allows everything
+	status.Store(http.StatusNotFound)
+	now = now.Add(2 * time.Hour)
+	assert.NoError(t, cache.check(ctx, target("/private")))
+	assert.Equal(t, int32(2), fetches.Load())
+
+	// An unreachable one disallows the site, but only briefly
+	status.Store(http.StatusServiceUnavailable)
+	now = now.Add(2 * time.Hour)
+	assert.ErrorIs(t, cache.check(ctx, target("/public")), errDisallowed)
+	status.Store(http.StatusNotFound)
+	now = now.Add(2 * unreachableRobotsTTL)
+	assert.NoError(t, cache.check(ctx, target("/public")))
+}
@@ -0,0 +1,111 @@
+package privacy
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+	"net/netip"
+	"sync"
+	"time"
+)
+
+// hashPrefix marks hashed addresses so they are not mistaken for addresses
+const hashPrefix = "anon-"
+
+// Anonymizer replaces visitor addresses before they are remembered for click
+// dedupe or written to request logs and trace spans. Truncation keeps the
+// network, so visitors of one network count as one; hashing keeps visitors
+// apart with an HMAC whose random key is replaced every KeyRotation and never
+// stored, so hashes cannot be reversed or linked across rotations.
+// A nil Anonymizer returns addresses as they are.
+type Anonymizer struct {
+	config Config
+	now    func() time.Time
+
+	mu        sync.Mutex
+	key       []byte
+	previous  []byte
+	rotatedAt time.Time
+}
+
+// New creates an anonymizer, or returns nil when anonymization is off
+func New(config Config) *Anonymizer {
+	if config.Mode == ModeOff {
+		return nil
+	}
+	return &Anonymizer{config: config, now: time.Now}
+}
+
+// Anonymize returns the anonymized form of addr, which may carry a port.
+// Ports are dropped; text that is not an address is hashed, or dropped when
+// truncating.
+func (a *Anonymizer) Anonymize(addr string) string {
+	if a == nil || addr == "" {
+		return addr
+	}
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		addr = host
+	}
+	parsed, err := netip.ParseAddr(addr)
+	if err == nil {
+		parsed = parsed.Unmap().WithZone("")
+		addr = parsed.String()
+	}
+
+	if a.config.Mode == ModeHash {
+		key, _ := a.keys()
+		return hash(key, addr)
+	}
+	if err != nil {
+		return ""
+	}
+	bits := a.config.IPv6Prefix
+	if parsed.Is4() {
+		bits = a.config.IPv4Prefix
+	}
+	prefix, _ := parsed.Prefix(bits)
+	return prefix.Addr().String()
+}
+
+// Variants returns every form addr may have been remembered under: its
+// anonymized form under the current key and, when hashing, the previous one
+func (a *Anonymizer) Variants(addr string) []string {
+	if a == nil {
+		return []string{addr}
+	}
+	if a.config.Mode != ModeHash {
+		return []string{a.Anonymize(addr)}
+	}
+	variants := []string{a.Anonymize(addr)}
+	if _, previous := a.keys(); previous != nil {
+		if parsed, err := netip.ParseAddr(addr); err == nil {
+			addr = parsed.Unmap().WithZone("").String()
+		}
+		variants = append(variants, hash(previous, addr))
+	}
+	return variants
+}
+
+// keys returns the current hash key and the one it replaced, rotating them
+// when the current key is due
+func (a *Anonymizer) keys() (current, previous []byte) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	now := a.now()
+	if a.key == nil || (a.config.KeyRotation > 0 && !now.Before(a.rotatedAt.Add(a.config.KeyRotation))) {
+		key := make([]byte, 32)
+		rand.Read(key)
+		a.previous, a.key, a.rotatedAt = a.key, key, now
+	}
+	return a.key, a.previous
+}
+
+// hash returns the keyed hash of addr
+func hash(key []byte, addr string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(addr))
+	return hashPrefix + hex.EncodeToString(mac.Sum(nil)[:16])
+}
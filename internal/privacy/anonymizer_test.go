@@ -0,0 +1,75 @@
+package privacy
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnonymizer_Truncate(t *testing.T) {
+	anonymizer := New(Config{Mode: ModeTruncate, IPv4Prefix: 24, IPv6Prefix: 48})
+
+	assert.Equal(t, "192.0.2.0", anonymizer.Anonymize("192.0.2.123"))
+	assert.Equal(t, "192.0.2.0", anonymizer.Anonymize("192.0.2.123:54321"), "ports are dropped")
+	assert.Equal(t, "192.0.2.0", anonymizer.Anonymize("::ffff:192.0.2.123"), "IPv4-mapped addresses are truncated as IPv4")
+	assert.Equal(t, "2001:db8:1234::", anonymizer.Anonymize("[2001:db8:1234:5678::1]:443"))
+	assert.Equal(t, "", anonymizer.Anonymize("not-an-address"))
+	assert.Equal(t, "", anonymizer.Anonymize(""))
+	assert.Equal(t, []string{"192.0.2.0"}, anonymizer.Variants("192.0.2.9"))
+}
+
+func TestAnonymizer_Hash(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	anonymizer := New(Config{Mode: ModeHash, KeyRotation: 24 * time.Hour})
+	anonymizer.now = func() time.Time { return now }
+
+	hashed := anonymizer.Anonymize("192.0.2.1")
+	assert.True(t, strings.HasPrefix(hashed, hashPrefix))
+	assert.NotContains(t, hashed, "192.0.2")
+	assert.Equal(t, hashed, anonymizer.Anonymize("[::ffff:192.0.2.1]:8080"), "one address hashes the same however it is written")
+	assert.NotEqual(t, hashed, anonymizer.Anonymize("192.0.2.2"))
+	assert.Equal(t, []string{hashed}, anonymizer.Variants("192.0.2.1"))
+
+	// Another instance has its own key
+	assert.NotEqual(t, hashed, New(Config{Mode: ModeHash}).Anonymize("192.0.2.1"))
+
+	// After rotation the address hashes differently, and purges still find
+	// what was hashed with the previous key
+	now = now.Add(24 * time.Hour)
+	rotated := anonymizer.Anonymize("192.0.2.1")
+	assert.NotEqual(t, hashed, rotated)
+	assert.Equal(t, []string{rotated, hashed}, anonymizer.Variants("192.0.2.1"))
+}
+
+func TestAnonymizer_Nil(t *testing.T) {
+	assert.Nil(t, New(DefaultConfig()), "no anonymizer when anonymization is off")
+
+	var anonymizer *Anonymizer
+	assert.Equal(t, "192.0.2.1:1234", anonymizer.Anonymize("192.0.2.1:1234"))
+	assert.Equal(t, []string{"192.0.2.1"}, anonymizer.Variants("192.0.2.1"))
+}
+
+func TestConfig_Validate(t *testing.T) {
+	assert.NoError(t, DefaultConfig().Validate())
+	assert.NoError(t, Config{Mode: ModeTruncate, IPv4Prefix: 16, IPv6Prefix: 32}.Validate())
+	assert.NoError(t, Config{Mode: ModeHash}.Validate())
+
+	testCases := []struct {
+		name   string
+		modify func(*Config)
+	}{
+		{"unknown mode", func(c *Config) { c.Mode = "mask" }},
+		{"IPv4 prefix too long", func(c *Config) { c.Mode = ModeTruncate; c.IPv4Prefix = 33 }},
+		{"negative IPv6 prefix", func(c *Config) { c.Mode = ModeTruncate; c.IPv6Prefix = -1 }},
+		{"negative key rotation", func(c *Config) { c.Mode = ModeHash; c.KeyRotation = -time.Hour }},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			config := DefaultConfig()
+			tc.modify(&config)
+			assert.Error(t, config.Validate())
+		})
+	}
+}
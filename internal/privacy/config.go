@@ -0,0 +1,56 @@
+package privacy
+
+import (
+	"fmt"
+	"time"
+)
+
+// Mode selects how visitor addresses are anonymized
+type Mode string
+
+const (
+	ModeOff      Mode = ""         // Addresses are used as they are
+	ModeTruncate Mode = "truncate" // Addresses are cut to their network prefix
+	ModeHash     Mode = "hash"     // Addresses are replaced by a keyed hash whose key rotates
+)
+
+// Config holds IP anonymization configuration
+type Config struct {
+	Mode        Mode
+	IPv4Prefix  int           // Bits of IPv4 addresses kept when truncating
+	IPv6Prefix  int           // Bits of IPv6 addresses kept when truncating
+	KeyRotation time.Duration // How often the hash key is replaced; 0 keeps one key until restart
+}
+
+// DefaultConfig returns the default configuration, which leaves addresses as
+// they are but truncates to a /24 or /48 network when enabled
+func DefaultConfig() Config {
+	return Config{
+		Mode:        ModeOff,
+		IPv4Prefix:  24,
+		IPv6Prefix:  48,
+		KeyRotation: 24 * time.Hour,
+	}
+}
+
+// Validate checks that the configuration values are usable
+func (c Config) Validate() error {
+	switch c.Mode {
+	case ModeOff:
+		return nil
+	case ModeTruncate:
+		if c.IPv4Prefix < 0 || c.IPv4Prefix > 32 {
+			return fmt.Errorf("IPv4 prefix must be between 0 and 32, got: %d", c.IPv4Prefix)
+		}
+		if c.IPv6Prefix < 0 || c.IPv6Prefix > 128 {
+			return fmt.Errorf("IPv6 prefix must be between 0 and 128, got: %d", c.IPv6Prefix)
+		}
+	case ModeHash:
+		if c.KeyRotation < 0 {
+			return fmt.Errorf("key rotation cannot be negative, got: %v", c.KeyRotation)
+		}
+	default:
+		return fmt.Errorf("mode must be %q or %q, got: %q", ModeTruncate, ModeHash, c.Mode)
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+// Package publicnet dials only publicly routable addresses, for requests to
+// URLs that users supply, so that a link cannot point the server at its own
+// network or at a cloud metadata endpoint.
+package publicnet
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+// sharedPrefixes are unicast ranges that IsPrivate does not cover but that
+// never lead to the public internet
+var sharedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("100.64.0.0/10"),  // Carrier-grade NAT (RFC 6598), also cloud metadata services
+	netip.MustParsePrefix("192.0.0.0/24"),   // IETF protocol assignments (RFC 6890)
+	netip.MustParsePrefix("198.18.0.0/15"),  // Benchmarking (RFC 2544)
+	netip.MustParsePrefix("64:ff9b::/96"),   // NAT64 well-known prefix (RFC 6052), embeds IPv4 addresses
+	netip.MustParsePrefix("64:ff9b:1::/48"), // Local-use NAT64 (RFC 8215)
+}
+
+// AddressError is returned when a connection, including one for a redirect,
+// would go to an address that is not publicly routable
+type AddressError struct {
+	Addr netip.Addr
+}
+
+func (e *AddressError) Error() string {
+	return fmt.Sprintf("destination resolves to private address %s", e.Addr)
+}
+
+// Public reports whether addr is a publicly routable unicast address: not
+// loopback, private, link-local, unspecified, multicast, carrier-grade NAT or
+// NAT64
+func Public(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return false
+	}
+	for _, prefix := range sharedPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// Dialer returns a dialer that refuses to connect to addresses allow rejects
+// with an *AddressError. The check runs after name resolution, so a public
+// name pointing at an internal address is refused as well. Use it without a
+// proxy, so the address checked is the one connected to.
+func Dialer(timeout time.Duration, allow func(netip.Addr) bool) *net.Dialer {
+	return &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, c syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+			if addr := addrPort.Addr().Unmap(); !allow(addr) {
+				return &AddressError{Addr: addr}
+			}
+			return nil
+		},
+	}
+}
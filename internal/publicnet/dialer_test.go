@@ -0,0 +1,56 @@
+package publicnet
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPublic(t *testing.T) {
+	for _, addr := range []string{
+		"127.0.0.1", "10.1.2.3", "172.16.0.1", "192.168.0.1", "169.254.169.254", "0.0.0.0", "224.0.0.1",
+		"100.64.0.1", "100.100.100.200", "100.127.255.254", "192.0.0.170", "198.18.0.1",
+		"::1", "::", "fd00::1", "fe80::1", "ff02::1",
+		"64:ff9b::a9fe:a9fe", "64:ff9b::7f00:1", "64:ff9b:1::a00:1", "::ffff:100.100.100.200",
+	} {
+		assert.False(t, Public(netip.MustParseAddr(addr)), addr)
+	}
+	for _, addr := range []string{
+		"93.184.216.34", "100.63.255.255", "100.128.0.1", "8.8.8.8",
+		"2606:2800:220:1:248:1893:25c8:1946", "64:ff9c::1", "::ffff:93.184.216.34",
+	} {
+		assert.True(t, Public(netip.MustParseAddr(addr)), addr)
+	}
+}
+
+func TestDialer(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+
+	_, err = Dialer(time.Second, Public).DialContext(context.Background(), "tcp", listener.Addr().String())
+	var refused *AddressError
+	require.True(t, errors.As(err, &refused), "got %v", err)
+	assert.Equal(t, netip.MustParseAddr("127.0.0.1"), refused.Addr)
+	assert.Equal(t, "destination resolves to private address 127.0.0.1", refused.Error())
+
+	conn, err := Dialer(time.Second, func(addr netip.Addr) bool { return addr.IsLoopback() }).
+		DialContext(context.Background(), "tcp", listener.Addr().String())
+	require.NoError(t, err)
+	conn.Close()
+}
@@ -0,0 +1,65 @@
+package reachability
+
+import (
+	"fmt"
+	"time"
+)
+
+// Mode selects what happens to links whose destination does not answer
+type Mode string
+
+const (
+	ModeOff    Mode = ""       // Destinations are not checked
+	ModeFlag   Mode = "flag"   // New links are checked in the background and unreachable ones are listed
+	ModeReject Mode = "reject" // Creating a link, or changing its URL, fails when the destination does not answer
+)
+
+// Config holds destination reachability check configuration
+type Config struct {
+	Mode         Mode
+	Timeout      time.Duration // Timeout for checking one destination, redirects included
+	MaxRedirects int           // Redirects followed before a destination counts as unreachable
+	Interval     time.Duration // How often every link is checked again; 0 never
+	QueueSize    int           // New links waiting for a background check before more are skipped
+}
+
+// DefaultConfig returns the default configuration, which checks nothing but
+// re-checks every link daily when enabled
+func DefaultConfig() Config {
+	return Config{
+		Mode:         ModeOff,
+		Timeout:      5 * time.Second,
+		MaxRedirects: 5,
+		Interval:     24 * time.Hour,
+		QueueSize:    1000,
+	}
+}
+
+// Enabled reports whether destinations are checked
+func (c Config) Enabled() bool {
+	return c.Mode != ModeOff
+}
+
+// Validate checks that the configuration values are usable
+func (c Config) Validate() error {
+	switch c.Mode {
+	case ModeOff:
+		return nil
+	case ModeFlag, ModeReject:
+	default:
+		return fmt.Errorf("mode must be flag or reject, got: %q", c.Mode)
+	}
+	if c.Timeout <= 0 {
+		return fmt.Errorf("timeout must be positive, got: %v", c.Timeout)
+	}
+	if c.MaxRedirects < 0 {
+		return fmt.Errorf("max redirects cannot be negative, got: %d", c.MaxRedirects)
+	}
+	if c.Interval < 0 {
+		return fmt.Errorf("interval cannot be negative, got: %v", c.Interval)
+	}
+	if c.QueueSize < 1 {
+		return fmt.Errorf("queue size must be at least 1, got: %d", c.QueueSize)
+	}
+	return nil
+}
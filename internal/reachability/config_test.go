@@ -0,0 +1,38 @@
+package reachability
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfig_Validate(t *testing.T) {
+	assert.NoError(t, DefaultConfig().Validate())
+	assert.False(t, DefaultConfig().Enabled(), "destinations are not checked by default")
+	assert.NoError(t, Config{Mode: ModeOff, Timeout: -time.Second}.Validate(), "settings are unused while off")
+
+	enabled := DefaultConfig()
+	enabled.Mode = ModeReject
+	enabled.Interval = 0
+	assert.NoError(t, enabled.Validate(), "existing links need not be checked again")
+
+	testCases := []struct {
+		name   string
+		modify func(*Config)
+	}{
+		{"unknown mode", func(c *Config) { c.Mode = "warn" }},
+		{"zero timeout", func(c *Config) { c.Timeout = 0 }},
+		{"negative redirects", func(c *Config) { c.MaxRedirects = -1 }},
+		{"negative interval", func(c *Config) { c.Interval = -time.Hour }},
+		{"empty queue", func(c *Config) { c.QueueSize = 0 }},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			config := DefaultConfig()
+			config.Mode = ModeFlag
+			tc.modify(&config)
+			assert.Error(t, config.Validate())
+		})
+	}
+}
@@ -0,0 +1,406 @@
+package reachability
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	"github.com/joshdurbin/url-shortener/internal/drain"
+	"github.com/joshdurbin/url-shortener/internal/publicnet"
+	"github.com/joshdurbin/url-shortener/internal/repository"
+	"github.com/joshdurbin/url-shortener/internal/service"
+	"github.com/joshdurbin/url-shortener/internal/version"
+)
+
+// maxConcurrentChecks bounds how many destinations are checked at once when
+// every link is checked again
+const maxConcurrentChecks = 8
+
+// Verifier checks that link destinations answer: a HEAD request, retried as
+// a GET when the server refuses HEAD, that follows at most MaxRedirects
+// redirects and never connects to a loopback, private, link-local,
+// carrier-grade NAT, NAT64 or otherwise non-public address. In reject mode the service calls Verify
+// before creating a link; in flag mode new links are checked in the
+// background. Either way every link is checked again each Interval and the
+// latest result of each is stored; a link whose destination stops answering
+// is published as url.broken.
+type Verifier struct {
+	config   Config
+	links    repository.URLRepository
+	store    repository.VerificationRepository
+	notifier service.Notifier // Receives url.broken events; may be nil
+	client   *http.Client
+	allow    func(netip.Addr) bool // Whether a destination may connect to an address
+	now      func() time.Time
+
+	mutex    sync.RWMutex
+	started  bool
+	closed   bool
+	queue    chan string // Short codes of new links waiting for a check
+	stopChan chan struct{}
+	cancel   context.CancelFunc // Aborts checks in progress on Close
+	wg       sync.WaitGroup
+
+	checkMutex sync.Mutex // Serializes checks of every link
+	gate       drain.Gate // Holds storing checks while the server is drained
+}
+
+// New creates a destination verifier publishing url.broken events to
+// notifier, or returns nil when checks are off; Start, Close, Verify and
+// Notify of a nil verifier do nothing
+func New(config Config, links repository.URLRepository, store repository.VerificationRepository, notifier service.Notifier) *Verifier {
+	if !config.Enabled() {
+		return nil
+	}
+
+	v := &Verifier{
+		config:   config,
+		links:    links,
+		store:    store,
+		notifier: notifier,
+		allow:    publicnet.Public,
+		now:      time.Now,
+		queue:    make(chan string, config.QueueSize),
+		stopChan: make(chan struct{}),
+	}
+	dialer := publicnet.Dialer(config.Timeout, func(addr netip.Addr) bool { return v.allow(addr) })
+	v.client = &http.Client{
+		Timeout: config.Timeout,
+		// No proxy, so the address checked is the one connected to
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: config.Timeout,
+			MaxIdleConnsPerHost: 1,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) > config.MaxRedirects {
+				return fmt.Errorf("stopped after %d redirects", config.MaxRedirects)
+			}
+			return nil
+		},
+	}
+	return v
+}
+
+// Start starts the background check worker and, when Interval is set, the
+// scheduler that checks every link again
+func (v *Verifier) Start(ctx context.Context) error {
+	if v == nil {
+		return nil
+	}
+
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+
+	if v.started {
+		return fmt.Errorf("destination verifier already started")
+	}
+	v.started = true
+
+	runCtx, cancel := context.WithCancel(context.Background())
+	v.cancel = cancel
+	v.wg.Add(1)
+	go v.worker(runCtx)
+	if v.config.Interval > 0 {
+		v.wg.Add(1)
+		go v.scheduleLoop(runCtx)
+	}
+	return nil
+}
+
+// Close stops the worker and scheduler, abandoning checks in progress
+func (v *Verifier) Close() error {
+	if v == nil {
+		return nil
+	}
+
+	v.mutex.Lock()
+	if v.closed {
+		v.mutex.Unlock()
+		return nil
+	}
+	v.closed = true
+	close(v.stopChan)
+	if v.cancel != nil {
+		v.cancel()
+	}
+	v.mutex.Unlock()
+
+	v.wg.Wait()
+	return nil
+}
+
+// Pause waits for checks being stored and holds the next ones until Resume,
+// so the stored checks stay put during a drain. Destinations are still
+// checked meanwhile, each check waiting to be stored.
+func (v *Verifier) Pause(ctx context.Context) error {
+	if v == nil {
+		return nil
+	}
+	return v.gate.Pause(ctx)
+}
+
+// Resume lets checks be stored again
+func (v *Verifier) Resume() {
+	if v == nil {
+		return
+	}
+	v.gate.Resume()
+}
+
+// Verify returns an error when a new link to destination must be refused:
+// in reject mode, when the destination does not answer. In flag mode links
+// are never refused; Notify checks them once they exist.
+func (v *Verifier) Verify(ctx context.Context, destination string) error {
+	if v == nil || v.config.Mode != ModeReject {
+		return nil
+	}
+	result := v.Check(ctx, destination)
+	if !result.Reachable {
+		return fmt.Errorf("destination is unreachable: %s", result.Error)
+	}
+	return nil
+}
+
+// Notify queues a check of each link created in flag mode without blocking;
+// links are skipped when the queue is full
+func (v *Verifier) Notify(event domain.Event) {
+	if v == nil || v.config.Mode != ModeFlag || event.Type != domain.EventURLCreated {
+		return
+	}
+
+	v.mutex.RLock()
+	defer v.mutex.RUnlock()
+
+	if !v.started || v.closed {
+		return
+	}
+
+	select {
+	case v.queue <- event.Data.ShortCode:
+	default:
+		log.Printf("Verification queue full, skipping the check of %s", event.Data.ShortCode)
+	}
+}
+
+// Check requests a destination once and reports whether it answered without
+// an error status. Template destinations are checked with their sample
+// values filled in.
+func (v *Verifier) Check(ctx context.Context, destination string) domain.LinkVerification {
+	ctx, cancel := context.WithTimeout(ctx, v.config.Timeout)
+	defer cancel()
+
+	result := domain.LinkVerification{URL: checkTarget(destination), CheckedAt: v.now().UTC()}
+	status, err := v.probe(ctx, result.URL)
+	result.StatusCode = status
+	switch {
+	case err != nil:
+		result.Error = err.Error()
+	case status >= http.StatusBadRequest:
+		result.Error = fmt.Sprintf("status %d", status)
+	default:
+		result.Reachable = true
+	}
+	return result
+}
+
+// probe sends a HEAD request and returns the final response status. Some
+// servers reject or mishandle HEAD, so an error status is retried with GET.
+func (v *Verifier) probe(ctx context.Context, target string) (int, error) {
+	u, err := url.Parse(target)
+	if err != nil {
+		return 0, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return 0, fmt.Errorf("unsupported scheme %q", u.Scheme)
+	}
+
+	status, err := v.request(ctx, http.MethodHead, target)
+	if err == nil && status >= http.StatusBadRequest {
+		status, err = v.request(ctx, http.MethodGet, target)
+	}
+	return status, err
+}
+
+// request sends one request and returns the response status
+func (v *Verifier) request(ctx context.Context, method, target string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, method, target, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("User-Agent", version.UserAgent())
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		// Report a private address without the url.Error and dial wrapping
+		var private *publicnet.AddressError
+		if errors.As(err, &private) {
+			return 0, private
+		}
+		return 0, err
+	}
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+// VerifyLink checks a link's destination now and stores the result
+func (v *Verifier) VerifyLink(ctx context.Context, shortCode string) (*domain.LinkVerification, error) {
+	entry, err := v.links.GetURL(ctx, shortCode)
+	if err != nil {
+		return nil, err
+	}
+
+	previous, err := v.store.GetLinkVerification(ctx, shortCode)
+	if err != nil && !errors.Is(err, domain.ErrVerificationNotFound) {
+		return nil, err
+	}
+
+	result := v.Check(ctx, entry.OriginalURL)
+	result.ShortCode = shortCode
+	if err := v.record(ctx, entry, result, previous); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// VerifyAll checks every link's destination and stores the results
+func (v *Verifier) VerifyAll(ctx context.Context) error {
+	v.checkMutex.Lock()
+	defer v.checkMutex.Unlock()
+
+	entries, err := v.links.GetAllURLs(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list links: %w", err)
+	}
+	stored, err := v.store.ListLinkVerifications(ctx, false)
+	if err != nil {
+		return fmt.Errorf("failed to list previous checks: %w", err)
+	}
+	previous := make(map[string]*domain.LinkVerification, len(stored))
+	for i := range stored {
+		previous[stored[i].ShortCode] = &stored[i]
+	}
+
+	results := make([]domain.LinkVerification, len(entries))
+	semaphore := make(chan struct{}, maxConcurrentChecks)
+	var wg sync.WaitGroup
+	for i, entry := range entries {
+		wg.Add(1)
+		semaphore <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+			results[i] = v.Check(ctx, entry.OriginalURL)
+			results[i].ShortCode = entry.ShortCode
+		}()
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	unreachable := 0
+	for i, result := range results {
+		if !result.Reachable {
+			unreachable++
+		}
+		if err := v.record(ctx, entries[i], result, previous[result.ShortCode]); err != nil {
+			return fmt.Errorf("failed to store the check of %s: %w", result.ShortCode, err)
+		}
+	}
+	if unreachable > 0 {
+		log.Printf("Verification: %d of %d link destinations are unreachable", unreachable, len(results))
+	}
+	return nil
+}
+
+// record stores a link's check and publishes url.broken when the destination
+// stopped answering: its previous check found it reachable, or there was
+// none. While the verifier is paused it waits for Resume.
+func (v *Verifier) record(ctx context.Context, entry *domain.URLEntry, result domain.LinkVerification, previous *domain.LinkVerification) error {
+	if err := v.gate.Enter(ctx); err != nil {
+		return err
+	}
+	err := v.store.SetLinkVerification(ctx, result)
+	v.gate.Leave()
+	if err != nil {
+		return err
+	}
+	if result.Reachable || (previous != nil && !previous.Reachable) {
+		return nil
+	}
+
+	log.Printf("Verification: %s destination %s is unreachable (%s)", entry.ShortCode, result.URL, result.Error)
+	if v.notifier != nil {
+		v.notifier.Notify(domain.NewEvent(domain.EventURLBroken, domain.EventData{
+			ShortCode:   entry.ShortCode,
+			OriginalURL: entry.OriginalURL,
+			Campaign:    entry.Campaign,
+			UsageCount:  entry.UsageCount,
+			BackupURL:   entry.BackupURL,
+			Reason:      result.Error,
+		}))
+	}
+	return nil
+}
+
+// List returns the latest check of every checked link, newest first, or only
+// of the unreachable ones
+func (v *Verifier) List(ctx context.Context, unreachableOnly bool) ([]domain.LinkVerification, error) {
+	return v.store.ListLinkVerifications(ctx, unreachableOnly)
+}
+
+// worker checks queued new links until the verifier is closed
+func (v *Verifier) worker(ctx context.Context) {
+	defer v.wg.Done()
+
+	for {
+		select {
+		case <-v.stopChan:
+			return
+		case shortCode := <-v.queue:
+			if _, err := v.VerifyLink(ctx, shortCode); err != nil && ctx.Err() == nil && !errors.Is(err, domain.ErrURLNotFound) {
+				log.Printf("Error checking the destination of %s: %v", shortCode, err)
+			}
+		}
+	}
+}
+
+// scheduleLoop checks every link each interval until the verifier is closed
+func (v *Verifier) scheduleLoop(ctx context.Context) {
+	defer v.wg.Done()
+
+	ticker := time.NewTicker(v.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-v.stopChan:
+			return
+		case <-ticker.C:
+			if err := v.VerifyAll(ctx); err != nil && ctx.Err() == nil {
+				log.Printf("Error checking link destinations: %v", err)
+			}
+		}
+	}
+}
+
+// checkTarget returns the URL checked for a destination; a template link is
+// checked with its sample URL (defaults, or "x" for required values)
+func checkTarget(destination string) string {
+	if !domain.IsTemplate(destination) {
+		return destination
+	}
+	tmpl, err := domain.ParseTemplate(destination)
+	if err != nil {
+		return destination
+	}
+	return tmpl.Sample()
+}
@@ -0,0 +1,277 @@
+package reachability
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	"github.com/joshdurbin/url-shortener/internal/publicnet"
+	"github.com/joshdurbin/url-shortener/internal/repository/mocks"
+)
+
+// newTestVerifier returns a verifier without a scheduler that may connect to
+// the loopback test servers
+func newTestVerifier(mode Mode, links *mocks.URLRepository, store *mocks.VerificationRepository) *Verifier {
+	config := DefaultConfig()
+	config.Mode = mode
+	config.Timeout = time.Second
+	config.MaxRedirects = 2
+	config.Interval = 0
+	v := New(config, links, store, nil)
+	v.allow = func(addr netip.Addr) bool { return addr.IsLoopback() }
+	return v
+}
+
+// newTestServer serves 200 at /ok, 404 at /missing, a HEAD-refusing page at
+// /get-only and a chain of redirects at /hops/N
+func newTestServer(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("/missing", http.NotFound)
+	mux.HandleFunc("/get-only", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+	mux.HandleFunc("/hops/", func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/hops/3":
+			http.Redirect(w, r, "/hops/2", http.StatusFound)
+		case "/hops/2":
+			http.Redirect(w, r, "/hops/1", http.StatusFound)
+		case "/hops/1":
+			http.Redirect(w, r, "/ok", http.StatusFound)
+		}
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestNew_Off(t *testing.T) {
+	v := New(DefaultConfig(), nil, nil, nil)
+	assert.Nil(t, v)
+
+	// A nil verifier accepts everything
+	assert.NoError(t, v.Start(context.Background()))
+	assert.NoError(t, v.Verify(context.Background(), "http://127.0.0.1:1/"))
+	v.Notify(domain.Event{Type: domain.EventURLCreated})
+	assert.NoError(t, v.Close())
+}
+
+func TestVerifier_Check(t *testing.T) {
+	server := newTestServer(t)
+	v := newTestVerifier(ModeReject, nil, nil)
+	ctx := context.Background()
+
+	result := v.Check(ctx, server.URL+"/ok")
+	assert.True(t, result.Reachable)
+	assert.Equal(t, http.StatusOK, result.StatusCode)
+	assert.Empty(t, result.Error)
+	assert.False(t, result.CheckedAt.IsZero())
+
+	result = v.Check(ctx, server.URL+"/missing")
+	assert.False(t, result.Reachable)
+	assert.Equal(t, http.StatusNotFound, result.StatusCode)
+	assert.Equal(t, "status 404", result.Error)
+
+	// HEAD refusals are retried with GET
+	assert.True(t, v.Check(ctx, server.URL+"/get-only").Reachable)
+
+	// Redirects are followed up to the limit
+	assert.True(t, v.Check(ctx, server.URL+"/hops/2").Reachable)
+	result = v.Check(ctx, server.URL+"/hops/3")
+	assert.False(t, result.Reachable)
+	assert.Contains(t, result.Error, "stopped after 2 redirects")
+
+	// Templates are checked with their sample values
+	result = v.Check(ctx, server.URL+"/{page=ok}")
+	assert.True(t, result.Reachable)
+	assert.Equal(t, server.URL+"/ok", result.URL)
+
+	result = v.Check(ctx, "ftp://example.com/file")
+	assert.False(t, result.Reachable)
+	assert.Contains(t, result.Error, "unsupported scheme")
+}
+
+func TestVerifier_CheckPrivateAddress(t *testing.T) {
+	server := newTestServer(t)
+	v := newTestVerifier(ModeReject, nil, nil)
+	v.allow = publicnet.Public
+
+	result := v.Check(context.Background(), server.URL+"/ok")
+	assert.False(t, result.Reachable)
+	assert.Equal(t, "destination resolves to private address 127.0.0.1", result.Error)
+
+	// Carrier-grade NAT (home of some clouds' metadata services) and NAT64
+	// addresses are refused like private ones
+	for _, addr := range []string{"100.100.100.200", "64:ff9b::a9fe:a9fe"} {
+		assert.False(t, v.allow(netip.MustParseAddr(addr)), addr)
+	}
+}
+
+func TestVerifier_Verify(t *testing.T) {
+	server := newTestServer(t)
+	ctx := context.Background()
+
+	v := newTestVerifier(ModeReject, nil, nil)
+	assert.NoError(t, v.Verify(ctx, server.URL+"/ok"))
+	assert.EqualError(t, v.Verify(ctx, server.URL+"/missing"), "destination is unreachable: status 404")
+
+	// Flag mode never refuses a link
+	v = newTestVerifier(ModeFlag, nil, nil)
+	assert.NoError(t, v.Verify(ctx, server.URL+"/missing"))
+}
+
+func TestVerifier_Notify(t *testing.T) {
+	server := newTestServer(t)
+	links := &mocks.URLRepository{}
+	links.On("GetURL", mock.Anything, "abc123").Return(&domain.URLEntry{ShortCode: "abc123", OriginalURL: server.URL + "/missing"}, nil)
+	stored := make(chan domain.LinkVerification, 1)
+	store := &mocks.VerificationRepository{}
+	store.On("GetLinkVerification", mock.Anything, "abc123").Return(nil, domain.ErrVerificationNotFound)
+	store.On("SetLinkVerification", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		stored <- args.Get(1).(domain.LinkVerification)
+	}).Return(nil)
+
+	v := newTestVerifier(ModeFlag, links, store)
+	require.NoError(t, v.Start(context.Background()))
+	defer v.Close()
+
+	v.Notify(domain.Event{Type: domain.EventURLClicked, Data: domain.EventData{ShortCode: "abc123"}})
+	v.Notify(domain.Event{Type: domain.EventURLCreated, Data: domain.EventData{ShortCode: "abc123"}})
+
+	select {
+	case result := <-stored:
+		assert.Equal(t, "abc123", result.ShortCode)
+		assert.False(t, result.Reachable)
+		assert.Equal(t, http.StatusNotFound, result.StatusCode)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the new link was not checked")
+	}
+	links.AssertNumberOfCalls(t, "GetURL", 1)
+}
+
+func TestVerifier_PauseHoldsChecks(t *testing.T) {
+	server := newTestServer(t)
+	links := &mocks.URLRepository{}
+	links.On("GetURL", mock.Anything, "abc123").Return(&domain.URLEntry{ShortCode: "abc123", OriginalURL: server.URL + "/ok"}, nil)
+	stored := make(chan domain.LinkVerification, 1)
+	store := &mocks.VerificationRepository{}
+	store.On("GetLinkVerification", mock.Anything, "abc123").Return(nil, domain.ErrVerificationNotFound)
+	store.On("SetLinkVerification", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		stored <- args.Get(1).(domain.LinkVerification)
+	}).Return(nil).Once()
+
+	v := newTestVerifier(ModeFlag, links, store)
+	require.NoError(t, v.Pause(context.Background()))
+	require.NoError(t, v.Start(context.Background()))
+	defer v.Close()
+
+	// Paused, the new link is checked but its result waits to be stored
+	v.Notify(domain.Event{Type: domain.EventURLCreated, Data: domain.EventData{ShortCode: "abc123"}})
+	select {
+	case <-stored:
+		t.Fatal("the check was stored while paused")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	v.Resume()
+	select {
+	case result := <-stored:
+		assert.True(t, result.Reachable)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the check was not stored after resuming")
+	}
+}
+
+func TestVerifier_VerifyAll(t *testing.T) {
+	server := newTestServer(t)
+	ctx := context.Background()
+	links := &mocks.URLRepository{}
+	links.On("GetAllURLs", ctx).Return([]*domain.URLEntry{
+		{ShortCode: "up", OriginalURL: server.URL + "/ok"},
+		{ShortCode: "down", OriginalURL: server.URL + "/missing"},
+	}, nil)
+	store := &mocks.VerificationRepository{}
+	store.On("ListLinkVerifications", ctx, false).Return([]domain.LinkVerification{}, nil)
+	store.On("SetLinkVerification", ctx, mock.MatchedBy(func(v domain.LinkVerification) bool {
+		return v.ShortCode == "up" && v.Reachable
+	})).Return(nil).Once()
+	store.On("SetLinkVerification", ctx, mock.MatchedBy(func(v domain.LinkVerification) bool {
+		return v.ShortCode == "down" && !v.Reachable && v.Error == "status 404"
+	})).Return(nil).Once()
+
+	v := newTestVerifier(ModeReject, links, store)
+	require.NoError(t, v.VerifyAll(ctx))
+	store.AssertExpectations(t)
+}
+
+func TestVerifier_VerifyLink(t *testing.T) {
+	server := newTestServer(t)
+	ctx := context.Background()
+	links := &mocks.URLRepository{}
+	links.On("GetURL", ctx, "abc123").Return(&domain.URLEntry{ShortCode: "abc123", OriginalURL: server.URL + "/ok"}, nil)
+	links.On("GetURL", ctx, "nope").Return(nil, domain.ErrURLNotFound)
+	store := &mocks.VerificationRepository{}
+	store.On("GetLinkVerification", ctx, "abc123").Return(nil, domain.ErrVerificationNotFound)
+	store.On("SetLinkVerification", ctx, mock.Anything).Return(nil)
+
+	v := newTestVerifier(ModeFlag, links, store)
+	result, err := v.VerifyLink(ctx, "abc123")
+	require.NoError(t, err)
+	assert.Equal(t, "abc123", result.ShortCode)
+	assert.True(t, result.Reachable)
+
+	_, err = v.VerifyLink(ctx, "nope")
+	assert.ErrorIs(t, err, domain.ErrURLNotFound)
+}
+
+// recordingNotifier collects the events it is notified of
+type recordingNotifier struct {
+	events []domain.Event
+}
+
+func (r *recordingNotifier) Notify(event domain.Event) {
+	r.events = append(r.events, event)
+}
+
+func TestVerifier_BrokenEvents(t *testing.T) {
+	server := newTestServer(t)
+	ctx := context.Background()
+	links := &mocks.URLRepository{}
+	links.On("GetAllURLs", ctx).Return([]*domain.URLEntry{
+		{ShortCode: "up", OriginalURL: server.URL + "/ok"},
+		{ShortCode: "new", OriginalURL: server.URL + "/missing", Campaign: "spring"},
+		{ShortCode: "still", OriginalURL: server.URL + "/missing"},
+		{ShortCode: "broke", OriginalURL: server.URL + "/missing"},
+	}, nil)
+	store := &mocks.VerificationRepository{}
+	store.On("ListLinkVerifications", ctx, false).Return([]domain.LinkVerification{
+		{ShortCode: "up", Reachable: false},
+		{ShortCode: "still", Reachable: false},
+		{ShortCode: "broke", Reachable: true},
+	}, nil)
+	store.On("SetLinkVerification", ctx, mock.Anything).Return(nil)
+
+	notifier := &recordingNotifier{}
+	v := newTestVerifier(ModeFlag, links, store)
+	v.notifier = notifier
+	require.NoError(t, v.VerifyAll(ctx))
+
+	// Only links that were not already unreachable are reported
+	require.Len(t, notifier.events, 2)
+	assert.Equal(t, domain.EventURLBroken, notifier.events[0].Type)
+	assert.Equal(t, "new", notifier.events[0].Data.ShortCode)
+	assert.Equal(t, "spring", notifier.events[0].Data.Campaign)
+	assert.Equal(t, "status 404", notifier.events[0].Data.Reason)
+	assert.Equal(t, "broke", notifier.events[1].Data.ShortCode)
+}
@@ -11,29 +11,274 @@ import (
 // URLRepository defines the interface for URL data operations
 type URLRepository interface {
 	// CreateURL creates a new short URL entry
-	CreateURL(ctx context.Context, shortCode, originalURL string, createdAt time.Time) (*domain.URLEntry, error)
+	CreateURL(ctx context.Context, shortCode, originalURL string, createdAt time.Time, opts domain.CreateOptions) (*domain.URLEntry, error)
 	
 	// GetURL retrieves a URL entry by its short code
 	GetURL(ctx context.Context, shortCode string) (*domain.URLEntry, error)
 	
+	// GetURLByOriginalURL retrieves the owner's oldest uncapped entry for a
+	// destination on the server's own host, or domain.ErrURLNotFound if there
+	// is none
+	GetURLByOriginalURL(ctx context.Context, originalURL, owner string) (*domain.URLEntry, error)
+	
 	// GetAllURLs retrieves all URL entries ordered by creation date (desc)
 	GetAllURLs(ctx context.Context) ([]*domain.URLEntry, error)
 	
-	// UpdateUsage updates the usage count and last used timestamp for a URL
+	// GetURLsByCampaign retrieves a campaign's URL entries ordered by creation date (desc)
+	GetURLsByCampaign(ctx context.Context, campaign string) ([]*domain.URLEntry, error)
+	
+	// GetURLsByOwner retrieves the URL entries created by an owner ordered by creation date (desc)
+	GetURLsByOwner(ctx context.Context, owner string) ([]*domain.URLEntry, error)
+	
+	// UpdateURL replaces the destination and settings (usage cap, tags, redirect status) of an existing URL entry
+	UpdateURL(ctx context.Context, shortCode, originalURL string, opts domain.CreateOptions) (*domain.URLEntry, error)
+	
+	// SetURLFailover records whether redirects for a URL go to its backup destination, and why
+	SetURLFailover(ctx context.Context, shortCode string, active bool, reason string, changedAt time.Time) (*domain.URLEntry, error)
+	
+	// SetURLPreview stores the preview fetched for a URL's destination page
+	SetURLPreview(ctx context.Context, shortCode string, preview domain.LinkPreview) (*domain.URLEntry, error)
+	
+	// UpdateUsage records a usage count and last used timestamp for a URL; the higher count wins
 	UpdateUsage(ctx context.Context, shortCode string, usageCount int, lastUsedAt time.Time) error
 	
+	// UpdateUsageBatch applies usage updates in one transaction and returns the merged count per short code
+	UpdateUsageBatch(ctx context.Context, updates []domain.UsageUpdate, strategy domain.UsageMergeStrategy) (map[string]int, error)
+	
+	// BurnURL claims the single redirect of a burn-after-reading link, or returns
+	// domain.ErrUsageLimitReached once it has been used
+	BurnURL(ctx context.Context, shortCode string, usedAt time.Time) error
+	
 	// DeleteURL removes a URL entry by its short code
 	DeleteURL(ctx context.Context, shortCode string) error
 	
 	// URLExists checks if a short code exists
 	URLExists(ctx context.Context, shortCode string) (bool, error)
 	
+	// GetRoutingRules retrieves a URL's routing rules in evaluation order
+	GetRoutingRules(ctx context.Context, shortCode string) ([]domain.RoutingRule, error)
+	
+	// SetRoutingRules replaces a URL's routing rules, or returns domain.ErrURLNotFound
+	SetRoutingRules(ctx context.Context, shortCode string, rules []domain.RoutingRule, createdAt time.Time) error
+	
 	// LoadCacheData loads all URL data for cache initialization
 	LoadCacheData(ctx context.Context) (map[string]*domain.CacheEntry, error)
 	
+	// ImportURLs inserts entries in a single transaction, resolving existing short codes with the given strategy
+	ImportURLs(ctx context.Context, entries []*domain.URLEntry, strategy domain.ConflictStrategy) (*domain.ImportResult, error)
+	
+	// MigrateShortCodes renames links and their history in a single transaction,
+	// then inserts aliases; nothing changes if any code is missing or taken
+	MigrateShortCodes(ctx context.Context, changes []domain.CodeChange, aliases []*domain.URLEntry) error
+	
 	// GetQueries returns the underlying sqlc queries for advanced operations
 	GetQueries() *sqlc.Queries
 	
+	// Ping verifies the database connection is alive
+	Ping(ctx context.Context) error
+	
 	// Close closes the repository connection
 	Close() error
-}
\ No newline at end of file
+}
+
+// WebhookRepository defines the interface for webhook endpoint and delivery log storage
+type WebhookRepository interface {
+	// CreateWebhookEndpoint stores a new webhook endpoint
+	CreateWebhookEndpoint(ctx context.Context, endpoint *domain.WebhookEndpoint) (*domain.WebhookEndpoint, error)
+
+	// ListWebhookEndpoints retrieves all stored webhook endpoints, including their secrets
+	ListWebhookEndpoints(ctx context.Context) ([]*domain.WebhookEndpoint, error)
+
+	// DeleteWebhookEndpoint removes a webhook endpoint and its delivery log
+	DeleteWebhookEndpoint(ctx context.Context, id int64) error
+
+	// RecordWebhookDelivery appends a delivery attempt to the log
+	RecordWebhookDelivery(ctx context.Context, delivery *domain.WebhookDelivery) error
+
+	// ListWebhookDeliveries retrieves the most recent delivery attempts, optionally for one endpoint (0 for all)
+	ListWebhookDeliveries(ctx context.Context, endpointID int64, limit int) ([]*domain.WebhookDelivery, error)
+
+	// PruneWebhookDeliveries removes delivery attempts older than the given time
+	PruneWebhookDeliveries(ctx context.Context, before time.Time) (int64, error)
+}
+
+// PolicyRepository defines the interface for lifecycle policy and audit log storage
+type PolicyRepository interface {
+	// CreateLifecyclePolicy stores a new lifecycle policy
+	CreateLifecyclePolicy(ctx context.Context, policy *domain.LifecyclePolicy) (*domain.LifecyclePolicy, error)
+
+	// ListLifecyclePolicies retrieves all stored lifecycle policies in creation order
+	ListLifecyclePolicies(ctx context.Context) ([]*domain.LifecyclePolicy, error)
+
+	// DeleteLifecyclePolicy removes a lifecycle policy
+	DeleteLifecyclePolicy(ctx context.Context, id int64) error
+
+	// RecordPolicyAction appends an action taken by a policy to the audit log
+	RecordPolicyAction(ctx context.Context, entry *domain.PolicyAuditEntry) error
+
+	// ListPolicyActions retrieves the most recent policy actions
+	ListPolicyActions(ctx context.Context, limit int) ([]*domain.PolicyAuditEntry, error)
+}
+
+// DestinationRepository defines the interface for the destination allow and
+// deny rules managed through the API
+type DestinationRepository interface {
+	// CreateDestinationRule stores a rule, failing with
+	// domain.ErrDestinationRuleExists if the list already has the pattern
+	CreateDestinationRule(ctx context.Context, rule domain.DestinationRule) error
+
+	// ListDestinationRules retrieves every stored rule, by list and in the order added
+	ListDestinationRules(ctx context.Context) ([]domain.DestinationRule, error)
+
+	// DeleteDestinationRule removes a pattern from a list
+	DeleteDestinationRule(ctx context.Context, list domain.DestinationList, pattern string) error
+}
+
+// DomainRepository defines the interface for the custom domains links are
+// served on
+type DomainRepository interface {
+	// CreateDomain stores a domain, failing with domain.ErrDomainExists if
+	// the host is already stored
+	CreateDomain(ctx context.Context, d domain.CustomDomain) error
+
+	// UpdateDomain replaces a domain's redirect status and branding
+	UpdateDomain(ctx context.Context, d domain.CustomDomain) error
+
+	// ListDomains retrieves every stored domain ordered by host
+	ListDomains(ctx context.Context) ([]domain.CustomDomain, error)
+
+	// DeleteDomain removes a domain, failing with domain.ErrDomainInUse while
+	// links are served on it
+	DeleteDomain(ctx context.Context, host string) error
+}
+
+// AliasRepository defines the interface for the extra short codes links can
+// be reached by
+type AliasRepository interface {
+	// CreateAlias stores an alias, failing with domain.ErrURLNotFound when its
+	// link does not exist and domain.ErrShortCodeTaken when the alias is
+	// already a short code or alias
+	CreateAlias(ctx context.Context, alias domain.Alias) error
+
+	// GetAlias retrieves an alias, or domain.ErrAliasNotFound
+	GetAlias(ctx context.Context, alias string) (domain.Alias, error)
+
+	// ListAliases retrieves a link's aliases, oldest first
+	ListAliases(ctx context.Context, shortCode string) ([]domain.Alias, error)
+
+	// ListAllAliases retrieves every alias
+	ListAllAliases(ctx context.Context) ([]domain.Alias, error)
+
+	// DeleteAlias removes one of a link's aliases, or returns domain.ErrAliasNotFound
+	DeleteAlias(ctx context.Context, shortCode, alias string) error
+}
+
+// VerificationRepository defines the interface for the results of destination
+// reachability checks
+type VerificationRepository interface {
+	// SetLinkVerification stores a link's latest check, replacing the one
+	// before; a check of a link that no longer exists is dropped
+	SetLinkVerification(ctx context.Context, verification domain.LinkVerification) error
+
+	// GetLinkVerification retrieves a link's latest check, failing with
+	// domain.ErrVerificationNotFound if it was never checked
+	GetLinkVerification(ctx context.Context, shortCode string) (*domain.LinkVerification, error)
+
+	// ListLinkVerifications retrieves the latest check of every checked link,
+	// newest first, or only of the unreachable ones
+	ListLinkVerifications(ctx context.Context, unreachableOnly bool) ([]domain.LinkVerification, error)
+}
+
+// StorageRepository defines the interface for measuring database usage
+type StorageRepository interface {
+	// StorageStats measures the database size, row counts and clicks, counting links created since the given time
+	StorageStats(ctx context.Context, since time.Time) (*domain.StorageStats, error)
+}
+
+// BackupRepository defines the interface for taking online database snapshots
+type BackupRepository interface {
+	// Backup writes a consistent copy of the database to path, which must not exist
+	Backup(ctx context.Context, path string) error
+}
+
+// AnalyticsRepository defines the interface for click analytics rollups
+type AnalyticsRepository interface {
+	// AddClickRollups adds clicks to the referrer, UTM, country and per-minute
+	// rollups in one transaction; clicks on links that no longer exist are skipped
+	AddClickRollups(ctx context.Context, rollups domain.ClickRollups) error
+
+	// ListReferrerClicks retrieves a link's clicks per referring domain, most first
+	ListReferrerClicks(ctx context.Context, shortCode string, limit int) ([]domain.ReferrerClicks, error)
+
+	// ListUTMClicks retrieves a link's clicks per combination of UTM parameters, most first
+	ListUTMClicks(ctx context.Context, shortCode string, limit int) ([]domain.UTMClicks, error)
+
+	// ListTopReferrers retrieves the referring domains with the most clicks across all links
+	ListTopReferrers(ctx context.Context, limit int) ([]domain.ReferrerClicks, error)
+
+	// ListCountryClicks retrieves a link's clicks per country and region, most first
+	ListCountryClicks(ctx context.Context, shortCode string, limit int) ([]domain.CountryClicks, error)
+
+	// ListTopCountries retrieves the countries with the most clicks across all links
+	ListTopCountries(ctx context.Context, limit int) ([]domain.CountryClicks, error)
+
+	// ListTopLinks retrieves the links with the most clicks since since, or with
+	// the largest gain over their clicks from previousSince to since
+	ListTopLinks(ctx context.Context, since, previousSince time.Time, sort domain.TopLinksSort, limit int) ([]domain.TopLink, error)
+
+	// ListClickBuckets retrieves a link's clicks per bucket of size from from
+	// until to, leaving out buckets without clicks
+	ListClickBuckets(ctx context.Context, shortCode string, from, to time.Time, size time.Duration) ([]domain.TimeSeriesBucket, error)
+
+	// CompactClickEvents sums the per-minute clicks before before, an hour
+	// boundary, into hourly rows and deletes the minutes, returning how many
+	// were compacted
+	CompactClickEvents(ctx context.Context, before time.Time) (int64, error)
+}
+
+// ArchiveRepository defines the interface for moving old per-minute and
+// per-hour clicks out of the database
+type ArchiveRepository interface {
+	// OldestClickBefore retrieves the start of the oldest minute or hour with
+	// clicks before before; ok is false when there is none
+	OldestClickBefore(ctx context.Context, before time.Time) (oldest time.Time, ok bool, err error)
+
+	// ListClicksBetween retrieves the minutes and hours with clicks from from
+	// until to, oldest first
+	ListClicksBetween(ctx context.Context, from, to time.Time) ([]domain.ArchivedClicks, error)
+
+	// DeleteClicksBetween deletes the minutes and hours from from until to in
+	// one transaction, returning how many rows were deleted
+	DeleteClicksBetween(ctx context.Context, from, to time.Time) (int64, error)
+}
+
+// ExportRepository defines the interface for the outbox of events waiting to
+// be exported to a message broker
+type ExportRepository interface {
+	// AddExportEvents appends events to the outbox in one transaction
+	AddExportEvents(ctx context.Context, events []domain.Event) error
+
+	// ListExportEvents retrieves the oldest events in the outbox, in order
+	ListExportEvents(ctx context.Context, limit int) ([]domain.ExportRecord, error)
+
+	// DeleteExportEvents removes the events up to and including id from the outbox
+	DeleteExportEvents(ctx context.Context, throughID int64) error
+
+	// CountExportEvents returns the number of events in the outbox
+	CountExportEvents(ctx context.Context) (int64, error)
+}
+
+// OutboxRepository defines the interface for the outbox of events written in
+// the same transaction as the links they describe, so a crash after the write
+// cannot lose them
+type OutboxRepository interface {
+	// CreateURLWithEvent creates a short URL and appends event to the outbox in
+	// one transaction; it fails like URLRepository.CreateURL
+	CreateURLWithEvent(ctx context.Context, shortCode, originalURL string, createdAt time.Time, opts domain.CreateOptions, event domain.Event) (*domain.URLEntry, error)
+
+	// ListOutboxEvents retrieves the oldest events in the outbox, in order
+	ListOutboxEvents(ctx context.Context, limit int) ([]domain.ExportRecord, error)
+
+	// DeleteOutboxEvents removes the events up to and including id from the outbox
+	DeleteOutboxEvents(ctx context.Context, throughID int64) error
+}
@@ -0,0 +1,49 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	"github.com/stretchr/testify/mock"
+)
+
+// AliasRepository is a mock implementation of repository.AliasRepository
+type AliasRepository struct {
+	mock.Mock
+}
+
+// CreateAlias stores an alias
+func (m *AliasRepository) CreateAlias(ctx context.Context, alias domain.Alias) error {
+	args := m.Called(ctx, alias)
+	return args.Error(0)
+}
+
+// GetAlias retrieves an alias
+func (m *AliasRepository) GetAlias(ctx context.Context, alias string) (domain.Alias, error) {
+	args := m.Called(ctx, alias)
+	return args.Get(0).(domain.Alias), args.Error(1)
+}
+
+// ListAliases retrieves a link's aliases
+func (m *AliasRepository) ListAliases(ctx context.Context, shortCode string) ([]domain.Alias, error) {
+	args := m.Called(ctx, shortCode)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.Alias), args.Error(1)
+}
+
+// ListAllAliases retrieves every alias
+func (m *AliasRepository) ListAllAliases(ctx context.Context) ([]domain.Alias, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.Alias), args.Error(1)
+}
+
+// DeleteAlias removes one of a link's aliases
+func (m *AliasRepository) DeleteAlias(ctx context.Context, shortCode, alias string) error {
+	args := m.Called(ctx, shortCode, alias)
+	return args.Error(0)
+}
@@ -0,0 +1,117 @@
+package mocks
+
+import (
+	"context"
+	"time"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	"github.com/stretchr/testify/mock"
+)
+
+// AnalyticsRepository is a mock implementation of repository.AnalyticsRepository
+type AnalyticsRepository struct {
+	mock.Mock
+}
+
+// AddClickRollups adds clicks to the referrer, UTM and country rollups
+func (m *AnalyticsRepository) AddClickRollups(ctx context.Context, rollups domain.ClickRollups) error {
+	args := m.Called(ctx, rollups)
+	return args.Error(0)
+}
+
+// ListReferrerClicks retrieves a link's clicks per referring domain
+func (m *AnalyticsRepository) ListReferrerClicks(ctx context.Context, shortCode string, limit int) ([]domain.ReferrerClicks, error) {
+	args := m.Called(ctx, shortCode, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.ReferrerClicks), args.Error(1)
+}
+
+// ListUTMClicks retrieves a link's clicks per combination of UTM parameters
+func (m *AnalyticsRepository) ListUTMClicks(ctx context.Context, shortCode string, limit int) ([]domain.UTMClicks, error) {
+	args := m.Called(ctx, shortCode, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.UTMClicks), args.Error(1)
+}
+
+// ListTopReferrers retrieves the referring domains with the most clicks across all links
+func (m *AnalyticsRepository) ListTopReferrers(ctx context.Context, limit int) ([]domain.ReferrerClicks, error) {
+	args := m.Called(ctx, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.ReferrerClicks), args.Error(1)
+}
+
+// ListCountryClicks retrieves a link's clicks per country and region
+func (m *AnalyticsRepository) ListCountryClicks(ctx context.Context, shortCode string, limit int) ([]domain.CountryClicks, error) {
+	args := m.Called(ctx, shortCode, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.CountryClicks), args.Error(1)
+}
+
+// ListTopCountries retrieves the countries with the most clicks across all links
+func (m *AnalyticsRepository) ListTopCountries(ctx context.Context, limit int) ([]domain.CountryClicks, error) {
+	args := m.Called(ctx, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.CountryClicks), args.Error(1)
+}
+
+// ListTopLinks retrieves the links with the most clicks since since, or with
+// the largest gain over their clicks from previousSince to since
+func (m *AnalyticsRepository) ListTopLinks(ctx context.Context, since, previousSince time.Time, sort domain.TopLinksSort, limit int) ([]domain.TopLink, error) {
+	args := m.Called(ctx, since, previousSince, sort, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.TopLink), args.Error(1)
+}
+
+// ListClickBuckets retrieves a link's clicks per bucket of size from from
+// until to, leaving out buckets without clicks
+func (m *AnalyticsRepository) ListClickBuckets(ctx context.Context, shortCode string, from, to time.Time, size time.Duration) ([]domain.TimeSeriesBucket, error) {
+	args := m.Called(ctx, shortCode, from, to, size)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.TimeSeriesBucket), args.Error(1)
+}
+
+// CompactClickEvents sums the per-minute clicks before before into hourly rows
+func (m *AnalyticsRepository) CompactClickEvents(ctx context.Context, before time.Time) (int64, error) {
+	args := m.Called(ctx, before)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// ArchiveRepository is a mock implementation of repository.ArchiveRepository
+type ArchiveRepository struct {
+	mock.Mock
+}
+
+// OldestClickBefore retrieves the start of the oldest minute or hour with clicks before before
+func (m *ArchiveRepository) OldestClickBefore(ctx context.Context, before time.Time) (time.Time, bool, error) {
+	args := m.Called(ctx, before)
+	return args.Get(0).(time.Time), args.Bool(1), args.Error(2)
+}
+
+// ListClicksBetween retrieves the minutes and hours with clicks from from until to
+func (m *ArchiveRepository) ListClicksBetween(ctx context.Context, from, to time.Time) ([]domain.ArchivedClicks, error) {
+	args := m.Called(ctx, from, to)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.ArchivedClicks), args.Error(1)
+}
+
+// DeleteClicksBetween deletes the minutes and hours from from until to
+func (m *ArchiveRepository) DeleteClicksBetween(ctx context.Context, from, to time.Time) (int64, error) {
+	args := m.Called(ctx, from, to)
+	return args.Get(0).(int64), args.Error(1)
+}
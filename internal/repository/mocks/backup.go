@@ -0,0 +1,18 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// BackupRepository is a mock implementation of repository.BackupRepository
+type BackupRepository struct {
+	mock.Mock
+}
+
+// Backup writes a consistent copy of the database to path
+func (m *BackupRepository) Backup(ctx context.Context, path string) error {
+	args := m.Called(ctx, path)
+	return args.Error(0)
+}
@@ -0,0 +1,34 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	"github.com/stretchr/testify/mock"
+)
+
+// DestinationRepository is a mock implementation of repository.DestinationRepository
+type DestinationRepository struct {
+	mock.Mock
+}
+
+// CreateDestinationRule stores a destination rule
+func (m *DestinationRepository) CreateDestinationRule(ctx context.Context, rule domain.DestinationRule) error {
+	args := m.Called(ctx, rule)
+	return args.Error(0)
+}
+
+// ListDestinationRules retrieves every stored destination rule
+func (m *DestinationRepository) ListDestinationRules(ctx context.Context) ([]domain.DestinationRule, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.DestinationRule), args.Error(1)
+}
+
+// DeleteDestinationRule removes a pattern from a list
+func (m *DestinationRepository) DeleteDestinationRule(ctx context.Context, list domain.DestinationList, pattern string) error {
+	args := m.Called(ctx, list, pattern)
+	return args.Error(0)
+}
@@ -0,0 +1,40 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	"github.com/stretchr/testify/mock"
+)
+
+// DomainRepository is a mock implementation of repository.DomainRepository
+type DomainRepository struct {
+	mock.Mock
+}
+
+// CreateDomain stores a custom domain
+func (m *DomainRepository) CreateDomain(ctx context.Context, d domain.CustomDomain) error {
+	args := m.Called(ctx, d)
+	return args.Error(0)
+}
+
+// UpdateDomain replaces a custom domain's redirect status and branding
+func (m *DomainRepository) UpdateDomain(ctx context.Context, d domain.CustomDomain) error {
+	args := m.Called(ctx, d)
+	return args.Error(0)
+}
+
+// ListDomains retrieves every stored custom domain
+func (m *DomainRepository) ListDomains(ctx context.Context) ([]domain.CustomDomain, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.CustomDomain), args.Error(1)
+}
+
+// DeleteDomain removes a custom domain
+func (m *DomainRepository) DeleteDomain(ctx context.Context, host string) error {
+	args := m.Called(ctx, host)
+	return args.Error(0)
+}
@@ -0,0 +1,40 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	"github.com/stretchr/testify/mock"
+)
+
+// ExportRepository is a mock implementation of repository.ExportRepository
+type ExportRepository struct {
+	mock.Mock
+}
+
+// AddExportEvents appends events to the outbox
+func (m *ExportRepository) AddExportEvents(ctx context.Context, events []domain.Event) error {
+	args := m.Called(ctx, events)
+	return args.Error(0)
+}
+
+// ListExportEvents retrieves the oldest events in the outbox
+func (m *ExportRepository) ListExportEvents(ctx context.Context, limit int) ([]domain.ExportRecord, error) {
+	args := m.Called(ctx, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.ExportRecord), args.Error(1)
+}
+
+// DeleteExportEvents removes the events up to and including id from the outbox
+func (m *ExportRepository) DeleteExportEvents(ctx context.Context, throughID int64) error {
+	args := m.Called(ctx, throughID)
+	return args.Error(0)
+}
+
+// CountExportEvents returns the number of events in the outbox
+func (m *ExportRepository) CountExportEvents(ctx context.Context) (int64, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(int64), args.Error(1)
+}
@@ -0,0 +1,38 @@
+package mocks
+
+import (
+	"context"
+	"time"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	"github.com/stretchr/testify/mock"
+)
+
+// OutboxRepository is a mock implementation of repository.OutboxRepository
+type OutboxRepository struct {
+	mock.Mock
+}
+
+// CreateURLWithEvent creates a short URL and appends event to the outbox
+func (m *OutboxRepository) CreateURLWithEvent(ctx context.Context, shortCode, originalURL string, createdAt time.Time, opts domain.CreateOptions, event domain.Event) (*domain.URLEntry, error) {
+	args := m.Called(ctx, shortCode, originalURL, createdAt, opts, event)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.URLEntry), args.Error(1)
+}
+
+// ListOutboxEvents retrieves the oldest events in the outbox
+func (m *OutboxRepository) ListOutboxEvents(ctx context.Context, limit int) ([]domain.ExportRecord, error) {
+	args := m.Called(ctx, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.ExportRecord), args.Error(1)
+}
+
+// DeleteOutboxEvents removes the events up to and including id from the outbox
+func (m *OutboxRepository) DeleteOutboxEvents(ctx context.Context, throughID int64) error {
+	args := m.Called(ctx, throughID)
+	return args.Error(0)
+}
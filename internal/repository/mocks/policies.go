@@ -0,0 +1,52 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	"github.com/stretchr/testify/mock"
+)
+
+// PolicyRepository is a mock implementation of repository.PolicyRepository
+type PolicyRepository struct {
+	mock.Mock
+}
+
+// CreateLifecyclePolicy stores a new lifecycle policy
+func (m *PolicyRepository) CreateLifecyclePolicy(ctx context.Context, policy *domain.LifecyclePolicy) (*domain.LifecyclePolicy, error) {
+	args := m.Called(ctx, policy)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.LifecyclePolicy), args.Error(1)
+}
+
+// ListLifecyclePolicies retrieves all stored lifecycle policies
+func (m *PolicyRepository) ListLifecyclePolicies(ctx context.Context) ([]*domain.LifecyclePolicy, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.LifecyclePolicy), args.Error(1)
+}
+
+// DeleteLifecyclePolicy removes a lifecycle policy
+func (m *PolicyRepository) DeleteLifecyclePolicy(ctx context.Context, id int64) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+// RecordPolicyAction appends an action taken by a policy to the audit log
+func (m *PolicyRepository) RecordPolicyAction(ctx context.Context, entry *domain.PolicyAuditEntry) error {
+	args := m.Called(ctx, entry)
+	return args.Error(0)
+}
+
+// ListPolicyActions retrieves the most recent policy actions
+func (m *PolicyRepository) ListPolicyActions(ctx context.Context, limit int) ([]*domain.PolicyAuditEntry, error) {
+	args := m.Called(ctx, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.PolicyAuditEntry), args.Error(1)
+}
@@ -15,8 +15,8 @@ type URLRepository struct {
 }
 
 // CreateURL creates a new short URL entry
-func (m *URLRepository) CreateURL(ctx context.Context, shortCode, originalURL string, createdAt time.Time) (*domain.URLEntry, error) {
-	args := m.Called(ctx, shortCode, originalURL, createdAt)
+func (m *URLRepository) CreateURL(ctx context.Context, shortCode, originalURL string, createdAt time.Time, opts domain.CreateOptions) (*domain.URLEntry, error) {
+	args := m.Called(ctx, shortCode, originalURL, createdAt, opts)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
@@ -32,6 +32,15 @@ func (m *URLRepository) GetURL(ctx context.Context, shortCode string) (*domain.U
 	return args.Get(0).(*domain.URLEntry), args.Error(1)
 }
 
+// GetURLByOriginalURL retrieves the owner's oldest uncapped entry for a destination
+func (m *URLRepository) GetURLByOriginalURL(ctx context.Context, originalURL, owner string) (*domain.URLEntry, error) {
+	args := m.Called(ctx, originalURL, owner)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.URLEntry), args.Error(1)
+}
+
 // GetAllURLs retrieves all URL entries ordered by creation date (desc)
 func (m *URLRepository) GetAllURLs(ctx context.Context) ([]*domain.URLEntry, error) {
 	args := m.Called(ctx)
@@ -41,12 +50,72 @@ func (m *URLRepository) GetAllURLs(ctx context.Context) ([]*domain.URLEntry, err
 	return args.Get(0).([]*domain.URLEntry), args.Error(1)
 }
 
-// UpdateUsage updates the usage count and last used timestamp for a URL
+// GetURLsByCampaign retrieves a campaign's URL entries ordered by creation date (desc)
+func (m *URLRepository) GetURLsByCampaign(ctx context.Context, campaign string) ([]*domain.URLEntry, error) {
+	args := m.Called(ctx, campaign)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.URLEntry), args.Error(1)
+}
+
+// GetURLsByOwner retrieves the URL entries created by an owner ordered by creation date (desc)
+func (m *URLRepository) GetURLsByOwner(ctx context.Context, owner string) ([]*domain.URLEntry, error) {
+	args := m.Called(ctx, owner)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.URLEntry), args.Error(1)
+}
+
+// UpdateURL replaces the destination and settings of an existing URL entry
+func (m *URLRepository) UpdateURL(ctx context.Context, shortCode, originalURL string, opts domain.CreateOptions) (*domain.URLEntry, error) {
+	args := m.Called(ctx, shortCode, originalURL, opts)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.URLEntry), args.Error(1)
+}
+
+// SetURLFailover records whether redirects for a URL go to its backup destination
+func (m *URLRepository) SetURLFailover(ctx context.Context, shortCode string, active bool, reason string, changedAt time.Time) (*domain.URLEntry, error) {
+	args := m.Called(ctx, shortCode, active, reason, changedAt)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.URLEntry), args.Error(1)
+}
+
+// SetURLPreview stores the preview fetched for a URL's destination page
+func (m *URLRepository) SetURLPreview(ctx context.Context, shortCode string, preview domain.LinkPreview) (*domain.URLEntry, error) {
+	args := m.Called(ctx, shortCode, preview)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.URLEntry), args.Error(1)
+}
+
+// UpdateUsage records a usage count and last used timestamp for a URL; the higher count wins
 func (m *URLRepository) UpdateUsage(ctx context.Context, shortCode string, usageCount int, lastUsedAt time.Time) error {
 	args := m.Called(ctx, shortCode, usageCount, lastUsedAt)
 	return args.Error(0)
 }
 
+// UpdateUsageBatch applies usage updates in one transaction and returns the merged count per short code
+func (m *URLRepository) UpdateUsageBatch(ctx context.Context, updates []domain.UsageUpdate, strategy domain.UsageMergeStrategy) (map[string]int, error) {
+	args := m.Called(ctx, updates, strategy)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[string]int), args.Error(1)
+}
+
+// BurnURL claims the single redirect of a burn-after-reading link
+func (m *URLRepository) BurnURL(ctx context.Context, shortCode string, usedAt time.Time) error {
+	args := m.Called(ctx, shortCode, usedAt)
+	return args.Error(0)
+}
+
 // DeleteURL removes a URL entry by its short code
 func (m *URLRepository) DeleteURL(ctx context.Context, shortCode string) error {
 	args := m.Called(ctx, shortCode)
@@ -59,6 +128,21 @@ func (m *URLRepository) URLExists(ctx context.Context, shortCode string) (bool,
 	return args.Bool(0), args.Error(1)
 }
 
+// GetRoutingRules retrieves a URL's routing rules in evaluation order
+func (m *URLRepository) GetRoutingRules(ctx context.Context, shortCode string) ([]domain.RoutingRule, error) {
+	args := m.Called(ctx, shortCode)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.RoutingRule), args.Error(1)
+}
+
+// SetRoutingRules replaces a URL's routing rules
+func (m *URLRepository) SetRoutingRules(ctx context.Context, shortCode string, rules []domain.RoutingRule, createdAt time.Time) error {
+	args := m.Called(ctx, shortCode, rules, createdAt)
+	return args.Error(0)
+}
+
 // LoadCacheData loads all URL data for cache initialization
 func (m *URLRepository) LoadCacheData(ctx context.Context) (map[string]*domain.CacheEntry, error) {
 	args := m.Called(ctx)
@@ -68,6 +152,21 @@ func (m *URLRepository) LoadCacheData(ctx context.Context) (map[string]*domain.C
 	return args.Get(0).(map[string]*domain.CacheEntry), args.Error(1)
 }
 
+// ImportURLs inserts entries in a single transaction, resolving existing short codes with the given strategy
+func (m *URLRepository) ImportURLs(ctx context.Context, entries []*domain.URLEntry, strategy domain.ConflictStrategy) (*domain.ImportResult, error) {
+	args := m.Called(ctx, entries, strategy)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.ImportResult), args.Error(1)
+}
+
+// MigrateShortCodes renames links and their history, then inserts aliases
+func (m *URLRepository) MigrateShortCodes(ctx context.Context, changes []domain.CodeChange, aliases []*domain.URLEntry) error {
+	args := m.Called(ctx, changes, aliases)
+	return args.Error(0)
+}
+
 // GetQueries returns the underlying sqlc queries for advanced operations
 func (m *URLRepository) GetQueries() *sqlc.Queries {
 	args := m.Called()
@@ -77,6 +176,12 @@ func (m *URLRepository) GetQueries() *sqlc.Queries {
 	return args.Get(0).(*sqlc.Queries)
 }
 
+// Ping verifies the database connection is alive
+func (m *URLRepository) Ping(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
+
 // Close closes the repository connection
 func (m *URLRepository) Close() error {
 	args := m.Called()
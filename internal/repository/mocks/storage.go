@@ -0,0 +1,23 @@
+package mocks
+
+import (
+	"context"
+	"time"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	"github.com/stretchr/testify/mock"
+)
+
+// StorageRepository is a mock implementation of repository.StorageRepository
+type StorageRepository struct {
+	mock.Mock
+}
+
+// StorageStats measures the database size, row counts and clicks
+func (m *StorageRepository) StorageStats(ctx context.Context, since time.Time) (*domain.StorageStats, error) {
+	args := m.Called(ctx, since)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.StorageStats), args.Error(1)
+}
@@ -0,0 +1,37 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	"github.com/stretchr/testify/mock"
+)
+
+// VerificationRepository is a mock implementation of repository.VerificationRepository
+type VerificationRepository struct {
+	mock.Mock
+}
+
+// SetLinkVerification stores a link's latest reachability check
+func (m *VerificationRepository) SetLinkVerification(ctx context.Context, verification domain.LinkVerification) error {
+	args := m.Called(ctx, verification)
+	return args.Error(0)
+}
+
+// GetLinkVerification retrieves a link's latest reachability check
+func (m *VerificationRepository) GetLinkVerification(ctx context.Context, shortCode string) (*domain.LinkVerification, error) {
+	args := m.Called(ctx, shortCode)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.LinkVerification), args.Error(1)
+}
+
+// ListLinkVerifications retrieves the latest reachability checks
+func (m *VerificationRepository) ListLinkVerifications(ctx context.Context, unreachableOnly bool) ([]domain.LinkVerification, error) {
+	args := m.Called(ctx, unreachableOnly)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.LinkVerification), args.Error(1)
+}
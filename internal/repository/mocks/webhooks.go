@@ -0,0 +1,59 @@
+package mocks
+
+import (
+	"context"
+	"time"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	"github.com/stretchr/testify/mock"
+)
+
+// WebhookRepository is a mock implementation of repository.WebhookRepository
+type WebhookRepository struct {
+	mock.Mock
+}
+
+// CreateWebhookEndpoint stores a new webhook endpoint
+func (m *WebhookRepository) CreateWebhookEndpoint(ctx context.Context, endpoint *domain.WebhookEndpoint) (*domain.WebhookEndpoint, error) {
+	args := m.Called(ctx, endpoint)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.WebhookEndpoint), args.Error(1)
+}
+
+// ListWebhookEndpoints retrieves all stored webhook endpoints
+func (m *WebhookRepository) ListWebhookEndpoints(ctx context.Context) ([]*domain.WebhookEndpoint, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.WebhookEndpoint), args.Error(1)
+}
+
+// DeleteWebhookEndpoint removes a webhook endpoint and its delivery log
+func (m *WebhookRepository) DeleteWebhookEndpoint(ctx context.Context, id int64) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+// RecordWebhookDelivery appends a delivery attempt to the log
+func (m *WebhookRepository) RecordWebhookDelivery(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	args := m.Called(ctx, delivery)
+	return args.Error(0)
+}
+
+// ListWebhookDeliveries retrieves the most recent delivery attempts
+func (m *WebhookRepository) ListWebhookDeliveries(ctx context.Context, endpointID int64, limit int) ([]*domain.WebhookDelivery, error) {
+	args := m.Called(ctx, endpointID, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.WebhookDelivery), args.Error(1)
+}
+
+// PruneWebhookDeliveries removes delivery attempts older than the given time
+func (m *WebhookRepository) PruneWebhookDeliveries(ctx context.Context, before time.Time) (int64, error) {
+	args := m.Called(ctx, before)
+	return args.Get(0).(int64), args.Error(1)
+}
@@ -0,0 +1,121 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/joshdurbin/url-shortener/db/sqlc"
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	"github.com/joshdurbin/url-shortener/internal/repository"
+)
+
+// CreateAlias stores an alias in one transaction with the checks that its
+// link exists and that no link or alias already uses the code
+func (r *Repository) CreateAlias(ctx context.Context, alias domain.Alias) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return domain.Storage(fmt.Errorf("failed to begin transaction: %w", err))
+	}
+	defer tx.Rollback()
+
+	queries := r.queries.WithTx(tx)
+
+	count, err := queries.URLExists(ctx, alias.ShortCode)
+	if err != nil {
+		return domain.Storage(fmt.Errorf("failed to check URL existence: %w", err))
+	}
+	if count == 0 {
+		return domain.ErrURLNotFound
+	}
+	if count, err = queries.URLExists(ctx, alias.Alias); err != nil {
+		return domain.Storage(fmt.Errorf("failed to check URL existence: %w", err))
+	}
+	if count > 0 {
+		return fmt.Errorf("alias %s: %w", alias.Alias, domain.ErrShortCodeTaken)
+	}
+
+	err = queries.CreateAlias(ctx, sqlc.CreateAliasParams{
+		Alias:     alias.Alias,
+		ShortCode: alias.ShortCode,
+		CreatedAt: alias.CreatedAt,
+	})
+	if isUniqueViolation(err) {
+		return fmt.Errorf("alias %s: %w", alias.Alias, domain.ErrShortCodeTaken)
+	}
+	if err != nil {
+		return domain.Storage(fmt.Errorf("failed to create alias: %w", err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return domain.Storage(fmt.Errorf("failed to commit alias: %w", err))
+	}
+	return nil
+}
+
+// GetAlias retrieves an alias, or domain.ErrAliasNotFound
+func (r *Repository) GetAlias(ctx context.Context, alias string) (domain.Alias, error) {
+	row, err := r.queries.GetAlias(ctx, alias)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.Alias{}, domain.ErrAliasNotFound
+	}
+	if err != nil {
+		return domain.Alias{}, domain.Storage(fmt.Errorf("failed to get alias: %w", err))
+	}
+	return sqlcAliasToDomain(row), nil
+}
+
+// ListAliases retrieves a link's aliases, oldest first
+func (r *Repository) ListAliases(ctx context.Context, shortCode string) ([]domain.Alias, error) {
+	rows, err := r.queries.ListAliases(ctx, shortCode)
+	if err != nil {
+		return nil, domain.Storage(fmt.Errorf("failed to list aliases: %w", err))
+	}
+	return sqlcAliasesToDomain(rows), nil
+}
+
+// ListAllAliases retrieves every alias
+func (r *Repository) ListAllAliases(ctx context.Context) ([]domain.Alias, error) {
+	rows, err := r.queries.ListAllAliases(ctx)
+	if err != nil {
+		return nil, domain.Storage(fmt.Errorf("failed to list aliases: %w", err))
+	}
+	return sqlcAliasesToDomain(rows), nil
+}
+
+// DeleteAlias removes one of a link's aliases, or returns domain.ErrAliasNotFound
+func (r *Repository) DeleteAlias(ctx context.Context, shortCode, alias string) error {
+	deleted, err := r.queries.DeleteAlias(ctx, sqlc.DeleteAliasParams{
+		ShortCode: shortCode,
+		Alias:     alias,
+	})
+	if err != nil {
+		return domain.Storage(fmt.Errorf("failed to delete alias: %w", err))
+	}
+	if deleted == 0 {
+		return domain.ErrAliasNotFound
+	}
+	return nil
+}
+
+// sqlcAliasToDomain converts a stored alias
+func sqlcAliasToDomain(row sqlc.Alias) domain.Alias {
+	return domain.Alias{
+		Alias:     row.Alias,
+		ShortCode: row.ShortCode,
+		CreatedAt: row.CreatedAt,
+	}
+}
+
+// sqlcAliasesToDomain converts stored aliases
+func sqlcAliasesToDomain(rows []sqlc.Alias) []domain.Alias {
+	aliases := make([]domain.Alias, len(rows))
+	for i, row := range rows {
+		aliases[i] = sqlcAliasToDomain(row)
+	}
+	return aliases
+}
+
+// Ensure Repository implements the interface
+var _ repository.AliasRepository = (*Repository)(nil)
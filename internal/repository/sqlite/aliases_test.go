@@ -0,0 +1,61 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+)
+
+func TestRepository_Aliases(t *testing.T) {
+	repo := setupTestRepo(t)
+	defer teardownTestRepo(t, repo)
+
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Second)
+	for _, code := range []string{"abc123", "def456"} {
+		_, err := repo.CreateURL(ctx, code, "https://example.com/"+code, now, domain.CreateOptions{})
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, repo.CreateAlias(ctx, domain.Alias{Alias: "spring", ShortCode: "abc123", CreatedAt: now}))
+	require.NoError(t, repo.CreateAlias(ctx, domain.Alias{Alias: "summer", ShortCode: "abc123", CreatedAt: now.Add(time.Second)}))
+
+	// Aliases share one namespace with short codes
+	err := repo.CreateAlias(ctx, domain.Alias{Alias: "spring", ShortCode: "def456", CreatedAt: now})
+	assert.ErrorIs(t, err, domain.ErrShortCodeTaken)
+	err = repo.CreateAlias(ctx, domain.Alias{Alias: "def456", ShortCode: "abc123", CreatedAt: now})
+	assert.ErrorIs(t, err, domain.ErrShortCodeTaken)
+	_, err = repo.CreateURL(ctx, "spring", "https://example.com/other", now, domain.CreateOptions{})
+	assert.ErrorIs(t, err, domain.ErrShortCodeTaken)
+	err = repo.CreateAlias(ctx, domain.Alias{Alias: "autumn", ShortCode: "missing", CreatedAt: now})
+	assert.ErrorIs(t, err, domain.ErrURLNotFound)
+
+	alias, err := repo.GetAlias(ctx, "spring")
+	require.NoError(t, err)
+	assert.Equal(t, "abc123", alias.ShortCode)
+	_, err = repo.GetAlias(ctx, "autumn")
+	assert.ErrorIs(t, err, domain.ErrAliasNotFound)
+
+	aliases, err := repo.ListAliases(ctx, "abc123")
+	require.NoError(t, err)
+	require.Len(t, aliases, 2)
+	assert.Equal(t, "spring", aliases[0].Alias)
+	assert.Equal(t, "summer", aliases[1].Alias)
+
+	// An alias is only removed through its own link
+	assert.ErrorIs(t, repo.DeleteAlias(ctx, "def456", "spring"), domain.ErrAliasNotFound)
+	require.NoError(t, repo.DeleteAlias(ctx, "abc123", "spring"))
+	_, err = repo.GetAlias(ctx, "spring")
+	assert.ErrorIs(t, err, domain.ErrAliasNotFound)
+
+	// Deleting the link deletes its aliases
+	require.NoError(t, repo.DeleteURL(ctx, "abc123"))
+	all, err := repo.ListAllAliases(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, all)
+}
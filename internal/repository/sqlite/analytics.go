@@ -0,0 +1,222 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/joshdurbin/url-shortener/db/sqlc"
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	"github.com/joshdurbin/url-shortener/internal/repository"
+)
+
+// AddClickRollups adds clicks to the referrer, UTM, country and per-minute
+// rollups in one transaction; clicks on links that no longer exist are skipped
+func (r *Repository) AddClickRollups(ctx context.Context, rollups domain.ClickRollups) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return domain.Storage(fmt.Errorf("failed to begin transaction: %w", err))
+	}
+	defer tx.Rollback()
+
+	queries := r.queries.WithTx(tx)
+
+	for _, rollup := range rollups.Referrers {
+		err := queries.AddReferrerClicks(ctx, sqlc.AddReferrerClicksParams{
+			ShortCode: rollup.ShortCode,
+			Referrer:  rollup.Referrer,
+			Clicks:    int64(rollup.Clicks),
+		})
+		if err != nil {
+			return domain.Storage(fmt.Errorf("failed to add referrer clicks: %w", err))
+		}
+	}
+
+	for _, rollup := range rollups.UTM {
+		err := queries.AddUTMClicks(ctx, sqlc.AddUTMClicksParams{
+			ShortCode:   rollup.ShortCode,
+			UtmSource:   rollup.Source,
+			UtmMedium:   rollup.Medium,
+			UtmCampaign: rollup.Campaign,
+			Clicks:      int64(rollup.Clicks),
+		})
+		if err != nil {
+			return domain.Storage(fmt.Errorf("failed to add UTM clicks: %w", err))
+		}
+	}
+
+	for _, rollup := range rollups.Countries {
+		err := queries.AddCountryClicks(ctx, sqlc.AddCountryClicksParams{
+			ShortCode: rollup.ShortCode,
+			Country:   rollup.Country,
+			Region:    rollup.Region,
+			Clicks:    int64(rollup.Clicks),
+		})
+		if err != nil {
+			return domain.Storage(fmt.Errorf("failed to add country clicks: %w", err))
+		}
+	}
+
+	for _, rollup := range rollups.Minutes {
+		err := queries.AddMinuteClicks(ctx, sqlc.AddMinuteClicksParams{
+			ShortCode: rollup.ShortCode,
+			Minute:    rollup.Minute.Unix(),
+			Clicks:    int64(rollup.Clicks),
+		})
+		if err != nil {
+			return domain.Storage(fmt.Errorf("failed to add minute clicks: %w", err))
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return domain.Storage(fmt.Errorf("failed to commit click rollups: %w", err))
+	}
+	return nil
+}
+
+// ListReferrerClicks retrieves a link's clicks per referring domain, most first
+func (r *Repository) ListReferrerClicks(ctx context.Context, shortCode string, limit int) ([]domain.ReferrerClicks, error) {
+	rows, err := r.queries.ListReferrerClicks(ctx, sqlc.ListReferrerClicksParams{ShortCode: shortCode, Limit: int64(limit)})
+	if err != nil {
+		return nil, domain.Storage(fmt.Errorf("failed to list referrer clicks: %w", err))
+	}
+
+	referrers := make([]domain.ReferrerClicks, 0, len(rows))
+	for _, row := range rows {
+		referrers = append(referrers, domain.ReferrerClicks{Referrer: row.Referrer, Clicks: int(row.Clicks)})
+	}
+	return referrers, nil
+}
+
+// ListUTMClicks retrieves a link's clicks per combination of UTM parameters, most first
+func (r *Repository) ListUTMClicks(ctx context.Context, shortCode string, limit int) ([]domain.UTMClicks, error) {
+	rows, err := r.queries.ListUTMClicks(ctx, sqlc.ListUTMClicksParams{ShortCode: shortCode, Limit: int64(limit)})
+	if err != nil {
+		return nil, domain.Storage(fmt.Errorf("failed to list UTM clicks: %w", err))
+	}
+
+	utm := make([]domain.UTMClicks, 0, len(rows))
+	for _, row := range rows {
+		utm = append(utm, domain.UTMClicks{
+			UTMParams: domain.UTMParams{Source: row.UtmSource, Medium: row.UtmMedium, Campaign: row.UtmCampaign},
+			Clicks:    int(row.Clicks),
+		})
+	}
+	return utm, nil
+}
+
+// ListTopReferrers retrieves the referring domains with the most clicks across all links
+func (r *Repository) ListTopReferrers(ctx context.Context, limit int) ([]domain.ReferrerClicks, error) {
+	rows, err := r.queries.ListTopReferrers(ctx, int64(limit))
+	if err != nil {
+		return nil, domain.Storage(fmt.Errorf("failed to list top referrers: %w", err))
+	}
+
+	referrers := make([]domain.ReferrerClicks, 0, len(rows))
+	for _, row := range rows {
+		referrers = append(referrers, domain.ReferrerClicks{Referrer: row.Referrer, Clicks: int(row.Clicks), Links: int(row.Links)})
+	}
+	return referrers, nil
+}
+
+// ListCountryClicks retrieves a link's clicks per country and region, most first
+func (r *Repository) ListCountryClicks(ctx context.Context, shortCode string, limit int) ([]domain.CountryClicks, error) {
+	rows, err := r.queries.ListCountryClicks(ctx, sqlc.ListCountryClicksParams{ShortCode: shortCode, Limit: int64(limit)})
+	if err != nil {
+		return nil, domain.Storage(fmt.Errorf("failed to list country clicks: %w", err))
+	}
+
+	countries := make([]domain.CountryClicks, 0, len(rows))
+	for _, row := range rows {
+		countries = append(countries, domain.CountryClicks{Country: row.Country, Region: row.Region, Clicks: int(row.Clicks)})
+	}
+	return countries, nil
+}
+
+// ListTopCountries retrieves the countries with the most clicks across all links
+func (r *Repository) ListTopCountries(ctx context.Context, limit int) ([]domain.CountryClicks, error) {
+	rows, err := r.queries.ListTopCountries(ctx, int64(limit))
+	if err != nil {
+		return nil, domain.Storage(fmt.Errorf("failed to list top countries: %w", err))
+	}
+
+	countries := make([]domain.CountryClicks, 0, len(rows))
+	for _, row := range rows {
+		countries = append(countries, domain.CountryClicks{Country: row.Country, Clicks: int(row.Clicks), Links: int(row.Links)})
+	}
+	return countries, nil
+}
+
+// ListTopLinks retrieves the links with the most clicks since since, or with
+// the largest gain over their clicks from previousSince to since
+func (r *Repository) ListTopLinks(ctx context.Context, since, previousSince time.Time, sort domain.TopLinksSort, limit int) ([]domain.TopLink, error) {
+	rows, err := r.queries.ListTopLinks(ctx, sqlc.ListTopLinksParams{
+		Since:         since.Unix(),
+		PreviousSince: previousSince.Unix(),
+		Trending:      sort == domain.TopLinksByTrending,
+		Limit:         int64(limit),
+	})
+	if err != nil {
+		return nil, domain.Storage(fmt.Errorf("failed to list top links: %w", err))
+	}
+
+	links := make([]domain.TopLink, 0, len(rows))
+	for _, row := range rows {
+		links = append(links, domain.TopLink{
+			ShortCode:      row.ShortCode,
+			OriginalURL:    row.OriginalUrl,
+			Clicks:         int(row.Clicks),
+			PreviousClicks: int(row.PreviousClicks),
+		})
+	}
+	return links, nil
+}
+
+// ListClickBuckets retrieves a link's clicks per bucket of size from from until
+// to, leaving out buckets without clicks. Clicks in compacted hours count at the
+// start of their hour.
+func (r *Repository) ListClickBuckets(ctx context.Context, shortCode string, from, to time.Time, size time.Duration) ([]domain.TimeSeriesBucket, error) {
+	rows, err := r.queries.ListClickBuckets(ctx, sqlc.ListClickBucketsParams{
+		BucketSeconds: int64(size / time.Second),
+		ShortCode:     shortCode,
+		FromTime:      from.Unix(),
+		ToTime:        to.Unix(),
+	})
+	if err != nil {
+		return nil, domain.Storage(fmt.Errorf("failed to list click buckets: %w", err))
+	}
+
+	buckets := make([]domain.TimeSeriesBucket, 0, len(rows))
+	for _, row := range rows {
+		buckets = append(buckets, domain.TimeSeriesBucket{Start: time.Unix(row.Bucket, 0).UTC(), Clicks: int(row.Clicks)})
+	}
+	return buckets, nil
+}
+
+// CompactClickEvents sums the per-minute clicks before before, an hour
+// boundary, into hourly rows and deletes the minutes, returning how many were
+// compacted
+func (r *Repository) CompactClickEvents(ctx context.Context, before time.Time) (int64, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, domain.Storage(fmt.Errorf("failed to begin transaction: %w", err))
+	}
+	defer tx.Rollback()
+
+	queries := r.queries.WithTx(tx)
+	if err := queries.AddHourClicksFromEvents(ctx, before.Unix()); err != nil {
+		return 0, domain.Storage(fmt.Errorf("failed to add hour clicks: %w", err))
+	}
+	compacted, err := queries.DeleteClickEventsBefore(ctx, before.Unix())
+	if err != nil {
+		return 0, domain.Storage(fmt.Errorf("failed to delete compacted click events: %w", err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, domain.Storage(fmt.Errorf("failed to commit click compaction: %w", err))
+	}
+	return compacted, nil
+}
+
+// Ensure Repository implements the interface
+var _ repository.AnalyticsRepository = (*Repository)(nil)
@@ -0,0 +1,222 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+)
+
+func TestRepository_ClickRollups(t *testing.T) {
+	repo := setupTestRepo(t)
+	defer teardownTestRepo(t, repo)
+
+	ctx := context.Background()
+	now := time.Now().UTC()
+	for _, code := range []string{"abc123", "def456"} {
+		_, err := repo.CreateURL(ctx, code, "https://example.com/"+code, now, domain.CreateOptions{})
+		require.NoError(t, err)
+	}
+
+	newsletter := domain.UTMParams{Source: "newsletter", Medium: "email"}
+	require.NoError(t, repo.AddClickRollups(ctx, domain.ClickRollups{
+		Referrers: []domain.ReferrerClicks{
+			{ShortCode: "abc123", Referrer: "twitter.com", Clicks: 2},
+			{ShortCode: "abc123", Referrer: domain.DirectReferrer, Clicks: 1},
+			{ShortCode: "def456", Referrer: "twitter.com", Clicks: 1},
+			{ShortCode: "gone", Referrer: "twitter.com", Clicks: 9},
+		},
+		UTM: []domain.UTMClicks{
+			{ShortCode: "abc123", UTMParams: newsletter, Clicks: 1},
+			{ShortCode: "gone", UTMParams: newsletter, Clicks: 9},
+		},
+		Countries: []domain.CountryClicks{
+			{ShortCode: "abc123", Country: "US", Region: "CA", Clicks: 2},
+			{ShortCode: "abc123", Country: "US", Region: "NY", Clicks: 1},
+			{ShortCode: "abc123", Country: "DE", Clicks: 1},
+			{ShortCode: "def456", Country: "US", Clicks: 1},
+			{ShortCode: "gone", Country: "FR", Clicks: 9},
+		},
+	}))
+
+	// A second flush adds to the existing rows
+	require.NoError(t, repo.AddClickRollups(ctx, domain.ClickRollups{
+		Referrers: []domain.ReferrerClicks{{ShortCode: "abc123", Referrer: "twitter.com", Clicks: 3}},
+		UTM:       []domain.UTMClicks{{ShortCode: "abc123", UTMParams: newsletter, Clicks: 2}},
+		Countries: []domain.CountryClicks{{ShortCode: "abc123", Country: "US", Region: "CA", Clicks: 1}},
+	}))
+
+	referrers, err := repo.ListReferrerClicks(ctx, "abc123", 10)
+	require.NoError(t, err)
+	assert.Equal(t, []domain.ReferrerClicks{
+		{Referrer: "twitter.com", Clicks: 5},
+		{Referrer: domain.DirectReferrer, Clicks: 1},
+	}, referrers)
+
+	referrers, err = repo.ListReferrerClicks(ctx, "abc123", 1)
+	require.NoError(t, err)
+	assert.Len(t, referrers, 1)
+
+	utm, err := repo.ListUTMClicks(ctx, "abc123", 10)
+	require.NoError(t, err)
+	assert.Equal(t, []domain.UTMClicks{{UTMParams: newsletter, Clicks: 3}}, utm)
+
+	countries, err := repo.ListCountryClicks(ctx, "abc123", 10)
+	require.NoError(t, err)
+	assert.Equal(t, []domain.CountryClicks{
+		{Country: "US", Region: "CA", Clicks: 3},
+		{Country: "DE", Clicks: 1},
+		{Country: "US", Region: "NY", Clicks: 1},
+	}, countries)
+
+	// Clicks on the missing link were skipped
+	topCountries, err := repo.ListTopCountries(ctx, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []domain.CountryClicks{
+		{Country: "US", Clicks: 5, Links: 2},
+		{Country: "DE", Clicks: 1, Links: 1},
+	}, topCountries)
+
+	top, err := repo.ListTopReferrers(ctx, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []domain.ReferrerClicks{
+		{Referrer: "twitter.com", Clicks: 6, Links: 2},
+		{Referrer: domain.DirectReferrer, Clicks: 1, Links: 1},
+	}, top)
+
+	// Deleting a link deletes its rollups
+	require.NoError(t, repo.DeleteURL(ctx, "abc123"))
+	referrers, err = repo.ListReferrerClicks(ctx, "abc123", 10)
+	require.NoError(t, err)
+	assert.Empty(t, referrers)
+	utm, err = repo.ListUTMClicks(ctx, "abc123", 10)
+	require.NoError(t, err)
+	assert.Empty(t, utm)
+	countries, err = repo.ListCountryClicks(ctx, "abc123", 10)
+	require.NoError(t, err)
+	assert.Empty(t, countries)
+
+	top, err = repo.ListTopReferrers(ctx, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []domain.ReferrerClicks{{Referrer: "twitter.com", Clicks: 1, Links: 1}}, top)
+}
+
+func TestRepository_ListTopLinks(t *testing.T) {
+	repo := setupTestRepo(t)
+	defer teardownTestRepo(t, repo)
+
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Minute)
+	for _, code := range []string{"abc123", "def456", "ghi789"} {
+		_, err := repo.CreateURL(ctx, code, "https://example.com/"+code, now, domain.CreateOptions{})
+		require.NoError(t, err)
+	}
+
+	since := now.Add(-time.Hour)
+	require.NoError(t, repo.AddClickRollups(ctx, domain.ClickRollups{
+		Minutes: []domain.MinuteClicks{
+			{ShortCode: "abc123", Minute: now, Clicks: 3},
+			{ShortCode: "abc123", Minute: since, Clicks: 2},
+			{ShortCode: "abc123", Minute: since.Add(-time.Minute), Clicks: 9}, // Previous window
+			{ShortCode: "def456", Minute: now.Add(-time.Minute), Clicks: 4},
+			{ShortCode: "ghi789", Minute: since.Add(-2 * time.Hour), Clicks: 7}, // Before both windows
+			{ShortCode: "gone", Minute: now, Clicks: 9},
+		},
+	}))
+	// A second flush adds to the existing minute
+	require.NoError(t, repo.AddClickRollups(ctx, domain.ClickRollups{
+		Minutes: []domain.MinuteClicks{{ShortCode: "def456", Minute: now.Add(-time.Minute), Clicks: 1}},
+	}))
+
+	top, err := repo.ListTopLinks(ctx, since, since.Add(-time.Hour), domain.TopLinksByClicks, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []domain.TopLink{
+		{ShortCode: "abc123", OriginalURL: "https://example.com/abc123", Clicks: 5, PreviousClicks: 9},
+		{ShortCode: "def456", OriginalURL: "https://example.com/def456", Clicks: 5},
+	}, top)
+
+	top, err = repo.ListTopLinks(ctx, since, since.Add(-time.Hour), domain.TopLinksByTrending, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []domain.TopLink{
+		{ShortCode: "def456", OriginalURL: "https://example.com/def456", Clicks: 5},
+	}, top)
+
+	// Deleting a link deletes its minutes
+	require.NoError(t, repo.DeleteURL(ctx, "def456"))
+	top, err = repo.ListTopLinks(ctx, since, since.Add(-time.Hour), domain.TopLinksByClicks, 10)
+	require.NoError(t, err)
+	require.Len(t, top, 1)
+	assert.Equal(t, "abc123", top[0].ShortCode)
+}
+
+func TestRepository_ClickBucketsAndCompaction(t *testing.T) {
+	repo := setupTestRepo(t)
+	defer teardownTestRepo(t, repo)
+
+	ctx := context.Background()
+	_, err := repo.CreateURL(ctx, "abc123", "https://example.com", time.Now().UTC(), domain.CreateOptions{})
+	require.NoError(t, err)
+
+	day := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	require.NoError(t, repo.AddClickRollups(ctx, domain.ClickRollups{
+		Minutes: []domain.MinuteClicks{
+			{ShortCode: "abc123", Minute: day.Add(10 * time.Minute), Clicks: 1},
+			{ShortCode: "abc123", Minute: day.Add(50 * time.Minute), Clicks: 2},
+			{ShortCode: "abc123", Minute: day.Add(2*time.Hour + 5*time.Minute), Clicks: 4},
+			{ShortCode: "abc123", Minute: day.Add(26 * time.Hour), Clicks: 8},
+		},
+	}))
+
+	assertBuckets := func(t *testing.T) {
+		t.Helper()
+		buckets, err := repo.ListClickBuckets(ctx, "abc123", day, day.Add(48*time.Hour), time.Hour)
+		require.NoError(t, err)
+		assert.Equal(t, []domain.TimeSeriesBucket{
+			{Start: day, Clicks: 3},
+			{Start: day.Add(2 * time.Hour), Clicks: 4},
+			{Start: day.Add(26 * time.Hour), Clicks: 8},
+		}, buckets)
+
+		buckets, err = repo.ListClickBuckets(ctx, "abc123", day, day.Add(48*time.Hour), 24*time.Hour)
+		require.NoError(t, err)
+		assert.Equal(t, []domain.TimeSeriesBucket{{Start: day, Clicks: 7}, {Start: day.Add(24 * time.Hour), Clicks: 8}}, buckets)
+
+		// to is exclusive
+		buckets, err = repo.ListClickBuckets(ctx, "abc123", day, day.Add(2*time.Hour), time.Hour)
+		require.NoError(t, err)
+		assert.Equal(t, []domain.TimeSeriesBucket{{Start: day, Clicks: 3}}, buckets)
+	}
+	assertBuckets(t)
+
+	// Compacting the first day's minutes keeps hourly and daily buckets the same
+	compacted, err := repo.CompactClickEvents(ctx, day.Add(24*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), compacted)
+	assertBuckets(t)
+
+	// Compacted minutes count at the start of their hour
+	buckets, err := repo.ListClickBuckets(ctx, "abc123", day, day.Add(time.Hour), time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, []domain.TimeSeriesBucket{{Start: day, Clicks: 3}}, buckets)
+
+	// Top links count compacted hours, and minutes flushed late add to them
+	require.NoError(t, repo.AddClickRollups(ctx, domain.ClickRollups{
+		Minutes: []domain.MinuteClicks{{ShortCode: "abc123", Minute: day.Add(20 * time.Minute), Clicks: 1}},
+	}))
+	_, err = repo.CompactClickEvents(ctx, day.Add(24*time.Hour))
+	require.NoError(t, err)
+	top, err := repo.ListTopLinks(ctx, day, day.Add(-24*time.Hour), domain.TopLinksByClicks, 10)
+	require.NoError(t, err)
+	require.Len(t, top, 1)
+	assert.Equal(t, 16, top[0].Clicks)
+
+	// Deleting the link deletes its hours
+	require.NoError(t, repo.DeleteURL(ctx, "abc123"))
+	buckets, err = repo.ListClickBuckets(ctx, "abc123", day, day.Add(48*time.Hour), time.Hour)
+	require.NoError(t, err)
+	assert.Empty(t, buckets)
+}
@@ -0,0 +1,78 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/joshdurbin/url-shortener/db/sqlc"
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	"github.com/joshdurbin/url-shortener/internal/repository"
+)
+
+// OldestClickBefore retrieves the start of the oldest minute or hour with
+// clicks before before; ok is false when there is none
+func (r *Repository) OldestClickBefore(ctx context.Context, before time.Time) (time.Time, bool, error) {
+	at, err := r.queries.OldestClickBefore(ctx, before.Unix())
+	if errors.Is(err, sql.ErrNoRows) {
+		return time.Time{}, false, nil
+	}
+	if err != nil {
+		return time.Time{}, false, domain.Storage(fmt.Errorf("failed to find oldest clicks: %w", err))
+	}
+	return time.Unix(at, 0).UTC(), true, nil
+}
+
+// ListClicksBetween retrieves the minutes and hours with clicks from from
+// until to, oldest first
+func (r *Repository) ListClicksBetween(ctx context.Context, from, to time.Time) ([]domain.ArchivedClicks, error) {
+	rows, err := r.queries.ListClicksBetween(ctx, sqlc.ListClicksBetweenParams{FromTime: from.Unix(), ToTime: to.Unix()})
+	if err != nil {
+		return nil, domain.Storage(fmt.Errorf("failed to list clicks: %w", err))
+	}
+
+	clicks := make([]domain.ArchivedClicks, 0, len(rows))
+	for _, row := range rows {
+		interval := domain.IntervalMinute
+		if row.BucketSeconds == int64(time.Hour/time.Second) {
+			interval = domain.IntervalHour
+		}
+		clicks = append(clicks, domain.ArchivedClicks{
+			ShortCode: row.ShortCode,
+			Start:     time.Unix(row.At, 0).UTC(),
+			Interval:  interval,
+			Clicks:    int(row.Clicks),
+		})
+	}
+	return clicks, nil
+}
+
+// DeleteClicksBetween deletes the minutes and hours from from until to in one
+// transaction, returning how many rows were deleted
+func (r *Repository) DeleteClicksBetween(ctx context.Context, from, to time.Time) (int64, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, domain.Storage(fmt.Errorf("failed to begin transaction: %w", err))
+	}
+	defer tx.Rollback()
+
+	queries := r.queries.WithTx(tx)
+	minutes, err := queries.DeleteClickEventsBetween(ctx, sqlc.DeleteClickEventsBetweenParams{FromTime: from.Unix(), ToTime: to.Unix()})
+	if err != nil {
+		return 0, domain.Storage(fmt.Errorf("failed to delete click events: %w", err))
+	}
+	hours, err := queries.DeleteClickHoursBetween(ctx, sqlc.DeleteClickHoursBetweenParams{FromTime: from.Unix(), ToTime: to.Unix()})
+	if err != nil {
+		return 0, domain.Storage(fmt.Errorf("failed to delete click hours: %w", err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, domain.Storage(fmt.Errorf("failed to commit click deletion: %w", err))
+	}
+	return minutes + hours, nil
+}
+
+// Ensure Repository implements the interface
+var _ repository.ArchiveRepository = (*Repository)(nil)
@@ -0,0 +1,65 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+)
+
+func TestRepository_ArchiveClicks(t *testing.T) {
+	repo := setupTestRepo(t)
+	defer teardownTestRepo(t, repo)
+
+	ctx := context.Background()
+	_, err := repo.CreateURL(ctx, "abc123", "https://example.com", time.Now(), domain.CreateOptions{})
+	require.NoError(t, err)
+
+	day := time.Date(2026, 1, 10, 0, 0, 0, 0, time.UTC)
+	_, ok, err := repo.OldestClickBefore(ctx, day.Add(72*time.Hour))
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	require.NoError(t, repo.AddClickRollups(ctx, domain.ClickRollups{
+		Minutes: []domain.MinuteClicks{
+			{ShortCode: "abc123", Minute: day.Add(10 * time.Minute), Clicks: 1},
+			{ShortCode: "abc123", Minute: day.Add(70 * time.Minute), Clicks: 2},
+			{ShortCode: "abc123", Minute: day.Add(25 * time.Hour), Clicks: 4},
+		},
+	}))
+	// The first day's minutes are compacted into hours
+	_, err = repo.CompactClickEvents(ctx, day.Add(time.Hour))
+	require.NoError(t, err)
+
+	oldest, ok, err := repo.OldestClickBefore(ctx, day.Add(72*time.Hour))
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, day, oldest)
+	_, ok, err = repo.OldestClickBefore(ctx, day)
+	require.NoError(t, err)
+	assert.False(t, ok, "before is exclusive")
+
+	clicks, err := repo.ListClicksBetween(ctx, day, day.Add(24*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, []domain.ArchivedClicks{
+		{ShortCode: "abc123", Start: day, Interval: domain.IntervalHour, Clicks: 1},
+		{ShortCode: "abc123", Start: day.Add(70 * time.Minute), Interval: domain.IntervalMinute, Clicks: 2},
+	}, clicks)
+
+	deleted, err := repo.DeleteClicksBetween(ctx, day, day.Add(24*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), deleted)
+
+	// The next day is left alone
+	oldest, ok, err = repo.OldestClickBefore(ctx, day.Add(72*time.Hour))
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, day.Add(25*time.Hour), oldest)
+	clicks, err = repo.ListClicksBetween(ctx, day, day.Add(24*time.Hour))
+	require.NoError(t, err)
+	assert.Empty(t, clicks)
+}
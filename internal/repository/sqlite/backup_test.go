@@ -0,0 +1,38 @@
+package sqlite
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+)
+
+func TestRepository_Backup(t *testing.T) {
+	repo := setupTestRepo(t)
+	defer teardownTestRepo(t, repo)
+
+	ctx := context.Background()
+	_, err := repo.CreateURL(ctx, "backup1", "https://example.com", time.Now().UTC(), domain.CreateOptions{Tags: []string{"kept"}})
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "snapshot.db")
+	require.NoError(t, repo.Backup(ctx, path))
+
+	// The snapshot is a complete, migrated database
+	snapshot, err := New(path, WithDriver(testDriver))
+	require.NoError(t, err)
+	defer snapshot.Close()
+	entry, err := snapshot.GetURL(ctx, "backup1")
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com", entry.OriginalURL)
+	assert.Equal(t, []string{"kept"}, entry.Tags)
+
+	// An existing file is never overwritten
+	err = repo.Backup(ctx, path)
+	assert.ErrorContains(t, err, "failed to back up database")
+}
@@ -0,0 +1,56 @@
+package sqlite
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+)
+
+func TestRepository_BurnURL(t *testing.T) {
+	repo := setupTestRepo(t)
+	defer teardownTestRepo(t, repo)
+
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Second)
+	_, err := repo.CreateURL(ctx, "abc123", "https://example.com/secret", now, domain.CreateOptions{MaxUses: 1, BurnAfterReading: true})
+	require.NoError(t, err)
+	_, err = repo.CreateURL(ctx, "def456", "https://example.com", now, domain.CreateOptions{})
+	require.NoError(t, err)
+
+	entry, err := repo.GetURL(ctx, "abc123")
+	require.NoError(t, err)
+	assert.True(t, entry.BurnAfterReading)
+
+	// Of many concurrent redirects, exactly one claims the link
+	var claimed atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := repo.BurnURL(ctx, "abc123", now); err == nil {
+				claimed.Add(1)
+			} else {
+				assert.ErrorIs(t, err, domain.ErrUsageLimitReached)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), claimed.Load())
+
+	entry, err = repo.GetURL(ctx, "abc123")
+	require.NoError(t, err)
+	assert.Equal(t, 1, entry.UsageCount)
+	require.NotNil(t, entry.LastUsedAt)
+
+	// Links without the flag and unknown codes are never claimed
+	assert.ErrorIs(t, repo.BurnURL(ctx, "def456", now), domain.ErrUsageLimitReached)
+	assert.ErrorIs(t, repo.BurnURL(ctx, "missing", now), domain.ErrURLNotFound)
+}
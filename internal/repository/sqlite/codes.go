@@ -0,0 +1,105 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/joshdurbin/url-shortener/db/sqlc"
+	"github.com/joshdurbin/url-shortener/internal/domain"
+)
+
+// codeTables are the tables besides urls whose rows belong to a link by its
+// short code
+var codeTables = []string{
+	"routing_rules",
+	"referrer_rollups",
+	"utm_rollups",
+	"country_rollups",
+	"click_events",
+	"click_hours",
+	"link_verifications",
+	"policy_actions",
+	"aliases",
+}
+
+// migratingPrefix marks codes halfway through a migration. It cannot start a
+// short code, so no temporary code equals a real one.
+const migratingPrefix = "~"
+
+// MigrateShortCodes renames links and their history in a single transaction,
+// then inserts aliases. Codes move to temporary names first, so a link may
+// take a code another link is giving up.
+func (r *Repository) MigrateShortCodes(ctx context.Context, changes []domain.CodeChange, aliases []*domain.URLEntry) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return domain.Storage(fmt.Errorf("failed to begin code migration transaction: %w", err))
+	}
+	defer tx.Rollback()
+
+	// The history tables reference urls, which are renamed first
+	if _, err := tx.ExecContext(ctx, "PRAGMA defer_foreign_keys = ON"); err != nil {
+		return domain.Storage(fmt.Errorf("failed to defer foreign keys: %w", err))
+	}
+
+	now := sql.NullTime{Time: time.Now(), Valid: true}
+	rename := func(from, to string) error {
+		result, err := tx.ExecContext(ctx, "UPDATE urls SET short_code = ?, updated_at = ? WHERE short_code = ?", to, now, from)
+		if err != nil {
+			return domain.Storage(fmt.Errorf("failed to rename %s to %s: %w", from, to, err))
+		}
+		if rows, _ := result.RowsAffected(); rows == 0 {
+			return fmt.Errorf("short code %s: %w", from, domain.ErrURLNotFound)
+		}
+		for _, table := range codeTables {
+			if _, err := tx.ExecContext(ctx, "UPDATE "+table+" SET short_code = ? WHERE short_code = ?", to, from); err != nil {
+				return domain.Storage(fmt.Errorf("failed to rename %s to %s in %s: %w", from, to, table, err))
+			}
+		}
+		return nil
+	}
+	for _, change := range changes {
+		if err := rename(change.OldCode, migratingPrefix+change.OldCode); err != nil {
+			return err
+		}
+	}
+	for _, change := range changes {
+		if err := rename(migratingPrefix+change.OldCode, change.NewCode); err != nil {
+			return err
+		}
+	}
+
+	queries := r.queries.WithTx(tx)
+	for _, alias := range aliases {
+		count, err := queries.URLExists(ctx, alias.ShortCode)
+		if err != nil {
+			return domain.Storage(fmt.Errorf("failed to check URL existence for %s: %w", alias.ShortCode, err))
+		}
+		if count > 0 {
+			return domain.Conflict(fmt.Errorf("alias %s is the short code of another link", alias.ShortCode))
+		}
+		if err := queries.ImportURL(ctx, sqlc.ImportURLParams{
+			ShortCode:      alias.ShortCode,
+			OriginalUrl:    alias.OriginalURL,
+			CreatedAt:      alias.CreatedAt,
+			UsageCount:     sql.NullInt64{Valid: true},
+			MaxUses:        nullMaxUses(alias.MaxUses),
+			Tags:           joinTags(alias.Tags),
+			RedirectStatus: int64(alias.RedirectStatus),
+			QueryParams:    encodeQueryParams(alias.QueryParams),
+			ForwardQuery:   alias.ForwardQuery,
+			Campaign:       alias.Campaign,
+			Owner:          alias.Owner,
+			Domain:         alias.Domain,
+			UpdatedAt:      now,
+		}); err != nil {
+			return domain.Storage(fmt.Errorf("failed to add alias %s: %w", alias.ShortCode, err))
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return domain.Storage(fmt.Errorf("failed to commit code migration: %w", err))
+	}
+	return nil
+}
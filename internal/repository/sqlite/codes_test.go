@@ -0,0 +1,94 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+)
+
+func TestRepository_MigrateShortCodes(t *testing.T) {
+	repo := setupTestRepo(t)
+	defer teardownTestRepo(t, repo)
+
+	ctx := context.Background()
+	now := time.Now().UTC()
+	for _, code := range []string{"aaa111", "bbb222", "ccc333"} {
+		_, err := repo.CreateURL(ctx, code, "https://example.com/"+code, now, domain.CreateOptions{Campaign: "spring"})
+		require.NoError(t, err)
+	}
+	rules := []domain.RoutingRule{{Countries: []string{"DE"}, Destination: "https://example.de"}}
+	require.NoError(t, repo.SetRoutingRules(ctx, "aaa111", rules, now))
+	require.NoError(t, repo.AddClickRollups(ctx, domain.ClickRollups{
+		Referrers: []domain.ReferrerClicks{{ShortCode: "aaa111", Referrer: "twitter.com", Clicks: 2}},
+	}))
+
+	// aaa111 takes bbb222's code while bbb222 moves on
+	changes := []domain.CodeChange{
+		{OldCode: "aaa111", NewCode: "bbb222"},
+		{OldCode: "bbb222", NewCode: "ddd444"},
+	}
+	aliases := []*domain.URLEntry{
+		{ShortCode: "aaa111", OriginalURL: "https://sho.rt/bbb222", CreatedAt: now, RedirectStatus: http.StatusMovedPermanently, Campaign: "spring"},
+	}
+	require.NoError(t, repo.MigrateShortCodes(ctx, changes, aliases))
+
+	moved, err := repo.GetURL(ctx, "bbb222")
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/aaa111", moved.OriginalURL)
+	routes, err := repo.GetRoutingRules(ctx, "bbb222")
+	require.NoError(t, err)
+	assert.Equal(t, rules, routes)
+	referrers, err := repo.ListReferrerClicks(ctx, "bbb222", 10)
+	require.NoError(t, err)
+	assert.Equal(t, []domain.ReferrerClicks{{Referrer: "twitter.com", Clicks: 2}}, referrers)
+
+	moved, err = repo.GetURL(ctx, "ddd444")
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/bbb222", moved.OriginalURL)
+
+	alias, err := repo.GetURL(ctx, "aaa111")
+	require.NoError(t, err)
+	assert.Equal(t, "https://sho.rt/bbb222", alias.OriginalURL)
+	assert.Equal(t, http.StatusMovedPermanently, alias.RedirectStatus)
+	assert.Equal(t, "spring", alias.Campaign)
+
+	unchanged, err := repo.GetURL(ctx, "ccc333")
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/ccc333", unchanged.OriginalURL)
+}
+
+func TestRepository_MigrateShortCodes_RollsBack(t *testing.T) {
+	repo := setupTestRepo(t)
+	defer teardownTestRepo(t, repo)
+
+	ctx := context.Background()
+	now := time.Now().UTC()
+	for _, code := range []string{"aaa111", "bbb222"} {
+		_, err := repo.CreateURL(ctx, code, "https://example.com/"+code, now, domain.CreateOptions{})
+		require.NoError(t, err)
+	}
+
+	// A missing link fails the whole migration
+	err := repo.MigrateShortCodes(ctx, []domain.CodeChange{
+		{OldCode: "aaa111", NewCode: "eee555"},
+		{OldCode: "missing", NewCode: "fff666"},
+	}, nil)
+	assert.True(t, errors.Is(err, domain.ErrURLNotFound))
+
+	// So does an alias on a code still in use
+	err = repo.MigrateShortCodes(ctx, []domain.CodeChange{{OldCode: "aaa111", NewCode: "eee555"}},
+		[]*domain.URLEntry{{ShortCode: "bbb222", OriginalURL: "https://sho.rt/eee555", CreatedAt: now}})
+	assert.ErrorContains(t, err, "another link")
+
+	_, err = repo.GetURL(ctx, "aaa111")
+	assert.NoError(t, err)
+	_, err = repo.GetURL(ctx, "eee555")
+	assert.ErrorIs(t, err, domain.ErrURLNotFound)
+}
@@ -0,0 +1,69 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/joshdurbin/url-shortener/db/sqlc"
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	"github.com/joshdurbin/url-shortener/internal/repository"
+)
+
+// CreateDestinationRule stores a rule, failing with
+// domain.ErrDestinationRuleExists if the list already has the pattern
+func (r *Repository) CreateDestinationRule(ctx context.Context, rule domain.DestinationRule) error {
+	createdAt := time.Now()
+	if rule.CreatedAt != nil {
+		createdAt = *rule.CreatedAt
+	}
+	err := r.queries.CreateDestinationRule(ctx, sqlc.CreateDestinationRuleParams{
+		List:      string(rule.List),
+		Pattern:   rule.Pattern,
+		CreatedAt: createdAt,
+	})
+	if isUniqueViolation(err) {
+		return domain.ErrDestinationRuleExists
+	}
+	if err != nil {
+		return domain.Storage(fmt.Errorf("failed to create destination rule: %w", err))
+	}
+	return nil
+}
+
+// ListDestinationRules retrieves every stored rule, by list and in the order added
+func (r *Repository) ListDestinationRules(ctx context.Context) ([]domain.DestinationRule, error) {
+	rows, err := r.queries.ListDestinationRules(ctx)
+	if err != nil {
+		return nil, domain.Storage(fmt.Errorf("failed to list destination rules: %w", err))
+	}
+
+	rules := make([]domain.DestinationRule, len(rows))
+	for i, row := range rows {
+		createdAt := row.CreatedAt
+		rules[i] = domain.DestinationRule{
+			List:      domain.DestinationList(row.List),
+			Pattern:   row.Pattern,
+			CreatedAt: &createdAt,
+		}
+	}
+	return rules, nil
+}
+
+// DeleteDestinationRule removes a pattern from a list
+func (r *Repository) DeleteDestinationRule(ctx context.Context, list domain.DestinationList, pattern string) error {
+	deleted, err := r.queries.DeleteDestinationRule(ctx, sqlc.DeleteDestinationRuleParams{
+		List:    string(list),
+		Pattern: pattern,
+	})
+	if err != nil {
+		return domain.Storage(fmt.Errorf("failed to delete destination rule: %w", err))
+	}
+	if deleted == 0 {
+		return domain.ErrDestinationRuleNotFound
+	}
+	return nil
+}
+
+// Ensure Repository implements the interface
+var _ repository.DestinationRepository = (*Repository)(nil)
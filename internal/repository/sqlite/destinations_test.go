@@ -0,0 +1,50 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+)
+
+func TestRepository_DestinationRules(t *testing.T) {
+	repo := setupTestRepo(t)
+	defer teardownTestRepo(t, repo)
+
+	ctx := context.Background()
+	first := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	second := first.Add(time.Minute)
+
+	rules, err := repo.ListDestinationRules(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, rules)
+
+	require.NoError(t, repo.CreateDestinationRule(ctx, domain.DestinationRule{List: domain.DestinationDeny, Pattern: "10.0.0.0/8", CreatedAt: &second}))
+	require.NoError(t, repo.CreateDestinationRule(ctx, domain.DestinationRule{List: domain.DestinationDeny, Pattern: "private", CreatedAt: &first}))
+	require.NoError(t, repo.CreateDestinationRule(ctx, domain.DestinationRule{List: domain.DestinationAllow, Pattern: "example.com", CreatedAt: &second}))
+
+	// A pattern may be on each list once
+	err = repo.CreateDestinationRule(ctx, domain.DestinationRule{List: domain.DestinationDeny, Pattern: "private"})
+	assert.ErrorIs(t, err, domain.ErrDestinationRuleExists)
+	assert.ErrorIs(t, err, domain.ErrConflict)
+
+	rules, err = repo.ListDestinationRules(ctx)
+	require.NoError(t, err)
+	require.Len(t, rules, 3)
+	assert.Equal(t, domain.DestinationRule{List: domain.DestinationAllow, Pattern: "example.com", CreatedAt: rules[0].CreatedAt}, rules[0])
+	assert.Equal(t, "private", rules[1].Pattern)
+	assert.Equal(t, "10.0.0.0/8", rules[2].Pattern)
+	assert.True(t, first.Equal(*rules[1].CreatedAt))
+
+	require.NoError(t, repo.DeleteDestinationRule(ctx, domain.DestinationDeny, "private"))
+	assert.ErrorIs(t, repo.DeleteDestinationRule(ctx, domain.DestinationDeny, "private"), domain.ErrDestinationRuleNotFound)
+	assert.ErrorIs(t, repo.DeleteDestinationRule(ctx, domain.DestinationAllow, "10.0.0.0/8"), domain.ErrNotFound)
+
+	rules, err = repo.ListDestinationRules(ctx)
+	require.NoError(t, err)
+	assert.Len(t, rules, 2)
+}
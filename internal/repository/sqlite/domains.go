@@ -0,0 +1,106 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/joshdurbin/url-shortener/db/sqlc"
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	"github.com/joshdurbin/url-shortener/internal/repository"
+)
+
+// CreateDomain stores a domain, failing with domain.ErrDomainExists if the
+// host is already stored
+func (r *Repository) CreateDomain(ctx context.Context, d domain.CustomDomain) error {
+	err := r.queries.CreateDomain(ctx, sqlc.CreateDomainParams{
+		Host:           d.Host,
+		RedirectStatus: int64(d.RedirectStatus),
+		BrandName:      d.Branding.Name,
+		BrandLogoUrl:   d.Branding.LogoURL,
+		BrandColor:     d.Branding.Color,
+		CreatedAt:      d.CreatedAt,
+	})
+	if isUniqueViolation(err) {
+		return domain.ErrDomainExists
+	}
+	if err != nil {
+		return domain.Storage(fmt.Errorf("failed to create domain: %w", err))
+	}
+	return nil
+}
+
+// UpdateDomain replaces a domain's redirect status and branding
+func (r *Repository) UpdateDomain(ctx context.Context, d domain.CustomDomain) error {
+	updated, err := r.queries.UpdateDomain(ctx, sqlc.UpdateDomainParams{
+		RedirectStatus: int64(d.RedirectStatus),
+		BrandName:      d.Branding.Name,
+		BrandLogoUrl:   d.Branding.LogoURL,
+		BrandColor:     d.Branding.Color,
+		Host:           d.Host,
+	})
+	if err != nil {
+		return domain.Storage(fmt.Errorf("failed to update domain: %w", err))
+	}
+	if updated == 0 {
+		return domain.ErrDomainNotFound
+	}
+	return nil
+}
+
+// ListDomains retrieves every stored domain ordered by host
+func (r *Repository) ListDomains(ctx context.Context) ([]domain.CustomDomain, error) {
+	rows, err := r.queries.ListDomains(ctx)
+	if err != nil {
+		return nil, domain.Storage(fmt.Errorf("failed to list domains: %w", err))
+	}
+
+	domains := make([]domain.CustomDomain, len(rows))
+	for i, row := range rows {
+		domains[i] = domain.CustomDomain{
+			Host:           row.Host,
+			RedirectStatus: int(row.RedirectStatus),
+			Branding: domain.Branding{
+				Name:    row.BrandName,
+				LogoURL: row.BrandLogoUrl,
+				Color:   row.BrandColor,
+			},
+			CreatedAt: row.CreatedAt,
+		}
+	}
+	return domains, nil
+}
+
+// DeleteDomain removes a domain, failing with domain.ErrDomainInUse while
+// links are served on it
+func (r *Repository) DeleteDomain(ctx context.Context, host string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return domain.Storage(fmt.Errorf("failed to begin transaction: %w", err))
+	}
+	defer tx.Rollback()
+
+	queries := r.queries.WithTx(tx)
+	links, err := queries.CountURLsByDomain(ctx, host)
+	if err != nil {
+		return domain.Storage(fmt.Errorf("failed to count the links of domain: %w", err))
+	}
+	if links > 0 {
+		return domain.ErrDomainInUse
+	}
+
+	deleted, err := queries.DeleteDomain(ctx, host)
+	if err != nil {
+		return domain.Storage(fmt.Errorf("failed to delete domain: %w", err))
+	}
+	if deleted == 0 {
+		return domain.ErrDomainNotFound
+	}
+
+	if err := tx.Commit(); err != nil {
+		return domain.Storage(fmt.Errorf("failed to commit domain deletion: %w", err))
+	}
+	return nil
+}
+
+// Ensure Repository implements the interface
+var _ repository.DomainRepository = (*Repository)(nil)
@@ -0,0 +1,72 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+)
+
+func TestRepository_Domains(t *testing.T) {
+	repo := setupTestRepo(t)
+	defer teardownTestRepo(t, repo)
+
+	ctx := context.Background()
+	createdAt := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	domains, err := repo.ListDomains(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, domains)
+
+	brand := domain.CustomDomain{
+		Host:           "go.example.com",
+		RedirectStatus: 301,
+		Branding:       domain.Branding{Name: "Example", LogoURL: "https://example.com/logo.png", Color: "#336699"},
+		CreatedAt:      createdAt,
+	}
+	require.NoError(t, repo.CreateDomain(ctx, brand))
+	require.NoError(t, repo.CreateDomain(ctx, domain.CustomDomain{Host: "a.example.org", CreatedAt: createdAt}))
+
+	err = repo.CreateDomain(ctx, domain.CustomDomain{Host: "go.example.com", CreatedAt: createdAt})
+	assert.ErrorIs(t, err, domain.ErrDomainExists)
+	assert.ErrorIs(t, err, domain.ErrConflict)
+
+	domains, err = repo.ListDomains(ctx)
+	require.NoError(t, err)
+	require.Len(t, domains, 2)
+	assert.Equal(t, "a.example.org", domains[0].Host)
+	assert.True(t, createdAt.Equal(domains[1].CreatedAt))
+	domains[1].CreatedAt = createdAt
+	assert.Equal(t, brand, domains[1])
+
+	brand.RedirectStatus = 0
+	brand.Branding = domain.Branding{Name: "Renamed"}
+	require.NoError(t, repo.UpdateDomain(ctx, brand))
+	assert.ErrorIs(t, repo.UpdateDomain(ctx, domain.CustomDomain{Host: "nope.example.com"}), domain.ErrDomainNotFound)
+
+	domains, err = repo.ListDomains(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, domains[1].RedirectStatus)
+	assert.Equal(t, domain.Branding{Name: "Renamed"}, domains[1].Branding)
+
+	// Links keep their domain, which cannot be removed while they exist
+	entry, err := repo.CreateURL(ctx, "abc123", "https://example.com", createdAt, domain.CreateOptions{Domain: "go.example.com"})
+	require.NoError(t, err)
+	assert.Equal(t, "go.example.com", entry.Domain)
+	stored, err := repo.GetURL(ctx, "abc123")
+	require.NoError(t, err)
+	assert.Equal(t, "go.example.com", stored.Domain)
+
+	assert.ErrorIs(t, repo.DeleteDomain(ctx, "go.example.com"), domain.ErrDomainInUse)
+	require.NoError(t, repo.DeleteURL(ctx, "abc123"))
+	require.NoError(t, repo.DeleteDomain(ctx, "go.example.com"))
+	assert.ErrorIs(t, repo.DeleteDomain(ctx, "go.example.com"), domain.ErrDomainNotFound)
+
+	domains, err = repo.ListDomains(ctx)
+	require.NoError(t, err)
+	assert.Len(t, domains, 1)
+}
@@ -0,0 +1,68 @@
+package sqlite
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Driver selects the database/sql driver behind the repository
+type Driver string
+
+// Driver constants
+const (
+	DriverMattn   Driver = "mattn"   // github.com/mattn/go-sqlite3; requires cgo
+	DriverModernc Driver = "modernc" // modernc.org/sqlite; pure Go, for CGO_ENABLED=0 builds
+)
+
+// sqlDriver describes how to open a database with a registered driver. The
+// data source name also makes transactions begin IMMEDIATE, so a writer
+// waits out the busy timeout instead of failing when it cannot upgrade a read
+// lock.
+type sqlDriver struct {
+	name string                                     // database/sql driver name
+	dsn  func(path string, pragmas []pragma) string // Data source name giving every connection the same settings
+}
+
+// sqlDrivers holds the drivers compiled into this binary. Each driver
+// registers itself from a file whose build constraints decide whether it is
+// available.
+var sqlDrivers = map[Driver]sqlDriver{}
+
+// Drivers returns the drivers compiled into this binary, sorted by name
+func Drivers() []Driver {
+	drivers := make([]Driver, 0, len(sqlDrivers))
+	for driver := range sqlDrivers {
+		drivers = append(drivers, driver)
+	}
+	sort.Slice(drivers, func(i, j int) bool { return drivers[i] < drivers[j] })
+	return drivers
+}
+
+// DefaultDriver returns mattn/go-sqlite3 when it is compiled in, otherwise modernc.org/sqlite
+func DefaultDriver() Driver {
+	if _, ok := sqlDrivers[DriverMattn]; ok {
+		return DriverMattn
+	}
+	return DriverModernc
+}
+
+// ParseDriver validates a driver name against the drivers compiled into this
+// binary; an empty name selects DefaultDriver
+func ParseDriver(name string) (Driver, error) {
+	if name == "" {
+		return DefaultDriver(), nil
+	}
+	driver := Driver(strings.ToLower(name))
+	if _, ok := sqlDrivers[driver]; !ok {
+		return "", fmt.Errorf("unsupported database driver %q: this build supports %v", name, Drivers())
+	}
+	return driver, nil
+}
+
+// isUniqueViolation reports whether err is a UNIQUE or PRIMARY KEY constraint
+// failure. Both drivers report SQLite's own message, so it is matched rather
+// than each driver's error type.
+func isUniqueViolation(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "UNIQUE constraint failed")
+}
@@ -0,0 +1,24 @@
+//go:build cgo && !purego
+
+package sqlite
+
+import (
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func init() {
+	sqlDrivers[DriverMattn] = sqlDriver{
+		name: "sqlite3",
+		dsn:  mattnDSN,
+	}
+}
+
+// mattnDSN passes each pragma as the matching underscore-prefixed parameter
+// of mattn/go-sqlite3
+func mattnDSN(path string, pragmas []pragma) string {
+	params := make([]string, 0, len(pragmas)+1)
+	for _, p := range pragmas {
+		params = append(params, "_"+p.name+"="+p.value)
+	}
+	return withQuery(path, append(params, "_txlock=immediate"))
+}
@@ -0,0 +1,22 @@
+package sqlite
+
+import (
+	_ "modernc.org/sqlite"
+)
+
+func init() {
+	sqlDrivers[DriverModernc] = sqlDriver{
+		name: "sqlite",
+		dsn:  moderncDSN,
+	}
+}
+
+// moderncDSN passes each pragma as a _pragma parameter, which
+// modernc.org/sqlite runs on every new connection
+func moderncDSN(path string, pragmas []pragma) string {
+	params := make([]string, 0, len(pragmas)+1)
+	for _, p := range pragmas {
+		params = append(params, "_pragma="+p.name+"("+p.value+")")
+	}
+	return withQuery(path, append(params, "_txlock=immediate"))
+}
@@ -0,0 +1,45 @@
+package sqlite
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testDriver is the driver the repository tests open databases with
+var testDriver = DefaultDriver()
+
+// TestMain runs the whole repository suite once per driver compiled into the
+// test binary, so both drivers must behave identically
+func TestMain(m *testing.M) {
+	code := 0
+	for _, driver := range Drivers() {
+		testDriver = driver
+		fmt.Printf("=== repository tests with the %s driver\n", driver)
+		if result := m.Run(); result != 0 {
+			code = result
+		}
+	}
+	os.Exit(code)
+}
+
+func TestParseDriver(t *testing.T) {
+	driver, err := ParseDriver("")
+	require.NoError(t, err)
+	assert.Equal(t, DefaultDriver(), driver)
+
+	driver, err = ParseDriver("MODERNC")
+	require.NoError(t, err)
+	assert.Equal(t, DriverModernc, driver)
+
+	_, err = ParseDriver("postgres")
+	assert.ErrorContains(t, err, "unsupported database driver")
+}
+
+func TestNew_UnavailableDriver(t *testing.T) {
+	_, err := New(createTempDB(t), WithDriver("postgres"))
+	assert.ErrorContains(t, err, "not compiled into this binary")
+}
@@ -0,0 +1,75 @@
+package sqlite
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/joshdurbin/url-shortener/db/sqlc"
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	"github.com/joshdurbin/url-shortener/internal/repository"
+)
+
+// AddExportEvents appends events to the outbox in one transaction
+func (r *Repository) AddExportEvents(ctx context.Context, events []domain.Event) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return domain.Storage(fmt.Errorf("failed to begin transaction: %w", err))
+	}
+	defer tx.Rollback()
+
+	queries := r.queries.WithTx(tx)
+	now := time.Now()
+	for _, event := range events {
+		data, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("failed to encode event %s: %w", event.ID, err)
+		}
+		if err := queries.AddExportEvent(ctx, sqlc.AddExportEventParams{Event: string(data), CreatedAt: now}); err != nil {
+			return domain.Storage(fmt.Errorf("failed to add export event: %w", err))
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return domain.Storage(fmt.Errorf("failed to commit export events: %w", err))
+	}
+	return nil
+}
+
+// ListExportEvents retrieves the oldest events in the outbox, in order
+func (r *Repository) ListExportEvents(ctx context.Context, limit int) ([]domain.ExportRecord, error) {
+	rows, err := r.queries.ListExportEvents(ctx, int64(limit))
+	if err != nil {
+		return nil, domain.Storage(fmt.Errorf("failed to list export events: %w", err))
+	}
+
+	records := make([]domain.ExportRecord, len(rows))
+	for i, row := range rows {
+		records[i].ID = row.ID
+		if err := json.Unmarshal([]byte(row.Event), &records[i].Event); err != nil {
+			return nil, fmt.Errorf("failed to decode export event %d: %w", row.ID, err)
+		}
+	}
+	return records, nil
+}
+
+// DeleteExportEvents removes the events up to and including id from the outbox
+func (r *Repository) DeleteExportEvents(ctx context.Context, throughID int64) error {
+	if _, err := r.queries.DeleteExportEventsThrough(ctx, throughID); err != nil {
+		return domain.Storage(fmt.Errorf("failed to delete export events: %w", err))
+	}
+	return nil
+}
+
+// CountExportEvents returns the number of events in the outbox
+func (r *Repository) CountExportEvents(ctx context.Context) (int64, error) {
+	count, err := r.queries.CountExportEvents(ctx)
+	if err != nil {
+		return 0, domain.Storage(fmt.Errorf("failed to count export events: %w", err))
+	}
+	return count, nil
+}
+
+// Ensure Repository implements the interface
+var _ repository.ExportRepository = (*Repository)(nil)
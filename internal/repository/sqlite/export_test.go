@@ -0,0 +1,48 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+)
+
+func TestRepository_ExportOutbox(t *testing.T) {
+	repo := setupTestRepo(t)
+	defer teardownTestRepo(t, repo)
+
+	ctx := context.Background()
+
+	count, err := repo.CountExportEvents(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, count)
+
+	first := domain.NewEvent(domain.EventURLCreated, domain.EventData{ShortCode: "abc123", Campaign: "spring"})
+	second := domain.NewEvent(domain.EventURLClicked, domain.EventData{ShortCode: "abc123"})
+	third := domain.NewEvent(domain.EventURLClicked, domain.EventData{ShortCode: "xyz789"})
+	require.NoError(t, repo.AddExportEvents(ctx, []domain.Event{first, second}))
+	require.NoError(t, repo.AddExportEvents(ctx, []domain.Event{third}))
+
+	count, err = repo.CountExportEvents(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), count)
+
+	// Records come back oldest first
+	records, err := repo.ListExportEvents(ctx, 2)
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, first.ID, records[0].Event.ID)
+	assert.Equal(t, "spring", records[0].Event.Data.Campaign)
+	assert.Equal(t, second.ID, records[1].Event.ID)
+	assert.Less(t, records[0].ID, records[1].ID)
+
+	require.NoError(t, repo.DeleteExportEvents(ctx, records[1].ID))
+	records, err = repo.ListExportEvents(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, third.ID, records[0].Event.ID)
+	assert.Equal(t, domain.EventURLClicked, records[0].Event.Type)
+}
@@ -0,0 +1 @@
+ALTER TABLE urls ADD COLUMN tags TEXT NOT NULL DEFAULT '';
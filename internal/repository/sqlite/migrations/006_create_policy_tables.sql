@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS lifecycle_policies (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL UNIQUE,
+    tag TEXT NOT NULL DEFAULT '',
+    older_than INTEGER NOT NULL DEFAULT 0,
+    unused_for INTEGER NOT NULL DEFAULT 0,
+    action TEXT NOT NULL,
+    created_at DATETIME NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS policy_actions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    policy_name TEXT NOT NULL,
+    action TEXT NOT NULL,
+    short_code TEXT NOT NULL,
+    original_url TEXT NOT NULL,
+    snapshot TEXT,
+    error TEXT,
+    created_at DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_policy_actions_created_at ON policy_actions(created_at);
@@ -0,0 +1,3 @@
+ALTER TABLE urls ADD COLUMN owner TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_urls_owner ON urls(owner);
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS referrer_rollups (
+    short_code TEXT NOT NULL REFERENCES urls(short_code) ON DELETE CASCADE,
+    referrer TEXT NOT NULL,
+    clicks INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (short_code, referrer)
+);
+
+CREATE INDEX IF NOT EXISTS idx_referrer_rollups_referrer ON referrer_rollups(referrer);
+
+CREATE TABLE IF NOT EXISTS utm_rollups (
+    short_code TEXT NOT NULL REFERENCES urls(short_code) ON DELETE CASCADE,
+    utm_source TEXT NOT NULL DEFAULT '',
+    utm_medium TEXT NOT NULL DEFAULT '',
+    utm_campaign TEXT NOT NULL DEFAULT '',
+    clicks INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (short_code, utm_source, utm_medium, utm_campaign)
+);
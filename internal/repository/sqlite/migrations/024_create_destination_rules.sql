@@ -0,0 +1,8 @@
+-- Destination host patterns added through the API to the allow and deny
+-- lists; patterns from the server configuration are not stored
+CREATE TABLE IF NOT EXISTS destination_rules (
+    list TEXT NOT NULL,
+    pattern TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (list, pattern)
+);
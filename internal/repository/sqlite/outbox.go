@@ -0,0 +1,69 @@
+package sqlite
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/joshdurbin/url-shortener/db/sqlc"
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	"github.com/joshdurbin/url-shortener/internal/repository"
+)
+
+// CreateURLWithEvent creates a short URL and appends event to the outbox in
+// one transaction
+func (r *Repository) CreateURLWithEvent(ctx context.Context, shortCode, originalURL string, createdAt time.Time, opts domain.CreateOptions, event domain.Event) (*domain.URLEntry, error) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode event %s: %w", event.ID, err)
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, domain.Storage(fmt.Errorf("failed to begin transaction: %w", err))
+	}
+	defer tx.Rollback()
+
+	queries := r.queries.WithTx(tx)
+	entry, err := r.createURL(ctx, queries, shortCode, originalURL, createdAt, opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := queries.AddOutboxEvent(ctx, sqlc.AddOutboxEventParams{Event: string(data), CreatedAt: time.Now()}); err != nil {
+		return nil, domain.Storage(fmt.Errorf("failed to add outbox event: %w", err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, domain.Storage(fmt.Errorf("failed to commit URL and outbox event: %w", err))
+	}
+	return entry, nil
+}
+
+// ListOutboxEvents retrieves the oldest events in the outbox, in order
+func (r *Repository) ListOutboxEvents(ctx context.Context, limit int) ([]domain.ExportRecord, error) {
+	rows, err := r.queries.ListOutboxEvents(ctx, int64(limit))
+	if err != nil {
+		return nil, domain.Storage(fmt.Errorf("failed to list outbox events: %w", err))
+	}
+
+	records := make([]domain.ExportRecord, len(rows))
+	for i, row := range rows {
+		records[i].ID = row.ID
+		if err := json.Unmarshal([]byte(row.Event), &records[i].Event); err != nil {
+			return nil, fmt.Errorf("failed to decode outbox event %d: %w", row.ID, err)
+		}
+	}
+	return records, nil
+}
+
+// DeleteOutboxEvents removes the events up to and including id from the outbox
+func (r *Repository) DeleteOutboxEvents(ctx context.Context, throughID int64) error {
+	if _, err := r.queries.DeleteOutboxEventsThrough(ctx, throughID); err != nil {
+		return domain.Storage(fmt.Errorf("failed to delete outbox events: %w", err))
+	}
+	return nil
+}
+
+// Ensure Repository implements the interface
+var _ repository.OutboxRepository = (*Repository)(nil)
@@ -0,0 +1,51 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+)
+
+func TestRepository_EventOutbox(t *testing.T) {
+	repo := setupTestRepo(t)
+	defer teardownTestRepo(t, repo)
+
+	ctx := context.Background()
+	now := time.Now()
+
+	first := domain.NewEvent(domain.EventURLCreated, domain.EventData{ShortCode: "abc123", OriginalURL: "https://example.com"})
+	entry, err := repo.CreateURLWithEvent(ctx, "abc123", "https://example.com", now, domain.CreateOptions{Campaign: "spring"}, first)
+	require.NoError(t, err)
+	assert.Equal(t, "abc123", entry.ShortCode)
+	assert.Equal(t, "spring", entry.Campaign)
+
+	// A link that is not created leaves no event behind
+	_, err = repo.CreateURLWithEvent(ctx, "abc123", "https://example.org", now, domain.CreateOptions{},
+		domain.NewEvent(domain.EventURLCreated, domain.EventData{ShortCode: "abc123"}))
+	assert.ErrorIs(t, err, domain.ErrShortCodeTaken)
+
+	second := domain.NewEvent(domain.EventURLCreated, domain.EventData{ShortCode: "xyz789"})
+	_, err = repo.CreateURLWithEvent(ctx, "xyz789", "https://example.net", now, domain.CreateOptions{}, second)
+	require.NoError(t, err)
+
+	records, err := repo.ListOutboxEvents(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, first.ID, records[0].Event.ID)
+	assert.Equal(t, "https://example.com", records[0].Event.Data.OriginalURL)
+	assert.Equal(t, second.ID, records[1].Event.ID)
+
+	require.NoError(t, repo.DeleteOutboxEvents(ctx, records[0].ID))
+	records, err = repo.ListOutboxEvents(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, second.ID, records[0].Event.ID)
+
+	_, err = repo.GetURL(ctx, "xyz789")
+	assert.NoError(t, err)
+}
@@ -0,0 +1,129 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/joshdurbin/url-shortener/db/sqlc"
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	"github.com/joshdurbin/url-shortener/internal/repository"
+)
+
+// CreateLifecyclePolicy stores a new lifecycle policy
+func (r *Repository) CreateLifecyclePolicy(ctx context.Context, policy *domain.LifecyclePolicy) (*domain.LifecyclePolicy, error) {
+	row, err := r.queries.CreateLifecyclePolicy(ctx, sqlc.CreateLifecyclePolicyParams{
+		Name:      policy.Name,
+		Tag:       policy.Tag,
+		OlderThan: int64(time.Duration(policy.OlderThan) / time.Second),
+		UnusedFor: int64(time.Duration(policy.UnusedFor) / time.Second),
+		Action:    string(policy.Action),
+		CreatedAt: policy.CreatedAt,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create lifecycle policy: %w", err)
+	}
+
+	return sqlcLifecyclePolicyToDomain(row), nil
+}
+
+// ListLifecyclePolicies retrieves all stored lifecycle policies in creation order
+func (r *Repository) ListLifecyclePolicies(ctx context.Context) ([]*domain.LifecyclePolicy, error) {
+	rows, err := r.queries.ListLifecyclePolicies(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list lifecycle policies: %w", err)
+	}
+
+	policies := make([]*domain.LifecyclePolicy, len(rows))
+	for i, row := range rows {
+		policies[i] = sqlcLifecyclePolicyToDomain(row)
+	}
+
+	return policies, nil
+}
+
+// DeleteLifecyclePolicy removes a lifecycle policy; its audit log entries are kept
+func (r *Repository) DeleteLifecyclePolicy(ctx context.Context, id int64) error {
+	deleted, err := r.queries.DeleteLifecyclePolicy(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete lifecycle policy: %w", err)
+	}
+	if deleted == 0 {
+		return domain.ErrPolicyNotFound
+	}
+	return nil
+}
+
+// RecordPolicyAction appends an action taken by a policy to the audit log
+func (r *Repository) RecordPolicyAction(ctx context.Context, entry *domain.PolicyAuditEntry) error {
+	var snapshot sql.NullString
+	if entry.Snapshot != nil {
+		data, err := json.Marshal(entry.Snapshot)
+		if err != nil {
+			return fmt.Errorf("failed to encode link snapshot: %w", err)
+		}
+		snapshot = sql.NullString{String: string(data), Valid: true}
+	}
+
+	err := r.queries.CreatePolicyAction(ctx, sqlc.CreatePolicyActionParams{
+		PolicyName:  entry.Policy,
+		Action:      string(entry.Action),
+		ShortCode:   entry.ShortCode,
+		OriginalUrl: entry.OriginalURL,
+		Snapshot:    snapshot,
+		Error:       sql.NullString{String: entry.Error, Valid: entry.Error != ""},
+		CreatedAt:   entry.CreatedAt,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record policy action: %w", err)
+	}
+	return nil
+}
+
+// ListPolicyActions retrieves the most recent policy actions
+func (r *Repository) ListPolicyActions(ctx context.Context, limit int) ([]*domain.PolicyAuditEntry, error) {
+	rows, err := r.queries.ListPolicyActions(ctx, int64(limit))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list policy actions: %w", err)
+	}
+
+	entries := make([]*domain.PolicyAuditEntry, len(rows))
+	for i, row := range rows {
+		entries[i] = &domain.PolicyAuditEntry{
+			ID:          row.ID,
+			Policy:      row.PolicyName,
+			Action:      domain.PolicyAction(row.Action),
+			ShortCode:   row.ShortCode,
+			OriginalURL: row.OriginalUrl,
+			Error:       row.Error.String,
+			CreatedAt:   row.CreatedAt,
+		}
+		if row.Snapshot.Valid {
+			var snapshot domain.URLEntry
+			if err := json.Unmarshal([]byte(row.Snapshot.String), &snapshot); err != nil {
+				return nil, fmt.Errorf("failed to decode snapshot of %s: %w", row.ShortCode, err)
+			}
+			entries[i].Snapshot = &snapshot
+		}
+	}
+
+	return entries, nil
+}
+
+// sqlcLifecyclePolicyToDomain converts a sqlc.LifecyclePolicy to domain.LifecyclePolicy
+func sqlcLifecyclePolicyToDomain(row sqlc.LifecyclePolicy) *domain.LifecyclePolicy {
+	return &domain.LifecyclePolicy{
+		ID:        row.ID,
+		Name:      row.Name,
+		Tag:       row.Tag,
+		OlderThan: domain.Age(time.Duration(row.OlderThan) * time.Second),
+		UnusedFor: domain.Age(time.Duration(row.UnusedFor) * time.Second),
+		Action:    domain.PolicyAction(row.Action),
+		CreatedAt: row.CreatedAt,
+	}
+}
+
+// Ensure Repository implements the interface
+var _ repository.PolicyRepository = (*Repository)(nil)
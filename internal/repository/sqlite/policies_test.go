@@ -0,0 +1,111 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+)
+
+func TestRepository_LifecyclePolicies(t *testing.T) {
+	repo := setupTestRepo(t)
+	defer teardownTestRepo(t, repo)
+
+	ctx := context.Background()
+
+	created, err := repo.CreateLifecyclePolicy(ctx, &domain.LifecyclePolicy{
+		Name:      "expire-temp",
+		Tag:       "temp",
+		OlderThan: domain.Age(30 * 24 * time.Hour),
+		Action:    domain.PolicyActionDelete,
+		CreatedAt: time.Now().UTC(),
+	})
+	require.NoError(t, err)
+	assert.NotZero(t, created.ID)
+
+	_, err = repo.CreateLifecyclePolicy(ctx, &domain.LifecyclePolicy{
+		Name:      "archive-unused",
+		UnusedFor: domain.Age(180 * 24 * time.Hour),
+		Action:    domain.PolicyActionArchive,
+		CreatedAt: time.Now().UTC(),
+	})
+	require.NoError(t, err)
+
+	_, err = repo.CreateLifecyclePolicy(ctx, &domain.LifecyclePolicy{Name: "expire-temp", OlderThan: 1, Action: domain.PolicyActionDelete})
+	assert.Error(t, err, "policy names are unique")
+
+	policies, err := repo.ListLifecyclePolicies(ctx)
+	require.NoError(t, err)
+	require.Len(t, policies, 2)
+	assert.Equal(t, "temp", policies[0].Tag)
+	assert.Equal(t, domain.Age(30*24*time.Hour), policies[0].OlderThan)
+	assert.Equal(t, domain.Age(180*24*time.Hour), policies[1].UnusedFor)
+	assert.Equal(t, domain.PolicyActionArchive, policies[1].Action)
+
+	require.NoError(t, repo.DeleteLifecyclePolicy(ctx, created.ID))
+	assert.ErrorIs(t, repo.DeleteLifecyclePolicy(ctx, created.ID), domain.ErrPolicyNotFound)
+
+	policies, err = repo.ListLifecyclePolicies(ctx)
+	require.NoError(t, err)
+	assert.Len(t, policies, 1)
+}
+
+func TestRepository_PolicyActions(t *testing.T) {
+	repo := setupTestRepo(t)
+	defer teardownTestRepo(t, repo)
+
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	snapshot := &domain.URLEntry{ShortCode: "abc123", OriginalURL: "https://example.com", CreatedAt: now, UsageCount: 7, Tags: []string{"temp"}}
+	require.NoError(t, repo.RecordPolicyAction(ctx, &domain.PolicyAuditEntry{
+		Policy: "archive-unused", Action: domain.PolicyActionArchive, ShortCode: "abc123", OriginalURL: "https://example.com",
+		Snapshot: snapshot, CreatedAt: now,
+	}))
+	require.NoError(t, repo.RecordPolicyAction(ctx, &domain.PolicyAuditEntry{
+		Policy: "expire-temp", Action: domain.PolicyActionDelete, ShortCode: "def456", OriginalURL: "https://example.org",
+		Error: "short code not found", CreatedAt: now,
+	}))
+
+	actions, err := repo.ListPolicyActions(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, actions, 2)
+
+	// Newest first
+	assert.Equal(t, "def456", actions[0].ShortCode)
+	assert.Equal(t, "short code not found", actions[0].Error)
+	assert.Nil(t, actions[0].Snapshot)
+
+	require.NotNil(t, actions[1].Snapshot)
+	assert.Equal(t, 7, actions[1].Snapshot.UsageCount)
+	assert.Equal(t, []string{"temp"}, actions[1].Snapshot.Tags)
+
+	actions, err = repo.ListPolicyActions(ctx, 1)
+	require.NoError(t, err)
+	assert.Len(t, actions, 1)
+}
+
+func TestRepository_URLTags(t *testing.T) {
+	repo := setupTestRepo(t)
+	defer teardownTestRepo(t, repo)
+
+	ctx := context.Background()
+
+	created, err := repo.CreateURL(ctx, "tagged1", "https://example.com", time.Now().UTC(), domain.CreateOptions{Tags: []string{"temp", "promo"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"temp", "promo"}, created.Tags)
+
+	untagged, err := repo.CreateURL(ctx, "plain1", "https://example.com", time.Now().UTC(), domain.CreateOptions{})
+	require.NoError(t, err)
+	assert.Nil(t, untagged.Tags)
+
+	entry, err := repo.GetURL(ctx, "tagged1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"temp", "promo"}, entry.Tags)
+	assert.True(t, entry.HasTag("temp"))
+	assert.False(t, entry.HasTag("other"))
+}
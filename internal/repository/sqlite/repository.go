@@ -3,10 +3,12 @@ package sqlite
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	neturl "net/url"
+	"strings"
 	"time"
 
-	_ "github.com/mattn/go-sqlite3"
 	"github.com/joshdurbin/url-shortener/db/sqlc"
 	"github.com/joshdurbin/url-shortener/internal/domain"
 	"github.com/joshdurbin/url-shortener/internal/repository"
@@ -15,30 +17,68 @@ import (
 // Repository implements repository.URLRepository using SQLite
 type Repository struct {
 	db      *sql.DB
+	stmts   *stmtCache
 	queries *sqlc.Queries
+	path    string
+	driver  Driver
+	tuning  Tuning
+}
+
+// Option configures optional repository behavior
+type Option func(*Repository)
+
+// WithDriver opens the database with the given driver instead of DefaultDriver
+func WithDriver(driver Driver) Option {
+	return func(r *Repository) {
+		r.driver = driver
+	}
+}
+
+// WithTuning sets the connection pragmas and pool limits instead of DefaultTuning
+func WithTuning(tuning Tuning) Option {
+	return func(r *Repository) {
+		r.tuning = tuning
+	}
 }
 
 // New creates a new SQLite repository
-func New(databasePath string) (*Repository, error) {
-	db, err := sql.Open("sqlite3", databasePath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open database: %w", err)
+func New(databasePath string, opts ...Option) (*Repository, error) {
+	repo := &Repository{
+		path:   databasePath,
+		driver: DefaultDriver(),
+		tuning: DefaultTuning(),
+	}
+	for _, opt := range opts {
+		opt(repo)
 	}
 
-	// Enable foreign keys and WAL mode for better performance
-	if _, err := db.Exec("PRAGMA foreign_keys = ON"); err != nil {
-		return nil, fmt.Errorf("failed to enable foreign keys: %w", err)
+	if err := repo.tuning.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid database tuning: %w", err)
 	}
-	
-	if _, err := db.Exec("PRAGMA journal_mode = WAL"); err != nil {
-		return nil, fmt.Errorf("failed to enable WAL mode: %w", err)
+
+	driver, ok := sqlDrivers[repo.driver]
+	if !ok {
+		return nil, fmt.Errorf("database driver %q is not compiled into this binary (available: %v)", repo.driver, Drivers())
 	}
 
-	repo := &Repository{
-		db:      db,
-		queries: sqlc.New(db),
+	// Foreign keys, WAL mode and the tuning pragmas are set on every
+	// connection through the data source name
+	db, err := sql.Open(driver.name, driver.dsn(databasePath, repo.tuning.pragmas()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	db.SetMaxOpenConns(repo.tuning.MaxOpenConns)
+	db.SetMaxIdleConns(repo.tuning.MaxIdleConns)
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
 
+	repo.db = db
+	repo.stmts = newStmtCache(db)
+	repo.queries = sqlc.New(repo.stmts)
+
 	if err := repo.runMigrations(context.Background()); err != nil {
 		return nil, fmt.Errorf("failed to run migrations: %w", err)
 	}
@@ -48,14 +88,43 @@ func New(databasePath string) (*Repository, error) {
 
 
 // CreateURL creates a new short URL entry
-func (r *Repository) CreateURL(ctx context.Context, shortCode, originalURL string, createdAt time.Time) (*domain.URLEntry, error) {
-	url, err := r.queries.CreateURL(ctx, sqlc.CreateURLParams{
-		ShortCode:   shortCode,
-		OriginalUrl: originalURL,
-		CreatedAt:   createdAt,
+func (r *Repository) CreateURL(ctx context.Context, shortCode, originalURL string, createdAt time.Time, opts domain.CreateOptions) (*domain.URLEntry, error) {
+	return r.createURL(ctx, r.queries, shortCode, originalURL, createdAt, opts)
+}
+
+// createURL inserts a URL with queries, which may belong to a transaction
+func (r *Repository) createURL(ctx context.Context, queries *sqlc.Queries, shortCode, originalURL string, createdAt time.Time, opts domain.CreateOptions) (*domain.URLEntry, error) {
+	// Aliases share the short code namespace
+	aliased, err := queries.AliasExists(ctx, shortCode)
+	if err != nil {
+		return nil, domain.Storage(fmt.Errorf("failed to check alias existence: %w", err))
+	}
+	if aliased > 0 {
+		return nil, fmt.Errorf("failed to create URL: short code %s is an alias: %w", shortCode, domain.ErrShortCodeTaken)
+	}
+
+	url, err := queries.CreateURL(ctx, sqlc.CreateURLParams{
+		ShortCode:        shortCode,
+		OriginalUrl:      originalURL,
+		CreatedAt:        createdAt,
+		MaxUses:          nullMaxUses(opts.MaxUses),
+		Tags:             joinTags(opts.Tags),
+		RedirectStatus:   int64(opts.RedirectStatus),
+		BackupUrl:        opts.BackupURL,
+		QueryParams:      encodeQueryParams(opts.QueryParams),
+		ForwardQuery:     opts.ForwardQuery,
+		DedupeSeconds:    int64(opts.DedupeSeconds),
+		Campaign:         opts.Campaign,
+		Owner:            opts.Owner,
+		Domain:           opts.Domain,
+		BurnAfterReading: opts.BurnAfterReading,
+		UpdatedAt:        sql.NullTime{Time: createdAt, Valid: true},
 	})
+	if isUniqueViolation(err) {
+		return nil, fmt.Errorf("failed to create URL: short code %s: %w", shortCode, domain.ErrShortCodeTaken)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to create URL: %w", err)
+		return nil, domain.Storage(fmt.Errorf("failed to create URL: %w", err))
 	}
 
 	return r.sqlcURLToDomain(url), nil
@@ -65,10 +134,29 @@ func (r *Repository) CreateURL(ctx context.Context, shortCode, originalURL strin
 func (r *Repository) GetURL(ctx context.Context, shortCode string) (*domain.URLEntry, error) {
 	url, err := r.queries.GetURL(ctx, shortCode)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("short code not found")
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrURLNotFound
+		}
+		return nil, domain.Storage(fmt.Errorf("failed to get URL: %w", err))
+	}
+
+	return r.sqlcURLToDomain(url), nil
+}
+
+// GetURLByOriginalURL retrieves the owner's oldest entry without a usage cap
+// for a destination on the server's own host. Capped entries are skipped
+// because they can expire, and entries on a custom domain because they only
+// redirect there.
+func (r *Repository) GetURLByOriginalURL(ctx context.Context, originalURL, owner string) (*domain.URLEntry, error) {
+	url, err := r.queries.GetReusableURLByOriginalURL(ctx, sqlc.GetReusableURLByOriginalURLParams{
+		OriginalUrl: originalURL,
+		Owner:       owner,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrURLNotFound
 		}
-		return nil, fmt.Errorf("failed to get URL: %w", err)
+		return nil, domain.Storage(fmt.Errorf("failed to get URL by destination: %w", err))
 	}
 
 	return r.sqlcURLToDomain(url), nil
@@ -78,7 +166,7 @@ func (r *Repository) GetURL(ctx context.Context, shortCode string) (*domain.URLE
 func (r *Repository) GetAllURLs(ctx context.Context) ([]*domain.URLEntry, error) {
 	urls, err := r.queries.GetAllURLs(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get all URLs: %w", err)
+		return nil, domain.Storage(fmt.Errorf("failed to get all URLs: %w", err))
 	}
 
 	entries := make([]*domain.URLEntry, len(urls))
@@ -89,24 +177,215 @@ func (r *Repository) GetAllURLs(ctx context.Context) ([]*domain.URLEntry, error)
 	return entries, nil
 }
 
-// UpdateUsage updates the usage count and last used timestamp for a URL
+// GetURLsByCampaign retrieves a campaign's URL entries ordered by creation date (desc)
+func (r *Repository) GetURLsByCampaign(ctx context.Context, campaign string) ([]*domain.URLEntry, error) {
+	urls, err := r.queries.GetURLsByCampaign(ctx, campaign)
+	if err != nil {
+		return nil, domain.Storage(fmt.Errorf("failed to get campaign URLs: %w", err))
+	}
+
+	entries := make([]*domain.URLEntry, len(urls))
+	for i, url := range urls {
+		entries[i] = r.sqlcURLToDomain(url)
+	}
+	return entries, nil
+}
+
+// GetURLsByOwner retrieves the URL entries created by an owner ordered by creation date (desc)
+func (r *Repository) GetURLsByOwner(ctx context.Context, owner string) ([]*domain.URLEntry, error) {
+	urls, err := r.queries.GetURLsByOwner(ctx, owner)
+	if err != nil {
+		return nil, domain.Storage(fmt.Errorf("failed to get owner URLs: %w", err))
+	}
+
+	entries := make([]*domain.URLEntry, len(urls))
+	for i, url := range urls {
+		entries[i] = r.sqlcURLToDomain(url)
+	}
+	return entries, nil
+}
+
+// UpdateURL replaces the destination and settings of an existing URL entry,
+// recording the time as its updated_at
+func (r *Repository) UpdateURL(ctx context.Context, shortCode, originalURL string, opts domain.CreateOptions) (*domain.URLEntry, error) {
+	url, err := r.queries.UpdateURL(ctx, sqlc.UpdateURLParams{
+		OriginalUrl:    originalURL,
+		MaxUses:        nullMaxUses(opts.MaxUses),
+		Tags:           joinTags(opts.Tags),
+		RedirectStatus: int64(opts.RedirectStatus),
+		BackupUrl:      opts.BackupURL,
+		QueryParams:    encodeQueryParams(opts.QueryParams),
+		ForwardQuery:   opts.ForwardQuery,
+		DedupeSeconds:  int64(opts.DedupeSeconds),
+		Campaign:       opts.Campaign,
+		UpdatedAt:      sql.NullTime{Time: time.Now(), Valid: true},
+		ShortCode:      shortCode,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrURLNotFound
+		}
+		return nil, domain.Storage(fmt.Errorf("failed to update URL: %w", err))
+	}
+
+	return r.sqlcURLToDomain(url), nil
+}
+
+// SetURLFailover records whether redirects for a URL go to its backup destination
+func (r *Repository) SetURLFailover(ctx context.Context, shortCode string, active bool, reason string, changedAt time.Time) (*domain.URLEntry, error) {
+	url, err := r.queries.SetURLFailover(ctx, sqlc.SetURLFailoverParams{
+		FailoverActive:    active,
+		FailoverReason:    reason,
+		FailoverChangedAt: sql.NullTime{Time: changedAt, Valid: true},
+		ShortCode:         shortCode,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrURLNotFound
+		}
+		return nil, domain.Storage(fmt.Errorf("failed to set URL failover: %w", err))
+	}
+
+	return r.sqlcURLToDomain(url), nil
+}
+
+// SetURLPreview stores the preview fetched for a URL's destination page
+func (r *Repository) SetURLPreview(ctx context.Context, shortCode string, preview domain.LinkPreview) (*domain.URLEntry, error) {
+	url, err := r.queries.SetURLPreview(ctx, sqlc.SetURLPreviewParams{
+		PreviewTitle:       preview.Title,
+		PreviewDescription: preview.Description,
+		PreviewFaviconUrl:  preview.FaviconURL,
+		PreviewError:       preview.Error,
+		PreviewFetchedAt:   sql.NullTime{Time: preview.FetchedAt, Valid: true},
+		ShortCode:          shortCode,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrURLNotFound
+		}
+		return nil, domain.Storage(fmt.Errorf("failed to set URL preview: %w", err))
+	}
+
+	return r.sqlcURLToDomain(url), nil
+}
+
+// UpdateUsage records a usage count and last used timestamp for a URL. The
+// higher of the stored and given values wins, so a stale writer cannot roll
+// the count back.
 func (r *Repository) UpdateUsage(ctx context.Context, shortCode string, usageCount int, lastUsedAt time.Time) error {
-	err := r.queries.UpdateUsage(ctx, sqlc.UpdateUsageParams{
+	_, err := r.queries.UpdateUsage(ctx, sqlc.UpdateUsageParams{
 		UsageCount: sql.NullInt64{Int64: int64(usageCount), Valid: true},
 		LastUsedAt: sql.NullTime{Time: lastUsedAt, Valid: true},
 		ShortCode:  shortCode,
 	})
-	if err != nil {
-		return fmt.Errorf("failed to update usage: %w", err)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return domain.Storage(fmt.Errorf("failed to update usage: %w", err))
 	}
 	return nil
 }
 
+// BurnURL claims the single redirect of a burn-after-reading link. The
+// conditional update is the one place the claim is decided, so concurrent
+// redirects on any instance cannot both win.
+func (r *Repository) BurnURL(ctx context.Context, shortCode string, usedAt time.Time) error {
+	claimed, err := r.queries.BurnURL(ctx, sqlc.BurnURLParams{
+		LastUsedAt: sql.NullTime{Time: usedAt, Valid: true},
+		ShortCode:  shortCode,
+	})
+	if err != nil {
+		return domain.Storage(fmt.Errorf("failed to burn URL: %w", err))
+	}
+	if claimed > 0 {
+		return nil
+	}
+
+	exists, err := r.URLExists(ctx, shortCode)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return domain.ErrURLNotFound
+	}
+	return fmt.Errorf("short code %s was already read: %w", shortCode, domain.ErrUsageLimitReached)
+}
+
+// UpdateUsageBatch applies a batch of usage updates in a single transaction,
+// merging each chunk of up to usageBatchSize codes with one UPDATE statement
+// and resolving concurrent writers with the given strategy. It returns the stored
+// count for each short code after the merge; codes that no longer exist are omitted.
+func (r *Repository) UpdateUsageBatch(ctx context.Context, updates []domain.UsageUpdate, strategy domain.UsageMergeStrategy) (map[string]int, error) {
+	switch strategy {
+	case domain.UsageMergeMax, domain.UsageMergeDelta:
+	default:
+		return nil, fmt.Errorf("unknown usage merge strategy: %s", strategy)
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, domain.Storage(fmt.Errorf("failed to begin usage transaction: %w", err))
+	}
+	defer tx.Rollback()
+
+	merged := mergeUsageUpdates(updates)
+	counts := make(map[string]int, len(merged))
+
+	// One statement per chunk instead of one per code keeps large syncs short
+	for start := 0; start < len(merged); start += usageBatchSize {
+		chunk := merged[start:min(start+usageBatchSize, len(merged))]
+		if err := execUsageChunk(ctx, r.stmts, tx, strategy, chunk, counts); err != nil {
+			return nil, domain.Storage(fmt.Errorf("failed to update usage for %d entries: %w", len(chunk), err))
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, domain.Storage(fmt.Errorf("failed to commit usage updates: %w", err))
+	}
+
+	return counts, nil
+}
+
 // DeleteURL removes a URL entry by its short code
 func (r *Repository) DeleteURL(ctx context.Context, shortCode string) error {
-	err := r.queries.DeleteURL(ctx, shortCode)
+	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
-		return fmt.Errorf("failed to delete URL: %w", err)
+		return domain.Storage(fmt.Errorf("failed to begin transaction: %w", err))
+	}
+	defer tx.Rollback()
+
+	queries := r.queries.WithTx(tx)
+
+	// foreign_keys is set per connection, so don't rely on the cascade
+	if err := queries.DeleteRoutingRules(ctx, shortCode); err != nil {
+		return domain.Storage(fmt.Errorf("failed to delete routing rules: %w", err))
+	}
+	if err := queries.DeleteReferrerRollups(ctx, shortCode); err != nil {
+		return domain.Storage(fmt.Errorf("failed to delete referrer rollups: %w", err))
+	}
+	if err := queries.DeleteUTMRollups(ctx, shortCode); err != nil {
+		return domain.Storage(fmt.Errorf("failed to delete UTM rollups: %w", err))
+	}
+	if err := queries.DeleteCountryRollups(ctx, shortCode); err != nil {
+		return domain.Storage(fmt.Errorf("failed to delete country rollups: %w", err))
+	}
+	if err := queries.DeleteClickEvents(ctx, shortCode); err != nil {
+		return domain.Storage(fmt.Errorf("failed to delete click events: %w", err))
+	}
+	if err := queries.DeleteClickHours(ctx, shortCode); err != nil {
+		return domain.Storage(fmt.Errorf("failed to delete click hours: %w", err))
+	}
+	if err := queries.DeleteLinkVerification(ctx, shortCode); err != nil {
+		return domain.Storage(fmt.Errorf("failed to delete link verification: %w", err))
+	}
+	if err := queries.DeleteLinkAliases(ctx, shortCode); err != nil {
+		return domain.Storage(fmt.Errorf("failed to delete aliases: %w", err))
+	}
+
+	if err := queries.DeleteURL(ctx, shortCode); err != nil {
+		return domain.Storage(fmt.Errorf("failed to delete URL: %w", err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return domain.Storage(fmt.Errorf("failed to commit URL deletion: %w", err))
 	}
 	return nil
 }
@@ -115,7 +394,7 @@ func (r *Repository) DeleteURL(ctx context.Context, shortCode string) error {
 func (r *Repository) URLExists(ctx context.Context, shortCode string) (bool, error) {
 	count, err := r.queries.URLExists(ctx, shortCode)
 	if err != nil {
-		return false, fmt.Errorf("failed to check URL existence: %w", err)
+		return false, domain.Storage(fmt.Errorf("failed to check URL existence: %w", err))
 	}
 	return count > 0, nil
 }
@@ -124,15 +403,34 @@ func (r *Repository) URLExists(ctx context.Context, shortCode string) (bool, err
 func (r *Repository) LoadCacheData(ctx context.Context) (map[string]*domain.CacheEntry, error) {
 	urls, err := r.queries.GetAllURLs(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to load cache data: %w", err)
+		return nil, domain.Storage(fmt.Errorf("failed to load cache data: %w", err))
+	}
+
+	routes, err := r.loadRoutingRules(ctx)
+	if err != nil {
+		return nil, domain.Storage(fmt.Errorf("failed to load cache data: %w", err))
 	}
 
 	cache := make(map[string]*domain.CacheEntry)
 	for _, url := range urls {
 		cacheEntry := &domain.CacheEntry{
-			OriginalURL: url.OriginalUrl,
-			UsageCount:  int(url.UsageCount.Int64),
-			Dirty:       false,
+			OriginalURL:      url.OriginalUrl,
+			UsageCount:       int(url.UsageCount.Int64),
+			MaxUses:          int(url.MaxUses.Int64),
+			RedirectStatus:   int(url.RedirectStatus),
+			BackupURL:        url.BackupUrl,
+			FailoverActive:   url.FailoverActive,
+			QueryParams:      decodeQueryParams(url.QueryParams),
+			ForwardQuery:     url.ForwardQuery,
+			DedupeSeconds:    int(url.DedupeSeconds),
+			Campaign:         url.Campaign,
+			Domain:           url.Domain,
+			BurnAfterReading: url.BurnAfterReading,
+			Routes:           routes[url.ShortCode],
+			Dirty:            false,
+			SyncedCount:      int(url.UsageCount.Int64),
+			BotsCount:        int(url.BotsCount),
+			SyncedBots:       int(url.BotsCount),
 		}
 		if url.LastUsedAt.Valid {
 			cacheEntry.LastUsedAt = url.LastUsedAt.Time
@@ -143,28 +441,201 @@ func (r *Repository) LoadCacheData(ctx context.Context) (map[string]*domain.Cach
 	return cache, nil
 }
 
+// ImportURLs inserts entries in a single transaction, resolving existing short codes with the given strategy
+func (r *Repository) ImportURLs(ctx context.Context, entries []*domain.URLEntry, strategy domain.ConflictStrategy) (*domain.ImportResult, error) {
+	switch strategy {
+	case domain.ConflictSkip, domain.ConflictOverwrite, domain.ConflictFail:
+	default:
+		return nil, fmt.Errorf("unknown conflict strategy: %s", strategy)
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, domain.Storage(fmt.Errorf("failed to begin import transaction: %w", err))
+	}
+	defer tx.Rollback()
+
+	queries := r.queries.WithTx(tx)
+	result := &domain.ImportResult{}
+	// Imported and overwritten links changed now as far as this database's
+	// readers are concerned, whatever their exported history
+	importedAt := sql.NullTime{Time: time.Now(), Valid: true}
+
+	for _, entry := range entries {
+		lastUsedAt := sql.NullTime{}
+		if entry.LastUsedAt != nil {
+			lastUsedAt = sql.NullTime{Time: *entry.LastUsedAt, Valid: true}
+		}
+		usageCount := sql.NullInt64{Int64: int64(entry.UsageCount), Valid: true}
+
+		count, err := queries.URLExists(ctx, entry.ShortCode)
+		if err != nil {
+			return nil, domain.Storage(fmt.Errorf("failed to check URL existence for %s: %w", entry.ShortCode, err))
+		}
+
+		if count == 0 {
+			if err := queries.ImportURL(ctx, sqlc.ImportURLParams{
+				ShortCode:        entry.ShortCode,
+				OriginalUrl:      entry.OriginalURL,
+				CreatedAt:        entry.CreatedAt,
+				LastUsedAt:       lastUsedAt,
+				UsageCount:       usageCount,
+				MaxUses:          nullMaxUses(entry.MaxUses),
+				Tags:             joinTags(entry.Tags),
+				RedirectStatus:   int64(entry.RedirectStatus),
+				BackupUrl:        entry.BackupURL,
+				QueryParams:      encodeQueryParams(entry.QueryParams),
+				ForwardQuery:     entry.ForwardQuery,
+				DedupeSeconds:    int64(entry.DedupeSeconds),
+				BotsCount:        int64(entry.BotsCount),
+				Campaign:         entry.Campaign,
+				Owner:            entry.Owner,
+				Domain:           entry.Domain,
+				BurnAfterReading: entry.BurnAfterReading,
+				UpdatedAt:        importedAt,
+			}); err != nil {
+				return nil, domain.Storage(fmt.Errorf("failed to import URL %s: %w", entry.ShortCode, err))
+			}
+			result.Imported++
+			continue
+		}
+
+		switch strategy {
+		case domain.ConflictSkip:
+			result.Skipped++
+		case domain.ConflictFail:
+			return nil, domain.Conflict(fmt.Errorf("short code %s already exists", entry.ShortCode))
+		case domain.ConflictOverwrite:
+			if err := queries.OverwriteURL(ctx, sqlc.OverwriteURLParams{
+				OriginalUrl:      entry.OriginalURL,
+				CreatedAt:        entry.CreatedAt,
+				LastUsedAt:       lastUsedAt,
+				UsageCount:       usageCount,
+				MaxUses:          nullMaxUses(entry.MaxUses),
+				Tags:             joinTags(entry.Tags),
+				RedirectStatus:   int64(entry.RedirectStatus),
+				BackupUrl:        entry.BackupURL,
+				QueryParams:      encodeQueryParams(entry.QueryParams),
+				ForwardQuery:     entry.ForwardQuery,
+				DedupeSeconds:    int64(entry.DedupeSeconds),
+				BotsCount:        int64(entry.BotsCount),
+				Campaign:         entry.Campaign,
+				Owner:            entry.Owner,
+				Domain:           entry.Domain,
+				BurnAfterReading: entry.BurnAfterReading,
+				UpdatedAt:        importedAt,
+				ShortCode:        entry.ShortCode,
+			}); err != nil {
+				return nil, domain.Storage(fmt.Errorf("failed to overwrite URL %s: %w", entry.ShortCode, err))
+			}
+			result.Overwritten++
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, domain.Storage(fmt.Errorf("failed to commit import: %w", err))
+	}
+
+	return result, nil
+}
+
+// Ping verifies the database connection is alive
+func (r *Repository) Ping(ctx context.Context) error {
+	return r.db.PingContext(ctx)
+}
+
 // Close closes the repository connection
 func (r *Repository) Close() error {
+	if err := r.stmts.Close(); err != nil {
+		r.db.Close()
+		return fmt.Errorf("failed to close prepared statements: %w", err)
+	}
 	return r.db.Close()
 }
 
 // sqlcURLToDomain converts a sqlc.Url to domain.URLEntry
 func (r *Repository) sqlcURLToDomain(url sqlc.Url) *domain.URLEntry {
 	entry := &domain.URLEntry{
-		ID:          int(url.ID),
-		ShortCode:   url.ShortCode,
-		OriginalURL: url.OriginalUrl,
-		CreatedAt:   url.CreatedAt,
-		UsageCount:  int(url.UsageCount.Int64),
+		ID:               int(url.ID),
+		ShortCode:        url.ShortCode,
+		OriginalURL:      url.OriginalUrl,
+		CreatedAt:        url.CreatedAt,
+		UsageCount:       int(url.UsageCount.Int64),
+		BotsCount:        int(url.BotsCount),
+		MaxUses:          int(url.MaxUses.Int64),
+		RedirectStatus:   int(url.RedirectStatus),
+		BackupURL:        url.BackupUrl,
+		FailoverActive:   url.FailoverActive,
+		FailoverReason:   url.FailoverReason,
+		QueryParams:      decodeQueryParams(url.QueryParams),
+		ForwardQuery:     url.ForwardQuery,
+		DedupeSeconds:    int(url.DedupeSeconds),
+		Campaign:         url.Campaign,
+		Owner:            url.Owner,
+		Domain:           url.Domain,
+		BurnAfterReading: url.BurnAfterReading,
 	}
 
 	if url.LastUsedAt.Valid {
 		entry.LastUsedAt = &url.LastUsedAt.Time
 	}
+	if url.FailoverChangedAt.Valid {
+		entry.FailoverChangedAt = &url.FailoverChangedAt.Time
+	}
+	if url.UpdatedAt.Valid {
+		entry.UpdatedAt = &url.UpdatedAt.Time
+	}
+	if url.PreviewFetchedAt.Valid {
+		entry.Preview = &domain.LinkPreview{
+			Title:       url.PreviewTitle,
+			Description: url.PreviewDescription,
+			FaviconURL:  url.PreviewFaviconUrl,
+			Error:       url.PreviewError,
+			FetchedAt:   url.PreviewFetchedAt.Time,
+		}
+	}
+	if url.Tags != "" {
+		entry.Tags = strings.Split(url.Tags, ",")
+	}
 
 	return entry
 }
 
+// nullMaxUses stores an unlimited (zero) usage cap as NULL
+func nullMaxUses(maxUses int) sql.NullInt64 {
+	return sql.NullInt64{Int64: int64(maxUses), Valid: maxUses > 0}
+}
+
+// joinTags stores a link's tags as a comma-separated list
+func joinTags(tags []string) string {
+	return strings.Join(tags, ",")
+}
+
+// encodeQueryParams stores a link's query parameters as a URL-encoded query string
+func encodeQueryParams(params map[string]string) string {
+	values := make(neturl.Values, len(params))
+	for name, value := range params {
+		values.Set(name, value)
+	}
+	return values.Encode()
+}
+
+// decodeQueryParams reads query parameters stored by encodeQueryParams
+func decodeQueryParams(encoded string) map[string]string {
+	if encoded == "" {
+		return nil
+	}
+	values, err := neturl.ParseQuery(encoded)
+	if err != nil {
+		return nil
+	}
+	params := make(map[string]string, len(values))
+	for name := range values {
+		params[name] = values.Get(name)
+	}
+	return params
+}
+
 // GetQueries returns the underlying sqlc queries for advanced operations
 func (r *Repository) GetQueries() *sqlc.Queries {
 	return r.queries
@@ -2,19 +2,23 @@ package sqlite
 
 import (
 	"context"
+	"fmt"
+	"net/http"
 	"os"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
 )
 
 func TestRepository_New(t *testing.T) {
 	dbPath := createTempDB(t)
 	defer os.Remove(dbPath)
 
-	repo, err := New(dbPath)
+	repo, err := New(dbPath, WithDriver(testDriver))
 	require.NoError(t, err)
 	assert.NotNil(t, repo)
 	assert.NotNil(t, repo.db)
@@ -31,7 +35,7 @@ func TestRepository_New(t *testing.T) {
 
 func TestRepository_New_InvalidPath(t *testing.T) {
 	// Test with invalid database path
-	repo, err := New("/invalid/path/to/database.db")
+	repo, err := New("/invalid/path/to/database.db", WithDriver(testDriver))
 	assert.Error(t, err)
 	assert.Nil(t, repo)
 }
@@ -46,7 +50,7 @@ func TestRepository_CreateURL(t *testing.T) {
 	createdAt := time.Now().UTC()
 
 	// Create URL
-	entry, err := repo.CreateURL(ctx, shortCode, originalURL, createdAt)
+	entry, err := repo.CreateURL(ctx, shortCode, originalURL, createdAt, domain.CreateOptions{})
 	require.NoError(t, err)
 	assert.NotNil(t, entry)
 	assert.NotZero(t, entry.ID)
@@ -57,6 +61,29 @@ func TestRepository_CreateURL(t *testing.T) {
 	assert.Equal(t, 0, entry.UsageCount)
 }
 
+func TestRepository_CreateURL_MaxUses(t *testing.T) {
+	repo := setupTestRepo(t)
+	defer teardownTestRepo(t, repo)
+
+	ctx := context.Background()
+
+	entry, err := repo.CreateURL(ctx, "capped", "https://example.com", time.Now(), domain.CreateOptions{MaxUses: 3})
+	require.NoError(t, err)
+	assert.Equal(t, 3, entry.MaxUses)
+
+	_, err = repo.CreateURL(ctx, "unlimited", "https://example.org", time.Now(), domain.CreateOptions{})
+	require.NoError(t, err)
+
+	fetched, err := repo.GetURL(ctx, "capped")
+	require.NoError(t, err)
+	assert.Equal(t, 3, fetched.MaxUses)
+
+	cacheData, err := repo.LoadCacheData(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 3, cacheData["capped"].MaxUses)
+	assert.Equal(t, 0, cacheData["unlimited"].MaxUses)
+}
+
 func TestRepository_CreateURL_Duplicate(t *testing.T) {
 	repo := setupTestRepo(t)
 	defer teardownTestRepo(t, repo)
@@ -67,13 +94,16 @@ func TestRepository_CreateURL_Duplicate(t *testing.T) {
 	createdAt := time.Now().UTC()
 
 	// Create first URL
-	_, err := repo.CreateURL(ctx, shortCode, originalURL, createdAt)
+	_, err := repo.CreateURL(ctx, shortCode, originalURL, createdAt, domain.CreateOptions{})
 	require.NoError(t, err)
 
 	// Try to create duplicate
-	_, err = repo.CreateURL(ctx, shortCode, "https://different.com", createdAt)
+	_, err = repo.CreateURL(ctx, shortCode, "https://different.com", createdAt, domain.CreateOptions{})
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "failed to create URL")
+	assert.ErrorIs(t, err, domain.ErrShortCodeTaken)
+	assert.ErrorIs(t, err, domain.ErrConflict)
+	assert.NotErrorIs(t, err, domain.ErrStorage)
 }
 
 func TestRepository_GetURL(t *testing.T) {
@@ -86,7 +116,7 @@ func TestRepository_GetURL(t *testing.T) {
 	createdAt := time.Now().UTC()
 
 	// Create URL first
-	created, err := repo.CreateURL(ctx, shortCode, originalURL, createdAt)
+	created, err := repo.CreateURL(ctx, shortCode, originalURL, createdAt, domain.CreateOptions{})
 	require.NoError(t, err)
 
 	// Get URL
@@ -107,8 +137,25 @@ func TestRepository_GetURL_NotFound(t *testing.T) {
 
 	// Try to get non-existent URL
 	_, err := repo.GetURL(ctx, "nonexistent")
-	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "short code not found")
+	assert.ErrorIs(t, err, domain.ErrURLNotFound)
+	assert.NotErrorIs(t, err, domain.ErrStorage)
+}
+
+func TestRepository_StorageErrors(t *testing.T) {
+	repo := setupTestRepo(t)
+	defer teardownTestRepo(t, repo)
+
+	ctx := context.Background()
+	require.NoError(t, repo.db.Close())
+
+	_, err := repo.GetURL(ctx, "abc123")
+	assert.ErrorIs(t, err, domain.ErrStorage)
+	assert.NotErrorIs(t, err, domain.ErrNotFound)
+
+	_, err = repo.URLExists(ctx, "abc123")
+	assert.ErrorIs(t, err, domain.ErrStorage)
+
+	assert.ErrorIs(t, repo.DeleteURL(ctx, "abc123"), domain.ErrStorage)
 }
 
 func TestRepository_GetAllURLs(t *testing.T) {
@@ -124,13 +171,13 @@ func TestRepository_GetAllURLs(t *testing.T) {
 
 	// Create multiple URLs with different timestamps
 	now := time.Now().UTC()
-	urls1, err := repo.CreateURL(ctx, "test1", "https://example1.com", now.Add(-2*time.Hour))
+	urls1, err := repo.CreateURL(ctx, "test1", "https://example1.com", now.Add(-2*time.Hour), domain.CreateOptions{})
 	require.NoError(t, err)
 
-	urls2, err := repo.CreateURL(ctx, "test2", "https://example2.com", now.Add(-1*time.Hour))
+	urls2, err := repo.CreateURL(ctx, "test2", "https://example2.com", now.Add(-1*time.Hour), domain.CreateOptions{})
 	require.NoError(t, err)
 
-	urls3, err := repo.CreateURL(ctx, "test3", "https://example3.com", now)
+	urls3, err := repo.CreateURL(ctx, "test3", "https://example3.com", now, domain.CreateOptions{})
 	require.NoError(t, err)
 
 	// Get all URLs
@@ -154,7 +201,7 @@ func TestRepository_UpdateUsage(t *testing.T) {
 	createdAt := time.Now().UTC()
 
 	// Create URL first
-	_, err := repo.CreateURL(ctx, shortCode, originalURL, createdAt)
+	_, err := repo.CreateURL(ctx, shortCode, originalURL, createdAt, domain.CreateOptions{})
 	require.NoError(t, err)
 
 	// Update usage
@@ -181,6 +228,274 @@ func TestRepository_UpdateUsage_NonExistent(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestRepository_GetURLByOriginalURL(t *testing.T) {
+	repo := setupTestRepo(t)
+	defer teardownTestRepo(t, repo)
+
+	ctx := context.Background()
+	now := time.Now().UTC()
+	_, err := repo.CreateURL(ctx, "capped", "https://example.com", now.Add(-4*time.Hour), domain.CreateOptions{MaxUses: 1})
+	require.NoError(t, err)
+	_, err = repo.CreateURL(ctx, "custom", "https://example.com", now.Add(-3*time.Hour), domain.CreateOptions{Domain: "go.example.com"})
+	require.NoError(t, err)
+	_, err = repo.CreateURL(ctx, "oldest", "https://example.com", now.Add(-2*time.Hour), domain.CreateOptions{})
+	require.NoError(t, err)
+	_, err = repo.CreateURL(ctx, "newest", "https://example.com", now.Add(-time.Hour), domain.CreateOptions{})
+	require.NoError(t, err)
+	_, err = repo.CreateURL(ctx, "alice", "https://example.com", now.Add(-30*time.Minute), domain.CreateOptions{Owner: "key:alice"})
+	require.NoError(t, err)
+
+	// Capped links and links on a custom domain are skipped, and the oldest
+	// remaining link wins
+	entry, err := repo.GetURLByOriginalURL(ctx, "https://example.com", "")
+	require.NoError(t, err)
+	assert.Equal(t, "oldest", entry.ShortCode)
+
+	// Only the owner's own links are returned
+	entry, err = repo.GetURLByOriginalURL(ctx, "https://example.com", "key:alice")
+	require.NoError(t, err)
+	assert.Equal(t, "alice", entry.ShortCode)
+
+	_, err = repo.GetURLByOriginalURL(ctx, "https://example.com", "key:bob")
+	assert.ErrorIs(t, err, domain.ErrURLNotFound)
+
+	_, err = repo.GetURLByOriginalURL(ctx, "https://example.com/other", "")
+	assert.ErrorIs(t, err, domain.ErrURLNotFound)
+}
+
+func TestRepository_UpdateURL(t *testing.T) {
+	repo := setupTestRepo(t)
+	defer teardownTestRepo(t, repo)
+
+	ctx := context.Background()
+	created, err := repo.CreateURL(ctx, "test123", "https://example.com", time.Now().UTC(), domain.CreateOptions{})
+	require.NoError(t, err)
+	require.NoError(t, repo.UpdateUsage(ctx, "test123", 3, time.Now()))
+
+	updated, err := repo.UpdateURL(ctx, "test123", "https://example.com/new", domain.CreateOptions{MaxUses: 10, Tags: []string{"promo", "q3"}, RedirectStatus: http.StatusMovedPermanently})
+	require.NoError(t, err)
+	assert.Equal(t, created.ID, updated.ID)
+	assert.Equal(t, "https://example.com/new", updated.OriginalURL)
+	assert.Equal(t, 10, updated.MaxUses)
+	assert.Equal(t, []string{"promo", "q3"}, updated.Tags)
+	assert.Equal(t, http.StatusMovedPermanently, updated.RedirectStatus)
+	assert.Equal(t, 3, updated.UsageCount, "usage is kept when a link is edited")
+	require.NotNil(t, created.UpdatedAt)
+	require.NotNil(t, updated.UpdatedAt)
+	assert.False(t, updated.UpdatedAt.Before(*created.UpdatedAt), "edits move updated_at")
+
+	cacheData, err := repo.LoadCacheData(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusMovedPermanently, cacheData["test123"].RedirectStatus)
+
+	// Zero removes the cap and no tags clears them
+	updated, err = repo.UpdateURL(ctx, "test123", "https://example.com/new", domain.CreateOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, 0, updated.MaxUses)
+	assert.Empty(t, updated.Tags)
+	assert.Equal(t, 0, updated.RedirectStatus)
+
+	_, err = repo.UpdateURL(ctx, "nonexistent", "https://example.com", domain.CreateOptions{})
+	assert.ErrorIs(t, err, domain.ErrURLNotFound)
+}
+
+func TestRepository_SetURLFailover(t *testing.T) {
+	repo := setupTestRepo(t)
+	defer teardownTestRepo(t, repo)
+
+	ctx := context.Background()
+	created, err := repo.CreateURL(ctx, "test123", "https://example.com", time.Now().UTC(), domain.CreateOptions{BackupURL: "https://mirror.example.com"})
+	require.NoError(t, err)
+	assert.Equal(t, "https://mirror.example.com", created.BackupURL)
+	assert.False(t, created.FailoverActive)
+	assert.Nil(t, created.FailoverChangedAt)
+
+	changedAt := time.Now().UTC().Truncate(time.Second)
+	updated, err := repo.SetURLFailover(ctx, "test123", true, "3 consecutive failed health checks", changedAt)
+	require.NoError(t, err)
+	assert.True(t, updated.FailoverActive)
+	assert.Equal(t, "3 consecutive failed health checks", updated.FailoverReason)
+	require.NotNil(t, updated.FailoverChangedAt)
+	assert.True(t, changedAt.Equal(*updated.FailoverChangedAt))
+
+	cacheData, err := repo.LoadCacheData(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "https://mirror.example.com", cacheData["test123"].BackupURL)
+	assert.True(t, cacheData["test123"].FailoverActive)
+
+	// Editing the link keeps the failover state
+	updated, err = repo.UpdateURL(ctx, "test123", "https://example.com/new", domain.CreateOptions{BackupURL: "https://mirror.example.com"})
+	require.NoError(t, err)
+	assert.True(t, updated.FailoverActive)
+
+	_, err = repo.SetURLFailover(ctx, "nonexistent", true, "down", changedAt)
+	assert.ErrorIs(t, err, domain.ErrURLNotFound)
+}
+
+func TestRepository_SetURLPreview(t *testing.T) {
+	repo := setupTestRepo(t)
+	defer teardownTestRepo(t, repo)
+
+	ctx := context.Background()
+	created, err := repo.CreateURL(ctx, "test123", "https://example.com", time.Now().UTC(), domain.CreateOptions{})
+	require.NoError(t, err)
+	assert.Nil(t, created.Preview, "links have no preview until one is fetched")
+
+	preview := domain.LinkPreview{
+		Title:       "Example Domain",
+		Description: "For use in illustrative examples",
+		FaviconURL:  "https://example.com/favicon.ico",
+		FetchedAt:   time.Now().UTC().Truncate(time.Second),
+	}
+	updated, err := repo.SetURLPreview(ctx, "test123", preview)
+	require.NoError(t, err)
+	require.NotNil(t, updated.Preview)
+	assert.Equal(t, preview.Title, updated.Preview.Title)
+	assert.Equal(t, preview.Description, updated.Preview.Description)
+	assert.Equal(t, preview.FaviconURL, updated.Preview.FaviconURL)
+	assert.True(t, preview.FetchedAt.Equal(updated.Preview.FetchedAt))
+
+	// A failed refresh replaces the old preview
+	updated, err = repo.SetURLPreview(ctx, "test123", domain.LinkPreview{Error: "disallowed by robots.txt", FetchedAt: time.Now().UTC()})
+	require.NoError(t, err)
+	entries, err := repo.GetAllURLs(ctx)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, updated.Preview, entries[0].Preview)
+	assert.Empty(t, entries[0].Preview.Title)
+	assert.Equal(t, "disallowed by robots.txt", entries[0].Preview.Error)
+
+	_, err = repo.SetURLPreview(ctx, "nonexistent", preview)
+	assert.ErrorIs(t, err, domain.ErrURLNotFound)
+}
+
+func TestRepository_QueryParams(t *testing.T) {
+	repo := setupTestRepo(t)
+	defer teardownTestRepo(t, repo)
+
+	ctx := context.Background()
+	params := map[string]string{"utm_source": "news letter", "utm_campaign": "a&b=c"}
+	created, err := repo.CreateURL(ctx, "test123", "https://example.com", time.Now().UTC(), domain.CreateOptions{QueryParams: params, ForwardQuery: true})
+	require.NoError(t, err)
+	assert.Equal(t, params, created.QueryParams)
+	assert.True(t, created.ForwardQuery)
+
+	cacheData, err := repo.LoadCacheData(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, params, cacheData["test123"].QueryParams)
+	assert.True(t, cacheData["test123"].ForwardQuery)
+
+	updated, err := repo.UpdateURL(ctx, "test123", "https://example.com", domain.CreateOptions{})
+	require.NoError(t, err)
+	assert.Nil(t, updated.QueryParams)
+	assert.False(t, updated.ForwardQuery)
+}
+
+func TestRepository_DedupeSeconds(t *testing.T) {
+	repo := setupTestRepo(t)
+	defer teardownTestRepo(t, repo)
+
+	ctx := context.Background()
+	created, err := repo.CreateURL(ctx, "test123", "https://example.com", time.Now().UTC(), domain.CreateOptions{DedupeSeconds: 600})
+	require.NoError(t, err)
+	assert.Equal(t, 600, created.DedupeSeconds)
+
+	cacheData, err := repo.LoadCacheData(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 600, cacheData["test123"].DedupeSeconds)
+
+	updated, err := repo.UpdateURL(ctx, "test123", "https://example.com", domain.CreateOptions{DedupeSeconds: -1})
+	require.NoError(t, err)
+	assert.Equal(t, -1, updated.DedupeSeconds)
+}
+
+func TestRepository_BotsCount(t *testing.T) {
+	repo := setupTestRepo(t)
+	defer teardownTestRepo(t, repo)
+
+	ctx := context.Background()
+	now := time.Now().UTC()
+	_, err := repo.CreateURL(ctx, "test123", "https://example.com", now, domain.CreateOptions{})
+	require.NoError(t, err)
+
+	// Bot deltas add up whatever the usage merge strategy
+	for _, strategy := range []domain.UsageMergeStrategy{domain.UsageMergeDelta, domain.UsageMergeMax} {
+		_, err = repo.UpdateUsageBatch(ctx, []domain.UsageUpdate{{ShortCode: "test123", UsageCount: 1, Delta: 1, BotsDelta: 2, LastUsedAt: now}}, strategy)
+		require.NoError(t, err)
+	}
+
+	entry, err := repo.GetURL(ctx, "test123")
+	require.NoError(t, err)
+	assert.Equal(t, 4, entry.BotsCount)
+
+	cacheData, err := repo.LoadCacheData(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 4, cacheData["test123"].BotsCount)
+	assert.Equal(t, 4, cacheData["test123"].SyncedBots)
+}
+
+func TestRepository_GetURLsByCampaign(t *testing.T) {
+	repo := setupTestRepo(t)
+	defer teardownTestRepo(t, repo)
+
+	ctx := context.Background()
+	now := time.Now().UTC()
+	_, err := repo.CreateURL(ctx, "a", "https://example.com/a", now.Add(-time.Hour), domain.CreateOptions{Campaign: "launch"})
+	require.NoError(t, err)
+	_, err = repo.CreateURL(ctx, "b", "https://example.com/b", now, domain.CreateOptions{Campaign: "launch"})
+	require.NoError(t, err)
+	_, err = repo.CreateURL(ctx, "c", "https://example.com/c", now, domain.CreateOptions{})
+	require.NoError(t, err)
+
+	entries, err := repo.GetURLsByCampaign(ctx, "launch")
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "b", entries[0].ShortCode)
+	assert.Equal(t, "a", entries[1].ShortCode)
+	assert.Equal(t, "launch", entries[0].Campaign)
+
+	// Updating without a campaign removes the link from it
+	_, err = repo.UpdateURL(ctx, "a", "https://example.com/a", domain.CreateOptions{})
+	require.NoError(t, err)
+	entries, err = repo.GetURLsByCampaign(ctx, "launch")
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+
+	entries, err = repo.GetURLsByCampaign(ctx, "missing")
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestRepository_GetURLsByOwner(t *testing.T) {
+	repo := setupTestRepo(t)
+	defer teardownTestRepo(t, repo)
+
+	ctx := context.Background()
+	now := time.Now().UTC()
+	created, err := repo.CreateURL(ctx, "a", "https://example.com/a", now, domain.CreateOptions{Owner: "key:alice"})
+	require.NoError(t, err)
+	assert.Equal(t, "key:alice", created.Owner)
+	_, err = repo.CreateURL(ctx, "b", "https://example.com/b", now, domain.CreateOptions{})
+	require.NoError(t, err)
+
+	// Updates never change the owner
+	updated, err := repo.UpdateURL(ctx, "a", "https://example.com/new", domain.CreateOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "key:alice", updated.Owner)
+
+	// Imports keep the owner of each entry
+	_, err = repo.ImportURLs(ctx, []*domain.URLEntry{
+		{ShortCode: "c", OriginalURL: "https://example.com/c", CreatedAt: now.Add(time.Hour), Owner: "key:alice"},
+	}, domain.ConflictSkip)
+	require.NoError(t, err)
+
+	entries, err := repo.GetURLsByOwner(ctx, "key:alice")
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "c", entries[0].ShortCode)
+	assert.Equal(t, "a", entries[1].ShortCode)
+}
+
 func TestRepository_DeleteURL(t *testing.T) {
 	repo := setupTestRepo(t)
 	defer teardownTestRepo(t, repo)
@@ -191,7 +506,7 @@ func TestRepository_DeleteURL(t *testing.T) {
 	createdAt := time.Now().UTC()
 
 	// Create URL first
-	_, err := repo.CreateURL(ctx, shortCode, originalURL, createdAt)
+	_, err := repo.CreateURL(ctx, shortCode, originalURL, createdAt, domain.CreateOptions{})
 	require.NoError(t, err)
 
 	// Verify it exists
@@ -204,8 +519,7 @@ func TestRepository_DeleteURL(t *testing.T) {
 
 	// Verify it's gone
 	_, err = repo.GetURL(ctx, shortCode)
-	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "short code not found")
+	assert.ErrorIs(t, err, domain.ErrURLNotFound)
 }
 
 func TestRepository_DeleteURL_NonExistent(t *testing.T) {
@@ -234,7 +548,7 @@ func TestRepository_URLExists(t *testing.T) {
 	assert.False(t, exists)
 
 	// Create URL
-	_, err = repo.CreateURL(ctx, shortCode, originalURL, createdAt)
+	_, err = repo.CreateURL(ctx, shortCode, originalURL, createdAt, domain.CreateOptions{})
 	require.NoError(t, err)
 
 	// Now should exist
@@ -267,11 +581,11 @@ func TestRepository_LoadCacheData(t *testing.T) {
 	now := time.Now().UTC()
 	
 	// URL with no usage
-	_, err = repo.CreateURL(ctx, "test1", "https://example1.com", now)
+	_, err = repo.CreateURL(ctx, "test1", "https://example1.com", now, domain.CreateOptions{})
 	require.NoError(t, err)
 
 	// URL with usage
-	_, err = repo.CreateURL(ctx, "test2", "https://example2.com", now)
+	_, err = repo.CreateURL(ctx, "test2", "https://example2.com", now, domain.CreateOptions{})
 	require.NoError(t, err)
 	err = repo.UpdateUsage(ctx, "test2", 5, now.Add(time.Hour))
 	require.NoError(t, err)
@@ -297,6 +611,88 @@ func TestRepository_LoadCacheData(t *testing.T) {
 	assert.False(t, entry2.Dirty)
 }
 
+func TestRepository_ImportURLs(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().UTC()
+	lastUsed := now.Add(time.Hour)
+
+	imported := []*domain.URLEntry{
+		{ShortCode: "existing", OriginalURL: "https://imported.com", CreatedAt: now, LastUsedAt: &lastUsed, UsageCount: 7},
+		{ShortCode: "fresh", OriginalURL: "https://fresh.com", CreatedAt: now, UsageCount: 3},
+	}
+
+	testCases := []struct {
+		name        string
+		strategy    domain.ConflictStrategy
+		wantErr     string
+		wantResult  *domain.ImportResult
+		wantURL     string
+		wantUsage   int
+		wantCreated bool
+	}{
+		{
+			name:        "skip keeps existing entry",
+			strategy:    domain.ConflictSkip,
+			wantResult:  &domain.ImportResult{Imported: 1, Skipped: 1},
+			wantURL:     "https://original.com",
+			wantUsage:   0,
+			wantCreated: true,
+		},
+		{
+			name:        "overwrite replaces existing entry",
+			strategy:    domain.ConflictOverwrite,
+			wantResult:  &domain.ImportResult{Imported: 1, Overwritten: 1},
+			wantURL:     "https://imported.com",
+			wantUsage:   7,
+			wantCreated: true,
+		},
+		{
+			name:        "fail rolls back the whole import",
+			strategy:    domain.ConflictFail,
+			wantErr:     "already exists",
+			wantURL:     "https://original.com",
+			wantUsage:   0,
+			wantCreated: false,
+		},
+		{
+			name:        "unknown strategy",
+			strategy:    domain.ConflictStrategy("merge"),
+			wantErr:     "unknown conflict strategy",
+			wantURL:     "https://original.com",
+			wantUsage:   0,
+			wantCreated: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := setupTestRepo(t)
+			defer teardownTestRepo(t, repo)
+
+			_, err := repo.CreateURL(ctx, "existing", "https://original.com", now, domain.CreateOptions{})
+			require.NoError(t, err)
+
+			result, err := repo.ImportURLs(ctx, imported, tc.strategy)
+			if tc.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tc.wantErr)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tc.wantResult, result)
+			}
+
+			existing, err := repo.GetURL(ctx, "existing")
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantURL, existing.OriginalURL)
+			assert.Equal(t, tc.wantUsage, existing.UsageCount)
+
+			fresh, err := repo.URLExists(ctx, "fresh")
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantCreated, fresh)
+		})
+	}
+}
+
 func TestRepository_ConcurrentOperations(t *testing.T) {
 	repo := setupTestRepo(t)
 	defer teardownTestRepo(t, repo)
@@ -313,7 +709,7 @@ func TestRepository_ConcurrentOperations(t *testing.T) {
 			originalURL := "https://example" + string(rune('0'+id)) + ".com"
 			createdAt := time.Now().UTC()
 			
-			_, err := repo.CreateURL(ctx, shortCode, originalURL, createdAt)
+			_, err := repo.CreateURL(ctx, shortCode, originalURL, createdAt, domain.CreateOptions{})
 			done <- err
 		}(i)
 	}
@@ -338,13 +734,13 @@ func TestRepository_DatabaseConstraints(t *testing.T) {
 
 	t.Run("empty short code", func(t *testing.T) {
 		// SQLite NOT NULL allows empty strings, only prevents NULL values
-		_, err := repo.CreateURL(ctx, "", "https://example.com", time.Now())
+		_, err := repo.CreateURL(ctx, "", "https://example.com", time.Now(), domain.CreateOptions{})
 		assert.NoError(t, err)
 	})
 
 	t.Run("empty original URL", func(t *testing.T) {
 		// SQLite NOT NULL allows empty strings, only prevents NULL values
-		_, err := repo.CreateURL(ctx, "test123", "", time.Now())
+		_, err := repo.CreateURL(ctx, "test123", "", time.Now(), domain.CreateOptions{})
 		assert.NoError(t, err)
 	})
 }
@@ -353,7 +749,7 @@ func TestRepository_Close(t *testing.T) {
 	dbPath := createTempDB(t)
 	defer os.Remove(dbPath)
 
-	repo, err := New(dbPath)
+	repo, err := New(dbPath, WithDriver(testDriver))
 	require.NoError(t, err)
 
 	// Close repository
@@ -366,6 +762,19 @@ func TestRepository_Close(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestRepository_Ping(t *testing.T) {
+	dbPath := createTempDB(t)
+	defer os.Remove(dbPath)
+
+	repo, err := New(dbPath, WithDriver(testDriver))
+	require.NoError(t, err)
+
+	assert.NoError(t, repo.Ping(context.Background()))
+
+	require.NoError(t, repo.Close())
+	assert.Error(t, repo.Ping(context.Background()))
+}
+
 func TestRepository_ContextCancellation(t *testing.T) {
 	repo := setupTestRepo(t)
 	defer teardownTestRepo(t, repo)
@@ -375,7 +784,7 @@ func TestRepository_ContextCancellation(t *testing.T) {
 	cancel() // Cancel immediately
 
 	// Operations should respect context cancellation
-	_, err := repo.CreateURL(ctx, "test123", "https://example.com", time.Now())
+	_, err := repo.CreateURL(ctx, "test123", "https://example.com", time.Now(), domain.CreateOptions{})
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "context canceled")
 }
@@ -397,7 +806,7 @@ func setupTestRepo(t *testing.T) *Repository {
 		os.Remove(dbPath)
 	})
 
-	repo, err := New(dbPath)
+	repo, err := New(dbPath, WithDriver(testDriver))
 	require.NoError(t, err)
 	
 	return repo
@@ -408,4 +817,161 @@ func teardownTestRepo(t *testing.T, repo *Repository) {
 	if repo != nil {
 		repo.Close()
 	}
-}
\ No newline at end of file
+}
+func TestRepository_UpdateUsage_StaleWriter(t *testing.T) {
+	repo := setupTestRepo(t)
+	defer teardownTestRepo(t, repo)
+
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	_, err := repo.CreateURL(ctx, "test123", "https://example.com", now, domain.CreateOptions{})
+	require.NoError(t, err)
+
+	require.NoError(t, repo.UpdateUsage(ctx, "test123", 7, now.Add(time.Minute)))
+	// A writer holding an older snapshot cannot roll the count or timestamp back
+	require.NoError(t, repo.UpdateUsage(ctx, "test123", 4, now))
+
+	retrieved, err := repo.GetURL(ctx, "test123")
+	require.NoError(t, err)
+	assert.Equal(t, 7, retrieved.UsageCount)
+	require.NotNil(t, retrieved.LastUsedAt)
+	assert.WithinDuration(t, now.Add(time.Minute), *retrieved.LastUsedAt, time.Second)
+}
+
+func TestRepository_UpdateUsageBatch(t *testing.T) {
+	now := time.Now().UTC()
+
+	tests := []struct {
+		name     string
+		strategy domain.UsageMergeStrategy
+		writers  [][]domain.UsageUpdate
+		expected map[string]int
+	}{
+		{
+			name:     "delta merges interleaved writers",
+			strategy: domain.UsageMergeDelta,
+			writers: [][]domain.UsageUpdate{
+				{{ShortCode: "a", UsageCount: 3, Delta: 3, LastUsedAt: now}, {ShortCode: "b", UsageCount: 1, Delta: 1, LastUsedAt: now}},
+				{{ShortCode: "a", UsageCount: 2, Delta: 2, LastUsedAt: now}},
+				{{ShortCode: "a", UsageCount: 4, Delta: 1, LastUsedAt: now}},
+			},
+			expected: map[string]int{"a": 6, "b": 1},
+		},
+		{
+			name:     "max keeps highest count",
+			strategy: domain.UsageMergeMax,
+			writers: [][]domain.UsageUpdate{
+				{{ShortCode: "a", UsageCount: 3, LastUsedAt: now}, {ShortCode: "b", UsageCount: 1, LastUsedAt: now}},
+				{{ShortCode: "a", UsageCount: 2, LastUsedAt: now}},
+				{{ShortCode: "a", UsageCount: 4, LastUsedAt: now}},
+			},
+			expected: map[string]int{"a": 4, "b": 1},
+		},
+		{
+			name:     "deleted codes are skipped",
+			strategy: domain.UsageMergeDelta,
+			writers: [][]domain.UsageUpdate{
+				{{ShortCode: "a", UsageCount: 1, Delta: 1, LastUsedAt: now}, {ShortCode: "gone", UsageCount: 1, Delta: 1, LastUsedAt: now}},
+			},
+			expected: map[string]int{"a": 1, "b": 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := setupTestRepo(t)
+			defer teardownTestRepo(t, repo)
+
+			ctx := context.Background()
+			_, err := repo.CreateURL(ctx, "a", "https://example.com/a", now, domain.CreateOptions{})
+			require.NoError(t, err)
+			_, err = repo.CreateURL(ctx, "b", "https://example.com/b", now, domain.CreateOptions{})
+			require.NoError(t, err)
+
+			for _, updates := range tt.writers {
+				counts, err := repo.UpdateUsageBatch(ctx, updates, tt.strategy)
+				require.NoError(t, err)
+				assert.NotContains(t, counts, "gone")
+			}
+
+			for shortCode, expected := range tt.expected {
+				retrieved, err := repo.GetURL(ctx, shortCode)
+				require.NoError(t, err)
+				assert.Equal(t, expected, retrieved.UsageCount, shortCode)
+			}
+		})
+	}
+}
+
+func TestRepository_UpdateUsageBatch_ReturnsMergedCounts(t *testing.T) {
+	repo := setupTestRepo(t)
+	defer teardownTestRepo(t, repo)
+
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	_, err := repo.CreateURL(ctx, "test123", "https://example.com", now, domain.CreateOptions{})
+	require.NoError(t, err)
+
+	// Another instance has already synced 5 redirects
+	_, err = repo.UpdateUsageBatch(ctx, []domain.UsageUpdate{{ShortCode: "test123", Delta: 5, LastUsedAt: now}}, domain.UsageMergeDelta)
+	require.NoError(t, err)
+
+	counts, err := repo.UpdateUsageBatch(ctx, []domain.UsageUpdate{{ShortCode: "test123", Delta: 2, LastUsedAt: now}}, domain.UsageMergeDelta)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"test123": 7}, counts)
+
+	_, err = repo.UpdateUsageBatch(ctx, nil, "latest")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown usage merge strategy")
+}
+
+func TestRepository_UpdateUsageBatch_LargeBatch(t *testing.T) {
+	repo := setupTestRepo(t)
+	defer teardownTestRepo(t, repo)
+
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Second)
+
+	// Enough codes to span several statements, plus a duplicate and a deleted code
+	total := usageBatchSize*2 + 17
+	updates := make([]domain.UsageUpdate, 0, total+2)
+	for i := 0; i < total; i++ {
+		shortCode := fmt.Sprintf("code%04d", i)
+		_, err := repo.CreateURL(ctx, shortCode, "https://example.com/"+shortCode, now, domain.CreateOptions{})
+		require.NoError(t, err)
+		updates = append(updates, domain.UsageUpdate{ShortCode: shortCode, UsageCount: i, Delta: i, LastUsedAt: now})
+	}
+	updates = append(updates,
+		domain.UsageUpdate{ShortCode: "code0001", UsageCount: 5, Delta: 4, LastUsedAt: now.Add(time.Minute)},
+		domain.UsageUpdate{ShortCode: "gone", UsageCount: 1, Delta: 1, LastUsedAt: now})
+
+	counts, err := repo.UpdateUsageBatch(ctx, updates, domain.UsageMergeDelta)
+	require.NoError(t, err)
+	assert.Len(t, counts, total)
+	assert.NotContains(t, counts, "gone")
+	assert.Equal(t, 5, counts["code0001"], "deltas for the same code add up")
+	assert.Equal(t, total-1, counts[fmt.Sprintf("code%04d", total-1)])
+
+	retrieved, err := repo.GetURL(ctx, "code0001")
+	require.NoError(t, err)
+	require.NotNil(t, retrieved.LastUsedAt)
+	assert.True(t, now.Add(time.Minute).Equal(*retrieved.LastUsedAt), "the latest timestamp wins")
+
+	// A stale max-merge writer cannot move counts or timestamps backwards
+	counts, err = repo.UpdateUsageBatch(ctx, []domain.UsageUpdate{
+		{ShortCode: "code0001", UsageCount: 2, LastUsedAt: now},
+		{ShortCode: "code0002", UsageCount: 9, LastUsedAt: now},
+	}, domain.UsageMergeMax)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"code0001": 5, "code0002": 9}, counts)
+
+	retrieved, err = repo.GetURL(ctx, "code0001")
+	require.NoError(t, err)
+	assert.True(t, now.Add(time.Minute).Equal(*retrieved.LastUsedAt))
+
+	counts, err = repo.UpdateUsageBatch(ctx, nil, domain.UsageMergeDelta)
+	require.NoError(t, err)
+	assert.Empty(t, counts)
+}
@@ -0,0 +1,113 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/joshdurbin/url-shortener/db/sqlc"
+	"github.com/joshdurbin/url-shortener/internal/domain"
+)
+
+// GetRoutingRules retrieves a URL's routing rules in evaluation order
+func (r *Repository) GetRoutingRules(ctx context.Context, shortCode string) ([]domain.RoutingRule, error) {
+	rows, err := r.queries.ListRoutingRules(ctx, shortCode)
+	if err != nil {
+		return nil, domain.Storage(fmt.Errorf("failed to list routing rules: %w", err))
+	}
+
+	rules := make([]domain.RoutingRule, 0, len(rows))
+	for _, row := range rows {
+		rules = append(rules, sqlcRoutingRuleToDomain(row))
+	}
+	return rules, nil
+}
+
+// SetRoutingRules replaces a URL's routing rules in one transaction, or
+// returns domain.ErrURLNotFound. An empty list removes them all.
+func (r *Repository) SetRoutingRules(ctx context.Context, shortCode string, rules []domain.RoutingRule, createdAt time.Time) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return domain.Storage(fmt.Errorf("failed to begin transaction: %w", err))
+	}
+	defer tx.Rollback()
+
+	queries := r.queries.WithTx(tx)
+
+	count, err := queries.URLExists(ctx, shortCode)
+	if err != nil {
+		return domain.Storage(fmt.Errorf("failed to check URL existence: %w", err))
+	}
+	if count == 0 {
+		return domain.ErrURLNotFound
+	}
+
+	if err := queries.DeleteRoutingRules(ctx, shortCode); err != nil {
+		return domain.Storage(fmt.Errorf("failed to delete routing rules: %w", err))
+	}
+
+	for position, rule := range rules {
+		err := queries.CreateRoutingRule(ctx, sqlc.CreateRoutingRuleParams{
+			ShortCode:   shortCode,
+			Position:    int64(position),
+			Countries:   strings.Join(rule.Countries, ","),
+			Devices:     joinDevices(rule.Devices),
+			Languages:   strings.Join(rule.Languages, ","),
+			Destination: rule.Destination,
+			CreatedAt:   createdAt,
+		})
+		if err != nil {
+			return domain.Storage(fmt.Errorf("failed to create routing rule: %w", err))
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return domain.Storage(fmt.Errorf("failed to commit routing rules: %w", err))
+	}
+	return nil
+}
+
+// loadRoutingRules retrieves every URL's routing rules keyed by short code
+func (r *Repository) loadRoutingRules(ctx context.Context) (map[string][]domain.RoutingRule, error) {
+	rows, err := r.queries.ListAllRoutingRules(ctx)
+	if err != nil {
+		return nil, domain.Storage(fmt.Errorf("failed to list routing rules: %w", err))
+	}
+
+	rules := make(map[string][]domain.RoutingRule)
+	for _, row := range rows {
+		rules[row.ShortCode] = append(rules[row.ShortCode], sqlcRoutingRuleToDomain(row))
+	}
+	return rules, nil
+}
+
+// sqlcRoutingRuleToDomain converts a stored routing rule to its domain form
+func sqlcRoutingRuleToDomain(row sqlc.RoutingRule) domain.RoutingRule {
+	rule := domain.RoutingRule{
+		Countries:   splitList(row.Countries),
+		Languages:   splitList(row.Languages),
+		Destination: row.Destination,
+	}
+	for _, device := range splitList(row.Devices) {
+		rule.Devices = append(rule.Devices, domain.DeviceType(device))
+	}
+	return rule
+}
+
+// joinDevices stores device types as a comma-separated list
+func joinDevices(devices []domain.DeviceType) string {
+	names := make([]string, len(devices))
+	for i, device := range devices {
+		names[i] = string(device)
+	}
+	return strings.Join(names, ",")
+}
+
+// splitList reads a comma-separated list, where an empty string is no items
+func splitList(list string) []string {
+	if list == "" {
+		return nil
+	}
+	return strings.Split(list, ",")
+}
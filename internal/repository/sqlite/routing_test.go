@@ -0,0 +1,57 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+)
+
+func TestRepository_RoutingRules(t *testing.T) {
+	repo := setupTestRepo(t)
+	defer teardownTestRepo(t, repo)
+
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	_, err := repo.CreateURL(ctx, "abc123", "https://example.com", now, domain.CreateOptions{})
+	require.NoError(t, err)
+
+	rules, err := repo.GetRoutingRules(ctx, "abc123")
+	require.NoError(t, err)
+	assert.Empty(t, rules)
+
+	want := []domain.RoutingRule{
+		{Countries: []string{"DE", "AT"}, Destination: "https://example.de"},
+		{Devices: []domain.DeviceType{domain.DeviceMobile, domain.DeviceTablet}, Languages: []string{"en"}, Destination: "https://m.example.com"},
+	}
+	require.NoError(t, repo.SetRoutingRules(ctx, "abc123", want, now))
+
+	rules, err = repo.GetRoutingRules(ctx, "abc123")
+	require.NoError(t, err)
+	assert.Equal(t, want, rules)
+
+	data, err := repo.LoadCacheData(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, want, data["abc123"].Routes)
+
+	// Replacing keeps only the new rules, in their new order
+	require.NoError(t, repo.SetRoutingRules(ctx, "abc123", want[1:], now))
+	rules, err = repo.GetRoutingRules(ctx, "abc123")
+	require.NoError(t, err)
+	assert.Equal(t, want[1:], rules)
+
+	assert.ErrorIs(t, repo.SetRoutingRules(ctx, "missing", want, now), domain.ErrURLNotFound)
+
+	// Deleting the link deletes its rules
+	require.NoError(t, repo.DeleteURL(ctx, "abc123"))
+	_, err = repo.CreateURL(ctx, "abc123", "https://example.com", now, domain.CreateOptions{})
+	require.NoError(t, err)
+	rules, err = repo.GetRoutingRules(ctx, "abc123")
+	require.NoError(t, err)
+	assert.Empty(t, rules)
+}
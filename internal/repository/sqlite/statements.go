@@ -0,0 +1,170 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"sync"
+
+	"github.com/joshdurbin/url-shortener/internal/tracing"
+)
+
+// stmtCache is the sqlc.DBTX behind Repository.queries. It prepares each query
+// the first time it runs and reuses the statement afterwards, so hot paths
+// such as GetURL on a redirect cache miss and the usage sync skip SQLite's
+// parse and plan step. database/sql re-prepares a statement on each pooled
+// connection it lands on, at most once per connection.
+//
+// Each query runs in a span named after its sqlc query when the context is
+// traced. Spans of queries returning rows end when the rows are returned, so
+// they leave out the time spent scanning them.
+//
+// A cache without a map runs every query unprepared; the benchmarks use it as
+// their baseline.
+type stmtCache struct {
+	db    *sql.DB
+	mu    sync.RWMutex
+	stmts map[string]*sql.Stmt
+}
+
+func newStmtCache(db *sql.DB) *stmtCache {
+	return &stmtCache{db: db, stmts: make(map[string]*sql.Stmt)}
+}
+
+// prepare returns the cached statement for query, preparing it on first use.
+// The set of queries is fixed (sqlc's constants and the usage merge
+// statements), so the cache needs no eviction.
+func (c *stmtCache) prepare(ctx context.Context, query string) (*sql.Stmt, error) {
+	c.mu.RLock()
+	stmt, ok := c.stmts[query]
+	c.mu.RUnlock()
+	if ok {
+		return stmt, nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if stmt, ok := c.stmts[query]; ok {
+		return stmt, nil
+	}
+	stmt, err := c.db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	c.stmts[query] = stmt
+	return stmt, nil
+}
+
+// cached reports whether query should go through a prepared statement. When
+// preparing fails the query runs unprepared, so the caller gets the driver's
+// error from the usual place.
+func (c *stmtCache) cached(ctx context.Context, query string) (*sql.Stmt, bool) {
+	if c.stmts == nil {
+		return nil, false
+	}
+	stmt, err := c.prepare(ctx, query)
+	return stmt, err == nil
+}
+
+func (c *stmtCache) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	ctx, span := startQuerySpan(ctx, query)
+	defer span.End()
+
+	var result sql.Result
+	var err error
+	if stmt, ok := c.cached(ctx, query); ok {
+		result, err = stmt.ExecContext(ctx, args...)
+	} else {
+		result, err = c.db.ExecContext(ctx, query, args...)
+	}
+	span.RecordError(err)
+	return result, err
+}
+
+func (c *stmtCache) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return c.db.PrepareContext(ctx, query)
+}
+
+func (c *stmtCache) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	ctx, span := startQuerySpan(ctx, query)
+	defer span.End()
+
+	var rows *sql.Rows
+	var err error
+	if stmt, ok := c.cached(ctx, query); ok {
+		rows, err = stmt.QueryContext(ctx, args...)
+	} else {
+		rows, err = c.db.QueryContext(ctx, query, args...)
+	}
+	span.RecordError(err)
+	return rows, err
+}
+
+func (c *stmtCache) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	ctx, span := startQuerySpan(ctx, query)
+	defer span.End()
+
+	var row *sql.Row
+	if stmt, ok := c.cached(ctx, query); ok {
+		row = stmt.QueryRowContext(ctx, args...)
+	} else {
+		row = c.db.QueryRowContext(ctx, query, args...)
+	}
+	if err := row.Err(); err != sql.ErrNoRows {
+		span.RecordError(err)
+	}
+	return row
+}
+
+// queryTx runs query inside tx, through the cached statement when there is one
+func (c *stmtCache) queryTx(ctx context.Context, tx *sql.Tx, query string, args ...interface{}) (*sql.Rows, error) {
+	ctx, span := startQuerySpan(ctx, query)
+	defer span.End()
+
+	var rows *sql.Rows
+	var err error
+	if stmt, ok := c.cached(ctx, query); ok {
+		rows, err = tx.StmtContext(ctx, stmt).QueryContext(ctx, args...)
+	} else {
+		rows, err = tx.QueryContext(ctx, query, args...)
+	}
+	span.RecordError(err)
+	return rows, err
+}
+
+// startQuerySpan starts the span of one query, named db.<sqlc query name>,
+// or after the statement's first keyword for the hand-written queries
+func startQuerySpan(ctx context.Context, query string) (context.Context, *tracing.Span) {
+	if tracing.FromContext(ctx) == nil {
+		return ctx, nil
+	}
+	return tracing.Start(ctx, "db."+queryName(query), tracing.String("db.system", "sqlite"))
+}
+
+// queryName returns the name from a sqlc query's "-- name: GetURL :one"
+// header, or the query's first word
+func queryName(query string) string {
+	if rest, ok := strings.CutPrefix(query, "-- name: "); ok {
+		if name, _, ok := strings.Cut(rest, " "); ok {
+			return name
+		}
+	}
+	if fields := strings.Fields(query); len(fields) > 0 {
+		return strings.ToUpper(fields[0])
+	}
+	return "query"
+}
+
+// Close closes every cached statement
+func (c *stmtCache) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var firstErr error
+	for query, stmt := range c.stmts {
+		if err := stmt.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		delete(c.stmts, query)
+	}
+	return firstErr
+}
@@ -0,0 +1,167 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/joshdurbin/url-shortener/db/sqlc"
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	"github.com/joshdurbin/url-shortener/internal/tracing"
+)
+
+func TestStmtCache(t *testing.T) {
+	repo := setupTestRepo(t)
+	defer teardownTestRepo(t, repo)
+
+	ctx := context.Background()
+	_, err := repo.CreateURL(ctx, "cached", "https://example.com", time.Now().UTC(), domain.CreateOptions{})
+	require.NoError(t, err)
+
+	t.Run("prepares each query once", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			_, err := repo.GetURL(ctx, "cached")
+			require.NoError(t, err)
+		}
+		first, err := repo.stmts.prepare(ctx, "SELECT 1")
+		require.NoError(t, err)
+		second, err := repo.stmts.prepare(ctx, "SELECT 1")
+		require.NoError(t, err)
+		assert.Same(t, first, second)
+	})
+
+	t.Run("full usage chunks share a statement", func(t *testing.T) {
+		updates := []domain.UsageUpdate{{ShortCode: "cached", Delta: 1, LastUsedAt: time.Now().UTC()}}
+		_, err := repo.UpdateUsageBatch(ctx, updates, domain.UsageMergeDelta)
+		require.NoError(t, err)
+		_, err = repo.UpdateUsageBatch(ctx, updates, domain.UsageMergeDelta)
+		require.NoError(t, err)
+
+		repo.stmts.mu.RLock()
+		_, cached := repo.stmts.stmts[bulkUsageQuery(domain.UsageMergeDelta, 1)]
+		repo.stmts.mu.RUnlock()
+		assert.True(t, cached)
+
+		entry, err := repo.GetURL(ctx, "cached")
+		require.NoError(t, err)
+		assert.Equal(t, 2, entry.UsageCount)
+	})
+
+	t.Run("invalid queries surface the driver error", func(t *testing.T) {
+		_, err := repo.stmts.ExecContext(ctx, "UPDATE missing_table SET x = 1")
+		assert.Error(t, err)
+		assert.Error(t, repo.stmts.QueryRowContext(ctx, "SELECT x FROM missing_table").Scan(new(int)))
+	})
+
+	t.Run("close releases every statement", func(t *testing.T) {
+		require.NoError(t, repo.stmts.Close())
+		assert.Empty(t, repo.stmts.stmts)
+
+		// Statements are prepared again on the next use
+		_, err := repo.GetURL(ctx, "cached")
+		require.NoError(t, err)
+	})
+}
+
+// benchmarkRepos runs bench against a repository that reuses prepared
+// statements and one that prepares every query, seeded with codes links
+func benchmarkRepos(b *testing.B, codes int, bench func(b *testing.B, repo *Repository, shortCodes []string)) {
+	for _, prepared := range []bool{false, true} {
+		name := "unprepared"
+		if prepared {
+			name = "prepared"
+		}
+		b.Run(name, func(b *testing.B) {
+			file, err := os.CreateTemp(b.TempDir(), "bench-*.db")
+			require.NoError(b, err)
+			file.Close()
+
+			repo, err := New(file.Name(), WithDriver(testDriver))
+			require.NoError(b, err)
+			defer repo.Close()
+			if !prepared {
+				repo.stmts = &stmtCache{db: repo.db}
+				repo.queries = sqlc.New(repo.stmts)
+			}
+
+			ctx := context.Background()
+			now := time.Now().UTC()
+			shortCodes := make([]string, codes)
+			for i := range shortCodes {
+				shortCodes[i] = fmt.Sprintf("code%05d", i)
+				_, err := repo.CreateURL(ctx, shortCodes[i], "https://example.com/"+shortCodes[i], now, domain.CreateOptions{})
+				require.NoError(b, err)
+			}
+
+			b.ResetTimer()
+			bench(b, repo, shortCodes)
+		})
+	}
+}
+
+// BenchmarkRepository_GetURL is the database side of a redirect cache miss
+func BenchmarkRepository_GetURL(b *testing.B) {
+	benchmarkRepos(b, 1000, func(b *testing.B, repo *Repository, shortCodes []string) {
+		ctx := context.Background()
+		b.RunParallel(func(pb *testing.PB) {
+			i := 0
+			for pb.Next() {
+				if _, err := repo.GetURL(ctx, shortCodes[i%len(shortCodes)]); err != nil {
+					b.Fatal(err)
+				}
+				i++
+			}
+		})
+	})
+}
+
+func BenchmarkRepository_UpdateUsage(b *testing.B) {
+	benchmarkRepos(b, 1000, func(b *testing.B, repo *Repository, shortCodes []string) {
+		ctx := context.Background()
+		now := time.Now().UTC()
+		for i := 0; i < b.N; i++ {
+			if err := repo.UpdateUsage(ctx, shortCodes[i%len(shortCodes)], i, now); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+// BenchmarkRepository_UpdateUsageBatch measures cache sync throughput
+func BenchmarkRepository_UpdateUsageBatch(b *testing.B) {
+	benchmarkRepos(b, 5000, func(b *testing.B, repo *Repository, shortCodes []string) {
+		ctx := context.Background()
+		now := time.Now().UTC()
+		updates := make([]domain.UsageUpdate, len(shortCodes))
+		for i, shortCode := range shortCodes {
+			updates[i] = domain.UsageUpdate{ShortCode: shortCode, Delta: 1, LastUsedAt: now}
+		}
+		for i := 0; i < b.N; i++ {
+			if _, err := repo.UpdateUsageBatch(ctx, updates, domain.UsageMergeDelta); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func TestQueryName(t *testing.T) {
+	tests := map[string]string{
+		"-- name: GetURL :one\nSELECT id FROM urls WHERE short_code = ?":          "GetURL",
+		"-- name: DeleteExportEventsThrough :execrows\nDELETE FROM export_outbox": "DeleteExportEventsThrough",
+		"\n\tupdate urls SET usage_count = ? WHERE short_code = ?":                "UPDATE",
+		"": "query",
+	}
+	for query, expected := range tests {
+		assert.Equal(t, expected, queryName(query), query)
+	}
+
+	// Untraced queries start no span
+	ctx, span := startQuerySpan(context.Background(), "-- name: GetURL :one")
+	assert.Nil(t, span)
+	assert.Nil(t, tracing.FromContext(ctx))
+}
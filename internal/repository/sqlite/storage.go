@@ -0,0 +1,99 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	"github.com/joshdurbin/url-shortener/internal/repository"
+)
+
+// StorageStats measures the database size, row counts and clicks, counting
+// links created since the given time
+func (r *Repository) StorageStats(ctx context.Context, since time.Time) (*domain.StorageStats, error) {
+	size, err := r.databaseBytes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tables, err := r.tableNames(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make(map[string]int64, len(tables))
+	for _, table := range tables {
+		var count int64
+		query := `SELECT COUNT(*) FROM "` + strings.ReplaceAll(table, `"`, `""`) + `"`
+		if err := r.db.QueryRowContext(ctx, query).Scan(&count); err != nil {
+			return nil, fmt.Errorf("failed to count rows in %s: %w", table, err)
+		}
+		rows[table] = count
+	}
+
+	clicks, err := r.queries.SumUsage(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sum usage: %w", err)
+	}
+
+	created, err := r.queries.CountURLsCreatedSince(ctx, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count recent URLs: %w", err)
+	}
+
+	return &domain.StorageStats{
+		DatabaseBytes: size,
+		TableRows:     rows,
+		TotalClicks:   clicks,
+		LinksCreated:  created,
+	}, nil
+}
+
+// databaseBytes returns the size of the database file and its write-ahead
+// log, or the allocated pages when there is no file (in-memory databases)
+func (r *Repository) databaseBytes(ctx context.Context) (int64, error) {
+	if info, err := os.Stat(r.path); err == nil {
+		size := info.Size()
+		if wal, err := os.Stat(r.path + "-wal"); err == nil {
+			size += wal.Size()
+		}
+		return size, nil
+	}
+
+	var pageCount, pageSize int64
+	if err := r.db.QueryRowContext(ctx, "PRAGMA page_count").Scan(&pageCount); err != nil {
+		return 0, fmt.Errorf("failed to read page count: %w", err)
+	}
+	if err := r.db.QueryRowContext(ctx, "PRAGMA page_size").Scan(&pageSize); err != nil {
+		return 0, fmt.Errorf("failed to read page size: %w", err)
+	}
+	return pageCount * pageSize, nil
+}
+
+// tableNames lists the user tables in the database
+func (r *Repository) tableNames(ctx context.Context) ([]string, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' ORDER BY name")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tables: %w", err)
+	}
+	defer rows.Close()
+
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("failed to list tables: %w", err)
+		}
+		names = append(names, name)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list tables: %w", err)
+	}
+	return names, nil
+}
+
+// Ensure Repository implements the interface
+var _ repository.StorageRepository = (*Repository)(nil)
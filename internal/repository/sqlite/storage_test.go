@@ -0,0 +1,36 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+)
+
+func TestRepository_StorageStats(t *testing.T) {
+	repo := setupTestRepo(t)
+	defer teardownTestRepo(t, repo)
+
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	_, err := repo.CreateURL(ctx, "old123", "https://example.com/old", now.Add(-60*24*time.Hour), domain.CreateOptions{})
+	require.NoError(t, err)
+	_, err = repo.CreateURL(ctx, "new123", "https://example.com/new", now.Add(-time.Hour), domain.CreateOptions{})
+	require.NoError(t, err)
+	require.NoError(t, repo.UpdateUsage(ctx, "old123", 7, now))
+	require.NoError(t, repo.UpdateUsage(ctx, "new123", 2, now))
+
+	stats, err := repo.StorageStats(ctx, now.Add(-30*24*time.Hour))
+	require.NoError(t, err)
+	assert.Positive(t, stats.DatabaseBytes)
+	assert.Equal(t, int64(2), stats.TableRows["urls"])
+	assert.Contains(t, stats.TableRows, "webhook_endpoints")
+	assert.Contains(t, stats.TableRows, "lifecycle_policies")
+	assert.Equal(t, int64(9), stats.TotalClicks)
+	assert.Equal(t, int64(1), stats.LinksCreated)
+}
@@ -0,0 +1,92 @@
+package sqlite
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Tuning holds the SQLite pragmas every pooled connection is opened with and
+// the limits of the connection pool
+type Tuning struct {
+	BusyTimeout  time.Duration // How long a connection waits for another connection's lock before SQLITE_BUSY
+	CacheSizeKiB int           // Page cache per connection in KiB
+	Synchronous  string        // OFF, NORMAL, FULL or EXTRA; NORMAL is durable across crashes in WAL mode
+	MaxOpenConns int           // Upper bound on open connections; 0 is unlimited
+	MaxIdleConns int           // Connections kept open between queries
+}
+
+// DefaultTuning returns settings suited to WAL mode with concurrent writers:
+// writers queue on the busy timeout instead of failing, and the pool is small
+// enough that they rarely have to
+func DefaultTuning() Tuning {
+	return Tuning{
+		BusyTimeout:  5 * time.Second,
+		CacheSizeKiB: 8192,
+		Synchronous:  "NORMAL",
+		MaxOpenConns: 8,
+		MaxIdleConns: 8,
+	}
+}
+
+// synchronousModes are the accepted values of the synchronous pragma
+var synchronousModes = []string{"OFF", "NORMAL", "FULL", "EXTRA"}
+
+// Validate checks that the settings are usable
+func (t Tuning) Validate() error {
+	if t.BusyTimeout < 0 {
+		return fmt.Errorf("busy timeout cannot be negative, got: %v", t.BusyTimeout)
+	}
+	if t.CacheSizeKiB < 0 {
+		return fmt.Errorf("cache size cannot be negative, got: %d", t.CacheSizeKiB)
+	}
+	if !validSynchronous(t.Synchronous) {
+		return fmt.Errorf("synchronous must be one of %v, got: %q", synchronousModes, t.Synchronous)
+	}
+	if t.MaxOpenConns < 0 || t.MaxIdleConns < 0 {
+		return fmt.Errorf("connection limits cannot be negative, got: %d open and %d idle", t.MaxOpenConns, t.MaxIdleConns)
+	}
+	if t.MaxOpenConns > 0 && t.MaxIdleConns > t.MaxOpenConns {
+		return fmt.Errorf("max idle connections (%d) cannot exceed max open connections (%d)", t.MaxIdleConns, t.MaxOpenConns)
+	}
+	return nil
+}
+
+func validSynchronous(mode string) bool {
+	for _, valid := range synchronousModes {
+		if strings.EqualFold(mode, valid) {
+			return true
+		}
+	}
+	return false
+}
+
+// pragma is a setting applied to every new connection
+type pragma struct {
+	name  string
+	value string
+}
+
+// pragmas lists the settings each connection needs. They are passed through
+// the data source name because a PRAGMA statement executed on the pool only
+// reaches whichever connection happened to run it.
+func (t Tuning) pragmas() []pragma {
+	return []pragma{
+		{"busy_timeout", strconv.FormatInt(t.BusyTimeout.Milliseconds(), 10)},
+		{"foreign_keys", "1"},
+		{"journal_mode", "WAL"},
+		{"synchronous", strings.ToUpper(t.Synchronous)},
+		// A negative cache_size is in KiB rather than pages
+		{"cache_size", strconv.Itoa(-t.CacheSizeKiB)},
+	}
+}
+
+// withQuery appends query parameters to a database path that may already have some
+func withQuery(path string, params []string) string {
+	separator := "?"
+	if strings.Contains(path, "?") {
+		separator = "&"
+	}
+	return path + separator + strings.Join(params, "&")
+}
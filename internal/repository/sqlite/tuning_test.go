@@ -0,0 +1,88 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTuning_Validate(t *testing.T) {
+	require.NoError(t, DefaultTuning().Validate())
+
+	tests := []struct {
+		name   string
+		modify func(*Tuning)
+		errMsg string
+	}{
+		{"negative busy timeout", func(c *Tuning) { c.BusyTimeout = -time.Second }, "busy timeout cannot be negative"},
+		{"negative cache size", func(c *Tuning) { c.CacheSizeKiB = -1 }, "cache size cannot be negative"},
+		{"unknown synchronous mode", func(c *Tuning) { c.Synchronous = "sometimes" }, "synchronous must be one of"},
+		{"negative connection limit", func(c *Tuning) { c.MaxOpenConns = -1 }, "connection limits cannot be negative"},
+		{"more idle than open", func(c *Tuning) { c.MaxOpenConns, c.MaxIdleConns = 2, 4 }, "cannot exceed max open connections"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tuning := DefaultTuning()
+			tt.modify(&tuning)
+			assert.ErrorContains(t, tuning.Validate(), tt.errMsg)
+		})
+	}
+
+	tuning := DefaultTuning()
+	tuning.Synchronous = "full"
+	tuning.MaxOpenConns = 0
+	assert.NoError(t, tuning.Validate())
+}
+
+func TestNew_Tuning(t *testing.T) {
+	tuning := Tuning{
+		BusyTimeout:  1500 * time.Millisecond,
+		CacheSizeKiB: 4096,
+		Synchronous:  "full",
+		MaxOpenConns: 3,
+		MaxIdleConns: 2,
+	}
+	repo, err := New(createTempDB(t), WithDriver(testDriver), WithTuning(tuning))
+	require.NoError(t, err)
+	defer repo.Close()
+
+	assert.Equal(t, 3, repo.db.Stats().MaxOpenConnections)
+
+	// Hold several connections at once so each one is checked, not just the
+	// one that ran the migrations
+	ctx := context.Background()
+	conns := make([]*sql.Conn, 0, tuning.MaxOpenConns)
+	for i := 0; i < tuning.MaxOpenConns; i++ {
+		conn, err := repo.db.Conn(ctx)
+		require.NoError(t, err)
+		defer conn.Close()
+		conns = append(conns, conn)
+	}
+
+	for _, conn := range conns {
+		var busyTimeout, cacheSize, synchronous, foreignKeys int
+		var journalMode string
+		require.NoError(t, conn.QueryRowContext(ctx, "PRAGMA busy_timeout").Scan(&busyTimeout))
+		require.NoError(t, conn.QueryRowContext(ctx, "PRAGMA cache_size").Scan(&cacheSize))
+		require.NoError(t, conn.QueryRowContext(ctx, "PRAGMA synchronous").Scan(&synchronous))
+		require.NoError(t, conn.QueryRowContext(ctx, "PRAGMA foreign_keys").Scan(&foreignKeys))
+		require.NoError(t, conn.QueryRowContext(ctx, "PRAGMA journal_mode").Scan(&journalMode))
+
+		assert.Equal(t, 1500, busyTimeout)
+		assert.Equal(t, -4096, cacheSize)
+		assert.Equal(t, 2, synchronous) // FULL
+		assert.Equal(t, 1, foreignKeys)
+		assert.Equal(t, "wal", journalMode)
+	}
+}
+
+func TestNew_InvalidTuning(t *testing.T) {
+	tuning := DefaultTuning()
+	tuning.Synchronous = "sometimes"
+	_, err := New(createTempDB(t), WithDriver(testDriver), WithTuning(tuning))
+	assert.ErrorContains(t, err, "invalid database tuning")
+}
@@ -0,0 +1,90 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+)
+
+// usageBatchSize is the number of rows merged per UPDATE statement. Each row
+// binds four parameters, keeping statements well under SQLite's variable limit.
+const usageBatchSize = 300
+
+// usageMergeSets holds the SET clause for each merge strategy. They match the
+// UpdateUsage and AddUsage queries, with "u" holding the incoming values.
+var usageMergeSets = map[domain.UsageMergeStrategy]string{
+	// Max-count wins: a stale writer can never move the count or timestamp backwards
+	domain.UsageMergeMax: `usage_count = MAX(COALESCE(urls.usage_count, 0), u.value)`,
+	// Delta merge: concurrent writers each add the redirects they counted since their last sync
+	domain.UsageMergeDelta: `usage_count = COALESCE(urls.usage_count, 0) + u.value`,
+}
+
+// bulkUsageQuery builds a single UPDATE ... FROM statement merging the usage of
+// rows short codes, bound as a VALUES list of (short_code, value, bots,
+// last_used_at). Bot counts are always added, whatever the strategy.
+func bulkUsageQuery(strategy domain.UsageMergeStrategy, rows int) string {
+	values := strings.TrimSuffix(strings.Repeat("(?, ?, ?, ?), ", rows), ", ")
+	return `WITH u(short_code, value, bots, last_used_at) AS (VALUES ` + values + `)
+UPDATE urls
+SET ` + usageMergeSets[strategy] + `,
+    bots_count = urls.bots_count + u.bots,
+    last_used_at = MAX(COALESCE(urls.last_used_at, u.last_used_at), u.last_used_at)
+FROM u
+WHERE urls.short_code = u.short_code
+RETURNING urls.short_code, urls.usage_count`
+}
+
+// mergeUsageUpdates folds updates for the same short code into one, so a code
+// appears once per statement. Deltas add up; counts and timestamps keep the highest.
+func mergeUsageUpdates(updates []domain.UsageUpdate) []domain.UsageUpdate {
+	merged := make([]domain.UsageUpdate, 0, len(updates))
+	index := make(map[string]int, len(updates))
+	for _, update := range updates {
+		i, seen := index[update.ShortCode]
+		if !seen {
+			index[update.ShortCode] = len(merged)
+			merged = append(merged, update)
+			continue
+		}
+		existing := &merged[i]
+		existing.Delta += update.Delta
+		existing.BotsDelta += update.BotsDelta
+		existing.UsageCount = max(existing.UsageCount, update.UsageCount)
+		if update.LastUsedAt.After(existing.LastUsedAt) {
+			existing.LastUsedAt = update.LastUsedAt
+		}
+	}
+	return merged
+}
+
+// execUsageChunk merges one chunk of updates with a single statement and
+// records the stored count of every code that still exists. Full chunks all
+// share one cached statement per strategy.
+func execUsageChunk(ctx context.Context, stmts *stmtCache, tx *sql.Tx, strategy domain.UsageMergeStrategy, chunk []domain.UsageUpdate, counts map[string]int) error {
+	args := make([]interface{}, 0, len(chunk)*4)
+	for _, update := range chunk {
+		value := update.Delta
+		if strategy == domain.UsageMergeMax {
+			value = update.UsageCount
+		}
+		args = append(args, update.ShortCode, int64(value), int64(update.BotsDelta), update.LastUsedAt)
+	}
+
+	rows, err := stmts.queryTx(ctx, tx, bulkUsageQuery(strategy, len(chunk)), args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var shortCode string
+		var stored sql.NullInt64
+		if err := rows.Scan(&shortCode, &stored); err != nil {
+			return err
+		}
+		counts[shortCode] = int(stored.Int64)
+	}
+	return rows.Err()
+}
@@ -0,0 +1,79 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/joshdurbin/url-shortener/db/sqlc"
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	"github.com/joshdurbin/url-shortener/internal/repository"
+)
+
+// SetLinkVerification stores a link's latest check, replacing the one before;
+// a check of a link that no longer exists is dropped
+func (r *Repository) SetLinkVerification(ctx context.Context, verification domain.LinkVerification) error {
+	err := r.queries.SetLinkVerification(ctx, sqlc.SetLinkVerificationParams{
+		ShortCode:  verification.ShortCode,
+		Url:        verification.URL,
+		Reachable:  verification.Reachable,
+		StatusCode: int64(verification.StatusCode),
+		Error:      verification.Error,
+		CheckedAt:  verification.CheckedAt,
+	})
+	if err != nil {
+		return domain.Storage(fmt.Errorf("failed to store link verification: %w", err))
+	}
+	return nil
+}
+
+// GetLinkVerification retrieves a link's latest check, failing with
+// domain.ErrVerificationNotFound if it was never checked
+func (r *Repository) GetLinkVerification(ctx context.Context, shortCode string) (*domain.LinkVerification, error) {
+	row, err := r.queries.GetLinkVerification(ctx, shortCode)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrVerificationNotFound
+	}
+	if err != nil {
+		return nil, domain.Storage(fmt.Errorf("failed to get link verification: %w", err))
+	}
+	verification := toDomainVerification(row)
+	return &verification, nil
+}
+
+// ListLinkVerifications retrieves the latest check of every checked link,
+// newest first, or only of the unreachable ones
+func (r *Repository) ListLinkVerifications(ctx context.Context, unreachableOnly bool) ([]domain.LinkVerification, error) {
+	var rows []sqlc.LinkVerification
+	var err error
+	if unreachableOnly {
+		rows, err = r.queries.ListUnreachableLinkVerifications(ctx)
+	} else {
+		rows, err = r.queries.ListLinkVerifications(ctx)
+	}
+	if err != nil {
+		return nil, domain.Storage(fmt.Errorf("failed to list link verifications: %w", err))
+	}
+
+	verifications := make([]domain.LinkVerification, len(rows))
+	for i, row := range rows {
+		verifications[i] = toDomainVerification(row)
+	}
+	return verifications, nil
+}
+
+// toDomainVerification converts a stored check to its domain form
+func toDomainVerification(row sqlc.LinkVerification) domain.LinkVerification {
+	return domain.LinkVerification{
+		ShortCode:  row.ShortCode,
+		URL:        row.Url,
+		Reachable:  row.Reachable,
+		StatusCode: int(row.StatusCode),
+		Error:      row.Error,
+		CheckedAt:  row.CheckedAt,
+	}
+}
+
+// Ensure Repository implements the interface
+var _ repository.VerificationRepository = (*Repository)(nil)
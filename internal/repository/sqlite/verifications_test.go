@@ -0,0 +1,70 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+)
+
+func TestRepository_LinkVerifications(t *testing.T) {
+	repo := setupTestRepo(t)
+	defer teardownTestRepo(t, repo)
+
+	ctx := context.Background()
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	for _, code := range []string{"up", "down"} {
+		_, err := repo.CreateURL(ctx, code, "https://"+code+".example.com", now, domain.CreateOptions{})
+		require.NoError(t, err)
+	}
+
+	verifications, err := repo.ListLinkVerifications(ctx, false)
+	require.NoError(t, err)
+	assert.Empty(t, verifications)
+
+	up := domain.LinkVerification{ShortCode: "up", URL: "https://up.example.com", Reachable: true, StatusCode: 200, CheckedAt: now}
+	down := domain.LinkVerification{ShortCode: "down", URL: "https://down.example.com", Reachable: true, StatusCode: 200, CheckedAt: now}
+	require.NoError(t, repo.SetLinkVerification(ctx, up))
+	require.NoError(t, repo.SetLinkVerification(ctx, down))
+
+	// A later check replaces the earlier one
+	down = domain.LinkVerification{ShortCode: "down", URL: "https://down.example.com", StatusCode: 503, Error: "status 503", CheckedAt: now.Add(time.Hour)}
+	require.NoError(t, repo.SetLinkVerification(ctx, down))
+
+	verifications, err = repo.ListLinkVerifications(ctx, false)
+	require.NoError(t, err)
+	require.Len(t, verifications, 2)
+	assert.Equal(t, "down", verifications[0].ShortCode)
+	assert.True(t, down.CheckedAt.Equal(verifications[0].CheckedAt))
+	assert.Equal(t, 503, verifications[0].StatusCode)
+	assert.Equal(t, "status 503", verifications[0].Error)
+	assert.False(t, verifications[0].Reachable)
+	assert.Equal(t, "up", verifications[1].ShortCode)
+
+	verifications, err = repo.ListLinkVerifications(ctx, true)
+	require.NoError(t, err)
+	require.Len(t, verifications, 1)
+	assert.Equal(t, "down", verifications[0].ShortCode)
+
+	verification, err := repo.GetLinkVerification(ctx, "up")
+	require.NoError(t, err)
+	assert.True(t, verification.Reachable)
+	assert.Equal(t, "https://up.example.com", verification.URL)
+
+	// Checks of missing links are dropped, and deleting a link drops its check
+	require.NoError(t, repo.SetLinkVerification(ctx, domain.LinkVerification{ShortCode: "gone", URL: "https://gone.example.com", CheckedAt: now}))
+	require.NoError(t, repo.DeleteURL(ctx, "down"))
+
+	verifications, err = repo.ListLinkVerifications(ctx, false)
+	require.NoError(t, err)
+	require.Len(t, verifications, 1)
+	assert.Equal(t, "up", verifications[0].ShortCode)
+
+	_, err = repo.GetLinkVerification(ctx, "down")
+	assert.ErrorIs(t, err, domain.ErrVerificationNotFound)
+}
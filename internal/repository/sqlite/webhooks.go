@@ -0,0 +1,168 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/joshdurbin/url-shortener/db/sqlc"
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	"github.com/joshdurbin/url-shortener/internal/repository"
+)
+
+// CreateWebhookEndpoint stores a new webhook endpoint
+func (r *Repository) CreateWebhookEndpoint(ctx context.Context, endpoint *domain.WebhookEndpoint) (*domain.WebhookEndpoint, error) {
+	row, err := r.queries.CreateWebhookEndpoint(ctx, sqlc.CreateWebhookEndpointParams{
+		Url:       endpoint.URL,
+		Secret:    endpoint.Secret,
+		Events:    joinEventTypes(endpoint.Events),
+		CreatedAt: endpoint.CreatedAt,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create webhook endpoint: %w", err)
+	}
+
+	return sqlcWebhookEndpointToDomain(row), nil
+}
+
+// ListWebhookEndpoints retrieves all stored webhook endpoints, including their secrets
+func (r *Repository) ListWebhookEndpoints(ctx context.Context) ([]*domain.WebhookEndpoint, error) {
+	rows, err := r.queries.ListWebhookEndpoints(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook endpoints: %w", err)
+	}
+
+	endpoints := make([]*domain.WebhookEndpoint, len(rows))
+	for i, row := range rows {
+		endpoints[i] = sqlcWebhookEndpointToDomain(row)
+	}
+
+	return endpoints, nil
+}
+
+// DeleteWebhookEndpoint removes a webhook endpoint and its delivery log
+func (r *Repository) DeleteWebhookEndpoint(ctx context.Context, id int64) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	queries := r.queries.WithTx(tx)
+
+	// foreign_keys is set per connection, so don't rely on the cascade
+	if err := queries.DeleteWebhookDeliveriesByEndpoint(ctx, sql.NullInt64{Int64: id, Valid: true}); err != nil {
+		return fmt.Errorf("failed to delete webhook deliveries: %w", err)
+	}
+
+	deleted, err := queries.DeleteWebhookEndpoint(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook endpoint: %w", err)
+	}
+	if deleted == 0 {
+		return domain.ErrWebhookNotFound
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit webhook deletion: %w", err)
+	}
+	return nil
+}
+
+// RecordWebhookDelivery appends a delivery attempt to the log
+func (r *Repository) RecordWebhookDelivery(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	err := r.queries.CreateWebhookDelivery(ctx, sqlc.CreateWebhookDeliveryParams{
+		EndpointID:  sql.NullInt64{Int64: delivery.EndpointID, Valid: delivery.EndpointID > 0},
+		EndpointUrl: delivery.EndpointURL,
+		EventID:     delivery.EventID,
+		EventType:   string(delivery.EventType),
+		Attempt:     int64(delivery.Attempt),
+		StatusCode:  sql.NullInt64{Int64: int64(delivery.StatusCode), Valid: delivery.StatusCode > 0},
+		Error:       sql.NullString{String: delivery.Error, Valid: delivery.Error != ""},
+		Success:     delivery.Success,
+		DurationMs:  delivery.DurationMs,
+		CreatedAt:   delivery.CreatedAt,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record webhook delivery: %w", err)
+	}
+	return nil
+}
+
+// ListWebhookDeliveries retrieves the most recent delivery attempts, optionally for one endpoint (0 for all)
+func (r *Repository) ListWebhookDeliveries(ctx context.Context, endpointID int64, limit int) ([]*domain.WebhookDelivery, error) {
+	var (
+		rows []sqlc.WebhookDelivery
+		err  error
+	)
+	if endpointID > 0 {
+		rows, err = r.queries.ListWebhookDeliveriesByEndpoint(ctx, sqlc.ListWebhookDeliveriesByEndpointParams{
+			EndpointID: sql.NullInt64{Int64: endpointID, Valid: true},
+			Limit:      int64(limit),
+		})
+	} else {
+		rows, err = r.queries.ListWebhookDeliveries(ctx, int64(limit))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook deliveries: %w", err)
+	}
+
+	deliveries := make([]*domain.WebhookDelivery, len(rows))
+	for i, row := range rows {
+		deliveries[i] = &domain.WebhookDelivery{
+			ID:          row.ID,
+			EndpointID:  row.EndpointID.Int64,
+			EndpointURL: row.EndpointUrl,
+			EventID:     row.EventID,
+			EventType:   domain.EventType(row.EventType),
+			Attempt:     int(row.Attempt),
+			StatusCode:  int(row.StatusCode.Int64),
+			Error:       row.Error.String,
+			Success:     row.Success,
+			DurationMs:  row.DurationMs,
+			CreatedAt:   row.CreatedAt,
+		}
+	}
+
+	return deliveries, nil
+}
+
+// PruneWebhookDeliveries removes delivery attempts older than the given time
+func (r *Repository) PruneWebhookDeliveries(ctx context.Context, before time.Time) (int64, error) {
+	pruned, err := r.queries.DeleteWebhookDeliveriesBefore(ctx, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune webhook deliveries: %w", err)
+	}
+	return pruned, nil
+}
+
+// sqlcWebhookEndpointToDomain converts a sqlc.WebhookEndpoint to domain.WebhookEndpoint
+func sqlcWebhookEndpointToDomain(row sqlc.WebhookEndpoint) *domain.WebhookEndpoint {
+	endpoint := &domain.WebhookEndpoint{
+		ID:        row.ID,
+		URL:       row.Url,
+		Secret:    row.Secret,
+		Events:    []domain.EventType{},
+		CreatedAt: row.CreatedAt,
+	}
+	if row.Events != "" {
+		for _, eventType := range strings.Split(row.Events, ",") {
+			endpoint.Events = append(endpoint.Events, domain.EventType(eventType))
+		}
+	}
+	return endpoint
+}
+
+// joinEventTypes stores an endpoint's subscriptions as a comma-separated list
+func joinEventTypes(eventTypes []domain.EventType) string {
+	names := make([]string, len(eventTypes))
+	for i, eventType := range eventTypes {
+		names[i] = string(eventType)
+	}
+	return strings.Join(names, ",")
+}
+
+// Ensure Repository implements the interface
+var _ repository.WebhookRepository = (*Repository)(nil)
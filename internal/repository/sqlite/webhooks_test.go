@@ -0,0 +1,101 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+)
+
+func TestRepository_WebhookEndpoints(t *testing.T) {
+	repo := setupTestRepo(t)
+	defer teardownTestRepo(t, repo)
+
+	ctx := context.Background()
+
+	created, err := repo.CreateWebhookEndpoint(ctx, &domain.WebhookEndpoint{
+		URL:       "https://example.com/hook",
+		Secret:    "s3cret",
+		Events:    []domain.EventType{domain.EventURLCreated, domain.EventURLExpired},
+		CreatedAt: time.Now().UTC(),
+	})
+	require.NoError(t, err)
+	assert.NotZero(t, created.ID)
+
+	_, err = repo.CreateWebhookEndpoint(ctx, &domain.WebhookEndpoint{
+		URL:       "https://example.com/all",
+		Secret:    "other",
+		CreatedAt: time.Now().UTC(),
+	})
+	require.NoError(t, err)
+
+	endpoints, err := repo.ListWebhookEndpoints(ctx)
+	require.NoError(t, err)
+	require.Len(t, endpoints, 2)
+	assert.Equal(t, "s3cret", endpoints[0].Secret)
+	assert.Equal(t, []domain.EventType{domain.EventURLCreated, domain.EventURLExpired}, endpoints[0].Events)
+	assert.Empty(t, endpoints[1].Events)
+
+	require.NoError(t, repo.DeleteWebhookEndpoint(ctx, created.ID))
+	assert.ErrorIs(t, repo.DeleteWebhookEndpoint(ctx, created.ID), domain.ErrWebhookNotFound)
+
+	endpoints, err = repo.ListWebhookEndpoints(ctx)
+	require.NoError(t, err)
+	assert.Len(t, endpoints, 1)
+}
+
+func TestRepository_WebhookDeliveries(t *testing.T) {
+	repo := setupTestRepo(t)
+	defer teardownTestRepo(t, repo)
+
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	endpoint, err := repo.CreateWebhookEndpoint(ctx, &domain.WebhookEndpoint{
+		URL:       "https://example.com/hook",
+		Secret:    "s3cret",
+		CreatedAt: now,
+	})
+	require.NoError(t, err)
+
+	deliveries := []*domain.WebhookDelivery{
+		{EndpointID: endpoint.ID, EndpointURL: endpoint.URL, EventID: "evt_1", EventType: domain.EventURLCreated,
+			Attempt: 1, StatusCode: 500, Error: "Internal Server Error", DurationMs: 12, CreatedAt: now.Add(-48 * time.Hour)},
+		{EndpointID: endpoint.ID, EndpointURL: endpoint.URL, EventID: "evt_1", EventType: domain.EventURLCreated,
+			Attempt: 2, StatusCode: 200, Success: true, DurationMs: 8, CreatedAt: now},
+		// Endpoints from the config file have no ID
+		{EndpointURL: "https://static.example.com", EventID: "evt_2", EventType: domain.EventURLDeleted,
+			Attempt: 1, Error: "connection refused", CreatedAt: now},
+	}
+	for _, delivery := range deliveries {
+		require.NoError(t, repo.RecordWebhookDelivery(ctx, delivery))
+	}
+
+	all, err := repo.ListWebhookDeliveries(ctx, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, all, 3)
+	assert.Equal(t, "https://static.example.com", all[0].EndpointURL)
+	assert.Zero(t, all[0].EndpointID)
+	assert.Zero(t, all[0].StatusCode)
+
+	forEndpoint, err := repo.ListWebhookDeliveries(ctx, endpoint.ID, 1)
+	require.NoError(t, err)
+	require.Len(t, forEndpoint, 1)
+	assert.Equal(t, 2, forEndpoint[0].Attempt)
+	assert.True(t, forEndpoint[0].Success)
+
+	pruned, err := repo.PruneWebhookDeliveries(ctx, now.Add(-24*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), pruned)
+
+	// Deleting the endpoint removes its log but keeps config file deliveries
+	require.NoError(t, repo.DeleteWebhookEndpoint(ctx, endpoint.ID))
+	all, err = repo.ListWebhookDeliveries(ctx, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, all, 1)
+	assert.Equal(t, "evt_2", all[0].EventID)
+}
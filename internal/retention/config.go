@@ -0,0 +1,39 @@
+package retention
+
+import (
+	"fmt"
+	"time"
+)
+
+// Config holds data retention configuration
+type Config struct {
+	ClickRetention   time.Duration // How long per-minute and per-hour clicks are kept; 0 keeps them forever
+	AddressRetention time.Duration // How long visitor addresses are remembered in memory; 0 until they expire on their own
+	Interval         time.Duration // How often the retention periods are enforced
+}
+
+// DefaultConfig returns the default configuration, which keeps everything
+func DefaultConfig() Config {
+	return Config{
+		Interval: time.Hour,
+	}
+}
+
+// Enabled reports whether any retention period is enforced
+func (c Config) Enabled() bool {
+	return c.ClickRetention > 0 || c.AddressRetention > 0
+}
+
+// Validate checks that the configuration values are usable
+func (c Config) Validate() error {
+	if c.ClickRetention < 0 || (c.ClickRetention > 0 && c.ClickRetention < 24*time.Hour) {
+		return fmt.Errorf("click retention must be 0 or at least 24h, got: %v", c.ClickRetention)
+	}
+	if c.AddressRetention < 0 {
+		return fmt.Errorf("address retention cannot be negative, got: %v", c.AddressRetention)
+	}
+	if c.Enabled() && c.Interval <= 0 {
+		return fmt.Errorf("interval must be positive, got: %v", c.Interval)
+	}
+	return nil
+}
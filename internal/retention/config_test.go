@@ -0,0 +1,32 @@
+package retention
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfig_Validate(t *testing.T) {
+	assert.NoError(t, DefaultConfig().Validate())
+	assert.False(t, DefaultConfig().Enabled(), "everything is kept by default")
+	assert.NoError(t, Config{ClickRetention: 365 * 24 * time.Hour, AddressRetention: 30 * 24 * time.Hour, Interval: time.Hour}.Validate())
+	assert.NoError(t, Config{}.Validate(), "the interval is unused without a retention")
+
+	testCases := []struct {
+		name   string
+		modify func(*Config)
+	}{
+		{"negative click retention", func(c *Config) { c.ClickRetention = -time.Hour }},
+		{"click retention under a day", func(c *Config) { c.ClickRetention = time.Hour }},
+		{"negative address retention", func(c *Config) { c.AddressRetention = -time.Hour }},
+		{"zero interval", func(c *Config) { c.AddressRetention = time.Hour; c.Interval = 0 }},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			config := DefaultConfig()
+			tc.modify(&config)
+			assert.Error(t, config.Validate())
+		})
+	}
+}
@@ -0,0 +1,172 @@
+package retention
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/joshdurbin/url-shortener/internal/drain"
+	"github.com/joshdurbin/url-shortener/internal/repository"
+)
+
+// AddressStore is something that remembers visitor addresses, such as the
+// click deduper or the crawler lookup cache
+type AddressStore interface {
+	// ForgetAddress forgets addr, returning how many entries were removed
+	ForgetAddress(addr string) int
+	// ForgetAddressesBefore forgets addresses seen before cutoff, returning
+	// how many entries were removed
+	ForgetAddressesBefore(cutoff time.Time) int
+}
+
+// Enforcer deletes clicks older than the click retention and forgets visitor
+// addresses older than the address retention on a schedule, and purges an
+// address on request. Addresses are only ever held in memory; clicks are
+// stored per link and time without who made them.
+type Enforcer struct {
+	config Config
+	clicks repository.ArchiveRepository
+	stores []AddressStore
+	now    func() time.Time
+	gate   drain.Gate // Holds click deletions while the server is drained
+
+	mutex    sync.Mutex
+	started  bool
+	closed   bool
+	stopChan chan struct{}
+	wg       sync.WaitGroup
+}
+
+// New creates a retention enforcer; nil stores are skipped
+func New(config Config, clicks repository.ArchiveRepository, stores ...AddressStore) *Enforcer {
+	var kept []AddressStore
+	for _, store := range stores {
+		if store != nil {
+			kept = append(kept, store)
+		}
+	}
+	return &Enforcer{
+		config:   config,
+		clicks:   clicks,
+		stores:   kept,
+		now:      time.Now,
+		stopChan: make(chan struct{}),
+	}
+}
+
+// Start enforces the retention periods now and then every interval; it does
+// nothing when no retention period is set
+func (e *Enforcer) Start(ctx context.Context) error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if e.started {
+		return fmt.Errorf("retention enforcer already started")
+	}
+	e.started = true
+	if !e.config.Enabled() {
+		return nil
+	}
+
+	e.wg.Add(1)
+	go e.scheduleLoop()
+	return nil
+}
+
+// Close stops the scheduler
+func (e *Enforcer) Close() error {
+	e.mutex.Lock()
+	if e.closed {
+		e.mutex.Unlock()
+		return nil
+	}
+	e.closed = true
+	close(e.stopChan)
+	e.mutex.Unlock()
+
+	e.wg.Wait()
+	return nil
+}
+
+// Pause waits for a click deletion in progress and holds the next ones until
+// Resume, so the stored clicks stay put during a drain. Addresses are still
+// forgotten meanwhile; they are only held in memory.
+func (e *Enforcer) Pause(ctx context.Context) error {
+	if e == nil {
+		return nil
+	}
+	return e.gate.Pause(ctx)
+}
+
+// Resume lets click deletions run again
+func (e *Enforcer) Resume() {
+	if e == nil {
+		return
+	}
+	e.gate.Resume()
+}
+
+// scheduleLoop enforces the retention periods every interval until the
+// enforcer is closed
+func (e *Enforcer) scheduleLoop() {
+	defer e.wg.Done()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-e.stopChan
+		cancel()
+	}()
+
+	ticker := time.NewTicker(e.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := e.Enforce(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("Error enforcing data retention: %v", err)
+		}
+		select {
+		case <-e.stopChan:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Enforce deletes the clicks and forgets the addresses past their retention;
+// clicks are kept while the enforcer is paused
+func (e *Enforcer) Enforce(ctx context.Context) error {
+	now := e.now()
+	if e.config.AddressRetention > 0 {
+		forgotten := 0
+		for _, store := range e.stores {
+			forgotten += store.ForgetAddressesBefore(now.Add(-e.config.AddressRetention))
+		}
+		if forgotten > 0 {
+			log.Printf("Forgot %d visitor addresses past the %v retention", forgotten, e.config.AddressRetention)
+		}
+	}
+	if e.config.ClickRetention > 0 && e.clicks != nil && e.gate.TryEnter() {
+		defer e.gate.Leave()
+		deleted, err := e.clicks.DeleteClicksBetween(ctx, time.Unix(0, 0), now.Add(-e.config.ClickRetention))
+		if err != nil {
+			return fmt.Errorf("failed to delete expired clicks: %w", err)
+		}
+		if deleted > 0 {
+			log.Printf("Deleted %d click rows past the %v retention", deleted, e.config.ClickRetention)
+		}
+	}
+	return nil
+}
+
+// Purge forgets everything remembered about a visitor address and returns
+// how many entries were removed
+func (e *Enforcer) Purge(addr string) int {
+	purged := 0
+	for _, store := range e.stores {
+		purged += store.ForgetAddress(addr)
+	}
+	return purged
+}
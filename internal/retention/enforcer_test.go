@@ -0,0 +1,112 @@
+package retention
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	"github.com/joshdurbin/url-shortener/internal/repository/mocks"
+)
+
+// fakeAddressStore records what it was asked to forget
+type fakeAddressStore struct {
+	forgot   []string
+	cutoffs  []time.Time
+	removing int
+}
+
+func (s *fakeAddressStore) ForgetAddress(addr string) int {
+	s.forgot = append(s.forgot, addr)
+	return s.removing
+}
+
+func (s *fakeAddressStore) ForgetAddressesBefore(cutoff time.Time) int {
+	s.cutoffs = append(s.cutoffs, cutoff)
+	return s.removing
+}
+
+var testNow = time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func TestEnforcer_Enforce(t *testing.T) {
+	ctx := context.Background()
+	clicks := &mocks.ArchiveRepository{}
+	dedupe, lookups := &fakeAddressStore{removing: 2}, &fakeAddressStore{removing: 1}
+	enforcer := New(Config{ClickRetention: 365 * 24 * time.Hour, AddressRetention: 30 * 24 * time.Hour, Interval: time.Hour}, clicks, dedupe, nil, lookups)
+	enforcer.now = func() time.Time { return testNow }
+
+	clicks.On("DeleteClicksBetween", ctx, time.Unix(0, 0), testNow.Add(-365*24*time.Hour)).Return(int64(42), nil).Once()
+	require.NoError(t, enforcer.Enforce(ctx))
+	clicks.AssertExpectations(t)
+	assert.Equal(t, []time.Time{testNow.Add(-30 * 24 * time.Hour)}, dedupe.cutoffs)
+	assert.Equal(t, []time.Time{testNow.Add(-30 * 24 * time.Hour)}, lookups.cutoffs)
+
+	clicks.On("DeleteClicksBetween", ctx, time.Unix(0, 0), testNow.Add(-365*24*time.Hour)).Return(int64(0), domain.Storage(errors.New("database is locked"))).Once()
+	assert.ErrorIs(t, enforcer.Enforce(ctx), domain.ErrStorage)
+}
+
+func TestEnforcer_EnforceNothing(t *testing.T) {
+	clicks := &mocks.ArchiveRepository{}
+	store := &fakeAddressStore{}
+	enforcer := New(DefaultConfig(), clicks, store)
+
+	require.NoError(t, enforcer.Enforce(context.Background()))
+	clicks.AssertNotCalled(t, "DeleteClicksBetween")
+	assert.Empty(t, store.cutoffs)
+
+	// Without a retention period there is nothing to schedule
+	require.NoError(t, enforcer.Start(context.Background()))
+	assert.Error(t, enforcer.Start(context.Background()))
+	require.NoError(t, enforcer.Close())
+	require.NoError(t, enforcer.Close())
+}
+
+func TestEnforcer_PauseKeepsClicks(t *testing.T) {
+	ctx := context.Background()
+	clicks := &mocks.ArchiveRepository{}
+	store := &fakeAddressStore{}
+	enforcer := New(Config{ClickRetention: 365 * 24 * time.Hour, AddressRetention: 30 * 24 * time.Hour, Interval: time.Hour}, clicks, store)
+	enforcer.now = func() time.Time { return testNow }
+
+	// Paused, addresses are forgotten but clicks are not deleted
+	require.NoError(t, enforcer.Pause(ctx))
+	require.NoError(t, enforcer.Enforce(ctx))
+	clicks.AssertNotCalled(t, "DeleteClicksBetween", mock.Anything, mock.Anything, mock.Anything)
+	assert.Len(t, store.cutoffs, 1)
+
+	enforcer.Resume()
+	clicks.On("DeleteClicksBetween", ctx, time.Unix(0, 0), testNow.Add(-365*24*time.Hour)).Return(int64(1), nil).Once()
+	require.NoError(t, enforcer.Enforce(ctx))
+	clicks.AssertExpectations(t)
+}
+
+func TestEnforcer_Purge(t *testing.T) {
+	dedupe, lookups := &fakeAddressStore{removing: 3}, &fakeAddressStore{removing: 1}
+	enforcer := New(DefaultConfig(), nil, dedupe, lookups)
+
+	assert.Equal(t, 4, enforcer.Purge("192.0.2.1"))
+	assert.Equal(t, []string{"192.0.2.1"}, dedupe.forgot)
+	assert.Equal(t, []string{"192.0.2.1"}, lookups.forgot)
+}
+
+func TestEnforcer_StartClose(t *testing.T) {
+	clicks := &mocks.ArchiveRepository{}
+	enforced := make(chan struct{}, 1)
+	clicks.On("DeleteClicksBetween", mock.Anything, time.Unix(0, 0), testNow.Add(-24*time.Hour)).
+		Run(func(mock.Arguments) { enforced <- struct{}{} }).Return(int64(0), nil)
+	enforcer := New(Config{ClickRetention: 24 * time.Hour, Interval: time.Hour}, clicks)
+	enforcer.now = func() time.Time { return testNow }
+
+	require.NoError(t, enforcer.Start(context.Background()))
+	select {
+	case <-enforced:
+	case <-time.After(time.Second):
+		t.Fatal("retention was not enforced on start")
+	}
+	require.NoError(t, enforcer.Close())
+}
@@ -0,0 +1,59 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/joshdurbin/url-shortener/internal/cache/memory"
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	repoMocks "github.com/joshdurbin/url-shortener/internal/repository/mocks"
+)
+
+// suspendingGuard suspends the links in its set and records what it screened
+type suspendingGuard struct {
+	suspended map[string]bool
+	screened  []domain.RedirectRequest
+}
+
+func (g *suspendingGuard) Screen(shortCode string, req domain.RedirectRequest) error {
+	g.screened = append(g.screened, req)
+	if g.suspended[shortCode] {
+		return fmt.Errorf("short code %s is disabled: %w", shortCode, domain.ErrLinkSuspended)
+	}
+	return nil
+}
+
+func TestURLShortener_AbuseGuard(t *testing.T) {
+	cache := memory.New()
+	require.NoError(t, cache.LoadData(context.Background(), map[string]*domain.CacheEntry{
+		"abc123": {OriginalURL: "https://example.com/a"},
+		"def456": {OriginalURL: "https://example.com/b"},
+	}))
+	guard := &suspendingGuard{suspended: map[string]bool{"abc123": true}}
+	repo := &repoMocks.URLRepository{}
+	repo.On("GetURL", mock.Anything, "nope").Return(nil, domain.ErrURLNotFound)
+	svc := NewURLShortener(repo, cache, NewTestGenerator(), WithAbuseGuard(guard))
+
+	_, _, err := svc.GetOriginalURL(context.Background(), "abc123", domain.RedirectRequest{ClientIP: "203.0.113.7"})
+	assert.ErrorIs(t, err, domain.ErrLinkSuspended)
+
+	url, _, err := svc.GetOriginalURL(context.Background(), "def456", domain.RedirectRequest{ClientIP: "203.0.113.7"})
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/b", url)
+
+	// Unknown codes never reach the guard
+	_, _, err = svc.GetOriginalURL(context.Background(), "nope", domain.RedirectRequest{})
+	assert.ErrorIs(t, err, domain.ErrURLNotFound)
+	require.Len(t, guard.screened, 2)
+	assert.Equal(t, "203.0.113.7", guard.screened[0].ClientIP)
+
+	// A suspended link's click is not counted
+	entry, exists := cache.Get(context.Background(), "abc123")
+	require.True(t, exists)
+	assert.Zero(t, entry.UsageCount)
+}
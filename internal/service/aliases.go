@@ -0,0 +1,216 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	"github.com/joshdurbin/url-shortener/internal/repository"
+)
+
+// ErrAliasesNotConfigured is returned by the alias methods of a service
+// created without WithAliases
+var ErrAliasesNotConfigured = errors.New("aliases are not configured")
+
+// WithAliases lets links be reached by aliases kept in store. Aliases are
+// loaded by InitializeCache and resolved in memory on each redirect.
+func WithAliases(store repository.AliasRepository) Option {
+	return func(s *urlShortener) {
+		s.aliasStore = store
+		s.aliases = newAliasIndex()
+	}
+}
+
+// aliasIndex maps aliases to the short codes of their links. It holds every
+// alias, so a code missing from it is no alias: InitializeCache loads them,
+// and aliases changed by other instances arrive through ReloadLinks.
+type aliasIndex struct {
+	mu      sync.RWMutex
+	codes   map[string]string   // Alias to short code
+	aliases map[string][]string // Short code to aliases
+}
+
+// newAliasIndex creates an empty index
+func newAliasIndex() *aliasIndex {
+	return &aliasIndex{codes: make(map[string]string), aliases: make(map[string][]string)}
+}
+
+// resolve returns the short code an alias belongs to; a nil index has no aliases
+func (x *aliasIndex) resolve(alias string) (string, bool) {
+	if x == nil {
+		return "", false
+	}
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	shortCode, ok := x.codes[alias]
+	return shortCode, ok
+}
+
+// of returns a link's aliases
+func (x *aliasIndex) of(shortCode string) []string {
+	if x == nil {
+		return nil
+	}
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	return append([]string(nil), x.aliases[shortCode]...)
+}
+
+// load replaces the index with aliases
+func (x *aliasIndex) load(aliases []domain.Alias) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.codes = make(map[string]string, len(aliases))
+	x.aliases = make(map[string][]string)
+	for _, alias := range aliases {
+		x.codes[alias.Alias] = alias.ShortCode
+		x.aliases[alias.ShortCode] = append(x.aliases[alias.ShortCode], alias.Alias)
+	}
+}
+
+// add points alias at shortCode
+func (x *aliasIndex) add(alias, shortCode string) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.removeLocked(alias)
+	x.codes[alias] = shortCode
+	x.aliases[shortCode] = append(x.aliases[shortCode], alias)
+}
+
+// remove forgets an alias
+func (x *aliasIndex) remove(alias string) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.removeLocked(alias)
+}
+
+// removeLocked forgets an alias; the caller holds the write lock
+func (x *aliasIndex) removeLocked(alias string) {
+	shortCode, ok := x.codes[alias]
+	if !ok {
+		return
+	}
+	delete(x.codes, alias)
+	remaining := x.aliases[shortCode][:0]
+	for _, other := range x.aliases[shortCode] {
+		if other != alias {
+			remaining = append(remaining, other)
+		}
+	}
+	if len(remaining) == 0 {
+		delete(x.aliases, shortCode)
+	} else {
+		x.aliases[shortCode] = remaining
+	}
+}
+
+// removeLink forgets a deleted link's aliases and returns them
+func (x *aliasIndex) removeLink(shortCode string) []string {
+	if x == nil {
+		return nil
+	}
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	aliases := x.aliases[shortCode]
+	delete(x.aliases, shortCode)
+	for _, alias := range aliases {
+		delete(x.codes, alias)
+	}
+	return aliases
+}
+
+// AddAlias gives a link another short code. The alias must be a valid code
+// the blacklist accepts and no link or alias may already use it.
+func (s *urlShortener) AddAlias(ctx context.Context, shortCode, alias string) (*domain.Alias, error) {
+	if s.aliasStore == nil {
+		return nil, ErrAliasesNotConfigured
+	}
+	if err := s.writable(); err != nil {
+		return nil, err
+	}
+	if err := domain.ValidateAlias(alias); err != nil {
+		return nil, err
+	}
+	if err := s.blacklist.Check(alias); err != nil {
+		return nil, domain.Invalid("alias", err)
+	}
+
+	created := domain.Alias{Alias: alias, ShortCode: shortCode, CreatedAt: time.Now()}
+	if err := s.aliasStore.CreateAlias(ctx, created); err != nil {
+		return nil, err
+	}
+	s.aliases.add(alias, shortCode)
+	// Peers learn the alias, and the CDN drops a cached miss for it
+	s.invalidatePeers(alias)
+	return &created, nil
+}
+
+// ListAliases retrieves a link's aliases, oldest first
+func (s *urlShortener) ListAliases(ctx context.Context, shortCode string) ([]domain.Alias, error) {
+	if s.aliasStore == nil {
+		return nil, ErrAliasesNotConfigured
+	}
+	exists, err := s.repo.URLExists(ctx, shortCode)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check URL existence: %w", err)
+	}
+	if !exists {
+		return nil, domain.ErrURLNotFound
+	}
+
+	aliases, err := s.aliasStore.ListAliases(ctx, shortCode)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list aliases: %w", err)
+	}
+	return aliases, nil
+}
+
+// DeleteAlias removes one of a link's aliases; the link and its stats stay
+func (s *urlShortener) DeleteAlias(ctx context.Context, shortCode, alias string) error {
+	if s.aliasStore == nil {
+		return ErrAliasesNotConfigured
+	}
+	if err := s.writable(); err != nil {
+		return err
+	}
+	if err := s.aliasStore.DeleteAlias(ctx, shortCode, alias); err != nil {
+		return err
+	}
+	s.aliases.remove(alias)
+	s.invalidatePeers(alias)
+	return nil
+}
+
+// loadAliases fills the alias index from the store
+func (s *urlShortener) loadAliases(ctx context.Context) error {
+	if s.aliasStore == nil {
+		return nil
+	}
+	aliases, err := s.aliasStore.ListAllAliases(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load aliases: %w", err)
+	}
+	s.aliases.load(aliases)
+	return nil
+}
+
+// reloadAlias refreshes a code another instance may have added or removed as
+// an alias
+func (s *urlShortener) reloadAlias(ctx context.Context, code string) error {
+	if s.aliasStore == nil {
+		return nil
+	}
+	alias, err := s.aliasStore.GetAlias(ctx, code)
+	if errors.Is(err, domain.ErrAliasNotFound) {
+		s.aliases.remove(code)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to reload alias %s: %w", code, err)
+	}
+	s.aliases.add(alias.Alias, alias.ShortCode)
+	return nil
+}
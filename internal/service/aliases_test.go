@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/joshdurbin/url-shortener/internal/cache/memory"
+	"github.com/joshdurbin/url-shortener/internal/cache/mocks"
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	repoMocks "github.com/joshdurbin/url-shortener/internal/repository/mocks"
+	"github.com/joshdurbin/url-shortener/internal/shortener"
+)
+
+func TestURLShortener_AliasResolvesToLink(t *testing.T) {
+	ctx := context.Background()
+	cache := memory.New()
+	require.NoError(t, cache.LoadData(ctx, map[string]*domain.CacheEntry{
+		"abc123": {OriginalURL: "https://example.com"},
+	}))
+
+	repo := &repoMocks.URLRepository{}
+	store := &repoMocks.AliasRepository{}
+	store.On("CreateAlias", ctx, mock.MatchedBy(func(alias domain.Alias) bool {
+		return alias.Alias == "spring" && alias.ShortCode == "abc123"
+	})).Return(nil)
+
+	peers := &invalidationLog{}
+	svc := NewURLShortener(repo, cache, NewTestGenerator(), WithAliases(store), WithPeers(peers))
+	added, err := svc.AddAlias(ctx, "abc123", "spring")
+	require.NoError(t, err)
+	assert.Equal(t, "spring", added.Alias)
+	assert.Equal(t, []string{"spring"}, peers.shortCodes)
+
+	// Both codes redirect and count toward the link's usage
+	for _, code := range []string{"abc123", "spring"} {
+		url, _, err := svc.GetOriginalURL(ctx, code, domain.RedirectRequest{})
+		require.NoError(t, err)
+		assert.Equal(t, "https://example.com", url)
+	}
+	entry, exists := cache.Get(ctx, "abc123")
+	require.True(t, exists)
+	assert.Equal(t, 2, entry.UsageCount)
+	_, exists = cache.Get(ctx, "spring")
+	assert.False(t, exists)
+	store.AssertExpectations(t)
+}
+
+func TestURLShortener_AddAlias_Invalid(t *testing.T) {
+	ctx := context.Background()
+	store := &repoMocks.AliasRepository{}
+	svc := NewURLShortener(&repoMocks.URLRepository{}, &mocks.SyncableCache{}, NewTestGenerator(),
+		WithAliases(store), WithBlacklist(shortener.NewBlacklist([]string{"admin"}, nil)))
+
+	for _, alias := range []string{"", "has space", "admin"} {
+		_, err := svc.AddAlias(ctx, "abc123", alias)
+		assert.ErrorIs(t, err, domain.ErrValidation, alias)
+	}
+	store.AssertNotCalled(t, "CreateAlias", mock.Anything, mock.Anything)
+}
+
+func TestURLShortener_Aliases_NotConfigured(t *testing.T) {
+	ctx := context.Background()
+	svc := NewURLShortener(&repoMocks.URLRepository{}, &mocks.SyncableCache{}, NewTestGenerator())
+
+	_, err := svc.AddAlias(ctx, "abc123", "spring")
+	assert.ErrorIs(t, err, ErrAliasesNotConfigured)
+	_, err = svc.ListAliases(ctx, "abc123")
+	assert.ErrorIs(t, err, ErrAliasesNotConfigured)
+	assert.ErrorIs(t, svc.DeleteAlias(ctx, "abc123", "spring"), ErrAliasesNotConfigured)
+}
+
+func TestURLShortener_DeleteShortURL_ForgetsAliases(t *testing.T) {
+	ctx := context.Background()
+	repo := &repoMocks.URLRepository{}
+	cache := &mocks.SyncableCache{}
+	store := &repoMocks.AliasRepository{}
+	store.On("ListAllAliases", ctx).Return([]domain.Alias{{Alias: "spring", ShortCode: "abc123"}}, nil)
+	repo.On("URLExists", ctx, "abc123").Return(true, nil)
+	repo.On("DeleteURL", ctx, "abc123").Return(nil)
+	cache.On("Delete", ctx, "abc123").Return(nil)
+
+	peers := &invalidationLog{}
+	svc := NewURLShortener(repo, cache, NewTestGenerator(), WithAliases(store), WithPeers(peers))
+	s := svc.(*urlShortener)
+	require.NoError(t, s.loadAliases(ctx))
+	require.NoError(t, svc.DeleteShortURL(ctx, "abc123"))
+
+	assert.Equal(t, []string{"abc123", "spring"}, peers.shortCodes)
+	_, ok := s.aliases.resolve("spring")
+	assert.False(t, ok)
+}
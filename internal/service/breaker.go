@@ -0,0 +1,351 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	"github.com/joshdurbin/url-shortener/internal/repository"
+)
+
+// ErrCircuitOpen is returned instead of calling the database while the
+// circuit breaker is open
+var ErrCircuitOpen = domain.Unavailable(errors.New("database circuit breaker is open"))
+
+// Circuit breaker states
+const (
+	BreakerClosed   = "closed"    // Calls go to the database
+	BreakerOpen     = "open"      // Calls fail fast with ErrCircuitOpen
+	BreakerHalfOpen = "half-open" // A few probe calls test whether the database recovered
+)
+
+// BreakerConfig holds circuit breaker configuration
+type BreakerConfig struct {
+	FailureRate float64       // Share of failed calls in a window that opens the breaker; 0 disables it
+	MinCalls    int           // Calls a window needs before its failure rate counts
+	Window      time.Duration // How long calls are counted before the counts start over
+	Cooldown    time.Duration // How long the breaker stays open before probing
+	Probes      int           // Probe calls that must succeed in a row to close the breaker
+}
+
+// DefaultBreakerConfig returns the default configuration, which opens the
+// breaker when half of at least 20 calls in 30 seconds fail
+func DefaultBreakerConfig() BreakerConfig {
+	return BreakerConfig{
+		FailureRate: 0.5,
+		MinCalls:    20,
+		Window:      30 * time.Second,
+		Cooldown:    10 * time.Second,
+		Probes:      3,
+	}
+}
+
+// Validate checks that the configuration values are usable
+func (c BreakerConfig) Validate() error {
+	if c.FailureRate < 0 || c.FailureRate > 1 {
+		return fmt.Errorf("circuit breaker failure rate must be between 0 and 1, got: %v", c.FailureRate)
+	}
+	if c.FailureRate == 0 {
+		return nil
+	}
+	if c.MinCalls < 1 {
+		return fmt.Errorf("circuit breaker minimum calls must be at least 1, got: %d", c.MinCalls)
+	}
+	if c.Window <= 0 || c.Cooldown <= 0 {
+		return fmt.Errorf("circuit breaker window and cooldown must be positive, got %v and %v", c.Window, c.Cooldown)
+	}
+	if c.Probes < 1 {
+		return fmt.Errorf("circuit breaker probes must be at least 1, got: %d", c.Probes)
+	}
+	return nil
+}
+
+// CircuitBreaker stops calling a failing database. While closed it counts
+// database calls and failures, which are storage errors and timeouts, not
+// missing links or other answers; when the failure rate of a window reaches
+// FailureRate the breaker opens and calls fail with ErrCircuitOpen at once,
+// instead of each waiting out a locked database. After Cooldown up to Probes
+// calls at a time are let through: Probes successes in a row close the
+// breaker, and any failure opens it again. Redirects of cached links never
+// call the database, so they keep working while it is open.
+// A nil CircuitBreaker lets every call through.
+type CircuitBreaker struct {
+	config BreakerConfig
+	now    func() time.Time
+
+	mu          sync.Mutex
+	state       string
+	windowStart time.Time
+	calls       int
+	failures    int
+	openedAt    time.Time
+	probing     int // Probe calls in flight
+	probed      int // Probe calls that succeeded in a row
+
+	opened   atomic.Uint64
+	rejected atomic.Uint64
+}
+
+// NewCircuitBreaker creates a circuit breaker, or returns nil when
+// config.FailureRate is 0
+func NewCircuitBreaker(config BreakerConfig) *CircuitBreaker {
+	if config.FailureRate <= 0 {
+		return nil
+	}
+	return &CircuitBreaker{config: config, now: time.Now, state: BreakerClosed}
+}
+
+// State returns BreakerClosed, BreakerOpen or BreakerHalfOpen
+func (b *CircuitBreaker) State() string {
+	if b == nil {
+		return BreakerClosed
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == BreakerOpen && b.now().Sub(b.openedAt) >= b.config.Cooldown {
+		return BreakerHalfOpen
+	}
+	return b.state
+}
+
+// Opened returns how many times the breaker opened
+func (b *CircuitBreaker) Opened() uint64 {
+	if b == nil {
+		return 0
+	}
+	return b.opened.Load()
+}
+
+// Rejected returns how many calls failed fast while the breaker was open
+func (b *CircuitBreaker) Rejected() uint64 {
+	if b == nil {
+		return 0
+	}
+	return b.rejected.Load()
+}
+
+// do runs call unless the breaker is open, and records its outcome
+func (b *CircuitBreaker) do(ctx context.Context, call func(context.Context) error) error {
+	if b == nil {
+		return call(ctx)
+	}
+	probe, err := b.allow()
+	if err != nil {
+		return err
+	}
+	err = call(ctx)
+	b.record(probe, err)
+	return err
+}
+
+// allow reports whether a call may go ahead and whether it is a probe
+func (b *CircuitBreaker) allow() (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerClosed:
+		return false, nil
+	case BreakerOpen:
+		if b.now().Sub(b.openedAt) < b.config.Cooldown {
+			b.rejected.Add(1)
+			return false, ErrCircuitOpen
+		}
+		b.state = BreakerHalfOpen
+		b.probing, b.probed = 0, 0
+	}
+	if b.probing >= b.config.Probes {
+		b.rejected.Add(1)
+		return false, ErrCircuitOpen
+	}
+	b.probing++
+	return true, nil
+}
+
+// record counts a call's outcome, opening or closing the breaker
+func (b *CircuitBreaker) record(probe bool, err error) {
+	failed := isDatabaseFailure(err)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	if probe {
+		if b.state != BreakerHalfOpen {
+			return
+		}
+		b.probing--
+		if errors.Is(err, context.Canceled) {
+			// Says nothing about the database; another call probes instead
+			return
+		}
+		if failed {
+			b.trip(now, err)
+			return
+		}
+		if b.probed++; b.probed >= b.config.Probes {
+			log.Printf("[INFO] Database circuit breaker closed after %d successful probes", b.probed)
+			b.state = BreakerClosed
+			b.windowStart, b.calls, b.failures = now, 0, 0
+		}
+		return
+	}
+	if b.state != BreakerClosed || errors.Is(err, context.Canceled) {
+		// Started before the breaker opened, or gave up before the database answered
+		return
+	}
+
+	if now.Sub(b.windowStart) >= b.config.Window {
+		b.windowStart, b.calls, b.failures = now, 0, 0
+	}
+	b.calls++
+	if failed {
+		b.failures++
+	}
+	if b.calls >= b.config.MinCalls && float64(b.failures) >= b.config.FailureRate*float64(b.calls) {
+		b.trip(now, err)
+	}
+}
+
+// trip opens the breaker
+func (b *CircuitBreaker) trip(now time.Time, err error) {
+	if b.state == BreakerHalfOpen {
+		log.Printf("[ERROR] Database circuit breaker reopened, probe failed: %v", err)
+	} else {
+		log.Printf("[ERROR] Database circuit breaker opened after %d of %d calls failed, last: %v", b.failures, b.calls, err)
+	}
+	b.state = BreakerOpen
+	b.openedAt = now
+	b.opened.Add(1)
+}
+
+// isDatabaseFailure reports whether an error means the database is in
+// trouble. Answers such as a missing link are not failures, and neither is a
+// caller giving up, but a call running out of time is.
+func isDatabaseFailure(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	return errors.Is(err, domain.ErrStorage) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// guard runs a repository call that returns a value through the breaker
+func guard[T any](b *CircuitBreaker, ctx context.Context, call func(context.Context) (T, error)) (T, error) {
+	var result T
+	err := b.do(ctx, func(ctx context.Context) error {
+		var err error
+		result, err = call(ctx)
+		return err
+	})
+	return result, err
+}
+
+// breakerRepository sends the calls the service makes to its repository
+// through a circuit breaker. Ping is not guarded, so readiness checks see the
+// database as it is.
+type breakerRepository struct {
+	repository.URLRepository
+	breaker *CircuitBreaker
+}
+
+func (r *breakerRepository) CreateURL(ctx context.Context, shortCode, originalURL string, createdAt time.Time, opts domain.CreateOptions) (*domain.URLEntry, error) {
+	return guard(r.breaker, ctx, func(ctx context.Context) (*domain.URLEntry, error) {
+		return r.URLRepository.CreateURL(ctx, shortCode, originalURL, createdAt, opts)
+	})
+}
+
+func (r *breakerRepository) GetURL(ctx context.Context, shortCode string) (*domain.URLEntry, error) {
+	return guard(r.breaker, ctx, func(ctx context.Context) (*domain.URLEntry, error) {
+		return r.URLRepository.GetURL(ctx, shortCode)
+	})
+}
+
+func (r *breakerRepository) GetURLByOriginalURL(ctx context.Context, originalURL, owner string) (*domain.URLEntry, error) {
+	return guard(r.breaker, ctx, func(ctx context.Context) (*domain.URLEntry, error) {
+		return r.URLRepository.GetURLByOriginalURL(ctx, originalURL, owner)
+	})
+}
+
+func (r *breakerRepository) GetAllURLs(ctx context.Context) ([]*domain.URLEntry, error) {
+	return guard(r.breaker, ctx, r.URLRepository.GetAllURLs)
+}
+
+func (r *breakerRepository) GetURLsByCampaign(ctx context.Context, campaign string) ([]*domain.URLEntry, error) {
+	return guard(r.breaker, ctx, func(ctx context.Context) ([]*domain.URLEntry, error) {
+		return r.URLRepository.GetURLsByCampaign(ctx, campaign)
+	})
+}
+
+func (r *breakerRepository) GetURLsByOwner(ctx context.Context, owner string) ([]*domain.URLEntry, error) {
+	return guard(r.breaker, ctx, func(ctx context.Context) ([]*domain.URLEntry, error) {
+		return r.URLRepository.GetURLsByOwner(ctx, owner)
+	})
+}
+
+func (r *breakerRepository) UpdateURL(ctx context.Context, shortCode, originalURL string, opts domain.CreateOptions) (*domain.URLEntry, error) {
+	return guard(r.breaker, ctx, func(ctx context.Context) (*domain.URLEntry, error) {
+		return r.URLRepository.UpdateURL(ctx, shortCode, originalURL, opts)
+	})
+}
+
+func (r *breakerRepository) SetURLFailover(ctx context.Context, shortCode string, active bool, reason string, changedAt time.Time) (*domain.URLEntry, error) {
+	return guard(r.breaker, ctx, func(ctx context.Context) (*domain.URLEntry, error) {
+		return r.URLRepository.SetURLFailover(ctx, shortCode, active, reason, changedAt)
+	})
+}
+
+func (r *breakerRepository) SetURLPreview(ctx context.Context, shortCode string, preview domain.LinkPreview) (*domain.URLEntry, error) {
+	return guard(r.breaker, ctx, func(ctx context.Context) (*domain.URLEntry, error) {
+		return r.URLRepository.SetURLPreview(ctx, shortCode, preview)
+	})
+}
+
+func (r *breakerRepository) UpdateUsage(ctx context.Context, shortCode string, usageCount int, lastUsedAt time.Time) error {
+	return r.breaker.do(ctx, func(ctx context.Context) error {
+		return r.URLRepository.UpdateUsage(ctx, shortCode, usageCount, lastUsedAt)
+	})
+}
+
+func (r *breakerRepository) UpdateUsageBatch(ctx context.Context, updates []domain.UsageUpdate, strategy domain.UsageMergeStrategy) (map[string]int, error) {
+	return guard(r.breaker, ctx, func(ctx context.Context) (map[string]int, error) {
+		return r.URLRepository.UpdateUsageBatch(ctx, updates, strategy)
+	})
+}
+
+func (r *breakerRepository) DeleteURL(ctx context.Context, shortCode string) error {
+	return r.breaker.do(ctx, func(ctx context.Context) error {
+		return r.URLRepository.DeleteURL(ctx, shortCode)
+	})
+}
+
+func (r *breakerRepository) URLExists(ctx context.Context, shortCode string) (bool, error) {
+	return guard(r.breaker, ctx, func(ctx context.Context) (bool, error) {
+		return r.URLRepository.URLExists(ctx, shortCode)
+	})
+}
+
+func (r *breakerRepository) GetRoutingRules(ctx context.Context, shortCode string) ([]domain.RoutingRule, error) {
+	return guard(r.breaker, ctx, func(ctx context.Context) ([]domain.RoutingRule, error) {
+		return r.URLRepository.GetRoutingRules(ctx, shortCode)
+	})
+}
+
+func (r *breakerRepository) SetRoutingRules(ctx context.Context, shortCode string, rules []domain.RoutingRule, createdAt time.Time) error {
+	return r.breaker.do(ctx, func(ctx context.Context) error {
+		return r.URLRepository.SetRoutingRules(ctx, shortCode, rules, createdAt)
+	})
+}
+
+func (r *breakerRepository) LoadCacheData(ctx context.Context) (map[string]*domain.CacheEntry, error) {
+	return guard(r.breaker, ctx, r.URLRepository.LoadCacheData)
+}
+
+func (r *breakerRepository) ImportURLs(ctx context.Context, entries []*domain.URLEntry, strategy domain.ConflictStrategy) (*domain.ImportResult, error) {
+	return guard(r.breaker, ctx, func(ctx context.Context) (*domain.ImportResult, error) {
+		return r.URLRepository.ImportURLs(ctx, entries, strategy)
+	})
+}
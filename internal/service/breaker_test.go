@@ -0,0 +1,174 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/joshdurbin/url-shortener/internal/cache/memory"
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	repoMocks "github.com/joshdurbin/url-shortener/internal/repository/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBreakerConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  BreakerConfig
+		wantErr string
+	}{
+		{name: "default", config: DefaultBreakerConfig()},
+		{name: "disabled ignores other fields", config: BreakerConfig{}},
+		{name: "rate above 1", config: BreakerConfig{FailureRate: 1.5}, wantErr: "between 0 and 1"},
+		{name: "negative rate", config: BreakerConfig{FailureRate: -0.1}, wantErr: "between 0 and 1"},
+		{name: "no minimum calls", config: BreakerConfig{FailureRate: 0.5, Window: time.Second, Cooldown: time.Second, Probes: 1}, wantErr: "minimum calls must be at least 1"},
+		{name: "no cooldown", config: BreakerConfig{FailureRate: 0.5, MinCalls: 1, Window: time.Second, Probes: 1}, wantErr: "must be positive"},
+		{name: "no probes", config: BreakerConfig{FailureRate: 0.5, MinCalls: 1, Window: time.Second, Cooldown: time.Second}, wantErr: "probes must be at least 1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+// testBreaker returns a breaker on a clock the test moves with advance
+func testBreaker(config BreakerConfig) (*CircuitBreaker, func(time.Duration)) {
+	var mu sync.Mutex
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	breaker := NewCircuitBreaker(config)
+	breaker.now = func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return now
+	}
+	return breaker, func(d time.Duration) {
+		mu.Lock()
+		defer mu.Unlock()
+		now = now.Add(d)
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	breaker, advance := testBreaker(BreakerConfig{FailureRate: 0.5, MinCalls: 4, Window: time.Minute, Cooldown: 10 * time.Second, Probes: 2})
+	ctx := context.Background()
+	storageErr := domain.Storage(errors.New("database is locked"))
+	calls := 0
+	call := func(err error) func(context.Context) error {
+		return func(context.Context) error {
+			calls++
+			return err
+		}
+	}
+
+	// Answers are not failures
+	for i := 0; i < 4; i++ {
+		assert.ErrorIs(t, breaker.do(ctx, call(domain.ErrURLNotFound)), domain.ErrURLNotFound)
+	}
+	assert.Equal(t, BreakerClosed, breaker.State())
+
+	// Counts start over with each window
+	advance(time.Minute)
+	assert.NoError(t, breaker.do(ctx, call(nil)))
+	assert.NoError(t, breaker.do(ctx, call(nil)))
+	assert.Error(t, breaker.do(ctx, call(storageErr)))
+	assert.Equal(t, BreakerClosed, breaker.State())
+	assert.Error(t, breaker.do(ctx, call(fmt.Errorf("get URL: %w", context.DeadlineExceeded))))
+	assert.Equal(t, BreakerOpen, breaker.State())
+	assert.Equal(t, uint64(1), breaker.Opened())
+
+	// Open: calls fail fast without reaching the database
+	calls = 0
+	err := breaker.do(ctx, call(nil))
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	assert.ErrorIs(t, err, domain.ErrUnavailable)
+	assert.Zero(t, calls)
+	assert.Equal(t, uint64(1), breaker.Rejected())
+
+	// Half open: a failed probe opens it again
+	advance(10 * time.Second)
+	assert.Equal(t, BreakerHalfOpen, breaker.State())
+	assert.Error(t, breaker.do(ctx, call(storageErr)))
+	assert.Equal(t, BreakerOpen, breaker.State())
+	assert.Equal(t, uint64(2), breaker.Opened())
+
+	// Canceled probes say nothing, and enough successful ones close it
+	advance(10 * time.Second)
+	assert.ErrorIs(t, breaker.do(ctx, call(context.Canceled)), context.Canceled)
+	assert.NoError(t, breaker.do(ctx, call(nil)))
+	assert.Equal(t, BreakerHalfOpen, breaker.State())
+	assert.NoError(t, breaker.do(ctx, call(nil)))
+	assert.Equal(t, BreakerClosed, breaker.State())
+}
+
+func TestCircuitBreaker_LimitsProbes(t *testing.T) {
+	breaker, advance := testBreaker(BreakerConfig{FailureRate: 1, MinCalls: 1, Window: time.Minute, Cooldown: time.Second, Probes: 1})
+	ctx := context.Background()
+	breaker.do(ctx, func(context.Context) error { return domain.Storage(errors.New("disk I/O error")) })
+	advance(time.Second)
+
+	// Only one probe at a time while the database is being tested
+	probing := make(chan struct{})
+	release := make(chan struct{})
+	done := make(chan error)
+	go func() {
+		done <- breaker.do(ctx, func(context.Context) error {
+			close(probing)
+			<-release
+			return nil
+		})
+	}()
+	<-probing
+	assert.ErrorIs(t, breaker.do(ctx, func(context.Context) error { return nil }), ErrCircuitOpen)
+	close(release)
+	assert.NoError(t, <-done)
+	assert.Equal(t, BreakerClosed, breaker.State())
+}
+
+func TestCircuitBreaker_Nil(t *testing.T) {
+	assert.Nil(t, NewCircuitBreaker(BreakerConfig{}))
+
+	var breaker *CircuitBreaker
+	assert.Equal(t, BreakerClosed, breaker.State())
+	assert.Zero(t, breaker.Opened())
+	assert.Zero(t, breaker.Rejected())
+	assert.NoError(t, breaker.do(context.Background(), func(context.Context) error { return nil }))
+}
+
+func TestURLShortener_CircuitBreaker(t *testing.T) {
+	ctx := context.Background()
+	repo := &repoMocks.URLRepository{}
+	repo.On("GetURL", mock.Anything, "missing").Return(nil, domain.Storage(errors.New("database is locked")))
+
+	cache := memory.New()
+	require.NoError(t, cache.Set(ctx, "cached", &domain.CacheEntry{OriginalURL: "https://example.com"}))
+
+	breaker := NewCircuitBreaker(BreakerConfig{FailureRate: 1, MinCalls: 2, Window: time.Minute, Cooldown: time.Minute, Probes: 1})
+	shortener := NewURLShortener(repo, cache, NewTestGenerator(), WithCircuitBreaker(breaker))
+
+	for i := 0; i < 2; i++ {
+		_, _, err := shortener.GetOriginalURL(ctx, "missing", domain.RedirectRequest{})
+		assert.ErrorIs(t, err, domain.ErrStorage)
+	}
+
+	// Uncached links fail fast without touching the database
+	_, _, err := shortener.GetOriginalURL(ctx, "missing", domain.RedirectRequest{})
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	repo.AssertNumberOfCalls(t, "GetURL", 2)
+
+	// Cached links still redirect
+	destination, _, err := shortener.GetOriginalURL(ctx, "cached", domain.RedirectRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com", destination)
+}
@@ -0,0 +1,76 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+)
+
+// burnedKey is the context key of a request's burn marker
+type burnedKey struct{}
+
+// TrackBurned returns a context that records whether GetOriginalURL served
+// the single redirect of a burn-after-reading link; see Burned
+func TrackBurned(ctx context.Context) context.Context {
+	return context.WithValue(ctx, burnedKey{}, new(atomic.Bool))
+}
+
+// Burned reports whether a redirect made with a TrackBurned context used up
+// a burn-after-reading link. Nothing may store such a redirect.
+func Burned(ctx context.Context) bool {
+	burned, _ := ctx.Value(burnedKey{}).(*atomic.Bool)
+	return burned != nil && burned.Load()
+}
+
+// MarkBurned records on a TrackBurned context that its redirect burned a
+// link; other contexts are left alone
+func MarkBurned(ctx context.Context) {
+	if burned, _ := ctx.Value(burnedKey{}).(*atomic.Bool); burned != nil {
+		burned.Store(true)
+	}
+}
+
+// burn serves the single redirect of a burn-after-reading link. The database
+// decides which visit gets it, so the link is read once even while other
+// instances, or this one's cache, still hold it unused. A visit that loses,
+// or cannot reach the database, gets no destination; neither does one while
+// the service is drained.
+func (s *urlShortener) burn(ctx context.Context, shortCode string, entry *domain.CacheEntry, destination string, req domain.RedirectRequest) (string, int, error) {
+	if err := s.writable(); err != nil {
+		return "", 0, err
+	}
+	err := s.repo.BurnURL(ctx, shortCode, time.Now())
+	if err == nil || errors.Is(err, domain.ErrUsageLimitReached) {
+		// Dropped instead of counted, so the next visit loads the used link
+		// and no sync writes this redirect a second time
+		if err := s.cache.Delete(ctx, shortCode); err != nil {
+			// Log error but don't fail the operation
+			fmt.Printf("Warning: failed to delete from cache %s: %v\n", shortCode, err)
+		}
+	}
+	if err != nil {
+		return "", 0, err
+	}
+	MarkBurned(ctx)
+	s.invalidateResponses(shortCode)
+
+	s.notifyClick(domain.EventData{
+		ShortCode:   shortCode,
+		OriginalURL: entry.OriginalURL,
+		Campaign:    entry.Campaign,
+		UsageCount:  1,
+		MaxUses:     1,
+	}, newClick(shortCode, req))
+
+	// Browsers repeat permanent redirects without asking
+	status := entry.RedirectStatus
+	if status == 0 || domain.PermanentRedirect(status) {
+		status = http.StatusFound
+	}
+	return destination, status, nil
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/joshdurbin/url-shortener/internal/cache/memory"
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	repoMocks "github.com/joshdurbin/url-shortener/internal/repository/mocks"
+)
+
+func TestURLShortener_BurnAfterReading(t *testing.T) {
+	cache := memory.New()
+	require.NoError(t, cache.LoadData(context.Background(), map[string]*domain.CacheEntry{
+		"abc123": {OriginalURL: "https://example.com/secret", MaxUses: 1, BurnAfterReading: true, RedirectStatus: http.StatusMovedPermanently},
+	}))
+	repo := &repoMocks.URLRepository{}
+	repo.On("BurnURL", mock.Anything, "abc123", mock.Anything).Return(nil).Once()
+	svc := NewURLShortener(repo, cache, NewTestGenerator())
+
+	// Checking the link neither reads nor burns it
+	_, _, err := svc.GetOriginalURL(context.Background(), "abc123", domain.RedirectRequest{Peek: true})
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+
+	ctx := TrackBurned(context.Background())
+	url, status, err := svc.GetOriginalURL(ctx, "abc123", domain.RedirectRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/secret", url)
+	assert.Equal(t, http.StatusFound, status, "a burned link never redirects permanently")
+	assert.True(t, Burned(ctx))
+
+	// The entry is dropped rather than counted, so no sync writes the redirect again
+	_, exists := cache.Get(context.Background(), "abc123")
+	assert.False(t, exists)
+	repo.AssertExpectations(t)
+}
+
+func TestURLShortener_BurnAfterReading_AlreadyRead(t *testing.T) {
+	// Another instance's cache claimed the link first
+	cache := memory.New()
+	require.NoError(t, cache.LoadData(context.Background(), map[string]*domain.CacheEntry{
+		"abc123": {OriginalURL: "https://example.com/secret", MaxUses: 1, BurnAfterReading: true},
+	}))
+	repo := &repoMocks.URLRepository{}
+	repo.On("BurnURL", mock.Anything, "abc123", mock.Anything).
+		Return(fmt.Errorf("short code abc123 was already read: %w", domain.ErrUsageLimitReached))
+	svc := NewURLShortener(repo, cache, NewTestGenerator())
+
+	ctx := TrackBurned(context.Background())
+	_, _, err := svc.GetOriginalURL(ctx, "abc123", domain.RedirectRequest{})
+	assert.ErrorIs(t, err, domain.ErrUsageLimitReached)
+	assert.False(t, Burned(ctx))
+	_, exists := cache.Get(context.Background(), "abc123")
+	assert.False(t, exists)
+}
+
+func TestURLShortener_BurnAfterReading_Used(t *testing.T) {
+	cache := memory.New()
+	require.NoError(t, cache.LoadData(context.Background(), map[string]*domain.CacheEntry{
+		"abc123": {OriginalURL: "https://example.com/secret", UsageCount: 1, MaxUses: 1, BurnAfterReading: true},
+	}))
+	repo := &repoMocks.URLRepository{}
+	svc := NewURLShortener(repo, cache, NewTestGenerator())
+
+	_, _, err := svc.GetOriginalURL(context.Background(), "abc123", domain.RedirectRequest{})
+	assert.ErrorIs(t, err, domain.ErrUsageLimitReached)
+	repo.AssertNotCalled(t, "BurnURL", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestCheckCreate_BurnAfterReading(t *testing.T) {
+	svc := NewURLShortener(&repoMocks.URLRepository{}, memory.New(), NewTestGenerator()).(*urlShortener)
+
+	opts, errs := svc.checkCreate("https://example.com", domain.CreateOptions{BurnAfterReading: true})
+	assert.Empty(t, errs)
+	assert.Equal(t, 1, opts.MaxUses)
+
+	_, errs = svc.checkCreate("https://example.com", domain.CreateOptions{BurnAfterReading: true, MaxUses: 5})
+	require.Len(t, errs, 1)
+	assert.Equal(t, "max_uses", errs[0].Field)
+}
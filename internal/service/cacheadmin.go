@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/joshdurbin/url-shortener/internal/cache"
+	"github.com/joshdurbin/url-shortener/internal/domain"
+)
+
+// ErrCacheNotInspectable is returned by the cache admin methods when the
+// cache does not implement cache.Inspector
+var ErrCacheNotInspectable = errors.New("cache inspection is not supported")
+
+// inspector returns the cache as a cache.Inspector
+func (s *urlShortener) inspector() (cache.Inspector, error) {
+	inspector, ok := s.cache.(cache.Inspector)
+	if !ok {
+		return nil, ErrCacheNotInspectable
+	}
+	return inspector, nil
+}
+
+// CacheStats reports the cache's size, unsynced entries and hit ratio
+func (s *urlShortener) CacheStats(ctx context.Context) (*domain.CacheStats, error) {
+	inspector, err := s.inspector()
+	if err != nil {
+		return nil, err
+	}
+	stats := inspector.Stats(ctx)
+	return &stats, nil
+}
+
+// InspectCache returns a short code's cache entry. Aliases are not resolved,
+// since they share their link's entry.
+func (s *urlShortener) InspectCache(ctx context.Context, shortCode string) (*domain.CacheEntry, error) {
+	inspector, err := s.inspector()
+	if err != nil {
+		return nil, err
+	}
+	entry, ok := inspector.Inspect(ctx, shortCode)
+	if !ok {
+		return nil, fmt.Errorf("short code %s: %w", shortCode, domain.ErrNotCached)
+	}
+	return entry, nil
+}
+
+// FlushCache writes the cache's unsynced usage to the repository now instead
+// of at the next sync interval. Clicks still in the click buffer are not
+// counted in the cache yet, so they wait for the next sync. While usage syncs
+// back off after failures, flushing is refused as unavailable.
+func (s *urlShortener) FlushCache(ctx context.Context) (int, error) {
+	inspector, err := s.inspector()
+	if err != nil {
+		return 0, err
+	}
+	synced, err := inspector.Flush(ctx)
+	if errors.Is(err, cache.ErrSyncDeferred) {
+		return 0, domain.Unavailable(fmt.Errorf("usage sync is backing off after failures: %w", err))
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to flush the cache: %w", err)
+	}
+	return synced, nil
+}
+
+// InvalidateCache drops a short code's cache entry, so its next redirect
+// reloads it from the repository. An entry with unsynced usage is flushed
+// first; one that is clicked again before it can be dropped returns
+// cache.ErrEntryDirty.
+func (s *urlShortener) InvalidateCache(ctx context.Context, shortCode string) error {
+	inspector, err := s.inspector()
+	if err != nil {
+		return err
+	}
+	evicted, err := inspector.Evict(ctx, shortCode)
+	if errors.Is(err, cache.ErrEntryDirty) {
+		if _, err := s.FlushCache(ctx); err != nil {
+			return err
+		}
+		evicted, err = inspector.Evict(ctx, shortCode)
+	}
+	if err != nil {
+		return err
+	}
+	if !evicted {
+		return fmt.Errorf("short code %s: %w", shortCode, domain.ErrNotCached)
+	}
+	s.invalidateResponses(shortCode)
+	return nil
+}
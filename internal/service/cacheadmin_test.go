@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/joshdurbin/url-shortener/internal/cache/memory"
+	"github.com/joshdurbin/url-shortener/internal/cache/mocks"
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	repoMocks "github.com/joshdurbin/url-shortener/internal/repository/mocks"
+)
+
+func TestURLShortener_CacheAdmin(t *testing.T) {
+	ctx := context.Background()
+	cache := memory.New()
+	require.NoError(t, cache.LoadData(ctx, map[string]*domain.CacheEntry{
+		"abc123": {OriginalURL: "https://example.com/a"},
+	}))
+	repo := &repoMocks.URLRepository{}
+	repo.On("UpdateUsageBatch", ctx, mock.MatchedBy(func(updates []domain.UsageUpdate) bool {
+		return len(updates) == 1 && updates[0].ShortCode == "abc123" && updates[0].Delta == 1
+	}), mock.Anything).Return(map[string]int{"abc123": 1}, nil).Once()
+	svc := NewURLShortener(repo, cache, NewTestGenerator())
+	require.NoError(t, svc.StartCacheSync(ctx, time.Hour))
+	defer svc.StopCacheSync()
+
+	_, _, err := svc.GetOriginalURL(ctx, "abc123", domain.RedirectRequest{})
+	require.NoError(t, err)
+
+	stats, err := svc.CacheStats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, stats.Entries)
+	assert.Equal(t, 1, stats.Dirty)
+
+	entry, err := svc.InspectCache(ctx, "abc123")
+	require.NoError(t, err)
+	assert.Equal(t, 1, entry.PendingUsage())
+	_, err = svc.InspectCache(ctx, "nope")
+	assert.ErrorIs(t, err, domain.ErrNotCached)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+
+	// The unsynced click is written before the entry is dropped
+	require.NoError(t, svc.InvalidateCache(ctx, "abc123"))
+	repo.AssertExpectations(t)
+	_, err = svc.InspectCache(ctx, "abc123")
+	assert.ErrorIs(t, err, domain.ErrNotCached)
+	assert.ErrorIs(t, svc.InvalidateCache(ctx, "abc123"), domain.ErrNotCached)
+
+	synced, err := svc.FlushCache(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, synced)
+}
+
+func TestURLShortener_CacheAdmin_NotInspectable(t *testing.T) {
+	svc := NewURLShortener(&repoMocks.URLRepository{}, &mocks.SyncableCache{}, NewTestGenerator())
+
+	_, err := svc.CacheStats(context.Background())
+	assert.ErrorIs(t, err, ErrCacheNotInspectable)
+	_, err = svc.FlushCache(context.Background())
+	assert.ErrorIs(t, err, ErrCacheNotInspectable)
+	assert.ErrorIs(t, svc.InvalidateCache(context.Background(), "abc123"), ErrCacheNotInspectable)
+}
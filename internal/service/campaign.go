@@ -0,0 +1,79 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+)
+
+// GetURLsByCampaign retrieves a campaign's short URLs with current cache data
+func (s *urlShortener) GetURLsByCampaign(ctx context.Context, campaign string) ([]*domain.URLEntry, error) {
+	campaign, err := normalizeCampaign(campaign)
+	if err != nil {
+		return nil, domain.Invalid("campaign", err)
+	}
+	if campaign == "" {
+		return nil, domain.Invalid("campaign", fmt.Errorf("invalid campaign: name cannot be empty"))
+	}
+
+	entries, err := s.repo.GetURLsByCampaign(ctx, campaign)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get URLs from database: %w", err)
+	}
+
+	s.applyCacheUsage(ctx, entries)
+	return entries, nil
+}
+
+// ListCampaigns summarizes every campaign that has at least one link, ordered
+// by name. Click counts include redirects not yet synced to the repository.
+func (s *urlShortener) ListCampaigns(ctx context.Context) ([]domain.Campaign, error) {
+	entries, err := s.GetAllURLs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	byName := make(map[string]*domain.Campaign)
+	for _, entry := range entries {
+		if entry.Campaign == "" {
+			continue
+		}
+		campaign, exists := byName[entry.Campaign]
+		if !exists {
+			campaign = &domain.Campaign{Name: entry.Campaign}
+			byName[entry.Campaign] = campaign
+		}
+		campaign.Links++
+		campaign.TotalClicks += entry.UsageCount
+		if entry.LastUsedAt == nil || entry.LastUsedAt.IsZero() {
+			continue
+		}
+		if campaign.LastUsedAt == nil || entry.LastUsedAt.After(*campaign.LastUsedAt) {
+			lastUsedAt := *entry.LastUsedAt
+			campaign.LastUsedAt = &lastUsedAt
+		}
+	}
+
+	campaigns := make([]domain.Campaign, 0, len(byName))
+	for _, campaign := range byName {
+		campaigns = append(campaigns, *campaign)
+	}
+	sort.Slice(campaigns, func(i, j int) bool { return campaigns[i].Name < campaigns[j].Name })
+	return campaigns, nil
+}
+
+// normalizeCampaign lowercases a campaign name, rejecting malformed ones. An
+// empty name means the link belongs to no campaign.
+func normalizeCampaign(campaign string) (string, error) {
+	campaign = strings.ToLower(strings.TrimSpace(campaign))
+	if campaign == "" {
+		return "", nil
+	}
+	if !domain.ValidCampaign(campaign) {
+		return "", fmt.Errorf("invalid campaign %q: use up to 64 letters, digits, '-', '_' or '.'", campaign)
+	}
+	return campaign, nil
+}
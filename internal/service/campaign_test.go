@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/joshdurbin/url-shortener/internal/cache/mocks"
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	repoMocks "github.com/joshdurbin/url-shortener/internal/repository/mocks"
+)
+
+func TestURLShortener_ListCampaigns(t *testing.T) {
+	ctx := context.Background()
+	earlier := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	later := earlier.Add(time.Hour)
+
+	repo := &repoMocks.URLRepository{}
+	cache := &mocks.SyncableCache{}
+	repo.On("GetAllURLs", ctx).Return([]*domain.URLEntry{
+		{ShortCode: "a", Campaign: "spring-sale", UsageCount: 3, LastUsedAt: &earlier},
+		{ShortCode: "b", Campaign: "newsletter", UsageCount: 1},
+		{ShortCode: "c", Campaign: "spring-sale", UsageCount: 4},
+		{ShortCode: "d", UsageCount: 9},
+	}, nil)
+	cache.On("Get", ctx, "c").Return(&domain.CacheEntry{UsageCount: 6, LastUsedAt: later}, true)
+	cache.On("Get", ctx, mock.Anything).Return(nil, false)
+
+	shortener := NewURLShortener(repo, cache, NewTestGenerator())
+	campaigns, err := shortener.ListCampaigns(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []domain.Campaign{
+		{Name: "newsletter", Links: 1, TotalClicks: 1},
+		{Name: "spring-sale", Links: 2, TotalClicks: 9, LastUsedAt: &later},
+	}, campaigns)
+}
+
+func TestURLShortener_GetURLsByCampaign(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("normalizes the name", func(t *testing.T) {
+		repo := &repoMocks.URLRepository{}
+		cache := &mocks.SyncableCache{}
+		entries := []*domain.URLEntry{{ShortCode: "a", Campaign: "spring-sale"}}
+		repo.On("GetURLsByCampaign", ctx, "spring-sale").Return(entries, nil)
+		cache.On("Get", ctx, "a").Return(nil, false)
+
+		shortener := NewURLShortener(repo, cache, NewTestGenerator())
+		got, err := shortener.GetURLsByCampaign(ctx, " Spring-Sale ")
+		require.NoError(t, err)
+		assert.Equal(t, entries, got)
+	})
+
+	for _, name := range []string{"", "spring sale", "-sale"} {
+		t.Run("rejects "+name, func(t *testing.T) {
+			repo := &repoMocks.URLRepository{}
+			shortener := NewURLShortener(repo, &mocks.SyncableCache{}, NewTestGenerator())
+			_, err := shortener.GetURLsByCampaign(ctx, name)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "invalid campaign")
+			repo.AssertNotCalled(t, "GetURLsByCampaign", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestURLShortener_CreateShortURL_Campaign(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("stores the normalized name", func(t *testing.T) {
+		repo := &repoMocks.URLRepository{}
+		cache := &mocks.SyncableCache{}
+		entry := &domain.URLEntry{ShortCode: "test0001", OriginalURL: "https://example.com", Campaign: "launch"}
+		repo.On("CreateURL", ctx, mock.AnythingOfType("string"), "https://example.com", mock.AnythingOfType("time.Time"), domain.CreateOptions{Campaign: "launch"}).
+			Return(entry, nil)
+		cache.On("Set", ctx, mock.AnythingOfType("string"), mock.AnythingOfType("*domain.CacheEntry")).Return(nil)
+
+		shortener := NewURLShortener(repo, cache, NewTestGenerator())
+		got, err := shortener.CreateShortURL(ctx, "https://example.com", domain.CreateOptions{Campaign: "Launch"})
+		require.NoError(t, err)
+		assert.Equal(t, "launch", got.Campaign)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("rejects malformed names", func(t *testing.T) {
+		repo := &repoMocks.URLRepository{}
+		shortener := NewURLShortener(repo, &mocks.SyncableCache{}, NewTestGenerator())
+		_, err := shortener.CreateShortURL(ctx, "https://example.com", domain.CreateOptions{Campaign: "spring/sale"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid campaign")
+		repo.AssertNotCalled(t, "CreateURL", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
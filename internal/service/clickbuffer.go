@@ -0,0 +1,282 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+)
+
+// Click buffer overflow policies, for clicks arriving while the buffer is full
+const (
+	ClickOverflowInline = "inline" // Count the click during the redirect, as without a buffer
+	ClickOverflowDrop   = "drop"   // Lose the click, counting it as dropped
+)
+
+// ClickBufferConfig holds click buffer configuration
+type ClickBufferConfig struct {
+	Size      int    // Clicks queued between redirects and the consumer; 0 counts every click during its redirect
+	BatchSize int    // Most clicks the consumer applies to the cache at once
+	Overflow  string // What happens to a click when the buffer is full: inline or drop
+	Async     bool   // Count every click after its redirect, capped links included, and drop clicks when the buffer is full
+}
+
+// DefaultClickBufferConfig returns the default configuration, which counts
+// clicks during their redirect
+func DefaultClickBufferConfig() ClickBufferConfig {
+	return ClickBufferConfig{
+		Size:      0,
+		BatchSize: 256,
+		Overflow:  ClickOverflowInline,
+	}
+}
+
+// Validate checks that the configuration values are usable
+func (c ClickBufferConfig) Validate() error {
+	if c.Size < 0 {
+		return fmt.Errorf("click buffer size cannot be negative, got: %d", c.Size)
+	}
+	if c.Size == 0 {
+		if c.Async {
+			return fmt.Errorf("async click counting needs a click buffer size")
+		}
+		return nil
+	}
+	if c.BatchSize < 1 {
+		return fmt.Errorf("click buffer batch size must be at least 1, got: %d", c.BatchSize)
+	}
+	switch c.Overflow {
+	case ClickOverflowInline, ClickOverflowDrop:
+	default:
+		return fmt.Errorf("click buffer overflow must be %s or %s, got: %q", ClickOverflowInline, ClickOverflowDrop, c.Overflow)
+	}
+	return nil
+}
+
+// bufferedClick is a redirect waiting to be counted
+type bufferedClick struct {
+	shortCode   string
+	originalURL string
+	campaign    string
+	maxUses     int          // The link's usage cap when it was redirected; 0 when uncapped
+	click       domain.Click // Only set when the service has a notifier
+}
+
+// ClickBuffer queues the clicks of redirects so they return without waiting
+// to count them: redirects send to a buffered channel and a consumer applies
+// the clicks to the cache in batches, adding each link's clicks at once, and
+// sends their events. Counts, and the usage synced to the database, lag
+// redirects by as long as the consumer takes to catch up.
+//
+// Links with a usage cap are only buffered with async counting, which never
+// waits: the cap is checked against the clicks already counted, so a link can
+// overshoot it by the clicks still queued, and a full buffer drops clicks.
+// A nil ClickBuffer buffers nothing.
+type ClickBuffer struct {
+	config ClickBufferConfig
+	clicks chan bufferedClick
+
+	mu       sync.RWMutex // Held for reading while enqueue sends, so stop cannot close in between
+	started  bool
+	closed   bool
+	stopChan chan struct{}
+	done     chan struct{}
+
+	queued  atomic.Uint64
+	applied atomic.Uint64
+	dropped atomic.Uint64
+	inline  atomic.Uint64
+}
+
+// NewClickBuffer creates a click buffer, or returns nil when config.Size is 0
+func NewClickBuffer(config ClickBufferConfig) *ClickBuffer {
+	if config.Size <= 0 {
+		return nil
+	}
+	return &ClickBuffer{
+		config:   config,
+		clicks:   make(chan bufferedClick, config.Size),
+		stopChan: make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// enqueue queues a click without blocking. It returns false when the caller
+// must count the click itself: the buffer is nil, stopped, or full with the
+// inline overflow policy and synchronous counting. A click dropped on
+// overflow returns true.
+func (b *ClickBuffer) enqueue(click bufferedClick) bool {
+	if b == nil {
+		return false
+	}
+	b.mu.RLock()
+	if b.closed {
+		b.mu.RUnlock()
+		return false
+	}
+	select {
+	case b.clicks <- click:
+		b.queued.Add(1)
+		b.mu.RUnlock()
+		return true
+	default:
+	}
+	b.mu.RUnlock()
+	if b.config.Overflow == ClickOverflowDrop || b.config.Async {
+		b.dropped.Add(1)
+		return true
+	}
+	b.inline.Add(1)
+	return false
+}
+
+// async reports whether capped links' clicks are buffered too
+func (b *ClickBuffer) async() bool {
+	return b != nil && b.config.Async
+}
+
+// start starts the consumer, which passes batches of queued clicks to apply
+func (b *ClickBuffer) start(apply func(batch []bufferedClick)) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.started {
+		return
+	}
+	b.started = true
+	go b.run(apply)
+}
+
+// stop stops queueing clicks and waits for the consumer to apply the ones
+// already queued. Later redirects count their clicks themselves; a click
+// enqueue accepted is always queued before the consumer's final drain.
+func (b *ClickBuffer) stop() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	if !b.started || b.closed {
+		b.mu.Unlock()
+		return
+	}
+	b.closed = true
+	close(b.stopChan)
+	b.mu.Unlock()
+
+	<-b.done
+}
+
+// settleInterval is how often settle checks the consumer's progress
+const settleInterval = 5 * time.Millisecond
+
+// settle waits until the consumer has applied the clicks queued before it was
+// called. Clicks queued meanwhile are not waited for.
+func (b *ClickBuffer) settle(ctx context.Context) error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	started := b.started
+	b.mu.Unlock()
+	if !started {
+		return nil
+	}
+
+	target := b.queued.Load()
+	ticker := time.NewTicker(settleInterval)
+	defer ticker.Stop()
+	for b.applied.Load() < target {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
+
+// run waits for a click, then applies it with whatever else is queued, up to
+// a batch, until the buffer is stopped; then it applies what is left
+func (b *ClickBuffer) run(apply func(batch []bufferedClick)) {
+	defer close(b.done)
+
+	batch := make([]bufferedClick, 0, b.config.BatchSize)
+	fill := func() {
+		for len(batch) < b.config.BatchSize {
+			select {
+			case click := <-b.clicks:
+				batch = append(batch, click)
+			default:
+				return
+			}
+		}
+	}
+
+	for {
+		select {
+		case click := <-b.clicks:
+			batch = append(batch, click)
+			fill()
+			apply(batch)
+			b.applied.Add(uint64(len(batch)))
+			batch = batch[:0]
+		case <-b.stopChan:
+			for {
+				fill()
+				if len(batch) == 0 {
+					return
+				}
+				apply(batch)
+				b.applied.Add(uint64(len(batch)))
+				batch = batch[:0]
+			}
+		}
+	}
+}
+
+// Capacity returns how many clicks the buffer holds
+func (b *ClickBuffer) Capacity() int {
+	if b == nil {
+		return 0
+	}
+	return cap(b.clicks)
+}
+
+// Queued returns how many clicks are waiting to be counted
+func (b *ClickBuffer) Queued() int {
+	if b == nil {
+		return 0
+	}
+	return len(b.clicks)
+}
+
+// Applied returns how many buffered clicks have been counted
+func (b *ClickBuffer) Applied() uint64 {
+	if b == nil {
+		return 0
+	}
+	return b.applied.Load()
+}
+
+// Dropped returns how many clicks were lost because the buffer was full
+func (b *ClickBuffer) Dropped() uint64 {
+	if b == nil {
+		return 0
+	}
+	return b.dropped.Load()
+}
+
+// Inline returns how many clicks were counted during their redirect because
+// the buffer was full
+func (b *ClickBuffer) Inline() uint64 {
+	if b == nil {
+		return 0
+	}
+	return b.inline.Load()
+}
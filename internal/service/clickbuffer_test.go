@@ -0,0 +1,286 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/joshdurbin/url-shortener/internal/cache/memory"
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	repoMocks "github.com/joshdurbin/url-shortener/internal/repository/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClickBufferConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  ClickBufferConfig
+		wantErr string
+	}{
+		{name: "default", config: DefaultClickBufferConfig()},
+		{name: "disabled ignores other fields", config: ClickBufferConfig{}},
+		{name: "drop", config: ClickBufferConfig{Size: 10, BatchSize: 5, Overflow: ClickOverflowDrop}},
+		{name: "negative size", config: ClickBufferConfig{Size: -1}, wantErr: "cannot be negative"},
+		{name: "async", config: ClickBufferConfig{Size: 10, BatchSize: 5, Overflow: ClickOverflowInline, Async: true}},
+		{name: "async without buffer", config: ClickBufferConfig{Async: true}, wantErr: "needs a click buffer size"},
+		{name: "zero batch", config: ClickBufferConfig{Size: 10, Overflow: ClickOverflowInline}, wantErr: "batch size must be at least 1"},
+		{name: "unknown overflow", config: ClickBufferConfig{Size: 10, BatchSize: 5, Overflow: "block"}, wantErr: "overflow must be inline or drop"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestNewClickBuffer_Disabled(t *testing.T) {
+	buffer := NewClickBuffer(DefaultClickBufferConfig())
+	assert.Nil(t, buffer)
+
+	// A nil buffer leaves every click to the redirect
+	assert.False(t, buffer.enqueue(bufferedClick{shortCode: "abc123"}))
+	buffer.start(func([]bufferedClick) {})
+	buffer.stop()
+	assert.Zero(t, buffer.Capacity())
+	assert.Zero(t, buffer.Applied())
+}
+
+func TestClickBuffer_Overflow(t *testing.T) {
+	t.Run("inline", func(t *testing.T) {
+		buffer := NewClickBuffer(ClickBufferConfig{Size: 1, BatchSize: 1, Overflow: ClickOverflowInline})
+		assert.True(t, buffer.enqueue(bufferedClick{shortCode: "abc123"}))
+		assert.False(t, buffer.enqueue(bufferedClick{shortCode: "abc123"}))
+		assert.Equal(t, 1, buffer.Queued())
+		assert.Equal(t, uint64(1), buffer.Inline())
+		assert.Zero(t, buffer.Dropped())
+	})
+
+	t.Run("drop", func(t *testing.T) {
+		buffer := NewClickBuffer(ClickBufferConfig{Size: 1, BatchSize: 1, Overflow: ClickOverflowDrop})
+		assert.True(t, buffer.enqueue(bufferedClick{shortCode: "abc123"}))
+		assert.True(t, buffer.enqueue(bufferedClick{shortCode: "abc123"}))
+		assert.Equal(t, 1, buffer.Queued())
+		assert.Equal(t, uint64(1), buffer.Dropped())
+		assert.Zero(t, buffer.Inline())
+	})
+
+	t.Run("async always drops", func(t *testing.T) {
+		buffer := NewClickBuffer(ClickBufferConfig{Size: 1, BatchSize: 1, Overflow: ClickOverflowInline, Async: true})
+		assert.True(t, buffer.enqueue(bufferedClick{shortCode: "abc123"}))
+		assert.True(t, buffer.enqueue(bufferedClick{shortCode: "abc123"}))
+		assert.Equal(t, uint64(1), buffer.Dropped())
+		assert.Zero(t, buffer.Inline())
+	})
+}
+
+func TestClickBuffer_StopAppliesQueuedClicks(t *testing.T) {
+	buffer := NewClickBuffer(ClickBufferConfig{Size: 10, BatchSize: 3, Overflow: ClickOverflowInline})
+	for i := 0; i < 7; i++ {
+		require.True(t, buffer.enqueue(bufferedClick{shortCode: "abc123"}))
+	}
+
+	var batches []int
+	buffer.start(func(batch []bufferedClick) {
+		batches = append(batches, len(batch))
+	})
+	buffer.stop()
+
+	assert.Equal(t, uint64(7), buffer.Applied())
+	assert.Zero(t, buffer.Queued())
+	for _, size := range batches {
+		assert.LessOrEqual(t, size, 3)
+	}
+
+	// Clicks after stopping are counted by their redirects
+	assert.False(t, buffer.enqueue(bufferedClick{shortCode: "abc123"}))
+}
+
+func TestClickBuffer_StopRacesEnqueue(t *testing.T) {
+	buffer := NewClickBuffer(ClickBufferConfig{Size: 1000, BatchSize: 16, Overflow: ClickOverflowInline})
+	var applied int
+	buffer.start(func(batch []bufferedClick) {
+		applied += len(batch)
+	})
+
+	// Every click enqueue accepts is applied, even one sent while stop runs
+	var accepted sync.WaitGroup
+	var count sync.Mutex
+	queued := 0
+	for i := 0; i < 8; i++ {
+		accepted.Add(1)
+		go func() {
+			defer accepted.Done()
+			for j := 0; j < 100; j++ {
+				if buffer.enqueue(bufferedClick{shortCode: "abc123"}) {
+					count.Lock()
+					queued++
+					count.Unlock()
+				}
+			}
+		}()
+	}
+	buffer.stop()
+	accepted.Wait()
+
+	assert.Equal(t, queued, applied)
+	assert.Equal(t, uint64(queued), buffer.Applied())
+	assert.Zero(t, buffer.Queued())
+}
+
+// usageLog records the usage count of every url.clicked event. Redirects and
+// the click buffer's consumer notify it concurrently.
+type usageLog struct {
+	mu     sync.Mutex
+	counts []int
+}
+
+func (l *usageLog) Notify(event domain.Event) {
+	if event.Type == domain.EventURLClicked {
+		l.mu.Lock()
+		l.counts = append(l.counts, event.Data.UsageCount)
+		l.mu.Unlock()
+	}
+}
+
+// Counts returns the usage counts recorded so far
+func (l *usageLog) Counts() []int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]int(nil), l.counts...)
+}
+
+func TestURLShortener_GetOriginalURL_ClickBuffer(t *testing.T) {
+	ctx := context.Background()
+	cache := memory.New()
+	require.NoError(t, cache.LoadData(ctx, map[string]*domain.CacheEntry{
+		"open01": {OriginalURL: "https://example.com/open", RedirectStatus: http.StatusFound, UsageCount: 4, SyncedCount: 4},
+		"capped": {OriginalURL: "https://example.com/capped", RedirectStatus: http.StatusFound, MaxUses: 2},
+	}))
+
+	repo := &repoMocks.URLRepository{}
+	repo.On("UpdateUsageBatch", ctx, mock.Anything, mock.Anything).Return(map[string]int{}, nil)
+
+	recorder := &usageLog{}
+	buffer := NewClickBuffer(ClickBufferConfig{Size: 100, BatchSize: 4, Overflow: ClickOverflowInline})
+	shortener := NewURLShortener(repo, cache, NewTestGenerator(), WithNotifier(recorder), WithClickBuffer(buffer))
+	require.NoError(t, shortener.StartCacheSync(ctx, time.Hour))
+
+	for i := 0; i < 10; i++ {
+		_, _, err := shortener.GetOriginalURL(ctx, "open01", domain.RedirectRequest{})
+		require.NoError(t, err)
+	}
+
+	// Capped links are counted during the redirect, so the cap still holds
+	for i := 0; i < 2; i++ {
+		_, _, err := shortener.GetOriginalURL(ctx, "capped", domain.RedirectRequest{})
+		require.NoError(t, err)
+	}
+	_, _, err := shortener.GetOriginalURL(ctx, "capped", domain.RedirectRequest{})
+	assert.ErrorIs(t, err, domain.ErrUsageLimitReached)
+
+	require.NoError(t, shortener.StopCacheSync())
+
+	entry, exists := cache.Get(ctx, "open01")
+	require.True(t, exists)
+	assert.Equal(t, 14, entry.UsageCount)
+	assert.Equal(t, uint64(10), buffer.Applied())
+
+	// Buffered clicks take consecutive usage counts in their events
+	var open []int
+	for _, count := range recorder.Counts() {
+		if count > 2 {
+			open = append(open, count)
+		}
+	}
+	assert.Equal(t, []int{5, 6, 7, 8, 9, 10, 11, 12, 13, 14}, open)
+}
+
+func TestURLShortener_ApplyClicks_Evicted(t *testing.T) {
+	ctx := context.Background()
+	cache := memory.New()
+	require.NoError(t, cache.LoadData(ctx, map[string]*domain.CacheEntry{
+		"cached": {OriginalURL: "https://example.com/cached", UsageCount: 1},
+	}))
+
+	// Clicks of a link that left the cache are added to the database
+	repo := &repoMocks.URLRepository{}
+	repo.On("UpdateUsageBatch", ctx, mock.MatchedBy(func(updates []domain.UsageUpdate) bool {
+		return len(updates) == 1 && updates[0].ShortCode == "gone01" && updates[0].Delta == 2
+	}), domain.UsageMergeDelta).Return(map[string]int{"gone01": 7}, nil).Once()
+
+	recorder := &usageLog{}
+	shortener := NewURLShortener(repo, cache, NewTestGenerator(), WithNotifier(recorder)).(*urlShortener)
+	shortener.applyClicks(ctx, []bufferedClick{{shortCode: "cached"}, {shortCode: "gone01"}, {shortCode: "gone01"}})
+
+	repo.AssertExpectations(t)
+	entry, exists := cache.Get(ctx, "cached")
+	require.True(t, exists)
+	assert.Equal(t, 2, entry.UsageCount)
+	assert.ElementsMatch(t, []int{2, 6, 7}, recorder.Counts())
+}
+
+// expiryLog records the short codes of url.expired events
+type expiryLog struct {
+	usageLog
+	expired []string // Guarded by usageLog.mu
+}
+
+func (l *expiryLog) Notify(event domain.Event) {
+	l.usageLog.Notify(event)
+	if event.Type == domain.EventURLExpired {
+		l.mu.Lock()
+		l.expired = append(l.expired, event.Data.ShortCode)
+		l.mu.Unlock()
+	}
+}
+
+// Expired returns the short codes of the url.expired events recorded so far
+func (l *expiryLog) Expired() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]string(nil), l.expired...)
+}
+
+func TestURLShortener_GetOriginalURL_AsyncClicks(t *testing.T) {
+	ctx := context.Background()
+	cache := memory.New()
+	require.NoError(t, cache.LoadData(ctx, map[string]*domain.CacheEntry{
+		"capped": {OriginalURL: "https://example.com/capped", RedirectStatus: http.StatusFound, MaxUses: 2},
+	}))
+
+	repo := &repoMocks.URLRepository{}
+	repo.On("UpdateUsageBatch", ctx, mock.Anything, mock.Anything).Return(map[string]int{}, nil)
+
+	recorder := &expiryLog{}
+	buffer := NewClickBuffer(ClickBufferConfig{Size: 100, BatchSize: 4, Overflow: ClickOverflowInline, Async: true})
+	shortener := NewURLShortener(repo, cache, NewTestGenerator(), WithNotifier(recorder), WithClickBuffer(buffer))
+
+	// Before the consumer runs, no click has been counted, so the cap is
+	// overshot by the queued clicks
+	for i := 0; i < 3; i++ {
+		_, _, err := shortener.GetOriginalURL(ctx, "capped", domain.RedirectRequest{})
+		require.NoError(t, err)
+	}
+	require.NoError(t, shortener.StartCacheSync(ctx, time.Hour))
+	require.NoError(t, shortener.StopCacheSync())
+
+	entry, exists := cache.Get(ctx, "capped")
+	require.True(t, exists)
+	assert.Equal(t, 3, entry.UsageCount)
+	assert.Equal(t, []int{1, 2, 3}, recorder.Counts())
+	assert.Equal(t, []string{"capped"}, recorder.Expired())
+
+	// Once the counted clicks reach the cap, redirects are refused
+	_, _, err := shortener.GetOriginalURL(ctx, "capped", domain.RedirectRequest{})
+	assert.ErrorIs(t, err, domain.ErrUsageLimitReached)
+}
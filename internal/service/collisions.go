@@ -0,0 +1,31 @@
+package service
+
+import "sync/atomic"
+
+// maxCollisionAttempts bounds how many generated codes that already exist are
+// tried before link creation gives up
+const maxCollisionAttempts = 5
+
+// CollisionStats counts generated short codes that were already taken. The
+// counter generators never repeat a code on their own, so collisions point at
+// a changed obfuscation setting or codes imported from elsewhere.
+type CollisionStats struct {
+	retried atomic.Uint64
+	failed  atomic.Uint64
+}
+
+// Retried returns how many generated codes collided and were regenerated
+func (c *CollisionStats) Retried() uint64 {
+	if c == nil {
+		return 0
+	}
+	return c.retried.Load()
+}
+
+// Failed returns how many link creations gave up after maxCollisionAttempts collisions
+func (c *CollisionStats) Failed() uint64 {
+	if c == nil {
+		return 0
+	}
+	return c.failed.Load()
+}
@@ -0,0 +1,166 @@
+package service
+
+import (
+	"net/netip"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Click dedupe limits
+const (
+	// MaxDedupeSeconds is the longest click dedupe window a link may set
+	MaxDedupeSeconds = 7 * 24 * 60 * 60
+	// maxDedupeClients bounds how many recent clicks are remembered; the
+	// oldest are forgotten first, so a flood of clients only shortens windows
+	maxDedupeClients = 100_000
+)
+
+// clickDeduper remembers which client recently clicked which link, so a
+// client's repeated clicks within a link's window are counted once. Windows are
+// fixed: they start at the counted click and are not extended by repeats.
+type clickDeduper struct {
+	mu     sync.Mutex
+	until  map[string]time.Time // Client and short code to the end of their window
+	order  []dedupedClick       // Counted clicks, oldest first
+	limit  int
+	window time.Duration // Server default for links without their own window
+}
+
+// dedupedClick is a counted click waiting to be forgotten
+type dedupedClick struct {
+	key   string
+	at    time.Time // When the click was counted
+	until time.Time
+}
+
+// newClickDeduper creates a deduper with the server's default window
+// (0 = count every click)
+func newClickDeduper(window time.Duration, limit int) *clickDeduper {
+	return &clickDeduper{
+		until:  make(map[string]time.Time),
+		limit:  limit,
+		window: window,
+	}
+}
+
+// windowFor returns the window of a link with the given dedupe seconds:
+// its own, the server default for 0, or none for -1
+func (d *clickDeduper) windowFor(dedupeSeconds int) time.Duration {
+	switch {
+	case dedupeSeconds > 0:
+		return time.Duration(dedupeSeconds) * time.Second
+	case dedupeSeconds < 0:
+		return 0
+	default:
+		return d.window
+	}
+}
+
+// repeat reports whether client already clicked shortCode within window,
+// recording the click as counted when it did not. Clicks from unknown clients
+// and links without a window are always counted.
+func (d *clickDeduper) repeat(client, shortCode string, window time.Duration, now time.Time) bool {
+	if client == "" || window <= 0 {
+		return false
+	}
+	key := client + " " + shortCode
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.forget(now)
+	if until, ok := d.until[key]; ok && now.Before(until) {
+		return true
+	}
+	until := now.Add(window)
+	d.until[key] = until
+	d.order = append(d.order, dedupedClick{key: key, at: now, until: until})
+	d.forget(now)
+	return false
+}
+
+// forget drops the oldest clicks while they have expired or too many are
+// remembered. A click whose key was counted again since is only removed from
+// the order; the newer click keeps the key.
+func (d *clickDeduper) forget(now time.Time) {
+	for len(d.order) > 0 && (!now.Before(d.order[0].until) || len(d.until) > d.limit) {
+		oldest := d.order[0]
+		d.order = d.order[1:]
+		if d.until[oldest.key].Equal(oldest.until) {
+			delete(d.until, oldest.key)
+		}
+	}
+}
+
+// ForgetAddress forgets the clicks remembered for a visitor address to
+// dedupe them, returning how many there were
+func (s *urlShortener) ForgetAddress(addr string) int {
+	return s.clicks.forgetClient(addr)
+}
+
+// ForgetAddressesBefore forgets the visitor addresses of clicks counted
+// before cutoff, returning how many there were
+func (s *urlShortener) ForgetAddressesBefore(cutoff time.Time) int {
+	return s.clicks.forgetBefore(cutoff)
+}
+
+// forgetClient drops every click remembered for client, however its address
+// is written, and returns how many links it had clicked
+func (d *clickDeduper) forgetClient(client string) int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	forgotten := 0
+	for key := range d.until {
+		if sameAddress(clientOf(key), client) {
+			delete(d.until, key)
+			forgotten++
+		}
+	}
+	if forgotten > 0 {
+		kept := d.order[:0]
+		for _, click := range d.order {
+			if !sameAddress(clientOf(click.key), client) {
+				kept = append(kept, click)
+			}
+		}
+		d.order = kept
+	}
+	return forgotten
+}
+
+// forgetBefore drops the clicks counted before cutoff, however long their
+// windows, and returns how many were dropped
+func (d *clickDeduper) forgetBefore(cutoff time.Time) int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	forgotten := 0
+	for len(d.order) > 0 && d.order[0].at.Before(cutoff) {
+		oldest := d.order[0]
+		d.order = d.order[1:]
+		if d.until[oldest.key].Equal(oldest.until) {
+			delete(d.until, oldest.key)
+			forgotten++
+		}
+	}
+	return forgotten
+}
+
+// clientOf returns the client address of a dedupe key
+func clientOf(key string) string {
+	client, _, _ := strings.Cut(key, " ")
+	return client
+}
+
+// sameAddress reports whether two addresses are the same, however each is
+// written; strings that are not addresses must match exactly
+func sameAddress(a, b string) bool {
+	x, errX := netip.ParseAddr(a)
+	y, errY := netip.ParseAddr(b)
+	if errX != nil || errY != nil {
+		return a == b
+	}
+	return x.Unmap() == y.Unmap()
+}
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/joshdurbin/url-shortener/internal/cache/mocks"
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	repoMocks "github.com/joshdurbin/url-shortener/internal/repository/mocks"
+)
+
+func TestClickDeduper(t *testing.T) {
+	now := time.Now()
+	deduper := newClickDeduper(time.Minute, 3)
+
+	t.Run("window", func(t *testing.T) {
+		assert.Equal(t, time.Minute, deduper.windowFor(0))
+		assert.Equal(t, 30*time.Second, deduper.windowFor(30))
+		assert.Zero(t, deduper.windowFor(-1))
+	})
+
+	t.Run("repeats within the window", func(t *testing.T) {
+		assert.False(t, deduper.repeat("10.0.0.1", "abc123", time.Minute, now))
+		assert.True(t, deduper.repeat("10.0.0.1", "abc123", time.Minute, now.Add(30*time.Second)))
+		assert.False(t, deduper.repeat("10.0.0.2", "abc123", time.Minute, now), "another client counts")
+		assert.False(t, deduper.repeat("10.0.0.1", "xyz789", time.Minute, now), "another link counts")
+
+		// Repeats do not extend the window
+		assert.False(t, deduper.repeat("10.0.0.1", "abc123", time.Minute, now.Add(time.Minute)))
+	})
+
+	t.Run("counts unknown clients and links without a window", func(t *testing.T) {
+		assert.False(t, deduper.repeat("", "abc123", time.Minute, now))
+		assert.False(t, deduper.repeat("", "abc123", time.Minute, now))
+		assert.False(t, deduper.repeat("10.0.0.3", "abc123", 0, now))
+		assert.False(t, deduper.repeat("10.0.0.3", "abc123", 0, now))
+	})
+
+	t.Run("forgets the oldest clicks past the limit", func(t *testing.T) {
+		limited := newClickDeduper(0, 2)
+		for _, client := range []string{"a", "b", "c"} {
+			assert.False(t, limited.repeat(client, "abc123", time.Hour, now))
+		}
+		assert.Len(t, limited.until, 2)
+		assert.False(t, limited.repeat("a", "abc123", time.Hour, now), "the oldest click was forgotten")
+		assert.True(t, limited.repeat("c", "abc123", time.Hour, now))
+	})
+
+	t.Run("forgets a client however its address is written", func(t *testing.T) {
+		clients := newClickDeduper(0, 10)
+		assert.False(t, clients.repeat("2001:db8::1", "abc123", time.Hour, now))
+		assert.False(t, clients.repeat("2001:db8::1", "xyz789", time.Hour, now))
+		assert.False(t, clients.repeat("192.0.2.1", "abc123", time.Hour, now))
+
+		assert.Equal(t, 2, clients.forgetClient("2001:0db8:0000::1"))
+		assert.Zero(t, clients.forgetClient("2001:db8::1"))
+		assert.Len(t, clients.order, 1)
+		assert.False(t, clients.repeat("2001:db8::1", "abc123", time.Hour, now), "the click was forgotten")
+		assert.True(t, clients.repeat("192.0.2.1", "abc123", time.Hour, now))
+	})
+
+	t.Run("forgets clicks counted before a cutoff", func(t *testing.T) {
+		aged := newClickDeduper(0, 10)
+		assert.False(t, aged.repeat("a", "abc123", 24*time.Hour, now))
+		assert.False(t, aged.repeat("b", "abc123", 24*time.Hour, now.Add(time.Hour)))
+
+		assert.Equal(t, 1, aged.forgetBefore(now.Add(30*time.Minute)))
+		assert.False(t, aged.repeat("a", "abc123", 24*time.Hour, now.Add(2*time.Hour)), "its window had not ended")
+		assert.True(t, aged.repeat("b", "abc123", 24*time.Hour, now.Add(2*time.Hour)))
+	})
+}
+
+func TestURLShortener_GetOriginalURL_ClickDedupe(t *testing.T) {
+	ctx := context.Background()
+	visitor := domain.RedirectRequest{ClientIP: "192.0.2.1"}
+
+	t.Run("counts one click per client", func(t *testing.T) {
+		repo := &repoMocks.URLRepository{}
+		cache := &mocks.SyncableCache{}
+		cache.On("Get", ctx, "abc123").Return(&domain.CacheEntry{OriginalURL: "https://example.com"}, true)
+		cache.On("IncrementUsage", ctx, "abc123").Return(1, nil).Twice()
+
+		shortener := NewURLShortener(repo, cache, NewTestGenerator(), WithClickDedupe(time.Hour))
+		for i := 0; i < 3; i++ {
+			destination, _, err := shortener.GetOriginalURL(ctx, "abc123", visitor)
+			require.NoError(t, err)
+			assert.Equal(t, "https://example.com", destination)
+		}
+		_, _, err := shortener.GetOriginalURL(ctx, "abc123", domain.RedirectRequest{ClientIP: "192.0.2.2"})
+		require.NoError(t, err)
+
+		cache.AssertNumberOfCalls(t, "IncrementUsage", 2)
+	})
+
+	t.Run("link window overrides the default", func(t *testing.T) {
+		repo := &repoMocks.URLRepository{}
+		cache := &mocks.SyncableCache{}
+		cache.On("Get", ctx, "abc123").Return(&domain.CacheEntry{OriginalURL: "https://example.com", DedupeSeconds: -1}, true)
+		cache.On("IncrementUsage", ctx, "abc123").Return(1, nil)
+
+		shortener := NewURLShortener(repo, cache, NewTestGenerator(), WithClickDedupe(time.Hour))
+		for i := 0; i < 3; i++ {
+			_, _, err := shortener.GetOriginalURL(ctx, "abc123", visitor)
+			require.NoError(t, err)
+		}
+
+		cache.AssertNumberOfCalls(t, "IncrementUsage", 3)
+	})
+
+	t.Run("repeats of a used up link are rejected", func(t *testing.T) {
+		repo := &repoMocks.URLRepository{}
+		cache := &mocks.SyncableCache{}
+		cache.On("Get", ctx, "abc123").Return(&domain.CacheEntry{OriginalURL: "https://example.com", DedupeSeconds: 60}, true).Once()
+		cache.On("IncrementUsage", ctx, "abc123").Return(1, nil).Once()
+		cache.On("Get", ctx, "abc123").Return(&domain.CacheEntry{OriginalURL: "https://example.com", DedupeSeconds: 60, UsageCount: 1, MaxUses: 1}, true)
+
+		shortener := NewURLShortener(repo, cache, NewTestGenerator())
+		_, _, err := shortener.GetOriginalURL(ctx, "abc123", visitor)
+		require.NoError(t, err)
+		_, _, err = shortener.GetOriginalURL(ctx, "abc123", visitor)
+		require.ErrorIs(t, err, domain.ErrUsageLimitReached)
+	})
+}
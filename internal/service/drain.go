@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	"github.com/joshdurbin/url-shortener/internal/drain"
+)
+
+// WithDrainWriters pauses the given background jobs, such as click analytics
+// and webhook deliveries, while the service is drained, so nothing writes to
+// the repository until Resume
+func WithDrainWriters(writers ...drain.Writer) Option {
+	return func(s *urlShortener) {
+		s.writers = append(s.writers, writers...)
+	}
+}
+
+// writable returns domain.ErrDraining while the service is drained
+func (s *urlShortener) writable() error {
+	if s.draining.Load() {
+		return domain.ErrDraining
+	}
+	return nil
+}
+
+// SyncNow counts the clicks waiting in the click buffer and writes the
+// cache's unsynced usage to the repository, instead of at the next sync
+// interval
+func (s *urlShortener) SyncNow(ctx context.Context) (*domain.SyncSummary, error) {
+	s.drainMu.Lock()
+	defer s.drainMu.Unlock()
+	return s.syncAll(ctx)
+}
+
+// Drain refuses link writes with domain.ErrDraining, syncs like SyncNow and
+// holds background syncs, then pauses the event outbox and the drain writers
+// once they have written what they hold, so the repository is left alone
+// until Resume and can be snapshotted. Redirects are still served; the usage
+// they count meanwhile is synced when the drain ends, or on shutdown.
+// Draining again syncs that usage too.
+func (s *urlShortener) Drain(ctx context.Context) (*domain.SyncSummary, error) {
+	s.drainMu.Lock()
+	defer s.drainMu.Unlock()
+
+	start := time.Now()
+	if !s.draining.Swap(true) {
+		log.Printf("Draining: writes are paused until the drain is ended")
+	}
+	summary, err := s.syncAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.pauseWriters(ctx); err != nil {
+		return nil, err
+	}
+	summary.DurationMs = time.Since(start).Milliseconds()
+	return summary, nil
+}
+
+// Resume ends a drain, accepting writes, syncing usage and resuming the
+// background writers again
+func (s *urlShortener) Resume() {
+	s.drainMu.Lock()
+	defer s.drainMu.Unlock()
+
+	if s.draining.Swap(false) {
+		log.Printf("Drain ended: writes are accepted again")
+	}
+	s.syncer.held.Store(false)
+	s.outbox.resume()
+	for _, writer := range s.writers {
+		writer.Resume()
+	}
+}
+
+// pauseWriters pauses the event outbox and the drain writers, each after it
+// has written what it holds. The caller holds drainMu.
+func (s *urlShortener) pauseWriters(ctx context.Context) error {
+	if err := s.outbox.pause(ctx); err != nil {
+		return fmt.Errorf("failed to pause the event outbox: %w", err)
+	}
+	for _, writer := range s.writers {
+		if err := writer.Pause(ctx); err != nil {
+			return fmt.Errorf("failed to pause background writes: %w", err)
+		}
+	}
+	return nil
+}
+
+// syncAll releases the hold on background syncs for as long as it counts the
+// buffered clicks and flushes the cache. The caller holds drainMu.
+func (s *urlShortener) syncAll(ctx context.Context) (*domain.SyncSummary, error) {
+	start := time.Now()
+	s.syncer.held.Store(false)
+	defer func() { s.syncer.held.Store(s.draining.Load()) }()
+
+	if err := s.buffer.settle(ctx); err != nil {
+		return nil, fmt.Errorf("failed to count buffered clicks: %w", err)
+	}
+	synced, err := s.FlushCache(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.SyncSummary{
+		Synced:     synced,
+		DurationMs: time.Since(start).Milliseconds(),
+		Draining:   s.draining.Load(),
+	}, nil
+}
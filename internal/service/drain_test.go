@@ -0,0 +1,166 @@
+package service
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/joshdurbin/url-shortener/internal/analytics"
+	"github.com/joshdurbin/url-shortener/internal/cache"
+	"github.com/joshdurbin/url-shortener/internal/cache/memory"
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	repoMocks "github.com/joshdurbin/url-shortener/internal/repository/mocks"
+)
+
+// usageDelta matches a usage sync of one click of shortCode
+func usageDelta(shortCode string) any {
+	return mock.MatchedBy(func(updates []domain.UsageUpdate) bool {
+		return len(updates) == 1 && updates[0].ShortCode == shortCode && updates[0].Delta == 1
+	})
+}
+
+func TestURLShortener_SyncNow_CountsBufferedClicks(t *testing.T) {
+	ctx := context.Background()
+	cache := memory.New()
+	require.NoError(t, cache.LoadData(ctx, map[string]*domain.CacheEntry{
+		"abc123": {OriginalURL: "https://example.com/a"},
+	}))
+	repo := &repoMocks.URLRepository{}
+	repo.On("UpdateUsageBatch", ctx, usageDelta("abc123"), mock.Anything).Return(map[string]int{"abc123": 1}, nil).Once()
+
+	buffer := NewClickBuffer(ClickBufferConfig{Size: 10, BatchSize: 4, Overflow: ClickOverflowInline})
+	svc := NewURLShortener(repo, cache, NewTestGenerator(), WithClickBuffer(buffer))
+	require.NoError(t, svc.StartCacheSync(ctx, time.Hour))
+	defer svc.StopCacheSync()
+
+	_, _, err := svc.GetOriginalURL(ctx, "abc123", domain.RedirectRequest{})
+	require.NoError(t, err)
+
+	summary, err := svc.SyncNow(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, summary.Synced)
+	assert.False(t, summary.Draining)
+	repo.AssertExpectations(t)
+}
+
+func TestURLShortener_Drain(t *testing.T) {
+	ctx := context.Background()
+	memoryCache := memory.New()
+	require.NoError(t, memoryCache.LoadData(ctx, map[string]*domain.CacheEntry{
+		"abc123": {OriginalURL: "https://example.com/a"},
+	}))
+	repo := &repoMocks.URLRepository{}
+	repo.On("UpdateUsageBatch", ctx, usageDelta("abc123"), mock.Anything).Return(map[string]int{"abc123": 1}, nil).Once()
+	repo.On("UpdateUsageBatch", ctx, mock.Anything, mock.Anything).Return(map[string]int{"abc123": 2}, nil).Once()
+
+	shortener := NewURLShortener(repo, memoryCache, NewTestGenerator())
+	svc := shortener.(*urlShortener)
+	require.NoError(t, svc.StartCacheSync(ctx, time.Hour))
+	defer svc.StopCacheSync()
+
+	_, _, err := svc.GetOriginalURL(ctx, "abc123", domain.RedirectRequest{})
+	require.NoError(t, err)
+	summary, err := svc.Drain(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, summary.Synced)
+	assert.True(t, summary.Draining)
+
+	// Writes are refused as unavailable
+	_, err = svc.CreateShortURL(ctx, "https://example.com/b", domain.CreateOptions{})
+	assert.ErrorIs(t, err, domain.ErrDraining)
+	assert.ErrorIs(t, err, domain.ErrUnavailable)
+	assert.ErrorIs(t, svc.DeleteShortURL(ctx, "abc123"), domain.ErrDraining)
+	_, err = svc.SetFailover(ctx, "abc123", true, "down")
+	assert.ErrorIs(t, err, domain.ErrDraining)
+
+	// Redirects are still counted, but background syncs are held
+	_, _, err = svc.GetOriginalURL(ctx, "abc123", domain.RedirectRequest{})
+	require.NoError(t, err)
+	_, err = svc.syncer.sync(nil, func() (map[string]int, error) {
+		t.Fatal("a held sync must not write")
+		return nil, nil
+	})
+	assert.ErrorIs(t, err, cache.ErrSyncDeferred)
+
+	// Ending the drain writes again
+	svc.Resume()
+	summary, err = svc.SyncNow(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, summary.Synced)
+	assert.False(t, summary.Draining)
+	repo.AssertExpectations(t)
+}
+
+// failWhile returns a mock hook that fails the test when method is called
+// while drained is set
+func failWhile(t *testing.T, drained *atomic.Bool, method string) func(mock.Arguments) {
+	return func(mock.Arguments) {
+		if drained.Load() {
+			t.Errorf("%s wrote to the repository during a drain", method)
+		}
+	}
+}
+
+func TestURLShortener_Drain_PausesBackgroundWriters(t *testing.T) {
+	ctx := context.Background()
+	memoryCache := memory.New()
+	require.NoError(t, memoryCache.LoadData(ctx, map[string]*domain.CacheEntry{
+		"abc123": {OriginalURL: "https://example.com/a"},
+		"secret": {OriginalURL: "https://example.com/s", MaxUses: 1, BurnAfterReading: true},
+	}))
+
+	var drained atomic.Bool
+	repo := &repoMocks.URLRepository{}
+	repo.On("UpdateUsageBatch", ctx, mock.Anything, mock.Anything).Run(failWhile(t, &drained, "UpdateUsageBatch")).Return(map[string]int{}, nil)
+	repo.On("BurnURL", mock.Anything, "secret", mock.Anything).Run(failWhile(t, &drained, "BurnURL")).Return(nil)
+	var flushed atomic.Int64
+	clicks := &repoMocks.AnalyticsRepository{}
+	clicks.On("AddClickRollups", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		failWhile(t, &drained, "AddClickRollups")(args)
+		for _, minute := range args.Get(1).(domain.ClickRollups).Minutes {
+			flushed.Add(int64(minute.Clicks))
+		}
+	}).Return(nil)
+	store := &repoMocks.OutboxRepository{}
+	store.On("ListOutboxEvents", ctx, 100).Run(failWhile(t, &drained, "ListOutboxEvents")).Return([]domain.ExportRecord{}, nil)
+
+	// Both write every millisecond unless they are paused
+	recorder := analytics.New(analytics.Config{FlushInterval: time.Millisecond}, clicks)
+	require.NoError(t, recorder.Start(ctx))
+	defer recorder.Close()
+	outbox := NewEventOutbox(OutboxConfig{Enabled: true, Interval: time.Millisecond, BatchSize: 100}, store)
+
+	svc := NewURLShortener(repo, memoryCache, NewTestGenerator(),
+		WithNotifier(recorder), WithEventOutbox(outbox), WithDrainWriters(recorder))
+	require.NoError(t, svc.StartCacheSync(ctx, time.Hour))
+	defer svc.StopCacheSync()
+
+	// The click before the drain is flushed by it
+	_, _, err := svc.GetOriginalURL(ctx, "abc123", domain.RedirectRequest{})
+	require.NoError(t, err)
+	_, err = svc.Drain(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), flushed.Load())
+	drained.Store(true)
+
+	// Redirects and the background loops write nothing until Resume; a
+	// burn-after-reading link cannot be used up
+	for i := 0; i < 3; i++ {
+		_, _, err = svc.GetOriginalURL(ctx, "abc123", domain.RedirectRequest{})
+		require.NoError(t, err)
+	}
+	_, _, err = svc.GetOriginalURL(ctx, "secret", domain.RedirectRequest{})
+	assert.ErrorIs(t, err, domain.ErrDraining)
+	time.Sleep(50 * time.Millisecond)
+	repo.AssertNotCalled(t, "BurnURL", mock.Anything, mock.Anything, mock.Anything)
+
+	// The clicks counted during the drain are flushed once it ends
+	drained.Store(false)
+	svc.Resume()
+	assert.Eventually(t, func() bool { return flushed.Load() == 4 }, 5*time.Second, time.Millisecond)
+}
@@ -0,0 +1,215 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+)
+
+// Event bus errors
+var (
+	// ErrTooManySubscribers is returned by Subscribe once the bus has as many
+	// subscribers as it allows
+	ErrTooManySubscribers = errors.New("too many event subscribers")
+	// ErrEventBusClosed is returned by Subscribe after the bus is closed
+	ErrEventBusClosed = errors.New("event bus closed")
+)
+
+// EventBusConfig holds event bus configuration
+type EventBusConfig struct {
+	MaxSubscribers int // Most live subscribers at once; 0 disables the bus
+	Buffer         int // Events held for each subscriber before new ones are dropped
+}
+
+// DefaultEventBusConfig returns the default configuration
+func DefaultEventBusConfig() EventBusConfig {
+	return EventBusConfig{
+		MaxSubscribers: 100,
+		Buffer:         256,
+	}
+}
+
+// Validate checks that the configuration values are usable
+func (c EventBusConfig) Validate() error {
+	if c.MaxSubscribers < 0 {
+		return fmt.Errorf("max event subscribers cannot be negative, got: %d", c.MaxSubscribers)
+	}
+	if c.MaxSubscribers > 0 && c.Buffer < 1 {
+		return fmt.Errorf("event buffer must be at least 1, got: %d", c.Buffer)
+	}
+	return nil
+}
+
+// EventFilter selects the events a subscriber receives. Empty fields match
+// every event.
+type EventFilter struct {
+	ShortCode string
+	Campaign  string
+	Types     []domain.EventType
+}
+
+// Matches reports whether event passes the filter
+func (f EventFilter) Matches(event domain.Event) bool {
+	if f.ShortCode != "" && event.Data.ShortCode != f.ShortCode {
+		return false
+	}
+	if f.Campaign != "" && event.Data.Campaign != f.Campaign {
+		return false
+	}
+	if len(f.Types) == 0 {
+		return true
+	}
+	for _, t := range f.Types {
+		if event.Type == t {
+			return true
+		}
+	}
+	return false
+}
+
+// EventBus fans link events out to live subscribers, such as dashboards
+// following GET /api/events. It subscribes to the events.Bus, so it sees every
+// event the service publishes, including each counted click.
+// Publishing never blocks a redirect: a subscriber whose buffer is full misses
+// the event, and its misses are counted so it can tell its stream has gaps.
+// A nil EventBus publishes nothing.
+type EventBus struct {
+	config EventBusConfig
+
+	mu          sync.RWMutex
+	subscribers map[*Subscription]struct{}
+	closed      bool
+	dropped     atomic.Int64
+}
+
+// NewEventBus creates an event bus, or returns nil when it is disabled
+func NewEventBus(config EventBusConfig) *EventBus {
+	if config.MaxSubscribers <= 0 {
+		return nil
+	}
+	return &EventBus{
+		config:      config,
+		subscribers: make(map[*Subscription]struct{}),
+	}
+}
+
+// Notify passes event to every subscriber whose filter it matches, without blocking
+func (b *EventBus) Notify(event domain.Event) {
+	if b == nil {
+		return
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for sub := range b.subscribers {
+		if !sub.filter.Matches(event) {
+			continue
+		}
+		select {
+		case sub.events <- event:
+		default:
+			sub.dropped.Add(1)
+			b.dropped.Add(1)
+		}
+	}
+}
+
+// Subscribe adds a subscriber receiving the events that match filter. The
+// subscriber must be closed when it stops reading.
+func (b *EventBus) Subscribe(filter EventFilter) (*Subscription, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return nil, ErrEventBusClosed
+	}
+	if len(b.subscribers) >= b.config.MaxSubscribers {
+		return nil, ErrTooManySubscribers
+	}
+
+	sub := &Subscription{
+		bus:    b,
+		filter: filter,
+		events: make(chan domain.Event, b.config.Buffer),
+		done:   make(chan struct{}),
+	}
+	b.subscribers[sub] = struct{}{}
+	return sub, nil
+}
+
+// Subscribers returns the number of live subscribers
+func (b *EventBus) Subscribers() int {
+	if b == nil {
+		return 0
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.subscribers)
+}
+
+// Dropped returns the events dropped because a subscriber's buffer was full
+func (b *EventBus) Dropped() int64 {
+	if b == nil {
+		return 0
+	}
+	return b.dropped.Load()
+}
+
+// Close ends every subscription and refuses new ones, so streams following
+// the bus finish before the server drains its connections
+func (b *EventBus) Close() {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return
+	}
+	b.closed = true
+	for sub := range b.subscribers {
+		delete(b.subscribers, sub)
+		sub.once.Do(func() { close(sub.done) })
+	}
+}
+
+// Subscription is one subscriber's view of an EventBus
+type Subscription struct {
+	bus     *EventBus
+	filter  EventFilter
+	events  chan domain.Event
+	done    chan struct{}
+	once    sync.Once
+	dropped atomic.Int64
+}
+
+// Events returns the subscriber's events. The channel is never closed; wait on
+// Done as well.
+func (s *Subscription) Events() <-chan domain.Event {
+	return s.events
+}
+
+// Done is closed once the subscription ends, when it or the bus is closed
+func (s *Subscription) Done() <-chan struct{} {
+	return s.done
+}
+
+// TakeDropped returns the events this subscriber missed since the last call
+func (s *Subscription) TakeDropped() int64 {
+	return s.dropped.Swap(0)
+}
+
+// Close removes the subscriber from the bus
+func (s *Subscription) Close() {
+	s.bus.mu.Lock()
+	delete(s.bus.subscribers, s)
+	s.bus.mu.Unlock()
+
+	s.once.Do(func() { close(s.done) })
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+)
+
+func TestEventFilter_Matches(t *testing.T) {
+	event := domain.Event{Type: domain.EventURLClicked, Data: domain.EventData{ShortCode: "abc123", Campaign: "spring"}}
+
+	assert.True(t, EventFilter{}.Matches(event))
+	assert.True(t, EventFilter{ShortCode: "abc123", Campaign: "spring", Types: []domain.EventType{domain.EventURLCreated, domain.EventURLClicked}}.Matches(event))
+	assert.False(t, EventFilter{ShortCode: "xyz789"}.Matches(event))
+	assert.False(t, EventFilter{Campaign: "autumn"}.Matches(event))
+	assert.False(t, EventFilter{Types: []domain.EventType{domain.EventURLDeleted}}.Matches(event))
+}
+
+func TestEventBus(t *testing.T) {
+	t.Run("disabled", func(t *testing.T) {
+		bus := NewEventBus(EventBusConfig{})
+		assert.Nil(t, bus)
+
+		// A nil bus publishes nothing
+		bus.Notify(domain.Event{Type: domain.EventURLCreated})
+		bus.Close()
+		assert.Zero(t, bus.Subscribers())
+		assert.Zero(t, bus.Dropped())
+	})
+
+	t.Run("delivers matching events", func(t *testing.T) {
+		bus := NewEventBus(DefaultEventBusConfig())
+		sub, err := bus.Subscribe(EventFilter{ShortCode: "abc123"})
+		require.NoError(t, err)
+		defer sub.Close()
+
+		bus.Notify(domain.Event{ID: "evt_1", Type: domain.EventURLClicked, Data: domain.EventData{ShortCode: "xyz789"}})
+		bus.Notify(domain.Event{ID: "evt_2", Type: domain.EventURLClicked, Data: domain.EventData{ShortCode: "abc123"}})
+
+		event := <-sub.Events()
+		assert.Equal(t, "evt_2", event.ID)
+		assert.Empty(t, sub.Events())
+	})
+
+	t.Run("drops events for slow subscribers", func(t *testing.T) {
+		bus := NewEventBus(EventBusConfig{MaxSubscribers: 2, Buffer: 1})
+		slow, err := bus.Subscribe(EventFilter{})
+		require.NoError(t, err)
+
+		for i := 0; i < 3; i++ {
+			bus.Notify(domain.Event{Type: domain.EventURLClicked})
+		}
+		assert.Len(t, slow.Events(), 1)
+		assert.Equal(t, int64(2), slow.TakeDropped())
+		assert.Zero(t, slow.TakeDropped(), "taking the count resets it")
+		assert.Equal(t, int64(2), bus.Dropped())
+	})
+
+	t.Run("limits subscribers", func(t *testing.T) {
+		bus := NewEventBus(EventBusConfig{MaxSubscribers: 1, Buffer: 1})
+		sub, err := bus.Subscribe(EventFilter{})
+		require.NoError(t, err)
+		assert.Equal(t, 1, bus.Subscribers())
+
+		_, err = bus.Subscribe(EventFilter{})
+		assert.ErrorIs(t, err, ErrTooManySubscribers)
+
+		sub.Close()
+		sub.Close()
+		assert.Zero(t, bus.Subscribers())
+		_, err = bus.Subscribe(EventFilter{})
+		assert.NoError(t, err, "closing a subscription frees its place")
+	})
+
+	t.Run("close ends subscriptions", func(t *testing.T) {
+		bus := NewEventBus(DefaultEventBusConfig())
+		sub, err := bus.Subscribe(EventFilter{})
+		require.NoError(t, err)
+
+		bus.Close()
+		<-sub.Done()
+		sub.Close()
+		assert.Zero(t, bus.Subscribers())
+
+		_, err = bus.Subscribe(EventFilter{})
+		assert.ErrorIs(t, err, ErrEventBusClosed)
+	})
+}
+
+func TestEventBusConfig_Validate(t *testing.T) {
+	assert.NoError(t, DefaultEventBusConfig().Validate())
+	assert.NoError(t, EventBusConfig{}.Validate(), "zero disables the bus")
+	assert.Error(t, EventBusConfig{MaxSubscribers: -1}.Validate())
+	assert.Error(t, EventBusConfig{MaxSubscribers: 1}.Validate())
+}
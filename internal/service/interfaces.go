@@ -9,21 +9,85 @@ import (
 
 // URLShortener defines the interface for URL shortening operations
 type URLShortener interface {
-	// CreateShortURL creates a new short URL
-	CreateShortURL(ctx context.Context, originalURL string) (*domain.URLEntry, error)
+	// CreateShortURL creates a new short URL. With opts.ReuseExisting, an existing
+	// uncapped link to the same URL is returned unchanged instead.
+	CreateShortURL(ctx context.Context, originalURL string, opts domain.CreateOptions) (*domain.URLEntry, error)
 	
-	// GetOriginalURL retrieves the original URL for a short code and increments usage
-	GetOriginalURL(ctx context.Context, shortCode string) (string, error)
+	// ValidateShortURL runs CreateShortURL's checks without creating anything,
+	// returning every problem found; none means the link would be accepted
+	ValidateShortURL(ctx context.Context, originalURL string, opts domain.CreateOptions) []domain.ValidationProblem
 	
-	// GetURLInfo retrieves detailed information about a short URL
+	// GetOriginalURL retrieves the destination for a short code and increments usage.
+	// The first of the link's routing rules that req matches replaces the original URL.
+	// The returned status is the link's redirect status, or 0 if it uses the server default.
+	// Returns domain.ErrUsageLimitReached once a capped link is exhausted, and
+	// domain.ErrInvalidTemplateParams when req.Query does not fill a template link.
+	// Unknown codes return domain.ErrURLNotFound; database failures wrap domain.ErrStorage.
+	// Codes the blacklist rejects return domain.ErrLinkBlocked without being looked up.
+	// The link's query parameters, and req.Query when it forwards them, are added to the destination.
+	GetOriginalURL(ctx context.Context, shortCode string, req domain.RedirectRequest) (string, int, error)
+	
+	// GetURLInfo retrieves detailed information about a short URL, or
+	// domain.ErrURLNotFound. Database failures wrap domain.ErrStorage.
 	GetURLInfo(ctx context.Context, shortCode string) (*domain.URLEntry, error)
 	
+	// UpdateShortURL changes a short URL's destination, usage cap, tags, redirect status, and/or backup URL, keeping its usage stats
+	UpdateShortURL(ctx context.Context, shortCode string, req domain.UpdateURLRequest) (*domain.URLEntry, error)
+	
+	// GetRoutingRules retrieves a short URL's routing rules in evaluation order
+	GetRoutingRules(ctx context.Context, shortCode string) ([]domain.RoutingRule, error)
+	
+	// SetRoutingRules validates and replaces a short URL's routing rules; an empty list removes them
+	SetRoutingRules(ctx context.Context, shortCode string, rules []domain.RoutingRule) ([]domain.RoutingRule, error)
+	
+	// AddAlias gives a link another short code resolving to it and sharing
+	// its usage and analytics. Invalid or blacklisted aliases fail validation,
+	// and codes already in use return domain.ErrShortCodeTaken.
+	AddAlias(ctx context.Context, shortCode, alias string) (*domain.Alias, error)
+	
+	// ListAliases retrieves a link's aliases, oldest first
+	ListAliases(ctx context.Context, shortCode string) ([]domain.Alias, error)
+	
+	// DeleteAlias removes one of a link's aliases, or returns domain.ErrAliasNotFound
+	DeleteAlias(ctx context.Context, shortCode, alias string) error
+	
+	// SetFailover switches a short URL's redirects to its backup URL (active) or back
+	// to its original URL, recording the reason and publishing url.failover or url.recovered
+	SetFailover(ctx context.Context, shortCode string, active bool, reason string) (*domain.URLEntry, error)
+	
 	// DeleteShortURL removes a short URL
 	DeleteShortURL(ctx context.Context, shortCode string) error
 	
 	// GetAllURLs retrieves all short URLs with current cache data
 	GetAllURLs(ctx context.Context) ([]*domain.URLEntry, error)
 	
+	// GetURLsByCampaign retrieves a campaign's short URLs with current cache data
+	GetURLsByCampaign(ctx context.Context, campaign string) ([]*domain.URLEntry, error)
+	
+	// GetURLsByOwner retrieves the short URLs created by an owner with current cache data
+	GetURLsByOwner(ctx context.Context, owner string) ([]*domain.URLEntry, error)
+	
+	// ForgetAddress forgets the clicks remembered for a visitor address to
+	// dedupe them, returning how many there were
+	ForgetAddress(addr string) int
+	
+	// ForgetAddressesBefore forgets the visitor addresses of clicks counted
+	// before cutoff, returning how many there were
+	ForgetAddressesBefore(cutoff time.Time) int
+	
+	// LastRemoval returns when a link last left the listings, by deletion or by
+	// moving to another campaign, or when the service started if none has.
+	// Entry timestamps cannot show a removal, so list validators include it.
+	LastRemoval() time.Time
+	
+	// ListCampaigns summarizes every campaign with its link count and aggregate clicks
+	ListCampaigns(ctx context.Context) ([]domain.Campaign, error)
+	
+	// ReloadLinks refreshes the cached settings of links another instance
+	// sharing the database changed, keeping their usage counters, and drops
+	// deleted links from the cache
+	ReloadLinks(ctx context.Context, shortCodes []string) error
+	
 	// InitializeCache loads data from repository into cache
 	InitializeCache(ctx context.Context) error
 	
@@ -33,6 +97,65 @@ type URLShortener interface {
 	// StopCacheSync stops background cache synchronization
 	StopCacheSync() error
 	
+	// CacheStats reports the cache's size, unsynced entries and hit ratio.
+	// Caches that cannot report on their contents return ErrCacheNotInspectable,
+	// as do the other cache admin methods.
+	CacheStats(ctx context.Context) (*domain.CacheStats, error)
+	
+	// InspectCache returns a short code's cache entry, or domain.ErrNotCached
+	InspectCache(ctx context.Context, shortCode string) (*domain.CacheEntry, error)
+	
+	// FlushCache writes the cache's unsynced usage to the repository now and
+	// returns how many entries it wrote
+	FlushCache(ctx context.Context) (int, error)
+	
+	// InvalidateCache drops a short code's cache entry after syncing its
+	// usage, so its next redirect reloads it, or returns domain.ErrNotCached
+	InvalidateCache(ctx context.Context, shortCode string) error
+	
+	// SyncNow counts buffered clicks and writes the cache's unsynced usage
+	// to the repository now
+	SyncNow(ctx context.Context) (*domain.SyncSummary, error)
+	
+	// Drain refuses link writes with domain.ErrDraining, syncs like SyncNow
+	// and holds background syncs until Resume
+	Drain(ctx context.Context) (*domain.SyncSummary, error)
+	
+	// Resume ends a drain
+	Resume()
+	
+	// CheckHealth reports whether the repository and cache are reachable
+	CheckHealth(ctx context.Context) *domain.HealthResponse
+	
 	// Close closes the service and its dependencies
 	Close() error
+}
+
+// Notifier receives link lifecycle and click events. Notify must not block.
+type Notifier interface {
+	Notify(event domain.Event)
+}
+
+// DestinationVerifier decides whether a destination may be used, for
+// example by checking that it answers
+type DestinationVerifier interface {
+	Verify(ctx context.Context, destination string) error
+}
+
+// AbuseGuard watches redirects for click fraud. Screen counts a redirect and
+// returns an error wrapping domain.ErrLinkSuspended when the link may not
+// redirect now; it must not block.
+type AbuseGuard interface {
+	Screen(shortCode string, req domain.RedirectRequest) error
+}
+
+// DomainResolver looks up the custom domains links may be served on
+type DomainResolver interface {
+	Lookup(host string) (domain.CustomDomain, bool)
+}
+
+// CacheInvalidator tells the other instances sharing the database, or a CDN,
+// that a link was created, changed or deleted. Invalidate must not block.
+type CacheInvalidator interface {
+	Invalidate(shortCode string)
 }
\ No newline at end of file
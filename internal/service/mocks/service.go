@@ -14,18 +14,27 @@ type URLShortener struct {
 }
 
 // CreateShortURL creates a new short URL
-func (m *URLShortener) CreateShortURL(ctx context.Context, originalURL string) (*domain.URLEntry, error) {
-	args := m.Called(ctx, originalURL)
+func (m *URLShortener) CreateShortURL(ctx context.Context, originalURL string, opts domain.CreateOptions) (*domain.URLEntry, error) {
+	args := m.Called(ctx, originalURL, opts)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*domain.URLEntry), args.Error(1)
 }
 
-// GetOriginalURL retrieves the original URL for a short code and increments usage
-func (m *URLShortener) GetOriginalURL(ctx context.Context, shortCode string) (string, error) {
-	args := m.Called(ctx, shortCode)
-	return args.String(0), args.Error(1)
+// ValidateShortURL runs the create checks without creating anything
+func (m *URLShortener) ValidateShortURL(ctx context.Context, originalURL string, opts domain.CreateOptions) []domain.ValidationProblem {
+	args := m.Called(ctx, originalURL, opts)
+	if args.Get(0) == nil {
+		return nil
+	}
+	return args.Get(0).([]domain.ValidationProblem)
+}
+
+// GetOriginalURL retrieves the original URL and redirect status for a short code and increments usage
+func (m *URLShortener) GetOriginalURL(ctx context.Context, shortCode string, req domain.RedirectRequest) (string, int, error) {
+	args := m.Called(ctx, shortCode, req)
+	return args.String(0), args.Int(1), args.Error(2)
 }
 
 // GetURLInfo retrieves detailed information about a short URL
@@ -37,6 +46,66 @@ func (m *URLShortener) GetURLInfo(ctx context.Context, shortCode string) (*domai
 	return args.Get(0).(*domain.URLEntry), args.Error(1)
 }
 
+// UpdateShortURL changes a short URL's destination and/or usage cap
+func (m *URLShortener) UpdateShortURL(ctx context.Context, shortCode string, req domain.UpdateURLRequest) (*domain.URLEntry, error) {
+	args := m.Called(ctx, shortCode, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.URLEntry), args.Error(1)
+}
+
+// GetRoutingRules retrieves a short URL's routing rules
+func (m *URLShortener) GetRoutingRules(ctx context.Context, shortCode string) ([]domain.RoutingRule, error) {
+	args := m.Called(ctx, shortCode)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.RoutingRule), args.Error(1)
+}
+
+// SetRoutingRules replaces a short URL's routing rules
+func (m *URLShortener) SetRoutingRules(ctx context.Context, shortCode string, rules []domain.RoutingRule) ([]domain.RoutingRule, error) {
+	args := m.Called(ctx, shortCode, rules)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.RoutingRule), args.Error(1)
+}
+
+// AddAlias gives a link another short code
+func (m *URLShortener) AddAlias(ctx context.Context, shortCode, alias string) (*domain.Alias, error) {
+	args := m.Called(ctx, shortCode, alias)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Alias), args.Error(1)
+}
+
+// ListAliases retrieves a link's aliases
+func (m *URLShortener) ListAliases(ctx context.Context, shortCode string) ([]domain.Alias, error) {
+	args := m.Called(ctx, shortCode)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.Alias), args.Error(1)
+}
+
+// DeleteAlias removes one of a link's aliases
+func (m *URLShortener) DeleteAlias(ctx context.Context, shortCode, alias string) error {
+	args := m.Called(ctx, shortCode, alias)
+	return args.Error(0)
+}
+
+// SetFailover switches a short URL's redirects to or from its backup URL
+func (m *URLShortener) SetFailover(ctx context.Context, shortCode string, active bool, reason string) (*domain.URLEntry, error) {
+	args := m.Called(ctx, shortCode, active, reason)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.URLEntry), args.Error(1)
+}
+
 // DeleteShortURL removes a short URL
 func (m *URLShortener) DeleteShortURL(ctx context.Context, shortCode string) error {
 	args := m.Called(ctx, shortCode)
@@ -52,6 +121,57 @@ func (m *URLShortener) GetAllURLs(ctx context.Context) ([]*domain.URLEntry, erro
 	return args.Get(0).([]*domain.URLEntry), args.Error(1)
 }
 
+// GetURLsByCampaign retrieves a campaign's short URLs with current cache data
+func (m *URLShortener) GetURLsByCampaign(ctx context.Context, campaign string) ([]*domain.URLEntry, error) {
+	args := m.Called(ctx, campaign)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.URLEntry), args.Error(1)
+}
+
+// GetURLsByOwner retrieves the short URLs created by an owner with current cache data
+func (m *URLShortener) GetURLsByOwner(ctx context.Context, owner string) ([]*domain.URLEntry, error) {
+	args := m.Called(ctx, owner)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.URLEntry), args.Error(1)
+}
+
+// ForgetAddress forgets the clicks remembered for a visitor address
+func (m *URLShortener) ForgetAddress(addr string) int {
+	args := m.Called(addr)
+	return args.Int(0)
+}
+
+// ForgetAddressesBefore forgets the visitor addresses of clicks counted before cutoff
+func (m *URLShortener) ForgetAddressesBefore(cutoff time.Time) int {
+	args := m.Called(cutoff)
+	return args.Int(0)
+}
+
+// LastRemoval returns when a link last left the listings
+func (m *URLShortener) LastRemoval() time.Time {
+	args := m.Called()
+	return args.Get(0).(time.Time)
+}
+
+// ReloadLinks refreshes the cached settings of links another instance changed
+func (m *URLShortener) ReloadLinks(ctx context.Context, shortCodes []string) error {
+	args := m.Called(ctx, shortCodes)
+	return args.Error(0)
+}
+
+// ListCampaigns summarizes every campaign with its link count and aggregate clicks
+func (m *URLShortener) ListCampaigns(ctx context.Context) ([]domain.Campaign, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.Campaign), args.Error(1)
+}
+
 // InitializeCache loads data from repository into cache
 func (m *URLShortener) InitializeCache(ctx context.Context) error {
 	args := m.Called(ctx)
@@ -70,6 +190,68 @@ func (m *URLShortener) StopCacheSync() error {
 	return args.Error(0)
 }
 
+// CacheStats reports the cache's size, unsynced entries and hit ratio
+func (m *URLShortener) CacheStats(ctx context.Context) (*domain.CacheStats, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.CacheStats), args.Error(1)
+}
+
+// InspectCache returns a short code's cache entry
+func (m *URLShortener) InspectCache(ctx context.Context, shortCode string) (*domain.CacheEntry, error) {
+	args := m.Called(ctx, shortCode)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.CacheEntry), args.Error(1)
+}
+
+// FlushCache writes the cache's unsynced usage to the repository now
+func (m *URLShortener) FlushCache(ctx context.Context) (int, error) {
+	args := m.Called(ctx)
+	return args.Int(0), args.Error(1)
+}
+
+// InvalidateCache drops a short code's cache entry after syncing its usage
+func (m *URLShortener) InvalidateCache(ctx context.Context, shortCode string) error {
+	args := m.Called(ctx, shortCode)
+	return args.Error(0)
+}
+
+// SyncNow counts buffered clicks and writes the cache's unsynced usage now
+func (m *URLShortener) SyncNow(ctx context.Context) (*domain.SyncSummary, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.SyncSummary), args.Error(1)
+}
+
+// Drain pauses link writes and syncs usage until Resume
+func (m *URLShortener) Drain(ctx context.Context) (*domain.SyncSummary, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.SyncSummary), args.Error(1)
+}
+
+// Resume ends a drain
+func (m *URLShortener) Resume() {
+	m.Called()
+}
+
+// CheckHealth reports whether the repository and cache are reachable
+func (m *URLShortener) CheckHealth(ctx context.Context) *domain.HealthResponse {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil
+	}
+	return args.Get(0).(*domain.HealthResponse)
+}
+
 // Close closes the service and its dependencies
 func (m *URLShortener) Close() error {
 	args := m.Called()
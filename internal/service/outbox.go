@@ -0,0 +1,250 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	"github.com/joshdurbin/url-shortener/internal/drain"
+	"github.com/joshdurbin/url-shortener/internal/repository"
+)
+
+// OutboxConfig holds event outbox configuration
+type OutboxConfig struct {
+	Enabled   bool          // Store created events with their links and publish them from the outbox
+	Interval  time.Duration // How often the outbox is checked for events left by failures or a crash
+	BatchSize int           // Most events published at once
+}
+
+// DefaultOutboxConfig returns the default configuration, which publishes
+// events directly
+func DefaultOutboxConfig() OutboxConfig {
+	return OutboxConfig{
+		Enabled:   false,
+		Interval:  5 * time.Second,
+		BatchSize: 100,
+	}
+}
+
+// Validate checks that the configuration values are usable
+func (c OutboxConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.Interval <= 0 {
+		return fmt.Errorf("event outbox interval must be positive, got: %v", c.Interval)
+	}
+	if c.BatchSize < 1 {
+		return fmt.Errorf("event outbox batch size must be at least 1, got: %d", c.BatchSize)
+	}
+	return nil
+}
+
+// EventOutbox makes link creation and its url.created event one transaction:
+// the repository stores the event with the link, and a background loop
+// publishes stored events to the notifier, oldest first, deleting them once
+// published. A crash between the two leaves the event in the outbox for the
+// next start, so it is delivered at least once; subscribers such as webhooks
+// may see it twice and should skip duplicates by event ID.
+// A nil EventOutbox publishes events directly, as they happen.
+type EventOutbox struct {
+	config OutboxConfig
+	store  repository.OutboxRepository
+	wake   chan struct{}
+
+	mu       sync.Mutex
+	stopChan chan struct{}
+	done     chan struct{}
+	gate     drain.Gate // Holds publishing while the service is drained
+
+	published atomic.Int64
+	failures  atomic.Int64
+}
+
+// NewEventOutbox creates an event outbox, or returns nil when it is disabled
+func NewEventOutbox(config OutboxConfig, store repository.OutboxRepository) *EventOutbox {
+	if !config.Enabled || store == nil {
+		return nil
+	}
+	return &EventOutbox{
+		config: config,
+		store:  store,
+		wake:   make(chan struct{}, 1),
+	}
+}
+
+// WithEventOutbox stores created events in the link's transaction and
+// publishes them from the outbox; a nil outbox publishes them directly
+func WithEventOutbox(outbox *EventOutbox) Option {
+	return func(s *urlShortener) {
+		s.outbox = outbox
+	}
+}
+
+// Published returns how many events the outbox has published
+func (o *EventOutbox) Published() int64 {
+	if o == nil {
+		return 0
+	}
+	return o.published.Load()
+}
+
+// Failures returns how many times reading or clearing the outbox failed
+func (o *EventOutbox) Failures() int64 {
+	if o == nil {
+		return 0
+	}
+	return o.failures.Load()
+}
+
+// start publishes what a previous run left in the outbox and keeps
+// publishing new events until stop
+func (o *EventOutbox) start(ctx context.Context, notifier Notifier) {
+	if o == nil {
+		return
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.stopChan != nil {
+		return // Already running
+	}
+	o.stopChan = make(chan struct{})
+	o.done = make(chan struct{})
+	go o.run(ctx, notifier, o.stopChan, o.done)
+}
+
+// stop publishes the events still in the outbox and stops the loop
+func (o *EventOutbox) stop() {
+	if o == nil {
+		return
+	}
+	o.mu.Lock()
+	stopChan, done := o.stopChan, o.done
+	o.stopChan, o.done = nil, nil
+	o.mu.Unlock()
+	if stopChan == nil {
+		return
+	}
+	close(stopChan)
+	<-done
+}
+
+// pause waits for a publish in progress and holds the next ones until
+// resume, so the outbox stays put during a drain; the final publish on stop
+// still runs
+func (o *EventOutbox) pause(ctx context.Context) error {
+	if o == nil {
+		return nil
+	}
+	return o.gate.Pause(ctx)
+}
+
+// resume lets the loop publish again
+func (o *EventOutbox) resume() {
+	if o == nil {
+		return
+	}
+	o.gate.Resume()
+}
+
+// signal wakes the loop to publish a newly stored event
+func (o *EventOutbox) signal() {
+	select {
+	case o.wake <- struct{}{}:
+	default:
+	}
+}
+
+// run publishes the outbox on every signal and interval until stopChan is
+// closed or ctx is done, closing done on exit
+func (o *EventOutbox) run(ctx context.Context, notifier Notifier, stopChan, done chan struct{}) {
+	defer close(done)
+	ticker := time.NewTicker(o.config.Interval)
+	defer ticker.Stop()
+
+	o.publishOpen(ctx, notifier)
+	for {
+		select {
+		case <-o.wake:
+			o.publishOpen(ctx, notifier)
+		case <-ticker.C:
+			o.publishOpen(ctx, notifier)
+		case <-stopChan:
+			o.publish(ctx, notifier)
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// publishOpen publishes the outbox unless it is paused
+func (o *EventOutbox) publishOpen(ctx context.Context, notifier Notifier) {
+	if !o.gate.TryEnter() {
+		return
+	}
+	defer o.gate.Leave()
+	o.publish(ctx, notifier)
+}
+
+// publish sends the outbox to the notifier a batch at a time, deleting each
+// batch once it is sent. Failures leave the events for the next attempt.
+func (o *EventOutbox) publish(ctx context.Context, notifier Notifier) {
+	for {
+		records, err := o.store.ListOutboxEvents(ctx, o.config.BatchSize)
+		if err != nil {
+			o.failures.Add(1)
+			log.Printf("Error reading event outbox: %v", err)
+			return
+		}
+		if len(records) == 0 {
+			return
+		}
+		for _, record := range records {
+			if notifier != nil {
+				notifier.Notify(record.Event)
+			}
+		}
+		if err := o.store.DeleteOutboxEvents(ctx, records[len(records)-1].ID); err != nil {
+			// The batch is published again on the next attempt
+			o.failures.Add(1)
+			log.Printf("Error clearing %d published events from the outbox: %v", len(records), err)
+			return
+		}
+		o.published.Add(int64(len(records)))
+		if len(records) < o.config.BatchSize {
+			return
+		}
+	}
+}
+
+// createURL stores a link with its url.created event, or without the event
+// when there is no outbox and the caller publishes it. Both go through the
+// circuit breaker, if there is one.
+func (s *urlShortener) createURL(ctx context.Context, shortCode, originalURL string, createdAt time.Time, opts domain.CreateOptions) (*domain.URLEntry, error) {
+	if s.outbox == nil {
+		return s.repo.CreateURL(ctx, shortCode, originalURL, createdAt, opts)
+	}
+	event := domain.NewEvent(domain.EventURLCreated, domain.EventData{
+		ShortCode:   shortCode,
+		OriginalURL: originalURL,
+		Campaign:    opts.Campaign,
+		MaxUses:     opts.MaxUses,
+	})
+	var breaker *CircuitBreaker
+	if guarded, ok := s.repo.(*breakerRepository); ok {
+		breaker = guarded.breaker
+	}
+	entry, err := guard(breaker, ctx, func(ctx context.Context) (*domain.URLEntry, error) {
+		return s.outbox.store.CreateURLWithEvent(ctx, shortCode, originalURL, createdAt, opts, event)
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.outbox.signal()
+	return entry, nil
+}
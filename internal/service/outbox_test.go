@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/joshdurbin/url-shortener/internal/cache/mocks"
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	repoMocks "github.com/joshdurbin/url-shortener/internal/repository/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOutboxConfig_Validate(t *testing.T) {
+	assert.NoError(t, DefaultOutboxConfig().Validate())
+	assert.NoError(t, OutboxConfig{}.Validate(), "a disabled outbox ignores the other fields")
+	assert.NoError(t, OutboxConfig{Enabled: true, Interval: time.Second, BatchSize: 1}.Validate())
+	assert.ErrorContains(t, OutboxConfig{Enabled: true, BatchSize: 1}.Validate(), "interval must be positive")
+	assert.ErrorContains(t, OutboxConfig{Enabled: true, Interval: time.Second}.Validate(), "batch size must be at least 1")
+}
+
+func TestNewEventOutbox_Disabled(t *testing.T) {
+	outbox := NewEventOutbox(DefaultOutboxConfig(), &repoMocks.OutboxRepository{})
+	assert.Nil(t, outbox)
+
+	// A nil outbox is safe to use
+	outbox.start(context.Background(), nil)
+	outbox.stop()
+	assert.Zero(t, outbox.Published())
+	assert.Zero(t, outbox.Failures())
+}
+
+func outboxRecord(id int64, shortCode string) domain.ExportRecord {
+	return domain.ExportRecord{ID: id, Event: domain.NewEvent(domain.EventURLCreated, domain.EventData{ShortCode: shortCode})}
+}
+
+func TestURLShortener_CreateShortURL_EventOutbox(t *testing.T) {
+	ctx := context.Background()
+	repo := &repoMocks.URLRepository{}
+	cache := &mocks.SyncableCache{}
+	store := &repoMocks.OutboxRepository{}
+	notifier := &recordingNotifier{}
+	outbox := NewEventOutbox(OutboxConfig{Enabled: true, Interval: time.Hour, BatchSize: 100}, store)
+	shortener := NewURLShortener(repo, cache, NewTestGenerator(), WithNotifier(notifier), WithEventOutbox(outbox))
+
+	// The event is stored with the link instead of being sent
+	store.On("CreateURLWithEvent", ctx, mock.AnythingOfType("string"), "https://example.com", mock.AnythingOfType("time.Time"), domain.CreateOptions{Campaign: "launch"},
+		mock.MatchedBy(func(event domain.Event) bool {
+			return event.Type == domain.EventURLCreated && event.Data.OriginalURL == "https://example.com" && event.Data.Campaign == "launch"
+		})).
+		Return(&domain.URLEntry{ShortCode: "new001", OriginalURL: "https://example.com", Campaign: "launch"}, nil)
+	cache.On("Set", ctx, "new001", mock.AnythingOfType("*domain.CacheEntry")).Return(nil)
+
+	entry, err := shortener.CreateShortURL(ctx, "https://example.com", domain.CreateOptions{Campaign: "launch"})
+	require.NoError(t, err)
+	assert.Equal(t, "new001", entry.ShortCode)
+	assert.Empty(t, notifier.events)
+	repo.AssertNotCalled(t, "CreateURL", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+
+	// The relay publishes what a crash left behind, then the new event, and
+	// deletes each once sent
+	store.On("ListOutboxEvents", ctx, 100).Return([]domain.ExportRecord{outboxRecord(1, "old001")}, nil).Once()
+	store.On("ListOutboxEvents", ctx, 100).Return([]domain.ExportRecord{outboxRecord(2, "new001")}, nil).Once()
+	store.On("ListOutboxEvents", ctx, 100).Return([]domain.ExportRecord{}, nil)
+	store.On("DeleteOutboxEvents", ctx, int64(1)).Return(nil).Once()
+	store.On("DeleteOutboxEvents", ctx, int64(2)).Return(nil).Once()
+	cache.On("StartBackgroundSync", ctx, time.Hour, mock.AnythingOfType("cache.SyncFunc")).Return(nil)
+	cache.On("StopBackgroundSync").Return(nil)
+
+	require.NoError(t, shortener.StartCacheSync(ctx, time.Hour))
+	require.NoError(t, shortener.StopCacheSync())
+
+	store.AssertExpectations(t)
+	assert.Equal(t, []domain.EventType{domain.EventURLCreated, domain.EventURLCreated}, notifier.events)
+	assert.Equal(t, int64(2), outbox.Published())
+}
+
+func TestURLShortener_CreateShortURL_EventOutboxFailure(t *testing.T) {
+	ctx := context.Background()
+	store := &repoMocks.OutboxRepository{}
+	notifier := &recordingNotifier{}
+	outbox := NewEventOutbox(OutboxConfig{Enabled: true, Interval: time.Hour, BatchSize: 100}, store)
+	shortener := NewURLShortener(&repoMocks.URLRepository{}, &mocks.SyncableCache{}, NewTestGenerator(), WithNotifier(notifier), WithEventOutbox(outbox))
+
+	// Neither the link nor its event is stored, so nothing is published
+	store.On("CreateURLWithEvent", ctx, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(nil, domain.Storage(errors.New("database is locked")))
+	_, err := shortener.CreateShortURL(ctx, "https://example.com", domain.CreateOptions{})
+	assert.ErrorIs(t, err, domain.ErrStorage)
+	assert.Empty(t, notifier.events)
+	assert.Empty(t, outbox.wake, "a failed create does not wake the relay")
+}
+
+func TestEventOutbox_Publish(t *testing.T) {
+	ctx := context.Background()
+	store := &repoMocks.OutboxRepository{}
+	notifier := &recordingNotifier{}
+	outbox := NewEventOutbox(OutboxConfig{Enabled: true, Interval: time.Hour, BatchSize: 2}, store)
+
+	// Full batches are followed by another read
+	store.On("ListOutboxEvents", ctx, 2).Return([]domain.ExportRecord{outboxRecord(1, "a"), outboxRecord(2, "b")}, nil).Once()
+	store.On("DeleteOutboxEvents", ctx, int64(2)).Return(nil).Once()
+	store.On("ListOutboxEvents", ctx, 2).Return([]domain.ExportRecord{outboxRecord(3, "c")}, nil).Once()
+	store.On("DeleteOutboxEvents", ctx, int64(3)).Return(nil).Once()
+	outbox.publish(ctx, notifier)
+	store.AssertExpectations(t)
+	assert.Len(t, notifier.events, 3)
+	assert.Equal(t, int64(3), outbox.Published())
+
+	// A batch that cannot be deleted is published again on the next attempt
+	store.On("ListOutboxEvents", ctx, 2).Return([]domain.ExportRecord{outboxRecord(4, "d")}, nil).Twice()
+	store.On("DeleteOutboxEvents", ctx, int64(4)).Return(domain.Storage(errors.New("database is locked"))).Once()
+	store.On("DeleteOutboxEvents", ctx, int64(4)).Return(nil).Once()
+	outbox.publish(ctx, notifier)
+	assert.Equal(t, int64(1), outbox.Failures())
+	outbox.publish(ctx, notifier)
+	store.AssertExpectations(t)
+	assert.Len(t, notifier.events, 5)
+	assert.Equal(t, int64(4), outbox.Published())
+
+	// Read failures are counted and leave the outbox as it is
+	store.On("ListOutboxEvents", ctx, 2).Return(nil, domain.Storage(errors.New("disk I/O error"))).Once()
+	outbox.publish(ctx, notifier)
+	assert.Equal(t, int64(2), outbox.Failures())
+	assert.Len(t, notifier.events, 5)
+}
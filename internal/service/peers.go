@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+)
+
+// invalidatePeers tells the other instances sharing the database, and the
+// CDN in front of them, that a link changed
+func (s *urlShortener) invalidatePeers(shortCode string) {
+	if s.peers != nil {
+		s.peers.Invalidate(shortCode)
+	}
+	s.purgeCDN(shortCode)
+}
+
+// purgeCDN drops a link's cached redirects, and its aliases', from the CDN
+func (s *urlShortener) purgeCDN(shortCode string) {
+	if s.cdn != nil {
+		s.cdn.Invalidate(shortCode)
+		for _, alias := range s.aliases.of(shortCode) {
+			s.cdn.Invalidate(alias)
+		}
+	}
+}
+
+// ReloadLinks refreshes the cached settings of links another instance changed.
+// Cached entries are updated in place so their pending usage is still synced;
+// links that are not cached are loaded on their next redirect as usual.
+// Codes another instance added or removed as aliases are looked up again.
+// Reloading never tells the peers, so invalidations do not echo.
+func (s *urlShortener) ReloadLinks(ctx context.Context, shortCodes []string) error {
+	for _, shortCode := range shortCodes {
+		s.invalidateResponses(shortCode)
+		if err := s.reloadAlias(ctx, shortCode); err != nil {
+			return err
+		}
+		// The peer may have deleted the link or moved it to another campaign
+		s.markRemoval()
+
+		if _, _, cached := s.lookup(ctx, shortCode); !cached {
+			continue
+		}
+
+		entry, err := s.repo.GetURL(ctx, shortCode)
+		if errors.Is(err, domain.ErrURLNotFound) {
+			if err := s.cache.Delete(ctx, shortCode); err != nil {
+				// Log error but don't fail the operation
+				fmt.Printf("Warning: failed to delete from cache %s: %v\n", shortCode, err)
+			}
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to reload %s: %w", shortCode, err)
+		}
+		routes, err := s.repo.GetRoutingRules(ctx, shortCode)
+		if err != nil {
+			return fmt.Errorf("failed to reload routing rules of %s: %w", shortCode, err)
+		}
+
+		opts := domain.CreateOptions{
+			MaxUses:        entry.MaxUses,
+			RedirectStatus: entry.RedirectStatus,
+			BackupURL:      entry.BackupURL,
+			QueryParams:    entry.QueryParams,
+			ForwardQuery:   entry.ForwardQuery,
+			Campaign:       entry.Campaign,
+			DedupeSeconds:  entry.DedupeSeconds,
+		}
+		if err := s.cache.UpdateLink(ctx, shortCode, entry.OriginalURL, opts); err != nil {
+			fmt.Printf("Warning: failed to update cache entry %s: %v\n", shortCode, err)
+		}
+		if err := s.cache.SetFailover(ctx, shortCode, entry.FailoverActive); err != nil {
+			fmt.Printf("Warning: failed to update cache failover %s: %v\n", shortCode, err)
+		}
+		if err := s.cache.SetRoutes(ctx, shortCode, routes); err != nil {
+			fmt.Printf("Warning: failed to update cache routes %s: %v\n", shortCode, err)
+		}
+	}
+	return nil
+}
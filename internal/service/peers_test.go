@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/joshdurbin/url-shortener/internal/cache/memory"
+	"github.com/joshdurbin/url-shortener/internal/cache/mocks"
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	repoMocks "github.com/joshdurbin/url-shortener/internal/repository/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// invalidationLog records the short codes sent to peers or the CDN
+type invalidationLog struct {
+	shortCodes []string
+}
+
+func (l *invalidationLog) Invalidate(shortCode string) {
+	l.shortCodes = append(l.shortCodes, shortCode)
+}
+
+func TestURLShortener_ReloadLinks(t *testing.T) {
+	ctx := context.Background()
+	cache := memory.New()
+	require.NoError(t, cache.LoadData(ctx, map[string]*domain.CacheEntry{
+		"abc123": {OriginalURL: "https://example.com/old", RedirectStatus: http.StatusFound, UsageCount: 7, SyncedCount: 5, Dirty: true},
+		"gone01": {OriginalURL: "https://example.com/gone"},
+	}))
+
+	routes := []domain.RoutingRule{{Countries: []string{"DE"}, Destination: "https://example.de"}}
+	repo := &repoMocks.URLRepository{}
+	repo.On("GetURL", ctx, "abc123").Return(&domain.URLEntry{
+		ShortCode:      "abc123",
+		OriginalURL:    "https://example.com/new",
+		MaxUses:        100,
+		BackupURL:      "https://backup.example.com",
+		FailoverActive: true,
+		Campaign:       "spring",
+		UsageCount:     5,
+	}, nil)
+	repo.On("GetRoutingRules", ctx, "abc123").Return(routes, nil)
+	repo.On("GetURL", ctx, "gone01").Return(nil, domain.ErrURLNotFound)
+
+	peers := &invalidationLog{}
+	shortener := NewURLShortener(repo, cache, NewTestGenerator(), WithPeers(peers))
+	before := shortener.LastRemoval()
+	time.Sleep(time.Millisecond)
+
+	// Links that are not cached are left to load on their next redirect
+	require.NoError(t, shortener.ReloadLinks(ctx, []string{"abc123", "gone01", "cold01"}))
+
+	entry, exists := cache.Get(ctx, "abc123")
+	require.True(t, exists)
+	assert.Equal(t, "https://example.com/new", entry.OriginalURL)
+	assert.Equal(t, 100, entry.MaxUses)
+	assert.True(t, entry.FailoverActive)
+	assert.Equal(t, "spring", entry.Campaign)
+	assert.Equal(t, routes, entry.Routes)
+	assert.Equal(t, 7, entry.UsageCount, "pending usage is kept")
+	assert.Equal(t, 2, entry.PendingUsage())
+
+	_, exists = cache.Get(ctx, "gone01")
+	assert.False(t, exists)
+	assert.True(t, shortener.LastRemoval().After(before))
+
+	// Reloads are not echoed back to the peers
+	assert.Empty(t, peers.shortCodes)
+	repo.AssertExpectations(t)
+}
+
+func TestURLShortener_DeleteShortURL_InvalidatesPeers(t *testing.T) {
+	ctx := context.Background()
+	repo := &repoMocks.URLRepository{}
+	cache := &mocks.SyncableCache{}
+	repo.On("URLExists", ctx, "abc123").Return(true, nil)
+	repo.On("DeleteURL", ctx, "abc123").Return(nil)
+	cache.On("Delete", ctx, "abc123").Return(nil)
+
+	peers := &invalidationLog{}
+	purger := &invalidationLog{}
+	shortener := NewURLShortener(repo, cache, NewTestGenerator(), WithPeers(peers), WithCDN(purger))
+	require.NoError(t, shortener.DeleteShortURL(ctx, "abc123"))
+
+	assert.Equal(t, []string{"abc123"}, peers.shortCodes)
+	assert.Equal(t, []string{"abc123"}, purger.shortCodes)
+}
@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+)
+
+// GetRoutingRules retrieves a short URL's routing rules in evaluation order
+func (s *urlShortener) GetRoutingRules(ctx context.Context, shortCode string) ([]domain.RoutingRule, error) {
+	exists, err := s.repo.URLExists(ctx, shortCode)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check URL existence: %w", err)
+	}
+	if !exists {
+		return nil, domain.ErrURLNotFound
+	}
+
+	rules, err := s.repo.GetRoutingRules(ctx, shortCode)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get routing rules: %w", err)
+	}
+	return rules, nil
+}
+
+// SetRoutingRules validates and replaces a short URL's routing rules. The
+// cache entry is updated in place so pending usage counts are kept.
+func (s *urlShortener) SetRoutingRules(ctx context.Context, shortCode string, rules []domain.RoutingRule) ([]domain.RoutingRule, error) {
+	if err := s.writable(); err != nil {
+		return nil, err
+	}
+	entry, err := s.repo.GetURL(ctx, shortCode)
+	if err != nil {
+		return nil, s.unavailable(err)
+	}
+
+	if rules, err = normalizeRoutingRules(rules, entry.ForwardQuery); err != nil {
+		return nil, domain.Invalid("", err)
+	}
+	for i, rule := range rules {
+		if err := s.destFilter.Check(rule.Destination); err != nil {
+			return nil, domain.Invalid("", fmt.Errorf("routing rule %d: %w", i+1, err))
+		}
+	}
+
+	if err := s.repo.SetRoutingRules(ctx, shortCode, rules, time.Now()); err != nil {
+		if errors.Is(err, domain.ErrURLNotFound) {
+			return nil, err
+		}
+		return nil, s.unavailable(fmt.Errorf("failed to set routing rules: %w", err))
+	}
+
+	if err := s.cache.SetRoutes(ctx, shortCode, rules); err != nil {
+		// Log error but don't fail the operation
+		fmt.Printf("Warning: failed to update cache routes %s: %v\n", shortCode, err)
+	}
+	s.invalidatePeers(shortCode)
+
+	return rules, nil
+}
+
+// normalizeRoutingRules uppercases country codes and lowercases devices and
+// languages, rejecting malformed rules. Every rule needs at least one
+// condition, since one without any would hide the original URL entirely.
+func normalizeRoutingRules(rules []domain.RoutingRule, forwardQuery bool) ([]domain.RoutingRule, error) {
+	if len(rules) > domain.MaxRoutingRules {
+		return nil, fmt.Errorf("a link can have at most %d routing rules", domain.MaxRoutingRules)
+	}
+
+	normalized := make([]domain.RoutingRule, 0, len(rules))
+	for i, rule := range rules {
+		number := i + 1
+		if len(rule.Countries) == 0 && len(rule.Devices) == 0 && len(rule.Languages) == 0 {
+			return nil, fmt.Errorf("routing rule %d has no conditions", number)
+		}
+		if err := validateURL(rule.Destination); err != nil {
+			return nil, fmt.Errorf("routing rule %d: %w", number, err)
+		}
+		if forwardQuery && domain.IsTemplate(rule.Destination) {
+			return nil, fmt.Errorf("routing rule %d: forward_query cannot be combined with a template URL", number)
+		}
+
+		out := domain.RoutingRule{Destination: rule.Destination}
+		for _, country := range rule.Countries {
+			country = strings.ToUpper(strings.TrimSpace(country))
+			if !domain.ValidCountry(country) {
+				return nil, fmt.Errorf("routing rule %d: invalid country %q: use a two-letter code such as US", number, country)
+			}
+			out.Countries = append(out.Countries, country)
+		}
+		for _, device := range rule.Devices {
+			device = domain.DeviceType(strings.ToLower(strings.TrimSpace(string(device))))
+			if !domain.ValidDeviceType(device) {
+				return nil, fmt.Errorf("routing rule %d: invalid device %q: use desktop, mobile, tablet or bot", number, device)
+			}
+			out.Devices = append(out.Devices, device)
+		}
+		for _, language := range rule.Languages {
+			language = strings.ToLower(strings.TrimSpace(language))
+			if !domain.ValidLanguage(language) {
+				return nil, fmt.Errorf("routing rule %d: invalid language %q: use a tag such as en or pt-br", number, language)
+			}
+			out.Languages = append(out.Languages, language)
+		}
+		normalized = append(normalized, out)
+	}
+	return normalized, nil
+}
@@ -0,0 +1,239 @@
+package service
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/joshdurbin/url-shortener/internal/cache/mocks"
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	repoMocks "github.com/joshdurbin/url-shortener/internal/repository/mocks"
+)
+
+func TestURLShortener_GetOriginalURL_Routing(t *testing.T) {
+	ctx := context.Background()
+	routes := []domain.RoutingRule{
+		{Countries: []string{"DE", "AT"}, Devices: []domain.DeviceType{domain.DeviceMobile}, Destination: "https://m.example.de"},
+		{Countries: []string{"DE", "AT"}, Destination: "https://example.de"},
+		{Languages: []string{"fr"}, Destination: "https://example.fr"},
+		{Devices: []domain.DeviceType{domain.DeviceMobile, domain.DeviceTablet}, Destination: "https://m.example.com/{page}"},
+	}
+
+	tests := []struct {
+		name    string
+		entry   domain.CacheEntry
+		req     domain.RedirectRequest
+		wantURL string
+	}{
+		{
+			name:    "no match uses the original URL",
+			entry:   domain.CacheEntry{OriginalURL: "https://example.com", Routes: routes},
+			req:     domain.RedirectRequest{Country: "US", Device: domain.DeviceDesktop, Language: "en-us"},
+			wantURL: "https://example.com",
+		},
+		{
+			name:    "first matching rule wins",
+			entry:   domain.CacheEntry{OriginalURL: "https://example.com", Routes: routes},
+			req:     domain.RedirectRequest{Country: "DE", Device: domain.DeviceMobile},
+			wantURL: "https://m.example.de",
+		},
+		{
+			name:    "later rule when earlier conditions fail",
+			entry:   domain.CacheEntry{OriginalURL: "https://example.com", Routes: routes},
+			req:     domain.RedirectRequest{Country: "AT", Device: domain.DeviceDesktop},
+			wantURL: "https://example.de",
+		},
+		{
+			name:    "language matches regional variants",
+			entry:   domain.CacheEntry{OriginalURL: "https://example.com", Routes: routes},
+			req:     domain.RedirectRequest{Device: domain.DeviceDesktop, Language: "fr-ca"},
+			wantURL: "https://example.fr",
+		},
+		{
+			name:    "unknown country never matches a country rule",
+			entry:   domain.CacheEntry{OriginalURL: "https://example.com", Routes: routes[1:2]},
+			req:     domain.RedirectRequest{Device: domain.DeviceDesktop},
+			wantURL: "https://example.com",
+		},
+		{
+			name:    "template destinations are expanded",
+			entry:   domain.CacheEntry{OriginalURL: "https://example.com/{page}", Routes: routes},
+			req:     domain.RedirectRequest{Query: url.Values{"page": {"pricing"}}, Device: domain.DeviceTablet},
+			wantURL: "https://m.example.com/pricing",
+		},
+		{
+			name:    "link query parameters apply to routed destinations",
+			entry:   domain.CacheEntry{OriginalURL: "https://example.com", Routes: routes, QueryParams: map[string]string{"utm_source": "qr"}},
+			req:     domain.RedirectRequest{Country: "DE"},
+			wantURL: "https://example.de?utm_source=qr",
+		},
+		{
+			name:    "failover overrides routing",
+			entry:   domain.CacheEntry{OriginalURL: "https://example.com", BackupURL: "https://mirror.example.com", FailoverActive: true, Routes: routes},
+			req:     domain.RedirectRequest{Country: "DE"},
+			wantURL: "https://mirror.example.com",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &repoMocks.URLRepository{}
+			cache := &mocks.SyncableCache{}
+			cache.On("Get", ctx, "abc123").Return(&tt.entry, true)
+			cache.On("IncrementUsage", ctx, "abc123").Return(1, nil)
+
+			shortener := NewURLShortener(repo, cache, NewTestGenerator())
+			result, _, err := shortener.GetOriginalURL(ctx, "abc123", tt.req)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantURL, result)
+		})
+	}
+}
+
+func TestURLShortener_GetOriginalURL_RoutingFromDatabase(t *testing.T) {
+	ctx := context.Background()
+	repo := &repoMocks.URLRepository{}
+	cache := &mocks.SyncableCache{}
+	routes := []domain.RoutingRule{{Countries: []string{"DE"}, Destination: "https://example.de"}}
+
+	cache.On("Get", ctx, "abc123").Return(nil, false)
+	repo.On("GetURL", ctx, "abc123").Return(&domain.URLEntry{ShortCode: "abc123", OriginalURL: "https://example.com"}, nil)
+	repo.On("GetRoutingRules", ctx, "abc123").Return(routes, nil)
+	cache.On("Set", ctx, "abc123", mock.MatchedBy(func(entry *domain.CacheEntry) bool {
+		return assert.ObjectsAreEqual(routes, entry.Routes)
+	})).Return(nil)
+	cache.On("IncrementUsage", ctx, "abc123").Return(1, nil)
+
+	shortener := NewURLShortener(repo, cache, NewTestGenerator())
+	result, _, err := shortener.GetOriginalURL(ctx, "abc123", domain.RedirectRequest{Country: "DE"})
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.de", result)
+	cache.AssertExpectations(t)
+}
+
+func TestURLShortener_SetRoutingRules(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		entry   *domain.URLEntry
+		rules   []domain.RoutingRule
+		want    []domain.RoutingRule
+		wantErr string
+	}{
+		{
+			name:  "normalizes conditions",
+			entry: &domain.URLEntry{ShortCode: "abc123", OriginalURL: "https://example.com"},
+			rules: []domain.RoutingRule{
+				{Countries: []string{"de", " at "}, Devices: []domain.DeviceType{"Mobile"}, Languages: []string{"PT-BR"}, Destination: "https://example.de"},
+			},
+			want: []domain.RoutingRule{
+				{Countries: []string{"DE", "AT"}, Devices: []domain.DeviceType{domain.DeviceMobile}, Languages: []string{"pt-br"}, Destination: "https://example.de"},
+			},
+		},
+		{
+			name:  "empty list clears rules",
+			entry: &domain.URLEntry{ShortCode: "abc123", OriginalURL: "https://example.com"},
+			want:  []domain.RoutingRule{},
+		},
+		{
+			name:    "rule without conditions",
+			entry:   &domain.URLEntry{ShortCode: "abc123", OriginalURL: "https://example.com"},
+			rules:   []domain.RoutingRule{{Destination: "https://example.de"}},
+			wantErr: "routing rule 1 has no conditions",
+		},
+		{
+			name:    "invalid destination",
+			entry:   &domain.URLEntry{ShortCode: "abc123", OriginalURL: "https://example.com"},
+			rules:   []domain.RoutingRule{{Countries: []string{"DE"}, Destination: "ftp://example.de"}},
+			wantErr: "routing rule 1: invalid URL",
+		},
+		{
+			name:    "invalid country",
+			entry:   &domain.URLEntry{ShortCode: "abc123", OriginalURL: "https://example.com"},
+			rules:   []domain.RoutingRule{{Countries: []string{"Germany"}, Destination: "https://example.de"}},
+			wantErr: `invalid country "GERMANY"`,
+		},
+		{
+			name:    "invalid device",
+			entry:   &domain.URLEntry{ShortCode: "abc123", OriginalURL: "https://example.com"},
+			rules:   []domain.RoutingRule{{Devices: []domain.DeviceType{"watch"}, Destination: "https://example.de"}},
+			wantErr: `invalid device "watch"`,
+		},
+		{
+			name:    "invalid language",
+			entry:   &domain.URLEntry{ShortCode: "abc123", OriginalURL: "https://example.com"},
+			rules:   []domain.RoutingRule{{Languages: []string{"english!"}, Destination: "https://example.de"}},
+			wantErr: `invalid language "english!"`,
+		},
+		{
+			name:    "template destination with forwarded query",
+			entry:   &domain.URLEntry{ShortCode: "abc123", OriginalURL: "https://example.com", ForwardQuery: true},
+			rules:   []domain.RoutingRule{{Countries: []string{"DE"}, Destination: "https://example.de/{id}"}},
+			wantErr: "forward_query cannot be combined with a template URL",
+		},
+		{
+			name:    "too many rules",
+			entry:   &domain.URLEntry{ShortCode: "abc123", OriginalURL: "https://example.com"},
+			rules:   make([]domain.RoutingRule, domain.MaxRoutingRules+1),
+			wantErr: "at most 20 routing rules",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &repoMocks.URLRepository{}
+			cache := &mocks.SyncableCache{}
+			repo.On("GetURL", ctx, "abc123").Return(tt.entry, nil)
+			if tt.wantErr == "" {
+				repo.On("SetRoutingRules", ctx, "abc123", tt.want, mock.AnythingOfType("time.Time")).Return(nil)
+				cache.On("SetRoutes", ctx, "abc123", tt.want).Return(nil)
+			}
+
+			shortener := NewURLShortener(repo, cache, NewTestGenerator())
+			rules, err := shortener.SetRoutingRules(ctx, "abc123", tt.rules)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				repo.AssertNotCalled(t, "SetRoutingRules", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, rules)
+			repo.AssertExpectations(t)
+			cache.AssertExpectations(t)
+		})
+	}
+
+	t.Run("unknown short code", func(t *testing.T) {
+		repo := &repoMocks.URLRepository{}
+		repo.On("GetURL", ctx, "missing").Return(nil, domain.ErrURLNotFound)
+
+		shortener := NewURLShortener(repo, &mocks.SyncableCache{}, NewTestGenerator())
+		_, err := shortener.SetRoutingRules(ctx, "missing", nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "not found")
+	})
+}
+
+func TestURLShortener_GetRoutingRules(t *testing.T) {
+	ctx := context.Background()
+	repo := &repoMocks.URLRepository{}
+	routes := []domain.RoutingRule{{Countries: []string{"DE"}, Destination: "https://example.de"}}
+	repo.On("URLExists", ctx, "abc123").Return(true, nil)
+	repo.On("URLExists", ctx, "missing").Return(false, nil)
+	repo.On("GetRoutingRules", ctx, "abc123").Return(routes, nil)
+
+	shortener := NewURLShortener(repo, &mocks.SyncableCache{}, NewTestGenerator())
+	rules, err := shortener.GetRoutingRules(ctx, "abc123")
+	require.NoError(t, err)
+	assert.Equal(t, routes, rules)
+
+	_, err = shortener.GetRoutingRules(ctx, "missing")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not found")
+}
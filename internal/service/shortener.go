@@ -2,166 +2,989 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/joshdurbin/url-shortener/internal/cache"
+	"github.com/joshdurbin/url-shortener/internal/cache/response"
+	"github.com/joshdurbin/url-shortener/internal/destinations"
 	"github.com/joshdurbin/url-shortener/internal/domain"
+	"github.com/joshdurbin/url-shortener/internal/drain"
 	"github.com/joshdurbin/url-shortener/internal/repository"
 	"github.com/joshdurbin/url-shortener/internal/shortener"
+	"github.com/joshdurbin/url-shortener/internal/tracing"
 )
 
 // urlShortener implements URLShortener interface
 type urlShortener struct {
-	repo      repository.URLRepository
-	cache     cache.SyncableCache
-	generator shortener.Generator
+	repo       repository.URLRepository
+	cache      cache.SyncableCache
+	viewer     cache.Viewer // The cache, when it can return entries without copying them
+	generator  shortener.Generator
+	notifier   Notifier
+	peers      CacheInvalidator
+	cdn        CacheInvalidator // Purges the CDN's cached redirects of changed links
+	merge      domain.UsageMergeStrategy
+	blacklist  *shortener.Blacklist
+	destFilter *destinations.Filter // Allowed and denied destination hosts
+	verifier   DestinationVerifier  // Refuses destinations that do not answer
+	abuse      AbuseGuard           // Suspends links whose clicks look like click fraud
+	domains    DomainResolver       // Custom domains new links may be served on
+	aliasStore repository.AliasRepository
+	aliases    *aliasIndex // Every alias and its link, when aliases are configured
+	responses  *response.Cache
+	collisions *CollisionStats
+	clicks     *clickDeduper
+	botClicks  domain.BotClickMode
+	buffer     *ClickBuffer // Counts the clicks of uncapped links after their redirects
+	serveStale bool         // Redirect from expired cache entries while the database fails
+	syncer     usageSyncer
+	outbox     *EventOutbox   // Stores created events with their links and publishes them
+	writers    []drain.Writer // Background jobs paused while the service is drained
+	removedAt  atomic.Int64   // UnixNano of the last LastRemoval
+	draining   atomic.Bool    // Writes are refused until Resume
+	drainMu    sync.Mutex     // Serializes forced syncs, drains and resumes
+}
+
+// maxGenerateAttempts bounds how many blacklisted codes are skipped before
+// link creation gives up
+const maxGenerateAttempts = 10
+
+// Option configures optional service behavior
+type Option func(*urlShortener)
+
+// WithNotifier publishes link lifecycle and click events to the given
+// notifier, normally an events.Bus
+func WithNotifier(notifier Notifier) Option {
+	return func(s *urlShortener) {
+		s.notifier = notifier
+	}
+}
+
+// WithPeers tells the other instances sharing the database about every link
+// created, changed or deleted through the service, so they reload it
+func WithPeers(peers CacheInvalidator) Option {
+	return func(s *urlShortener) {
+		s.peers = peers
+	}
+}
+
+// WithCDN purges the redirects a CDN cached for every link created, changed
+// or deleted through the service, and for capped links when they run out
+func WithCDN(purger CacheInvalidator) Option {
+	return func(s *urlShortener) {
+		s.cdn = purger
+	}
+}
+
+// WithUsageMerge sets how cache syncs resolve usage counts written by other
+// instances sharing the database
+func WithUsageMerge(strategy domain.UsageMergeStrategy) Option {
+	return func(s *urlShortener) {
+		s.merge = strategy
+	}
+}
+
+// WithBlacklist rejects short codes that are reserved or contain a blocked
+// word before they are persisted
+func WithBlacklist(blacklist *shortener.Blacklist) Option {
+	return func(s *urlShortener) {
+		s.blacklist = blacklist
+	}
+}
+
+// WithDestinations rejects new destinations, backup URLs and routing rule
+// destinations that the filter's allow and deny lists do not permit
+func WithDestinations(filter *destinations.Filter) Option {
+	return func(s *urlShortener) {
+		s.destFilter = filter
+	}
+}
+
+// WithAbuseGuard screens every redirect of a known link with guard, which
+// can suspend links whose clicks look like click fraud
+func WithAbuseGuard(guard AbuseGuard) Option {
+	return func(s *urlShortener) {
+		s.abuse = guard
+	}
+}
+
+// WithVerifier asks the verifier whether each new link's destination, and
+// each changed URL, may be used before storing it
+func WithVerifier(verifier DestinationVerifier) Option {
+	return func(s *urlShortener) {
+		s.verifier = verifier
+	}
+}
+
+// WithDomains lets new links be served on the resolver's custom domains
+// instead of the server's own host
+func WithDomains(domains DomainResolver) Option {
+	return func(s *urlShortener) {
+		s.domains = domains
+	}
+}
+
+// WithResponseCache serves GetURLInfo and GetAllURLs from a short-lived
+// response cache; mutations through the service invalidate affected entries
+func WithResponseCache(responses *response.Cache) Option {
+	return func(s *urlShortener) {
+		s.responses = responses
+	}
+}
+
+// WithClickBuffer counts the clicks of uncapped links after their redirects
+// return, through buffer; it is drained by StopCacheSync
+func WithClickBuffer(buffer *ClickBuffer) Option {
+	return func(s *urlShortener) {
+		s.buffer = buffer
+	}
+}
+
+// WithCollisionStats counts generated short codes that were already taken
+// into stats, for reporting on /metrics
+func WithCollisionStats(stats *CollisionStats) Option {
+	return func(s *urlShortener) {
+		s.collisions = stats
+	}
+}
+
+// WithClickDedupe counts one click per client and link per window, for links
+// that do not set their own window (0 = count every click)
+func WithClickDedupe(window time.Duration) Option {
+	return func(s *urlShortener) {
+		s.clicks.window = window
+	}
+}
+
+// WithBotClicks sets how redirects by bots and crawlers are counted:
+// as clicks, not at all, or in a separate bot count
+func WithBotClicks(mode domain.BotClickMode) Option {
+	return func(s *urlShortener) {
+		s.botClicks = mode
+	}
+}
+
+// WithCircuitBreaker fails repository calls fast while the database is
+// failing; a nil breaker leaves calls unguarded
+func WithCircuitBreaker(breaker *CircuitBreaker) Option {
+	return func(s *urlShortener) {
+		if breaker != nil {
+			s.repo = &breakerRepository{URLRepository: s.repo, breaker: breaker}
+		}
+	}
+}
+
+// Response cache keys
+const responseListKey = "urls"
+
+func responseInfoKey(shortCode string) string {
+	return "url:" + shortCode
 }
 
 // NewURLShortener creates a new URL shortener service
-func NewURLShortener(repo repository.URLRepository, cache cache.SyncableCache, generator shortener.Generator) URLShortener {
-	return &urlShortener{
-		repo:      repo,
-		cache:     cache,
-		generator: generator,
+func NewURLShortener(repo repository.URLRepository, urlCache cache.SyncableCache, generator shortener.Generator, opts ...Option) URLShortener {
+	s := &urlShortener{
+		repo:       repo,
+		cache:      urlCache,
+		generator:  generator,
+		merge:      domain.UsageMergeDelta,
+		collisions: &CollisionStats{},
+		clicks:     newClickDeduper(0, maxDedupeClients),
+		botClicks:  domain.BotClicksCount,
+	}
+	s.viewer, _ = urlCache.(cache.Viewer)
+	s.removedAt.Store(time.Now().UnixNano())
+	for _, opt := range opts {
+		opt(s)
 	}
+	return s
 }
 
 // StartCacheSync starts the background cache synchronization
 func (s *urlShortener) StartCacheSync(ctx context.Context, interval time.Duration) error {
-	syncFunc := func(dirtyEntries map[string]*domain.CacheEntry) error {
+	syncFunc := func(dirtyEntries map[string]*domain.CacheEntry) (map[string]int, error) {
+		updates := make([]domain.UsageUpdate, 0, len(dirtyEntries))
 		for shortCode, entry := range dirtyEntries {
-			if err := s.repo.UpdateUsage(ctx, shortCode, entry.UsageCount, entry.LastUsedAt); err != nil {
-				return fmt.Errorf("failed to sync entry %s: %w", shortCode, err)
-			}
+			updates = append(updates, domain.UsageUpdate{
+				ShortCode:  shortCode,
+				UsageCount: entry.UsageCount,
+				Delta:      entry.PendingUsage(),
+				BotsDelta:  entry.PendingBots(),
+				LastUsedAt: entry.LastUsedAt,
+			})
 		}
-		return nil
+		return s.syncer.sync(updates, func() (map[string]int, error) {
+			counts, err := s.repo.UpdateUsageBatch(ctx, updates, s.merge)
+			if err != nil {
+				return nil, fmt.Errorf("failed to sync %d entries: %w", len(updates), err)
+			}
+			return counts, nil
+		})
 	}
+	s.syncer.start(interval)
 	
+	s.buffer.start(func(batch []bufferedClick) {
+		s.applyClicks(ctx, batch)
+	})
+	s.outbox.start(ctx, s.notifier)
 	return s.cache.StartBackgroundSync(ctx, interval, syncFunc)
 }
 
-// StopCacheSync stops the background cache synchronization
+// StopCacheSync stops the background cache synchronization, first counting
+// the clicks still buffered so the final sync includes them and publishing
+// the events left in the outbox. The final sync does not wait out a backoff,
+// and with a WAL keeps what it fails to write.
 func (s *urlShortener) StopCacheSync() error {
+	s.buffer.stop()
+	s.outbox.stop()
+	s.syncer.stopping.Store(true)
 	return s.cache.StopBackgroundSync()
 }
 
-// InitializeCache loads data from the repository into the cache
+// InitializeCache loads data from the repository into the cache, after
+// writing the usage a previous run left in the WAL
 func (s *urlShortener) InitializeCache(ctx context.Context) error {
+	if err := s.replayWAL(ctx); err != nil {
+		return err
+	}
+
 	data, err := s.repo.LoadCacheData(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to load cache data: %w", err)
 	}
+	if err := s.loadAliases(ctx); err != nil {
+		return err
+	}
 	
 	return s.cache.LoadData(ctx, data)
 }
 
 
 // CreateShortURL creates a new short URL
-func (s *urlShortener) CreateShortURL(ctx context.Context, originalURL string) (*domain.URLEntry, error) {
-	// Validate URL
-	parsedURL, err := url.ParseRequestURI(originalURL)
-	if err != nil {
-		return nil, fmt.Errorf("invalid URL: %w", err)
+func (s *urlShortener) CreateShortURL(ctx context.Context, originalURL string, opts domain.CreateOptions) (*domain.URLEntry, error) {
+	ctx, span := tracing.Start(ctx, "service.CreateShortURL")
+	defer span.End()
+
+	entry, err := s.createShortURL(ctx, originalURL, opts)
+	err = s.unavailable(err)
+	span.RecordError(err)
+	if entry != nil {
+		span.SetAttributes(tracing.String("url.short_code", entry.ShortCode))
 	}
-	
-	// Only allow HTTP and HTTPS schemes
-	if parsedURL.Scheme != "http" && parsedURL.Scheme != "https" {
-		return nil, fmt.Errorf("invalid URL: only HTTP and HTTPS are supported")
+	return entry, err
+}
+
+// createShortURL does the work of CreateShortURL
+func (s *urlShortener) createShortURL(ctx context.Context, originalURL string, opts domain.CreateOptions) (*domain.URLEntry, error) {
+	if err := s.writable(); err != nil {
+		return nil, err
+	}
+	opts, problems := s.checkCreate(originalURL, opts)
+	if len(problems) > 0 {
+		return nil, problems[0]
 	}
 
-	createdAt := time.Now()
-	shortCode, err := s.generator.GenerateShortCode(ctx, originalURL, createdAt)
-	if err != nil {
-		return nil, fmt.Errorf("failed to generate short code: %w", err)
+	if opts.ReuseExisting {
+		// Only the caller's own links are reused, so one credential never
+		// receives a link another one owns
+		existing, err := s.repo.GetURLByOriginalURL(ctx, originalURL, opts.Owner)
+		if err == nil {
+			return existing, nil
+		}
+		if !errors.Is(err, domain.ErrURLNotFound) {
+			return nil, fmt.Errorf("failed to look up existing URL: %w", err)
+		}
 	}
 
-	// Insert into database
-	entry, err := s.repo.CreateURL(ctx, shortCode, originalURL, createdAt)
+	if s.verifier != nil {
+		if err := s.verifier.Verify(ctx, originalURL); err != nil {
+			return nil, domain.Invalid("url", err)
+		}
+	}
+
+	createdAt := time.Now()
+	entry, err := s.insertGenerated(ctx, originalURL, createdAt, opts)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create URL: %w", err)
+		return nil, err
 	}
+	shortCode := entry.ShortCode
 
 	// Add to cache
 	cacheEntry := &domain.CacheEntry{
-		OriginalURL: originalURL,
-		UsageCount:  0,
-		LastUsedAt:  createdAt,
-		Dirty:       false,
+		OriginalURL:      originalURL,
+		UsageCount:       0,
+		MaxUses:          opts.MaxUses,
+		BurnAfterReading: opts.BurnAfterReading,
+		RedirectStatus:   opts.RedirectStatus,
+		BackupURL:        opts.BackupURL,
+		QueryParams:      opts.QueryParams,
+		ForwardQuery:     opts.ForwardQuery,
+		DedupeSeconds:    opts.DedupeSeconds,
+		Campaign:         opts.Campaign,
+		Domain:           opts.Domain,
+		LastUsedAt:       createdAt,
+		Dirty:            false,
 	}
 	if err := s.cache.Set(ctx, shortCode, cacheEntry); err != nil {
 		// Log error but don't fail the operation
 		fmt.Printf("Warning: failed to cache new entry %s: %v\n", shortCode, err)
 	}
 
+	describeTemplate(entry)
+	s.invalidateResponses(shortCode)
+	s.invalidatePeers(shortCode)
+	if s.outbox == nil {
+		// Otherwise the event was stored with the link and the outbox publishes it
+		s.notify(domain.EventURLCreated, domain.EventData{
+			ShortCode:   entry.ShortCode,
+			OriginalURL: entry.OriginalURL,
+			Campaign:    entry.Campaign,
+			MaxUses:     entry.MaxUses,
+		})
+	}
+
 	return entry, nil
 }
 
-// GetOriginalURL retrieves the original URL for a short code and increments usage
-func (s *urlShortener) GetOriginalURL(ctx context.Context, shortCode string) (string, error) {
-	// Try cache first
-	if entry, exists := s.cache.Get(ctx, shortCode); exists {
-		if err := s.cache.IncrementUsage(ctx, shortCode); err != nil {
-			// Log error but don't fail the operation
-			fmt.Printf("Warning: failed to increment usage in cache for %s: %v\n", shortCode, err)
+// insertGenerated stores the link under a generated code, generating another
+// when the code is already taken. The caller did not choose the code, so
+// running out of attempts is an internal error rather than a conflict.
+func (s *urlShortener) insertGenerated(ctx context.Context, originalURL string, createdAt time.Time, opts domain.CreateOptions) (*domain.URLEntry, error) {
+	for attempt := 1; ; attempt++ {
+		shortCode, err := s.generateShortCode(ctx, originalURL, createdAt)
+		if err != nil {
+			return nil, err
 		}
-		
-		return entry.OriginalURL, nil
+
+		entry, err := s.createURL(ctx, shortCode, originalURL, createdAt, opts)
+		if err == nil {
+			return entry, nil
+		}
+		if !errors.Is(err, domain.ErrShortCodeTaken) {
+			return nil, fmt.Errorf("failed to create URL: %w", err)
+		}
+		if attempt == maxCollisionAttempts {
+			s.collisions.failed.Add(1)
+			return nil, fmt.Errorf("failed to create URL: %d generated short codes already existed, the last was %s", attempt, shortCode)
+		}
+		s.collisions.retried.Add(1)
 	}
+}
 
-	// Fall back to database
-	entry, err := s.repo.GetURL(ctx, shortCode)
+// generateShortCode asks the generator for codes until one passes the blacklist
+func (s *urlShortener) generateShortCode(ctx context.Context, originalURL string, createdAt time.Time) (string, error) {
+	var blocked error
+	for attempt := 0; attempt < maxGenerateAttempts; attempt++ {
+		shortCode, err := s.generator.GenerateShortCode(ctx, originalURL, createdAt)
+		if err != nil {
+			return "", fmt.Errorf("failed to generate short code: %w", err)
+		}
+		if blocked = s.blacklist.Check(shortCode); blocked == nil {
+			return shortCode, nil
+		}
+	}
+	return "", fmt.Errorf("failed to generate an allowed short code after %d attempts: %w", maxGenerateAttempts, blocked)
+}
+
+// GetOriginalURL retrieves the original URL and redirect status for a short code and increments usage,
+// once per client within the link's click dedupe window; peeks never count.
+// A template link's placeholders are filled from params before the use is counted; other
+// links add their query parameters to the destination and, when forwarding, params too.
+func (s *urlShortener) GetOriginalURL(ctx context.Context, shortCode string, req domain.RedirectRequest) (string, int, error) {
+	ctx, span := tracing.Start(ctx, "service.GetOriginalURL", tracing.String("url.short_code", shortCode))
+	defer span.End()
+
+	destination, status, err := s.getOriginalURL(ctx, shortCode, req)
+	span.RecordError(err)
+	return destination, status, err
+}
+
+// getOriginalURL does the work of GetOriginalURL
+func (s *urlShortener) getOriginalURL(ctx context.Context, shortCode string, req domain.RedirectRequest) (string, int, error) {
+	// Links issued before a word was blocked stop redirecting once it is
+	if err := s.blacklist.Check(shortCode); err != nil {
+		return "", 0, fmt.Errorf("%w: %v", domain.ErrLinkBlocked, err)
+	}
+	// An alias redirects, and counts, as its link
+	if linked, ok := s.aliases.resolve(shortCode); ok {
+		shortCode = linked
+	}
+
+	entry, uses, exists := s.lookup(ctx, shortCode)
+	if !exists {
+		// Fall back to database
+		var err error
+		if entry, err = s.loadEntry(ctx, shortCode); err != nil {
+			// domain.ErrURLNotFound or a storage failure; keep them apart.
+			// While the database fails, an expired entry may still serve.
+			if entry, uses, exists = s.lookupStale(ctx, shortCode, err); !exists {
+				return "", 0, err
+			}
+		} else {
+			uses = entry.UsageCount
+		}
+	}
+	// Each host serves only its own links
+	if entry.Domain != req.Domain {
+		return "", 0, domain.ErrURLNotFound
+	}
+	// Links flagged for click fraud may be throttled or disabled for a while
+	if s.abuse != nil {
+		if err := s.abuse.Screen(shortCode, req); err != nil {
+			return "", 0, err
+		}
+	}
+	usedUp := entry.MaxUses > 0 && uses >= entry.MaxUses
+
+	destination, err := expandDestination(entry.Route(req), req.Query)
 	if err != nil {
-		return "", fmt.Errorf("short code not found")
+		return "", 0, fmt.Errorf("short code %s: %w", shortCode, err)
+	}
+	var forwarded url.Values
+	if entry.ForwardQuery {
+		forwarded = req.Query
+	}
+	if destination, err = appendQuery(destination, entry.QueryParams, forwarded); err != nil {
+		return "", 0, fmt.Errorf("short code %s: %w", shortCode, err)
 	}
 
-	// Add to cache and increment usage
-	cacheEntry := &domain.CacheEntry{
+	// A burn-after-reading link gives its destination to one counted visit
+	if entry.BurnAfterReading {
+		if usedUp {
+			return "", 0, fmt.Errorf("short code %s: %w", shortCode, domain.ErrUsageLimitReached)
+		}
+		if req.Peek || (req.Device == domain.DeviceBot && s.botClicks != domain.BotClicksCount) {
+			// Link checkers and chat unfurlers must neither read nor burn it
+			return "", 0, domain.NotFound(fmt.Errorf("short code %s only redirects visitors", shortCode))
+		}
+		return s.burn(ctx, shortCode, entry, destination, req)
+	}
+
+	// Peeks, such as HEAD requests checking the link, are never clicks
+	if req.Peek {
+		if usedUp {
+			return "", 0, fmt.Errorf("short code %s: %w", shortCode, domain.ErrUsageLimitReached)
+		}
+		return destination, entry.RedirectStatus, nil
+	}
+
+	// Unless bots count as clicks, their redirects neither use up the link nor
+	// send click events
+	if req.Device == domain.DeviceBot && s.botClicks != domain.BotClicksCount {
+		if usedUp {
+			return "", 0, fmt.Errorf("short code %s: %w", shortCode, domain.ErrUsageLimitReached)
+		}
+		if s.botClicks == domain.BotClicksSeparate {
+			if err := s.cache.IncrementBots(ctx, shortCode); err != nil {
+				// Log error but don't fail the operation
+				fmt.Printf("Warning: failed to count bot click in cache for %s: %v\n", shortCode, err)
+			}
+		}
+		return destination, entry.RedirectStatus, nil
+	}
+
+	// A client's repeated clicks within the link's dedupe window redirect
+	// without counting, unless the link has already been used up
+	if s.clicks.repeat(req.ClientIP, shortCode, s.clicks.windowFor(entry.DedupeSeconds), time.Now()) {
+		if usedUp {
+			return "", 0, fmt.Errorf("short code %s: %w", shortCode, domain.ErrUsageLimitReached)
+		}
+		return destination, entry.RedirectStatus, nil
+	}
+
+	var click domain.Click
+	if s.notifier != nil {
+		// The click rides along for analytics
+		click = newClick(shortCode, req)
+	}
+
+	// Clicks of uncapped links can be counted after the redirect, and with
+	// async counting so can capped links', checked against the clicks counted
+	// so far
+	if entry.MaxUses == 0 || s.buffer.async() {
+		if usedUp {
+			return "", 0, fmt.Errorf("short code %s: %w", shortCode, domain.ErrUsageLimitReached)
+		}
+		if s.buffer.enqueue(bufferedClick{shortCode: shortCode, originalURL: entry.OriginalURL, campaign: entry.Campaign, maxUses: entry.MaxUses, click: click}) {
+			return destination, entry.RedirectStatus, nil
+		}
+	}
+
+	// The cache checks the cap and increments atomically
+	usageCount, err := s.cache.IncrementUsage(ctx, shortCode)
+	if err != nil {
+		if errors.Is(err, domain.ErrUsageLimitReached) {
+			return "", 0, fmt.Errorf("short code %s: %w", shortCode, err)
+		}
+		// Log error but don't fail the operation
+		fmt.Printf("Warning: failed to increment usage in cache for %s: %v\n", shortCode, err)
+	}
+
+	s.notifyClick(domain.EventData{
+		ShortCode:   shortCode,
 		OriginalURL: entry.OriginalURL,
-		UsageCount:  entry.UsageCount + 1,
-		LastUsedAt:  time.Now(),
-		Dirty:       true,
+		Campaign:    entry.Campaign,
+		UsageCount:  usageCount,
+		MaxUses:     entry.MaxUses,
+	}, click)
+	return destination, entry.RedirectStatus, nil
+}
+
+// newClick describes a redirect of shortCode for the click analytics
+func newClick(shortCode string, req domain.RedirectRequest) domain.Click {
+	return domain.Click{
+		ShortCode: shortCode,
+		Referrer:  domain.ReferrerDomain(req.Referrer),
+		UTM:       domain.UTMFromQuery(req.Query),
+		Country:   req.Country,
+		Region:    req.Region,
+		At:        time.Now(),
 	}
-	if err := s.cache.Set(ctx, shortCode, cacheEntry); err != nil {
+}
+
+// loadEntry reads a link missing from the cache from the repository and
+// caches it
+func (s *urlShortener) loadEntry(ctx context.Context, shortCode string) (*domain.CacheEntry, error) {
+	dbEntry, err := s.repo.GetURL(ctx, shortCode)
+	if err != nil {
+		return nil, err
+	}
+
+	// Load into cache so the usage cap is enforced in one place
+	entry := &domain.CacheEntry{
+		OriginalURL:      dbEntry.OriginalURL,
+		UsageCount:       dbEntry.UsageCount,
+		MaxUses:          dbEntry.MaxUses,
+		BurnAfterReading: dbEntry.BurnAfterReading,
+		RedirectStatus:   dbEntry.RedirectStatus,
+		BackupURL:        dbEntry.BackupURL,
+		FailoverActive:   dbEntry.FailoverActive,
+		QueryParams:      dbEntry.QueryParams,
+		ForwardQuery:     dbEntry.ForwardQuery,
+		DedupeSeconds:    dbEntry.DedupeSeconds,
+		Campaign:         dbEntry.Campaign,
+		Domain:           dbEntry.Domain,
+		BotsCount:        dbEntry.BotsCount,
+		Dirty:            false,
+		SyncedCount:      dbEntry.UsageCount,
+		SyncedBots:       dbEntry.BotsCount,
+	}
+	if dbEntry.LastUsedAt != nil {
+		entry.LastUsedAt = *dbEntry.LastUsedAt
+	}
+	if entry.Routes, err = s.repo.GetRoutingRules(ctx, shortCode); err != nil {
+		return nil, fmt.Errorf("short code %s: %w", shortCode, err)
+	}
+	if err := s.cache.Set(ctx, shortCode, entry); err != nil {
 		// Log error but don't fail the operation
 		fmt.Printf("Warning: failed to cache entry %s: %v\n", shortCode, err)
 	}
+	return entry, nil
+}
+
+// notifyClick sends the clicked event of a counted redirect, and the expired
+// event when it used up the link
+func (s *urlShortener) notifyClick(data domain.EventData, click domain.Click) {
+	if s.notifier != nil {
+		// Copied here so the click only escapes when an event is sent
+		counted := click
+		event := domain.NewEvent(domain.EventURLClicked, data)
+		event.Click = &counted
+		s.notifier.Notify(event)
+	}
+	// Only the redirect that consumes the last use sees the count reach the cap
+	if data.MaxUses > 0 && data.UsageCount == data.MaxUses {
+		s.notify(domain.EventURLExpired, data)
+		// A cached redirect would keep working past the cap
+		s.purgeCDN(data.ShortCode)
+	}
+}
+
+// applyClicks counts a batch of buffered clicks, adding each link's at once,
+// and sends their events with the usage count each click reached, including
+// the expired event of the click that reached a capped link's cap
+func (s *urlShortener) applyClicks(ctx context.Context, batch []bufferedClick) {
+	added := make(map[string]int)
+	for _, click := range batch {
+		added[click.shortCode]++
+	}
+	// Each link's clicks take the counts after the ones before them
+	next := make(map[string]int, len(added))
+	var evicted []domain.UsageUpdate
+	for shortCode, count := range added {
+		usageCount, err := s.cache.AddUsage(ctx, shortCode, count)
+		if err != nil {
+			fmt.Printf("Warning: failed to add %d buffered clicks in cache for %s: %v\n", count, shortCode, err)
+			continue
+		}
+		if usageCount > 0 {
+			next[shortCode] = usageCount - count + 1
+			continue
+		}
+		// The entry left the cache after its redirect, so its clicks are
+		// written to the database instead
+		evicted = append(evicted, domain.UsageUpdate{ShortCode: shortCode, Delta: count, LastUsedAt: time.Now()})
+	}
+	if len(evicted) > 0 {
+		counts, err := s.repo.UpdateUsageBatch(ctx, evicted, domain.UsageMergeDelta)
+		if err != nil {
+			fmt.Printf("Warning: failed to write %d evicted links' buffered clicks: %v\n", len(evicted), err)
+		}
+		for _, update := range evicted {
+			if usageCount := counts[update.ShortCode]; usageCount > 0 {
+				next[update.ShortCode] = usageCount - update.Delta + 1
+			}
+		}
+	}
+
+	for _, click := range batch {
+		usageCount := next[click.shortCode]
+		if usageCount > 0 {
+			next[click.shortCode]++
+		}
+		s.notifyClick(domain.EventData{
+			ShortCode:   click.shortCode,
+			OriginalURL: click.originalURL,
+			Campaign:    click.campaign,
+			UsageCount:  usageCount,
+			MaxUses:     click.maxUses,
+		}, click.click)
+	}
+}
+
+// lookup returns a short code's cached entry and usage count. Caches that
+// can are asked for a shared entry, which must not be modified, so a
+// redirect from a warm cache does not copy it.
+func (s *urlShortener) lookup(ctx context.Context, shortCode string) (*domain.CacheEntry, int, bool) {
+	if s.viewer != nil {
+		return s.viewer.View(ctx, shortCode)
+	}
+	entry, exists := s.cache.Get(ctx, shortCode)
+	if !exists {
+		return nil, 0, false
+	}
+	return entry, entry.UsageCount, true
+}
 
-	return entry.OriginalURL, nil
+// describeTemplate lists a template link's placeholders on the entry
+func describeTemplate(entry *domain.URLEntry) {
+	if !domain.IsTemplate(entry.OriginalURL) {
+		return
+	}
+	if tmpl, err := domain.ParseTemplate(entry.OriginalURL); err == nil {
+		entry.TemplateParams = tmpl.Params
+	}
+}
+
+// expandDestination fills a template destination's placeholders from params;
+// other destinations are returned unchanged and ignore params
+func expandDestination(destination string, params url.Values) (string, error) {
+	if !domain.IsTemplate(destination) {
+		return destination, nil
+	}
+	tmpl, err := domain.ParseTemplate(destination)
+	if err != nil {
+		return "", fmt.Errorf("invalid URL template: %w", err)
+	}
+	return tmpl.Expand(params)
+}
+
+// appendQuery adds a link's query parameters, then forwarded ones, to the
+// destination's query. Parameters already present are never replaced, so the
+// destination's own query wins over the link's, and both win over forwarded ones.
+func appendQuery(destination string, params map[string]string, forwarded url.Values) (string, error) {
+	if len(params) == 0 && len(forwarded) == 0 {
+		return destination, nil
+	}
+
+	parsed, err := url.Parse(destination)
+	if err != nil {
+		return "", fmt.Errorf("invalid destination: %w", err)
+	}
+	existing := parsed.Query()
+
+	added := url.Values{}
+	for name, value := range params {
+		if _, ok := existing[name]; !ok {
+			added.Set(name, value)
+		}
+	}
+	for name, values := range forwarded {
+		if _, ok := existing[name]; ok {
+			continue
+		}
+		if _, ok := added[name]; !ok {
+			added[name] = values
+		}
+	}
+	if len(added) == 0 {
+		return destination, nil
+	}
+
+	// Append rather than re-encode so the destination's query is kept byte for byte
+	if parsed.RawQuery != "" {
+		parsed.RawQuery += "&"
+	}
+	parsed.RawQuery += added.Encode()
+	return parsed.String(), nil
 }
 
 // GetURLInfo retrieves detailed information about a short URL
 func (s *urlShortener) GetURLInfo(ctx context.Context, shortCode string) (*domain.URLEntry, error) {
+	ctx, span := tracing.Start(ctx, "service.GetURLInfo", tracing.String("url.short_code", shortCode))
+	defer span.End()
+
+	entry, err := s.getURLInfo(ctx, shortCode)
+	span.RecordError(err)
+	return entry, err
+}
+
+// getURLInfo does the work of GetURLInfo
+func (s *urlShortener) getURLInfo(ctx context.Context, shortCode string) (*domain.URLEntry, error) {
+	if cached, ok := s.responses.Get(responseInfoKey(shortCode)); ok {
+		entry := *cached.(*domain.URLEntry)
+		return &entry, nil
+	}
+
 	entry, err := s.repo.GetURL(ctx, shortCode)
 	if err != nil {
-		return nil, fmt.Errorf("short code not found")
+		return nil, err
 	}
 
 	// Update with cache data if available
 	if cacheEntry, exists := s.cache.Get(ctx, shortCode); exists {
 		entry.UsageCount = cacheEntry.UsageCount
+		entry.BotsCount = cacheEntry.BotsCount
 		entry.LastUsedAt = &cacheEntry.LastUsedAt
 	}
+	describeTemplate(entry)
 
+	cached := *entry
+	s.responses.Set(responseInfoKey(shortCode), &cached)
 	return entry, nil
 }
 
+// UpdateShortURL changes a short URL's destination, usage cap, tags, redirect status, backup URL, query parameters, campaign and/or click dedupe window
+func (s *urlShortener) UpdateShortURL(ctx context.Context, shortCode string, req domain.UpdateURLRequest) (*domain.URLEntry, error) {
+	ctx, span := tracing.Start(ctx, "service.UpdateShortURL", tracing.String("url.short_code", shortCode))
+	defer span.End()
+
+	entry, err := s.updateShortURL(ctx, shortCode, req)
+	err = s.unavailable(err)
+	span.RecordError(err)
+	return entry, err
+}
+
+// updateShortURL does the work of UpdateShortURL
+func (s *urlShortener) updateShortURL(ctx context.Context, shortCode string, req domain.UpdateURLRequest) (*domain.URLEntry, error) {
+	if err := s.writable(); err != nil {
+		return nil, err
+	}
+	entry, err := s.repo.GetURL(ctx, shortCode)
+	if err != nil {
+		return nil, err
+	}
+
+	originalURL := entry.OriginalURL
+	opts := domain.CreateOptions{
+		MaxUses:        entry.MaxUses,
+		Tags:           entry.Tags,
+		RedirectStatus: entry.RedirectStatus,
+		BackupURL:      entry.BackupURL,
+		QueryParams:    entry.QueryParams,
+		ForwardQuery:   entry.ForwardQuery,
+		Campaign:       entry.Campaign,
+		DedupeSeconds:  entry.DedupeSeconds,
+	}
+	if req.OriginalURL != nil {
+		if err := validateURL(*req.OriginalURL); err != nil {
+			return nil, domain.Invalid("url", err)
+		}
+		if err := s.destFilter.Check(*req.OriginalURL); err != nil {
+			return nil, domain.Invalid("url", err)
+		}
+		originalURL = *req.OriginalURL
+	}
+	if req.MaxUses != nil {
+		if *req.MaxUses < 0 {
+			return nil, domain.Invalid("max_uses", fmt.Errorf("max uses cannot be negative"))
+		}
+		if entry.BurnAfterReading && *req.MaxUses != 1 {
+			return nil, domain.Invalid("max_uses", fmt.Errorf("a burn-after-reading link has max_uses 1"))
+		}
+		opts.MaxUses = *req.MaxUses
+	}
+	if req.Tags != nil {
+		if opts.Tags, err = normalizeTags(*req.Tags); err != nil {
+			return nil, domain.Invalid("tags", err)
+		}
+	}
+	if req.RedirectStatus != nil {
+		if err := validateRedirectStatus(*req.RedirectStatus); err != nil {
+			return nil, domain.Invalid("redirect_status", err)
+		}
+		opts.RedirectStatus = *req.RedirectStatus
+	}
+	if req.BackupURL != nil {
+		opts.BackupURL = *req.BackupURL
+	}
+	if err := validateBackupURL(opts.BackupURL, originalURL); err != nil {
+		return nil, domain.Invalid("backup_url", err)
+	}
+	if req.BackupURL != nil && opts.BackupURL != "" {
+		if err := s.destFilter.Check(opts.BackupURL); err != nil {
+			return nil, domain.Invalid("backup_url", err)
+		}
+	}
+	if req.QueryParams != nil {
+		opts.QueryParams = *req.QueryParams
+	}
+	if req.ForwardQuery != nil {
+		opts.ForwardQuery = *req.ForwardQuery
+	}
+	if opts.QueryParams, err = validateQueryParams(opts.QueryParams, opts.ForwardQuery, originalURL); err != nil {
+		return nil, domain.Invalid("query_params", err)
+	}
+	if req.Campaign != nil {
+		if opts.Campaign, err = normalizeCampaign(*req.Campaign); err != nil {
+			return nil, domain.Invalid("campaign", err)
+		}
+	}
+	if req.DedupeSeconds != nil {
+		if err := validateDedupeSeconds(*req.DedupeSeconds); err != nil {
+			return nil, domain.Invalid("dedupe_seconds", err)
+		}
+		opts.DedupeSeconds = *req.DedupeSeconds
+	}
+	if s.verifier != nil && originalURL != entry.OriginalURL {
+		if err := s.verifier.Verify(ctx, originalURL); err != nil {
+			return nil, domain.Invalid("url", err)
+		}
+	}
+
+	updated, err := s.repo.UpdateURL(ctx, shortCode, originalURL, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update URL: %w", err)
+	}
+
+	s.invalidateResponses(shortCode)
+	s.invalidatePeers(shortCode)
+	if opts.Campaign != entry.Campaign {
+		s.markRemoval()
+	}
+	if err := s.cache.UpdateLink(ctx, shortCode, originalURL, opts); err != nil {
+		// Log error but don't fail the operation
+		fmt.Printf("Warning: failed to update cache entry %s: %v\n", shortCode, err)
+	}
+
+	// Without a backup there is nothing to fail over to
+	if updated.FailoverActive && opts.BackupURL == "" {
+		if updated, err = s.SetFailover(ctx, shortCode, false, "backup URL removed"); err != nil {
+			return nil, err
+		}
+	}
+
+	// Update with cache data if available
+	if cacheEntry, exists := s.cache.Get(ctx, shortCode); exists {
+		updated.UsageCount = cacheEntry.UsageCount
+		updated.BotsCount = cacheEntry.BotsCount
+		updated.LastUsedAt = &cacheEntry.LastUsedAt
+	}
+	describeTemplate(updated)
+
+	return updated, nil
+}
+
+// SetFailover switches a short URL's redirects to its backup URL (active) or
+// back to its original URL, recording why
+func (s *urlShortener) SetFailover(ctx context.Context, shortCode string, active bool, reason string) (*domain.URLEntry, error) {
+	if err := s.writable(); err != nil {
+		return nil, err
+	}
+	updated, err := s.repo.SetURLFailover(ctx, shortCode, active, reason, time.Now())
+	if err != nil {
+		return nil, s.unavailable(fmt.Errorf("failed to set failover: %w", err))
+	}
+	s.invalidateResponses(shortCode)
+	s.invalidatePeers(shortCode)
+
+	if err := s.cache.SetFailover(ctx, shortCode, active); err != nil {
+		// Log error but don't fail the operation
+		fmt.Printf("Warning: failed to update cache failover %s: %v\n", shortCode, err)
+	}
+
+	eventType := domain.EventURLRecovered
+	if active {
+		eventType = domain.EventURLFailover
+	}
+	s.notify(eventType, domain.EventData{
+		ShortCode:   updated.ShortCode,
+		OriginalURL: updated.OriginalURL,
+		Campaign:    updated.Campaign,
+		BackupURL:   updated.BackupURL,
+		Reason:      reason,
+	})
+
+	// Update with cache data if available
+	if cacheEntry, exists := s.cache.Get(ctx, shortCode); exists {
+		updated.UsageCount = cacheEntry.UsageCount
+		updated.BotsCount = cacheEntry.BotsCount
+		updated.LastUsedAt = &cacheEntry.LastUsedAt
+	}
+
+	return updated, nil
+}
+
 // DeleteShortURL removes a short URL
 func (s *urlShortener) DeleteShortURL(ctx context.Context, shortCode string) error {
+	ctx, span := tracing.Start(ctx, "service.DeleteShortURL", tracing.String("url.short_code", shortCode))
+	defer span.End()
+
+	err := s.unavailable(s.deleteShortURL(ctx, shortCode))
+	span.RecordError(err)
+	return err
+}
+
+// deleteShortURL does the work of DeleteShortURL
+func (s *urlShortener) deleteShortURL(ctx context.Context, shortCode string) error {
+	if err := s.writable(); err != nil {
+		return err
+	}
 	// Check if URL exists
 	exists, err := s.repo.URLExists(ctx, shortCode)
 	if err != nil {
 		return fmt.Errorf("failed to check URL existence: %w", err)
 	}
 	if !exists {
-		return fmt.Errorf("short code not found")
+		return domain.ErrURLNotFound
+	}
+
+	// The deleted event carries the campaign while the cache still knows it
+	data := domain.EventData{ShortCode: shortCode}
+	if s.notifier != nil {
+		if cached, ok := s.cache.Get(ctx, shortCode); ok {
+			data.Campaign = cached.Campaign
+		}
 	}
 
 	// Delete from database
 	if err := s.repo.DeleteURL(ctx, shortCode); err != nil {
 		return fmt.Errorf("failed to delete URL from database: %w", err)
 	}
+	s.invalidateResponses(shortCode)
+	// The database deleted the link's aliases with it
+	aliases := s.aliases.removeLink(shortCode)
+	s.invalidatePeers(shortCode)
+	for _, alias := range aliases {
+		s.invalidatePeers(alias)
+	}
+	s.markRemoval()
 
 	// Delete from cache
 	if err := s.cache.Delete(ctx, shortCode); err != nil {
@@ -169,25 +992,228 @@ func (s *urlShortener) DeleteShortURL(ctx context.Context, shortCode string) err
 		fmt.Printf("Warning: failed to delete from cache %s: %v\n", shortCode, err)
 	}
 
+	s.notify(domain.EventURLDeleted, data)
+
 	return nil
 }
 
 // GetAllURLs retrieves all short URLs with current cache data
 func (s *urlShortener) GetAllURLs(ctx context.Context) ([]*domain.URLEntry, error) {
+	if cached, ok := s.responses.Get(responseListKey); ok {
+		return cloneEntries(cached.([]*domain.URLEntry)), nil
+	}
+
 	entries, err := s.repo.GetAllURLs(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get URLs from database: %w", err)
 	}
 
-	// Update with cache data
+	s.applyCacheUsage(ctx, entries)
+
+	s.responses.Set(responseListKey, cloneEntries(entries))
+	return entries, nil
+}
+
+// GetURLsByOwner retrieves the short URLs created by an owner with current cache data
+func (s *urlShortener) GetURLsByOwner(ctx context.Context, owner string) ([]*domain.URLEntry, error) {
+	if owner == "" {
+		return nil, domain.Invalid("owner", fmt.Errorf("owner cannot be empty"))
+	}
+
+	entries, err := s.repo.GetURLsByOwner(ctx, owner)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get URLs from database: %w", err)
+	}
+
+	s.applyCacheUsage(ctx, entries)
+	return entries, nil
+}
+
+// applyCacheUsage replaces the usage stats of entries with the cache's
+// current, possibly unsynced, values
+func (s *urlShortener) applyCacheUsage(ctx context.Context, entries []*domain.URLEntry) {
 	for _, entry := range entries {
 		if cacheEntry, exists := s.cache.Get(ctx, entry.ShortCode); exists {
 			entry.UsageCount = cacheEntry.UsageCount
+			entry.BotsCount = cacheEntry.BotsCount
 			entry.LastUsedAt = &cacheEntry.LastUsedAt
 		}
 	}
+}
 
-	return entries, nil
+// LastRemoval returns when a link last left the listings
+func (s *urlShortener) LastRemoval() time.Time {
+	return time.Unix(0, s.removedAt.Load())
+}
+
+// markRemoval records that a link left a listing now
+func (s *urlShortener) markRemoval() {
+	s.removedAt.Store(time.Now().UnixNano())
+}
+
+// invalidateResponses drops cached responses that include a changed link
+func (s *urlShortener) invalidateResponses(shortCode string) {
+	s.responses.Invalidate(responseListKey, responseInfoKey(shortCode))
+}
+
+// cloneEntries copies entries so callers cannot modify cached responses
+func cloneEntries(entries []*domain.URLEntry) []*domain.URLEntry {
+	clones := make([]*domain.URLEntry, len(entries))
+	for i, entry := range entries {
+		clone := *entry
+		clones[i] = &clone
+	}
+	return clones
+}
+
+// CheckHealth reports whether the repository and cache are reachable. The
+// repository is required to serve requests; an unreachable cache backend
+// only degrades the service, since cache errors already fall back to the repository.
+func (s *urlShortener) CheckHealth(ctx context.Context) *domain.HealthResponse {
+	health := &domain.HealthResponse{
+		Status:     domain.HealthOK,
+		Components: make(map[string]domain.ComponentHealth),
+	}
+
+	if err := s.repo.Ping(ctx); err != nil {
+		health.Status = domain.HealthUnavailable
+		health.Components["database"] = domain.ComponentHealth{Status: domain.HealthUnavailable, Error: err.Error()}
+	} else {
+		health.Components["database"] = domain.ComponentHealth{Status: domain.HealthOK}
+	}
+
+	checker, ok := s.cache.(cache.HealthChecker)
+	if !ok {
+		// In-process caches cannot become unreachable
+		health.Components["cache"] = domain.ComponentHealth{Status: domain.HealthOK}
+		return health
+	}
+	if err := checker.Ping(ctx); err != nil {
+		if health.Status == domain.HealthOK {
+			health.Status = domain.HealthDegraded
+		}
+		health.Components["cache"] = domain.ComponentHealth{Status: domain.HealthDegraded, Error: err.Error()}
+	} else {
+		health.Components["cache"] = domain.ComponentHealth{Status: domain.HealthOK}
+	}
+
+	return health
+}
+
+// maxURLLength is the longest destination accepted. Browsers, proxies and
+// link previews commonly truncate or reject longer URLs.
+const maxURLLength = 2048
+
+// validateURL accepts only absolute HTTP and HTTPS URLs up to maxURLLength
+func validateURL(originalURL string) error {
+	if len(originalURL) > maxURLLength {
+		return fmt.Errorf("invalid URL: longer than %d characters", maxURLLength)
+	}
+
+	if domain.IsTemplate(originalURL) {
+		tmpl, err := domain.ParseTemplate(originalURL)
+		if err != nil {
+			return fmt.Errorf("invalid URL template: %w", err)
+		}
+		originalURL = tmpl.Sample()
+	}
+
+	parsedURL, err := url.ParseRequestURI(originalURL)
+	if err != nil {
+		return fmt.Errorf("invalid URL: %w", err)
+	}
+	
+	// Only allow HTTP and HTTPS schemes
+	if parsedURL.Scheme != "http" && parsedURL.Scheme != "https" {
+		return fmt.Errorf("invalid URL: only HTTP and HTTPS are supported")
+	}
+
+	return nil
+}
+
+// validateBackupURL accepts no backup, or an HTTP(S) URL other than the original
+func validateBackupURL(backupURL, originalURL string) error {
+	if backupURL == "" {
+		return nil
+	}
+	if err := validateURL(backupURL); err != nil {
+		return fmt.Errorf("invalid backup URL: %w", err)
+	}
+	if backupURL == originalURL {
+		return fmt.Errorf("backup URL must differ from the original URL")
+	}
+	return nil
+}
+
+// validateQueryParams checks the parameters a link adds to its destination,
+// returning nil for none. Template links cannot forward their query because
+// it fills their placeholders.
+func validateQueryParams(params map[string]string, forward bool, originalURL string) (map[string]string, error) {
+	if forward && domain.IsTemplate(originalURL) {
+		return nil, fmt.Errorf("forward_query cannot be combined with a template URL")
+	}
+	if len(params) == 0 {
+		return nil, nil
+	}
+	if len(params) > domain.MaxQueryParams {
+		return nil, fmt.Errorf("a link can have at most %d query parameters", domain.MaxQueryParams)
+	}
+	for name, value := range params {
+		if name == "" {
+			return nil, fmt.Errorf("query parameter names cannot be empty")
+		}
+		if len(name) > domain.MaxQueryParamLength || len(value) > domain.MaxQueryParamLength {
+			return nil, fmt.Errorf("query parameter %q: names and values are limited to %d characters", name, domain.MaxQueryParamLength)
+		}
+	}
+	return params, nil
+}
+
+// normalizeTags lowercases and de-duplicates tags, rejecting malformed ones
+func normalizeTags(tags []string) ([]string, error) {
+	if len(tags) == 0 {
+		return nil, nil
+	}
+
+	normalized := make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if !domain.ValidTag(tag) {
+			return nil, fmt.Errorf("invalid tag %q: use up to 32 letters, digits, '-' or '_'", tag)
+		}
+		if !seen[tag] {
+			seen[tag] = true
+			normalized = append(normalized, tag)
+		}
+	}
+	if len(normalized) > domain.MaxTags {
+		return nil, fmt.Errorf("a link can have at most %d tags", domain.MaxTags)
+	}
+	return normalized, nil
+}
+
+// validateRedirectStatus accepts 0 (the server default) or a supported redirect status
+func validateRedirectStatus(status int) error {
+	if status != 0 && !domain.ValidRedirectStatus(status) {
+		return fmt.Errorf("invalid redirect status %d: use 301, 302, 307 or 308", status)
+	}
+	return nil
+}
+
+// validateDedupeSeconds checks a link's click dedupe window
+func validateDedupeSeconds(seconds int) error {
+	if seconds < -1 || seconds > MaxDedupeSeconds {
+		return fmt.Errorf("dedupe seconds must be -1 (count every click), 0 (server default) or up to %d, got %d", MaxDedupeSeconds, seconds)
+	}
+	return nil
+}
+
+// notify publishes an event if a notifier is configured
+func (s *urlShortener) notify(eventType domain.EventType, data domain.EventData) {
+	if s.notifier != nil {
+		s.notifier.Notify(domain.NewEvent(eventType, data))
+	}
 }
 
 // Close closes the service and its dependencies
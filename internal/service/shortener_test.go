@@ -2,6 +2,10 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
 	"testing"
 	"time"
 
@@ -9,9 +13,13 @@ import (
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
+	cacheIface "github.com/joshdurbin/url-shortener/internal/cache"
+	"github.com/joshdurbin/url-shortener/internal/cache/memory"
 	"github.com/joshdurbin/url-shortener/internal/cache/mocks"
+	"github.com/joshdurbin/url-shortener/internal/cache/response"
 	"github.com/joshdurbin/url-shortener/internal/domain"
 	repoMocks "github.com/joshdurbin/url-shortener/internal/repository/mocks"
+	"github.com/joshdurbin/url-shortener/internal/shortener"
 )
 
 func TestURLShortener_CreateShortURL(t *testing.T) {
@@ -20,15 +28,190 @@ func TestURLShortener_CreateShortURL(t *testing.T) {
 	tests := []struct {
 		name        string
 		originalURL string
+		opts        domain.CreateOptions
 		setupMocks  func(*repoMocks.URLRepository, *mocks.SyncableCache)
 		wantErr     bool
 		errContains string
 	}{
+		{
+			name:        "creation with max uses",
+			originalURL: "https://example.com",
+			opts:        domain.CreateOptions{MaxUses: 2},
+			setupMocks: func(repo *repoMocks.URLRepository, cache *mocks.SyncableCache) {
+				repo.On("CreateURL", ctx, mock.AnythingOfType("string"), "https://example.com", mock.AnythingOfType("time.Time"), domain.CreateOptions{MaxUses: 2}).
+					Return(&domain.URLEntry{
+						ID:          1,
+						ShortCode:   "abc123",
+						OriginalURL: "https://example.com",
+						CreatedAt:   time.Now(),
+						MaxUses:     2,
+					}, nil)
+				cache.On("Set", ctx, mock.AnythingOfType("string"), mock.MatchedBy(func(entry *domain.CacheEntry) bool {
+					return entry.MaxUses == 2
+				})).Return(nil)
+			},
+			wantErr: false,
+		},
+		{
+			name:        "negative max uses",
+			originalURL: "https://example.com",
+			opts:        domain.CreateOptions{MaxUses: -1},
+			setupMocks:  func(repo *repoMocks.URLRepository, cache *mocks.SyncableCache) {},
+			wantErr:     true,
+			errContains: "max uses cannot be negative",
+		},
+		{
+			name:        "unsupported redirect status",
+			originalURL: "https://example.com",
+			opts:        domain.CreateOptions{RedirectStatus: http.StatusSeeOther},
+			setupMocks:  func(repo *repoMocks.URLRepository, cache *mocks.SyncableCache) {},
+			wantErr:     true,
+			errContains: "invalid redirect status 303",
+		},
+		{
+			name:        "forward query on template link",
+			originalURL: "https://example.com/item/{id}",
+			opts:        domain.CreateOptions{ForwardQuery: true},
+			setupMocks:  func(repo *repoMocks.URLRepository, cache *mocks.SyncableCache) {},
+			wantErr:     true,
+			errContains: "forward_query cannot be combined with a template URL",
+		},
+		{
+			name:        "empty query parameter name",
+			originalURL: "https://example.com",
+			opts:        domain.CreateOptions{QueryParams: map[string]string{"": "x"}},
+			setupMocks:  func(repo *repoMocks.URLRepository, cache *mocks.SyncableCache) {},
+			wantErr:     true,
+			errContains: "query parameter names cannot be empty",
+		},
+		{
+			name:        "reuse with query parameters",
+			originalURL: "https://example.com",
+			opts:        domain.CreateOptions{ReuseExisting: true, QueryParams: map[string]string{"utm_source": "mail"}},
+			setupMocks:  func(repo *repoMocks.URLRepository, cache *mocks.SyncableCache) {},
+			wantErr:     true,
+			errContains: "reuse_existing cannot be combined with query_params",
+		},
+		{
+			name:        "reuse existing link",
+			originalURL: "https://example.com",
+			opts:        domain.CreateOptions{ReuseExisting: true},
+			setupMocks: func(repo *repoMocks.URLRepository, cache *mocks.SyncableCache) {
+				repo.On("GetURLByOriginalURL", ctx, "https://example.com", "").
+					Return(&domain.URLEntry{ID: 7, ShortCode: "old123", OriginalURL: "https://example.com", UsageCount: 4}, nil)
+			},
+			wantErr: false,
+		},
+		{
+			name:        "reuse creates when no link exists",
+			originalURL: "https://example.com",
+			opts:        domain.CreateOptions{ReuseExisting: true},
+			setupMocks: func(repo *repoMocks.URLRepository, cache *mocks.SyncableCache) {
+				repo.On("GetURLByOriginalURL", ctx, "https://example.com", "").Return(nil, domain.ErrURLNotFound)
+				repo.On("CreateURL", ctx, mock.AnythingOfType("string"), "https://example.com", mock.AnythingOfType("time.Time"), domain.CreateOptions{ReuseExisting: true}).
+					Return(&domain.URLEntry{ID: 1, ShortCode: "abc123", OriginalURL: "https://example.com", CreatedAt: time.Now()}, nil)
+				cache.On("Set", ctx, mock.AnythingOfType("string"), mock.AnythingOfType("*domain.CacheEntry")).Return(nil)
+			},
+			wantErr: false,
+		},
+		{
+			name:        "reuse lookup error",
+			originalURL: "https://example.com",
+			opts:        domain.CreateOptions{ReuseExisting: true},
+			setupMocks: func(repo *repoMocks.URLRepository, cache *mocks.SyncableCache) {
+				repo.On("GetURLByOriginalURL", ctx, "https://example.com", "").Return(nil, assert.AnError)
+			},
+			wantErr:     true,
+			errContains: "failed to look up existing URL",
+		},
+		{
+			// Another credential's link to the same URL is not found, so the
+			// caller gets a link of its own
+			name:        "reuse only the caller's own link",
+			originalURL: "https://example.com",
+			opts:        domain.CreateOptions{ReuseExisting: true, Owner: "key:alice"},
+			setupMocks: func(repo *repoMocks.URLRepository, cache *mocks.SyncableCache) {
+				repo.On("GetURLByOriginalURL", ctx, "https://example.com", "key:alice").Return(nil, domain.ErrURLNotFound)
+				repo.On("CreateURL", ctx, mock.AnythingOfType("string"), "https://example.com", mock.AnythingOfType("time.Time"), domain.CreateOptions{ReuseExisting: true, Owner: "key:alice"}).
+					Return(&domain.URLEntry{ID: 2, ShortCode: "own123", OriginalURL: "https://example.com", Owner: "key:alice", CreatedAt: time.Now()}, nil)
+				cache.On("Set", ctx, mock.AnythingOfType("string"), mock.AnythingOfType("*domain.CacheEntry")).Return(nil)
+			},
+			wantErr: false,
+		},
+		{
+			name:        "reuse with tags",
+			originalURL: "https://example.com",
+			opts:        domain.CreateOptions{ReuseExisting: true, Tags: []string{"launch"}},
+			setupMocks:  func(repo *repoMocks.URLRepository, cache *mocks.SyncableCache) {},
+			wantErr:     true,
+			errContains: "cannot be combined with tags",
+		},
+		{
+			name:        "reuse with redirect status",
+			originalURL: "https://example.com",
+			opts:        domain.CreateOptions{ReuseExisting: true, RedirectStatus: 301},
+			setupMocks:  func(repo *repoMocks.URLRepository, cache *mocks.SyncableCache) {},
+			wantErr:     true,
+			errContains: "cannot be combined with redirect_status",
+		},
+		{
+			name:        "reuse with dedupe seconds",
+			originalURL: "https://example.com",
+			opts:        domain.CreateOptions{ReuseExisting: true, DedupeSeconds: 60},
+			setupMocks:  func(repo *repoMocks.URLRepository, cache *mocks.SyncableCache) {},
+			wantErr:     true,
+			errContains: "cannot be combined with dedupe_seconds",
+		},
+		{
+			name:        "reuse with max uses",
+			originalURL: "https://example.com",
+			opts:        domain.CreateOptions{ReuseExisting: true, MaxUses: 1},
+			setupMocks:  func(repo *repoMocks.URLRepository, cache *mocks.SyncableCache) {},
+			wantErr:     true,
+			errContains: "cannot be combined with max_uses",
+		},
+		{
+			name:        "creation with backup URL",
+			originalURL: "https://example.com",
+			opts:        domain.CreateOptions{BackupURL: "https://mirror.example.com"},
+			setupMocks: func(repo *repoMocks.URLRepository, cache *mocks.SyncableCache) {
+				repo.On("CreateURL", ctx, mock.AnythingOfType("string"), "https://example.com", mock.AnythingOfType("time.Time"), domain.CreateOptions{BackupURL: "https://mirror.example.com"}).
+					Return(&domain.URLEntry{ID: 1, ShortCode: "abc123", OriginalURL: "https://example.com", BackupURL: "https://mirror.example.com"}, nil)
+				cache.On("Set", ctx, mock.AnythingOfType("string"), mock.MatchedBy(func(entry *domain.CacheEntry) bool {
+					return entry.BackupURL == "https://mirror.example.com" && !entry.FailoverActive
+				})).Return(nil)
+			},
+			wantErr: false,
+		},
+		{
+			name:        "invalid backup URL",
+			originalURL: "https://example.com",
+			opts:        domain.CreateOptions{BackupURL: "ftp://mirror.example.com"},
+			setupMocks:  func(repo *repoMocks.URLRepository, cache *mocks.SyncableCache) {},
+			wantErr:     true,
+			errContains: "invalid backup URL",
+		},
+		{
+			name:        "backup URL same as original",
+			originalURL: "https://example.com",
+			opts:        domain.CreateOptions{BackupURL: "https://example.com"},
+			setupMocks:  func(repo *repoMocks.URLRepository, cache *mocks.SyncableCache) {},
+			wantErr:     true,
+			errContains: "must differ from the original URL",
+		},
+		{
+			name:        "reuse with backup URL",
+			originalURL: "https://example.com",
+			opts:        domain.CreateOptions{ReuseExisting: true, BackupURL: "https://mirror.example.com"},
+			setupMocks:  func(repo *repoMocks.URLRepository, cache *mocks.SyncableCache) {},
+			wantErr:     true,
+			errContains: "cannot be combined with backup_url",
+		},
 		{
 			name:        "successful creation",
 			originalURL: "https://example.com",
 			setupMocks: func(repo *repoMocks.URLRepository, cache *mocks.SyncableCache) {
-				repo.On("CreateURL", ctx, mock.AnythingOfType("string"), "https://example.com", mock.AnythingOfType("time.Time")).
+				repo.On("CreateURL", ctx, mock.AnythingOfType("string"), "https://example.com", mock.AnythingOfType("time.Time"), domain.CreateOptions{}).
 					Return(&domain.URLEntry{
 						ID:          1,
 						ShortCode:   "abc123",
@@ -53,7 +236,7 @@ func TestURLShortener_CreateShortURL(t *testing.T) {
 			name:        "repository error",
 			originalURL: "https://example.com",
 			setupMocks: func(repo *repoMocks.URLRepository, cache *mocks.SyncableCache) {
-				repo.On("CreateURL", ctx, mock.AnythingOfType("string"), "https://example.com", mock.AnythingOfType("time.Time")).
+				repo.On("CreateURL", ctx, mock.AnythingOfType("string"), "https://example.com", mock.AnythingOfType("time.Time"), domain.CreateOptions{}).
 					Return(nil, assert.AnError)
 			},
 			wantErr:     true,
@@ -70,7 +253,7 @@ func TestURLShortener_CreateShortURL(t *testing.T) {
 			
 			shortener := NewURLShortener(repo, cache, NewTestGenerator())
 			
-			result, err := shortener.CreateShortURL(ctx, tt.originalURL)
+			result, err := shortener.CreateShortURL(ctx, tt.originalURL, tt.opts)
 			
 			if tt.wantErr {
 				require.Error(t, err)
@@ -91,6 +274,43 @@ func TestURLShortener_CreateShortURL(t *testing.T) {
 	}
 }
 
+func TestURLShortener_LookupErrors(t *testing.T) {
+	ctx := context.Background()
+	storageErr := domain.Storage(errors.New("failed to get URL: disk I/O error"))
+
+	tests := []struct {
+		name    string
+		repoErr error
+		want    error
+		notWant error
+	}{
+		{"unknown code", domain.ErrURLNotFound, domain.ErrNotFound, domain.ErrStorage},
+		{"storage failure", storageErr, domain.ErrStorage, domain.ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &repoMocks.URLRepository{}
+			cache := &mocks.SyncableCache{}
+			repo.On("GetURL", ctx, "abc123").Return(nil, tt.repoErr)
+			cache.On("Get", ctx, "abc123").Return(nil, false)
+			shortener := NewURLShortener(repo, cache, NewTestGenerator())
+
+			_, err := shortener.GetURLInfo(ctx, "abc123")
+			assert.ErrorIs(t, err, tt.want)
+			assert.NotErrorIs(t, err, tt.notWant)
+
+			_, _, err = shortener.GetOriginalURL(ctx, "abc123", domain.RedirectRequest{})
+			assert.ErrorIs(t, err, tt.want)
+			assert.NotErrorIs(t, err, tt.notWant)
+
+			_, err = shortener.SetRoutingRules(ctx, "abc123", nil)
+			assert.ErrorIs(t, err, tt.want)
+			assert.NotErrorIs(t, err, tt.notWant)
+		})
+	}
+}
+
 func TestURLShortener_GetOriginalURL(t *testing.T) {
 	ctx := context.Background()
 	
@@ -99,6 +319,7 @@ func TestURLShortener_GetOriginalURL(t *testing.T) {
 		shortCode  string
 		setupMocks func(*repoMocks.URLRepository, *mocks.SyncableCache)
 		wantURL    string
+		wantStatus int
 		wantErr    bool
 	}{
 		{
@@ -113,7 +334,7 @@ func TestURLShortener_GetOriginalURL(t *testing.T) {
 					}, true)
 				
 				cache.On("IncrementUsage", ctx, "abc123").
-					Return(nil)
+					Return(1, nil)
 			},
 			wantURL: "https://example.com",
 			wantErr: false,
@@ -127,19 +348,59 @@ func TestURLShortener_GetOriginalURL(t *testing.T) {
 				
 				repo.On("GetURL", ctx, "abc123").
 					Return(&domain.URLEntry{
-						ID:          1,
-						ShortCode:   "abc123",
-						OriginalURL: "https://example.com",
-						CreatedAt:   time.Now(),
-						UsageCount:  0,
+						ID:             1,
+						ShortCode:      "abc123",
+						OriginalURL:    "https://example.com",
+						CreatedAt:      time.Now(),
+						UsageCount:     0,
+						RedirectStatus: http.StatusMovedPermanently,
 					}, nil)
+				repo.On("GetRoutingRules", ctx, "abc123").
+					Return([]domain.RoutingRule{}, nil)
 				
-				cache.On("Set", ctx, "abc123", mock.AnythingOfType("*domain.CacheEntry")).
-					Return(nil)
+				cache.On("Set", ctx, "abc123", mock.MatchedBy(func(entry *domain.CacheEntry) bool {
+					return entry.RedirectStatus == http.StatusMovedPermanently
+				})).Return(nil)
+				cache.On("IncrementUsage", ctx, "abc123").
+					Return(1, nil)
 			},
-			wantURL: "https://example.com",
+			wantURL:    "https://example.com",
+			wantStatus: http.StatusMovedPermanently,
+			wantErr:    false,
+		},
+		{
+			name:      "failover redirects to backup",
+			shortCode: "abc123",
+			setupMocks: func(repo *repoMocks.URLRepository, cache *mocks.SyncableCache) {
+				cache.On("Get", ctx, "abc123").
+					Return(&domain.CacheEntry{
+						OriginalURL:    "https://example.com",
+						BackupURL:      "https://mirror.example.com",
+						FailoverActive: true,
+					}, true)
+				cache.On("IncrementUsage", ctx, "abc123").
+					Return(1, nil)
+			},
+			wantURL: "https://mirror.example.com",
 			wantErr: false,
 		},
+		{
+			name:      "usage limit reached",
+			shortCode: "abc123",
+			setupMocks: func(repo *repoMocks.URLRepository, cache *mocks.SyncableCache) {
+				cache.On("Get", ctx, "abc123").
+					Return(&domain.CacheEntry{
+						OriginalURL: "https://example.com",
+						UsageCount:  1,
+						MaxUses:     1,
+						LastUsedAt:  time.Now(),
+					}, true)
+				cache.On("IncrementUsage", ctx, "abc123").
+					Return(1, domain.ErrUsageLimitReached)
+			},
+			wantURL: "",
+			wantErr: true,
+		},
 		{
 			name:      "not found anywhere",
 			shortCode: "notfound",
@@ -148,7 +409,7 @@ func TestURLShortener_GetOriginalURL(t *testing.T) {
 					Return(nil, false)
 				
 				repo.On("GetURL", ctx, "notfound").
-					Return(nil, assert.AnError)
+					Return(nil, domain.ErrURLNotFound)
 			},
 			wantURL: "",
 			wantErr: true,
@@ -164,7 +425,7 @@ func TestURLShortener_GetOriginalURL(t *testing.T) {
 			
 			shortener := NewURLShortener(repo, cache, NewTestGenerator())
 			
-			result, err := shortener.GetOriginalURL(ctx, tt.shortCode)
+			result, status, err := shortener.GetOriginalURL(ctx, tt.shortCode, domain.RedirectRequest{})
 			
 			if tt.wantErr {
 				require.Error(t, err)
@@ -172,6 +433,7 @@ func TestURLShortener_GetOriginalURL(t *testing.T) {
 			} else {
 				require.NoError(t, err)
 				assert.Equal(t, tt.wantURL, result)
+				assert.Equal(t, tt.wantStatus, status)
 			}
 			
 			repo.AssertExpectations(t)
@@ -213,7 +475,7 @@ func TestURLShortener_DeleteShortURL(t *testing.T) {
 					Return(false, nil)
 			},
 			wantErr:     true,
-			errContains: "short code not found",
+			errContains: "short URL not found",
 		},
 		{
 			name:      "repository error on deletion",
@@ -238,6 +500,7 @@ func TestURLShortener_DeleteShortURL(t *testing.T) {
 			tt.setupMocks(repo, cache)
 			
 			shortener := NewURLShortener(repo, cache, NewTestGenerator())
+			started := shortener.LastRemoval()
 			
 			err := shortener.DeleteShortURL(ctx, tt.shortCode)
 			
@@ -246,8 +509,10 @@ func TestURLShortener_DeleteShortURL(t *testing.T) {
 				if tt.errContains != "" {
 					assert.Contains(t, err.Error(), tt.errContains)
 				}
+				assert.Equal(t, started, shortener.LastRemoval())
 			} else {
 				require.NoError(t, err)
+				assert.False(t, shortener.LastRemoval().Before(started), "deletions move the listings' Last-Modified")
 			}
 			
 			repo.AssertExpectations(t)
@@ -322,49 +587,138 @@ func TestURLShortener_GetURLInfo(t *testing.T) {
 	}
 }
 
-func TestURLShortener_GetAllURLs(t *testing.T) {
+func TestURLShortener_UpdateShortURL(t *testing.T) {
 	ctx := context.Background()
-	
+	newURL := "https://example.com/new"
+	badURL := "ftp://example.com"
+	maxUses := 10
+	negative := -1
+	tags := []string{" Promo ", "promo", "q3"}
+	badTags := []string{"not a tag"}
+	permanent := http.StatusPermanentRedirect
+	seeOther := http.StatusSeeOther
+	existing := func() *domain.URLEntry {
+		return &domain.URLEntry{ID: 1, ShortCode: "abc123", OriginalURL: "https://example.com", MaxUses: 3, UsageCount: 2, Tags: []string{"temp"}}
+	}
+
 	tests := []struct {
 		name       string
+		shortCode  string
+		req        domain.UpdateURLRequest
 		setupMocks func(*repoMocks.URLRepository, *mocks.SyncableCache)
-		wantCount  int
-		wantErr    bool
+		wantURL    string
+		wantMax    int
+		wantErr    string
 	}{
 		{
-			name: "successful retrieval with URLs",
+			name:      "update destination keeps cap",
+			shortCode: "abc123",
+			req:       domain.UpdateURLRequest{OriginalURL: &newURL},
 			setupMocks: func(repo *repoMocks.URLRepository, cache *mocks.SyncableCache) {
-				repo.On("GetAllURLs", ctx).
-					Return([]*domain.URLEntry{
-						{ID: 1, ShortCode: "abc123", OriginalURL: "https://example.com"},
-						{ID: 2, ShortCode: "def456", OriginalURL: "https://google.com"},
-					}, nil)
-				// Cache miss for both entries
-				cache.On("Get", ctx, "abc123").
-					Return((*domain.CacheEntry)(nil), false)
-				cache.On("Get", ctx, "def456").
-					Return((*domain.CacheEntry)(nil), false)
+				repo.On("GetURL", ctx, "abc123").Return(existing(), nil)
+				repo.On("UpdateURL", ctx, "abc123", newURL, domain.CreateOptions{MaxUses: 3, Tags: []string{"temp"}}).
+					Return(&domain.URLEntry{ID: 1, ShortCode: "abc123", OriginalURL: newURL, MaxUses: 3, UsageCount: 2}, nil)
+				cache.On("UpdateLink", ctx, "abc123", newURL, domain.CreateOptions{MaxUses: 3, Tags: []string{"temp"}}).Return(nil)
+				cache.On("Get", ctx, "abc123").Return(&domain.CacheEntry{OriginalURL: newURL, UsageCount: 4, MaxUses: 3}, true)
 			},
-			wantCount: 2,
-			wantErr:   false,
+			wantURL: newURL,
+			wantMax: 3,
 		},
 		{
-			name: "empty list",
+			name:      "update cap keeps destination",
+			shortCode: "abc123",
+			req:       domain.UpdateURLRequest{MaxUses: &maxUses},
 			setupMocks: func(repo *repoMocks.URLRepository, cache *mocks.SyncableCache) {
-				repo.On("GetAllURLs", ctx).
-					Return([]*domain.URLEntry{}, nil)
+				repo.On("GetURL", ctx, "abc123").Return(existing(), nil)
+				repo.On("UpdateURL", ctx, "abc123", "https://example.com", domain.CreateOptions{MaxUses: maxUses, Tags: []string{"temp"}}).
+					Return(&domain.URLEntry{ID: 1, ShortCode: "abc123", OriginalURL: "https://example.com", MaxUses: maxUses}, nil)
+				cache.On("UpdateLink", ctx, "abc123", "https://example.com", domain.CreateOptions{MaxUses: maxUses, Tags: []string{"temp"}}).Return(nil)
+				cache.On("Get", ctx, "abc123").Return((*domain.CacheEntry)(nil), false)
 			},
-			wantCount: 0,
-			wantErr:   false,
+			wantURL: "https://example.com",
+			wantMax: maxUses,
 		},
 		{
-			name: "repository error",
+			name:      "update tags normalizes them",
+			shortCode: "abc123",
+			req:       domain.UpdateURLRequest{Tags: &tags},
 			setupMocks: func(repo *repoMocks.URLRepository, cache *mocks.SyncableCache) {
-				repo.On("GetAllURLs", ctx).
-					Return(nil, assert.AnError)
+				repo.On("GetURL", ctx, "abc123").Return(existing(), nil)
+				repo.On("UpdateURL", ctx, "abc123", "https://example.com", domain.CreateOptions{MaxUses: 3, Tags: []string{"promo", "q3"}}).
+					Return(&domain.URLEntry{ID: 1, ShortCode: "abc123", OriginalURL: "https://example.com", MaxUses: 3, Tags: []string{"promo", "q3"}}, nil)
+				cache.On("UpdateLink", ctx, "abc123", "https://example.com", domain.CreateOptions{MaxUses: 3, Tags: []string{"promo", "q3"}}).Return(nil)
+				cache.On("Get", ctx, "abc123").Return((*domain.CacheEntry)(nil), false)
 			},
-			wantCount: 0,
-			wantErr:   true,
+			wantURL: "https://example.com",
+			wantMax: 3,
+		},
+		{
+			name:      "update redirect status",
+			shortCode: "abc123",
+			req:       domain.UpdateURLRequest{RedirectStatus: &permanent},
+			setupMocks: func(repo *repoMocks.URLRepository, cache *mocks.SyncableCache) {
+				repo.On("GetURL", ctx, "abc123").Return(existing(), nil)
+				repo.On("UpdateURL", ctx, "abc123", "https://example.com", domain.CreateOptions{MaxUses: 3, Tags: []string{"temp"}, RedirectStatus: permanent}).
+					Return(&domain.URLEntry{ID: 1, ShortCode: "abc123", OriginalURL: "https://example.com", MaxUses: 3, RedirectStatus: permanent}, nil)
+				cache.On("UpdateLink", ctx, "abc123", "https://example.com", domain.CreateOptions{MaxUses: 3, Tags: []string{"temp"}, RedirectStatus: permanent}).Return(nil)
+				cache.On("Get", ctx, "abc123").Return((*domain.CacheEntry)(nil), false)
+			},
+			wantURL: "https://example.com",
+			wantMax: 3,
+		},
+		{
+			name:      "unsupported redirect status",
+			shortCode: "abc123",
+			req:       domain.UpdateURLRequest{RedirectStatus: &seeOther},
+			setupMocks: func(repo *repoMocks.URLRepository, cache *mocks.SyncableCache) {
+				repo.On("GetURL", ctx, "abc123").Return(existing(), nil)
+			},
+			wantErr: "invalid redirect status",
+		},
+		{
+			name:      "invalid tag",
+			shortCode: "abc123",
+			req:       domain.UpdateURLRequest{Tags: &badTags},
+			setupMocks: func(repo *repoMocks.URLRepository, cache *mocks.SyncableCache) {
+				repo.On("GetURL", ctx, "abc123").Return(existing(), nil)
+			},
+			wantErr: "invalid tag",
+		},
+		{
+			name:      "invalid URL",
+			shortCode: "abc123",
+			req:       domain.UpdateURLRequest{OriginalURL: &badURL},
+			setupMocks: func(repo *repoMocks.URLRepository, cache *mocks.SyncableCache) {
+				repo.On("GetURL", ctx, "abc123").Return(existing(), nil)
+			},
+			wantErr: "invalid URL",
+		},
+		{
+			name:      "negative max uses",
+			shortCode: "abc123",
+			req:       domain.UpdateURLRequest{MaxUses: &negative},
+			setupMocks: func(repo *repoMocks.URLRepository, cache *mocks.SyncableCache) {
+				repo.On("GetURL", ctx, "abc123").Return(existing(), nil)
+			},
+			wantErr: "max uses cannot be negative",
+		},
+		{
+			name:      "not found",
+			shortCode: "notfound",
+			req:       domain.UpdateURLRequest{OriginalURL: &newURL},
+			setupMocks: func(repo *repoMocks.URLRepository, cache *mocks.SyncableCache) {
+				repo.On("GetURL", ctx, "notfound").Return(nil, domain.ErrURLNotFound)
+			},
+			wantErr: "short URL not found",
+		},
+		{
+			name:      "storage failure is not reported as not found",
+			shortCode: "abc123",
+			req:       domain.UpdateURLRequest{OriginalURL: &newURL},
+			setupMocks: func(repo *repoMocks.URLRepository, cache *mocks.SyncableCache) {
+				repo.On("GetURL", ctx, "abc123").Return(nil, domain.Storage(errors.New("failed to get URL: database is locked")))
+			},
+			wantErr: "database is locked",
 		},
 	}
 
@@ -372,42 +726,185 @@ func TestURLShortener_GetAllURLs(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			repo := &repoMocks.URLRepository{}
 			cache := &mocks.SyncableCache{}
-			
+
 			tt.setupMocks(repo, cache)
-			
+
 			shortener := NewURLShortener(repo, cache, NewTestGenerator())
-			
-			result, err := shortener.GetAllURLs(ctx)
-			
-			if tt.wantErr {
+
+			result, err := shortener.UpdateShortURL(ctx, tt.shortCode, tt.req)
+
+			if tt.wantErr != "" {
 				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
 				assert.Nil(t, result)
 			} else {
 				require.NoError(t, err)
-				assert.Len(t, result, tt.wantCount)
+				assert.Equal(t, tt.wantURL, result.OriginalURL)
+				assert.Equal(t, tt.wantMax, result.MaxUses)
 			}
-			
+
 			repo.AssertExpectations(t)
 			cache.AssertExpectations(t)
 		})
 	}
 }
 
-func TestURLShortener_CacheOperations(t *testing.T) {
+// pingableCache is a cache mock backed by an external service
+type pingableCache struct {
+	*mocks.SyncableCache
+	err error
+}
+
+func (c *pingableCache) Ping(ctx context.Context) error {
+	return c.err
+}
+
+func TestURLShortener_CheckHealth(t *testing.T) {
 	ctx := context.Background()
-	
-	t.Run("InitializeCache", func(t *testing.T) {
-		repo := &repoMocks.URLRepository{}
-		cache := &mocks.SyncableCache{}
-			
-		cacheData := map[string]*domain.CacheEntry{
-			"abc123": {OriginalURL: "https://example.com", UsageCount: 1},
-			"def456": {OriginalURL: "https://google.com", UsageCount: 2},
-		}
-		
-		repo.On("LoadCacheData", ctx).Return(cacheData, nil)
-		cache.On("LoadData", ctx, cacheData).Return(nil)
-		
+
+	tests := []struct {
+		name           string
+		dbErr          error
+		cache          cacheIface.SyncableCache
+		expectedStatus domain.HealthStatus
+		expectedCache  domain.HealthStatus
+	}{
+		{
+			name:           "memory cache",
+			cache:          &mocks.SyncableCache{},
+			expectedStatus: domain.HealthOK,
+			expectedCache:  domain.HealthOK,
+		},
+		{
+			name:           "external cache reachable",
+			cache:          &pingableCache{SyncableCache: &mocks.SyncableCache{}},
+			expectedStatus: domain.HealthOK,
+			expectedCache:  domain.HealthOK,
+		},
+		{
+			name:           "external cache down degrades",
+			cache:          &pingableCache{SyncableCache: &mocks.SyncableCache{}, err: assert.AnError},
+			expectedStatus: domain.HealthDegraded,
+			expectedCache:  domain.HealthDegraded,
+		},
+		{
+			name:           "database down is unavailable",
+			dbErr:          assert.AnError,
+			cache:          &pingableCache{SyncableCache: &mocks.SyncableCache{}, err: assert.AnError},
+			expectedStatus: domain.HealthUnavailable,
+			expectedCache:  domain.HealthDegraded,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &repoMocks.URLRepository{}
+			repo.On("Ping", ctx).Return(tt.dbErr)
+
+			shortener := NewURLShortener(repo, tt.cache, NewTestGenerator())
+
+			health := shortener.CheckHealth(ctx)
+
+			assert.Equal(t, tt.expectedStatus, health.Status)
+			assert.Equal(t, tt.expectedCache, health.Components["cache"].Status)
+			if tt.dbErr != nil {
+				assert.Equal(t, domain.HealthUnavailable, health.Components["database"].Status)
+				assert.NotEmpty(t, health.Components["database"].Error)
+			} else {
+				assert.Equal(t, domain.HealthOK, health.Components["database"].Status)
+			}
+
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestURLShortener_GetAllURLs(t *testing.T) {
+	ctx := context.Background()
+	
+	tests := []struct {
+		name       string
+		setupMocks func(*repoMocks.URLRepository, *mocks.SyncableCache)
+		wantCount  int
+		wantErr    bool
+	}{
+		{
+			name: "successful retrieval with URLs",
+			setupMocks: func(repo *repoMocks.URLRepository, cache *mocks.SyncableCache) {
+				repo.On("GetAllURLs", ctx).
+					Return([]*domain.URLEntry{
+						{ID: 1, ShortCode: "abc123", OriginalURL: "https://example.com"},
+						{ID: 2, ShortCode: "def456", OriginalURL: "https://google.com"},
+					}, nil)
+				// Cache miss for both entries
+				cache.On("Get", ctx, "abc123").
+					Return((*domain.CacheEntry)(nil), false)
+				cache.On("Get", ctx, "def456").
+					Return((*domain.CacheEntry)(nil), false)
+			},
+			wantCount: 2,
+			wantErr:   false,
+		},
+		{
+			name: "empty list",
+			setupMocks: func(repo *repoMocks.URLRepository, cache *mocks.SyncableCache) {
+				repo.On("GetAllURLs", ctx).
+					Return([]*domain.URLEntry{}, nil)
+			},
+			wantCount: 0,
+			wantErr:   false,
+		},
+		{
+			name: "repository error",
+			setupMocks: func(repo *repoMocks.URLRepository, cache *mocks.SyncableCache) {
+				repo.On("GetAllURLs", ctx).
+					Return(nil, assert.AnError)
+			},
+			wantCount: 0,
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &repoMocks.URLRepository{}
+			cache := &mocks.SyncableCache{}
+			
+			tt.setupMocks(repo, cache)
+			
+			shortener := NewURLShortener(repo, cache, NewTestGenerator())
+			
+			result, err := shortener.GetAllURLs(ctx)
+			
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.Nil(t, result)
+			} else {
+				require.NoError(t, err)
+				assert.Len(t, result, tt.wantCount)
+			}
+			
+			repo.AssertExpectations(t)
+			cache.AssertExpectations(t)
+		})
+	}
+}
+
+func TestURLShortener_CacheOperations(t *testing.T) {
+	ctx := context.Background()
+	
+	t.Run("InitializeCache", func(t *testing.T) {
+		repo := &repoMocks.URLRepository{}
+		cache := &mocks.SyncableCache{}
+			
+		cacheData := map[string]*domain.CacheEntry{
+			"abc123": {OriginalURL: "https://example.com", UsageCount: 1},
+			"def456": {OriginalURL: "https://google.com", UsageCount: 2},
+		}
+		
+		repo.On("LoadCacheData", ctx).Return(cacheData, nil)
+		cache.On("LoadData", ctx, cacheData).Return(nil)
+		
 		shortener := NewURLShortener(repo, cache, NewTestGenerator())
 		err := shortener.InitializeCache(ctx)
 		
@@ -435,7 +932,7 @@ func TestURLShortener_CacheOperations(t *testing.T) {
 		cache := &mocks.SyncableCache{}
 			
 		syncInterval := 100 * time.Millisecond
-		cache.On("StartBackgroundSync", ctx, syncInterval, mock.AnythingOfType("func(map[string]*domain.CacheEntry) error")).Return(nil)
+		cache.On("StartBackgroundSync", ctx, syncInterval, mock.AnythingOfType("cache.SyncFunc")).Return(nil)
 		
 		shortener := NewURLShortener(repo, cache, NewTestGenerator())
 		err := shortener.StartCacheSync(ctx, syncInterval)
@@ -445,6 +942,31 @@ func TestURLShortener_CacheOperations(t *testing.T) {
 		cache.AssertExpectations(t)
 	})
 
+	t.Run("StartCacheSync merges usage", func(t *testing.T) {
+		repo := &repoMocks.URLRepository{}
+		cache := &mocks.SyncableCache{}
+		lastUsedAt := time.Now()
+
+		var syncFunc cacheIface.SyncFunc
+		cache.On("StartBackgroundSync", ctx, time.Second, mock.AnythingOfType("cache.SyncFunc")).
+			Run(func(args mock.Arguments) { syncFunc = args.Get(2).(cacheIface.SyncFunc) }).
+			Return(nil)
+		repo.On("UpdateUsageBatch", ctx, []domain.UsageUpdate{
+			{ShortCode: "abc123", UsageCount: 7, Delta: 2, LastUsedAt: lastUsedAt},
+		}, domain.UsageMergeMax).Return(map[string]int{"abc123": 9}, nil)
+
+		shortener := NewURLShortener(repo, cache, NewTestGenerator(), WithUsageMerge(domain.UsageMergeMax))
+		require.NoError(t, shortener.StartCacheSync(ctx, time.Second))
+
+		counts, err := syncFunc(map[string]*domain.CacheEntry{
+			"abc123": {UsageCount: 7, SyncedCount: 5, LastUsedAt: lastUsedAt, Dirty: true},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]int{"abc123": 9}, counts)
+		repo.AssertExpectations(t)
+		cache.AssertExpectations(t)
+	})
+
 	t.Run("StopCacheSync", func(t *testing.T) {
 		repo := &repoMocks.URLRepository{}
 		cache := &mocks.SyncableCache{}
@@ -482,7 +1004,7 @@ func TestURLShortener_ErrorHandling(t *testing.T) {
 		repo := &repoMocks.URLRepository{}
 		cache := &mocks.SyncableCache{}
 			
-		repo.On("CreateURL", ctx, mock.AnythingOfType("string"), "https://example.com", mock.AnythingOfType("time.Time")).
+		repo.On("CreateURL", ctx, mock.AnythingOfType("string"), "https://example.com", mock.AnythingOfType("time.Time"), domain.CreateOptions{}).
 			Return(&domain.URLEntry{
 				ID:          1,
 				ShortCode:   "abc123",
@@ -497,7 +1019,7 @@ func TestURLShortener_ErrorHandling(t *testing.T) {
 		shortener := NewURLShortener(repo, cache, NewTestGenerator())
 		
 		// Should still succeed even if cache fails
-		result, err := shortener.CreateShortURL(ctx, "https://example.com")
+		result, err := shortener.CreateShortURL(ctx, "https://example.com", domain.CreateOptions{})
 		require.NoError(t, err)
 		assert.NotNil(t, result)
 		
@@ -519,14 +1041,16 @@ func TestURLShortener_ErrorHandling(t *testing.T) {
 				CreatedAt:   time.Now(),
 				UsageCount:  0,
 			}, nil)
+		repo.On("GetRoutingRules", ctx, "abc123").Return([]domain.RoutingRule{}, nil)
 		
 		cache.On("Set", ctx, "abc123", mock.AnythingOfType("*domain.CacheEntry")).
 			Return(assert.AnError) // Cache set fails
+		cache.On("IncrementUsage", ctx, "abc123").Return(1, nil)
 		
 		shortener := NewURLShortener(repo, cache, NewTestGenerator())
 		
 		// Should still work even if cache set fails
-		result, err := shortener.GetOriginalURL(ctx, "abc123")
+		result, _, err := shortener.GetOriginalURL(ctx, "abc123", domain.RedirectRequest{})
 		require.NoError(t, err)
 		assert.Equal(t, "https://example.com", result)
 		
@@ -548,11 +1072,15 @@ func TestURLShortener_URLValidation(t *testing.T) {
 		"javascript:alert(1)",
 		"data:text/plain,hello",
 		"file:///etc/passwd",
+		"https://{host}/path",              // Placeholders only in the path, query or fragment
+		"https://example.com/item/{id",      // Unterminated placeholder
+		"https://example.com/item/{1d}",     // Malformed placeholder name
+		"https://example.com/{id}/{id=42}", // Conflicting defaults
 	}
 	
 	for _, url := range invalidURLs {
 		t.Run("invalid_url_"+url, func(t *testing.T) {
-			_, err := shortener.CreateShortURL(ctx, url)
+			_, err := shortener.CreateShortURL(ctx, url, domain.CreateOptions{})
 			assert.Error(t, err)
 			assert.Contains(t, err.Error(), "invalid URL")
 		})
@@ -564,11 +1092,12 @@ func TestURLShortener_URLValidation(t *testing.T) {
 		"https://example.com:8080",
 		"https://example.com/path?query=1",
 		"https://subdomain.example.com",
+		"https://example.com/item/{id}?page={page=1}",
 	}
 	
 	for _, url := range validURLs {
 		t.Run("valid_url_"+url, func(t *testing.T) {
-			repo.On("CreateURL", ctx, mock.AnythingOfType("string"), url, mock.AnythingOfType("time.Time")).
+			repo.On("CreateURL", ctx, mock.AnythingOfType("string"), url, mock.AnythingOfType("time.Time"), domain.CreateOptions{}).
 				Return(&domain.URLEntry{
 					ID:          1,
 					ShortCode:   "abc123",
@@ -580,7 +1109,7 @@ func TestURLShortener_URLValidation(t *testing.T) {
 			cache.On("Set", ctx, mock.AnythingOfType("string"), mock.AnythingOfType("*domain.CacheEntry")).
 				Return(nil)
 			
-			result, err := shortener.CreateShortURL(ctx, url)
+			result, err := shortener.CreateShortURL(ctx, url, domain.CreateOptions{})
 			assert.NoError(t, err)
 			assert.Equal(t, url, result.OriginalURL)
 		})
@@ -588,4 +1117,661 @@ func TestURLShortener_URLValidation(t *testing.T) {
 	
 	repo.AssertExpectations(t)
 	cache.AssertExpectations(t)
-}
\ No newline at end of file
+}
+// recordingNotifier collects published event types
+type recordingNotifier struct {
+	events []domain.EventType
+}
+
+func (n *recordingNotifier) Notify(event domain.Event) {
+	n.events = append(n.events, event.Type)
+}
+
+func TestURLShortener_Notifications(t *testing.T) {
+	ctx := context.Background()
+	repo := &repoMocks.URLRepository{}
+	cache := &mocks.SyncableCache{}
+	notifier := &recordingNotifier{}
+	shortener := NewURLShortener(repo, cache, NewTestGenerator(), WithNotifier(notifier))
+
+	repo.On("CreateURL", ctx, mock.AnythingOfType("string"), "https://example.com", mock.AnythingOfType("time.Time"), domain.CreateOptions{MaxUses: 2}).
+		Return(&domain.URLEntry{ShortCode: "abc123", OriginalURL: "https://example.com", MaxUses: 2}, nil)
+	cache.On("Set", ctx, mock.AnythingOfType("string"), mock.AnythingOfType("*domain.CacheEntry")).Return(nil)
+	_, err := shortener.CreateShortURL(ctx, "https://example.com", domain.CreateOptions{MaxUses: 2})
+	require.NoError(t, err)
+
+	cache.On("Get", ctx, "abc123").Return(&domain.CacheEntry{OriginalURL: "https://example.com", MaxUses: 2}, true)
+	cache.On("IncrementUsage", ctx, "abc123").Return(1, nil).Once()
+	cache.On("IncrementUsage", ctx, "abc123").Return(2, nil).Once()
+	cache.On("IncrementUsage", ctx, "abc123").Return(2, domain.ErrUsageLimitReached).Once()
+	for i := 0; i < 3; i++ {
+		shortener.GetOriginalURL(ctx, "abc123", domain.RedirectRequest{})
+	}
+
+	repo.On("URLExists", ctx, "abc123").Return(true, nil)
+	repo.On("DeleteURL", ctx, "abc123").Return(nil)
+	cache.On("Delete", ctx, "abc123").Return(nil)
+	require.NoError(t, shortener.DeleteShortURL(ctx, "abc123"))
+
+	// The last allowed redirect expires the link; the refused one publishes nothing
+	assert.Equal(t, []domain.EventType{
+		domain.EventURLCreated,
+		domain.EventURLClicked,
+		domain.EventURLClicked,
+		domain.EventURLExpired,
+		domain.EventURLDeleted,
+	}, notifier.events)
+}
+
+func TestURLShortener_SetFailover(t *testing.T) {
+	ctx := context.Background()
+	repo := &repoMocks.URLRepository{}
+	cache := &mocks.SyncableCache{}
+	notifier := &recordingNotifier{}
+	shortener := NewURLShortener(repo, cache, NewTestGenerator(), WithNotifier(notifier))
+
+	changedAt := time.Now()
+	repo.On("SetURLFailover", ctx, "abc123", true, "3 consecutive failed health checks", mock.AnythingOfType("time.Time")).
+		Return(&domain.URLEntry{
+			ShortCode:         "abc123",
+			OriginalURL:       "https://example.com",
+			BackupURL:         "https://mirror.example.com",
+			FailoverActive:    true,
+			FailoverReason:    "3 consecutive failed health checks",
+			FailoverChangedAt: &changedAt,
+		}, nil)
+	repo.On("SetURLFailover", ctx, "abc123", false, "2 consecutive successful health checks", mock.AnythingOfType("time.Time")).
+		Return(&domain.URLEntry{ShortCode: "abc123", OriginalURL: "https://example.com", BackupURL: "https://mirror.example.com"}, nil)
+	repo.On("SetURLFailover", ctx, "missing", true, "down", mock.AnythingOfType("time.Time")).
+		Return(nil, domain.ErrURLNotFound)
+	cache.On("SetFailover", ctx, "abc123", true).Return(nil)
+	cache.On("SetFailover", ctx, "abc123", false).Return(nil)
+	cache.On("Get", ctx, "abc123").Return(&domain.CacheEntry{UsageCount: 9, LastUsedAt: changedAt}, true)
+
+	entry, err := shortener.SetFailover(ctx, "abc123", true, "3 consecutive failed health checks")
+	require.NoError(t, err)
+	assert.True(t, entry.FailoverActive)
+	assert.Equal(t, 9, entry.UsageCount)
+
+	entry, err = shortener.SetFailover(ctx, "abc123", false, "2 consecutive successful health checks")
+	require.NoError(t, err)
+	assert.False(t, entry.FailoverActive)
+
+	_, err = shortener.SetFailover(ctx, "missing", true, "down")
+	assert.ErrorIs(t, err, domain.ErrURLNotFound)
+
+	assert.Equal(t, []domain.EventType{domain.EventURLFailover, domain.EventURLRecovered}, notifier.events)
+	repo.AssertExpectations(t)
+	cache.AssertExpectations(t)
+}
+
+func TestURLShortener_CreateShortURL_Blacklist(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("blacklisted codes are skipped", func(t *testing.T) {
+		repo := &repoMocks.URLRepository{}
+		cache := &mocks.SyncableCache{}
+		blacklist := shortener.NewBlacklist([]string{"TEST0001"}, []string{"0002"})
+		service := NewURLShortener(repo, cache, NewTestGenerator(), WithBlacklist(blacklist))
+
+		repo.On("CreateURL", ctx, "test0003", "https://example.com", mock.AnythingOfType("time.Time"), domain.CreateOptions{}).
+			Return(&domain.URLEntry{ShortCode: "test0003", OriginalURL: "https://example.com"}, nil)
+		cache.On("Set", ctx, "test0003", mock.AnythingOfType("*domain.CacheEntry")).Return(nil)
+
+		entry, err := service.CreateShortURL(ctx, "https://example.com", domain.CreateOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, "test0003", entry.ShortCode)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("gives up when every code is blacklisted", func(t *testing.T) {
+		repo := &repoMocks.URLRepository{}
+		blacklist := shortener.NewBlacklist(nil, []string{"test"})
+		service := NewURLShortener(repo, &mocks.SyncableCache{}, NewTestGenerator(), WithBlacklist(blacklist))
+
+		_, err := service.CreateShortURL(ctx, "https://example.com", domain.CreateOptions{})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to generate an allowed short code")
+		assert.Contains(t, err.Error(), "contains a blocked word")
+		repo.AssertNotCalled(t, "CreateURL", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestURLShortener_CreateShortURL_Collision(t *testing.T) {
+	ctx := context.Background()
+	taken := fmt.Errorf("short code test0001: %w", domain.ErrShortCodeTaken)
+
+	t.Run("taken codes are regenerated", func(t *testing.T) {
+		repo := &repoMocks.URLRepository{}
+		cache := &mocks.SyncableCache{}
+		stats := &CollisionStats{}
+		service := NewURLShortener(repo, cache, NewTestGenerator(), WithCollisionStats(stats))
+
+		repo.On("CreateURL", ctx, "test0001", "https://example.com", mock.AnythingOfType("time.Time"), domain.CreateOptions{}).
+			Return(nil, taken)
+		repo.On("CreateURL", ctx, "test0002", "https://example.com", mock.AnythingOfType("time.Time"), domain.CreateOptions{}).
+			Return(&domain.URLEntry{ShortCode: "test0002", OriginalURL: "https://example.com"}, nil)
+		cache.On("Set", ctx, "test0002", mock.AnythingOfType("*domain.CacheEntry")).Return(nil)
+
+		entry, err := service.CreateShortURL(ctx, "https://example.com", domain.CreateOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, "test0002", entry.ShortCode)
+		assert.Equal(t, uint64(1), stats.Retried())
+		assert.Equal(t, uint64(0), stats.Failed())
+		repo.AssertExpectations(t)
+		cache.AssertExpectations(t)
+	})
+
+	t.Run("gives up after bounded attempts", func(t *testing.T) {
+		repo := &repoMocks.URLRepository{}
+		stats := &CollisionStats{}
+		service := NewURLShortener(repo, &mocks.SyncableCache{}, NewTestGenerator(), WithCollisionStats(stats))
+
+		repo.On("CreateURL", ctx, mock.AnythingOfType("string"), "https://example.com", mock.AnythingOfType("time.Time"), domain.CreateOptions{}).
+			Return(nil, taken)
+
+		_, err := service.CreateShortURL(ctx, "https://example.com", domain.CreateOptions{})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "5 generated short codes already existed")
+		// The caller did not pick the code, so this is not a conflict for them to resolve
+		assert.NotErrorIs(t, err, domain.ErrConflict)
+		assert.Equal(t, uint64(maxCollisionAttempts-1), stats.Retried())
+		assert.Equal(t, uint64(1), stats.Failed())
+		repo.AssertNumberOfCalls(t, "CreateURL", maxCollisionAttempts)
+	})
+
+	t.Run("other errors are not retried", func(t *testing.T) {
+		repo := &repoMocks.URLRepository{}
+		service := NewURLShortener(repo, &mocks.SyncableCache{}, NewTestGenerator())
+
+		repo.On("CreateURL", ctx, "test0001", "https://example.com", mock.AnythingOfType("time.Time"), domain.CreateOptions{}).
+			Return(nil, domain.Storage(errors.New("disk full")))
+
+		_, err := service.CreateShortURL(ctx, "https://example.com", domain.CreateOptions{})
+		assert.ErrorIs(t, err, domain.ErrStorage)
+		repo.AssertNumberOfCalls(t, "CreateURL", 1)
+	})
+}
+
+func TestURLShortener_GetOriginalURL_Blacklist(t *testing.T) {
+	ctx := context.Background()
+	cache := &mocks.SyncableCache{}
+	blacklist := shortener.NewBlacklist([]string{"admin"}, []string{"0002"})
+	service := NewURLShortener(&repoMocks.URLRepository{}, cache, NewTestGenerator(), WithBlacklist(blacklist))
+
+	for _, code := range []string{"Admin", "test0002"} {
+		_, _, err := service.GetOriginalURL(ctx, code, domain.RedirectRequest{})
+		assert.ErrorIs(t, err, domain.ErrLinkBlocked, code)
+	}
+	cache.AssertNotCalled(t, "IncrementUsage", mock.Anything, mock.Anything)
+}
+
+func TestURLShortener_GetOriginalURL_Template(t *testing.T) {
+	ctx := context.Background()
+	const template = "https://example.com/item/{id}?page={page=1}&q={q=}"
+
+	tests := []struct {
+		name    string
+		params  url.Values
+		wantURL string
+		wantErr string
+	}{
+		{
+			name:    "defaults",
+			params:  url.Values{"id": {"42"}},
+			wantURL: "https://example.com/item/42?page=1&q=",
+		},
+		{
+			name:    "values are escaped",
+			params:  url.Values{"id": {"a/b c"}, "page": {"2"}, "q": {"x&y=z"}},
+			wantURL: "https://example.com/item/a%2Fb%20c?page=2&q=x%26y%3Dz",
+		},
+		{
+			name:    "missing required parameter",
+			params:  url.Values{"page": {"2"}},
+			wantErr: `missing parameter "id"`,
+		},
+		{
+			name:    "unknown parameter",
+			params:  url.Values{"id": {"42"}, "utm_source": {"mail"}},
+			wantErr: `unknown parameter "utm_source"`,
+		},
+		{
+			name:    "repeated parameter",
+			params:  url.Values{"id": {"1", "2"}},
+			wantErr: `parameter "id" given more than once`,
+		},
+		{
+			name:    "empty parameter",
+			params:  url.Values{"id": {""}},
+			wantErr: `parameter "id" is empty`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &repoMocks.URLRepository{}
+			cache := &mocks.SyncableCache{}
+			cache.On("Get", ctx, "abc123").Return(&domain.CacheEntry{OriginalURL: template}, true)
+			if tt.wantErr == "" {
+				cache.On("IncrementUsage", ctx, "abc123").Return(1, nil)
+			}
+
+			shortener := NewURLShortener(repo, cache, NewTestGenerator())
+			result, _, err := shortener.GetOriginalURL(ctx, "abc123", domain.RedirectRequest{Query: tt.params})
+
+			if tt.wantErr != "" {
+				require.ErrorIs(t, err, domain.ErrInvalidTemplateParams)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				// A rejected request does not use up the link
+				cache.AssertNotCalled(t, "IncrementUsage", ctx, "abc123")
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantURL, result)
+		})
+	}
+}
+
+func TestURLShortener_GetOriginalURL_QueryParams(t *testing.T) {
+	ctx := context.Background()
+	utm := map[string]string{"utm_source": "newsletter", "utm_campaign": "spring"}
+
+	tests := []struct {
+		name    string
+		entry   domain.CacheEntry
+		params  url.Values
+		wantURL string
+	}{
+		{
+			name:    "appends link parameters",
+			entry:   domain.CacheEntry{OriginalURL: "https://example.com/sale", QueryParams: utm},
+			wantURL: "https://example.com/sale?utm_campaign=spring&utm_source=newsletter",
+		},
+		{
+			name:    "keeps the destination's query and fragment",
+			entry:   domain.CacheEntry{OriginalURL: "https://example.com/sale?b=2&a=1&utm_source=site#top", QueryParams: utm},
+			wantURL: "https://example.com/sale?b=2&a=1&utm_source=site&utm_campaign=spring#top",
+		},
+		{
+			name:    "ignores incoming parameters without forwarding",
+			entry:   domain.CacheEntry{OriginalURL: "https://example.com/sale"},
+			params:  url.Values{"ref": {"friend"}},
+			wantURL: "https://example.com/sale",
+		},
+		{
+			name:    "forwards incoming parameters",
+			entry:   domain.CacheEntry{OriginalURL: "https://example.com/sale?a=1", ForwardQuery: true},
+			params:  url.Values{"ref": {"friend"}, "tag": {"x", "y"}},
+			wantURL: "https://example.com/sale?a=1&ref=friend&tag=x&tag=y",
+		},
+		{
+			name:    "link parameters win over forwarded ones",
+			entry:   domain.CacheEntry{OriginalURL: "https://example.com/sale?a=1", QueryParams: utm, ForwardQuery: true},
+			params:  url.Values{"a": {"9"}, "utm_source": {"spoofed"}, "ref": {"friend"}},
+			wantURL: "https://example.com/sale?a=1&ref=friend&utm_campaign=spring&utm_source=newsletter",
+		},
+		{
+			name:    "applies to the backup during failover",
+			entry:   domain.CacheEntry{OriginalURL: "https://example.com", BackupURL: "https://mirror.example.com", FailoverActive: true, QueryParams: utm},
+			wantURL: "https://mirror.example.com?utm_campaign=spring&utm_source=newsletter",
+		},
+		{
+			name:    "template links append after expansion",
+			entry:   domain.CacheEntry{OriginalURL: "https://example.com/item/{id}", QueryParams: map[string]string{"utm_source": "qr"}},
+			params:  url.Values{"id": {"42"}},
+			wantURL: "https://example.com/item/42?utm_source=qr",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &repoMocks.URLRepository{}
+			cache := &mocks.SyncableCache{}
+			cache.On("Get", ctx, "abc123").Return(&tt.entry, true)
+			cache.On("IncrementUsage", ctx, "abc123").Return(1, nil)
+
+			shortener := NewURLShortener(repo, cache, NewTestGenerator())
+			result, _, err := shortener.GetOriginalURL(ctx, "abc123", domain.RedirectRequest{Query: tt.params})
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantURL, result)
+		})
+	}
+}
+
+func TestURLShortener_UpdateShortURL_QueryParams(t *testing.T) {
+	ctx := context.Background()
+	repo := &repoMocks.URLRepository{}
+	cache := &mocks.SyncableCache{}
+	existing := &domain.URLEntry{ShortCode: "abc123", OriginalURL: "https://example.com", QueryParams: map[string]string{"utm_source": "mail"}}
+	repo.On("GetURL", ctx, "abc123").Return(existing, nil)
+
+	forward := true
+	wantOpts := domain.CreateOptions{QueryParams: map[string]string{"utm_source": "mail"}, ForwardQuery: true}
+	repo.On("UpdateURL", ctx, "abc123", "https://example.com", wantOpts).
+		Return(&domain.URLEntry{ShortCode: "abc123", OriginalURL: "https://example.com", QueryParams: wantOpts.QueryParams, ForwardQuery: true}, nil)
+	cache.On("UpdateLink", ctx, "abc123", "https://example.com", wantOpts).Return(nil)
+	cache.On("Get", ctx, "abc123").Return(nil, false)
+
+	shortener := NewURLShortener(repo, cache, NewTestGenerator())
+	updated, err := shortener.UpdateShortURL(ctx, "abc123", domain.UpdateURLRequest{ForwardQuery: &forward})
+	require.NoError(t, err)
+	assert.True(t, updated.ForwardQuery)
+	assert.Equal(t, "mail", updated.QueryParams["utm_source"])
+
+	// Clearing the parameters with an empty object
+	none := map[string]string{}
+	clearedOpts := domain.CreateOptions{ForwardQuery: false}
+	repo.On("UpdateURL", ctx, "abc123", "https://example.com", clearedOpts).
+		Return(&domain.URLEntry{ShortCode: "abc123", OriginalURL: "https://example.com"}, nil)
+	cache.On("UpdateLink", ctx, "abc123", "https://example.com", clearedOpts).Return(nil)
+
+	updated, err = shortener.UpdateShortURL(ctx, "abc123", domain.UpdateURLRequest{QueryParams: &none})
+	require.NoError(t, err)
+	assert.Empty(t, updated.QueryParams)
+}
+
+func TestURLShortener_GetURLInfo_Template(t *testing.T) {
+	ctx := context.Background()
+	repo := &repoMocks.URLRepository{}
+	cache := &mocks.SyncableCache{}
+	repo.On("GetURL", ctx, "abc123").Return(&domain.URLEntry{
+		ShortCode:   "abc123",
+		OriginalURL: "https://example.com/item/{id}?page={page=1}",
+	}, nil)
+	cache.On("Get", ctx, "abc123").Return(nil, false)
+
+	entry, err := NewURLShortener(repo, cache, NewTestGenerator()).GetURLInfo(ctx, "abc123")
+	require.NoError(t, err)
+	assert.Equal(t, []domain.TemplateParam{
+		{Name: "id", Required: true},
+		{Name: "page", Default: "1"},
+	}, entry.TemplateParams)
+}
+
+func TestURLShortener_ResponseCache(t *testing.T) {
+	ctx := context.Background()
+	repo := &repoMocks.URLRepository{}
+	cache := &mocks.SyncableCache{}
+	responses := response.New(time.Minute)
+
+	repo.On("GetURL", ctx, "abc123").Return(&domain.URLEntry{ShortCode: "abc123", OriginalURL: "https://example.com"}, nil)
+	repo.On("GetAllURLs", ctx).Return([]*domain.URLEntry{{ShortCode: "abc123", OriginalURL: "https://example.com"}}, nil)
+	repo.On("URLExists", ctx, "abc123").Return(true, nil)
+	repo.On("DeleteURL", ctx, "abc123").Return(nil)
+	cache.On("Get", ctx, "abc123").Return(nil, false)
+	cache.On("Delete", ctx, "abc123").Return(nil)
+
+	shortener := NewURLShortener(repo, cache, NewTestGenerator(), WithResponseCache(responses))
+
+	for i := 0; i < 3; i++ {
+		entry, err := shortener.GetURLInfo(ctx, "abc123")
+		require.NoError(t, err)
+		// Callers get copies, so changing one does not change the cached response
+		entry.UsageCount = 99
+
+		entries, err := shortener.GetAllURLs(ctx)
+		require.NoError(t, err)
+		entries[0].UsageCount = 99
+	}
+	repo.AssertNumberOfCalls(t, "GetURL", 1)
+	repo.AssertNumberOfCalls(t, "GetAllURLs", 1)
+
+	entry, err := shortener.GetURLInfo(ctx, "abc123")
+	require.NoError(t, err)
+	assert.Zero(t, entry.UsageCount)
+
+	// A mutation invalidates the link's info and the list
+	require.NoError(t, shortener.DeleteShortURL(ctx, "abc123"))
+	_, err = shortener.GetURLInfo(ctx, "abc123")
+	require.NoError(t, err)
+	_, err = shortener.GetAllURLs(ctx)
+	require.NoError(t, err)
+	repo.AssertNumberOfCalls(t, "GetURL", 2)
+	repo.AssertNumberOfCalls(t, "GetAllURLs", 2)
+
+	stats := responses.Stats()
+	assert.Equal(t, uint64(5), stats.Hits)
+	assert.Equal(t, uint64(4), stats.Misses)
+}
+
+func TestURLShortener_GetURLsByOwner(t *testing.T) {
+	ctx := context.Background()
+	repo := &repoMocks.URLRepository{}
+	cache := &mocks.SyncableCache{}
+	repo.On("GetURLsByOwner", ctx, "key:alice").Return([]*domain.URLEntry{{ShortCode: "a", Owner: "key:alice", UsageCount: 1}}, nil)
+	cache.On("Get", ctx, "a").Return(&domain.CacheEntry{UsageCount: 5}, true)
+
+	shortener := NewURLShortener(repo, cache, NewTestGenerator())
+	entries, err := shortener.GetURLsByOwner(ctx, "key:alice")
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, 5, entries[0].UsageCount)
+
+	_, err = shortener.GetURLsByOwner(ctx, "")
+	assert.Error(t, err)
+}
+
+func TestURLShortener_GetOriginalURL_BotClicks(t *testing.T) {
+	ctx := context.Background()
+	bot := domain.RedirectRequest{Device: domain.DeviceBot}
+	entry := &domain.CacheEntry{OriginalURL: "https://example.com", RedirectStatus: http.StatusFound}
+
+	t.Run("count", func(t *testing.T) {
+		repo := &repoMocks.URLRepository{}
+		cache := &mocks.SyncableCache{}
+		cache.On("Get", ctx, "abc123").Return(entry, true)
+		cache.On("IncrementUsage", ctx, "abc123").Return(1, nil)
+
+		shortener := NewURLShortener(repo, cache, NewTestGenerator())
+		_, _, err := shortener.GetOriginalURL(ctx, "abc123", bot)
+		require.NoError(t, err)
+
+		cache.AssertNumberOfCalls(t, "IncrementUsage", 1)
+	})
+
+	t.Run("exclude", func(t *testing.T) {
+		repo := &repoMocks.URLRepository{}
+		cache := &mocks.SyncableCache{}
+		cache.On("Get", ctx, "abc123").Return(entry, true)
+		cache.On("IncrementUsage", ctx, "abc123").Return(1, nil)
+
+		shortener := NewURLShortener(repo, cache, NewTestGenerator(), WithBotClicks(domain.BotClicksExclude))
+		destination, status, err := shortener.GetOriginalURL(ctx, "abc123", bot)
+		require.NoError(t, err)
+		assert.Equal(t, "https://example.com", destination)
+		assert.Equal(t, http.StatusFound, status)
+		cache.AssertNotCalled(t, "IncrementUsage", ctx, "abc123")
+
+		// People are still counted
+		_, _, err = shortener.GetOriginalURL(ctx, "abc123", domain.RedirectRequest{Device: domain.DeviceDesktop})
+		require.NoError(t, err)
+		cache.AssertNumberOfCalls(t, "IncrementUsage", 1)
+	})
+
+	t.Run("separate", func(t *testing.T) {
+		repo := &repoMocks.URLRepository{}
+		cache := &mocks.SyncableCache{}
+		cache.On("Get", ctx, "abc123").Return(entry, true)
+		cache.On("IncrementBots", ctx, "abc123").Return(nil)
+
+		shortener := NewURLShortener(repo, cache, NewTestGenerator(), WithBotClicks(domain.BotClicksSeparate))
+		_, _, err := shortener.GetOriginalURL(ctx, "abc123", bot)
+		require.NoError(t, err)
+
+		cache.AssertNumberOfCalls(t, "IncrementBots", 1)
+		cache.AssertNotCalled(t, "IncrementUsage", ctx, "abc123")
+	})
+
+	t.Run("used up links reject bots", func(t *testing.T) {
+		repo := &repoMocks.URLRepository{}
+		cache := &mocks.SyncableCache{}
+		cache.On("Get", ctx, "abc123").Return(&domain.CacheEntry{OriginalURL: "https://example.com", UsageCount: 1, MaxUses: 1}, true)
+
+		shortener := NewURLShortener(repo, cache, NewTestGenerator(), WithBotClicks(domain.BotClicksSeparate))
+		_, _, err := shortener.GetOriginalURL(ctx, "abc123", bot)
+		require.ErrorIs(t, err, domain.ErrUsageLimitReached)
+	})
+}
+
+func TestURLShortener_GetOriginalURL_Peek(t *testing.T) {
+	ctx := context.Background()
+	peek := domain.RedirectRequest{Peek: true, ClientIP: "192.0.2.1"}
+
+	cache := &mocks.SyncableCache{}
+	cache.On("Get", ctx, "abc123").Return(&domain.CacheEntry{OriginalURL: "https://example.com", MaxUses: 5, RedirectStatus: http.StatusMovedPermanently}, true)
+	cache.On("Get", ctx, "used").Return(&domain.CacheEntry{OriginalURL: "https://example.com", UsageCount: 1, MaxUses: 1}, true)
+	events := &clickLog{}
+	shortener := NewURLShortener(&repoMocks.URLRepository{}, cache, NewTestGenerator(), WithNotifier(events))
+
+	destination, status, err := shortener.GetOriginalURL(ctx, "abc123", peek)
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com", destination)
+	assert.Equal(t, http.StatusMovedPermanently, status)
+
+	// A used up link still answers as expired
+	_, _, err = shortener.GetOriginalURL(ctx, "used", peek)
+	require.ErrorIs(t, err, domain.ErrUsageLimitReached)
+
+	cache.AssertNotCalled(t, "IncrementUsage", mock.Anything, mock.Anything)
+	assert.Empty(t, events.clicks)
+
+	// The peek did not start a dedupe window, so the visitor's click counts
+	cache.On("IncrementUsage", ctx, "abc123").Return(1, nil).Once()
+	_, _, err = shortener.GetOriginalURL(ctx, "abc123", domain.RedirectRequest{ClientIP: "192.0.2.1"})
+	require.NoError(t, err)
+	cache.AssertExpectations(t)
+}
+
+// clickLog is a Notifier that keeps the clicks behind url.clicked events
+type clickLog struct {
+	clicks []domain.Click
+}
+
+func (l *clickLog) Notify(event domain.Event) {
+	if event.Type == domain.EventURLClicked {
+		l.clicks = append(l.clicks, *event.Click)
+	}
+}
+
+func TestURLShortener_GetOriginalURL_RecordsClicks(t *testing.T) {
+	ctx := context.Background()
+	entry := &domain.CacheEntry{OriginalURL: "https://example.com", RedirectStatus: http.StatusFound}
+
+	repo := &repoMocks.URLRepository{}
+	cache := &mocks.SyncableCache{}
+	cache.On("Get", ctx, "abc123").Return(entry, true)
+	cache.On("IncrementUsage", ctx, "abc123").Return(1, nil)
+
+	recorder := &clickLog{}
+	shortener := NewURLShortener(repo, cache, NewTestGenerator(), WithNotifier(recorder), WithBotClicks(domain.BotClicksExclude))
+
+	requests := []domain.RedirectRequest{
+		{Referrer: "https://www.Twitter.com./status/1", Query: url.Values{"utm_source": {"newsletter"}, "utm_campaign": {"spring"}}, Country: "US", Region: "CA"},
+		{Referrer: "android-app://com.slack"},
+		{Device: domain.DeviceBot, Referrer: "https://example.org"}, // Excluded bots are not recorded
+	}
+	for _, req := range requests {
+		_, _, err := shortener.GetOriginalURL(ctx, "abc123", req)
+		require.NoError(t, err)
+	}
+
+	// Clicks carry the time of the redirect
+	for i := range recorder.clicks {
+		assert.WithinDuration(t, time.Now(), recorder.clicks[i].At, time.Minute)
+		recorder.clicks[i].At = time.Time{}
+	}
+	assert.Equal(t, []domain.Click{
+		{ShortCode: "abc123", Referrer: "twitter.com", UTM: domain.UTMParams{Source: "newsletter", Campaign: "spring"}, Country: "US", Region: "CA"},
+		{ShortCode: "abc123", Referrer: domain.DirectReferrer},
+	}, recorder.clicks)
+}
+
+// warmShortener returns a shortener whose in-memory cache holds count links
+// with mixed-case codes, as the base62 generators produce
+func warmShortener(tb testing.TB, count int) (URLShortener, []string) {
+	cache := memory.New()
+	shortCodes := make([]string, count)
+	entries := make(map[string]*domain.CacheEntry, count)
+	for i := range shortCodes {
+		shortCodes[i] = fmt.Sprintf("Ab%05dZ", i)
+		entries[shortCodes[i]] = &domain.CacheEntry{OriginalURL: fmt.Sprintf("https://example.com/page/%d", i)}
+	}
+	require.NoError(tb, cache.LoadData(context.Background(), entries))
+
+	blacklist := shortener.NewBlacklist(shortener.DefaultReservedCodes, shortener.DefaultBlockedWords)
+	return NewURLShortener(&repoMocks.URLRepository{}, cache, NewTestGenerator(), WithBlacklist(blacklist)), shortCodes
+}
+
+func TestURLShortener_GetOriginalURL_WarmCacheAllocations(t *testing.T) {
+	service, shortCodes := warmShortener(t, 1)
+	ctx := context.Background()
+	req := domain.RedirectRequest{Device: domain.DeviceDesktop}
+
+	allocs := testing.AllocsPerRun(100, func() {
+		if _, _, err := service.GetOriginalURL(ctx, shortCodes[0], req); err != nil {
+			t.Fatal(err)
+		}
+	})
+	assert.Zero(t, allocs, "a redirect from a warm cache should not allocate")
+}
+
+// BenchmarkURLShortener_GetOriginalURL measures redirects from a warm cache
+// across all cores, without click events or dedupe
+func BenchmarkURLShortener_GetOriginalURL(b *testing.B) {
+	service, shortCodes := warmShortener(b, 10000)
+	ctx := context.Background()
+	req := domain.RedirectRequest{Device: domain.DeviceDesktop}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			if _, _, err := service.GetOriginalURL(ctx, shortCodes[i%len(shortCodes)], req); err != nil {
+				b.Fatal(err)
+			}
+			i++
+		}
+	})
+	b.ReportMetric(float64(b.N)/b.Elapsed().Seconds(), "redirects/s")
+}
+
+// BenchmarkURLShortener_GetOriginalURL_HotLink measures every core
+// redirecting the same link, the worst case for lock contention
+func BenchmarkURLShortener_GetOriginalURL_HotLink(b *testing.B) {
+	service, shortCodes := warmShortener(b, 1)
+	ctx := context.Background()
+	req := domain.RedirectRequest{Device: domain.DeviceDesktop}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if _, _, err := service.GetOriginalURL(ctx, shortCodes[0], req); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.ReportMetric(float64(b.N)/b.Elapsed().Seconds(), "redirects/s")
+}
+
+func TestURLShortener_GetOriginalURL_ViewsCache(t *testing.T) {
+	ctx := context.Background()
+	cache := memory.New()
+	require.NoError(t, cache.Set(ctx, "abc123", &domain.CacheEntry{OriginalURL: "https://example.com", MaxUses: 2}))
+	service := NewURLShortener(&repoMocks.URLRepository{}, cache, NewTestGenerator(), WithBotClicks(domain.BotClicksExclude))
+
+	for i := 0; i < 2; i++ {
+		destination, _, err := service.GetOriginalURL(ctx, "abc123", domain.RedirectRequest{})
+		require.NoError(t, err)
+		assert.Equal(t, "https://example.com", destination)
+	}
+
+	// The usage count comes with the shared entry, so a used-up link stops
+	// redirecting bots too
+	_, _, err := service.GetOriginalURL(ctx, "abc123", domain.RedirectRequest{})
+	assert.ErrorIs(t, err, domain.ErrUsageLimitReached)
+	_, _, err = service.GetOriginalURL(ctx, "abc123", domain.RedirectRequest{Device: domain.DeviceBot})
+	assert.ErrorIs(t, err, domain.ErrUsageLimitReached)
+}
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+
+	"github.com/joshdurbin/url-shortener/internal/cache"
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	"github.com/joshdurbin/url-shortener/internal/tracing"
+)
+
+// WithServeStale keeps redirects working while the database is failing:
+// links the repository cannot load are served from expired cache entries
+// the cache still holds (see memory.WithStaleTTL), and writes that fail on
+// the database are rejected as unavailable (503) rather than as internal
+// errors
+func WithServeStale(enabled bool) Option {
+	return func(s *urlShortener) {
+		s.serveStale = enabled
+	}
+}
+
+// staleKey is the context key of a request's stale marker
+type staleKey struct{}
+
+// TrackStale returns a context that records whether GetOriginalURL served
+// the redirect from a stale cache entry; see ServedStale
+func TrackStale(ctx context.Context) context.Context {
+	return context.WithValue(ctx, staleKey{}, new(atomic.Bool))
+}
+
+// ServedStale reports whether a redirect made with a TrackStale context was
+// served from a stale cache entry
+func ServedStale(ctx context.Context) bool {
+	stale, _ := ctx.Value(staleKey{}).(*atomic.Bool)
+	return stale != nil && stale.Load()
+}
+
+// MarkStale records on a TrackStale context that its redirect was served
+// stale; other contexts are left alone
+func MarkStale(ctx context.Context) {
+	if stale, _ := ctx.Value(staleKey{}).(*atomic.Bool); stale != nil {
+		stale.Store(true)
+	}
+}
+
+// repositoryDown reports whether err means the database, rather than the
+// request, failed
+func repositoryDown(err error) bool {
+	return isDatabaseFailure(err) || errors.Is(err, domain.ErrUnavailable)
+}
+
+// lookupStale returns the expired cache entry of a link the repository could
+// not load because of err, when serving stale entries is enabled
+func (s *urlShortener) lookupStale(ctx context.Context, shortCode string, err error) (*domain.CacheEntry, int, bool) {
+	if !s.serveStale || !repositoryDown(err) {
+		return nil, 0, false
+	}
+	viewer, ok := s.cache.(cache.StaleViewer)
+	if !ok {
+		return nil, 0, false
+	}
+	entry, uses, exists := viewer.ViewStale(ctx, shortCode)
+	if exists {
+		MarkStale(ctx)
+		tracing.FromContext(ctx).SetAttributes(tracing.Bool("cache.stale", true))
+	}
+	return entry, uses, exists
+}
+
+// unavailable reports a write that failed on the database as unavailable
+// when serving stale entries is enabled, since redirects keep working
+func (s *urlShortener) unavailable(err error) error {
+	if !s.serveStale || !isDatabaseFailure(err) || errors.Is(err, domain.ErrUnavailable) {
+		return err
+	}
+	return domain.Unavailable(err)
+}
@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/joshdurbin/url-shortener/internal/cache/memory"
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	repoMocks "github.com/joshdurbin/url-shortener/internal/repository/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStaleContext(t *testing.T) {
+	// Untracked contexts are never stale and ignore marks
+	ctx := context.Background()
+	MarkStale(ctx)
+	assert.False(t, ServedStale(ctx))
+
+	ctx = TrackStale(ctx)
+	assert.False(t, ServedStale(ctx))
+	MarkStale(ctx)
+	assert.True(t, ServedStale(ctx))
+}
+
+func TestURLShortener_ServeStale(t *testing.T) {
+	storageErr := domain.Storage(errors.New("database is locked"))
+
+	tests := []struct {
+		name       string
+		serveStale bool
+		repoErr    error
+		wantErr    error
+		wantStale  bool
+	}{
+		{name: "database failure serves the expired entry", serveStale: true, repoErr: storageErr, wantStale: true},
+		{name: "open circuit serves the expired entry", serveStale: true, repoErr: ErrCircuitOpen, wantStale: true},
+		{name: "deleted link is not served", serveStale: true, repoErr: domain.ErrURLNotFound, wantErr: domain.ErrURLNotFound},
+		{name: "disabled", repoErr: storageErr, wantErr: domain.ErrStorage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &repoMocks.URLRepository{}
+			repo.On("GetURL", mock.Anything, "abc123").Return(nil, tt.repoErr)
+
+			// An entry that expired a moment ago, kept for stale reads
+			cache := memory.New(memory.WithEntryTTL(time.Nanosecond, false), memory.WithStaleTTL(time.Hour))
+			require.NoError(t, cache.Set(context.Background(), "abc123", &domain.CacheEntry{OriginalURL: "https://example.com", RedirectStatus: 301}))
+			time.Sleep(time.Millisecond)
+
+			shortener := NewURLShortener(repo, cache, NewTestGenerator(), WithServeStale(tt.serveStale))
+			ctx := TrackStale(context.Background())
+			destination, status, err := shortener.GetOriginalURL(ctx, "abc123", domain.RedirectRequest{})
+			assert.Equal(t, tt.wantStale, ServedStale(ctx))
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "https://example.com", destination)
+			assert.Equal(t, 301, status)
+
+			// The stale redirect still counts, to be synced once the database is back
+			dirty, err := cache.GetDirtyEntries(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, 1, dirty["abc123"].UsageCount)
+		})
+	}
+}
+
+func TestURLShortener_ServeStale_RejectsWrites(t *testing.T) {
+	ctx := context.Background()
+	storageErr := domain.Storage(errors.New("database is locked"))
+	repo := &repoMocks.URLRepository{}
+	repo.On("GetURL", mock.Anything, "abc123").Return(nil, storageErr)
+	repo.On("URLExists", mock.Anything, "abc123").Return(false, storageErr)
+	repo.On("URLExists", mock.Anything, "missing").Return(false, nil)
+	repo.On("SetURLFailover", mock.Anything, "abc123", true, "manual", mock.Anything).Return(nil, storageErr)
+
+	shortener := NewURLShortener(repo, memory.New(), NewTestGenerator(), WithServeStale(true))
+
+	_, err := shortener.UpdateShortURL(ctx, "abc123", domain.UpdateURLRequest{})
+	assert.ErrorIs(t, err, domain.ErrUnavailable)
+	_, err = shortener.SetRoutingRules(ctx, "abc123", nil)
+	assert.ErrorIs(t, err, domain.ErrUnavailable)
+	_, err = shortener.SetFailover(ctx, "abc123", true, "manual")
+	assert.ErrorIs(t, err, domain.ErrUnavailable)
+	err = shortener.DeleteShortURL(ctx, "abc123")
+	assert.ErrorIs(t, err, domain.ErrUnavailable)
+	assert.ErrorIs(t, err, domain.ErrStorage)
+
+	// Answers from a working database keep their meaning
+	assert.ErrorIs(t, shortener.DeleteShortURL(ctx, "missing"), domain.ErrURLNotFound)
+	assert.NotErrorIs(t, shortener.DeleteShortURL(ctx, "missing"), domain.ErrUnavailable)
+}
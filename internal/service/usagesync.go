@@ -0,0 +1,250 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/joshdurbin/url-shortener/internal/cache"
+	"github.com/joshdurbin/url-shortener/internal/domain"
+)
+
+// SyncConfig sets what happens to usage the background sync fails to write.
+// Failed syncs are retried with exponential backoff: after the nth failure
+// in a row the next attempt waits 2^(n-1) sync intervals, at most
+// MaxBackoff. The zero value retries at every interval, forever, and keeps
+// nothing across restarts.
+type SyncConfig struct {
+	MaxBackoff     time.Duration // Longest wait between failed syncs; 0 retries at every interval
+	MaxAttempts    int           // Failed syncs in a row before pending usage is dead-lettered; 0 retries forever
+	DeadLetterPath string        // File dead-lettered usage is appended to as JSON lines; "" logs it instead
+	WALPath        string        // File pending usage is written to when the final sync fails, replayed by InitializeCache; "" loses it
+}
+
+// DefaultSyncConfig returns the default configuration, which backs off up to
+// a minute and never gives up
+func DefaultSyncConfig() SyncConfig {
+	return SyncConfig{MaxBackoff: time.Minute}
+}
+
+// Validate checks that the configuration values are usable
+func (c SyncConfig) Validate() error {
+	if c.MaxBackoff < 0 {
+		return fmt.Errorf("sync max backoff cannot be negative, got: %v", c.MaxBackoff)
+	}
+	if c.MaxAttempts < 0 {
+		return fmt.Errorf("sync max attempts cannot be negative, got: %d", c.MaxAttempts)
+	}
+	return nil
+}
+
+// WithSyncRetry sets how failed usage syncs are retried, dead-lettered and
+// kept across restarts
+func WithSyncRetry(config SyncConfig) Option {
+	return func(s *urlShortener) {
+		s.syncer.config = config
+	}
+}
+
+// usageSyncer writes usage updates for the background sync, applying
+// SyncConfig when the write fails
+type usageSyncer struct {
+	config   SyncConfig
+	stopping atomic.Bool // The next sync is the final one
+	held     atomic.Bool // Syncs are deferred while the service is drained
+
+	mu       sync.Mutex
+	interval time.Duration
+	failures int // Failed syncs in a row
+	skip     int // Syncs left to skip before retrying
+}
+
+// pendingUsage is a usage update kept in the dead-letter log or the WAL
+type pendingUsage struct {
+	ShortCode  string    `json:"short_code"`
+	UsageCount int       `json:"usage_count"`
+	Delta      int       `json:"delta"`
+	BotsDelta  int       `json:"bots_delta,omitempty"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	Error      string    `json:"error,omitempty"` // Why a dead-lettered update was given up
+}
+
+// start resets the syncer for a background sync running every interval
+func (u *usageSyncer) start(interval time.Duration) {
+	u.stopping.Store(false)
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.interval = interval
+	u.failures, u.skip = 0, 0
+}
+
+// sync writes updates with write. It returns cache.ErrSyncDeferred while
+// backing off or held, and treats updates it dead-letters or keeps in the
+// WAL as synced.
+func (u *usageSyncer) sync(updates []domain.UsageUpdate, write func() (map[string]int, error)) (map[string]int, error) {
+	final := u.stopping.Load()
+	if u.held.Load() && !final {
+		return nil, cache.ErrSyncDeferred
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if u.skip > 0 && !final {
+		u.skip--
+		return nil, cache.ErrSyncDeferred
+	}
+	counts, err := write()
+	if err == nil {
+		if u.failures > 0 {
+			log.Printf("Usage sync recovered after %d failed attempts", u.failures)
+		}
+		u.failures, u.skip = 0, 0
+		return counts, nil
+	}
+	u.failures++
+
+	if final && u.config.WALPath != "" {
+		if werr := appendUsage(u.config.WALPath, updates, ""); werr != nil {
+			return nil, fmt.Errorf("%w (and writing the usage WAL failed: %v)", err, werr)
+		}
+		log.Printf("Final usage sync failed, kept %d updates in %s until the next start: %v", len(updates), u.config.WALPath, err)
+		return nil, nil
+	}
+	if u.config.MaxAttempts > 0 && u.failures >= u.config.MaxAttempts {
+		u.deadLetter(updates, err)
+		u.failures, u.skip = 0, 0
+		return nil, nil
+	}
+	u.skip = u.backoff() - 1
+	return nil, err
+}
+
+// backoff returns how many sync intervals to wait after the current run of
+// failures: doubling with each failure, but no longer than MaxBackoff
+func (u *usageSyncer) backoff() int {
+	limit := 1
+	if u.interval > 0 && u.config.MaxBackoff > u.interval {
+		limit = int(u.config.MaxBackoff / u.interval)
+	}
+	intervals := 1
+	for i := 1; i < u.failures && intervals < limit; i++ {
+		intervals *= 2
+	}
+	return min(intervals, limit)
+}
+
+// deadLetter gives up on updates, appending them to the dead-letter log
+func (u *usageSyncer) deadLetter(updates []domain.UsageUpdate, cause error) {
+	log.Printf("[ERROR] Giving up on %d usage updates after %d failed syncs: %v", len(updates), u.failures, cause)
+	if u.config.DeadLetterPath == "" {
+		for _, update := range updates {
+			line, _ := json.Marshal(newPendingUsage(update, cause.Error()))
+			log.Printf("[ERROR] Dead-lettered usage: %s", line)
+		}
+		return
+	}
+	if err := appendUsage(u.config.DeadLetterPath, updates, cause.Error()); err != nil {
+		log.Printf("[ERROR] Failed to write dead-lettered usage to %s: %v", u.config.DeadLetterPath, err)
+	}
+}
+
+func newPendingUsage(update domain.UsageUpdate, cause string) pendingUsage {
+	return pendingUsage{
+		ShortCode:  update.ShortCode,
+		UsageCount: update.UsageCount,
+		Delta:      update.Delta,
+		BotsDelta:  update.BotsDelta,
+		LastUsedAt: update.LastUsedAt,
+		Error:      cause,
+	}
+}
+
+// appendUsage appends updates to a JSON lines file, creating it if needed,
+// and syncs it to disk
+func appendUsage(path string, updates []domain.UsageUpdate, cause string) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(file)
+	encoder := json.NewEncoder(w)
+	for _, update := range updates {
+		if err := encoder.Encode(newPendingUsage(update, cause)); err != nil {
+			file.Close()
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// replayWAL writes the usage a previous run kept in the WAL to the repository
+// and removes the file. Updates of the same link are merged first.
+func (s *urlShortener) replayWAL(ctx context.Context) error {
+	path := s.syncer.config.WALPath
+	if path == "" {
+		return nil
+	}
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open usage WAL: %w", err)
+	}
+	defer file.Close()
+
+	merged := make(map[string]*domain.UsageUpdate)
+	var order []string
+	decoder := json.NewDecoder(file)
+	for {
+		var pending pendingUsage
+		if err := decoder.Decode(&pending); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return fmt.Errorf("failed to read usage WAL %s: %w", path, err)
+		}
+		update, ok := merged[pending.ShortCode]
+		if !ok {
+			update = &domain.UsageUpdate{ShortCode: pending.ShortCode}
+			merged[pending.ShortCode] = update
+			order = append(order, pending.ShortCode)
+		}
+		update.UsageCount = max(update.UsageCount, pending.UsageCount)
+		update.Delta += pending.Delta
+		update.BotsDelta += pending.BotsDelta
+		if pending.LastUsedAt.After(update.LastUsedAt) {
+			update.LastUsedAt = pending.LastUsedAt
+		}
+	}
+
+	updates := make([]domain.UsageUpdate, 0, len(order))
+	for _, shortCode := range order {
+		updates = append(updates, *merged[shortCode])
+	}
+	if len(updates) > 0 {
+		if _, err := s.repo.UpdateUsageBatch(ctx, updates, s.merge); err != nil {
+			return fmt.Errorf("failed to replay usage WAL %s: %w", path, err)
+		}
+	}
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("failed to remove replayed usage WAL: %w", err)
+	}
+	log.Printf("Replayed %d usage updates from %s", len(updates), path)
+	return nil
+}
@@ -0,0 +1,151 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	cacheIface "github.com/joshdurbin/url-shortener/internal/cache"
+	"github.com/joshdurbin/url-shortener/internal/cache/mocks"
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	repoMocks "github.com/joshdurbin/url-shortener/internal/repository/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSyncConfig_Validate(t *testing.T) {
+	assert.NoError(t, DefaultSyncConfig().Validate())
+	assert.NoError(t, SyncConfig{}.Validate())
+	assert.ErrorContains(t, SyncConfig{MaxBackoff: -time.Second}.Validate(), "max backoff cannot be negative")
+	assert.ErrorContains(t, SyncConfig{MaxAttempts: -1}.Validate(), "max attempts cannot be negative")
+}
+
+func TestUsageSyncer_Backoff(t *testing.T) {
+	syncer := &usageSyncer{config: SyncConfig{MaxBackoff: 4 * time.Second}}
+	syncer.start(time.Second)
+	updates := []domain.UsageUpdate{{ShortCode: "abc123", Delta: 1}}
+	writes := 0
+	failing := func() (map[string]int, error) {
+		writes++
+		return nil, domain.Storage(errors.New("database is locked"))
+	}
+
+	// Each failure doubles the wait, up to four intervals
+	var attempts []int
+	for tick := 1; tick <= 16; tick++ {
+		before := writes
+		_, err := syncer.sync(updates, failing)
+		require.Error(t, err)
+		if writes > before {
+			attempts = append(attempts, tick)
+			assert.ErrorIs(t, err, domain.ErrStorage)
+		} else {
+			assert.ErrorIs(t, err, cacheIface.ErrSyncDeferred)
+		}
+	}
+	assert.Equal(t, []int{1, 2, 4, 8, 12, 16}, attempts)
+
+	// The final sync does not wait, and success resets the backoff
+	syncer.stopping.Store(true)
+	_, err := syncer.sync(updates, func() (map[string]int, error) { return map[string]int{"abc123": 1}, nil })
+	require.NoError(t, err)
+	syncer.stopping.Store(false)
+	_, err = syncer.sync(updates, failing)
+	assert.ErrorIs(t, err, domain.ErrStorage)
+	_, err = syncer.sync(updates, failing)
+	assert.ErrorIs(t, err, domain.ErrStorage)
+}
+
+func TestUsageSyncer_DeadLetter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dead-letter.jsonl")
+	syncer := &usageSyncer{config: SyncConfig{MaxAttempts: 2, DeadLetterPath: path}}
+	syncer.start(time.Second)
+	lastUsedAt := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	updates := []domain.UsageUpdate{{ShortCode: "abc123", UsageCount: 7, Delta: 2, LastUsedAt: lastUsedAt}}
+	failing := func() (map[string]int, error) { return nil, errors.New("disk I/O error") }
+
+	_, err := syncer.sync(updates, failing)
+	require.Error(t, err)
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+
+	// Given up on: the cache treats the entries as synced
+	counts, err := syncer.sync(updates, failing)
+	require.NoError(t, err)
+	assert.Empty(t, counts)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var got pendingUsage
+	require.NoError(t, json.Unmarshal(data, &got))
+	assert.Equal(t, pendingUsage{ShortCode: "abc123", UsageCount: 7, Delta: 2, LastUsedAt: lastUsedAt, Error: "disk I/O error"}, got)
+
+	// The next failure starts a new run of attempts
+	_, err = syncer.sync(updates, failing)
+	assert.Error(t, err)
+}
+
+func TestURLShortener_UsageWAL(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "usage.wal")
+	lastUsedAt := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	// Shutdown: the final sync fails and the usage is kept in the WAL
+	repo := &repoMocks.URLRepository{}
+	cache := &mocks.SyncableCache{}
+	var syncFunc cacheIface.SyncFunc
+	cache.On("StartBackgroundSync", ctx, time.Second, mock.AnythingOfType("cache.SyncFunc")).
+		Run(func(args mock.Arguments) { syncFunc = args.Get(2).(cacheIface.SyncFunc) }).
+		Return(nil)
+	cache.On("StopBackgroundSync").Run(func(mock.Arguments) {
+		counts, err := syncFunc(map[string]*domain.CacheEntry{
+			"abc123": {UsageCount: 7, SyncedCount: 5, LastUsedAt: lastUsedAt, Dirty: true},
+		})
+		assert.NoError(t, err)
+		assert.Empty(t, counts)
+	}).Return(nil)
+	repo.On("UpdateUsageBatch", ctx, mock.Anything, domain.UsageMergeDelta).Return(nil, domain.Storage(errors.New("database is locked")))
+
+	shortener := NewURLShortener(repo, cache, NewTestGenerator(), WithSyncRetry(SyncConfig{WALPath: path}))
+	require.NoError(t, shortener.StartCacheSync(ctx, time.Second))
+	require.NoError(t, shortener.StopCacheSync())
+	cache.AssertExpectations(t)
+
+	// A second run's leftovers for the same link are merged on replay
+	require.NoError(t, appendUsage(path, []domain.UsageUpdate{{ShortCode: "abc123", UsageCount: 8, Delta: 1, BotsDelta: 1, LastUsedAt: lastUsedAt.Add(time.Minute)}}, ""))
+
+	// Start: the WAL is written to the database before the cache loads
+	repo = &repoMocks.URLRepository{}
+	cache = &mocks.SyncableCache{}
+	repo.On("UpdateUsageBatch", ctx, []domain.UsageUpdate{
+		{ShortCode: "abc123", UsageCount: 8, Delta: 3, BotsDelta: 1, LastUsedAt: lastUsedAt.Add(time.Minute)},
+	}, domain.UsageMergeDelta).Return(map[string]int{"abc123": 8}, nil).Once()
+	repo.On("LoadCacheData", ctx).Return(map[string]*domain.CacheEntry{}, nil)
+	cache.On("LoadData", ctx, map[string]*domain.CacheEntry{}).Return(nil)
+
+	shortener = NewURLShortener(repo, cache, NewTestGenerator(), WithSyncRetry(SyncConfig{WALPath: path}))
+	require.NoError(t, shortener.InitializeCache(ctx))
+	repo.AssertExpectations(t)
+	_, err := os.Stat(path)
+	assert.True(t, os.IsNotExist(err), "a replayed WAL is removed")
+
+	// Without a WAL there is nothing to replay
+	require.NoError(t, shortener.InitializeCache(ctx))
+	repo.AssertNumberOfCalls(t, "UpdateUsageBatch", 1)
+}
+
+func TestURLShortener_UsageWAL_Corrupt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "usage.wal")
+	require.NoError(t, os.WriteFile(path, []byte(`{"short_code":"abc123","delta":`), 0o600))
+
+	shortener := NewURLShortener(&repoMocks.URLRepository{}, &mocks.SyncableCache{}, NewTestGenerator(), WithSyncRetry(SyncConfig{WALPath: path}))
+	err := shortener.InitializeCache(context.Background())
+	assert.ErrorContains(t, err, "failed to read usage WAL")
+	_, err = os.Stat(path)
+	assert.NoError(t, err, "an unreadable WAL is kept")
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+)
+
+// ValidateShortURL runs CreateShortURL's checks without creating anything
+func (s *urlShortener) ValidateShortURL(ctx context.Context, originalURL string, opts domain.CreateOptions) []domain.ValidationProblem {
+	_, found := s.checkCreate(originalURL, opts)
+	problems := make([]domain.ValidationProblem, len(found))
+	for i, problem := range found {
+		problems[i] = domain.ValidationProblem{Field: problem.Field, Message: problem.Err.Error()}
+	}
+	return problems
+}
+
+// checkCreate validates a new link and returns its options normalized. Every
+// field is checked, so a pre-flight validation reports all problems at once;
+// CreateShortURL fails with the first.
+func (s *urlShortener) checkCreate(originalURL string, opts domain.CreateOptions) (domain.CreateOptions, []*domain.ValidationError) {
+	var problems []*domain.ValidationError
+	fail := func(field string, err error) {
+		problems = append(problems, &domain.ValidationError{Field: field, Err: err})
+	}
+
+	if err := validateURL(originalURL); err != nil {
+		fail("url", err)
+	} else if err := s.destFilter.Check(originalURL); err != nil {
+		fail("url", err)
+	}
+
+	if opts.MaxUses < 0 {
+		fail("max_uses", fmt.Errorf("max uses cannot be negative"))
+	}
+	if opts.BurnAfterReading {
+		if opts.MaxUses > 1 {
+			fail("max_uses", fmt.Errorf("a burn-after-reading link has max_uses 1"))
+		}
+		opts.MaxUses = 1
+	}
+
+	if tags, err := normalizeTags(opts.Tags); err != nil {
+		fail("tags", err)
+	} else {
+		opts.Tags = tags
+	}
+
+	if err := validateRedirectStatus(opts.RedirectStatus); err != nil {
+		fail("redirect_status", err)
+	}
+
+	if err := validateBackupURL(opts.BackupURL, originalURL); err != nil {
+		fail("backup_url", err)
+	} else if opts.BackupURL != "" {
+		if err := s.destFilter.Check(opts.BackupURL); err != nil {
+			fail("backup_url", err)
+		}
+	}
+
+	if params, err := validateQueryParams(opts.QueryParams, opts.ForwardQuery, originalURL); err != nil {
+		fail("query_params", err)
+	} else {
+		opts.QueryParams = params
+	}
+
+	if campaign, err := normalizeCampaign(opts.Campaign); err != nil {
+		fail("campaign", err)
+	} else {
+		opts.Campaign = campaign
+	}
+
+	if err := validateDedupeSeconds(opts.DedupeSeconds); err != nil {
+		fail("dedupe_seconds", err)
+	}
+
+	if opts.Domain != "" {
+		opts.Domain = strings.ToLower(opts.Domain)
+		if s.domains == nil {
+			fail("domain", fmt.Errorf("custom domains are not configured"))
+		} else if _, ok := s.domains.Lookup(opts.Domain); !ok {
+			fail("domain", fmt.Errorf("unknown domain %q", opts.Domain))
+		}
+	}
+
+	if opts.ReuseExisting {
+		if opts.MaxUses > 0 {
+			fail("reuse_existing", fmt.Errorf("reuse_existing cannot be combined with max_uses"))
+		}
+		if opts.BackupURL != "" {
+			fail("reuse_existing", fmt.Errorf("reuse_existing cannot be combined with backup_url"))
+		}
+		if len(opts.QueryParams) > 0 || opts.ForwardQuery {
+			fail("reuse_existing", fmt.Errorf("reuse_existing cannot be combined with query_params or forward_query"))
+		}
+		if opts.Campaign != "" {
+			fail("reuse_existing", fmt.Errorf("reuse_existing cannot be combined with campaign"))
+		}
+		// A reused link keeps its own settings, so these would be ignored
+		if len(opts.Tags) > 0 {
+			fail("reuse_existing", fmt.Errorf("reuse_existing cannot be combined with tags"))
+		}
+		if opts.RedirectStatus != 0 {
+			fail("reuse_existing", fmt.Errorf("reuse_existing cannot be combined with redirect_status"))
+		}
+		if opts.DedupeSeconds != 0 {
+			fail("reuse_existing", fmt.Errorf("reuse_existing cannot be combined with dedupe_seconds"))
+		}
+		if opts.Domain != "" {
+			fail("reuse_existing", fmt.Errorf("reuse_existing cannot be combined with domain"))
+		}
+	}
+
+	return opts, problems
+}
@@ -0,0 +1,239 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/joshdurbin/url-shortener/internal/cache/mocks"
+	"github.com/joshdurbin/url-shortener/internal/destinations"
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	repoMocks "github.com/joshdurbin/url-shortener/internal/repository/mocks"
+)
+
+func TestURLShortener_ValidateShortURL(t *testing.T) {
+	ctx := context.Background()
+	repo := &repoMocks.URLRepository{}
+	cache := &mocks.SyncableCache{}
+	shortener := NewURLShortener(repo, cache, NewTestGenerator())
+
+	t.Run("valid", func(t *testing.T) {
+		problems := shortener.ValidateShortURL(ctx, "https://example.com/{page=home}", domain.CreateOptions{
+			Tags:     []string{"Docs"},
+			Campaign: "Launch",
+		})
+		assert.Empty(t, problems)
+	})
+
+	t.Run("reports every problem", func(t *testing.T) {
+		problems := shortener.ValidateShortURL(ctx, "ftp://example.com", domain.CreateOptions{
+			MaxUses:        -1,
+			RedirectStatus: 303,
+			BackupURL:      "not a url",
+			Campaign:       "spring sale",
+			DedupeSeconds:  -2,
+		})
+		fields := make([]string, len(problems))
+		for i, problem := range problems {
+			fields[i] = problem.Field
+			assert.NotEmpty(t, problem.Message)
+		}
+		assert.Equal(t, []string{"url", "max_uses", "redirect_status", "backup_url", "campaign", "dedupe_seconds"}, fields)
+	})
+
+	t.Run("too long", func(t *testing.T) {
+		problems := shortener.ValidateShortURL(ctx, "https://example.com/"+strings.Repeat("a", maxURLLength), domain.CreateOptions{})
+		require.Len(t, problems, 1)
+		assert.Equal(t, domain.ValidationProblem{Field: "url", Message: "invalid URL: longer than 2048 characters"}, problems[0])
+	})
+
+	t.Run("reuse conflicts", func(t *testing.T) {
+		problems := shortener.ValidateShortURL(ctx, "https://example.com", domain.CreateOptions{
+			ReuseExisting: true,
+			MaxUses:       5,
+			Campaign:      "launch",
+		})
+		require.Len(t, problems, 2)
+		assert.Equal(t, "reuse_existing", problems[0].Field)
+		assert.Contains(t, problems[1].Message, "cannot be combined with campaign")
+	})
+
+	t.Run("create fails with the first problem", func(t *testing.T) {
+		_, err := shortener.CreateShortURL(ctx, "https://example.com", domain.CreateOptions{MaxUses: -1, Campaign: "spring sale"})
+		require.ErrorIs(t, err, domain.ErrValidation)
+		var validation *domain.ValidationError
+		require.ErrorAs(t, err, &validation)
+		assert.Equal(t, "max_uses", validation.Field)
+		assert.EqualError(t, err, "max uses cannot be negative")
+	})
+
+	repo.AssertNotCalled(t, "CreateURL", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	repo.AssertNotCalled(t, "GetURLByOriginalURL", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestURLShortener_Destinations(t *testing.T) {
+	ctx := context.Background()
+	store := &repoMocks.DestinationRepository{}
+	store.On("ListDestinationRules", ctx).Return([]domain.DestinationRule{}, nil)
+	filter, err := destinations.New(destinations.Config{Deny: []string{"private", "evil.example"}}, store)
+	require.NoError(t, err)
+	require.NoError(t, filter.Start(ctx))
+	defer filter.Close()
+
+	repo := &repoMocks.URLRepository{}
+	shortener := NewURLShortener(repo, &mocks.SyncableCache{}, NewTestGenerator(), WithDestinations(filter))
+
+	t.Run("create", func(t *testing.T) {
+		_, err := shortener.CreateShortURL(ctx, "http://169.254.169.254/latest/meta-data/", domain.CreateOptions{})
+		var invalid *domain.ValidationError
+		require.ErrorAs(t, err, &invalid)
+		assert.Equal(t, "url", invalid.Field)
+		repo.AssertNotCalled(t, "CreateURL", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+
+		_, err = shortener.CreateShortURL(ctx, "https://example.com", domain.CreateOptions{BackupURL: "https://www.evil.example"})
+		require.ErrorAs(t, err, &invalid)
+		assert.Equal(t, "backup_url", invalid.Field)
+	})
+
+	t.Run("validate", func(t *testing.T) {
+		problems := shortener.ValidateShortURL(ctx, "http://localhost/", domain.CreateOptions{BackupURL: "http://10.0.0.1/"})
+		require.Len(t, problems, 2)
+		assert.Equal(t, "url", problems[0].Field)
+		assert.Contains(t, problems[0].Message, `denied by "private"`)
+		assert.Equal(t, "backup_url", problems[1].Field)
+	})
+
+	t.Run("update", func(t *testing.T) {
+		repo.On("GetURL", ctx, "abc123").Return(&domain.URLEntry{ShortCode: "abc123", OriginalURL: "https://example.com"}, nil)
+		denied := "https://evil.example/login"
+		_, err := shortener.UpdateShortURL(ctx, "abc123", domain.UpdateURLRequest{OriginalURL: &denied})
+		assert.ErrorIs(t, err, domain.ErrValidation)
+		_, err = shortener.UpdateShortURL(ctx, "abc123", domain.UpdateURLRequest{BackupURL: &denied})
+		assert.ErrorIs(t, err, domain.ErrValidation)
+		repo.AssertNotCalled(t, "UpdateURL", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("routing rules", func(t *testing.T) {
+		_, err := shortener.SetRoutingRules(ctx, "abc123", []domain.RoutingRule{
+			{Countries: []string{"DE"}, Destination: "https://example.de"},
+			{Countries: []string{"AT"}, Destination: "http://127.0.0.1:8080/"},
+		})
+		assert.ErrorIs(t, err, domain.ErrValidation)
+		assert.ErrorContains(t, err, "routing rule 2")
+	})
+}
+
+// verifierFunc adapts a function to DestinationVerifier
+type verifierFunc func(ctx context.Context, destination string) error
+
+func (f verifierFunc) Verify(ctx context.Context, destination string) error {
+	return f(ctx, destination)
+}
+
+func TestURLShortener_Verifier(t *testing.T) {
+	ctx := context.Background()
+	var verified []string
+	verifier := verifierFunc(func(ctx context.Context, destination string) error {
+		verified = append(verified, destination)
+		if destination == "https://gone.example.com" {
+			return errors.New("destination is unreachable: status 404")
+		}
+		return nil
+	})
+
+	repo := &repoMocks.URLRepository{}
+	shortener := NewURLShortener(repo, &mocks.SyncableCache{}, NewTestGenerator(), WithVerifier(verifier))
+
+	t.Run("create", func(t *testing.T) {
+		_, err := shortener.CreateShortURL(ctx, "https://gone.example.com", domain.CreateOptions{})
+		var invalid *domain.ValidationError
+		require.ErrorAs(t, err, &invalid)
+		assert.Equal(t, "url", invalid.Field)
+		assert.ErrorContains(t, err, "status 404")
+		repo.AssertNotCalled(t, "CreateURL", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+
+		// Validation problems are reported without a request
+		verified = nil
+		_, err = shortener.CreateShortURL(ctx, "https://gone.example.com", domain.CreateOptions{MaxUses: -1})
+		assert.ErrorIs(t, err, domain.ErrValidation)
+		assert.Empty(t, verified)
+	})
+
+	t.Run("update", func(t *testing.T) {
+		cache := &mocks.SyncableCache{}
+		shortener := NewURLShortener(repo, cache, NewTestGenerator(), WithVerifier(verifier))
+		repo.On("GetURL", ctx, "abc123").Return(&domain.URLEntry{ShortCode: "abc123", OriginalURL: "https://gone.example.com"}, nil)
+		repo.On("UpdateURL", ctx, "abc123", mock.Anything, mock.Anything).Return(&domain.URLEntry{ShortCode: "abc123"}, nil)
+		cache.On("UpdateLink", ctx, "abc123", mock.Anything, mock.Anything).Return(nil)
+		cache.On("Get", ctx, "abc123").Return(nil, false)
+
+		// Only a changed URL is checked
+		verified = nil
+		_, err := shortener.UpdateShortURL(ctx, "abc123", domain.UpdateURLRequest{Tags: &[]string{"kept"}})
+		require.NoError(t, err)
+		assert.Empty(t, verified)
+
+		moved := "https://example.com/moved"
+		_, err = shortener.UpdateShortURL(ctx, "abc123", domain.UpdateURLRequest{OriginalURL: &moved})
+		require.NoError(t, err)
+		assert.Equal(t, []string{moved}, verified)
+
+		gone := "https://gone.example.com"
+		repo.On("GetURL", ctx, "def456").Return(&domain.URLEntry{ShortCode: "def456", OriginalURL: moved}, nil)
+		_, err = shortener.UpdateShortURL(ctx, "def456", domain.UpdateURLRequest{OriginalURL: &gone})
+		assert.ErrorIs(t, err, domain.ErrValidation)
+		repo.AssertNotCalled(t, "UpdateURL", mock.Anything, "def456", mock.Anything, mock.Anything)
+	})
+}
+
+// domainSet is a DomainResolver over a fixed set of hosts
+type domainSet map[string]bool
+
+func (d domainSet) Lookup(host string) (domain.CustomDomain, bool) {
+	return domain.CustomDomain{Host: host}, d[host]
+}
+
+func TestURLShortener_Domains(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("not configured", func(t *testing.T) {
+		shortener := NewURLShortener(&repoMocks.URLRepository{}, &mocks.SyncableCache{}, NewTestGenerator())
+		problems := shortener.ValidateShortURL(ctx, "https://example.com", domain.CreateOptions{Domain: "go.example.com"})
+		require.Len(t, problems, 1)
+		assert.Equal(t, domain.ValidationProblem{Field: "domain", Message: "custom domains are not configured"}, problems[0])
+	})
+
+	shortener := NewURLShortener(&repoMocks.URLRepository{}, &mocks.SyncableCache{}, NewTestGenerator(), WithDomains(domainSet{"go.example.com": true}))
+
+	t.Run("validate", func(t *testing.T) {
+		assert.Empty(t, shortener.ValidateShortURL(ctx, "https://example.com", domain.CreateOptions{Domain: "Go.Example.com"}))
+
+		problems := shortener.ValidateShortURL(ctx, "https://example.com", domain.CreateOptions{Domain: "other.example.com", ReuseExisting: true})
+		require.Len(t, problems, 2)
+		assert.Equal(t, "domain", problems[0].Field)
+		assert.Contains(t, problems[0].Message, `unknown domain "other.example.com"`)
+		assert.Equal(t, "reuse_existing", problems[1].Field)
+	})
+
+	t.Run("each host serves only its own links", func(t *testing.T) {
+		cache := &mocks.SyncableCache{}
+		cache.On("Get", ctx, "abc123").Return(&domain.CacheEntry{OriginalURL: "https://example.com", Domain: "go.example.com"}, true)
+		cache.On("IncrementUsage", ctx, "abc123").Return(1, nil).Once()
+		shortener := NewURLShortener(&repoMocks.URLRepository{}, cache, NewTestGenerator(), WithDomains(domainSet{"go.example.com": true}))
+
+		_, _, err := shortener.GetOriginalURL(ctx, "abc123", domain.RedirectRequest{})
+		assert.ErrorIs(t, err, domain.ErrURLNotFound)
+		_, _, err = shortener.GetOriginalURL(ctx, "abc123", domain.RedirectRequest{Domain: "other.example.com"})
+		assert.ErrorIs(t, err, domain.ErrURLNotFound)
+
+		url, _, err := shortener.GetOriginalURL(ctx, "abc123", domain.RedirectRequest{Domain: "go.example.com"})
+		require.NoError(t, err)
+		assert.Equal(t, "https://example.com", url)
+		cache.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,14 @@
+// Package shardkey hashes short codes to pick the shard of a striped map, so
+// every sharded structure spreads codes the same way.
+package shardkey
+
+// Hash returns the FNV-1a hash of a short code. It is computed inline rather
+// than with hash/fnv so hashing does not allocate on the redirect path.
+func Hash(shortCode string) uint32 {
+	hash := uint32(2166136261)
+	for i := 0; i < len(shortCode); i++ {
+		hash ^= uint32(shortCode[i])
+		hash *= 16777619
+	}
+	return hash
+}
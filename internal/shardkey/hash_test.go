@@ -0,0 +1,19 @@
+package shardkey
+
+import (
+	"hash/fnv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHash(t *testing.T) {
+	// Matches hash/fnv's FNV-1a
+	for _, code := range []string{"", "a", "abc123", "a-much-longer-custom-alias"} {
+		h := fnv.New32a()
+		h.Write([]byte(code))
+		assert.Equal(t, h.Sum32(), Hash(code), code)
+	}
+
+	assert.Zero(t, testing.AllocsPerRun(100, func() { Hash("abc123") }))
+}
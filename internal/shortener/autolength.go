@@ -0,0 +1,231 @@
+package shortener
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Auto length defaults
+const (
+	DefaultMinLength = 4   // Shortest codes an auto-length generator starts with
+	DefaultGrowAt    = 0.5 // Fraction of a length's codes used before growing
+)
+
+// Counters rows recording auto length state. The length is a hint for
+// processes starting up: each length's own counters decide when to grow, so a
+// stale value only costs a lease. The shard count is the one the shorter
+// lengths' regions were split by.
+const (
+	codeLengthKey = "url_code_length"
+	codeShardsKey = "url_code_length_shards"
+)
+
+// lengthTier is one code length an auto-length generator can issue. Shorter
+// lengths split their codes between the configured shards rather than into
+// MaxCounterShards fixed regions, so a single shard can use all of them.
+type lengthTier struct {
+	space      codeSpace
+	obfuscator Obfuscator
+	span       uint64 // Counter values in each shard's region
+	limit      int64  // Last shard-local counter issued before growing
+	final      bool   // The configured length, which never grows
+}
+
+// key is the counters row backing a shard of this tier. The final length
+// keeps the fixed-length keys, so its sequence continues from codes issued
+// before auto length was enabled.
+func (t lengthTier) key(shard int) string {
+	if t.final {
+		return counterKey(shard)
+	}
+	key := "url_counter_len" + strconv.Itoa(t.space.length)
+	if shard > 0 {
+		key += "_" + strconv.Itoa(shard)
+	}
+	return key
+}
+
+// AutoLengthGenerator generates obfuscated codes that start short and grow one
+// character at a time. Each length has its own counters; once a shard has
+// issued the growAt fraction of its region, every caller moves on to the next
+// length. Codes of different lengths can never be equal, so growing never
+// collides with codes already issued.
+type AutoLengthGenerator struct {
+	counterProvider CounterProvider
+	shards          *shardPicker
+	tiers           []lengthTier // Shortest first; the last is the configured length
+	current         atomic.Int32 // Index of the tier in use
+	loadMu          sync.Mutex
+	loaded          atomic.Bool // Whether the stored length has been read
+}
+
+// newAutoLengthGenerator creates an auto-length generator with one tier per
+// length from minLength to the config's length
+func newAutoLengthGenerator(counterProvider CounterProvider, config Config) (*AutoLengthGenerator, error) {
+	picker, err := newShardPicker(config.shards(), config.ShardPick)
+	if err != nil {
+		return nil, err
+	}
+	tiers, growAt, err := config.lengthTiers()
+	if err != nil {
+		return nil, err
+	}
+	for i := range tiers {
+		regions := uint64(picker.shards)
+		if tiers[i].final {
+			regions = MaxCounterShards
+		}
+		tiers[i].span = tiers[i].space.size / regions
+		tiers[i].limit = min(int64(float64(tiers[i].span)*growAt), int64(tiers[i].span)-1)
+	}
+
+	return &AutoLengthGenerator{
+		counterProvider: counterProvider,
+		shards:          picker,
+		tiers:           tiers,
+	}, nil
+}
+
+// GenerateShortCode generates an obfuscated short code of the current length,
+// growing the length first when the current one is used up
+func (g *AutoLengthGenerator) GenerateShortCode(ctx context.Context, originalURL string, timestamp time.Time) (string, error) {
+	if err := g.load(ctx); err != nil {
+		return "", err
+	}
+
+	for {
+		index := g.current.Load()
+		tier := g.tiers[index]
+		shard := g.shards.pick()
+		local, err := g.counterProvider.GetNextCounter(ctx, tier.key(shard))
+		if err != nil {
+			return "", err
+		}
+		if !tier.final && local > tier.limit {
+			if err := g.grow(ctx, index); err != nil {
+				return "", err
+			}
+			continue
+		}
+
+		counter, err := shardCounter(shard, local, tier.span)
+		if err != nil {
+			return "", err
+		}
+		code, err := tier.obfuscator.Encode(counter)
+		if err != nil {
+			return "", fmt.Errorf("failed to encode counter: %w", err)
+		}
+		return code, nil
+	}
+}
+
+// load starts from the length stored by an earlier run, once. When the shard
+// count changed since, the shorter length in use is split differently and its
+// counters could repeat codes, so it is skipped.
+func (g *AutoLengthGenerator) load(ctx context.Context) error {
+	if g.loaded.Load() {
+		return nil
+	}
+	g.loadMu.Lock()
+	defer g.loadMu.Unlock()
+	if g.loaded.Load() {
+		return nil
+	}
+
+	length, err := g.counterProvider.GetCounter(ctx, codeLengthKey)
+	if err != nil {
+		return err
+	}
+	for i, tier := range g.tiers {
+		if int64(tier.space.length) <= length {
+			g.current.Store(int32(i))
+		}
+	}
+
+	shards, err := g.counterProvider.GetCounter(ctx, codeShardsKey)
+	if err != nil {
+		return err
+	}
+	if shards != int64(g.shards.shards) {
+		if index := g.current.Load(); shards != 0 && !g.tiers[index].final {
+			if err := g.grow(ctx, index); err != nil {
+				return err
+			}
+		}
+		if err := g.counterProvider.SetCounter(ctx, codeShardsKey, int64(g.shards.shards)); err != nil {
+			return fmt.Errorf("failed to record counter shards: %w", err)
+		}
+	}
+
+	g.loaded.Store(true)
+	return nil
+}
+
+// grow moves from the tier at index to the next one and records the new
+// length. Concurrent callers that saw the same tier run out grow it once.
+func (g *AutoLengthGenerator) grow(ctx context.Context, index int32) error {
+	if !g.current.CompareAndSwap(index, index+1) {
+		return nil
+	}
+	length := g.tiers[index+1].space.length
+	if err := g.counterProvider.SetCounter(ctx, codeLengthKey, int64(length)); err != nil {
+		return fmt.Errorf("failed to record code length %d: %w", length, err)
+	}
+	return nil
+}
+
+// Type returns the generator type
+func (g *AutoLengthGenerator) Type() string {
+	return "counter"
+}
+
+// Length returns the length of the codes currently issued
+func (g *AutoLengthGenerator) Length() int {
+	return g.tiers[g.current.Load()].space.length
+}
+
+// Shards returns the number of counter shards in use
+func (g *AutoLengthGenerator) Shards() int {
+	return g.shards.shards
+}
+
+// Obfuscation returns the obfuscation strategy in use
+func (g *AutoLengthGenerator) Obfuscation() string {
+	return g.tiers[0].obfuscator.Strategy()
+}
+
+// Warm reads the stored length and leases a range for every shard's counter
+// of the length in use
+func (g *AutoLengthGenerator) Warm(ctx context.Context) error {
+	if err := g.load(ctx); err != nil {
+		return err
+	}
+	tier := g.tiers[g.current.Load()]
+	keys := make([]string, g.shards.shards)
+	for shard := range keys {
+		keys[shard] = tier.key(shard)
+	}
+	return warm(ctx, g.counterProvider, keys)
+}
+
+// Counters returns the counter cache codes are issued from, nil for other
+// providers
+func (g *AutoLengthGenerator) Counters() *CounterCache {
+	return counterCache(g.counterProvider)
+}
+
+// Close performs cleanup
+func (g *AutoLengthGenerator) Close() error {
+	if g.counterProvider != nil {
+		return g.counterProvider.Close()
+	}
+	return nil
+}
+
+// Ensure AutoLengthGenerator implements Generator interface
+var _ Generator = (*AutoLengthGenerator)(nil)
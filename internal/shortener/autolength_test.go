@@ -0,0 +1,150 @@
+package shortener
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func autoLengthConfig() Config {
+	return Config{
+		CounterStep: 50,
+		Obfuscation: ObfuscationFeistel,
+		Secret:      "secret",
+		Length:      6,
+		AutoLength:  true,
+		MinLength:   4,
+		GrowAt:      0.00001, // 145 four-character codes, 9016 five-character ones
+	}
+}
+
+func TestAutoLengthGenerator_Grows(t *testing.T) {
+	queries := setupCounterTestDB(t)
+	ctx := context.Background()
+
+	generator, err := newAutoLengthGenerator(NewCounterCache(queries, 50), autoLengthConfig())
+	if err != nil {
+		t.Fatalf("newAutoLengthGenerator failed: %v", err)
+	}
+	defer generator.Close()
+
+	seen := make(map[string]bool)
+	lengths := make(map[int]int)
+	for i := 0; i < 400; i++ {
+		code, err := generator.GenerateShortCode(ctx, "https://example.com", time.Now())
+		if err != nil {
+			t.Fatalf("GenerateShortCode failed: %v", err)
+		}
+		if seen[code] {
+			t.Fatalf("Duplicate code %s", code)
+		}
+		seen[code] = true
+		lengths[len(code)]++
+	}
+
+	if lengths[4] != 145 || lengths[5] != 255 {
+		t.Errorf("Expected 145 four-character and 255 five-character codes, got %v", lengths)
+	}
+	if generator.Length() != 5 {
+		t.Errorf("Expected current length 5, got %d", generator.Length())
+	}
+
+	stored, err := queries.GetCounter(ctx, codeLengthKey)
+	if err != nil || stored != 5 {
+		t.Errorf("Expected the stored code length to be 5, got %d, %v", stored, err)
+	}
+
+	// A restarted generator resumes at the stored length
+	restarted, err := newAutoLengthGenerator(NewCounterCache(queries, 50), autoLengthConfig())
+	if err != nil {
+		t.Fatalf("newAutoLengthGenerator failed: %v", err)
+	}
+	code, err := restarted.GenerateShortCode(ctx, "https://example.com", time.Now())
+	if err != nil {
+		t.Fatalf("GenerateShortCode failed: %v", err)
+	}
+	if len(code) != 5 || seen[code] {
+		t.Errorf("Expected a new five-character code after restart, got %s", code)
+	}
+}
+
+func TestAutoLengthGenerator_FinalLength(t *testing.T) {
+	queries := setupCounterTestDB(t)
+	ctx := context.Background()
+
+	config := autoLengthConfig()
+	config.MinLength = 6
+	generator, err := newAutoLengthGenerator(NewCounterCache(queries, 50), config)
+	if err != nil {
+		t.Fatalf("newAutoLengthGenerator failed: %v", err)
+	}
+
+	// The configured length never grows and uses the fixed-length counter keys
+	for i := 0; i < 200; i++ {
+		code, err := generator.GenerateShortCode(ctx, "https://example.com", time.Now())
+		if err != nil {
+			t.Fatalf("GenerateShortCode failed: %v", err)
+		}
+		if len(code) != 6 {
+			t.Fatalf("Expected a six-character code, got %s", code)
+		}
+	}
+	if value, err := queries.GetCounter(ctx, counterKey(0)); err != nil || value == 0 {
+		t.Errorf("Expected the final length to use %s, got %d, %v", counterKey(0), value, err)
+	}
+}
+
+func TestAutoLengthGenerator_ShardCountChange(t *testing.T) {
+	queries := setupCounterTestDB(t)
+	ctx := context.Background()
+
+	generator, err := newAutoLengthGenerator(NewCounterCache(queries, 50), autoLengthConfig())
+	if err != nil {
+		t.Fatalf("newAutoLengthGenerator failed: %v", err)
+	}
+	if _, err := generator.GenerateShortCode(ctx, "https://example.com", time.Now()); err != nil {
+		t.Fatalf("GenerateShortCode failed: %v", err)
+	}
+
+	// Four-character codes were split for one shard, so two shards must skip them
+	config := autoLengthConfig()
+	config.CounterShards = 2
+	resharded, err := newAutoLengthGenerator(NewCounterCache(queries, 50), config)
+	if err != nil {
+		t.Fatalf("newAutoLengthGenerator failed: %v", err)
+	}
+	code, err := resharded.GenerateShortCode(ctx, "https://example.com", time.Now())
+	if err != nil {
+		t.Fatalf("GenerateShortCode failed: %v", err)
+	}
+	if len(code) != 5 {
+		t.Errorf("Expected a five-character code after changing the shard count, got %s", code)
+	}
+}
+
+func TestConfig_AutoLengthInvalid(t *testing.T) {
+	testCases := []struct {
+		name        string
+		config      Config
+		errContains string
+	}{
+		{"hashids", Config{AutoLength: true, Obfuscation: ObfuscationHashids}, "does not support hashids"},
+		{"minimum above length", Config{AutoLength: true, MinLength: 8}, "exceeds the code length"},
+		{"grow-at above one", Config{AutoLength: true, GrowAt: 1.5}, "grow-at fraction"},
+		{"too few short codes", Config{AutoLength: true, Alphabet: "0123456789abcdef", Length: 7}, "fewer than"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.config.Validate()
+			if err == nil || !strings.Contains(err.Error(), tc.errContains) {
+				t.Errorf("Expected error containing %q, got %v", tc.errContains, err)
+			}
+		})
+	}
+
+	if err := (Config{AutoLength: true}).Validate(); err != nil {
+		t.Errorf("Expected the auto length defaults to be valid: %v", err)
+	}
+}
@@ -0,0 +1,118 @@
+package shortener
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// DefaultReservedCodes are short codes that would be shadowed by server routes
+var DefaultReservedCodes = []string{"admin", "api", "healthz", "readyz", "login", "logout", "metrics", "static"}
+
+// DefaultBlockedWords are words no short code may contain
+var DefaultBlockedWords = []string{
+	"anal", "anus", "arse", "ass", "bitch", "boob", "butt", "cock", "crap", "cum",
+	"cunt", "damn", "dick", "dildo", "fag", "fuck", "jizz", "kkk", "nazi", "nigg",
+	"penis", "piss", "poop", "porn", "pussy", "sex", "shit", "slut", "tit", "twat",
+	"vagina", "wank", "whore",
+}
+
+// Blacklist rejects short codes that are reserved or contain a blocked word.
+// Matching is case-insensitive: reserved codes must match the whole code,
+// blocked words anywhere in it.
+type Blacklist struct {
+	reserved map[string]struct{}
+	words    [][]byte
+}
+
+// NewBlacklist creates a blacklist from reserved codes and blocked words
+func NewBlacklist(reservedCodes, blockedWords []string) *Blacklist {
+	b := &Blacklist{reserved: make(map[string]struct{}, len(reservedCodes))}
+	for _, code := range reservedCodes {
+		if code = normalizeBlacklistEntry(code); code != "" {
+			b.reserved[code] = struct{}{}
+		}
+	}
+	for _, word := range blockedWords {
+		if word = normalizeBlacklistEntry(word); word != "" {
+			b.words = append(b.words, []byte(word))
+		}
+	}
+	return b
+}
+
+// Check returns an error when a short code is reserved or contains a blocked word
+func (b *Blacklist) Check(code string) error {
+	if b == nil {
+		return nil
+	}
+
+	// Every redirect is checked, so ASCII codes are lowered on the stack
+	var buf [maxStackCodeLength]byte
+	lower, ok := lowerASCII(buf[:0], code)
+	if !ok {
+		lower = []byte(strings.ToLower(code))
+	}
+	if _, ok := b.reserved[string(lower)]; ok {
+		return fmt.Errorf("short code %q is reserved", code)
+	}
+	for _, word := range b.words {
+		if bytes.Contains(lower, word) {
+			return fmt.Errorf("short code %q contains a blocked word", code)
+		}
+	}
+	return nil
+}
+
+// maxStackCodeLength is the longest code Check lowers without allocating
+const maxStackCodeLength = 64
+
+// lowerASCII appends code lowercased to dst, or reports false when code is
+// too long or not ASCII
+func lowerASCII(dst []byte, code string) ([]byte, bool) {
+	if len(code) > maxStackCodeLength {
+		return nil, false
+	}
+	for i := 0; i < len(code); i++ {
+		c := code[i]
+		switch {
+		case c >= 0x80:
+			return nil, false
+		case 'A' <= c && c <= 'Z':
+			c += 'a' - 'A'
+		}
+		dst = append(dst, c)
+	}
+	return dst, true
+}
+
+// LoadBlockedWords reads blocked words from a file with one word per line.
+// Blank lines and lines starting with # are ignored.
+func LoadBlockedWords(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open blocked words file: %w", err)
+	}
+	defer file.Close()
+
+	var words []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		words = append(words, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read blocked words file: %w", err)
+	}
+	return words, nil
+}
+
+// normalizeBlacklistEntry lowercases an entry and strips surrounding whitespace
+func normalizeBlacklistEntry(entry string) string {
+	return strings.ToLower(strings.TrimSpace(entry))
+}
@@ -0,0 +1,80 @@
+package shortener
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBlacklist_Check(t *testing.T) {
+	blacklist := NewBlacklist([]string{"admin", " API "}, []string{"Shit"})
+
+	testCases := []struct {
+		code     string
+		contains string
+	}{
+		{"abc123", ""},
+		{"admin", "is reserved"},
+		{"ADMIN", "is reserved"},
+		{"api", "is reserved"},
+		{"admins", ""},
+		{"xShIty", "contains a blocked word"},
+		{"ÀdminShit", "contains a blocked word"}, // Not ASCII
+		{strings.Repeat("a", 70) + "SHIT", "contains a blocked word"}, // Too long to lower on the stack
+	}
+
+	for _, tc := range testCases {
+		err := blacklist.Check(tc.code)
+		if tc.contains == "" {
+			if err != nil {
+				t.Errorf("Check(%q) returned unexpected error: %v", tc.code, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tc.contains) {
+			t.Errorf("Check(%q) = %v, expected error containing %q", tc.code, err, tc.contains)
+		}
+	}
+
+	var none *Blacklist
+	if err := none.Check("admin"); err != nil {
+		t.Errorf("nil blacklist should allow every code, got: %v", err)
+	}
+}
+
+func TestDefaultConfig_Blacklist(t *testing.T) {
+	blacklist := DefaultConfig().Blacklist()
+
+	for _, code := range []string{"admin", "api", "healthz", "readyz", "login"} {
+		if err := blacklist.Check(code); err == nil {
+			t.Errorf("Expected default blacklist to reserve %q", code)
+		}
+	}
+	if err := blacklist.Check("xFuCk1"); err == nil {
+		t.Error("Expected default blacklist to block profanity")
+	}
+	if err := blacklist.Check("b7Kp2Q"); err != nil {
+		t.Errorf("Expected default blacklist to allow b7Kp2Q, got: %v", err)
+	}
+}
+
+func TestLoadBlockedWords(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "words.txt")
+	content := "# brand names we don't want in links\nacme\n\n  rival  \n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("Failed to write words file: %v", err)
+	}
+
+	words, err := LoadBlockedWords(path)
+	if err != nil {
+		t.Fatalf("LoadBlockedWords returned error: %v", err)
+	}
+	if len(words) != 2 || words[0] != "acme" || words[1] != "rival" {
+		t.Errorf("Expected [acme rival], got %v", words)
+	}
+
+	if _, err := LoadBlockedWords(filepath.Join(t.TempDir(), "missing.txt")); err == nil {
+		t.Error("Expected error for missing file")
+	}
+}
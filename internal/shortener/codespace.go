@@ -0,0 +1,144 @@
+package shortener
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+	"strings"
+)
+
+// Alphabet presets; Config.Alphabet also accepts the characters themselves
+const (
+	AlphabetBase62 = "0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	AlphabetBase58 = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz" // No 0/O or I/l, which are easily confused
+)
+
+// Limits of a configured code space
+const (
+	minAlphabetSize = 16
+	maxAlphabetSize = 64
+	minCodeLength   = 4
+	minCodeSpace    = 1_000_000 // Fewer codes than this would run out almost at once
+)
+
+// ResolveAlphabet returns the characters of an alphabet preset name
+// ("base62", "base58"), or alphabet itself when it is not a preset name
+func ResolveAlphabet(alphabet string) string {
+	switch alphabet {
+	case "", "base62":
+		return AlphabetBase62
+	case "base58":
+		return AlphabetBase58
+	default:
+		return alphabet
+	}
+}
+
+// codeSpace is the set of codes counter obfuscators produce: every string of
+// exactly length characters from alphabet that does not start with its first
+// character. As numbers in base len(alphabet) they are min through max.
+type codeSpace struct {
+	alphabet string
+	length   int
+	min, max uint64
+	size     uint64 // max - min + 1
+}
+
+// defaultCodeSpace is 7-character base62 codes, the space before alphabets
+// and lengths were configurable
+var defaultCodeSpace = codeSpace{
+	alphabet: AlphabetBase62,
+	length:   targetLength,
+	min:      minCodeValue,
+	max:      maxCodeValue,
+	size:     codeRangeSize,
+}
+
+// newCodeSpace checks an alphabet and length and returns their code space.
+// Alphabets are 16 to 64 distinct letters, digits, '-' or '_', so codes need
+// no escaping in a URL path.
+func newCodeSpace(alphabet string, length int) (codeSpace, error) {
+	if len(alphabet) < minAlphabetSize || len(alphabet) > maxAlphabetSize {
+		return codeSpace{}, fmt.Errorf("alphabet must have %d to %d characters, got %d", minAlphabetSize, maxAlphabetSize, len(alphabet))
+	}
+	for i, char := range alphabet {
+		if !isCodeChar(char) {
+			return codeSpace{}, fmt.Errorf("alphabet character %q is not a letter, digit, '-' or '_'", char)
+		}
+		if strings.IndexRune(alphabet, char) != i {
+			return codeSpace{}, fmt.Errorf("alphabet repeats character %q", char)
+		}
+	}
+	if length < minCodeLength {
+		return codeSpace{}, fmt.Errorf("code length must be at least %d, got %d", minCodeLength, length)
+	}
+
+	radix := uint64(len(alphabet))
+	min := uint64(1)
+	for i := 1; i < length; i++ {
+		hi, lo := bits.Mul64(min, radix)
+		if hi != 0 || lo > math.MaxUint64/radix {
+			return codeSpace{}, fmt.Errorf("%d-character codes over %d characters do not fit in 64 bits", length, radix)
+		}
+		min = lo
+	}
+	max := min*radix - 1
+	if max-min+1 < minCodeSpace {
+		return codeSpace{}, fmt.Errorf("%d-character codes over %d characters give fewer than %d codes", length, radix, minCodeSpace)
+	}
+
+	return codeSpace{alphabet: alphabet, length: length, min: min, max: max, size: max - min + 1}, nil
+}
+
+// isCodeChar reports whether char may appear in a short code
+func isCodeChar(char rune) bool {
+	return char >= '0' && char <= '9' || char >= 'a' && char <= 'z' || char >= 'A' && char <= 'Z' || char == '-' || char == '_'
+}
+
+// encode writes a number in the space's alphabet
+func (s codeSpace) encode(num uint64) string {
+	return encodeDigits(num, s.alphabet)
+}
+
+// decode reads a code back into a number, rejecting codes outside the space
+func (s codeSpace) decode(code string) (uint64, error) {
+	value, err := decodeDigits(code, s.alphabet)
+	if err != nil {
+		return 0, err
+	}
+	if len(code) != s.length || value < s.min || value > s.max {
+		return 0, fmt.Errorf("code %q is outside the %d-character code space", code, s.length)
+	}
+	return value, nil
+}
+
+// encodeDigits writes num in base len(alphabet)
+func encodeDigits(num uint64, alphabet string) string {
+	if num == 0 {
+		return alphabet[:1]
+	}
+
+	radix := uint64(len(alphabet))
+	var buf [64]byte
+	i := len(buf)
+	for num > 0 {
+		i--
+		buf[i] = alphabet[num%radix]
+		num /= radix
+	}
+	return string(buf[i:])
+}
+
+// decodeDigits reads a number written in base len(alphabet)
+func decodeDigits(str, alphabet string) (uint64, error) {
+	radix := uint64(len(alphabet))
+	result := uint64(0)
+	for _, char := range str {
+		index := strings.IndexRune(alphabet, char)
+		if index < 0 {
+			return 0, fmt.Errorf("invalid character %q", char)
+		}
+		result = result*radix + uint64(index)
+	}
+	return result, nil
+}
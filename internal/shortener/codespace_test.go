@@ -0,0 +1,152 @@
+package shortener
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNewCodeSpace(t *testing.T) {
+	testCases := []struct {
+		name        string
+		alphabet    string
+		length      int
+		errContains string
+	}{
+		{"base62", AlphabetBase62, 7, ""},
+		{"base58", AlphabetBase58, 6, ""},
+		{"hex", "0123456789abcdef", 10, ""},
+		{"too few characters", "0123456789", 7, "16 to 64 characters"},
+		{"repeated character", "0123456789abcdea", 7, "repeats character 'a'"},
+		{"unsafe character", "0123456789abcde/", 7, "not a letter, digit"},
+		{"too short", AlphabetBase58, 3, "at least 4"},
+		{"too few codes", "0123456789abcdef", 5, "fewer than"},
+		{"too long", AlphabetBase62, 12, "do not fit in 64 bits"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			space, err := newCodeSpace(tc.alphabet, tc.length)
+			if tc.errContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.errContains) {
+					t.Errorf("Expected error containing %q, got %v", tc.errContains, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("newCodeSpace failed: %v", err)
+			}
+			if got := space.encode(space.min); len(got) != tc.length {
+				t.Errorf("Expected the smallest code to have %d characters, got %s", tc.length, got)
+			}
+			if got := space.encode(space.max); len(got) != tc.length {
+				t.Errorf("Expected the largest code to have %d characters, got %s", tc.length, got)
+			}
+		})
+	}
+
+	space, _ := newCodeSpace(AlphabetBase62, targetLength)
+	if space != defaultCodeSpace {
+		t.Errorf("Expected 7-character base62 to be the default code space, got %+v", space)
+	}
+}
+
+func TestConfig_CodeSpace(t *testing.T) {
+	strategies := []struct {
+		obfuscation string
+		secret      string
+	}{
+		{ObfuscationMultiplicative, ""},
+		{ObfuscationFeistel, "secret"},
+		{ObfuscationHashids, "secret"},
+		{ObfuscationFF1, "secret"},
+	}
+
+	for _, strategy := range strategies {
+		t.Run(strategy.obfuscation, func(t *testing.T) {
+			config := Config{Obfuscation: strategy.obfuscation, Secret: strategy.secret, Alphabet: "base58", Length: 6}
+			obfuscator, err := config.Obfuscator()
+			if err != nil {
+				t.Fatalf("Obfuscator failed: %v", err)
+			}
+
+			seen := make(map[string]bool)
+			for id := uint64(0); id < 5000; id++ {
+				code, err := obfuscator.Encode(id)
+				if err != nil {
+					t.Fatalf("Encode(%d) failed: %v", id, err)
+				}
+				if len(code) < 6 || (strategy.obfuscation != ObfuscationHashids && len(code) != 6) {
+					t.Fatalf("Expected a 6-character code, got %s", code)
+				}
+				if strings.ContainsAny(code, "0OIl") {
+					t.Fatalf("Expected no ambiguous characters in base58 code %s", code)
+				}
+				if strategy.obfuscation != ObfuscationMultiplicative && seen[code] {
+					t.Fatalf("Collision at counter %d: %s", id, code)
+				}
+				seen[code] = true
+			}
+		})
+	}
+}
+
+func TestConfig_CodeSpaceDefaults(t *testing.T) {
+	// An unset alphabet and length must keep the codes issued before they existed
+	for _, config := range []Config{{}, {Alphabet: "base62", Length: 7}, {Alphabet: AlphabetBase62}} {
+		obfuscator, err := config.Obfuscator()
+		if err != nil {
+			t.Fatalf("Obfuscator failed: %v", err)
+		}
+		code, _ := obfuscator.Encode(1)
+		want, _ := newMultiplicativeObfuscator().Encode(1)
+		if code != want {
+			t.Errorf("Expected %+v to keep code %s, got %s", config, want, code)
+		}
+	}
+}
+
+func TestConfig_CodeSpaceInvalid(t *testing.T) {
+	testCases := []struct {
+		name        string
+		config      Config
+		errContains string
+	}{
+		{"unknown preset", Config{Alphabet: "base32"}, "16 to 64 characters"},
+		{"short length", Config{Length: 3}, "at least 4"},
+		{"ff1 too long", Config{Obfuscation: ObfuscationFF1, Secret: "secret", Alphabet: "0123456789abcdefg", Length: 15}, "at most 14 characters"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.config.Validate()
+			if err == nil || !strings.Contains(err.Error(), tc.errContains) {
+				t.Errorf("Expected error containing %q, got %v", tc.errContains, err)
+			}
+		})
+	}
+}
+
+func TestCounterGenerator_ShardedCodeSpace(t *testing.T) {
+	space, err := newCodeSpace(AlphabetBase58, 5)
+	if err != nil {
+		t.Fatalf("newCodeSpace failed: %v", err)
+	}
+	obfuscator := newFeistelObfuscatorIn(space, "secret")
+
+	generator, err := newShardedCounterGenerator(nil, obfuscator, space, MaxCounterShards, ShardPickRoundRobin)
+	if err != nil {
+		t.Fatalf("newShardedCounterGenerator failed: %v", err)
+	}
+	if generator.span != space.size/MaxCounterShards {
+		t.Errorf("Expected shard regions of %d counters, got %d", space.size/MaxCounterShards, generator.span)
+	}
+
+	// The last shard's last counter must still be inside the code space
+	last, err := shardCounter(MaxCounterShards-1, int64(generator.span)-1, generator.span)
+	if err != nil {
+		t.Fatalf("shardCounter failed: %v", err)
+	}
+	if _, err := obfuscator.Encode(last); err != nil {
+		t.Errorf("Expected the last shard counter to encode, got %v", err)
+	}
+}
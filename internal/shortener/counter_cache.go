@@ -3,218 +3,343 @@ package shortener
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"log"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/joshdurbin/url-shortener/db/sqlc"
 )
 
-// CounterCache provides an in-memory counter cache with async writeback to database
+// RefillAt is the fraction of a lease handed out before a prefetching cache
+// leases the next range in the background
+const RefillAt = 0.8
+
+// prefetchTimeout bounds leasing a range in the background
+const prefetchTimeout = 30 * time.Second
+
+// CounterCache provides an in-memory counter cache backed by atomic range leases.
+//
+// Each lease reserves the next jumpAhead values for a key with a single
+// INSERT ... ON CONFLICT DO UPDATE ... RETURNING statement, so multiple server
+// processes sharing one database always receive non-overlapping ranges.
+// Values left unused in a lease when a process stops are skipped, never reused.
+//
+// Each key has its own lock, so callers using different keys (counter shards)
+// never wait on each other; only the map of keys is shared.
+//
+// With prefetching, the next range of a key is leased in the background once
+// RefillAt of the current one is handed out, so callers only wait on the
+// database when codes are issued faster than a lease takes.
 type CounterCache struct {
-	mu           sync.RWMutex
-	db           *sqlc.Queries
-	counters     map[string]*cacheEntry
-	jumpAhead    int64
-	writebackCh  chan writebackRequest
-	stopCh       chan struct{}
-	wg           sync.WaitGroup
+	mu        sync.RWMutex // Guards counters and closed; each lease has its own lock
+	db        *sqlc.Queries
+	counters  map[string]*counterLease
+	jumpAhead int64
+	prefetch  bool
+	closed    bool
+	wg        sync.WaitGroup // Background leases
+
+	waited     atomic.Int64 // Leases taken while a caller waited
+	prefetched atomic.Int64 // Leases taken ahead of use
 }
 
-type cacheEntry struct {
-	current   int64
-	allocated int64
-	dirty     bool
+// counterLease is a range of counter values reserved by this process
+type counterLease struct {
+	mu         sync.Mutex
+	current    int64  // Last value handed out
+	end        int64  // Last value in the leased range (inclusive); current == end means none left
+	next       int64  // Last value of the range leased in the background, 0 when there is none
+	fetching   bool   // Whether a background lease is in flight
+	generation uint64 // Bumped by SetCounter so background leases from before it are dropped
 }
 
-type writebackRequest struct {
-	key   string
-	value int64
-	resp  chan error
+// CounterCacheOption configures a CounterCache
+type CounterCacheOption func(*CounterCache)
+
+// WithPrefetch leases each key's next range in the background once RefillAt
+// of the current one is handed out
+func WithPrefetch() CounterCacheOption {
+	return func(c *CounterCache) {
+		c.prefetch = true
+	}
 }
 
 // NewCounterCache creates a new counter cache
-func NewCounterCache(db *sqlc.Queries, jumpAhead int64) *CounterCache {
-	cache := &CounterCache{
-		db:          db,
-		counters:    make(map[string]*cacheEntry),
-		jumpAhead:   jumpAhead,
-		writebackCh: make(chan writebackRequest, 100),
-		stopCh:      make(chan struct{}),
-	}
-	
-	// Start writeback goroutine
-	cache.wg.Add(1)
-	go cache.writebackWorker()
-	
-	return cache
+func NewCounterCache(db *sqlc.Queries, jumpAhead int64, opts ...CounterCacheOption) *CounterCache {
+	if jumpAhead < 1 {
+		jumpAhead = 1
+	}
+
+	c := &CounterCache{
+		db:        db,
+		counters:  make(map[string]*counterLease),
+		jumpAhead: jumpAhead,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
 }
 
-// GetNextCounter returns the next counter value, allocating more from DB if needed
+// GetNextCounter returns the next counter value, leasing a new range from the DB if needed
 func (c *CounterCache) GetNextCounter(ctx context.Context, key string) (int64, error) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-	
-	entry, exists := c.counters[key]
-	if !exists {
-		// Initialize from database
-		dbValue, err := c.db.GetCounter(ctx, key)
-		if err != nil && err != sql.ErrNoRows {
-			return 0, fmt.Errorf("failed to get counter from DB: %w", err)
-		}
-		
-		if err == sql.ErrNoRows {
-			dbValue = 0
-		}
-		
-		entry = &cacheEntry{
-			current:   dbValue,
-			allocated: dbValue + c.jumpAhead,
-			dirty:     true,
+	lease := c.leaseFor(key)
+	lease.mu.Lock()
+	defer lease.mu.Unlock()
+
+	if lease.current >= lease.end {
+		if lease.next != 0 {
+			lease.current, lease.end, lease.next = lease.next-c.jumpAhead, lease.next, 0
+		} else {
+			end, err := c.lease(ctx, key)
+			if err != nil {
+				return 0, err
+			}
+			c.waited.Add(1)
+			lease.current, lease.end = end-c.jumpAhead, end
 		}
-		c.counters[key] = entry
-		
-		// Async writeback of allocated value
-		go c.asyncWriteback(key, entry.allocated)
-	}
-	
-	// Check if we need to allocate more
-	if entry.current >= entry.allocated {
-		newAllocated := entry.allocated + c.jumpAhead
-		oldAllocated := entry.allocated
-		entry.allocated = newAllocated
-		entry.dirty = true
-		
-		// Async writeback of new allocated value
-		go c.asyncWriteback(key, newAllocated)
-		
-		// Update current to continue from where we left off
-		entry.current = oldAllocated
-	}
-	
-	entry.current++
-	return entry.current, nil
-}
-
-// SetCounter sets a counter value
-func (c *CounterCache) SetCounter(ctx context.Context, key string, value int64) error {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-	
-	entry := &cacheEntry{
-		current:   value,
-		allocated: value + c.jumpAhead,
-		dirty:     true,
-	}
-	c.counters[key] = entry
-	
-	// Async writeback
-	go c.asyncWriteback(key, entry.allocated)
-	
-	return nil
+	}
+
+	lease.current++
+	if c.prefetch {
+		c.refill(key, lease)
+	}
+	return lease.current, nil
 }
 
-// asyncWriteback performs async writeback without blocking
-func (c *CounterCache) asyncWriteback(key string, value int64) {
-	select {
-	case c.writebackCh <- writebackRequest{key: key, value: value}:
-	case <-c.stopCh:
+// refill leases a key's next range in the background once RefillAt of the
+// current one is handed out. The caller holds the lease's lock.
+func (c *CounterCache) refill(key string, lease *counterLease) {
+	if lease.fetching || lease.next != 0 || float64(lease.end-lease.current) > float64(c.jumpAhead)*(1-RefillAt) {
 		return
-	default:
-		// Channel full, skip this writeback (will be handled by sync on close)
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.closed {
 		return
 	}
+	lease.fetching = true
+	generation := lease.generation
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		ctx, cancel := context.WithTimeout(context.Background(), prefetchTimeout)
+		defer cancel()
+		end, err := c.lease(ctx, key)
+
+		lease.mu.Lock()
+		defer lease.mu.Unlock()
+		lease.fetching = false
+		if err != nil {
+			log.Printf("Error preallocating counter range for %s: %v", key, err)
+			return
+		}
+		c.prefetched.Add(1)
+		if lease.generation == generation {
+			lease.next = end
+		}
+	}()
 }
 
-// writebackWorker handles async writebacks
-func (c *CounterCache) writebackWorker() {
-	defer c.wg.Done()
-	
-	for {
-		select {
-		case req := <-c.writebackCh:
-			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-			err := c.db.SetCounter(ctx, sqlc.SetCounterParams{
-				Key:   req.key,
-				Value: req.value,
-			})
-			cancel()
-			
-			if req.resp != nil {
-				req.resp <- err
-			}
-			
-		case <-c.stopCh:
-			// Process remaining requests
-			for {
-				select {
-				case req := <-c.writebackCh:
-					ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-					err := c.db.SetCounter(ctx, sqlc.SetCounterParams{
-						Key:   req.key,
-						Value: req.value,
-					})
-					cancel()
-					
-					if req.resp != nil {
-						req.resp <- err
-					}
-				default:
-					return
-				}
+// Warm leases a range for each key that has none, so the first values handed
+// out after a start do not wait on the database
+func (c *CounterCache) Warm(ctx context.Context, keys ...string) error {
+	for _, key := range keys {
+		lease := c.leaseFor(key)
+		lease.mu.Lock()
+		if lease.current >= lease.end && lease.next == 0 {
+			end, err := c.lease(ctx, key)
+			if err != nil {
+				lease.mu.Unlock()
+				return err
 			}
+			c.prefetched.Add(1)
+			lease.current, lease.end = end-c.jumpAhead, end
 		}
+		lease.mu.Unlock()
 	}
+	return nil
 }
 
-// Sync synchronously writes all dirty entries to database
-func (c *CounterCache) Sync(ctx context.Context) error {
+// leaseFor returns the lease of a key, adding an empty one on first use
+func (c *CounterCache) leaseFor(key string) *counterLease {
 	c.mu.RLock()
-	dirtyEntries := make(map[string]int64)
-	for key, entry := range c.counters {
-		if entry.dirty {
-			dirtyEntries[key] = entry.allocated
-		}
+	lease, exists := c.counters[key]
+	c.mu.RUnlock()
+	if exists {
+		return lease
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if lease, exists = c.counters[key]; !exists {
+		lease = &counterLease{}
+		c.counters[key] = lease
+	}
+	return lease
+}
+
+// lease atomically reserves the next jumpAhead values for a key and returns
+// the last of them
+func (c *CounterCache) lease(ctx context.Context, key string) (int64, error) {
+	end, err := c.db.IncrementCounter(ctx, sqlc.IncrementCounterParams{
+		Key:   key,
+		Value: c.jumpAhead,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to lease counter range from DB: %w", err)
+	}
+	return end, nil
+}
+
+// GetCounter returns the value stored for a key, 0 for a key never set. For a
+// leased key this is the end of the latest lease by any process, not the last
+// value handed out.
+func (c *CounterCache) GetCounter(ctx context.Context, key string) (int64, error) {
+	value, err := c.db.GetCounter(ctx, key)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get counter %s: %w", key, err)
+	}
+	return value, nil
+}
+
+// SetCounter sets a counter value so the next value handed out is value+1
+func (c *CounterCache) SetCounter(ctx context.Context, key string, value int64) error {
+	lease := c.leaseFor(key)
+	lease.mu.Lock()
+	defer lease.mu.Unlock()
+
+	if err := c.db.SetCounter(ctx, sqlc.SetCounterParams{
+		Key:   key,
+		Value: value,
+	}); err != nil {
+		return fmt.Errorf("failed to set counter %s: %w", key, err)
+	}
+
+	// Drop the local leases so the next call leases from the new value
+	lease.current, lease.end, lease.next = 0, 0, 0
+	lease.generation++
+	return nil
+}
+
+// LeaseStats describes the counter values this process holds for one key
+type LeaseStats struct {
+	Key       string
+	Remaining int64 // Leased values not yet handed out, including a range leased in the background
+}
+
+// CounterStats describes a counter cache's leases
+type CounterStats struct {
+	LeaseSize  int64        // Values reserved by each lease
+	Leases     []LeaseStats // One per key values were leased for, ordered by key
+	Waited     int64        // Leases taken while a caller waited on the database
+	Prefetched int64        // Leases taken ahead of use, at start or in the background
+}
+
+// Stats returns the values left in each lease and the leases taken so far
+func (c *CounterCache) Stats() CounterStats {
+	stats := CounterStats{
+		LeaseSize:  c.jumpAhead,
+		Waited:     c.waited.Load(),
+		Prefetched: c.prefetched.Load(),
+	}
+
+	// Leases are locked after the map is released: refill takes the map's
+	// lock while holding a lease's
+	c.mu.RLock()
+	leases := make(map[string]*counterLease, len(c.counters))
+	for key, lease := range c.counters {
+		leases[key] = lease
 	}
 	c.mu.RUnlock()
-	
-	for key, value := range dirtyEntries {
-		if err := c.db.SetCounter(ctx, sqlc.SetCounterParams{
-			Key:   key,
-			Value: value,
-		}); err != nil {
-			return fmt.Errorf("failed to sync counter %s: %w", key, err)
+
+	for key, lease := range leases {
+		lease.mu.Lock()
+		held := lease.end != 0 || lease.next != 0
+		remaining := lease.end - lease.current
+		if lease.next != 0 {
+			remaining += c.jumpAhead
 		}
-		
-		// Mark as clean
-		c.mu.Lock()
-		if entry, exists := c.counters[key]; exists {
-			entry.dirty = false
+		lease.mu.Unlock()
+		// Keys only ever set, such as the auto length state, hold no lease
+		if held {
+			stats.Leases = append(stats.Leases, LeaseStats{Key: key, Remaining: remaining})
 		}
-		c.mu.Unlock()
 	}
-	
+
+	sort.Slice(stats.Leases, func(i, j int) bool { return stats.Leases[i].Key < stats.Leases[j].Key })
+	return stats
+}
+
+// Sync is kept for callers that flush counters before shutdown. Leases are
+// persisted when they are acquired, so there is no pending state to write.
+func (c *CounterCache) Sync(ctx context.Context) error {
 	return nil
 }
 
-// Close closes the counter cache and syncs all dirty entries
+// Close waits for background leases and releases the counter cache
 func (c *CounterCache) Close() error {
-	select {
-	case <-c.stopCh:
-		// Already closed
+	c.mu.Lock()
+	c.closed = true
+	c.mu.Unlock()
+	c.wg.Wait()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.counters = make(map[string]*counterLease)
+	return nil
+}
+
+// leasingGenerator is a generator whose counters can be leased ahead of use
+type leasingGenerator interface {
+	Warm(ctx context.Context) error
+	Counters() *CounterCache
+}
+
+// Counters returns the counter cache a generator issues codes from, or nil
+// when it keeps none
+func Counters(g Generator) *CounterCache {
+	if leasing, ok := g.(leasingGenerator); ok {
+		return leasing.Counters()
+	}
+	return nil
+}
+
+// Warm leases the counter ranges a generator issues its next codes from, so
+// the first codes after a start do not wait on the database
+func Warm(ctx context.Context, g Generator) error {
+	if leasing, ok := g.(leasingGenerator); ok {
+		return leasing.Warm(ctx)
+	}
+	return nil
+}
+
+// counterCache returns provider as a CounterCache, or nil when it is another
+// provider
+func counterCache(provider CounterProvider) *CounterCache {
+	cache, _ := provider.(*CounterCache)
+	return cache
+}
+
+// warm leases ranges for keys when provider is a CounterCache
+func warm(ctx context.Context, provider CounterProvider, keys []string) error {
+	cache := counterCache(provider)
+	if cache == nil {
 		return nil
-	default:
-		close(c.stopCh)
 	}
-	
-	c.wg.Wait()
-	
-	// Sync all dirty entries
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
-	
-	return c.Sync(ctx)
+	if err := cache.Warm(ctx, keys...); err != nil {
+		return fmt.Errorf("failed to preallocate counter ranges: %w", err)
+	}
+	return nil
 }
 
 // Ensure CounterCache implements CounterProvider
-var _ CounterProvider = (*CounterCache)(nil)
\ No newline at end of file
+var _ CounterProvider = (*CounterCache)(nil)
@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 	"sync"
 	"testing"
+	"time"
 
 	_ "github.com/mattn/go-sqlite3"
 	"github.com/joshdurbin/url-shortener/db/sqlc"
@@ -211,4 +212,261 @@ func TestCounterCacheSync(t *testing.T) {
 	if value <= 1 {
 		t.Errorf("Expected counter to continue from synced value, got %d", value)
 	}
-}
\ No newline at end of file
+}
+func TestCounterCacheMultipleInstances(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "shared.db")
+
+	// Each instance gets its own connection pool, as separate processes would
+	openQueries := func() *sqlc.Queries {
+		db, err := sql.Open("sqlite3", dbPath+"?_busy_timeout=5000")
+		if err != nil {
+			t.Fatalf("Failed to open shared database: %v", err)
+		}
+		t.Cleanup(func() { db.Close() })
+
+		if _, err := db.Exec(`
+			CREATE TABLE IF NOT EXISTS counters (
+				key TEXT PRIMARY KEY,
+				value INTEGER NOT NULL DEFAULT 0,
+				updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+			)
+		`); err != nil {
+			t.Fatalf("Failed to create counters table: %v", err)
+		}
+		return sqlc.New(db)
+	}
+
+	ctx := context.Background()
+	key := "shared-counter"
+	numInstances := 3
+	numIncrements := 50
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	seen := make(map[int64]int)
+
+	for i := 0; i < numInstances; i++ {
+		cache := NewCounterCache(openQueries(), 7)
+		defer cache.Close()
+
+		wg.Add(1)
+		go func(instance int) {
+			defer wg.Done()
+			for j := 0; j < numIncrements; j++ {
+				value, err := cache.GetNextCounter(ctx, key)
+				if err != nil {
+					t.Errorf("Instance %d: GetNextCounter failed: %v", instance, err)
+					return
+				}
+
+				mu.Lock()
+				if previous, dup := seen[value]; dup {
+					t.Errorf("Value %d handed out by instances %d and %d", value, previous, instance)
+				}
+				seen[value] = instance
+				mu.Unlock()
+			}
+		}(i)
+	}
+
+	wg.Wait()
+
+	if len(seen) != numInstances*numIncrements {
+		t.Errorf("Expected %d unique values, got %d", numInstances*numIncrements, len(seen))
+	}
+}
+
+func TestCounterCacheLeaseBoundaries(t *testing.T) {
+	queries := setupTestDB(t)
+	cache := NewCounterCache(queries, 3)
+	defer cache.Close()
+
+	ctx := context.Background()
+	key := "lease-test"
+
+	for i := int64(1); i <= 4; i++ {
+		value, err := cache.GetNextCounter(ctx, key)
+		if err != nil {
+			t.Fatalf("GetNextCounter failed: %v", err)
+		}
+		if value != i {
+			t.Errorf("Expected counter value %d, got %d", i, value)
+		}
+	}
+
+	// Two leases of 3 have been taken, so the database records the high-water mark
+	stored, err := queries.GetCounter(ctx, key)
+	if err != nil {
+		t.Fatalf("GetCounter failed: %v", err)
+	}
+	if stored != 6 {
+		t.Errorf("Expected stored counter 6 after two leases, got %d", stored)
+	}
+}
+
+// waitForPrefetch waits until a key's next range has been leased in the background
+func waitForPrefetch(t *testing.T, cache *CounterCache, key string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		lease := cache.leaseFor(key)
+		lease.mu.Lock()
+		ready := lease.next != 0
+		lease.mu.Unlock()
+		if ready {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("the next range of %s was not leased in the background", key)
+}
+
+func TestCounterCachePrefetch(t *testing.T) {
+	queries := setupTestDB(t)
+	cache := NewCounterCache(queries, 10, WithPrefetch())
+	defer cache.Close()
+
+	ctx := context.Background()
+	key := "prefetch-test"
+
+	// 8 of 10 values handed out reaches RefillAt
+	for i := int64(1); i <= 8; i++ {
+		value, err := cache.GetNextCounter(ctx, key)
+		if err != nil {
+			t.Fatalf("GetNextCounter failed: %v", err)
+		}
+		if value != i {
+			t.Errorf("Expected counter value %d, got %d", i, value)
+		}
+	}
+	waitForPrefetch(t, cache, key)
+
+	stats := cache.Stats()
+	if stats.Waited != 1 || stats.Prefetched != 1 {
+		t.Errorf("Expected 1 lease waited on and 1 prefetched, got %d and %d", stats.Waited, stats.Prefetched)
+	}
+	if len(stats.Leases) != 1 || stats.Leases[0].Key != key || stats.Leases[0].Remaining != 12 {
+		t.Errorf("Expected 12 values left for %s, got %+v", key, stats.Leases)
+	}
+
+	// The prefetched range follows the current one without waiting
+	for i := int64(9); i <= 12; i++ {
+		value, err := cache.GetNextCounter(ctx, key)
+		if err != nil {
+			t.Fatalf("GetNextCounter failed: %v", err)
+		}
+		if value != i {
+			t.Errorf("Expected counter value %d, got %d", i, value)
+		}
+	}
+	if waited := cache.Stats().Waited; waited != 1 {
+		t.Errorf("Expected no further leases waited on, got %d", waited)
+	}
+}
+
+func TestCounterCacheWarm(t *testing.T) {
+	queries := setupTestDB(t)
+	cache := NewCounterCache(queries, 100)
+	defer cache.Close()
+
+	ctx := context.Background()
+	if err := cache.Warm(ctx, "warm-a", "warm-b"); err != nil {
+		t.Fatalf("Warm failed: %v", err)
+	}
+	// Warming again keeps the leases already held
+	if err := cache.Warm(ctx, "warm-a"); err != nil {
+		t.Fatalf("Warm failed: %v", err)
+	}
+
+	for _, key := range []string{"warm-a", "warm-b"} {
+		stored, err := queries.GetCounter(ctx, key)
+		if err != nil {
+			t.Fatalf("GetCounter failed: %v", err)
+		}
+		if stored != 100 {
+			t.Errorf("Expected one lease of 100 for %s, got %d", key, stored)
+		}
+	}
+
+	value, err := cache.GetNextCounter(ctx, "warm-a")
+	if err != nil {
+		t.Fatalf("GetNextCounter failed: %v", err)
+	}
+	if value != 1 {
+		t.Errorf("Expected counter value 1, got %d", value)
+	}
+	stats := cache.Stats()
+	if stats.Waited != 0 || stats.Prefetched != 2 {
+		t.Errorf("Expected 0 leases waited on and 2 prefetched, got %d and %d", stats.Waited, stats.Prefetched)
+	}
+	if len(stats.Leases) != 2 || stats.Leases[0].Remaining != 99 || stats.Leases[1].Remaining != 100 {
+		t.Errorf("Unexpected leases: %+v", stats.Leases)
+	}
+}
+
+func TestCounterCacheSetCounterDropsPrefetch(t *testing.T) {
+	queries := setupTestDB(t)
+	cache := NewCounterCache(queries, 5, WithPrefetch())
+	defer cache.Close()
+
+	ctx := context.Background()
+	key := "set-prefetch"
+	for i := 0; i < 4; i++ {
+		if _, err := cache.GetNextCounter(ctx, key); err != nil {
+			t.Fatalf("GetNextCounter failed: %v", err)
+		}
+	}
+	waitForPrefetch(t, cache, key)
+
+	if err := cache.SetCounter(ctx, key, 1000); err != nil {
+		t.Fatalf("SetCounter failed: %v", err)
+	}
+	value, err := cache.GetNextCounter(ctx, key)
+	if err != nil {
+		t.Fatalf("GetNextCounter failed: %v", err)
+	}
+	if value != 1001 {
+		t.Errorf("Expected counter value 1001 after SetCounter, got %d", value)
+	}
+}
+
+func TestWarmGenerator(t *testing.T) {
+	queries := setupTestDB(t)
+	config := DefaultConfig()
+	config.CounterStep = 50
+	config.CounterShards = 3
+	config.Preallocate = true
+	generator, err := NewGenerator(config, queries)
+	if err != nil {
+		t.Fatalf("NewGenerator failed: %v", err)
+	}
+	defer generator.Close()
+
+	ctx := context.Background()
+	if err := Warm(ctx, generator); err != nil {
+		t.Fatalf("Warm failed: %v", err)
+	}
+	counters := Counters(generator)
+	if counters == nil {
+		t.Fatal("Expected the generator's counter cache")
+	}
+	stats := counters.Stats()
+	if len(stats.Leases) != 3 || stats.Leases[0].Key != "url_counter" || stats.Leases[0].Remaining != 50 {
+		t.Errorf("Expected a lease of 50 for each of 3 shards, got %+v", stats.Leases)
+	}
+
+	config.AutoLength = true
+	config.CounterShards = 1
+	autoLength, err := NewGenerator(config, queries)
+	if err != nil {
+		t.Fatalf("NewGenerator failed: %v", err)
+	}
+	defer autoLength.Close()
+	if err := Warm(ctx, autoLength); err != nil {
+		t.Fatalf("Warm failed: %v", err)
+	}
+	stats = Counters(autoLength).Stats()
+	if len(stats.Leases) != 1 || stats.Leases[0].Key != "url_counter_len4" {
+		t.Errorf("Expected a lease for the shortest length, got %+v", stats.Leases)
+	}
+}
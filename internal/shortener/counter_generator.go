@@ -2,118 +2,102 @@ package shortener
 
 import (
 	"context"
-	"math/bits"
+	"fmt"
 	"time"
 )
 
-const (
-	// Base62 characters: 0-9, a-z, A-Z (case sensitive)
-	base62Chars = "0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
-	targetLength = 7 // Target length for short codes
-)
-
-// CounterGenerator generates obfuscated short codes using a monotonic counter with bit manipulation
+// CounterGenerator generates obfuscated short codes from a monotonic counter.
+// With more than one shard, each shard is an independent counter (its own key
+// and lease), so concurrent callers rarely wait on each other.
 type CounterGenerator struct {
 	counterProvider CounterProvider
-	counterKey      string
-	multiplier      uint64 // Large prime multiplier for obfuscation
-	salt           uint64 // Salt value to add entropy
+	shards          *shardPicker
+	span            uint64 // Counter values in each shard's region
+	obfuscator      Obfuscator
 }
 
-// NewCounterGenerator creates a new counter-based generator with obfuscation
+// NewCounterGenerator creates a new counter-based generator using the
+// multiplicative obfuscation scheme
 func NewCounterGenerator(counterProvider CounterProvider) *CounterGenerator {
+	return NewCounterGeneratorWithObfuscator(counterProvider, newMultiplicativeObfuscator())
+}
+
+// NewCounterGeneratorWithObfuscator creates a new counter-based generator with the given obfuscator
+func NewCounterGeneratorWithObfuscator(counterProvider CounterProvider, obfuscator Obfuscator) *CounterGenerator {
+	generator, _ := NewShardedCounterGenerator(counterProvider, obfuscator, 1, ShardPickRoundRobin)
+	return generator
+}
+
+// NewShardedCounterGenerator creates a counter-based generator spreading codes
+// over shards counters (1 to MaxCounterShards), chosen by the pick strategy
+func NewShardedCounterGenerator(counterProvider CounterProvider, obfuscator Obfuscator, shards int, pick string) (*CounterGenerator, error) {
+	return newShardedCounterGenerator(counterProvider, obfuscator, defaultCodeSpace, shards, pick)
+}
+
+// newShardedCounterGenerator creates a sharded generator whose obfuscator
+// produces codes in space; the shard regions split that space's counters
+func newShardedCounterGenerator(counterProvider CounterProvider, obfuscator Obfuscator, space codeSpace, shards int, pick string) (*CounterGenerator, error) {
+	picker, err := newShardPicker(shards, pick)
+	if err != nil {
+		return nil, err
+	}
+
 	return &CounterGenerator{
 		counterProvider: counterProvider,
-		counterKey:      "url_counter",
-		multiplier:      0x5DEECE66D,         // Large odd multiplier (used in LCGs)
-		salt:           0x9E3779B97F4A7C15,   // Large prime-like constant
-	}
+		shards:          picker,
+		span:            space.size / MaxCounterShards,
+		obfuscator:      obfuscator,
+	}, nil
 }
 
 // GenerateShortCode generates an obfuscated short code from a monotonic counter
 func (g *CounterGenerator) GenerateShortCode(ctx context.Context, originalURL string, timestamp time.Time) (string, error) {
-	counter, err := g.counterProvider.GetNextCounter(ctx, g.counterKey)
+	shard := g.shards.pick()
+	local, err := g.counterProvider.GetNextCounter(ctx, counterKey(shard))
 	if err != nil {
 		return "", err
 	}
+	counter, err := shardCounter(shard, local, g.span)
+	if err != nil {
+		return "", err
+	}
+	
+	code, err := g.obfuscator.Encode(counter)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode counter: %w", err)
+	}
 	
-	return g.encodeCounter(uint64(counter)), nil
+	return code, nil
 }
 
-// encodeCounter transforms the counter value and converts it to a short code
-func (g *CounterGenerator) encodeCounter(counter uint64) string {
-	// Apply multiple transformations to completely obscure the original counter
-	transformed := g.obfuscateValue(counter)
-	
-	// Ensure the result falls within our desired length range
-	// For 7 characters: 62^6 to 62^7-1 (to ensure 7 chars)
-	minVal := uint64(56800235584)     // 62^6 (ensures at least 7 characters)
-	maxVal := uint64(3521614606207)   // 62^7-1 (ensures exactly 7 characters)
-	
-	// Map the transformed value to our target range
-	rangeSize := maxVal - minVal + 1
-	finalValue := (transformed % rangeSize) + minVal
-	
-	return g.toBase62(finalValue)
+// Type returns the generator type
+func (g *CounterGenerator) Type() string {
+	return "counter"
 }
 
-// obfuscateValue applies multiple transformations to hide the original value
-func (g *CounterGenerator) obfuscateValue(value uint64) uint64 {
-	// Step 1: XOR with salt
-	result := value ^ g.salt
-	
-	// Step 2: Multiply by large odd number (this scrambles bits significantly)
-	result *= g.multiplier
-	
-	// Step 3: Bit rotation to further scramble
-	result = bits.RotateLeft64(result, 21)
-	
-	// Step 4: XOR with rotated version of itself
-	result ^= bits.RotateLeft64(result, 32)
-	
-	// Step 5: Apply bit reversal on lower 32 bits for extra scrambling
-	lower := uint32(result & 0xFFFFFFFF)
-	upper := uint32(result >> 32)
-	result = (uint64(bits.Reverse32(lower)) << 32) | uint64(upper)
-	
-	return result
+// Shards returns the number of counter shards in use
+func (g *CounterGenerator) Shards() int {
+	return g.shards.shards
 }
 
-// toBase62 converts a number to base62 representation
-func (g *CounterGenerator) toBase62(num uint64) string {
-	if num == 0 {
-		return "0"
-	}
-	
-	result := ""
-	for num > 0 {
-		result = string(base62Chars[num%62]) + result
-		num /= 62
-	}
-	
-	return result
+// Obfuscation returns the obfuscation strategy in use
+func (g *CounterGenerator) Obfuscation() string {
+	return g.obfuscator.Strategy()
 }
 
-// fromBase62 converts a base62 string back to a number
-func (g *CounterGenerator) fromBase62(str string) uint64 {
-	result := uint64(0)
-	for _, char := range str {
-		var value uint64
-		if char >= '0' && char <= '9' {
-			value = uint64(char - '0')
-		} else if char >= 'a' && char <= 'z' {
-			value = uint64(char - 'a' + 10)
-		} else if char >= 'A' && char <= 'Z' {
-			value = uint64(char - 'A' + 36)
-		}
-		result = result*62 + value
+// Warm leases a range for every shard's counter
+func (g *CounterGenerator) Warm(ctx context.Context) error {
+	keys := make([]string, g.shards.shards)
+	for shard := range keys {
+		keys[shard] = counterKey(shard)
 	}
-	return result
+	return warm(ctx, g.counterProvider, keys)
 }
 
-// Type returns the generator type
-func (g *CounterGenerator) Type() string {
-	return "counter"
+// Counters returns the counter cache codes are issued from, nil for other
+// providers
+func (g *CounterGenerator) Counters() *CounterCache {
+	return counterCache(g.counterProvider)
 }
 
 // Close performs cleanup
@@ -125,8 +109,8 @@ func (g *CounterGenerator) Close() error {
 }
 
 // GenerateShortCodeForID generates a short code for a specific ID/counter value (for testing)
-func (g *CounterGenerator) GenerateShortCodeForID(id uint64) string {
-	return g.encodeCounter(id)
+func (g *CounterGenerator) GenerateShortCodeForID(id uint64) (string, error) {
+	return g.obfuscator.Encode(id)
 }
 
 // Ensure CounterGenerator implements Generator interface
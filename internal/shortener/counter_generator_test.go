@@ -3,6 +3,7 @@ package shortener
 import (
 	"context"
 	"database/sql"
+	"fmt"
 	"path/filepath"
 	"strings"
 	"testing"
@@ -116,8 +117,11 @@ func TestCounterGenerator_ObfuscatedEncoding(t *testing.T) {
 	testCases := []uint64{1, 2, 3, 4, 5, 100, 1000, 10000}
 	
 	for _, id := range testCases {
-		code1 := generator.GenerateShortCodeForID(id)
-		code2 := generator.GenerateShortCodeForID(id)
+		code1, err := generator.GenerateShortCodeForID(id)
+		if err != nil {
+			t.Fatalf("GenerateShortCodeForID failed: %v", err)
+		}
+		code2, _ := generator.GenerateShortCodeForID(id)
 		
 		if code1 != code2 {
 			t.Errorf("Same ID %d produced different codes: %s vs %s", id, code1, code2)
@@ -143,7 +147,11 @@ func TestCounterGenerator_NonSequentialPattern(t *testing.T) {
 	
 	codes := make([]string, 15)
 	for i := uint64(1); i <= 15; i++ {
-		codes[i-1] = generator.GenerateShortCodeForID(i)
+		code, err := generator.GenerateShortCodeForID(i)
+		if err != nil {
+			t.Fatalf("GenerateShortCodeForID failed: %v", err)
+		}
+		codes[i-1] = code
 	}
 	
 	// Verify that the codes don't follow an obvious pattern
@@ -181,7 +189,10 @@ func TestCounterGenerator_LengthDistribution(t *testing.T) {
 	const testCount = 100
 	
 	for i := uint64(1); i <= testCount; i++ {
-		code := generator.GenerateShortCodeForID(i)
+		code, err := generator.GenerateShortCodeForID(i)
+		if err != nil {
+			t.Fatalf("GenerateShortCodeForID failed: %v", err)
+		}
 		lengthCount[len(code)]++
 	}
 	
@@ -198,13 +209,6 @@ func TestCounterGenerator_LengthDistribution(t *testing.T) {
 }
 
 func TestCounterGenerator_Base62Conversion(t *testing.T) {
-	queries := setupCounterTestDB(t)
-	counterProvider := NewCounterCache(queries, 1)
-	defer counterProvider.Close()
-	
-	generator := NewCounterGenerator(counterProvider)
-	defer generator.Close()
-	
 	testCases := []struct {
 		name string
 		num  uint64
@@ -220,10 +224,13 @@ func TestCounterGenerator_Base62Conversion(t *testing.T) {
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			// Test toBase62
-			encoded := generator.toBase62(tc.num)
+			encoded := toBase62(tc.num)
 			
 			// Test fromBase62
-			decoded := generator.fromBase62(encoded)
+			decoded, err := fromBase62(encoded)
+			if err != nil {
+				t.Fatalf("fromBase62 failed: %v", err)
+			}
 			
 			if decoded != tc.num {
 				t.Errorf("Encoding/decoding failed: %d -> %s -> %d", tc.num, encoded, decoded)
@@ -240,19 +247,14 @@ func TestCounterGenerator_Base62Conversion(t *testing.T) {
 }
 
 func TestCounterGenerator_ObfuscationFunctions(t *testing.T) {
-	queries := setupCounterTestDB(t)
-	counterProvider := NewCounterCache(queries, 1)
-	defer counterProvider.Close()
-	
-	generator := NewCounterGenerator(counterProvider)
-	defer generator.Close()
+	obfuscator := newMultiplicativeObfuscator()
 	
 	// Test that obfuscation produces different results for sequential inputs
 	values := []uint64{1, 2, 3, 4, 5}
 	obfuscated := make([]uint64, len(values))
 	
 	for i, val := range values {
-		obfuscated[i] = generator.obfuscateValue(val)
+		obfuscated[i] = obfuscator.obfuscateValue(val)
 		t.Logf("obfuscateValue(%d) = %d", val, obfuscated[i])
 	}
 	
@@ -268,8 +270,8 @@ func TestCounterGenerator_ObfuscationFunctions(t *testing.T) {
 	
 	// Test that the same input always produces the same output
 	for _, val := range values {
-		result1 := generator.obfuscateValue(val)
-		result2 := generator.obfuscateValue(val)
+		result1 := obfuscator.obfuscateValue(val)
+		result2 := obfuscator.obfuscateValue(val)
 		if result1 != result2 {
 			t.Errorf("obfuscateValue(%d) is not deterministic: %d vs %d", val, result1, result2)
 		}
@@ -330,6 +332,139 @@ func TestCounterGenerator_ConcurrentGeneration(t *testing.T) {
 	}
 }
 
+func TestCounterGenerator_Sharded(t *testing.T) {
+	ctx := context.Background()
+	timestamp := time.Now()
+
+	t.Run("round robin spreads codes over every shard", func(t *testing.T) {
+		queries := setupCounterTestDB(t)
+		feistel := newFeistelObfuscator("secret")
+		generator, err := NewShardedCounterGenerator(NewCounterCache(queries, 10), feistel, 4, ShardPickRoundRobin)
+		if err != nil {
+			t.Fatalf("NewShardedCounterGenerator failed: %v", err)
+		}
+		defer generator.Close()
+
+		perShard := make(map[uint64]int)
+		for i := 0; i < 20; i++ {
+			code, err := generator.GenerateShortCode(ctx, "https://example.com", timestamp)
+			if err != nil {
+				t.Fatalf("GenerateShortCode failed: %v", err)
+			}
+			counter, err := feistel.Decode(code)
+			if err != nil {
+				t.Fatalf("Decode failed: %v", err)
+			}
+			perShard[counter/shardSpan]++
+		}
+
+		if len(perShard) != 4 {
+			t.Fatalf("Expected codes from 4 shards, got %v", perShard)
+		}
+		for shard, count := range perShard {
+			if count != 5 {
+				t.Errorf("Expected 5 codes from shard %d, got %d", shard, count)
+			}
+		}
+	})
+
+	t.Run("shard 0 continues the unsharded sequence", func(t *testing.T) {
+		queries := setupCounterTestDB(t)
+		counterProvider := NewCounterCache(queries, 1)
+		if err := counterProvider.SetCounter(ctx, "url_counter", 41); err != nil {
+			t.Fatalf("SetCounter failed: %v", err)
+		}
+
+		generator, err := NewShardedCounterGenerator(counterProvider, newMultiplicativeObfuscator(), 2, ShardPickRoundRobin)
+		if err != nil {
+			t.Fatalf("NewShardedCounterGenerator failed: %v", err)
+		}
+		defer generator.Close()
+
+		code, err := generator.GenerateShortCode(ctx, "https://example.com", timestamp)
+		if err != nil {
+			t.Fatalf("GenerateShortCode failed: %v", err)
+		}
+		expected, _ := generator.GenerateShortCodeForID(42)
+		if code != expected {
+			t.Errorf("Expected the code for counter 42 (%s), got %s", expected, code)
+		}
+
+		// The next code comes from the start of shard 1's region
+		code, err = generator.GenerateShortCode(ctx, "https://example.com", timestamp)
+		if err != nil {
+			t.Fatalf("GenerateShortCode failed: %v", err)
+		}
+		expected, _ = generator.GenerateShortCodeForID(shardSpan + 1)
+		if code != expected {
+			t.Errorf("Expected the code for counter %d (%s), got %s", shardSpan+1, expected, code)
+		}
+	})
+
+	t.Run("random picks stay unique under concurrency", func(t *testing.T) {
+		queries := setupCounterTestDB(t)
+		generator, err := NewShardedCounterGenerator(NewCounterCache(queries, 5), newFeistelObfuscator("secret"), 8, ShardPickRandom)
+		if err != nil {
+			t.Fatalf("NewShardedCounterGenerator failed: %v", err)
+		}
+		defer generator.Close()
+
+		const goroutines, perGoroutine = 8, 50
+		results := make(chan string, goroutines*perGoroutine)
+		errs := make(chan error, goroutines)
+		for i := 0; i < goroutines; i++ {
+			go func() {
+				for j := 0; j < perGoroutine; j++ {
+					code, err := generator.GenerateShortCode(ctx, "https://example.com", timestamp)
+					if err != nil {
+						errs <- err
+						return
+					}
+					results <- code
+				}
+				errs <- nil
+			}()
+		}
+		for i := 0; i < goroutines; i++ {
+			if err := <-errs; err != nil {
+				t.Fatalf("GenerateShortCode failed: %v", err)
+			}
+		}
+		close(results)
+
+		codes := make(map[string]bool)
+		for code := range results {
+			if codes[code] {
+				t.Fatalf("Duplicate code %s", code)
+			}
+			codes[code] = true
+		}
+		if len(codes) != goroutines*perGoroutine {
+			t.Errorf("Expected %d unique codes, got %d", goroutines*perGoroutine, len(codes))
+		}
+	})
+
+	t.Run("invalid sharding", func(t *testing.T) {
+		for _, tc := range []struct {
+			shards int
+			pick   string
+		}{{0, ShardPickRoundRobin}, {MaxCounterShards + 1, ShardPickRoundRobin}, {2, "sticky"}} {
+			if _, err := NewShardedCounterGenerator(NewCounterCache(nil, 1), newMultiplicativeObfuscator(), tc.shards, tc.pick); err == nil {
+				t.Errorf("Expected an error for %d shards picked %q", tc.shards, tc.pick)
+			}
+		}
+	})
+
+	t.Run("exhausted shard", func(t *testing.T) {
+		if _, err := shardCounter(3, int64(shardSpan), shardSpan); err == nil || !strings.Contains(err.Error(), "counter shard 3 is exhausted") {
+			t.Errorf("Expected an exhausted shard error, got %v", err)
+		}
+		if counter, err := shardCounter(MaxCounterShards-1, int64(shardSpan)-1, shardSpan); err != nil || counter >= codeRangeSize {
+			t.Errorf("Expected the last value of the last shard inside the code space, got %d (%v)", counter, err)
+		}
+	})
+}
+
 func BenchmarkCounterGenerator_GenerateShortCode(b *testing.B) {
 	queries := setupCounterTestDB(&testing.T{}) // This is a hack for benchmarks
 	counterProvider := NewCounterCache(queries, 100)
@@ -353,45 +488,56 @@ func BenchmarkCounterGenerator_GenerateShortCode(b *testing.B) {
 	})
 }
 
+// BenchmarkCounterGenerator_Sharded measures parallel code generation as the
+// shard count grows; with one shard every caller waits on the same lease
+func BenchmarkCounterGenerator_Sharded(b *testing.B) {
+	ctx := context.Background()
+	timestamp := time.Now()
+
+	for _, pick := range []string{ShardPickRoundRobin, ShardPickRandom} {
+		for _, shards := range []int{1, 4, 16} {
+			b.Run(fmt.Sprintf("%s/shards=%d", pick, shards), func(b *testing.B) {
+				queries := setupCounterTestDB(&testing.T{}) // This is a hack for benchmarks
+				counterProvider := NewCounterCache(queries, 100)
+				generator, err := NewShardedCounterGenerator(counterProvider, newMultiplicativeObfuscator(), shards, pick)
+				if err != nil {
+					b.Fatal(err)
+				}
+				defer generator.Close()
+
+				b.ResetTimer()
+				b.RunParallel(func(pb *testing.PB) {
+					for pb.Next() {
+						if _, err := generator.GenerateShortCode(ctx, "https://example.com", timestamp); err != nil {
+							b.Fatal(err)
+						}
+					}
+				})
+			})
+		}
+	}
+}
+
 func BenchmarkCounterGenerator_ObfuscateValue(b *testing.B) {
-	queries := setupCounterTestDB(&testing.T{})
-	counterProvider := NewCounterCache(queries, 1)
-	defer counterProvider.Close()
-	
-	generator := NewCounterGenerator(counterProvider)
-	defer generator.Close()
+	obfuscator := newMultiplicativeObfuscator()
 	
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		_ = generator.obfuscateValue(uint64(i))
+		_ = obfuscator.obfuscateValue(uint64(i))
 	}
 }
 
 func BenchmarkCounterGenerator_ToBase62(b *testing.B) {
-	queries := setupCounterTestDB(&testing.T{})
-	counterProvider := NewCounterCache(queries, 1)
-	defer counterProvider.Close()
-	
-	generator := NewCounterGenerator(counterProvider)
-	defer generator.Close()
-	
 	numbers := []uint64{0, 1, 61, 62, 3844, 123456789, 3521614606207}
 	
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		num := numbers[i%len(numbers)]
-		_ = generator.toBase62(num)
+		_ = toBase62(num)
 	}
 }
 
 func BenchmarkCounterGenerator_FromBase62(b *testing.B) {
-	queries := setupCounterTestDB(&testing.T{})
-	counterProvider := NewCounterCache(queries, 1)
-	defer counterProvider.Close()
-	
-	generator := NewCounterGenerator(counterProvider)
-	defer generator.Close()
-	
 	codes := []string{
 		"0",
 		"1",
@@ -405,6 +551,6 @@ func BenchmarkCounterGenerator_FromBase62(b *testing.B) {
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		code := codes[i%len(codes)]
-		_ = generator.fromBase62(code)
+		_, _ = fromBase62(code)
 	}
 }
\ No newline at end of file
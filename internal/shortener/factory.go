@@ -12,6 +12,32 @@ func NewGenerator(config Config, db *sqlc.Queries) (Generator, error) {
 		return nil, fmt.Errorf("database queries required for counter-based generator")
 	}
 	
-	counterProvider := NewCounterCache(db, config.CounterStep)
-	return NewCounterGenerator(counterProvider), nil
+	var opts []CounterCacheOption
+	if config.Preallocate {
+		opts = append(opts, WithPrefetch())
+	}
+	
+	if config.AutoLength {
+		generator, err := newAutoLengthGenerator(NewCounterCache(db, config.CounterStep, opts...), config)
+		if err != nil {
+			return nil, err
+		}
+		return generator, nil
+	}
+	
+	space, err := config.codeSpace()
+	if err != nil {
+		return nil, err
+	}
+	obfuscator, err := config.Obfuscator()
+	if err != nil {
+		return nil, err
+	}
+	
+	counterProvider := NewCounterCache(db, config.CounterStep, opts...)
+	generator, err := newShardedCounterGenerator(counterProvider, obfuscator, space, config.shards(), config.ShardPick)
+	if err != nil {
+		return nil, err
+	}
+	return generator, nil
 }
\ No newline at end of file
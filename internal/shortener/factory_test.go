@@ -3,6 +3,7 @@ package shortener
 import (
 	"database/sql"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	_ "github.com/mattn/go-sqlite3"
@@ -60,6 +61,25 @@ func TestNewGenerator(t *testing.T) {
 			expectedType: TypeCounter,
 			shouldError:  false,
 		},
+		{
+			name: "Auto length counter generator",
+			config: Config{
+				CounterStep: 100,
+				AutoLength:  true,
+			},
+			requiresDB:   true,
+			expectedType: TypeCounter,
+			shouldError:  false,
+		},
+		{
+			name: "Auto length with hashids",
+			config: Config{
+				AutoLength:  true,
+				Obfuscation: ObfuscationHashids,
+			},
+			requiresDB:  true,
+			shouldError: true,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -94,6 +114,50 @@ func TestNewGenerator(t *testing.T) {
 		})
 	}
 
+	t.Run("Counter generator with feistel obfuscation", func(t *testing.T) {
+		generator, err := NewGenerator(Config{CounterStep: 1, Obfuscation: ObfuscationFeistel, Secret: "secret"}, queries)
+		if err != nil {
+			t.Fatalf("NewGenerator failed: %v", err)
+		}
+		defer generator.Close()
+
+		counterGenerator, ok := generator.(*CounterGenerator)
+		if !ok {
+			t.Fatalf("Expected *CounterGenerator, got %T", generator)
+		}
+		if counterGenerator.Obfuscation() != ObfuscationFeistel {
+			t.Errorf("Expected %s obfuscation, got %s", ObfuscationFeistel, counterGenerator.Obfuscation())
+		}
+	})
+
+	t.Run("Counter generator with shards", func(t *testing.T) {
+		generator, err := NewGenerator(Config{CounterStep: 1, CounterShards: 8, ShardPick: ShardPickRandom}, queries)
+		if err != nil {
+			t.Fatalf("NewGenerator failed: %v", err)
+		}
+		defer generator.Close()
+
+		if shards := generator.(*CounterGenerator).Shards(); shards != 8 {
+			t.Errorf("Expected 8 shards, got %d", shards)
+		}
+	})
+
+	t.Run("Too many shards fail", func(t *testing.T) {
+		generator, err := NewGenerator(Config{CounterStep: 1, CounterShards: MaxCounterShards + 1}, queries)
+		if err == nil {
+			t.Error("Expected error for too many counter shards")
+			generator.Close()
+		}
+	})
+
+	t.Run("Unknown obfuscation fails", func(t *testing.T) {
+		generator, err := NewGenerator(Config{CounterStep: 1, Obfuscation: "rot13"}, queries)
+		if err == nil {
+			t.Error("Expected error for unknown obfuscation strategy")
+			generator.Close()
+		}
+	})
+
 	t.Run("Counter generator without database fails", func(t *testing.T) {
 		config := Config{
 			CounterStep: 100,
@@ -119,4 +183,57 @@ func TestDefaultConfig(t *testing.T) {
 	if config.CounterStep != expectedDefaults.CounterStep {
 		t.Errorf("Expected default counter step %d, got %d", expectedDefaults.CounterStep, config.CounterStep)
 	}
-}
\ No newline at end of file
+
+	if config.Obfuscation != ObfuscationMultiplicative {
+		t.Errorf("Expected default obfuscation %s, got %s", ObfuscationMultiplicative, config.Obfuscation)
+	}
+
+	if config.CounterShards != 1 || config.ShardPick != ShardPickRoundRobin {
+		t.Errorf("Expected one round robin counter shard by default, got %d %s", config.CounterShards, config.ShardPick)
+	}
+
+	if err := config.Validate(); err != nil {
+		t.Errorf("Default config should be valid: %v", err)
+	}
+
+	config.ShardPick = "sticky"
+	if err := config.Validate(); err == nil {
+		t.Error("Expected an unknown shard pick strategy to be invalid")
+	}
+}
+
+func TestConfig_Obfuscator(t *testing.T) {
+	testCases := []struct {
+		name        string
+		config      Config
+		expected    string
+		errContains string
+	}{
+		{"custom multiplicative constants", Config{Multiplier: 0x2545F4914F6CDD1D, Salt: 42}, ObfuscationMultiplicative, ""},
+		{"even multiplier", Config{Multiplier: 2}, "", "multiplier must be odd"},
+		{"constants with another strategy", Config{Obfuscation: ObfuscationFeistel, Salt: 42}, "", "only apply to multiplicative"},
+		{"ff1", Config{Obfuscation: ObfuscationFF1, Secret: "secret"}, ObfuscationFF1, ""},
+		{"ff1 without a secret", Config{Obfuscation: ObfuscationFF1}, "", "requires a secret"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			obfuscator, err := tc.config.Obfuscator()
+			if tc.errContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.errContains) {
+					t.Errorf("Expected error containing %q, got %v", tc.errContains, err)
+				}
+				if tc.config.Validate() == nil {
+					t.Error("Expected Validate to fail too")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Obfuscator failed: %v", err)
+			}
+			if obfuscator.Strategy() != tc.expected {
+				t.Errorf("Expected strategy %s, got %s", tc.expected, obfuscator.Strategy())
+			}
+		})
+	}
+}
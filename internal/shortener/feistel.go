@@ -0,0 +1,101 @@
+package shortener
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"math/bits"
+)
+
+const feistelRounds = 4
+
+// feistelObfuscator maps counters onto codes with a keyed Feistel network. A
+// Feistel network is a permutation, and cycle-walking restricts it to the
+// code range, so distinct counters never collide and codes can be decoded
+// back to their counter. The block is the smallest even bit width covering
+// the code range: 42 bits for 7-character base62 codes.
+type feistelObfuscator struct {
+	keys     [feistelRounds]uint64
+	space    codeSpace
+	halfBits int
+	halfMask uint64
+}
+
+// newFeistelObfuscator derives the round keys from a secret
+func newFeistelObfuscator(secret string) *feistelObfuscator {
+	return newFeistelObfuscatorIn(defaultCodeSpace, secret)
+}
+
+// newFeistelObfuscatorIn derives the round keys from a secret for codes in space
+func newFeistelObfuscatorIn(space codeSpace, secret string) *feistelObfuscator {
+	sum := sha256.Sum256([]byte(secret))
+	o := &feistelObfuscator{space: space}
+	for i := range o.keys {
+		o.keys[i] = binary.BigEndian.Uint64(sum[i*8:])
+	}
+	o.halfBits = (bits.Len64(space.size-1) + 1) / 2
+	o.halfMask = uint64(1)<<o.halfBits - 1
+	return o
+}
+
+// Encode permutes the counter within the code range and encodes it
+func (o *feistelObfuscator) Encode(counter uint64) (string, error) {
+	if counter >= o.space.size {
+		return "", fmt.Errorf("counter %d exceeds the %d-character code space", counter, o.space.length)
+	}
+
+	// Cycle-walk: re-apply the permutation until the value lands in range
+	value := o.permute(counter)
+	for value >= o.space.size {
+		value = o.permute(value)
+	}
+
+	return o.space.encode(value + o.space.min), nil
+}
+
+// Decode recovers the counter a code was generated from
+func (o *feistelObfuscator) Decode(code string) (uint64, error) {
+	value, err := o.space.decode(code)
+	if err != nil {
+		return 0, err
+	}
+
+	value = o.unpermute(value - o.space.min)
+	for value >= o.space.size {
+		value = o.unpermute(value)
+	}
+
+	return value, nil
+}
+
+// permute applies the Feistel rounds to a block
+func (o *feistelObfuscator) permute(value uint64) uint64 {
+	left, right := value>>o.halfBits, value&o.halfMask
+	for _, key := range o.keys {
+		left, right = right, left^o.round(right, key)
+	}
+	return left<<o.halfBits | right
+}
+
+// unpermute applies the Feistel rounds in reverse
+func (o *feistelObfuscator) unpermute(value uint64) uint64 {
+	left, right := value>>o.halfBits, value&o.halfMask
+	for i := feistelRounds - 1; i >= 0; i-- {
+		left, right = right^o.round(left, o.keys[i]), left
+	}
+	return left<<o.halfBits | right
+}
+
+// round is the Feistel round function (a splitmix64 finalizer keyed per round)
+func (o *feistelObfuscator) round(half, key uint64) uint64 {
+	z := half ^ key
+	z = (z ^ (z >> 30)) * 0xBF58476D1CE4E5B9
+	z = (z ^ (z >> 27)) * 0x94D049BB133111EB
+	z ^= z >> 31
+	return z & o.halfMask
+}
+
+// Strategy returns the obfuscation strategy name
+func (o *feistelObfuscator) Strategy() string {
+	return ObfuscationFeistel
+}
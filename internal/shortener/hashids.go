@@ -0,0 +1,168 @@
+package shortener
+
+import (
+	"math"
+	"strings"
+)
+
+const (
+	hashidsAlphabet = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ1234567890"
+	hashidsSeps     = "cfhistuCFHISTU"
+	hashidsSepDiv   = 3.5
+	hashidsGuardDiv = 12.0
+)
+
+// hashidsObfuscator encodes counters with the hashids algorithm
+// (https://hashids.org), compatible with the reference implementations for a
+// single number. Codes are at least minLength characters and grow as the
+// counter does, so they never collide.
+type hashidsObfuscator struct {
+	salt      []byte
+	alphabet  []byte
+	seps      []byte
+	guards    []byte
+	minLength int
+}
+
+// newHashidsObfuscator prepares the alphabet, separators, and guards for a salt
+func newHashidsObfuscator(salt string, minLength int) *hashidsObfuscator {
+	return newHashidsObfuscatorWith(hashidsAlphabet, salt, minLength)
+}
+
+// newHashidsObfuscatorIn creates hashids for codes of at least the space's
+// length. The default base62 space keeps the reference hashids alphabet,
+// whose order differs, so existing codes stay the same.
+func newHashidsObfuscatorIn(space codeSpace, salt string) *hashidsObfuscator {
+	if space.alphabet == AlphabetBase62 {
+		return newHashidsObfuscatorWith(hashidsAlphabet, salt, space.length)
+	}
+	return newHashidsObfuscatorWith(space.alphabet, salt, space.length)
+}
+
+// newHashidsObfuscatorWith prepares an alphabet's separators and guards for a salt
+func newHashidsObfuscatorWith(alphabetChars, salt string, minLength int) *hashidsObfuscator {
+	alphabet := []byte(alphabetChars)
+	// Separators are the standard ones the alphabet contains
+	seps := removeBytes([]byte(hashidsSeps), removeBytes([]byte(hashidsSeps), alphabet))
+
+	// Separators are taken out of the alphabet
+	alphabet = removeBytes(alphabet, seps)
+	seps = consistentShuffle(seps, []byte(salt))
+
+	if len(seps) == 0 || float64(len(alphabet))/float64(len(seps)) > hashidsSepDiv {
+		sepsLength := int(math.Ceil(float64(len(alphabet)) / hashidsSepDiv))
+		if sepsLength == 1 {
+			sepsLength++
+		}
+		if sepsLength > len(seps) {
+			diff := sepsLength - len(seps)
+			seps = append(seps, alphabet[:diff]...)
+			alphabet = alphabet[diff:]
+		} else {
+			seps = seps[:sepsLength]
+		}
+	}
+
+	alphabet = consistentShuffle(alphabet, []byte(salt))
+
+	guardCount := int(math.Ceil(float64(len(alphabet)) / hashidsGuardDiv))
+	var guards []byte
+	if len(alphabet) < 3 {
+		guards, seps = seps[:guardCount], seps[guardCount:]
+	} else {
+		guards, alphabet = alphabet[:guardCount], alphabet[guardCount:]
+	}
+
+	return &hashidsObfuscator{
+		salt:      []byte(salt),
+		alphabet:  alphabet,
+		seps:      seps,
+		guards:    guards,
+		minLength: minLength,
+	}
+}
+
+// Encode converts the counter into a hashid
+func (o *hashidsObfuscator) Encode(counter uint64) (string, error) {
+	alphabet := append([]byte(nil), o.alphabet...)
+
+	numbersHash := counter % 100
+	lottery := alphabet[numbersHash%uint64(len(alphabet))]
+
+	buffer := append([]byte{lottery}, o.salt...)
+	buffer = append(buffer, alphabet...)
+	alphabet = consistentShuffle(alphabet, buffer[:len(alphabet)])
+
+	result := append([]byte{lottery}, hashidsHash(counter, alphabet)...)
+
+	if len(result) < o.minLength {
+		guardIndex := (numbersHash + uint64(result[0])) % uint64(len(o.guards))
+		result = append([]byte{o.guards[guardIndex]}, result...)
+
+		if len(result) < o.minLength {
+			guardIndex = (numbersHash + uint64(result[2])) % uint64(len(o.guards))
+			result = append(result, o.guards[guardIndex])
+		}
+	}
+
+	halfLength := len(alphabet) / 2
+	for len(result) < o.minLength {
+		alphabet = consistentShuffle(alphabet, append([]byte(nil), alphabet...))
+		padded := append([]byte(nil), alphabet[halfLength:]...)
+		padded = append(padded, result...)
+		result = append(padded, alphabet[:halfLength]...)
+
+		if excess := len(result) - o.minLength; excess > 0 {
+			result = result[excess/2 : excess/2+o.minLength]
+		}
+	}
+
+	return string(result), nil
+}
+
+// Strategy returns the obfuscation strategy name
+func (o *hashidsObfuscator) Strategy() string {
+	return ObfuscationHashids
+}
+
+// hashidsHash writes a number in the given alphabet
+func hashidsHash(number uint64, alphabet []byte) []byte {
+	var hash []byte
+	base := uint64(len(alphabet))
+	for {
+		hash = append([]byte{alphabet[number%base]}, hash...)
+		number /= base
+		if number == 0 {
+			return hash
+		}
+	}
+}
+
+// consistentShuffle deterministically shuffles an alphabet with a salt
+func consistentShuffle(alphabet, salt []byte) []byte {
+	result := append([]byte(nil), alphabet...)
+	if len(salt) == 0 {
+		return result
+	}
+
+	for i, v, p := len(result)-1, 0, 0; i > 0; i, v = i-1, v+1 {
+		v %= len(salt)
+		integer := int(salt[v])
+		p += integer
+		j := (integer + v + p) % i
+		result[i], result[j] = result[j], result[i]
+	}
+
+	return result
+}
+
+// removeBytes returns alphabet without any byte present in remove
+func removeBytes(alphabet, remove []byte) []byte {
+	var result []byte
+	for _, b := range alphabet {
+		if !strings.ContainsRune(string(remove), rune(b)) {
+			result = append(result, b)
+		}
+	}
+	return result
+}
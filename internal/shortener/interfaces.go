@@ -2,6 +2,7 @@ package shortener
 
 import (
 	"context"
+	"fmt"
 	"time"
 )
 
@@ -22,6 +23,9 @@ type CounterProvider interface {
 	// GetNextCounter returns the next counter value for a given key
 	GetNextCounter(ctx context.Context, key string) (int64, error)
 	
+	// GetCounter returns the stored value for a given key, 0 if it was never set
+	GetCounter(ctx context.Context, key string) (int64, error)
+	
 	// SetCounter sets the counter value for a given key
 	SetCounter(ctx context.Context, key string, value int64) error
 	
@@ -29,9 +33,32 @@ type CounterProvider interface {
 	Close() error
 }
 
+// Obfuscator maps counter values to short codes
+type Obfuscator interface {
+	// Encode converts a counter value into a short code
+	Encode(counter uint64) (string, error)
+	
+	// Strategy returns the obfuscation strategy name
+	Strategy() string
+}
+
 // Config holds configuration for shortener generators
 type Config struct {
-	CounterStep int64 `json:"counter_step"` // Step size for counter-based generators
+	CounterStep   int64    `json:"counter_step"`   // Step size for counter-based generators
+	CounterShards int      `json:"counter_shards"` // Independent counters codes are spread over, 1 to MaxCounterShards (0 = 1)
+	Preallocate   bool     `json:"preallocate"`    // Lease every counter range at start and the next in the background once RefillAt is used
+	ShardPick     string   `json:"shard_pick"`     // How a shard is chosen: round_robin or random
+	Obfuscation   string   `json:"obfuscation"`    // Counter obfuscation strategy: multiplicative, feistel, hashids, or ff1
+	Secret        string   `json:"secret"`         // Key for feistel rounds and ff1, or salt for hashids
+	Multiplier    uint64   `json:"multiplier"`     // Odd multiplier for multiplicative obfuscation (0 = built-in)
+	Salt          uint64   `json:"salt"`           // XOR salt for multiplicative obfuscation (0 = built-in)
+	Alphabet      string   `json:"alphabet"`       // Code characters: base62, base58, or the characters themselves ("" = base62)
+	Length        int      `json:"length"`         // Characters per code (0 = 7); hashids codes may be longer
+	AutoLength    bool     `json:"auto_length"`    // Start with MinLength codes and grow toward Length as codes are used
+	MinLength     int      `json:"min_length"`     // Shortest codes with AutoLength (0 = DefaultMinLength)
+	GrowAt        float64  `json:"grow_at"`        // Fraction of a length's codes used before growing, in (0, 1] (0 = DefaultGrowAt)
+	ReservedCodes []string `json:"reserved_codes"` // Short codes that are never issued
+	BlockedWords  []string `json:"blocked_words"`  // Words no issued short code may contain
 }
 
 // GeneratorType constants
@@ -42,6 +69,131 @@ const (
 // DefaultConfig returns the default configuration
 func DefaultConfig() Config {
 	return Config{
-		CounterStep: 1,
+		CounterStep:   1,
+		CounterShards: 1,
+		ShardPick:     ShardPickRoundRobin,
+		Obfuscation:   ObfuscationMultiplicative,
+		Alphabet:      "base62",
+		Length:        targetLength,
+		MinLength:     DefaultMinLength,
+		GrowAt:        DefaultGrowAt,
+		ReservedCodes: DefaultReservedCodes,
+		BlockedWords:  DefaultBlockedWords,
+	}
+}
+
+// Validate checks that the configured obfuscation strategy exists and the
+// counter sharding and code space are usable
+func (c Config) Validate() error {
+	if _, err := newShardPicker(c.shards(), c.ShardPick); err != nil {
+		return err
+	}
+	if c.AutoLength {
+		_, _, err := c.lengthTiers()
+		return err
 	}
+	_, err := c.Obfuscator()
+	return err
+}
+
+// Obfuscator creates the configured counter obfuscator. A multiplier and salt
+// only apply to the multiplicative strategy, and the multiplier must be odd:
+// an even one shifts counter bits out of the product.
+func (c Config) Obfuscator() (Obfuscator, error) {
+	space, err := c.codeSpace()
+	if err != nil {
+		return nil, err
+	}
+	return c.obfuscatorIn(space)
+}
+
+// obfuscatorIn creates the configured counter obfuscator for codes in space
+func (c Config) obfuscatorIn(space codeSpace) (Obfuscator, error) {
+	if c.Obfuscation != "" && c.Obfuscation != ObfuscationMultiplicative {
+		if c.Multiplier != 0 || c.Salt != 0 {
+			return nil, fmt.Errorf("a multiplier and salt only apply to %s obfuscation, not %s", ObfuscationMultiplicative, c.Obfuscation)
+		}
+		return newObfuscator(c.Obfuscation, c.Secret, space)
+	}
+	if c.Multiplier != 0 && c.Multiplier%2 == 0 {
+		return nil, fmt.Errorf("obfuscation multiplier must be odd, got: %#x", c.Multiplier)
+	}
+	return newMultiplicativeObfuscatorIn(space, c.Multiplier, c.Salt), nil
+}
+
+// codeSpace returns the configured alphabet and length, treating "" as base62
+// and 0 as 7 characters
+func (c Config) codeSpace() (codeSpace, error) {
+	return c.codeSpaceOf(c.length())
+}
+
+// codeSpaceOf returns the configured alphabet's codes of length characters
+func (c Config) codeSpaceOf(length int) (codeSpace, error) {
+	alphabet := ResolveAlphabet(c.Alphabet)
+	if alphabet == AlphabetBase62 && length == targetLength {
+		return defaultCodeSpace, nil
+	}
+	return newCodeSpace(alphabet, length)
+}
+
+// length returns the configured code length, treating 0 as 7 characters
+func (c Config) length() int {
+	if c.Length == 0 {
+		return targetLength
+	}
+	return c.Length
+}
+
+// lengthTiers returns the code lengths an auto-length generator issues, from
+// MinLength up to Length, and the fraction of each to use before growing.
+// Hashids is not supported: its codes grow past their minimum length and
+// could equal a longer tier's codes.
+func (c Config) lengthTiers() ([]lengthTier, float64, error) {
+	if c.Obfuscation == ObfuscationHashids {
+		return nil, 0, fmt.Errorf("auto length does not support %s obfuscation, whose codes have no fixed length", ObfuscationHashids)
+	}
+	minLength, growAt := c.MinLength, c.GrowAt
+	if minLength == 0 {
+		minLength = DefaultMinLength
+	}
+	if growAt == 0 {
+		growAt = DefaultGrowAt
+	}
+	if minLength > c.length() {
+		return nil, 0, fmt.Errorf("auto length minimum %d exceeds the code length %d", minLength, c.length())
+	}
+	if growAt <= 0 || growAt > 1 {
+		return nil, 0, fmt.Errorf("auto length grow-at fraction must be in (0, 1], got: %g", growAt)
+	}
+
+	var tiers []lengthTier
+	for length := minLength; length <= c.length(); length++ {
+		space, err := c.codeSpaceOf(length)
+		if err != nil {
+			return nil, 0, err
+		}
+		obfuscator, err := c.obfuscatorIn(space)
+		if err != nil {
+			return nil, 0, err
+		}
+		tiers = append(tiers, lengthTier{
+			space:      space,
+			obfuscator: obfuscator,
+			final:      length == c.length(),
+		})
+	}
+	return tiers, growAt, nil
+}
+
+// shards returns the configured shard count, treating 0 as unsharded
+func (c Config) shards() int {
+	if c.CounterShards == 0 {
+		return 1
+	}
+	return c.CounterShards
+}
+
+// Blacklist returns the blacklist for the configured reserved codes and blocked words
+func (c Config) Blacklist() *Blacklist {
+	return NewBlacklist(c.ReservedCodes, c.BlockedWords)
 }
\ No newline at end of file
@@ -0,0 +1,145 @@
+package shortener
+
+import (
+	"fmt"
+	"math/bits"
+)
+
+const (
+	// Base62 characters: 0-9, a-z, A-Z (case sensitive)
+	base62Chars  = AlphabetBase62
+	targetLength = 7 // Default length of short codes
+
+	// Counters are mapped into [62^6, 62^7-1] so every code is exactly 7 characters
+	minCodeValue  = uint64(56800235584)   // 62^6
+	maxCodeValue  = uint64(3521614606207) // 62^7-1
+	codeRangeSize = maxCodeValue - minCodeValue + 1
+)
+
+// Obfuscation strategy constants
+const (
+	ObfuscationMultiplicative = "multiplicative" // Bit-mixing plus modulo; may collide in theory
+	ObfuscationFeistel        = "feistel"        // Keyed Feistel permutation; reversible and collision-free
+	ObfuscationHashids        = "hashids"        // Hashids encoding; reversible, variable length
+	ObfuscationFF1            = "ff1"            // FF1 format-preserving encryption; needs a secret to reverse
+)
+
+// NewObfuscator creates the obfuscator for a strategy producing 7-character
+// base62 codes. The secret keys the Feistel rounds and FF1 and salts hashids;
+// the multiplicative scheme ignores it (see Config.Multiplier and Config.Salt).
+func NewObfuscator(strategy, secret string) (Obfuscator, error) {
+	return newObfuscator(strategy, secret, defaultCodeSpace)
+}
+
+// newObfuscator creates the obfuscator for a strategy producing codes in space
+func newObfuscator(strategy, secret string, space codeSpace) (Obfuscator, error) {
+	switch strategy {
+	case "", ObfuscationMultiplicative:
+		return newMultiplicativeObfuscatorIn(space, 0, 0), nil
+	case ObfuscationFeistel:
+		return newFeistelObfuscatorIn(space, secret), nil
+	case ObfuscationHashids:
+		return newHashidsObfuscatorIn(space, secret), nil
+	case ObfuscationFF1:
+		if secret == "" {
+			return nil, fmt.Errorf("%s obfuscation requires a secret", ObfuscationFF1)
+		}
+		return newFF1ObfuscatorIn(space, secret)
+	default:
+		return nil, fmt.Errorf("unknown obfuscation strategy %q: must be %s, %s, %s, or %s",
+			strategy, ObfuscationMultiplicative, ObfuscationFeistel, ObfuscationHashids, ObfuscationFF1)
+	}
+}
+
+// multiplicativeObfuscator scrambles counter bits and maps the result into the
+// code space with a modulo, which can theoretically collide
+type multiplicativeObfuscator struct {
+	multiplier uint64 // Large prime multiplier for obfuscation
+	salt       uint64 // Salt value to add entropy
+	space      codeSpace
+}
+
+// Built-in multiplicative constants. They are public in this source, so codes
+// made with them can be mapped back to counters by anyone who reads it.
+const (
+	defaultMultiplier = 0x5DEECE66D        // Large odd multiplier (used in LCGs)
+	defaultSalt       = 0x9E3779B97F4A7C15 // Large prime-like constant
+)
+
+// newMultiplicativeObfuscator creates the original counter obfuscation scheme
+func newMultiplicativeObfuscator() *multiplicativeObfuscator {
+	return newMultiplicativeObfuscatorWith(0, 0)
+}
+
+// newMultiplicativeObfuscatorWith creates the multiplicative scheme with an
+// install's own multiplier and salt; 0 keeps the built-in value
+func newMultiplicativeObfuscatorWith(multiplier, salt uint64) *multiplicativeObfuscator {
+	return newMultiplicativeObfuscatorIn(defaultCodeSpace, multiplier, salt)
+}
+
+// newMultiplicativeObfuscatorIn creates the multiplicative scheme for codes in space
+func newMultiplicativeObfuscatorIn(space codeSpace, multiplier, salt uint64) *multiplicativeObfuscator {
+	if multiplier == 0 {
+		multiplier = defaultMultiplier
+	}
+	if salt == 0 {
+		salt = defaultSalt
+	}
+	return &multiplicativeObfuscator{multiplier: multiplier, salt: salt, space: space}
+}
+
+// Encode transforms the counter value and converts it to a short code
+func (o *multiplicativeObfuscator) Encode(counter uint64) (string, error) {
+	// Apply multiple transformations to completely obscure the original counter
+	transformed := o.obfuscateValue(counter)
+
+	// Map the transformed value to our target range
+	finalValue := (transformed % o.space.size) + o.space.min
+
+	return o.space.encode(finalValue), nil
+}
+
+// obfuscateValue applies multiple transformations to hide the original value
+func (o *multiplicativeObfuscator) obfuscateValue(value uint64) uint64 {
+	// Step 1: XOR with salt
+	result := value ^ o.salt
+
+	// Step 2: Multiply by large odd number (this scrambles bits significantly)
+	result *= o.multiplier
+
+	// Step 3: Bit rotation to further scramble
+	result = bits.RotateLeft64(result, 21)
+
+	// Step 4: XOR with rotated version of itself
+	result ^= bits.RotateLeft64(result, 32)
+
+	// Step 5: Apply bit reversal on lower 32 bits for extra scrambling
+	lower := uint32(result & 0xFFFFFFFF)
+	upper := uint32(result >> 32)
+	result = (uint64(bits.Reverse32(lower)) << 32) | uint64(upper)
+
+	return result
+}
+
+// Strategy returns the obfuscation strategy name
+func (o *multiplicativeObfuscator) Strategy() string {
+	return ObfuscationMultiplicative
+}
+
+// toBase62 converts a number to base62 representation
+func toBase62(num uint64) string {
+	return encodeDigits(num, base62Chars)
+}
+
+// fromBase62 converts a base62 string back to a number
+func fromBase62(str string) (uint64, error) {
+	return decodeDigits(str, base62Chars)
+}
+
+// Ensure obfuscators implement Obfuscator interface
+var (
+	_ Obfuscator = (*multiplicativeObfuscator)(nil)
+	_ Obfuscator = (*feistelObfuscator)(nil)
+	_ Obfuscator = (*hashidsObfuscator)(nil)
+	_ Obfuscator = (*ff1Obfuscator)(nil)
+)
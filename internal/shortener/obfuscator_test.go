@@ -0,0 +1,291 @@
+package shortener
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+func TestNewObfuscator(t *testing.T) {
+	testCases := []struct {
+		strategy    string
+		expected    string
+		shouldError bool
+	}{
+		{"", ObfuscationMultiplicative, false},
+		{ObfuscationMultiplicative, ObfuscationMultiplicative, false},
+		{ObfuscationFeistel, ObfuscationFeistel, false},
+		{ObfuscationHashids, ObfuscationHashids, false},
+		{ObfuscationFF1, ObfuscationFF1, false},
+		{"rot13", "", true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.strategy, func(t *testing.T) {
+			obfuscator, err := NewObfuscator(tc.strategy, "secret")
+			if tc.shouldError {
+				if err == nil {
+					t.Error("Expected error, got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewObfuscator failed: %v", err)
+			}
+			if obfuscator.Strategy() != tc.expected {
+				t.Errorf("Expected strategy %s, got %s", tc.expected, obfuscator.Strategy())
+			}
+		})
+	}
+}
+
+func TestMultiplicativeObfuscator_StableCodes(t *testing.T) {
+	// Existing deployments depend on these codes staying the same
+	obfuscator, err := NewObfuscator(ObfuscationMultiplicative, "ignored")
+	if err != nil {
+		t.Fatalf("NewObfuscator failed: %v", err)
+	}
+
+	expected := map[uint64]string{
+		1:         "ZYhhlfA",
+		2:         "QCjHiZK",
+		1000:      "ZD6V97S",
+		123456789: "e6c5NOZ",
+	}
+	for id, want := range expected {
+		code, err := obfuscator.Encode(id)
+		if err != nil {
+			t.Fatalf("Encode failed: %v", err)
+		}
+		if code != want {
+			t.Errorf("Encode(%d) = %s, want %s", id, code, want)
+		}
+	}
+}
+
+func TestFeistelObfuscator_RoundTrip(t *testing.T) {
+	obfuscator := newFeistelObfuscator("secret")
+
+	seen := make(map[string]uint64)
+	for id := uint64(0); id < 20000; id++ {
+		code, err := obfuscator.Encode(id)
+		if err != nil {
+			t.Fatalf("Encode(%d) failed: %v", id, err)
+		}
+		if len(code) != targetLength {
+			t.Fatalf("Expected code length %d, got %d for code %s", targetLength, len(code), code)
+		}
+		if other, exists := seen[code]; exists {
+			t.Fatalf("Collision: %d and %d both encode to %s", other, id, code)
+		}
+		seen[code] = id
+
+		decoded, err := obfuscator.Decode(code)
+		if err != nil {
+			t.Fatalf("Decode(%s) failed: %v", code, err)
+		}
+		if decoded != id {
+			t.Fatalf("Round trip failed: %d -> %s -> %d", id, code, decoded)
+		}
+	}
+}
+
+func TestFeistelObfuscator_Boundaries(t *testing.T) {
+	obfuscator := newFeistelObfuscator("secret")
+
+	last := codeRangeSize - 1
+	code, err := obfuscator.Encode(last)
+	if err != nil {
+		t.Fatalf("Encode of the last counter failed: %v", err)
+	}
+	decoded, err := obfuscator.Decode(code)
+	if err != nil || decoded != last {
+		t.Errorf("Round trip of the last counter failed: got %d, %v", decoded, err)
+	}
+
+	if _, err := obfuscator.Encode(codeRangeSize); err == nil {
+		t.Error("Expected error for a counter beyond the code space")
+	}
+
+	if _, err := obfuscator.Decode("abc"); err == nil {
+		t.Error("Expected error decoding a code outside the code space")
+	}
+	if _, err := obfuscator.Decode("abc-def"); err == nil {
+		t.Error("Expected error decoding a non-base62 code")
+	}
+}
+
+func TestFeistelObfuscator_SecretChangesCodes(t *testing.T) {
+	first := newFeistelObfuscator("one")
+	second := newFeistelObfuscator("two")
+
+	differing := 0
+	for id := uint64(1); id <= 10; id++ {
+		a, _ := first.Encode(id)
+		b, _ := second.Encode(id)
+		if a != b {
+			differing++
+		}
+	}
+	if differing == 0 {
+		t.Error("Expected different secrets to produce different codes")
+	}
+}
+
+func TestMultiplicativeObfuscator_CustomConstants(t *testing.T) {
+	builtIn := newMultiplicativeObfuscator()
+	custom := newMultiplicativeObfuscatorWith(0x2545F4914F6CDD1D, 0x1234)
+
+	for id := uint64(1); id <= 10; id++ {
+		a, _ := builtIn.Encode(id)
+		b, _ := custom.Encode(id)
+		if a == b {
+			t.Errorf("Expected custom constants to change the code of %d, both are %s", id, a)
+		}
+		if len(b) != targetLength {
+			t.Errorf("Expected code length %d, got %s", targetLength, b)
+		}
+	}
+
+	if zero := newMultiplicativeObfuscatorWith(0, 0); *zero != *builtIn {
+		t.Error("Expected zero constants to keep the built-in ones")
+	}
+}
+
+func TestFF1_NISTVectors(t *testing.T) {
+	// Samples 1, 4 and 7 of the NIST SP 800-38G FF1 examples (radix 10, empty tweak)
+	testCases := []struct {
+		key    string
+		cipher uint64
+	}{
+		{"2B7E151628AED2A6ABF7158809CF4F3C", 2433477484},
+		{"2B7E151628AED2A6ABF7158809CF4F3CEF4359D8D580AA4F", 2830668132},
+		{"2B7E151628AED2A6ABF7158809CF4F3CEF4359D8D580AA4F7F036D6F04FC6A94", 6657667009},
+	}
+
+	for _, tc := range testCases {
+		key, err := hex.DecodeString(tc.key)
+		if err != nil {
+			t.Fatalf("Bad key: %v", err)
+		}
+		f, err := newFF1(key, 10, 10)
+		if err != nil {
+			t.Fatalf("newFF1 failed: %v", err)
+		}
+
+		if got := f.encrypt(123456789); got != tc.cipher {
+			t.Errorf("AES-%d: encrypt(0123456789) = %010d, want %010d", len(key)*8, got, tc.cipher)
+		}
+		if got := f.decrypt(tc.cipher); got != 123456789 {
+			t.Errorf("AES-%d: decrypt(%010d) = %010d, want 0123456789", len(key)*8, tc.cipher, got)
+		}
+	}
+}
+
+func TestFF1Obfuscator_RoundTrip(t *testing.T) {
+	obfuscator, err := newFF1Obfuscator("secret")
+	if err != nil {
+		t.Fatalf("newFF1Obfuscator failed: %v", err)
+	}
+
+	seen := make(map[string]uint64)
+	for _, id := range []uint64{0, 1, 2, 3, 1000, 123456789, shardSpan, codeRangeSize - 1} {
+		code, err := obfuscator.Encode(id)
+		if err != nil {
+			t.Fatalf("Encode(%d) failed: %v", id, err)
+		}
+		if len(code) != targetLength || strings.HasPrefix(code, "0") {
+			t.Fatalf("Expected a %d-character code without a leading zero, got %s", targetLength, code)
+		}
+		if other, exists := seen[code]; exists {
+			t.Fatalf("Collision: %d and %d both encode to %s", other, id, code)
+		}
+		seen[code] = id
+
+		decoded, err := obfuscator.Decode(code)
+		if err != nil {
+			t.Fatalf("Decode(%s) failed: %v", code, err)
+		}
+		if decoded != id {
+			t.Fatalf("Round trip failed: %d -> %s -> %d", id, code, decoded)
+		}
+	}
+
+	if _, err := obfuscator.Encode(codeRangeSize); err == nil {
+		t.Error("Expected error for a counter beyond the code space")
+	}
+	if _, err := obfuscator.Decode("abc"); err == nil {
+		t.Error("Expected error decoding a code outside the code space")
+	}
+}
+
+func TestFF1Obfuscator_Secret(t *testing.T) {
+	if _, err := NewObfuscator(ObfuscationFF1, ""); err == nil || !strings.Contains(err.Error(), "requires a secret") {
+		t.Errorf("Expected ff1 without a secret to fail, got %v", err)
+	}
+
+	first, _ := newFF1Obfuscator("one")
+	second, _ := newFF1Obfuscator("two")
+	differing := 0
+	for id := uint64(1); id <= 10; id++ {
+		a, _ := first.Encode(id)
+		b, _ := second.Encode(id)
+		if a != b {
+			differing++
+		}
+	}
+	if differing == 0 {
+		t.Error("Expected different secrets to produce different codes")
+	}
+}
+
+func TestHashidsObfuscator_ReferenceVectors(t *testing.T) {
+	// Vectors from the reference hashids implementations
+	testCases := []struct {
+		salt      string
+		minLength int
+		number    uint64
+		expected  string
+	}{
+		{"this is my salt", 0, 12345, "NkK9"},
+		{"this is my salt", 8, 1, "gB0NV05e"},
+		{"", 0, 1, "jR"},
+		{"", 0, 12345, "j0gW"},
+	}
+
+	for _, tc := range testCases {
+		obfuscator := newHashidsObfuscator(tc.salt, tc.minLength)
+		code, err := obfuscator.Encode(tc.number)
+		if err != nil {
+			t.Fatalf("Encode failed: %v", err)
+		}
+		if code != tc.expected {
+			t.Errorf("Encode(%d) with salt %q and min length %d = %s, want %s",
+				tc.number, tc.salt, tc.minLength, code, tc.expected)
+		}
+	}
+}
+
+func TestHashidsObfuscator_UniqueCodes(t *testing.T) {
+	obfuscator := newHashidsObfuscator("secret", targetLength)
+
+	seen := make(map[string]uint64)
+	for id := uint64(1); id <= 20000; id++ {
+		code, err := obfuscator.Encode(id)
+		if err != nil {
+			t.Fatalf("Encode(%d) failed: %v", id, err)
+		}
+		if len(code) < targetLength {
+			t.Fatalf("Expected code length >= %d, got %s", targetLength, code)
+		}
+		for _, char := range code {
+			if !strings.ContainsRune(base62Chars, char) {
+				t.Fatalf("Code %s contains invalid character %c", code, char)
+			}
+		}
+		if other, exists := seen[code]; exists {
+			t.Fatalf("Collision: %d and %d both encode to %s", other, id, code)
+		}
+		seen[code] = id
+	}
+}
@@ -0,0 +1,185 @@
+package shortener
+
+import (
+	"context"
+	"fmt"
+)
+
+// CodeMapping is an issued code and the code the same counter value gets
+// under another configuration
+type CodeMapping struct {
+	Old string
+	New string
+}
+
+// Recoding is the result of mapping existing codes to a new configuration
+type Recoding struct {
+	Changes   []CodeMapping // Codes that move, in the order they were given
+	Unmatched []string      // Codes the old configuration never issued, such as custom codes; kept
+	Blocked   []string      // Codes whose new code the new configuration rejects; kept
+}
+
+// decoder is an obfuscator that can recover the counter behind a code
+type decoder interface {
+	Decode(code string) (uint64, error)
+}
+
+// Recoder maps codes issued under one generator configuration to the codes
+// the same counter values produce under another. Counters are not touched, so
+// a generator built from the new configuration continues after the last
+// value either one leased and never issues a mapped code again.
+type Recoder struct {
+	counters    CounterProvider
+	from, to    Obfuscator
+	fromSpan    uint64
+	toSpan      uint64
+	toBlacklist *Blacklist
+}
+
+// NewRecoder creates a recoder from one fixed-length configuration to
+// another. Auto length is not supported: its codes come from several lengths'
+// counters.
+func NewRecoder(from, to Config, counters CounterProvider) (*Recoder, error) {
+	if from.AutoLength || to.AutoLength {
+		return nil, fmt.Errorf("recoding does not support auto length")
+	}
+	fromSpace, err := from.codeSpace()
+	if err != nil {
+		return nil, fmt.Errorf("invalid current configuration: %w", err)
+	}
+	fromObfuscator, err := from.obfuscatorIn(fromSpace)
+	if err != nil {
+		return nil, fmt.Errorf("invalid current configuration: %w", err)
+	}
+	toSpace, err := to.codeSpace()
+	if err != nil {
+		return nil, fmt.Errorf("invalid new configuration: %w", err)
+	}
+	toObfuscator, err := to.obfuscatorIn(toSpace)
+	if err != nil {
+		return nil, fmt.Errorf("invalid new configuration: %w", err)
+	}
+
+	return &Recoder{
+		counters:    counters,
+		from:        fromObfuscator,
+		to:          toObfuscator,
+		fromSpan:    fromSpace.size / MaxCounterShards,
+		toSpan:      toSpace.size / MaxCounterShards,
+		toBlacklist: to.Blacklist(),
+	}, nil
+}
+
+// Recode maps codes to the new configuration. A code maps when the old
+// configuration issued it from a shard's counter at or below the value
+// stored for that shard. Recode fails when a new code would equal a code that
+// is kept or another new code.
+func (r *Recoder) Recode(ctx context.Context, codes []string) (*Recoding, error) {
+	issued, err := r.issued(ctx)
+	if err != nil {
+		return nil, err
+	}
+	counters, err := r.counterValues(codes, issued)
+	if err != nil {
+		return nil, err
+	}
+
+	recoding := &Recoding{}
+	kept := make(map[string]struct{})
+	var mapped []CodeMapping
+	for _, code := range codes {
+		counter, ok := counters[code]
+		if !ok {
+			recoding.Unmatched = append(recoding.Unmatched, code)
+			kept[code] = struct{}{}
+			continue
+		}
+		shard, local := int(counter/r.fromSpan), int64(counter%r.fromSpan)
+		target, err := shardCounter(shard, local, r.toSpan)
+		if err != nil {
+			return nil, fmt.Errorf("code %s does not fit the new code space: %w", code, err)
+		}
+		newCode, err := r.to.Encode(target)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode counter for %s: %w", code, err)
+		}
+		if r.toBlacklist.Check(newCode) != nil {
+			recoding.Blocked = append(recoding.Blocked, code)
+			kept[code] = struct{}{}
+			continue
+		}
+		if newCode != code {
+			mapped = append(mapped, CodeMapping{Old: code, New: newCode})
+		}
+	}
+
+	taken := make(map[string]string, len(mapped))
+	for _, change := range mapped {
+		if _, ok := kept[change.New]; ok {
+			return nil, fmt.Errorf("new code %s for %s equals a code that is kept", change.New, change.Old)
+		}
+		if other, ok := taken[change.New]; ok {
+			return nil, fmt.Errorf("codes %s and %s both map to %s", other, change.Old, change.New)
+		}
+		taken[change.New] = change.Old
+	}
+	recoding.Changes = mapped
+	return recoding, nil
+}
+
+// issued returns the highest counter value leased for each shard
+func (r *Recoder) issued(ctx context.Context) ([]int64, error) {
+	issued := make([]int64, MaxCounterShards)
+	for shard := range issued {
+		value, err := r.counters.GetCounter(ctx, counterKey(shard))
+		if err != nil {
+			return nil, err
+		}
+		issued[shard] = value
+	}
+	return issued, nil
+}
+
+// counterValues finds the counter value behind each code the old
+// configuration issued. Reversible obfuscators decode each code; the others
+// encode every leased value and look the codes up.
+func (r *Recoder) counterValues(codes []string, issued []int64) (map[string]uint64, error) {
+	counters := make(map[string]uint64)
+	if d, ok := r.from.(decoder); ok {
+		for _, code := range codes {
+			counter, err := d.Decode(code)
+			if err != nil {
+				continue
+			}
+			shard, local := counter/r.fromSpan, int64(counter%r.fromSpan)
+			if shard < MaxCounterShards && local >= 1 && local <= issued[shard] {
+				counters[code] = counter
+			}
+		}
+		return counters, nil
+	}
+
+	wanted := make(map[string]struct{}, len(codes))
+	for _, code := range codes {
+		wanted[code] = struct{}{}
+	}
+	for shard, last := range issued {
+		last = min(last, int64(r.fromSpan)-1)
+		for local := int64(1); local <= last; local++ {
+			counter, err := shardCounter(shard, local, r.fromSpan)
+			if err != nil {
+				return nil, err
+			}
+			code, err := r.from.Encode(counter)
+			if err != nil {
+				return nil, fmt.Errorf("failed to encode counter: %w", err)
+			}
+			if _, ok := wanted[code]; ok {
+				if _, seen := counters[code]; !seen {
+					counters[code] = counter
+				}
+			}
+		}
+	}
+	return counters, nil
+}
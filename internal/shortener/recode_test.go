@@ -0,0 +1,132 @@
+package shortener
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+// issueCodes generates count codes under config, spread over two shards
+func issueCodes(t *testing.T, counters CounterProvider, config Config, count int) []string {
+	config.CounterShards = 2
+	space, err := config.codeSpace()
+	if err != nil {
+		t.Fatalf("Failed to build code space: %v", err)
+	}
+	obfuscator, err := config.Obfuscator()
+	if err != nil {
+		t.Fatalf("Failed to build obfuscator: %v", err)
+	}
+	generator, err := newShardedCounterGenerator(counters, obfuscator, space, config.shards(), config.ShardPick)
+	if err != nil {
+		t.Fatalf("Failed to create generator: %v", err)
+	}
+
+	codes := make([]string, count)
+	for i := range codes {
+		if codes[i], err = generator.GenerateShortCode(context.Background(), "https://example.com", time.Now()); err != nil {
+			t.Fatalf("Failed to generate code: %v", err)
+		}
+	}
+	return codes
+}
+
+func TestRecoder_Recode(t *testing.T) {
+	strategies := []string{ObfuscationMultiplicative, ObfuscationFeistel}
+	for _, strategy := range strategies {
+		t.Run(strategy, func(t *testing.T) {
+			ctx := context.Background()
+			counters := NewCounterCache(setupCounterTestDB(t), 10)
+			from := Config{Obfuscation: strategy, Secret: "old-secret"}
+			if strategy == ObfuscationMultiplicative {
+				from.Secret = ""
+			}
+			to := Config{Obfuscation: ObfuscationFF1, Secret: "new-secret", Alphabet: "base58", Length: 8}
+
+			codes := issueCodes(t, counters, from, 20)
+			codes = append(codes, "custom-link")
+
+			recoder, err := NewRecoder(from, to, counters)
+			if err != nil {
+				t.Fatalf("Failed to create recoder: %v", err)
+			}
+			recoding, err := recoder.Recode(ctx, codes)
+			if err != nil {
+				t.Fatalf("Recode failed: %v", err)
+			}
+
+			if len(recoding.Unmatched) != 1 || recoding.Unmatched[0] != "custom-link" {
+				t.Errorf("Expected only the custom code to be unmatched, got %v", recoding.Unmatched)
+			}
+			if len(recoding.Changes)+len(recoding.Blocked) != 20 {
+				t.Fatalf("Expected 20 codes to be mapped or blocked, got %d and %d", len(recoding.Changes), len(recoding.Blocked))
+			}
+
+			// Generating under the new configuration continues past every mapped code
+			next := issueCodes(t, counters, to, 20)
+			seen := make(map[string]bool)
+			for _, change := range recoding.Changes {
+				if len(change.New) != 8 {
+					t.Errorf("Expected an 8-character code, got %q", change.New)
+				}
+				seen[change.New] = true
+			}
+			for _, code := range next {
+				if seen[code] {
+					t.Errorf("New generator issued mapped code %s", code)
+				}
+			}
+		})
+	}
+}
+
+func TestRecoder_SameConfig(t *testing.T) {
+	counters := NewCounterCache(setupCounterTestDB(t), 10)
+	config := DefaultConfig()
+	codes := issueCodes(t, counters, config, 5)
+
+	recoder, err := NewRecoder(config, config, counters)
+	if err != nil {
+		t.Fatalf("Failed to create recoder: %v", err)
+	}
+	recoding, err := recoder.Recode(context.Background(), codes)
+	if err != nil {
+		t.Fatalf("Recode failed: %v", err)
+	}
+	if len(recoding.Changes) != 0 || len(recoding.Unmatched) != 0 {
+		t.Errorf("Expected no changes for the same configuration, got %+v", recoding)
+	}
+}
+
+func TestRecoder_Collision(t *testing.T) {
+	counters := NewCounterCache(setupCounterTestDB(t), 10)
+	from := DefaultConfig()
+	to := Config{Obfuscation: ObfuscationFeistel, Secret: "new-secret"}
+	codes := issueCodes(t, counters, from, 1)
+
+	recoder, err := NewRecoder(from, to, counters)
+	if err != nil {
+		t.Fatalf("Failed to create recoder: %v", err)
+	}
+	recoding, err := recoder.Recode(context.Background(), codes)
+	if err != nil {
+		t.Fatalf("Recode failed: %v", err)
+	}
+
+	// A custom link already holding the new code makes the migration fail
+	_, err = recoder.Recode(context.Background(), append(codes, recoding.Changes[0].New))
+	if err == nil || !strings.Contains(err.Error(), "kept") {
+		t.Errorf("Expected a collision with a kept code, got %v", err)
+	}
+}
+
+func TestNewRecoder_AutoLength(t *testing.T) {
+	counters := NewCounterCache(setupCounterTestDB(t), 10)
+	if _, err := NewRecoder(DefaultConfig(), Config{AutoLength: true}, counters); err == nil {
+		t.Error("Expected auto length to be rejected")
+	}
+	if _, err := NewRecoder(DefaultConfig(), Config{Alphabet: "abc"}, counters); err == nil {
+		t.Error("Expected an invalid alphabet to be rejected")
+	}
+}
@@ -0,0 +1,75 @@
+package shortener
+
+import (
+	"fmt"
+	mathrand "math/rand/v2"
+	"strconv"
+	"sync/atomic"
+)
+
+// Shard pick strategies
+const (
+	ShardPickRoundRobin = "round_robin" // Shards take turns; spreads codes evenly
+	ShardPickRandom     = "random"      // Each call picks a shard at random; no shared cursor
+)
+
+// MaxCounterShards is the most counter shards a generator may use. The code
+// space is split into this many fixed regions, one per shard index, so the
+// shard count can change between restarts without two shards ever handing out
+// the same counter value.
+const MaxCounterShards = 64
+
+// shardSpan is the number of counter values in each shard's region of the
+// default code space
+const shardSpan = codeRangeSize / MaxCounterShards
+
+// counterKey is the counters row backing a shard. Shard 0 keeps the original
+// key, so an unsharded install continues its sequence when sharding is enabled.
+func counterKey(shard int) string {
+	if shard == 0 {
+		return "url_counter"
+	}
+	return "url_counter_" + strconv.Itoa(shard)
+}
+
+// shardPicker chooses the shard for the next code
+type shardPicker struct {
+	shards int
+	random bool
+	next   atomic.Uint64
+}
+
+// newShardPicker creates a picker over shards shards
+func newShardPicker(shards int, pick string) (*shardPicker, error) {
+	if shards < 1 || shards > MaxCounterShards {
+		return nil, fmt.Errorf("counter shards must be between 1 and %d, got: %d", MaxCounterShards, shards)
+	}
+	switch pick {
+	case "", ShardPickRoundRobin:
+		return &shardPicker{shards: shards}, nil
+	case ShardPickRandom:
+		return &shardPicker{shards: shards, random: true}, nil
+	default:
+		return nil, fmt.Errorf("unknown shard pick strategy %q: must be %s or %s", pick, ShardPickRoundRobin, ShardPickRandom)
+	}
+}
+
+// pick returns the next shard index
+func (p *shardPicker) pick() int {
+	if p.shards == 1 {
+		return 0
+	}
+	if p.random {
+		return mathrand.IntN(p.shards)
+	}
+	return int((p.next.Add(1) - 1) % uint64(p.shards))
+}
+
+// shardCounter maps a shard's own counter value into the shard's region of
+// span values
+func shardCounter(shard int, local int64, span uint64) (uint64, error) {
+	if local < 0 || uint64(local) >= span {
+		return 0, fmt.Errorf("counter shard %d is exhausted after %d values", shard, span)
+	}
+	return uint64(shard)*span + uint64(local), nil
+}
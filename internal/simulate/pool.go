@@ -0,0 +1,87 @@
+package simulate
+
+import (
+	"math/rand"
+	"sync"
+)
+
+// popularitySkew controls how strongly redirects favour the oldest links.
+// Higher values concentrate more traffic on fewer links, similar to real
+// shortener traffic where a handful of links receive most clicks.
+const popularitySkew = 3
+
+// linkPool tracks the links created during a simulation
+type linkPool struct {
+	mu    sync.RWMutex
+	codes []string
+	urls  map[string]string
+}
+
+// newLinkPool creates an empty link pool
+func newLinkPool() *linkPool {
+	return &linkPool{
+		urls: make(map[string]string),
+	}
+}
+
+// add adds a link to the pool
+func (p *linkPool) add(shortCode, originalURL string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.codes = append(p.codes, shortCode)
+	p.urls[shortCode] = originalURL
+}
+
+// pick returns a link with a skewed distribution favouring earlier links
+func (p *linkPool) pick(rng *rand.Rand) (string, string, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if len(p.codes) == 0 {
+		return "", "", false
+	}
+
+	u := rng.Float64()
+	weighted := u
+	for i := 1; i < popularitySkew; i++ {
+		weighted *= u
+	}
+
+	shortCode := p.codes[int(weighted*float64(len(p.codes)))]
+	return shortCode, p.urls[shortCode], true
+}
+
+// remove removes a uniformly chosen link from the pool
+func (p *linkPool) remove(rng *rand.Rand) (string, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// Always keep at least one link so redirects have something to resolve
+	if len(p.codes) <= 1 {
+		return "", false
+	}
+
+	i := rng.Intn(len(p.codes))
+	shortCode := p.codes[i]
+	p.codes = append(p.codes[:i], p.codes[i+1:]...)
+	delete(p.urls, shortCode)
+	return shortCode, true
+}
+
+// contains reports whether a link is still in the pool
+func (p *linkPool) contains(shortCode string) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	_, exists := p.urls[shortCode]
+	return exists
+}
+
+// size returns the number of links in the pool
+func (p *linkPool) size() int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return len(p.codes)
+}
@@ -0,0 +1,160 @@
+package simulate
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxErrorSamples limits how many distinct error messages are kept per run
+const maxErrorSamples = 10
+
+// OperationStats summarizes the results for one operation type
+type OperationStats struct {
+	Operation Operation     `json:"operation"`
+	Requests  int           `json:"requests"`
+	Errors    int           `json:"errors"`
+	P50       time.Duration `json:"p50"`
+	P90       time.Duration `json:"p90"`
+	P99       time.Duration `json:"p99"`
+	Max       time.Duration `json:"max"`
+}
+
+// Report summarizes a simulation run
+type Report struct {
+	Duration     time.Duration    `json:"duration"`
+	Operations   []OperationStats `json:"operations"`
+	Skipped      int              `json:"skipped"`
+	ErrorSamples []string         `json:"error_samples,omitempty"`
+}
+
+// TotalRequests returns the number of requests sent across all operations
+func (r *Report) TotalRequests() int {
+	total := 0
+	for _, op := range r.Operations {
+		total += op.Requests
+	}
+	return total
+}
+
+// TotalErrors returns the number of failed or invalid responses
+func (r *Report) TotalErrors() int {
+	total := 0
+	for _, op := range r.Operations {
+		total += op.Errors
+	}
+	return total
+}
+
+// Print writes a human-readable latency report
+func (r *Report) Print(w io.Writer) {
+	fmt.Fprintf(w, "Simulation completed in %s\n", r.Duration.Round(time.Millisecond))
+	if r.Duration > 0 {
+		fmt.Fprintf(w, "Achieved throughput: %.1f req/s\n", float64(r.TotalRequests())/r.Duration.Seconds())
+	}
+	fmt.Fprintf(w, "Skipped (workers saturated): %d\n\n", r.Skipped)
+
+	fmt.Fprintf(w, "%-10s %10s %8s %12s %12s %12s %12s\n", "Operation", "Requests", "Errors", "p50", "p90", "p99", "max")
+	fmt.Fprintln(w, strings.Repeat("-", 82))
+	for _, op := range r.Operations {
+		fmt.Fprintf(w, "%-10s %10d %8d %12s %12s %12s %12s\n",
+			op.Operation,
+			op.Requests,
+			op.Errors,
+			op.P50.Round(time.Microsecond),
+			op.P90.Round(time.Microsecond),
+			op.P99.Round(time.Microsecond),
+			op.Max.Round(time.Microsecond),
+		)
+	}
+
+	if len(r.ErrorSamples) > 0 {
+		fmt.Fprintf(w, "\nSample errors:\n")
+		for _, sample := range r.ErrorSamples {
+			fmt.Fprintf(w, "  %s\n", sample)
+		}
+	}
+}
+
+// recorder collects latencies and errors from concurrent workers
+type recorder struct {
+	mu        sync.Mutex
+	latencies map[Operation][]time.Duration
+	errors    map[Operation]int
+	samples   []string
+	skipped   int
+}
+
+// newRecorder creates an empty recorder
+func newRecorder() *recorder {
+	return &recorder{
+		latencies: make(map[Operation][]time.Duration),
+		errors:    make(map[Operation]int),
+	}
+}
+
+// record stores the outcome of a single request
+func (r *recorder) record(op Operation, latency time.Duration, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.latencies[op] = append(r.latencies[op], latency)
+	if err != nil {
+		r.errors[op]++
+		if len(r.samples) < maxErrorSamples {
+			r.samples = append(r.samples, fmt.Sprintf("%s: %v", op, err))
+		}
+	}
+}
+
+// skip records a request slot that could not be dispatched
+func (r *recorder) skip() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.skipped++
+}
+
+// report builds a Report from the recorded results
+func (r *recorder) report(elapsed time.Duration) *Report {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	report := &Report{
+		Duration:     elapsed,
+		Skipped:      r.skipped,
+		ErrorSamples: append([]string(nil), r.samples...),
+	}
+
+	for _, op := range []Operation{OpRedirect, OpCreate, OpDelete} {
+		latencies := append([]time.Duration(nil), r.latencies[op]...)
+		if len(latencies) == 0 {
+			continue
+		}
+		sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+
+		report.Operations = append(report.Operations, OperationStats{
+			Operation: op,
+			Requests:  len(latencies),
+			Errors:    r.errors[op],
+			P50:       percentile(latencies, 0.50),
+			P90:       percentile(latencies, 0.90),
+			P99:       percentile(latencies, 0.99),
+			Max:       latencies[len(latencies)-1],
+		})
+	}
+
+	return report
+}
+
+// percentile returns the value at the given percentile of sorted latencies
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	i := int(float64(len(sorted)-1) * p)
+	return sorted[i]
+}
@@ -0,0 +1,267 @@
+package simulate
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	"github.com/joshdurbin/url-shortener/internal/version"
+	"github.com/joshdurbin/url-shortener/pkg/urlshortener/client"
+)
+
+// Operation identifies a kind of simulated request
+type Operation string
+
+// Operation constants
+const (
+	OpRedirect Operation = "redirect"
+	OpCreate   Operation = "create"
+	OpDelete   Operation = "delete"
+)
+
+// Config holds configuration for a simulation run
+type Config struct {
+	TargetURL   string        // Base URL of the server under test
+	RPS         int           // Target requests per second
+	Duration    time.Duration // How long to generate traffic
+	Concurrency int           // Number of concurrent workers
+	SeedURLs    int           // Number of links created before traffic starts
+	CreateRatio float64       // Fraction of requests that create a new link
+	DeleteRatio float64       // Fraction of requests that delete an existing link
+}
+
+// DefaultConfig returns the default simulation configuration
+func DefaultConfig() Config {
+	return Config{
+		TargetURL:   "http://localhost:8080",
+		RPS:         500,
+		Duration:    5 * time.Minute,
+		Concurrency: 50,
+		SeedURLs:    100,
+		CreateRatio: 0.05,
+		DeleteRatio: 0.01,
+	}
+}
+
+// validate validates the simulation configuration
+func (c Config) validate() error {
+	if c.TargetURL == "" {
+		return fmt.Errorf("target URL cannot be empty")
+	}
+	if c.RPS <= 0 {
+		return fmt.Errorf("rps must be positive, got: %d", c.RPS)
+	}
+	if c.Duration <= 0 {
+		return fmt.Errorf("duration must be positive, got: %v", c.Duration)
+	}
+	if c.Concurrency <= 0 {
+		return fmt.Errorf("concurrency must be positive, got: %d", c.Concurrency)
+	}
+	if c.SeedURLs <= 0 {
+		return fmt.Errorf("seed URLs must be positive, got: %d", c.SeedURLs)
+	}
+	if c.CreateRatio < 0 || c.DeleteRatio < 0 || c.CreateRatio+c.DeleteRatio >= 1 {
+		return fmt.Errorf("create and delete ratios must be non-negative and sum to less than 1")
+	}
+	return nil
+}
+
+// Simulator generates synthetic traffic against a running server
+type Simulator struct {
+	config     Config
+	client     *client.Client
+	httpClient *http.Client
+	pool       *linkPool
+	recorder   *recorder
+
+	rngMu sync.Mutex
+	rng   *rand.Rand
+}
+
+// New creates a new simulator for the given configuration
+func New(config Config) (*Simulator, error) {
+	if err := config.validate(); err != nil {
+		return nil, fmt.Errorf("invalid simulation config: %w", err)
+	}
+
+	return &Simulator{
+		config: config,
+		client: client.NewClient(config.TargetURL),
+		httpClient: &http.Client{
+			Timeout: 10 * time.Second,
+			// Redirects are validated, not followed
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		pool:     newLinkPool(),
+		recorder: newRecorder(),
+		rng:      rand.New(rand.NewSource(time.Now().UnixNano())),
+	}, nil
+}
+
+// Run seeds the target with links, generates traffic for the configured
+// duration and returns a report of the results
+func (s *Simulator) Run(ctx context.Context) (*Report, error) {
+	if err := s.seed(ctx); err != nil {
+		return nil, fmt.Errorf("failed to seed links: %w", err)
+	}
+
+	runCtx, cancel := context.WithTimeout(ctx, s.config.Duration)
+	defer cancel()
+
+	jobs := make(chan Operation, s.config.Concurrency)
+	var wg sync.WaitGroup
+	for i := 0; i < s.config.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for op := range jobs {
+				s.execute(ctx, op)
+			}
+		}()
+	}
+
+	start := time.Now()
+	ticker := time.NewTicker(time.Second / time.Duration(s.config.RPS))
+	defer ticker.Stop()
+
+dispatch:
+	for {
+		select {
+		case <-runCtx.Done():
+			break dispatch
+		case <-ticker.C:
+			select {
+			case jobs <- s.nextOperation():
+			default:
+				// All workers are busy; record the missed slot rather than queueing
+				s.recorder.skip()
+			}
+		}
+	}
+
+	close(jobs)
+	wg.Wait()
+
+	return s.recorder.report(time.Since(start)), nil
+}
+
+// seed creates the initial set of links that redirects are drawn from
+func (s *Simulator) seed(ctx context.Context) error {
+	for i := 0; i < s.config.SeedURLs; i++ {
+		if err := s.create(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// nextOperation picks the next operation according to the configured mix
+func (s *Simulator) nextOperation() Operation {
+	s.rngMu.Lock()
+	roll := s.rng.Float64()
+	s.rngMu.Unlock()
+
+	switch {
+	case roll < s.config.CreateRatio:
+		return OpCreate
+	case roll < s.config.CreateRatio+s.config.DeleteRatio:
+		return OpDelete
+	default:
+		return OpRedirect
+	}
+}
+
+// execute runs a single operation, recording its latency and outcome
+func (s *Simulator) execute(ctx context.Context, op Operation) {
+	switch op {
+	case OpCreate:
+		s.create(ctx)
+	case OpDelete:
+		s.delete(ctx)
+	default:
+		s.redirect(ctx)
+	}
+}
+
+// create creates a new link and adds it to the pool
+func (s *Simulator) create(ctx context.Context) error {
+	s.rngMu.Lock()
+	originalURL := fmt.Sprintf("https://example.com/simulated/%d?ref=%d", s.rng.Int63(), s.rng.Intn(1000))
+	s.rngMu.Unlock()
+
+	start := time.Now()
+	result, err := s.client.CreateURL(ctx, originalURL, domain.CreateOptions{})
+	latency := time.Since(start)
+	if err != nil {
+		s.recorder.record(OpCreate, latency, fmt.Errorf("create failed: %w", err))
+		return err
+	}
+
+	if result.ShortCode == "" || result.OriginalURL != originalURL {
+		err := fmt.Errorf("create returned unexpected entry %+v", result)
+		s.recorder.record(OpCreate, latency, err)
+		return err
+	}
+
+	s.pool.add(result.ShortCode, originalURL)
+	s.recorder.record(OpCreate, latency, nil)
+	return nil
+}
+
+// delete removes a random link from the pool and the server
+func (s *Simulator) delete(ctx context.Context) {
+	s.rngMu.Lock()
+	shortCode, ok := s.pool.remove(s.rng)
+	s.rngMu.Unlock()
+	if !ok {
+		return
+	}
+
+	start := time.Now()
+	err := s.client.DeleteURL(ctx, shortCode)
+	s.recorder.record(OpDelete, time.Since(start), err)
+}
+
+// redirect resolves a popular link and validates the Location header
+func (s *Simulator) redirect(ctx context.Context) {
+	s.rngMu.Lock()
+	shortCode, originalURL, ok := s.pool.pick(s.rng)
+	s.rngMu.Unlock()
+	if !ok {
+		return
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.config.TargetURL+"/"+shortCode, nil)
+	if err != nil {
+		s.recorder.record(OpRedirect, 0, fmt.Errorf("failed to create request: %w", err))
+		return
+	}
+	req.Header.Set("User-Agent", version.UserAgent())
+
+	start := time.Now()
+	resp, err := s.httpClient.Do(req)
+	latency := time.Since(start)
+	if err != nil {
+		s.recorder.record(OpRedirect, latency, fmt.Errorf("failed to make request: %w", err))
+		return
+	}
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound && !s.pool.contains(shortCode):
+		// Raced with a simulated delete; not a server fault
+		s.recorder.record(OpRedirect, latency, nil)
+	case resp.StatusCode < 300 || resp.StatusCode >= 400:
+		s.recorder.record(OpRedirect, latency, fmt.Errorf("unexpected status %d for %s", resp.StatusCode, shortCode))
+	case resp.Header.Get("Location") != originalURL:
+		s.recorder.record(OpRedirect, latency, fmt.Errorf("redirect for %s pointed to %q, want %q", shortCode, resp.Header.Get("Location"), originalURL))
+	default:
+		s.recorder.record(OpRedirect, latency, nil)
+	}
+}
@@ -0,0 +1,213 @@
+package simulate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+)
+
+// fakeServer is a minimal in-memory implementation of the shortener API
+type fakeServer struct {
+	mu      sync.Mutex
+	urls    map[string]string
+	next    int
+	badCode string // code whose redirect points to the wrong place
+}
+
+func newFakeServer() *fakeServer {
+	return &fakeServer{urls: make(map[string]string)}
+}
+
+func (f *fakeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/api/urls":
+		var req domain.CreateURLRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		f.next++
+		code := fmt.Sprintf("c%d", f.next)
+		f.urls[code] = req.URL
+		json.NewEncoder(w).Encode(domain.CreateURLResponse{
+			ShortCode:   code,
+			OriginalURL: req.URL,
+			CreatedAt:   time.Now(),
+		})
+	case r.Method == http.MethodDelete && strings.HasPrefix(r.URL.Path, "/api/urls/"):
+		code := strings.TrimPrefix(r.URL.Path, "/api/urls/")
+		if _, ok := f.urls[code]; !ok {
+			http.NotFound(w, r)
+			return
+		}
+		delete(f.urls, code)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		code := strings.TrimPrefix(r.URL.Path, "/")
+		target, ok := f.urls[code]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		if code == f.badCode {
+			target = "https://wrong.example.com"
+		}
+		http.Redirect(w, r, target, http.StatusFound)
+	}
+}
+
+func testConfig(targetURL string) Config {
+	return Config{
+		TargetURL:   targetURL,
+		RPS:         200,
+		Duration:    300 * time.Millisecond,
+		Concurrency: 4,
+		SeedURLs:    5,
+		CreateRatio: 0.1,
+		DeleteRatio: 0.05,
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	testCases := []struct {
+		name   string
+		modify func(*Config)
+		errMsg string
+	}{
+		{"empty target", func(c *Config) { c.TargetURL = "" }, "target URL cannot be empty"},
+		{"zero rps", func(c *Config) { c.RPS = 0 }, "rps must be positive"},
+		{"zero duration", func(c *Config) { c.Duration = 0 }, "duration must be positive"},
+		{"zero concurrency", func(c *Config) { c.Concurrency = 0 }, "concurrency must be positive"},
+		{"zero seed", func(c *Config) { c.SeedURLs = 0 }, "seed URLs must be positive"},
+		{"ratios too large", func(c *Config) { c.CreateRatio = 0.6; c.DeleteRatio = 0.4 }, "ratios"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			tc.modify(&cfg)
+
+			_, err := New(cfg)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.errMsg)
+		})
+	}
+
+	_, err := New(DefaultConfig())
+	assert.NoError(t, err)
+}
+
+func TestSimulator_Run(t *testing.T) {
+	fake := newFakeServer()
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	sim, err := New(testConfig(server.URL))
+	require.NoError(t, err)
+
+	report, err := sim.Run(context.Background())
+	require.NoError(t, err)
+
+	assert.Greater(t, report.TotalRequests(), 5)
+	assert.Equal(t, 0, report.TotalErrors(), "unexpected errors: %v", report.ErrorSamples)
+
+	var ops []Operation
+	for _, op := range report.Operations {
+		ops = append(ops, op.Operation)
+		assert.LessOrEqual(t, op.P50, op.P99)
+		assert.LessOrEqual(t, op.P99, op.Max)
+	}
+	assert.Contains(t, ops, OpRedirect)
+	assert.Contains(t, ops, OpCreate)
+
+	var buf bytes.Buffer
+	report.Print(&buf)
+	assert.Contains(t, buf.String(), "Simulation completed")
+	assert.Contains(t, buf.String(), "redirect")
+}
+
+func TestSimulator_DetectsInvalidRedirects(t *testing.T) {
+	fake := newFakeServer()
+	fake.badCode = "c1"
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	cfg := testConfig(server.URL)
+	cfg.SeedURLs = 1
+	cfg.CreateRatio = 0
+	cfg.DeleteRatio = 0
+
+	sim, err := New(cfg)
+	require.NoError(t, err)
+
+	report, err := sim.Run(context.Background())
+	require.NoError(t, err)
+
+	assert.Greater(t, report.TotalErrors(), 0)
+	require.NotEmpty(t, report.ErrorSamples)
+	assert.Contains(t, report.ErrorSamples[0], "wrong.example.com")
+}
+
+func TestSimulator_SeedFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "boom", http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	sim, err := New(testConfig(server.URL))
+	require.NoError(t, err)
+
+	_, err = sim.Run(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to seed links")
+}
+
+func TestLinkPool(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	pool := newLinkPool()
+
+	_, _, ok := pool.pick(rng)
+	assert.False(t, ok)
+
+	for i := 0; i < 10; i++ {
+		pool.add(fmt.Sprintf("code%d", i), fmt.Sprintf("https://example.com/%d", i))
+	}
+	assert.Equal(t, 10, pool.size())
+
+	// Picks are skewed toward the earliest links
+	hits := make(map[string]int)
+	for i := 0; i < 1000; i++ {
+		code, url, ok := pool.pick(rng)
+		require.True(t, ok)
+		assert.Equal(t, "https://example.com/"+strings.TrimPrefix(code, "code"), url)
+		hits[code]++
+	}
+	assert.Greater(t, hits["code0"], hits["code9"])
+
+	// Removal never empties the pool
+	for i := 0; i < 20; i++ {
+		pool.remove(rng)
+	}
+	assert.Equal(t, 1, pool.size())
+}
+
+func TestPercentile(t *testing.T) {
+	sorted := []time.Duration{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+
+	assert.Equal(t, time.Duration(5), percentile(sorted, 0.5))
+	assert.Equal(t, time.Duration(10), percentile(sorted, 1))
+	assert.Equal(t, time.Duration(0), percentile(nil, 0.5))
+}
@@ -0,0 +1,41 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+)
+
+// Config holds storage reporting and quota configuration
+type Config struct {
+	MaxBytes         int64         // Database size quota in bytes; 0 disables
+	MaxRows          int64         // Total row quota across all tables; 0 disables
+	WarnRatio        float64       // Fraction of a quota at which a warning is raised
+	GrowthWindow     time.Duration // How far back link creation is measured to estimate growth
+	ProjectionWindow time.Duration // How far ahead growth is projected
+}
+
+// DefaultConfig returns the default configuration
+func DefaultConfig() Config {
+	return Config{
+		WarnRatio:        0.8,
+		GrowthWindow:     30 * 24 * time.Hour,
+		ProjectionWindow: 30 * 24 * time.Hour,
+	}
+}
+
+// Validate checks that the configuration values are usable
+func (c Config) Validate() error {
+	if c.MaxBytes < 0 || c.MaxRows < 0 {
+		return fmt.Errorf("quotas cannot be negative, got: %d bytes and %d rows", c.MaxBytes, c.MaxRows)
+	}
+	if c.WarnRatio <= 0 || c.WarnRatio > 1 {
+		return fmt.Errorf("warn ratio must be in (0, 1], got: %v", c.WarnRatio)
+	}
+	if c.GrowthWindow < 24*time.Hour {
+		return fmt.Errorf("growth window must be at least 24h, got: %v", c.GrowthWindow)
+	}
+	if c.ProjectionWindow < 0 {
+		return fmt.Errorf("projection window cannot be negative, got: %v", c.ProjectionWindow)
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	"github.com/joshdurbin/url-shortener/internal/repository"
+)
+
+const day = 24 * time.Hour
+
+// Reporter measures database usage, projects its growth from the recent link
+// creation rate and warns as configured quotas are approached. Growth is
+// estimated by assuming every new link brings the average bytes and rows per
+// existing link, which includes its share of delivery logs and audit entries.
+type Reporter struct {
+	config Config
+	repo   repository.StorageRepository
+	now    func() time.Time
+}
+
+// New creates a storage reporter
+func New(config Config, repo repository.StorageRepository) *Reporter {
+	return &Reporter{
+		config: config,
+		repo:   repo,
+		now:    time.Now,
+	}
+}
+
+// Report measures the database and evaluates the quotas
+func (r *Reporter) Report(ctx context.Context) (*domain.StorageReport, error) {
+	now := r.now()
+	stats, err := r.repo.StorageStats(ctx, now.Add(-r.config.GrowthWindow))
+	if err != nil {
+		return nil, fmt.Errorf("failed to measure storage: %w", err)
+	}
+
+	report := &domain.StorageReport{
+		DatabaseBytes: stats.DatabaseBytes,
+		TableRows:     stats.TableRows,
+		TotalClicks:   stats.TotalClicks,
+		Warnings:      []string{},
+		GeneratedAt:   now,
+	}
+	for _, count := range stats.TableRows {
+		report.TotalRows += count
+	}
+
+	report.Growth = r.growth(stats, report.TotalRows)
+	report.Quota = domain.StorageQuota{MaxBytes: r.config.MaxBytes, MaxRows: r.config.MaxRows}
+
+	if r.config.MaxBytes > 0 {
+		report.Quota.BytesUsed = float64(report.DatabaseBytes) / float64(r.config.MaxBytes)
+		r.checkQuota(report, "database size", formatBytes(report.DatabaseBytes), formatBytes(r.config.MaxBytes),
+			report.Quota.BytesUsed, r.config.MaxBytes-report.DatabaseBytes, report.Growth.BytesPerDay)
+	}
+	if r.config.MaxRows > 0 {
+		report.Quota.RowsUsed = float64(report.TotalRows) / float64(r.config.MaxRows)
+		r.checkQuota(report, "row count", fmt.Sprintf("%d rows", report.TotalRows), fmt.Sprintf("%d rows", r.config.MaxRows),
+			report.Quota.RowsUsed, r.config.MaxRows-report.TotalRows, report.Growth.RowsPerDay)
+	}
+
+	return report, nil
+}
+
+// growth estimates daily growth from links created over the growth window
+func (r *Reporter) growth(stats *domain.StorageStats, totalRows int64) domain.StorageGrowth {
+	growth := domain.StorageGrowth{
+		WindowDays:     int(r.config.GrowthWindow / day),
+		ProjectionDays: int(r.config.ProjectionWindow / day),
+	}
+	growth.LinksPerDay = float64(stats.LinksCreated) / float64(growth.WindowDays)
+
+	if links := stats.TableRows["urls"]; links > 0 {
+		growth.BytesPerDay = int64(growth.LinksPerDay * float64(stats.DatabaseBytes) / float64(links))
+		growth.RowsPerDay = int64(growth.LinksPerDay * float64(totalRows) / float64(links))
+	}
+
+	growth.ProjectedBytes = stats.DatabaseBytes + growth.BytesPerDay*int64(growth.ProjectionDays)
+	growth.ProjectedRows = totalRows + growth.RowsPerDay*int64(growth.ProjectionDays)
+	return growth
+}
+
+// checkQuota adds warnings for a quota that is exceeded, nearly used or
+// projected to fill within the projection window, and tracks the soonest
+// day a quota fills
+func (r *Reporter) checkQuota(report *domain.StorageReport, name, used, limit string, fraction float64, remaining, perDay int64) {
+	switch {
+	case remaining <= 0:
+		report.Warnings = append(report.Warnings, fmt.Sprintf("%s %s exceeds the %s quota", name, used, limit))
+		r.setDaysUntilFull(report, 0)
+		return
+	case fraction >= r.config.WarnRatio:
+		report.Warnings = append(report.Warnings, fmt.Sprintf("%s %s is at %.0f%% of the %s quota", name, used, fraction*100, limit))
+	}
+
+	if perDay <= 0 {
+		return
+	}
+	days := int(remaining / perDay)
+	r.setDaysUntilFull(report, days)
+	if days <= report.Growth.ProjectionDays {
+		report.Warnings = append(report.Warnings, fmt.Sprintf("%s is projected to reach the %s quota in %d days", name, limit, days))
+	}
+}
+
+// setDaysUntilFull keeps the soonest day any quota fills
+func (r *Reporter) setDaysUntilFull(report *domain.StorageReport, days int) {
+	if report.Quota.DaysUntilFull == nil || days < *report.Quota.DaysUntilFull {
+		report.Quota.DaysUntilFull = &days
+	}
+}
+
+// formatBytes renders a byte count with a binary unit, e.g. "1.5 MiB"
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
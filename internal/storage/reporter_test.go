@@ -0,0 +1,151 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	"github.com/joshdurbin/url-shortener/internal/repository/mocks"
+)
+
+const mib = 1024 * 1024
+
+// testStats describes a 10 MiB database holding 1000 links and 1000 other
+// rows, 300 of the links created in the last 30 days
+func testStats() *domain.StorageStats {
+	return &domain.StorageStats{
+		DatabaseBytes: 10 * mib,
+		TableRows:     map[string]int64{"urls": 1000, "webhook_deliveries": 1000},
+		TotalClicks:   42000,
+		LinksCreated:  300,
+	}
+}
+
+func newTestReporter(config Config, stats *domain.StorageStats, now time.Time) (*Reporter, *mocks.StorageRepository) {
+	repo := &mocks.StorageRepository{}
+	repo.On("StorageStats", mock.Anything, now.Add(-config.GrowthWindow)).Return(stats, nil)
+	reporter := New(config, repo)
+	reporter.now = func() time.Time { return now }
+	return reporter, repo
+}
+
+func TestReporter_Report(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	reporter, repo := newTestReporter(DefaultConfig(), testStats(), now)
+
+	report, err := reporter.Report(context.Background())
+	require.NoError(t, err)
+	repo.AssertExpectations(t)
+
+	assert.Equal(t, int64(10*mib), report.DatabaseBytes)
+	assert.Equal(t, int64(2000), report.TotalRows)
+	assert.Equal(t, int64(42000), report.TotalClicks)
+	assert.Equal(t, now, report.GeneratedAt)
+
+	// 10 links a day, each bringing ~10 KiB and two rows
+	assert.Equal(t, 30, report.Growth.WindowDays)
+	assert.InDelta(t, 10.0, report.Growth.LinksPerDay, 0.001)
+	assert.Equal(t, int64(10*mib/100), report.Growth.BytesPerDay)
+	assert.Equal(t, int64(20), report.Growth.RowsPerDay)
+	assert.Equal(t, int64(10*mib+30*(10*mib/100)), report.Growth.ProjectedBytes)
+	assert.Equal(t, int64(2600), report.Growth.ProjectedRows)
+
+	assert.Empty(t, report.Warnings, "no quotas are configured")
+	assert.Nil(t, report.Quota.DaysUntilFull)
+}
+
+func TestReporter_Quotas(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		maxBytes      int64
+		maxRows       int64
+		wantWarnings  []string
+		wantDaysUntil *int
+	}{
+		{
+			name:          "plenty of headroom",
+			maxBytes:      1024 * mib,
+			maxRows:       1000000,
+			wantDaysUntil: intPtr(10140),
+		},
+		{
+			name:     "approaching the byte quota",
+			maxBytes: 12 * mib,
+			wantWarnings: []string{
+				"database size 10.0 MiB is at 83% of the 12.0 MiB quota",
+				"database size is projected to reach the 12.0 MiB quota in 20 days",
+			},
+			wantDaysUntil: intPtr(20),
+		},
+		{
+			name:    "row quota exceeded",
+			maxRows: 1500,
+			wantWarnings: []string{
+				"row count 2000 rows exceeds the 1500 rows quota",
+			},
+			wantDaysUntil: intPtr(0),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultConfig()
+			config.MaxBytes = tt.maxBytes
+			config.MaxRows = tt.maxRows
+			reporter, _ := newTestReporter(config, testStats(), now)
+
+			report, err := reporter.Report(context.Background())
+			require.NoError(t, err)
+			if tt.wantWarnings == nil {
+				assert.Empty(t, report.Warnings)
+			} else {
+				assert.Equal(t, tt.wantWarnings, report.Warnings)
+			}
+			assert.Equal(t, tt.wantDaysUntil, report.Quota.DaysUntilFull)
+		})
+	}
+}
+
+func TestReporter_ReportError(t *testing.T) {
+	repo := &mocks.StorageRepository{}
+	repo.On("StorageStats", mock.Anything, mock.Anything).Return(nil, errors.New("database is locked"))
+
+	_, err := New(DefaultConfig(), repo).Report(context.Background())
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to measure storage")
+}
+
+func TestFormatBytes(t *testing.T) {
+	assert.Equal(t, "512 B", formatBytes(512))
+	assert.Equal(t, "1.5 KiB", formatBytes(1536))
+	assert.Equal(t, "10.0 MiB", formatBytes(10*mib))
+	assert.Equal(t, "2.0 GiB", formatBytes(2048*mib))
+}
+
+func TestConfig_Validate(t *testing.T) {
+	assert.NoError(t, DefaultConfig().Validate())
+
+	config := DefaultConfig()
+	config.MaxBytes = -1
+	assert.Error(t, config.Validate())
+
+	config = DefaultConfig()
+	config.WarnRatio = 1.5
+	assert.Error(t, config.Validate())
+
+	config = DefaultConfig()
+	config.GrowthWindow = time.Hour
+	assert.Error(t, config.Validate())
+}
+
+func intPtr(n int) *int {
+	return &n
+}
@@ -0,0 +1,93 @@
+package tracing
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Exporter names
+const (
+	ExporterNone = "none" // Tracing is disabled
+	ExporterOTLP = "otlp" // Spans are sent to an OTLP/HTTP collector
+)
+
+// Config holds tracing configuration
+type Config struct {
+	Exporter      string        // Where spans are sent: none or otlp
+	Endpoint      string        // OTLP/HTTP collector base URL; spans are posted to <endpoint>/v1/traces
+	Headers       []string      // Extra key=value request headers, such as an API key for a hosted collector
+	ServiceName   string        // service.name resource attribute
+	SampleRatio   float64       // Fraction of new traces recorded; requests with a traceparent follow its sampled flag
+	BatchSize     int           // Spans sent per export request
+	FlushInterval time.Duration // How often ended spans are exported when a batch is not full
+	QueueSize     int           // Ended spans buffered for export before new ones are dropped
+	Timeout       time.Duration // Timeout for each export request
+}
+
+// DefaultConfig returns the default configuration
+func DefaultConfig() Config {
+	return Config{
+		Exporter:      ExporterNone,
+		Endpoint:      "http://localhost:4318",
+		ServiceName:   "url-shortener",
+		SampleRatio:   1,
+		BatchSize:     512,
+		FlushInterval: 5 * time.Second,
+		QueueSize:     2048,
+		Timeout:       10 * time.Second,
+	}
+}
+
+// Validate checks that the configuration values are usable
+func (c Config) Validate() error {
+	switch c.Exporter {
+	case "", ExporterNone:
+		return nil
+	case ExporterOTLP:
+	default:
+		return fmt.Errorf("trace exporter must be %s or %s, got: %q", ExporterNone, ExporterOTLP, c.Exporter)
+	}
+
+	u, err := url.Parse(c.Endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("OTLP endpoint must be an http or https URL, got: %q", c.Endpoint)
+	}
+	if _, err := c.headers(); err != nil {
+		return err
+	}
+	if c.ServiceName == "" {
+		return fmt.Errorf("service name cannot be empty")
+	}
+	if c.SampleRatio < 0 || c.SampleRatio > 1 {
+		return fmt.Errorf("sample ratio must be between 0 and 1, got: %v", c.SampleRatio)
+	}
+	if c.BatchSize < 1 {
+		return fmt.Errorf("trace batch size must be at least 1, got: %d", c.BatchSize)
+	}
+	if c.FlushInterval <= 0 {
+		return fmt.Errorf("trace flush interval must be positive, got: %v", c.FlushInterval)
+	}
+	if c.QueueSize < c.BatchSize {
+		return fmt.Errorf("trace queue size (%d) must be at least the batch size (%d)", c.QueueSize, c.BatchSize)
+	}
+	if c.Timeout <= 0 {
+		return fmt.Errorf("trace export timeout must be positive, got: %v", c.Timeout)
+	}
+	return nil
+}
+
+// headers parses the key=value headers
+func (c Config) headers() (map[string]string, error) {
+	headers := make(map[string]string, len(c.Headers))
+	for _, header := range c.Headers {
+		key, value, ok := strings.Cut(header, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("OTLP header must be key=value, got: %q", header)
+		}
+		headers[key] = strings.TrimSpace(value)
+	}
+	return headers, nil
+}
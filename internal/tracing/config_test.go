@@ -0,0 +1,42 @@
+package tracing
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfig_Validate(t *testing.T) {
+	assert.NoError(t, DefaultConfig().Validate())
+	assert.NoError(t, Config{}.Validate(), "no exporter needs no settings")
+
+	otlp := DefaultConfig()
+	otlp.Exporter = ExporterOTLP
+	otlp.Headers = []string{"authorization=Bearer abc"}
+	assert.NoError(t, otlp.Validate())
+
+	tests := []struct {
+		name   string
+		modify func(*Config)
+	}{
+		{"unknown exporter", func(c *Config) { c.Exporter = "jaeger" }},
+		{"grpc endpoint", func(c *Config) { c.Endpoint = "grpc://localhost:4317" }},
+		{"endpoint without host", func(c *Config) { c.Endpoint = "http://" }},
+		{"header without value", func(c *Config) { c.Headers = []string{"authorization"} }},
+		{"header without key", func(c *Config) { c.Headers = []string{"=abc"} }},
+		{"empty service name", func(c *Config) { c.ServiceName = "" }},
+		{"negative ratio", func(c *Config) { c.SampleRatio = -0.1 }},
+		{"ratio above one", func(c *Config) { c.SampleRatio = 1.5 }},
+		{"zero batch", func(c *Config) { c.BatchSize = 0 }},
+		{"zero interval", func(c *Config) { c.FlushInterval = 0 }},
+		{"queue below batch", func(c *Config) { c.QueueSize = c.BatchSize - 1 }},
+		{"zero timeout", func(c *Config) { c.Timeout = 0 }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := otlp
+			tt.modify(&config)
+			assert.Error(t, config.Validate())
+		})
+	}
+}
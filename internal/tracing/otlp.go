@@ -0,0 +1,162 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/joshdurbin/url-shortener/internal/version"
+)
+
+// scopeName identifies the instrumentation in exported spans
+const scopeName = "github.com/joshdurbin/url-shortener"
+
+// OTLP status codes
+const (
+	otlpStatusUnset = 0
+	otlpStatusError = 2
+)
+
+// The OTLP/JSON encoding of an export request. IDs are hex strings and 64-bit
+// integers are decimal strings, as the OTLP JSON mapping requires.
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+type otlpSpan struct {
+	TraceID           string         `json:"traceId"`
+	SpanID            string         `json:"spanId"`
+	ParentSpanID      string         `json:"parentSpanId,omitempty"`
+	Name              string         `json:"name"`
+	Kind              Kind           `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Status            otlpStatus     `json:"status"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpKeyValue struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpValue struct {
+	StringValue *string `json:"stringValue,omitempty"`
+	IntValue    *string `json:"intValue,omitempty"`
+	BoolValue   *bool   `json:"boolValue,omitempty"`
+}
+
+// otlpTracesURL returns the traces endpoint under an OTLP/HTTP base URL
+func otlpTracesURL(endpoint string) string {
+	return strings.TrimRight(endpoint, "/") + "/v1/traces"
+}
+
+// post sends spans to the collector as one OTLP/JSON export request
+func (t *Tracer) post(ctx context.Context, batch []*Span) error {
+	spans := make([]otlpSpan, len(batch))
+	for i, span := range batch {
+		spans[i] = encodeSpan(span)
+	}
+	body, err := json.Marshal(otlpRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource:   otlpResource{Attributes: encodeAttributes(t.resource)},
+		ScopeSpans: []otlpScopeSpans{{Scope: otlpScope{Name: scopeName, Version: version.Version}, Spans: spans}},
+	}}})
+	if err != nil {
+		return fmt.Errorf("failed to encode spans: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range t.headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("collector at %s returned %s", t.endpoint, resp.Status)
+	}
+	return nil
+}
+
+// encodeSpan converts an ended span to its OTLP form
+func encodeSpan(span *Span) otlpSpan {
+	span.mu.Lock()
+	defer span.mu.Unlock()
+
+	encoded := otlpSpan{
+		TraceID:           span.traceID.String(),
+		SpanID:            span.spanID.String(),
+		Name:              span.name,
+		Kind:              span.kind,
+		StartTimeUnixNano: strconv.FormatInt(span.start.UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(span.end.UnixNano(), 10),
+		Attributes:        encodeAttributes(span.attrs),
+		Status:            otlpStatus{Code: otlpStatusUnset},
+	}
+	if span.parentID.IsValid() {
+		encoded.ParentSpanID = span.parentID.String()
+	}
+	if span.failed {
+		encoded.Status = otlpStatus{Code: otlpStatusError, Message: span.message}
+	}
+	return encoded
+}
+
+// encodeAttributes converts attributes to OTLP key-values
+func encodeAttributes(attrs []Attribute) []otlpKeyValue {
+	encoded := make([]otlpKeyValue, 0, len(attrs))
+	for _, attr := range attrs {
+		var value otlpValue
+		switch attr.typ {
+		case attributeInt:
+			s := strconv.FormatInt(attr.num, 10)
+			value.IntValue = &s
+		case attributeBool:
+			b := attr.value
+			value.BoolValue = &b
+		default:
+			s := attr.str
+			value.StringValue = &s
+		}
+		encoded = append(encoded, otlpKeyValue{Key: attr.Key, Value: value})
+	}
+	return encoded
+}
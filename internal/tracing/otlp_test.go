@@ -0,0 +1,109 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTracer_ExportsOTLP(t *testing.T) {
+	requests := make(chan *http.Request, 10)
+	bodies := make(chan []byte, 10)
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests <- r
+		bodies <- body
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer collector.Close()
+
+	config := DefaultConfig()
+	config.Exporter = ExporterOTLP
+	config.Endpoint = collector.URL + "/"
+	config.Headers = []string{"x-api-key = s3cret"}
+	config.FlushInterval = time.Hour
+	tracer, err := New(config, "1.2.3")
+	require.NoError(t, err)
+	require.NoError(t, tracer.Start(context.Background()))
+
+	ctx, root := tracer.StartRequest(context.Background(), "GET /", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		String("url.path", "/abc123"), Int("http.response.status_code", 500), Bool("cached", true))
+	_, child := Start(ctx, "cache.Get")
+	child.End()
+	root.SetFailed("500 Internal Server Error")
+	root.End()
+
+	// Close exports what is queued
+	require.NoError(t, tracer.Close())
+	require.NoError(t, tracer.Close())
+	assert.Equal(t, int64(2), tracer.Exported())
+
+	req := <-requests
+	assert.Equal(t, "/v1/traces", req.URL.Path)
+	assert.Equal(t, "application/json", req.Header.Get("Content-Type"))
+	assert.Equal(t, "s3cret", req.Header.Get("x-api-key"))
+
+	var export struct {
+		ResourceSpans []struct {
+			Resource struct {
+				Attributes []map[string]any `json:"attributes"`
+			} `json:"resource"`
+			ScopeSpans []struct {
+				Spans []map[string]any `json:"spans"`
+			} `json:"scopeSpans"`
+		} `json:"resourceSpans"`
+	}
+	require.NoError(t, json.Unmarshal(<-bodies, &export))
+	require.Len(t, export.ResourceSpans, 1)
+	assert.Contains(t, export.ResourceSpans[0].Resource.Attributes,
+		map[string]any{"key": "service.name", "value": map[string]any{"stringValue": "url-shortener"}})
+	assert.Contains(t, export.ResourceSpans[0].Resource.Attributes,
+		map[string]any{"key": "service.version", "value": map[string]any{"stringValue": "1.2.3"}})
+
+	spans := export.ResourceSpans[0].ScopeSpans[0].Spans
+	require.Len(t, spans, 2)
+	assert.Equal(t, "cache.Get", spans[0]["name"])
+	assert.Equal(t, float64(KindInternal), spans[0]["kind"])
+	assert.Equal(t, spans[1]["spanId"], spans[0]["parentSpanId"])
+
+	assert.Equal(t, "GET /", spans[1]["name"])
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", spans[1]["traceId"])
+	assert.Equal(t, "00f067aa0ba902b7", spans[1]["parentSpanId"])
+	assert.Equal(t, float64(KindServer), spans[1]["kind"])
+	assert.IsType(t, "", spans[1]["startTimeUnixNano"])
+	assert.Equal(t, map[string]any{"code": float64(otlpStatusError), "message": "500 Internal Server Error"}, spans[1]["status"])
+	assert.Equal(t, []any{
+		map[string]any{"key": "url.path", "value": map[string]any{"stringValue": "/abc123"}},
+		map[string]any{"key": "http.response.status_code", "value": map[string]any{"intValue": "500"}},
+		map[string]any{"key": "cached", "value": map[string]any{"boolValue": true}},
+	}, spans[1]["attributes"])
+}
+
+func TestTracer_ExportFailures(t *testing.T) {
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer collector.Close()
+
+	config := DefaultConfig()
+	config.Exporter = ExporterOTLP
+	config.Endpoint = collector.URL
+	tracer, err := New(config, "test")
+	require.NoError(t, err)
+	require.NoError(t, tracer.Start(context.Background()))
+
+	_, span := tracer.StartRequest(context.Background(), "GET /", "")
+	span.RecordError(errors.New("boom"))
+	span.End()
+	require.NoError(t, tracer.Close())
+	assert.Equal(t, int64(1), tracer.Failed())
+	assert.Zero(t, tracer.Exported())
+}
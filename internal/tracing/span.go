@@ -0,0 +1,217 @@
+package tracing
+
+import (
+	"context"
+	"encoding/hex"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Kind says what a span represents, with OTLP's numbering
+type Kind int
+
+// Kind constants
+const (
+	KindInternal Kind = 1 // An operation inside the process
+	KindServer   Kind = 2 // An incoming request
+)
+
+// TraceID identifies a trace
+type TraceID [16]byte
+
+// SpanID identifies a span within a trace
+type SpanID [8]byte
+
+// String returns the ID in lowercase hex
+func (t TraceID) String() string { return hex.EncodeToString(t[:]) }
+
+// String returns the ID in lowercase hex
+func (s SpanID) String() string { return hex.EncodeToString(s[:]) }
+
+// IsValid reports whether the ID is not all zeros
+func (t TraceID) IsValid() bool { return t != TraceID{} }
+
+// IsValid reports whether the ID is not all zeros
+func (s SpanID) IsValid() bool { return s != SpanID{} }
+
+// attributeType says which field of an Attribute holds its value
+type attributeType uint8
+
+// attributeType constants
+const (
+	attributeString attributeType = iota
+	attributeInt
+	attributeBool
+)
+
+// Attribute is a key and a string, integer or boolean value. Values are kept
+// in typed fields rather than an interface, so building attributes for an
+// untraced request does not allocate.
+type Attribute struct {
+	Key   string
+	typ   attributeType
+	str   string
+	num   int64
+	value bool
+}
+
+// String returns a string attribute
+func String(key, value string) Attribute {
+	return Attribute{Key: key, typ: attributeString, str: value}
+}
+
+// Int returns an integer attribute
+func Int(key string, value int) Attribute {
+	return Attribute{Key: key, typ: attributeInt, num: int64(value)}
+}
+
+// Bool returns a boolean attribute
+func Bool(key string, value bool) Attribute {
+	return Attribute{Key: key, typ: attributeBool, value: value}
+}
+
+// Span is one timed operation in a trace. A nil *Span is a valid span that
+// records nothing, so callers never need to check whether tracing is on.
+type Span struct {
+	tracer   *Tracer
+	traceID  TraceID
+	spanID   SpanID
+	parentID SpanID
+	name     string
+	kind     Kind
+	start    time.Time
+
+	mu      sync.Mutex
+	end     time.Time
+	attrs   []Attribute
+	failed  bool
+	message string
+	ended   bool
+}
+
+// spanKey is the context key of the current span
+type spanKey struct{}
+
+// ContextWithSpan returns ctx with span as its current span
+func ContextWithSpan(ctx context.Context, span *Span) context.Context {
+	return context.WithValue(ctx, spanKey{}, span)
+}
+
+// FromContext returns the current span, or nil when ctx is not traced
+func FromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+// Start starts a child of the span in ctx and returns a context with the new
+// span as current. Without a span in ctx nothing is recorded: it returns ctx
+// and a nil span, so untraced work such as background syncs costs only the
+// context lookup.
+func Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, *Span) {
+	parent := FromContext(ctx)
+	if parent == nil {
+		return ctx, nil
+	}
+	span := parent.tracer.newSpan(parent.traceID, parent.spanID, name, KindInternal, attrs)
+	return ContextWithSpan(ctx, span), span
+}
+
+// SetAttributes adds attributes to the span
+func (s *Span) SetAttributes(attrs ...Attribute) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.attrs = append(s.attrs, attrs...)
+}
+
+// RecordError marks the span as failed with err's message. A nil err is
+// ignored, so it can be called with any returned error.
+func (s *Span) RecordError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failed = true
+	s.message = err.Error()
+}
+
+// SetFailed marks the span as failed with a message, for failures that are
+// not Go errors such as 5xx responses
+func (s *Span) SetFailed(message string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failed = true
+	s.message = message
+}
+
+// End records the span's end time and queues it for export. Later calls do
+// nothing.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.end = time.Now()
+	s.mu.Unlock()
+
+	s.tracer.enqueue(s)
+}
+
+// TraceID returns the ID of the span's trace
+func (s *Span) TraceID() TraceID {
+	if s == nil {
+		return TraceID{}
+	}
+	return s.traceID
+}
+
+// TraceParent returns the span as a W3C traceparent header value, for
+// passing the trace on to another service
+func (s *Span) TraceParent() string {
+	if s == nil {
+		return ""
+	}
+	return "00-" + s.traceID.String() + "-" + s.spanID.String() + "-01"
+}
+
+// parseTraceParent reads a W3C traceparent header: version, trace ID, parent
+// span ID and flags, dash separated. Unknown future versions are read as
+// version 00, as the specification asks.
+func parseTraceParent(header string) (traceID TraceID, parentID SpanID, sampled bool, ok bool) {
+	parts := strings.Split(strings.TrimSpace(header), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" {
+		return TraceID{}, SpanID{}, false, false
+	}
+	if parts[0] == "00" && len(parts) != 4 {
+		return TraceID{}, SpanID{}, false, false
+	}
+	if len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return TraceID{}, SpanID{}, false, false
+	}
+	if _, err := hex.Decode(traceID[:], []byte(parts[1])); err != nil || strings.ToLower(parts[1]) != parts[1] {
+		return TraceID{}, SpanID{}, false, false
+	}
+	if _, err := hex.Decode(parentID[:], []byte(parts[2])); err != nil || strings.ToLower(parts[2]) != parts[2] {
+		return TraceID{}, SpanID{}, false, false
+	}
+	var flags [1]byte
+	if _, err := hex.Decode(flags[:], []byte(parts[3])); err != nil {
+		return TraceID{}, SpanID{}, false, false
+	}
+	if !traceID.IsValid() || !parentID.IsValid() {
+		return TraceID{}, SpanID{}, false, false
+	}
+	return traceID, parentID, flags[0]&1 == 1, true
+}
@@ -0,0 +1,272 @@
+// Package tracing records OpenTelemetry-compatible spans for requests and
+// sends them to an OTLP/HTTP collector. Incoming W3C traceparent headers are
+// continued, so a redirect's time in the HTTP handler, the service, the cache
+// and each SQL query shows up in the caller's trace.
+//
+// Spans are started with Start, which only records when the context already
+// carries a span; the HTTP middleware starts the root span of each request
+// with Tracer.StartRequest. Export is best effort: spans are dropped when the
+// queue is full or the collector is unreachable, and never slow a request.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Tracer creates spans and exports the ended ones in batches. A nil *Tracer
+// records nothing.
+type Tracer struct {
+	config   Config
+	endpoint string
+	headers  map[string]string
+	client   *http.Client
+	resource []Attribute
+
+	mu       sync.Mutex
+	started  bool
+	closed   bool
+	queue    chan *Span
+	stopChan chan struct{}
+	wg       sync.WaitGroup
+
+	exported atomic.Int64
+	dropped  atomic.Int64
+	failed   atomic.Int64
+	failing  bool // Whether the last export failed; only used by the export loop
+}
+
+// New creates a tracer for config, or returns nil when tracing is disabled.
+// version is reported as the service.version resource attribute.
+func New(config Config, version string) (*Tracer, error) {
+	switch config.Exporter {
+	case "", ExporterNone:
+		return nil, nil
+	case ExporterOTLP:
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q", config.Exporter)
+	}
+
+	headers, err := config.headers()
+	if err != nil {
+		return nil, err
+	}
+	return &Tracer{
+		config:   config,
+		endpoint: otlpTracesURL(config.Endpoint),
+		headers:  headers,
+		client:   &http.Client{Timeout: config.Timeout},
+		resource: []Attribute{
+			String("service.name", config.ServiceName),
+			String("service.version", version),
+			String("telemetry.sdk.language", "go"),
+		},
+		queue:    make(chan *Span, config.QueueSize),
+		stopChan: make(chan struct{}),
+	}, nil
+}
+
+// StartRequest starts the root span of an incoming request. When traceParent
+// holds a valid W3C traceparent header the span joins that trace and follows
+// its sampled flag; otherwise a new trace is started and sampled at the
+// configured ratio. Unsampled requests get ctx back with a nil span.
+func (t *Tracer) StartRequest(ctx context.Context, name, traceParent string, attrs ...Attribute) (context.Context, *Span) {
+	if t == nil {
+		return ctx, nil
+	}
+
+	traceID, parentID, sampled, ok := parseTraceParent(traceParent)
+	if !ok {
+		traceID = newTraceID()
+		parentID = SpanID{}
+		sampled = t.sample(traceID)
+	}
+	if !sampled {
+		return ctx, nil
+	}
+
+	span := t.newSpan(traceID, parentID, name, KindServer, attrs)
+	return ContextWithSpan(ctx, span), span
+}
+
+// sample decides whether to record a new trace from the low 8 bytes of its
+// ID, which are random, so the decision is the same for every span of it
+func (t *Tracer) sample(traceID TraceID) bool {
+	switch {
+	case t.config.SampleRatio >= 1:
+		return true
+	case t.config.SampleRatio <= 0:
+		return false
+	}
+	bound := uint64(t.config.SampleRatio * (1 << 63))
+	return binary.BigEndian.Uint64(traceID[8:])>>1 < bound
+}
+
+// newSpan creates a started span
+func (t *Tracer) newSpan(traceID TraceID, parentID SpanID, name string, kind Kind, attrs []Attribute) *Span {
+	span := &Span{
+		tracer:   t,
+		traceID:  traceID,
+		spanID:   newSpanID(),
+		parentID: parentID,
+		name:     name,
+		kind:     kind,
+		start:    time.Now(),
+	}
+	if len(attrs) > 0 {
+		span.attrs = append([]Attribute(nil), attrs...)
+	}
+	return span
+}
+
+// enqueue queues an ended span for export without blocking
+func (t *Tracer) enqueue(span *Span) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.closed {
+		t.dropped.Add(1)
+		return
+	}
+	select {
+	case t.queue <- span:
+	default:
+		t.dropped.Add(1)
+	}
+}
+
+// Start starts exporting ended spans
+func (t *Tracer) Start(ctx context.Context) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.started {
+		return fmt.Errorf("tracer already started")
+	}
+	t.started = true
+
+	t.wg.Add(1)
+	go t.run()
+	return nil
+}
+
+// Close stops accepting spans and exports the ones already queued
+func (t *Tracer) Close() error {
+	t.mu.Lock()
+	if t.closed {
+		t.mu.Unlock()
+		return nil
+	}
+	t.closed = true
+	close(t.stopChan)
+	started := t.started
+	t.mu.Unlock()
+
+	if started {
+		t.wg.Wait()
+	}
+	return nil
+}
+
+// run exports a batch every flush interval, or as soon as one fills, until
+// the tracer is closed; then it exports what is left
+func (t *Tracer) run() {
+	defer t.wg.Done()
+
+	ticker := time.NewTicker(t.config.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]*Span, 0, t.config.BatchSize)
+	flush := func() {
+		if len(batch) > 0 {
+			t.export(batch)
+			batch = batch[:0]
+		}
+	}
+
+	for {
+		select {
+		case span := <-t.queue:
+			batch = append(batch, span)
+			if len(batch) >= t.config.BatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-t.stopChan:
+			for {
+				select {
+				case span := <-t.queue:
+					batch = append(batch, span)
+					if len(batch) >= t.config.BatchSize {
+						flush()
+					}
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+// export sends a batch to the collector, counting the spans lost on failure
+func (t *Tracer) export(batch []*Span) {
+	ctx, cancel := context.WithTimeout(context.Background(), t.config.Timeout)
+	defer cancel()
+
+	// Only changes are logged, so a collector that is down does not flood the log
+	if err := t.post(ctx, batch); err != nil {
+		t.failed.Add(int64(len(batch)))
+		if !t.failing {
+			log.Printf("Failed to export spans, dropping them until the collector recovers: %v", err)
+		}
+		t.failing = true
+		return
+	}
+	t.exported.Add(int64(len(batch)))
+	if t.failing {
+		log.Printf("Exporting spans again")
+	}
+	t.failing = false
+}
+
+// Exported returns the number of spans the collector accepted
+func (t *Tracer) Exported() int64 {
+	return t.exported.Load()
+}
+
+// Dropped returns the number of spans dropped because the queue was full
+func (t *Tracer) Dropped() int64 {
+	return t.dropped.Load()
+}
+
+// Failed returns the number of spans lost to failed export requests
+func (t *Tracer) Failed() int64 {
+	return t.failed.Load()
+}
+
+// newTraceID returns a random trace ID
+func newTraceID() TraceID {
+	var id TraceID
+	for !id.IsValid() {
+		rand.Read(id[:])
+	}
+	return id
+}
+
+// newSpanID returns a random span ID
+func newSpanID() SpanID {
+	var id SpanID
+	for !id.IsValid() {
+		rand.Read(id[:])
+	}
+	return id
+}
@@ -0,0 +1,150 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testTracer returns a tracer whose spans stay in its queue
+func testTracer(t *testing.T) *Tracer {
+	config := DefaultConfig()
+	config.Exporter = ExporterOTLP
+	tracer, err := New(config, "test")
+	require.NoError(t, err)
+	return tracer
+}
+
+// ended returns the spans ended so far, in the order they ended
+func ended(tracer *Tracer) []*Span {
+	var spans []*Span
+	for len(tracer.queue) > 0 {
+		spans = append(spans, <-tracer.queue)
+	}
+	return spans
+}
+
+func TestTracer_SpanTree(t *testing.T) {
+	tracer := testTracer(t)
+
+	ctx, root := tracer.StartRequest(context.Background(), "GET /", "", String("http.request.method", "GET"))
+	require.NotNil(t, root)
+	assert.Equal(t, root, FromContext(ctx))
+
+	childCtx, child := Start(ctx, "service.GetOriginalURL", String("short_code", "abc123"))
+	_, grandchild := Start(childCtx, "db.GetURL")
+	grandchild.RecordError(errors.New("database is locked"))
+	grandchild.End()
+	child.RecordError(nil)
+	child.End()
+	root.SetAttributes(Int("http.response.status_code", 302))
+	root.End()
+	root.End()
+
+	spans := ended(tracer)
+	require.Len(t, spans, 3)
+	assert.Equal(t, "db.GetURL", spans[0].name)
+	assert.Equal(t, child.spanID, spans[0].parentID)
+	assert.True(t, spans[0].failed)
+	assert.Equal(t, "database is locked", spans[0].message)
+	assert.Equal(t, root.spanID, spans[1].parentID)
+	assert.False(t, spans[1].failed)
+	assert.False(t, spans[2].parentID.IsValid(), "a new trace's root has no parent")
+	assert.Equal(t, KindServer, spans[2].kind)
+	assert.Equal(t, KindInternal, spans[1].kind)
+	for _, span := range spans {
+		assert.Equal(t, root.traceID, span.traceID)
+		assert.False(t, span.end.Before(span.start))
+	}
+	assert.Contains(t, spans[2].attrs, Int("http.response.status_code", 302))
+}
+
+func TestTracer_TraceParent(t *testing.T) {
+	tracer := testTracer(t)
+
+	// A sampled parent is continued
+	_, span := tracer.StartRequest(context.Background(), "GET /", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	require.NotNil(t, span)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", span.parentID.String())
+	assert.Regexp(t, `^00-4bf92f3577b34da6a3ce929d0e0e4736-[0-9a-f]{16}-01$`, span.TraceParent())
+
+	// An unsampled parent is not recorded, whatever the ratio
+	ctx, span := tracer.StartRequest(context.Background(), "GET /", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00")
+	assert.Nil(t, span)
+	assert.Nil(t, FromContext(ctx))
+
+	// Invalid headers start a new trace
+	for _, header := range []string{
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		"garbage",
+	} {
+		_, span := tracer.StartRequest(context.Background(), "GET /", header)
+		require.NotNil(t, span, header)
+		assert.NotEqual(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.TraceID().String(), header)
+		assert.False(t, span.parentID.IsValid(), header)
+	}
+
+	// Later versions may add fields
+	_, span = tracer.StartRequest(context.Background(), "GET /", "01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-more")
+	require.NotNil(t, span)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.TraceID().String())
+}
+
+func TestTracer_Sampling(t *testing.T) {
+	tracer := testTracer(t)
+	tracer.config.SampleRatio = 0
+	_, span := tracer.StartRequest(context.Background(), "GET /", "")
+	assert.Nil(t, span)
+
+	tracer.config.SampleRatio = 0.25
+	sampled := 0
+	for i := 0; i < 4000; i++ {
+		if _, span := tracer.StartRequest(context.Background(), "GET /", ""); span != nil {
+			sampled++
+		}
+	}
+	assert.InDelta(t, 1000, sampled, 150)
+}
+
+func TestTracer_Disabled(t *testing.T) {
+	tracer, err := New(DefaultConfig(), "test")
+	require.NoError(t, err)
+	assert.Nil(t, tracer)
+
+	// A nil tracer and nil spans record nothing
+	ctx, span := tracer.StartRequest(context.Background(), "GET /", "")
+	assert.Nil(t, span)
+	ctx, span = Start(ctx, "service.GetOriginalURL")
+	assert.Nil(t, span)
+	assert.Nil(t, FromContext(ctx))
+	span.SetAttributes(String("short_code", "abc123"))
+	span.RecordError(errors.New("boom"))
+	span.SetFailed("boom")
+	span.End()
+	assert.Empty(t, span.TraceParent())
+	assert.False(t, span.TraceID().IsValid())
+}
+
+func TestTracer_QueueFull(t *testing.T) {
+	tracer := testTracer(t)
+	tracer.queue = make(chan *Span, 1)
+
+	_, first := tracer.StartRequest(context.Background(), "GET /", "")
+	_, second := tracer.StartRequest(context.Background(), "GET /", "")
+	first.End()
+	second.End()
+	assert.Equal(t, int64(1), tracer.Dropped())
+
+	require.NoError(t, tracer.Close())
+	_, third := tracer.StartRequest(context.Background(), "GET /", "")
+	third.End()
+	assert.Equal(t, int64(2), tracer.Dropped(), "spans ended after close are dropped")
+}
@@ -0,0 +1,231 @@
+package transfer
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+)
+
+// Format identifies a dump file format
+type Format string
+
+// Format constants
+const (
+	FormatJSON Format = "json"
+	FormatCSV  Format = "csv"
+)
+
+// csvHeader is the column layout for CSV dumps
+var csvHeader = []string{"short_code", "original_url", "created_at", "last_used_at", "usage_count", "max_uses"}
+
+// ParseFormat validates a format name
+func ParseFormat(name string) (Format, error) {
+	switch Format(strings.ToLower(name)) {
+	case FormatJSON:
+		return FormatJSON, nil
+	case FormatCSV:
+		return FormatCSV, nil
+	default:
+		return "", fmt.Errorf("unsupported format %q: must be json or csv", name)
+	}
+}
+
+// FormatFromPath infers the format from a file extension, defaulting to JSON
+func FormatFromPath(path string) Format {
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		return FormatCSV
+	}
+	return FormatJSON
+}
+
+// Encode writes URL entries, including usage stats, in the given format
+func Encode(w io.Writer, entries []*domain.URLEntry, format Format) error {
+	switch format {
+	case FormatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(entries); err != nil {
+			return fmt.Errorf("failed to encode JSON: %w", err)
+		}
+		return nil
+	case FormatCSV:
+		return encodeCSV(w, entries)
+	default:
+		return fmt.Errorf("unsupported format: %s", format)
+	}
+}
+
+// Decode reads URL entries in the given format
+func Decode(r io.Reader, format Format) ([]*domain.URLEntry, error) {
+	switch format {
+	case FormatJSON:
+		var entries []*domain.URLEntry
+		if err := json.NewDecoder(r).Decode(&entries); err != nil {
+			return nil, fmt.Errorf("failed to decode JSON: %w", err)
+		}
+		for i, entry := range entries {
+			if err := validateEntry(entry); err != nil {
+				return nil, fmt.Errorf("invalid entry %d: %w", i, err)
+			}
+		}
+		return entries, nil
+	case FormatCSV:
+		return decodeCSV(r)
+	default:
+		return nil, fmt.Errorf("unsupported format: %s", format)
+	}
+}
+
+// encodeCSV writes entries as CSV with a header row
+func encodeCSV(w io.Writer, entries []*domain.URLEntry) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(csvHeader); err != nil {
+		return fmt.Errorf("failed to write CSV header: %w", err)
+	}
+
+	for _, entry := range entries {
+		lastUsedAt := ""
+		if entry.LastUsedAt != nil {
+			lastUsedAt = entry.LastUsedAt.Format(time.RFC3339Nano)
+		}
+
+		if err := writer.Write([]string{
+			entry.ShortCode,
+			entry.OriginalURL,
+			entry.CreatedAt.Format(time.RFC3339Nano),
+			lastUsedAt,
+			strconv.Itoa(entry.UsageCount),
+			strconv.Itoa(entry.MaxUses),
+		}); err != nil {
+			return fmt.Errorf("failed to write CSV row: %w", err)
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// EncodeCodeChanges writes a short code migration's mapping from old to new
+// codes in the given format
+func EncodeCodeChanges(w io.Writer, changes []domain.CodeChange, format Format) error {
+	switch format {
+	case FormatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(changes); err != nil {
+			return fmt.Errorf("failed to encode JSON: %w", err)
+		}
+		return nil
+	case FormatCSV:
+		writer := csv.NewWriter(w)
+		if err := writer.Write([]string{"old_code", "new_code"}); err != nil {
+			return fmt.Errorf("failed to write CSV header: %w", err)
+		}
+		for _, change := range changes {
+			if err := writer.Write([]string{change.OldCode, change.NewCode}); err != nil {
+				return fmt.Errorf("failed to write CSV row: %w", err)
+			}
+		}
+		writer.Flush()
+		return writer.Error()
+	default:
+		return fmt.Errorf("unsupported format: %s", format)
+	}
+}
+
+// decodeCSV reads entries from CSV with a header row
+func decodeCSV(r io.Reader) ([]*domain.URLEntry, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = len(csvHeader)
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+	for i, column := range csvHeader {
+		if header[i] != column {
+			return nil, fmt.Errorf("unexpected CSV column %q, want %q", header[i], column)
+		}
+	}
+
+	var entries []*domain.URLEntry
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CSV line %d: %w", line, err)
+		}
+
+		entry, err := parseRecord(record)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV line %d: %w", line, err)
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// parseRecord converts a CSV record into a URL entry
+func parseRecord(record []string) (*domain.URLEntry, error) {
+	createdAt, err := time.Parse(time.RFC3339Nano, record[2])
+	if err != nil {
+		return nil, fmt.Errorf("invalid created_at: %w", err)
+	}
+
+	entry := &domain.URLEntry{
+		ShortCode:   record[0],
+		OriginalURL: record[1],
+		CreatedAt:   createdAt,
+	}
+
+	if record[3] != "" {
+		lastUsedAt, err := time.Parse(time.RFC3339Nano, record[3])
+		if err != nil {
+			return nil, fmt.Errorf("invalid last_used_at: %w", err)
+		}
+		entry.LastUsedAt = &lastUsedAt
+	}
+
+	if entry.UsageCount, err = strconv.Atoi(record[4]); err != nil {
+		return nil, fmt.Errorf("invalid usage_count: %w", err)
+	}
+
+	if entry.MaxUses, err = strconv.Atoi(record[5]); err != nil {
+		return nil, fmt.Errorf("invalid max_uses: %w", err)
+	}
+
+	return entry, validateEntry(entry)
+}
+
+// validateEntry checks the fields required to restore an entry
+func validateEntry(entry *domain.URLEntry) error {
+	if entry == nil {
+		return fmt.Errorf("entry cannot be null")
+	}
+	if entry.ShortCode == "" {
+		return fmt.Errorf("short code cannot be empty")
+	}
+	if entry.OriginalURL == "" {
+		return fmt.Errorf("original URL cannot be empty for %s", entry.ShortCode)
+	}
+	if entry.CreatedAt.IsZero() {
+		return fmt.Errorf("created_at cannot be empty for %s", entry.ShortCode)
+	}
+	if entry.UsageCount < 0 {
+		return fmt.Errorf("usage count cannot be negative for %s", entry.ShortCode)
+	}
+	if entry.MaxUses < 0 {
+		return fmt.Errorf("max uses cannot be negative for %s", entry.ShortCode)
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package transfer
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+)
+
+func sampleEntries() []*domain.URLEntry {
+	lastUsed := time.Date(2024, 3, 2, 10, 30, 0, 0, time.UTC)
+	return []*domain.URLEntry{
+		{
+			ShortCode:   "abc123",
+			OriginalURL: "https://example.com/a,b",
+			CreatedAt:   time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			LastUsedAt:  &lastUsed,
+			UsageCount:  42,
+			MaxUses:     100,
+		},
+		{
+			ShortCode:   "def456",
+			OriginalURL: "https://example.org",
+			CreatedAt:   time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+		},
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	format, err := ParseFormat("JSON")
+	require.NoError(t, err)
+	assert.Equal(t, FormatJSON, format)
+
+	format, err = ParseFormat("csv")
+	require.NoError(t, err)
+	assert.Equal(t, FormatCSV, format)
+
+	_, err = ParseFormat("xml")
+	assert.Error(t, err)
+}
+
+func TestFormatFromPath(t *testing.T) {
+	assert.Equal(t, FormatCSV, FormatFromPath("dump.CSV"))
+	assert.Equal(t, FormatJSON, FormatFromPath("dump.json"))
+	assert.Equal(t, FormatJSON, FormatFromPath("dump"))
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, format := range []Format{FormatJSON, FormatCSV} {
+		t.Run(string(format), func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, Encode(&buf, sampleEntries(), format))
+
+			decoded, err := Decode(&buf, format)
+			require.NoError(t, err)
+			require.Len(t, decoded, 2)
+
+			expected := sampleEntries()
+			for i := range expected {
+				assert.Equal(t, expected[i].ShortCode, decoded[i].ShortCode)
+				assert.Equal(t, expected[i].OriginalURL, decoded[i].OriginalURL)
+				assert.True(t, expected[i].CreatedAt.Equal(decoded[i].CreatedAt))
+				assert.Equal(t, expected[i].UsageCount, decoded[i].UsageCount)
+				assert.Equal(t, expected[i].MaxUses, decoded[i].MaxUses)
+				if expected[i].LastUsedAt == nil {
+					assert.Nil(t, decoded[i].LastUsedAt)
+				} else {
+					require.NotNil(t, decoded[i].LastUsedAt)
+					assert.True(t, expected[i].LastUsedAt.Equal(*decoded[i].LastUsedAt))
+				}
+			}
+		})
+	}
+}
+
+func TestDecode_Invalid(t *testing.T) {
+	testCases := []struct {
+		name   string
+		format Format
+		input  string
+		errMsg string
+	}{
+		{"malformed JSON", FormatJSON, "{", "failed to decode JSON"},
+		{"JSON missing code", FormatJSON, `[{"original_url":"https://x.com","created_at":"2024-01-01T00:00:00Z"}]`, "short code cannot be empty"},
+		{"CSV wrong header", FormatCSV, "code,url,created,last,count,max\n", "unexpected CSV column"},
+		{"CSV bad date", FormatCSV, "short_code,original_url,created_at,last_used_at,usage_count,max_uses\nabc,https://x.com,yesterday,,0,0\n", "invalid created_at"},
+		{"CSV bad count", FormatCSV, "short_code,original_url,created_at,last_used_at,usage_count,max_uses\nabc,https://x.com,2024-01-01T00:00:00Z,,many,0\n", "invalid usage_count"},
+		{"CSV negative max uses", FormatCSV, "short_code,original_url,created_at,last_used_at,usage_count,max_uses\nabc,https://x.com,2024-01-01T00:00:00Z,,0,-1\n", "max uses cannot be negative"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Decode(strings.NewReader(tc.input), tc.format)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.errMsg)
+		})
+	}
+}
+
+func TestEncodeCodeChanges(t *testing.T) {
+	changes := []domain.CodeChange{{OldCode: "abc1234", NewCode: "Xy7kP2mQ"}}
+
+	var buf bytes.Buffer
+	require.NoError(t, EncodeCodeChanges(&buf, changes, FormatCSV))
+	assert.Equal(t, "old_code,new_code\nabc1234,Xy7kP2mQ\n", buf.String())
+
+	buf.Reset()
+	require.NoError(t, EncodeCodeChanges(&buf, changes, FormatJSON))
+	assert.JSONEq(t, `[{"old_code":"abc1234","new_code":"Xy7kP2mQ"}]`, buf.String())
+}
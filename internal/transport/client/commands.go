@@ -1,37 +1,153 @@
 package client
 
 import (
+	"bufio"
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	"github.com/joshdurbin/url-shortener/internal/version"
+	apiclient "github.com/joshdurbin/url-shortener/pkg/urlshortener/client"
 )
 
 // Commands provides command-line operations for the client
 type Commands struct {
-	client *Client
+	client apiclient.API
+	format OutputFormat
+}
+
+// CommandsOption configures optional command behavior
+type CommandsOption func(*Commands)
+
+// WithOutputFormat prints results as a table (default), JSON or CSV
+func WithOutputFormat(format OutputFormat) CommandsOption {
+	return func(c *Commands) {
+		c.format = format
+	}
 }
 
 // NewCommands creates a new Commands instance
-func NewCommands(client *Client) *Commands {
-	return &Commands{
+func NewCommands(client apiclient.API, opts ...CommandsOption) *Commands {
+	c := &Commands{
 		client: client,
+		format: OutputTable,
+	}
+	for _, opt := range opts {
+		opt(c)
 	}
+	return c
+}
+
+// isNotFound reports whether a request failed because the short code does not exist
+func isNotFound(err error) bool {
+	var notFound *apiclient.NotFoundError
+	return errors.As(err, &notFound)
+}
+
+// notFound reports a missing short code: tables print a message and succeed,
+// while JSON and CSV return the error so scripts do not parse the message
+func (c *Commands) notFound(shortCode string, err error) error {
+	if c.format != OutputTable {
+		return err
+	}
+	fmt.Printf("Short code '%s' not found\n", shortCode)
+	return nil
 }
 
 // Create creates a short URL and displays the result
-func (c *Commands) Create(ctx context.Context, originalURL string) error {
-	result, err := c.client.CreateURL(ctx, originalURL)
+func (c *Commands) Create(ctx context.Context, originalURL string, opts domain.CreateOptions) error {
+	result, err := c.client.CreateURL(ctx, originalURL, opts)
 	if err != nil {
 		return err
 	}
 
+	switch c.format {
+	case OutputJSON:
+		return printJSON(result)
+	case OutputCSV:
+		return printCSV(
+			[]string{"short_code", "short_url", "original_url", "created_at", "max_uses", "tags", "redirect_status", "backup_url", "query_params", "forward_query", "campaign", "dedupe_seconds"},
+			[]string{result.ShortCode, result.ShortURL, result.OriginalURL, result.CreatedAt.Format(time.RFC3339),
+				formatInt(result.MaxUses), strings.Join(result.Tags, ";"), formatInt(result.RedirectStatus), result.BackupURL,
+				formatQueryParams(result.QueryParams), strconv.FormatBool(result.ForwardQuery), result.Campaign, formatInt(result.DedupeSeconds)},
+		)
+	}
+
 	fmt.Printf("Short URL created:\n")
 	fmt.Printf("Short Code: %s\n", result.ShortCode)
 	fmt.Printf("Short URL: %s\n", result.ShortURL)
 	fmt.Printf("Original URL: %s\n", result.OriginalURL)
 	fmt.Printf("Created At: %s\n", result.CreatedAt.Format(time.RFC3339))
+	if result.MaxUses > 0 {
+		fmt.Printf("Max Uses: %d\n", result.MaxUses)
+	}
+	if result.BurnAfterReading {
+		fmt.Printf("Burn After Reading: yes\n")
+	}
+	if len(result.Tags) > 0 {
+		fmt.Printf("Tags: %s\n", strings.Join(result.Tags, ", "))
+	}
+	if result.RedirectStatus != 0 {
+		fmt.Printf("Redirect Status: %d\n", result.RedirectStatus)
+	}
+	if result.BackupURL != "" {
+		fmt.Printf("Backup URL: %s\n", result.BackupURL)
+	}
+	printQueryOptions(result.QueryParams, result.ForwardQuery)
+	printDedupe(result.DedupeSeconds)
+	if result.Campaign != "" {
+		fmt.Printf("Campaign: %s\n", result.Campaign)
+	}
+	if result.Domain != "" {
+		fmt.Printf("Domain: %s\n", result.Domain)
+	}
+
+	return nil
+}
 
+// Validate checks a URL and create options against the server's rules without
+// creating a link. It fails when there are problems, so scripts can branch on
+// the exit status.
+func (c *Commands) Validate(ctx context.Context, originalURL string, opts domain.CreateOptions) error {
+	result, err := c.client.ValidateURL(ctx, originalURL, opts)
+	if err != nil {
+		return err
+	}
+
+	switch c.format {
+	case OutputJSON:
+		if err := printJSON(result); err != nil {
+			return err
+		}
+	case OutputCSV:
+		records := make([][]string, len(result.Problems))
+		for i, problem := range result.Problems {
+			records[i] = []string{problem.Field, problem.Message}
+		}
+		if err := printCSV([]string{"field", "message"}, records...); err != nil {
+			return err
+		}
+	default:
+		if result.Valid {
+			fmt.Printf("Valid: %s\n", originalURL)
+		} else {
+			fmt.Printf("Invalid: %s\n", originalURL)
+			for _, problem := range result.Problems {
+				fmt.Printf("  %s: %s\n", problem.Field, problem.Message)
+			}
+		}
+	}
+
+	if !result.Valid {
+		return fmt.Errorf("validation found %d problem(s)", len(result.Problems))
+	}
 	return nil
 }
 
@@ -39,13 +155,19 @@ func (c *Commands) Create(ctx context.Context, originalURL string) error {
 func (c *Commands) Get(ctx context.Context, shortCode string) error {
 	entry, err := c.client.GetURL(ctx, shortCode)
 	if err != nil {
-		if strings.Contains(err.Error(), "not found") {
-			fmt.Printf("Short code '%s' not found\n", shortCode)
-			return nil
+		if isNotFound(err) {
+			return c.notFound(shortCode, err)
 		}
 		return err
 	}
 
+	switch c.format {
+	case OutputJSON:
+		return printJSON(entry)
+	case OutputCSV:
+		return printEntries([]*domain.URLEntry{entry}, c.format)
+	}
+
 	fmt.Printf("URL Information:\n")
 	fmt.Printf("Short Code: %s\n", entry.ShortCode)
 	fmt.Printf("Original URL: %s\n", entry.OriginalURL)
@@ -55,7 +177,56 @@ func (c *Commands) Get(ctx context.Context, shortCode string) error {
 	} else {
 		fmt.Printf("Last Used At: Never\n")
 	}
-	fmt.Printf("Usage Count: %d\n", entry.UsageCount)
+	if entry.MaxUses > 0 {
+		fmt.Printf("Usage Count: %d / %d\n", entry.UsageCount, entry.MaxUses)
+	} else {
+		fmt.Printf("Usage Count: %d\n", entry.UsageCount)
+	}
+	if entry.BurnAfterReading {
+		fmt.Printf("Burn After Reading: yes\n")
+	}
+	if entry.BotsCount > 0 {
+		fmt.Printf("Bot Clicks: %d\n", entry.BotsCount)
+	}
+	if len(entry.Tags) > 0 {
+		fmt.Printf("Tags: %s\n", strings.Join(entry.Tags, ", "))
+	}
+	if entry.RedirectStatus != 0 {
+		fmt.Printf("Redirect Status: %d\n", entry.RedirectStatus)
+	}
+	if entry.BackupURL != "" {
+		fmt.Printf("Backup URL: %s\n", entry.BackupURL)
+		if entry.FailoverActive {
+			fmt.Printf("Failover: active, redirecting to backup (%s)\n", entry.FailoverReason)
+		} else {
+			fmt.Printf("Failover: inactive, redirecting to primary\n")
+		}
+		if entry.FailoverChangedAt != nil {
+			fmt.Printf("Failover Changed At: %s\n", entry.FailoverChangedAt.Format(time.RFC3339))
+		}
+	}
+	printQueryOptions(entry.QueryParams, entry.ForwardQuery)
+	printDedupe(entry.DedupeSeconds)
+	if entry.Campaign != "" {
+		fmt.Printf("Campaign: %s\n", entry.Campaign)
+	}
+	if entry.Domain != "" {
+		fmt.Printf("Domain: %s\n", entry.Domain)
+	}
+	if entry.Owner != "" {
+		fmt.Printf("Owner: %s\n", entry.Owner)
+	}
+	if preview := entry.Preview; preview != nil {
+		if preview.Title != "" {
+			fmt.Printf("Title: %s\n", preview.Title)
+		}
+		if preview.Description != "" {
+			fmt.Printf("Description: %s\n", preview.Description)
+		}
+		if preview.Error != "" {
+			fmt.Printf("Preview Error: %s\n", preview.Error)
+		}
+	}
 
 	return nil
 }
@@ -64,23 +235,175 @@ func (c *Commands) Get(ctx context.Context, shortCode string) error {
 func (c *Commands) Delete(ctx context.Context, shortCode string) error {
 	err := c.client.DeleteURL(ctx, shortCode)
 	if err != nil {
-		if strings.Contains(err.Error(), "not found") {
-			fmt.Printf("Short code '%s' not found\n", shortCode)
-			return nil
+		if isNotFound(err) {
+			return c.notFound(shortCode, err)
 		}
 		return err
 	}
 
+	switch c.format {
+	case OutputJSON:
+		return printJSON(map[string]any{"short_code": shortCode, "deleted": true})
+	case OutputCSV:
+		return printCSV([]string{"short_code", "deleted"}, []string{shortCode, "true"})
+	}
+
 	fmt.Printf("Short URL '%s' deleted successfully\n", shortCode)
 	return nil
 }
 
+// ReadShortCodes reads one short code per line, skipping blank lines and
+// lines starting with #
+func ReadShortCodes(r io.Reader) ([]string, error) {
+	var shortCodes []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		shortCodes = append(shortCodes, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read short codes: %w", err)
+	}
+	return shortCodes, nil
+}
+
+// DeleteMany deletes short URLs in batches of domain.MaxBulkDelete and
+// displays which were deleted, unknown, owned by another credential or failed
+func (c *Commands) DeleteMany(ctx context.Context, shortCodes []string) error {
+	result, err := c.deleteBatches(ctx, shortCodes)
+	if err != nil {
+		return err
+	}
+	return c.printDeleted(result, len(shortCodes))
+}
+
+// deleteBatches deletes short codes with as few bulk delete requests as
+// possible, merging their results
+func (c *Commands) deleteBatches(ctx context.Context, shortCodes []string) (*domain.BulkDeleteResponse, error) {
+	merged := &domain.BulkDeleteResponse{Deleted: []string{}}
+	for start := 0; start < len(shortCodes); start += domain.MaxBulkDelete {
+		batch := shortCodes[start:min(start+domain.MaxBulkDelete, len(shortCodes))]
+		result, err := c.client.BulkDeleteURLs(ctx, batch)
+		if err != nil {
+			return nil, err
+		}
+		merged.Deleted = append(merged.Deleted, result.Deleted...)
+		merged.NotFound = append(merged.NotFound, result.NotFound...)
+		merged.Forbidden = append(merged.Forbidden, result.Forbidden...)
+		merged.Failed = append(merged.Failed, result.Failed...)
+	}
+	return merged, nil
+}
+
+// printDeleted displays the outcome of deleting requested short codes
+func (c *Commands) printDeleted(result *domain.BulkDeleteResponse, requested int) error {
+	switch c.format {
+	case OutputJSON:
+		return printJSON(result)
+	case OutputCSV:
+		var records [][]string
+		for _, outcome := range []struct {
+			status     string
+			shortCodes []string
+		}{{"deleted", result.Deleted}, {"not_found", result.NotFound}, {"forbidden", result.Forbidden}, {"failed", result.Failed}} {
+			for _, shortCode := range outcome.shortCodes {
+				records = append(records, []string{shortCode, outcome.status})
+			}
+		}
+		return printCSV([]string{"short_code", "status"}, records...)
+	}
+
+	fmt.Printf("Deleted %d of %d short URLs\n", len(result.Deleted), requested)
+	if len(result.NotFound) > 0 {
+		fmt.Printf("Not found: %s\n", strings.Join(result.NotFound, ", "))
+	}
+	if len(result.Forbidden) > 0 {
+		fmt.Printf("Owned by another credential: %s\n", strings.Join(result.Forbidden, ", "))
+	}
+	if len(result.Failed) > 0 {
+		fmt.Printf("Failed: %s\n", strings.Join(result.Failed, ", "))
+	}
+	return nil
+}
+
+// Prune deletes the links matching the request's conditions. A dry run only
+// lists them. Otherwise, when confirm is set, the matching links are listed
+// and only deleted if confirm, given their count, returns true; without
+// confirm they are deleted straight away.
+func (c *Commands) Prune(ctx context.Context, prune domain.PruneRequest, confirm func(matches int) bool) error {
+	if confirm == nil && !prune.DryRun {
+		result, err := c.client.PruneURLs(ctx, prune)
+		if err != nil {
+			return err
+		}
+		return c.printDeleted(&result.Results, len(result.Links))
+	}
+
+	dryRun := prune.DryRun
+	prune.DryRun = true
+	matches, err := c.client.PruneURLs(ctx, prune)
+	if err != nil {
+		return err
+	}
+	if dryRun || len(matches.Links) == 0 {
+		return c.printList(matches.Links)
+	}
+
+	if c.format == OutputTable {
+		if err := c.printList(matches.Links); err != nil {
+			return err
+		}
+	}
+	if !confirm(len(matches.Links)) {
+		if c.format != OutputTable {
+			return c.printDeleted(&domain.BulkDeleteResponse{Deleted: []string{}}, 0)
+		}
+		fmt.Println("Nothing deleted")
+		return nil
+	}
+
+	shortCodes := make([]string, len(matches.Links))
+	for i, entry := range matches.Links {
+		shortCodes[i] = entry.ShortCode
+	}
+	return c.DeleteMany(ctx, shortCodes)
+}
+
 // List displays all short URLs in a table format
 func (c *Commands) List(ctx context.Context) error {
 	entries, err := c.client.ListURLs(ctx)
 	if err != nil {
 		return err
 	}
+	return c.printList(entries)
+}
+
+// ListCampaign displays the short URLs of one campaign in a table format
+func (c *Commands) ListCampaign(ctx context.Context, campaign string) error {
+	entries, err := c.client.ListCampaignURLs(ctx, campaign)
+	if err != nil {
+		return err
+	}
+	return c.printList(entries)
+}
+
+// ListOwner displays the short URLs created by an owner in a table format
+func (c *Commands) ListOwner(ctx context.Context, owner string) error {
+	entries, err := c.client.ListOwnerURLs(ctx, owner)
+	if err != nil {
+		return err
+	}
+	return c.printList(entries)
+}
+
+// printList displays links as a table, or in the export layout for JSON and CSV
+func (c *Commands) printList(entries []*domain.URLEntry) error {
+	if c.format != OutputTable {
+		return printEntries(entries, c.format)
+	}
 
 	if len(entries) == 0 {
 		fmt.Println("No URLs found")
@@ -111,4 +434,704 @@ func (c *Commands) List(ctx context.Context) error {
 	}
 
 	return nil
-}
\ No newline at end of file
+}
+
+// Campaigns displays every campaign with its link count and aggregate clicks
+func (c *Commands) Campaigns(ctx context.Context) error {
+	campaigns, err := c.client.ListCampaigns(ctx)
+	if err != nil {
+		return err
+	}
+
+	switch c.format {
+	case OutputJSON:
+		if campaigns == nil {
+			campaigns = []domain.Campaign{}
+		}
+		return printJSON(campaigns)
+	case OutputCSV:
+		records := make([][]string, len(campaigns))
+		for i, campaign := range campaigns {
+			lastUsed := ""
+			if campaign.LastUsedAt != nil {
+				lastUsed = campaign.LastUsedAt.Format(time.RFC3339)
+			}
+			records[i] = []string{campaign.Name, strconv.Itoa(campaign.Links), strconv.Itoa(campaign.TotalClicks), lastUsed}
+		}
+		return printCSV([]string{"name", "links", "total_clicks", "last_used_at"}, records...)
+	}
+
+	if len(campaigns) == 0 {
+		fmt.Println("No campaigns found")
+		return nil
+	}
+
+	fmt.Printf("%-30s %8s %12s  %s\n", "Campaign", "Links", "Clicks", "Last Used")
+	fmt.Println(strings.Repeat("-", 75))
+	for _, campaign := range campaigns {
+		lastUsed := "Never"
+		if campaign.LastUsedAt != nil {
+			lastUsed = campaign.LastUsedAt.Format("2006-01-02 15:04:05")
+		}
+		fmt.Printf("%-30s %8d %12d  %s\n", campaign.Name, campaign.Links, campaign.TotalClicks, lastUsed)
+	}
+
+	return nil
+}
+
+// statsTopLinks is the number of most-used links and top referrers shown by Stats
+const statsTopLinks = 5
+
+// LinkStats summarizes all links for the stats command
+type LinkStats struct {
+	Links             int                     `json:"links"`
+	TotalClicks       int                     `json:"total_clicks"`
+	NeverUsed         int                     `json:"never_used"`
+	UsageLimitReached int                     `json:"usage_limit_reached"`
+	FailoverActive    int                     `json:"failover_active"`
+	MostUsed          []*domain.URLEntry      `json:"most_used"`
+	TopReferrers      []domain.ReferrerClicks `json:"top_referrers,omitempty"`
+	TopCountries      []domain.CountryClicks  `json:"top_countries,omitempty"`
+}
+
+// Stats displays totals across all short URLs and the most used links
+func (c *Commands) Stats(ctx context.Context) error {
+	entries, err := c.client.ListURLs(ctx)
+	if err != nil {
+		return err
+	}
+
+	stats := LinkStats{Links: len(entries), MostUsed: []*domain.URLEntry{}}
+	for _, entry := range entries {
+		stats.TotalClicks += entry.UsageCount
+		if entry.MaxUses > 0 && entry.UsageCount >= entry.MaxUses {
+			stats.UsageLimitReached++
+		}
+		if entry.FailoverActive {
+			stats.FailoverActive++
+		}
+		if entry.UsageCount == 0 {
+			stats.NeverUsed++
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].UsageCount > entries[j].UsageCount })
+	for _, entry := range entries[:min(statsTopLinks, len(entries))] {
+		if entry.UsageCount == 0 {
+			break
+		}
+		stats.MostUsed = append(stats.MostUsed, entry)
+	}
+
+	// Servers without click analytics, and keys that cannot read them, show no
+	// referrers or countries
+	topReferrers, err := c.client.GetTopReferrers(ctx, statsTopLinks)
+	if err != nil && !unavailableReport(err) {
+		return err
+	}
+	if err == nil {
+		stats.TopReferrers = topReferrers.Referrers
+	}
+	topCountries, err := c.client.GetTopCountries(ctx, statsTopLinks)
+	if err != nil && !unavailableReport(err) {
+		return err
+	}
+	if err == nil {
+		stats.TopCountries = topCountries.Countries
+	}
+
+	switch c.format {
+	case OutputJSON:
+		return printJSON(stats)
+	case OutputCSV:
+		return printCSV(
+			[]string{"links", "total_clicks", "never_used", "usage_limit_reached", "failover_active"},
+			[]string{strconv.Itoa(stats.Links), strconv.Itoa(stats.TotalClicks), strconv.Itoa(stats.NeverUsed),
+				strconv.Itoa(stats.UsageLimitReached), strconv.Itoa(stats.FailoverActive)},
+		)
+	}
+
+	fmt.Printf("Links: %d\n", stats.Links)
+	fmt.Printf("Total Clicks: %d\n", stats.TotalClicks)
+	fmt.Printf("Never Used: %d\n", stats.NeverUsed)
+	fmt.Printf("Usage Limit Reached: %d\n", stats.UsageLimitReached)
+	fmt.Printf("Failover Active: %d\n", stats.FailoverActive)
+
+	if len(stats.MostUsed) > 0 {
+		fmt.Printf("\nMost Used:\n")
+		for _, entry := range stats.MostUsed {
+			fmt.Printf("%-15s %8d  %s\n", entry.ShortCode, entry.UsageCount, entry.OriginalURL)
+		}
+	}
+	if len(stats.TopReferrers) > 0 {
+		fmt.Printf("\nTop Referrers:\n")
+		for _, referrer := range stats.TopReferrers {
+			fmt.Printf("%-40s %8d  (%d links)\n", referrer.Referrer, referrer.Clicks, referrer.Links)
+		}
+	}
+	if len(stats.TopCountries) > 0 {
+		fmt.Printf("\nTop Countries:\n")
+		for _, country := range stats.TopCountries {
+			fmt.Printf("%-40s %8d  (%d links)\n", country.Country, country.Clicks, country.Links)
+		}
+	}
+
+	return nil
+}
+
+// statsDays is how many days of clicks stats SHORT_CODE charts
+const statsDays = 7
+
+// sparkTicks are the bar heights of a sparkline, lowest first
+var sparkTicks = []rune("▁▂▃▄▅▆▇█")
+
+// CodeStats is one link's usage, recent daily clicks, referrers and countries
+type CodeStats struct {
+	ShortCode    string                    `json:"short_code"`
+	OriginalURL  string                    `json:"original_url"`
+	UsageCount   int                       `json:"usage_count"`
+	MaxUses      int                       `json:"max_uses,omitempty"`
+	LastUsedAt   *time.Time                `json:"last_used_at,omitempty"`
+	Daily        []domain.TimeSeriesBucket `json:"daily,omitempty"` // UTC days, oldest first
+	TopReferrers []domain.ReferrerClicks   `json:"top_referrers,omitempty"`
+	TopCountries []domain.CountryClicks    `json:"top_countries,omitempty"`
+}
+
+// CodeStats displays a short URL's usage count, its clicks per day over the
+// last week as a sparkline, and its top referrers and countries, up to limit
+// rows each (0 = server default)
+func (c *Commands) CodeStats(ctx context.Context, shortCode string, limit int) error {
+	entry, err := c.client.GetURL(ctx, shortCode)
+	if err != nil {
+		if isNotFound(err) {
+			return c.notFound(shortCode, err)
+		}
+		return err
+	}
+	stats := CodeStats{
+		ShortCode:   entry.ShortCode,
+		OriginalURL: entry.OriginalURL,
+		UsageCount:  entry.UsageCount,
+		MaxUses:     entry.MaxUses,
+		LastUsedAt:  entry.LastUsedAt,
+	}
+
+	// Servers without click analytics, and keys that cannot read them, show
+	// only the usage count
+	now := time.Now().UTC()
+	from := now.Truncate(24*time.Hour).AddDate(0, 0, 1-statsDays)
+	series, err := c.client.GetTimeSeries(ctx, shortCode, domain.IntervalDay, from, now)
+	if err != nil && !unavailableReport(err) {
+		return err
+	}
+	if err == nil {
+		stats.Daily = series.Buckets
+	}
+	referrers, err := c.client.GetReferrers(ctx, shortCode, limit)
+	if err != nil && !unavailableReport(err) {
+		return err
+	}
+	if err == nil {
+		stats.TopReferrers = referrers.Referrers
+	}
+	countries, err := c.client.GetCountries(ctx, shortCode, limit)
+	if err != nil && !unavailableReport(err) {
+		return err
+	}
+	if err == nil {
+		stats.TopCountries = countries.Countries
+	}
+
+	switch c.format {
+	case OutputJSON:
+		return printJSON(stats)
+	case OutputCSV:
+		weekClicks := 0
+		for _, bucket := range stats.Daily {
+			weekClicks += bucket.Clicks
+		}
+		topReferrer, topCountry := "", ""
+		if len(stats.TopReferrers) > 0 {
+			topReferrer = stats.TopReferrers[0].Referrer
+		}
+		if len(stats.TopCountries) > 0 {
+			topCountry = stats.TopCountries[0].Country
+		}
+		return printCSV(
+			[]string{"short_code", "usage_count", "clicks_last_7_days", "top_referrer", "top_country"},
+			[]string{stats.ShortCode, strconv.Itoa(stats.UsageCount), strconv.Itoa(weekClicks), topReferrer, topCountry},
+		)
+	}
+
+	fmt.Printf("Short Code: %s\n", stats.ShortCode)
+	fmt.Printf("Original URL: %s\n", stats.OriginalURL)
+	if stats.MaxUses > 0 {
+		fmt.Printf("Usage Count: %d / %d\n", stats.UsageCount, stats.MaxUses)
+	} else {
+		fmt.Printf("Usage Count: %d\n", stats.UsageCount)
+	}
+	if stats.LastUsedAt != nil && !stats.LastUsedAt.IsZero() {
+		fmt.Printf("Last Used: %s\n", stats.LastUsedAt.Local().Format("2006-01-02 15:04:05"))
+	}
+
+	if len(stats.Daily) > 0 {
+		weekClicks := 0
+		for _, bucket := range stats.Daily {
+			weekClicks += bucket.Clicks
+		}
+		first, last := stats.Daily[0].Start, stats.Daily[len(stats.Daily)-1].Start
+		fmt.Printf("\nLast %d Days: %s  %d clicks (%s to %s)\n", statsDays, sparkline(stats.Daily), weekClicks,
+			first.UTC().Format("Jan 2"), last.UTC().Format("Jan 2"))
+	}
+	if len(stats.TopReferrers) > 0 {
+		fmt.Printf("\nTop Referrers:\n")
+		for _, referrer := range stats.TopReferrers {
+			fmt.Printf("%-40s %8d\n", referrer.Referrer, referrer.Clicks)
+		}
+	}
+	if len(stats.TopCountries) > 0 {
+		fmt.Printf("\nTop Countries:\n")
+		for _, country := range stats.TopCountries {
+			name := country.Country
+			if country.Region != "" {
+				name += "/" + country.Region
+			}
+			fmt.Printf("%-40s %8d\n", name, country.Clicks)
+		}
+	}
+
+	return nil
+}
+
+// sparkline draws one bar per bucket, scaled to the busiest one; buckets
+// without clicks get the lowest bar
+func sparkline(buckets []domain.TimeSeriesBucket) string {
+	busiest := 0
+	for _, bucket := range buckets {
+		busiest = max(busiest, bucket.Clicks)
+	}
+	line := make([]rune, len(buckets))
+	for i, bucket := range buckets {
+		tick := 0
+		if busiest > 0 {
+			tick = bucket.Clicks * (len(sparkTicks) - 1) / busiest
+		}
+		line[i] = sparkTicks[tick]
+	}
+	return string(line)
+}
+
+// unavailableReport reports whether an analytics request failed because the
+// server has no click analytics or the key may not read them
+func unavailableReport(err error) bool {
+	var apiErr *apiclient.APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	switch apiErr.StatusCode {
+	case http.StatusForbidden, http.StatusNotFound, http.StatusNotImplemented:
+		return true
+	}
+	return false
+}
+
+// Referrers displays a short URL's clicks by referring domain and by UTM
+// parameters, up to limit rows each (0 = server default)
+func (c *Commands) Referrers(ctx context.Context, shortCode string, limit int) error {
+	report, err := c.client.GetReferrers(ctx, shortCode, limit)
+	if err != nil {
+		if isNotFound(err) {
+			return c.notFound(shortCode, err)
+		}
+		return err
+	}
+
+	switch c.format {
+	case OutputJSON:
+		return printJSON(report)
+	case OutputCSV:
+		records := make([][]string, 0, len(report.Referrers)+len(report.UTM))
+		for _, referrer := range report.Referrers {
+			records = append(records, []string{referrer.Referrer, "", "", "", strconv.Itoa(referrer.Clicks)})
+		}
+		for _, utm := range report.UTM {
+			records = append(records, []string{"", utm.Source, utm.Medium, utm.Campaign, strconv.Itoa(utm.Clicks)})
+		}
+		return printCSV([]string{"referrer", "utm_source", "utm_medium", "utm_campaign", "clicks"}, records...)
+	}
+
+	if len(report.Referrers) == 0 {
+		fmt.Printf("No clicks recorded for %s\n", shortCode)
+		return nil
+	}
+
+	fmt.Printf("Referrers for %s:\n", shortCode)
+	for _, referrer := range report.Referrers {
+		fmt.Printf("%-40s %8d\n", referrer.Referrer, referrer.Clicks)
+	}
+	if len(report.UTM) == 0 {
+		return nil
+	}
+
+	fmt.Printf("\n%-20s %-20s %-25s %8s\n", "UTM Source", "UTM Medium", "UTM Campaign", "Clicks")
+	fmt.Println(strings.Repeat("-", 76))
+	for _, utm := range report.UTM {
+		fmt.Printf("%-20s %-20s %-25s %8d\n", utm.Source, utm.Medium, utm.Campaign, utm.Clicks)
+	}
+
+	return nil
+}
+
+// ShareToken issues a read-only share token for a short URL and displays it
+func (c *Commands) ShareToken(ctx context.Context, shortCode string, ttl time.Duration) error {
+	result, err := c.client.CreateShareToken(ctx, shortCode, ttl)
+	if err != nil {
+		if isNotFound(err) {
+			return c.notFound(shortCode, err)
+		}
+		return err
+	}
+
+	switch c.format {
+	case OutputJSON:
+		return printJSON(result)
+	case OutputCSV:
+		return printCSV(
+			[]string{"short_code", "token", "expires_at", "info_url"},
+			[]string{result.ShortCode, result.Token, result.ExpiresAt.Format(time.RFC3339), result.InfoURL},
+		)
+	}
+
+	fmt.Printf("Share token created:\n")
+	fmt.Printf("Short Code: %s\n", result.ShortCode)
+	fmt.Printf("Token: %s\n", result.Token)
+	fmt.Printf("Expires At: %s\n", result.ExpiresAt.Format(time.RFC3339))
+	fmt.Printf("Info URL: %s\n", result.InfoURL)
+
+	return nil
+}
+
+// Version displays the client build and the server's build and configuration
+func (c *Commands) Version(ctx context.Context) error {
+	if c.format != OutputTable {
+		info, err := c.client.GetVersion(ctx)
+		if err != nil {
+			return err
+		}
+		if c.format == OutputJSON {
+			return printJSON(map[string]any{"client_version": version.String(), "server": info})
+		}
+		return printCSV(
+			[]string{"client_version", "server_version", "commit", "build_date", "go_version", "storage", "cache", "generator", "features"},
+			[]string{version.String(), info.Version, info.Commit, info.BuildDate, info.GoVersion, info.Storage, info.Cache, info.Generator, strings.Join(info.Features, ";")},
+		)
+	}
+
+	fmt.Printf("Client Version: %s\n", version.String())
+
+	info, err := c.client.GetVersion(ctx)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Server Version: %s (commit %s, built %s, %s)\n", info.Version, info.Commit, info.BuildDate, info.GoVersion)
+	fmt.Printf("Storage: %s\n", info.Storage)
+	fmt.Printf("Cache: %s\n", info.Cache)
+	fmt.Printf("Generator: %s\n", info.Generator)
+	if len(info.Features) > 0 {
+		fmt.Printf("Features: %s\n", strings.Join(info.Features, ", "))
+	}
+
+	return nil
+}
+
+// Countries displays a short URL's clicks by visitor country and region, up to
+// limit rows (0 = server default)
+func (c *Commands) Countries(ctx context.Context, shortCode string, limit int) error {
+	report, err := c.client.GetCountries(ctx, shortCode, limit)
+	if err != nil {
+		if isNotFound(err) {
+			return c.notFound(shortCode, err)
+		}
+		return err
+	}
+
+	switch c.format {
+	case OutputJSON:
+		return printJSON(report)
+	case OutputCSV:
+		records := make([][]string, 0, len(report.Countries))
+		for _, country := range report.Countries {
+			records = append(records, []string{country.Country, country.Region, strconv.Itoa(country.Clicks)})
+		}
+		return printCSV([]string{"country", "region", "clicks"}, records...)
+	}
+
+	if len(report.Countries) == 0 {
+		fmt.Printf("No clicks from known countries recorded for %s\n", shortCode)
+		return nil
+	}
+
+	fmt.Printf("%-10s %-10s %8s\n", "Country", "Region", "Clicks")
+	fmt.Println(strings.Repeat("-", 30))
+	for _, country := range report.Countries {
+		fmt.Printf("%-10s %-10s %8d\n", country.Country, country.Region, country.Clicks)
+	}
+
+	return nil
+}
+
+// TimeSeries displays a link's clicks per interval from from until to, with a
+// bar per bucket scaled to the busiest one
+func (c *Commands) TimeSeries(ctx context.Context, shortCode string, interval domain.TimeSeriesInterval, from, to time.Time) error {
+	series, err := c.client.GetTimeSeries(ctx, shortCode, interval, from, to)
+	if err != nil {
+		if isNotFound(err) {
+			return c.notFound(shortCode, err)
+		}
+		return err
+	}
+
+	switch c.format {
+	case OutputJSON:
+		return printJSON(series)
+	case OutputCSV:
+		records := make([][]string, 0, len(series.Buckets))
+		for _, bucket := range series.Buckets {
+			records = append(records, []string{bucket.Start.Format(time.RFC3339), strconv.Itoa(bucket.Clicks)})
+		}
+		return printCSV([]string{"start", "clicks"}, records...)
+	}
+
+	// Day buckets are UTC days, so they are shown as such
+	layout, location := "2006-01-02 15:04", time.Local
+	if series.Interval == domain.IntervalDay {
+		layout, location = "2006-01-02", time.UTC
+	}
+	fmt.Printf("Clicks on %s per %s: %d in total\n\n", shortCode, series.Interval, series.Total)
+
+	busiest := 0
+	for _, bucket := range series.Buckets {
+		busiest = max(busiest, bucket.Clicks)
+	}
+	for _, bucket := range series.Buckets {
+		bar := ""
+		if busiest > 0 {
+			bar = strings.Repeat("#", bucket.Clicks*40/busiest)
+		}
+		fmt.Printf("%-16s %8d  %s\n", bucket.Start.In(location).Format(layout), bucket.Clicks, bar)
+	}
+
+	return nil
+}
+
+// Top displays the links with the most clicks over the last window, or with
+// the largest gain over the window before when trending
+func (c *Commands) Top(ctx context.Context, window time.Duration, limit int, trending bool) error {
+	top, err := c.client.GetTopLinks(ctx, window, limit, trending)
+	if err != nil {
+		return err
+	}
+
+	switch c.format {
+	case OutputJSON:
+		return printJSON(top)
+	case OutputCSV:
+		records := make([][]string, 0, len(top.Links))
+		for _, link := range top.Links {
+			records = append(records, []string{link.ShortCode, strconv.Itoa(link.Clicks), strconv.Itoa(link.PreviousClicks), link.OriginalURL})
+		}
+		return printCSV([]string{"short_code", "clicks", "previous_clicks", "original_url"}, records...)
+	}
+
+	if len(top.Links) == 0 {
+		fmt.Printf("No clicks recorded in the last %s\n", top.Window)
+		return nil
+	}
+
+	fmt.Printf("Top links in the last %s (since %s, by %s)\n\n", top.Window, top.Since.Local().Format("2006-01-02 15:04"), top.Sort)
+	fmt.Printf("%-15s %8s %8s  %s\n", "Short Code", "Clicks", "Previous", "Original URL")
+	fmt.Println(strings.Repeat("-", 85))
+	for _, link := range top.Links {
+		originalURL := link.OriginalURL
+		if len(originalURL) > 50 {
+			originalURL = originalURL[:47] + "..."
+		}
+		fmt.Printf("%-15s %8d %8d  %s\n", link.ShortCode, link.Clicks, link.PreviousClicks, originalURL)
+	}
+
+	return nil
+}
+
+// CacheStats displays the server's link cache size, unsynced entries and hit ratio
+func (c *Commands) CacheStats(ctx context.Context) error {
+	stats, err := c.client.GetCacheStats(ctx)
+	if err != nil {
+		return err
+	}
+
+	lastSync := ""
+	if stats.LastSyncAt != nil {
+		lastSync = stats.LastSyncAt.Format(time.RFC3339)
+	}
+	switch c.format {
+	case OutputJSON:
+		return printJSON(stats)
+	case OutputCSV:
+		return printCSV(
+			[]string{"entries", "expired", "dirty", "pending_usage", "hits", "misses", "hit_ratio", "last_sync_at"},
+			[]string{
+				strconv.Itoa(stats.Entries), strconv.Itoa(stats.Expired), strconv.Itoa(stats.Dirty), strconv.Itoa(stats.PendingUsage),
+				strconv.FormatUint(stats.Hits, 10), strconv.FormatUint(stats.Misses, 10), strconv.FormatFloat(stats.HitRatio, 'f', 4, 64), lastSync,
+			},
+		)
+	}
+
+	fmt.Printf("Cache Entries: %d (%d expired)\n", stats.Entries, stats.Expired)
+	fmt.Printf("Unsynced Entries: %d (%d clicks pending)\n", stats.Dirty, stats.PendingUsage)
+	fmt.Printf("Hit Ratio: %.1f%% (%d hits, %d misses)\n", stats.HitRatio*100, stats.Hits, stats.Misses)
+	if lastSync == "" {
+		lastSync = "Never"
+	}
+	fmt.Printf("Last Sync: %s\n", lastSync)
+
+	return nil
+}
+
+// CacheEntry displays a short code's entry in the server's link cache
+func (c *Commands) CacheEntry(ctx context.Context, shortCode string) error {
+	entry, err := c.client.GetCacheEntry(ctx, shortCode)
+	if err != nil {
+		if isNotFound(err) && c.format == OutputTable {
+			fmt.Printf("Short code '%s' is not cached\n", shortCode)
+			return nil
+		}
+		return err
+	}
+
+	expiresAt := ""
+	if !entry.ExpiresAt.IsZero() {
+		expiresAt = entry.ExpiresAt.Format(time.RFC3339)
+	}
+	switch c.format {
+	case OutputJSON:
+		return printJSON(entry)
+	case OutputCSV:
+		return printCSV(
+			[]string{"short_code", "original_url", "usage_count", "synced_count", "pending_usage", "dirty", "expires_at"},
+			[]string{shortCode, entry.OriginalURL, strconv.Itoa(entry.UsageCount), strconv.Itoa(entry.SyncedCount), strconv.Itoa(entry.PendingUsage()), strconv.FormatBool(entry.Dirty), expiresAt},
+		)
+	}
+
+	fmt.Printf("Cache Entry:\n")
+	fmt.Printf("Short Code: %s\n", shortCode)
+	fmt.Printf("Destination: %s\n", entry.Destination())
+	fmt.Printf("Usage Count: %d (%d synced)\n", entry.UsageCount, entry.SyncedCount)
+	if entry.BotsCount > 0 {
+		fmt.Printf("Bot Clicks: %d (%d synced)\n", entry.BotsCount, entry.SyncedBots)
+	}
+	fmt.Printf("Dirty: %t\n", entry.Dirty)
+	if !entry.LastUsedAt.IsZero() {
+		fmt.Printf("Last Used At: %s\n", entry.LastUsedAt.Format(time.RFC3339))
+	}
+	if expiresAt != "" {
+		fmt.Printf("Expires At: %s\n", expiresAt)
+	}
+
+	return nil
+}
+
+// FlushCache makes the server write its cached usage to the database now
+func (c *Commands) FlushCache(ctx context.Context) error {
+	result, err := c.client.FlushCache(ctx)
+	if err != nil {
+		return err
+	}
+
+	switch c.format {
+	case OutputJSON:
+		return printJSON(result)
+	case OutputCSV:
+		return printCSV([]string{"synced"}, []string{strconv.Itoa(result.Synced)})
+	}
+
+	fmt.Printf("Synced %d cache entries\n", result.Synced)
+	return nil
+}
+
+// InvalidateCache drops a short code's entry from the server's link cache
+func (c *Commands) InvalidateCache(ctx context.Context, shortCode string) error {
+	err := c.client.InvalidateCache(ctx, shortCode)
+	if err != nil {
+		if isNotFound(err) && c.format == OutputTable {
+			fmt.Printf("Short code '%s' is not cached\n", shortCode)
+			return nil
+		}
+		return err
+	}
+
+	switch c.format {
+	case OutputJSON:
+		return printJSON(map[string]any{"short_code": shortCode, "invalidated": true})
+	case OutputCSV:
+		return printCSV([]string{"short_code", "invalidated"}, []string{shortCode, "true"})
+	}
+
+	fmt.Printf("Cache entry of '%s' invalidated\n", shortCode)
+	return nil
+}
+
+// SyncNow makes the server write its cached usage to the database now
+func (c *Commands) SyncNow(ctx context.Context) error {
+	result, err := c.client.SyncNow(ctx)
+	if err != nil {
+		return err
+	}
+	return c.printSyncSummary(result, "Synced %d cache entries in %dms\n")
+}
+
+// Drain makes the server refuse API writes and sync its cached usage, so its
+// database can be snapshotted
+func (c *Commands) Drain(ctx context.Context) error {
+	result, err := c.client.Drain(ctx)
+	if err != nil {
+		return err
+	}
+	if err := c.printSyncSummary(result, "Drained: synced %d cache entries in %dms\n"); err != nil {
+		return err
+	}
+	if c.format == OutputTable {
+		fmt.Println("Writes are paused until the drain is ended with 'client drain --end'")
+	}
+	return nil
+}
+
+// EndDrain makes a drained server accept writes and sync usage again
+func (c *Commands) EndDrain(ctx context.Context) error {
+	if err := c.client.EndDrain(ctx); err != nil {
+		return err
+	}
+
+	switch c.format {
+	case OutputJSON:
+		return printJSON(map[string]any{"draining": false})
+	case OutputCSV:
+		return printCSV([]string{"draining"}, []string{"false"})
+	}
+
+	fmt.Println("Drain ended, writes are accepted again")
+	return nil
+}
+
+// printSyncSummary prints a forced sync's result, with message in table format
+func (c *Commands) printSyncSummary(result *apiclient.SyncSummary, message string) error {
+	switch c.format {
+	case OutputJSON:
+		return printJSON(result)
+	case OutputCSV:
+		return printCSV([]string{"synced", "duration_ms", "draining"},
+			[]string{strconv.Itoa(result.Synced), strconv.FormatInt(result.DurationMs, 10), strconv.FormatBool(result.Draining)})
+	}
+
+	fmt.Printf(message, result.Synced, result.DurationMs)
+	return nil
+}
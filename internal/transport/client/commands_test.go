@@ -7,15 +7,20 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"os"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
 	"github.com/joshdurbin/url-shortener/internal/domain"
+	apiclient "github.com/joshdurbin/url-shortener/pkg/urlshortener/client"
+	apimocks "github.com/joshdurbin/url-shortener/pkg/urlshortener/client/mocks"
 )
 
 // captureOutput captures stdout for testing print statements
@@ -53,7 +58,7 @@ func captureOutput(t *testing.T, fn func()) string {
 }
 
 func TestNewCommands(t *testing.T) {
-	client := NewClient("http://localhost:8080")
+	client := apiclient.NewClient("http://localhost:8080")
 	commands := NewCommands(client)
 	
 	assert.NotNil(t, commands)
@@ -76,12 +81,12 @@ func TestCommands_Create(t *testing.T) {
 		}))
 		defer server.Close()
 
-		client := NewClient(server.URL)
+		client := apiclient.NewClient(server.URL)
 		commands := NewCommands(client)
 		ctx := context.Background()
 
 		output := captureOutput(t, func() {
-			err := commands.Create(ctx, "https://example.com")
+			err := commands.Create(ctx, "https://example.com", domain.CreateOptions{})
 			assert.NoError(t, err)
 		})
 
@@ -98,15 +103,50 @@ func TestCommands_Create(t *testing.T) {
 		}))
 		defer server.Close()
 
-		client := NewClient(server.URL)
+		client := apiclient.NewClient(server.URL)
 		commands := NewCommands(client)
 		ctx := context.Background()
 
-		err := commands.Create(ctx, "invalid-url")
+		err := commands.Create(ctx, "invalid-url", domain.CreateOptions{})
 		assert.Error(t, err)
 	})
 }
 
+func TestCommands_Validate(t *testing.T) {
+	var response domain.ValidateURLResponse
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	}))
+	defer server.Close()
+
+	commands := NewCommands(apiclient.NewClient(server.URL))
+	ctx := context.Background()
+
+	t.Run("valid", func(t *testing.T) {
+		response = domain.ValidateURLResponse{Valid: true}
+		output := captureOutput(t, func() {
+			assert.NoError(t, commands.Validate(ctx, "https://example.com", domain.CreateOptions{}))
+		})
+		assert.Contains(t, output, "Valid: https://example.com")
+	})
+
+	t.Run("problems", func(t *testing.T) {
+		response = domain.ValidateURLResponse{Problems: []domain.ValidationProblem{
+			{Field: "url", Message: "invalid URL: missing host"},
+			{Field: "max_uses", Message: "max_uses cannot be negative"},
+		}}
+		var err error
+		output := captureOutput(t, func() {
+			err = commands.Validate(ctx, "https://", domain.CreateOptions{MaxUses: -1})
+		})
+		assert.EqualError(t, err, "validation found 2 problem(s)")
+		assert.Contains(t, output, "Invalid: https://")
+		assert.Contains(t, output, "url: invalid URL: missing host")
+		assert.Contains(t, output, "max_uses: max_uses cannot be negative")
+	})
+}
+
 func TestCommands_Get(t *testing.T) {
 	t.Run("successful retrieval", func(t *testing.T) {
 		now := time.Now()
@@ -117,6 +157,7 @@ func TestCommands_Get(t *testing.T) {
 			CreatedAt:   now,
 			LastUsedAt:  &now,
 			UsageCount:  5,
+			Preview:     &domain.LinkPreview{Title: "Example Domain", FetchedAt: now},
 		}
 
 		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -126,7 +167,7 @@ func TestCommands_Get(t *testing.T) {
 		}))
 		defer server.Close()
 
-		client := NewClient(server.URL)
+		client := apiclient.NewClient(server.URL)
 		commands := NewCommands(client)
 		ctx := context.Background()
 
@@ -141,6 +182,7 @@ func TestCommands_Get(t *testing.T) {
 		assert.Contains(t, output, "Usage Count: 5")
 		assert.Contains(t, output, "Last Used At:")
 		assert.NotContains(t, output, "Never")
+		assert.Contains(t, output, "Title: Example Domain")
 	})
 
 	t.Run("entry with no last used date", func(t *testing.T) {
@@ -160,7 +202,7 @@ func TestCommands_Get(t *testing.T) {
 		}))
 		defer server.Close()
 
-		client := NewClient(server.URL)
+		client := apiclient.NewClient(server.URL)
 		commands := NewCommands(client)
 		ctx := context.Background()
 
@@ -179,7 +221,7 @@ func TestCommands_Get(t *testing.T) {
 		}))
 		defer server.Close()
 
-		client := NewClient(server.URL)
+		client := apiclient.NewClient(server.URL)
 		commands := NewCommands(client)
 		ctx := context.Background()
 
@@ -197,7 +239,7 @@ func TestCommands_Get(t *testing.T) {
 		}))
 		defer server.Close()
 
-		client := NewClient(server.URL)
+		client := apiclient.NewClient(server.URL)
 		commands := NewCommands(client)
 		ctx := context.Background()
 
@@ -213,7 +255,7 @@ func TestCommands_Delete(t *testing.T) {
 		}))
 		defer server.Close()
 
-		client := NewClient(server.URL)
+		client := apiclient.NewClient(server.URL)
 		commands := NewCommands(client)
 		ctx := context.Background()
 
@@ -231,7 +273,7 @@ func TestCommands_Delete(t *testing.T) {
 		}))
 		defer server.Close()
 
-		client := NewClient(server.URL)
+		client := apiclient.NewClient(server.URL)
 		commands := NewCommands(client)
 		ctx := context.Background()
 
@@ -249,7 +291,7 @@ func TestCommands_Delete(t *testing.T) {
 		}))
 		defer server.Close()
 
-		client := NewClient(server.URL)
+		client := apiclient.NewClient(server.URL)
 		commands := NewCommands(client)
 		ctx := context.Background()
 
@@ -287,7 +329,7 @@ func TestCommands_List(t *testing.T) {
 		}))
 		defer server.Close()
 
-		client := NewClient(server.URL)
+		client := apiclient.NewClient(server.URL)
 		commands := NewCommands(client)
 		ctx := context.Background()
 
@@ -333,7 +375,7 @@ func TestCommands_List(t *testing.T) {
 		}))
 		defer server.Close()
 
-		client := NewClient(server.URL)
+		client := apiclient.NewClient(server.URL)
 		commands := NewCommands(client)
 		ctx := context.Background()
 
@@ -356,7 +398,7 @@ func TestCommands_List(t *testing.T) {
 		}))
 		defer server.Close()
 
-		client := NewClient(server.URL)
+		client := apiclient.NewClient(server.URL)
 		commands := NewCommands(client)
 		ctx := context.Background()
 
@@ -374,7 +416,7 @@ func TestCommands_List(t *testing.T) {
 		}))
 		defer server.Close()
 
-		client := NewClient(server.URL)
+		client := apiclient.NewClient(server.URL)
 		commands := NewCommands(client)
 		ctx := context.Background()
 
@@ -383,6 +425,483 @@ func TestCommands_List(t *testing.T) {
 	})
 }
 
+func TestCommands_Stats(t *testing.T) {
+	now := time.Now()
+	entries := []*domain.URLEntry{
+		{ShortCode: "abc123", OriginalURL: "https://example.com", CreatedAt: now, UsageCount: 5},
+		{ShortCode: "def456", OriginalURL: "https://google.com", CreatedAt: now, UsageCount: 2, MaxUses: 2},
+		{ShortCode: "ghi789", OriginalURL: "https://golang.org", CreatedAt: now, FailoverActive: true, BackupURL: "https://go.dev"},
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/api/stats/top-referrers" {
+			assert.Equal(t, "5", r.URL.Query().Get("limit"))
+			json.NewEncoder(w).Encode(domain.TopReferrersResponse{Referrers: []domain.ReferrerClicks{
+				{Referrer: "news.ycombinator.com", Clicks: 4, Links: 2},
+			}})
+			return
+		}
+		if r.URL.Path == "/api/stats/top-countries" {
+			json.NewEncoder(w).Encode(domain.TopCountriesResponse{Countries: []domain.CountryClicks{
+				{Country: "NZ", Clicks: 3, Links: 1},
+			}})
+			return
+		}
+		json.NewEncoder(w).Encode(entries)
+	}))
+	defer server.Close()
+
+	commands := NewCommands(apiclient.NewClient(server.URL))
+	output := captureOutput(t, func() {
+		assert.NoError(t, commands.Stats(context.Background()))
+	})
+
+	assert.Contains(t, output, "Links: 3")
+	assert.Contains(t, output, "Total Clicks: 7")
+	assert.Contains(t, output, "Never Used: 1")
+	assert.Contains(t, output, "Usage Limit Reached: 1")
+	assert.Contains(t, output, "Failover Active: 1")
+	assert.Less(t, strings.Index(output, "abc123"), strings.Index(output, "def456"), "most used first")
+	assert.NotContains(t, output, "ghi789", "unused links are not listed as most used")
+	assert.Contains(t, output, "Top Referrers:")
+	assert.Contains(t, output, "news.ycombinator.com")
+	assert.Contains(t, output, "Top Countries:")
+	assert.Contains(t, output, "NZ")
+}
+
+func TestCommands_StatsWithoutAnalytics(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/api/stats/") {
+			w.WriteHeader(http.StatusNotImplemented)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode([]*domain.URLEntry{{ShortCode: "abc123", OriginalURL: "https://example.com", UsageCount: 1}})
+	}))
+	defer server.Close()
+
+	commands := NewCommands(apiclient.NewClient(server.URL))
+	output := captureOutput(t, func() {
+		assert.NoError(t, commands.Stats(context.Background()))
+	})
+
+	assert.Contains(t, output, "Links: 1")
+	assert.NotContains(t, output, "Top Referrers:")
+	assert.NotContains(t, output, "Top Countries:")
+}
+
+func TestCommands_Countries(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/urls/abc123/countries" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(domain.CountryReport{ShortCode: "abc123", Countries: []domain.CountryClicks{
+			{Country: "US", Region: "CA", Clicks: 3},
+			{Country: "DE", Clicks: 1},
+		}})
+	}))
+	defer server.Close()
+
+	commands := NewCommands(apiclient.NewClient(server.URL))
+	output := captureOutput(t, func() {
+		assert.NoError(t, commands.Countries(context.Background(), "abc123", 0))
+	})
+	assert.Contains(t, output, "US")
+	assert.Contains(t, output, "CA")
+
+	commands = NewCommands(apiclient.NewClient(server.URL), WithOutputFormat(OutputCSV))
+	output = captureOutput(t, func() {
+		assert.NoError(t, commands.Countries(context.Background(), "abc123", 0))
+	})
+	assert.Equal(t, "country,region,clicks\nUS,CA,3\nDE,,1\n", output)
+}
+
+func TestCommands_Referrers(t *testing.T) {
+	report := domain.ReferrerReport{
+		ShortCode: "abc123",
+		Referrers: []domain.ReferrerClicks{{Referrer: "twitter.com", Clicks: 3}, {Referrer: domain.DirectReferrer, Clicks: 1}},
+		UTM:       []domain.UTMClicks{{UTMParams: domain.UTMParams{Source: "newsletter", Medium: "email"}, Clicks: 2}},
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/urls/abc123/referrers" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(report)
+	}))
+	defer server.Close()
+
+	t.Run("table", func(t *testing.T) {
+		commands := NewCommands(apiclient.NewClient(server.URL))
+		output := captureOutput(t, func() {
+			assert.NoError(t, commands.Referrers(context.Background(), "abc123", 0))
+		})
+		assert.Contains(t, output, "Referrers for abc123:")
+		assert.Contains(t, output, "twitter.com")
+		assert.Contains(t, output, "(direct)")
+		assert.Contains(t, output, "newsletter")
+	})
+
+	t.Run("csv", func(t *testing.T) {
+		commands := NewCommands(apiclient.NewClient(server.URL), WithOutputFormat(OutputCSV))
+		output := captureOutput(t, func() {
+			assert.NoError(t, commands.Referrers(context.Background(), "abc123", 0))
+		})
+		assert.Equal(t, "referrer,utm_source,utm_medium,utm_campaign,clicks\ntwitter.com,,,,3\n(direct),,,,1\n,newsletter,email,,2\n", output)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		commands := NewCommands(apiclient.NewClient(server.URL))
+		output := captureOutput(t, func() {
+			assert.NoError(t, commands.Referrers(context.Background(), "missing", 0))
+		})
+		assert.Contains(t, output, "Short code 'missing' not found")
+
+		commands = NewCommands(apiclient.NewClient(server.URL), WithOutputFormat(OutputJSON))
+		assert.ErrorContains(t, commands.Referrers(context.Background(), "missing", 0), "not found")
+	})
+}
+
+func TestCommands_Top(t *testing.T) {
+	var query url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/stats/top", r.URL.Path)
+		query = r.URL.Query()
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(domain.TopLinksResponse{
+			Window: domain.Age(7 * 24 * time.Hour),
+			Since:  time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+			Sort:   domain.TopLinksByTrending,
+			Links:  []domain.TopLink{{ShortCode: "abc123", OriginalURL: "https://example.com", Clicks: 5, PreviousClicks: 2}},
+		})
+	}))
+	defer server.Close()
+
+	t.Run("table", func(t *testing.T) {
+		commands := NewCommands(apiclient.NewClient(server.URL))
+		output := captureOutput(t, func() {
+			assert.NoError(t, commands.Top(context.Background(), 7*24*time.Hour, 5, true))
+		})
+		assert.Equal(t, url.Values{"window": {"7d"}, "limit": {"5"}, "sort": {"trending"}}, query)
+		assert.Contains(t, output, "Top links in the last 7d")
+		assert.Contains(t, output, "abc123")
+		assert.Contains(t, output, "https://example.com")
+	})
+
+	t.Run("csv with server defaults", func(t *testing.T) {
+		commands := NewCommands(apiclient.NewClient(server.URL), WithOutputFormat(OutputCSV))
+		output := captureOutput(t, func() {
+			assert.NoError(t, commands.Top(context.Background(), 0, 0, false))
+		})
+		assert.Empty(t, query)
+		assert.Equal(t, "short_code,clicks,previous_clicks,original_url\nabc123,5,2,https://example.com\n", output)
+	})
+}
+
+func TestCommands_TimeSeries(t *testing.T) {
+	day := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	var query url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/urls/abc123/timeseries" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		query = r.URL.Query()
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(domain.TimeSeries{
+			ShortCode: "abc123",
+			Interval:  domain.IntervalDay,
+			From:      day,
+			To:        day.Add(48 * time.Hour),
+			Total:     3,
+			Buckets:   []domain.TimeSeriesBucket{{Start: day}, {Start: day.Add(24 * time.Hour), Clicks: 3}},
+		})
+	}))
+	defer server.Close()
+
+	t.Run("table", func(t *testing.T) {
+		commands := NewCommands(apiclient.NewClient(server.URL))
+		output := captureOutput(t, func() {
+			assert.NoError(t, commands.TimeSeries(context.Background(), "abc123", domain.IntervalDay, day, day.Add(48*time.Hour)))
+		})
+		assert.Equal(t, url.Values{"interval": {"day"}, "from": {"2024-05-01T00:00:00Z"}, "to": {"2024-05-03T00:00:00Z"}}, query)
+		assert.Contains(t, output, "Clicks on abc123 per day: 3 in total")
+		assert.Contains(t, output, "2024-05-02              3  "+strings.Repeat("#", 40))
+	})
+
+	t.Run("csv", func(t *testing.T) {
+		commands := NewCommands(apiclient.NewClient(server.URL), WithOutputFormat(OutputCSV))
+		output := captureOutput(t, func() {
+			assert.NoError(t, commands.TimeSeries(context.Background(), "abc123", "", time.Time{}, time.Time{}))
+		})
+		assert.Empty(t, query)
+		assert.Equal(t, "start,clicks\n2024-05-01T00:00:00Z,0\n2024-05-02T00:00:00Z,3\n", output)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		commands := NewCommands(apiclient.NewClient(server.URL))
+		output := captureOutput(t, func() {
+			assert.NoError(t, commands.TimeSeries(context.Background(), "missing", "", time.Time{}, time.Time{}))
+		})
+		assert.Contains(t, output, "Short code 'missing' not found")
+	})
+}
+
+func TestReadShortCodes(t *testing.T) {
+	shortCodes, err := ReadShortCodes(strings.NewReader("abc123\n\n# old campaign\n  def456  \n"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"abc123", "def456"}, shortCodes)
+}
+
+func TestCommands_DeleteMany(t *testing.T) {
+	var batches [][]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req domain.BulkDeleteRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		batches = append(batches, req.ShortCodes)
+		result := domain.BulkDeleteResponse{Deleted: []string{}}
+		for _, shortCode := range req.ShortCodes {
+			if shortCode == "missing" {
+				result.NotFound = append(result.NotFound, shortCode)
+			} else {
+				result.Deleted = append(result.Deleted, shortCode)
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(result)
+	}))
+	defer server.Close()
+
+	t.Run("batches", func(t *testing.T) {
+		batches = nil
+		shortCodes := make([]string, domain.MaxBulkDelete+1)
+		for i := range shortCodes {
+			shortCodes[i] = "code" + strconv.Itoa(i)
+		}
+		shortCodes[0] = "missing"
+		commands := NewCommands(apiclient.NewClient(server.URL))
+		output := captureOutput(t, func() {
+			assert.NoError(t, commands.DeleteMany(context.Background(), shortCodes))
+		})
+		require.Len(t, batches, 2)
+		assert.Len(t, batches[0], domain.MaxBulkDelete)
+		assert.Equal(t, []string{"code1000"}, batches[1])
+		assert.Contains(t, output, "Deleted 1000 of 1001 short URLs")
+		assert.Contains(t, output, "Not found: missing")
+	})
+
+	t.Run("csv", func(t *testing.T) {
+		commands := NewCommands(apiclient.NewClient(server.URL), WithOutputFormat(OutputCSV))
+		output := captureOutput(t, func() {
+			assert.NoError(t, commands.DeleteMany(context.Background(), []string{"abc123", "missing"}))
+		})
+		assert.Equal(t, "short_code,status\nabc123,deleted\nmissing,not_found\n", output)
+	})
+}
+
+func TestCommands_Prune(t *testing.T) {
+	var prunes []domain.PruneRequest
+	var deleted []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/urls/prune":
+			var req domain.PruneRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			prunes = append(prunes, req)
+			response := domain.PruneResponse{Links: []*domain.URLEntry{{ShortCode: "stale", OriginalURL: "https://example.com"}}, DryRun: req.DryRun}
+			response.Results.Deleted = []string{}
+			if !req.DryRun {
+				response.Results.Deleted = []string{"stale"}
+			}
+			json.NewEncoder(w).Encode(response)
+		case "/api/urls/delete":
+			var req domain.BulkDeleteRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			deleted = append(deleted, req.ShortCodes...)
+			json.NewEncoder(w).Encode(domain.BulkDeleteResponse{Deleted: req.ShortCodes})
+		}
+	}))
+	defer server.Close()
+	prune := domain.PruneRequest{UnusedFor: domain.Age(90 * 24 * time.Hour)}
+
+	tests := []struct {
+		name            string
+		prune           domain.PruneRequest
+		confirm         func(matches int) bool
+		expectedDryRuns []bool
+		expectedDeleted []string
+		expectedOutput  string
+	}{
+		{
+			name:            "confirmed",
+			prune:           prune,
+			confirm:         func(matches int) bool { return matches == 1 },
+			expectedDryRuns: []bool{true},
+			expectedDeleted: []string{"stale"},
+			expectedOutput:  "Deleted 1 of 1 short URLs",
+		},
+		{
+			name:            "declined",
+			prune:           prune,
+			confirm:         func(int) bool { return false },
+			expectedDryRuns: []bool{true},
+			expectedOutput:  "Nothing deleted",
+		},
+		{
+			name:            "yes",
+			prune:           prune,
+			expectedDryRuns: []bool{false},
+			expectedOutput:  "Deleted 1 of 1 short URLs",
+		},
+		{
+			name:            "dry run",
+			prune:           domain.PruneRequest{UnusedFor: prune.UnusedFor, DryRun: true},
+			confirm:         func(int) bool { t.Fatal("dry run asked for confirmation"); return false },
+			expectedDryRuns: []bool{true},
+			expectedOutput:  "stale",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prunes, deleted = nil, nil
+			commands := NewCommands(apiclient.NewClient(server.URL))
+			output := captureOutput(t, func() {
+				assert.NoError(t, commands.Prune(context.Background(), tt.prune, tt.confirm))
+			})
+			var dryRuns []bool
+			for _, req := range prunes {
+				assert.Equal(t, prune.UnusedFor, req.UnusedFor)
+				dryRuns = append(dryRuns, req.DryRun)
+			}
+			assert.Equal(t, tt.expectedDryRuns, dryRuns)
+			assert.Equal(t, tt.expectedDeleted, deleted)
+			assert.Contains(t, output, tt.expectedOutput)
+		})
+	}
+}
+
+func TestCommands_CodeStats(t *testing.T) {
+	day := time.Now().UTC().Truncate(24 * time.Hour)
+	analytics := true
+	var from string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/urls/abc123":
+			json.NewEncoder(w).Encode(domain.URLEntry{ShortCode: "abc123", OriginalURL: "https://example.com", UsageCount: 12, MaxUses: 100})
+			return
+		case "/api/urls/abc123/timeseries":
+			if analytics {
+				from = r.URL.Query().Get("from")
+				buckets := make([]domain.TimeSeriesBucket, 7)
+				for i := range buckets {
+					buckets[i] = domain.TimeSeriesBucket{Start: day.AddDate(0, 0, i-6), Clicks: i}
+				}
+				json.NewEncoder(w).Encode(domain.TimeSeries{ShortCode: "abc123", Interval: domain.IntervalDay, Total: 21, Buckets: buckets})
+				return
+			}
+		case "/api/urls/abc123/referrers":
+			if analytics {
+				json.NewEncoder(w).Encode(domain.ReferrerReport{ShortCode: "abc123", Referrers: []domain.ReferrerClicks{{Referrer: "news.ycombinator.com", Clicks: 9}}})
+				return
+			}
+		case "/api/urls/abc123/countries":
+			if analytics {
+				json.NewEncoder(w).Encode(domain.CountryReport{ShortCode: "abc123", Countries: []domain.CountryClicks{{Country: "US", Region: "CA", Clicks: 7}}})
+				return
+			}
+		}
+		if r.URL.Path == "/api/urls/abc123/timeseries" || r.URL.Path == "/api/urls/abc123/referrers" || r.URL.Path == "/api/urls/abc123/countries" {
+			w.WriteHeader(http.StatusNotImplemented)
+			json.NewEncoder(w).Encode(map[string]string{"error": "Click analytics is not enabled"})
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Short code not found"})
+	}))
+	defer server.Close()
+
+	t.Run("table", func(t *testing.T) {
+		commands := NewCommands(apiclient.NewClient(server.URL))
+		output := captureOutput(t, func() {
+			assert.NoError(t, commands.CodeStats(context.Background(), "abc123", 5))
+		})
+		assert.Equal(t, day.AddDate(0, 0, -6).Format(time.RFC3339), from)
+		assert.Contains(t, output, "Usage Count: 12 / 100")
+		assert.Contains(t, output, "Last 7 Days: ▁▂▃▄▅▆█  21 clicks")
+		assert.Contains(t, output, "news.ycombinator.com")
+		assert.Contains(t, output, "US/CA")
+	})
+
+	t.Run("json", func(t *testing.T) {
+		commands := NewCommands(apiclient.NewClient(server.URL), WithOutputFormat(OutputJSON))
+		output := captureOutput(t, func() {
+			assert.NoError(t, commands.CodeStats(context.Background(), "abc123", 0))
+		})
+		var stats CodeStats
+		require.NoError(t, json.Unmarshal([]byte(output), &stats))
+		assert.Equal(t, 12, stats.UsageCount)
+		assert.Len(t, stats.Daily, 7)
+		assert.Equal(t, "news.ycombinator.com", stats.TopReferrers[0].Referrer)
+		assert.Equal(t, "US", stats.TopCountries[0].Country)
+	})
+
+	t.Run("without analytics", func(t *testing.T) {
+		analytics = false
+		defer func() { analytics = true }()
+		commands := NewCommands(apiclient.NewClient(server.URL))
+		output := captureOutput(t, func() {
+			assert.NoError(t, commands.CodeStats(context.Background(), "abc123", 0))
+		})
+		assert.Contains(t, output, "Usage Count: 12 / 100")
+		assert.NotContains(t, output, "Last 7 Days")
+		assert.NotContains(t, output, "Top Referrers")
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		commands := NewCommands(apiclient.NewClient(server.URL))
+		output := captureOutput(t, func() {
+			assert.NoError(t, commands.CodeStats(context.Background(), "missing", 0))
+		})
+		assert.Contains(t, output, "Short code 'missing' not found")
+	})
+}
+
+func TestSparkline(t *testing.T) {
+	assert.Equal(t, "▁▁▁", sparkline([]domain.TimeSeriesBucket{{}, {}, {}}))
+	assert.Equal(t, "▁▄█", sparkline([]domain.TimeSeriesBucket{{Clicks: 0}, {Clicks: 5}, {Clicks: 10}}))
+}
+
+func TestCommands_Version(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(domain.VersionResponse{
+			Version:   "v1.2.0",
+			Commit:    "abc1234",
+			Storage:   "sqlite",
+			Generator: "md5",
+			Features:  []string{"webhooks", "api_key_auth"},
+		})
+	}))
+	defer server.Close()
+
+	commands := NewCommands(apiclient.NewClient(server.URL))
+	output := captureOutput(t, func() {
+		assert.NoError(t, commands.Version(context.Background()))
+	})
+
+	assert.Contains(t, output, "Client Version: dev")
+	assert.Contains(t, output, "Server Version: v1.2.0 (commit abc1234")
+	assert.Contains(t, output, "Generator: md5")
+	assert.Contains(t, output, "Features: webhooks, api_key_auth")
+}
+
 func TestCommands_OutputFormatting(t *testing.T) {
 	t.Run("date formatting in list", func(t *testing.T) {
 		// Test specific date formatting
@@ -405,7 +924,7 @@ func TestCommands_OutputFormatting(t *testing.T) {
 		}))
 		defer server.Close()
 
-		client := NewClient(server.URL)
+		client := apiclient.NewClient(server.URL)
 		commands := NewCommands(client)
 		ctx := context.Background()
 
@@ -446,13 +965,13 @@ func TestCommands_OutputFormatting(t *testing.T) {
 		}))
 		defer server.Close()
 
-		client := NewClient(server.URL)
+		client := apiclient.NewClient(server.URL)
 		commands := NewCommands(client)
 		ctx := context.Background()
 
 		// Test Create output
 		createOutput := captureOutput(t, func() {
-			err := commands.Create(ctx, "https://example.com")
+			err := commands.Create(ctx, "https://example.com", domain.CreateOptions{})
 			assert.NoError(t, err)
 		})
 		assert.Contains(t, createOutput, "2023-12-25T15:30:45Z")
@@ -474,12 +993,11 @@ func TestCommands_ErrorHandling(t *testing.T) {
 		}))
 		defer server.Close()
 
-		client := NewClient(server.URL)
-		client.httpClient.Timeout = 10 * time.Millisecond // Very short timeout
+		client := apiclient.NewClient(server.URL, apiclient.WithTimeout(10*time.Millisecond)) // Very short timeout
 		commands := NewCommands(client)
 		ctx := context.Background()
 
-		err := commands.Create(ctx, "https://example.com")
+		err := commands.Create(ctx, "https://example.com", domain.CreateOptions{})
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "context deadline exceeded")
 	})
@@ -491,12 +1009,29 @@ func TestCommands_ErrorHandling(t *testing.T) {
 		}))
 		defer server.Close()
 
-		client := NewClient(server.URL)
+		client := apiclient.NewClient(server.URL)
 		commands := NewCommands(client)
 		ctx, cancel := context.WithCancel(context.Background())
 		cancel() // Cancel immediately
 
-		err := commands.Create(ctx, "https://example.com")
+		err := commands.Create(ctx, "https://example.com", domain.CreateOptions{})
 		assert.Error(t, err)
 	})
-}
\ No newline at end of file
+}
+func TestCommands_MockAPI(t *testing.T) {
+	api := &apimocks.API{}
+	notFound := &apiclient.NotFoundError{APIError: &apiclient.APIError{StatusCode: http.StatusNotFound}, ShortCode: "missing"}
+	api.On("DeleteURL", mock.Anything, "missing").Return(notFound)
+	api.On("DeleteURL", mock.Anything, "abc123").Return(nil).Once()
+
+	commands := NewCommands(api)
+	output := captureOutput(t, func() {
+		assert.NoError(t, commands.Delete(context.Background(), "missing"))
+		assert.NoError(t, commands.Delete(context.Background(), "abc123"))
+	})
+	assert.Contains(t, output, "Short code 'missing' not found")
+
+	commands = NewCommands(api, WithOutputFormat(OutputJSON))
+	assert.ErrorIs(t, commands.Delete(context.Background(), "missing"), notFound)
+	api.AssertExpectations(t)
+}
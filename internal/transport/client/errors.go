@@ -0,0 +1,52 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"syscall"
+
+	apiclient "github.com/joshdurbin/url-shortener/pkg/urlshortener/client"
+)
+
+// Suggestion returns an actionable hint for common client failures, or an
+// empty string when there is nothing more useful to say than the error itself
+func Suggestion(err error) string {
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return fmt.Sprintf("Could not resolve host %q. Check the --server-url value and your DNS settings.", dnsErr.Name)
+	}
+
+	var connErr *apiclient.ConnectionError
+	if errors.As(err, &connErr) {
+		switch {
+		case errors.Is(err, syscall.ECONNREFUSED):
+			return fmt.Sprintf("Is the server running at %s? Start it with 'url-shortener server' or pass --server-url.", connErr.ServerURL)
+		case errors.Is(err, context.DeadlineExceeded) || isTimeout(err):
+			return fmt.Sprintf("The server at %s did not respond in time. Check that it is reachable and not overloaded.", connErr.ServerURL)
+		}
+		return ""
+	}
+
+	var apiErr *apiclient.APIError
+	if errors.As(err, &apiErr) {
+		switch {
+		case apiErr.StatusCode == http.StatusUnauthorized:
+			return "The server requires authentication. Check --api-key or set URL_SHORTENER_API_KEY."
+		case apiErr.StatusCode == http.StatusForbidden:
+			return "The credentials do not allow this operation. Share tokens are read-only and limited to one link, and user API keys can only change the links they created; use an admin API key instead."
+		case apiErr.StatusCode >= http.StatusInternalServerError:
+			return "The server failed to handle the request. Check the server logs for details."
+		}
+	}
+
+	return ""
+}
+
+// isTimeout reports whether err is a network timeout
+func isTimeout(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
@@ -0,0 +1,79 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	apiclient "github.com/joshdurbin/url-shortener/pkg/urlshortener/client"
+)
+
+func TestSuggestion_ConnectionRefused(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	serverURL := server.URL
+	server.Close()
+
+	_, err := apiclient.NewClient(serverURL).ListURLs(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, Suggestion(err), "Is the server running at "+serverURL)
+}
+
+func TestSuggestion(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected string
+	}{
+		{
+			name:     "DNS failure",
+			err:      &apiclient.ConnectionError{ServerURL: "http://shortener.invalid", Err: &net.DNSError{Name: "shortener.invalid", Err: "no such host", IsNotFound: true}},
+			expected: `Could not resolve host "shortener.invalid"`,
+		},
+		{
+			name:     "timeout",
+			err:      &apiclient.ConnectionError{ServerURL: "http://localhost:8080", Err: context.DeadlineExceeded},
+			expected: "did not respond in time",
+		},
+		{
+			name:     "unauthorized",
+			err:      fmt.Errorf("listing: %w", &apiclient.APIError{StatusCode: http.StatusUnauthorized}),
+			expected: "--api-key",
+		},
+		{
+			name:     "forbidden",
+			err:      &apiclient.APIError{StatusCode: http.StatusForbidden},
+			expected: "Share tokens are read-only",
+		},
+		{
+			name:     "server error",
+			err:      &apiclient.APIError{StatusCode: http.StatusInternalServerError},
+			expected: "server logs",
+		},
+		{
+			name: "bad request has no hint",
+			err:  &apiclient.APIError{StatusCode: http.StatusBadRequest, Message: "invalid URL"},
+		},
+		{
+			name: "unrelated error has no hint",
+			err:  errors.New("boom"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hint := Suggestion(tt.err)
+			if tt.expected == "" {
+				assert.Empty(t, hint)
+			} else {
+				assert.Contains(t, hint, tt.expected)
+			}
+		})
+	}
+}
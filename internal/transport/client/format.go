@@ -0,0 +1,102 @@
+package client
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	"github.com/joshdurbin/url-shortener/internal/transfer"
+)
+
+// OutputFormat selects how client commands print their results
+type OutputFormat string
+
+// OutputFormat constants
+const (
+	OutputTable OutputFormat = "table" // Human-readable text
+	OutputJSON  OutputFormat = "json"  // Indented JSON, e.g. for jq
+	OutputCSV   OutputFormat = "csv"   // CSV with a header row, e.g. for spreadsheets
+)
+
+// ParseOutputFormat validates an output format name
+func ParseOutputFormat(name string) (OutputFormat, error) {
+	switch format := OutputFormat(strings.ToLower(name)); format {
+	case OutputTable, OutputJSON, OutputCSV:
+		return format, nil
+	default:
+		return "", fmt.Errorf("unsupported output format %q: must be table, json or csv", name)
+	}
+}
+
+// printJSON writes v to stdout as indented JSON
+func printJSON(v any) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		return fmt.Errorf("failed to encode JSON: %w", err)
+	}
+	return nil
+}
+
+// printCSV writes a header row and records to stdout as CSV
+func printCSV(header []string, records ...[]string) error {
+	writer := csv.NewWriter(os.Stdout)
+	if err := writer.Write(header); err != nil {
+		return fmt.Errorf("failed to write CSV header: %w", err)
+	}
+	if err := writer.WriteAll(records); err != nil {
+		return fmt.Errorf("failed to write CSV: %w", err)
+	}
+	return nil
+}
+
+// printEntries writes links in the export layout, so CSV output can be
+// imported with "server import"
+func printEntries(entries []*domain.URLEntry, format OutputFormat) error {
+	if entries == nil {
+		entries = []*domain.URLEntry{}
+	}
+	return transfer.Encode(os.Stdout, entries, transfer.Format(format))
+}
+
+// formatInt renders a number for CSV, empty when zero (unset)
+func formatInt(n int) string {
+	if n == 0 {
+		return ""
+	}
+	return strconv.Itoa(n)
+}
+
+// formatQueryParams renders a link's query parameters as a query string
+func formatQueryParams(params map[string]string) string {
+	values := make(url.Values, len(params))
+	for name, value := range params {
+		values.Set(name, value)
+	}
+	return values.Encode()
+}
+
+// printQueryOptions prints the query parameters a link adds to its destination
+func printQueryOptions(params map[string]string, forward bool) {
+	if len(params) > 0 {
+		fmt.Printf("Query Params: %s\n", formatQueryParams(params))
+	}
+	if forward {
+		fmt.Printf("Forward Query: yes\n")
+	}
+}
+
+// printDedupe prints a link's click dedupe window when it sets its own
+func printDedupe(seconds int) {
+	switch {
+	case seconds > 0:
+		fmt.Printf("Click Dedupe: one click per visitor every %ds\n", seconds)
+	case seconds < 0:
+		fmt.Printf("Click Dedupe: off, every click counts\n")
+	}
+}
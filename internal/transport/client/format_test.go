@@ -0,0 +1,144 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	"github.com/joshdurbin/url-shortener/internal/transfer"
+	apiclient "github.com/joshdurbin/url-shortener/pkg/urlshortener/client"
+)
+
+func TestParseOutputFormat(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    OutputFormat
+		wantErr bool
+	}{
+		{name: "table", want: OutputTable},
+		{name: "json", want: OutputJSON},
+		{name: "CSV", want: OutputCSV},
+		{name: "yaml", wantErr: true},
+		{name: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			format, err := ParseOutputFormat(tt.name)
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), "must be table, json or csv")
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, format)
+		})
+	}
+}
+
+func TestCommands_OutputFormats(t *testing.T) {
+	createdAt := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	entries := []*domain.URLEntry{
+		{ShortCode: "abc123", OriginalURL: "https://example.com", CreatedAt: createdAt, UsageCount: 5, Tags: []string{"docs"}},
+		{ShortCode: "def456", OriginalURL: "https://example.com/a,b", CreatedAt: createdAt, MaxUses: 3},
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/urls":
+			json.NewEncoder(w).Encode(entries)
+		case "/api/urls/abc123":
+			json.NewEncoder(w).Encode(entries[0])
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	client := apiclient.NewClient(server.URL)
+
+	t.Run("list json", func(t *testing.T) {
+		commands := NewCommands(client, WithOutputFormat(OutputJSON))
+		output := captureOutput(t, func() {
+			assert.NoError(t, commands.List(ctx))
+		})
+
+		var decoded []*domain.URLEntry
+		require.NoError(t, json.Unmarshal([]byte(output), &decoded))
+		require.Len(t, decoded, 2)
+		assert.Equal(t, "abc123", decoded[0].ShortCode)
+		assert.Equal(t, []string{"docs"}, decoded[0].Tags)
+	})
+
+	t.Run("list csv is importable", func(t *testing.T) {
+		commands := NewCommands(client, WithOutputFormat(OutputCSV))
+		output := captureOutput(t, func() {
+			assert.NoError(t, commands.List(ctx))
+		})
+
+		decoded, err := transfer.Decode(strings.NewReader(output), transfer.FormatCSV)
+		require.NoError(t, err)
+		require.Len(t, decoded, 2)
+		assert.Equal(t, "https://example.com/a,b", decoded[1].OriginalURL)
+		assert.Equal(t, 3, decoded[1].MaxUses)
+	})
+
+	t.Run("get json", func(t *testing.T) {
+		commands := NewCommands(client, WithOutputFormat(OutputJSON))
+		output := captureOutput(t, func() {
+			assert.NoError(t, commands.Get(ctx, "abc123"))
+		})
+
+		var decoded domain.URLEntry
+		require.NoError(t, json.Unmarshal([]byte(output), &decoded))
+		assert.Equal(t, "abc123", decoded.ShortCode)
+		assert.Equal(t, 5, decoded.UsageCount)
+	})
+
+	t.Run("get not found returns error", func(t *testing.T) {
+		commands := NewCommands(client, WithOutputFormat(OutputJSON))
+		output := captureOutput(t, func() {
+			assert.Error(t, commands.Get(ctx, "missing"))
+		})
+		assert.Empty(t, output)
+	})
+
+	t.Run("stats json", func(t *testing.T) {
+		commands := NewCommands(client, WithOutputFormat(OutputJSON))
+		output := captureOutput(t, func() {
+			assert.NoError(t, commands.Stats(ctx))
+		})
+
+		var stats LinkStats
+		require.NoError(t, json.Unmarshal([]byte(output), &stats))
+		assert.Equal(t, 2, stats.Links)
+		assert.Equal(t, 5, stats.TotalClicks)
+		assert.Equal(t, 1, stats.NeverUsed)
+		require.Len(t, stats.MostUsed, 1)
+		assert.Equal(t, "abc123", stats.MostUsed[0].ShortCode)
+	})
+
+	t.Run("stats csv", func(t *testing.T) {
+		commands := NewCommands(client, WithOutputFormat(OutputCSV))
+		output := captureOutput(t, func() {
+			assert.NoError(t, commands.Stats(ctx))
+		})
+
+		assert.Equal(t, "links,total_clicks,never_used,usage_limit_reached,failover_active\n2,5,1,0,0\n", output)
+	})
+
+	t.Run("delete csv", func(t *testing.T) {
+		commands := NewCommands(client, WithOutputFormat(OutputCSV))
+		assert.Error(t, commands.Delete(ctx, "missing"))
+	})
+}
@@ -0,0 +1,154 @@
+package client
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+)
+
+// Control keys handled by the line editor
+const (
+	keyCtrlC     = 3
+	keyCtrlD     = 4
+	keyBackspace = 8
+	keyTab       = '\t'
+	keyCtrlU     = 21
+	keyEscape    = 27
+	keyDelete    = 127
+)
+
+// completeFunc returns the candidates for word, the last word on the line;
+// before is the text preceding it
+type completeFunc func(before, word string) []string
+
+// lineEditor reads lines from a terminal in raw mode with history recall
+// (up/down arrows) and tab completion. The cursor always stays at the end of
+// the line, which keeps redrawing to a single escape sequence.
+type lineEditor struct {
+	in       *bufio.Reader
+	out      io.Writer
+	prompt   string
+	history  []string
+	complete completeFunc
+}
+
+// readLine reads one line. Ctrl-C discards the line and returns an empty
+// string; Ctrl-D on an empty line returns io.EOF.
+func (e *lineEditor) readLine() (string, error) {
+	var line []rune
+	recalled := len(e.history)
+	e.redraw(line)
+
+	for {
+		r, _, err := e.in.ReadRune()
+		if err != nil {
+			return "", err
+		}
+
+		switch r {
+		case '\r', '\n':
+			fmt.Fprint(e.out, "\r\n")
+			return string(line), nil
+		case keyCtrlC:
+			fmt.Fprint(e.out, "^C\r\n")
+			return "", nil
+		case keyCtrlD:
+			if len(line) == 0 {
+				fmt.Fprint(e.out, "\r\n")
+				return "", io.EOF
+			}
+		case keyBackspace, keyDelete:
+			if len(line) > 0 {
+				line = line[:len(line)-1]
+			}
+		case keyCtrlU:
+			line = line[:0]
+		case keyTab:
+			line = e.completeLine(line)
+		case keyEscape:
+			switch e.readEscape() {
+			case 'A':
+				if recalled > 0 {
+					recalled--
+					line = []rune(e.history[recalled])
+				}
+			case 'B':
+				if recalled < len(e.history) {
+					recalled++
+				}
+				line = nil
+				if recalled < len(e.history) {
+					line = []rune(e.history[recalled])
+				}
+			}
+		default:
+			if unicode.IsPrint(r) {
+				line = append(line, r)
+			}
+		}
+		e.redraw(line)
+	}
+}
+
+// readEscape consumes a CSI sequence such as "[A" and returns its final
+// byte, or 0 for anything else
+func (e *lineEditor) readEscape() rune {
+	if r, _, err := e.in.ReadRune(); err != nil || r != '[' {
+		return 0
+	}
+	for {
+		r, _, err := e.in.ReadRune()
+		if err != nil {
+			return 0
+		}
+		// Parameter bytes come before the final byte
+		if r >= 0x40 && r <= 0x7e {
+			return r
+		}
+	}
+}
+
+// completeLine completes the last word: a single candidate is inserted in
+// full, several are extended to their common prefix or listed when that adds
+// nothing
+func (e *lineEditor) completeLine(line []rune) []rune {
+	if e.complete == nil {
+		return line
+	}
+
+	text := string(line)
+	start := strings.LastIndexByte(text, ' ') + 1
+	before, word := text[:start], text[start:]
+	candidates := e.complete(before, word)
+
+	switch len(candidates) {
+	case 0:
+		return line
+	case 1:
+		return []rune(before + candidates[0] + " ")
+	}
+
+	if prefix := commonPrefix(candidates); len(prefix) > len(word) {
+		return []rune(before + prefix)
+	}
+	fmt.Fprintf(e.out, "\r\n%s\r\n", strings.Join(candidates, "  "))
+	return line
+}
+
+// redraw clears the current terminal line and writes the prompt and line
+func (e *lineEditor) redraw(line []rune) {
+	fmt.Fprintf(e.out, "\r\033[K%s%s", e.prompt, string(line))
+}
+
+// commonPrefix returns the longest prefix shared by all words
+func commonPrefix(words []string) string {
+	prefix := words[0]
+	for _, word := range words[1:] {
+		for !strings.HasPrefix(word, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	return prefix
+}
@@ -0,0 +1,136 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+
+	apiclient "github.com/joshdurbin/url-shortener/pkg/urlshortener/client"
+)
+
+// DefaultProfileName is the profile used when neither --profile nor the
+// config file's default_profile names one
+const DefaultProfileName = "default"
+
+// Profile holds the settings the CLI uses to reach one server. Empty fields
+// fall back to the command line defaults.
+type Profile struct {
+	ServerURL  string `yaml:"server_url"`
+	APIKey     string `yaml:"api_key"`
+	Output     string `yaml:"output"`      // table, json or csv
+	Proxy      string `yaml:"proxy"`       // HTTP(S) proxy URL; empty uses $HTTPS_PROXY and friends
+	CACert     string `yaml:"ca_cert"`     // PEM file of the CA that signed the server's certificate
+	ClientCert string `yaml:"client_cert"` // PEM client certificate for mutual TLS
+	ClientKey  string `yaml:"client_key"`  // PEM key of the client certificate
+}
+
+// ClientOptions returns the API client options that reach the profile's server
+func (p Profile) ClientOptions() ([]apiclient.Option, error) {
+	opts := []apiclient.Option{apiclient.WithAPIKey(p.APIKey)}
+	if p.Proxy != "" {
+		proxyURL, err := url.Parse(p.Proxy)
+		if err != nil || (proxyURL.Scheme != "http" && proxyURL.Scheme != "https") || proxyURL.Host == "" {
+			return nil, fmt.Errorf("proxy must be an http or https URL, got: %q", p.Proxy)
+		}
+		opts = append(opts, apiclient.WithProxy(proxyURL))
+	}
+	if p.CACert != "" || p.ClientCert != "" || p.ClientKey != "" {
+		config, err := apiclient.LoadTLSConfig(p.CACert, p.ClientCert, p.ClientKey)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, apiclient.WithTLSConfig(config))
+	}
+	return opts, nil
+}
+
+// ConfigFile is the layout of the client config file
+//
+//	default_profile: prod
+//	profiles:
+//	  prod:
+//	    server_url: https://sho.rt
+//	    api_key: secret
+//	    output: json
+//	    ca_cert: /etc/ssl/internal-ca.pem
+type ConfigFile struct {
+	DefaultProfile string             `yaml:"default_profile"`
+	Profiles       map[string]Profile `yaml:"profiles"`
+}
+
+// DefaultConfigPath returns ~/.url-shortener/config.yaml, or "" when the home
+// directory is unknown
+func DefaultConfigPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".url-shortener", "config.yaml")
+}
+
+// LoadConfigFile reads the client config file at path. A missing file is an
+// empty config, so the CLI works without one.
+func LoadConfigFile(path string) (*ConfigFile, error) {
+	file := &ConfigFile{}
+	if path == "" {
+		return file, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return file, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read client config file: %w", err)
+	}
+	if err := yaml.Unmarshal(data, file); err != nil {
+		return nil, fmt.Errorf("failed to parse client config file %s: %w", path, err)
+	}
+
+	for name, profile := range file.Profiles {
+		if profile.Output != "" {
+			if _, err := ParseOutputFormat(profile.Output); err != nil {
+				return nil, fmt.Errorf("profile %q: %w", name, err)
+			}
+		}
+	}
+	if file.DefaultProfile != "" {
+		if _, ok := file.Profiles[file.DefaultProfile]; !ok {
+			return nil, fmt.Errorf("default_profile %q is not defined in %s", file.DefaultProfile, path)
+		}
+	}
+	return file, nil
+}
+
+// Profile returns the named profile, or the default profile when name is "".
+// Naming a profile the file does not define is an error; a missing default
+// profile is an empty one.
+func (f *ConfigFile) Profile(name string) (Profile, error) {
+	if name != "" {
+		profile, ok := f.Profiles[name]
+		if !ok {
+			return Profile{}, fmt.Errorf("unknown profile %q (defined: %v)", name, f.profileNames())
+		}
+		return profile, nil
+	}
+	if f.DefaultProfile != "" {
+		return f.Profiles[f.DefaultProfile], nil
+	}
+	return f.Profiles[DefaultProfileName], nil
+}
+
+// profileNames returns the defined profile names in order
+func (f *ConfigFile) profileNames() []string {
+	names := make([]string, 0, len(f.Profiles))
+	for name := range f.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
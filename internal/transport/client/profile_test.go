@@ -0,0 +1,114 @@
+package client
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeConfigFile(t *testing.T, contents string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(contents), 0o600))
+	return path
+}
+
+func TestLoadConfigFile(t *testing.T) {
+	path := writeConfigFile(t, `
+default_profile: staging
+profiles:
+  staging:
+    server_url: https://staging.sho.rt
+  prod:
+    server_url: https://sho.rt
+    api_key: prod-key
+    output: json
+`)
+	file, err := LoadConfigFile(path)
+	require.NoError(t, err)
+
+	profile, err := file.Profile("")
+	require.NoError(t, err)
+	assert.Equal(t, Profile{ServerURL: "https://staging.sho.rt"}, profile)
+
+	profile, err = file.Profile("prod")
+	require.NoError(t, err)
+	assert.Equal(t, Profile{ServerURL: "https://sho.rt", APIKey: "prod-key", Output: "json"}, profile)
+
+	_, err = file.Profile("dev")
+	assert.EqualError(t, err, `unknown profile "dev" (defined: [prod staging])`)
+}
+
+func TestLoadConfigFile_DefaultProfile(t *testing.T) {
+	file, err := LoadConfigFile(writeConfigFile(t, "profiles:\n  default:\n    server_url: https://sho.rt\n"))
+	require.NoError(t, err)
+
+	profile, err := file.Profile("")
+	require.NoError(t, err)
+	assert.Equal(t, "https://sho.rt", profile.ServerURL)
+}
+
+func TestLoadConfigFile_Missing(t *testing.T) {
+	for _, path := range []string{"", filepath.Join(t.TempDir(), "missing.yaml")} {
+		file, err := LoadConfigFile(path)
+		require.NoError(t, err)
+
+		profile, err := file.Profile("")
+		require.NoError(t, err)
+		assert.Equal(t, Profile{}, profile)
+	}
+}
+
+func TestLoadConfigFile_Invalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+		errorMsg string
+	}{
+		{
+			name:     "bad YAML",
+			contents: "profiles: [",
+			errorMsg: "failed to parse client config file",
+		},
+		{
+			name:     "bad output format",
+			contents: "profiles:\n  prod:\n    output: xml\n",
+			errorMsg: `profile "prod"`,
+		},
+		{
+			name:     "undefined default profile",
+			contents: "default_profile: prod\nprofiles:\n  staging: {}\n",
+			errorMsg: `default_profile "prod" is not defined`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadConfigFile(writeConfigFile(t, tt.contents))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errorMsg)
+		})
+	}
+}
+
+func TestProfile_ClientOptions(t *testing.T) {
+	opts, err := Profile{APIKey: "key"}.ClientOptions()
+	require.NoError(t, err)
+	assert.Len(t, opts, 1)
+
+	opts, err = Profile{Proxy: "http://proxy.internal:3128"}.ClientOptions()
+	require.NoError(t, err)
+	assert.Len(t, opts, 2)
+
+	_, err = Profile{Proxy: "proxy.internal:3128"}.ClientOptions()
+	assert.ErrorContains(t, err, "proxy must be an http or https URL")
+
+	_, err = Profile{ClientCert: "cert.pem"}.ClientOptions()
+	assert.ErrorContains(t, err, "set together")
+
+	_, err = Profile{CACert: filepath.Join(t.TempDir(), "missing.pem")}.ClientOptions()
+	assert.ErrorContains(t, err, "failed to read CA certificate")
+}
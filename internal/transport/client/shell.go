@@ -0,0 +1,424 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/pflag"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	apiclient "github.com/joshdurbin/url-shortener/pkg/urlshortener/client"
+)
+
+// maxHistory is the number of lines kept in the shell history file
+const maxHistory = 1000
+
+// shellCommands are the commands the shell understands, in help order
+var shellCommands = []struct {
+	name  string
+	usage string
+}{
+	{"create", "create <url> [--max-uses N] [--tag T]... [--redirect-status N] [--backup-url URL] [--param K=V]... [--forward-query] [--campaign NAME] [--dedupe-seconds N] [--reuse] [--burn-after-reading]"},
+	{"validate", "validate <url> [create options]"},
+	{"get", "get <code>"},
+	{"delete", "delete <code>"},
+	{"list", "list [--campaign NAME | --owner ID]"},
+	{"campaigns", "campaigns"},
+	{"stats", "stats [code]"},
+	{"top", "top [--window 24h] [--limit N] [--trending]"},
+	{"help", "help"},
+	{"exit", "exit"},
+}
+
+// Shell is an interactive prompt for managing links. On a terminal it offers
+// tab completion of commands and short codes and keeps a persistent history;
+// otherwise it reads one command per line, e.g. from a script.
+type Shell struct {
+	commands    *Commands
+	client      apiclient.API
+	historyFile string
+	history     []string
+	codes       []string // Short codes offered by tab completion
+	timeout     time.Duration
+	out         io.Writer
+}
+
+// NewShell creates a shell running commands, which also sets the output
+// format. An empty historyFile disables persistent history.
+func NewShell(commands *Commands, historyFile string) *Shell {
+	return &Shell{
+		commands:    commands,
+		client:      commands.client,
+		historyFile: historyFile,
+		timeout:     10 * time.Second,
+		out:         os.Stdout,
+	}
+}
+
+// Run reads and executes commands until exit, Ctrl-D or end of input
+func (s *Shell) Run(ctx context.Context, in io.Reader, out io.Writer) error {
+	s.out = out
+	file, ok := in.(*os.File)
+	if !ok || !isTerminal(int(file.Fd())) {
+		return s.runScript(ctx, in)
+	}
+
+	s.loadHistory()
+	s.refreshCodes(ctx)
+	fmt.Fprintf(out, "Connected to %s. Type 'help' for commands, Tab to complete.\n", s.client.ServerURL())
+
+	editor := &lineEditor{
+		in:       bufio.NewReader(file),
+		out:      out,
+		prompt:   "url-shortener> ",
+		complete: s.complete,
+	}
+	for {
+		restore, err := makeRaw(int(file.Fd()))
+		if err != nil {
+			return fmt.Errorf("failed to enter raw terminal mode: %w", err)
+		}
+		editor.history = s.history
+		line, err := editor.readLine()
+		if restoreErr := restore(); restoreErr != nil {
+			return fmt.Errorf("failed to restore terminal mode: %w", restoreErr)
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		s.addHistory(line)
+		if s.execute(ctx, line) {
+			return nil
+		}
+	}
+}
+
+// runScript executes commands read line by line without prompting
+func (s *Shell) runScript(ctx context.Context, in io.Reader) error {
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if s.execute(ctx, line) {
+			return nil
+		}
+	}
+	return scanner.Err()
+}
+
+// execute runs one command line, printing errors, and reports whether the
+// shell should exit
+func (s *Shell) execute(ctx context.Context, line string) bool {
+	args, err := splitArgs(line)
+	if err != nil {
+		fmt.Fprintf(s.out, "Error: %v\n", err)
+		return false
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	switch args[0] {
+	case "create":
+		err = s.create(ctx, args[1:])
+	case "validate":
+		err = s.validate(ctx, args[1:])
+	case "get":
+		err = s.withCode(args, func(code string) error { return s.commands.Get(ctx, code) })
+	case "delete":
+		err = s.withCode(args, func(code string) error { return s.commands.Delete(ctx, code) })
+		s.refreshCodes(ctx)
+	case "list":
+		err = s.list(ctx, args[1:])
+		s.refreshCodes(ctx)
+	case "campaigns":
+		err = s.commands.Campaigns(ctx)
+	case "stats":
+		if len(args) > 1 {
+			err = s.commands.CodeStats(ctx, args[1], 0)
+		} else {
+			err = s.commands.Stats(ctx)
+		}
+	case "top":
+		err = s.top(ctx, args[1:])
+	case "help":
+		s.help()
+	case "exit", "quit":
+		return true
+	default:
+		err = fmt.Errorf("unknown command %q, type 'help' for commands", args[0])
+	}
+
+	if err != nil {
+		fmt.Fprintf(s.out, "Error: %v\n", err)
+	}
+	return false
+}
+
+// create parses the create command's flags the same way as "client create"
+func (s *Shell) create(ctx context.Context, args []string) error {
+	originalURL, opts, err := s.parseLinkOptions("create", args)
+	if err != nil {
+		return err
+	}
+
+	err = s.commands.Create(ctx, originalURL, opts)
+	if err == nil {
+		s.refreshCodes(ctx)
+	}
+	return err
+}
+
+// validate checks a URL with the create command's flags without creating it
+func (s *Shell) validate(ctx context.Context, args []string) error {
+	originalURL, opts, err := s.parseLinkOptions("validate", args)
+	if err != nil {
+		return err
+	}
+	return s.commands.Validate(ctx, originalURL, opts)
+}
+
+// parseLinkOptions parses a URL and the link options shared by create and validate
+func (s *Shell) parseLinkOptions(command string, args []string) (string, domain.CreateOptions, error) {
+	flags := pflag.NewFlagSet(command, pflag.ContinueOnError)
+	flags.SetOutput(s.out)
+	maxUses := flags.Int("max-uses", 0, "Deactivate the link after this many redirects (0 = unlimited)")
+	tags := flags.StringSlice("tag", nil, "Tag the link (repeatable)")
+	redirectStatus := flags.Int("redirect-status", 0, "Redirect with 301, 302, 307 or 308 (0 = server default)")
+	backupURL := flags.String("backup-url", "", "Redirect here instead while health checks find the destination broken")
+	queryParams := flags.StringToString("param", nil, "Add a query parameter to the destination on every redirect (repeatable)")
+	forwardQuery := flags.Bool("forward-query", false, "Pass the short link's query parameters on to the destination")
+	campaign := flags.String("campaign", "", "Group the link under a campaign")
+	dedupeSeconds := flags.Int("dedupe-seconds", 0, "Count one click per visitor in this many seconds (0 = server default, -1 = every click)")
+	reuse := flags.Bool("reuse", false, "Return an existing uncapped short URL for the same destination")
+	customDomain := flags.String("domain", "", "Serve the link only on this custom domain")
+	burn := flags.Bool("burn-after-reading", false, "Deactivate the link after its first redirect")
+	if err := flags.Parse(args); err != nil {
+		return "", domain.CreateOptions{}, err
+	}
+	if flags.NArg() != 1 {
+		return "", domain.CreateOptions{}, fmt.Errorf("usage: %s", shellUsage(command))
+	}
+
+	return flags.Arg(0), domain.CreateOptions{
+		MaxUses:          *maxUses,
+		Tags:             *tags,
+		RedirectStatus:   *redirectStatus,
+		ReuseExisting:    *reuse,
+		BackupURL:        *backupURL,
+		QueryParams:      *queryParams,
+		ForwardQuery:     *forwardQuery,
+		Campaign:         *campaign,
+		DedupeSeconds:    *dedupeSeconds,
+		Domain:           *customDomain,
+		BurnAfterReading: *burn,
+	}, nil
+}
+
+// list parses the list command's optional campaign or owner filter
+func (s *Shell) list(ctx context.Context, args []string) error {
+	flags := pflag.NewFlagSet("list", pflag.ContinueOnError)
+	flags.SetOutput(s.out)
+	campaign := flags.String("campaign", "", "Only list the links of this campaign")
+	owner := flags.String("owner", "", "Only list the links created by this owner (\"me\" for your own)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 || (*campaign != "" && *owner != "") {
+		return fmt.Errorf("usage: %s", shellUsage("list"))
+	}
+	if *campaign != "" {
+		return s.commands.ListCampaign(ctx, *campaign)
+	}
+	if *owner != "" {
+		return s.commands.ListOwner(ctx, *owner)
+	}
+	return s.commands.List(ctx)
+}
+
+// top parses the top command's flags the same way as "client top"
+func (s *Shell) top(ctx context.Context, args []string) error {
+	flags := pflag.NewFlagSet("top", pflag.ContinueOnError)
+	flags.SetOutput(s.out)
+	window := flags.String("window", "", "How far back to count clicks, e.g. 1h or 7d")
+	limit := flags.Int("limit", 0, "Number of links")
+	trending := flags.Bool("trending", false, "Order by the gain in clicks over the window before")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		return fmt.Errorf("usage: %s", shellUsage("top"))
+	}
+
+	var age domain.Age
+	if *window != "" {
+		var err error
+		if age, err = domain.ParseAge(*window); err != nil {
+			return fmt.Errorf("invalid --window: %w", err)
+		}
+	}
+	return s.commands.Top(ctx, time.Duration(age), *limit, *trending)
+}
+
+// withCode runs fn with the command's single short code argument
+func (s *Shell) withCode(args []string, fn func(code string) error) error {
+	if len(args) != 2 {
+		return fmt.Errorf("usage: %s <code>", args[0])
+	}
+	return fn(args[1])
+}
+
+// shellUsage returns the usage line of a shell command
+func shellUsage(name string) string {
+	for _, command := range shellCommands {
+		if command.name == name {
+			return command.usage
+		}
+	}
+	return name
+}
+
+// help lists the shell commands
+func (s *Shell) help() {
+	fmt.Fprintln(s.out, "Commands:")
+	for _, command := range shellCommands {
+		fmt.Fprintf(s.out, "  %s\n", command.usage)
+	}
+}
+
+// complete offers command names for the first word and short codes for the
+// argument of get, delete and stats
+func (s *Shell) complete(before, word string) []string {
+	var options []string
+	switch strings.TrimSpace(before) {
+	case "":
+		for _, command := range shellCommands {
+			options = append(options, command.name)
+		}
+	case "get", "delete", "stats":
+		options = s.codes
+	}
+
+	var candidates []string
+	for _, option := range options {
+		if strings.HasPrefix(option, word) {
+			candidates = append(candidates, option)
+		}
+	}
+	return candidates
+}
+
+// refreshCodes fetches the short codes offered by completion; on failure the
+// previous codes are kept
+func (s *Shell) refreshCodes(ctx context.Context) {
+	entries, err := s.client.ListURLs(ctx)
+	if err != nil {
+		return
+	}
+	codes := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		codes = append(codes, entry.ShortCode)
+	}
+	sort.Strings(codes)
+	s.codes = codes
+}
+
+// loadHistory reads the most recent lines of the history file
+func (s *Shell) loadHistory() {
+	if s.historyFile == "" {
+		return
+	}
+	data, err := os.ReadFile(s.historyFile)
+	if err != nil {
+		return
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			s.history = append(s.history, line)
+		}
+	}
+	if len(s.history) > maxHistory {
+		s.history = s.history[len(s.history)-maxHistory:]
+		// Rewrite the file so it does not grow without bound
+		if err := os.WriteFile(s.historyFile, []byte(strings.Join(s.history, "\n")+"\n"), 0600); err != nil {
+			fmt.Fprintf(s.out, "Warning: failed to trim history file: %v\n", err)
+		}
+	}
+}
+
+// addHistory records a line, skipping immediate repeats, and appends it to the history file
+func (s *Shell) addHistory(line string) {
+	if len(s.history) > 0 && s.history[len(s.history)-1] == line {
+		return
+	}
+	s.history = append(s.history, line)
+	if s.historyFile == "" {
+		return
+	}
+
+	file, err := os.OpenFile(s.historyFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		fmt.Fprintf(s.out, "Warning: failed to save history: %v\n", err)
+		return
+	}
+	defer file.Close()
+	fmt.Fprintln(file, line)
+}
+
+// splitArgs splits a command line on spaces, keeping single or double quoted
+// text together
+func splitArgs(line string) ([]string, error) {
+	var args []string
+	var current strings.Builder
+	var quote rune
+	inArg := false
+
+	for _, r := range line {
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+			current.WriteRune(r)
+		case r == '"' || r == '\'':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote", quote)
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	if len(args) == 0 {
+		return nil, fmt.Errorf("empty command")
+	}
+	return args, nil
+}
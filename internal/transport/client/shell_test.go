@@ -0,0 +1,197 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	apiclient "github.com/joshdurbin/url-shortener/pkg/urlshortener/client"
+)
+
+func TestSplitArgs(t *testing.T) {
+	tests := []struct {
+		line    string
+		want    []string
+		wantErr string
+	}{
+		{line: "get abc123", want: []string{"get", "abc123"}},
+		{line: "  list  ", want: []string{"list"}},
+		{line: `create "https://example.com/a b" --tag x`, want: []string{"create", "https://example.com/a b", "--tag", "x"}},
+		{line: `create 'it''s'`, want: []string{"create", "its"}},
+		{line: `create "https://example.com`, wantErr: "unterminated"},
+		{line: "   ", wantErr: "empty command"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			args, err := splitArgs(tt.line)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, args)
+		})
+	}
+}
+
+func TestShell_Complete(t *testing.T) {
+	shell := NewShell(NewCommands(apiclient.NewClient("http://localhost:8080")), "")
+	shell.codes = []string{"abc123", "abd456", "xyz789"}
+
+	assert.Equal(t, []string{"delete"}, shell.complete("", "d"))
+	assert.Equal(t, []string{"exit"}, shell.complete("", "ex"))
+	assert.Len(t, shell.complete("", ""), len(shellCommands))
+	assert.Equal(t, []string{"abc123", "abd456"}, shell.complete("get ", "ab"))
+	assert.Equal(t, []string{"xyz789"}, shell.complete("delete ", "x"))
+	assert.Empty(t, shell.complete("list ", ""), "list takes no arguments")
+	assert.Empty(t, shell.complete("create ", "ab"), "create takes a URL, not a code")
+}
+
+func TestLineEditor_ReadLine(t *testing.T) {
+	complete := func(before, word string) []string {
+		var candidates []string
+		for _, option := range []string{"abc123", "abd456", "delete"} {
+			if strings.HasPrefix(option, word) {
+				candidates = append(candidates, option)
+			}
+		}
+		return candidates
+	}
+
+	tests := []struct {
+		name    string
+		input   string
+		history []string
+		want    string
+		wantOut string
+		wantErr error
+	}{
+		{name: "plain line", input: "list\r", want: "list"},
+		{name: "backspace", input: "lisx\x7ft\r", want: "list"},
+		{name: "ctrl-u clears", input: "junk\x15list\r", want: "list"},
+		{name: "single completion", input: "de\t\r", want: "delete "},
+		{name: "common prefix completion", input: "get a\t\r", want: "get ab"},
+		{name: "ambiguous completion lists candidates", input: "get ab\t\r", want: "get ab", wantOut: "abc123  abd456"},
+		{name: "history up", input: "\x1b[A\x1b[A\r", history: []string{"get abc123", "list"}, want: "get abc123"},
+		{name: "history up and down", input: "\x1b[A\x1b[A\x1b[B\r", history: []string{"get abc123", "list"}, want: "list"},
+		{name: "other escape sequences ignored", input: "li\x1b[Cst\r", want: "list"},
+		{name: "ctrl-c discards the line", input: "delete abc123\x03", want: ""},
+		{name: "ctrl-d on empty line", input: "\x04", wantErr: io.EOF},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			editor := &lineEditor{
+				in:       bufio.NewReader(strings.NewReader(tt.input)),
+				out:      &out,
+				prompt:   "> ",
+				history:  tt.history,
+				complete: complete,
+			}
+
+			line, err := editor.readLine()
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, line)
+			assert.Contains(t, out.String(), tt.wantOut)
+		})
+	}
+}
+
+func TestShell_RunScript(t *testing.T) {
+	var created []domain.CreateURLRequest
+	var deleted []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/api/urls":
+			var req domain.CreateURLRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			created = append(created, req)
+			json.NewEncoder(w).Encode(domain.CreateURLResponse{ShortCode: "abc123", OriginalURL: req.URL, CreatedAt: time.Now()})
+		case r.Method == http.MethodPost && r.URL.Path == "/api/urls/validate":
+			json.NewEncoder(w).Encode(domain.ValidateURLResponse{Valid: true})
+		case r.Method == http.MethodGet && r.URL.Path == "/api/urls":
+			json.NewEncoder(w).Encode([]*domain.URLEntry{{ShortCode: "abc123", OriginalURL: "https://example.com"}})
+		case r.Method == http.MethodDelete:
+			deleted = append(deleted, strings.TrimPrefix(r.URL.Path, "/api/urls/"))
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	script := strings.Join([]string{
+		"# comments and blank lines are skipped",
+		"",
+		`create "https://example.com/a b" --max-uses 3 --tag promo --tag q3`,
+		"create",
+		"validate https://example.com/v --max-uses 2",
+		"validate",
+		"delete abc123",
+		"frobnicate",
+		"exit",
+		"delete never-reached",
+	}, "\n")
+
+	shell := NewShell(NewCommands(apiclient.NewClient(server.URL)), "")
+	var out bytes.Buffer
+	captureOutput(t, func() {
+		require.NoError(t, shell.Run(context.Background(), strings.NewReader(script), &out))
+	})
+
+	require.Len(t, created, 1)
+	assert.Equal(t, "https://example.com/a b", created[0].URL)
+	assert.Equal(t, 3, created[0].MaxUses)
+	assert.Equal(t, []string{"promo", "q3"}, created[0].Tags)
+	assert.Equal(t, []string{"abc123"}, deleted)
+	assert.Equal(t, []string{"abc123"}, shell.codes, "codes are refreshed after create and delete")
+	assert.Contains(t, out.String(), "Error: usage: create <url>")
+	assert.Contains(t, out.String(), "Error: usage: validate <url>")
+	assert.Contains(t, out.String(), `Error: unknown command "frobnicate"`)
+}
+
+func TestShell_History(t *testing.T) {
+	historyFile := filepath.Join(t.TempDir(), "history")
+	lines := make([]string, maxHistory+5)
+	for i := range lines {
+		lines[i] = "get code" + strings.Repeat("x", i%3)
+	}
+	require.NoError(t, os.WriteFile(historyFile, []byte(strings.Join(lines, "\n")+"\n"), 0600))
+
+	shell := NewShell(NewCommands(apiclient.NewClient("http://localhost:8080")), historyFile)
+	shell.out = io.Discard
+	shell.loadHistory()
+	assert.Len(t, shell.history, maxHistory, "history is capped")
+
+	shell.addHistory("list")
+	shell.addHistory("list")
+	assert.Equal(t, "list", shell.history[len(shell.history)-1])
+	assert.NotEqual(t, "list", shell.history[len(shell.history)-2], "immediate repeats are not recorded")
+
+	data, err := os.ReadFile(historyFile)
+	require.NoError(t, err)
+	saved := strings.Split(strings.TrimSpace(string(data)), "\n")
+	assert.Len(t, saved, maxHistory+1, "the file was trimmed, then the new line appended")
+	assert.Equal(t, "list", saved[len(saved)-1])
+}
@@ -0,0 +1,16 @@
+//go:build !linux && !darwin
+
+package client
+
+import "errors"
+
+// isTerminal reports false where raw mode is not supported, so the shell
+// reads plain lines without completion or history recall
+func isTerminal(fd int) bool {
+	return false
+}
+
+// makeRaw is not supported on this platform
+func makeRaw(fd int) (func() error, error) {
+	return nil, errors.New("raw terminal mode is not supported on this platform")
+}
@@ -0,0 +1,38 @@
+package http
+
+import (
+	"log"
+	"net/http"
+	"strings"
+)
+
+// Abuse handles /api/admin/abuse: GET lists the links flagged for click
+// fraud; DELETE /api/admin/abuse/{shortCode} clears a link's flag, lifting
+// any throttling or disabling
+func (h *Handler) Abuse(w http.ResponseWriter, r *http.Request) {
+	if h.abuse == nil {
+		writeError(w, http.StatusNotImplemented, "Abuse detection is not configured")
+		return
+	}
+
+	shortCode := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/admin/abuse"), "/")
+	if shortCode == "" {
+		if r.Method != http.MethodGet {
+			writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+		writeJSON(w, http.StatusOK, h.abuse.Flags())
+		return
+	}
+
+	if r.Method != http.MethodDelete {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	if err := h.abuse.Clear(shortCode); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	log.Printf("[INFO] Cleared the abuse flag of '%s'", shortCode)
+	w.WriteHeader(http.StatusNoContent)
+}
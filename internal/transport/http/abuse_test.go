@@ -0,0 +1,85 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/joshdurbin/url-shortener/internal/abuse"
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	"github.com/joshdurbin/url-shortener/internal/service/mocks"
+)
+
+func TestHandler_Abuse(t *testing.T) {
+	config := abuse.DefaultConfig()
+	config.MaxClientClicks = 1
+	config.Action = domain.AbuseActionDisable
+	detector := abuse.New(config, nil)
+	server := NewServer(&mocks.URLShortener{}, "8080", "http://localhost:8080", false, WithAbuseDetector(detector))
+
+	serve := func(method, target string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, nil)
+		w := httptest.NewRecorder()
+		server.server.Handler.ServeHTTP(w, req)
+		return w
+	}
+
+	w := serve(http.MethodGet, "/api/admin/abuse")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `[]`, w.Body.String())
+
+	visitor := domain.RedirectRequest{ClientIP: "203.0.113.7"}
+	require.NoError(t, detector.Screen("abc123", visitor))
+	require.ErrorIs(t, detector.Screen("abc123", visitor), domain.ErrLinkSuspended)
+
+	w = serve(http.MethodGet, "/api/admin/abuse")
+	require.Equal(t, http.StatusOK, w.Code)
+	var flags []domain.AbuseFlag
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &flags))
+	require.Len(t, flags, 1)
+	assert.Equal(t, "abc123", flags[0].ShortCode)
+	assert.Equal(t, domain.AbuseClientSpike, flags[0].Reason)
+	assert.Equal(t, "203.0.113.7", flags[0].Source)
+	assert.Equal(t, domain.AbuseActionDisable, flags[0].Action)
+
+	w = serve(http.MethodPost, "/api/admin/abuse")
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	w = serve(http.MethodGet, "/api/admin/abuse/abc123")
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+
+	w = serve(http.MethodDelete, "/api/admin/abuse/abc123")
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	w = serve(http.MethodDelete, "/api/admin/abuse/abc123")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Empty(t, detector.Flags())
+}
+
+func TestHandler_AbuseNotConfigured(t *testing.T) {
+	server := NewServer(&mocks.URLShortener{}, "8080", "http://localhost:8080", false)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/admin/abuse", nil)
+	w := httptest.NewRecorder()
+	server.server.Handler.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotImplemented, w.Code)
+}
+
+func TestServer_RedirectSuspended(t *testing.T) {
+	mockService := &mocks.URLShortener{}
+	mockService.On("GetOriginalURL", mock.Anything, "abc123", mock.Anything).
+		Return("", 0, fmt.Errorf("short code abc123 is disabled: %w", domain.ErrLinkSuspended))
+
+	server := NewServer(mockService, "8080", "http://localhost:8080", false)
+
+	req := httptest.NewRequest(http.MethodGet, "/abc123", nil)
+	w := httptest.NewRecorder()
+	server.server.Handler.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Contains(t, w.Body.String(), "temporarily suspended")
+}
@@ -0,0 +1,89 @@
+package http
+
+import (
+	"embed"
+	"io/fs"
+	"log"
+	"net/http"
+	"net/url"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+)
+
+// adminAssets holds the single-page admin dashboard served at /admin/
+//
+//go:embed static/admin
+var adminAssets embed.FS
+
+// adminCSP is the Content-Security-Policy for the dashboard without identity provider sign-in
+const adminCSP = "default-src 'self'; img-src 'self' data:; frame-ancestors 'none'"
+
+// AdminHandler serves the embedded admin dashboard. The assets themselves are
+// public; the dashboard calls the JSON API with the API key or identity
+// provider token the user signs in with, so the AuthMiddleware still guards
+// every read and write.
+func (h *Handler) AdminHandler() http.Handler {
+	assets, err := fs.Sub(adminAssets, "static/admin")
+	if err != nil {
+		panic(err) // The embedded directory is fixed at build time
+	}
+	files := http.StripPrefix("/admin/", http.FileServer(http.FS(assets)))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+
+		config, err := h.dashboardConfig(r)
+		if err != nil {
+			log.Printf("[ADMIN] Failed to load identity provider metadata: %v", err)
+		}
+
+		csp := adminCSP
+		if config.OIDC != nil {
+			// The dashboard exchanges the authorization code with the provider directly
+			if endpoint, err := url.Parse(config.OIDC.TokenEndpoint); err == nil {
+				csp += "; connect-src 'self' " + endpoint.Scheme + "://" + endpoint.Host
+			}
+		}
+		w.Header().Set("Content-Security-Policy", csp)
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.Header().Set("Cache-Control", "no-cache")
+
+		if r.URL.Path == "/admin/config.json" {
+			if err != nil {
+				writeError(w, http.StatusServiceUnavailable, "Identity provider unavailable")
+				return
+			}
+			writeJSON(w, http.StatusOK, config)
+			return
+		}
+		files.ServeHTTP(w, r)
+	})
+}
+
+// dashboardConfig describes how the dashboard signs users in. Provider
+// metadata is fetched once and cached by the authenticator.
+func (h *Handler) dashboardConfig(r *http.Request) (domain.DashboardConfig, error) {
+	if h.authenticator == nil {
+		return domain.DashboardConfig{}, nil
+	}
+	oidcConfig, enabled := h.authenticator.OIDC()
+	if !enabled {
+		return domain.DashboardConfig{}, nil
+	}
+
+	metadata, err := h.authenticator.ProviderMetadata(r.Context())
+	if err != nil {
+		return domain.DashboardConfig{}, err
+	}
+	return domain.DashboardConfig{
+		OIDC: &domain.DashboardOIDC{
+			ClientID:              oidcConfig.ClientID,
+			AuthorizationEndpoint: metadata.AuthorizationEndpoint,
+			TokenEndpoint:         metadata.TokenEndpoint,
+			Scopes:                oidcConfig.Scopes,
+		},
+	}, nil
+}
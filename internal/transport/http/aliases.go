@@ -0,0 +1,66 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	"github.com/joshdurbin/url-shortener/internal/service"
+)
+
+// Aliases handles /api/urls/{shortCode}/aliases: GET lists the link's aliases
+// and POST adds one; DELETE /api/urls/{shortCode}/aliases/{alias} removes one
+func (h *Handler) Aliases(w http.ResponseWriter, r *http.Request) {
+	shortCode, alias, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/api/urls/"), "/aliases")
+	alias = strings.Trim(alias, "/")
+	if shortCode == "" {
+		writeError(w, http.StatusBadRequest, "Short code is required")
+		return
+	}
+
+	if r.Method != http.MethodGet && !h.authorizeOwner(w, r, shortCode) {
+		return
+	}
+
+	var err error
+	switch {
+	case alias == "" && r.Method == http.MethodGet:
+		var aliases []domain.Alias
+		if aliases, err = h.shortener.ListAliases(r.Context(), shortCode); err == nil {
+			writeJSON(w, http.StatusOK, aliases)
+			return
+		}
+	case alias == "" && r.Method == http.MethodPost:
+		var req domain.AddAliasRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			log.Printf("[ERROR] Invalid JSON in alias request: %v", err)
+			writeError(w, http.StatusBadRequest, "Invalid JSON")
+			return
+		}
+		var added *domain.Alias
+		if added, err = h.shortener.AddAlias(r.Context(), shortCode, req.Alias); err == nil {
+			log.Printf("[INFO] Added alias '%s' to '%s'", added.Alias, shortCode)
+			writeJSON(w, http.StatusCreated, added)
+			return
+		}
+	case alias != "" && r.Method == http.MethodDelete:
+		if err = h.shortener.DeleteAlias(r.Context(), shortCode, alias); err == nil {
+			log.Printf("[INFO] Removed alias '%s' from '%s'", alias, shortCode)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	if errors.Is(err, service.ErrAliasesNotConfigured) {
+		writeError(w, http.StatusNotImplemented, "Aliases are not configured")
+		return
+	}
+	log.Printf("[ERROR] Failed to handle aliases for code '%s': %v", shortCode, err)
+	writeServiceError(w, err)
+}
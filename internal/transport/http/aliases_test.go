@@ -0,0 +1,132 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	"github.com/joshdurbin/url-shortener/internal/service"
+	"github.com/joshdurbin/url-shortener/internal/service/mocks"
+)
+
+func TestHandler_Aliases(t *testing.T) {
+	created := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	alias := domain.Alias{Alias: "spring", ShortCode: "abc123", CreatedAt: created}
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		setupMocks     func(*mocks.URLShortener)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:   "list",
+			method: http.MethodGet,
+			path:   "/api/urls/abc123/aliases",
+			setupMocks: func(shortener *mocks.URLShortener) {
+				shortener.On("ListAliases", mock.Anything, "abc123").Return([]domain.Alias{alias}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `[{"alias":"spring","short_code":"abc123","created_at":"2024-03-01T12:00:00Z"}]`,
+		},
+		{
+			name:   "list unknown code",
+			method: http.MethodGet,
+			path:   "/api/urls/missing/aliases",
+			setupMocks: func(shortener *mocks.URLShortener) {
+				shortener.On("ListAliases", mock.Anything, "missing").Return(nil, domain.ErrURLNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:   "add",
+			method: http.MethodPost,
+			path:   "/api/urls/abc123/aliases",
+			body:   `{"alias":"spring"}`,
+			setupMocks: func(shortener *mocks.URLShortener) {
+				shortener.On("AddAlias", mock.Anything, "abc123", "spring").Return(&alias, nil)
+			},
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `"alias":"spring"`,
+		},
+		{
+			name:   "add taken alias",
+			method: http.MethodPost,
+			path:   "/api/urls/abc123/aliases",
+			body:   `{"alias":"def456"}`,
+			setupMocks: func(shortener *mocks.URLShortener) {
+				shortener.On("AddAlias", mock.Anything, "abc123", "def456").Return(nil, domain.ErrShortCodeTaken)
+			},
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:           "add invalid JSON",
+			method:         http.MethodPost,
+			path:           "/api/urls/abc123/aliases",
+			body:           `{`,
+			setupMocks:     func(shortener *mocks.URLShortener) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "delete",
+			method: http.MethodDelete,
+			path:   "/api/urls/abc123/aliases/spring",
+			setupMocks: func(shortener *mocks.URLShortener) {
+				shortener.On("DeleteAlias", mock.Anything, "abc123", "spring").Return(nil)
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:   "delete unknown alias",
+			method: http.MethodDelete,
+			path:   "/api/urls/abc123/aliases/autumn",
+			setupMocks: func(shortener *mocks.URLShortener) {
+				shortener.On("DeleteAlias", mock.Anything, "abc123", "autumn").Return(domain.ErrAliasNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "delete without alias",
+			method:         http.MethodDelete,
+			path:           "/api/urls/abc123/aliases",
+			setupMocks:     func(shortener *mocks.URLShortener) {},
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+		{
+			name:   "not configured",
+			method: http.MethodGet,
+			path:   "/api/urls/abc123/aliases",
+			setupMocks: func(shortener *mocks.URLShortener) {
+				shortener.On("ListAliases", mock.Anything, "abc123").Return(nil, service.ErrAliasesNotConfigured)
+			},
+			expectedStatus: http.StatusNotImplemented,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shortener := &mocks.URLShortener{}
+			tt.setupMocks(shortener)
+			server := NewServer(shortener, "8080", "http://localhost:8080", false)
+
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+			server.server.Handler.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedBody != "" {
+				assert.Contains(t, w.Body.String(), tt.expectedBody)
+			}
+			shortener.AssertExpectations(t)
+		})
+	}
+}
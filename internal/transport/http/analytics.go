@@ -0,0 +1,230 @@
+package http
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joshdurbin/url-shortener/internal/analytics"
+	"github.com/joshdurbin/url-shortener/internal/domain"
+)
+
+// Referrers handles GET /api/urls/{shortCode}/referrers, a link's clicks by
+// referring domain and by UTM parameters, with an optional ?limit= per section
+func (h *Handler) Referrers(w http.ResponseWriter, r *http.Request) {
+	shortCode, limit, ok := h.linkReport(w, r, "/referrers")
+	if !ok {
+		return
+	}
+
+	report, err := h.analytics.ReferrerReport(r.Context(), shortCode, limit)
+	if err != nil {
+		log.Printf("[ERROR] Failed to build the referrer report for code '%s': %v", shortCode, err)
+		writeServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, report)
+}
+
+// Countries handles GET /api/urls/{shortCode}/countries, a link's clicks by
+// visitor country and region, with an optional ?limit=
+func (h *Handler) Countries(w http.ResponseWriter, r *http.Request) {
+	shortCode, limit, ok := h.linkReport(w, r, "/countries")
+	if !ok {
+		return
+	}
+
+	report, err := h.analytics.CountryReport(r.Context(), shortCode, limit)
+	if err != nil {
+		log.Printf("[ERROR] Failed to build the country report for code '%s': %v", shortCode, err)
+		writeServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, report)
+}
+
+// TimeSeries handles GET /api/urls/{shortCode}/timeseries, a link's clicks
+// per ?interval= (minute, hour or day; hour by default) from ?from= until
+// ?to=, both RFC 3339 times. to defaults to now and from to a span before it
+// that depends on the interval.
+func (h *Handler) TimeSeries(w http.ResponseWriter, r *http.Request) {
+	shortCode, _, ok := h.linkReport(w, r, "/timeseries")
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	interval := domain.TimeSeriesInterval(query.Get("interval"))
+	if interval == "" {
+		interval = domain.IntervalHour
+	}
+	if interval.Duration() == 0 {
+		writeError(w, http.StatusBadRequest, "interval must be minute, hour or day")
+		return
+	}
+
+	to := time.Now()
+	if value := query.Get("to"); value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "to must be an RFC 3339 time, e.g. 2024-05-01T00:00:00Z")
+			return
+		}
+		to = parsed
+	}
+	from := to.Add(-analytics.DefaultTimeSeriesSpan(interval))
+	if value := query.Get("from"); value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "from must be an RFC 3339 time, e.g. 2024-05-01T00:00:00Z")
+			return
+		}
+		from = parsed
+	}
+	if !from.Before(to) {
+		writeError(w, http.StatusBadRequest, "from must be before to")
+		return
+	}
+	// Aligning both ends to whole buckets adds at most one
+	if to.Sub(from)/interval.Duration() >= analytics.MaxTimeSeriesBuckets {
+		writeError(w, http.StatusBadRequest, "at most "+strconv.Itoa(analytics.MaxTimeSeriesBuckets)+" buckets may be requested, use a longer interval")
+		return
+	}
+
+	series, err := h.analytics.TimeSeries(r.Context(), shortCode, interval, from, to)
+	if err != nil {
+		log.Printf("[ERROR] Failed to build the time series for code '%s': %v", shortCode, err)
+		writeServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, series)
+}
+
+// TopReferrers handles GET /api/stats/top-referrers, the referring domains
+// with the most clicks across all links, with an optional ?limit=
+func (h *Handler) TopReferrers(w http.ResponseWriter, r *http.Request) {
+	limit, ok := h.statsReport(w, r)
+	if !ok {
+		return
+	}
+
+	referrers, err := h.analytics.TopReferrers(r.Context(), limit)
+	if err != nil {
+		log.Printf("[ERROR] Failed to list top referrers: %v", err)
+		writeServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, referrers)
+}
+
+// TopCountries handles GET /api/stats/top-countries, the countries with the
+// most clicks across all links, with an optional ?limit=
+func (h *Handler) TopCountries(w http.ResponseWriter, r *http.Request) {
+	limit, ok := h.statsReport(w, r)
+	if !ok {
+		return
+	}
+
+	countries, err := h.analytics.TopCountries(r.Context(), limit)
+	if err != nil {
+		log.Printf("[ERROR] Failed to list top countries: %v", err)
+		writeServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, countries)
+}
+
+// TopLinks handles GET /api/stats/top, the links with the most clicks over a
+// sliding ?window= (24h by default, "30d" style days allowed), with an
+// optional ?limit=. With ?sort=trending links are ordered by their gain over
+// the window before.
+func (h *Handler) TopLinks(w http.ResponseWriter, r *http.Request) {
+	limit, ok := h.statsReport(w, r)
+	if !ok {
+		return
+	}
+
+	window := analytics.DefaultTopWindow
+	if value := r.URL.Query().Get("window"); value != "" {
+		age, err := domain.ParseAge(value)
+		if err != nil || time.Duration(age) < analytics.MinTopWindow || time.Duration(age) > analytics.MaxTopWindow {
+			writeError(w, http.StatusBadRequest, "window must be a duration between 1m and 30d")
+			return
+		}
+		window = time.Duration(age)
+	}
+
+	sort := domain.TopLinksSort(r.URL.Query().Get("sort"))
+	switch sort {
+	case "":
+		sort = domain.TopLinksByClicks
+	case domain.TopLinksByClicks, domain.TopLinksByTrending:
+	default:
+		writeError(w, http.StatusBadRequest, "sort must be clicks or trending")
+		return
+	}
+
+	links, err := h.analytics.TopLinks(r.Context(), window, sort, limit)
+	if err != nil {
+		log.Printf("[ERROR] Failed to list top links: %v", err)
+		writeServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, links)
+}
+
+// linkReport checks a request for one link's analytics report at
+// /api/urls/{shortCode}{suffix}, returning its short code and limit. Otherwise
+// it writes the error response: 404 for unknown codes rather than an empty
+// report.
+func (h *Handler) linkReport(w http.ResponseWriter, r *http.Request, suffix string) (string, int, bool) {
+	limit, ok := h.statsReport(w, r)
+	if !ok {
+		return "", 0, false
+	}
+
+	shortCode := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/urls/"), suffix)
+	if shortCode == "" {
+		writeError(w, http.StatusBadRequest, "Short code is required")
+		return "", 0, false
+	}
+
+	if _, err := h.shortener.GetURLInfo(r.Context(), shortCode); err != nil {
+		log.Printf("[ERROR] Failed to get URL info for code '%s': %v", shortCode, err)
+		writeServiceError(w, err)
+		return "", 0, false
+	}
+	return shortCode, limit, true
+}
+
+// statsReport checks that analytics are configured and the request is a GET,
+// and parses its ?limit=, writing the error response when any check fails
+func (h *Handler) statsReport(w http.ResponseWriter, r *http.Request) (int, bool) {
+	if h.analytics == nil {
+		writeError(w, http.StatusNotImplemented, "Click analytics are not configured")
+		return 0, false
+	}
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return 0, false
+	}
+
+	value := r.URL.Query().Get("limit")
+	if value == "" {
+		return analytics.DefaultReportLimit, true
+	}
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit < 1 || limit > analytics.MaxReportLimit {
+		writeError(w, http.StatusBadRequest, "limit must be between 1 and "+strconv.Itoa(analytics.MaxReportLimit))
+		return 0, false
+	}
+	return limit, true
+}
@@ -0,0 +1,271 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/joshdurbin/url-shortener/internal/analytics"
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	repoMocks "github.com/joshdurbin/url-shortener/internal/repository/mocks"
+	"github.com/joshdurbin/url-shortener/internal/service/mocks"
+)
+
+func TestHandler_Referrers(t *testing.T) {
+	tests := []struct {
+		name           string
+		path           string
+		setupMocks     func(*mocks.URLShortener, *repoMocks.AnalyticsRepository)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name: "link report",
+			path: "/api/urls/abc123/referrers?limit=5",
+			setupMocks: func(shortener *mocks.URLShortener, store *repoMocks.AnalyticsRepository) {
+				shortener.On("GetURLInfo", mock.Anything, "abc123").Return(&domain.URLEntry{ShortCode: "abc123"}, nil)
+				store.On("ListReferrerClicks", mock.Anything, "abc123", 5).Return([]domain.ReferrerClicks{{Referrer: "twitter.com", Clicks: 3}}, nil)
+				store.On("ListUTMClicks", mock.Anything, "abc123", 5).Return([]domain.UTMClicks{}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"short_code":"abc123","referrers":[{"referrer":"twitter.com","clicks":3}],"utm":[]}`,
+		},
+		{
+			name: "unknown link",
+			path: "/api/urls/missing/referrers",
+			setupMocks: func(shortener *mocks.URLShortener, store *repoMocks.AnalyticsRepository) {
+				shortener.On("GetURLInfo", mock.Anything, "missing").Return(nil, domain.ErrURLNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "limit out of range",
+			path:           "/api/urls/abc123/referrers?limit=500",
+			setupMocks:     func(*mocks.URLShortener, *repoMocks.AnalyticsRepository) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "limit must be between 1 and 100",
+		},
+		{
+			name: "top referrers",
+			path: "/api/stats/top-referrers",
+			setupMocks: func(shortener *mocks.URLShortener, store *repoMocks.AnalyticsRepository) {
+				store.On("ListTopReferrers", mock.Anything, analytics.DefaultReportLimit).Return([]domain.ReferrerClicks{{Referrer: "twitter.com", Clicks: 4, Links: 2}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"referrers":[{"referrer":"twitter.com","clicks":4,"links":2}]}`,
+		},
+		{
+			name: "link countries",
+			path: "/api/urls/abc123/countries",
+			setupMocks: func(shortener *mocks.URLShortener, store *repoMocks.AnalyticsRepository) {
+				shortener.On("GetURLInfo", mock.Anything, "abc123").Return(&domain.URLEntry{ShortCode: "abc123"}, nil)
+				store.On("ListCountryClicks", mock.Anything, "abc123", analytics.DefaultReportLimit).Return([]domain.CountryClicks{{Country: "US", Region: "CA", Clicks: 2}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"short_code":"abc123","countries":[{"country":"US","region":"CA","clicks":2}]}`,
+		},
+		{
+			name: "top countries",
+			path: "/api/stats/top-countries?limit=3",
+			setupMocks: func(shortener *mocks.URLShortener, store *repoMocks.AnalyticsRepository) {
+				store.On("ListTopCountries", mock.Anything, 3).Return([]domain.CountryClicks{{Country: "US", Clicks: 4, Links: 2}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"countries":[{"country":"US","clicks":4,"links":2}]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shortener := &mocks.URLShortener{}
+			store := &repoMocks.AnalyticsRepository{}
+			tt.setupMocks(shortener, store)
+			server := NewServer(shortener, "8080", "http://localhost:8080", false, WithAnalytics(analytics.New(analytics.DefaultConfig(), store)))
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			w := httptest.NewRecorder()
+			server.server.Handler.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedBody != "" {
+				assert.Contains(t, w.Body.String(), tt.expectedBody)
+			}
+			shortener.AssertExpectations(t)
+			store.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandler_ReferrersNotConfigured(t *testing.T) {
+	server := NewServer(&mocks.URLShortener{}, "8080", "http://localhost:8080", false)
+
+	for _, path := range []string{"/api/urls/abc123/referrers", "/api/urls/abc123/countries", "/api/stats/top-referrers", "/api/stats/top-countries", "/api/stats/top", "/api/urls/abc123/timeseries"} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		w := httptest.NewRecorder()
+		server.server.Handler.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotImplemented, w.Code, path)
+	}
+}
+
+func TestHandler_TopLinks(t *testing.T) {
+	tests := []struct {
+		name           string
+		path           string
+		setupMocks     func(*repoMocks.AnalyticsRepository)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name: "defaults",
+			path: "/api/stats/top",
+			setupMocks: func(store *repoMocks.AnalyticsRepository) {
+				store.On("ListTopLinks", mock.Anything, mock.Anything, mock.Anything, domain.TopLinksByClicks, analytics.DefaultReportLimit).
+					Return([]domain.TopLink{{ShortCode: "abc123", OriginalURL: "https://example.com", Clicks: 3, PreviousClicks: 1}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"links":[{"short_code":"abc123","original_url":"https://example.com","clicks":3,"previous_clicks":1}]`,
+		},
+		{
+			name: "trending over days",
+			path: "/api/stats/top?window=7d&limit=5&sort=trending",
+			setupMocks: func(store *repoMocks.AnalyticsRepository) {
+				store.On("ListTopLinks", mock.Anything, mock.MatchedBy(func(since time.Time) bool {
+					return time.Since(since) > 7*24*time.Hour-time.Minute
+				}), mock.Anything, domain.TopLinksByTrending, 5).Return([]domain.TopLink{}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"window":"7d"`,
+		},
+		{
+			name:           "window too short",
+			path:           "/api/stats/top?window=30s",
+			setupMocks:     func(*repoMocks.AnalyticsRepository) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "window must be a duration between 1m and 30d",
+		},
+		{
+			name:           "window too long",
+			path:           "/api/stats/top?window=31d",
+			setupMocks:     func(*repoMocks.AnalyticsRepository) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "unknown sort",
+			path:           "/api/stats/top?sort=newest",
+			setupMocks:     func(*repoMocks.AnalyticsRepository) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "sort must be clicks or trending",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &repoMocks.AnalyticsRepository{}
+			tt.setupMocks(store)
+			server := NewServer(&mocks.URLShortener{}, "8080", "http://localhost:8080", false, WithAnalytics(analytics.New(analytics.DefaultConfig(), store)))
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			w := httptest.NewRecorder()
+			server.server.Handler.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedBody != "" {
+				assert.Contains(t, w.Body.String(), tt.expectedBody)
+			}
+			store.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandler_TimeSeries(t *testing.T) {
+	day := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name           string
+		path           string
+		setupMocks     func(*mocks.URLShortener, *repoMocks.AnalyticsRepository)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name: "daily",
+			path: "/api/urls/abc123/timeseries?interval=day&from=2024-05-01T00:00:00Z&to=2024-05-03T00:00:00Z",
+			setupMocks: func(shortener *mocks.URLShortener, store *repoMocks.AnalyticsRepository) {
+				shortener.On("GetURLInfo", mock.Anything, "abc123").Return(&domain.URLEntry{ShortCode: "abc123"}, nil)
+				store.On("ListClickBuckets", mock.Anything, "abc123", day, day.Add(48*time.Hour), 24*time.Hour).
+					Return([]domain.TimeSeriesBucket{{Start: day.Add(24 * time.Hour), Clicks: 3}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"total":3,"buckets":[{"start":"2024-05-01T00:00:00Z","clicks":0},{"start":"2024-05-02T00:00:00Z","clicks":3}]`,
+		},
+		{
+			name: "hourly by default",
+			path: "/api/urls/abc123/timeseries",
+			setupMocks: func(shortener *mocks.URLShortener, store *repoMocks.AnalyticsRepository) {
+				shortener.On("GetURLInfo", mock.Anything, "abc123").Return(&domain.URLEntry{ShortCode: "abc123"}, nil)
+				store.On("ListClickBuckets", mock.Anything, "abc123", mock.Anything, mock.Anything, time.Hour).Return([]domain.TimeSeriesBucket{}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"interval":"hour"`,
+		},
+		{
+			name: "unknown interval",
+			path: "/api/urls/abc123/timeseries?interval=week",
+			setupMocks: func(shortener *mocks.URLShortener, store *repoMocks.AnalyticsRepository) {
+				shortener.On("GetURLInfo", mock.Anything, "abc123").Return(&domain.URLEntry{ShortCode: "abc123"}, nil)
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "interval must be minute, hour or day",
+		},
+		{
+			name: "from after to",
+			path: "/api/urls/abc123/timeseries?from=2024-05-02T00:00:00Z&to=2024-05-01T00:00:00Z",
+			setupMocks: func(shortener *mocks.URLShortener, store *repoMocks.AnalyticsRepository) {
+				shortener.On("GetURLInfo", mock.Anything, "abc123").Return(&domain.URLEntry{ShortCode: "abc123"}, nil)
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "from must be before to",
+		},
+		{
+			name: "too many buckets",
+			path: "/api/urls/abc123/timeseries?interval=minute&from=2024-05-01T00:00:00Z&to=2024-05-02T00:00:00Z",
+			setupMocks: func(shortener *mocks.URLShortener, store *repoMocks.AnalyticsRepository) {
+				shortener.On("GetURLInfo", mock.Anything, "abc123").Return(&domain.URLEntry{ShortCode: "abc123"}, nil)
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "at most 1440 buckets",
+		},
+		{
+			name: "unknown link",
+			path: "/api/urls/missing/timeseries",
+			setupMocks: func(shortener *mocks.URLShortener, store *repoMocks.AnalyticsRepository) {
+				shortener.On("GetURLInfo", mock.Anything, "missing").Return(nil, domain.ErrURLNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shortener := &mocks.URLShortener{}
+			store := &repoMocks.AnalyticsRepository{}
+			tt.setupMocks(shortener, store)
+			server := NewServer(shortener, "8080", "http://localhost:8080", false, WithAnalytics(analytics.New(analytics.DefaultConfig(), store)))
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			w := httptest.NewRecorder()
+			server.server.Handler.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedBody != "" {
+				assert.Contains(t, w.Body.String(), tt.expectedBody)
+			}
+			shortener.AssertExpectations(t)
+			store.AssertExpectations(t)
+		})
+	}
+}
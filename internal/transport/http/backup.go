@@ -0,0 +1,44 @@
+package http
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+)
+
+// Backup handles /api/admin/backup: GET lists the snapshots and POST takes
+// one now
+func (h *Handler) Backup(w http.ResponseWriter, r *http.Request) {
+	if h.backups == nil {
+		writeError(w, http.StatusNotImplemented, "Backups are not configured")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		snapshots, err := h.backups.Snapshots()
+		if err != nil {
+			log.Printf("Error listing backups: %v", err)
+			writeError(w, http.StatusInternalServerError, "Internal server error")
+			return
+		}
+		writeJSON(w, http.StatusOK, snapshots)
+	case http.MethodPost:
+		snapshot, err := h.backups.Backup(r.Context())
+		if err != nil {
+			log.Printf("Error backing up database: %v", err)
+			writeError(w, http.StatusInternalServerError, "Backup failed")
+			return
+		}
+		snapshots, err := h.backups.Snapshots()
+		if err != nil {
+			log.Printf("Error listing backups: %v", err)
+			writeError(w, http.StatusInternalServerError, "Internal server error")
+			return
+		}
+		writeJSON(w, http.StatusCreated, domain.BackupResponse{Snapshot: *snapshot, Snapshots: snapshots})
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
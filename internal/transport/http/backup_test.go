@@ -0,0 +1,77 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/joshdurbin/url-shortener/internal/backup"
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	repoMocks "github.com/joshdurbin/url-shortener/internal/repository/mocks"
+	"github.com/joshdurbin/url-shortener/internal/service/mocks"
+)
+
+func TestHandler_Backup(t *testing.T) {
+	store := &repoMocks.BackupRepository{}
+	store.On("Backup", mock.Anything, mock.AnythingOfType("string")).Run(func(args mock.Arguments) {
+		require.NoError(t, os.WriteFile(args.String(1), []byte("SQLite format 3"), 0o600))
+	}).Return(nil)
+	manager := backup.New(backup.Config{Dir: t.TempDir(), Keep: 5}, store)
+	server := NewServer(&mocks.URLShortener{}, "8080", "http://localhost:8080", false, WithBackups(manager))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/admin/backup", nil)
+	w := httptest.NewRecorder()
+	server.server.Handler.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `[]`, w.Body.String())
+
+	req = httptest.NewRequest(http.MethodPost, "/api/admin/backup", nil)
+	w = httptest.NewRecorder()
+	server.server.Handler.ServeHTTP(w, req)
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	var created domain.BackupResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	assert.Equal(t, int64(15), created.Snapshot.Bytes)
+	assert.Equal(t, []domain.Snapshot{created.Snapshot}, created.Snapshots)
+
+	req = httptest.NewRequest(http.MethodGet, "/api/admin/backup", nil)
+	w = httptest.NewRecorder()
+	server.server.Handler.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"name":"`+created.Snapshot.Name+`"`)
+
+	req = httptest.NewRequest(http.MethodDelete, "/api/admin/backup", nil)
+	w = httptest.NewRecorder()
+	server.server.Handler.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
+
+func TestHandler_BackupError(t *testing.T) {
+	store := &repoMocks.BackupRepository{}
+	store.On("Backup", mock.Anything, mock.Anything).Return(errors.New("disk full"))
+	manager := backup.New(backup.Config{Dir: t.TempDir()}, store)
+	server := NewServer(&mocks.URLShortener{}, "8080", "http://localhost:8080", false, WithBackups(manager))
+
+	req := httptest.NewRequest(http.MethodPost, "/api/admin/backup", nil)
+	w := httptest.NewRecorder()
+	server.server.Handler.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.NotContains(t, w.Body.String(), "disk full")
+}
+
+func TestHandler_BackupNotConfigured(t *testing.T) {
+	server := NewServer(&mocks.URLShortener{}, "8080", "http://localhost:8080", false, WithBackups(nil))
+
+	req := httptest.NewRequest(http.MethodPost, "/api/admin/backup", nil)
+	w := httptest.NewRecorder()
+	server.server.Handler.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotImplemented, w.Code)
+}
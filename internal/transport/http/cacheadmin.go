@@ -0,0 +1,64 @@
+package http
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	"github.com/joshdurbin/url-shortener/internal/service"
+)
+
+// CacheAdmin handles /api/admin/cache: GET /stats reports the link cache's
+// size, unsynced entries and hit ratio, POST /flush syncs its usage now, and
+// GET and DELETE /{shortCode} show or drop one entry
+func (h *Handler) CacheAdmin(w http.ResponseWriter, r *http.Request) {
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/admin/cache"), "/")
+	var err error
+	switch {
+	case path == "" || strings.Contains(path, "/"):
+		writeError(w, http.StatusNotFound, "Not found")
+		return
+	case path == "stats" && r.Method == http.MethodGet:
+		var stats *domain.CacheStats
+		if stats, err = h.shortener.CacheStats(r.Context()); err == nil {
+			writeJSON(w, http.StatusOK, stats)
+			return
+		}
+	case path == "flush" && r.Method == http.MethodPost:
+		var synced int
+		if synced, err = h.shortener.FlushCache(r.Context()); err == nil {
+			log.Printf("[INFO] Flushed %d cache entries", synced)
+			writeJSON(w, http.StatusOK, domain.CacheFlushResponse{Synced: synced})
+			return
+		}
+	case path == "stats" || path == "flush":
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	case r.Method == http.MethodGet:
+		var entry *domain.CacheEntry
+		if entry, err = h.shortener.InspectCache(r.Context(), path); err == nil {
+			writeJSON(w, http.StatusOK, entry)
+			return
+		}
+	case r.Method == http.MethodDelete:
+		if err = h.shortener.InvalidateCache(r.Context(), path); err == nil {
+			log.Printf("[INFO] Invalidated the cache entry of '%s'", path)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	if errors.Is(err, service.ErrCacheNotInspectable) {
+		writeError(w, http.StatusNotImplemented, "The cache does not support inspection")
+		return
+	}
+	if !errors.Is(err, domain.ErrNotFound) {
+		log.Printf("[ERROR] Failed to handle cache request %s %s: %v", r.Method, r.URL.Path, err)
+	}
+	writeServiceError(w, err)
+}
@@ -0,0 +1,81 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/joshdurbin/url-shortener/internal/cache"
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	"github.com/joshdurbin/url-shortener/internal/service"
+	"github.com/joshdurbin/url-shortener/internal/service/mocks"
+)
+
+func TestHandler_CacheAdmin(t *testing.T) {
+	mockService := &mocks.URLShortener{}
+	mockService.On("CacheStats", mock.Anything).
+		Return(&domain.CacheStats{Entries: 2, Dirty: 1, Hits: 3, Misses: 1, HitRatio: 0.75}, nil)
+	mockService.On("FlushCache", mock.Anything).Return(1, nil)
+	mockService.On("InspectCache", mock.Anything, "abc123").
+		Return(&domain.CacheEntry{OriginalURL: "https://example.com", UsageCount: 4}, nil)
+	mockService.On("InspectCache", mock.Anything, "nope").
+		Return(nil, fmt.Errorf("short code nope: %w", domain.ErrNotCached))
+	mockService.On("InvalidateCache", mock.Anything, "abc123").Return(nil)
+	mockService.On("InvalidateCache", mock.Anything, "busy").
+		Return(fmt.Errorf("short code busy: %w", cache.ErrEntryDirty))
+	server := NewServer(mockService, "8080", "http://localhost:8080", false)
+
+	serve := func(method, target string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, nil)
+		w := httptest.NewRecorder()
+		server.server.Handler.ServeHTTP(w, req)
+		return w
+	}
+
+	w := serve(http.MethodGet, "/api/admin/cache/stats")
+	require.Equal(t, http.StatusOK, w.Code)
+	var stats domain.CacheStats
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &stats))
+	assert.Equal(t, 2, stats.Entries)
+	assert.Equal(t, 1, stats.Dirty)
+	assert.Equal(t, 0.75, stats.HitRatio)
+
+	w = serve(http.MethodPost, "/api/admin/cache/flush")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"synced":1}`, w.Body.String())
+
+	w = serve(http.MethodGet, "/api/admin/cache/abc123")
+	require.Equal(t, http.StatusOK, w.Code)
+	var entry domain.CacheEntry
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &entry))
+	assert.Equal(t, "https://example.com", entry.OriginalURL)
+	assert.Equal(t, 4, entry.UsageCount)
+
+	assert.Equal(t, http.StatusNotFound, serve(http.MethodGet, "/api/admin/cache/nope").Code)
+	assert.Equal(t, http.StatusNoContent, serve(http.MethodDelete, "/api/admin/cache/abc123").Code)
+	assert.Equal(t, http.StatusConflict, serve(http.MethodDelete, "/api/admin/cache/busy").Code)
+
+	assert.Equal(t, http.StatusMethodNotAllowed, serve(http.MethodPut, "/api/admin/cache/stats").Code)
+	assert.Equal(t, http.StatusMethodNotAllowed, serve(http.MethodGet, "/api/admin/cache/flush").Code)
+	assert.Equal(t, http.StatusMethodNotAllowed, serve(http.MethodPost, "/api/admin/cache/abc123").Code)
+	assert.Equal(t, http.StatusNotFound, serve(http.MethodGet, "/api/admin/cache").Code)
+	assert.Equal(t, http.StatusNotFound, serve(http.MethodGet, "/api/admin/cache/abc123/extra").Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestHandler_CacheAdminNotInspectable(t *testing.T) {
+	mockService := &mocks.URLShortener{}
+	mockService.On("CacheStats", mock.Anything).Return(nil, service.ErrCacheNotInspectable)
+	server := NewServer(mockService, "8080", "http://localhost:8080", false)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/admin/cache/stats", nil)
+	w := httptest.NewRecorder()
+	server.server.Handler.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotImplemented, w.Code)
+}
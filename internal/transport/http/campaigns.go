@@ -0,0 +1,24 @@
+package http
+
+import (
+	"log"
+	"net/http"
+)
+
+// ListCampaigns handles GET /api/campaigns, listing every campaign with its
+// link count and aggregate clicks
+func (h *Handler) ListCampaigns(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	campaigns, err := h.shortener.ListCampaigns(r.Context())
+	if err != nil {
+		log.Printf("Error listing campaigns: %v", err)
+		writeError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, campaigns)
+}
@@ -0,0 +1,95 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	"github.com/joshdurbin/url-shortener/internal/service/mocks"
+)
+
+func TestHandler_Campaigns(t *testing.T) {
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		setupMocks     func(*mocks.URLShortener)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:   "list campaigns",
+			method: http.MethodGet,
+			path:   "/api/campaigns",
+			setupMocks: func(shortener *mocks.URLShortener) {
+				shortener.On("ListCampaigns", mock.Anything).
+					Return([]domain.Campaign{{Name: "spring-sale", Links: 2, TotalClicks: 9}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `[{"name":"spring-sale","links":2,"total_clicks":9}]`,
+		},
+		{
+			name:   "list campaigns fails",
+			method: http.MethodGet,
+			path:   "/api/campaigns",
+			setupMocks: func(shortener *mocks.URLShortener) {
+				shortener.On("ListCampaigns", mock.Anything).Return(nil, errors.New("database is locked"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+		{
+			name:           "method not allowed",
+			method:         http.MethodPost,
+			path:           "/api/campaigns",
+			setupMocks:     func(shortener *mocks.URLShortener) {},
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+		{
+			name:   "list URLs filtered by campaign",
+			method: http.MethodGet,
+			path:   "/api/urls?campaign=spring-sale",
+			setupMocks: func(shortener *mocks.URLShortener) {
+				shortener.On("GetURLsByCampaign", mock.Anything, "spring-sale").
+					Return([]*domain.URLEntry{{ShortCode: "abc123", Campaign: "spring-sale"}}, nil)
+				shortener.On("LastRemoval").Return(time.Time{})
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"campaign":"spring-sale"`,
+		},
+		{
+			name:   "list URLs with invalid campaign",
+			method: http.MethodGet,
+			path:   "/api/urls?campaign=spring%20sale",
+			setupMocks: func(shortener *mocks.URLShortener) {
+				shortener.On("GetURLsByCampaign", mock.Anything, "spring sale").
+					Return(nil, domain.Invalid("campaign", errors.New(`invalid campaign "spring sale"`)))
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "invalid campaign",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shortener := &mocks.URLShortener{}
+			tt.setupMocks(shortener)
+			server := NewServer(shortener, "8080", "http://localhost:8080", false)
+
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			w := httptest.NewRecorder()
+			server.server.Handler.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedBody != "" {
+				assert.Contains(t, w.Body.String(), tt.expectedBody)
+			}
+			shortener.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package http
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+)
+
+// writeConditionalJSON writes body as a 200 JSON response carrying an ETag
+// over the encoded body and modified as its Last-Modified, or 304 Not Modified
+// with no body when the request's validators show the caller already has it.
+// Dashboards that poll the API then only download links that changed.
+//
+// As in RFC 9110, If-None-Match takes precedence, and If-Modified-Since is
+// only consulted without it. Last-Modified has one-second precision, so the
+// ETag is the reliable validator; usage counts in particular can change
+// within a second.
+func writeConditionalJSON(w http.ResponseWriter, r *http.Request, modified time.Time, body any) {
+	encoded, err := json.Marshal(body)
+	if err != nil {
+		log.Printf("Error encoding response: %v", err)
+		writeError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	encoded = append(encoded, '\n')
+
+	sum := sha256.Sum256(encoded)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+
+	// Clients must revalidate rather than reuse a stored copy unasked
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("ETag", etag)
+	if !modified.IsZero() {
+		w.Header().Set("Last-Modified", modified.UTC().Format(http.TimeFormat))
+	}
+
+	if notModified(r, etag, modified) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(encoded); err != nil {
+		log.Printf("Error writing response: %v", err)
+	}
+}
+
+// notModified reports whether r's conditional headers match the current
+// representation
+func notModified(r *http.Request, etag string, modified time.Time) bool {
+	if match := r.Header.Get("If-None-Match"); match != "" {
+		return etagMatches(match, etag)
+	}
+
+	since := r.Header.Get("If-Modified-Since")
+	if since == "" || modified.IsZero() {
+		return false
+	}
+	t, err := http.ParseTime(since)
+	if err != nil {
+		return false
+	}
+	return !modified.Truncate(time.Second).After(t)
+}
+
+// etagMatches reports whether an If-None-Match list names etag, comparing
+// weakly as If-None-Match requires
+func etagMatches(list, etag string) bool {
+	for _, candidate := range strings.Split(list, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// listModified returns the Last-Modified of a listing: its most recently
+// changed entry, or the last removal of a link when that is later
+func listModified(entries []*domain.URLEntry, removed time.Time) time.Time {
+	modified := removed
+	for _, entry := range entries {
+		if t := entry.LastModified(); t.After(modified) {
+			modified = t
+		}
+	}
+	return modified
+}
@@ -0,0 +1,119 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	"github.com/joshdurbin/url-shortener/internal/service/mocks"
+)
+
+func TestHandler_ConditionalGetURL(t *testing.T) {
+	created := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	updated := created.Add(time.Hour)
+	entry := &domain.URLEntry{ShortCode: "abc123", OriginalURL: "https://example.com", CreatedAt: created, UpdatedAt: &updated}
+
+	get := func(t *testing.T, entry *domain.URLEntry, headers map[string]string) *httptest.ResponseRecorder {
+		shortener := &mocks.URLShortener{}
+		shortener.On("GetURLInfo", mock.Anything, "abc123").Return(entry, nil)
+		server := NewServer(shortener, "8080", "http://localhost:8080", false)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/urls/abc123", nil)
+		for name, value := range headers {
+			req.Header.Set(name, value)
+		}
+		w := httptest.NewRecorder()
+		server.server.Handler.ServeHTTP(w, req)
+		shortener.AssertExpectations(t)
+		return w
+	}
+
+	first := get(t, entry, nil)
+	require.Equal(t, http.StatusOK, first.Code)
+	etag := first.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+	assert.Equal(t, "Fri, 01 Mar 2024 13:00:00 GMT", first.Header().Get("Last-Modified"))
+	assert.Equal(t, "no-cache", first.Header().Get("Cache-Control"))
+
+	tests := []struct {
+		name           string
+		headers        map[string]string
+		expectedStatus int
+	}{
+		{"matching ETag", map[string]string{"If-None-Match": etag}, http.StatusNotModified},
+		{"matching weak ETag in a list", map[string]string{"If-None-Match": `"other", W/` + etag}, http.StatusNotModified},
+		{"any ETag", map[string]string{"If-None-Match": "*"}, http.StatusNotModified},
+		{"stale ETag", map[string]string{"If-None-Match": `"other"`}, http.StatusOK},
+		{"not modified since", map[string]string{"If-Modified-Since": "Fri, 01 Mar 2024 13:00:00 GMT"}, http.StatusNotModified},
+		{"modified since", map[string]string{"If-Modified-Since": "Fri, 01 Mar 2024 12:59:59 GMT"}, http.StatusOK},
+		{"unparseable date", map[string]string{"If-Modified-Since": "yesterday"}, http.StatusOK},
+		{"ETag wins over date", map[string]string{"If-None-Match": `"other"`, "If-Modified-Since": "Fri, 01 Mar 2024 13:00:00 GMT"}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := get(t, entry, tt.headers)
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Equal(t, etag, w.Header().Get("ETag"))
+			if tt.expectedStatus == http.StatusNotModified {
+				assert.Empty(t, w.Body.String())
+			} else {
+				assert.Contains(t, w.Body.String(), `"short_code":"abc123"`)
+			}
+		})
+	}
+
+	t.Run("changed entry gets a new ETag", func(t *testing.T) {
+		changed := *entry
+		changed.UsageCount = 1
+		w := get(t, &changed, map[string]string{"If-None-Match": etag})
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NotEqual(t, etag, w.Header().Get("ETag"))
+	})
+}
+
+func TestHandler_ConditionalListURLs(t *testing.T) {
+	created := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	used := created.Add(2 * time.Hour)
+	entries := []*domain.URLEntry{
+		{ShortCode: "abc123", OriginalURL: "https://example.com", CreatedAt: created},
+		{ShortCode: "def456", OriginalURL: "https://example.org", CreatedAt: created, LastUsedAt: &used},
+	}
+
+	list := func(t *testing.T, removed time.Time, headers map[string]string) *httptest.ResponseRecorder {
+		shortener := &mocks.URLShortener{}
+		shortener.On("GetAllURLs", mock.Anything).Return(entries, nil)
+		shortener.On("LastRemoval").Return(removed)
+		server := NewServer(shortener, "8080", "http://localhost:8080", false)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/urls", nil)
+		for name, value := range headers {
+			req.Header.Set(name, value)
+		}
+		w := httptest.NewRecorder()
+		server.server.Handler.ServeHTTP(w, req)
+		shortener.AssertExpectations(t)
+		return w
+	}
+
+	t.Run("last modified entry", func(t *testing.T) {
+		w := list(t, created, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "Fri, 01 Mar 2024 14:00:00 GMT", w.Header().Get("Last-Modified"))
+
+		w = list(t, created, map[string]string{"If-None-Match": w.Header().Get("ETag")})
+		assert.Equal(t, http.StatusNotModified, w.Code)
+	})
+
+	t.Run("removal after the last change", func(t *testing.T) {
+		removed := used.Add(time.Minute)
+		w := list(t, removed, map[string]string{"If-Modified-Since": "Fri, 01 Mar 2024 14:00:00 GMT"})
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "Fri, 01 Mar 2024 14:01:00 GMT", w.Header().Get("Last-Modified"))
+	})
+}
@@ -0,0 +1,54 @@
+package http
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+)
+
+// Destinations handles /api/admin/destinations: GET lists the allow and deny
+// rules, POST adds one and DELETE ?list=...&pattern=... removes one
+func (h *Handler) Destinations(w http.ResponseWriter, r *http.Request) {
+	if h.destinations == nil {
+		writeError(w, http.StatusNotImplemented, "Destination rules are not configured")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, h.destinations.Rules())
+	case http.MethodPost:
+		var req domain.DestinationRule
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			log.Printf("[ERROR] Invalid JSON in destination rule request: %v", err)
+			writeError(w, http.StatusBadRequest, "Invalid JSON")
+			return
+		}
+		rule, err := h.destinations.Add(r.Context(), req.List, req.Pattern)
+		if err != nil {
+			log.Printf("[ERROR] Failed to add %s destination rule '%s': %v", req.List, req.Pattern, err)
+			writeServiceError(w, err)
+			return
+		}
+		log.Printf("[INFO] Added %s destination rule '%s'", rule.List, rule.Pattern)
+		writeJSON(w, http.StatusCreated, rule)
+	case http.MethodDelete:
+		list := domain.DestinationList(r.URL.Query().Get("list"))
+		pattern := r.URL.Query().Get("pattern")
+		if !domain.ValidDestinationList(list) || pattern == "" {
+			writeError(w, http.StatusBadRequest, "list (allow or deny) and pattern are required")
+			return
+		}
+		if err := h.destinations.Remove(r.Context(), list, pattern); err != nil {
+			log.Printf("[ERROR] Failed to remove %s destination rule '%s': %v", list, pattern, err)
+			writeServiceError(w, err)
+			return
+		}
+		log.Printf("[INFO] Removed %s destination rule '%s'", list, pattern)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
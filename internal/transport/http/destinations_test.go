@@ -0,0 +1,79 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/joshdurbin/url-shortener/internal/destinations"
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	repoMocks "github.com/joshdurbin/url-shortener/internal/repository/mocks"
+	"github.com/joshdurbin/url-shortener/internal/service/mocks"
+)
+
+func TestHandler_Destinations(t *testing.T) {
+	store := &repoMocks.DestinationRepository{}
+	store.On("ListDestinationRules", mock.Anything).Return([]domain.DestinationRule{}, nil).Once()
+	filter, err := destinations.New(destinations.Config{Deny: []string{"private"}}, store)
+	require.NoError(t, err)
+	require.NoError(t, filter.Start(context.Background()))
+	defer filter.Close()
+	server := NewServer(&mocks.URLShortener{}, "8080", "http://localhost:8080", false, WithDestinations(filter))
+
+	serve := func(method, target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		w := httptest.NewRecorder()
+		server.server.Handler.ServeHTTP(w, req)
+		return w
+	}
+
+	w := serve(http.MethodGet, "/api/admin/destinations", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `[{"list":"deny","pattern":"private","static":true}]`, w.Body.String())
+
+	store.On("CreateDestinationRule", mock.Anything, mock.MatchedBy(func(rule domain.DestinationRule) bool {
+		return rule.List == domain.DestinationDeny && rule.Pattern == "10.0.0.0/8"
+	})).Return(nil).Once()
+	store.On("ListDestinationRules", mock.Anything).Return([]domain.DestinationRule{{List: domain.DestinationDeny, Pattern: "10.0.0.0/8"}}, nil).Once()
+	w = serve(http.MethodPost, "/api/admin/destinations", `{"list":"deny","pattern":"10.1.0.0/8"}`)
+	require.Equal(t, http.StatusCreated, w.Code)
+	assert.Contains(t, w.Body.String(), `"pattern":"10.0.0.0/8"`)
+
+	w = serve(http.MethodPost, "/api/admin/destinations", `{"list":"deny","pattern":"https://example.com"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = serve(http.MethodPost, "/api/admin/destinations", `{`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = serve(http.MethodDelete, "/api/admin/destinations?list=deny&pattern=private", "")
+	assert.Equal(t, http.StatusConflict, w.Code, "configured rules cannot be removed")
+	w = serve(http.MethodDelete, "/api/admin/destinations?pattern=private", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	store.On("DeleteDestinationRule", mock.Anything, domain.DestinationDeny, "10.0.0.0/8").Return(nil).Once()
+	store.On("ListDestinationRules", mock.Anything).Return([]domain.DestinationRule{}, nil).Once()
+	w = serve(http.MethodDelete, "/api/admin/destinations?list=deny&pattern=10.0.0.0/8", "")
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	store.On("DeleteDestinationRule", mock.Anything, domain.DestinationAllow, "example.com").Return(domain.ErrDestinationRuleNotFound).Once()
+	w = serve(http.MethodDelete, "/api/admin/destinations?list=allow&pattern=example.com", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = serve(http.MethodPut, "/api/admin/destinations", "")
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	store.AssertExpectations(t)
+}
+
+func TestHandler_DestinationsNotConfigured(t *testing.T) {
+	server := NewServer(&mocks.URLShortener{}, "8080", "http://localhost:8080", false)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/admin/destinations", nil)
+	w := httptest.NewRecorder()
+	server.server.Handler.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotImplemented, w.Code)
+}
@@ -0,0 +1,88 @@
+package http
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+)
+
+// Domains handles /api/admin/domains: GET lists the custom domains and POST
+// adds one; PATCH and DELETE /api/admin/domains/{host} change or remove one
+func (h *Handler) Domains(w http.ResponseWriter, r *http.Request) {
+	if h.hosts == nil {
+		writeError(w, http.StatusNotImplemented, "Custom domains are not configured")
+		return
+	}
+
+	host := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/admin/domains"), "/")
+	if host == "" {
+		switch r.Method {
+		case http.MethodGet:
+			writeJSON(w, http.StatusOK, h.hosts.Domains())
+		case http.MethodPost:
+			var req domain.CustomDomain
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				log.Printf("[ERROR] Invalid JSON in custom domain request: %v", err)
+				writeError(w, http.StatusBadRequest, "Invalid JSON")
+				return
+			}
+			added, err := h.hosts.Add(r.Context(), req)
+			if err != nil {
+				log.Printf("[ERROR] Failed to add custom domain '%s': %v", req.Host, err)
+				writeServiceError(w, err)
+				return
+			}
+			log.Printf("[INFO] Added custom domain '%s'", added.Host)
+			writeJSON(w, http.StatusCreated, added)
+		default:
+			writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		}
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPatch:
+		var req domain.UpdateDomainRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			log.Printf("[ERROR] Invalid JSON in custom domain update: %v", err)
+			writeError(w, http.StatusBadRequest, "Invalid JSON")
+			return
+		}
+		updated, err := h.hosts.Update(r.Context(), host, req)
+		if err != nil {
+			log.Printf("[ERROR] Failed to update custom domain '%s': %v", host, err)
+			writeServiceError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, updated)
+	case http.MethodDelete:
+		if err := h.hosts.Remove(r.Context(), host); err != nil {
+			log.Printf("[ERROR] Failed to remove custom domain '%s': %v", host, err)
+			writeServiceError(w, err)
+			return
+		}
+		log.Printf("[INFO] Removed custom domain '%s'", host)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// baseURL returns the public base URL of a link's host, without a trailing
+// slash: the server URL for the server's own host (""), otherwise the custom
+// domain with the server URL's scheme
+func (h *Handler) baseURL(host string) string {
+	serverURL := strings.TrimSuffix(h.serverURL, "/")
+	if host == "" {
+		return serverURL
+	}
+	scheme := "https"
+	if u, err := url.Parse(serverURL); err == nil && u.Scheme != "" {
+		scheme = u.Scheme
+	}
+	return scheme + "://" + host
+}
@@ -0,0 +1,152 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	"github.com/joshdurbin/url-shortener/internal/hosts"
+	repoMocks "github.com/joshdurbin/url-shortener/internal/repository/mocks"
+	"github.com/joshdurbin/url-shortener/internal/service/mocks"
+)
+
+func newTestHosts(t *testing.T, stored ...domain.CustomDomain) (*hosts.Registry, *repoMocks.DomainRepository) {
+	t.Helper()
+	store := &repoMocks.DomainRepository{}
+	store.On("ListDomains", mock.Anything).Return(stored, nil).Once()
+	registry := hosts.New(hosts.Config{Enabled: true}, store, "http://localhost:8080")
+	require.NoError(t, registry.Start(context.Background()))
+	t.Cleanup(func() { registry.Close() })
+	return registry, store
+}
+
+func TestHandler_Domains(t *testing.T) {
+	registry, store := newTestHosts(t, domain.CustomDomain{Host: "go.example.com", RedirectStatus: 301})
+	server := NewServer(&mocks.URLShortener{}, "8080", "http://localhost:8080", false, WithHosts(registry))
+
+	serve := func(method, target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		w := httptest.NewRecorder()
+		server.server.Handler.ServeHTTP(w, req)
+		return w
+	}
+
+	w := serve(http.MethodGet, "/api/admin/domains", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"host":"go.example.com"`)
+
+	store.On("CreateDomain", mock.Anything, mock.MatchedBy(func(d domain.CustomDomain) bool {
+		return d.Host == "links.example.org" && d.Branding.Color == "#336699"
+	})).Return(nil).Once()
+	store.On("ListDomains", mock.Anything).Return([]domain.CustomDomain{
+		{Host: "go.example.com", RedirectStatus: 301},
+		{Host: "links.example.org", Branding: domain.Branding{Color: "#336699"}},
+	}, nil)
+	w = serve(http.MethodPost, "/api/admin/domains", `{"host":"Links.Example.org","branding":{"color":"#336699"}}`)
+	require.Equal(t, http.StatusCreated, w.Code)
+	assert.Contains(t, w.Body.String(), `"host":"links.example.org"`)
+
+	w = serve(http.MethodPost, "/api/admin/domains", `{"host":"localhost"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = serve(http.MethodPost, "/api/admin/domains", `{"host":"go.example.org","redirect_status":303}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	store.On("UpdateDomain", mock.Anything, mock.MatchedBy(func(d domain.CustomDomain) bool {
+		return d.Host == "go.example.com" && d.RedirectStatus == 308
+	})).Return(nil).Once()
+	w = serve(http.MethodPatch, "/api/admin/domains/go.example.com", `{"redirect_status":308}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"redirect_status":308`)
+
+	w = serve(http.MethodPatch, "/api/admin/domains/nope.example.com", `{}`)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	store.On("DeleteDomain", mock.Anything, "go.example.com").Return(domain.ErrDomainInUse).Once()
+	w = serve(http.MethodDelete, "/api/admin/domains/go.example.com", "")
+	assert.Equal(t, http.StatusConflict, w.Code)
+	store.On("DeleteDomain", mock.Anything, "links.example.org").Return(nil).Once()
+	w = serve(http.MethodDelete, "/api/admin/domains/links.example.org", "")
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	w = serve(http.MethodPut, "/api/admin/domains", "")
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	store.AssertExpectations(t)
+}
+
+func TestHandler_DomainsNotConfigured(t *testing.T) {
+	server := NewServer(&mocks.URLShortener{}, "8080", "http://localhost:8080", false)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/admin/domains", nil)
+	w := httptest.NewRecorder()
+	server.server.Handler.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotImplemented, w.Code)
+}
+
+func TestHandler_Redirect_CustomDomain(t *testing.T) {
+	registry, _ := newTestHosts(t,
+		domain.CustomDomain{Host: "go.example.com", RedirectStatus: 301, Branding: domain.Branding{Name: "Example Co", Color: "#336699"}},
+	)
+
+	mockService := &mocks.URLShortener{}
+	mockService.On("GetOriginalURL", mock.Anything, "abc123", mock.MatchedBy(func(req domain.RedirectRequest) bool {
+		return req.Domain == "go.example.com"
+	})).Return("https://example.com/page", 0, nil)
+	mockService.On("GetOriginalURL", mock.Anything, "def456", mock.MatchedBy(func(req domain.RedirectRequest) bool {
+		return req.Domain == "go.example.com"
+	})).Return("https://example.com/temporary", http.StatusTemporaryRedirect, nil)
+	mockService.On("GetOriginalURL", mock.Anything, "ghi789", mock.MatchedBy(func(req domain.RedirectRequest) bool {
+		return req.Domain == "go.example.com"
+	})).Return("", 0, domain.ErrURLNotFound)
+	mockService.On("GetOriginalURL", mock.Anything, "abc123", mock.MatchedBy(func(req domain.RedirectRequest) bool {
+		return req.Domain == ""
+	})).Return("", 0, domain.ErrURLNotFound)
+	handler := NewHandler(mockService, "https://sho.rt")
+	handler.hosts = registry
+
+	redirect := func(host, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Host = host
+		req.Header.Set("Accept", "text/html")
+		w := httptest.NewRecorder()
+		handler.Redirect(w, req)
+		return w
+	}
+
+	// Links without their own status use the domain's default
+	w := redirect("go.example.com", "/abc123")
+	assert.Equal(t, http.StatusMovedPermanently, w.Code)
+	assert.Equal(t, "https://example.com/page", w.Header().Get("Location"))
+
+	w = redirect("GO.example.com:443", "/def456")
+	assert.Equal(t, http.StatusTemporaryRedirect, w.Code)
+
+	// Error pages carry the domain's branding and base URL
+	w = redirect("go.example.com", "/ghi789")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), "Example Co")
+	assert.Contains(t, w.Body.String(), "h1 { color: #336699; }")
+	assert.Contains(t, w.Body.String(), "https://go.example.com/ghi789")
+
+	// The server's own host does not serve the domain's links
+	w = redirect("sho.rt", "/abc123")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), "https://sho.rt/abc123")
+	assert.NotContains(t, w.Body.String(), "Example Co")
+	mockService.AssertExpectations(t)
+}
+
+func TestHandler_BaseURL(t *testing.T) {
+	handler := NewHandler(&mocks.URLShortener{}, "http://localhost:8080/")
+	assert.Equal(t, "http://localhost:8080", handler.baseURL(""))
+	assert.Equal(t, "http://go.example.com", handler.baseURL("go.example.com"))
+
+	handler = NewHandler(&mocks.URLShortener{}, "https://sho.rt")
+	assert.Equal(t, "https://go.example.com", handler.baseURL("go.example.com"))
+}
@@ -0,0 +1,85 @@
+package http
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	"github.com/joshdurbin/url-shortener/internal/service"
+)
+
+// drainAllowed lists the writes accepted while the server is drained: the
+// drain and sync endpoints themselves, backups and validation, which leave
+// the database alone
+var drainAllowed = map[string]bool{
+	"/api/admin/drain":   true,
+	"/api/admin/sync":    true,
+	"/api/admin/backup":  true,
+	"/api/urls/validate": true,
+}
+
+// SyncNow handles POST /api/admin/sync, writing the cache's unsynced usage to
+// the database now
+func (h *Handler) SyncNow(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	summary, err := h.shortener.SyncNow(r.Context())
+	if err != nil {
+		h.writeSyncError(w, "sync", err)
+		return
+	}
+	log.Printf("[INFO] Synced %d cache entries in %dms", summary.Synced, summary.DurationMs)
+	writeJSON(w, http.StatusOK, summary)
+}
+
+// Drain handles /api/admin/drain: POST refuses API writes with 503 and syncs
+// the cache before holding its syncs, so the database can be snapshotted, and
+// DELETE ends the drain
+func (h *Handler) Drain(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		// Writes are refused before the sync, even when it fails, so the
+		// caller can retry it
+		h.draining.Store(true)
+		summary, err := h.shortener.Drain(r.Context())
+		if err != nil {
+			h.writeSyncError(w, "drain", err)
+			return
+		}
+		log.Printf("[INFO] Drained: synced %d cache entries in %dms", summary.Synced, summary.DurationMs)
+		writeJSON(w, http.StatusOK, summary)
+	case http.MethodDelete:
+		h.shortener.Resume()
+		h.draining.Store(false)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// writeSyncError writes the response for a failed forced sync or drain
+func (h *Handler) writeSyncError(w http.ResponseWriter, action string, err error) {
+	if errors.Is(err, service.ErrCacheNotInspectable) {
+		writeError(w, http.StatusNotImplemented, "The cache does not support forced syncs")
+		return
+	}
+	log.Printf("[ERROR] Failed to %s: %v", action, err)
+	writeServiceError(w, err)
+}
+
+// DrainMiddleware refuses API writes with 503 while the server is drained.
+// Redirects and reads are still served.
+func (h *Handler) DrainMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if h.draining.Load() && !readOnly(r) && strings.HasPrefix(r.URL.Path, "/api/") && !drainAllowed[r.URL.Path] {
+			writeError(w, http.StatusServiceUnavailable, domain.ErrDraining.Error())
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,67 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	"github.com/joshdurbin/url-shortener/internal/service"
+	"github.com/joshdurbin/url-shortener/internal/service/mocks"
+)
+
+func TestHandler_Drain(t *testing.T) {
+	mockService := &mocks.URLShortener{}
+	mockService.On("Drain", mock.Anything).Return(&domain.SyncSummary{Synced: 3, DurationMs: 12, Draining: true}, nil)
+	mockService.On("SyncNow", mock.Anything).Return(&domain.SyncSummary{Synced: 1, DurationMs: 2, Draining: true}, nil)
+	mockService.On("Resume").Return()
+	mockService.On("GetURLInfo", mock.Anything, "abc123").Return(&domain.URLEntry{ShortCode: "abc123", OriginalURL: "https://example.com"}, nil)
+	server := NewServer(mockService, "8080", "http://localhost:8080", false)
+
+	serve := func(method, target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		w := httptest.NewRecorder()
+		server.server.Handler.ServeHTTP(w, req)
+		return w
+	}
+
+	w := serve(http.MethodPost, "/api/admin/drain", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var summary domain.SyncSummary
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &summary))
+	assert.Equal(t, domain.SyncSummary{Synced: 3, DurationMs: 12, Draining: true}, summary)
+
+	// API writes are refused; reads, syncs and redirects are not
+	w = serve(http.MethodPost, "/api/urls", `{"url":"https://example.com"}`)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Contains(t, w.Body.String(), "draining")
+	assert.Equal(t, http.StatusServiceUnavailable, serve(http.MethodPut, "/api/webhooks/1", "{}").Code)
+	assert.Equal(t, http.StatusOK, serve(http.MethodGet, "/api/urls/abc123", "").Code)
+	w = serve(http.MethodPost, "/api/admin/sync", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"synced":1,"duration_ms":2,"draining":true}`, w.Body.String())
+
+	assert.Equal(t, http.StatusNoContent, serve(http.MethodDelete, "/api/admin/drain", "").Code)
+	assert.NotEqual(t, http.StatusServiceUnavailable, serve(http.MethodPut, "/api/webhooks/1", "{}").Code)
+
+	assert.Equal(t, http.StatusMethodNotAllowed, serve(http.MethodGet, "/api/admin/drain", "").Code)
+	assert.Equal(t, http.StatusMethodNotAllowed, serve(http.MethodGet, "/api/admin/sync", "").Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestHandler_SyncNotSupported(t *testing.T) {
+	mockService := &mocks.URLShortener{}
+	mockService.On("SyncNow", mock.Anything).Return(nil, service.ErrCacheNotInspectable)
+	server := NewServer(mockService, "8080", "http://localhost:8080", false)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/admin/sync", nil)
+	w := httptest.NewRecorder()
+	server.server.Handler.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotImplemented, w.Code)
+}
@@ -0,0 +1,80 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+)
+
+// writeError writes a domain.ErrorResponse with the code for status
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeErrorResponse(w, status, domain.ErrorResponse{Code: errorCode(status), Message: message})
+}
+
+// writeServiceError writes the response for an error from the service layer
+// (or the webhook and policy components), choosing the status from the error's
+// domain category. Uncategorized errors are internal and their message is not
+// shown to the caller, so handlers log errors before writing them.
+func writeServiceError(w http.ResponseWriter, err error) {
+	var validation *domain.ValidationError
+	switch {
+	case errors.As(err, &validation):
+		response := domain.ErrorResponse{Code: domain.ErrorCodeValidation, Message: err.Error()}
+		if validation.Field != "" {
+			response.Details = map[string]any{"field": validation.Field}
+		}
+		writeErrorResponse(w, http.StatusBadRequest, response)
+	case errors.Is(err, domain.ErrValidation):
+		writeErrorResponse(w, http.StatusBadRequest, domain.ErrorResponse{Code: domain.ErrorCodeValidation, Message: err.Error()})
+	case errors.Is(err, domain.ErrNotFound):
+		writeError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, domain.ErrConflict):
+		writeError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, domain.ErrUsageLimitReached):
+		writeError(w, http.StatusGone, "This link has reached its usage limit")
+	case errors.Is(err, domain.ErrLinkBlocked):
+		writeError(w, http.StatusForbidden, "This link has been blocked")
+	case errors.Is(err, domain.ErrLinkSuspended):
+		writeError(w, http.StatusTooManyRequests, "This link is temporarily suspended")
+	case errors.Is(err, domain.ErrUnavailable):
+		writeError(w, http.StatusServiceUnavailable, "Service temporarily unavailable")
+	default:
+		writeError(w, http.StatusInternalServerError, "Internal server error")
+	}
+}
+
+// writeErrorResponse writes an error body. Like http.Error, it stops browsers
+// from sniffing the body as something other than JSON.
+func writeErrorResponse(w http.ResponseWriter, status int, response domain.ErrorResponse) {
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	writeJSON(w, status, response)
+}
+
+// errorCode returns the ErrorResponse code for a status
+func errorCode(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return domain.ErrorCodeInvalidRequest
+	case http.StatusUnauthorized:
+		return domain.ErrorCodeUnauthorized
+	case http.StatusForbidden:
+		return domain.ErrorCodeForbidden
+	case http.StatusNotFound:
+		return domain.ErrorCodeNotFound
+	case http.StatusMethodNotAllowed:
+		return domain.ErrorCodeMethodNotAllowed
+	case http.StatusConflict:
+		return domain.ErrorCodeConflict
+	case http.StatusGone:
+		return domain.ErrorCodeUsageLimit
+	case http.StatusTooManyRequests:
+		return domain.ErrorCodeRateLimited
+	case http.StatusNotImplemented:
+		return domain.ErrorCodeNotImplemented
+	case http.StatusServiceUnavailable:
+		return domain.ErrorCodeUnavailable
+	default:
+		return domain.ErrorCodeInternal
+	}
+}
@@ -0,0 +1,129 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	"github.com/joshdurbin/url-shortener/internal/service/mocks"
+)
+
+func TestWriteServiceError(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		want   domain.ErrorResponse
+	}{
+		{
+			name:   "validation error with a field",
+			err:    domain.Invalid("max_uses", errors.New("max uses cannot be negative")),
+			status: http.StatusBadRequest,
+			want: domain.ErrorResponse{
+				Code:    domain.ErrorCodeValidation,
+				Message: "max uses cannot be negative",
+				Details: map[string]any{"field": "max_uses"},
+			},
+		},
+		{
+			name:   "wrapped template error",
+			err:    fmt.Errorf("short code abc: %w: missing parameter \"id\"", domain.ErrInvalidTemplateParams),
+			status: http.StatusBadRequest,
+			want:   domain.ErrorResponse{Code: domain.ErrorCodeValidation, Message: "short code abc: invalid template parameters: missing parameter \"id\""},
+		},
+		{
+			name:   "not found",
+			err:    fmt.Errorf("webhook 7: %w", domain.ErrWebhookNotFound),
+			status: http.StatusNotFound,
+			want:   domain.ErrorResponse{Code: domain.ErrorCodeNotFound, Message: "webhook 7: webhook endpoint not found"},
+		},
+		{
+			name:   "conflict",
+			err:    domain.Conflict(errors.New(`a policy named "cleanup" already exists`)),
+			status: http.StatusConflict,
+			want:   domain.ErrorResponse{Code: domain.ErrorCodeConflict, Message: `a policy named "cleanup" already exists`},
+		},
+		{
+			name:   "usage limit",
+			err:    fmt.Errorf("short code abc: %w", domain.ErrUsageLimitReached),
+			status: http.StatusGone,
+			want:   domain.ErrorResponse{Code: domain.ErrorCodeUsageLimit, Message: "This link has reached its usage limit"},
+		},
+		{
+			name:   "blocked link",
+			err:    fmt.Errorf("%w: short code \"admin\" is reserved", domain.ErrLinkBlocked),
+			status: http.StatusForbidden,
+			want:   domain.ErrorResponse{Code: domain.ErrorCodeForbidden, Message: "This link has been blocked"},
+		},
+		{
+			name:   "storage failure is not a missing link",
+			err:    domain.Storage(fmt.Errorf("failed to get URL: %w", errors.New("database is locked"))),
+			status: http.StatusInternalServerError,
+			want:   domain.ErrorResponse{Code: domain.ErrorCodeInternal, Message: "Internal server error"},
+		},
+		{
+			name:   "unavailable",
+			err:    domain.Unavailable(errors.New("database circuit breaker is open")),
+			status: http.StatusServiceUnavailable,
+			want:   domain.ErrorResponse{Code: domain.ErrorCodeUnavailable, Message: "Service temporarily unavailable"},
+		},
+		{
+			name:   "uncategorized errors are internal",
+			err:    errors.New("database is locked"),
+			status: http.StatusInternalServerError,
+			want:   domain.ErrorResponse{Code: domain.ErrorCodeInternal, Message: "Internal server error"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			writeServiceError(w, tt.err)
+
+			assert.Equal(t, tt.status, w.Code)
+			assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+			assert.Equal(t, "nosniff", w.Header().Get("X-Content-Type-Options"))
+
+			var got domain.ErrorResponse
+			require.NoError(t, json.NewDecoder(w.Body).Decode(&got))
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestErrorEnvelope(t *testing.T) {
+	server := NewServer(&mocks.URLShortener{}, "8080", "http://localhost:8080", false)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		status int
+		code   string
+	}{
+		{"method not allowed", http.MethodPut, "/api/urls", http.StatusMethodNotAllowed, domain.ErrorCodeMethodNotAllowed},
+		{"invalid JSON", http.MethodPost, "/api/urls", http.StatusBadRequest, domain.ErrorCodeInvalidRequest},
+		{"not configured", http.MethodGet, "/api/webhooks", http.StatusNotImplemented, domain.ErrorCodeNotImplemented},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			w := httptest.NewRecorder()
+			server.server.Handler.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.status, w.Code)
+			var got domain.ErrorResponse
+			require.NoError(t, json.NewDecoder(w.Body).Decode(&got))
+			assert.Equal(t, tt.code, got.Code)
+			assert.NotEmpty(t, got.Message)
+		})
+	}
+}
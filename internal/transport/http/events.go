@@ -0,0 +1,136 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	"github.com/joshdurbin/url-shortener/internal/service"
+)
+
+// Event stream timing
+const (
+	// eventsHeartbeat is how often an idle stream sends a comment, so proxies
+	// keep the connection open and dead clients are noticed
+	eventsHeartbeat = 15 * time.Second
+	// eventsWriteTimeout bounds each write to a stream; a client that cannot
+	// take an event in time is disconnected rather than slowing the server
+	eventsWriteTimeout = 10 * time.Second
+)
+
+// Events handles GET /api/events, a Server-Sent Events stream of link events
+// as they happen. ?code=, ?campaign= and ?type= (repeatable or comma
+// separated) narrow the stream. A client that reads too slowly misses events;
+// a "dropped" event reports how many before the next one it receives.
+func (h *Handler) Events(w http.ResponseWriter, r *http.Request) {
+	if h.events == nil {
+		writeError(w, http.StatusNotImplemented, "Event streaming is not configured")
+		return
+	}
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	query := r.URL.Query()
+	filter := service.EventFilter{ShortCode: query.Get("code"), Campaign: query.Get("campaign")}
+	for _, value := range query["type"] {
+		for _, name := range strings.Split(value, ",") {
+			eventType := domain.EventType(strings.TrimSpace(name))
+			if !eventType.Valid() {
+				writeError(w, http.StatusBadRequest, fmt.Sprintf("unknown event type %q", name))
+				return
+			}
+			filter.Types = append(filter.Types, eventType)
+		}
+	}
+
+	sub, err := h.events.Subscribe(filter)
+	if err != nil {
+		if errors.Is(err, service.ErrTooManySubscribers) {
+			writeError(w, http.StatusServiceUnavailable, "Too many event streams, try again later")
+			return
+		}
+		writeError(w, http.StatusServiceUnavailable, "Event streaming is shutting down")
+		return
+	}
+	defer sub.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no") // Stop nginx from buffering the stream
+	w.WriteHeader(http.StatusOK)
+
+	stream := &eventStream{w: w, rc: http.NewResponseController(w)}
+	if err := stream.send(": connected\n\n"); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(eventsHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		var err error
+		select {
+		case <-r.Context().Done():
+			return
+		case <-sub.Done():
+			return
+		case <-heartbeat.C:
+			err = stream.dropped(sub.TakeDropped())
+			if err == nil {
+				err = stream.send(": ping\n\n")
+			}
+		case event := <-sub.Events():
+			err = stream.dropped(sub.TakeDropped())
+			if err == nil {
+				err = stream.event(event)
+			}
+		}
+		if err != nil {
+			log.Printf("[INFO] Closing event stream for %s: %v", h.privacy.Anonymize(r.RemoteAddr), err)
+			return
+		}
+	}
+}
+
+// eventStream writes Server-Sent Events, flushing each one
+type eventStream struct {
+	w  io.Writer
+	rc *http.ResponseController
+}
+
+// event writes a link event, named after its type
+func (s *eventStream) event(event domain.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event %s: %w", event.ID, err)
+	}
+	return s.send(fmt.Sprintf("id: %s\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data))
+}
+
+// dropped reports events the client missed, if any
+func (s *eventStream) dropped(count int64) error {
+	if count == 0 {
+		return nil
+	}
+	return s.send(fmt.Sprintf("event: dropped\ndata: {\"dropped\":%d}\n\n", count))
+}
+
+// send writes a message within eventsWriteTimeout and flushes it
+func (s *eventStream) send(message string) error {
+	// The server's write timeout would end the stream, so each write gets its own
+	if err := s.rc.SetWriteDeadline(time.Now().Add(eventsWriteTimeout)); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return err
+	}
+	if _, err := io.WriteString(s.w, message); err != nil {
+		return err
+	}
+	return s.rc.Flush()
+}
@@ -0,0 +1,175 @@
+package http
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	"github.com/joshdurbin/url-shortener/internal/events"
+	"github.com/joshdurbin/url-shortener/internal/export"
+	"github.com/joshdurbin/url-shortener/internal/service"
+	"github.com/joshdurbin/url-shortener/internal/service/mocks"
+)
+
+func TestHandler_Events_Errors(t *testing.T) {
+	tests := []struct {
+		name           string
+		bus            *service.EventBus
+		method         string
+		path           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "not configured",
+			method:         http.MethodGet,
+			path:           "/api/events",
+			expectedStatus: http.StatusNotImplemented,
+			expectedBody:   "not configured",
+		},
+		{
+			name:           "wrong method",
+			bus:            service.NewEventBus(service.DefaultEventBusConfig()),
+			method:         http.MethodPost,
+			path:           "/api/events",
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+		{
+			name:           "unknown type",
+			bus:            service.NewEventBus(service.DefaultEventBusConfig()),
+			method:         http.MethodGet,
+			path:           "/api/events?type=url.clicked,url.renamed",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `unknown event type \"url.renamed\"`,
+		},
+		{
+			name: "too many streams",
+			bus: func() *service.EventBus {
+				bus := service.NewEventBus(service.EventBusConfig{MaxSubscribers: 1, Buffer: 1})
+				_, _ = bus.Subscribe(service.EventFilter{})
+				return bus
+			}(),
+			method:         http.MethodGet,
+			path:           "/api/events",
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedBody:   "Too many event streams",
+		},
+		{
+			name: "closed",
+			bus: func() *service.EventBus {
+				bus := service.NewEventBus(service.DefaultEventBusConfig())
+				bus.Close()
+				return bus
+			}(),
+			method:         http.MethodGet,
+			path:           "/api/events",
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedBody:   "shutting down",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := NewServer(&mocks.URLShortener{}, "8080", "http://localhost:8080", false, WithEventBus(tt.bus))
+
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			w := httptest.NewRecorder()
+			server.server.Handler.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+		})
+	}
+}
+
+func TestHandler_Events_Stream(t *testing.T) {
+	bus := service.NewEventBus(service.DefaultEventBusConfig())
+	server := NewServer(&mocks.URLShortener{}, "8080", "http://localhost:8080", false, WithEventBus(bus))
+	ts := httptest.NewServer(server.server.Handler)
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/api/events?campaign=spring&type=url.clicked")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	reader := bufio.NewReader(resp.Body)
+	readMessage := func() string {
+		var lines []string
+		for {
+			line, err := reader.ReadString('\n')
+			require.NoError(t, err)
+			if line == "\n" {
+				return strings.Join(lines, "")
+			}
+			lines = append(lines, line)
+		}
+	}
+	assert.Equal(t, ": connected\n", readMessage())
+	assert.Equal(t, 1, bus.Subscribers())
+
+	bus.Notify(domain.Event{ID: "evt_1", Type: domain.EventURLClicked, Data: domain.EventData{ShortCode: "abc123", Campaign: "autumn"}})
+	bus.Notify(domain.Event{ID: "evt_2", Type: domain.EventURLCreated, Data: domain.EventData{ShortCode: "abc123", Campaign: "spring"}})
+	bus.Notify(domain.Event{ID: "evt_3", Type: domain.EventURLClicked, Data: domain.EventData{ShortCode: "abc123", Campaign: "spring"}})
+
+	message := readMessage()
+	assert.True(t, strings.HasPrefix(message, "id: evt_3\nevent: url.clicked\ndata: {"), message)
+	assert.Contains(t, message, `"campaign":"spring"`)
+
+	// Closing the bus ends the stream
+	bus.Close()
+	_, err = reader.ReadString('\n')
+	assert.Error(t, err)
+}
+
+func TestHandler_EventMetrics(t *testing.T) {
+	bus := service.NewEventBus(service.DefaultEventBusConfig())
+	_, err := bus.Subscribe(service.EventFilter{})
+	require.NoError(t, err)
+	server := NewServer(&mocks.URLShortener{}, "8080", "http://localhost:8080", false, WithEventBus(bus))
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	w := httptest.NewRecorder()
+	server.server.Handler.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "url_shortener_event_streams 1\n")
+	assert.Contains(t, w.Body.String(), "url_shortener_events_dropped_total 0\n")
+}
+
+func TestHandler_BusMetrics(t *testing.T) {
+	bus := events.New(events.DefaultConfig(), nil)
+	bus.Notify(domain.NewEvent(domain.EventURLClicked, domain.EventData{ShortCode: "abc123"}))
+	server := NewServer(&mocks.URLShortener{}, "8080", "http://localhost:8080", false, WithEventMetrics(bus))
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	w := httptest.NewRecorder()
+	server.server.Handler.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `url_shortener_events_published_total{type="url.clicked"} 1`+"\n")
+	assert.Contains(t, w.Body.String(), `url_shortener_events_published_total{type="url.created"} 0`+"\n")
+	assert.NotContains(t, w.Body.String(), "url_shortener_event_broker_", "broker counts need a broker")
+}
+
+func TestHandler_ExportMetrics(t *testing.T) {
+	config := export.DefaultConfig()
+	config.BatchSize, config.MaxPending = 1, 1
+	exporter := export.New(config, nil, nil)
+	exporter.Notify(domain.NewEvent(domain.EventURLClicked, domain.EventData{ShortCode: "abc123"}))
+	exporter.Notify(domain.NewEvent(domain.EventURLClicked, domain.EventData{ShortCode: "abc123"}))
+	server := NewServer(&mocks.URLShortener{}, "8080", "http://localhost:8080", false, WithExporter(exporter))
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	w := httptest.NewRecorder()
+	server.server.Handler.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "url_shortener_events_exported_total 0\n")
+	assert.Contains(t, w.Body.String(), "url_shortener_events_export_dropped_total 1\n")
+	assert.Contains(t, w.Body.String(), "url_shortener_events_export_backlog 0\n")
+}
@@ -2,18 +2,76 @@ package http
 
 import (
 	"encoding/json"
+	"errors"
+	"io"
 	"log"
 	"net/http"
 	"strings"
+	"sync/atomic"
+	"time"
 
+	"github.com/joshdurbin/url-shortener/internal/abuse"
+	"github.com/joshdurbin/url-shortener/internal/analytics"
+	"github.com/joshdurbin/url-shortener/internal/auth"
+	"github.com/joshdurbin/url-shortener/internal/backup"
+	"github.com/joshdurbin/url-shortener/internal/bots"
+	"github.com/joshdurbin/url-shortener/internal/cache/response"
+	"github.com/joshdurbin/url-shortener/internal/destinations"
 	"github.com/joshdurbin/url-shortener/internal/domain"
+	"github.com/joshdurbin/url-shortener/internal/events"
+	"github.com/joshdurbin/url-shortener/internal/export"
+	"github.com/joshdurbin/url-shortener/internal/geoip"
+	"github.com/joshdurbin/url-shortener/internal/hosts"
+	"github.com/joshdurbin/url-shortener/internal/peers"
+	"github.com/joshdurbin/url-shortener/internal/policy"
+	"github.com/joshdurbin/url-shortener/internal/preview"
+	"github.com/joshdurbin/url-shortener/internal/privacy"
+	"github.com/joshdurbin/url-shortener/internal/reachability"
+	"github.com/joshdurbin/url-shortener/internal/retention"
 	"github.com/joshdurbin/url-shortener/internal/service"
+	"github.com/joshdurbin/url-shortener/internal/shortener"
+	"github.com/joshdurbin/url-shortener/internal/storage"
+	"github.com/joshdurbin/url-shortener/internal/tracing"
+	"github.com/joshdurbin/url-shortener/internal/transfer"
+	"github.com/joshdurbin/url-shortener/internal/version"
+	"github.com/joshdurbin/url-shortener/internal/webhook"
 )
 
 // Handler holds the HTTP handlers for the URL shortener
 type Handler struct {
-	shortener service.URLShortener
-	serverURL string
+	shortener     service.URLShortener
+	serverURL     string
+	authenticator *auth.Authenticator
+	webhooks      *webhook.Dispatcher
+	policies      *policy.Engine
+	previews      *preview.Fetcher
+	storage       *storage.Reporter
+	backups       *backup.Manager
+	retention     *retention.Enforcer
+	privacy       *privacy.Anonymizer
+	destinations  *destinations.Filter
+	verifier      *reachability.Verifier
+	abuse         *abuse.Detector
+	hosts         *hosts.Registry
+	responses     *response.Cache
+	collisions    *service.CollisionStats
+	counters      *shortener.CounterCache
+	clicks        *service.ClickBuffer
+	breaker       *service.CircuitBreaker
+	peers         *peers.Broadcaster
+	peerSecret    string
+	geo           *geoip.Locator
+	bots          *bots.Detector
+	analytics     *analytics.Recorder
+	events        *service.EventBus
+	bus           *events.Bus
+	exporter      *export.Exporter
+	tracer        *tracing.Tracer
+	timeouts      *TimeoutMiddleware
+	redirects     RedirectConfig
+	pages         *ErrorPages
+	version       domain.VersionResponse
+	draining      atomic.Bool // API writes are refused until the drain is ended
 }
 
 // NewHandler creates a new HTTP handler
@@ -21,140 +79,461 @@ func NewHandler(shortener service.URLShortener, serverURL string) *Handler {
 	return &Handler{
 		shortener: shortener,
 		serverURL: serverURL,
+		redirects: DefaultRedirectConfig(),
+		pages:     DefaultErrorPages(),
+		version:   version.Info(),
 	}
 }
 
 // CreateURL handles POST /api/urls
 func (h *Handler) CreateURL(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
 	var req domain.CreateURLRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		log.Printf("[ERROR] Invalid JSON in create URL request: %v", err)
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, "Invalid JSON")
 		return
 	}
 
 	if req.URL == "" {
 		log.Printf("[ERROR] Empty URL provided in create request")
-		http.Error(w, "URL is required", http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, "URL is required")
 		return
 	}
 
-	entry, err := h.shortener.CreateShortURL(r.Context(), req.URL)
+	entry, err := h.shortener.CreateShortURL(r.Context(), req.URL, createOptions(r, req))
 	if err != nil {
 		log.Printf("[ERROR] Failed to create short URL for '%s': %v", req.URL, err)
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeServiceError(w, err)
 		return
 	}
 
 	response := domain.CreateURLResponse{
-		ShortCode:   entry.ShortCode,
-		ShortURL:    h.serverURL + "/" + entry.ShortCode,
-		OriginalURL: entry.OriginalURL,
-		CreatedAt:   entry.CreatedAt,
+		ShortCode:        entry.ShortCode,
+		ShortURL:         h.baseURL(entry.Domain) + "/" + entry.ShortCode,
+		OriginalURL:      entry.OriginalURL,
+		CreatedAt:        entry.CreatedAt,
+		MaxUses:          entry.MaxUses,
+		BurnAfterReading: entry.BurnAfterReading,
+		Tags:             entry.Tags,
+		RedirectStatus:   entry.RedirectStatus,
+		BackupURL:        entry.BackupURL,
+		TemplateParams:   entry.TemplateParams,
+		QueryParams:      entry.QueryParams,
+		ForwardQuery:     entry.ForwardQuery,
+		Campaign:         entry.Campaign,
+		DedupeSeconds:    entry.DedupeSeconds,
+		Domain:           entry.Domain,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(response); err != nil {
 		log.Printf("Error encoding response: %v", err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		writeError(w, http.StatusInternalServerError, "Internal server error")
 		return
 	}
 }
 
+// createOptions returns the options of a create request, owned by the caller
+func createOptions(r *http.Request, req domain.CreateURLRequest) domain.CreateOptions {
+	return domain.CreateOptions{
+		MaxUses:          req.MaxUses,
+		BurnAfterReading: req.BurnAfterReading,
+		Tags:             req.Tags,
+		RedirectStatus:   req.RedirectStatus,
+		ReuseExisting:    req.ReuseExisting,
+		BackupURL:        req.BackupURL,
+		QueryParams:      req.QueryParams,
+		ForwardQuery:     req.ForwardQuery,
+		Campaign:         req.Campaign,
+		DedupeSeconds:    req.DedupeSeconds,
+		Domain:           req.Domain,
+		Owner:            requestOwner(r),
+	}
+}
+
 // GetURL handles GET /api/urls/{shortCode}
 func (h *Handler) GetURL(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
 	shortCode := strings.TrimPrefix(r.URL.Path, "/api/urls/")
 	if shortCode == "" {
-		http.Error(w, "Short code is required", http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, "Short code is required")
 		return
 	}
 
 	entry, err := h.shortener.GetURLInfo(r.Context(), shortCode)
 	if err != nil {
 		log.Printf("[ERROR] Failed to get URL info for code '%s': %v", shortCode, err)
-		http.Error(w, err.Error(), http.StatusNotFound)
+		writeServiceError(w, err)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(entry); err != nil {
-		log.Printf("Error encoding response: %v", err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	writeConditionalJSON(w, r, entry.LastModified(), entry)
+}
+
+// UpdateURL handles PATCH /api/urls/{shortCode}
+func (h *Handler) UpdateURL(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPatch {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	shortCode := strings.TrimPrefix(r.URL.Path, "/api/urls/")
+	if shortCode == "" {
+		writeError(w, http.StatusBadRequest, "Short code is required")
+		return
+	}
+
+	if !h.authorizeOwner(w, r, shortCode) {
+		return
+	}
+
+	var req domain.UpdateURLRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("[ERROR] Invalid JSON in update URL request: %v", err)
+		writeError(w, http.StatusBadRequest, "Invalid JSON")
 		return
 	}
+
+	entry, err := h.shortener.UpdateShortURL(r.Context(), shortCode, req)
+	if err != nil {
+		log.Printf("[ERROR] Failed to update URL with code '%s': %v", shortCode, err)
+		writeServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, entry)
 }
 
 // DeleteURL handles DELETE /api/urls/{shortCode}
 func (h *Handler) DeleteURL(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodDelete {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
 	shortCode := strings.TrimPrefix(r.URL.Path, "/api/urls/")
 	if shortCode == "" {
-		http.Error(w, "Short code is required", http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, "Short code is required")
+		return
+	}
+
+	if !h.authorizeOwner(w, r, shortCode) {
 		return
 	}
 
 	err := h.shortener.DeleteShortURL(r.Context(), shortCode)
 	if err != nil {
 		log.Printf("[ERROR] Failed to delete URL with code '%s': %v", shortCode, err)
-		http.Error(w, err.Error(), http.StatusNotFound)
+		writeServiceError(w, err)
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
 
-// ListURLs handles GET /api/urls
+// ListURLs handles GET /api/urls, optionally filtered with ?campaign=name or
+// ?owner=id (owner=me for the caller's own links), and with ?status=broken
+// to the links whose destination failed its latest check. Like GetURL it
+// answers conditional requests with 304 Not Modified.
 func (h *Handler) ListURLs(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	broken := false
+	switch r.URL.Query().Get("status") {
+	case "":
+	case "broken":
+		if h.verifier == nil {
+			writeError(w, http.StatusNotImplemented, "Destination verification is not configured")
+			return
+		}
+		broken = true
+	default:
+		writeError(w, http.StatusBadRequest, "status must be broken")
+		return
+	}
+	page, err := parsePage(r.URL.Query())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if owner := r.URL.Query().Get("owner"); owner != "" {
+		if owner == "me" {
+			if owner = requestOwner(r); owner == "" {
+				writeError(w, http.StatusBadRequest, "owner=me requires an API key or identity provider credential")
+				return
+			}
+		}
+		entries, err := h.shortener.GetURLsByOwner(r.Context(), owner)
+		if err != nil {
+			log.Printf("[ERROR] Failed to get URLs for owner '%s': %v", owner, err)
+			writeServiceError(w, err)
+			return
+		}
+		h.writeURLList(w, r, entries, broken, page)
+		return
+	}
+
+	if campaign := r.URL.Query().Get("campaign"); campaign != "" {
+		entries, err := h.shortener.GetURLsByCampaign(r.Context(), campaign)
+		if err != nil {
+			log.Printf("[ERROR] Failed to get URLs for campaign '%s': %v", campaign, err)
+			writeServiceError(w, err)
+			return
+		}
+		h.writeURLList(w, r, entries, broken, page)
 		return
 	}
 
 	entries, err := h.shortener.GetAllURLs(r.Context())
 	if err != nil {
 		log.Printf("Error getting all URLs: %v", err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		writeServiceError(w, err)
+		return
+	}
+
+	h.writeURLList(w, r, entries, broken, page)
+}
+
+// ShareToken handles POST /api/urls/{shortCode}/share-token
+func (h *Handler) ShareToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	shortCode := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/urls/"), "/share-token")
+	if shortCode == "" {
+		writeError(w, http.StatusBadRequest, "Short code is required")
+		return
+	}
+
+	var req domain.ShareTokenRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+			writeError(w, http.StatusBadRequest, "Invalid JSON")
+			return
+		}
+	}
+
+	var ttl time.Duration
+	if req.TTL != "" {
+		parsed, err := time.ParseDuration(req.TTL)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid ttl: "+err.Error())
+			return
+		}
+		ttl = parsed
+	}
+
+	if h.authenticator == nil {
+		writeError(w, http.StatusNotImplemented, "Share tokens are not configured")
+		return
+	}
+
+	if !h.authorizeOwner(w, r, shortCode) {
+		return
+	}
+
+	if _, err := h.shortener.GetURLInfo(r.Context(), shortCode); err != nil {
+		log.Printf("[ERROR] Failed to issue share token for code '%s': %v", shortCode, err)
+		writeServiceError(w, err)
+		return
+	}
+
+	token, expiresAt, err := h.authenticator.IssueShareToken(shortCode, ttl)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
+	response := domain.ShareTokenResponse{
+		Token:     token,
+		ShortCode: shortCode,
+		ExpiresAt: expiresAt,
+		InfoURL:   h.serverURL + "/api/urls/" + shortCode + "?token=" + token,
+	}
+
 	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(entries); err != nil {
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
 		log.Printf("Error encoding response: %v", err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+// ExportURLs handles GET /api/admin/export?format=json|csv
+func (h *Handler) ExportURLs(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	format := transfer.FormatJSON
+	if name := r.URL.Query().Get("format"); name != "" {
+		parsed, err := transfer.ParseFormat(name)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		format = parsed
+	}
+
+	entries, err := h.shortener.GetAllURLs(r.Context())
+	if err != nil {
+		log.Printf("Error getting all URLs for export: %v", err)
+		writeServiceError(w, err)
 		return
 	}
+
+	if format == transfer.FormatCSV {
+		w.Header().Set("Content-Type", "text/csv")
+	} else {
+		w.Header().Set("Content-Type", "application/json")
+	}
+	w.Header().Set("Content-Disposition", "attachment; filename=\"urls."+string(format)+"\"")
+
+	if err := transfer.Encode(w, entries, format); err != nil {
+		log.Printf("Error encoding export: %v", err)
+		return
+	}
+}
+
+// Version handles GET /api/version
+func (h *Handler) Version(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, h.version)
 }
 
-// Redirect handles GET /{shortCode} - redirects to original URL
+// Healthz handles GET /healthz - liveness, true whenever the process can serve HTTP
+func (h *Handler) Healthz(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]domain.HealthStatus{"status": domain.HealthOK})
+}
+
+// Readyz handles GET /readyz - readiness, 503 only when requests cannot be
+// served. A degraded cache still reports 200 so instances stay in rotation.
+func (h *Handler) Readyz(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	health := h.shortener.CheckHealth(r.Context())
+	status := http.StatusOK
+	if health.Status == domain.HealthUnavailable {
+		status = http.StatusServiceUnavailable
+	}
+	writeJSON(w, status, health)
+}
+
+// Redirect handles GET /{shortCode} - redirects to original URL. The root path
+// answers with the configured landing, and /{shortCode}+ with the link's stats
+// page when stats pages are on. HEAD answers the same headers without counting
+// a use, OPTIONS lists the allowed methods, and other methods are refused.
 func (h *Handler) Redirect(w http.ResponseWriter, r *http.Request) {
 	shortCode := strings.TrimPrefix(r.URL.Path, "/")
-	if shortCode == "" || shortCode == "api/urls" || strings.HasPrefix(shortCode, "api/") {
-		http.NotFound(w, r)
+	if shortCode == "api/urls" || strings.HasPrefix(shortCode, "api/") {
+		writeError(w, http.StatusNotFound, "Not found")
+		return
+	}
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+	case http.MethodOptions:
+		w.Header().Set("Allow", redirectMethods)
+		w.WriteHeader(http.StatusNoContent)
+		return
+	default:
+		w.Header().Set("Allow", redirectMethods)
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	if shortCode == "" {
+		h.landing(w, r)
+		return
+	}
+	if code, ok := strings.CutSuffix(shortCode, "+"); ok && h.redirects.StatsPages {
+		h.statsPage(w, r, code)
 		return
 	}
 
-	originalURL, err := h.shortener.GetOriginalURL(r.Context(), shortCode)
+	ctx := service.TrackBurned(service.TrackStale(r.Context()))
+	req := h.redirectRequest(r)
+	originalURL, linkStatus, err := h.shortener.GetOriginalURL(ctx, shortCode, req)
 	if err != nil {
-		log.Printf("[ERROR] Failed to get original URL for code '%s': %v", shortCode, err)
-		http.NotFound(w, r)
+		if !errors.Is(err, domain.ErrUsageLimitReached) && !errors.Is(err, domain.ErrValidation) && !errors.Is(err, domain.ErrLinkBlocked) && !errors.Is(err, domain.ErrLinkSuspended) {
+			log.Printf("[ERROR] Failed to get original URL for code '%s': %v", shortCode, err)
+		}
+		if !h.redirectFallback(w, r, shortCode, err) {
+			h.writeRedirectError(w, r, shortCode, err)
+		}
 		return
 	}
 
-	http.Redirect(w, r, originalURL, http.StatusFound)
+	if linkStatus == 0 && req.Domain != "" {
+		customDomain, _ := h.hosts.Lookup(req.Domain)
+		linkStatus = customDomain.RedirectStatus
+	}
+	status := h.redirects.statusFor(linkStatus)
+	h.redirects.setCacheHeaders(w.Header(), status, time.Now())
+	if service.ServedStale(ctx) {
+		// The link may have changed in the database; nothing should keep this answer
+		w.Header().Set("X-Cache-Status", "stale")
+		setNoStore(w.Header())
+	}
+	if service.Burned(ctx) {
+		// The destination was for this visitor only
+		setNoStore(w.Header())
+	}
+	http.Redirect(w, r, originalURL, status)
+}
+
+// redirectFallback sends a visit to an unknown code, or to a link past its
+// usage limit, to the configured fallback URL. It reports whether it answered.
+func (h *Handler) redirectFallback(w http.ResponseWriter, r *http.Request, shortCode string, err error) bool {
+	if !h.redirects.hasFallback() {
+		return false
+	}
+	var campaign string
+	switch {
+	case errors.Is(err, domain.ErrUsageLimitReached):
+		if len(h.redirects.CampaignFallbacks) > 0 {
+			if entry, infoErr := h.shortener.GetURLInfo(r.Context(), shortCode); infoErr == nil {
+				campaign = entry.Campaign
+			}
+		}
+	case errors.Is(err, domain.ErrNotFound):
+	default:
+		return false
+	}
+
+	target := h.redirects.fallbackURL(shortCode, campaign)
+	if target == "" {
+		return false
+	}
+	// The code may be created or renewed later, so nothing should keep this answer
+	setNoStore(w.Header())
+	http.Redirect(w, r, target, http.StatusFound)
+	return true
 }
 
 // URLsHandler handles both POST /api/urls and GET /api/urls
@@ -165,18 +544,69 @@ func (h *Handler) URLsHandler(w http.ResponseWriter, r *http.Request) {
 	case http.MethodGet:
 		h.ListURLs(w, r)
 	default:
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
 	}
 }
 
-// URLsDetailHandler handles GET /api/urls/{shortCode} and DELETE /api/urls/{shortCode}
+// URLsDetailHandler handles GET, PATCH and DELETE /api/urls/{shortCode},
+// plus POST /api/urls/{shortCode}/share-token, /api/urls/{shortCode}/routes and /aliases,
+// POST /api/urls/{shortCode}/preview and /verify, GET /api/urls/{shortCode}/referrers,
+// /countries and /timeseries, and POST /api/urls/validate, /delete and /prune
 func (h *Handler) URLsDetailHandler(w http.ResponseWriter, r *http.Request) {
+	if strings.HasSuffix(r.URL.Path, "/share-token") {
+		h.ShareToken(w, r)
+		return
+	}
+	if _, rest, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/api/urls/"), "/"); rest == "aliases" || strings.HasPrefix(rest, "aliases/") {
+		h.Aliases(w, r)
+		return
+	}
+	if strings.HasSuffix(strings.TrimPrefix(r.URL.Path, "/api/urls/"), "/routes") {
+		h.RoutingRules(w, r)
+		return
+	}
+	if strings.HasSuffix(strings.TrimPrefix(r.URL.Path, "/api/urls/"), "/preview") {
+		h.RefreshPreview(w, r)
+		return
+	}
+	if strings.HasSuffix(strings.TrimPrefix(r.URL.Path, "/api/urls/"), "/verify") {
+		h.VerifyURL(w, r)
+		return
+	}
+	if strings.HasSuffix(strings.TrimPrefix(r.URL.Path, "/api/urls/"), "/referrers") {
+		h.Referrers(w, r)
+		return
+	}
+	if strings.HasSuffix(strings.TrimPrefix(r.URL.Path, "/api/urls/"), "/countries") {
+		h.Countries(w, r)
+		return
+	}
+	if strings.HasSuffix(strings.TrimPrefix(r.URL.Path, "/api/urls/"), "/timeseries") {
+		h.TimeSeries(w, r)
+		return
+	}
+	// Only POST, so links whose short codes are "validate", "delete" or "prune" can still be read and managed
+	if r.URL.Path == "/api/urls/validate" && r.Method == http.MethodPost {
+		h.ValidateURL(w, r)
+		return
+	}
+	if r.URL.Path == "/api/urls/delete" && r.Method == http.MethodPost {
+		h.BulkDelete(w, r)
+		return
+	}
+	if r.URL.Path == "/api/urls/prune" && r.Method == http.MethodPost {
+		h.Prune(w, r)
+		return
+	}
+
 	switch r.Method {
 	case http.MethodGet:
 		h.GetURL(w, r)
+	case http.MethodPatch:
+		h.UpdateURL(w, r)
 	case http.MethodDelete:
 		h.DeleteURL(w, r)
 	default:
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
 	}
 }
\ No newline at end of file
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"strings"
 	"testing"
 	"time"
@@ -15,6 +16,7 @@ import (
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
+	"github.com/joshdurbin/url-shortener/internal/auth"
 	"github.com/joshdurbin/url-shortener/internal/domain"
 	"github.com/joshdurbin/url-shortener/internal/service/mocks"
 )
@@ -27,13 +29,90 @@ func TestHandler_CreateURL(t *testing.T) {
 		expectedStatus int
 		expectedBody   string
 	}{
+		{
+			name: "creation with max uses",
+			requestBody: domain.CreateURLRequest{
+				URL:     "https://example.com",
+				MaxUses: 1,
+			},
+			setupMocks: func(mockService *mocks.URLShortener) {
+				mockService.On("CreateShortURL", context.Background(), "https://example.com", domain.CreateOptions{MaxUses: 1}).
+					Return(&domain.URLEntry{
+						ID:          1,
+						ShortCode:   "abc123",
+						OriginalURL: "https://example.com",
+						CreatedAt:   time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+						MaxUses:     1,
+					}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"max_uses":1`,
+		},
+		{
+			name: "reuse existing link",
+			requestBody: domain.CreateURLRequest{
+				URL:           "https://example.com",
+				ReuseExisting: true,
+			},
+			setupMocks: func(mockService *mocks.URLShortener) {
+				mockService.On("CreateShortURL", context.Background(), "https://example.com", domain.CreateOptions{ReuseExisting: true}).
+					Return(&domain.URLEntry{
+						ID:          7,
+						ShortCode:   "old123",
+						OriginalURL: "https://example.com",
+						CreatedAt:   time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+					}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"short_code":"old123"`,
+		},
+		{
+			name: "creation with backup URL",
+			requestBody: domain.CreateURLRequest{
+				URL:       "https://example.com",
+				BackupURL: "https://mirror.example.com",
+			},
+			setupMocks: func(mockService *mocks.URLShortener) {
+				mockService.On("CreateShortURL", context.Background(), "https://example.com", domain.CreateOptions{BackupURL: "https://mirror.example.com"}).
+					Return(&domain.URLEntry{
+						ID:          1,
+						ShortCode:   "abc123",
+						OriginalURL: "https://example.com",
+						CreatedAt:   time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+						BackupURL:   "https://mirror.example.com",
+					}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"backup_url":"https://mirror.example.com"`,
+		},
+		{
+			name: "creation with query parameters",
+			requestBody: domain.CreateURLRequest{
+				URL:          "https://example.com",
+				QueryParams:  map[string]string{"utm_source": "newsletter"},
+				ForwardQuery: true,
+			},
+			setupMocks: func(mockService *mocks.URLShortener) {
+				mockService.On("CreateShortURL", context.Background(), "https://example.com", domain.CreateOptions{QueryParams: map[string]string{"utm_source": "newsletter"}, ForwardQuery: true}).
+					Return(&domain.URLEntry{
+						ID:           1,
+						ShortCode:    "abc123",
+						OriginalURL:  "https://example.com",
+						CreatedAt:    time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+						QueryParams:  map[string]string{"utm_source": "newsletter"},
+						ForwardQuery: true,
+					}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"query_params":{"utm_source":"newsletter"},"forward_query":true`,
+		},
 		{
 			name: "successful creation",
 			requestBody: domain.CreateURLRequest{
 				URL: "https://example.com",
 			},
 			setupMocks: func(mockService *mocks.URLShortener) {
-				mockService.On("CreateShortURL", context.Background(), "https://example.com").
+				mockService.On("CreateShortURL", context.Background(), "https://example.com", domain.CreateOptions{}).
 					Return(&domain.URLEntry{
 						ID:          1,
 						ShortCode:   "abc123",
@@ -61,15 +140,28 @@ func TestHandler_CreateURL(t *testing.T) {
 			expectedBody:   "Invalid JSON",
 		},
 		{
-			name: "service error",
+			name: "validation error",
 			requestBody: domain.CreateURLRequest{
 				URL: "invalid-url",
 			},
 			setupMocks: func(mockService *mocks.URLShortener) {
-				mockService.On("CreateShortURL", context.Background(), "invalid-url").
-					Return(nil, assert.AnError)
+				mockService.On("CreateShortURL", context.Background(), "invalid-url", domain.CreateOptions{}).
+					Return(nil, domain.Invalid("url", fmt.Errorf("invalid URL: only HTTP and HTTPS are supported")))
 			},
 			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"code":"validation_failed","message":"invalid URL: only HTTP and HTTPS are supported","details":{"field":"url"}}`,
+		},
+		{
+			name: "service error",
+			requestBody: domain.CreateURLRequest{
+				URL: "https://example.com",
+			},
+			setupMocks: func(mockService *mocks.URLShortener) {
+				mockService.On("CreateShortURL", context.Background(), "https://example.com", domain.CreateOptions{}).
+					Return(nil, assert.AnError)
+			},
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   `{"code":"internal_error","message":"Internal server error"}`,
 		},
 	}
 
@@ -133,10 +225,19 @@ func TestHandler_GetURL(t *testing.T) {
 			shortCode: "notfound",
 			setupMocks: func(mockService *mocks.URLShortener) {
 				mockService.On("GetURLInfo", context.Background(), "notfound").
-					Return(nil, assert.AnError)
+					Return(nil, domain.ErrURLNotFound)
 			},
 			expectedStatus: http.StatusNotFound,
 		},
+		{
+			name:      "storage failure",
+			shortCode: "abc123",
+			setupMocks: func(mockService *mocks.URLShortener) {
+				mockService.On("GetURLInfo", context.Background(), "abc123").
+					Return(nil, domain.Storage(fmt.Errorf("failed to get URL: database is locked")))
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
 		{
 			name:           "empty short code",
 			shortCode:      "",
@@ -194,7 +295,7 @@ func TestHandler_DeleteURL(t *testing.T) {
 			shortCode: "notfound",
 			setupMocks: func(mockService *mocks.URLShortener) {
 				mockService.On("DeleteShortURL", context.Background(), "notfound").
-					Return(assert.AnError)
+					Return(domain.ErrURLNotFound)
 			},
 			expectedStatus: http.StatusNotFound,
 		},
@@ -245,21 +346,59 @@ func TestHandler_Redirect(t *testing.T) {
 			name: "successful redirect",
 			path: "/abc123",
 			setupMocks: func(mockService *mocks.URLShortener) {
-				mockService.On("GetOriginalURL", context.Background(), "abc123").
-					Return("https://example.com", nil)
+				mockService.On("GetOriginalURL", mock.Anything, "abc123", mock.Anything).
+					Return("https://example.com", 0, nil)
 			},
 			expectedStatus: http.StatusFound,
 			expectedHeader: "https://example.com",
 		},
+		{
+			name: "link redirect status",
+			path: "/abc123",
+			setupMocks: func(mockService *mocks.URLShortener) {
+				mockService.On("GetOriginalURL", mock.Anything, "abc123", mock.Anything).
+					Return("https://example.com", http.StatusTemporaryRedirect, nil)
+			},
+			expectedStatus: http.StatusTemporaryRedirect,
+			expectedHeader: "https://example.com",
+		},
 		{
 			name: "short code not found",
 			path: "/notfound",
 			setupMocks: func(mockService *mocks.URLShortener) {
-				mockService.On("GetOriginalURL", context.Background(), "notfound").
-					Return("", assert.AnError)
+				mockService.On("GetOriginalURL", mock.Anything, "notfound", mock.Anything).
+					Return("", 0, domain.ErrURLNotFound)
 			},
 			expectedStatus: http.StatusNotFound,
 		},
+		{
+			name: "usage limit reached",
+			path: "/abc123",
+			setupMocks: func(mockService *mocks.URLShortener) {
+				mockService.On("GetOriginalURL", mock.Anything, "abc123", mock.Anything).
+					Return("", 0, fmt.Errorf("short code abc123: %w", domain.ErrUsageLimitReached))
+			},
+			expectedStatus: http.StatusGone,
+		},
+		{
+			name: "template parameters passed through",
+			path: "/abc123?id=42",
+			setupMocks: func(mockService *mocks.URLShortener) {
+				mockService.On("GetOriginalURL", mock.Anything, "abc123", domain.RedirectRequest{Query: url.Values{"id": {"42"}}, Device: domain.DeviceDesktop, ClientIP: "192.0.2.1"}).
+					Return("https://example.com/item/42", 0, nil)
+			},
+			expectedStatus: http.StatusFound,
+			expectedHeader: "https://example.com/item/42",
+		},
+		{
+			name: "invalid template parameters",
+			path: "/abc123?other=1",
+			setupMocks: func(mockService *mocks.URLShortener) {
+				mockService.On("GetOriginalURL", mock.Anything, "abc123", mock.Anything).
+					Return("", 0, fmt.Errorf("short code abc123: %w: unknown parameter \"other\"", domain.ErrInvalidTemplateParams))
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
 		{
 			name:           "API path ignored",
 			path:           "/api/urls",
@@ -311,6 +450,7 @@ func TestHandler_ListURLs(t *testing.T) {
 						{ID: 1, ShortCode: "abc123", OriginalURL: "https://example.com"},
 						{ID: 2, ShortCode: "def456", OriginalURL: "https://google.com"},
 					}, nil)
+				mockService.On("LastRemoval").Return(time.Time{})
 			},
 			expectedStatus: http.StatusOK,
 			expectedCount:  2,
@@ -320,6 +460,7 @@ func TestHandler_ListURLs(t *testing.T) {
 			setupMocks: func(mockService *mocks.URLShortener) {
 				mockService.On("GetAllURLs", context.Background()).
 					Return([]*domain.URLEntry{}, nil)
+				mockService.On("LastRemoval").Return(time.Time{})
 			},
 			expectedStatus: http.StatusOK,
 			expectedCount:  0,
@@ -332,6 +473,14 @@ func TestHandler_ListURLs(t *testing.T) {
 			},
 			expectedStatus: http.StatusInternalServerError,
 		},
+		{
+			name: "draining",
+			setupMocks: func(mockService *mocks.URLShortener) {
+				mockService.On("GetAllURLs", context.Background()).
+					Return(nil, domain.ErrDraining)
+			},
+			expectedStatus: http.StatusServiceUnavailable,
+		},
 	}
 
 	for _, tt := range tests {
@@ -360,6 +509,458 @@ func TestHandler_ListURLs(t *testing.T) {
 	}
 }
 
+func TestHandler_ExportURLs(t *testing.T) {
+	entries := []*domain.URLEntry{
+		{ID: 1, ShortCode: "abc123", OriginalURL: "https://example.com", CreatedAt: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC), UsageCount: 4},
+	}
+
+	tests := []struct {
+		name           string
+		query          string
+		setupMocks     func(*mocks.URLShortener)
+		expectedStatus int
+		expectedType   string
+		expectedBody   string
+	}{
+		{
+			name:  "default JSON export",
+			query: "",
+			setupMocks: func(mockService *mocks.URLShortener) {
+				mockService.On("GetAllURLs", context.Background()).Return(entries, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedType:   "application/json",
+			expectedBody:   `"usage_count": 4`,
+		},
+		{
+			name:  "CSV export",
+			query: "?format=csv",
+			setupMocks: func(mockService *mocks.URLShortener) {
+				mockService.On("GetAllURLs", context.Background()).Return(entries, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedType:   "text/csv",
+			expectedBody:   "abc123,https://example.com,2023-01-01T00:00:00Z,,4,0",
+		},
+		{
+			name:           "unsupported format",
+			query:          "?format=xml",
+			setupMocks:     func(mockService *mocks.URLShortener) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "unsupported format",
+		},
+		{
+			name:  "service error",
+			query: "",
+			setupMocks: func(mockService *mocks.URLShortener) {
+				mockService.On("GetAllURLs", context.Background()).Return(nil, assert.AnError)
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+		{
+			name:  "draining",
+			query: "",
+			setupMocks: func(mockService *mocks.URLShortener) {
+				mockService.On("GetAllURLs", context.Background()).Return(nil, domain.ErrDraining)
+			},
+			expectedStatus: http.StatusServiceUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mocks.URLShortener{}
+			tt.setupMocks(mockService)
+
+			handler := NewHandler(mockService, "http://localhost:8080")
+
+			req := httptest.NewRequest(http.MethodGet, "/api/admin/export"+tt.query, nil)
+			w := httptest.NewRecorder()
+
+			handler.ExportURLs(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedType != "" {
+				assert.Equal(t, tt.expectedType, w.Header().Get("Content-Type"))
+				assert.Contains(t, w.Header().Get("Content-Disposition"), "attachment")
+			}
+			if tt.expectedBody != "" {
+				assert.Contains(t, w.Body.String(), tt.expectedBody)
+			}
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandler_ShareToken(t *testing.T) {
+	authenticator, err := auth.New(auth.Config{APIKeys: []string{"admin-key"}, ShareTokenSecret: "secret", ShareTokenMaxTTL: 48 * time.Hour})
+	require.NoError(t, err)
+
+	entry := &domain.URLEntry{ID: 1, ShortCode: "abc123", OriginalURL: "https://example.com"}
+
+	tests := []struct {
+		name           string
+		path           string
+		body           string
+		authenticator  *auth.Authenticator
+		setupMocks     func(*mocks.URLShortener)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:          "default ttl",
+			path:          "/api/urls/abc123/share-token",
+			authenticator: authenticator,
+			setupMocks: func(mockService *mocks.URLShortener) {
+				mockService.On("GetURLInfo", context.Background(), "abc123").Return(entry, nil)
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:          "explicit ttl",
+			path:          "/api/urls/abc123/share-token",
+			body:          `{"ttl":"2h"}`,
+			authenticator: authenticator,
+			setupMocks: func(mockService *mocks.URLShortener) {
+				mockService.On("GetURLInfo", context.Background(), "abc123").Return(entry, nil)
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:          "ttl above maximum",
+			path:          "/api/urls/abc123/share-token",
+			body:          `{"ttl":"72h"}`,
+			authenticator: authenticator,
+			setupMocks: func(mockService *mocks.URLShortener) {
+				mockService.On("GetURLInfo", context.Background(), "abc123").Return(entry, nil)
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "ttl must be",
+		},
+		{
+			name:           "invalid ttl",
+			path:           "/api/urls/abc123/share-token",
+			body:           `{"ttl":"soon"}`,
+			authenticator:  authenticator,
+			setupMocks:     func(mockService *mocks.URLShortener) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "Invalid ttl",
+		},
+		{
+			name:          "unknown short code",
+			path:          "/api/urls/missing/share-token",
+			authenticator: authenticator,
+			setupMocks: func(mockService *mocks.URLShortener) {
+				mockService.On("GetURLInfo", context.Background(), "missing").Return(nil, domain.ErrURLNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "not configured",
+			path:           "/api/urls/abc123/share-token",
+			setupMocks:     func(mockService *mocks.URLShortener) {},
+			expectedStatus: http.StatusNotImplemented,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mocks.URLShortener{}
+			tt.setupMocks(mockService)
+
+			handler := NewHandler(mockService, "http://localhost:8080")
+			handler.authenticator = tt.authenticator
+
+			req := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+
+			handler.ShareToken(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedBody != "" {
+				assert.Contains(t, w.Body.String(), tt.expectedBody)
+			}
+			if w.Code == http.StatusCreated {
+				var response domain.ShareTokenResponse
+				require.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+				assert.Equal(t, "abc123", response.ShortCode)
+				assert.Contains(t, response.InfoURL, "?token="+response.Token)
+
+				principal, err := authenticator.Authenticate(context.Background(), response.Token)
+				require.NoError(t, err)
+				assert.Equal(t, auth.KindShareToken, principal.Kind)
+				assert.Equal(t, "abc123", principal.ShortCode)
+			}
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandler_UpdateURL(t *testing.T) {
+	newURL := "https://example.com/new"
+	maxUses := 5
+
+	tests := []struct {
+		name           string
+		method         string
+		shortCode      string
+		body           string
+		setupMocks     func(*mocks.URLShortener)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:      "successful update",
+			method:    http.MethodPatch,
+			shortCode: "abc123",
+			body:      `{"original_url":"https://example.com/new","max_uses":5}`,
+			setupMocks: func(mockService *mocks.URLShortener) {
+				mockService.On("UpdateShortURL", context.Background(), "abc123", domain.UpdateURLRequest{OriginalURL: &newURL, MaxUses: &maxUses}).
+					Return(&domain.URLEntry{ShortCode: "abc123", OriginalURL: newURL, MaxUses: maxUses}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"original_url":"https://example.com/new"`,
+		},
+		{
+			name:      "short code not found",
+			method:    http.MethodPatch,
+			shortCode: "notfound",
+			body:      `{"max_uses":5}`,
+			setupMocks: func(mockService *mocks.URLShortener) {
+				mockService.On("UpdateShortURL", context.Background(), "notfound", domain.UpdateURLRequest{MaxUses: &maxUses}).
+					Return(nil, domain.ErrURLNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:      "invalid URL",
+			method:    http.MethodPatch,
+			shortCode: "abc123",
+			body:      `{"original_url":"ftp://example.com"}`,
+			setupMocks: func(mockService *mocks.URLShortener) {
+				mockService.On("UpdateShortURL", context.Background(), "abc123", mock.Anything).
+					Return(nil, domain.Invalid("url", fmt.Errorf("invalid URL: only HTTP and HTTPS are supported")))
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "invalid URL",
+		},
+		{
+			name:           "invalid JSON",
+			method:         http.MethodPatch,
+			shortCode:      "abc123",
+			body:           `{`,
+			setupMocks:     func(mockService *mocks.URLShortener) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "Invalid JSON",
+		},
+		{
+			name:           "empty short code",
+			method:         http.MethodPatch,
+			body:           `{}`,
+			setupMocks:     func(mockService *mocks.URLShortener) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "Short code is required",
+		},
+		{
+			name:           "method not allowed",
+			method:         http.MethodPut,
+			shortCode:      "abc123",
+			body:           `{}`,
+			setupMocks:     func(mockService *mocks.URLShortener) {},
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mocks.URLShortener{}
+			tt.setupMocks(mockService)
+
+			handler := NewHandler(mockService, "http://localhost:8080")
+
+			req := httptest.NewRequest(tt.method, "/api/urls/"+tt.shortCode, strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+
+			handler.UpdateURL(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedBody != "" {
+				assert.Contains(t, w.Body.String(), tt.expectedBody)
+			}
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandler_Admin(t *testing.T) {
+	authenticator, err := auth.New(auth.Config{APIKeys: []string{"admin-key"}})
+	require.NoError(t, err)
+	server := NewServer(&mocks.URLShortener{}, "8080", "http://localhost:8080", false, WithAuthenticator(authenticator))
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{name: "index", method: http.MethodGet, path: "/admin/", expectedStatus: http.StatusOK, expectedBody: "<title>URL Shortener Admin</title>"},
+		{name: "script", method: http.MethodGet, path: "/admin/app.js", expectedStatus: http.StatusOK, expectedBody: "X-API-Key"},
+		{name: "stylesheet", method: http.MethodGet, path: "/admin/style.css", expectedStatus: http.StatusOK},
+		{name: "missing asset", method: http.MethodGet, path: "/admin/missing.js", expectedStatus: http.StatusNotFound},
+		{name: "config without OIDC", method: http.MethodGet, path: "/admin/config.json", expectedStatus: http.StatusOK, expectedBody: "{}"},
+		{name: "trailing slash redirect", method: http.MethodGet, path: "/admin", expectedStatus: http.StatusTemporaryRedirect},
+		{name: "method not allowed", method: http.MethodPost, path: "/admin/", expectedStatus: http.StatusMethodNotAllowed},
+		{name: "API still requires a key", method: http.MethodGet, path: "/api/urls", expectedStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			w := httptest.NewRecorder()
+
+			server.server.Handler.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedBody != "" {
+				assert.Contains(t, w.Body.String(), tt.expectedBody)
+			}
+			if strings.HasPrefix(tt.path, "/admin/") && tt.expectedStatus == http.StatusOK {
+				assert.Contains(t, w.Header().Get("Content-Security-Policy"), "default-src 'self'")
+				assert.Equal(t, "nosniff", w.Header().Get("X-Content-Type-Options"))
+			}
+		})
+	}
+}
+
+func TestHandler_AdminOIDC(t *testing.T) {
+	issuer, _ := newTestIdentityProvider(t)
+	authenticator, err := auth.New(auth.Config{OIDC: auth.OIDCConfig{Issuer: issuer, ClientID: "dashboard", AdminGroups: []string{"admins"}}})
+	require.NoError(t, err)
+	server := NewServer(&mocks.URLShortener{}, "8080", "http://localhost:8080", false, WithAuthenticator(authenticator))
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/config.json", nil)
+	w := httptest.NewRecorder()
+
+	server.server.Handler.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Security-Policy"), "connect-src 'self' "+issuer)
+
+	var config domain.DashboardConfig
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&config))
+	require.NotNil(t, config.OIDC)
+	assert.Equal(t, "dashboard", config.OIDC.ClientID)
+	assert.Equal(t, issuer+"/authorize", config.OIDC.AuthorizationEndpoint)
+	assert.Equal(t, issuer+"/token", config.OIDC.TokenEndpoint)
+	assert.Equal(t, []string{"openid", "profile", "email"}, config.OIDC.Scopes)
+}
+
+func TestHandler_Version(t *testing.T) {
+	info := domain.VersionResponse{Version: "v1.2.0", Commit: "abc1234", Storage: "sqlite", Generator: "base62_counter", Features: []string{"webhooks"}}
+
+	t.Run("configured", func(t *testing.T) {
+		server := NewServer(&mocks.URLShortener{}, "8080", "http://localhost:8080", false, WithVersion(info))
+
+		req := httptest.NewRequest(http.MethodGet, "/api/version", nil)
+		w := httptest.NewRecorder()
+		server.server.Handler.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response domain.VersionResponse
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+		assert.Equal(t, info, response)
+	})
+
+	t.Run("build info only", func(t *testing.T) {
+		server := NewServer(&mocks.URLShortener{}, "8080", "http://localhost:8080", false)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/version", nil)
+		w := httptest.NewRecorder()
+		server.server.Handler.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"go_version":"go`)
+		assert.NotContains(t, w.Body.String(), `"storage"`)
+	})
+
+	t.Run("method not allowed", func(t *testing.T) {
+		server := NewServer(&mocks.URLShortener{}, "8080", "http://localhost:8080", false)
+
+		req := httptest.NewRequest(http.MethodPost, "/api/version", nil)
+		w := httptest.NewRecorder()
+		server.server.Handler.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	})
+}
+
+func TestHandler_Probes(t *testing.T) {
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		health         *domain.HealthResponse
+		expectedStatus int
+		expectedBody   string
+	}{
+		{name: "liveness", method: http.MethodGet, path: "/healthz", expectedStatus: http.StatusOK, expectedBody: `"status":"ok"`},
+		{
+			name:           "ready",
+			method:         http.MethodGet,
+			path:           "/readyz",
+			health:         &domain.HealthResponse{Status: domain.HealthOK, Components: map[string]domain.ComponentHealth{"database": {Status: domain.HealthOK}}},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"status":"ok"`,
+		},
+		{
+			name:           "degraded stays in rotation",
+			method:         http.MethodGet,
+			path:           "/readyz",
+			health:         &domain.HealthResponse{Status: domain.HealthDegraded, Components: map[string]domain.ComponentHealth{"cache": {Status: domain.HealthDegraded, Error: "connection refused"}}},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"status":"degraded"`,
+		},
+		{
+			name:           "unavailable",
+			method:         http.MethodGet,
+			path:           "/readyz",
+			health:         &domain.HealthResponse{Status: domain.HealthUnavailable, Components: map[string]domain.ComponentHealth{"database": {Status: domain.HealthUnavailable, Error: "database is closed"}}},
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedBody:   "database is closed",
+		},
+		{name: "method not allowed", method: http.MethodPost, path: "/readyz", expectedStatus: http.StatusMethodNotAllowed},
+	}
+
+	authenticator, err := auth.New(auth.Config{APIKeys: []string{"admin-key"}})
+	require.NoError(t, err)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mocks.URLShortener{}
+			if tt.health != nil {
+				mockService.On("CheckHealth", mock.Anything).Return(tt.health)
+			}
+			// Probes must work without credentials when auth is enabled
+			server := NewServer(mockService, "8080", "http://localhost:8080", false, WithAuthenticator(authenticator))
+
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			w := httptest.NewRecorder()
+			server.server.Handler.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedBody != "" {
+				assert.Contains(t, w.Body.String(), tt.expectedBody)
+			}
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
 func TestHandler_ErrorScenarios(t *testing.T) {
 	t.Run("malformed JSON in CreateURL", func(t *testing.T) {
 		mockService := &mocks.URLShortener{}
@@ -383,7 +984,7 @@ func TestHandler_ErrorScenarios(t *testing.T) {
 
 		// Even without Content-Type, the handler will still try to decode JSON
 		// and call the service if JSON is valid
-		mockService.On("CreateShortURL", mock.Anything, "https://example.com").
+		mockService.On("CreateShortURL", mock.Anything, "https://example.com", domain.CreateOptions{}).
 			Return(&domain.URLEntry{
 				ID:          1,
 				ShortCode:   "abc123",
@@ -435,8 +1036,8 @@ func TestHandler_ErrorScenarios(t *testing.T) {
 
 		// The handler will try to call the service with this large URL
 		// Let's mock it to return an error indicating URL validation failure
-		mockService.On("CreateShortURL", mock.Anything, largeURL).
-			Return(nil, fmt.Errorf("URL too long"))
+		mockService.On("CreateShortURL", mock.Anything, largeURL, domain.CreateOptions{}).
+			Return(nil, domain.Invalid("url", fmt.Errorf("URL too long")))
 
 		req := httptest.NewRequest(http.MethodPost, "/api/urls", bytes.NewBuffer(jsonData))
 		req.Header.Set("Content-Type", "application/json")
@@ -509,8 +1110,8 @@ func TestHandler_PathEdgeCases(t *testing.T) {
 			handler := NewHandler(mockService, "http://localhost:8080")
 
 			// The handler will attempt to resolve these as short codes
-			mockService.On("GetOriginalURL", mock.Anything, tc.shortCode).
-				Return("", fmt.Errorf("not found"))
+			mockService.On("GetOriginalURL", mock.Anything, tc.shortCode, mock.Anything).
+				Return("", 0, domain.ErrURLNotFound)
 
 			req := httptest.NewRequest(tc.method, tc.path, nil)
 			w := httptest.NewRecorder()
@@ -530,6 +1131,7 @@ func TestHandler_ResponseHeaders(t *testing.T) {
 
 		// Test JSON responses have correct Content-Type
 		mockService.On("GetAllURLs", mock.Anything).Return([]*domain.URLEntry{}, nil)
+		mockService.On("LastRemoval").Return(time.Time{})
 
 		req := httptest.NewRequest(http.MethodGet, "/api/urls", nil)
 		w := httptest.NewRecorder()
@@ -564,6 +1166,7 @@ func TestHandler_ContextPropagation(t *testing.T) {
 		// Mock should receive a context (any context)
 		mockService.On("GetAllURLs", mock.AnythingOfType("*context.valueCtx")).
 			Return([]*domain.URLEntry{}, nil)
+		mockService.On("LastRemoval").Return(time.Time{})
 
 		req := httptest.NewRequest(http.MethodGet, "/api/urls", nil)
 		// Add a value to context to ensure it's propagated
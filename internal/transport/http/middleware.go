@@ -2,21 +2,32 @@ package http
 
 import (
 	"bytes"
+	"errors"
 	"io"
 	"log"
 	"net/http"
+	"strings"
+	"sync/atomic"
 	"time"
+
+	"github.com/joshdurbin/url-shortener/internal/auth"
+	"github.com/joshdurbin/url-shortener/internal/privacy"
+	"github.com/joshdurbin/url-shortener/internal/tracing"
 )
 
 // LoggingMiddleware creates HTTP middleware for logging requests and responses
 type LoggingMiddleware struct {
 	verbose bool
+	config  RequestLogConfig
+	privacy *privacy.Anonymizer // Anonymizes the logged client address; nil logs it as it is
+	count   atomic.Uint64
 }
 
 // NewLoggingMiddleware creates a new logging middleware
-func NewLoggingMiddleware(verbose bool) *LoggingMiddleware {
+func NewLoggingMiddleware(verbose bool, config RequestLogConfig) *LoggingMiddleware {
 	return &LoggingMiddleware{
 		verbose: verbose,
+		config:  config,
 	}
 }
 
@@ -24,7 +35,7 @@ func NewLoggingMiddleware(verbose bool) *LoggingMiddleware {
 type loggingResponseWriter struct {
 	http.ResponseWriter
 	statusCode int
-	body       *bytes.Buffer
+	body       *limitedBuffer
 }
 
 func (lrw *loggingResponseWriter) WriteHeader(code int) {
@@ -39,6 +50,20 @@ func (lrw *loggingResponseWriter) Write(b []byte) (int, error) {
 	return lrw.ResponseWriter.Write(b)
 }
 
+// Unwrap lets http.ResponseController flush and set deadlines on the
+// underlying writer, which event streams need
+func (lrw *loggingResponseWriter) Unwrap() http.ResponseWriter {
+	return lrw.ResponseWriter
+}
+
+// sampled reports whether the next request is one of the 1 in SampleRate logged
+func (l *LoggingMiddleware) sampled() bool {
+	if l.config.SampleRate <= 1 {
+		return true
+	}
+	return (l.count.Add(1)-1)%uint64(l.config.SampleRate) == 0
+}
+
 // Middleware returns the HTTP logging middleware function
 func (l *LoggingMiddleware) Middleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -48,30 +73,32 @@ func (l *LoggingMiddleware) Middleware(next http.Handler) http.Handler {
 		}
 
 		start := time.Now()
+		sampled := l.sampled()
+		logBodies := sampled && l.config.MaxBodyBytes > 0
 
 		// Log request
-		log.Printf("[HTTP REQUEST] %s %s from %s", r.Method, r.URL.Path, r.RemoteAddr)
+		if sampled {
+			log.Printf("[HTTP REQUEST] %s %s from %s", r.Method, r.URL.Path, l.privacy.Anonymize(r.RemoteAddr))
+		}
 		
-		// Log request body for POST/PUT requests
-		if r.Method == http.MethodPost || r.Method == http.MethodPut {
-			if r.Body != nil {
-				bodyBytes, err := io.ReadAll(r.Body)
-				if err != nil {
-					log.Printf("[HTTP REQUEST] Error reading request body: %v", err)
-				} else {
-					// Create a new reader for the handler
-					r.Body = io.NopCloser(bytes.NewBuffer(bodyBytes))
-					if len(bodyBytes) > 0 {
-						log.Printf("[HTTP REQUEST] Body: %s", string(bodyBytes))
-					}
-				}
+		// Log request body for POST/PUT requests, reading only what can be redacted
+		if logBodies && (r.Method == http.MethodPost || r.Method == http.MethodPut) && r.Body != nil {
+			head, err := io.ReadAll(io.LimitReader(r.Body, maxInspectedBody+1))
+			if err != nil {
+				log.Printf("[HTTP REQUEST] Error reading request body: %v", err)
+			}
+			// The handler reads what was inspected, then the rest
+			r.Body = readCloser{io.MultiReader(bytes.NewReader(head), r.Body), r.Body}
+			if err == nil && len(head) > 0 {
+				log.Printf("[HTTP REQUEST] Body: %s", l.config.formatBody(head, r.Header.Get("Content-Type"), len(head) <= maxInspectedBody))
 			}
 		}
 
 		// Wrap the response writer to capture response details
-		var responseBody *bytes.Buffer
-		if l.verbose {
-			responseBody = &bytes.Buffer{}
+		// Event streams run until the client leaves, so their bodies are not kept
+		var responseBody *limitedBuffer
+		if logBodies && r.URL.Path != "/api/events" {
+			responseBody = &limitedBuffer{}
 		}
 		
 		lrw := &loggingResponseWriter{
@@ -83,12 +110,175 @@ func (l *LoggingMiddleware) Middleware(next http.Handler) http.Handler {
 		// Process the request
 		next.ServeHTTP(lrw, r)
 
-		// Log response
+		// Log response; server errors are logged even when not sampled
+		if !sampled && lrw.statusCode < http.StatusInternalServerError {
+			return
+		}
 		duration := time.Since(start)
 		log.Printf("[HTTP RESPONSE] %s %s -> %d in %v", r.Method, r.URL.Path, lrw.statusCode, duration)
 		
 		if responseBody != nil && responseBody.Len() > 0 && lrw.statusCode >= 400 {
-			log.Printf("[HTTP RESPONSE] Error body: %s", responseBody.String())
+			log.Printf("[HTTP RESPONSE] Error body: %s", l.config.formatBody(responseBody.Bytes(), lrw.Header().Get("Content-Type"), !responseBody.truncated))
+		}
+	})
+}
+
+// readCloser reads from one reader and closes another
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// untracedRoutes are polled by probes, scrapers, crawlers and browsers; their
+// spans would crowd out real traffic
+var untracedRoutes = map[string]bool{"/healthz": true, "/readyz": true, "/metrics": true, "/robots.txt": true, "/favicon.ico": true}
+
+// TracingMiddleware creates HTTP middleware starting the root span of each
+// request, continuing the caller's trace from its traceparent header
+type TracingMiddleware struct {
+	tracer  *tracing.Tracer
+	mux     *http.ServeMux
+	privacy *privacy.Anonymizer // Anonymizes the recorded client address; nil records it as it is
+}
+
+// NewTracingMiddleware creates a tracing middleware. Spans are named after the
+// mux pattern a request matches, such as "GET /api/urls/", so that redirects
+// to every short code share one name.
+func NewTracingMiddleware(tracer *tracing.Tracer, mux *http.ServeMux) *TracingMiddleware {
+	return &TracingMiddleware{
+		tracer: tracer,
+		mux:    mux,
+	}
+}
+
+// Middleware returns the HTTP tracing middleware function
+func (m *TracingMiddleware) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, route := m.mux.Handler(r)
+		if untracedRoutes[route] {
+			next.ServeHTTP(w, r)
+			return
+		}
+		ctx, span := m.tracer.StartRequest(r.Context(), r.Method+" "+route, r.Header.Get("traceparent"),
+			tracing.String("http.request.method", r.Method),
+			tracing.String("http.route", route),
+			tracing.String("url.path", r.URL.Path),
+			tracing.String("client.address", m.privacy.Anonymize(r.RemoteAddr)),
+			tracing.String("user_agent.original", r.UserAgent()))
+		if span == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		defer span.End()
+
+		// The trace ID lets a client find its request in the tracing backend
+		w.Header().Set("Trace-Id", span.TraceID().String())
+
+		lrw := &loggingResponseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+		next.ServeHTTP(lrw, r.WithContext(ctx))
+
+		span.SetAttributes(tracing.Int("http.response.status_code", lrw.statusCode))
+		if lrw.statusCode >= 500 {
+			span.SetFailed(http.StatusText(lrw.statusCode))
 		}
 	})
-}
\ No newline at end of file
+}
+
+// AuthMiddleware creates HTTP middleware enforcing API key, share token and identity provider auth on /api/ routes
+type AuthMiddleware struct {
+	authenticator *auth.Authenticator
+}
+
+// NewAuthMiddleware creates a new auth middleware
+func NewAuthMiddleware(authenticator *auth.Authenticator) *AuthMiddleware {
+	return &AuthMiddleware{
+		authenticator: authenticator,
+	}
+}
+
+// Middleware returns the HTTP auth middleware function
+func (a *AuthMiddleware) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Public redirects never require credentials
+		if !strings.HasPrefix(r.URL.Path, "/api/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		principal, err := a.authenticator.Authenticate(r.Context(), credentialFromRequest(r))
+		if errors.Is(err, auth.ErrNoRole) {
+			log.Printf("[AUTH] No role for %s %s: %v", r.Method, r.URL.Path, err)
+			writeError(w, http.StatusForbidden, "Identity is not in an authorized group")
+			return
+		}
+		if err != nil {
+			if errors.Is(err, auth.ErrMissingCredentials) {
+				log.Printf("[AUTH] Missing credentials for %s %s", r.Method, r.URL.Path)
+			} else {
+				log.Printf("[AUTH] Rejected credentials for %s %s: %v", r.Method, r.URL.Path, err)
+			}
+			w.Header().Set("WWW-Authenticate", `Bearer realm="url-shortener"`)
+			writeError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		if principal.Kind == auth.KindShareToken && !shareTokenAllows(principal, r) {
+			writeError(w, http.StatusForbidden, "Share token does not grant access to this resource")
+			return
+		}
+
+		if principal.Role == auth.RoleViewer && !readOnly(r) {
+			writeError(w, http.StatusForbidden, "Viewer role is read-only")
+			return
+		}
+
+		if principal.Role == auth.RoleUser && !userAllows(r) {
+			writeError(w, http.StatusForbidden, "User keys can only access link endpoints")
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(auth.WithPrincipal(r.Context(), principal)))
+	})
+}
+
+// credentialFromRequest extracts an API key or share token from a request.
+// Share tokens may also be passed as a ?token= query parameter so they can be
+// embedded in links.
+func credentialFromRequest(r *http.Request) string {
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return key
+	}
+	if header := r.Header.Get("Authorization"); header != "" {
+		if token, found := strings.CutPrefix(header, "Bearer "); found {
+			return strings.TrimSpace(token)
+		}
+	}
+	return r.URL.Query().Get("token")
+}
+
+// shareTokenAllows reports whether a share token permits a request: read-only
+// access to its link's info and sub-resources
+func shareTokenAllows(principal *auth.Principal, r *http.Request) bool {
+	if !readOnly(r) {
+		return false
+	}
+
+	base := "/api/urls/" + principal.ShortCode
+	return r.URL.Path == base || strings.HasPrefix(r.URL.Path, base+"/")
+}
+
+// userAllows reports whether a user key may access a route: links, campaigns
+// and version info, but not admin, webhook or policy endpoints. Ownership of
+// individual links is checked by the handlers.
+func userAllows(r *http.Request) bool {
+	switch r.URL.Path {
+	case "/api/urls", "/api/campaigns", "/api/version":
+		return true
+	}
+	return strings.HasPrefix(r.URL.Path, "/api/urls/")
+}
+
+// readOnly reports whether a request cannot modify state
+func readOnly(r *http.Request) bool {
+	return r.Method == http.MethodGet || r.Method == http.MethodHead
+}
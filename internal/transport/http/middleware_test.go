@@ -0,0 +1,272 @@
+package http
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/joshdurbin/url-shortener/internal/auth"
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	"github.com/joshdurbin/url-shortener/internal/service/mocks"
+	"github.com/joshdurbin/url-shortener/internal/tracing"
+)
+
+func TestAuthMiddleware(t *testing.T) {
+	authenticator, err := auth.New(auth.Config{APIKeys: []string{"admin-key"}, UserAPIKeys: []string{"user-key"}, ShareTokenSecret: "secret"})
+	require.NoError(t, err)
+
+	shareToken, _, err := authenticator.IssueShareToken("abc123", time.Hour)
+	require.NoError(t, err)
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, ok := auth.PrincipalFromContext(r.Context())
+		if !ok {
+			w.Write([]byte("public"))
+			return
+		}
+		w.Write([]byte(principal.Kind))
+	})
+	handler := NewAuthMiddleware(authenticator).Middleware(next)
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		headers        map[string]string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{name: "redirects are public", method: http.MethodGet, path: "/abc123", expectedStatus: http.StatusOK, expectedBody: "public"},
+		{name: "missing credentials", method: http.MethodGet, path: "/api/urls", expectedStatus: http.StatusUnauthorized},
+		{name: "invalid API key", method: http.MethodGet, path: "/api/urls", headers: map[string]string{"X-API-Key": "wrong"}, expectedStatus: http.StatusUnauthorized},
+		{name: "API key header", method: http.MethodGet, path: "/api/urls", headers: map[string]string{"X-API-Key": "admin-key"}, expectedStatus: http.StatusOK, expectedBody: "api_key"},
+		{name: "bearer API key", method: http.MethodDelete, path: "/api/urls/abc123", headers: map[string]string{"Authorization": "Bearer admin-key"}, expectedStatus: http.StatusOK, expectedBody: "api_key"},
+		{name: "share token reads its link", method: http.MethodGet, path: "/api/urls/abc123", headers: map[string]string{"Authorization": "Bearer " + shareToken}, expectedStatus: http.StatusOK, expectedBody: "share_token"},
+		{name: "share token in query", method: http.MethodGet, path: "/api/urls/abc123?token=" + shareToken, expectedStatus: http.StatusOK, expectedBody: "share_token"},
+		{name: "share token on another link", method: http.MethodGet, path: "/api/urls/other", headers: map[string]string{"Authorization": "Bearer " + shareToken}, expectedStatus: http.StatusForbidden},
+		{name: "share token cannot delete", method: http.MethodDelete, path: "/api/urls/abc123", headers: map[string]string{"Authorization": "Bearer " + shareToken}, expectedStatus: http.StatusForbidden},
+		{name: "share token cannot list", method: http.MethodGet, path: "/api/urls", headers: map[string]string{"Authorization": "Bearer " + shareToken}, expectedStatus: http.StatusForbidden},
+		{name: "user key manages links", method: http.MethodPatch, path: "/api/urls/abc123", headers: map[string]string{"X-API-Key": "user-key"}, expectedStatus: http.StatusOK, expectedBody: "api_key"},
+		{name: "user key lists campaigns", method: http.MethodGet, path: "/api/campaigns", headers: map[string]string{"X-API-Key": "user-key"}, expectedStatus: http.StatusOK, expectedBody: "api_key"},
+		{name: "user key cannot manage webhooks", method: http.MethodGet, path: "/api/webhooks", headers: map[string]string{"X-API-Key": "user-key"}, expectedStatus: http.StatusForbidden},
+		{name: "user key cannot export", method: http.MethodGet, path: "/api/admin/export", headers: map[string]string{"X-API-Key": "user-key"}, expectedStatus: http.StatusForbidden},
+		{name: "share token cannot mint tokens", method: http.MethodPost, path: "/api/urls/abc123/share-token", headers: map[string]string{"Authorization": "Bearer " + shareToken}, expectedStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			for key, value := range tt.headers {
+				req.Header.Set(key, value)
+			}
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusUnauthorized {
+				assert.NotEmpty(t, w.Header().Get("WWW-Authenticate"))
+			}
+			if tt.expectedBody != "" {
+				assert.Equal(t, tt.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestAuthMiddleware_Disabled(t *testing.T) {
+	authenticator, err := auth.New(auth.Config{})
+	require.NoError(t, err)
+
+	handler := NewAuthMiddleware(authenticator).Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/urls/abc123", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+}
+
+func TestAuthMiddleware_OIDC(t *testing.T) {
+	issuer, sign := newTestIdentityProvider(t)
+	authenticator, err := auth.New(auth.Config{OIDC: auth.OIDCConfig{
+		Issuer:       issuer,
+		ClientID:     "dashboard",
+		AdminGroups:  []string{"admins"},
+		ViewerGroups: []string{"staff"},
+	}})
+	require.NoError(t, err)
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, _ := auth.PrincipalFromContext(r.Context())
+		w.Write([]byte(principal.Role))
+	})
+	handler := NewAuthMiddleware(authenticator).Middleware(next)
+
+	token := func(groups ...string) string {
+		return sign(map[string]any{"iss": issuer, "sub": "user-1", "aud": "dashboard", "exp": time.Now().Add(time.Hour).Unix(), "groups": groups})
+	}
+
+	tests := []struct {
+		name           string
+		method         string
+		token          string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{name: "admin writes", method: http.MethodDelete, token: token("admins"), expectedStatus: http.StatusOK, expectedBody: "admin"},
+		{name: "viewer reads", method: http.MethodGet, token: token("staff"), expectedStatus: http.StatusOK, expectedBody: "viewer"},
+		{name: "viewer cannot write", method: http.MethodDelete, token: token("staff"), expectedStatus: http.StatusForbidden},
+		{name: "no matching group", method: http.MethodGet, token: token("contractors"), expectedStatus: http.StatusForbidden},
+		{name: "malformed token", method: http.MethodGet, token: token("admins")[:40] + ".e30.", expectedStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/api/urls/abc123", nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedBody != "" {
+				assert.Equal(t, tt.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
+
+// newTestIdentityProvider starts an OpenID Connect provider with an ES256
+// signing key and returns its issuer URL and a function signing ID tokens
+func newTestIdentityProvider(t *testing.T) (string, func(claims map[string]any) string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	encode := base64.RawURLEncoding.EncodeToString
+
+	var server *httptest.Server
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{
+			"issuer":                 server.URL,
+			"authorization_endpoint": server.URL + "/authorize",
+			"token_endpoint":         server.URL + "/token",
+			"jwks_uri":               server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]any{"keys": []map[string]string{{
+			"kty": "EC", "kid": "test", "crv": "P-256",
+			"x": encode(key.X.FillBytes(make([]byte, 32))),
+			"y": encode(key.Y.FillBytes(make([]byte, 32))),
+		}}})
+	})
+	server = httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	sign := func(claims map[string]any) string {
+		header, err := json.Marshal(map[string]string{"alg": "ES256", "kid": "test"})
+		require.NoError(t, err)
+		payload, err := json.Marshal(claims)
+		require.NoError(t, err)
+
+		signed := encode(header) + "." + encode(payload)
+		digest := sha256.Sum256([]byte(signed))
+		r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+		require.NoError(t, err)
+		return signed + "." + encode(append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...))
+	}
+	return server.URL, sign
+}
+
+func TestTracingMiddleware(t *testing.T) {
+	bodies := make(chan []byte, 10)
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies <- body
+	}))
+	defer collector.Close()
+
+	config := tracing.DefaultConfig()
+	config.Exporter = tracing.ExporterOTLP
+	config.Endpoint = collector.URL
+	tracer, err := tracing.New(config, "test")
+	require.NoError(t, err)
+	require.NoError(t, tracer.Start(context.Background()))
+
+	// Handlers receive the request's span in their context
+	mockService := &mocks.URLShortener{}
+	mockService.On("GetURLInfo", mock.Anything, "missing").
+		Run(func(args mock.Arguments) {
+			assert.NotNil(t, tracing.FromContext(args.Get(0).(context.Context)))
+		}).
+		Return(nil, domain.ErrURLNotFound)
+	server := NewServer(mockService, "8080", "http://localhost:8080", false, WithTracer(tracer))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/urls/missing", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	w := httptest.NewRecorder()
+	server.server.Handler.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", w.Header().Get("Trace-Id"))
+
+	// Probes are not traced
+	req = httptest.NewRequest(http.MethodGet, "/healthz", nil)
+	w = httptest.NewRecorder()
+	server.server.Handler.ServeHTTP(w, req)
+	assert.Empty(t, w.Header().Get("Trace-Id"))
+
+	require.NoError(t, tracer.Close())
+	assert.Equal(t, int64(1), tracer.Exported())
+
+	var export struct {
+		ResourceSpans []struct {
+			ScopeSpans []struct {
+				Spans []struct {
+					TraceID      string `json:"traceId"`
+					ParentSpanID string `json:"parentSpanId"`
+					Name         string `json:"name"`
+					Attributes   []struct {
+						Key   string         `json:"key"`
+						Value map[string]any `json:"value"`
+					} `json:"attributes"`
+					Status struct {
+						Code int `json:"code"`
+					} `json:"status"`
+				} `json:"spans"`
+			} `json:"scopeSpans"`
+		} `json:"resourceSpans"`
+	}
+	require.NoError(t, json.Unmarshal(<-bodies, &export))
+	span := export.ResourceSpans[0].ScopeSpans[0].Spans[0]
+	assert.Equal(t, "GET /api/urls/", span.Name)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.TraceID)
+	assert.Equal(t, "00f067aa0ba902b7", span.ParentSpanID)
+	assert.Zero(t, span.Status.Code, "client errors do not fail the span")
+	attributes := make(map[string]any)
+	for _, attr := range span.Attributes {
+		for _, value := range attr.Value {
+			attributes[attr.Key] = value
+		}
+	}
+	assert.Equal(t, "/api/urls/missing", attributes["url.path"])
+	assert.Equal(t, "/api/urls/", attributes["http.route"])
+	assert.Equal(t, "404", attributes["http.response.status_code"])
+}
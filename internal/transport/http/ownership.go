@@ -0,0 +1,49 @@
+package http
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/joshdurbin/url-shortener/internal/auth"
+	"github.com/joshdurbin/url-shortener/internal/domain"
+)
+
+// requestOwner returns the owner recorded on links created by the request's
+// principal, or "" when it has none (e.g. auth is disabled)
+func requestOwner(r *http.Request) string {
+	principal, ok := auth.PrincipalFromContext(r.Context())
+	if !ok {
+		return ""
+	}
+	return principal.Owner()
+}
+
+// authorizeOwner reports whether the request's principal may modify a link,
+// writing a 403 response when it may not. Admin keys override ownership.
+// It fails closed: an unknown code is answered with 404 and a failed lookup
+// with 500.
+func (h *Handler) authorizeOwner(w http.ResponseWriter, r *http.Request, shortCode string) bool {
+	principal, ok := auth.PrincipalFromContext(r.Context())
+	if !ok || principal.ManagesAll() {
+		return true
+	}
+
+	entry, err := h.shortener.GetURLInfo(r.Context(), shortCode)
+	if errors.Is(err, domain.ErrURLNotFound) {
+		writeServiceError(w, err)
+		return false
+	}
+	if err != nil {
+		log.Printf("[ERROR] Failed to check the owner of link '%s': %v", shortCode, err)
+		writeError(w, http.StatusInternalServerError, "Internal server error")
+		return false
+	}
+	if principal.CanManage(entry.Owner) {
+		return true
+	}
+
+	log.Printf("[AUTH] %s %s denied: link '%s' is owned by %q, not %q", r.Method, r.URL.Path, shortCode, entry.Owner, principal.Owner())
+	writeError(w, http.StatusForbidden, "Link is owned by another credential")
+	return false
+}
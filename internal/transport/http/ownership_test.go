@@ -0,0 +1,202 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/joshdurbin/url-shortener/internal/auth"
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	"github.com/joshdurbin/url-shortener/internal/service/mocks"
+)
+
+func TestHandler_Ownership(t *testing.T) {
+	authenticator, err := auth.New(auth.Config{APIKeys: []string{"admin-key"}, UserAPIKeys: []string{"alice-key", "bob-key"}, ShareTokenSecret: "secret"})
+	require.NoError(t, err)
+	alice := "key:" + auth.KeyID("alice-key")
+	aliceLink := &domain.URLEntry{ShortCode: "abc123", OriginalURL: "https://example.com", Owner: alice}
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		key            string
+		body           string
+		setupMocks     func(*mocks.URLShortener)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:   "create records the owner",
+			method: http.MethodPost,
+			path:   "/api/urls",
+			key:    "alice-key",
+			body:   `{"url":"https://example.com"}`,
+			setupMocks: func(shortener *mocks.URLShortener) {
+				shortener.On("CreateShortURL", mock.Anything, "https://example.com", domain.CreateOptions{Owner: alice}).Return(aliceLink, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "owner deletes",
+			method: http.MethodDelete,
+			path:   "/api/urls/abc123",
+			key:    "alice-key",
+			setupMocks: func(shortener *mocks.URLShortener) {
+				shortener.On("GetURLInfo", mock.Anything, "abc123").Return(aliceLink, nil)
+				shortener.On("DeleteShortURL", mock.Anything, "abc123").Return(nil)
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:   "other user cannot delete",
+			method: http.MethodDelete,
+			path:   "/api/urls/abc123",
+			key:    "bob-key",
+			setupMocks: func(shortener *mocks.URLShortener) {
+				shortener.On("GetURLInfo", mock.Anything, "abc123").Return(aliceLink, nil)
+			},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:   "other user cannot update",
+			method: http.MethodPatch,
+			path:   "/api/urls/abc123",
+			key:    "bob-key",
+			body:   `{"max_uses":1}`,
+			setupMocks: func(shortener *mocks.URLShortener) {
+				shortener.On("GetURLInfo", mock.Anything, "abc123").Return(aliceLink, nil)
+			},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:   "other user cannot change routing rules",
+			method: http.MethodDelete,
+			path:   "/api/urls/abc123/routes",
+			key:    "bob-key",
+			setupMocks: func(shortener *mocks.URLShortener) {
+				shortener.On("GetURLInfo", mock.Anything, "abc123").Return(aliceLink, nil)
+			},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:   "other user cannot issue a share token",
+			method: http.MethodPost,
+			path:   "/api/urls/abc123/share-token",
+			key:    "bob-key",
+			setupMocks: func(shortener *mocks.URLShortener) {
+				shortener.On("GetURLInfo", mock.Anything, "abc123").Return(aliceLink, nil)
+			},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:   "owner issues a share token",
+			method: http.MethodPost,
+			path:   "/api/urls/abc123/share-token",
+			key:    "alice-key",
+			setupMocks: func(shortener *mocks.URLShortener) {
+				shortener.On("GetURLInfo", mock.Anything, "abc123").Return(aliceLink, nil)
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:   "unknown link is not found",
+			method: http.MethodDelete,
+			path:   "/api/urls/missing",
+			key:    "bob-key",
+			setupMocks: func(shortener *mocks.URLShortener) {
+				shortener.On("GetURLInfo", mock.Anything, "missing").Return(nil, domain.ErrURLNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:   "failed owner lookup denies the change",
+			method: http.MethodDelete,
+			path:   "/api/urls/abc123",
+			key:    "bob-key",
+			setupMocks: func(shortener *mocks.URLShortener) {
+				shortener.On("GetURLInfo", mock.Anything, "abc123").Return(nil, errors.New("database is locked"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+		{
+			name:   "other user can read",
+			method: http.MethodGet,
+			path:   "/api/urls/abc123",
+			key:    "bob-key",
+			setupMocks: func(shortener *mocks.URLShortener) {
+				shortener.On("GetURLInfo", mock.Anything, "abc123").Return(aliceLink, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "admin key overrides ownership",
+			method: http.MethodDelete,
+			path:   "/api/urls/abc123",
+			key:    "admin-key",
+			setupMocks: func(shortener *mocks.URLShortener) {
+				shortener.On("DeleteShortURL", mock.Anything, "abc123").Return(nil)
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:   "list own links",
+			method: http.MethodGet,
+			path:   "/api/urls?owner=me",
+			key:    "alice-key",
+			setupMocks: func(shortener *mocks.URLShortener) {
+				shortener.On("GetURLsByOwner", mock.Anything, alice).Return([]*domain.URLEntry{aliceLink}, nil)
+				shortener.On("LastRemoval").Return(time.Time{})
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"owner":"` + alice + `"`,
+		},
+		{
+			name:   "list another owner's links",
+			method: http.MethodGet,
+			path:   "/api/urls?owner=" + alice,
+			key:    "admin-key",
+			setupMocks: func(shortener *mocks.URLShortener) {
+				shortener.On("GetURLsByOwner", mock.Anything, alice).Return([]*domain.URLEntry{aliceLink}, nil)
+				shortener.On("LastRemoval").Return(time.Time{})
+			},
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shortener := &mocks.URLShortener{}
+			tt.setupMocks(shortener)
+			server := NewServer(shortener, "8080", "http://localhost:8080", false, WithAuthenticator(authenticator))
+
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("X-API-Key", tt.key)
+			w := httptest.NewRecorder()
+			server.server.Handler.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedBody != "" {
+				assert.Contains(t, w.Body.String(), tt.expectedBody)
+			}
+			shortener.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandler_OwnerMeWithoutAuth(t *testing.T) {
+	server := NewServer(&mocks.URLShortener{}, "8080", "http://localhost:8080", false)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/urls?owner=me", nil)
+	w := httptest.NewRecorder()
+	server.server.Handler.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
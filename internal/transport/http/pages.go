@@ -0,0 +1,242 @@
+package http
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"log"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+)
+
+// defaultPages holds the built-in pages for browsers following a short link
+//
+//go:embed static/pages
+var defaultPages embed.FS
+
+// Page template names; an override directory replaces a page by holding a
+// file of the same name
+const (
+	PageNotFound = "not_found.html"
+	PageExpired  = "expired.html"
+	PageBlocked  = "blocked.html"
+	PageLanding  = "landing.html"
+	PageStats    = "stats.html"
+)
+
+// pageNames lists every page
+var pageNames = []string{PageNotFound, PageExpired, PageBlocked, PageLanding, PageStats}
+
+// PageData is what page templates are executed with
+type PageData struct {
+	Code      string          // The short code that was requested, "" for the root path
+	ServerURL string          // Public base URL of the host the link was requested on, without a trailing slash
+	Status    int             // The response status
+	Brand     domain.Branding // The custom domain's name, logo and color; empty on the server's own host
+	Link      *PageLink       // The link shown by the stats page; nil on other pages
+}
+
+// PageLink is what the stats page shows of a link. It is public, so it holds
+// only what following the link reveals anyway, plus its click count.
+type PageLink struct {
+	ShortURL    string
+	OriginalURL string
+	CreatedAt   time.Time
+	Clicks      int
+	MaxUses     int    // 0 means unlimited
+	Title       string // From the destination's preview, when fetched
+	Description string
+	FaviconURL  string
+}
+
+// ErrorPages renders the HTML pages browsers see when a short link cannot
+// redirect: an unknown code, a link past its usage limit, or a blocked code.
+// It also holds the landing page and the per-link stats page.
+type ErrorPages struct {
+	pages map[string]*template.Template
+}
+
+// DefaultErrorPages returns the built-in error pages
+func DefaultErrorPages() *ErrorPages {
+	pages, err := LoadErrorPages("")
+	if err != nil {
+		panic(err) // The embedded templates are fixed at build time
+	}
+	return pages
+}
+
+// LoadErrorPages parses the error page templates. Pages found in dir (when
+// not empty) replace the built-in ones; the rest keep their default. A
+// template that does not parse is an error, so a typo fails at startup rather
+// than on a visitor's request.
+func LoadErrorPages(dir string) (*ErrorPages, error) {
+	builtIn, err := fs.Sub(defaultPages, "static/pages")
+	if err != nil {
+		return nil, err
+	}
+
+	if dir != "" {
+		info, err := os.Stat(dir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read error pages directory: %w", err)
+		}
+		if !info.IsDir() {
+			return nil, fmt.Errorf("error pages path %s is not a directory", dir)
+		}
+	}
+
+	e := &ErrorPages{pages: make(map[string]*template.Template, len(pageNames))}
+	for _, name := range pageNames {
+		source, err := fs.ReadFile(builtIn, name)
+		if dir != "" {
+			override, overrideErr := os.ReadFile(filepath.Join(dir, name))
+			switch {
+			case overrideErr == nil:
+				source, err = override, nil
+			case !errors.Is(overrideErr, fs.ErrNotExist):
+				return nil, fmt.Errorf("failed to read error page %s: %w", name, overrideErr)
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		tmpl, err := template.New(name).Parse(string(source))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse error page %s: %w", name, err)
+		}
+		e.pages[name] = tmpl
+	}
+	return e, nil
+}
+
+// render writes a page with status. A page that fails to execute is logged
+// and replaced by the plain JSON error, since a half-written page would be
+// worse.
+func (e *ErrorPages) render(w http.ResponseWriter, name string, status int, data PageData) bool {
+	tmpl, ok := e.pages[name]
+	if !ok {
+		return false
+	}
+
+	var body bytes.Buffer
+	if err := tmpl.Execute(&body, data); err != nil {
+		log.Printf("[ERROR] Failed to render error page %s: %v", name, err)
+		return false
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	w.Write(body.Bytes())
+	return true
+}
+
+// pageFor returns the error page and status for an error from GetOriginalURL,
+// or "" when the error has no page (bad template parameters, internal errors)
+func pageFor(err error) (string, int) {
+	switch {
+	case errors.Is(err, domain.ErrLinkBlocked):
+		return PageBlocked, http.StatusForbidden
+	case errors.Is(err, domain.ErrUsageLimitReached):
+		return PageExpired, http.StatusGone
+	case errors.Is(err, domain.ErrNotFound):
+		return PageNotFound, http.StatusNotFound
+	default:
+		return "", 0
+	}
+}
+
+// acceptsHTML reports whether a request comes from a browser, which lists
+// text/html in its Accept header; API clients and curl keep getting JSON
+func acceptsHTML(r *http.Request) bool {
+	for _, accepted := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(accepted))
+		if err == nil && (mediaType == "text/html" || mediaType == "application/xhtml+xml") {
+			return true
+		}
+	}
+	return false
+}
+
+// writeRedirectError answers a short link that cannot redirect: with an error
+// page for browsers, else with the JSON error envelope
+func (h *Handler) writeRedirectError(w http.ResponseWriter, r *http.Request, shortCode string, err error) {
+	if h.pages != nil && acceptsHTML(r) {
+		if name, status := pageFor(err); name != "" {
+			if h.pages.render(w, name, status, h.pageData(r, shortCode, status)) {
+				return
+			}
+		}
+	}
+	writeServiceError(w, err)
+}
+
+// pageData returns the data of a page requested on r's host: the custom
+// domain's base URL and branding, or the server's own
+func (h *Handler) pageData(r *http.Request, shortCode string, status int) PageData {
+	data := PageData{Code: shortCode, ServerURL: h.baseURL(""), Status: status}
+	if customDomain, ok := h.hosts.Lookup(r.Host); ok {
+		data.ServerURL = h.baseURL(customDomain.Host)
+		data.Brand = customDomain.Branding
+	}
+	return data
+}
+
+// landing answers the root path as configured: with the not found page, the
+// landing page, or a redirect to another site
+func (h *Handler) landing(w http.ResponseWriter, r *http.Request) {
+	switch h.redirects.Landing {
+	case "":
+		h.writeRedirectError(w, r, "", domain.NotFound(errors.New("Not found")))
+	case LandingPage:
+		if h.pages == nil || !h.pages.render(w, PageLanding, http.StatusOK, h.pageData(r, "", http.StatusOK)) {
+			writeError(w, http.StatusInternalServerError, "Failed to render landing page")
+		}
+	default:
+		http.Redirect(w, r, h.redirects.Landing, http.StatusFound)
+	}
+}
+
+// statsPage answers /{code}+ with a page showing where the link leads and
+// how often it was followed; unlike a redirect it does not count a click
+func (h *Handler) statsPage(w http.ResponseWriter, r *http.Request, shortCode string) {
+	entry, err := h.shortener.GetURLInfo(r.Context(), shortCode)
+	if customDomain, _ := h.hosts.Lookup(r.Host); err == nil && entry.Domain != customDomain.Host {
+		// Each host shows only its own links
+		err = domain.ErrURLNotFound
+	}
+	if err != nil {
+		if !errors.Is(err, domain.ErrNotFound) {
+			log.Printf("[ERROR] Failed to get stats page for code '%s': %v", shortCode, err)
+		}
+		h.writeRedirectError(w, r, shortCode, err)
+		return
+	}
+
+	data := h.pageData(r, shortCode, http.StatusOK)
+	data.Link = &PageLink{
+		ShortURL:    data.ServerURL + "/" + shortCode,
+		OriginalURL: entry.OriginalURL,
+		CreatedAt:   entry.CreatedAt,
+		Clicks:      entry.UsageCount,
+		MaxUses:     entry.MaxUses,
+	}
+	if preview := entry.Preview; preview != nil {
+		data.Link.Title = preview.Title
+		data.Link.Description = preview.Description
+		data.Link.FaviconURL = preview.FaviconURL
+	}
+	if h.pages == nil || !h.pages.render(w, PageStats, http.StatusOK, data) {
+		writeError(w, http.StatusInternalServerError, "Failed to render stats page")
+	}
+}
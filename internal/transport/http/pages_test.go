@@ -0,0 +1,176 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	"github.com/joshdurbin/url-shortener/internal/service/mocks"
+)
+
+func TestLoadErrorPages(t *testing.T) {
+	t.Run("overrides replace only their page", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, PageNotFound), []byte(`<p>No {{.Code}} on {{.ServerURL}} ({{.Status}})</p>`), 0o644))
+
+		pages, err := LoadErrorPages(dir)
+		require.NoError(t, err)
+
+		w := httptest.NewRecorder()
+		require.True(t, pages.render(w, PageNotFound, http.StatusNotFound, PageData{Code: "<abc>", ServerURL: "https://sho.rt", Status: http.StatusNotFound}))
+		assert.Equal(t, "<p>No &lt;abc&gt; on https://sho.rt (404)</p>", w.Body.String())
+		assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+
+		w = httptest.NewRecorder()
+		require.True(t, pages.render(w, PageExpired, http.StatusGone, PageData{Code: "abc"}))
+		assert.Contains(t, w.Body.String(), "Link expired")
+	})
+
+	t.Run("template errors fail at load", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, PageBlocked), []byte(`{{.Code`), 0o644))
+
+		_, err := LoadErrorPages(dir)
+		assert.ErrorContains(t, err, "failed to parse error page blocked.html")
+	})
+
+	t.Run("missing directory", func(t *testing.T) {
+		_, err := LoadErrorPages(filepath.Join(t.TempDir(), "missing"))
+		assert.ErrorContains(t, err, "failed to read error pages directory")
+	})
+
+	t.Run("file instead of directory", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "page.html")
+		require.NoError(t, os.WriteFile(file, nil, 0o644))
+
+		_, err := LoadErrorPages(file)
+		assert.ErrorContains(t, err, "is not a directory")
+	})
+}
+
+func TestHandler_Redirect_ErrorPages(t *testing.T) {
+	const browserAccept = "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8"
+
+	tests := []struct {
+		name           string
+		path           string
+		accept         string
+		err            error
+		expectedStatus int
+		expectedType   string
+		expectedBody   string
+	}{
+		{"not found page", "/abc123", browserAccept, domain.ErrURLNotFound, http.StatusNotFound, "text/html; charset=utf-8", "http://localhost:8080/abc123"},
+		{"expired page", "/abc123", browserAccept, fmt.Errorf("short code abc123: %w", domain.ErrUsageLimitReached), http.StatusGone, "text/html; charset=utf-8", "Link expired"},
+		{"blocked page", "/abc123", browserAccept, fmt.Errorf("%w: reserved", domain.ErrLinkBlocked), http.StatusForbidden, "text/html; charset=utf-8", "Link blocked"},
+		{"root path", "/", browserAccept, nil, http.StatusNotFound, "text/html; charset=utf-8", "Link not found"},
+		{"JSON for API clients", "/abc123", "*/*", domain.ErrURLNotFound, http.StatusNotFound, "application/json", `"code":"not_found"`},
+		{"internal errors keep JSON", "/abc123", browserAccept, domain.Storage(errors.New("disk I/O error")), http.StatusInternalServerError, "application/json", `"code":"internal_error"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mocks.URLShortener{}
+			if tt.err != nil {
+				mockService.On("GetOriginalURL", mock.Anything, "abc123", mock.Anything).Return("", 0, tt.err)
+			}
+			handler := NewHandler(mockService, "http://localhost:8080")
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.Header.Set("Accept", tt.accept)
+			w := httptest.NewRecorder()
+			handler.Redirect(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Equal(t, tt.expectedType, w.Header().Get("Content-Type"))
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandler_Landing(t *testing.T) {
+	tests := []struct {
+		name             string
+		landing          string
+		expectedStatus   int
+		expectedLocation string
+		expectedBody     string
+	}{
+		{"not found by default", "", http.StatusNotFound, "", "Link not found"},
+		{"landing page", LandingPage, http.StatusOK, "", "http://localhost:8080/abc123"},
+		{"redirect", "https://www.example.com/", http.StatusFound, "https://www.example.com/", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewHandler(&mocks.URLShortener{}, "http://localhost:8080")
+			handler.redirects.Landing = tt.landing
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Accept", "text/html")
+			w := httptest.NewRecorder()
+			handler.Redirect(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Equal(t, tt.expectedLocation, w.Header().Get("Location"))
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+		})
+	}
+}
+
+func TestHandler_StatsPage(t *testing.T) {
+	mockService := &mocks.URLShortener{}
+	mockService.On("GetURLInfo", mock.Anything, "abc123").Return(&domain.URLEntry{
+		ShortCode:   "abc123",
+		OriginalURL: "https://example.com/spring?ref=<x>",
+		CreatedAt:   time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC),
+		UsageCount:  42,
+		MaxUses:     100,
+		Owner:       "key-1234",
+		Preview:     &domain.LinkPreview{Title: "Spring Sale", Description: "Everything must go"},
+	}, nil)
+	mockService.On("GetURLInfo", mock.Anything, "nope").Return(nil, domain.ErrURLNotFound)
+	handler := NewHandler(mockService, "http://localhost:8080")
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Accept", "text/html")
+		w := httptest.NewRecorder()
+		handler.Redirect(w, req)
+		return w
+	}
+
+	// Off by default: the + is part of the code
+	mockService.On("GetOriginalURL", mock.Anything, "abc123+", mock.Anything).Return("", 0, domain.ErrURLNotFound).Once()
+	w := serve("/abc123+")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	handler.redirects.StatsPages = true
+	w = serve("/abc123+")
+	require.Equal(t, http.StatusOK, w.Code)
+	body := w.Body.String()
+	assert.Contains(t, body, "http://localhost:8080/abc123")
+	assert.Contains(t, body, "https://example.com/spring?ref=%3cx%3e")
+	assert.Contains(t, body, "Spring Sale")
+	assert.Contains(t, body, "Everything must go")
+	assert.Contains(t, body, "1 March 2026")
+	assert.Contains(t, body, "42 of 100 allowed")
+	assert.NotContains(t, body, "key-1234")
+
+	w = serve("/nope+")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), "Link not found")
+
+	// Showing the page does not count a click
+	mockService.AssertNotCalled(t, "GetOriginalURL", mock.Anything, "abc123", mock.Anything)
+	mockService.AssertExpectations(t)
+}
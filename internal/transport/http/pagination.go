@@ -0,0 +1,56 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+)
+
+// totalCountHeader carries the size of the whole listing on a paged response
+const totalCountHeader = "X-Total-Count"
+
+// page selects part of a link listing from ?limit= and ?offset=; the zero
+// page is the whole listing
+type page struct {
+	limit  int // Most links returned; 0 returns every link after offset
+	offset int // Links skipped from the start of the listing
+}
+
+// parsePage reads the page a listing request asks for
+func parsePage(query url.Values) (page, error) {
+	var p page
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > domain.MaxListLimit {
+			return page{}, fmt.Errorf("limit must be between 1 and %d", domain.MaxListLimit)
+		}
+		p.limit = limit
+	}
+	if value := query.Get("offset"); value != "" {
+		offset, err := strconv.Atoi(value)
+		if err != nil || offset < 0 {
+			return page{}, fmt.Errorf("offset must be a non-negative integer")
+		}
+		p.offset = offset
+	}
+	return p, nil
+}
+
+// apply returns the page's part of entries. A paged response reports the
+// size of the whole listing in X-Total-Count, so clients know when to stop.
+func (p page) apply(w http.ResponseWriter, entries []*domain.URLEntry) []*domain.URLEntry {
+	if p == (page{}) {
+		return entries
+	}
+	w.Header().Set(totalCountHeader, strconv.Itoa(len(entries)))
+
+	start := min(p.offset, len(entries))
+	end := len(entries)
+	if p.limit > 0 {
+		end = min(start+p.limit, end)
+	}
+	return append([]*domain.URLEntry{}, entries[start:end]...)
+}
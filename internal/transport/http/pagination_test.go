@@ -0,0 +1,65 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	"github.com/joshdurbin/url-shortener/internal/service/mocks"
+)
+
+func TestHandler_ListURLs_Paged(t *testing.T) {
+	mockService := &mocks.URLShortener{}
+	mockService.On("GetAllURLs", mock.Anything).Return([]*domain.URLEntry{
+		{ID: 1, ShortCode: "a"},
+		{ID: 2, ShortCode: "b"},
+		{ID: 3, ShortCode: "c"},
+	}, nil)
+	mockService.On("LastRemoval").Return(time.Time{})
+	handler := NewHandler(mockService, "http://localhost:8080")
+
+	list := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/urls"+query, nil)
+		w := httptest.NewRecorder()
+		handler.ListURLs(w, req)
+		return w
+	}
+	codes := func(w *httptest.ResponseRecorder) []string {
+		var entries []domain.URLEntry
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &entries))
+		result := make([]string, len(entries))
+		for i, entry := range entries {
+			result[i] = entry.ShortCode
+		}
+		return result
+	}
+
+	// Unpaged listings are unchanged
+	w := list("")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("X-Total-Count"))
+	assert.Equal(t, []string{"a", "b", "c"}, codes(w))
+
+	w = list("?limit=2")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "3", w.Header().Get("X-Total-Count"))
+	assert.Equal(t, []string{"a", "b"}, codes(w))
+
+	w = list("?limit=2&offset=2")
+	assert.Equal(t, []string{"c"}, codes(w))
+
+	w = list("?offset=5")
+	assert.Equal(t, "3", w.Header().Get("X-Total-Count"))
+	assert.Empty(t, codes(w))
+
+	for _, query := range []string{"?limit=0", "?limit=1001", "?limit=x", "?offset=-1"} {
+		assert.Equal(t, http.StatusBadRequest, list(query).Code, query)
+	}
+}
@@ -0,0 +1,59 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/joshdurbin/url-shortener/internal/peers"
+)
+
+// maxInvalidationBytes bounds the body of a cache invalidation
+const maxInvalidationBytes = 64 * 1024
+
+// CacheInvalidate handles POST /internal/cache/invalidate from another
+// instance sharing the database, reloading the links it changed
+func (h *Handler) CacheInvalidate(w http.ResponseWriter, r *http.Request) {
+	if h.peerSecret == "" {
+		writeError(w, http.StatusNotImplemented, "Cache invalidation is not configured")
+		return
+	}
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxInvalidationBytes+1))
+	if err != nil || len(body) > maxInvalidationBytes {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if !peers.Verify(h.peerSecret, r.Header.Get(peers.HeaderTimestamp), r.Header.Get(peers.HeaderSignature), body, time.Now()) {
+		writeError(w, http.StatusUnauthorized, "Invalid signature")
+		return
+	}
+
+	var invalidation peers.Invalidation
+	if err := json.Unmarshal(body, &invalidation); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+
+	if err := h.shortener.ReloadLinks(r.Context(), invalidation.ShortCodes); err != nil {
+		log.Printf("Error reloading invalidated links: %v", err)
+		writeError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writePeerMetrics writes cache invalidation counters in the Prometheus text format
+func writePeerMetrics(w io.Writer, broadcaster *peers.Broadcaster) {
+	fmt.Fprintf(w, "# HELP url_shortener_peer_invalidations_sent_total Cache invalidations peers accepted.\n# TYPE url_shortener_peer_invalidations_sent_total counter\nurl_shortener_peer_invalidations_sent_total %d\n", broadcaster.Sent())
+	fmt.Fprintf(w, "# HELP url_shortener_peer_invalidations_failed_total Cache invalidations that did not reach a peer.\n# TYPE url_shortener_peer_invalidations_failed_total counter\nurl_shortener_peer_invalidations_failed_total %d\n", broadcaster.Failed())
+	fmt.Fprintf(w, "# HELP url_shortener_peer_invalidations_dropped_total Changed links not broadcast because the queue was full.\n# TYPE url_shortener_peer_invalidations_dropped_total counter\nurl_shortener_peer_invalidations_dropped_total %d\n", broadcaster.Dropped())
+}
@@ -0,0 +1,75 @@
+package http
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/joshdurbin/url-shortener/internal/peers"
+	"github.com/joshdurbin/url-shortener/internal/service/mocks"
+)
+
+// invalidationRequest builds a cache invalidation signed with secret at signedAt
+func invalidationRequest(secret string, signedAt time.Time, body string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, peers.Path, bytes.NewBufferString(body))
+	req.Header.Set(peers.HeaderTimestamp, strconv.FormatInt(signedAt.Unix(), 10))
+	req.Header.Set(peers.HeaderSignature, peers.Sign(secret, signedAt.Unix(), []byte(body)))
+	return req
+}
+
+func TestHandler_CacheInvalidate(t *testing.T) {
+	body := `{"short_codes":["abc123","xyz789"]}`
+
+	tests := []struct {
+		name       string
+		secret     string
+		req        *http.Request
+		reloadErr  error
+		wantStatus int
+		wantReload bool
+	}{
+		{name: "not configured", req: invalidationRequest("secret", time.Now(), body), wantStatus: http.StatusNotImplemented},
+		{name: "reloads links", secret: "secret", req: invalidationRequest("secret", time.Now(), body), wantStatus: http.StatusNoContent, wantReload: true},
+		{name: "wrong secret", secret: "secret", req: invalidationRequest("other", time.Now(), body), wantStatus: http.StatusUnauthorized},
+		{name: "replayed", secret: "secret", req: invalidationRequest("secret", time.Now().Add(-time.Hour), body), wantStatus: http.StatusUnauthorized},
+		{name: "invalid JSON", secret: "secret", req: invalidationRequest("secret", time.Now(), "{"), wantStatus: http.StatusBadRequest},
+		{name: "wrong method", secret: "secret", req: httptest.NewRequest(http.MethodGet, peers.Path, nil), wantStatus: http.StatusMethodNotAllowed},
+		{name: "reload fails", secret: "secret", req: invalidationRequest("secret", time.Now(), body), reloadErr: errors.New("database is locked"), wantStatus: http.StatusInternalServerError, wantReload: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shortener := &mocks.URLShortener{}
+			if tt.wantReload {
+				shortener.On("ReloadLinks", mock.Anything, []string{"abc123", "xyz789"}).Return(tt.reloadErr)
+			}
+			server := NewServer(shortener, "8080", "http://localhost:8080", false, WithPeers(tt.secret, nil))
+
+			w := httptest.NewRecorder()
+			server.server.Handler.ServeHTTP(w, tt.req)
+			assert.Equal(t, tt.wantStatus, w.Code)
+			shortener.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandler_PeerMetrics(t *testing.T) {
+	broadcaster := peers.New(peers.Config{Peers: []string{"http://10.0.0.2:8080"}, Secret: "secret", Timeout: time.Second, QueueSize: 10})
+	server := NewServer(&mocks.URLShortener{}, "8080", "http://localhost:8080", false, WithPeers("secret", broadcaster))
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	w := httptest.NewRecorder()
+	server.server.Handler.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "url_shortener_peer_invalidations_sent_total 0\n")
+	assert.Contains(t, w.Body.String(), "url_shortener_peer_invalidations_failed_total 0\n")
+	assert.Contains(t, w.Body.String(), "url_shortener_peer_invalidations_dropped_total 0\n")
+}
@@ -0,0 +1,155 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+)
+
+// PoliciesHandler routes /api/policies requests
+func (h *Handler) PoliciesHandler(w http.ResponseWriter, r *http.Request) {
+	if h.policies == nil {
+		writeError(w, http.StatusNotImplemented, "Lifecycle policies are not configured")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, h.policies.Policies())
+	case http.MethodPost:
+		h.CreatePolicy(w, r)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// PoliciesDetailHandler routes /api/policies/{id}, /api/policies/preview,
+// /api/policies/run, and /api/policies/actions requests
+func (h *Handler) PoliciesDetailHandler(w http.ResponseWriter, r *http.Request) {
+	if h.policies == nil {
+		writeError(w, http.StatusNotImplemented, "Lifecycle policies are not configured")
+		return
+	}
+
+	switch path := strings.TrimPrefix(r.URL.Path, "/api/policies/"); path {
+	case "preview":
+		h.PreviewPolicies(w, r)
+	case "run":
+		h.RunPolicies(w, r)
+	case "actions":
+		h.ListPolicyActions(w, r)
+	default:
+		id, err := strconv.ParseInt(path, 10, 64)
+		if err != nil || id <= 0 {
+			writeError(w, http.StatusBadRequest, "Invalid policy ID")
+			return
+		}
+		if r.Method != http.MethodDelete {
+			writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+		h.DeletePolicy(w, r, id)
+	}
+}
+
+// CreatePolicy handles POST /api/policies
+func (h *Handler) CreatePolicy(w http.ResponseWriter, r *http.Request) {
+	var req domain.LifecyclePolicy
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("[ERROR] Invalid JSON in create policy request: %v", err)
+		writeError(w, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+
+	policy, err := h.policies.CreatePolicy(r.Context(), req)
+	if err != nil {
+		log.Printf("[ERROR] Failed to create policy '%s': %v", req.Name, err)
+		writeServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, policy)
+}
+
+// DeletePolicy handles DELETE /api/policies/{id}
+func (h *Handler) DeletePolicy(w http.ResponseWriter, r *http.Request, id int64) {
+	if err := h.policies.DeletePolicy(r.Context(), id); err != nil {
+		if errors.Is(err, domain.ErrPolicyNotFound) {
+			writeError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		log.Printf("[ERROR] Failed to delete policy %d: %v", id, err)
+		writeError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// PreviewPolicies handles GET /api/policies/preview, a dry run listing the
+// links the policies would act on now
+func (h *Handler) PreviewPolicies(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	matches, err := h.policies.Preview(r.Context())
+	if err != nil {
+		log.Printf("Error previewing lifecycle policies: %v", err)
+		writeError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, matches)
+}
+
+// RunPolicies handles POST /api/policies/run, applying the policies immediately
+func (h *Handler) RunPolicies(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	actions, err := h.policies.Run(r.Context())
+	if err != nil {
+		log.Printf("Error applying lifecycle policies: %v", err)
+		writeError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, actions)
+}
+
+// ListPolicyActions handles GET /api/policies/actions, the audit log of
+// policy actions, newest first, with an optional ?limit=
+func (h *Handler) ListPolicyActions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	limit := defaultLogLimit
+	if value := r.URL.Query().Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > maxLogLimit {
+			writeError(w, http.StatusBadRequest, "limit must be between 1 and "+strconv.Itoa(maxLogLimit))
+			return
+		}
+		limit = parsed
+	}
+
+	actions, err := h.policies.Actions(r.Context(), limit)
+	if err != nil {
+		log.Printf("Error listing policy actions: %v", err)
+		writeError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, actions)
+}
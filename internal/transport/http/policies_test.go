@@ -0,0 +1,188 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	"github.com/joshdurbin/url-shortener/internal/policy"
+	repoMocks "github.com/joshdurbin/url-shortener/internal/repository/mocks"
+	"github.com/joshdurbin/url-shortener/internal/service/mocks"
+)
+
+func TestHandler_Policies(t *testing.T) {
+	old := time.Now().Add(-60 * 24 * time.Hour)
+	links := []*domain.URLEntry{
+		{ShortCode: "oldtmp", OriginalURL: "https://example.com", CreatedAt: old, Tags: []string{"temp"}},
+		{ShortCode: "keep01", OriginalURL: "https://example.org", CreatedAt: time.Now()},
+	}
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		setupMocks     func(*repoMocks.PolicyRepository, *mocks.URLShortener)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "list includes file policies",
+			method:         http.MethodGet,
+			path:           "/api/policies",
+			setupMocks:     func(store *repoMocks.PolicyRepository, shortener *mocks.URLShortener) {},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"name":"expire-temp","tag":"temp","older_than":"30d","action":"delete"`,
+		},
+		{
+			name:   "create",
+			method: http.MethodPost,
+			path:   "/api/policies",
+			body:   `{"name":"archive-unused","unused_for":"180d","action":"archive"}`,
+			setupMocks: func(store *repoMocks.PolicyRepository, shortener *mocks.URLShortener) {
+				store.On("CreateLifecyclePolicy", mock.Anything, mock.AnythingOfType("*domain.LifecyclePolicy")).
+					Return(&domain.LifecyclePolicy{ID: 1, Name: "archive-unused", UnusedFor: domain.Age(180 * 24 * time.Hour), Action: domain.PolicyActionArchive}, nil)
+			},
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `"id":1`,
+		},
+		{
+			name:           "create with invalid age",
+			method:         http.MethodPost,
+			path:           "/api/policies",
+			body:           `{"name":"p","older_than":"a while","action":"delete"}`,
+			setupMocks:     func(store *repoMocks.PolicyRepository, shortener *mocks.URLShortener) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "create with unknown action",
+			method:         http.MethodPost,
+			path:           "/api/policies",
+			body:           `{"name":"p","older_than":"1d","action":"expire"}`,
+			setupMocks:     func(store *repoMocks.PolicyRepository, shortener *mocks.URLShortener) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "unknown policy action",
+		},
+		{
+			name:   "delete",
+			method: http.MethodDelete,
+			path:   "/api/policies/1",
+			setupMocks: func(store *repoMocks.PolicyRepository, shortener *mocks.URLShortener) {
+				store.On("DeleteLifecyclePolicy", mock.Anything, int64(1)).Return(nil)
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:   "delete unknown",
+			method: http.MethodDelete,
+			path:   "/api/policies/99",
+			setupMocks: func(store *repoMocks.PolicyRepository, shortener *mocks.URLShortener) {
+				store.On("DeleteLifecyclePolicy", mock.Anything, int64(99)).Return(domain.ErrPolicyNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "invalid id",
+			method:         http.MethodDelete,
+			path:           "/api/policies/abc",
+			setupMocks:     func(store *repoMocks.PolicyRepository, shortener *mocks.URLShortener) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "preview",
+			method: http.MethodGet,
+			path:   "/api/policies/preview",
+			setupMocks: func(store *repoMocks.PolicyRepository, shortener *mocks.URLShortener) {
+				shortener.On("GetAllURLs", mock.Anything).Return(links, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"policy":"expire-temp","action":"delete","link":{"id":0,"short_code":"oldtmp"`,
+		},
+		{
+			name:   "run",
+			method: http.MethodPost,
+			path:   "/api/policies/run",
+			setupMocks: func(store *repoMocks.PolicyRepository, shortener *mocks.URLShortener) {
+				shortener.On("GetAllURLs", mock.Anything).Return(links, nil)
+				shortener.On("DeleteShortURL", mock.Anything, "oldtmp").Return(nil)
+				store.On("RecordPolicyAction", mock.Anything, mock.AnythingOfType("*domain.PolicyAuditEntry")).Return(nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"short_code":"oldtmp"`,
+		},
+		{
+			name:           "run requires POST",
+			method:         http.MethodGet,
+			path:           "/api/policies/run",
+			setupMocks:     func(store *repoMocks.PolicyRepository, shortener *mocks.URLShortener) {},
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+		{
+			name:   "actions",
+			method: http.MethodGet,
+			path:   "/api/policies/actions?limit=5",
+			setupMocks: func(store *repoMocks.PolicyRepository, shortener *mocks.URLShortener) {
+				store.On("ListPolicyActions", mock.Anything, 5).Return([]*domain.PolicyAuditEntry{
+					{ID: 3, Policy: "expire-temp", Action: domain.PolicyActionDelete, ShortCode: "oldtmp"},
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"id":3`,
+		},
+		{
+			name:           "actions with invalid limit",
+			method:         http.MethodGet,
+			path:           "/api/policies/actions?limit=5000",
+			setupMocks:     func(store *repoMocks.PolicyRepository, shortener *mocks.URLShortener) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &repoMocks.PolicyRepository{}
+			store.On("ListLifecyclePolicies", mock.Anything).Return([]*domain.LifecyclePolicy{}, nil)
+			shortener := &mocks.URLShortener{}
+			tt.setupMocks(store, shortener)
+
+			static := []*domain.LifecyclePolicy{
+				{Name: "expire-temp", Tag: "temp", OlderThan: domain.Age(30 * 24 * time.Hour), Action: domain.PolicyActionDelete},
+			}
+			engine := policy.New(policy.Config{}, store, shortener, static)
+			assert.NoError(t, engine.Start(context.Background()))
+			defer engine.Close()
+
+			server := NewServer(shortener, "8080", "http://localhost:8080", false, WithPolicies(engine))
+
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+			server.server.Handler.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedBody != "" {
+				assert.Contains(t, w.Body.String(), tt.expectedBody)
+			}
+			store.AssertExpectations(t)
+			shortener.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandler_PoliciesNotConfigured(t *testing.T) {
+	server := NewServer(&mocks.URLShortener{}, "8080", "http://localhost:8080", false)
+
+	for _, path := range []string{"/api/policies", "/api/policies/preview"} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		w := httptest.NewRecorder()
+		server.server.Handler.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotImplemented, w.Code)
+	}
+}
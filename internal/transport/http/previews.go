@@ -0,0 +1,39 @@
+package http
+
+import (
+	"log"
+	"net/http"
+	"strings"
+)
+
+// RefreshPreview handles POST /api/urls/{shortCode}/preview, fetching the
+// link's preview again now and returning it
+func (h *Handler) RefreshPreview(w http.ResponseWriter, r *http.Request) {
+	if h.previews == nil {
+		writeError(w, http.StatusNotImplemented, "Link previews are not configured")
+		return
+	}
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	shortCode := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/urls/"), "/preview")
+	if shortCode == "" {
+		writeError(w, http.StatusBadRequest, "Short code is required")
+		return
+	}
+
+	if !h.authorizeOwner(w, r, shortCode) {
+		return
+	}
+
+	preview, err := h.previews.Refresh(r.Context(), shortCode)
+	if err != nil {
+		log.Printf("[ERROR] Failed to refresh the preview of code '%s': %v", shortCode, err)
+		writeServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, preview)
+}
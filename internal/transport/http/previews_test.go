@@ -0,0 +1,63 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	"github.com/joshdurbin/url-shortener/internal/preview"
+	repoMocks "github.com/joshdurbin/url-shortener/internal/repository/mocks"
+	"github.com/joshdurbin/url-shortener/internal/service/mocks"
+)
+
+func TestHandler_RefreshPreview(t *testing.T) {
+	site := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<title>Example Domain</title>`))
+	}))
+	defer site.Close()
+
+	store := &repoMocks.URLRepository{}
+	store.On("GetURL", mock.Anything, "abc123").Return(&domain.URLEntry{ShortCode: "abc123", OriginalURL: site.URL}, nil)
+	store.On("GetURL", mock.Anything, "missing").Return(nil, domain.ErrURLNotFound)
+	store.On("SetURLPreview", mock.Anything, "abc123", mock.AnythingOfType("domain.LinkPreview")).
+		Return(&domain.URLEntry{ShortCode: "abc123", Preview: &domain.LinkPreview{Title: "Example Domain"}}, nil)
+	fetcher := preview.New(preview.DefaultConfig(), store)
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		fetcher        *preview.Fetcher
+		expectedStatus int
+		expectedBody   string
+	}{
+		{"refresh", http.MethodPost, "/api/urls/abc123/preview", fetcher, http.StatusOK, `"title":"Example Domain"`},
+		{"unknown code", http.MethodPost, "/api/urls/missing/preview", fetcher, http.StatusNotFound, `"code":"not_found"`},
+		{"wrong method", http.MethodGet, "/api/urls/abc123/preview", fetcher, http.StatusMethodNotAllowed, ""},
+		{"not configured", http.MethodPost, "/api/urls/abc123/preview", nil, http.StatusNotImplemented, "Link previews are not configured"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := NewServer(&mocks.URLShortener{}, "8080", "http://localhost:8080", false, WithPreviews(tt.fetcher))
+
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			w := httptest.NewRecorder()
+			server.server.Handler.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedBody != "" {
+				assert.Contains(t, w.Body.String(), tt.expectedBody)
+			}
+		})
+	}
+	store.AssertExpectations(t)
+}
@@ -0,0 +1,242 @@
+package http
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// ProxyConfig says which reverse proxies may report the visitor's address.
+// Requests arriving from a trusted proxy take their client address from the
+// Forwarded (RFC 7239), X-Forwarded-For or X-Real-IP header, in that order of
+// preference, or only from ClientIPHeader when it is set; all others keep the
+// connection's address. Logging, tracing, click dedupe, bot checks and
+// country lookups all see the result.
+type ProxyConfig struct {
+	// TrustedProxies are networks (CIDRs) or single addresses of proxies, or
+	// "unix" for proxies connecting over unix socket listeners
+	TrustedProxies []string
+
+	// ClientIPHeader, when set, names the one header trusted proxies put the
+	// visitor's address in (e.g. CF-Connecting-IP); its first address is used
+	ClientIPHeader string
+}
+
+// Validate checks that every trusted proxy is an address, CIDR or "unix",
+// and that a client IP header has proxies to trust it from
+func (c ProxyConfig) Validate() error {
+	if strings.ContainsAny(c.ClientIPHeader, " :") {
+		return fmt.Errorf("client IP header must be a header name, got: %q", c.ClientIPHeader)
+	}
+	if c.ClientIPHeader != "" && !c.Enabled() {
+		return fmt.Errorf("client IP header %s needs trusted proxies to believe it from", c.ClientIPHeader)
+	}
+	_, _, err := c.parse()
+	return err
+}
+
+// Enabled reports whether any proxy is trusted
+func (c ProxyConfig) Enabled() bool {
+	return len(c.TrustedProxies) > 0
+}
+
+// parse returns the trusted networks, a single address being a one-address
+// network, and whether unix socket peers are trusted
+func (c ProxyConfig) parse() ([]netip.Prefix, bool, error) {
+	prefixes := make([]netip.Prefix, 0, len(c.TrustedProxies))
+	unix := false
+	for _, proxy := range c.TrustedProxies {
+		proxy = strings.TrimSpace(proxy)
+		if proxy == "unix" {
+			unix = true
+			continue
+		}
+		if prefix, err := netip.ParsePrefix(proxy); err == nil {
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(proxy)
+		if err != nil {
+			return nil, false, fmt.Errorf("trusted proxy must be an IP address, CIDR or \"unix\", got: %q", proxy)
+		}
+		addr = addr.Unmap()
+		prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return prefixes, unix, nil
+}
+
+// ProxyMiddleware replaces the address of requests from trusted proxies with
+// the client address they report
+type ProxyMiddleware struct {
+	trusted   []netip.Prefix
+	trustUnix bool
+	header    string // The only header the client address is taken from, if set
+}
+
+// NewProxyMiddleware creates the middleware; the configuration must be valid
+func NewProxyMiddleware(config ProxyConfig) *ProxyMiddleware {
+	trusted, trustUnix, _ := config.parse()
+	return &ProxyMiddleware{trusted: trusted, trustUnix: trustUnix, header: config.ClientIPHeader}
+}
+
+// Middleware returns the client address middleware function
+func (m *ProxyMiddleware) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if client, ok := m.clientAddr(r); ok {
+			r.RemoteAddr = client.String()
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// clientAddr walks the forwarding chain from the connection towards the
+// client and returns the first address that is not a trusted proxy. When the
+// connection is not from a trusted proxy there is nothing to believe and ok
+// is false. A hop that cannot be parsed ends the walk at the proxy that
+// reported it, since nothing before it can be checked. With a client IP
+// header, its first address is the client instead.
+func (m *ProxyMiddleware) clientAddr(r *http.Request) (netip.Addr, bool) {
+	var client netip.Addr
+	if remote, ok := parseNode(r.RemoteAddr); ok {
+		if !m.isTrusted(remote) {
+			return netip.Addr{}, false
+		}
+		client = remote
+	} else if !m.trustUnix || !overUnixSocket(r) {
+		return netip.Addr{}, false
+	}
+
+	if m.header != "" {
+		first, _, _ := strings.Cut(r.Header.Get(m.header), ",")
+		if addr, ok := parseNode(strings.TrimSpace(first)); ok {
+			client = addr
+		}
+		return client, client.IsValid()
+	}
+
+	chain := forwardedChain(r.Header)
+	for i := len(chain) - 1; i >= 0; i-- {
+		hop, ok := parseNode(chain[i])
+		if !ok {
+			break
+		}
+		client = hop
+		if !m.isTrusted(hop) {
+			break
+		}
+	}
+	return client, client.IsValid()
+}
+
+// overUnixSocket reports whether the request arrived on a unix socket listener
+func overUnixSocket(r *http.Request) bool {
+	local, ok := r.Context().Value(http.LocalAddrContextKey).(net.Addr)
+	return ok && local.Network() == "unix"
+}
+
+// isTrusted reports whether addr belongs to a trusted proxy
+func (m *ProxyMiddleware) isTrusted(addr netip.Addr) bool {
+	for _, prefix := range m.trusted {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// forwardedChain returns the addresses proxies reported, client first: from
+// Forwarded when present, else X-Forwarded-For, else X-Real-IP
+func forwardedChain(header http.Header) []string {
+	if values := header.Values("Forwarded"); len(values) > 0 {
+		return parseForwarded(values)
+	}
+	if values := header.Values("X-Forwarded-For"); len(values) > 0 {
+		var chain []string
+		for _, value := range values {
+			for _, hop := range strings.Split(value, ",") {
+				chain = append(chain, strings.TrimSpace(hop))
+			}
+		}
+		return chain
+	}
+	if value := header.Get("X-Real-IP"); value != "" {
+		return []string{strings.TrimSpace(value)}
+	}
+	return nil
+}
+
+// parseForwarded returns the for= node of each element of RFC 7239 Forwarded
+// header values, in order. An element without for= yields "", which ends the
+// walk like any other unknown node.
+func parseForwarded(values []string) []string {
+	var nodes []string
+	for _, value := range values {
+		for _, element := range splitQuoted(value, ',') {
+			node := ""
+			for _, pair := range splitQuoted(element, ';') {
+				name, v, ok := strings.Cut(strings.TrimSpace(pair), "=")
+				if ok && strings.EqualFold(name, "for") {
+					node = unquote(strings.TrimSpace(v))
+				}
+			}
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes
+}
+
+// splitQuoted splits s at sep outside quoted strings
+func splitQuoted(s string, sep byte) []string {
+	var parts []string
+	quoted, escaped, start := false, false, 0
+	for i := 0; i < len(s); i++ {
+		switch {
+		case escaped:
+			escaped = false
+		case quoted && s[i] == '\\':
+			escaped = true
+		case s[i] == '"':
+			quoted = !quoted
+		case !quoted && s[i] == sep:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// unquote removes the quotes and escapes of an RFC 7230 quoted string, and
+// returns tokens as they are
+func unquote(s string) string {
+	if len(s) < 2 || s[0] != '"' || s[len(s)-1] != '"' {
+		return s
+	}
+	var b strings.Builder
+	for i := 1; i < len(s)-1; i++ {
+		if s[i] == '\\' && i+1 < len(s)-1 {
+			i++
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// parseNode parses an address as proxies report it: "192.0.2.1",
+// "192.0.2.1:4711", "2001:db8::1" or "[2001:db8::1]:4711". Obfuscated
+// identifiers and "unknown" are not addresses.
+func parseNode(node string) (netip.Addr, bool) {
+	if addr, err := netip.ParseAddr(node); err == nil {
+		return addr.Unmap(), true
+	}
+	host, _, err := net.SplitHostPort(node)
+	if err != nil {
+		host = strings.TrimSuffix(strings.TrimPrefix(node, "["), "]")
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil || addr.Zone() != "" {
+		return netip.Addr{}, false
+	}
+	return addr.Unmap(), true
+}
@@ -0,0 +1,178 @@
+package http
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	"github.com/joshdurbin/url-shortener/internal/service/mocks"
+)
+
+func TestProxyConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		proxies []string
+		header  string
+		wantErr string
+	}{
+		{name: "none"},
+		{name: "addresses and networks", proxies: []string{"10.0.0.0/8", "192.0.2.1", "2001:db8::/32", "unix"}},
+		{name: "host name", proxies: []string{"proxy.internal"}, wantErr: "must be an IP address, CIDR"},
+		{name: "bad network", proxies: []string{"10.0.0.0/33"}, wantErr: "must be an IP address, CIDR"},
+		{name: "client IP header", proxies: []string{"10.0.0.0/8"}, header: "CF-Connecting-IP"},
+		{name: "client IP header without proxies", header: "X-Forwarded-For", wantErr: "needs trusted proxies"},
+		{name: "malformed client IP header", proxies: []string{"10.0.0.0/8"}, header: "X-Forwarded-For: 1", wantErr: "must be a header name"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ProxyConfig{TrustedProxies: tt.proxies, ClientIPHeader: tt.header}.Validate()
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestProxyMiddleware(t *testing.T) {
+	tests := []struct {
+		name       string
+		remoteAddr string
+		header     http.Header
+		want       string
+	}{
+		{name: "untrusted connection keeps its address", remoteAddr: "198.51.100.7:4321",
+			header: http.Header{"X-Forwarded-For": {"203.0.113.9"}}, want: "198.51.100.7:4321"},
+		{name: "trusted proxy without headers", remoteAddr: "10.0.0.1:4321", want: "10.0.0.1"},
+		{name: "X-Forwarded-For", remoteAddr: "10.0.0.1:4321",
+			header: http.Header{"X-Forwarded-For": {"203.0.113.9"}}, want: "203.0.113.9"},
+		{name: "spoofed hops before the client are ignored", remoteAddr: "10.0.0.1:4321",
+			header: http.Header{"X-Forwarded-For": {"192.0.2.66, 203.0.113.9, 10.0.0.2"}}, want: "203.0.113.9"},
+		{name: "X-Forwarded-For over several lines", remoteAddr: "10.0.0.1:4321",
+			header: http.Header{"X-Forwarded-For": {"203.0.113.9", "10.0.0.2"}}, want: "203.0.113.9"},
+		{name: "only trusted hops", remoteAddr: "10.0.0.1:4321",
+			header: http.Header{"X-Forwarded-For": {"10.0.0.3, 10.0.0.2"}}, want: "10.0.0.3"},
+		{name: "unknown hop stops at the proxy that reported it", remoteAddr: "10.0.0.1:4321",
+			header: http.Header{"X-Forwarded-For": {"203.0.113.9, unknown, 10.0.0.2"}}, want: "10.0.0.2"},
+		{name: "X-Real-IP", remoteAddr: "10.0.0.1:4321",
+			header: http.Header{"X-Real-Ip": {"203.0.113.9"}}, want: "203.0.113.9"},
+		{name: "Forwarded wins over X-Forwarded-For", remoteAddr: "10.0.0.1:4321",
+			header: http.Header{"Forwarded": {"for=203.0.113.9;proto=https"}, "X-Forwarded-For": {"192.0.2.66"}}, want: "203.0.113.9"},
+		{name: "Forwarded IPv6 with port", remoteAddr: "10.0.0.1:4321",
+			header: http.Header{"Forwarded": {`For="[2001:db8:cafe::17]:4711"`}}, want: "2001:db8:cafe::17"},
+		{name: "Forwarded chain", remoteAddr: "10.0.0.1:4321",
+			header: http.Header{"Forwarded": {`for=192.0.2.66, for="203.0.113.9:80";by=10.0.0.2`, "for=10.0.0.2"}}, want: "203.0.113.9"},
+		{name: "Forwarded quoted separators", remoteAddr: "10.0.0.1:4321",
+			header: http.Header{"Forwarded": {`for=203.0.113.9;host="a,b;c", for=10.0.0.2`}}, want: "203.0.113.9"},
+		{name: "Forwarded obfuscated client", remoteAddr: "10.0.0.1:4321",
+			header: http.Header{"Forwarded": {"for=_hidden, for=10.0.0.2"}}, want: "10.0.0.2"},
+		{name: "Forwarded element without for", remoteAddr: "10.0.0.1:4321",
+			header: http.Header{"Forwarded": {"proto=https"}}, want: "10.0.0.1"},
+		{name: "trusted IPv6 proxy", remoteAddr: "[2001:db8::2]:4321",
+			header: http.Header{"X-Forwarded-For": {"203.0.113.9"}}, want: "203.0.113.9"},
+		{name: "IPv4-mapped proxy address", remoteAddr: "[::ffff:10.0.0.1]:4321",
+			header: http.Header{"X-Forwarded-For": {"203.0.113.9"}}, want: "203.0.113.9"},
+	}
+
+	middleware := NewProxyMiddleware(ProxyConfig{TrustedProxies: []string{"10.0.0.0/8", "2001:db8::2"}})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/abc123", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for name, values := range tt.header {
+				for _, value := range values {
+					req.Header.Add(name, value)
+				}
+			}
+
+			var got string
+			middleware.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = r.RemoteAddr
+			})).ServeHTTP(httptest.NewRecorder(), req)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestProxyMiddleware_ClientIPHeader(t *testing.T) {
+	tests := []struct {
+		name       string
+		remoteAddr string
+		header     http.Header
+		want       string
+	}{
+		{name: "untrusted connection cannot pick its address", remoteAddr: "198.51.100.7:4321",
+			header: http.Header{"Cf-Connecting-Ip": {"203.0.113.9"}}, want: "198.51.100.7:4321"},
+		{name: "first address from a trusted proxy", remoteAddr: "10.0.0.1:4321",
+			header: http.Header{"Cf-Connecting-Ip": {"203.0.113.9, 10.0.0.2"}}, want: "203.0.113.9"},
+		{name: "other forwarding headers are ignored", remoteAddr: "10.0.0.1:4321",
+			header: http.Header{"X-Forwarded-For": {"192.0.2.66"}}, want: "10.0.0.1"},
+		{name: "malformed header keeps the proxy", remoteAddr: "10.0.0.1:4321",
+			header: http.Header{"Cf-Connecting-Ip": {"unknown"}}, want: "10.0.0.1"},
+	}
+
+	middleware := NewProxyMiddleware(ProxyConfig{TrustedProxies: []string{"10.0.0.0/8"}, ClientIPHeader: "CF-Connecting-IP"})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/abc123", nil)
+			req.RemoteAddr = tt.remoteAddr
+			req.Header = tt.header
+
+			var got string
+			middleware.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = r.RemoteAddr
+			})).ServeHTTP(httptest.NewRecorder(), req)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestProxyMiddleware_UnixSocket(t *testing.T) {
+	unixAddr := &net.UnixAddr{Name: "/run/shortener.sock", Net: "unix"}
+	request := func() *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/abc123", nil)
+		req = req.WithContext(context.WithValue(req.Context(), http.LocalAddrContextKey, unixAddr))
+		req.RemoteAddr = "@"
+		req.Header.Set("X-Real-IP", "203.0.113.9")
+		return req
+	}
+	clientOf := func(config ProxyConfig) string {
+		var got string
+		NewProxyMiddleware(config).Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got = r.RemoteAddr
+		})).ServeHTTP(httptest.NewRecorder(), request())
+		return got
+	}
+
+	assert.Equal(t, "203.0.113.9", clientOf(ProxyConfig{TrustedProxies: []string{"unix"}}))
+	assert.Equal(t, "@", clientOf(ProxyConfig{TrustedProxies: []string{"10.0.0.0/8"}}))
+}
+
+func TestServer_TrustedProxies(t *testing.T) {
+	mockService := &mocks.URLShortener{}
+	mockService.On("GetOriginalURL", mock.Anything, "abc123", mock.MatchedBy(func(req domain.RedirectRequest) bool {
+		return req.ClientIP == "203.0.113.9"
+	})).Return("https://example.com", 0, nil)
+
+	server := NewServer(mockService, "8080", "http://localhost:8080", false,
+		WithProxies(ProxyConfig{TrustedProxies: []string{"10.0.0.0/8"}}))
+
+	req := httptest.NewRequest(http.MethodGet, "/abc123", nil)
+	req.RemoteAddr = "10.0.0.1:4321"
+	req.Header.Set("Forwarded", "for=203.0.113.9")
+	w := httptest.NewRecorder()
+	server.server.Handler.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusFound, w.Code)
+	mockService.AssertExpectations(t)
+}
@@ -0,0 +1,119 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/joshdurbin/url-shortener/internal/auth"
+	"github.com/joshdurbin/url-shortener/internal/domain"
+)
+
+// BulkDelete handles POST /api/urls/delete, deleting the short codes listed
+// in the body. Codes that are unknown, owned by another credential or fail
+// to delete are reported rather than failing the request.
+func (h *Handler) BulkDelete(w http.ResponseWriter, r *http.Request) {
+	var req domain.BulkDeleteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("[ERROR] Invalid JSON in bulk delete request: %v", err)
+		writeError(w, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+	if len(req.ShortCodes) == 0 {
+		writeError(w, http.StatusBadRequest, "short_codes is required")
+		return
+	}
+	if len(req.ShortCodes) > domain.MaxBulkDelete {
+		writeError(w, http.StatusBadRequest, "At most "+strconv.Itoa(domain.MaxBulkDelete)+" short codes can be deleted at once")
+		return
+	}
+
+	principal, _ := auth.PrincipalFromContext(r.Context())
+	result := domain.BulkDeleteResponse{Deleted: []string{}}
+	for _, shortCode := range req.ShortCodes {
+		if principal != nil && !principal.ManagesAll() {
+			// Fail closed: a link whose owner cannot be looked up is not deleted
+			entry, err := h.shortener.GetURLInfo(r.Context(), shortCode)
+			if errors.Is(err, domain.ErrNotFound) {
+				result.NotFound = append(result.NotFound, shortCode)
+				continue
+			}
+			if err != nil {
+				log.Printf("[ERROR] Failed to check the owner of link '%s': %v", shortCode, err)
+				result.Forbidden = append(result.Forbidden, shortCode)
+				continue
+			}
+			if !principal.CanManage(entry.Owner) {
+				result.Forbidden = append(result.Forbidden, shortCode)
+				continue
+			}
+		}
+		h.deleteInto(r.Context(), &result, shortCode)
+	}
+
+	log.Printf("[INFO] Bulk delete removed %d of %d links", len(result.Deleted), len(req.ShortCodes))
+	writeJSON(w, http.StatusOK, result)
+}
+
+// Prune handles POST /api/urls/prune, deleting the links that match every
+// condition in the body, or only listing them for a dry run. Principals that
+// do not manage every link only match their own.
+func (h *Handler) Prune(w http.ResponseWriter, r *http.Request) {
+	var req domain.PruneRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("[ERROR] Invalid JSON in prune request: %v", err)
+		writeError(w, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+	if req.Empty() {
+		writeError(w, http.StatusBadRequest, "unused_for or created_before is required")
+		return
+	}
+
+	entries, err := h.shortener.GetAllURLs(r.Context())
+	if err != nil {
+		log.Printf("Error getting all URLs: %v", err)
+		writeServiceError(w, err)
+		return
+	}
+
+	principal, _ := auth.PrincipalFromContext(r.Context())
+	now := time.Now()
+	response := domain.PruneResponse{Links: []*domain.URLEntry{}, DryRun: req.DryRun}
+	response.Results.Deleted = []string{}
+	for _, entry := range entries {
+		if !req.Matches(entry, now) {
+			continue
+		}
+		if principal != nil && !principal.CanManage(entry.Owner) {
+			continue
+		}
+		response.Links = append(response.Links, entry)
+		if !req.DryRun {
+			h.deleteInto(r.Context(), &response.Results, entry.ShortCode)
+		}
+	}
+
+	if !req.DryRun {
+		log.Printf("[INFO] Prune removed %d of %d matching links", len(response.Results.Deleted), len(response.Links))
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// deleteInto deletes a short code, recording the outcome in result
+func (h *Handler) deleteInto(ctx context.Context, result *domain.BulkDeleteResponse, shortCode string) {
+	err := h.shortener.DeleteShortURL(ctx, shortCode)
+	switch {
+	case err == nil:
+		result.Deleted = append(result.Deleted, shortCode)
+	case errors.Is(err, domain.ErrNotFound):
+		result.NotFound = append(result.NotFound, shortCode)
+	default:
+		log.Printf("[ERROR] Failed to delete URL with code '%s': %v", shortCode, err)
+		result.Failed = append(result.Failed, shortCode)
+	}
+}
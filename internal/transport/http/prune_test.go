@@ -0,0 +1,243 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/joshdurbin/url-shortener/internal/auth"
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	"github.com/joshdurbin/url-shortener/internal/service/mocks"
+)
+
+func TestHandler_BulkDelete(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		setupMocks     func(*mocks.URLShortener)
+		expectedStatus int
+		expected       domain.BulkDeleteResponse
+	}{
+		{
+			name: "reports each code",
+			body: `{"short_codes":["abc123","missing","broken"]}`,
+			setupMocks: func(shortener *mocks.URLShortener) {
+				shortener.On("DeleteShortURL", mock.Anything, "abc123").Return(nil)
+				shortener.On("DeleteShortURL", mock.Anything, "missing").Return(domain.ErrURLNotFound)
+				shortener.On("DeleteShortURL", mock.Anything, "broken").Return(errors.New("database is locked"))
+			},
+			expectedStatus: http.StatusOK,
+			expected:       domain.BulkDeleteResponse{Deleted: []string{"abc123"}, NotFound: []string{"missing"}, Failed: []string{"broken"}},
+		},
+		{
+			name:           "no codes",
+			body:           `{"short_codes":[]}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "too many codes",
+			body:           `{"short_codes":["` + strings.Repeat(`a","`, domain.MaxBulkDelete) + `a"]}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid JSON",
+			body:           `{`,
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shortener := &mocks.URLShortener{}
+			if tt.setupMocks != nil {
+				tt.setupMocks(shortener)
+			}
+			server := NewServer(shortener, "8080", "http://localhost:8080", false)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/urls/delete", bytes.NewBufferString(tt.body))
+			w := httptest.NewRecorder()
+			server.server.Handler.ServeHTTP(w, req)
+
+			require.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusOK {
+				var got domain.BulkDeleteResponse
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+				assert.Equal(t, tt.expected, got)
+			}
+			shortener.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandler_BulkDeleteOwnership(t *testing.T) {
+	authenticator, err := auth.New(auth.Config{UserAPIKeys: []string{"alice-key"}})
+	require.NoError(t, err)
+	alice := "key:" + auth.KeyID("alice-key")
+
+	shortener := &mocks.URLShortener{}
+	shortener.On("GetURLInfo", mock.Anything, "mine").Return(&domain.URLEntry{ShortCode: "mine", Owner: alice}, nil)
+	shortener.On("GetURLInfo", mock.Anything, "theirs").Return(&domain.URLEntry{ShortCode: "theirs", Owner: "key:other"}, nil)
+	shortener.On("DeleteShortURL", mock.Anything, "mine").Return(nil)
+	server := NewServer(shortener, "8080", "http://localhost:8080", false, WithAuthenticator(authenticator))
+
+	req := httptest.NewRequest(http.MethodPost, "/api/urls/delete", bytes.NewBufferString(`{"short_codes":["mine","theirs"]}`))
+	req.Header.Set("X-API-Key", "alice-key")
+	w := httptest.NewRecorder()
+	server.server.Handler.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var got domain.BulkDeleteResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+	assert.Equal(t, domain.BulkDeleteResponse{Deleted: []string{"mine"}, Forbidden: []string{"theirs"}}, got)
+	shortener.AssertNotCalled(t, "DeleteShortURL", mock.Anything, "theirs")
+}
+
+func TestHandler_BulkDeleteOwnerLookupFails(t *testing.T) {
+	authenticator, err := auth.New(auth.Config{UserAPIKeys: []string{"alice-key"}})
+	require.NoError(t, err)
+
+	shortener := &mocks.URLShortener{}
+	shortener.On("GetURLInfo", mock.Anything, "broken").Return(nil, errors.New("database is locked"))
+	shortener.On("GetURLInfo", mock.Anything, "missing").Return(nil, domain.ErrURLNotFound)
+	server := NewServer(shortener, "8080", "http://localhost:8080", false, WithAuthenticator(authenticator))
+
+	req := httptest.NewRequest(http.MethodPost, "/api/urls/delete", bytes.NewBufferString(`{"short_codes":["broken","missing"]}`))
+	req.Header.Set("X-API-Key", "alice-key")
+	w := httptest.NewRecorder()
+	server.server.Handler.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var got domain.BulkDeleteResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+	assert.Equal(t, domain.BulkDeleteResponse{Deleted: []string{}, NotFound: []string{"missing"}, Forbidden: []string{"broken"}}, got)
+	shortener.AssertNotCalled(t, "DeleteShortURL", mock.Anything, mock.Anything)
+}
+
+func TestHandler_Prune(t *testing.T) {
+	now := time.Now()
+	recent := now.Add(-time.Hour)
+	entries := []*domain.URLEntry{
+		{ShortCode: "stale", CreatedAt: now.AddDate(0, 0, -200)},
+		{ShortCode: "used", CreatedAt: now.AddDate(0, 0, -200), LastUsedAt: &recent},
+		{ShortCode: "new", CreatedAt: now.AddDate(0, 0, -1)},
+	}
+
+	tests := []struct {
+		name           string
+		body           string
+		setupMocks     func(*mocks.URLShortener)
+		expectedStatus int
+		expectedLinks  []string
+		expectedResult domain.BulkDeleteResponse
+	}{
+		{
+			name: "dry run",
+			body: `{"unused_for":"90d","dry_run":true}`,
+			setupMocks: func(shortener *mocks.URLShortener) {
+				shortener.On("GetAllURLs", mock.Anything).Return(entries, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedLinks:  []string{"stale"},
+			expectedResult: domain.BulkDeleteResponse{Deleted: []string{}},
+		},
+		{
+			name: "deletes matches",
+			body: `{"created_before":"` + now.AddDate(0, 0, -30).Format(time.RFC3339) + `"}`,
+			setupMocks: func(shortener *mocks.URLShortener) {
+				shortener.On("GetAllURLs", mock.Anything).Return(entries, nil)
+				shortener.On("DeleteShortURL", mock.Anything, "stale").Return(nil)
+				shortener.On("DeleteShortURL", mock.Anything, "used").Return(nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedLinks:  []string{"stale", "used"},
+			expectedResult: domain.BulkDeleteResponse{Deleted: []string{"stale", "used"}},
+		},
+		{
+			name: "service error",
+			body: `{"unused_for":"90d"}`,
+			setupMocks: func(shortener *mocks.URLShortener) {
+				shortener.On("GetAllURLs", mock.Anything).Return(nil, assert.AnError)
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+		{
+			name: "draining",
+			body: `{"unused_for":"90d"}`,
+			setupMocks: func(shortener *mocks.URLShortener) {
+				shortener.On("GetAllURLs", mock.Anything).Return(nil, domain.ErrDraining)
+			},
+			expectedStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name:           "no conditions",
+			body:           `{"dry_run":true}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid age",
+			body:           `{"unused_for":"soon"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shortener := &mocks.URLShortener{}
+			if tt.setupMocks != nil {
+				tt.setupMocks(shortener)
+			}
+			server := NewServer(shortener, "8080", "http://localhost:8080", false)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/urls/prune", bytes.NewBufferString(tt.body))
+			w := httptest.NewRecorder()
+			server.server.Handler.ServeHTTP(w, req)
+
+			require.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusOK {
+				var got domain.PruneResponse
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+				var links []string
+				for _, entry := range got.Links {
+					links = append(links, entry.ShortCode)
+				}
+				assert.Equal(t, tt.expectedLinks, links)
+				assert.Equal(t, tt.expectedResult, got.Results)
+			}
+			shortener.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandler_PruneMatchesOwnLinksOnly(t *testing.T) {
+	authenticator, err := auth.New(auth.Config{UserAPIKeys: []string{"alice-key"}})
+	require.NoError(t, err)
+	alice := "key:" + auth.KeyID("alice-key")
+	old := time.Now().AddDate(-1, 0, 0)
+
+	shortener := &mocks.URLShortener{}
+	shortener.On("GetAllURLs", mock.Anything).Return([]*domain.URLEntry{
+		{ShortCode: "mine", CreatedAt: old, Owner: alice},
+		{ShortCode: "theirs", CreatedAt: old, Owner: "key:other"},
+	}, nil)
+	server := NewServer(shortener, "8080", "http://localhost:8080", false, WithAuthenticator(authenticator))
+
+	req := httptest.NewRequest(http.MethodPost, "/api/urls/prune", bytes.NewBufferString(`{"unused_for":"30d","dry_run":true}`))
+	req.Header.Set("X-API-Key", "alice-key")
+	w := httptest.NewRecorder()
+	server.server.Handler.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var got domain.PruneResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+	require.Len(t, got.Links, 1)
+	assert.Equal(t, "mine", got.Links[0].ShortCode)
+}
@@ -0,0 +1,201 @@
+package http
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+)
+
+// RedirectConfig controls the status code and caching of short link redirects,
+// and what the public paths around them answer
+type RedirectConfig struct {
+	// Status is used for links that do not set their own redirect status:
+	// 301 or 308 (permanent) or 302 or 307 (temporary)
+	Status int
+
+	// PermanentMaxAge, when positive, adds "Cache-Control: public, max-age=N"
+	// to permanent redirects so clients re-check the link eventually. With 0,
+	// clients may cache permanent redirects indefinitely.
+	PermanentMaxAge time.Duration
+
+	// TemporaryMaxAge, when positive, lets clients and CDNs cache temporary
+	// (302 and 307) redirects for that long. With 0 they carry
+	// "Cache-Control: no-store", so every visit reaches the server and is
+	// counted.
+	TemporaryMaxAge time.Duration
+
+	// Landing is what the root path answers: "" the not found page,
+	// LandingPage the landing.html page, or an http or https URL (e.g. a
+	// marketing site) visitors are redirected to
+	Landing string
+
+	// StatsPages, when set, answers /{code}+ with a page showing the link's
+	// destination, preview and clicks instead of redirecting
+	StatsPages bool
+
+	// AllowCrawling lets search engines follow short links; by default
+	// /robots.txt disallows every path
+	AllowCrawling bool
+
+	// FaviconFile is served at /favicon.ico; when empty the icon answers
+	// 204 No Content, so browsers stop asking without reaching the resolver
+	FaviconFile string
+
+	// Fallback, when set, is an http or https URL that unknown codes and
+	// links past their usage limit redirect to instead of answering 404 or
+	// 410, so the visit is not lost. The attempted code is added to its query
+	// as FallbackParam.
+	Fallback string
+
+	// CampaignFallbacks maps campaigns to fallback URLs used instead of
+	// Fallback for the links of that campaign past their usage limit.
+	// Unknown codes have no campaign and always use Fallback.
+	CampaignFallbacks map[string]string
+
+	// FallbackParam is the query parameter carrying the attempted code to a
+	// fallback URL (empty = "code")
+	FallbackParam string
+}
+
+// DefaultFallbackParam is the query parameter fallback URLs get the attempted
+// code in when FallbackParam is not set
+const DefaultFallbackParam = "code"
+
+// LandingPage serves the landing.html page at the root path
+const LandingPage = "page"
+
+// redirectMethods are the methods short links answer; HEAD resolves a link
+// without counting a use
+const redirectMethods = "GET, HEAD, OPTIONS"
+
+// DefaultRedirectConfig returns temporary (302) redirects, matching the
+// behavior before redirect statuses were configurable
+func DefaultRedirectConfig() RedirectConfig {
+	return RedirectConfig{Status: http.StatusFound}
+}
+
+// Validate checks the default status and cache lifetime
+func (c RedirectConfig) Validate() error {
+	if !domain.ValidRedirectStatus(c.Status) {
+		return fmt.Errorf("redirect status must be 301, 302, 307 or 308, got: %d", c.Status)
+	}
+	if c.PermanentMaxAge < 0 {
+		return fmt.Errorf("permanent redirect max age cannot be negative, got: %v", c.PermanentMaxAge)
+	}
+	if c.TemporaryMaxAge < 0 {
+		return fmt.Errorf("temporary redirect max age cannot be negative, got: %v", c.TemporaryMaxAge)
+	}
+	if c.Landing != "" && c.Landing != LandingPage && !isHTTPURL(c.Landing) {
+		return fmt.Errorf("landing must be %q or an http or https URL, got: %q", LandingPage, c.Landing)
+	}
+	if c.Fallback != "" && !isHTTPURL(c.Fallback) {
+		return fmt.Errorf("fallback must be an http or https URL, got: %q", c.Fallback)
+	}
+	for campaign, fallback := range c.CampaignFallbacks {
+		if campaign == "" {
+			return fmt.Errorf("campaign fallback %q has no campaign", fallback)
+		}
+		if !isHTTPURL(fallback) {
+			return fmt.Errorf("fallback of campaign %s must be an http or https URL, got: %q", campaign, fallback)
+		}
+	}
+	if strings.ContainsAny(c.FallbackParam, "&=# ") {
+		return fmt.Errorf("fallback parameter must be a query parameter name, got: %q", c.FallbackParam)
+	}
+	if c.FaviconFile != "" {
+		info, err := os.Stat(c.FaviconFile)
+		if err != nil {
+			return fmt.Errorf("failed to read favicon: %w", err)
+		}
+		if !info.Mode().IsRegular() {
+			return fmt.Errorf("favicon %s is not a file", c.FaviconFile)
+		}
+	}
+	return nil
+}
+
+// isHTTPURL reports whether s is an absolute http or https URL
+func isHTTPURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// hasFallback reports whether any code can be sent to a fallback URL
+func (c RedirectConfig) hasFallback() bool {
+	return c.Fallback != "" || len(c.CampaignFallbacks) > 0
+}
+
+// fallbackURL returns where a visit to shortCode goes instead of an error:
+// the campaign's fallback URL, else the global one, with the code in its
+// query. It returns "" when neither is set.
+func (c RedirectConfig) fallbackURL(shortCode, campaign string) string {
+	target := c.Fallback
+	if campaignTarget, ok := c.CampaignFallbacks[campaign]; ok && campaign != "" {
+		target = campaignTarget
+	}
+	u, err := url.Parse(target)
+	if target == "" || err != nil {
+		return ""
+	}
+	param := c.FallbackParam
+	if param == "" {
+		param = DefaultFallbackParam
+	}
+	query := u.Query()
+	query.Set(param, shortCode)
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// statusFor returns the link's own redirect status, or the default if it has none
+func (c RedirectConfig) statusFor(linkStatus int) int {
+	if linkStatus != 0 {
+		return linkStatus
+	}
+	return c.Status
+}
+
+// clientIP returns the visitor's address: the connection's, which
+// ProxyMiddleware has already replaced with the address a trusted proxy
+// reported. Click dedupe counts repeated clicks from one address once.
+func (c RedirectConfig) clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// setCacheHeaders sets the Cache-Control and Expires headers of a redirect
+// with the given status. Permanent redirects without a max age are left to
+// the client; temporary ones without one must not be stored.
+func (c RedirectConfig) setCacheHeaders(header http.Header, status int, now time.Time) {
+	maxAge := c.TemporaryMaxAge
+	if domain.PermanentRedirect(status) {
+		if c.PermanentMaxAge <= 0 {
+			return
+		}
+		maxAge = c.PermanentMaxAge
+	}
+	if maxAge <= 0 {
+		setNoStore(header)
+		return
+	}
+	header.Set("Cache-Control", "public, max-age="+strconv.Itoa(int(maxAge/time.Second)))
+	// For HTTP/1.0 caches, which ignore Cache-Control
+	header.Set("Expires", now.Add(maxAge).UTC().Format(http.TimeFormat))
+}
+
+// setNoStore keeps clients and caches from storing a response. The invalid
+// Expires date counts as already expired.
+func setNoStore(header http.Header) {
+	header.Set("Cache-Control", "no-store")
+	header.Set("Expires", "0")
+}
@@ -0,0 +1,301 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	"github.com/joshdurbin/url-shortener/internal/service"
+	"github.com/joshdurbin/url-shortener/internal/service/mocks"
+)
+
+func TestRedirectConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  RedirectConfig
+		wantErr string
+	}{
+		{name: "default", config: DefaultRedirectConfig()},
+		{name: "permanent with max age", config: RedirectConfig{Status: http.StatusMovedPermanently, PermanentMaxAge: time.Hour}},
+		{name: "unsupported status", config: RedirectConfig{Status: http.StatusSeeOther}, wantErr: "must be 301, 302, 307 or 308"},
+		{name: "missing status", config: RedirectConfig{}, wantErr: "must be 301, 302, 307 or 308"},
+		{name: "negative max age", config: RedirectConfig{Status: http.StatusFound, PermanentMaxAge: -time.Second}, wantErr: "cannot be negative"},
+		{name: "negative temporary max age", config: RedirectConfig{Status: http.StatusFound, TemporaryMaxAge: -time.Second}, wantErr: "temporary redirect max age"},
+		{name: "landing page", config: RedirectConfig{Status: http.StatusFound, Landing: LandingPage, StatsPages: true}},
+		{name: "landing URL", config: RedirectConfig{Status: http.StatusFound, Landing: "https://www.example.com/"}},
+		{name: "unsupported landing", config: RedirectConfig{Status: http.StatusFound, Landing: "www.example.com"}, wantErr: `landing must be "page" or an http or https URL`},
+		{name: "missing favicon", config: RedirectConfig{Status: http.StatusFound, FaviconFile: "/nonexistent/favicon.ico"}, wantErr: "failed to read favicon"},
+		{name: "favicon directory", config: RedirectConfig{Status: http.StatusFound, FaviconFile: os.TempDir()}, wantErr: "is not a file"},
+		{name: "fallback", config: RedirectConfig{Status: http.StatusFound, Fallback: "https://www.example.com/missing", CampaignFallbacks: map[string]string{"spring": "https://www.example.com/spring"}}},
+		{name: "unsupported fallback", config: RedirectConfig{Status: http.StatusFound, Fallback: "/missing"}, wantErr: "fallback must be an http or https URL"},
+		{name: "unsupported campaign fallback", config: RedirectConfig{Status: http.StatusFound, CampaignFallbacks: map[string]string{"spring": "spring.html"}}, wantErr: "fallback of campaign spring"},
+		{name: "campaign fallback without campaign", config: RedirectConfig{Status: http.StatusFound, CampaignFallbacks: map[string]string{"": "https://www.example.com/"}}, wantErr: "has no campaign"},
+		{name: "malformed fallback parameter", config: RedirectConfig{Status: http.StatusFound, FallbackParam: "code=1"}, wantErr: "must be a query parameter name"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestRedirectConfig_ClientIP(t *testing.T) {
+	tests := []struct {
+		name       string
+		remoteAddr string
+		want       string
+	}{
+		{name: "connection address", remoteAddr: "192.0.2.1:4321", want: "192.0.2.1"},
+		{name: "IPv6 connection address", remoteAddr: "[2001:db8::1]:4321", want: "2001:db8::1"},
+		{name: "address set by the proxy middleware", remoteAddr: "203.0.113.9", want: "203.0.113.9"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/abc123", nil)
+			req.RemoteAddr = tt.remoteAddr
+			// Forwarding headers are only believed by ProxyMiddleware
+			req.Header.Set("X-Forwarded-For", "198.51.100.7")
+			assert.Equal(t, tt.want, RedirectConfig{Status: http.StatusFound}.clientIP(req))
+		})
+	}
+}
+
+func TestServer_RedirectStatus(t *testing.T) {
+	tests := []struct {
+		name                 string
+		config               RedirectConfig
+		linkStatus           int
+		expectedStatus       int
+		expectedCacheControl string
+	}{
+		{name: "server default", config: DefaultRedirectConfig(), expectedStatus: http.StatusFound, expectedCacheControl: "no-store"},
+		{name: "temporary with max age", config: RedirectConfig{Status: http.StatusTemporaryRedirect, TemporaryMaxAge: time.Minute}, expectedStatus: http.StatusTemporaryRedirect, expectedCacheControl: "public, max-age=60"},
+		{name: "permanent default with max age", config: RedirectConfig{Status: http.StatusMovedPermanently, PermanentMaxAge: 24 * time.Hour}, expectedStatus: http.StatusMovedPermanently, expectedCacheControl: "public, max-age=86400"},
+		{name: "permanent default without max age", config: RedirectConfig{Status: http.StatusMovedPermanently}, expectedStatus: http.StatusMovedPermanently},
+		{name: "link overrides permanent default", config: RedirectConfig{Status: http.StatusMovedPermanently, PermanentMaxAge: time.Hour}, linkStatus: http.StatusFound, expectedStatus: http.StatusFound, expectedCacheControl: "no-store"},
+		{name: "link permanent redirect", config: RedirectConfig{Status: http.StatusFound, PermanentMaxAge: time.Hour}, linkStatus: http.StatusPermanentRedirect, expectedStatus: http.StatusPermanentRedirect, expectedCacheControl: "public, max-age=3600"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mocks.URLShortener{}
+			mockService.On("GetOriginalURL", mock.Anything, "abc123", mock.Anything).Return("https://example.com", tt.linkStatus, nil)
+
+			server := NewServer(mockService, "8080", "http://localhost:8080", false, WithRedirects(tt.config))
+
+			req := httptest.NewRequest(http.MethodGet, "/abc123", nil)
+			w := httptest.NewRecorder()
+
+			server.server.Handler.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Equal(t, "https://example.com", w.Header().Get("Location"))
+			assert.Equal(t, tt.expectedCacheControl, w.Header().Get("Cache-Control"))
+			switch {
+			case tt.expectedCacheControl == "":
+				assert.Empty(t, w.Header().Get("Expires"))
+			case tt.expectedCacheControl == "no-store":
+				assert.Equal(t, "0", w.Header().Get("Expires"))
+			default:
+				expires, err := http.ParseTime(w.Header().Get("Expires"))
+				require.NoError(t, err)
+				assert.True(t, expires.After(time.Now()))
+			}
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandler_RedirectFallback(t *testing.T) {
+	config := RedirectConfig{
+		Status:            http.StatusFound,
+		Fallback:          "https://www.example.com/missing?from=short",
+		CampaignFallbacks: map[string]string{"spring": "https://www.example.com/spring"},
+	}
+
+	tests := []struct {
+		name             string
+		config           RedirectConfig
+		err              error
+		setupMocks       func(*mocks.URLShortener)
+		expectedStatus   int
+		expectedLocation string
+	}{
+		{
+			name:             "unknown code",
+			config:           config,
+			err:              domain.ErrURLNotFound,
+			expectedStatus:   http.StatusFound,
+			expectedLocation: "https://www.example.com/missing?code=abc123&from=short",
+		},
+		{
+			name:   "expired link of a campaign",
+			config: config,
+			err:    fmt.Errorf("short code abc123: %w", domain.ErrUsageLimitReached),
+			setupMocks: func(shortener *mocks.URLShortener) {
+				shortener.On("GetURLInfo", mock.Anything, "abc123").Return(&domain.URLEntry{ShortCode: "abc123", Campaign: "spring"}, nil)
+			},
+			expectedStatus:   http.StatusFound,
+			expectedLocation: "https://www.example.com/spring?code=abc123",
+		},
+		{
+			name:   "expired link of another campaign",
+			config: config,
+			err:    domain.ErrUsageLimitReached,
+			setupMocks: func(shortener *mocks.URLShortener) {
+				shortener.On("GetURLInfo", mock.Anything, "abc123").Return(&domain.URLEntry{ShortCode: "abc123", Campaign: "summer"}, nil)
+			},
+			expectedStatus:   http.StatusFound,
+			expectedLocation: "https://www.example.com/missing?code=abc123&from=short",
+		},
+		{
+			name:             "custom parameter without campaign fallbacks",
+			config:           RedirectConfig{Status: http.StatusFound, Fallback: "https://www.example.com/", FallbackParam: "ref"},
+			err:              domain.ErrUsageLimitReached,
+			expectedStatus:   http.StatusFound,
+			expectedLocation: "https://www.example.com/?ref=abc123",
+		},
+		{
+			name:           "unknown code without a global fallback",
+			config:         RedirectConfig{Status: http.StatusFound, CampaignFallbacks: config.CampaignFallbacks},
+			err:            domain.ErrURLNotFound,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "blocked codes keep their error",
+			config:         config,
+			err:            domain.ErrLinkBlocked,
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "storage errors keep their error",
+			config:         config,
+			err:            domain.Storage(errors.New("disk I/O error")),
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mocks.URLShortener{}
+			mockService.On("GetOriginalURL", mock.Anything, "abc123", mock.Anything).Return("", 0, tt.err)
+			if tt.setupMocks != nil {
+				tt.setupMocks(mockService)
+			}
+			server := NewServer(mockService, "8080", "http://localhost:8080", false, WithRedirects(tt.config))
+
+			req := httptest.NewRequest(http.MethodGet, "/abc123", nil)
+			w := httptest.NewRecorder()
+			server.server.Handler.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Equal(t, tt.expectedLocation, w.Header().Get("Location"))
+			if tt.expectedLocation != "" {
+				assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+			}
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestServer_RedirectMethods(t *testing.T) {
+	mockService := &mocks.URLShortener{}
+	mockService.On("GetOriginalURL", mock.Anything, "abc123", mock.MatchedBy(func(req domain.RedirectRequest) bool {
+		return req.Peek
+	})).Return("https://example.com", 0, nil).Once()
+	mockService.On("GetOriginalURL", mock.Anything, "abc123", mock.MatchedBy(func(req domain.RedirectRequest) bool {
+		return !req.Peek
+	})).Return("https://example.com", 0, nil).Once()
+	server := httptest.NewServer(NewServer(mockService, "8080", "http://localhost:8080", false).server.Handler)
+	defer server.Close()
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+
+	do := func(method string) (*http.Response, string) {
+		req, err := http.NewRequest(method, server.URL+"/abc123", nil)
+		require.NoError(t, err)
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp, string(body)
+	}
+
+	// HEAD answers the redirect's headers without a body and without counting a use
+	resp, body := do(http.MethodHead)
+	assert.Equal(t, http.StatusFound, resp.StatusCode)
+	assert.Equal(t, "https://example.com", resp.Header.Get("Location"))
+	assert.Empty(t, body)
+
+	resp, _ = do(http.MethodGet)
+	assert.Equal(t, http.StatusFound, resp.StatusCode)
+
+	resp, body = do(http.MethodOptions)
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	assert.Equal(t, "GET, HEAD, OPTIONS", resp.Header.Get("Allow"))
+	assert.Empty(t, body)
+
+	for _, method := range []string{http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete} {
+		resp, body = do(method)
+		assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode, method)
+		assert.Equal(t, "GET, HEAD, OPTIONS", resp.Header.Get("Allow"), method)
+		assert.Contains(t, body, "Method not allowed", method)
+	}
+	mockService.AssertExpectations(t)
+}
+
+func TestServer_RedirectServedStale(t *testing.T) {
+	mockService := &mocks.URLShortener{}
+	mockService.On("GetOriginalURL", mock.Anything, "abc123", mock.Anything).
+		Run(func(args mock.Arguments) { service.MarkStale(args.Get(0).(context.Context)) }).
+		Return("https://example.com", http.StatusMovedPermanently, nil)
+
+	server := NewServer(mockService, "8080", "http://localhost:8080", false, WithRedirects(RedirectConfig{Status: http.StatusFound, PermanentMaxAge: time.Hour}))
+
+	req := httptest.NewRequest(http.MethodGet, "/abc123", nil)
+	w := httptest.NewRecorder()
+	server.server.Handler.ServeHTTP(w, req)
+
+	// A stale redirect is served but never cached, even when permanent
+	assert.Equal(t, http.StatusMovedPermanently, w.Code)
+	assert.Equal(t, "https://example.com", w.Header().Get("Location"))
+	assert.Equal(t, "stale", w.Header().Get("X-Cache-Status"))
+	assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+}
+
+func TestServer_RedirectBurned(t *testing.T) {
+	mockService := &mocks.URLShortener{}
+	mockService.On("GetOriginalURL", mock.Anything, "abc123", mock.Anything).
+		Run(func(args mock.Arguments) { service.MarkBurned(args.Get(0).(context.Context)) }).
+		Return("https://example.com/secret", http.StatusFound, nil)
+
+	server := NewServer(mockService, "8080", "http://localhost:8080", false, WithRedirects(RedirectConfig{Status: http.StatusFound, TemporaryMaxAge: time.Hour}))
+
+	req := httptest.NewRequest(http.MethodGet, "/abc123", nil)
+	w := httptest.NewRecorder()
+	server.server.Handler.ServeHTTP(w, req)
+
+	// The one redirect of a burn-after-reading link is never cached
+	assert.Equal(t, http.StatusFound, w.Code)
+	assert.Equal(t, "https://example.com/secret", w.Header().Get("Location"))
+	assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+}
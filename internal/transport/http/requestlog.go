@@ -0,0 +1,143 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/url"
+	"strings"
+)
+
+// redacted replaces the values of redacted fields in logged bodies
+const redacted = "[REDACTED]"
+
+// maxInspectedBody is the most of a body verbose logging reads to redact it.
+// Longer bodies are counted but not shown, since they cannot be redacted
+// without parsing them whole.
+const maxInspectedBody = 64 * 1024
+
+// RequestLogConfig controls what verbose mode logs for each request
+type RequestLogConfig struct {
+	// SampleRate logs one in this many requests; 1 logs every request.
+	// Requests failing with a 5xx status are always logged.
+	SampleRate int
+
+	// MaxBodyBytes truncates logged request and error response bodies;
+	// 0 logs no bodies
+	MaxBodyBytes int
+
+	// RedactFields are field names whose values are replaced in JSON and
+	// form bodies. A field is redacted when its name contains one of them,
+	// ignoring case, so "secret" also covers "client_secret". Bodies of other
+	// types are never shown.
+	RedactFields []string
+}
+
+// DefaultRequestLogConfig logs every request with bodies up to 1 KiB and
+// credentials redacted
+func DefaultRequestLogConfig() RequestLogConfig {
+	return RequestLogConfig{
+		SampleRate:   1,
+		MaxBodyBytes: 1024,
+		RedactFields: []string{"secret", "token", "password", "api_key", "authorization"},
+	}
+}
+
+// Validate checks the sample rate and body limit
+func (c RequestLogConfig) Validate() error {
+	if c.SampleRate < 1 {
+		return fmt.Errorf("request log sample rate must be at least 1, got: %d", c.SampleRate)
+	}
+	if c.MaxBodyBytes < 0 {
+		return fmt.Errorf("request log body limit cannot be negative, got: %d", c.MaxBodyBytes)
+	}
+	return nil
+}
+
+// redactedField reports whether a field's value must not be logged
+func (c RequestLogConfig) redactedField(name string) bool {
+	name = strings.ToLower(name)
+	for _, field := range c.RedactFields {
+		if field != "" && strings.Contains(name, strings.ToLower(field)) {
+			return true
+		}
+	}
+	return false
+}
+
+// formatBody returns a body as it may be logged: redacted, truncated to
+// MaxBodyBytes, or a description when its content cannot be shown. complete
+// is false when only the first maxInspectedBody bytes were read.
+func (c RequestLogConfig) formatBody(body []byte, contentType string, complete bool) string {
+	if !complete {
+		return fmt.Sprintf("(more than %d bytes, not shown)", maxInspectedBody)
+	}
+
+	var shown []byte
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json") || (mediaType == "" && json.Valid(body)):
+		var value any
+		if err := json.Unmarshal(body, &value); err != nil {
+			return fmt.Sprintf("(%d bytes of invalid JSON, not shown)", len(body))
+		}
+		shown, _ = json.Marshal(c.redactJSON(value))
+	case mediaType == "application/x-www-form-urlencoded":
+		form, err := url.ParseQuery(string(body))
+		if err != nil {
+			return fmt.Sprintf("(%d bytes of invalid form data, not shown)", len(body))
+		}
+		for name := range form {
+			if c.redactedField(name) {
+				form[name] = []string{redacted}
+			}
+		}
+		shown = []byte(form.Encode())
+	default:
+		if mediaType == "" {
+			mediaType = "unknown type"
+		}
+		return fmt.Sprintf("(%d bytes of %s, not shown)", len(body), mediaType)
+	}
+
+	if len(shown) > c.MaxBodyBytes {
+		return fmt.Sprintf("%s... (%d bytes)", shown[:c.MaxBodyBytes], len(shown))
+	}
+	return string(shown)
+}
+
+// redactJSON replaces the values of redacted fields at any depth
+func (c RequestLogConfig) redactJSON(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for name, field := range v {
+			if c.redactedField(name) {
+				v[name] = redacted
+			} else {
+				v[name] = c.redactJSON(field)
+			}
+		}
+	case []any:
+		for i, item := range v {
+			v[i] = c.redactJSON(item)
+		}
+	}
+	return value
+}
+
+// limitedBuffer keeps the first maxInspectedBody bytes written to it
+type limitedBuffer struct {
+	bytes.Buffer
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	n := len(p)
+	if room := maxInspectedBody - b.Len(); n > room {
+		b.truncated = true
+		p = p[:room]
+	}
+	b.Buffer.Write(p)
+	return n, nil
+}
@@ -0,0 +1,126 @@
+package http
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestLogConfig_Validate(t *testing.T) {
+	assert.NoError(t, DefaultRequestLogConfig().Validate())
+	assert.ErrorContains(t, RequestLogConfig{SampleRate: 0}.Validate(), "sample rate must be at least 1")
+	assert.ErrorContains(t, RequestLogConfig{SampleRate: 1, MaxBodyBytes: -1}.Validate(), "cannot be negative")
+}
+
+func TestRequestLogConfig_FormatBody(t *testing.T) {
+	config := DefaultRequestLogConfig()
+	config.MaxBodyBytes = 100
+
+	tests := []struct {
+		name        string
+		body        string
+		contentType string
+		incomplete  bool
+		want        string
+	}{
+		{name: "JSON", body: `{"url":"https://example.com"}`, contentType: "application/json",
+			want: `{"url":"https://example.com"}`},
+		{name: "nested secrets", body: `{"url":"https://x.io","hooks":[{"Secret":"s3"}],"auth":{"api_key":"k"}}`,
+			want: `{"auth":{"api_key":"[REDACTED]"},"hooks":[{"Secret":"[REDACTED]"}],"url":"https://x.io"}`},
+		{name: "redacted before truncation", body: `{"client_secret":"` + strings.Repeat("s", 100) + `"}`, contentType: "application/json",
+			want: `{"client_secret":"[REDACTED]"}`},
+		{name: "truncated", body: `{"url":"https://example.com/` + strings.Repeat("a", 100) + `"}`, contentType: "application/json; charset=utf-8",
+			want: `{"url":"https://example.com/` + strings.Repeat("a", 72) + `... (130 bytes)`},
+		{name: "invalid JSON", body: `{"token":`, contentType: "application/json", want: "(9 bytes of invalid JSON, not shown)"},
+		{name: "form", body: "password=hunter2&user=ann", contentType: "application/x-www-form-urlencoded",
+			want: "password=%5BREDACTED%5D&user=ann"},
+		{name: "other types are not shown", body: "short_code,original_url\nabc,https://example.com\n", contentType: "text/csv",
+			want: "(48 bytes of text/csv, not shown)"},
+		{name: "unknown type", body: "token=abc", want: "(9 bytes of unknown type, not shown)"},
+		{name: "too long to inspect", body: "{}", incomplete: true, want: "(more than 65536 bytes, not shown)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, config.formatBody([]byte(tt.body), tt.contentType, !tt.incomplete))
+		})
+	}
+}
+
+// captureLog returns what fn logs
+func captureLog(t *testing.T, fn func()) string {
+	t.Helper()
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+	fn()
+	return buf.String()
+}
+
+func TestLoggingMiddleware(t *testing.T) {
+	var received string
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received = string(body)
+		if r.URL.Path == "/fail" {
+			writeError(w, http.StatusInternalServerError, "database is locked")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":"invalid secret","secret":"s3cr3t"}`))
+	})
+	config := DefaultRequestLogConfig()
+	config.SampleRate = 2
+	middleware := NewLoggingMiddleware(true, config).Middleware(handler)
+
+	send := func(path, body string) string {
+		return captureLog(t, func() {
+			req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			middleware.ServeHTTP(httptest.NewRecorder(), req)
+		})
+	}
+
+	logged := send("/api/webhooks", `{"url":"https://hooks.example.com","secret":"s3cr3t"}`)
+	assert.Equal(t, `{"url":"https://hooks.example.com","secret":"s3cr3t"}`, received, "the handler reads the whole body")
+	assert.Contains(t, logged, `Body: {"secret":"[REDACTED]","url":"https://hooks.example.com"}`)
+	assert.Contains(t, logged, "POST /api/webhooks -> 400")
+	assert.Contains(t, logged, `Error body: {"error":"invalid secret","secret":"[REDACTED]"}`)
+	assert.NotContains(t, logged, "s3cr3t")
+
+	// The second request is not sampled
+	assert.Empty(t, send("/api/webhooks", `{}`))
+
+	// Server errors are logged even when not sampled
+	send("/api/webhooks", `{}`)
+	logged = send("/fail", `{}`)
+	assert.Contains(t, logged, "POST /fail -> 500")
+	assert.NotContains(t, logged, "[HTTP REQUEST]")
+}
+
+func TestLoggingMiddleware_LargeBody(t *testing.T) {
+	var received int
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		received = len(body)
+	})
+	middleware := NewLoggingMiddleware(true, DefaultRequestLogConfig()).Middleware(handler)
+
+	body := `{"urls":["` + strings.Repeat("a", 2*maxInspectedBody) + `"]}`
+	logged := captureLog(t, func() {
+		req := httptest.NewRequest(http.MethodPost, "/api/urls", strings.NewReader(body))
+		middleware.ServeHTTP(httptest.NewRecorder(), req)
+	})
+	assert.Equal(t, len(body), received)
+	assert.Contains(t, logged, "Body: (more than 65536 bytes, not shown)")
+}
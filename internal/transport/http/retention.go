@@ -0,0 +1,40 @@
+package http
+
+import (
+	"log"
+	"net/http"
+	"net/netip"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+)
+
+// PurgeData handles DELETE /api/admin/data?ip=..., forgetting everything the
+// server remembers about a visitor address, as a data subject may request
+func (h *Handler) PurgeData(w http.ResponseWriter, r *http.Request) {
+	if h.retention == nil {
+		writeError(w, http.StatusNotImplemented, "Data purging is not configured")
+		return
+	}
+	if r.Method != http.MethodDelete {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	ip := r.URL.Query().Get("ip")
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "ip must be an IPv4 or IPv6 address")
+		return
+	}
+
+	// Click dedupe remembers the anonymized address and crawler lookups the
+	// address itself
+	removed := h.retention.Purge(addr.Unmap().String())
+	if h.privacy != nil {
+		for _, variant := range h.privacy.Variants(addr.String()) {
+			removed += h.retention.Purge(variant)
+		}
+	}
+	log.Printf("[INFO] Purged %d entries holding a visitor address on request", removed)
+	writeJSON(w, http.StatusOK, domain.DataPurgeResponse{IP: ip, EntriesRemoved: removed})
+}
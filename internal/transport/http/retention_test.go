@@ -0,0 +1,78 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/joshdurbin/url-shortener/internal/privacy"
+	"github.com/joshdurbin/url-shortener/internal/retention"
+	"github.com/joshdurbin/url-shortener/internal/service/mocks"
+)
+
+// forgettingStore remembers which addresses it was asked to forget
+type forgettingStore struct {
+	forgot []string
+}
+
+func (s *forgettingStore) ForgetAddress(addr string) int {
+	s.forgot = append(s.forgot, addr)
+	return 2
+}
+
+func (s *forgettingStore) ForgetAddressesBefore(cutoff time.Time) int {
+	return 0
+}
+
+func TestHandler_PurgeData(t *testing.T) {
+	store := &forgettingStore{}
+	server := NewServer(&mocks.URLShortener{}, "8080", "http://localhost:8080", false,
+		WithRetention(retention.New(retention.DefaultConfig(), nil, store)))
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/admin/data?ip=::ffff:192.0.2.1", nil)
+	w := httptest.NewRecorder()
+	server.server.Handler.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"ip":"::ffff:192.0.2.1","entries_removed":2}`, w.Body.String())
+	assert.Equal(t, []string{"192.0.2.1"}, store.forgot, "IPv4-mapped addresses are purged as IPv4")
+
+	for _, target := range []string{"/api/admin/data", "/api/admin/data?ip=example.com"} {
+		req = httptest.NewRequest(http.MethodDelete, target, nil)
+		w = httptest.NewRecorder()
+		server.server.Handler.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code, target)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/admin/data?ip=192.0.2.1", nil)
+	w = httptest.NewRecorder()
+	server.server.Handler.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
+
+func TestHandler_PurgeDataNotConfigured(t *testing.T) {
+	server := NewServer(&mocks.URLShortener{}, "8080", "http://localhost:8080", false)
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/admin/data?ip=192.0.2.1", nil)
+	w := httptest.NewRecorder()
+	server.server.Handler.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotImplemented, w.Code)
+}
+
+func TestHandler_PurgeDataAnonymized(t *testing.T) {
+	store := &forgettingStore{}
+	anonymizer := privacy.New(privacy.Config{Mode: privacy.ModeHash, KeyRotation: time.Hour})
+	server := NewServer(&mocks.URLShortener{}, "8080", "http://localhost:8080", false,
+		WithRetention(retention.New(retention.DefaultConfig(), nil, store)), WithPrivacy(anonymizer))
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/admin/data?ip=192.0.2.1", nil)
+	w := httptest.NewRecorder()
+	server.server.Handler.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	// Both the address and the form click dedupe remembered it under are purged
+	assert.Equal(t, []string{"192.0.2.1", anonymizer.Anonymize("192.0.2.1")}, store.forgot)
+}
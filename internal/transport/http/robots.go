@@ -0,0 +1,49 @@
+package http
+
+import (
+	"io"
+	"net/http"
+)
+
+// crawlerMaxAge is how long crawlers and browsers may cache robots.txt and
+// the favicon, in seconds
+const crawlerMaxAge = "86400"
+
+// Robots files for /robots.txt: by default nothing is crawled; with crawling
+// allowed, short links are followed but the API and dashboard are not
+const (
+	robotsDisallow = "User-agent: *\nDisallow: /\n"
+	robotsAllow    = "User-agent: *\nDisallow: /api/\nDisallow: /admin/\n"
+)
+
+// Robots handles GET /robots.txt without reaching the redirect resolver
+func (h *Handler) Robots(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Cache-Control", "public, max-age="+crawlerMaxAge)
+	if h.redirects.AllowCrawling {
+		io.WriteString(w, robotsAllow)
+	} else {
+		io.WriteString(w, robotsDisallow)
+	}
+}
+
+// Favicon handles GET /favicon.ico: the configured icon, or 204 No Content
+// when there is none
+func (h *Handler) Favicon(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	w.Header().Set("Cache-Control", "public, max-age="+crawlerMaxAge)
+	if h.redirects.FaviconFile == "" {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	http.ServeFile(w, r, h.redirects.FaviconFile)
+}
@@ -0,0 +1,70 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/joshdurbin/url-shortener/internal/service/mocks"
+)
+
+func TestHandler_Robots(t *testing.T) {
+	mockService := &mocks.URLShortener{}
+	server := NewServer(mockService, "8080", "http://localhost:8080", false)
+
+	serve := func(method string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/robots.txt", nil)
+		w := httptest.NewRecorder()
+		server.server.Handler.ServeHTTP(w, req)
+		return w
+	}
+
+	w := serve(http.MethodGet)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/plain; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, "public, max-age=86400", w.Header().Get("Cache-Control"))
+	assert.Equal(t, "User-agent: *\nDisallow: /\n", w.Body.String())
+
+	server = NewServer(mockService, "8080", "http://localhost:8080", false, WithRedirects(RedirectConfig{Status: http.StatusFound, AllowCrawling: true}))
+	w = serve(http.MethodGet)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "User-agent: *\nDisallow: /api/\nDisallow: /admin/\n", w.Body.String())
+
+	w = serve(http.MethodPost)
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+
+	// The resolver is never asked for a robots.txt code
+	mockService.AssertNotCalled(t, "GetOriginalURL")
+}
+
+func TestHandler_Favicon(t *testing.T) {
+	mockService := &mocks.URLShortener{}
+	server := NewServer(mockService, "8080", "http://localhost:8080", false)
+
+	serve := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/favicon.ico", nil)
+		w := httptest.NewRecorder()
+		server.server.Handler.ServeHTTP(w, req)
+		return w
+	}
+
+	w := serve()
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "public, max-age=86400", w.Header().Get("Cache-Control"))
+	assert.Empty(t, w.Body.String())
+
+	icon := filepath.Join(t.TempDir(), "favicon.ico")
+	require.NoError(t, os.WriteFile(icon, []byte("\x00\x00\x01\x00icon"), 0o644))
+	server = NewServer(mockService, "8080", "http://localhost:8080", false, WithRedirects(RedirectConfig{Status: http.StatusFound, FaviconFile: icon}))
+	w = serve()
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "icon")
+	assert.Equal(t, "\x00\x00\x01\x00icon", w.Body.String())
+
+	mockService.AssertNotCalled(t, "GetOriginalURL")
+}
@@ -0,0 +1,88 @@
+package http
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+)
+
+// redirectRequest describes a redirect for routing rules, click dedupe, bot
+// filtering and analytics: its query, the visitor's country and region (when a
+// GeoIP locator is configured), device, language, address and referrer.
+// Visitors whose address resolves to a known crawler are bots, when a bot
+// detector is configured; the address is anonymized after that check, when
+// IP anonymization is configured.
+func (h *Handler) redirectRequest(r *http.Request) domain.RedirectRequest {
+	location := h.geo.Locate(r)
+	req := domain.RedirectRequest{
+		Query:    r.URL.Query(),
+		Country:  location.Country,
+		Region:   location.Region,
+		ASN:      location.ASN,
+		Device:   domain.ParseDeviceType(r.UserAgent()),
+		Language: domain.PreferredLanguage(r.Header.Get("Accept-Language")),
+		ClientIP: h.redirects.clientIP(r),
+		Referrer: r.Referer(),
+		Peek:     r.Method == http.MethodHead,
+	}
+	if customDomain, ok := h.hosts.Lookup(r.Host); ok {
+		req.Domain = customDomain.Host
+	}
+	if req.Device != domain.DeviceBot && h.bots.Crawler(r.Context(), req.ClientIP) {
+		req.Device = domain.DeviceBot
+	}
+	req.ClientIP = h.privacy.Anonymize(req.ClientIP)
+	return req
+}
+
+// RoutingRules handles GET, PUT and DELETE /api/urls/{shortCode}/routes. PUT
+// replaces the whole ordered list of rules; DELETE removes them all.
+func (h *Handler) RoutingRules(w http.ResponseWriter, r *http.Request) {
+	shortCode := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/urls/"), "/routes")
+	if shortCode == "" {
+		writeError(w, http.StatusBadRequest, "Short code is required")
+		return
+	}
+
+	if r.Method != http.MethodGet && !h.authorizeOwner(w, r, shortCode) {
+		return
+	}
+
+	var (
+		rules []domain.RoutingRule
+		err   error
+	)
+	switch r.Method {
+	case http.MethodGet:
+		rules, err = h.shortener.GetRoutingRules(r.Context(), shortCode)
+	case http.MethodPut:
+		if err := json.NewDecoder(r.Body).Decode(&rules); err != nil {
+			log.Printf("[ERROR] Invalid JSON in routing rules request: %v", err)
+			writeError(w, http.StatusBadRequest, "Invalid JSON")
+			return
+		}
+		rules, err = h.shortener.SetRoutingRules(r.Context(), shortCode, rules)
+	case http.MethodDelete:
+		if _, err := h.shortener.SetRoutingRules(r.Context(), shortCode, nil); err != nil {
+			log.Printf("[ERROR] Failed to delete routing rules for code '%s': %v", shortCode, err)
+			writeServiceError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	if err != nil {
+		log.Printf("[ERROR] Failed to handle routing rules for code '%s': %v", shortCode, err)
+		writeServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, rules)
+}
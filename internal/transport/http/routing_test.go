@@ -0,0 +1,249 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/joshdurbin/url-shortener/internal/bots"
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	"github.com/joshdurbin/url-shortener/internal/geoip"
+	"github.com/joshdurbin/url-shortener/internal/privacy"
+	"github.com/joshdurbin/url-shortener/internal/service/mocks"
+)
+
+func TestHandler_RoutingRules(t *testing.T) {
+	rules := []domain.RoutingRule{{Countries: []string{"DE"}, Destination: "https://example.de"}}
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		setupMocks     func(*mocks.URLShortener)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:   "get",
+			method: http.MethodGet,
+			path:   "/api/urls/abc123/routes",
+			setupMocks: func(shortener *mocks.URLShortener) {
+				shortener.On("GetRoutingRules", mock.Anything, "abc123").Return(rules, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `[{"countries":["DE"],"destination":"https://example.de"}]`,
+		},
+		{
+			name:   "get unknown code",
+			method: http.MethodGet,
+			path:   "/api/urls/missing/routes",
+			setupMocks: func(shortener *mocks.URLShortener) {
+				shortener.On("GetRoutingRules", mock.Anything, "missing").Return(nil, domain.ErrURLNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:   "put",
+			method: http.MethodPut,
+			path:   "/api/urls/abc123/routes",
+			body:   `[{"countries":["de"],"destination":"https://example.de"}]`,
+			setupMocks: func(shortener *mocks.URLShortener) {
+				shortener.On("SetRoutingRules", mock.Anything, "abc123", []domain.RoutingRule{{Countries: []string{"de"}, Destination: "https://example.de"}}).
+					Return(rules, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"countries":["DE"]`,
+		},
+		{
+			name:   "put invalid rule",
+			method: http.MethodPut,
+			path:   "/api/urls/abc123/routes",
+			body:   `[{"destination":"https://example.de"}]`,
+			setupMocks: func(shortener *mocks.URLShortener) {
+				shortener.On("SetRoutingRules", mock.Anything, "abc123", mock.Anything).
+					Return(nil, domain.Invalid("", errors.New("routing rule 1 has no conditions")))
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "routing rule 1 has no conditions",
+		},
+		{
+			name:           "put invalid JSON",
+			method:         http.MethodPut,
+			path:           "/api/urls/abc123/routes",
+			body:           `{"countries":["DE"]}`,
+			setupMocks:     func(shortener *mocks.URLShortener) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "delete",
+			method: http.MethodDelete,
+			path:   "/api/urls/abc123/routes",
+			setupMocks: func(shortener *mocks.URLShortener) {
+				shortener.On("SetRoutingRules", mock.Anything, "abc123", []domain.RoutingRule(nil)).Return([]domain.RoutingRule{}, nil)
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:           "method not allowed",
+			method:         http.MethodPost,
+			path:           "/api/urls/abc123/routes",
+			setupMocks:     func(shortener *mocks.URLShortener) {},
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+		{
+			name:   "short code named routes is a link",
+			method: http.MethodGet,
+			path:   "/api/urls/routes",
+			setupMocks: func(shortener *mocks.URLShortener) {
+				shortener.On("GetURLInfo", mock.Anything, "routes").Return(&domain.URLEntry{ShortCode: "routes"}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"short_code":"routes"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shortener := &mocks.URLShortener{}
+			tt.setupMocks(shortener)
+			server := NewServer(shortener, "8080", "http://localhost:8080", false)
+
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+			server.server.Handler.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedBody != "" {
+				assert.Contains(t, w.Body.String(), tt.expectedBody)
+			}
+			shortener.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandler_RedirectDescribesVisitor(t *testing.T) {
+	locator, err := geoip.New(geoip.Config{CountryHeader: "CF-IPCountry"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    domain.RedirectRequest
+	}{
+		{
+			name: "mobile visitor with country and language",
+			headers: map[string]string{
+				"User-Agent":      "Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) Mobile/15E148",
+				"Accept-Language": "de-AT,de;q=0.9,en;q=0.5",
+				"CF-IPCountry":    "at",
+			},
+			want: domain.RedirectRequest{Query: url.Values{}, ClientIP: "192.0.2.1", Country: "AT", Device: domain.DeviceMobile, Language: "de-at"},
+		},
+		{
+			name: "tablet",
+			headers: map[string]string{
+				"User-Agent": "Mozilla/5.0 (Linux; Android 13; SM-X700) AppleWebKit/537.36 Chrome/120.0 Safari/537.36",
+			},
+			want: domain.RedirectRequest{Query: url.Values{}, ClientIP: "192.0.2.1", Device: domain.DeviceTablet},
+		},
+		{
+			name: "bot with weighted languages",
+			headers: map[string]string{
+				"User-Agent":      "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)",
+				"Accept-Language": "en;q=0.4, fr",
+			},
+			want: domain.RedirectRequest{Query: url.Values{}, ClientIP: "192.0.2.1", Device: domain.DeviceBot, Language: "fr"},
+		},
+		{
+			name: "desktop",
+			headers: map[string]string{
+				"User-Agent": "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 Chrome/120.0 Safari/537.36",
+			},
+			want: domain.RedirectRequest{Query: url.Values{}, ClientIP: "192.0.2.1", Device: domain.DeviceDesktop},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shortener := &mocks.URLShortener{}
+			shortener.On("GetOriginalURL", mock.Anything, "abc123", tt.want).Return("https://example.com", 0, nil)
+			server := NewServer(shortener, "8080", "http://localhost:8080", false, WithGeoIP(locator))
+
+			req := httptest.NewRequest(http.MethodGet, "/abc123", nil)
+			for name, value := range tt.headers {
+				req.Header.Set(name, value)
+			}
+			w := httptest.NewRecorder()
+			server.server.Handler.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusFound, w.Code)
+			shortener.AssertExpectations(t)
+		})
+	}
+}
+
+// crawlerResolver resolves 192.0.2.1 to a verified Googlebot host
+type crawlerResolver struct{}
+
+func (crawlerResolver) LookupAddr(ctx context.Context, addr string) ([]string, error) {
+	if addr == "192.0.2.1" {
+		return []string{"crawl-192-0-2-1.googlebot.com."}, nil
+	}
+	return nil, errors.New("no such host")
+}
+
+func (crawlerResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	return []string{"192.0.2.1"}, nil
+}
+
+func TestHandler_RedirectDetectsCrawlerByAddress(t *testing.T) {
+	config := bots.DefaultConfig()
+	config.VerifyDNS = true
+	detector := bots.NewWithResolver(config, crawlerResolver{})
+
+	want := domain.RedirectRequest{Query: url.Values{}, ClientIP: "192.0.2.1", Device: domain.DeviceBot}
+	shortener := &mocks.URLShortener{}
+	shortener.On("GetOriginalURL", mock.Anything, "abc123", want).Return("https://example.com", 0, nil)
+	server := NewServer(shortener, "8080", "http://localhost:8080", false, WithBotDetector(detector))
+
+	req := httptest.NewRequest(http.MethodGet, "/abc123", nil)
+	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 Chrome/120.0 Safari/537.36")
+	w := httptest.NewRecorder()
+	server.server.Handler.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusFound, w.Code)
+	shortener.AssertExpectations(t)
+}
+
+func TestHandler_RedirectAnonymizesAddress(t *testing.T) {
+	config := bots.DefaultConfig()
+	config.VerifyDNS = true
+	detector := bots.NewWithResolver(config, crawlerResolver{})
+	anonymizer := privacy.New(privacy.Config{Mode: privacy.ModeTruncate, IPv4Prefix: 24, IPv6Prefix: 48})
+
+	// Crawlers are recognized by the whole address before it is truncated
+	want := domain.RedirectRequest{Query: url.Values{}, ClientIP: "192.0.2.0", Device: domain.DeviceBot}
+	shortener := &mocks.URLShortener{}
+	shortener.On("GetOriginalURL", mock.Anything, "abc123", want).Return("https://example.com", 0, nil)
+	server := NewServer(shortener, "8080", "http://localhost:8080", true, WithBotDetector(detector), WithPrivacy(anonymizer))
+
+	logged := captureLog(t, func() {
+		req := httptest.NewRequest(http.MethodGet, "/abc123", nil)
+		req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 Chrome/120.0 Safari/537.36")
+		w := httptest.NewRecorder()
+		server.server.Handler.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusFound, w.Code)
+	})
+	shortener.AssertExpectations(t)
+	assert.Contains(t, logged, "GET /abc123 from 192.0.2.0")
+	assert.NotContains(t, logged, "192.0.2.1")
+}
@@ -3,41 +3,490 @@ package http
 import (
 	"context"
 	"log"
+	"net"
 	"net/http"
+	"strings"
 	"time"
 
+	"github.com/joshdurbin/url-shortener/internal/abuse"
+	"github.com/joshdurbin/url-shortener/internal/analytics"
+	"github.com/joshdurbin/url-shortener/internal/auth"
+	"github.com/joshdurbin/url-shortener/internal/backup"
+	"github.com/joshdurbin/url-shortener/internal/bots"
+	"github.com/joshdurbin/url-shortener/internal/cache/response"
+	"github.com/joshdurbin/url-shortener/internal/destinations"
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	"github.com/joshdurbin/url-shortener/internal/events"
+	"github.com/joshdurbin/url-shortener/internal/export"
+	"github.com/joshdurbin/url-shortener/internal/geoip"
+	"github.com/joshdurbin/url-shortener/internal/hosts"
+	"github.com/joshdurbin/url-shortener/internal/peers"
+	"github.com/joshdurbin/url-shortener/internal/policy"
+	"github.com/joshdurbin/url-shortener/internal/preview"
+	"github.com/joshdurbin/url-shortener/internal/privacy"
+	"github.com/joshdurbin/url-shortener/internal/reachability"
+	"github.com/joshdurbin/url-shortener/internal/retention"
 	"github.com/joshdurbin/url-shortener/internal/service"
+	"github.com/joshdurbin/url-shortener/internal/shortener"
+	"github.com/joshdurbin/url-shortener/internal/storage"
+	"github.com/joshdurbin/url-shortener/internal/tracing"
+	"github.com/joshdurbin/url-shortener/internal/webhook"
 )
 
 // Server represents the HTTP server
 type Server struct {
-	handler *Handler
-	server  *http.Server
-	port    string
+	handler        *Handler
+	server         *http.Server
+	port           string
+	listeners      []net.Listener
+	admin          *http.Server // Serves every route on adminListeners; nil without them
+	adminListeners []net.Listener
+	tls            TLSConfig
+	certificate    *certificate // Static certificate files; nil with ACME or without TLS
+	redirect       *http.Server
 }
 
-// NewServer creates a new HTTP server
-func NewServer(shortener service.URLShortener, port, serverURL string, verbose bool) *Server {
-	handler := NewHandler(shortener, serverURL)
+// Option configures optional server behavior
+type Option func(*options)
+
+// options holds the optional server dependencies
+type options struct {
+	authenticator  *auth.Authenticator
+	webhooks       *webhook.Dispatcher
+	policies       *policy.Engine
+	previews       *preview.Fetcher
+	storage        *storage.Reporter
+	backups        *backup.Manager
+	retention      *retention.Enforcer
+	privacy        *privacy.Anonymizer
+	destinations   *destinations.Filter
+	verifier       *reachability.Verifier
+	abuse          *abuse.Detector
+	hosts          *hosts.Registry
+	responses      *response.Cache
+	collisions     *service.CollisionStats
+	counters       *shortener.CounterCache
+	clicks         *service.ClickBuffer
+	breaker        *service.CircuitBreaker
+	peers          *peers.Broadcaster
+	peerSecret     string
+	geo            *geoip.Locator
+	bots           *bots.Detector
+	analytics      *analytics.Recorder
+	events         *service.EventBus
+	bus            *events.Bus
+	exporter       *export.Exporter
+	tracer         *tracing.Tracer
+	version        *domain.VersionResponse
+	listeners      []net.Listener
+	adminListeners []net.Listener
+	tls            TLSConfig
+	redirects      *RedirectConfig
+	proxies        ProxyConfig
+	requestLog     RequestLogConfig
+	timeouts       TimeoutConfig
+	pages          *ErrorPages
+}
+
+// WithAuthenticator enables API key and share token authentication on /api/ routes
+func WithAuthenticator(authenticator *auth.Authenticator) Option {
+	return func(o *options) {
+		o.authenticator = authenticator
+	}
+}
+
+// WithWebhooks enables the /api/webhooks endpoint management API
+func WithWebhooks(dispatcher *webhook.Dispatcher) Option {
+	return func(o *options) {
+		o.webhooks = dispatcher
+	}
+}
+
+// WithPolicies enables the /api/policies lifecycle policy API
+func WithPolicies(engine *policy.Engine) Option {
+	return func(o *options) {
+		o.policies = engine
+	}
+}
+
+// WithPreviews enables POST /api/urls/{shortCode}/preview; a nil fetcher leaves it disabled
+func WithPreviews(fetcher *preview.Fetcher) Option {
+	return func(o *options) {
+		o.previews = fetcher
+	}
+}
+
+// WithStorage enables the /api/admin/storage report and the /metrics storage gauges
+func WithStorage(reporter *storage.Reporter) Option {
+	return func(o *options) {
+		o.storage = reporter
+	}
+}
+
+// WithBackups enables /api/admin/backup; a nil manager leaves it disabled
+func WithBackups(manager *backup.Manager) Option {
+	return func(o *options) {
+		o.backups = manager
+	}
+}
+
+// WithRetention enables DELETE /api/admin/data; a nil enforcer leaves it disabled
+func WithRetention(enforcer *retention.Enforcer) Option {
+	return func(o *options) {
+		o.retention = enforcer
+	}
+}
+
+// WithPrivacy anonymizes visitor addresses before click dedupe remembers
+// them and request logs and trace spans record them
+func WithPrivacy(anonymizer *privacy.Anonymizer) Option {
+	return func(o *options) {
+		o.privacy = anonymizer
+	}
+}
+
+// WithDestinations enables /api/admin/destinations for managing the
+// destination allow and deny lists; a nil filter leaves it disabled
+func WithDestinations(filter *destinations.Filter) Option {
+	return func(o *options) {
+		o.destinations = filter
+	}
+}
+
+// WithVerifier enables POST /api/urls/{shortCode}/verify and
+// /api/admin/verifications; a nil verifier leaves them disabled
+func WithVerifier(verifier *reachability.Verifier) Option {
+	return func(o *options) {
+		o.verifier = verifier
+	}
+}
+
+// WithAbuseDetector enables /api/admin/abuse and the abuse metrics; a nil
+// detector leaves them disabled
+func WithAbuseDetector(detector *abuse.Detector) Option {
+	return func(o *options) {
+		o.abuse = detector
+	}
+}
+
+// WithHosts serves links on the registry's custom domains and enables
+// /api/admin/domains; a nil registry leaves them disabled
+func WithHosts(registry *hosts.Registry) Option {
+	return func(o *options) {
+		o.hosts = registry
+	}
+}
+
+// WithResponseCache serves the storage report from the given response cache
+// and exposes the cache's hit rate on /metrics
+func WithResponseCache(responses *response.Cache) Option {
+	return func(o *options) {
+		o.responses = responses
+	}
+}
+
+// WithClickBuffer exposes the click buffer's depth and overflow counts on /metrics
+func WithClickBuffer(buffer *service.ClickBuffer) Option {
+	return func(o *options) {
+		o.clicks = buffer
+	}
+}
+
+// WithCircuitBreaker exposes the database circuit breaker's state and counts on /metrics
+func WithCircuitBreaker(breaker *service.CircuitBreaker) Option {
+	return func(o *options) {
+		o.breaker = breaker
+	}
+}
+
+// WithPeers accepts cache invalidations from instances signing them with
+// secret, and exposes broadcaster's counters on /metrics. An empty secret
+// refuses invalidations; broadcaster may be nil.
+func WithPeers(secret string, broadcaster *peers.Broadcaster) Option {
+	return func(o *options) {
+		o.peerSecret = secret
+		o.peers = broadcaster
+	}
+}
+
+// WithCollisionStats exposes the service's short code collision counts on /metrics
+func WithCollisionStats(stats *service.CollisionStats) Option {
+	return func(o *options) {
+		o.collisions = stats
+	}
+}
+
+// WithCounters exposes the counter leases short codes are issued from on
+// /metrics; counters may be nil
+func WithCounters(counters *shortener.CounterCache) Option {
+	return func(o *options) {
+		o.counters = counters
+	}
+}
+
+// WithGeoIP resolves visitor countries for routing rules with the given locator
+func WithGeoIP(locator *geoip.Locator) Option {
+	return func(o *options) {
+		o.geo = locator
+	}
+}
+
+// WithBotDetector also treats visitors whose address resolves to a known
+// crawler as bots, whatever their user agent
+func WithBotDetector(detector *bots.Detector) Option {
+	return func(o *options) {
+		o.bots = detector
+	}
+}
+
+// WithAnalytics enables the referrer and UTM reports from the given recorder
+func WithAnalytics(recorder *analytics.Recorder) Option {
+	return func(o *options) {
+		o.analytics = recorder
+	}
+}
+
+// WithEventBus streams link events from the given bus at /api/events
+func WithEventBus(bus *service.EventBus) Option {
+	return func(o *options) {
+		o.events = bus
+	}
+}
+
+// WithEventMetrics adds the given bus's published event counts to /metrics
+func WithEventMetrics(bus *events.Bus) Option {
+	return func(o *options) {
+		o.bus = bus
+	}
+}
+
+// WithTracer records a span for each request, continues incoming traces and
+// adds the tracer's export counts to /metrics
+func WithTracer(tracer *tracing.Tracer) Option {
+	return func(o *options) {
+		o.tracer = tracer
+	}
+}
+
+// WithExporter adds the given exporter's counts to /metrics
+func WithExporter(exporter *export.Exporter) Option {
+	return func(o *options) {
+		o.exporter = exporter
+	}
+}
+
+// WithVersion reports the given build and configuration summary from /api/version
+func WithVersion(info domain.VersionResponse) Option {
+	return func(o *options) {
+		o.version = &info
+	}
+}
+
+// WithTLS serves HTTPS (and HTTP/2) using certificate files or ACME, with an
+// optional plain HTTP listener that redirects to HTTPS
+func WithTLS(tlsConfig TLSConfig) Option {
+	return func(o *options) {
+		o.tls = tlsConfig
+	}
+}
+
+// WithListeners serves on listeners opened elsewhere, such as unix sockets or
+// sockets passed by systemd socket activation, instead of listening on the port
+func WithListeners(listeners ...net.Listener) Option {
+	return func(o *options) {
+		o.listeners = append(o.listeners, listeners...)
+	}
+}
+
+// WithAdminListeners serves the API, admin dashboard, metrics and peer cache
+// invalidation only on these listeners, so the others can be exposed to the
+// internet with nothing but redirects and probes
+func WithAdminListeners(listeners ...net.Listener) Option {
+	return func(o *options) {
+		o.adminListeners = append(o.adminListeners, listeners...)
+	}
+}
+
+// WithRedirects sets the default redirect status and permanent redirect caching
+func WithRedirects(redirectConfig RedirectConfig) Option {
+	return func(o *options) {
+		o.redirects = &redirectConfig
+	}
+}
+
+// WithProxies takes the client address of requests from trusted reverse
+// proxies from their forwarding headers
+func WithProxies(proxyConfig ProxyConfig) Option {
+	return func(o *options) {
+		o.proxies = proxyConfig
+	}
+}
+
+// WithRequestLog sets the sampling, body limit and redaction of verbose
+// request logging (default: DefaultRequestLogConfig)
+func WithRequestLog(logConfig RequestLogConfig) Option {
+	return func(o *options) {
+		o.requestLog = logConfig
+	}
+}
+
+// WithTimeouts sets the deadline of each route class (default: DefaultTimeoutConfig)
+func WithTimeouts(timeouts TimeoutConfig) Option {
+	return func(o *options) {
+		o.timeouts = timeouts
+	}
+}
+
+// WithErrorPages sets the pages browsers see for unknown, expired and
+// blocked short links (default: DefaultErrorPages)
+func WithErrorPages(pages *ErrorPages) Option {
+	return func(o *options) {
+		o.pages = pages
+	}
+}
+
+// publicRoutes registers the routes the internet needs: redirects, probes,
+// robots.txt and the favicon
+func (h *Handler) publicRoutes(mux *http.ServeMux) {
+	// Probes are outside /api/ so they never require credentials
+	mux.HandleFunc("/healthz", h.Healthz)
+	mux.HandleFunc("/readyz", h.Readyz)
 	
-	mux := http.NewServeMux()
+	// Requested by crawlers and browsers on every host; answered here so they
+	// never count as unknown short codes
+	mux.HandleFunc("/robots.txt", h.Robots)
+	mux.HandleFunc("/favicon.ico", h.Favicon)
 	
+	// Redirect endpoint (catch-all)
+	mux.HandleFunc("/", h.Redirect)
+}
+
+// managementRoutes registers the API, admin dashboard, metrics and peer
+// cache invalidation
+func (h *Handler) managementRoutes(mux *http.ServeMux) {
 	// API endpoints
-	mux.HandleFunc("/api/urls", handler.URLsHandler)
-	mux.HandleFunc("/api/urls/", handler.URLsDetailHandler)
+	mux.HandleFunc("/api/urls", h.URLsHandler)
+	mux.HandleFunc("/api/urls/", h.URLsDetailHandler)
+	mux.HandleFunc("/api/campaigns", h.ListCampaigns)
+	mux.HandleFunc("/api/stats/top-referrers", h.TopReferrers)
+	mux.HandleFunc("/api/stats/top-countries", h.TopCountries)
+	mux.HandleFunc("/api/stats/top", h.TopLinks)
+	mux.HandleFunc("/api/admin/export", h.ExportURLs)
+	mux.HandleFunc("/api/admin/storage", h.StorageReport)
+	mux.HandleFunc("/api/admin/backup", h.Backup)
+	mux.HandleFunc("/api/admin/data", h.PurgeData)
+	mux.HandleFunc("/api/admin/destinations", h.Destinations)
+	mux.HandleFunc("/api/admin/verifications", h.Verifications)
+	mux.HandleFunc("/api/admin/abuse", h.Abuse)
+	mux.HandleFunc("/api/admin/abuse/", h.Abuse)
+	mux.HandleFunc("/api/admin/domains", h.Domains)
+	mux.HandleFunc("/api/admin/domains/", h.Domains)
+	mux.HandleFunc("/api/admin/cache", h.CacheAdmin)
+	mux.HandleFunc("/api/admin/cache/", h.CacheAdmin)
+	mux.HandleFunc("/api/admin/sync", h.SyncNow)
+	mux.HandleFunc("/api/admin/drain", h.Drain)
+	mux.HandleFunc("/api/webhooks", h.WebhooksHandler)
+	mux.HandleFunc("/api/webhooks/", h.WebhooksDetailHandler)
+	mux.HandleFunc("/api/policies", h.PoliciesHandler)
+	mux.HandleFunc("/api/policies/", h.PoliciesDetailHandler)
+	mux.HandleFunc("/api/version", h.Version)
+	mux.HandleFunc("/api/events", h.Events)
+	mux.HandleFunc("/metrics", h.Metrics)
 	
-	// Redirect endpoint (catch-all)
-	mux.HandleFunc("/", handler.Redirect)
+	// Peers sign their invalidations instead of sending credentials
+	mux.HandleFunc(peers.Path, h.CacheInvalidate)
+	
+	// Admin dashboard (/admin redirects to /admin/)
+	mux.Handle("/admin/", h.AdminHandler())
+}
+
+// withMiddleware wraps a mux with drain checks, authentication, deadlines,
+// tracing and logging
+func withMiddleware(mux *http.ServeMux, o *options, handler *Handler, verbose bool) http.Handler {
+	// Inside auth, so only authenticated callers learn that writes are paused
+	finalHandler := handler.DrainMiddleware(mux)
 	
-	// Wrap with middlewares
-	var finalHandler http.Handler = mux
+	if o.authenticator != nil {
+		finalHandler = NewAuthMiddleware(o.authenticator).Middleware(finalHandler)
+	}
+	
+	// Deadlines cover authentication's key lookups too
+	finalHandler = handler.timeouts.Middleware(finalHandler)
+	
+	// Trace outside auth so rejected requests are recorded too
+	if o.tracer != nil {
+		tracingMiddleware := NewTracingMiddleware(o.tracer, mux)
+		tracingMiddleware.privacy = o.privacy
+		finalHandler = tracingMiddleware.Middleware(finalHandler)
+	}
 	
 	// Add logging middleware first (outermost)
 	if verbose {
-		loggingMiddleware := NewLoggingMiddleware(verbose)
+		loggingMiddleware := NewLoggingMiddleware(verbose, o.requestLog)
+		loggingMiddleware.privacy = o.privacy
 		finalHandler = loggingMiddleware.Middleware(finalHandler)
 	}
 	
+	// Resolve the client address before anything records it
+	if o.proxies.Enabled() {
+		finalHandler = NewProxyMiddleware(o.proxies).Middleware(finalHandler)
+	}
+	return finalHandler
+}
+
+// NewServer creates a new HTTP server
+func NewServer(shortener service.URLShortener, port, serverURL string, verbose bool, opts ...Option) *Server {
+	o := &options{requestLog: DefaultRequestLogConfig(), timeouts: DefaultTimeoutConfig()}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	handler := NewHandler(shortener, serverURL)
+	handler.authenticator = o.authenticator
+	handler.webhooks = o.webhooks
+	handler.policies = o.policies
+	handler.previews = o.previews
+	handler.storage = o.storage
+	handler.backups = o.backups
+	handler.retention = o.retention
+	handler.privacy = o.privacy
+	handler.destinations = o.destinations
+	handler.verifier = o.verifier
+	handler.abuse = o.abuse
+	handler.hosts = o.hosts
+	handler.responses = o.responses
+	handler.collisions = o.collisions
+	handler.counters = o.counters
+	handler.clicks = o.clicks
+	handler.breaker = o.breaker
+	handler.peers = o.peers
+	handler.peerSecret = o.peerSecret
+	handler.geo = o.geo
+	handler.bots = o.bots
+	handler.analytics = o.analytics
+	handler.events = o.events
+	handler.bus = o.bus
+	handler.exporter = o.exporter
+	handler.tracer = o.tracer
+	handler.timeouts = NewTimeoutMiddleware(o.timeouts)
+	if o.version != nil {
+		handler.version = *o.version
+	}
+	if o.redirects != nil {
+		handler.redirects = *o.redirects
+	}
+	if o.pages != nil {
+		handler.pages = o.pages
+	}
+	
+	// With admin listeners the management routes are only served there
+	mux := http.NewServeMux()
+	handler.publicRoutes(mux)
+	if len(o.adminListeners) == 0 {
+		handler.managementRoutes(mux)
+	}
+	finalHandler := withMiddleware(mux, o, handler, verbose)
+	
 	server := &http.Server{
 		Addr:         ":" + port,
 		Handler:      finalHandler,
@@ -46,22 +495,144 @@ func NewServer(shortener service.URLShortener, port, serverURL string, verbose b
 		IdleTimeout:  60 * time.Second,
 	}
 	
-	return &Server{
-		handler: handler,
-		server:  server,
-		port:    port,
+	s := &Server{
+		handler:   handler,
+		server:    server,
+		port:      port,
+		listeners: o.listeners,
+		tls:       o.tls,
+	}
+	
+	if o.tls.Enabled() {
+		server.TLSConfig = baseTLSConfig()
+		var redirect http.Handler
+		if o.tls.RedirectPort != "" {
+			redirect = httpsRedirectHandler(port)
+		}
+		if o.tls.ACME() {
+			manager := newCertManager(o.tls)
+			server.TLSConfig.GetCertificate = manager.GetCertificate
+			server.TLSConfig.NextProtos = append(server.TLSConfig.NextProtos, "acme-tls/1")
+			if redirect != nil {
+				// Answers HTTP-01 challenges and redirects everything else
+				redirect = manager.HTTPHandler(redirect)
+			}
+		} else {
+			s.certificate = &certificate{certFile: o.tls.CertFile, keyFile: o.tls.KeyFile}
+			server.TLSConfig.GetCertificate = s.certificate.get
+		}
+		if redirect != nil {
+			s.redirect = &http.Server{
+				Addr:         ":" + o.tls.RedirectPort,
+				Handler:      redirect,
+				ReadTimeout:  10 * time.Second,
+				WriteTimeout: 10 * time.Second,
+				IdleTimeout:  60 * time.Second,
+			}
+		}
 	}
+	
+	if len(o.adminListeners) > 0 {
+		adminMux := http.NewServeMux()
+		handler.publicRoutes(adminMux)
+		handler.managementRoutes(adminMux)
+		s.adminListeners = o.adminListeners
+		s.admin = &http.Server{
+			Handler:      withMiddleware(adminMux, o, handler, verbose),
+			TLSConfig:    server.TLSConfig,
+			ReadTimeout:  10 * time.Second,
+			WriteTimeout: 10 * time.Second,
+			IdleTimeout:  60 * time.Second,
+		}
+	}
+	
+	return s
 }
 
-// Start starts the HTTP server
+// Start starts the HTTP server on every listener, serving HTTPS when TLS is
+// configured. It returns when any listener fails or the server shuts down.
 func (s *Server) Start() error {
-	log.Printf("Server starting on port %s", s.port)
-	return s.server.ListenAndServe()
+	listeners := s.listeners
+	if len(listeners) == 0 {
+		listener, err := net.Listen("tcp", s.server.Addr)
+		if err != nil {
+			return err
+		}
+		listeners = []net.Listener{listener}
+	}
+
+	serve := func(server *http.Server, listener net.Listener) error {
+		return server.Serve(listener)
+	}
+	if !s.tls.Enabled() {
+		log.Printf("Server starting on %s", s.Address())
+	} else {
+		if s.redirect != nil {
+			log.Printf("Redirecting HTTP on port %s to HTTPS", s.tls.RedirectPort)
+			go func() {
+				if err := s.redirect.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+					log.Printf("HTTP redirect listener error: %v", err)
+				}
+			}()
+		}
+
+		if s.tls.ACME() {
+			log.Printf("Server starting on %s with HTTPS (ACME certificates for %s)", s.Address(), strings.Join(s.tls.ACMEDomains, ", "))
+		} else {
+			if err := s.certificate.load(); err != nil {
+				return err
+			}
+			log.Printf("Server starting on %s with HTTPS", s.Address())
+		}
+		// Certificates come from GetCertificate
+		serve = func(server *http.Server, listener net.Listener) error {
+			return server.ServeTLS(listener, "", "")
+		}
+	}
+	if s.admin != nil {
+		log.Printf("Admin API, dashboard and metrics on %s only", s.AdminAddress())
+	}
+
+	errs := make(chan error, len(listeners)+len(s.adminListeners))
+	for _, listener := range listeners {
+		go func() {
+			errs <- serve(s.server, listener)
+		}()
+	}
+	for _, listener := range s.adminListeners {
+		go func() {
+			errs <- serve(s.admin, listener)
+		}()
+	}
+	return <-errs
+}
+
+// ReloadCertificate reads the TLS certificate files again, so renewed
+// certificates are served to new connections without a restart. It does
+// nothing without certificate files.
+func (s *Server) ReloadCertificate() error {
+	if s.certificate == nil {
+		return nil
+	}
+	return s.certificate.load()
 }
 
-// Shutdown gracefully shuts down the server
+// Shutdown gracefully shuts down the server, the admin listeners and the
+// redirect listener
 func (s *Server) Shutdown(ctx context.Context) error {
 	log.Println("Server shutting down...")
+	// Event streams never go idle, so end them before draining
+	s.handler.events.Close()
+	if s.redirect != nil {
+		if err := s.redirect.Shutdown(ctx); err != nil {
+			log.Printf("Error shutting down HTTP redirect listener: %v", err)
+		}
+	}
+	if s.admin != nil {
+		if err := s.admin.Shutdown(ctx); err != nil {
+			log.Printf("Error shutting down admin listeners: %v", err)
+		}
+	}
 	return s.server.Shutdown(ctx)
 }
 
@@ -70,6 +641,30 @@ func (s *Server) Port() string {
 	return s.port
 }
 
+// Address returns where the server accepts requests: its listeners'
+// addresses, or the port it listens on
+func (s *Server) Address() string {
+	if len(s.listeners) == 0 {
+		return ":" + s.port
+	}
+	return listenerAddresses(s.listeners)
+}
+
+// AdminAddress returns where the management routes are served separately,
+// or "" when every listener serves them
+func (s *Server) AdminAddress() string {
+	return listenerAddresses(s.adminListeners)
+}
+
+// listenerAddresses lists the listeners' addresses, comma-separated
+func listenerAddresses(listeners []net.Listener) string {
+	addresses := make([]string, len(listeners))
+	for i, listener := range listeners {
+		addresses[i] = listener.Addr().String()
+	}
+	return strings.Join(addresses, ", ")
+}
+
 // Handler returns the server handler (useful for testing)
 func (s *Server) Handler() *Handler {
 	return s.handler
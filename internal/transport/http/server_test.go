@@ -0,0 +1,80 @@
+package http
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/joshdurbin/url-shortener/internal/service/mocks"
+)
+
+func TestServer_Listeners(t *testing.T) {
+	tcp, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "shortener.sock")
+	unix, err := net.Listen("unix", path)
+	require.NoError(t, err)
+
+	server := NewServer(&mocks.URLShortener{}, "0", "http://localhost", false, WithListeners(tcp, unix))
+	assert.Equal(t, tcp.Addr().String()+", "+path, server.Address())
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- server.Start()
+	}()
+
+	unixClient := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, "unix", path)
+		},
+	}}
+	for client, url := range map[*http.Client]string{
+		http.DefaultClient: "http://" + tcp.Addr().String() + "/healthz",
+		unixClient:         "http://shortener/healthz",
+	} {
+		resp, err := client.Get(url)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode, url)
+	}
+
+	require.NoError(t, server.Shutdown(context.Background()))
+	select {
+	case err := <-errs:
+		assert.ErrorIs(t, err, http.ErrServerClosed)
+	case <-time.After(2 * time.Second):
+		t.Fatal("Start did not return after Shutdown")
+	}
+}
+
+func TestServer_AdminListeners(t *testing.T) {
+	public, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	admin, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	server := NewServer(&mocks.URLShortener{}, "0", "http://localhost", false,
+		WithListeners(public), WithAdminListeners(admin))
+	assert.Equal(t, admin.Addr().String(), server.AdminAddress())
+
+	go server.Start()
+	defer server.Shutdown(context.Background())
+
+	status := func(listener net.Listener, path string) int {
+		resp, err := http.Get("http://" + listener.Addr().String() + path)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	assert.Equal(t, http.StatusOK, status(public, "/healthz"))
+	assert.Equal(t, http.StatusNotFound, status(public, "/api/version"))
+	assert.Equal(t, http.StatusOK, status(admin, "/healthz"))
+	assert.Equal(t, http.StatusOK, status(admin, "/api/version"))
+}
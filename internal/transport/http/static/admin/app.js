@@ -0,0 +1,421 @@
+// Admin dashboard for the URL shortener. Talks only to the JSON API, so API
+// key or identity provider auth (when enabled) applies to everything the
+// dashboard reads or writes.
+(function () {
+  "use strict";
+
+  const KEY_STORAGE = "url-shortener-api-key";
+  const TOKEN_STORAGE = "url-shortener-id-token";
+  const PKCE_STORAGE = "url-shortener-oidc-pkce";
+  const SVG_NS = "http://www.w3.org/2000/svg";
+
+  const $ = (id) => document.getElementById(id);
+  let links = [];
+  let oidc = null;
+
+  function apiKey() {
+    return sessionStorage.getItem(KEY_STORAGE) || "";
+  }
+
+  function idToken() {
+    return sessionStorage.getItem(TOKEN_STORAGE) || "";
+  }
+
+  async function api(method, path, body) {
+    const headers = {};
+    if (idToken()) {
+      headers["Authorization"] = "Bearer " + idToken();
+    } else if (apiKey()) {
+      headers["X-API-Key"] = apiKey();
+    }
+    if (body !== undefined) {
+      headers["Content-Type"] = "application/json";
+    }
+
+    const resp = await fetch(path, {
+      method: method,
+      headers: headers,
+      body: body === undefined ? undefined : JSON.stringify(body),
+    });
+
+    if (resp.status === 401) {
+      // Expired ID tokens are dropped so the user can sign in again
+      sessionStorage.removeItem(TOKEN_STORAGE);
+      showLogin();
+      throw new Error("API key required");
+    }
+    if (!resp.ok) {
+      throw new Error(await errorMessage(resp));
+    }
+    if (resp.status === 204) {
+      return null;
+    }
+    return resp.json();
+  }
+
+  // errorMessage reads the message of an API error body ({code, message, details})
+  async function errorMessage(resp) {
+    const text = (await resp.text()).trim();
+    try {
+      const body = JSON.parse(text);
+      if (body && body.message) {
+        return body.message;
+      }
+    } catch (e) {
+      // Not JSON, e.g. from a proxy
+    }
+    return text || "Request failed with status " + resp.status;
+  }
+
+  function showLogin() {
+    $("app").classList.add("hidden");
+    $("login").classList.remove("hidden");
+    $("logout").classList.add("hidden");
+    $("api-key").focus();
+  }
+
+  function showApp() {
+    $("login").classList.add("hidden");
+    $("app").classList.remove("hidden");
+    $("logout").classList.toggle("hidden", !apiKey() && !idToken());
+  }
+
+  function notify(text, isError) {
+    const el = $("message");
+    el.textContent = text;
+    el.classList.toggle("error", !!isError);
+    el.classList.remove("hidden");
+    clearTimeout(notify.timer);
+    notify.timer = setTimeout(() => el.classList.add("hidden"), 5000);
+  }
+
+  function formatDate(value) {
+    return value ? new Date(value).toLocaleString() : "Never";
+  }
+
+  function cell(text, className) {
+    const td = document.createElement("td");
+    td.textContent = text;
+    if (className) {
+      td.className = className;
+    }
+    return td;
+  }
+
+  function button(text, className, onClick) {
+    const b = document.createElement("button");
+    b.type = "button";
+    b.textContent = text;
+    b.className = className;
+    b.addEventListener("click", onClick);
+    return b;
+  }
+
+  function previewTitle(link) {
+    return (link.preview && link.preview.title) || "";
+  }
+
+  function renderTable() {
+    const filter = $("filter").value.trim().toLowerCase();
+    const visible = links.filter((l) =>
+      !filter || l.short_code.toLowerCase().includes(filter) || l.original_url.toLowerCase().includes(filter) ||
+      previewTitle(l).toLowerCase().includes(filter));
+
+    const tbody = $("links");
+    tbody.replaceChildren();
+    for (const link of visible) {
+      const tr = document.createElement("tr");
+
+      const code = document.createElement("td");
+      const a = document.createElement("a");
+      a.href = "/" + encodeURIComponent(link.short_code);
+      a.textContent = link.short_code;
+      a.target = "_blank";
+      a.rel = "noopener";
+      code.appendChild(a);
+      tr.appendChild(code);
+
+      // Links with a fetched preview show the page title; hovering shows the URL
+      const title = previewTitle(link);
+      const url = cell(title || link.original_url, "url");
+      url.title = title ? title + "\n" + link.original_url : link.original_url;
+      tr.appendChild(url);
+      tr.appendChild(cell(formatDate(link.created_at)));
+      tr.appendChild(cell(link.usage_count > 0 ? formatDate(link.last_used_at) : "Never"));
+
+      const exhausted = link.max_uses > 0 && link.usage_count >= link.max_uses;
+      const clicks = link.max_uses > 0 ? link.usage_count + " / " + link.max_uses : String(link.usage_count);
+      tr.appendChild(cell(clicks, exhausted ? "num exhausted" : "num"));
+
+      const actions = document.createElement("td");
+      actions.className = "actions";
+      actions.appendChild(button("Edit", "secondary", () => openEdit(link)));
+      actions.appendChild(button("Delete", "danger", () => deleteLink(link)));
+      tr.appendChild(actions);
+
+      tbody.appendChild(tr);
+    }
+
+    $("link-count").textContent = filter ? "(" + visible.length + " of " + links.length + ")" : "(" + links.length + ")";
+  }
+
+  function svg(name, attrs, text) {
+    const el = document.createElementNS(SVG_NS, name);
+    for (const [k, v] of Object.entries(attrs)) {
+      el.setAttribute(k, v);
+    }
+    if (text !== undefined) {
+      el.textContent = text;
+    }
+    return el;
+  }
+
+  // barChart draws labelled vertical bars scaled to the largest value
+  function barChart(target, data) {
+    const width = 560, height = 220, pad = 24;
+    target.setAttribute("viewBox", "0 0 " + width + " " + height);
+    target.replaceChildren();
+
+    if (data.length === 0) {
+      target.appendChild(svg("text", { x: width / 2, y: height / 2, "text-anchor": "middle", class: "label" }, "No data yet"));
+      return;
+    }
+
+    const max = Math.max(1, ...data.map((d) => d.value));
+    const slot = (width - pad) / data.length;
+    const barWidth = Math.max(4, slot * 0.7);
+    data.forEach((d, i) => {
+      const h = (d.value / max) * (height - 2 * pad - 12);
+      const x = pad / 2 + i * slot + (slot - barWidth) / 2;
+      const y = height - pad - h;
+      const bar = svg("rect", { x: x, y: y, width: barWidth, height: h, class: "bar" });
+      bar.appendChild(svg("title", {}, d.title || d.label + ": " + d.value));
+      target.appendChild(bar);
+      target.appendChild(svg("text", { x: x + barWidth / 2, y: y - 4, "text-anchor": "middle", class: "value" }, String(d.value)));
+      target.appendChild(svg("text", { x: x + barWidth / 2, y: height - pad + 14, "text-anchor": "middle", class: "label" }, d.label));
+    });
+  }
+
+  function renderCharts() {
+    const top = links
+      .filter((l) => l.usage_count > 0)
+      .sort((a, b) => b.usage_count - a.usage_count)
+      .slice(0, 10)
+      .map((l) => ({ label: l.short_code, value: l.usage_count, title: l.short_code + " → " + l.original_url + ": " + l.usage_count }));
+    barChart($("chart-top"), top);
+
+    const days = [];
+    const counts = new Map();
+    for (let i = 13; i >= 0; i--) {
+      const day = new Date();
+      day.setHours(0, 0, 0, 0);
+      day.setDate(day.getDate() - i);
+      days.push(day);
+      counts.set(day.getTime(), 0);
+    }
+    for (const link of links) {
+      const day = new Date(link.created_at);
+      day.setHours(0, 0, 0, 0);
+      if (counts.has(day.getTime())) {
+        counts.set(day.getTime(), counts.get(day.getTime()) + 1);
+      }
+    }
+    barChart($("chart-created"), days.map((day) => ({
+      label: (day.getMonth() + 1) + "/" + day.getDate(),
+      value: counts.get(day.getTime()),
+    })));
+  }
+
+  async function load() {
+    try {
+      links = (await api("GET", "/api/urls")) || [];
+      showApp();
+      renderTable();
+      renderCharts();
+    } catch (err) {
+      if (err.message !== "API key required") {
+        showApp();
+        notify(err.message, true);
+      }
+    }
+  }
+
+  async function loadVersion() {
+    try {
+      const info = await api("GET", "/api/version");
+      $("version").textContent = info.version + " · " + (info.generator || "unknown") + " generator";
+    } catch (err) {
+      // Informational only
+    }
+  }
+
+  async function createLink(event) {
+    event.preventDefault();
+    const body = { url: $("create-url").value };
+    const maxUses = parseInt($("create-max-uses").value, 10);
+    if (maxUses > 0) {
+      body.max_uses = maxUses;
+    }
+    try {
+      const created = await api("POST", "/api/urls", body);
+      $("create-form").reset();
+      notify("Created " + created.short_url);
+      await load();
+    } catch (err) {
+      notify(err.message, true);
+    }
+  }
+
+  function openEdit(link) {
+    $("edit-code").textContent = link.short_code;
+    $("edit-url").value = link.original_url;
+    $("edit-max-uses").value = link.max_uses || 0;
+    $("edit-dialog").dataset.code = link.short_code;
+    $("edit-dialog").showModal();
+  }
+
+  async function saveEdit(event) {
+    event.preventDefault();
+    const code = $("edit-dialog").dataset.code;
+    try {
+      await api("PATCH", "/api/urls/" + encodeURIComponent(code), {
+        original_url: $("edit-url").value,
+        max_uses: parseInt($("edit-max-uses").value, 10) || 0,
+      });
+      $("edit-dialog").close();
+      notify("Updated " + code);
+      await load();
+    } catch (err) {
+      notify(err.message, true);
+    }
+  }
+
+  async function deleteLink(link) {
+    if (!confirm("Delete " + link.short_code + " → " + link.original_url + "?")) {
+      return;
+    }
+    try {
+      await api("DELETE", "/api/urls/" + encodeURIComponent(link.short_code));
+      notify("Deleted " + link.short_code);
+      await load();
+    } catch (err) {
+      notify(err.message, true);
+    }
+  }
+
+  function base64url(bytes) {
+    return btoa(String.fromCharCode(...new Uint8Array(bytes)))
+      .replace(/\+/g, "-").replace(/\//g, "_").replace(/=+$/, "");
+  }
+
+  function randomString() {
+    return base64url(crypto.getRandomValues(new Uint8Array(32)));
+  }
+
+  function redirectURI() {
+    return location.origin + "/admin/";
+  }
+
+  // loadConfig shows the SSO button when the server accepts identity provider tokens
+  async function loadConfig() {
+    try {
+      const resp = await fetch("config.json");
+      if (resp.ok) {
+        oidc = (await resp.json()).oidc || null;
+      }
+    } catch (err) {
+      // API key sign-in still works
+    }
+    $("sso").classList.toggle("hidden", !oidc);
+  }
+
+  // signInWithSSO starts an authorization code flow with PKCE
+  async function signInWithSSO() {
+    const verifier = randomString();
+    const state = randomString();
+    sessionStorage.setItem(PKCE_STORAGE, JSON.stringify({ verifier: verifier, state: state }));
+
+    const challenge = base64url(await crypto.subtle.digest("SHA-256", new TextEncoder().encode(verifier)));
+    const params = new URLSearchParams({
+      response_type: "code",
+      client_id: oidc.client_id,
+      redirect_uri: redirectURI(),
+      scope: oidc.scopes.join(" "),
+      state: state,
+      code_challenge: challenge,
+      code_challenge_method: "S256",
+    });
+    location.assign(oidc.authorization_endpoint + "?" + params.toString());
+  }
+
+  // completeSSO exchanges the authorization code from the provider's redirect
+  // for an ID token, which then authenticates API calls
+  async function completeSSO() {
+    const params = new URLSearchParams(location.search);
+    if (!params.has("code") && !params.has("error")) {
+      return;
+    }
+    history.replaceState(null, "", location.pathname);
+
+    const pkce = JSON.parse(sessionStorage.getItem(PKCE_STORAGE) || "null");
+    sessionStorage.removeItem(PKCE_STORAGE);
+    if (params.has("error")) {
+      throw new Error("Sign-in failed: " + (params.get("error_description") || params.get("error")));
+    }
+    if (!oidc || !pkce || pkce.state !== params.get("state")) {
+      throw new Error("Sign-in failed: unexpected response from identity provider");
+    }
+
+    const resp = await fetch(oidc.token_endpoint, {
+      method: "POST",
+      headers: { "Content-Type": "application/x-www-form-urlencoded" },
+      body: new URLSearchParams({
+        grant_type: "authorization_code",
+        code: params.get("code"),
+        redirect_uri: redirectURI(),
+        client_id: oidc.client_id,
+        code_verifier: pkce.verifier,
+      }),
+    });
+    const tokens = await resp.json();
+    if (!resp.ok || !tokens.id_token) {
+      throw new Error("Sign-in failed: " + (tokens.error_description || tokens.error || "no ID token returned"));
+    }
+    sessionStorage.removeItem(KEY_STORAGE);
+    sessionStorage.setItem(TOKEN_STORAGE, tokens.id_token);
+  }
+
+  async function start() {
+    await loadConfig();
+    try {
+      await completeSSO();
+    } catch (err) {
+      showApp();
+      notify(err.message, true);
+    }
+    load();
+    loadVersion();
+  }
+
+  $("login-form").addEventListener("submit", (event) => {
+    event.preventDefault();
+    sessionStorage.setItem(KEY_STORAGE, $("api-key").value);
+    $("login-form").reset();
+    load();
+    loadVersion();
+  });
+  $("sso-login").addEventListener("click", signInWithSSO);
+  $("logout").addEventListener("click", () => {
+    sessionStorage.removeItem(KEY_STORAGE);
+    sessionStorage.removeItem(TOKEN_STORAGE);
+    links = [];
+    showLogin();
+  });
+  $("create-form").addEventListener("submit", createLink);
+  $("edit-form").addEventListener("submit", saveEdit);
+  $("edit-cancel").addEventListener("click", () => $("edit-dialog").close());
+  $("filter").addEventListener("input", renderTable);
+  $("refresh").addEventListener("click", load);
+
+  start();
+})();
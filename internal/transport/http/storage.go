@@ -0,0 +1,253 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+
+	"github.com/joshdurbin/url-shortener/internal/abuse"
+	"github.com/joshdurbin/url-shortener/internal/cache/response"
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	"github.com/joshdurbin/url-shortener/internal/events"
+	"github.com/joshdurbin/url-shortener/internal/export"
+	"github.com/joshdurbin/url-shortener/internal/service"
+	"github.com/joshdurbin/url-shortener/internal/shortener"
+	"github.com/joshdurbin/url-shortener/internal/tracing"
+)
+
+// StorageReport handles GET /api/admin/storage
+func (h *Handler) StorageReport(w http.ResponseWriter, r *http.Request) {
+	if h.storage == nil {
+		writeError(w, http.StatusNotImplemented, "Storage reporting is not configured")
+		return
+	}
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	report, err := h.storageReport(r.Context())
+	if err != nil {
+		log.Printf("Error building storage report: %v", err)
+		writeError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, report)
+}
+
+// storageReportKey is the response cache key of the storage report
+const storageReportKey = "storage"
+
+// storageReport returns the storage report, from the response cache when fresh
+func (h *Handler) storageReport(ctx context.Context) (*domain.StorageReport, error) {
+	if cached, ok := h.responses.Get(storageReportKey); ok {
+		return cached.(*domain.StorageReport), nil
+	}
+	report, err := h.storage.Report(ctx)
+	if err != nil {
+		return nil, err
+	}
+	h.responses.Set(storageReportKey, report)
+	return report, nil
+}
+
+// Metrics handles GET /metrics, exposing the storage report, the response
+// cache hit rate, short code collisions, counter leases, buffered clicks, peer
+// invalidations, published and exported events, event streams and exported
+// spans in the Prometheus text format
+func (h *Handler) Metrics(w http.ResponseWriter, r *http.Request) {
+	if h.storage == nil && h.responses == nil && h.collisions == nil && h.counters == nil && h.clicks == nil && h.peers == nil && h.events == nil && h.bus == nil && h.exporter == nil && h.tracer == nil && h.timeouts == nil {
+		writeError(w, http.StatusNotImplemented, "Metrics are not configured")
+		return
+	}
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var report *domain.StorageReport
+	if h.storage != nil {
+		var err error
+		if report, err = h.storageReport(r.Context()); err != nil {
+			log.Printf("Error building storage metrics: %v", err)
+			writeError(w, http.StatusInternalServerError, "Internal server error")
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	if report != nil {
+		writeStorageMetrics(w, report)
+	}
+	if h.responses != nil {
+		writeResponseCacheMetrics(w, h.responses.Stats())
+	}
+	if h.collisions != nil {
+		writeCollisionMetrics(w, h.collisions)
+	}
+	if h.counters != nil {
+		writeCounterMetrics(w, h.counters.Stats())
+	}
+	if h.clicks != nil {
+		writeClickBufferMetrics(w, h.clicks)
+	}
+	if h.breaker != nil {
+		writeBreakerMetrics(w, h.breaker)
+	}
+	if h.abuse != nil {
+		writeAbuseMetrics(w, h.abuse)
+	}
+	if h.peers != nil {
+		writePeerMetrics(w, h.peers)
+	}
+	if h.bus != nil {
+		writeBusMetrics(w, h.bus)
+	}
+	if h.exporter != nil {
+		writeExportMetrics(w, h.exporter)
+	}
+	if h.tracer != nil {
+		writeTracingMetrics(w, h.tracer)
+	}
+	if h.events != nil {
+		writeEventMetrics(w, h.events)
+	}
+	if h.timeouts != nil {
+		writeTimeoutMetrics(w, h.timeouts)
+	}
+}
+
+// writeCollisionMetrics writes short code collision counters in the Prometheus text format
+func writeCollisionMetrics(w io.Writer, stats *service.CollisionStats) {
+	fmt.Fprintf(w, "# HELP url_shortener_code_collisions_total Generated short codes that already existed and were regenerated.\n# TYPE url_shortener_code_collisions_total counter\nurl_shortener_code_collisions_total %d\n", stats.Retried())
+	fmt.Fprintf(w, "# HELP url_shortener_code_collision_failures_total Link creations that failed because every generated short code already existed.\n# TYPE url_shortener_code_collision_failures_total counter\nurl_shortener_code_collision_failures_total %d\n", stats.Failed())
+}
+
+// writeCounterMetrics writes the counter values leased and not yet issued, and
+// the leases taken, in the Prometheus text format
+func writeCounterMetrics(w io.Writer, stats shortener.CounterStats) {
+	fmt.Fprintf(w, "# HELP url_shortener_counter_lease_size Counter values reserved by each lease.\n# TYPE url_shortener_counter_lease_size gauge\nurl_shortener_counter_lease_size %d\n", stats.LeaseSize)
+	fmt.Fprintf(w, "# HELP url_shortener_counter_lease_remaining Leased counter values not yet issued as short codes, by counter.\n# TYPE url_shortener_counter_lease_remaining gauge\n")
+	for _, lease := range stats.Leases {
+		fmt.Fprintf(w, "url_shortener_counter_lease_remaining{key=%q} %d\n", lease.Key, lease.Remaining)
+	}
+	fmt.Fprintf(w, "# HELP url_shortener_counter_leases_total Counter ranges leased from the database, by whether a link creation waited for them.\n# TYPE url_shortener_counter_leases_total counter\n")
+	fmt.Fprintf(w, "url_shortener_counter_leases_total{mode=\"waited\"} %d\n", stats.Waited)
+	fmt.Fprintf(w, "url_shortener_counter_leases_total{mode=\"prefetched\"} %d\n", stats.Prefetched)
+}
+
+// writeBreakerMetrics writes the database circuit breaker's state and counters in the Prometheus text format
+func writeBreakerMetrics(w io.Writer, breaker *service.CircuitBreaker) {
+	state := breaker.State()
+	fmt.Fprintf(w, "# HELP url_shortener_db_breaker_state Whether the database circuit breaker is in each state.\n# TYPE url_shortener_db_breaker_state gauge\n")
+	for _, s := range []string{service.BreakerClosed, service.BreakerOpen, service.BreakerHalfOpen} {
+		active := 0
+		if s == state {
+			active = 1
+		}
+		fmt.Fprintf(w, "url_shortener_db_breaker_state{state=%q} %d\n", s, active)
+	}
+	fmt.Fprintf(w, "# HELP url_shortener_db_breaker_opened_total Times the database circuit breaker opened.\n# TYPE url_shortener_db_breaker_opened_total counter\nurl_shortener_db_breaker_opened_total %d\n", breaker.Opened())
+	fmt.Fprintf(w, "# HELP url_shortener_db_breaker_rejected_total Database calls failed fast while the circuit breaker was open.\n# TYPE url_shortener_db_breaker_rejected_total counter\nurl_shortener_db_breaker_rejected_total %d\n", breaker.Rejected())
+}
+
+// writeAbuseMetrics writes the links flagged for click fraud and the clicks
+// abuse detection could not track in the Prometheus text format
+func writeAbuseMetrics(w io.Writer, detector *abuse.Detector) {
+	fmt.Fprintf(w, "# HELP url_shortener_abuse_flagged_links Links currently flagged for click fraud.\n# TYPE url_shortener_abuse_flagged_links gauge\nurl_shortener_abuse_flagged_links %d\n", len(detector.Flags()))
+	fmt.Fprintf(w, "# HELP url_shortener_abuse_untracked_clicks_total Clicks abuse detection could not count because its counters were full.\n# TYPE url_shortener_abuse_untracked_clicks_total counter\nurl_shortener_abuse_untracked_clicks_total %d\n", detector.Dropped())
+}
+
+// writeClickBufferMetrics writes click buffer gauges and counters in the Prometheus text format
+func writeClickBufferMetrics(w io.Writer, buffer *service.ClickBuffer) {
+	fmt.Fprintf(w, "# HELP url_shortener_click_buffer_queued Clicks waiting to be counted.\n# TYPE url_shortener_click_buffer_queued gauge\nurl_shortener_click_buffer_queued %d\n", buffer.Queued())
+	fmt.Fprintf(w, "# HELP url_shortener_click_buffer_capacity Clicks the buffer holds.\n# TYPE url_shortener_click_buffer_capacity gauge\nurl_shortener_click_buffer_capacity %d\n", buffer.Capacity())
+	fmt.Fprintf(w, "# HELP url_shortener_click_buffer_applied_total Buffered clicks counted after their redirects.\n# TYPE url_shortener_click_buffer_applied_total counter\nurl_shortener_click_buffer_applied_total %d\n", buffer.Applied())
+	fmt.Fprintf(w, "# HELP url_shortener_click_buffer_dropped_total Clicks lost because the buffer was full.\n# TYPE url_shortener_click_buffer_dropped_total counter\nurl_shortener_click_buffer_dropped_total %d\n", buffer.Dropped())
+	fmt.Fprintf(w, "# HELP url_shortener_click_buffer_inline_total Clicks counted during their redirect because the buffer was full.\n# TYPE url_shortener_click_buffer_inline_total counter\nurl_shortener_click_buffer_inline_total %d\n", buffer.Inline())
+}
+
+// writeResponseCacheMetrics writes response cache counters in the Prometheus text format
+func writeResponseCacheMetrics(w io.Writer, stats response.Stats) {
+	fmt.Fprintf(w, "# HELP url_shortener_response_cache_hits_total Responses served from the response cache.\n# TYPE url_shortener_response_cache_hits_total counter\nurl_shortener_response_cache_hits_total %d\n", stats.Hits)
+	fmt.Fprintf(w, "# HELP url_shortener_response_cache_misses_total Response cache lookups that reached the repository.\n# TYPE url_shortener_response_cache_misses_total counter\nurl_shortener_response_cache_misses_total %d\n", stats.Misses)
+	fmt.Fprintf(w, "# HELP url_shortener_response_cache_hit_ratio Fraction of response cache lookups that hit.\n# TYPE url_shortener_response_cache_hit_ratio gauge\nurl_shortener_response_cache_hit_ratio %g\n", stats.HitRate())
+	fmt.Fprintf(w, "# HELP url_shortener_response_cache_entries Responses currently cached.\n# TYPE url_shortener_response_cache_entries gauge\nurl_shortener_response_cache_entries %d\n", stats.Entries)
+}
+
+// writeStorageMetrics writes a storage report in the Prometheus text format
+func writeStorageMetrics(w io.Writer, report *domain.StorageReport) {
+	gauge := func(name, help string, value any) {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n%s %v\n", name, help, name, name, value)
+	}
+
+	gauge("url_shortener_storage_database_bytes", "Size of the database files in bytes.", report.DatabaseBytes)
+
+	tables := make([]string, 0, len(report.TableRows))
+	for table := range report.TableRows {
+		tables = append(tables, table)
+	}
+	sort.Strings(tables)
+	fmt.Fprintf(w, "# HELP url_shortener_storage_table_rows Rows per database table.\n# TYPE url_shortener_storage_table_rows gauge\n")
+	for _, table := range tables {
+		fmt.Fprintf(w, "url_shortener_storage_table_rows{table=%q} %d\n", table, report.TableRows[table])
+	}
+
+	gauge("url_shortener_storage_clicks", "Redirects recorded across all links.", report.TotalClicks)
+	gauge("url_shortener_storage_links_per_day", "Links created per day over the growth window.", report.Growth.LinksPerDay)
+	gauge("url_shortener_storage_projected_bytes", "Projected database size at the end of the projection window.", report.Growth.ProjectedBytes)
+	gauge("url_shortener_storage_projected_rows", "Projected total rows at the end of the projection window.", report.Growth.ProjectedRows)
+	if report.Quota.MaxBytes > 0 {
+		gauge("url_shortener_storage_quota_bytes", "Configured database size quota in bytes.", report.Quota.MaxBytes)
+	}
+	if report.Quota.MaxRows > 0 {
+		gauge("url_shortener_storage_quota_rows", "Configured total row quota.", report.Quota.MaxRows)
+	}
+	gauge("url_shortener_storage_warnings", "Storage quota warnings currently raised.", len(report.Warnings))
+}
+
+// writeEventMetrics writes live event stream counts in the Prometheus text format
+func writeEventMetrics(w io.Writer, events *service.EventBus) {
+	fmt.Fprintf(w, "# HELP url_shortener_event_streams Live event streams.\n# TYPE url_shortener_event_streams gauge\nurl_shortener_event_streams %d\n", events.Subscribers())
+	fmt.Fprintf(w, "# HELP url_shortener_events_dropped_total Events missed by streams that read too slowly.\n# TYPE url_shortener_events_dropped_total counter\nurl_shortener_events_dropped_total %d\n", events.Dropped())
+}
+
+// writeBusMetrics writes published event and broker counts in the Prometheus text format
+func writeBusMetrics(w io.Writer, bus *events.Bus) {
+	fmt.Fprintf(w, "# HELP url_shortener_events_published_total Events published to the event bus, by type.\n# TYPE url_shortener_events_published_total counter\n")
+	for _, eventType := range domain.EventTypes {
+		fmt.Fprintf(w, "url_shortener_events_published_total{type=%q} %d\n", eventType, bus.Published(eventType))
+	}
+	if !bus.Brokered() {
+		return
+	}
+	fmt.Fprintf(w, "# HELP url_shortener_event_broker_dropped_total Events dropped because the broker queue was full.\n# TYPE url_shortener_event_broker_dropped_total counter\nurl_shortener_event_broker_dropped_total %d\n", bus.BrokerDropped())
+	fmt.Fprintf(w, "# HELP url_shortener_event_broker_failures_total Events the broker failed to publish.\n# TYPE url_shortener_event_broker_failures_total counter\nurl_shortener_event_broker_failures_total %d\n", bus.BrokerFailed())
+}
+
+// writeExportMetrics writes event export counts in the Prometheus text format
+func writeExportMetrics(w io.Writer, exporter *export.Exporter) {
+	fmt.Fprintf(w, "# HELP url_shortener_events_exported_total Events acknowledged by the export sink.\n# TYPE url_shortener_events_exported_total counter\nurl_shortener_events_exported_total %d\n", exporter.Exported())
+	fmt.Fprintf(w, "# HELP url_shortener_events_export_dropped_total Events dropped because the export buffer was full.\n# TYPE url_shortener_events_export_dropped_total counter\nurl_shortener_events_export_dropped_total %d\n", exporter.Dropped())
+	fmt.Fprintf(w, "# HELP url_shortener_events_export_failures_total Failed attempts to send the export outbox.\n# TYPE url_shortener_events_export_failures_total counter\nurl_shortener_events_export_failures_total %d\n", exporter.Failures())
+	fmt.Fprintf(w, "# HELP url_shortener_events_export_backlog Events in the export outbox waiting to be sent.\n# TYPE url_shortener_events_export_backlog gauge\nurl_shortener_events_export_backlog %d\n", exporter.Backlog())
+}
+
+// writeTimeoutMetrics writes timed out request counts per route class in the Prometheus text format
+func writeTimeoutMetrics(w io.Writer, timeouts *TimeoutMiddleware) {
+	fmt.Fprintf(w, "# HELP url_shortener_request_timeouts_total Requests cancelled because they ran past their route's deadline.\n# TYPE url_shortener_request_timeouts_total counter\n")
+	for _, route := range []string{RouteRedirect, RouteAPI, RouteBulk} {
+		fmt.Fprintf(w, "url_shortener_request_timeouts_total{route=%q} %d\n", route, timeouts.TimedOut(route))
+	}
+}
+
+// writeTracingMetrics writes span export counts in the Prometheus text format
+func writeTracingMetrics(w io.Writer, tracer *tracing.Tracer) {
+	fmt.Fprintf(w, "# HELP url_shortener_spans_exported_total Spans accepted by the trace collector.\n# TYPE url_shortener_spans_exported_total counter\nurl_shortener_spans_exported_total %d\n", tracer.Exported())
+	fmt.Fprintf(w, "# HELP url_shortener_spans_dropped_total Spans dropped because the export queue was full.\n# TYPE url_shortener_spans_dropped_total counter\nurl_shortener_spans_dropped_total %d\n", tracer.Dropped())
+	fmt.Fprintf(w, "# HELP url_shortener_spans_failed_total Spans lost to failed export requests.\n# TYPE url_shortener_spans_failed_total counter\nurl_shortener_spans_failed_total %d\n", tracer.Failed())
+}
@@ -0,0 +1,210 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/joshdurbin/url-shortener/internal/cache/response"
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	repoMocks "github.com/joshdurbin/url-shortener/internal/repository/mocks"
+	"github.com/joshdurbin/url-shortener/internal/service"
+	"github.com/joshdurbin/url-shortener/internal/service/mocks"
+	"github.com/joshdurbin/url-shortener/internal/shortener"
+	"github.com/joshdurbin/url-shortener/internal/storage"
+)
+
+func TestHandler_Storage(t *testing.T) {
+	stats := &domain.StorageStats{
+		DatabaseBytes: 900,
+		TableRows:     map[string]int64{"urls": 3, "webhook_deliveries": 6},
+		TotalClicks:   12,
+		LinksCreated:  3,
+	}
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		statsErr       error
+		expectedStatus int
+		expectedBody   []string
+	}{
+		{
+			name:           "report",
+			method:         http.MethodGet,
+			path:           "/api/admin/storage",
+			expectedStatus: http.StatusOK,
+			expectedBody: []string{
+				`"database_bytes":900`,
+				`"table_rows":{"urls":3,"webhook_deliveries":6}`,
+				`"total_clicks":12`,
+				`"max_bytes":1000`,
+				`"database size 900 B is at 90% of the 1000 B quota"`,
+			},
+		},
+		{
+			name:           "metrics",
+			method:         http.MethodGet,
+			path:           "/metrics",
+			expectedStatus: http.StatusOK,
+			expectedBody: []string{
+				"# TYPE url_shortener_storage_database_bytes gauge\nurl_shortener_storage_database_bytes 900\n",
+				`url_shortener_storage_table_rows{table="urls"} 3`,
+				"url_shortener_storage_quota_bytes 1000\n",
+				"url_shortener_storage_warnings 2\n",
+			},
+		},
+		{
+			name:           "method not allowed",
+			method:         http.MethodPost,
+			path:           "/api/admin/storage",
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+		{
+			name:           "repository error",
+			method:         http.MethodGet,
+			path:           "/api/admin/storage",
+			statsErr:       errors.New("database is locked"),
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &repoMocks.StorageRepository{}
+			if tt.statsErr != nil {
+				store.On("StorageStats", mock.Anything, mock.Anything).Return(nil, tt.statsErr)
+			} else {
+				store.On("StorageStats", mock.Anything, mock.Anything).Return(stats, nil)
+			}
+
+			config := storage.DefaultConfig()
+			config.MaxBytes = 1000
+			server := NewServer(&mocks.URLShortener{}, "8080", "http://localhost:8080", false, WithStorage(storage.New(config, store)))
+
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			w := httptest.NewRecorder()
+			server.server.Handler.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			for _, expected := range tt.expectedBody {
+				assert.Contains(t, w.Body.String(), expected)
+			}
+		})
+	}
+}
+
+func TestHandler_StorageNotConfigured(t *testing.T) {
+	server := NewServer(&mocks.URLShortener{}, "8080", "http://localhost:8080", false)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/admin/storage", nil)
+	w := httptest.NewRecorder()
+	server.server.Handler.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotImplemented, w.Code)
+
+	// Metrics always include the request timeout counters
+	req = httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	w = httptest.NewRecorder()
+	server.server.Handler.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), "url_shortener_storage")
+}
+
+func TestHandler_StorageResponseCache(t *testing.T) {
+	store := &repoMocks.StorageRepository{}
+	store.On("StorageStats", mock.Anything, mock.Anything).Return(&domain.StorageStats{DatabaseBytes: 900}, nil)
+	server := NewServer(&mocks.URLShortener{}, "8080", "http://localhost:8080", false,
+		WithStorage(storage.New(storage.DefaultConfig(), store)),
+		WithResponseCache(response.New(time.Minute)))
+
+	for i := 0; i < 3; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/api/admin/storage", nil)
+		w := httptest.NewRecorder()
+		server.server.Handler.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+	}
+	store.AssertNumberOfCalls(t, "StorageStats", 1)
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	w := httptest.NewRecorder()
+	server.server.Handler.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "url_shortener_storage_database_bytes 900\n")
+	assert.Contains(t, w.Body.String(), "url_shortener_response_cache_hits_total 3\n")
+	assert.Contains(t, w.Body.String(), "url_shortener_response_cache_misses_total 1\n")
+	assert.Contains(t, w.Body.String(), "url_shortener_response_cache_hit_ratio 0.75\n")
+}
+
+func TestHandler_CollisionMetrics(t *testing.T) {
+	server := NewServer(&mocks.URLShortener{}, "8080", "http://localhost:8080", false,
+		WithCollisionStats(&service.CollisionStats{}))
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	w := httptest.NewRecorder()
+	server.server.Handler.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "url_shortener_code_collisions_total 0\n")
+	assert.Contains(t, w.Body.String(), "url_shortener_code_collision_failures_total 0\n")
+	assert.NotContains(t, w.Body.String(), "url_shortener_storage_")
+}
+
+func TestHandler_CounterMetrics(t *testing.T) {
+	server := NewServer(&mocks.URLShortener{}, "8080", "http://localhost:8080", false,
+		WithCounters(shortener.NewCounterCache(nil, 1000)))
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	w := httptest.NewRecorder()
+	server.server.Handler.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "url_shortener_counter_lease_size 1000\n")
+	assert.Contains(t, w.Body.String(), "url_shortener_counter_leases_total{mode=\"waited\"} 0\n")
+
+	var b strings.Builder
+	writeCounterMetrics(&b, shortener.CounterStats{
+		LeaseSize:  1000,
+		Leases:     []shortener.LeaseStats{{Key: "url_counter", Remaining: 1800}, {Key: "url_counter_1", Remaining: 12}},
+		Waited:     1,
+		Prefetched: 4,
+	})
+	assert.Contains(t, b.String(), "url_shortener_counter_lease_remaining{key=\"url_counter\"} 1800\n")
+	assert.Contains(t, b.String(), "url_shortener_counter_lease_remaining{key=\"url_counter_1\"} 12\n")
+	assert.Contains(t, b.String(), "url_shortener_counter_leases_total{mode=\"prefetched\"} 4\n")
+}
+
+func TestHandler_ClickBufferMetrics(t *testing.T) {
+	buffer := service.NewClickBuffer(service.ClickBufferConfig{Size: 16, BatchSize: 4, Overflow: service.ClickOverflowDrop})
+	server := NewServer(&mocks.URLShortener{}, "8080", "http://localhost:8080", false,
+		WithClickBuffer(buffer))
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	w := httptest.NewRecorder()
+	server.server.Handler.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "url_shortener_click_buffer_capacity 16\n")
+	assert.Contains(t, w.Body.String(), "url_shortener_click_buffer_queued 0\n")
+	assert.Contains(t, w.Body.String(), "url_shortener_click_buffer_dropped_total 0\n")
+	assert.NotContains(t, w.Body.String(), "url_shortener_code_collisions_total")
+}
+
+func TestHandler_CircuitBreakerMetrics(t *testing.T) {
+	breaker := service.NewCircuitBreaker(service.DefaultBreakerConfig())
+	server := NewServer(&mocks.URLShortener{}, "8080", "http://localhost:8080", false,
+		WithCircuitBreaker(breaker))
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	w := httptest.NewRecorder()
+	server.server.Handler.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "url_shortener_db_breaker_state{state=\"closed\"} 1\n")
+	assert.Contains(t, w.Body.String(), "url_shortener_db_breaker_state{state=\"open\"} 0\n")
+	assert.Contains(t, w.Body.String(), "url_shortener_db_breaker_opened_total 0\n")
+	assert.Contains(t, w.Body.String(), "url_shortener_db_breaker_rejected_total 0\n")
+}
@@ -0,0 +1,254 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Route classes with their own request deadlines
+const (
+	RouteRedirect = "redirect" // Short links, the catch-all route
+	RouteAPI      = "api"      // API, dashboard, probes and metrics
+	RouteBulk     = "bulk"     // Link listing, export, backup, bulk delete and prune
+)
+
+// TimeoutConfig sets how long each class of request may run. The request's
+// context is cancelled at the deadline, which interrupts its database
+// queries, and a client still waiting gets 503. 0 disables a class's deadline.
+// Live event streams never time out.
+type TimeoutConfig struct {
+	Redirect time.Duration
+	API      time.Duration
+	Bulk     time.Duration
+}
+
+// DefaultTimeoutConfig returns short deadlines for redirects and long ones
+// for requests that read every link
+func DefaultTimeoutConfig() TimeoutConfig {
+	return TimeoutConfig{
+		Redirect: 5 * time.Second,
+		API:      30 * time.Second,
+		Bulk:     5 * time.Minute,
+	}
+}
+
+// Validate checks that no deadline is negative
+func (c TimeoutConfig) Validate() error {
+	if c.Redirect < 0 || c.API < 0 || c.Bulk < 0 {
+		return fmt.Errorf("request timeouts cannot be negative, got redirect %v, api %v and bulk %v", c.Redirect, c.API, c.Bulk)
+	}
+	return nil
+}
+
+// route returns a request's class and deadline; "" means it has no deadline
+func (c TimeoutConfig) route(r *http.Request) (string, time.Duration) {
+	switch {
+	case r.URL.Path == "/api/events":
+		return "", 0
+	case r.URL.Path == "/api/urls" && r.Method == http.MethodGet,
+		r.URL.Path == "/api/admin/export", r.URL.Path == "/api/admin/backup",
+		r.URL.Path == "/api/urls/delete" && r.Method == http.MethodPost,
+		r.URL.Path == "/api/urls/prune" && r.Method == http.MethodPost:
+		return RouteBulk, c.Bulk
+	case isManagementPath(r.URL.Path):
+		return RouteAPI, c.API
+	default:
+		return RouteRedirect, c.Redirect
+	}
+}
+
+// isManagementPath reports whether a path is served by anything but the redirect route
+func isManagementPath(path string) bool {
+	switch path {
+	case "/metrics", "/healthz", "/readyz", "/admin":
+		return true
+	}
+	return strings.HasPrefix(path, "/api/") || strings.HasPrefix(path, "/admin/") || strings.HasPrefix(path, "/internal/")
+}
+
+// TimeoutMiddleware enforces TimeoutConfig and counts timed out requests per route class
+type TimeoutMiddleware struct {
+	config   TimeoutConfig
+	redirect atomic.Int64
+	api      atomic.Int64
+	bulk     atomic.Int64
+}
+
+// NewTimeoutMiddleware creates a timeout middleware
+func NewTimeoutMiddleware(config TimeoutConfig) *TimeoutMiddleware {
+	return &TimeoutMiddleware{config: config}
+}
+
+// TimedOut returns how many requests of a route class ran past their deadline
+func (m *TimeoutMiddleware) TimedOut(route string) int64 {
+	if counter := m.counter(route); counter != nil {
+		return counter.Load()
+	}
+	return 0
+}
+
+// counter returns the timed out counter of a route class
+func (m *TimeoutMiddleware) counter(route string) *atomic.Int64 {
+	switch route {
+	case RouteRedirect:
+		return &m.redirect
+	case RouteAPI:
+		return &m.api
+	case RouteBulk:
+		return &m.bulk
+	}
+	return nil
+}
+
+// Middleware returns the timeout middleware function. The handler runs in
+// its own goroutine so a client gets its 503 at the deadline even when the
+// handler is stuck in something that ignores its context; its later writes
+// are discarded. A handler that already started its response cannot be
+// answered with 503, so its context is cancelled and the response ends when
+// the handler returns.
+func (m *TimeoutMiddleware) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route, timeout := m.config.route(r)
+		if timeout <= 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+		// Long deadlines outlast the server's write timeout
+		http.NewResponseController(w).SetWriteDeadline(time.Now().Add(timeout + time.Second))
+
+		tw := &timeoutWriter{w: w, ctx: ctx, header: make(http.Header)}
+		done := make(chan struct{})
+		panicked := make(chan any, 1)
+		go func() {
+			defer func() {
+				if p := recover(); p != nil {
+					panicked <- p
+					return
+				}
+				close(done)
+			}()
+			next.ServeHTTP(tw, r.WithContext(ctx))
+		}()
+
+		select {
+		case <-done:
+			if !tw.discarded() {
+				return
+			}
+		case p := <-panicked:
+			panic(p)
+		case <-ctx.Done():
+		}
+		if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			// The client left; nothing is waiting for an answer
+			tw.abandon()
+			return
+		}
+
+		m.counter(route).Add(1)
+		if tw.timeout() {
+			return
+		}
+		// The response already started, so it ends when the handler does
+		select {
+		case <-done:
+		case p := <-panicked:
+			panic(p)
+		}
+	})
+}
+
+// timeoutWriter passes a handler's response through until the request times
+// out, then discards it
+type timeoutWriter struct {
+	w      http.ResponseWriter
+	ctx    context.Context
+	header http.Header
+
+	mu          sync.Mutex
+	wroteHeader bool
+	timedOut    bool
+}
+
+// Header returns the handler's own header map, copied to the response when
+// the header is written, so a handler outliving its request never shares one
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.header
+}
+
+func (tw *timeoutWriter) WriteHeader(code int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	tw.writeHeaderLocked(code)
+}
+
+func (tw *timeoutWriter) writeHeaderLocked(code int) {
+	if tw.timedOut || tw.wroteHeader {
+		return
+	}
+	if tw.ctx.Err() != nil {
+		// Too late to start a response; the middleware answers instead
+		tw.timedOut = true
+		return
+	}
+	tw.wroteHeader = true
+	for key, values := range tw.header {
+		tw.w.Header()[key] = values
+	}
+	tw.w.WriteHeader(code)
+}
+
+func (tw *timeoutWriter) Write(b []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	tw.writeHeaderLocked(http.StatusOK)
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	return tw.w.Write(b)
+}
+
+// Flush sends buffered data to the client
+func (tw *timeoutWriter) Flush() {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if !tw.timedOut {
+		http.NewResponseController(tw.w).Flush()
+	}
+}
+
+// timeout answers 503 when the response has not started, reporting whether it did
+func (tw *timeoutWriter) timeout() bool {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.wroteHeader {
+		return false
+	}
+	tw.timedOut = true
+	writeError(tw.w, http.StatusServiceUnavailable, "Request timed out")
+	return true
+}
+
+// discarded reports whether the handler's response was dropped because it
+// started after the deadline
+func (tw *timeoutWriter) discarded() bool {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	return tw.timedOut
+}
+
+// abandon discards the rest of the handler's response
+func (tw *timeoutWriter) abandon() {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	tw.timedOut = true
+}
@@ -0,0 +1,146 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/joshdurbin/url-shortener/internal/service/mocks"
+)
+
+func TestTimeoutConfig_Validate(t *testing.T) {
+	assert.NoError(t, DefaultTimeoutConfig().Validate())
+	assert.NoError(t, TimeoutConfig{}.Validate())
+	assert.ErrorContains(t, TimeoutConfig{Bulk: -time.Second}.Validate(), "cannot be negative")
+}
+
+func TestTimeoutConfig_Route(t *testing.T) {
+	config := TimeoutConfig{Redirect: 1, API: 2, Bulk: 3}
+	tests := []struct {
+		method string
+		path   string
+		want   string
+	}{
+		{http.MethodGet, "/abc123", RouteRedirect},
+		{http.MethodGet, "/metricsabc", RouteRedirect},
+		{http.MethodGet, "/api/urls/abc123", RouteAPI},
+		{http.MethodPost, "/api/urls", RouteAPI},
+		{http.MethodGet, "/metrics", RouteAPI},
+		{http.MethodGet, "/healthz", RouteAPI},
+		{http.MethodGet, "/admin/", RouteAPI},
+		{http.MethodPost, "/internal/cache/invalidate", RouteAPI},
+		{http.MethodGet, "/api/urls", RouteBulk},
+		{http.MethodGet, "/api/admin/export", RouteBulk},
+		{http.MethodPost, "/api/admin/backup", RouteBulk},
+		{http.MethodPost, "/api/urls/delete", RouteBulk},
+		{http.MethodPost, "/api/urls/prune", RouteBulk},
+		{http.MethodGet, "/api/urls/prune", RouteAPI},
+		{http.MethodGet, "/api/events", ""},
+	}
+
+	for _, tt := range tests {
+		route, _ := config.route(httptest.NewRequest(tt.method, tt.path, nil))
+		assert.Equal(t, tt.want, route, tt.method+" "+tt.path)
+	}
+}
+
+func TestTimeoutMiddleware(t *testing.T) {
+	middleware := NewTimeoutMiddleware(TimeoutConfig{Redirect: 20 * time.Millisecond, API: time.Minute})
+
+	t.Run("fast request", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		middleware.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, ok := r.Context().Deadline()
+			assert.True(t, ok, "the request context has a deadline")
+			w.Header().Set("Location", "https://example.com")
+			w.WriteHeader(http.StatusFound)
+		})).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/abc123", nil))
+
+		assert.Equal(t, http.StatusFound, w.Code)
+		assert.Equal(t, "https://example.com", w.Header().Get("Location"))
+		assert.Zero(t, middleware.TimedOut(RouteRedirect))
+	})
+
+	t.Run("slow request is cancelled", func(t *testing.T) {
+		cancelled := make(chan error, 2)
+		w := httptest.NewRecorder()
+		middleware.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-r.Context().Done()
+			cancelled <- r.Context().Err()
+			// Writes after the deadline are discarded
+			_, err := w.Write([]byte("late"))
+			cancelled <- err
+		})).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/abc123", nil))
+
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		assert.JSONEq(t, `{"code":"unavailable","message":"Request timed out"}`, w.Body.String())
+		assert.ErrorIs(t, <-cancelled, context.DeadlineExceeded)
+		assert.ErrorIs(t, <-cancelled, http.ErrHandlerTimeout)
+		assert.NotContains(t, w.Body.String(), "late")
+		assert.Equal(t, int64(1), middleware.TimedOut(RouteRedirect))
+	})
+
+	t.Run("response started after the deadline is replaced", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		middleware.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-r.Context().Done()
+			writeError(w, http.StatusInternalServerError, "Internal server error")
+		})).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/abc123", nil))
+
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		assert.Equal(t, int64(2), middleware.TimedOut(RouteRedirect))
+	})
+
+	t.Run("started response ends with the handler", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		middleware.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte("partial"))
+			<-r.Context().Done()
+		})).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/abc123", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "partial", w.Body.String())
+		assert.Equal(t, int64(3), middleware.TimedOut(RouteRedirect))
+	})
+
+	t.Run("disabled class has no deadline", func(t *testing.T) {
+		middleware.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, ok := r.Context().Deadline()
+			assert.False(t, ok)
+		})).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/urls", nil))
+	})
+
+	t.Run("panics reach the server", func(t *testing.T) {
+		assert.PanicsWithValue(t, "boom", func() {
+			middleware.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				panic("boom")
+			})).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/version", nil))
+		})
+	})
+}
+
+func TestServer_Timeouts(t *testing.T) {
+	mockService := &mocks.URLShortener{}
+	mockService.On("GetOriginalURL", mock.Anything, "slow", mock.Anything).Return("", 0, context.DeadlineExceeded).
+		WaitUntil(time.After(50 * time.Millisecond))
+
+	server := NewServer(mockService, "8080", "http://localhost:8080", false,
+		WithTimeouts(TimeoutConfig{Redirect: 10 * time.Millisecond}))
+
+	w := httptest.NewRecorder()
+	server.server.Handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/slow", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.JSONEq(t, `{"code":"unavailable","message":"Request timed out"}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	server.server.Handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `url_shortener_request_timeouts_total{route="redirect"} 1`)
+	assert.Contains(t, w.Body.String(), `url_shortener_request_timeouts_total{route="bulk"} 0`)
+}
@@ -0,0 +1,119 @@
+package http
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"sync/atomic"
+
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// TLSConfig configures HTTPS for the server. Certificates come either from
+// files or from Let's Encrypt via ACME; with neither set, the server speaks
+// plain HTTP. HTTP/2 is negotiated automatically over TLS.
+type TLSConfig struct {
+	// CertFile and KeyFile are PEM files for a static certificate
+	CertFile string
+	KeyFile  string
+
+	// ACMEDomains enables automatic certificates for these host names
+	ACMEDomains []string
+	// ACMECacheDir stores issued certificates across restarts
+	ACMECacheDir string
+	// ACMEEmail is the optional contact address for the ACME account
+	ACMEEmail string
+
+	// RedirectPort, when set, serves plain HTTP on this port and redirects
+	// every request to HTTPS. In ACME mode it also answers HTTP-01 challenges.
+	RedirectPort string
+}
+
+// Enabled reports whether the server should serve HTTPS
+func (c TLSConfig) Enabled() bool {
+	return c.CertFile != "" || c.KeyFile != "" || len(c.ACMEDomains) > 0
+}
+
+// ACME reports whether certificates are obtained automatically
+func (c TLSConfig) ACME() bool {
+	return len(c.ACMEDomains) > 0
+}
+
+// Validate checks that exactly one certificate source is configured
+func (c TLSConfig) Validate() error {
+	if (c.CertFile == "") != (c.KeyFile == "") {
+		return fmt.Errorf("TLS certificate and key must be set together")
+	}
+	if c.CertFile != "" && c.ACME() {
+		return fmt.Errorf("TLS certificate files and ACME domains are mutually exclusive")
+	}
+	if c.ACME() && c.ACMECacheDir == "" {
+		return fmt.Errorf("ACME requires a certificate cache directory")
+	}
+	if c.RedirectPort != "" && !c.Enabled() {
+		return fmt.Errorf("HTTP to HTTPS redirect requires TLS to be enabled")
+	}
+	return nil
+}
+
+// newCertManager creates the ACME certificate manager for the configured domains
+func newCertManager(c TLSConfig) *autocert.Manager {
+	return &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		HostPolicy: autocert.HostWhitelist(c.ACMEDomains...),
+		Cache:      autocert.DirCache(c.ACMECacheDir),
+		Email:      c.ACMEEmail,
+	}
+}
+
+// certificate serves the certificate files, keeping the last good pair so
+// they can be replaced while the server runs
+type certificate struct {
+	certFile string
+	keyFile  string
+	current  atomic.Pointer[tls.Certificate]
+}
+
+// load reads the certificate files, keeping the current pair when they are invalid
+func (c *certificate) load() error {
+	pair, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load TLS certificate: %w", err)
+	}
+	c.current.Store(&pair)
+	return nil
+}
+
+// get is the tls.Config GetCertificate callback
+func (c *certificate) get(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return c.current.Load(), nil
+}
+
+// baseTLSConfig returns the server's TLS settings, with HTTP/2 preferred
+func baseTLSConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		NextProtos: []string{"h2", "http/1.1"},
+	}
+}
+
+// httpsRedirectHandler redirects plain HTTP requests to the same host and
+// path on the HTTPS port, keeping the method via 308 for non-GET requests
+func httpsRedirectHandler(httpsPort string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if httpsPort != "" && httpsPort != "443" {
+			host = net.JoinHostPort(host, httpsPort)
+		}
+
+		status := http.StatusPermanentRedirect
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			status = http.StatusMovedPermanently
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), status)
+	})
+}
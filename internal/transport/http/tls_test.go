@@ -0,0 +1,205 @@
+package http
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/joshdurbin/url-shortener/internal/service/mocks"
+)
+
+func TestTLSConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  TLSConfig
+		enabled bool
+		wantErr string
+	}{
+		{name: "disabled", config: TLSConfig{}},
+		{name: "certificate files", config: TLSConfig{CertFile: "cert.pem", KeyFile: "key.pem", RedirectPort: "80"}, enabled: true},
+		{name: "ACME", config: TLSConfig{ACMEDomains: []string{"sho.rt"}, ACMECacheDir: "certs"}, enabled: true},
+		{name: "certificate without key", config: TLSConfig{CertFile: "cert.pem"}, enabled: true, wantErr: "must be set together"},
+		{name: "files and ACME", config: TLSConfig{CertFile: "cert.pem", KeyFile: "key.pem", ACMEDomains: []string{"sho.rt"}, ACMECacheDir: "certs"}, enabled: true, wantErr: "mutually exclusive"},
+		{name: "ACME without cache", config: TLSConfig{ACMEDomains: []string{"sho.rt"}}, enabled: true, wantErr: "cache directory"},
+		{name: "redirect without TLS", config: TLSConfig{RedirectPort: "80"}, wantErr: "requires TLS"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.enabled, tt.config.Enabled())
+			err := tt.config.Validate()
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestHTTPSRedirectHandler(t *testing.T) {
+	tests := []struct {
+		name             string
+		method           string
+		target           string
+		httpsPort        string
+		expectedStatus   int
+		expectedLocation string
+	}{
+		{name: "default port", method: http.MethodGet, target: "http://sho.rt/abc123?x=1", httpsPort: "443", expectedStatus: http.StatusMovedPermanently, expectedLocation: "https://sho.rt/abc123?x=1"},
+		{name: "custom port", method: http.MethodGet, target: "http://sho.rt:8080/abc123", httpsPort: "8443", expectedStatus: http.StatusMovedPermanently, expectedLocation: "https://sho.rt:8443/abc123"},
+		{name: "POST keeps method", method: http.MethodPost, target: "http://sho.rt/api/urls", httpsPort: "443", expectedStatus: http.StatusPermanentRedirect, expectedLocation: "https://sho.rt/api/urls"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, nil)
+			w := httptest.NewRecorder()
+
+			httpsRedirectHandler(tt.httpsPort).ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Equal(t, tt.expectedLocation, w.Header().Get("Location"))
+		})
+	}
+}
+
+func TestServer_TLS(t *testing.T) {
+	certFile, keyFile := writeTestCertificate(t)
+	httpsPort, redirectPort := freePort(t), freePort(t)
+
+	server := NewServer(&mocks.URLShortener{}, httpsPort, "https://localhost:"+httpsPort, false,
+		WithTLS(TLSConfig{CertFile: certFile, KeyFile: keyFile, RedirectPort: redirectPort}))
+
+	go server.Start()
+	defer server.Shutdown(context.Background())
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig:   &tls.Config{InsecureSkipVerify: true},
+			ForceAttemptHTTP2: true,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	var resp *http.Response
+	require.Eventually(t, func() bool {
+		var err error
+		resp, err = client.Get("https://localhost:" + httpsPort + "/healthz")
+		return err == nil
+	}, 2*time.Second, 20*time.Millisecond)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "HTTP/2.0", resp.Proto)
+
+	redirect, err := client.Get("http://localhost:" + redirectPort + "/abc123")
+	require.NoError(t, err)
+	defer redirect.Body.Close()
+
+	assert.Equal(t, http.StatusMovedPermanently, redirect.StatusCode)
+	assert.Equal(t, "https://localhost:"+httpsPort+"/abc123", redirect.Header.Get("Location"))
+}
+
+// writeTestCertificate creates a self-signed certificate for localhost
+func writeTestCertificate(t *testing.T) (string, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+	return certFile, keyFile
+}
+
+// freePort returns a TCP port that was free when checked
+func freePort(t *testing.T) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	_, port, err := net.SplitHostPort(listener.Addr().String())
+	require.NoError(t, err)
+	return port
+}
+
+func TestServer_ReloadCertificate(t *testing.T) {
+	certFile, keyFile := writeTestCertificate(t)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	server := NewServer(&mocks.URLShortener{}, "0", "https://localhost", false,
+		WithTLS(TLSConfig{CertFile: certFile, KeyFile: keyFile}), WithListeners(listener))
+	assert.Equal(t, listener.Addr().String(), server.Address())
+
+	go server.Start()
+	defer server.Shutdown(context.Background())
+
+	served := func() []byte {
+		var raw []byte
+		require.Eventually(t, func() bool {
+			conn, err := tls.Dial("tcp", listener.Addr().String(), &tls.Config{InsecureSkipVerify: true})
+			if err != nil {
+				return false
+			}
+			defer conn.Close()
+			raw = conn.ConnectionState().PeerCertificates[0].Raw
+			return true
+		}, 2*time.Second, 20*time.Millisecond)
+		return raw
+	}
+	first := served()
+
+	// Invalid files keep the current certificate
+	require.NoError(t, os.WriteFile(certFile, []byte("not a certificate"), 0o600))
+	assert.Error(t, server.ReloadCertificate())
+	assert.Equal(t, first, served())
+
+	renewedCert, renewedKey := writeTestCertificate(t)
+	for from, to := range map[string]string{renewedCert: certFile, renewedKey: keyFile} {
+		data, err := os.ReadFile(from)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(to, data, 0o600))
+	}
+	require.NoError(t, server.ReloadCertificate())
+	assert.NotEqual(t, first, served())
+}
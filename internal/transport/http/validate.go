@@ -0,0 +1,31 @@
+package http
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+)
+
+// ValidateURL handles POST /api/urls/validate. It takes the body of a create
+// request and reports every reason creation would reject it, without creating
+// anything. The status is 200 either way; "valid" carries the verdict.
+func (h *Handler) ValidateURL(w http.ResponseWriter, r *http.Request) {
+	var req domain.CreateURLRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("[ERROR] Invalid JSON in validate URL request: %v", err)
+		writeError(w, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+
+	problems := append([]domain.ValidationProblem{}, h.shortener.ValidateShortURL(r.Context(), req.URL, createOptions(r, req))...)
+	for i := range problems {
+		// Match the create endpoint's message for a missing URL
+		if problems[i].Field == "url" && req.URL == "" {
+			problems[i].Message = "URL is required"
+		}
+	}
+
+	writeJSON(w, http.StatusOK, domain.ValidateURLResponse{Valid: len(problems) == 0, Problems: problems})
+}
@@ -0,0 +1,100 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	"github.com/joshdurbin/url-shortener/internal/service/mocks"
+)
+
+func TestHandler_ValidateURL(t *testing.T) {
+	tests := []struct {
+		name         string
+		body         string
+		url          string
+		opts         domain.CreateOptions
+		problems     []domain.ValidationProblem
+		expectedCode int
+		expected     domain.ValidateURLResponse
+	}{
+		{
+			name:         "valid",
+			body:         `{"url":"https://example.com","tags":["docs"]}`,
+			url:          "https://example.com",
+			opts:         domain.CreateOptions{Tags: []string{"docs"}},
+			problems:     []domain.ValidationProblem{},
+			expectedCode: http.StatusOK,
+			expected:     domain.ValidateURLResponse{Valid: true, Problems: []domain.ValidationProblem{}},
+		},
+		{
+			name: "problems",
+			body: `{"url":"ftp://example.com","max_uses":-1}`,
+			url:  "ftp://example.com",
+			opts: domain.CreateOptions{MaxUses: -1},
+			problems: []domain.ValidationProblem{
+				{Field: "url", Message: "invalid URL: only HTTP and HTTPS are supported"},
+				{Field: "max_uses", Message: "max uses cannot be negative"},
+			},
+			expectedCode: http.StatusOK,
+			expected: domain.ValidateURLResponse{Problems: []domain.ValidationProblem{
+				{Field: "url", Message: "invalid URL: only HTTP and HTTPS are supported"},
+				{Field: "max_uses", Message: "max uses cannot be negative"},
+			}},
+		},
+		{
+			name:         "missing URL",
+			body:         `{}`,
+			problems:     []domain.ValidationProblem{{Field: "url", Message: `invalid URL: parse "": empty url`}},
+			expectedCode: http.StatusOK,
+			expected:     domain.ValidateURLResponse{Problems: []domain.ValidationProblem{{Field: "url", Message: "URL is required"}}},
+		},
+		{
+			name:         "invalid JSON",
+			body:         `{`,
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shortener := &mocks.URLShortener{}
+			if tt.problems != nil {
+				shortener.On("ValidateShortURL", mock.Anything, tt.url, tt.opts).Return(tt.problems)
+			}
+			server := NewServer(shortener, "8080", "http://localhost:8080", false)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/urls/validate", bytes.NewBufferString(tt.body))
+			w := httptest.NewRecorder()
+			server.server.Handler.ServeHTTP(w, req)
+
+			require.Equal(t, tt.expectedCode, w.Code)
+			if tt.expectedCode == http.StatusOK {
+				var got domain.ValidateURLResponse
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+				assert.Equal(t, tt.expected, got)
+			}
+			shortener.AssertNotCalled(t, "CreateShortURL", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestHandler_ValidateShortCodeStillReadable(t *testing.T) {
+	shortener := &mocks.URLShortener{}
+	shortener.On("GetURLInfo", mock.Anything, "validate").Return(&domain.URLEntry{ShortCode: "validate", OriginalURL: "https://example.com"}, nil)
+	server := NewServer(shortener, "8080", "http://localhost:8080", false)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/urls/validate", nil)
+	w := httptest.NewRecorder()
+	server.server.Handler.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"short_code":"validate"`)
+}
@@ -0,0 +1,104 @@
+package http
+
+import (
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+)
+
+// VerifyURL handles POST /api/urls/{shortCode}/verify, checking that the
+// link's destination answers now and returning the stored result
+func (h *Handler) VerifyURL(w http.ResponseWriter, r *http.Request) {
+	if h.verifier == nil {
+		writeError(w, http.StatusNotImplemented, "Destination verification is not configured")
+		return
+	}
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	shortCode := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/urls/"), "/verify")
+	if shortCode == "" {
+		writeError(w, http.StatusBadRequest, "Short code is required")
+		return
+	}
+
+	if !h.authorizeOwner(w, r, shortCode) {
+		return
+	}
+
+	result, err := h.verifier.VerifyLink(r.Context(), shortCode)
+	if err != nil {
+		log.Printf("[ERROR] Failed to verify the destination of code '%s': %v", shortCode, err)
+		writeServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+// Verifications handles GET /api/admin/verifications, listing the latest
+// destination check of every checked link, or with ?status=unreachable only
+// the links whose destination did not answer
+func (h *Handler) Verifications(w http.ResponseWriter, r *http.Request) {
+	if h.verifier == nil {
+		writeError(w, http.StatusNotImplemented, "Destination verification is not configured")
+		return
+	}
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	status := r.URL.Query().Get("status")
+	if status != "" && status != "unreachable" {
+		writeError(w, http.StatusBadRequest, "status must be unreachable")
+		return
+	}
+
+	verifications, err := h.verifier.List(r.Context(), status == "unreachable")
+	if err != nil {
+		log.Printf("[ERROR] Failed to list link verifications: %v", err)
+		writeServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, verifications)
+}
+
+// writeURLList writes a link listing. With broken set only the links whose
+// latest destination check failed are written, each with that check; the
+// listing then changes without the links changing, so it is not conditional.
+func (h *Handler) writeURLList(w http.ResponseWriter, r *http.Request, entries []*domain.URLEntry, broken bool, page page) {
+	if !broken {
+		// Modified as of the whole listing, as a change anywhere can shift
+		// the page
+		writeConditionalJSON(w, r, listModified(entries, h.shortener.LastRemoval()), page.apply(w, entries))
+		return
+	}
+
+	verifications, err := h.verifier.List(r.Context(), true)
+	if err != nil {
+		log.Printf("[ERROR] Failed to list link verifications: %v", err)
+		writeServiceError(w, err)
+		return
+	}
+	failed := make(map[string]*domain.LinkVerification, len(verifications))
+	for i := range verifications {
+		failed[verifications[i].ShortCode] = &verifications[i]
+	}
+
+	result := make([]*domain.URLEntry, 0, len(failed))
+	for _, entry := range entries {
+		if verification, ok := failed[entry.ShortCode]; ok {
+			// Copied, as the entries may be shared with the cache
+			listed := *entry
+			listed.Verification = verification
+			result = append(result, &listed)
+		}
+	}
+	writeJSON(w, http.StatusOK, page.apply(w, result))
+}
@@ -0,0 +1,121 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	"github.com/joshdurbin/url-shortener/internal/reachability"
+	repoMocks "github.com/joshdurbin/url-shortener/internal/repository/mocks"
+	"github.com/joshdurbin/url-shortener/internal/service/mocks"
+)
+
+func TestHandler_Verifications(t *testing.T) {
+	links := &repoMocks.URLRepository{}
+	links.On("GetURL", mock.Anything, "abc123").Return(&domain.URLEntry{ShortCode: "abc123", OriginalURL: "http://127.0.0.1:1/admin"}, nil)
+	links.On("GetURL", mock.Anything, "nope").Return(nil, domain.ErrURLNotFound)
+	store := &repoMocks.VerificationRepository{}
+	store.On("GetLinkVerification", mock.Anything, "abc123").Return(nil, domain.ErrVerificationNotFound)
+	store.On("SetLinkVerification", mock.Anything, mock.MatchedBy(func(v domain.LinkVerification) bool {
+		return v.ShortCode == "abc123" && !v.Reachable
+	})).Return(nil).Once()
+	checkedAt := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	store.On("ListLinkVerifications", mock.Anything, true).Return([]domain.LinkVerification{
+		{ShortCode: "abc123", URL: "https://gone.example.com", StatusCode: 404, Error: "status 404", CheckedAt: checkedAt},
+	}, nil).Once()
+
+	config := reachability.DefaultConfig()
+	config.Mode = reachability.ModeFlag
+	verifier := reachability.New(config, links, store, nil)
+	server := NewServer(&mocks.URLShortener{}, "8080", "http://localhost:8080", false, WithVerifier(verifier))
+
+	serve := func(method, target string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, nil)
+		w := httptest.NewRecorder()
+		server.server.Handler.ServeHTTP(w, req)
+		return w
+	}
+
+	// Destinations on private addresses are never requested
+	w := serve(http.MethodPost, "/api/urls/abc123/verify")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"reachable":false`)
+	assert.Contains(t, w.Body.String(), "private address 127.0.0.1")
+
+	w = serve(http.MethodPost, "/api/urls/nope/verify")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = serve(http.MethodGet, "/api/urls/abc123/verify")
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+
+	w = serve(http.MethodGet, "/api/admin/verifications?status=unreachable")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `[{"short_code":"abc123","url":"https://gone.example.com","reachable":false,"status_code":404,"error":"status 404","checked_at":"2026-03-01T12:00:00Z"}]`, w.Body.String())
+
+	w = serve(http.MethodGet, "/api/admin/verifications?status=ok")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	store.AssertExpectations(t)
+}
+
+func TestHandler_VerificationsNotConfigured(t *testing.T) {
+	server := NewServer(&mocks.URLShortener{}, "8080", "http://localhost:8080", false)
+
+	for _, target := range []string{"/api/admin/verifications", "/api/urls/abc123/verify"} {
+		req := httptest.NewRequest(http.MethodPost, target, nil)
+		w := httptest.NewRecorder()
+		server.server.Handler.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusNotImplemented, w.Code, target)
+	}
+}
+
+func TestHandler_ListBrokenURLs(t *testing.T) {
+	store := &repoMocks.VerificationRepository{}
+	checkedAt := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	store.On("ListLinkVerifications", mock.Anything, true).Return([]domain.LinkVerification{
+		{ShortCode: "gone", URL: "https://gone.example.com", StatusCode: 404, Error: "status 404", CheckedAt: checkedAt},
+	}, nil)
+
+	config := reachability.DefaultConfig()
+	config.Mode = reachability.ModeFlag
+	verifier := reachability.New(config, &repoMocks.URLRepository{}, store, nil)
+
+	gone := &domain.URLEntry{ShortCode: "gone", OriginalURL: "https://gone.example.com"}
+	shortener := &mocks.URLShortener{}
+	shortener.On("GetAllURLs", mock.Anything).Return([]*domain.URLEntry{
+		{ShortCode: "fine", OriginalURL: "https://example.com"},
+		gone,
+	}, nil)
+	server := NewServer(shortener, "8080", "http://localhost:8080", false, WithVerifier(verifier))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/urls?status=broken", nil)
+	w := httptest.NewRecorder()
+	server.server.Handler.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var entries []domain.URLEntry
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &entries))
+	require.Len(t, entries, 1)
+	assert.Equal(t, "gone", entries[0].ShortCode)
+	require.NotNil(t, entries[0].Verification)
+	assert.Equal(t, 404, entries[0].Verification.StatusCode)
+	assert.Equal(t, checkedAt, entries[0].Verification.CheckedAt)
+	assert.Nil(t, gone.Verification, "listed entries are copies")
+
+	req = httptest.NewRequest(http.MethodGet, "/api/urls?status=fine", nil)
+	w = httptest.NewRecorder()
+	server.server.Handler.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// Without verification there is nothing to filter on
+	server = NewServer(shortener, "8080", "http://localhost:8080", false)
+	req = httptest.NewRequest(http.MethodGet, "/api/urls?status=broken", nil)
+	w = httptest.NewRecorder()
+	server.server.Handler.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotImplemented, w.Code)
+}
@@ -0,0 +1,156 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+)
+
+// Limits on the number of log entries (webhook deliveries, policy actions) returned per request
+const (
+	defaultLogLimit = 50
+	maxLogLimit     = 1000
+)
+
+// WebhooksHandler routes /api/webhooks requests
+func (h *Handler) WebhooksHandler(w http.ResponseWriter, r *http.Request) {
+	if h.webhooks == nil {
+		writeError(w, http.StatusNotImplemented, "Webhooks are not configured")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, h.webhooks.ListEndpoints())
+	case http.MethodPost:
+		h.CreateWebhook(w, r)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// WebhooksDetailHandler routes /api/webhooks/{id}, /api/webhooks/{id}/deliveries,
+// and /api/webhooks/deliveries requests
+func (h *Handler) WebhooksDetailHandler(w http.ResponseWriter, r *http.Request) {
+	if h.webhooks == nil {
+		writeError(w, http.StatusNotImplemented, "Webhooks are not configured")
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, "/api/webhooks/")
+	if path == "deliveries" {
+		h.ListWebhookDeliveries(w, r, 0)
+		return
+	}
+
+	idPart, deliveries := strings.CutSuffix(path, "/deliveries")
+	id, err := strconv.ParseInt(idPart, 10, 64)
+	if err != nil || id <= 0 {
+		writeError(w, http.StatusBadRequest, "Invalid webhook ID")
+		return
+	}
+
+	if deliveries {
+		h.ListWebhookDeliveries(w, r, id)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		endpoint, err := h.webhooks.GetEndpoint(id)
+		if err != nil {
+			writeServiceError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, endpoint)
+	case http.MethodDelete:
+		h.DeleteWebhook(w, r, id)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// CreateWebhook handles POST /api/webhooks
+func (h *Handler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	var req domain.CreateWebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("[ERROR] Invalid JSON in create webhook request: %v", err)
+		writeError(w, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+
+	if req.URL == "" {
+		writeError(w, http.StatusBadRequest, "URL is required")
+		return
+	}
+
+	endpoint, err := h.webhooks.CreateEndpoint(r.Context(), req)
+	if err != nil {
+		log.Printf("[ERROR] Failed to create webhook for '%s': %v", req.URL, err)
+		writeServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, endpoint)
+}
+
+// DeleteWebhook handles DELETE /api/webhooks/{id}
+func (h *Handler) DeleteWebhook(w http.ResponseWriter, r *http.Request, id int64) {
+	if err := h.webhooks.DeleteEndpoint(r.Context(), id); err != nil {
+		if errors.Is(err, domain.ErrWebhookNotFound) {
+			writeError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		log.Printf("[ERROR] Failed to delete webhook %d: %v", id, err)
+		writeError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListWebhookDeliveries handles GET /api/webhooks/deliveries and
+// GET /api/webhooks/{id}/deliveries, newest first, with an optional ?limit=
+func (h *Handler) ListWebhookDeliveries(w http.ResponseWriter, r *http.Request, id int64) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	limit := defaultLogLimit
+	if value := r.URL.Query().Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > maxLogLimit {
+			writeError(w, http.StatusBadRequest, "limit must be between 1 and "+strconv.Itoa(maxLogLimit))
+			return
+		}
+		limit = parsed
+	}
+
+	deliveries, err := h.webhooks.ListDeliveries(r.Context(), id, limit)
+	if err != nil {
+		if errors.Is(err, domain.ErrWebhookNotFound) {
+			writeError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		log.Printf("Error listing webhook deliveries: %v", err)
+		writeError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, deliveries)
+}
+
+// writeJSON writes a JSON response with the given status code
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		log.Printf("Error encoding response: %v", err)
+	}
+}
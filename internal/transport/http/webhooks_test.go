@@ -0,0 +1,193 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	repoMocks "github.com/joshdurbin/url-shortener/internal/repository/mocks"
+	"github.com/joshdurbin/url-shortener/internal/service/mocks"
+	"github.com/joshdurbin/url-shortener/internal/webhook"
+)
+
+func TestHandler_Webhooks(t *testing.T) {
+	deliveries := []*domain.WebhookDelivery{
+		{ID: 1, EndpointID: 1, EndpointURL: "https://example.com/hook", EventID: "evt_1", EventType: domain.EventURLCreated, Attempt: 1, Success: true},
+	}
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		setupMocks     func(*repoMocks.WebhookRepository)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:   "create with generated secret",
+			method: http.MethodPost,
+			path:   "/api/webhooks",
+			body:   `{"url":"https://example.com/hook","events":["url.created"]}`,
+			setupMocks: func(store *repoMocks.WebhookRepository) {
+				store.On("CreateWebhookEndpoint", mock.Anything, mock.AnythingOfType("*domain.WebhookEndpoint")).
+					Return(&domain.WebhookEndpoint{ID: 2, URL: "https://example.com/hook", Secret: "whsec_abc", Events: []domain.EventType{domain.EventURLCreated}}, nil)
+			},
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `"secret":"whsec_abc"`,
+		},
+		{
+			name:           "create with unknown event",
+			method:         http.MethodPost,
+			path:           "/api/webhooks",
+			body:           `{"url":"https://example.com/hook","events":["url.renamed"]}`,
+			setupMocks:     func(store *repoMocks.WebhookRepository) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "unknown event type",
+		},
+		{
+			name:           "create without url",
+			method:         http.MethodPost,
+			path:           "/api/webhooks",
+			body:           `{}`,
+			setupMocks:     func(store *repoMocks.WebhookRepository) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "URL is required",
+		},
+		{
+			name:           "list hides secrets",
+			method:         http.MethodGet,
+			path:           "/api/webhooks",
+			setupMocks:     func(store *repoMocks.WebhookRepository) {},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"url":"https://example.com/hook","events":[]`,
+		},
+		{
+			name:           "get",
+			method:         http.MethodGet,
+			path:           "/api/webhooks/1",
+			setupMocks:     func(store *repoMocks.WebhookRepository) {},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"id":1`,
+		},
+		{
+			name:           "get unknown",
+			method:         http.MethodGet,
+			path:           "/api/webhooks/99",
+			setupMocks:     func(store *repoMocks.WebhookRepository) {},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "invalid id",
+			method:         http.MethodGet,
+			path:           "/api/webhooks/abc",
+			setupMocks:     func(store *repoMocks.WebhookRepository) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "delete",
+			method: http.MethodDelete,
+			path:   "/api/webhooks/1",
+			setupMocks: func(store *repoMocks.WebhookRepository) {
+				store.On("DeleteWebhookEndpoint", mock.Anything, int64(1)).Return(nil)
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:   "delete unknown",
+			method: http.MethodDelete,
+			path:   "/api/webhooks/99",
+			setupMocks: func(store *repoMocks.WebhookRepository) {
+				store.On("DeleteWebhookEndpoint", mock.Anything, int64(99)).Return(domain.ErrWebhookNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:   "endpoint deliveries",
+			method: http.MethodGet,
+			path:   "/api/webhooks/1/deliveries?limit=10",
+			setupMocks: func(store *repoMocks.WebhookRepository) {
+				store.On("ListWebhookDeliveries", mock.Anything, int64(1), 10).Return(deliveries, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"event_id":"evt_1"`,
+		},
+		{
+			name:   "all deliveries",
+			method: http.MethodGet,
+			path:   "/api/webhooks/deliveries",
+			setupMocks: func(store *repoMocks.WebhookRepository) {
+				store.On("ListWebhookDeliveries", mock.Anything, int64(0), defaultLogLimit).Return(deliveries, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "deliveries for unknown endpoint",
+			method:         http.MethodGet,
+			path:           "/api/webhooks/99/deliveries",
+			setupMocks:     func(store *repoMocks.WebhookRepository) {},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "invalid limit",
+			method:         http.MethodGet,
+			path:           "/api/webhooks/deliveries?limit=0",
+			setupMocks:     func(store *repoMocks.WebhookRepository) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "method not allowed",
+			method:         http.MethodPut,
+			path:           "/api/webhooks",
+			setupMocks:     func(store *repoMocks.WebhookRepository) {},
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &repoMocks.WebhookRepository{}
+			store.On("ListWebhookEndpoints", mock.Anything).Return([]*domain.WebhookEndpoint{
+				{ID: 1, URL: "https://example.com/hook", Secret: "s3cret", Events: []domain.EventType{}},
+			}, nil)
+			tt.setupMocks(store)
+
+			config := webhook.DefaultConfig()
+			config.Retention = 0
+			dispatcher := webhook.New(config, store, nil)
+			assert.NoError(t, dispatcher.Start(context.Background()))
+			defer dispatcher.Close()
+
+			server := NewServer(&mocks.URLShortener{}, "8080", "http://localhost:8080", false, WithWebhooks(dispatcher))
+
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+			server.server.Handler.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedBody != "" {
+				assert.Contains(t, w.Body.String(), tt.expectedBody)
+			}
+			assert.NotContains(t, w.Body.String(), "s3cret")
+			store.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandler_WebhooksNotConfigured(t *testing.T) {
+	server := NewServer(&mocks.URLShortener{}, "8080", "http://localhost:8080", false)
+
+	for _, path := range []string{"/api/webhooks", "/api/webhooks/1"} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		w := httptest.NewRecorder()
+		server.server.Handler.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotImplemented, w.Code)
+	}
+}
@@ -0,0 +1,40 @@
+// Package version reports build metadata injected at link time, e.g.
+//
+//	go build -ldflags "-X github.com/joshdurbin/url-shortener/internal/version.Version=v1.2.0 \
+//	  -X github.com/joshdurbin/url-shortener/internal/version.Commit=$(git rev-parse --short HEAD) \
+//	  -X github.com/joshdurbin/url-shortener/internal/version.BuildDate=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+package version
+
+import (
+	"fmt"
+	"runtime"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+)
+
+// Build metadata, overridden with -ldflags "-X ..."
+var (
+	Version   = "dev"
+	Commit    = "unknown"
+	BuildDate = "unknown"
+)
+
+// String returns a one-line description of the build
+func String() string {
+	return fmt.Sprintf("%s (commit %s, built %s, %s)", Version, Commit, BuildDate, runtime.Version())
+}
+
+// UserAgent returns the User-Agent sent by the client
+func UserAgent() string {
+	return "url-shortener/" + Version
+}
+
+// Info returns the build metadata; callers fill in the server configuration fields
+func Info() domain.VersionResponse {
+	return domain.VersionResponse{
+		Version:   Version,
+		Commit:    Commit,
+		BuildDate: BuildDate,
+		GoVersion: runtime.Version(),
+	}
+}
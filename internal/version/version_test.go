@@ -0,0 +1,23 @@
+package version
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInfo(t *testing.T) {
+	original := Version
+	Version = "v1.2.0"
+	defer func() { Version = original }()
+
+	info := Info()
+	assert.Equal(t, "v1.2.0", info.Version)
+	assert.Equal(t, "unknown", info.Commit)
+	assert.Equal(t, runtime.Version(), info.GoVersion)
+	assert.Empty(t, info.Storage)
+
+	assert.Equal(t, "url-shortener/v1.2.0", UserAgent())
+	assert.Contains(t, String(), "v1.2.0 (commit unknown")
+}
@@ -0,0 +1,105 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+)
+
+// Config holds webhook delivery configuration
+type Config struct {
+	EndpointsFile   string        // Optional JSON file of endpoints to deliver to alongside stored ones
+	Timeout         time.Duration // Timeout for a single delivery attempt
+	MaxAttempts     int           // Attempts per delivery before giving up
+	InitialBackoff  time.Duration // Wait before the first retry; doubles per attempt
+	MaxBackoff      time.Duration // Upper bound on the wait between retries
+	ClickSampleRate float64       // Fraction of url.clicked events delivered, from 0 to 1
+	ClickRateLimit  float64       // Delivered url.clicked events per second before sampling tightens; 0 disables
+	QueueSize       int           // Pending deliveries buffered before events are dropped
+	Workers         int           // Concurrent deliveries
+	Retention       time.Duration // How long delivery log entries are kept; 0 keeps them forever
+}
+
+// DefaultConfig returns the default configuration
+func DefaultConfig() Config {
+	return Config{
+		Timeout:         5 * time.Second,
+		MaxAttempts:     5,
+		InitialBackoff:  time.Second,
+		MaxBackoff:      time.Minute,
+		ClickSampleRate: 0.1,
+		ClickRateLimit:  50,
+		QueueSize:       1000,
+		Workers:         4,
+		Retention:       7 * 24 * time.Hour,
+	}
+}
+
+// Validate checks that the configuration values are usable
+func (c Config) Validate() error {
+	if c.Timeout <= 0 {
+		return fmt.Errorf("timeout must be positive, got: %v", c.Timeout)
+	}
+	if c.MaxAttempts < 1 {
+		return fmt.Errorf("max attempts must be at least 1, got: %d", c.MaxAttempts)
+	}
+	if c.InitialBackoff < 0 || c.MaxBackoff < c.InitialBackoff {
+		return fmt.Errorf("backoff must satisfy 0 <= initial (%v) <= max (%v)", c.InitialBackoff, c.MaxBackoff)
+	}
+	if c.ClickSampleRate < 0 || c.ClickSampleRate > 1 {
+		return fmt.Errorf("click sample rate must be between 0 and 1, got: %v", c.ClickSampleRate)
+	}
+	if c.ClickRateLimit < 0 {
+		return fmt.Errorf("click rate limit cannot be negative, got: %v", c.ClickRateLimit)
+	}
+	if c.QueueSize < 1 {
+		return fmt.Errorf("queue size must be at least 1, got: %d", c.QueueSize)
+	}
+	if c.Workers < 1 {
+		return fmt.Errorf("workers must be at least 1, got: %d", c.Workers)
+	}
+	if c.Retention < 0 {
+		return fmt.Errorf("retention cannot be negative, got: %v", c.Retention)
+	}
+	return nil
+}
+
+// endpointsFile is the layout of the endpoints config file
+type endpointsFile struct {
+	Endpoints []domain.CreateWebhookRequest `json:"endpoints"`
+}
+
+// LoadEndpoints reads endpoints from a JSON config file of the form
+// {"endpoints": [{"url": "...", "secret": "...", "events": ["url.created"]}]}.
+// File endpoints must set a secret because it is never shown by the API.
+func LoadEndpoints(path string) ([]*domain.WebhookEndpoint, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read webhook endpoints file: %w", err)
+	}
+
+	var file endpointsFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse webhook endpoints file: %w", err)
+	}
+
+	endpoints := make([]*domain.WebhookEndpoint, 0, len(file.Endpoints))
+	for i, req := range file.Endpoints {
+		if req.Secret == "" {
+			return nil, fmt.Errorf("webhook endpoint %d: secret is required", i+1)
+		}
+		if err := validateRequest(req); err != nil {
+			return nil, fmt.Errorf("webhook endpoint %d: %w", i+1, err)
+		}
+		endpoints = append(endpoints, &domain.WebhookEndpoint{
+			URL:    req.URL,
+			Secret: req.Secret,
+			Events: req.Events,
+		})
+	}
+
+	return endpoints, nil
+}
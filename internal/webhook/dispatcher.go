@@ -0,0 +1,480 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	mathrand "math/rand/v2"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	"github.com/joshdurbin/url-shortener/internal/drain"
+	"github.com/joshdurbin/url-shortener/internal/repository"
+)
+
+// pruneInterval is how often expired delivery log entries are removed
+const pruneInterval = time.Hour
+
+// delivery is one event queued for one endpoint
+type delivery struct {
+	endpoint *domain.WebhookEndpoint
+	event    domain.Event
+	body     []byte
+}
+
+// Dispatcher delivers link lifecycle events to webhook endpoints. Events are
+// queued without blocking the caller and delivered by a pool of workers that
+// sign each request, retry failures with exponential backoff, and record every
+// attempt in the delivery log.
+type Dispatcher struct {
+	config Config
+	store  repository.WebhookRepository
+	client *http.Client
+	static []*domain.WebhookEndpoint // Endpoints from the config file
+
+	mutex    sync.RWMutex
+	stored   []*domain.WebhookEndpoint // Cached copy of the stored endpoints
+	started  bool
+	closed   bool
+	queue    chan delivery
+	stopChan chan struct{}
+	stopped  context.Context // Done once the dispatcher is closed
+	stop     context.CancelFunc
+	wg       sync.WaitGroup
+	gate     drain.Gate // Holds writes to the delivery log while the server is drained
+
+	sampler *clickSampler
+	random  func() float64   // Source for click sampling
+	now     func() time.Time // Clock for measuring the click rate
+}
+
+// New creates a dispatcher. Static endpoints, typically from LoadEndpoints,
+// receive events alongside the endpoints managed through the API.
+func New(config Config, store repository.WebhookRepository, static []*domain.WebhookEndpoint) *Dispatcher {
+	stopped, stop := context.WithCancel(context.Background())
+	return &Dispatcher{
+		config:   config,
+		store:    store,
+		client:   &http.Client{Timeout: config.Timeout},
+		static:   static,
+		queue:    make(chan delivery, config.QueueSize),
+		stopChan: make(chan struct{}),
+		stopped:  stopped,
+		stop:     stop,
+		sampler:  newClickSampler(config.ClickSampleRate, config.ClickRateLimit),
+		random:   mathrand.Float64,
+		now:      time.Now,
+	}
+}
+
+// Start loads the stored endpoints and starts the delivery workers
+func (d *Dispatcher) Start(ctx context.Context) error {
+	endpoints, err := d.store.ListWebhookEndpoints(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load webhook endpoints: %w", err)
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if d.started {
+		return fmt.Errorf("webhook dispatcher already started")
+	}
+	d.started = true
+	d.stored = endpoints
+
+	for i := 0; i < d.config.Workers; i++ {
+		d.wg.Add(1)
+		go d.worker()
+	}
+
+	if d.config.Retention > 0 {
+		d.wg.Add(1)
+		go d.pruneLoop()
+	}
+
+	return nil
+}
+
+// Close stops the workers. Deliveries still queued or waiting to retry are dropped.
+func (d *Dispatcher) Close() error {
+	d.mutex.Lock()
+	if d.closed {
+		d.mutex.Unlock()
+		return nil
+	}
+	d.closed = true
+	close(d.stopChan)
+	d.stop()
+	d.mutex.Unlock()
+
+	d.wg.Wait()
+
+	if pending := len(d.queue); pending > 0 {
+		log.Printf("Webhook dispatcher stopped with %d undelivered events", pending)
+	}
+	return nil
+}
+
+// Pause waits for delivery attempts being logged and holds the next ones, and
+// pruning, until Resume, so the delivery log stays put during a drain. Events
+// are still delivered meanwhile; each worker waits to log its attempt, so
+// deliveries queue up behind it.
+func (d *Dispatcher) Pause(ctx context.Context) error {
+	if d == nil {
+		return nil
+	}
+	return d.gate.Pause(ctx)
+}
+
+// Resume lets delivery attempts be logged again
+func (d *Dispatcher) Resume() {
+	if d == nil {
+		return
+	}
+	d.gate.Resume()
+}
+
+// Notify queues an event for every subscribed endpoint without blocking.
+// url.clicked events are sampled, more sparsely under heavy traffic, and
+// events are dropped when the queue is full so redirects never wait on webhooks.
+func (d *Dispatcher) Notify(event domain.Event) {
+	if event.Type == domain.EventURLClicked {
+		rate := d.sampler.rate(d.now())
+		if rate < 1 && d.random() >= rate {
+			return
+		}
+		event.SampleRate = rate
+	}
+
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	if !d.started || d.closed {
+		return
+	}
+
+	var body []byte
+	for _, endpoints := range [][]*domain.WebhookEndpoint{d.static, d.stored} {
+		for _, endpoint := range endpoints {
+			if !endpoint.Subscribes(event.Type) {
+				continue
+			}
+
+			if body == nil {
+				encoded, err := json.Marshal(event)
+				if err != nil {
+					log.Printf("Failed to encode webhook event %s: %v", event.ID, err)
+					return
+				}
+				body = encoded
+			}
+
+			select {
+			case d.queue <- delivery{endpoint: endpoint, event: event, body: body}:
+			default:
+				log.Printf("Webhook queue full, dropping %s event %s for %s", event.Type, event.ID, endpoint.URL)
+			}
+		}
+	}
+}
+
+// CreateEndpoint validates and stores a new endpoint, generating a secret when
+// none is given. The returned endpoint is the only place the secret is shown.
+func (d *Dispatcher) CreateEndpoint(ctx context.Context, req domain.CreateWebhookRequest) (*domain.WebhookEndpoint, error) {
+	if err := validateRequest(req); err != nil {
+		return nil, domain.Invalid("", err)
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		generated, err := generateSecret()
+		if err != nil {
+			return nil, err
+		}
+		secret = generated
+	}
+
+	endpoint, err := d.store.CreateWebhookEndpoint(ctx, &domain.WebhookEndpoint{
+		URL:       req.URL,
+		Secret:    secret,
+		Events:    req.Events,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	d.mutex.Lock()
+	d.stored = append(d.stored, endpoint)
+	d.mutex.Unlock()
+
+	created := *endpoint
+	return &created, nil
+}
+
+// ListEndpoints returns the stored endpoints with their secrets removed
+func (d *Dispatcher) ListEndpoints() []*domain.WebhookEndpoint {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	endpoints := make([]*domain.WebhookEndpoint, len(d.stored))
+	for i, endpoint := range d.stored {
+		endpoints[i] = redact(endpoint)
+	}
+	return endpoints
+}
+
+// GetEndpoint returns a stored endpoint with its secret removed
+func (d *Dispatcher) GetEndpoint(id int64) (*domain.WebhookEndpoint, error) {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	for _, endpoint := range d.stored {
+		if endpoint.ID == id {
+			return redact(endpoint), nil
+		}
+	}
+	return nil, domain.ErrWebhookNotFound
+}
+
+// DeleteEndpoint removes a stored endpoint. Deliveries still queued for it are dropped.
+func (d *Dispatcher) DeleteEndpoint(ctx context.Context, id int64) error {
+	if err := d.store.DeleteWebhookEndpoint(ctx, id); err != nil {
+		return err
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	remaining := make([]*domain.WebhookEndpoint, 0, len(d.stored))
+	for _, endpoint := range d.stored {
+		if endpoint.ID != id {
+			remaining = append(remaining, endpoint)
+		}
+	}
+	d.stored = remaining
+	return nil
+}
+
+// ListDeliveries returns the most recent delivery attempts, optionally for one endpoint (0 for all)
+func (d *Dispatcher) ListDeliveries(ctx context.Context, endpointID int64, limit int) ([]*domain.WebhookDelivery, error) {
+	if endpointID > 0 {
+		if _, err := d.GetEndpoint(endpointID); err != nil {
+			return nil, err
+		}
+	}
+	return d.store.ListWebhookDeliveries(ctx, endpointID, limit)
+}
+
+// worker delivers queued events until the dispatcher is closed
+func (d *Dispatcher) worker() {
+	defer d.wg.Done()
+
+	for {
+		select {
+		case <-d.stopChan:
+			return
+		case job := <-d.queue:
+			d.deliver(job)
+		}
+	}
+}
+
+// deliver sends one event to one endpoint, retrying retryable failures
+func (d *Dispatcher) deliver(job delivery) {
+	for attempt := 1; attempt <= d.config.MaxAttempts; attempt++ {
+		if !d.active(job.endpoint) {
+			return
+		}
+
+		statusCode, duration, err := d.send(job)
+		success := err == nil && statusCode >= 200 && statusCode < 300
+
+		record := &domain.WebhookDelivery{
+			EndpointID:  job.endpoint.ID,
+			EndpointURL: job.endpoint.URL,
+			EventID:     job.event.ID,
+			EventType:   job.event.Type,
+			Attempt:     attempt,
+			StatusCode:  statusCode,
+			Success:     success,
+			DurationMs:  duration.Milliseconds(),
+			CreatedAt:   time.Now(),
+		}
+		if err != nil {
+			record.Error = err.Error()
+		} else if !success {
+			record.Error = http.StatusText(statusCode)
+		}
+		d.record(record)
+
+		if success || !retryable(statusCode, err) {
+			return
+		}
+		if attempt == d.config.MaxAttempts {
+			log.Printf("Giving up on %s event %s for %s after %d attempts", job.event.Type, job.event.ID, job.endpoint.URL, attempt)
+			return
+		}
+
+		select {
+		case <-d.stopChan:
+			return
+		case <-time.After(d.backoff(attempt)):
+		}
+	}
+}
+
+// active reports whether an endpoint still exists; static endpoints always do
+func (d *Dispatcher) active(endpoint *domain.WebhookEndpoint) bool {
+	if endpoint.ID == 0 {
+		return true
+	}
+
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	for _, stored := range d.stored {
+		if stored.ID == endpoint.ID {
+			return true
+		}
+	}
+	return false
+}
+
+// send makes a single signed delivery attempt
+func (d *Dispatcher) send(job delivery) (int, time.Duration, error) {
+	timestamp := time.Now().Unix()
+
+	req, err := http.NewRequest(http.MethodPost, job.endpoint.URL, bytes.NewReader(job.body))
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "url-shortener-webhooks")
+	req.Header.Set(HeaderEvent, string(job.event.Type))
+	req.Header.Set(HeaderID, job.event.ID)
+	req.Header.Set(HeaderTimestamp, fmt.Sprintf("%d", timestamp))
+	req.Header.Set(HeaderSignature, Sign(job.endpoint.Secret, timestamp, job.body))
+
+	start := time.Now()
+	resp, err := d.client.Do(req)
+	duration := time.Since(start)
+	if err != nil {
+		return 0, duration, err
+	}
+	defer resp.Body.Close()
+
+	// Drain a bounded amount so the connection can be reused
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	return resp.StatusCode, duration, nil
+}
+
+// record writes a delivery attempt to the log, waiting while the dispatcher
+// is paused; attempts are not logged once it is closed
+func (d *Dispatcher) record(record *domain.WebhookDelivery) {
+	if d.gate.Enter(d.stopped) != nil {
+		return
+	}
+	defer d.gate.Leave()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := d.store.RecordWebhookDelivery(ctx, record); err != nil {
+		log.Printf("Failed to record webhook delivery for event %s: %v", record.EventID, err)
+	}
+}
+
+// backoff returns the wait before the retry that follows the given attempt
+func (d *Dispatcher) backoff(attempt int) time.Duration {
+	wait := d.config.InitialBackoff
+	for i := 1; i < attempt && wait < d.config.MaxBackoff; i++ {
+		wait *= 2
+	}
+	if wait > d.config.MaxBackoff {
+		wait = d.config.MaxBackoff
+	}
+	return wait
+}
+
+// pruneLoop periodically removes delivery log entries older than the retention period
+func (d *Dispatcher) pruneLoop() {
+	defer d.wg.Done()
+
+	ticker := time.NewTicker(pruneInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-d.stopChan:
+			return
+		case <-ticker.C:
+			if !d.gate.TryEnter() {
+				continue
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			pruned, err := d.store.PruneWebhookDeliveries(ctx, time.Now().Add(-d.config.Retention))
+			cancel()
+			d.gate.Leave()
+			if err != nil {
+				log.Printf("Error pruning webhook deliveries: %v", err)
+			} else if pruned > 0 {
+				log.Printf("Pruned %d webhook deliveries", pruned)
+			}
+		}
+	}
+}
+
+// retryable reports whether a failed attempt may succeed later. Network
+// errors, rate limiting, and server errors are retried; other client errors
+// mean the endpoint rejected the event and are not.
+func retryable(statusCode int, err error) bool {
+	if err != nil {
+		return true
+	}
+	return statusCode == http.StatusTooManyRequests || statusCode >= 500
+}
+
+// validateRequest checks an endpoint's URL and event subscriptions
+func validateRequest(req domain.CreateWebhookRequest) error {
+	parsedURL, err := url.ParseRequestURI(req.URL)
+	if err != nil {
+		return fmt.Errorf("invalid webhook URL: %w", err)
+	}
+	if parsedURL.Scheme != "http" && parsedURL.Scheme != "https" {
+		return fmt.Errorf("invalid webhook URL: only HTTP and HTTPS are supported")
+	}
+
+	for _, eventType := range req.Events {
+		if !eventType.Valid() {
+			return fmt.Errorf("unknown event type %q", eventType)
+		}
+	}
+	return nil
+}
+
+// generateSecret creates a random signing secret
+func generateSecret() (string, error) {
+	secret := make([]byte, 24)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+	return "whsec_" + hex.EncodeToString(secret), nil
+}
+
+// redact returns a copy of an endpoint without its secret
+func redact(endpoint *domain.WebhookEndpoint) *domain.WebhookEndpoint {
+	redacted := *endpoint
+	redacted.Secret = ""
+	return &redacted
+}
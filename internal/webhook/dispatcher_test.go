@@ -0,0 +1,375 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	"github.com/joshdurbin/url-shortener/internal/repository/mocks"
+)
+
+// testConfig returns a configuration with fast retries for tests
+func testConfig() Config {
+	config := DefaultConfig()
+	config.InitialBackoff = time.Millisecond
+	config.MaxBackoff = 5 * time.Millisecond
+	config.ClickSampleRate = 1
+	config.Retention = 0
+	return config
+}
+
+// recordDeliveries captures delivery log entries on a channel
+func recordDeliveries(store *mocks.WebhookRepository) chan *domain.WebhookDelivery {
+	records := make(chan *domain.WebhookDelivery, 100)
+	store.On("RecordWebhookDelivery", mock.Anything, mock.AnythingOfType("*domain.WebhookDelivery")).
+		Run(func(args mock.Arguments) {
+			records <- args.Get(1).(*domain.WebhookDelivery)
+		}).
+		Return(nil)
+	return records
+}
+
+// nextDelivery waits for the next delivery log entry
+func nextDelivery(t *testing.T, records chan *domain.WebhookDelivery) *domain.WebhookDelivery {
+	t.Helper()
+	select {
+	case record := <-records:
+		return record
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for webhook delivery")
+		return nil
+	}
+}
+
+func TestDispatcher_DeliversSignedEvents(t *testing.T) {
+	type received struct {
+		header http.Header
+		body   []byte
+	}
+	requests := make(chan received, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests <- received{header: r.Header, body: body}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	store := &mocks.WebhookRepository{}
+	store.On("ListWebhookEndpoints", mock.Anything).Return([]*domain.WebhookEndpoint{
+		{ID: 7, URL: server.URL, Secret: "s3cret", Events: []domain.EventType{domain.EventURLCreated}},
+	}, nil)
+	records := recordDeliveries(store)
+
+	dispatcher := New(testConfig(), store, nil)
+	require.NoError(t, dispatcher.Start(context.Background()))
+	defer dispatcher.Close()
+
+	// Not subscribed, so only the created event is delivered
+	dispatcher.Notify(domain.NewEvent(domain.EventURLDeleted, domain.EventData{ShortCode: "abc123"}))
+	event := domain.NewEvent(domain.EventURLCreated, domain.EventData{ShortCode: "abc123", OriginalURL: "https://example.com"})
+	dispatcher.Notify(event)
+
+	req := <-requests
+	assert.Equal(t, string(domain.EventURLCreated), req.header.Get(HeaderEvent))
+	assert.Equal(t, event.ID, req.header.Get(HeaderID))
+
+	timestamp, err := strconv.ParseInt(req.header.Get(HeaderTimestamp), 10, 64)
+	require.NoError(t, err)
+	assert.True(t, Verify("s3cret", req.header.Get(HeaderSignature), timestamp, req.body))
+	assert.False(t, Verify("wrong", req.header.Get(HeaderSignature), timestamp, req.body))
+
+	var decoded domain.Event
+	require.NoError(t, json.Unmarshal(req.body, &decoded))
+	assert.Equal(t, event.ID, decoded.ID)
+	assert.Equal(t, "abc123", decoded.Data.ShortCode)
+
+	record := nextDelivery(t, records)
+	assert.Equal(t, int64(7), record.EndpointID)
+	assert.Equal(t, event.ID, record.EventID)
+	assert.Equal(t, 1, record.Attempt)
+	assert.Equal(t, http.StatusNoContent, record.StatusCode)
+	assert.True(t, record.Success)
+}
+
+func TestDispatcher_PauseHoldsTheDeliveryLog(t *testing.T) {
+	requests := make(chan struct{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests <- struct{}{}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	store := &mocks.WebhookRepository{}
+	store.On("ListWebhookEndpoints", mock.Anything).Return([]*domain.WebhookEndpoint{
+		{ID: 7, URL: server.URL, Events: []domain.EventType{domain.EventURLCreated}},
+	}, nil)
+	records := recordDeliveries(store)
+
+	dispatcher := New(testConfig(), store, nil)
+	require.NoError(t, dispatcher.Start(context.Background()))
+	defer dispatcher.Close()
+
+	// Paused, the event is delivered but its attempt waits to be logged
+	require.NoError(t, dispatcher.Pause(context.Background()))
+	dispatcher.Notify(domain.NewEvent(domain.EventURLCreated, domain.EventData{ShortCode: "abc123"}))
+	select {
+	case <-requests:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the event was not delivered while paused")
+	}
+	select {
+	case <-records:
+		t.Fatal("the attempt was logged while paused")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	dispatcher.Resume()
+	assert.True(t, nextDelivery(t, records).Success)
+}
+
+func TestDispatcher_RetriesWithBackoff(t *testing.T) {
+	tests := []struct {
+		name         string
+		statuses     []int
+		wantAttempts int
+		wantSuccess  bool
+	}{
+		{"recovers after server errors", []int{500, 503, 200}, 3, true},
+		{"gives up after max attempts", []int{500, 500, 500, 500, 500}, 3, false},
+		{"retries rate limiting", []int{429, 200}, 2, true},
+		{"does not retry client errors", []int{400}, 1, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				call := int(calls.Add(1)) - 1
+				w.WriteHeader(tt.statuses[min(call, len(tt.statuses)-1)])
+			}))
+			defer server.Close()
+
+			store := &mocks.WebhookRepository{}
+			store.On("ListWebhookEndpoints", mock.Anything).Return([]*domain.WebhookEndpoint{}, nil)
+			records := recordDeliveries(store)
+
+			config := testConfig()
+			config.MaxAttempts = 3
+			static := []*domain.WebhookEndpoint{{URL: server.URL, Secret: "s3cret"}}
+			dispatcher := New(config, store, static)
+			require.NoError(t, dispatcher.Start(context.Background()))
+			defer dispatcher.Close()
+
+			dispatcher.Notify(domain.NewEvent(domain.EventURLCreated, domain.EventData{ShortCode: "abc123"}))
+
+			var last *domain.WebhookDelivery
+			for attempt := 1; attempt <= tt.wantAttempts; attempt++ {
+				last = nextDelivery(t, records)
+				assert.Equal(t, attempt, last.Attempt)
+				assert.Zero(t, last.EndpointID)
+			}
+			assert.Equal(t, tt.wantSuccess, last.Success)
+			if !tt.wantSuccess {
+				assert.NotEmpty(t, last.Error)
+			}
+
+			// No further attempts are made
+			select {
+			case extra := <-records:
+				t.Fatalf("unexpected extra attempt %d", extra.Attempt)
+			case <-time.After(50 * time.Millisecond):
+			}
+		})
+	}
+}
+
+func TestDispatcher_ClickSampling(t *testing.T) {
+	config := testConfig()
+	config.ClickSampleRate = 0.25
+	static := []*domain.WebhookEndpoint{{URL: "https://example.com/hook", Secret: "s3cret"}}
+	dispatcher := New(config, &mocks.WebhookRepository{}, static)
+
+	// Mark started without running workers so queued deliveries stay put
+	dispatcher.started = true
+	samples := []float64{0.1, 0.3, 0.2, 0.9}
+	dispatcher.random = func() float64 {
+		sample := samples[0]
+		samples = samples[1:]
+		return sample
+	}
+	for i := 0; i < 4; i++ {
+		dispatcher.Notify(domain.NewEvent(domain.EventURLClicked, domain.EventData{ShortCode: "abc123"}))
+	}
+	// Lifecycle events are never sampled
+	dispatcher.Notify(domain.NewEvent(domain.EventURLCreated, domain.EventData{ShortCode: "abc123"}))
+
+	assert.Equal(t, 3, len(dispatcher.queue))
+	for len(dispatcher.queue) > 0 {
+		queued := <-dispatcher.queue
+		if queued.event.Type == domain.EventURLClicked {
+			assert.Equal(t, 0.25, queued.event.SampleRate)
+		}
+	}
+}
+
+func TestDispatcher_AdaptiveClickSampling(t *testing.T) {
+	config := testConfig()
+	config.ClickRateLimit = 10
+	static := []*domain.WebhookEndpoint{{URL: "https://example.com/hook", Secret: "s3cret", Events: []domain.EventType{domain.EventURLClicked}}}
+	dispatcher := New(config, &mocks.WebhookRepository{}, static)
+	dispatcher.started = true
+
+	now := time.Unix(1700000000, 0)
+	dispatcher.now = func() time.Time { return now }
+	dispatcher.random = func() float64 { return 0.05 }
+	click := domain.NewEvent(domain.EventURLClicked, domain.EventData{ShortCode: "abc123"})
+
+	// The first window is delivered in full while the rate is measured
+	for i := 0; i < 100; i++ {
+		dispatcher.Notify(click)
+	}
+	assert.Equal(t, 100, len(dispatcher.queue))
+	for len(dispatcher.queue) > 0 {
+		<-dispatcher.queue
+	}
+
+	// 100 clicks/s against a limit of 10/s keeps one click in ten
+	now = now.Add(time.Second)
+	dispatcher.Notify(click)
+	queued := <-dispatcher.queue
+	assert.InDelta(t, 0.1, queued.event.SampleRate, 1e-9)
+
+	dispatcher.random = func() float64 { return 0.5 }
+	dispatcher.Notify(click)
+	assert.Equal(t, 0, len(dispatcher.queue))
+
+	// Once traffic falls below the limit every click is delivered again
+	now = now.Add(time.Second)
+	dispatcher.Notify(click)
+	queued = <-dispatcher.queue
+	assert.Equal(t, 1.0, queued.event.SampleRate)
+}
+
+func TestDispatcher_Endpoints(t *testing.T) {
+	ctx := context.Background()
+	store := &mocks.WebhookRepository{}
+	store.On("ListWebhookEndpoints", ctx).Return([]*domain.WebhookEndpoint{}, nil)
+	store.On("CreateWebhookEndpoint", ctx, mock.MatchedBy(func(endpoint *domain.WebhookEndpoint) bool {
+		return endpoint.URL == "https://example.com/hook" && len(endpoint.Secret) > 0
+	})).Return(&domain.WebhookEndpoint{ID: 1, URL: "https://example.com/hook", Secret: "generated"}, nil)
+	store.On("DeleteWebhookEndpoint", ctx, int64(1)).Return(nil)
+	store.On("DeleteWebhookEndpoint", ctx, int64(2)).Return(domain.ErrWebhookNotFound)
+
+	dispatcher := New(testConfig(), store, nil)
+	require.NoError(t, dispatcher.Start(ctx))
+	defer dispatcher.Close()
+
+	_, err := dispatcher.CreateEndpoint(ctx, domain.CreateWebhookRequest{URL: "ftp://example.com"})
+	assert.Error(t, err)
+	_, err = dispatcher.CreateEndpoint(ctx, domain.CreateWebhookRequest{
+		URL:    "https://example.com/hook",
+		Events: []domain.EventType{"url.renamed"},
+	})
+	assert.ErrorContains(t, err, "unknown event type")
+
+	created, err := dispatcher.CreateEndpoint(ctx, domain.CreateWebhookRequest{URL: "https://example.com/hook"})
+	require.NoError(t, err)
+	assert.Equal(t, "generated", created.Secret)
+
+	// Secrets are only shown at creation
+	endpoints := dispatcher.ListEndpoints()
+	require.Len(t, endpoints, 1)
+	assert.Empty(t, endpoints[0].Secret)
+	endpoint, err := dispatcher.GetEndpoint(1)
+	require.NoError(t, err)
+	assert.Empty(t, endpoint.Secret)
+
+	require.NoError(t, dispatcher.DeleteEndpoint(ctx, 1))
+	assert.Empty(t, dispatcher.ListEndpoints())
+	_, err = dispatcher.GetEndpoint(1)
+	assert.ErrorIs(t, err, domain.ErrWebhookNotFound)
+	assert.ErrorIs(t, dispatcher.DeleteEndpoint(ctx, 2), domain.ErrWebhookNotFound)
+
+	store.AssertExpectations(t)
+}
+
+func TestDispatcher_Backoff(t *testing.T) {
+	config := DefaultConfig()
+	config.InitialBackoff = time.Second
+	config.MaxBackoff = 5 * time.Second
+	dispatcher := New(config, nil, nil)
+
+	assert.Equal(t, time.Second, dispatcher.backoff(1))
+	assert.Equal(t, 2*time.Second, dispatcher.backoff(2))
+	assert.Equal(t, 4*time.Second, dispatcher.backoff(3))
+	assert.Equal(t, 5*time.Second, dispatcher.backoff(4))
+	assert.Equal(t, 5*time.Second, dispatcher.backoff(10))
+}
+
+func TestConfig_Validate(t *testing.T) {
+	assert.NoError(t, DefaultConfig().Validate())
+
+	tests := []struct {
+		name   string
+		modify func(*Config)
+	}{
+		{"zero timeout", func(c *Config) { c.Timeout = 0 }},
+		{"zero attempts", func(c *Config) { c.MaxAttempts = 0 }},
+		{"max backoff below initial", func(c *Config) { c.MaxBackoff = c.InitialBackoff / 2 }},
+		{"sample rate above one", func(c *Config) { c.ClickSampleRate = 1.5 }},
+		{"negative click rate limit", func(c *Config) { c.ClickRateLimit = -1 }},
+		{"zero queue", func(c *Config) { c.QueueSize = 0 }},
+		{"zero workers", func(c *Config) { c.Workers = 0 }},
+		{"negative retention", func(c *Config) { c.Retention = -time.Hour }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultConfig()
+			tt.modify(&config)
+			assert.Error(t, config.Validate())
+		})
+	}
+}
+
+func TestLoadEndpoints(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		return path
+	}
+
+	endpoints, err := LoadEndpoints(write("valid.json", `{"endpoints": [
+		{"url": "https://example.com/hook", "secret": "s3cret", "events": ["url.created", "url.expired"]},
+		{"url": "http://localhost:9000/all", "secret": "other"}
+	]}`))
+	require.NoError(t, err)
+	require.Len(t, endpoints, 2)
+	assert.Equal(t, "https://example.com/hook", endpoints[0].URL)
+	assert.True(t, endpoints[0].Subscribes(domain.EventURLExpired))
+	assert.False(t, endpoints[0].Subscribes(domain.EventURLClicked))
+	assert.True(t, endpoints[1].Subscribes(domain.EventURLClicked))
+
+	_, err = LoadEndpoints(write("nosecret.json", `{"endpoints": [{"url": "https://example.com/hook"}]}`))
+	assert.ErrorContains(t, err, "secret is required")
+
+	_, err = LoadEndpoints(write("badevent.json", `{"endpoints": [{"url": "https://example.com/hook", "secret": "s", "events": ["nope"]}]}`))
+	assert.ErrorContains(t, err, "unknown event type")
+
+	_, err = LoadEndpoints(filepath.Join(dir, "missing.json"))
+	assert.Error(t, err)
+}
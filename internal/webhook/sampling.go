@@ -0,0 +1,67 @@
+package webhook
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+// rateWindow is how long click traffic is counted before the rate is updated
+const rateWindow = time.Second
+
+// clickSampler decides which url.clicked events are delivered. At normal load
+// it keeps the configured fraction of clicks; once the observed click rate
+// would push more than ClickRateLimit deliveries per second, the fraction
+// shrinks so delivered clicks stay near that limit. Usage counts are tracked
+// by the cache and are always exact, so only the click details are sampled.
+type clickSampler struct {
+	baseRate  float64 // Configured fraction of clicks delivered
+	rateLimit float64 // Delivered clicks per second before sampling tightens; 0 disables
+
+	mutex       sync.Mutex
+	windowStart time.Time
+	count       int     // Clicks seen in the current window
+	observed    float64 // Clicks per second over the last complete window
+	adaptive    bool    // Whether the last window exceeded the limit
+}
+
+// newClickSampler creates a sampler for the configured rates
+func newClickSampler(baseRate, rateLimit float64) *clickSampler {
+	return &clickSampler{baseRate: baseRate, rateLimit: rateLimit}
+}
+
+// rate records a click seen at now and returns the fraction of clicks that
+// should currently be delivered
+func (s *clickSampler) rate(now time.Time) float64 {
+	if s.rateLimit <= 0 {
+		return s.baseRate
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.windowStart.IsZero() {
+		s.windowStart = now
+	}
+	if elapsed := now.Sub(s.windowStart); elapsed >= rateWindow {
+		s.observed = float64(s.count) / elapsed.Seconds()
+		s.count = 0
+		s.windowStart = now
+
+		adaptive := s.observed*s.baseRate > s.rateLimit
+		if adaptive != s.adaptive {
+			if adaptive {
+				log.Printf("Webhook click sampling tightened: %.0f clicks/s exceeds the %.0f/s delivery limit", s.observed, s.rateLimit)
+			} else {
+				log.Printf("Webhook click sampling restored to %v", s.baseRate)
+			}
+			s.adaptive = adaptive
+		}
+	}
+	s.count++
+
+	if s.adaptive {
+		return s.rateLimit / s.observed
+	}
+	return s.baseRate
+}
@@ -0,0 +1,34 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+)
+
+// Headers set on every delivery
+const (
+	HeaderEvent     = "X-Webhook-Event"     // Event type, e.g. url.created
+	HeaderID        = "X-Webhook-ID"        // Event ID; identical across retries
+	HeaderTimestamp = "X-Webhook-Timestamp" // Unix seconds the attempt was signed at
+	HeaderSignature = "X-Webhook-Signature" // sha256=<hex HMAC of "timestamp.body">
+)
+
+// signaturePrefix names the HMAC algorithm in the signature header
+const signaturePrefix = "sha256="
+
+// Sign computes the signature header value for a delivery. The timestamp is
+// part of the signed message so receivers can reject replayed deliveries.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks a signature header value in constant time
+func Verify(secret, signature string, timestamp int64, body []byte) bool {
+	return hmac.Equal([]byte(signature), []byte(Sign(secret, timestamp, body)))
+}
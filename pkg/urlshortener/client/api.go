@@ -0,0 +1,51 @@
+package client
+
+import (
+	"context"
+	"iter"
+	"time"
+)
+
+// API is the set of calls Client makes, so code using the client can be
+// tested against a mock (see the mocks package)
+type API interface {
+	// Links
+	CreateURL(ctx context.Context, originalURL string, opts CreateOptions) (*CreateURLResponse, error)
+	ValidateURL(ctx context.Context, originalURL string, opts CreateOptions) (*ValidateURLResponse, error)
+	GetURL(ctx context.Context, shortCode string) (*URLEntry, error)
+	DeleteURL(ctx context.Context, shortCode string) error
+	BulkDeleteURLs(ctx context.Context, shortCodes []string) (*BulkDeleteResponse, error)
+	PruneURLs(ctx context.Context, prune PruneRequest) (*PruneResponse, error)
+	CreateShareToken(ctx context.Context, shortCode string, ttl time.Duration) (*ShareTokenResponse, error)
+
+	// Listing
+	ListURLs(ctx context.Context) ([]*URLEntry, error)
+	ListCampaignURLs(ctx context.Context, campaign string) ([]*URLEntry, error)
+	ListOwnerURLs(ctx context.Context, owner string) ([]*URLEntry, error)
+	ListURLsPage(ctx context.Context, opts ListOptions, offset int) (*URLPage, error)
+	AllURLs(ctx context.Context, opts ListOptions) iter.Seq2[*URLEntry, error]
+	ListCampaigns(ctx context.Context) ([]Campaign, error)
+
+	// Analytics
+	GetReferrers(ctx context.Context, shortCode string, limit int) (*ReferrerReport, error)
+	GetTopReferrers(ctx context.Context, limit int) (*TopReferrersResponse, error)
+	GetCountries(ctx context.Context, shortCode string, limit int) (*CountryReport, error)
+	GetTopCountries(ctx context.Context, limit int) (*TopCountriesResponse, error)
+	GetTopLinks(ctx context.Context, window time.Duration, limit int, trending bool) (*TopLinksResponse, error)
+	GetTimeSeries(ctx context.Context, shortCode string, interval TimeSeriesInterval, from, to time.Time) (*TimeSeries, error)
+
+	// Cache
+	GetCacheStats(ctx context.Context) (*CacheStats, error)
+	GetCacheEntry(ctx context.Context, shortCode string) (*CacheEntry, error)
+	FlushCache(ctx context.Context) (*CacheFlushResponse, error)
+	InvalidateCache(ctx context.Context, shortCode string) error
+
+	// Server
+	GetVersion(ctx context.Context) (*VersionResponse, error)
+	SyncNow(ctx context.Context) (*SyncSummary, error)
+	Drain(ctx context.Context) (*SyncSummary, error)
+	EndDrain(ctx context.Context) error
+	ServerURL() string
+}
+
+var _ API = (*Client)(nil)
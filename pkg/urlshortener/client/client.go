@@ -0,0 +1,746 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	mathrand "math/rand/v2"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	"github.com/joshdurbin/url-shortener/internal/version"
+)
+
+// DefaultTimeout bounds each request attempt unless WithTimeout or
+// WithHTTPClient says otherwise
+const DefaultTimeout = 30 * time.Second
+
+// Client is an HTTP client for the URL shortener API. It is safe for
+// concurrent use.
+type Client struct {
+	serverURL      string
+	apiKey         string
+	httpClient     *http.Client
+	retries        int
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+	random         func() float64 // Source for backoff jitter
+	headers        http.Header    // Sent with every request (WithHeader)
+	proxy          func(*http.Request) (*url.URL, error)
+	tlsConfig      *tls.Config
+}
+
+// Option configures optional client behavior
+type Option func(*Client)
+
+// WithAPIKey sends the given API key (or share token) with every request
+func WithAPIKey(apiKey string) Option {
+	return func(c *Client) {
+		c.apiKey = apiKey
+	}
+}
+
+// WithHTTPClient sends requests with the given HTTP client, e.g. one with a
+// custom transport or proxy. Its Timeout applies unless WithTimeout follows.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithTimeout bounds each request attempt, including reading the response
+// body; zero means no limit beyond the request's context. The default is
+// DefaultTimeout.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		// Copied so a client passed to WithHTTPClient is left unchanged
+		httpClient := *c.httpClient
+		httpClient.Timeout = timeout
+		c.httpClient = &httpClient
+	}
+}
+
+// NewClient creates a new URL shortener client
+func NewClient(serverURL string, opts ...Option) *Client {
+	c := &Client{
+		serverURL: serverURL,
+		httpClient: &http.Client{
+			Timeout: DefaultTimeout,
+		},
+		retries:        DefaultRetries,
+		initialBackoff: DefaultInitialBackoff,
+		maxBackoff:     DefaultMaxBackoff,
+		random:         mathrand.Float64,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	c.configureTransport()
+	return c
+}
+
+// ServerURL returns the base URL the client sends requests to
+func (c *Client) ServerURL() string {
+	return c.serverURL
+}
+
+// newRequest creates an API request with authentication headers applied
+func (c *Client) newRequest(ctx context.Context, method, path string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.serverURL+path, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", version.UserAgent())
+	for name, values := range c.headers {
+		req.Header[name] = values
+	}
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+	return req, nil
+}
+
+// do sends a request, wrapping transport failures in a ConnectionError.
+// Idempotent requests are retried with backoff after connection failures and
+// retryable statuses, waiting as long as Retry-After asks on 429 and 503.
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	retries := 0
+	if idempotent(req.Method) {
+		retries = c.retries
+	}
+
+	for attempt := 1; ; attempt++ {
+		resp, err := c.httpClient.Do(req)
+		if err == nil && !retryableStatus(resp.StatusCode) {
+			return resp, nil
+		}
+		if attempt > retries || req.Context().Err() != nil {
+			if err != nil {
+				return nil, &ConnectionError{ServerURL: c.serverURL, Err: err}
+			}
+			return resp, nil
+		}
+
+		wait := c.backoff(attempt)
+		if err == nil {
+			if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable {
+				if after, ok := retryAfter(resp, time.Now()); ok {
+					wait = min(after, c.maxBackoff)
+				}
+			}
+			discard(resp)
+		}
+		if err := sleep(req.Context(), wait); err != nil {
+			return nil, &ConnectionError{ServerURL: c.serverURL, Err: err}
+		}
+	}
+}
+
+// CreateURL creates a short URL
+func (c *Client) CreateURL(ctx context.Context, originalURL string, opts CreateOptions) (*CreateURLResponse, error) {
+	var result CreateURLResponse
+	if err := c.postCreateRequest(ctx, "/api/urls", originalURL, opts, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// ValidateURL checks whether the server would create a short URL, without creating it
+func (c *Client) ValidateURL(ctx context.Context, originalURL string, opts CreateOptions) (*ValidateURLResponse, error) {
+	var result ValidateURLResponse
+	if err := c.postCreateRequest(ctx, "/api/urls/validate", originalURL, opts, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// postCreateRequest posts a create request body to path and decodes the response into result
+func (c *Client) postCreateRequest(ctx context.Context, path, originalURL string, opts CreateOptions, result any) error {
+	reqBody := domain.CreateURLRequest{
+		URL:              originalURL,
+		MaxUses:          opts.MaxUses,
+		Tags:             opts.Tags,
+		RedirectStatus:   opts.RedirectStatus,
+		ReuseExisting:    opts.ReuseExisting,
+		BackupURL:        opts.BackupURL,
+		QueryParams:      opts.QueryParams,
+		ForwardQuery:     opts.ForwardQuery,
+		Campaign:         opts.Campaign,
+		DedupeSeconds:    opts.DedupeSeconds,
+		Domain:           opts.Domain,
+		BurnAfterReading: opts.BurnAfterReading,
+	}
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := c.newRequest(ctx, http.MethodPost, path, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return responseError(resp, "")
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return nil
+}
+
+// GetURL retrieves information about a short URL
+func (c *Client) GetURL(ctx context.Context, shortCode string) (*URLEntry, error) {
+	req, err := c.newRequest(ctx, http.MethodGet, "/api/urls/"+shortCode, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseError(resp, shortCode)
+	}
+
+	var entry URLEntry
+	if err := json.NewDecoder(resp.Body).Decode(&entry); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &entry, nil
+}
+
+// DeleteURL deletes a short URL
+func (c *Client) DeleteURL(ctx context.Context, shortCode string) error {
+	req, err := c.newRequest(ctx, http.MethodDelete, "/api/urls/"+shortCode, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent {
+		return responseError(resp, shortCode)
+	}
+
+	return nil
+}
+
+// BulkDeleteURLs deletes up to MaxBulkDelete short codes in one request
+func (c *Client) BulkDeleteURLs(ctx context.Context, shortCodes []string) (*BulkDeleteResponse, error) {
+	var result BulkDeleteResponse
+	if err := c.postJSON(ctx, "/api/urls/delete", domain.BulkDeleteRequest{ShortCodes: shortCodes}, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// PruneURLs deletes the links matching the request's conditions, or only
+// lists them when it is a dry run
+func (c *Client) PruneURLs(ctx context.Context, prune PruneRequest) (*PruneResponse, error) {
+	var result PruneResponse
+	if err := c.postJSON(ctx, "/api/urls/prune", prune, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// postJSON posts body as JSON to path and decodes a 200 response into result
+func (c *Client) postJSON(ctx context.Context, path string, body, result any) error {
+	jsonData, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := c.newRequest(ctx, http.MethodPost, path, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return responseError(resp, "")
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// ListURLs retrieves all short URLs
+func (c *Client) ListURLs(ctx context.Context) ([]*URLEntry, error) {
+	return c.listURLs(ctx, "/api/urls")
+}
+
+// ListCampaignURLs retrieves the short URLs belonging to a campaign
+func (c *Client) ListCampaignURLs(ctx context.Context, campaign string) ([]*URLEntry, error) {
+	return c.listURLs(ctx, "/api/urls?campaign="+url.QueryEscape(campaign))
+}
+
+// ListOwnerURLs retrieves the short URLs created by an owner ("me" for the
+// links created with this client's API key)
+func (c *Client) ListOwnerURLs(ctx context.Context, owner string) ([]*URLEntry, error) {
+	return c.listURLs(ctx, "/api/urls?owner="+url.QueryEscape(owner))
+}
+
+// listURLs retrieves the short URLs listed at path
+func (c *Client) listURLs(ctx context.Context, path string) ([]*URLEntry, error) {
+	page, err := c.listURLPage(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	return page.Links, nil
+}
+
+// listURLPage retrieves the short URLs listed at path along with the size of
+// the whole listing, which a paged response reports in X-Total-Count
+func (c *Client) listURLPage(ctx context.Context, path string) (*URLPage, error) {
+	req, err := c.newRequest(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseError(resp, "")
+	}
+
+	page := &URLPage{}
+	if err := json.NewDecoder(resp.Body).Decode(&page.Links); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	page.Total = len(page.Links)
+	if total, err := strconv.Atoi(resp.Header.Get(totalCountHeader)); err == nil {
+		page.Total = total
+	}
+
+	return page, nil
+}
+
+// ListCampaigns retrieves every campaign with its link count and aggregate clicks
+func (c *Client) ListCampaigns(ctx context.Context) ([]Campaign, error) {
+	req, err := c.newRequest(ctx, http.MethodGet, "/api/campaigns", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseError(resp, "")
+	}
+
+	var campaigns []Campaign
+	if err := json.NewDecoder(resp.Body).Decode(&campaigns); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return campaigns, nil
+}
+
+// GetReferrers retrieves a short URL's clicks by referring domain and by UTM
+// parameters, up to limit rows each (0 = server default)
+func (c *Client) GetReferrers(ctx context.Context, shortCode string, limit int) (*ReferrerReport, error) {
+	req, err := c.newRequest(ctx, http.MethodGet, "/api/urls/"+shortCode+"/referrers"+limitQuery(limit), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseError(resp, shortCode)
+	}
+
+	var report ReferrerReport
+	if err := json.NewDecoder(resp.Body).Decode(&report); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &report, nil
+}
+
+// GetTopReferrers retrieves the referring domains with the most clicks across
+// all links, up to limit (0 = server default)
+func (c *Client) GetTopReferrers(ctx context.Context, limit int) (*TopReferrersResponse, error) {
+	req, err := c.newRequest(ctx, http.MethodGet, "/api/stats/top-referrers"+limitQuery(limit), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseError(resp, "")
+	}
+
+	var top TopReferrersResponse
+	if err := json.NewDecoder(resp.Body).Decode(&top); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &top, nil
+}
+
+// GetCountries retrieves a short URL's clicks by visitor country and region,
+// up to limit rows (0 = server default)
+func (c *Client) GetCountries(ctx context.Context, shortCode string, limit int) (*CountryReport, error) {
+	req, err := c.newRequest(ctx, http.MethodGet, "/api/urls/"+shortCode+"/countries"+limitQuery(limit), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseError(resp, shortCode)
+	}
+
+	var report CountryReport
+	if err := json.NewDecoder(resp.Body).Decode(&report); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &report, nil
+}
+
+// GetTopCountries retrieves the countries with the most clicks across all
+// links, up to limit (0 = server default)
+func (c *Client) GetTopCountries(ctx context.Context, limit int) (*TopCountriesResponse, error) {
+	req, err := c.newRequest(ctx, http.MethodGet, "/api/stats/top-countries"+limitQuery(limit), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseError(resp, "")
+	}
+
+	var top TopCountriesResponse
+	if err := json.NewDecoder(resp.Body).Decode(&top); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &top, nil
+}
+
+// GetTopLinks retrieves the links with the most clicks over the last window
+// (0 = server default), or with the largest gain over the window before when
+// trending, up to limit (0 = server default)
+func (c *Client) GetTopLinks(ctx context.Context, window time.Duration, limit int, trending bool) (*TopLinksResponse, error) {
+	query := url.Values{}
+	if window > 0 {
+		query.Set("window", domain.Age(window).String())
+	}
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+	if trending {
+		query.Set("sort", string(domain.TopLinksByTrending))
+	}
+	path := "/api/stats/top"
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	req, err := c.newRequest(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseError(resp, "")
+	}
+
+	var top TopLinksResponse
+	if err := json.NewDecoder(resp.Body).Decode(&top); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &top, nil
+}
+
+// GetTimeSeries retrieves a short URL's clicks per interval from from until to;
+// an empty interval and zero times use the server defaults
+func (c *Client) GetTimeSeries(ctx context.Context, shortCode string, interval TimeSeriesInterval, from, to time.Time) (*TimeSeries, error) {
+	query := url.Values{}
+	if interval != "" {
+		query.Set("interval", string(interval))
+	}
+	if !from.IsZero() {
+		query.Set("from", from.Format(time.RFC3339))
+	}
+	if !to.IsZero() {
+		query.Set("to", to.Format(time.RFC3339))
+	}
+	path := "/api/urls/" + shortCode + "/timeseries"
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	req, err := c.newRequest(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseError(resp, shortCode)
+	}
+
+	var series TimeSeries
+	if err := json.NewDecoder(resp.Body).Decode(&series); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &series, nil
+}
+
+// limitQuery returns the ?limit= query for a report, or none for 0
+func limitQuery(limit int) string {
+	if limit <= 0 {
+		return ""
+	}
+	return "?limit=" + strconv.Itoa(limit)
+}
+
+// CreateShareToken issues a read-only share token for a short URL
+func (c *Client) CreateShareToken(ctx context.Context, shortCode string, ttl time.Duration) (*ShareTokenResponse, error) {
+	reqBody := domain.ShareTokenRequest{}
+	if ttl > 0 {
+		reqBody.TTL = ttl.String()
+	}
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := c.newRequest(ctx, http.MethodPost, "/api/urls/"+shortCode+"/share-token", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		return nil, responseError(resp, shortCode)
+	}
+
+	var result ShareTokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &result, nil
+}
+
+// GetVersion retrieves the server's build and configuration summary
+func (c *Client) GetVersion(ctx context.Context) (*VersionResponse, error) {
+	req, err := c.newRequest(ctx, http.MethodGet, "/api/version", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseError(resp, "")
+	}
+
+	var info VersionResponse
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &info, nil
+}
+
+// GetCacheStats retrieves the server's link cache size, unsynced entries and hit ratio
+func (c *Client) GetCacheStats(ctx context.Context) (*CacheStats, error) {
+	var stats CacheStats
+	if err := c.getJSON(ctx, "/api/admin/cache/stats", "", &stats); err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// GetCacheEntry retrieves a short code's entry in the server's link cache; a
+// code that is not cached returns a NotFoundError
+func (c *Client) GetCacheEntry(ctx context.Context, shortCode string) (*CacheEntry, error) {
+	var entry CacheEntry
+	if err := c.getJSON(ctx, "/api/admin/cache/"+shortCode, shortCode, &entry); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// FlushCache makes the server write its cached usage to the database now
+func (c *Client) FlushCache(ctx context.Context) (*CacheFlushResponse, error) {
+	var result CacheFlushResponse
+	if err := c.postJSON(ctx, "/api/admin/cache/flush", struct{}{}, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// InvalidateCache drops a short code's entry from the server's link cache, so
+// its next redirect reloads it from the database
+func (c *Client) InvalidateCache(ctx context.Context, shortCode string) error {
+	req, err := c.newRequest(ctx, http.MethodDelete, "/api/admin/cache/"+shortCode, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent {
+		return responseError(resp, shortCode)
+	}
+
+	return nil
+}
+
+// SyncNow makes the server count its buffered clicks and write its cached
+// usage to the database now
+func (c *Client) SyncNow(ctx context.Context) (*SyncSummary, error) {
+	var result SyncSummary
+	if err := c.postJSON(ctx, "/api/admin/sync", struct{}{}, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Drain makes the server refuse API writes and sync its cached usage, leaving
+// the database alone until EndDrain
+func (c *Client) Drain(ctx context.Context) (*SyncSummary, error) {
+	var result SyncSummary
+	if err := c.postJSON(ctx, "/api/admin/drain", struct{}{}, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// EndDrain makes a drained server accept writes and sync usage again
+func (c *Client) EndDrain(ctx context.Context) error {
+	req, err := c.newRequest(ctx, http.MethodDelete, "/api/admin/drain", nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent {
+		return responseError(resp, "")
+	}
+
+	return nil
+}
+
+// getJSON gets path and decodes a 200 response into result; shortCode names
+// the link a 404 is about, if any
+func (c *Client) getJSON(ctx context.Context, path, shortCode string, result any) error {
+	req, err := c.newRequest(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return responseError(resp, shortCode)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
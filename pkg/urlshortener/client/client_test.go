@@ -13,6 +13,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/joshdurbin/url-shortener/internal/domain"
+	"github.com/joshdurbin/url-shortener/internal/version"
 )
 
 func TestNewClient(t *testing.T) {
@@ -25,6 +26,104 @@ func TestNewClient(t *testing.T) {
 	assert.Equal(t, 30*time.Second, client.httpClient.Timeout)
 }
 
+func TestClient_HTTPClientOptions(t *testing.T) {
+	shared := &http.Client{Timeout: time.Minute}
+
+	c := NewClient("http://localhost:8080", WithHTTPClient(shared))
+	assert.Same(t, shared, c.httpClient)
+	assert.Equal(t, "http://localhost:8080", c.ServerURL())
+
+	c = NewClient("http://localhost:8080", WithHTTPClient(shared), WithTimeout(5*time.Second))
+	assert.Equal(t, 5*time.Second, c.httpClient.Timeout)
+	assert.Equal(t, time.Minute, shared.Timeout)
+}
+
+func TestClient_WithAPIKey(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer secret-key", r.Header.Get("Authorization"))
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode([]*domain.URLEntry{})
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, WithAPIKey("secret-key"))
+	_, err := client.ListURLs(context.Background())
+	require.NoError(t, err)
+}
+
+func TestClient_GetVersion(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/version", r.URL.Path)
+		assert.Equal(t, version.UserAgent(), r.Header.Get("User-Agent"))
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(domain.VersionResponse{Version: "v1.2.0", Storage: "sqlite"})
+	}))
+	defer server.Close()
+
+	info, err := NewClient(server.URL).GetVersion(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "v1.2.0", info.Version)
+	assert.Equal(t, "sqlite", info.Storage)
+}
+
+func TestClient_CreateShareToken(t *testing.T) {
+	expiresAt := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/urls/missing/share-token" {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/api/urls/abc123/share-token", r.URL.Path)
+
+		var req domain.ShareTokenRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, "1h0m0s", req.TTL)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(domain.ShareTokenResponse{Token: "tok", ShortCode: "abc123", ExpiresAt: expiresAt})
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL)
+
+	result, err := client.CreateShareToken(context.Background(), "abc123", time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, "tok", result.Token)
+	assert.True(t, expiresAt.Equal(result.ExpiresAt))
+
+	_, err = client.CreateShareToken(context.Background(), "missing", time.Hour)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not found")
+}
+
+func TestClient_ValidateURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/api/urls/validate", r.URL.Path)
+
+		var req domain.CreateURLRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, "ftp://example.com", req.URL)
+		assert.Equal(t, 5, req.MaxUses)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(domain.ValidateURLResponse{
+			Problems: []domain.ValidationProblem{{Field: "url", Message: "invalid URL: scheme must be http or https"}},
+		})
+	}))
+	defer server.Close()
+
+	response, err := NewClient(server.URL).ValidateURL(context.Background(), "ftp://example.com", domain.CreateOptions{MaxUses: 5})
+	require.NoError(t, err)
+	assert.False(t, response.Valid)
+	require.Len(t, response.Problems, 1)
+	assert.Equal(t, "url", response.Problems[0].Field)
+}
+
 func TestClient_CreateURL(t *testing.T) {
 	t.Run("successful creation", func(t *testing.T) {
 		expectedResponse := domain.CreateURLResponse{
@@ -55,7 +154,7 @@ func TestClient_CreateURL(t *testing.T) {
 		client := NewClient(server.URL)
 		ctx := context.Background()
 
-		response, err := client.CreateURL(ctx, "https://example.com")
+		response, err := client.CreateURL(ctx, "https://example.com", domain.CreateOptions{})
 		require.NoError(t, err)
 		assert.Equal(t, expectedResponse.ShortCode, response.ShortCode)
 		assert.Equal(t, expectedResponse.ShortURL, response.ShortURL)
@@ -71,7 +170,7 @@ func TestClient_CreateURL(t *testing.T) {
 		client := NewClient(server.URL)
 		ctx := context.Background()
 
-		_, err := client.CreateURL(ctx, "invalid-url")
+		_, err := client.CreateURL(ctx, "invalid-url", domain.CreateOptions{})
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "server returned status 400")
 	})
@@ -87,7 +186,7 @@ func TestClient_CreateURL(t *testing.T) {
 		client := NewClient(server.URL)
 		ctx := context.Background()
 
-		_, err := client.CreateURL(ctx, "https://example.com")
+		_, err := client.CreateURL(ctx, "https://example.com", domain.CreateOptions{})
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "failed to decode response")
 	})
@@ -103,7 +202,7 @@ func TestClient_CreateURL(t *testing.T) {
 		ctx, cancel := context.WithCancel(context.Background())
 		cancel() // Cancel immediately
 
-		_, err := client.CreateURL(ctx, "https://example.com")
+		_, err := client.CreateURL(ctx, "https://example.com", domain.CreateOptions{})
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "context canceled")
 	})
@@ -215,6 +314,50 @@ func TestClient_DeleteURL(t *testing.T) {
 	})
 }
 
+func TestClient_Campaigns(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodGet, r.Method)
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/urls":
+			assert.Equal(t, "spring sale", r.URL.Query().Get("campaign"))
+			json.NewEncoder(w).Encode([]*domain.URLEntry{{ShortCode: "abc123", Campaign: "spring-sale"}})
+		case "/api/campaigns":
+			json.NewEncoder(w).Encode([]domain.Campaign{{Name: "spring-sale", Links: 1, TotalClicks: 4}})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL)
+	ctx := context.Background()
+
+	entries, err := client.ListCampaignURLs(ctx, "spring sale")
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "spring-sale", entries[0].Campaign)
+
+	campaigns, err := client.ListCampaigns(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []domain.Campaign{{Name: "spring-sale", Links: 1, TotalClicks: 4}}, campaigns)
+}
+
+func TestClient_ListOwnerURLs(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/urls", r.URL.Path)
+		assert.Equal(t, "me", r.URL.Query().Get("owner"))
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode([]*domain.URLEntry{{ShortCode: "abc123", Owner: "key:0123456789ab"}})
+	}))
+	defer server.Close()
+
+	entries, err := NewClient(server.URL).ListOwnerURLs(context.Background(), "me")
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "key:0123456789ab", entries[0].Owner)
+}
+
 func TestClient_ListURLs(t *testing.T) {
 	t.Run("successful listing", func(t *testing.T) {
 		now := time.Now()
@@ -291,7 +434,7 @@ func TestClient_NetworkErrors(t *testing.T) {
 	ctx := context.Background()
 
 	t.Run("create URL network error", func(t *testing.T) {
-		_, err := client.CreateURL(ctx, "https://example.com")
+		_, err := client.CreateURL(ctx, "https://example.com", domain.CreateOptions{})
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "failed to make request")
 	})
@@ -321,7 +464,7 @@ func TestClient_InvalidRequests(t *testing.T) {
 	ctx := context.Background()
 
 	t.Run("invalid URL in CreateURL", func(t *testing.T) {
-		_, err := client.CreateURL(ctx, "https://example.com")
+		_, err := client.CreateURL(ctx, "https://example.com", domain.CreateOptions{})
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "failed to create request")
 	})
@@ -358,7 +501,7 @@ func TestClient_Timeout(t *testing.T) {
 	client.httpClient.Timeout = 10 * time.Millisecond
 
 	ctx := context.Background()
-	_, err := client.CreateURL(ctx, "https://example.com")
+	_, err := client.CreateURL(ctx, "https://example.com", domain.CreateOptions{})
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "context deadline exceeded")
 }
@@ -388,7 +531,7 @@ func TestClient_MalformedJSON(t *testing.T) {
 			client := NewClient(server.URL)
 			ctx := context.Background()
 
-			_, err := client.CreateURL(ctx, "https://example.com")
+			_, err := client.CreateURL(ctx, "https://example.com", domain.CreateOptions{})
 			if tc.name == "null response" {
 				// null JSON is valid JSON and decodes to zero values, should not error
 				assert.NoError(t, err)
@@ -406,7 +549,7 @@ func TestClient_LargeResponse(t *testing.T) {
 	for i := 0; i < 1000; i++ {
 		largeEntries[i] = &domain.URLEntry{
 			ID:          i,
-			ShortCode:   strings.Repeat("a", 100), // Long short code
+			ShortCode:   strings.Repeat("a", 100),                   // Long short code
 			OriginalURL: strings.Repeat("https://example.com/", 50), // Long URL
 			CreatedAt:   time.Now(),
 			UsageCount:  i,
@@ -426,4 +569,116 @@ func TestClient_LargeResponse(t *testing.T) {
 	entries, err := client.ListURLs(ctx)
 	require.NoError(t, err)
 	assert.Len(t, entries, 1000)
-}
\ No newline at end of file
+}
+func TestClient_Retries(t *testing.T) {
+	tests := []struct {
+		name         string
+		method       string
+		statuses     []int
+		retries      int
+		wantAttempts int
+		wantStatus   int
+	}{
+		{name: "get retries unavailable", method: http.MethodGet, statuses: []int{503, 503, 200}, retries: 2, wantAttempts: 3, wantStatus: 200},
+		{name: "delete retries gateway errors", method: http.MethodDelete, statuses: []int{502, 504, 204}, retries: 2, wantAttempts: 3, wantStatus: 204},
+		{name: "gives up after retries", method: http.MethodGet, statuses: []int{503, 503, 503, 200}, retries: 2, wantAttempts: 3, wantStatus: 503},
+		{name: "post is not retried", method: http.MethodPost, statuses: []int{503, 200}, retries: 2, wantAttempts: 1, wantStatus: 503},
+		{name: "client errors are not retried", method: http.MethodGet, statuses: []int{404, 200}, retries: 2, wantAttempts: 1, wantStatus: 404},
+		{name: "retries disabled", method: http.MethodGet, statuses: []int{503, 200}, retries: 0, wantAttempts: 1, wantStatus: 503},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.statuses[attempts])
+				attempts++
+			}))
+			defer server.Close()
+
+			client := NewClient(server.URL, WithRetries(tt.retries), WithBackoff(time.Millisecond, 10*time.Millisecond))
+			req, err := client.newRequest(context.Background(), tt.method, "/api/urls", nil)
+			require.NoError(t, err)
+
+			resp, err := client.do(req)
+			require.NoError(t, err)
+			resp.Body.Close()
+			assert.Equal(t, tt.wantStatus, resp.StatusCode)
+			assert.Equal(t, tt.wantAttempts, attempts)
+		})
+	}
+}
+
+func TestClient_RetryAfter(t *testing.T) {
+	var times []time.Time
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		times = append(times, time.Now())
+		if len(times) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode([]*domain.URLEntry{})
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, WithBackoff(time.Millisecond, 5*time.Second))
+	_, err := client.ListURLs(context.Background())
+	require.NoError(t, err)
+	require.Len(t, times, 2)
+	assert.GreaterOrEqual(t, times[1].Sub(times[0]), time.Second)
+}
+
+func TestClient_RetryConnectionError(t *testing.T) {
+	client := NewClient("http://nonexistent-server:9999", WithRetries(1), WithBackoff(time.Millisecond, time.Millisecond))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := client.GetURL(ctx, "abc123")
+
+	var connErr *ConnectionError
+	require.ErrorAs(t, err, &connErr)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		header string
+		want   time.Duration
+		wantOK bool
+	}{
+		{name: "seconds", header: "3", want: 3 * time.Second, wantOK: true},
+		{name: "http date", header: now.Add(90 * time.Second).Format(http.TimeFormat), want: 90 * time.Second, wantOK: true},
+		{name: "date in the past", header: now.Add(-time.Minute).Format(http.TimeFormat), want: 0, wantOK: true},
+		{name: "missing", header: ""},
+		{name: "invalid", header: "soon"},
+		{name: "negative", header: "-1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{Header: http.Header{}}
+			if tt.header != "" {
+				resp.Header.Set("Retry-After", tt.header)
+			}
+			got, ok := retryAfter(resp, now)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestClient_Backoff(t *testing.T) {
+	client := NewClient("http://localhost:8080", WithBackoff(100*time.Millisecond, time.Second))
+
+	client.random = func() float64 { return 0 }
+	assert.Equal(t, 50*time.Millisecond, client.backoff(1))
+	assert.Equal(t, 100*time.Millisecond, client.backoff(2))
+	assert.Equal(t, 500*time.Millisecond, client.backoff(10), "capped at max backoff")
+
+	client.random = func() float64 { return 0.999 }
+	assert.InDelta(t, float64(200*time.Millisecond), float64(client.backoff(2)), float64(time.Millisecond))
+}
@@ -0,0 +1,30 @@
+// Package client is a Go client for the URL shortener's HTTP API.
+//
+//	c := client.NewClient("https://sho.rt",
+//		client.WithAPIKey(os.Getenv("URL_SHORTENER_API_KEY")),
+//		client.WithTimeout(10*time.Second),
+//	)
+//
+//	created, err := c.CreateURL(ctx, "https://example.com/launch", client.CreateOptions{Campaign: "spring"})
+//	if err != nil {
+//		return err
+//	}
+//	fmt.Println(created.ShortURL)
+//
+// Every call takes a context, which cancels the request and any retries.
+// Idempotent requests (GET and DELETE) are retried after connection failures
+// and 429, 502, 503 and 504 responses; see WithRetries.
+//
+// Failures are typed. A request that never got a response returns a
+// ConnectionError; any other unexpected status returns an APIError carrying
+// the server's message, or one of the more specific errors wrapping it:
+//
+//	var notFound *client.NotFoundError
+//	if errors.As(err, &notFound) {
+//		// The short code does not exist
+//	}
+//
+// Listings can be fetched a page at a time with ListURLsPage, or iterated
+// across pages with AllURLs. Code that uses the client can accept the API
+// interface and be tested with the mock in the mocks package.
+package client
@@ -0,0 +1,108 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+)
+
+// maxErrorBody caps how much of an error response is read into an APIError
+const maxErrorBody = 1024
+
+// ConnectionError is returned when a request never got a response from the server
+type ConnectionError struct {
+	ServerURL string
+	Err       error
+}
+
+func (e *ConnectionError) Error() string {
+	return fmt.Sprintf("failed to make request: %v", e.Err)
+}
+
+func (e *ConnectionError) Unwrap() error {
+	return e.Err
+}
+
+// APIError is returned when the server responds with an unexpected status.
+// Code and Details come from the server's JSON error body, when it sent one.
+// A 404 or 409 response is returned as a NotFoundError or ConflictError,
+// which errors.As also matches as an APIError.
+type APIError struct {
+	StatusCode int
+	Code       string
+	Message    string
+	Details    map[string]any
+}
+
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("server returned status %d", e.StatusCode)
+	}
+	return fmt.Sprintf("server returned status %d: %s", e.StatusCode, e.Message)
+}
+
+// NotFoundError is returned when the server answers 404, most often because
+// a short code does not exist. ShortCode is set by the calls that take one.
+type NotFoundError struct {
+	*APIError
+	ShortCode string
+}
+
+func (e *NotFoundError) Error() string {
+	if e.ShortCode == "" {
+		return e.APIError.Error()
+	}
+	return fmt.Sprintf("short code '%s' not found", e.ShortCode)
+}
+
+func (e *NotFoundError) Unwrap() error {
+	return e.APIError
+}
+
+// ConflictError is returned when the server answers 409, e.g. for a custom
+// short code that is already taken
+type ConflictError struct {
+	*APIError
+}
+
+func (e *ConflictError) Unwrap() error {
+	return e.APIError
+}
+
+// responseError builds the error for an unexpected response, keeping the
+// server's error message. shortCode names the link the request was about, if
+// any. Bodies that are not a domain.ErrorResponse (e.g. from a proxy) are
+// kept as text.
+func responseError(resp *http.Response, shortCode string) error {
+	apiErr := newAPIError(resp)
+	switch resp.StatusCode {
+	case http.StatusNotFound:
+		return &NotFoundError{APIError: apiErr, ShortCode: shortCode}
+	case http.StatusConflict:
+		return &ConflictError{APIError: apiErr}
+	}
+	return apiErr
+}
+
+// newAPIError builds an APIError from a response's status and body
+func newAPIError(resp *http.Response) *APIError {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+
+	var envelope domain.ErrorResponse
+	if err := json.Unmarshal(body, &envelope); err == nil && envelope.Message != "" {
+		return &APIError{
+			StatusCode: resp.StatusCode,
+			Code:       envelope.Code,
+			Message:    envelope.Message,
+			Details:    envelope.Details,
+		}
+	}
+	return &APIError{
+		StatusCode: resp.StatusCode,
+		Message:    strings.TrimSpace(string(body)),
+	}
+}
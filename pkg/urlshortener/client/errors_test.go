@@ -0,0 +1,105 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+)
+
+func TestClient_TypedErrors(t *testing.T) {
+	t.Run("API error keeps the server message", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		}))
+		defer server.Close()
+
+		_, err := NewClient(server.URL).ListURLs(context.Background())
+		require.Error(t, err)
+
+		var apiErr *APIError
+		require.True(t, errors.As(err, &apiErr))
+		assert.Equal(t, http.StatusUnauthorized, apiErr.StatusCode)
+		assert.Equal(t, "Unauthorized", apiErr.Message)
+		assert.Equal(t, "server returned status 401: Unauthorized", err.Error())
+	})
+
+	t.Run("API error decodes the JSON error body", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(domain.ErrorResponse{
+				Code:    domain.ErrorCodeValidation,
+				Message: "max uses cannot be negative",
+				Details: map[string]any{"field": "max_uses"},
+			})
+		}))
+		defer server.Close()
+
+		_, err := NewClient(server.URL).CreateURL(context.Background(), "https://example.com", domain.CreateOptions{MaxUses: -1})
+
+		var apiErr *APIError
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, domain.ErrorCodeValidation, apiErr.Code)
+		assert.Equal(t, map[string]any{"field": "max_uses"}, apiErr.Details)
+		assert.Equal(t, "server returned status 400: max uses cannot be negative", err.Error())
+	})
+
+	t.Run("connection refused", func(t *testing.T) {
+		server := httptest.NewServer(http.NotFoundHandler())
+		serverURL := server.URL
+		server.Close()
+
+		_, err := NewClient(serverURL).ListURLs(context.Background())
+		require.Error(t, err)
+
+		var connErr *ConnectionError
+		require.True(t, errors.As(err, &connErr))
+		assert.Equal(t, serverURL, connErr.ServerURL)
+	})
+
+	t.Run("not found names the short code", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "URL not found", http.StatusNotFound)
+		}))
+		defer server.Close()
+
+		_, err := NewClient(server.URL).GetURL(context.Background(), "missing")
+
+		var notFound *NotFoundError
+		require.ErrorAs(t, err, &notFound)
+		assert.Equal(t, "missing", notFound.ShortCode)
+		assert.Equal(t, "short code 'missing' not found", err.Error())
+
+		var apiErr *APIError
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+		assert.Equal(t, "URL not found", apiErr.Message)
+	})
+
+	t.Run("conflict", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(domain.ErrorResponse{Code: domain.ErrorCodeConflict, Message: "short code is already taken"})
+		}))
+		defer server.Close()
+
+		_, err := NewClient(server.URL).CreateURL(context.Background(), "https://example.com", CreateOptions{})
+
+		var conflict *ConflictError
+		require.ErrorAs(t, err, &conflict)
+		assert.Equal(t, domain.ErrorCodeConflict, conflict.Code)
+		assert.Equal(t, "server returned status 409: short code is already taken", err.Error())
+
+		var notFound *NotFoundError
+		assert.False(t, errors.As(err, &notFound))
+	})
+}
@@ -0,0 +1,106 @@
+package client
+
+import (
+	"context"
+	"iter"
+	"net/url"
+	"strconv"
+
+	"github.com/joshdurbin/url-shortener/internal/domain"
+)
+
+// totalCountHeader carries the size of the whole listing on a paged response
+const totalCountHeader = "X-Total-Count"
+
+// DefaultPageSize is the number of links ListURLsPage and AllURLs fetch per
+// request when ListOptions.PageSize is zero
+const DefaultPageSize = 100
+
+// ListOptions selects the links ListURLsPage and AllURLs list. The filters
+// combine as they do on GET /api/urls, where campaign and owner are exclusive.
+type ListOptions struct {
+	Campaign string // Only links in this campaign
+	Owner    string // Only links created by this owner; "me" for this client's API key
+	Broken   bool   // Only links whose latest destination check failed
+	PageSize int    // Links per request, up to MaxPageSize; 0 uses DefaultPageSize
+}
+
+// MaxPageSize is the largest page the server returns
+const MaxPageSize = domain.MaxListLimit
+
+// URLPage is one page of a link listing
+type URLPage struct {
+	Links  []*URLEntry
+	Offset int // Position of the first link in the whole listing
+	Total  int // Size of the whole listing
+}
+
+// More reports whether links follow this page
+func (p *URLPage) More() bool {
+	return len(p.Links) > 0 && p.Offset+len(p.Links) < p.Total
+}
+
+// ListURLsPage retrieves the page of links starting offset links into the
+// listing selected by opts
+func (c *Client) ListURLsPage(ctx context.Context, opts ListOptions, offset int) (*URLPage, error) {
+	query := url.Values{}
+	switch {
+	case opts.Campaign != "":
+		query.Set("campaign", opts.Campaign)
+	case opts.Owner != "":
+		query.Set("owner", opts.Owner)
+	}
+	if opts.Broken {
+		query.Set("status", "broken")
+	}
+	pageSize := opts.PageSize
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+	query.Set("limit", strconv.Itoa(min(pageSize, MaxPageSize)))
+	query.Set("offset", strconv.Itoa(offset))
+
+	page, err := c.listURLPage(ctx, "/api/urls?"+query.Encode())
+	if err != nil {
+		return nil, err
+	}
+	page.Offset = offset
+	if page.Total < offset+len(page.Links) {
+		// A server without paging returned the whole listing
+		page.Total = offset + len(page.Links)
+	}
+	return page, nil
+}
+
+// AllURLs iterates over every link selected by opts, fetching a page at a
+// time. An error ends the iteration after being yielded with a nil entry.
+// Links created or deleted while iterating may shift the pages, so a link
+// can be skipped or seen twice.
+//
+//	for entry, err := range c.AllURLs(ctx, client.ListOptions{Campaign: "spring"}) {
+//		if err != nil {
+//			return err
+//		}
+//		fmt.Println(entry.ShortCode)
+//	}
+func (c *Client) AllURLs(ctx context.Context, opts ListOptions) iter.Seq2[*URLEntry, error] {
+	return func(yield func(*URLEntry, error) bool) {
+		offset := 0
+		for {
+			page, err := c.ListURLsPage(ctx, opts, offset)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			for _, entry := range page.Links {
+				if !yield(entry, nil) {
+					return
+				}
+			}
+			if !page.More() {
+				return
+			}
+			offset += len(page.Links)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pagedServer serves count links from GET /api/urls the way the server pages them
+func pagedServer(t *testing.T, count int, requests *[]string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requests = append(*requests, r.URL.RawQuery)
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+
+		entries := []*URLEntry{}
+		for i := offset; i < count && i < offset+limit; i++ {
+			entries = append(entries, &URLEntry{ID: i + 1, ShortCode: fmt.Sprintf("code%d", i)})
+		}
+		w.Header().Set("X-Total-Count", strconv.Itoa(count))
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(entries)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestClient_ListURLsPage(t *testing.T) {
+	var requests []string
+	server := pagedServer(t, 5, &requests)
+
+	page, err := NewClient(server.URL).ListURLsPage(context.Background(), ListOptions{Campaign: "spring", Broken: true, PageSize: 2}, 2)
+	require.NoError(t, err)
+	assert.Equal(t, "campaign=spring&limit=2&offset=2&status=broken", requests[0])
+	assert.Len(t, page.Links, 2)
+	assert.Equal(t, "code2", page.Links[0].ShortCode)
+	assert.Equal(t, 2, page.Offset)
+	assert.Equal(t, 5, page.Total)
+	assert.True(t, page.More())
+}
+
+func TestClient_AllURLs(t *testing.T) {
+	t.Run("iterates every page", func(t *testing.T) {
+		var requests []string
+		server := pagedServer(t, 5, &requests)
+
+		var codes []string
+		for entry, err := range NewClient(server.URL).AllURLs(context.Background(), ListOptions{PageSize: 2}) {
+			require.NoError(t, err)
+			codes = append(codes, entry.ShortCode)
+		}
+		assert.Equal(t, []string{"code0", "code1", "code2", "code3", "code4"}, codes)
+		assert.Len(t, requests, 3)
+	})
+
+	t.Run("stops when the caller breaks", func(t *testing.T) {
+		var requests []string
+		server := pagedServer(t, 5, &requests)
+
+		for entry, err := range NewClient(server.URL).AllURLs(context.Background(), ListOptions{PageSize: 2}) {
+			require.NoError(t, err)
+			if entry.ShortCode == "code1" {
+				break
+			}
+		}
+		assert.Len(t, requests, 1)
+	})
+
+	t.Run("server without paging", func(t *testing.T) {
+		var requests int
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			json.NewEncoder(w).Encode([]*URLEntry{{ShortCode: "a"}, {ShortCode: "b"}, {ShortCode: "c"}})
+		}))
+		defer server.Close()
+
+		count := 0
+		for _, err := range NewClient(server.URL).AllURLs(context.Background(), ListOptions{PageSize: 2}) {
+			require.NoError(t, err)
+			count++
+		}
+		assert.Equal(t, 3, count)
+		assert.Equal(t, 1, requests)
+	})
+
+	t.Run("yields the error", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+		}))
+		defer server.Close()
+
+		var errs []error
+		for entry, err := range NewClient(server.URL).AllURLs(context.Background(), ListOptions{}) {
+			assert.Nil(t, entry)
+			errs = append(errs, err)
+		}
+		require.Len(t, errs, 1)
+		var apiErr *APIError
+		require.ErrorAs(t, errs[0], &apiErr)
+		assert.Equal(t, http.StatusForbidden, apiErr.StatusCode)
+	})
+}
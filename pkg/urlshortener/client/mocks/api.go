@@ -0,0 +1,253 @@
+package mocks
+
+import (
+	"context"
+	"iter"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+
+	"github.com/joshdurbin/url-shortener/pkg/urlshortener/client"
+)
+
+// API is a mock implementation of client.API
+type API struct {
+	mock.Mock
+}
+
+// CreateURL creates a short URL
+func (m *API) CreateURL(ctx context.Context, originalURL string, opts client.CreateOptions) (*client.CreateURLResponse, error) {
+	args := m.Called(ctx, originalURL, opts)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*client.CreateURLResponse), args.Error(1)
+}
+
+// ValidateURL checks whether the server would create a short URL
+func (m *API) ValidateURL(ctx context.Context, originalURL string, opts client.CreateOptions) (*client.ValidateURLResponse, error) {
+	args := m.Called(ctx, originalURL, opts)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*client.ValidateURLResponse), args.Error(1)
+}
+
+// GetURL retrieves information about a short URL
+func (m *API) GetURL(ctx context.Context, shortCode string) (*client.URLEntry, error) {
+	args := m.Called(ctx, shortCode)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*client.URLEntry), args.Error(1)
+}
+
+// DeleteURL deletes a short URL
+func (m *API) DeleteURL(ctx context.Context, shortCode string) error {
+	args := m.Called(ctx, shortCode)
+	return args.Error(0)
+}
+
+// BulkDeleteURLs deletes several short URLs in one request
+func (m *API) BulkDeleteURLs(ctx context.Context, shortCodes []string) (*client.BulkDeleteResponse, error) {
+	args := m.Called(ctx, shortCodes)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*client.BulkDeleteResponse), args.Error(1)
+}
+
+// PruneURLs deletes or lists the links matching a prune request
+func (m *API) PruneURLs(ctx context.Context, prune client.PruneRequest) (*client.PruneResponse, error) {
+	args := m.Called(ctx, prune)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*client.PruneResponse), args.Error(1)
+}
+
+// CreateShareToken issues a read-only share token for a short URL
+func (m *API) CreateShareToken(ctx context.Context, shortCode string, ttl time.Duration) (*client.ShareTokenResponse, error) {
+	args := m.Called(ctx, shortCode, ttl)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*client.ShareTokenResponse), args.Error(1)
+}
+
+// ListURLs retrieves all short URLs
+func (m *API) ListURLs(ctx context.Context) ([]*client.URLEntry, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*client.URLEntry), args.Error(1)
+}
+
+// ListCampaignURLs retrieves a campaign's short URLs
+func (m *API) ListCampaignURLs(ctx context.Context, campaign string) ([]*client.URLEntry, error) {
+	args := m.Called(ctx, campaign)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*client.URLEntry), args.Error(1)
+}
+
+// ListOwnerURLs retrieves an owner's short URLs
+func (m *API) ListOwnerURLs(ctx context.Context, owner string) ([]*client.URLEntry, error) {
+	args := m.Called(ctx, owner)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*client.URLEntry), args.Error(1)
+}
+
+// ListURLsPage retrieves one page of a link listing
+func (m *API) ListURLsPage(ctx context.Context, opts client.ListOptions, offset int) (*client.URLPage, error) {
+	args := m.Called(ctx, opts, offset)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*client.URLPage), args.Error(1)
+}
+
+// AllURLs iterates over every link in a listing
+func (m *API) AllURLs(ctx context.Context, opts client.ListOptions) iter.Seq2[*client.URLEntry, error] {
+	args := m.Called(ctx, opts)
+	return args.Get(0).(iter.Seq2[*client.URLEntry, error])
+}
+
+// ListCampaigns retrieves every campaign
+func (m *API) ListCampaigns(ctx context.Context) ([]client.Campaign, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]client.Campaign), args.Error(1)
+}
+
+// GetReferrers retrieves a short URL's clicks by referrer
+func (m *API) GetReferrers(ctx context.Context, shortCode string, limit int) (*client.ReferrerReport, error) {
+	args := m.Called(ctx, shortCode, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*client.ReferrerReport), args.Error(1)
+}
+
+// GetTopReferrers retrieves the referring domains with the most clicks
+func (m *API) GetTopReferrers(ctx context.Context, limit int) (*client.TopReferrersResponse, error) {
+	args := m.Called(ctx, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*client.TopReferrersResponse), args.Error(1)
+}
+
+// GetCountries retrieves a short URL's clicks by country
+func (m *API) GetCountries(ctx context.Context, shortCode string, limit int) (*client.CountryReport, error) {
+	args := m.Called(ctx, shortCode, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*client.CountryReport), args.Error(1)
+}
+
+// GetTopCountries retrieves the countries with the most clicks
+func (m *API) GetTopCountries(ctx context.Context, limit int) (*client.TopCountriesResponse, error) {
+	args := m.Called(ctx, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*client.TopCountriesResponse), args.Error(1)
+}
+
+// GetTopLinks retrieves the links with the most clicks
+func (m *API) GetTopLinks(ctx context.Context, window time.Duration, limit int, trending bool) (*client.TopLinksResponse, error) {
+	args := m.Called(ctx, window, limit, trending)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*client.TopLinksResponse), args.Error(1)
+}
+
+// GetTimeSeries retrieves a short URL's clicks per interval
+func (m *API) GetTimeSeries(ctx context.Context, shortCode string, interval client.TimeSeriesInterval, from, to time.Time) (*client.TimeSeries, error) {
+	args := m.Called(ctx, shortCode, interval, from, to)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*client.TimeSeries), args.Error(1)
+}
+
+// GetCacheStats retrieves the server's link cache size, unsynced entries and hit ratio
+func (m *API) GetCacheStats(ctx context.Context) (*client.CacheStats, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*client.CacheStats), args.Error(1)
+}
+
+// GetCacheEntry retrieves a short code's entry in the server's link cache
+func (m *API) GetCacheEntry(ctx context.Context, shortCode string) (*client.CacheEntry, error) {
+	args := m.Called(ctx, shortCode)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*client.CacheEntry), args.Error(1)
+}
+
+// FlushCache makes the server write its cached usage to the database now
+func (m *API) FlushCache(ctx context.Context) (*client.CacheFlushResponse, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*client.CacheFlushResponse), args.Error(1)
+}
+
+// InvalidateCache drops a short code's entry from the server's link cache
+func (m *API) InvalidateCache(ctx context.Context, shortCode string) error {
+	args := m.Called(ctx, shortCode)
+	return args.Error(0)
+}
+
+// GetVersion retrieves the server's build and configuration summary
+func (m *API) GetVersion(ctx context.Context) (*client.VersionResponse, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*client.VersionResponse), args.Error(1)
+}
+
+// SyncNow makes the server write its cached usage to the database now
+func (m *API) SyncNow(ctx context.Context) (*client.SyncSummary, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*client.SyncSummary), args.Error(1)
+}
+
+// Drain makes the server refuse API writes and sync its cached usage
+func (m *API) Drain(ctx context.Context) (*client.SyncSummary, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*client.SyncSummary), args.Error(1)
+}
+
+// EndDrain makes a drained server accept writes again
+func (m *API) EndDrain(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
+
+// ServerURL returns the base URL the client sends requests to
+func (m *API) ServerURL() string {
+	args := m.Called()
+	return args.String(0)
+}
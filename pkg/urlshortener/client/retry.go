@@ -0,0 +1,93 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Retry defaults used by NewClient
+const (
+	DefaultRetries        = 2
+	DefaultInitialBackoff = 200 * time.Millisecond
+	DefaultMaxBackoff     = 10 * time.Second
+)
+
+// WithRetries retries idempotent requests (GET and DELETE) up to retries
+// times after a connection failure or a 429, 502, 503 or 504 response.
+// Zero disables retries.
+func WithRetries(retries int) Option {
+	return func(c *Client) {
+		c.retries = max(retries, 0)
+	}
+}
+
+// WithBackoff sets the wait before the first retry, which doubles for each
+// later retry up to maxBackoff. A Retry-After header is also capped at maxBackoff.
+func WithBackoff(initial, maxBackoff time.Duration) Option {
+	return func(c *Client) {
+		c.initialBackoff = initial
+		c.maxBackoff = maxBackoff
+	}
+}
+
+// idempotent reports whether a request can safely be sent again
+func idempotent(method string) bool {
+	return method == http.MethodGet || method == http.MethodDelete
+}
+
+// retryableStatus reports whether a response status is worth retrying
+func retryableStatus(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// backoff returns the wait before the retry that follows the given attempt,
+// with jitter spreading it over the upper half so clients do not retry in step
+func (c *Client) backoff(attempt int) time.Duration {
+	wait := c.initialBackoff
+	for i := 1; i < attempt && wait < c.maxBackoff; i++ {
+		wait *= 2
+	}
+	wait = min(wait, c.maxBackoff)
+	return wait/2 + time.Duration(c.random()*float64(wait/2))
+}
+
+// retryAfter parses a Retry-After header given in seconds or as an HTTP date
+func retryAfter(resp *http.Response, now time.Time) (time.Duration, bool) {
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(at.Sub(now), 0), true
+	}
+	return 0, false
+}
+
+// sleep waits for d or until ctx is done
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// discard drains and closes a response that is about to be retried so its
+// connection can be reused
+func discard(resp *http.Response) {
+	io.Copy(io.Discard, io.LimitReader(resp.Body, maxErrorBody))
+	resp.Body.Close()
+}
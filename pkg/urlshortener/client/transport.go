@@ -0,0 +1,110 @@
+package client
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+)
+
+// WithTransport sends requests through rt, e.g. one that adds tracing or
+// signs requests. WithProxy and WithTLSConfig only apply to an *http.Transport.
+func WithTransport(rt http.RoundTripper) Option {
+	return func(c *Client) {
+		// Copied so a client passed to WithHTTPClient is left unchanged
+		httpClient := *c.httpClient
+		httpClient.Transport = rt
+		c.httpClient = &httpClient
+	}
+}
+
+// WithHeader sends a header with every request, e.g. one a gateway in front
+// of the server requires. It can replace the User-Agent but not the
+// Authorization header set by WithAPIKey.
+func WithHeader(name, value string) Option {
+	return func(c *Client) {
+		if c.headers == nil {
+			c.headers = make(http.Header)
+		}
+		c.headers.Set(name, value)
+	}
+}
+
+// WithProxy sends requests through the HTTP or HTTPS proxy at proxyURL; nil
+// connects directly. By default the HTTP_PROXY, HTTPS_PROXY and NO_PROXY
+// environment variables choose the proxy.
+func WithProxy(proxyURL *url.URL) Option {
+	return func(c *Client) {
+		c.proxy = http.ProxyURL(proxyURL)
+	}
+}
+
+// WithTLSConfig connects to HTTPS servers with config, e.g. to trust a
+// private CA or present a client certificate for mutual TLS (see
+// LoadTLSConfig)
+func WithTLSConfig(config *tls.Config) Option {
+	return func(c *Client) {
+		c.tlsConfig = config
+	}
+}
+
+// LoadTLSConfig builds a TLS configuration from PEM files: caFile replaces
+// the system roots when set, and certFile and keyFile, set together, are the
+// client certificate for mutual TLS
+func LoadTLSConfig(caFile, certFile, keyFile string) (*tls.Config, error) {
+	config := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if caFile != "" {
+		data, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA certificate: %w", err)
+		}
+		config.RootCAs = x509.NewCertPool()
+		if !config.RootCAs.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("no PEM certificates found in %s", caFile)
+		}
+	}
+
+	if (certFile == "") != (keyFile == "") {
+		return nil, fmt.Errorf("client certificate and key must be set together")
+	}
+	if certFile != "" {
+		pair, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{pair}
+	}
+	return config, nil
+}
+
+// configureTransport applies the proxy and TLS options to a copy of the
+// client's transport, so the process-wide default transport and a transport
+// passed in by the caller are left unchanged
+func (c *Client) configureTransport() {
+	if c.proxy == nil && c.tlsConfig == nil {
+		return
+	}
+
+	var transport *http.Transport
+	switch rt := c.httpClient.Transport.(type) {
+	case nil:
+		transport = http.DefaultTransport.(*http.Transport).Clone()
+	case *http.Transport:
+		transport = rt.Clone()
+	default:
+		return
+	}
+	if c.proxy != nil {
+		transport.Proxy = c.proxy
+	}
+	if c.tlsConfig != nil {
+		transport.TLSClientConfig = c.tlsConfig
+	}
+
+	httpClient := *c.httpClient
+	httpClient.Transport = transport
+	c.httpClient = &httpClient
+}
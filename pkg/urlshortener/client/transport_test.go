@@ -0,0 +1,171 @@
+package client
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeTestCertificate creates a self-signed certificate for 127.0.0.1 that
+// serves as the CA, the server certificate and the client certificate
+func writeTestCertificate(t *testing.T) (string, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "localhost"},
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1)},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+	return certFile, keyFile
+}
+
+func TestClient_MutualTLS(t *testing.T) {
+	certFile, keyFile := writeTestCertificate(t)
+	pair, err := tls.LoadX509KeyPair(certFile, keyFile)
+	require.NoError(t, err)
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(pair.Leaf)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode([]*URLEntry{})
+	}))
+	server.TLS = &tls.Config{
+		Certificates: []tls.Certificate{pair},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    clientCAs,
+	}
+	server.Config.ErrorLog = log.New(io.Discard, "", 0) // Rejected handshakes are expected
+	server.StartTLS()
+	defer server.Close()
+
+	// The server's certificate is not trusted by default
+	_, err = NewClient(server.URL, WithRetries(0)).ListURLs(context.Background())
+	var connErr *ConnectionError
+	require.ErrorAs(t, err, &connErr)
+
+	// Trusted, but without a client certificate
+	config, err := LoadTLSConfig(certFile, "", "")
+	require.NoError(t, err)
+	_, err = NewClient(server.URL, WithRetries(0), WithTLSConfig(config)).ListURLs(context.Background())
+	require.Error(t, err)
+
+	config, err = LoadTLSConfig(certFile, certFile, keyFile)
+	require.NoError(t, err)
+	c := NewClient(server.URL, WithTLSConfig(config))
+	_, err = c.ListURLs(context.Background())
+	require.NoError(t, err)
+	assert.NotSame(t, http.DefaultTransport, c.httpClient.Transport, "the default transport must be left unchanged")
+}
+
+func TestLoadTLSConfig(t *testing.T) {
+	certFile, keyFile := writeTestCertificate(t)
+
+	_, err := LoadTLSConfig("", certFile, "")
+	assert.ErrorContains(t, err, "set together")
+	_, err = LoadTLSConfig(filepath.Join(t.TempDir(), "missing.pem"), "", "")
+	assert.ErrorContains(t, err, "failed to read CA certificate")
+	_, err = LoadTLSConfig(keyFile, "", "")
+	assert.ErrorContains(t, err, "no PEM certificates")
+
+	config, err := LoadTLSConfig("", "", "")
+	require.NoError(t, err)
+	assert.Nil(t, config.RootCAs)
+	assert.Empty(t, config.Certificates)
+}
+
+func TestClient_WithProxy(t *testing.T) {
+	var proxied string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// A proxy receives the absolute URL of the request
+		proxied = r.URL.String()
+		json.NewEncoder(w).Encode([]*URLEntry{})
+	}))
+	defer proxy.Close()
+	proxyURL, err := url.Parse(proxy.URL)
+	require.NoError(t, err)
+
+	_, err = NewClient("http://shortener.invalid", WithProxy(proxyURL)).ListURLs(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "http://shortener.invalid/api/urls", proxied)
+}
+
+func TestClient_WithHeader(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "gateway-secret", r.Header.Get("X-Gateway-Key"))
+		assert.Equal(t, "inventory-sync/1.0", r.Header.Get("User-Agent"))
+		assert.Equal(t, "Bearer secret-key", r.Header.Get("Authorization"))
+		json.NewEncoder(w).Encode([]*URLEntry{})
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL,
+		WithHeader("X-Gateway-Key", "gateway-secret"),
+		WithHeader("User-Agent", "inventory-sync/1.0"),
+		WithHeader("Authorization", "Basic ignored"),
+		WithAPIKey("secret-key"),
+	)
+	_, err := c.ListURLs(context.Background())
+	require.NoError(t, err)
+}
+
+func TestClient_WithTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "yes", r.Header.Get("X-Round-Tripped"))
+		json.NewEncoder(w).Encode([]*URLEntry{})
+	}))
+	defer server.Close()
+
+	transport := roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		r = r.Clone(r.Context())
+		r.Header.Set("X-Round-Tripped", "yes")
+		return http.DefaultTransport.RoundTrip(r)
+	})
+	_, err := NewClient(server.URL, WithTransport(transport)).ListURLs(context.Background())
+	require.NoError(t, err)
+}
+
+// roundTripFunc adapts a function to http.RoundTripper
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
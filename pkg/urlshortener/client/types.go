@@ -0,0 +1,39 @@
+package client
+
+import "github.com/joshdurbin/url-shortener/internal/domain"
+
+// The API's request and response types, shared with the server so the JSON
+// always matches
+type (
+	URLEntry             = domain.URLEntry
+	CreateOptions        = domain.CreateOptions
+	CreateURLResponse    = domain.CreateURLResponse
+	ValidateURLResponse  = domain.ValidateURLResponse
+	BulkDeleteResponse   = domain.BulkDeleteResponse
+	PruneRequest         = domain.PruneRequest
+	PruneResponse        = domain.PruneResponse
+	Campaign             = domain.Campaign
+	ReferrerReport       = domain.ReferrerReport
+	TopReferrersResponse = domain.TopReferrersResponse
+	CountryReport        = domain.CountryReport
+	TopCountriesResponse = domain.TopCountriesResponse
+	TopLinksResponse     = domain.TopLinksResponse
+	TimeSeries           = domain.TimeSeries
+	TimeSeriesInterval   = domain.TimeSeriesInterval
+	ShareTokenResponse   = domain.ShareTokenResponse
+	VersionResponse      = domain.VersionResponse
+	CacheStats           = domain.CacheStats
+	CacheEntry           = domain.CacheEntry
+	CacheFlushResponse   = domain.CacheFlushResponse
+	SyncSummary          = domain.SyncSummary
+)
+
+// Time series bucket widths for GetTimeSeries
+const (
+	IntervalMinute = domain.IntervalMinute
+	IntervalHour   = domain.IntervalHour
+	IntervalDay    = domain.IntervalDay
+)
+
+// MaxBulkDelete is the most short codes BulkDeleteURLs takes in one call
+const MaxBulkDelete = domain.MaxBulkDelete
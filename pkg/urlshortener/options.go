@@ -0,0 +1,107 @@
+package urlshortener
+
+import (
+	"time"
+
+	"github.com/joshdurbin/url-shortener/internal/shortener"
+)
+
+// Obfuscation strategies for WithObfuscation, which decide how counter values
+// are turned into short codes
+const (
+	ObfuscationMultiplicative = shortener.ObfuscationMultiplicative // Bit-mixing plus modulo (default)
+	ObfuscationFeistel        = shortener.ObfuscationFeistel        // Keyed Feistel permutation; collision-free
+	ObfuscationHashids        = shortener.ObfuscationHashids        // Hashids encoding; variable length
+	ObfuscationFF1            = shortener.ObfuscationFF1            // FF1 format-preserving encryption; needs a secret
+)
+
+// Database drivers for WithDriver
+const (
+	DriverMattn   = "mattn"   // github.com/mattn/go-sqlite3; requires cgo
+	DriverModernc = "modernc" // modernc.org/sqlite; pure Go
+)
+
+// options holds what New builds the shortener from
+type options struct {
+	databasePath string
+	driver       string
+	syncInterval time.Duration
+	codes        shortener.Config
+}
+
+// defaultOptions returns the options New starts from, matching the server's defaults
+func defaultOptions() options {
+	return options{
+		databasePath: "urls.db",
+		syncInterval: 5 * time.Second,
+		codes:        shortener.DefaultConfig(),
+	}
+}
+
+// Option configures a Shortener
+type Option func(*options)
+
+// WithDatabase stores links in the SQLite database at path (default: urls.db).
+// The database is created and migrated if needed, and may be shared with a
+// server using the same code settings.
+func WithDatabase(path string) Option {
+	return func(o *options) {
+		o.databasePath = path
+	}
+}
+
+// WithDriver selects the SQLite driver, DriverMattn or DriverModernc; by
+// default mattn is used when it is compiled in
+func WithDriver(driver string) Option {
+	return func(o *options) {
+		o.driver = driver
+	}
+}
+
+// WithSyncInterval sets how often usage counted in memory is written to the
+// database (default: 5s). Close writes what is left.
+func WithSyncInterval(interval time.Duration) Option {
+	return func(o *options) {
+		o.syncInterval = interval
+	}
+}
+
+// WithObfuscation sets how counter values become short codes. The secret keys
+// the Feistel and FF1 strategies and salts hashids.
+func WithObfuscation(strategy, secret string) Option {
+	return func(o *options) {
+		o.codes.Obfuscation = strategy
+		o.codes.Secret = secret
+	}
+}
+
+// WithAlphabet sets the characters of short codes: base62 (default), base58,
+// or the characters themselves
+func WithAlphabet(alphabet string) Option {
+	return func(o *options) {
+		o.codes.Alphabet = alphabet
+	}
+}
+
+// WithCodeLength sets how many characters short codes have (default: 7)
+func WithCodeLength(length int) Option {
+	return func(o *options) {
+		o.codes.Length = length
+	}
+}
+
+// WithAutoLength starts with codes of minLength characters and grows toward
+// the code length as each length fills up
+func WithAutoLength(minLength int) Option {
+	return func(o *options) {
+		o.codes.AutoLength = true
+		o.codes.MinLength = minLength
+	}
+}
+
+// WithBlockedWords replaces the words no issued short code may contain
+func WithBlockedWords(words ...string) Option {
+	return func(o *options) {
+		o.codes.BlockedWords = words
+	}
+}
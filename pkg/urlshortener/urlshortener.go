@@ -0,0 +1,181 @@
+// Package urlshortener embeds the URL shortener in other Go programs. Links
+// are created and resolved by the same service the server runs, backed by a
+// SQLite database and an in-memory cache, without an HTTP server:
+//
+//	s, err := urlshortener.New(ctx, urlshortener.WithDatabase("links.db"))
+//	if err != nil {
+//		return err
+//	}
+//	defer s.Close()
+//
+//	link, err := s.Create(ctx, "https://example.com/a/long/path", urlshortener.CreateOptions{})
+//	destination, err := s.Resolve(ctx, link.ShortCode)
+//
+// The types in this package are its stable API; they do not change when the
+// server's internals do.
+package urlshortener
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/joshdurbin/url-shortener/internal/cache/memory"
+	"github.com/joshdurbin/url-shortener/internal/domain"
+	"github.com/joshdurbin/url-shortener/internal/repository/sqlite"
+	"github.com/joshdurbin/url-shortener/internal/service"
+	"github.com/joshdurbin/url-shortener/internal/shortener"
+)
+
+// Errors returned by a Shortener; compare with errors.Is
+var (
+	ErrNotFound          = domain.ErrNotFound          // The short code does not exist
+	ErrInvalid           = domain.ErrValidation        // The URL or options were rejected; the error says why
+	ErrUsageLimitReached = domain.ErrUsageLimitReached // The link has been used MaxUses times
+	ErrBlocked           = domain.ErrLinkBlocked       // The short code is reserved or contains a blocked word
+	ErrStorage           = domain.ErrStorage           // The database failed
+)
+
+// Link is a short link and its usage
+type Link struct {
+	ShortCode   string
+	OriginalURL string
+	CreatedAt   time.Time
+	LastUsedAt  time.Time // Zero when never resolved
+	UsageCount  int       // Times Resolve returned the link
+	MaxUses     int       // 0 means unlimited
+	Tags        []string
+	Campaign    string
+}
+
+// CreateOptions are the optional settings of a new link
+type CreateOptions struct {
+	MaxUses       int      // Stop resolving the link after this many uses; 0 means unlimited
+	Tags          []string // Labels used to group links
+	Campaign      string   // Groups the link with others for reporting
+	ReuseExisting bool     // Return an existing uncapped link to the same URL instead of creating one
+}
+
+// Shortener creates and resolves short links. It is safe for concurrent use.
+type Shortener struct {
+	service service.URLShortener
+}
+
+// New opens the database, loads its links into memory and starts writing
+// usage back in the background. Call Close when done.
+func New(ctx context.Context, opts ...Option) (*Shortener, error) {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if err := o.codes.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid code settings: %w", err)
+	}
+	if o.syncInterval <= 0 {
+		return nil, fmt.Errorf("sync interval must be positive, got: %v", o.syncInterval)
+	}
+	driver, err := sqlite.ParseDriver(o.driver)
+	if err != nil {
+		return nil, err
+	}
+
+	repo, err := sqlite.New(o.databasePath, sqlite.WithDriver(driver))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	generator, err := shortener.NewGenerator(o.codes, repo.GetQueries())
+	if err != nil {
+		repo.Close()
+		return nil, fmt.Errorf("failed to create code generator: %w", err)
+	}
+
+	// Closing the service closes the generator, cache and database
+	urlShortener := service.NewURLShortener(repo, memory.New(), generator,
+		service.WithBlacklist(o.codes.Blacklist()),
+		service.WithAliases(repo))
+	if err := urlShortener.InitializeCache(ctx); err != nil {
+		urlShortener.Close()
+		return nil, err
+	}
+	if err := urlShortener.StartCacheSync(context.Background(), o.syncInterval); err != nil {
+		urlShortener.Close()
+		return nil, fmt.Errorf("failed to start usage sync: %w", err)
+	}
+
+	return &Shortener{service: urlShortener}, nil
+}
+
+// Create shortens originalURL, an absolute http or https URL
+func (s *Shortener) Create(ctx context.Context, originalURL string, opts CreateOptions) (*Link, error) {
+	entry, err := s.service.CreateShortURL(ctx, originalURL, domain.CreateOptions{
+		MaxUses:       opts.MaxUses,
+		Tags:          opts.Tags,
+		Campaign:      opts.Campaign,
+		ReuseExisting: opts.ReuseExisting,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return newLink(entry), nil
+}
+
+// Resolve returns a short code's destination and counts a use, as a redirect
+// does. A capped link returns ErrUsageLimitReached once it is used up.
+func (s *Shortener) Resolve(ctx context.Context, shortCode string) (string, error) {
+	destination, _, err := s.service.GetOriginalURL(ctx, shortCode, domain.RedirectRequest{})
+	return destination, err
+}
+
+// Get returns a link without counting a use
+func (s *Shortener) Get(ctx context.Context, shortCode string) (*Link, error) {
+	entry, err := s.service.GetURLInfo(ctx, shortCode)
+	if err != nil {
+		return nil, err
+	}
+	return newLink(entry), nil
+}
+
+// List returns every link
+func (s *Shortener) List(ctx context.Context) ([]*Link, error) {
+	entries, err := s.service.GetAllURLs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	links := make([]*Link, len(entries))
+	for i, entry := range entries {
+		links[i] = newLink(entry)
+	}
+	return links, nil
+}
+
+// Delete removes a link
+func (s *Shortener) Delete(ctx context.Context, shortCode string) error {
+	return s.service.DeleteShortURL(ctx, shortCode)
+}
+
+// Close writes the usage counted since the last sync to the database and
+// closes it
+func (s *Shortener) Close() error {
+	if err := s.service.StopCacheSync(); err != nil {
+		return fmt.Errorf("failed to sync usage: %w", err)
+	}
+	return s.service.Close()
+}
+
+// newLink converts a service entry to the public Link
+func newLink(entry *domain.URLEntry) *Link {
+	link := &Link{
+		ShortCode:   entry.ShortCode,
+		OriginalURL: entry.OriginalURL,
+		CreatedAt:   entry.CreatedAt,
+		UsageCount:  entry.UsageCount,
+		MaxUses:     entry.MaxUses,
+		Tags:        entry.Tags,
+		Campaign:    entry.Campaign,
+	}
+	if entry.LastUsedAt != nil {
+		link.LastUsedAt = *entry.LastUsedAt
+	}
+	return link
+}
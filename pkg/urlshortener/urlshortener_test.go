@@ -0,0 +1,98 @@
+package urlshortener
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShortener_Workflow(t *testing.T) {
+	ctx := context.Background()
+	dbPath := filepath.Join(t.TempDir(), "links.db")
+
+	s, err := New(ctx, WithDatabase(dbPath), WithCodeLength(8))
+	require.NoError(t, err)
+
+	link, err := s.Create(ctx, "https://example.com/a/long/path", CreateOptions{MaxUses: 2, Tags: []string{"docs"}})
+	require.NoError(t, err)
+	assert.Len(t, link.ShortCode, 8)
+	assert.Equal(t, "https://example.com/a/long/path", link.OriginalURL)
+	assert.Equal(t, 2, link.MaxUses)
+
+	for i := 0; i < 2; i++ {
+		destination, err := s.Resolve(ctx, link.ShortCode)
+		require.NoError(t, err)
+		assert.Equal(t, "https://example.com/a/long/path", destination)
+	}
+	_, err = s.Resolve(ctx, link.ShortCode)
+	assert.ErrorIs(t, err, ErrUsageLimitReached)
+
+	_, err = s.Resolve(ctx, "missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	_, err = s.Create(ctx, "not a url", CreateOptions{})
+	assert.ErrorIs(t, err, ErrInvalid)
+
+	links, err := s.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, links, 1)
+	assert.Equal(t, []string{"docs"}, links[0].Tags)
+
+	// Usage counted in memory is written to the database on Close
+	require.NoError(t, s.Close())
+
+	reopened, err := New(ctx, WithDatabase(dbPath), WithCodeLength(8))
+	require.NoError(t, err)
+	defer reopened.Close()
+
+	stored, err := reopened.Get(ctx, link.ShortCode)
+	require.NoError(t, err)
+	assert.Equal(t, 2, stored.UsageCount)
+	assert.False(t, stored.LastUsedAt.IsZero())
+
+	require.NoError(t, reopened.Delete(ctx, link.ShortCode))
+	_, err = reopened.Get(ctx, link.ShortCode)
+	assert.True(t, errors.Is(err, ErrNotFound))
+}
+
+func TestNew_InvalidOptions(t *testing.T) {
+	ctx := context.Background()
+	dbPath := filepath.Join(t.TempDir(), "links.db")
+
+	tests := []struct {
+		name    string
+		opts    []Option
+		wantErr string
+	}{
+		{name: "unknown obfuscation", opts: []Option{WithObfuscation("rot13", "")}, wantErr: "invalid code settings"},
+		{name: "unknown driver", opts: []Option{WithDriver("postgres")}, wantErr: "unsupported database driver"},
+		{name: "zero sync interval", opts: []Option{WithSyncInterval(0)}, wantErr: "sync interval must be positive"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(ctx, append([]Option{WithDatabase(dbPath)}, tt.opts...)...)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestNew_Obfuscation(t *testing.T) {
+	ctx := context.Background()
+	s, err := New(ctx, WithDatabase(filepath.Join(t.TempDir(), "links.db")),
+		WithObfuscation(ObfuscationFF1, "0123456789abcdef0123456789abcdef"),
+		WithAlphabet("base58"),
+		WithSyncInterval(time.Second))
+	require.NoError(t, err)
+	defer s.Close()
+
+	link, err := s.Create(ctx, "https://example.com", CreateOptions{})
+	require.NoError(t, err)
+	assert.Len(t, link.ShortCode, 7)
+	assert.NotContainsf(t, link.ShortCode, "0", "base58 codes have no zero")
+}
@@ -2,8 +2,15 @@ package integration
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -11,9 +18,11 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/joshdurbin/url-shortener/internal/cache/memory"
+	"github.com/joshdurbin/url-shortener/internal/domain"
 	"github.com/joshdurbin/url-shortener/internal/repository/sqlite"
 	"github.com/joshdurbin/url-shortener/internal/service"
 	"github.com/joshdurbin/url-shortener/internal/shortener"
+	"github.com/joshdurbin/url-shortener/internal/webhook"
 )
 
 func TestIntegration_FullWorkflow(t *testing.T) {
@@ -49,7 +58,7 @@ func TestIntegration_FullWorkflow(t *testing.T) {
 	// Test: Create a short URL directly via service
 	originalURL := "https://example.com/very/long/path/to/resource"
 	
-	result, err := urlShortener.CreateShortURL(ctx, originalURL)
+	result, err := urlShortener.CreateShortURL(ctx, originalURL, domain.CreateOptions{})
 	require.NoError(t, err)
 	assert.NotEmpty(t, result.ShortCode)
 	assert.Equal(t, originalURL, result.OriginalURL)
@@ -64,7 +73,7 @@ func TestIntegration_FullWorkflow(t *testing.T) {
 	assert.Equal(t, 0, urlInfo.UsageCount)
 
 	// Test: Get original URL (simulates redirect)
-	retrievedURL, err := urlShortener.GetOriginalURL(ctx, shortCode)
+	retrievedURL, _, err := urlShortener.GetOriginalURL(ctx, shortCode, domain.RedirectRequest{})
 	require.NoError(t, err)
 	assert.Equal(t, originalURL, retrievedURL)
 
@@ -83,7 +92,7 @@ func TestIntegration_FullWorkflow(t *testing.T) {
 
 	// Test: Create another URL
 	secondURL := "https://google.com"
-	result2, err := urlShortener.CreateShortURL(ctx, secondURL)
+	result2, err := urlShortener.CreateShortURL(ctx, secondURL, domain.CreateOptions{})
 	require.NoError(t, err)
 	assert.NotEqual(t, shortCode, result2.ShortCode)
 
@@ -111,7 +120,7 @@ func TestIntegration_FullWorkflow(t *testing.T) {
 	time.Sleep(200 * time.Millisecond) // Wait for sync
 
 	// Get URL info for the remaining URL to increment usage
-	_, err = urlShortener.GetOriginalURL(ctx, result2.ShortCode)
+	_, _, err = urlShortener.GetOriginalURL(ctx, result2.ShortCode, domain.RedirectRequest{})
 	require.NoError(t, err)
 
 	// Wait for sync
@@ -149,11 +158,11 @@ func TestIntegration_ErrorCases(t *testing.T) {
 	require.NoError(t, urlShortener.InitializeCache(ctx))
 
 	// Test: Invalid URL
-	_, err = urlShortener.CreateShortURL(ctx, "not-a-url")
+	_, err = urlShortener.CreateShortURL(ctx, "not-a-url", domain.CreateOptions{})
 	require.Error(t, err)
 
 	// Test: Get non-existent URL
-	_, err = urlShortener.GetOriginalURL(ctx, "nonexistent")
+	_, _, err = urlShortener.GetOriginalURL(ctx, "nonexistent", domain.RedirectRequest{})
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "not found")
 
@@ -192,7 +201,7 @@ func TestIntegration_ConcurrentAccess(t *testing.T) {
 
 	// Create a URL to test concurrent access
 	originalURL := "https://example.com/concurrent"
-	entry, err := urlShortener.CreateShortURL(ctx, originalURL)
+	entry, err := urlShortener.CreateShortURL(ctx, originalURL, domain.CreateOptions{})
 	require.NoError(t, err)
 
 	shortCode := entry.ShortCode
@@ -207,7 +216,7 @@ func TestIntegration_ConcurrentAccess(t *testing.T) {
 			
 			// Each goroutine accesses the URL 5 times
 			for j := 0; j < 5; j++ {
-				url, err := urlShortener.GetOriginalURL(ctx, shortCode)
+				url, _, err := urlShortener.GetOriginalURL(ctx, shortCode, domain.RedirectRequest{})
 				assert.NoError(t, err)
 				assert.Equal(t, originalURL, url)
 				time.Sleep(1 * time.Millisecond)
@@ -229,3 +238,181 @@ func TestIntegration_ConcurrentAccess(t *testing.T) {
 	assert.Equal(t, concurrency*5, info.UsageCount)
 }
 
+
+func TestIntegration_MaxUses(t *testing.T) {
+	dbPath := fmt.Sprintf("/tmp/test_max_uses_%d.db", time.Now().UnixNano())
+	defer os.Remove(dbPath)
+
+	repo, err := sqlite.New(dbPath)
+	require.NoError(t, err)
+	defer repo.Close()
+
+	generator, err := shortener.NewGenerator(shortener.DefaultConfig(), repo.GetQueries())
+	require.NoError(t, err)
+	defer generator.Close()
+
+	urlShortener := service.NewURLShortener(repo, memory.New(), generator)
+
+	ctx := context.Background()
+	require.NoError(t, urlShortener.InitializeCache(ctx))
+
+	entry, err := urlShortener.CreateShortURL(ctx, "https://example.com/invite", domain.CreateOptions{MaxUses: 3})
+	require.NoError(t, err)
+
+	// Concurrent redirects never exceed the cap
+	var allowed, exhausted int64
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, _, err := urlShortener.GetOriginalURL(ctx, entry.ShortCode, domain.RedirectRequest{}); err == nil {
+				atomic.AddInt64(&allowed, 1)
+			} else if errors.Is(err, domain.ErrUsageLimitReached) {
+				atomic.AddInt64(&exhausted, 1)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int64(3), allowed)
+	assert.Equal(t, int64(7), exhausted)
+
+	// A fresh instance without a warm cache falls back to the persisted usage count
+	require.NoError(t, repo.UpdateUsage(ctx, entry.ShortCode, 3, time.Now()))
+	coldShortener := service.NewURLShortener(repo, memory.New(), generator)
+
+	_, _, err = coldShortener.GetOriginalURL(ctx, entry.ShortCode, domain.RedirectRequest{})
+	require.Error(t, err)
+	assert.ErrorIs(t, err, domain.ErrUsageLimitReached)
+
+	info, err := coldShortener.GetURLInfo(ctx, entry.ShortCode)
+	require.NoError(t, err)
+	assert.Equal(t, 3, info.UsageCount)
+	assert.Equal(t, 3, info.MaxUses)
+}
+
+func TestIntegration_SharedDatabaseUsage(t *testing.T) {
+	dbPath := fmt.Sprintf("/tmp/test_shared_usage_%d.db", time.Now().UnixNano())
+	defer os.Remove(dbPath)
+
+	ctx := context.Background()
+
+	// Two instances with their own connections and caches share one database
+	instances := make([]service.URLShortener, 2)
+	repos := make([]*sqlite.Repository, 2)
+	for i := range instances {
+		repo, err := sqlite.New(dbPath)
+		require.NoError(t, err)
+		defer repo.Close()
+		repos[i] = repo
+
+		generator, err := shortener.NewGenerator(shortener.DefaultConfig(), repo.GetQueries())
+		require.NoError(t, err)
+		defer generator.Close()
+
+		instances[i] = service.NewURLShortener(repo, memory.New(), generator)
+	}
+
+	entry, err := instances[0].CreateShortURL(ctx, "https://example.com/shared", domain.CreateOptions{})
+	require.NoError(t, err)
+
+	for _, instance := range instances {
+		require.NoError(t, instance.InitializeCache(ctx))
+		require.NoError(t, instance.StartCacheSync(ctx, 20*time.Millisecond))
+		defer instance.StopCacheSync()
+	}
+
+	// Interleave redirects on both instances across several sync intervals
+	const redirectsPerInstance = 50
+	var wg sync.WaitGroup
+	for _, instance := range instances {
+		wg.Add(1)
+		go func(instance service.URLShortener) {
+			defer wg.Done()
+			for i := 0; i < redirectsPerInstance; i++ {
+				_, _, err := instance.GetOriginalURL(ctx, entry.ShortCode, domain.RedirectRequest{})
+				assert.NoError(t, err)
+				time.Sleep(time.Millisecond)
+			}
+		}(instance)
+	}
+	wg.Wait()
+
+	// Delta merging keeps every redirect; absolute writes would lose the other instance's count
+	require.Eventually(t, func() bool {
+		stored, err := repos[1].GetURL(ctx, entry.ShortCode)
+		return err == nil && stored.UsageCount == 2*redirectsPerInstance
+	}, 5*time.Second, 20*time.Millisecond)
+
+	// The next sync rebases an instance's cache on the merged count
+	_, _, err = instances[0].GetOriginalURL(ctx, entry.ShortCode, domain.RedirectRequest{})
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		info, err := instances[0].GetURLInfo(ctx, entry.ShortCode)
+		return err == nil && info.UsageCount == 2*redirectsPerInstance+1
+	}, 5*time.Second, 20*time.Millisecond)
+}
+
+func TestIntegration_Webhooks(t *testing.T) {
+	dbPath := fmt.Sprintf("/tmp/test_webhooks_%d.db", time.Now().UnixNano())
+	defer os.Remove(dbPath)
+
+	repo, err := sqlite.New(dbPath)
+	require.NoError(t, err)
+	defer repo.Close()
+
+	generator, err := shortener.NewGenerator(shortener.DefaultConfig(), repo.GetQueries())
+	require.NoError(t, err)
+	defer generator.Close()
+
+	// Receiver verifies signatures and records event types in arrival order
+	received := make(chan domain.EventType, 10)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		timestamp, _ := strconv.ParseInt(r.Header.Get(webhook.HeaderTimestamp), 10, 64)
+		if !webhook.Verify("s3cret", r.Header.Get(webhook.HeaderSignature), timestamp, body) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		received <- domain.EventType(r.Header.Get(webhook.HeaderEvent))
+	}))
+	defer receiver.Close()
+
+	config := webhook.DefaultConfig()
+	config.Workers = 1
+	dispatcher := webhook.New(config, repo, nil)
+	ctx := context.Background()
+	require.NoError(t, dispatcher.Start(ctx))
+	defer dispatcher.Close()
+
+	_, err = dispatcher.CreateEndpoint(ctx, domain.CreateWebhookRequest{
+		URL:    receiver.URL,
+		Secret: "s3cret",
+		Events: []domain.EventType{domain.EventURLCreated, domain.EventURLExpired, domain.EventURLDeleted},
+	})
+	require.NoError(t, err)
+
+	urlShortener := service.NewURLShortener(repo, memory.New(), generator, service.WithNotifier(dispatcher))
+	require.NoError(t, urlShortener.InitializeCache(ctx))
+
+	entry, err := urlShortener.CreateShortURL(ctx, "https://example.com/once", domain.CreateOptions{MaxUses: 1})
+	require.NoError(t, err)
+	_, _, err = urlShortener.GetOriginalURL(ctx, entry.ShortCode, domain.RedirectRequest{})
+	require.NoError(t, err)
+	require.NoError(t, urlShortener.DeleteShortURL(ctx, entry.ShortCode))
+
+	for _, want := range []domain.EventType{domain.EventURLCreated, domain.EventURLExpired, domain.EventURLDeleted} {
+		select {
+		case got := <-received:
+			assert.Equal(t, want, got)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for %s", want)
+		}
+	}
+
+	// Every attempt is in the delivery log
+	assert.Eventually(t, func() bool {
+		deliveries, err := repo.ListWebhookDeliveries(ctx, 0, 10)
+		return err == nil && len(deliveries) == 3 && deliveries[0].Success
+	}, 5*time.Second, 10*time.Millisecond)
+}